@@ -12,14 +12,19 @@ import (
 
 	"scriberr/internal/api"
 	"scriberr/internal/auth"
+	"scriberr/internal/cleanup"
 	"scriberr/internal/config"
 	"scriberr/internal/database"
+	"scriberr/internal/gpu"
+	"scriberr/internal/ingest"
 	"scriberr/internal/queue"
+	"scriberr/internal/quota"
+	"scriberr/internal/storage"
 	"scriberr/internal/transcription"
+	"scriberr/internal/transcription/adapters"
 	"scriberr/pkg/logger"
 
-	_ "scriberr/api-docs"                        // Import generated Swagger docs
-	_ "scriberr/internal/transcription/adapters" // Import adapters for auto-registration
+	_ "scriberr/api-docs" // Import generated Swagger docs
 )
 
 // Version information (set by GoReleaser)
@@ -56,6 +61,7 @@ var (
 func main() {
 	// Handle version flag
 	var showVersion = flag.Bool("version", false, "Show version information")
+	var migrateStorage = flag.Bool("migrate-storage", false, "Move audio still in the upload directory into the configured storage backend, then exit")
 	flag.Parse()
 
 	if *showVersion {
@@ -78,6 +84,10 @@ func main() {
 	// Load configuration
 	logger.Startup("config", "Loading configuration")
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		logger.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
 
 	// Initialize database
 	logger.Startup("database", "Connecting to database")
@@ -87,12 +97,54 @@ func main() {
 	}
 	defer database.Close()
 
+	// Select where uploaded audio is kept
+	backend, err := storage.New(cfg.Storage, cfg.UploadDir)
+	if err != nil {
+		logger.Error("Failed to configure storage", "error", err)
+		os.Exit(1)
+	}
+	storage.Configure(backend)
+
+	if *migrateStorage {
+		result, err := storage.MigrateLocalFiles(context.Background())
+		if err != nil {
+			logger.Error("Storage migration failed", "error", err)
+			os.Exit(1)
+		}
+		logger.Info("Storage migration finished", "moved", result.Moved, "missing", result.Missing, "failed", result.Failed)
+		if result.Failed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := auth.EnsureAdminUser(cfg.AdminUsername, cfg.AdminPassword); err != nil {
+		logger.Error("Failed to ensure admin user", "error", err)
+		os.Exit(1)
+	}
+
+	if err := ingest.FailInterruptedDownloads(); err != nil {
+		logger.Warn("Failed to clean up interrupted downloads", "error", err)
+	}
+
 	// Initialize authentication service
 	logger.Startup("auth", "Setting up authentication")
-	authService := auth.NewAuthService(cfg.JWTSecret)
+	authService := auth.NewAuthService(cfg.JWTSecret, cfg.AccessTokenTTL)
+	cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+	defer stopCleanup()
+	authService.StartRevocationCleanup(cleanupCtx, time.Hour)
+	quota.StartResetLoop(cleanupCtx, time.Minute)
+	gpu.NewGPUMonitor(30 * time.Second).Start(cleanupCtx)
 
 	// Initialize unified transcription processor
 	logger.Startup("transcription", "Initializing transcription service")
+	adapters.ConfigureOpenAI(adapters.OpenAISettings{
+		BaseURL: cfg.OpenAIBaseURL,
+		APIKey:  cfg.OpenAIAPIKey,
+		Model:   cfg.OpenAITranscriptionModel,
+	})
+	adapters.ConfigureFasterWhisper(cfg.FasterWhisperPath)
+	gpu.ConfigureModelRequirements(cfg.ModelVRAMMB)
 	unifiedProcessor := transcription.NewUnifiedJobProcessor()
 
 	// Bootstrap embedded Python environment (for all adapters)
@@ -110,14 +162,37 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Jobs left processing by a server that stopped mid-run are queued again
+	if requeued, err := transcription.RecoverInterruptedJobs(); err != nil {
+		logger.Warn("Failed to recover interrupted jobs", "error", err)
+	} else if requeued > 0 {
+		logger.Info("Requeued interrupted jobs", "count", requeued)
+	}
+
 	// Initialize task queue
 	logger.Startup("queue", "Starting background processing")
 	taskQueue := queue.NewTaskQueue(2, unifiedProcessor) // 2 workers
+	taskQueue.DeleteAudioAfterTranscription(cfg.DeleteAudioAfterTranscription)
+	taskQueue.CacheResults(!cfg.DisableResultCache)
+	taskQueue.LimitJobsPerDevice(cfg.MaxConcurrentJobsPerDevice, cfg.Environment.AutoDevice())
+	taskQueue.SetJobTimeout(cfg.JobTimeout, cfg.JobTimeoutRetries)
 	taskQueue.Start()
 	defer taskQueue.Stop()
 
 	// Initialize API handlers
 	handler := api.NewHandler(cfg, authService, taskQueue, unifiedProcessor, quickTranscriptionService)
+	if err := handler.StartWatchFolders(cleanupCtx); err != nil {
+		logger.Warn("Failed to start watch folders", "error", err)
+	}
+	handler.StartUploadCleanup(cleanupCtx, time.Hour)
+	handler.StartStorageMonitor(cleanupCtx, 15*time.Minute)
+	handler.StartEnvironmentCheck(cleanupCtx)
+	cleanup.ConfigureOutputDir(cfg.OutputDir)
+	cleanup.Start(cleanupCtx, cleanup.Policy{
+		AudioRetentionDays:   cfg.AudioRetentionDays,
+		JobRetentionDays:     cfg.JobRetentionDays,
+		DeletedRetentionDays: cfg.DeletedJobRetentionDays,
+	}, cfg.CleanupInterval)
 
 	// Log final configuration snapshot for diagnostics
 	logger.Info("Configuration snapshot", "config", cfg.Snapshot())
@@ -130,6 +205,9 @@ func main() {
 		Addr:    cfg.Host + ":" + cfg.Port,
 		Handler: router,
 	}
+	// Shutdown leaves WebSocket connections to their handlers
+	srv.RegisterOnShutdown(handler.CloseEventSockets)
+	srv.RegisterOnShutdown(handler.CloseLiveStreams)
 
 	// Start server in a goroutine
 	go func() {
@@ -153,6 +231,12 @@ func main() {
 
 	logger.Info("Shutting down server")
 
+	// Start no more jobs, and let the running ones' processes exit cleanly
+	taskQueue.Hold()
+	if err := transcription.GracefulShutdown(context.Background(), cfg.ShutdownTimeout); err != nil {
+		logger.Warn("Transcription processes did not stop cleanly", "error", err)
+	}
+
 	// Create a deadline for shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -0,0 +1,361 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/auth"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+)
+
+// AdminUserResponse describes an account as seen by administrators
+type AdminUserResponse struct {
+	ID          uint      `json:"id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	Role        string    `json:"role"`
+	Disabled    bool      `json:"disabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// AdminCreateUserRequest creates an account
+type AdminCreateUserRequest struct {
+	Username    string `json:"username" binding:"required,min=3,max=50"`
+	Password    string `json:"password" binding:"required"`
+	DisplayName string `json:"display_name,omitempty"`
+	Role        string `json:"role,omitempty"`
+}
+
+// AdminUpdateUserRequest changes an account's role or disabled state. Omitted
+// fields are left untouched.
+type AdminUpdateUserRequest struct {
+	Role     *string `json:"role,omitempty"`
+	Disabled *bool   `json:"disabled,omitempty"`
+}
+
+// UserStorageUsage is the audio stored for one user's jobs. Jobs submitted
+// without a user, such as with API keys or from watch folders, are grouped
+// under a null user ID.
+type UserStorageUsage struct {
+	UserID   *uint  `json:"user_id"`
+	Username string `json:"username"`
+	JobCount int64  `json:"job_count"`
+	Bytes    int64  `json:"bytes"`
+}
+
+// StorageUsageResponse is the audio stored per user, largest first
+type StorageUsageResponse struct {
+	TotalBytes int64              `json:"total_bytes"`
+	Users      []UserStorageUsage `json:"users"`
+}
+
+func newAdminUserResponse(user *models.User) AdminUserResponse {
+	return AdminUserResponse{
+		ID:          user.ID,
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Role:        user.Role,
+		Disabled:    user.Disabled,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+	}
+}
+
+func isValidRole(role string) bool {
+	return role == models.RoleAdmin || role == models.RoleUser
+}
+
+// ListUsers returns a page of accounts
+// @Summary List users
+// @Description Get a paginated list of user accounts (admin only)
+// @Tags admin
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} apierror.Envelope
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/users [get]
+func (h *Handler) ListUsers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	var total int64
+	if err := database.DB.Model(&models.User{}).Count(&total).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to list users"))
+		return
+	}
+
+	var users []models.User
+	if err := database.DB.Order("id ASC").Offset((page - 1) * limit).Limit(limit).Find(&users).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to list users"))
+		return
+	}
+
+	response := make([]AdminUserResponse, len(users))
+	for i := range users {
+		response[i] = newAdminUserResponse(&users[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users": response,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// CreateUser creates an account with the given role
+// @Summary Create user
+// @Description Create a user account with a role (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body AdminCreateUserRequest true "New user"
+// @Success 201 {object} AdminUserResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/users [post]
+func (h *Handler) CreateUser(c *gin.Context) {
+	var req AdminCreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.RoleUser
+	}
+	if !isValidRole(req.Role) {
+		apierror.Abort(c, apierror.Validation("Role must be 'admin' or 'user'"))
+		return
+	}
+	if err := auth.ValidatePassword(req.Password, req.Username); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	displayName := strings.TrimSpace(req.DisplayName)
+	if len(displayName) > maxDisplayNameLength {
+		apierror.Abort(c, apierror.Validation("Display name is too long"))
+		return
+	}
+
+	// Soft-deleted accounts keep their username reserved
+	var existing int64
+	if err := database.DB.Unscoped().Model(&models.User{}).Where("username = ?", req.Username).Count(&existing).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to create user"))
+		return
+	}
+	if existing > 0 {
+		apierror.Abort(c, apierror.Conflict("Username already exists"))
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(req.Password)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to secure password"))
+		return
+	}
+	user := models.User{
+		Username:    req.Username,
+		Password:    hashedPassword,
+		DisplayName: displayName,
+		Role:        req.Role,
+	}
+	if err := database.DB.Create(&user).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to create user"))
+		return
+	}
+
+	logger.Info("User created by admin", "username", user.Username, "role", user.Role, "admin", c.GetString("username"))
+	c.JSON(http.StatusCreated, newAdminUserResponse(&user))
+}
+
+// UpdateUser changes an account's role or disables it
+// @Summary Update user
+// @Description Change a user's role or disable/enable the account (admin only). Disabling an account revokes its sessions.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body AdminUpdateUserRequest true "Changes"
+// @Success 200 {object} AdminUserResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id} [patch]
+func (h *Handler) UpdateUser(c *gin.Context) {
+	user, ok := h.loadManagedUser(c)
+	if !ok {
+		return
+	}
+
+	var req AdminUpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
+		return
+	}
+	if req.Role == nil && req.Disabled == nil {
+		apierror.Abort(c, apierror.BadRequest("Nothing to update"))
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Role != nil {
+		if !isValidRole(*req.Role) {
+			apierror.Abort(c, apierror.Validation("Role must be 'admin' or 'user'"))
+			return
+		}
+		if h.isCurrentUser(c, user.ID) && *req.Role != models.RoleAdmin {
+			apierror.Abort(c, apierror.BadRequest("You cannot remove your own admin role"))
+			return
+		}
+		updates["role"] = *req.Role
+	}
+	if req.Disabled != nil {
+		if h.isCurrentUser(c, user.ID) && *req.Disabled {
+			apierror.Abort(c, apierror.BadRequest("You cannot disable your own account"))
+			return
+		}
+		updates["disabled"] = *req.Disabled
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(updates).Error; err != nil {
+			return err
+		}
+		if user.Disabled {
+			return revokeUserRefreshTokens(tx, user.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to update user"))
+		return
+	}
+
+	logger.Info("User updated by admin", "username", user.Username, "role", user.Role, "disabled", user.Disabled, "admin", c.GetString("username"))
+	c.JSON(http.StatusOK, newAdminUserResponse(user))
+}
+
+// DeleteUser soft-deletes an account
+// @Summary Delete user
+// @Description Soft-delete a user account (admin only). The account can no longer authenticate and its sessions are revoked.
+// @Tags admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id} [delete]
+func (h *Handler) DeleteUser(c *gin.Context) {
+	user, ok := h.loadManagedUser(c)
+	if !ok {
+		return
+	}
+	if h.isCurrentUser(c, user.ID) {
+		apierror.Abort(c, apierror.BadRequest("You cannot delete your own account"))
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(user).Error; err != nil {
+			return err
+		}
+		return revokeUserRefreshTokens(tx, user.ID)
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to delete user"))
+		return
+	}
+
+	logger.Info("User deleted by admin", "username", user.Username, "admin", c.GetString("username"))
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
+}
+
+// GetStorageByUser reports the audio stored for each user's jobs
+// @Summary Get storage used per user
+// @Description Sums the file sizes of each user's jobs, largest first. Deleted jobs count until cleanup purges them; audio removed by retention cleanup and jobs uploaded before file sizes were recorded count as zero bytes.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} StorageUsageResponse
+// @Failure 403 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/storage [get]
+func (h *Handler) GetStorageByUser(c *gin.Context) {
+	response := StorageUsageResponse{Users: []UserStorageUsage{}}
+	err := database.DB.Unscoped().Table("transcription_jobs").
+		Select("transcription_jobs.user_id, COALESCE(users.username, '') AS username, COUNT(*) AS job_count, " +
+			"COALESCE(SUM(CASE WHEN transcription_jobs.audio_deleted_at IS NULL THEN transcription_jobs.file_size END), 0) AS bytes").
+		Joins("LEFT JOIN users ON users.id = transcription_jobs.user_id").
+		Group("transcription_jobs.user_id, users.username").
+		Order("bytes DESC, transcription_jobs.user_id").
+		Scan(&response.Users).Error
+	if err != nil {
+		logger.Error("Failed to sum storage per user", "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to get storage usage"))
+		return
+	}
+	for _, usage := range response.Users {
+		response.TotalBytes += usage.Bytes
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// loadManagedUser fetches the user named by the :id path parameter, writing an
+// error response and returning false if it does not exist
+func (h *Handler) loadManagedUser(c *gin.Context) (*models.User, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid user ID"))
+		return nil, false
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.NotFound("User not found"))
+			return nil, false
+		}
+		apierror.Abort(c, apierror.Internal("Failed to get user"))
+		return nil, false
+	}
+	return &user, true
+}
+
+// isCurrentUser reports whether userID is the authenticated caller
+func (h *Handler) isCurrentUser(c *gin.Context, userID uint) bool {
+	id, ok := currentUserID(c)
+	return ok && id == userID
+}
+
+// revokeUserRefreshTokens invalidates every refresh token of a user
+func revokeUserRefreshTokens(tx *gorm.DB, userID uint) error {
+	return tx.Model(&models.RefreshToken{}).Where("user_id = ? AND revoked = ?", userID, false).Update("revoked", true).Error
+}
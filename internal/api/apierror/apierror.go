@@ -0,0 +1,182 @@
+// Package apierror defines the envelope every API error response uses, the
+// typed errors handlers return, and the middleware that writes them.
+//
+// An error response looks like
+//
+//	{"error": "Job not found", "code": "JOB_NOT_FOUND", "details": {...}, "request_id": "..."}
+//
+// error is a message for people and code is for programs. details is
+// present only for errors that carry data clients act on, such as the offset
+// of an interrupted upload.
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Code identifies the kind of an error for clients
+type Code string
+
+// Error codes
+const (
+	CodeBadRequest          Code = "BAD_REQUEST"       // The request cannot be carried out as made
+	CodeValidationFailed    Code = "VALIDATION_FAILED" // A field of the request is missing or invalid
+	CodeUnauthorized        Code = "UNAUTHORIZED"
+	CodeForbidden           Code = "FORBIDDEN"
+	CodeSetupRequired       Code = "SETUP_REQUIRED" // No admin exists yet; only setup is served
+	CodeNotFound            Code = "NOT_FOUND"
+	CodeJobNotFound         Code = "JOB_NOT_FOUND"
+	CodeConflict            Code = "CONFLICT" // The resource is not in a state that allows the request
+	CodeGone                Code = "GONE"
+	CodePayloadTooLarge     Code = "PAYLOAD_TOO_LARGE"
+	CodeUnsupportedMedia    Code = "UNSUPPORTED_MEDIA_TYPE" // The upload is not an accepted audio or video type
+	CodeRateLimited         Code = "RATE_LIMITED"
+	CodeQuotaExceeded       Code = "QUOTA_EXCEEDED"
+	CodeInvalidAudio        Code = "INVALID_AUDIO"    // The upload cannot be transcribed; details.reason says why
+	CodeTranscodeFailed     Code = "TRANSCODE_FAILED" // ffmpeg could not convert the file
+	CodeStorageFailed       Code = "STORAGE_FAILED"   // The storage backend rejected the file
+	CodeInsufficientStorage Code = "INSUFFICIENT_STORAGE"
+	CodeEngineUnavailable   Code = "ENGINE_UNAVAILABLE" // A transcription or LLM engine is not configured or not reachable
+	CodeUpstreamFailed      Code = "UPSTREAM_FAILED"    // A provider the request depends on failed
+	CodeInternal            Code = "INTERNAL_ERROR"
+)
+
+// Error is an API error. Message and Details are sent to the client; the
+// cause is only logged, and shown in development mode.
+type Error struct {
+	Status  int
+	Code    Code
+	Message string
+	Details map[string]any
+	cause   error
+	stack   []byte
+}
+
+// New returns an error answered with status and code
+func New(status int, code Code, message string) *Error {
+	e := &Error{Status: status, Code: code, Message: message}
+	if status >= http.StatusInternalServerError {
+		e.stack = callers(3)
+	}
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.cause)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// WithCause records the underlying error
+func (e *Error) WithCause(err error) *Error {
+	e.cause = err
+	return e
+}
+
+// WithDetails adds data for clients to the error
+func (e *Error) WithDetails(details map[string]any) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]any, len(details))
+	}
+	for key, value := range details {
+		e.Details[key] = value
+	}
+	return e
+}
+
+// From returns err as an *Error, wrapping errors that are not one as
+// internal errors
+func From(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return New(http.StatusInternalServerError, CodeInternal, "Internal server error").WithCause(err)
+}
+
+// BadRequest is a 400 for requests that cannot be carried out as made
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+// Validation is a 400 for missing or malformed fields
+func Validation(message string) *Error {
+	return New(http.StatusBadRequest, CodeValidationFailed, message)
+}
+
+// Unprocessable is a 422 for well-formed fields whose values cannot be used
+// together or with the resource
+func Unprocessable(message string) *Error {
+	return New(http.StatusUnprocessableEntity, CodeValidationFailed, message)
+}
+
+// Unauthorized is a 401
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+// Forbidden is a 403
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, CodeForbidden, message)
+}
+
+// SetupRequired is the 403 for API requests before first-run setup
+func SetupRequired() *Error {
+	return New(http.StatusForbidden, CodeSetupRequired, "Setup required")
+}
+
+// NotFound is a 404
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+// JobNotFound is the 404 for transcription jobs that do not exist or belong
+// to someone else
+func JobNotFound() *Error {
+	return New(http.StatusNotFound, CodeJobNotFound, "Job not found")
+}
+
+// Conflict is a 409
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, CodeConflict, message)
+}
+
+// Gone is a 410
+func Gone(message string) *Error {
+	return New(http.StatusGone, CodeGone, message)
+}
+
+// TooLarge is a 413
+func TooLarge(message string) *Error {
+	return New(http.StatusRequestEntityTooLarge, CodePayloadTooLarge, message)
+}
+
+// UnsupportedMedia is a 415 for uploads that are not an accepted audio or
+// video type
+func UnsupportedMedia(message string) *Error {
+	return New(http.StatusUnsupportedMediaType, CodeUnsupportedMedia, message)
+}
+
+// RateLimited is a 429 telling the client when to retry
+func RateLimited(message string, retryAfterSeconds int) *Error {
+	return New(http.StatusTooManyRequests, CodeRateLimited, message).WithDetails(map[string]any{"retry_after": retryAfterSeconds})
+}
+
+// EngineUnavailable is a 503 for a transcription or LLM engine that is not
+// configured or cannot be reached
+func EngineUnavailable(message string) *Error {
+	return New(http.StatusServiceUnavailable, CodeEngineUnavailable, message)
+}
+
+// Internal is a 500. Its message must not include the cause; pass that to
+// WithCause.
+func Internal(message string) *Error {
+	return New(http.StatusInternalServerError, CodeInternal, message)
+}
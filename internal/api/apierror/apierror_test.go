@@ -0,0 +1,84 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serve(t *testing.T, production bool, handler gin.HandlerFunc) (int, Envelope) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(production))
+	router.GET("/", handler)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	var envelope Envelope
+	if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("Expected one JSON envelope, got %q: %v", w.Body.String(), err)
+	}
+	return w.Code, envelope
+}
+
+func TestProductionHidesInternals(t *testing.T) {
+	cause := errors.New("open /var/lib/scriberr/uploads/a.wav: permission denied")
+	tests := []struct {
+		name        string
+		err         error
+		production  string
+		development string
+	}{
+		{"path", Internal("Failed to read /var/lib/scriberr/uploads/a.wav").WithCause(cause), "Failed to read [path]", "Failed to read /var/lib/scriberr/uploads/a.wav"},
+		{"sql", BadRequest("UNIQUE constraint failed: users.username"), "Bad Request", "UNIQUE constraint failed: users.username"},
+		{"plain error", cause, "Internal server error", "Internal server error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, envelope := serve(t, true, func(c *gin.Context) { Abort(c, tt.err) })
+			if envelope.Error != tt.production || envelope.Details != nil {
+				t.Errorf("Expected %q without details, got %+v", tt.production, envelope)
+			}
+
+			_, envelope = serve(t, false, func(c *gin.Context) { Abort(c, tt.err) })
+			if envelope.Error != tt.development {
+				t.Errorf("Expected %q in development, got %q", tt.development, envelope.Error)
+			}
+		})
+	}
+}
+
+func TestDevelopmentShowsCause(t *testing.T) {
+	err := Internal("Failed to save").WithCause(errors.New("disk full")).WithDetails(map[string]any{"job_id": "j1"})
+	status, envelope := serve(t, false, func(c *gin.Context) { Abort(c, err) })
+	if status != http.StatusInternalServerError || envelope.Code != CodeInternal {
+		t.Fatalf("Expected a 500 %s, got %d %s", CodeInternal, status, envelope.Code)
+	}
+	if envelope.Details["cause"] != "disk full" || envelope.Details["job_id"] != "j1" {
+		t.Errorf("Expected the cause next to the details, got %v", envelope.Details)
+	}
+	if _, ok := err.Details["cause"]; ok {
+		t.Error("Expected the error's own details to be left alone")
+	}
+}
+
+func TestMiddlewareWritesRecordedErrors(t *testing.T) {
+	status, envelope := serve(t, true, func(c *gin.Context) {
+		_ = c.Error(JobNotFound())
+	})
+	if status != http.StatusNotFound || envelope.Code != CodeJobNotFound || envelope.Error != "Job not found" {
+		t.Errorf("Expected the recorded error to be written, got %d %+v", status, envelope)
+	}
+
+	status, envelope = serve(t, true, func(c *gin.Context) {
+		_ = c.Error(errors.New("boom"))
+	})
+	if status != http.StatusInternalServerError || envelope.Code != CodeInternal || envelope.Error != "Internal server error" {
+		t.Errorf("Expected a plain error to become an internal error, got %d %+v", status, envelope)
+	}
+}
@@ -0,0 +1,139 @@
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"scriberr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Envelope is the body of every API error response
+type Envelope struct {
+	Error     string         `json:"error"`                // What went wrong
+	Code      Code           `json:"code"`                 // Machine-readable kind of error
+	Details   map[string]any `json:"details,omitempty"`    // Data clients act on, such as retry_after or an upload offset
+	RequestID string         `json:"request_id,omitempty"` // Also returned in X-Request-ID; quote it when reporting problems
+}
+
+const (
+	// productionKey records in the Gin context whether internal details are
+	// hidden from error responses
+	productionKey = "apierror_production"
+	// writtenKey marks requests whose error response has been written, so no
+	// second one is. Writers wrapping the response may hold the body back, so
+	// c.Writer.Written() cannot tell.
+	writtenKey = "apierror_written"
+)
+
+var (
+	// Absolute paths of two or more components, such as /data/uploads/x.wav
+	pathPattern = regexp.MustCompile(`(?:[A-Za-z]:)?(?:/[\w.\-]+){2,}/?`)
+	// Messages of the SQL driver, or statements quoted in them
+	sqlPattern = regexp.MustCompile(`\bSQL\b|sqlite|constraint failed|no such (?:table|column)|\bSELECT\b.+\bFROM\b|\bINSERT INTO\b|\bUPDATE\b.+\bSET\b|\bDELETE FROM\b`)
+)
+
+// Middleware writes errors handlers record with c.Error but do not answer
+// themselves. In production, messages are stripped of file paths, messages
+// quoting SQL are replaced, and causes are never shown; in development the
+// cause is added to the details.
+func Middleware(production bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(productionKey, production)
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		write(c, c.Errors.Last().Err)
+	}
+}
+
+// Abort answers the request with err and stops the remaining handlers. Only
+// the first error of a request is written.
+func Abort(c *gin.Context, err error) {
+	_ = c.Error(err)
+	write(c, err)
+	c.Abort()
+}
+
+// write sends err as an envelope, logging server errors with the request's
+// logger
+func write(c *gin.Context, err error) {
+	if c.GetBool(writtenKey) {
+		return
+	}
+	apiErr := From(err)
+	production := isProduction(c)
+
+	if apiErr.Status >= http.StatusInternalServerError {
+		fields := []logger.Field{
+			logger.Int("status", apiErr.Status),
+			logger.String("code", string(apiErr.Code)),
+			logger.String("message", apiErr.Message),
+			logger.ErrorField(apiErr.cause),
+		}
+		if apiErr.stack != nil {
+			fields = append(fields, logger.String("stack", string(apiErr.stack)))
+		}
+		logger.FromContext(c.Request.Context()).Error("Request failed", fields...)
+	}
+
+	envelope := Envelope{
+		Error:     apiErr.Message,
+		Code:      apiErr.Code,
+		Details:   apiErr.Details,
+		RequestID: logger.RequestID(c),
+	}
+	if production {
+		envelope.Error = sanitize(apiErr.Message, apiErr.Status)
+	} else if apiErr.cause != nil {
+		details := make(map[string]any, len(apiErr.Details)+1)
+		for key, value := range apiErr.Details {
+			details[key] = value
+		}
+		details["cause"] = apiErr.cause.Error()
+		envelope.Details = details
+	}
+	c.Set(writtenKey, true)
+	c.AbortWithStatusJSON(apiErr.Status, envelope)
+}
+
+// isProduction reports whether internal details are hidden. Requests the
+// middleware has not seen yet are treated as production.
+func isProduction(c *gin.Context) bool {
+	production, ok := c.Get(productionKey)
+	return !ok || production.(bool)
+}
+
+// sanitize removes file paths from a message, and replaces a message that
+// quotes SQL with the status text
+func sanitize(message string, status int) string {
+	if sqlPattern.MatchString(message) {
+		return http.StatusText(status)
+	}
+	return pathPattern.ReplaceAllString(message, "[path]")
+}
+
+// callers formats the stack above skip frames for logging
+func callers(skip int) []byte {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(skip, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var b strings.Builder
+	for {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, "github.com/gin-gonic/gin.") {
+			break // The rest is the router
+		}
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return []byte(b.String())
+}
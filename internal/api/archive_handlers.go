@@ -0,0 +1,121 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+)
+
+// BulkArchiveRequest names the jobs to archive, at most 1000
+type BulkArchiveRequest struct {
+	JobIDs []string `json:"job_ids" binding:"required,min=1,max=1000"`
+}
+
+// ArchiveJob hides a job from the default job list
+// @Summary Archive a job
+// @Description Archives a job so the job list leaves it out unless include_archived is set. The job stays available at its own endpoints. Archiving an archived job keeps its original archive time.
+// @Tags transcription
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} models.TranscriptionJob
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/{id}/archive [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) ArchiveJob(c *gin.Context) {
+	setJobArchived(c, true)
+}
+
+// UnarchiveJob returns an archived job to the default job list
+// @Summary Unarchive a job
+// @Tags transcription
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} models.TranscriptionJob
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/{id}/unarchive [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) UnarchiveJob(c *gin.Context) {
+	setJobArchived(c, false)
+}
+
+// BulkArchiveJobs archives several jobs at once
+// @Summary Archive jobs in bulk
+// @Description Archives every listed job in one transaction. If any job does not exist nothing is archived.
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param request body BulkArchiveRequest true "Jobs to archive"
+// @Success 200 {object} BulkResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/archive [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) BulkArchiveJobs(c *gin.Context) {
+	var req BulkArchiveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+
+	ids := uniqueIDs(req.JobIDs)
+	now := time.Now()
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := findBulkJobs(tx.Scopes(ownedJobs(c)), ids); err != nil {
+			return err
+		}
+		return tx.Model(&models.TranscriptionJob{}).Where("id IN ? AND archived_at IS NULL", ids).Update("archived_at", now).Error
+	})
+	if !bulkSucceeded(c, err, "archive") {
+		return
+	}
+	c.JSON(http.StatusOK, BulkResponse{Updated: len(ids)})
+}
+
+// setJobArchived archives or unarchives the job named by the :id path
+// parameter and writes it
+func setJobArchived(c *gin.Context, archived bool) {
+	jobID := c.Param("id")
+
+	var job models.TranscriptionJob
+	if err := database.DB.Scopes(ownedJobs(c)).Select("id").Where("id = ?", jobID).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.JobNotFound())
+			return
+		}
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
+		return
+	}
+
+	query := database.DB.Model(&models.TranscriptionJob{}).Where("id = ?", jobID)
+	var err error
+	if archived {
+		err = query.Where("archived_at IS NULL").Update("archived_at", time.Now()).Error
+	} else {
+		err = query.Update("archived_at", nil).Error
+	}
+	if err != nil {
+		logger.Error("Failed to update job archive state", "job_id", jobID, "archived", archived, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to update job"))
+		return
+	}
+
+	if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", jobID).First(&job).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
+		return
+	}
+	job.HasNotes = job.Notes != nil
+	c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,435 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/internal/quota"
+	"scriberr/internal/storage"
+	"scriberr/internal/transcription"
+	"scriberr/pkg/logger"
+)
+
+// maxBatchFiles is the most files one batch upload may contain
+const maxBatchFiles = 100
+
+// maxBatchSubmissionFiles is the most files one all-or-nothing batch
+// submission may contain
+const maxBatchSubmissionFiles = 20
+
+// Batch file error codes, alongside the transcription.Validation* codes
+const (
+	batchUnsupportedType = "unsupported_type"
+	batchSaveFailed      = "save_failed"
+)
+
+// BatchFileResult reports what happened to one file of a batch upload
+type BatchFileResult struct {
+	Filename string `json:"filename"`
+	JobID    string `json:"job_id,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Code     string `json:"code,omitempty"`
+}
+
+// BatchUploadResponse lists the jobs of a batch upload in input order
+type BatchUploadResponse struct {
+	BatchID string            `json:"batch_id"`
+	JobIDs  []*string         `json:"job_ids"` // Null for rejected files
+	Files   []BatchFileResult `json:"files"`
+}
+
+// BatchSubmissionJob is the job created for one file of a batch submission
+type BatchSubmissionJob struct {
+	File  string `json:"file"`
+	JobID string `json:"job_id"`
+	Error string `json:"error,omitempty"` // Set if the job failed before it could be queued
+	Code  string `json:"code,omitempty"`
+}
+
+// BatchSubmissionResponse lists the jobs of a batch submission in input order
+type BatchSubmissionResponse struct {
+	BatchID string               `json:"batch_id"`
+	Jobs    []BatchSubmissionJob `json:"jobs"`
+}
+
+// BatchJobsResponse lists every job of a batch
+type BatchJobsResponse struct {
+	BatchID string                    `json:"batch_id"`
+	Jobs    []models.TranscriptionJob `json:"jobs"`
+}
+
+// BatchJobStatus is one job of a batch
+type BatchJobStatus struct {
+	ID       string           `json:"id"`
+	Title    *string          `json:"title,omitempty"`
+	Status   models.JobStatus `json:"status"`
+	Progress float64          `json:"progress"`
+}
+
+// BatchStatus reports the aggregate progress of a batch upload
+type BatchStatus struct {
+	BatchID  string                   `json:"batch_id"`
+	Total    int                      `json:"total"`
+	Statuses map[models.JobStatus]int `json:"statuses"` // Number of jobs in each status
+	Progress float64                  `json:"progress"` // 0.0 - 1.0; completed and failed jobs count as finished
+	Done     bool                     `json:"done"`     // Every job has completed or failed
+	Jobs     []BatchJobStatus         `json:"jobs"`
+}
+
+// CreateBatch creates a transcription job for each uploaded file
+// @Summary Upload a batch of files for transcription
+// @Description Upload several audio files sharing one set of parameters. All jobs are created in one transaction and queued. Files that are not transcribable audio are reported per file without failing the batch, unless atomic is true. Files that cannot be converted to a readable format are reported with their failed job.
+// @Tags transcription
+// @Accept multipart/form-data
+// @Produce json
+// @Param audio formData file true "Audio files; repeat the field for each file"
+// @Param parameters formData string false "JSON overrides for the default models.WhisperXParams, or for the profile's parameters with profile_id"
+// @Param profile_id formData string false "Transcription profile to take the parameters from"
+// @Param priority formData int false "Queue priority: -1 low, 0 normal, 1 high (admins only)" default(0)
+// @Param atomic query bool false "Reject the whole batch if any file is invalid"
+// @Success 201 {object} BatchUploadResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 403 {object} apierror.Envelope
+// @Failure 413 {object} apierror.Envelope "Body is larger than MAX_UPLOAD_SIZE_MB"
+// @Failure 422 {object} apierror.Envelope "No file, or with atomic any file, is transcribable"
+// @Failure 429 {object} apierror.Envelope "Quota exceeded"
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/jobs/batch [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) CreateBatch(c *gin.Context) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		apierror.Abort(c, apierror.Validation("Multipart form with audio files is required"))
+		return
+	}
+	response, ok := h.createBatch(c, form.File["audio"], maxBatchFiles, c.PostForm("parameters"), c.Query("atomic") == "true")
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// createBatch saves and queues a job for each file with the shared parameters
+// in rawParams, or the profile_id form field's profile with rawParams as
+// overrides, writing an error response and returning false if the batch is
+// rejected. With atomic, any file that is not transcribable rejects the batch.
+func (h *Handler) createBatch(c *gin.Context, files []*multipart.FileHeader, maxFiles int, rawParams string, atomic bool) (*BatchUploadResponse, bool) {
+	if len(files) == 0 {
+		apierror.Abort(c, apierror.Validation("At least one audio file is required"))
+		return nil, false
+	}
+	if len(files) > maxFiles {
+		apierror.Abort(c, apierror.Validation(fmt.Sprintf("A batch may contain at most %d files", maxFiles)))
+		return nil, false
+	}
+
+	params := h.defaultTranscriptionParams()
+	if profileID := c.PostForm("profile_id"); profileID != "" {
+		var ok bool
+		if params, ok = profileParameters(c, profileID, []byte(rawParams)); !ok {
+			return nil, false
+		}
+	} else if rawParams != "" {
+		if err := json.Unmarshal([]byte(rawParams), &params); err != nil {
+			apierror.Abort(c, apierror.Validation("Invalid parameters: "+err.Error()))
+			return nil, false
+		}
+	}
+	if err := params.ValidateDiarization(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return nil, false
+	}
+	if !validJobOptions(c, params) {
+		return nil, false
+	}
+	if params.IsMultiTrackEnabled {
+		apierror.Abort(c, apierror.BadRequest("Multi-track transcription cannot be used with batch uploads"))
+		return nil, false
+	}
+	priority, ok := requestedPriority(c)
+	if !ok {
+		return nil, false
+	}
+
+	if err := os.MkdirAll(h.config.UploadDir, 0755); err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to create upload directory"))
+		return nil, false
+	}
+
+	// Save and check every file before creating any job
+	batchID := uuid.New().String()
+	results := make([]BatchFileResult, len(files))
+	var jobs []*models.TranscriptionJob
+	var resultIndex []int // Index in results of each job
+	for i, header := range files {
+		results[i].Filename = header.Filename
+		job, code, message := h.saveBatchFile(c, header)
+		if job == nil {
+			results[i].Code, results[i].Error = code, message
+			continue
+		}
+		job.BatchID = &batchID
+		job.Parameters = params
+		job.Diarization = params.Diarize
+		job.Priority = priority
+		results[i].JobID = job.ID
+		jobs = append(jobs, job)
+		resultIndex = append(resultIndex, i)
+	}
+	removeFiles := func() {
+		for _, job := range jobs {
+			os.Remove(job.AudioPath)
+		}
+	}
+
+	if len(jobs) == 0 || (atomic && len(jobs) < len(files)) {
+		removeFiles()
+		for i := range results {
+			results[i].JobID = ""
+		}
+		apierror.Abort(c, apierror.Unprocessable("Batch contains files that cannot be transcribed").WithDetails(map[string]any{"files": results}))
+		return nil, false
+	}
+
+	if !h.enforceBatchQuota(c, jobs) {
+		removeFiles()
+		return nil, false
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, job := range jobs {
+			if err := tx.Create(job).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		removeFiles()
+		logger.Error("Failed to create batch jobs", "batch_id", batchID, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to create jobs"))
+		return nil, false
+	}
+
+	// Jobs that cannot be queued now stay pending for the queue's scanner
+	for i, job := range jobs {
+		if h.config.NormalizeOnUpload || !storage.Local() {
+			if err := normalizeJob(c.Request.Context(), job); err != nil {
+				result := &results[resultIndex[i]]
+				result.Code, result.Error = codeTranscodeFailed, err.Error()
+				continue
+			}
+		}
+		if err := storeJob(c.Request.Context(), job); err != nil {
+			result := &results[resultIndex[i]]
+			result.Code, result.Error = codeStorageFailed, "Failed to store audio"
+			continue
+		}
+		h.queueWaveform(job.ID)
+		if err := h.taskQueue.EnqueueJob(job.ID); err != nil {
+			logger.Warn("Failed to enqueue batch job", "batch_id", batchID, "job_id", job.ID, "error", err)
+		}
+	}
+
+	response := BatchUploadResponse{BatchID: batchID, JobIDs: make([]*string, len(results)), Files: results}
+	for i := range results {
+		if results[i].JobID != "" {
+			response.JobIDs[i] = &results[i].JobID
+		}
+	}
+	logger.Info("Batch created", "batch_id", batchID, "jobs", len(jobs), "rejected", len(files)-len(jobs))
+	return &response, true
+}
+
+// saveBatchFile saves one file of a batch upload and returns its unsaved job,
+// or the error code and message to report for the file
+func (h *Handler) saveBatchFile(c *gin.Context, header *multipart.FileHeader) (*models.TranscriptionJob, string, string) {
+	if !transcription.IsSupportedMediaFile(header.Filename) {
+		return nil, batchUnsupportedType, "Unsupported file type"
+	}
+
+	jobID := uuid.New().String()
+	filePath := filepath.Join(h.config.UploadDir, jobID+filepath.Ext(header.Filename))
+	if err := c.SaveUploadedFile(header, filePath); err != nil {
+		logger.Warn("Failed to save batch file", "file", header.Filename, "error", err)
+		return nil, batchSaveFailed, "Failed to save file"
+	}
+
+	err := transcription.CheckMediaFile(filePath)
+	if err == nil {
+		err = transcription.ValidateAudioFile(c.Request.Context(), filePath)
+	}
+	if err != nil {
+		var validationErr *transcription.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			os.Remove(filePath)
+			return nil, validationErr.Code, validationErr.Message
+		case errors.Is(err, transcription.ErrFFprobeUnavailable):
+			logger.Warn("Skipping audio validation, ffprobe is not installed", "file", filePath)
+		default:
+			os.Remove(filePath)
+			return nil, transcription.ValidationCorruptFile, "Failed to validate audio file"
+		}
+	}
+
+	title := header.Filename
+	job := &models.TranscriptionJob{
+		ID:        jobID,
+		Title:     &title,
+		AudioPath: filePath,
+		Status:    models.StatusPending,
+		UserID:    jobOwner(c),
+	}
+	job.PhaseTimings = uploadTimings(c)
+	h.detectAudioInfo(c, job)
+	return job, "", ""
+}
+
+// enforceBatchQuota checks the caller's quotas against the whole batch at
+// once, writing a 429 response and returning false if it would exceed one
+func (h *Handler) enforceBatchQuota(c *gin.Context, jobs []*models.TranscriptionJob) bool {
+	var total float64
+	for _, job := range jobs {
+		seconds, err := quota.JobSeconds(c.Request.Context(), job)
+		if err != nil {
+			apierror.Abort(c, apierror.Internal("Failed to check quota"))
+			return false
+		}
+		total += float64(seconds)
+	}
+
+	batch := models.TranscriptionJob{AudioPath: jobs[0].AudioPath, DurationSeconds: &total}
+	if !h.enforceQuota(c, &batch) {
+		return false
+	}
+	for _, job := range jobs {
+		job.UserID = batch.UserID
+	}
+	return true
+}
+
+// GetBatchStatus reports the aggregate progress of a batch upload
+// @Summary Get batch status
+// @Description Get the number of jobs in each status and the overall progress of a batch upload. List the jobs themselves with /transcription/list?batch_id=.
+// @Tags transcription
+// @Produce json
+// @Param id path string true "Batch ID"
+// @Success 200 {object} BatchStatus
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/jobs/batch/{id} [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) GetBatchStatus(c *gin.Context) {
+	batchID := c.Param("id")
+	var jobs []models.TranscriptionJob
+	if err := database.DB.Scopes(ownedJobs(c)).Select("id", "title", "status", "progress", "created_at").
+		Where("batch_id = ?", batchID).Order("created_at ASC").Find(&jobs).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to get batch"))
+		return
+	}
+	if len(jobs) == 0 {
+		apierror.Abort(c, apierror.NotFound("Batch not found"))
+		return
+	}
+
+	status := BatchStatus{
+		BatchID:  batchID,
+		Total:    len(jobs),
+		Statuses: make(map[models.JobStatus]int),
+		Done:     true,
+		Jobs:     make([]BatchJobStatus, len(jobs)),
+	}
+	var progress float64
+	for i, job := range jobs {
+		status.Statuses[job.Status]++
+		status.Jobs[i] = BatchJobStatus{ID: job.ID, Title: job.Title, Status: job.Status, Progress: job.Progress}
+		switch job.Status {
+		case models.StatusCompleted, models.StatusFailed:
+			progress++
+		default:
+			progress += job.Progress
+			status.Done = false
+		}
+	}
+	status.Progress = progress / float64(len(jobs))
+	c.JSON(http.StatusOK, status)
+}
+
+// SubmitBatch creates a transcription job for each uploaded file, or none
+// @Summary Submit a batch of files for transcription
+// @Description Upload up to 20 audio files sharing one set of options. Every file is validated first; if any is not transcribable audio no job is created. Otherwise all jobs are created in one transaction and queued.
+// @Tags transcription
+// @Accept multipart/form-data
+// @Produce json
+// @Param files formData file true "Audio files; repeat the field for each file"
+// @Param options formData string false "JSON overrides for the default models.WhisperXParams, or for the profile's parameters with profile_id"
+// @Param profile_id formData string false "Transcription profile to take the parameters from"
+// @Param priority formData int false "Queue priority: -1 low, 0 normal, 1 high (admins only)" default(0)
+// @Success 201 {object} BatchSubmissionResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 403 {object} apierror.Envelope
+// @Failure 413 {object} apierror.Envelope "Body is larger than MAX_UPLOAD_SIZE_MB"
+// @Failure 422 {object} apierror.Envelope "A file is not transcribable"
+// @Failure 429 {object} apierror.Envelope "Quota exceeded"
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/batch [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) SubmitBatch(c *gin.Context) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		apierror.Abort(c, apierror.Validation("Multipart form with audio files is required"))
+		return
+	}
+	batch, ok := h.createBatch(c, form.File["files"], maxBatchSubmissionFiles, c.PostForm("options"), true)
+	if !ok {
+		return
+	}
+
+	response := BatchSubmissionResponse{BatchID: batch.BatchID, Jobs: make([]BatchSubmissionJob, len(batch.Files))}
+	for i, file := range batch.Files {
+		response.Jobs[i] = BatchSubmissionJob{File: file.Filename, JobID: file.JobID, Error: file.Error, Code: file.Code}
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetBatchJobs lists the jobs of a batch
+// @Summary List the jobs of a batch
+// @Description Get every job created by a batch submission or upload, oldest first
+// @Tags transcription
+// @Produce json
+// @Param batch_id path string true "Batch ID"
+// @Success 200 {object} BatchJobsResponse
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/batch/{batch_id} [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) GetBatchJobs(c *gin.Context) {
+	batchID := c.Param("batch_id")
+	var jobs []models.TranscriptionJob
+	if err := database.DB.Scopes(ownedJobs(c)).Where("batch_id = ?", batchID).Order("created_at ASC").Find(&jobs).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to get batch"))
+		return
+	}
+	if len(jobs) == 0 {
+		apierror.Abort(c, apierror.NotFound("Batch not found"))
+		return
+	}
+	c.JSON(http.StatusOK, BatchJobsResponse{BatchID: batchID, Jobs: jobs})
+}
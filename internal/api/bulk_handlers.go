@@ -0,0 +1,246 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+)
+
+// BulkJobsRequest names the jobs a bulk operation applies to, at most 1000
+type BulkJobsRequest struct {
+	IDs []string `json:"ids" binding:"required,min=1,max=1000"`
+}
+
+// BulkTagRequest adds and removes tags on several jobs
+type BulkTagRequest struct {
+	BulkJobsRequest
+	Add    []string `json:"add,omitempty"`    // key:value tags to add
+	Remove []string `json:"remove,omitempty"` // key:value tags, or keys to remove every value of
+}
+
+// BulkMoveRequest files several jobs in a folder
+type BulkMoveRequest struct {
+	BulkJobsRequest
+	FolderID *uint `json:"folder_id"` // null takes the jobs out of their folder
+}
+
+// BulkResponse reports how many jobs a bulk operation changed
+type BulkResponse struct {
+	Updated int `json:"updated"`
+}
+
+// BulkTagJobs adds and removes tags on several jobs at once
+// @Summary Tag jobs in bulk
+// @Description Adds and removes tags on every listed job in one transaction. Removals are applied first. If any job does not exist nothing is changed.
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param request body BulkTagRequest true "Jobs and tags"
+// @Success 200 {object} BulkResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/bulk/tags [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) BulkTagJobs(c *gin.Context) {
+	var req BulkTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	if len(req.Add) == 0 && len(req.Remove) == 0 {
+		apierror.Abort(c, apierror.Validation("No tags to add or remove"))
+		return
+	}
+	for _, tag := range req.Add {
+		if _, _, err := models.ParseTag(tag); err != nil {
+			apierror.Abort(c, apierror.Validation(err.Error()))
+			return
+		}
+	}
+	for _, tag := range req.Remove {
+		if _, _, err := models.ParseTagFilter(tag); err != nil {
+			apierror.Abort(c, apierror.Validation(err.Error()))
+			return
+		}
+	}
+
+	ids := uniqueIDs(req.IDs)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := findBulkJobs(tx.Scopes(ownedJobs(c)), ids); err != nil {
+			return err
+		}
+		if err := database.UntagJobs(tx, ids, req.Remove); err != nil {
+			return err
+		}
+		return database.TagJobs(tx, ids, req.Add)
+	})
+	if !bulkSucceeded(c, err, "tag") {
+		return
+	}
+	c.JSON(http.StatusOK, BulkResponse{Updated: len(ids)})
+}
+
+// BulkMoveJobs files several jobs in a folder at once
+// @Summary Move jobs in bulk
+// @Description Files every listed job in a folder, or in no folder when folder_id is null, in one transaction. If any job does not exist nothing is changed.
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param request body BulkMoveRequest true "Jobs and folder"
+// @Success 200 {object} BulkResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/bulk/move [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) BulkMoveJobs(c *gin.Context) {
+	var req BulkMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+
+	ids := uniqueIDs(req.IDs)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if req.FolderID != nil {
+			var folder models.JobFolder
+			if err := tx.First(&folder, *req.FolderID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errBulkFolderNotFound
+				}
+				return err
+			}
+		}
+		if _, err := findBulkJobs(tx.Scopes(ownedJobs(c)), ids); err != nil {
+			return err
+		}
+		return tx.Model(&models.TranscriptionJob{}).Where("id IN ?", ids).Update("folder_id", req.FolderID).Error
+	})
+	if !bulkSucceeded(c, err, "move") {
+		return
+	}
+	c.JSON(http.StatusOK, BulkResponse{Updated: len(ids)})
+}
+
+// BulkDeleteJobs deletes several jobs at once
+// @Summary Delete jobs in bulk
+// @Description Deletes every listed job in one transaction: if any job does not exist or is processing, nothing is deleted. Deleted jobs can be restored until retention cleanup purges them with their files.
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param request body BulkJobsRequest true "Jobs to delete"
+// @Success 200 {object} map[string]int
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/bulk/delete [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) BulkDeleteJobs(c *gin.Context) {
+	var req BulkJobsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+
+	jobs, err := findBulkJobs(database.DB.Scopes(ownedJobs(c)), uniqueIDs(req.IDs))
+	if !bulkSucceeded(c, err, "delete") {
+		return
+	}
+	var processing []string
+	for _, job := range jobs {
+		if job.Status == models.StatusProcessing {
+			processing = append(processing, job.ID)
+		}
+	}
+	if len(processing) > 0 {
+		apierror.Abort(c, apierror.BadRequest("Cannot delete jobs that are currently processing").WithDetails(map[string]any{"ids": processing}))
+		return
+	}
+
+	ids := make([]string, len(jobs))
+	for i, job := range jobs {
+		ids[i] = job.ID
+	}
+	if err := database.DB.Where("id IN ?", ids).Delete(&models.TranscriptionJob{}).Error; err != nil {
+		logger.Error("Failed to delete jobs", "count", len(jobs), "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to delete jobs"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": len(jobs)})
+}
+
+// missingJobsError lists the requested jobs that do not exist
+type missingJobsError struct {
+	ids []string
+}
+
+func (e *missingJobsError) Error() string {
+	return "jobs not found: " + strings.Join(e.ids, ", ")
+}
+
+// errBulkFolderNotFound fails a move to a folder that does not exist
+var errBulkFolderNotFound = errors.New("Folder not found")
+
+// findBulkJobs loads the jobs with ids, failing with a missingJobsError
+// naming any that do not exist
+func findBulkJobs(tx *gorm.DB, ids []string) ([]models.TranscriptionJob, error) {
+	var jobs []models.TranscriptionJob
+	if err := tx.Where("id IN ?", ids).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	if len(jobs) == len(ids) {
+		return jobs, nil
+	}
+	found := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		found[job.ID] = true
+	}
+	missing := &missingJobsError{}
+	for _, id := range ids {
+		if !found[id] {
+			missing.ids = append(missing.ids, id)
+		}
+	}
+	return nil, missing
+}
+
+// bulkSucceeded writes the error response for a failed bulk operation and
+// returns false, or returns true if err is nil
+func bulkSucceeded(c *gin.Context, err error, operation string) bool {
+	if err == nil {
+		return true
+	}
+	var missing *missingJobsError
+	switch {
+	case errors.As(err, &missing):
+		apierror.Abort(c, apierror.New(http.StatusNotFound, apierror.CodeJobNotFound, "Some jobs were not found").WithDetails(map[string]any{"ids": missing.ids}))
+	case errors.Is(err, errBulkFolderNotFound):
+		apierror.Abort(c, apierror.NotFound(err.Error()))
+	default:
+		logger.Error("Bulk job operation failed", "operation", operation, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to "+operation+" jobs"))
+	}
+	return false
+}
+
+// uniqueIDs returns ids without repeats, in their first order
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+)
+
+// ChaptersResponse lists where the topics of a transcript start
+type ChaptersResponse struct {
+	JobID    string           `json:"job_id"`
+	Chapters []models.Chapter `json:"chapters"`
+}
+
+// GetJobChapters returns the chapters detected in a job's transcript
+// @Summary Get transcript chapters
+// @Description Lists the chapter markers found in a completed transcript, in order. Chapters are detected by the chapters post-processor, which starts one wherever a sentence ends before a long enough pause; jobs that did not run it have none.
+// @Tags transcription
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} ChaptersResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/{id}/chapters [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) GetJobChapters(c *gin.Context) {
+	var job models.TranscriptionJob
+	if err := database.DB.Scopes(ownedJobs(c)).Select("id", "status", "chapters").Where("id = ?", c.Param("id")).First(&job).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apierror.Abort(c, apierror.JobNotFound())
+			return
+		}
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
+		return
+	}
+	if job.Status != models.StatusCompleted {
+		apierror.Abort(c, apierror.BadRequest("Transcript not available"))
+		return
+	}
+
+	chapters := job.Chapters
+	if chapters == nil {
+		chapters = []models.Chapter{}
+	}
+	c.JSON(http.StatusOK, ChaptersResponse{JobID: job.ID, Chapters: chapters})
+}
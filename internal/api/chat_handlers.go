@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"scriberr/internal/api/apierror"
 	"scriberr/internal/database"
 	"scriberr/internal/llm"
 	"scriberr/internal/models"
@@ -45,10 +46,11 @@ type ChatSessionResponse struct {
 
 // ChatMessageResponse represents a chat message response
 type ChatMessageResponse struct {
-	ID        uint      `json:"id"`
-	Role      string    `json:"role"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        uint                `json:"id"`
+	Role      string              `json:"role"`
+	Content   string              `json:"content"`
+	Sources   []models.ChatSource `json:"sources,omitempty"` // Transcript passages a transcript chat answer cites
+	CreatedAt time.Time           `json:"created_at"`
 }
 
 // ChatModelsResponse represents the available chat models
@@ -92,15 +94,15 @@ func (h *Handler) getLLMService() (llm.Service, string, error) {
 // @Tags chat
 // @Produce json
 // @Success 200 {object} ChatModelsResponse
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 502 {object} apierror.Envelope
+// @Failure 503 {object} apierror.Envelope
 // @Router /api/v1/chat/models [get]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (h *Handler) GetChatModels(c *gin.Context) {
 	svc, _, err := h.getLLMService()
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.EngineUnavailable(err.Error()))
 		return
 	}
 
@@ -109,7 +111,7 @@ func (h *Handler) GetChatModels(c *gin.Context) {
 
 	models, err := svc.GetModels(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch models: " + err.Error()})
+		apierror.Abort(c, apierror.New(http.StatusBadGateway, apierror.CodeUpstreamFailed, "Failed to fetch models from the LLM provider").WithCause(err))
 		return
 	}
 
@@ -123,39 +125,40 @@ func (h *Handler) GetChatModels(c *gin.Context) {
 // @Produce json
 // @Param request body ChatCreateRequest true "Chat session creation request"
 // @Success 201 {object} ChatSessionResponse
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Failure 503 {object} apierror.Envelope
 // @Router /api/v1/chat/sessions [post]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (h *Handler) CreateChatSession(c *gin.Context) {
 	var req ChatCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Validation(err.Error()))
 		return
 	}
 
 	// Verify transcription exists and has completed transcript
 	var transcription models.TranscriptionJob
-	if err := database.DB.Where("id = ?", req.TranscriptionID).First(&transcription).Error; err != nil {
+	if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", req.TranscriptionID).First(&transcription).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Transcription not found"})
+			apierror.Abort(c, apierror.JobNotFound())
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get transcription"})
+		apierror.Abort(c, apierror.Internal("Failed to get transcription"))
 		return
 	}
 
 	if transcription.Status != models.StatusCompleted || transcription.Transcript == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Transcription must be completed to create a chat session"})
+		apierror.Abort(c, apierror.Validation("Transcription must be completed to create a chat session"))
 		return
 	}
 
 	// Verify LLM service is available
 	_, _, err := h.getLLMService()
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.EngineUnavailable(err.Error()))
 		return
 	}
 
@@ -178,7 +181,7 @@ func (h *Handler) CreateChatSession(c *gin.Context) {
 	}
 
 	if err := database.DB.Create(&chatSession).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create chat session"})
+		apierror.Abort(c, apierror.Internal("Failed to create chat session"))
 		return
 	}
 
@@ -204,22 +207,22 @@ func (h *Handler) CreateChatSession(c *gin.Context) {
 // @Produce json
 // @Param transcription_id path string true "Transcription ID"
 // @Success 200 {array} ChatSessionResponse
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
 // @Router /api/v1/chat/transcriptions/{transcription_id}/sessions [get]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (h *Handler) GetChatSessions(c *gin.Context) {
 	transcriptionID := c.Param("transcription_id")
 	if transcriptionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Transcription ID is required"})
+		apierror.Abort(c, apierror.Validation("Transcription ID is required"))
 		return
 	}
 
 	var sessions []models.ChatSession
-	if err := database.DB.Where("transcription_id = ?", transcriptionID).
+	if err := database.DB.Scopes(ofOwnedJobs(c)).Where("transcription_id = ?", transcriptionID).
 		Order("updated_at DESC").Find(&sessions).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get chat sessions"})
+		apierror.Abort(c, apierror.Internal("Failed to get chat sessions"))
 		return
 	}
 
@@ -296,32 +299,32 @@ func (h *Handler) GetChatSessions(c *gin.Context) {
 // @Produce json
 // @Param session_id path string true "Chat Session ID"
 // @Success 200 {object} ChatSessionWithMessages
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
 // @Router /api/v1/chat/sessions/{session_id} [get]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (h *Handler) GetChatSession(c *gin.Context) {
 	sessionID := c.Param("session_id")
 	if sessionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Session ID is required"})
+		apierror.Abort(c, apierror.Validation("Session ID is required"))
 		return
 	}
 
 	var session models.ChatSession
-	if err := database.DB.Where("id = ?", sessionID).First(&session).Error; err != nil {
+	if err := database.DB.Scopes(ofOwnedJobs(c)).Where("id = ?", sessionID).First(&session).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Chat session not found"})
+			apierror.Abort(c, apierror.NotFound("Chat session not found"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get chat session"})
+		apierror.Abort(c, apierror.Internal("Failed to get chat session"))
 		return
 	}
 
 	var messages []models.ChatMessage
 	if err := database.DB.Where("chat_session_id = ?", sessionID).
 		Order("created_at ASC").Find(&messages).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get messages"})
+		apierror.Abort(c, apierror.Internal("Failed to get messages"))
 		return
 	}
 
@@ -331,6 +334,7 @@ func (h *Handler) GetChatSession(c *gin.Context) {
 			ID:        msg.ID,
 			Role:      msg.Role,
 			Content:   msg.Content,
+			Sources:   msg.Sources,
 			CreatedAt: msg.CreatedAt,
 		})
 	}
@@ -362,40 +366,41 @@ func (h *Handler) GetChatSession(c *gin.Context) {
 // @Param session_id path string true "Chat Session ID"
 // @Param message body ChatMessageRequest true "Message content"
 // @Success 200 {string} string "Streaming response"
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Failure 503 {object} apierror.Envelope
 // @Router /api/v1/chat/sessions/{session_id}/messages [post]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (h *Handler) SendChatMessage(c *gin.Context) {
 	sessionID := c.Param("session_id")
 	if sessionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Session ID is required"})
+		apierror.Abort(c, apierror.Validation("Session ID is required"))
 		return
 	}
 
 	var req ChatMessageRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Validation(err.Error()))
 		return
 	}
 
 	// Get chat session
 	var session models.ChatSession
-	if err := database.DB.Preload("Transcription").Where("id = ?", sessionID).First(&session).Error; err != nil {
+	if err := database.DB.Preload("Transcription").Scopes(ofOwnedJobs(c)).Where("id = ?", sessionID).First(&session).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Chat session not found"})
+			apierror.Abort(c, apierror.NotFound("Chat session not found"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get chat session"})
+		apierror.Abort(c, apierror.Internal("Failed to get chat session"))
 		return
 	}
 
 	// Get LLM service
 	svc, _, err := h.getLLMService()
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.EngineUnavailable(err.Error()))
 		return
 	}
 
@@ -408,7 +413,7 @@ func (h *Handler) SendChatMessage(c *gin.Context) {
 	}
 
 	if err := database.DB.Create(&userMessage).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save message"})
+		apierror.Abort(c, apierror.Internal("Failed to save message"))
 		return
 	}
 
@@ -449,7 +454,6 @@ func (h *Handler) SendChatMessage(c *gin.Context) {
 	c.Header("Content-Type", "text/plain")
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
-	c.Header("Access-Control-Allow-Origin", "*")
 
 	// Stream the response
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
@@ -571,16 +575,16 @@ func (h *Handler) SendChatMessage(c *gin.Context) {
 // @Param session_id path string true "Chat Session ID"
 // @Param request body map[string]string true "Title update request"
 // @Success 200 {object} ChatSessionResponse
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
 // @Router /api/v1/chat/sessions/{session_id}/title [put]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (h *Handler) UpdateChatSessionTitle(c *gin.Context) {
 	sessionID := c.Param("session_id")
 	if sessionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Session ID is required"})
+		apierror.Abort(c, apierror.Validation("Session ID is required"))
 		return
 	}
 
@@ -588,23 +592,23 @@ func (h *Handler) UpdateChatSessionTitle(c *gin.Context) {
 		Title string `json:"title" binding:"required,min=1,max=255"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Validation(err.Error()))
 		return
 	}
 
 	var session models.ChatSession
-	if err := database.DB.Where("id = ?", sessionID).First(&session).Error; err != nil {
+	if err := database.DB.Scopes(ofOwnedJobs(c)).Where("id = ?", sessionID).First(&session).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Chat session not found"})
+			apierror.Abort(c, apierror.NotFound("Chat session not found"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get chat session"})
+		apierror.Abort(c, apierror.Internal("Failed to get chat session"))
 		return
 	}
 
 	session.Title = req.Title
 	if err := database.DB.Save(&session).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update title"})
+		apierror.Abort(c, apierror.Internal("Failed to update title"))
 		return
 	}
 
@@ -630,33 +634,43 @@ func (h *Handler) UpdateChatSessionTitle(c *gin.Context) {
 // @Produce json
 // @Param session_id path string true "Chat Session ID"
 // @Success 204
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
 // @Router /api/v1/chat/sessions/{session_id} [delete]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (h *Handler) DeleteChatSession(c *gin.Context) {
 	sessionID := c.Param("session_id")
 	if sessionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Session ID is required"})
+		apierror.Abort(c, apierror.Validation("Session ID is required"))
+		return
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.ChatSession{}).Scopes(ofOwnedJobs(c)).Where("id = ?", sessionID).Count(&count).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to delete chat session"))
+		return
+	}
+	if count == 0 {
+		apierror.Abort(c, apierror.NotFound("Chat session not found"))
 		return
 	}
 
 	// Delete messages first (due to foreign key constraint)
 	if err := database.DB.Where("chat_session_id = ?", sessionID).Delete(&models.ChatMessage{}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete messages"})
+		apierror.Abort(c, apierror.Internal("Failed to delete messages"))
 		return
 	}
 
 	// Delete session
 	result := database.DB.Where("id = ?", sessionID).Delete(&models.ChatSession{})
 	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete chat session"})
+		apierror.Abort(c, apierror.Internal("Failed to delete chat session"))
 		return
 	}
 
 	if result.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Chat session not found"})
+		apierror.Abort(c, apierror.NotFound("Chat session not found"))
 		return
 	}
 
@@ -690,26 +704,27 @@ func generateChatTitle(message string) string {
 // @Produce json
 // @Param session_id path string true "Chat Session ID"
 // @Success 200 {object} ChatSessionResponse
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 503 {object} apierror.Envelope
 // @Router /api/v1/chat/sessions/{session_id}/title/auto [post]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (h *Handler) AutoGenerateChatTitle(c *gin.Context) {
 	sessionID := c.Param("session_id")
 	if sessionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Session ID is required"})
+		apierror.Abort(c, apierror.Validation("Session ID is required"))
 		return
 	}
 
 	// Load session
 	var session models.ChatSession
-	if err := database.DB.Where("id = ?", sessionID).First(&session).Error; err != nil {
+	if err := database.DB.Scopes(ofOwnedJobs(c)).Where("id = ?", sessionID).First(&session).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Chat session not found"})
+			apierror.Abort(c, apierror.NotFound("Chat session not found"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get chat session"})
+		apierror.Abort(c, apierror.Internal("Failed to get chat session"))
 		return
 	}
 
@@ -746,11 +761,11 @@ func (h *Handler) AutoGenerateChatTitle(c *gin.Context) {
 	// Fetch recent messages (first user + first assistant ideally)
 	var msgs []models.ChatMessage
 	if err := database.DB.Where("chat_session_id = ?", sessionID).Order("created_at ASC").Limit(6).Find(&msgs).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get messages"})
+		apierror.Abort(c, apierror.Internal("Failed to get messages"))
 		return
 	}
 	if len(msgs) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Not enough conversation to generate a title"})
+		apierror.Abort(c, apierror.BadRequest("Not enough conversation to generate a title"))
 		return
 	}
 
@@ -799,7 +814,7 @@ Return only the title, nothing else.`
 	// Use configured LLM service
 	svc, _, err := h.getLLMService()
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.EngineUnavailable(err.Error()))
 		return
 	}
 
@@ -809,7 +824,7 @@ Return only the title, nothing else.`
 	// Use model defaults: do not set temperature explicitly
 	resp, err := svc.ChatCompletion(ctx, session.Model, chatMsgs, 0.0)
 	if err != nil || resp == nil || len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate title"})
+		apierror.Abort(c, apierror.Internal("Failed to generate title"))
 		return
 	}
 
@@ -831,14 +846,14 @@ Return only the title, nothing else.`
 
 	// Update session title
 	if err := database.DB.Model(&session).Update("title", title).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update title"})
+		apierror.Abort(c, apierror.Internal("Failed to update title"))
 		return
 	}
 
 	// Reload to return response
 	var updated models.ChatSession
 	if err := database.DB.Where("id = ?", sessionID).First(&updated).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load updated session"})
+		apierror.Abort(c, apierror.Internal("Failed to load updated session"))
 		return
 	}
 
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/cleanup"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RetentionRequest changes how long a job is kept. Day counts of -1 return
+// the job to the global policy and 0 keeps it forever.
+type RetentionRequest struct {
+	Pinned             *bool `json:"pinned,omitempty"`
+	AudioRetentionDays *int  `json:"audio_retention_days,omitempty"`
+	JobRetentionDays   *int  `json:"job_retention_days,omitempty"`
+}
+
+// CleanupPreviewResponse lists what the next cleanup run would remove
+type CleanupPreviewResponse struct {
+	Policy           cleanup.Policy   `json:"policy"`
+	Actions          []cleanup.Action `json:"actions"`
+	ReclaimableBytes int64            `json:"reclaimable_bytes"`
+}
+
+// cleanupPolicy is the global retention from the configuration
+func (h *Handler) cleanupPolicy() cleanup.Policy {
+	return cleanup.Policy{
+		AudioRetentionDays:   h.config.AudioRetentionDays,
+		JobRetentionDays:     h.config.JobRetentionDays,
+		DeletedRetentionDays: h.config.DeletedJobRetentionDays,
+	}
+}
+
+// validRetentionDays writes an error response and returns false for negative
+// retention periods
+func validRetentionDays(c *gin.Context, days ...*int) bool {
+	for _, d := range days {
+		if d != nil && *d < 0 {
+			apierror.Abort(c, apierror.Validation("Retention days must be 0 or more"))
+			return false
+		}
+	}
+	return true
+}
+
+// UpdateJobRetention pins a job or overrides its retention
+// @Summary Update job retention
+// @Description Pin a job so cleanup never removes it, or override how many days its audio and the job are kept. -1 returns a setting to the global policy and 0 keeps forever.
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param id path string true "Job ID"
+// @Param request body RetentionRequest true "Retention settings"
+// @Success 200 {object} models.TranscriptionJob
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/{id}/retention [patch]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) UpdateJobRetention(c *gin.Context) {
+	jobID := c.Param("id")
+
+	var req RetentionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Pinned != nil {
+		updates["pinned"] = *req.Pinned
+	}
+	for column, days := range map[string]*int{
+		"audio_retention_days": req.AudioRetentionDays,
+		"job_retention_days":   req.JobRetentionDays,
+	} {
+		switch {
+		case days == nil:
+		case *days == -1:
+			updates[column] = nil
+		case *days < 0:
+			apierror.Abort(c, apierror.Validation(column+" must be -1, 0 or more"))
+			return
+		default:
+			updates[column] = *days
+		}
+	}
+
+	var job models.TranscriptionJob
+	if err := database.DB.Scopes(ownedJobs(c)).Select("id").Where("id = ?", jobID).First(&job).Error; err != nil {
+		apierror.Abort(c, apierror.JobNotFound())
+		return
+	}
+	if len(updates) > 0 {
+		if err := database.DB.Model(&models.TranscriptionJob{}).Where("id = ?", jobID).Updates(updates).Error; err != nil {
+			logger.Error("Failed to update job retention", "job_id", jobID, "error", err)
+			apierror.Abort(c, apierror.Internal("Failed to update job retention"))
+			return
+		}
+	}
+
+	if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", jobID).First(&job).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// DeleteJobAudio removes a completed job's audio now, keeping its transcript
+// @Summary Delete job audio
+// @Description Delete the audio of a completed job to free space. The transcript, notes and summaries are kept; audio playback and download stop working. Deleting audio that is already gone succeeds.
+// @Tags transcription
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} models.TranscriptionJob
+// @Failure 404 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/{id}/delete-audio [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) DeleteJobAudio(c *gin.Context) {
+	var job models.TranscriptionJob
+	if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", c.Param("id")).First(&job).Error; err != nil {
+		apierror.Abort(c, apierror.JobNotFound())
+		return
+	}
+	if job.Status != models.StatusCompleted {
+		apierror.Abort(c, apierror.Conflict("Audio can only be deleted once the job has completed"))
+		return
+	}
+	if job.AudioDeletedAt == nil {
+		bytes, err := cleanup.RemoveAudio(c.Request.Context(), &job)
+		if err != nil {
+			logger.Error("Failed to delete job audio", "job_id", job.ID, "error", err)
+			apierror.Abort(c, apierror.Internal("Failed to delete audio"))
+			return
+		}
+		logger.Info("Deleted job audio", "job_id", job.ID, "path", job.AudioPath, "bytes", bytes)
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// PreviewCleanup lists what retention cleanup would remove without removing it
+// @Summary Preview retention cleanup
+// @Description List the jobs whose audio or records the next cleanup run would delete, with the space each frees. Nothing is deleted.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} CleanupPreviewResponse
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/admin/cleanup/preview [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) PreviewCleanup(c *gin.Context) {
+	policy := h.cleanupPolicy()
+	actions, err := cleanup.Preview(c.Request.Context(), policy, time.Now())
+	if err != nil {
+		logger.Error("Failed to preview cleanup", "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to preview cleanup"))
+		return
+	}
+	response := CleanupPreviewResponse{Policy: policy, Actions: actions}
+	for _, action := range actions {
+		response.ReclaimableBytes += action.Bytes
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RunCleanup applies retention cleanup now rather than waiting for the janitor
+// @Summary Run retention cleanup
+// @Description Delete everything the cleanup preview lists and report what was removed
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.CleanupRun
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/admin/cleanup/run [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) RunCleanup(c *gin.Context) {
+	run, err := cleanup.Run(c.Request.Context(), h.cleanupPolicy(), time.Now())
+	if err != nil {
+		logger.Error("Failed to run cleanup", "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to run cleanup"))
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
+
+// GetCleanupStats reports what retention cleanup has removed
+// @Summary Get retention cleanup stats
+// @Description Report the retention policy, the audio files and jobs cleanup has deleted, the bytes reclaimed and the last run
+// @Tags admin
+// @Produce json
+// @Success 200 {object} cleanup.Stats
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/admin/cleanup/stats [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) GetCleanupStats(c *gin.Context) {
+	stats, err := cleanup.Summarize(c.Request.Context(), h.cleanupPolicy())
+	if err != nil {
+		logger.Error("Failed to get cleanup stats", "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to get cleanup stats"))
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
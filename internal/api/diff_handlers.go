@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/diff"
+	"scriberr/internal/models"
+)
+
+// DiffTranscripts compares the transcripts of two jobs word by word
+// @Summary Compare two transcripts
+// @Description Diffs the text of two completed jobs word by word, typically the same audio transcribed with different parameters. added and removed list the runs of words only in the other job and only in this job; unchanged_pct is the share of words the two have in common. Both jobs must belong to the caller.
+// @Tags transcription
+// @Produce json
+// @Param id path string true "Transcription Job ID to compare from"
+// @Param other_id path string true "Transcription Job ID to compare to"
+// @Success 200 {object} diff.Result
+// @Failure 400 {object} apierror.Envelope
+// @Failure 403 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/transcriptions/{id}/diff/{other_id} [get]
+func (h *Handler) DiffTranscripts(c *gin.Context) {
+	job, ok := findDiffJob(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	other, ok := findDiffJob(c, c.Param("other_id"))
+	if !ok {
+		return
+	}
+	if !ownsBothJobs(c, job, other) {
+		apierror.Abort(c, apierror.Forbidden("Both transcriptions must belong to you"))
+		return
+	}
+
+	oldText, err := transcriptText(job)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to parse transcript"))
+		return
+	}
+	newText, err := transcriptText(other)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to parse transcript"))
+		return
+	}
+	c.JSON(http.StatusOK, diff.Compare(oldText, newText))
+}
+
+// findDiffJob loads a completed job to compare, writing an error response and
+// returning false if it is missing or has no transcript
+func findDiffJob(c *gin.Context, jobID string) (*models.TranscriptionJob, bool) {
+	var job models.TranscriptionJob
+	if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", jobID).First(&job).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apierror.Abort(c, apierror.JobNotFound())
+			return nil, false
+		}
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
+		return nil, false
+	}
+	if job.Status != models.StatusCompleted || job.Transcript == nil {
+		apierror.Abort(c, apierror.BadRequest("Transcript not available for job "+jobID))
+		return nil, false
+	}
+	return &job, true
+}
+
+// ownsBothJobs reports whether two jobs have the same owner and the caller
+// is that owner. Jobs queued without a user, such as by API key, have no
+// owner and can be compared with each other.
+func ownsBothJobs(c *gin.Context, job, other *models.TranscriptionJob) bool {
+	if (job.UserID == nil) != (other.UserID == nil) {
+		return false
+	}
+	if job.UserID == nil {
+		return true
+	}
+	userID, ok := currentUserID(c)
+	return ok && *job.UserID == userID && *other.UserID == userID
+}
+
+// transcriptText joins the text of a job's transcript segments
+func transcriptText(job *models.TranscriptionJob) (string, error) {
+	var transcript struct {
+		Segments []struct {
+			Text string `json:"text"`
+		} `json:"segments"`
+	}
+	if err := json.Unmarshal([]byte(*job.Transcript), &transcript); err != nil {
+		return "", err
+	}
+	texts := make([]string, len(transcript.Segments))
+	for i, segment := range transcript.Segments {
+		texts[i] = segment.Text
+	}
+	return strings.Join(texts, " "), nil
+}
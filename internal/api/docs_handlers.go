@@ -0,0 +1,64 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"net/http"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/api/spec"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiDocsPage renders the OpenAPI document with the Swagger UI assets served
+// under /swagger. The document is inlined, as the page cannot attach
+// credentials to a fetch of /api/openapi.json.
+var apiDocsPage = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Scriberr API</title>
+  <link rel="stylesheet" href="/swagger/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="/swagger/swagger-ui-bundle.js"></script>
+  <script>
+    SwaggerUIBundle({spec: {{.}}, dom_id: "#swagger-ui", deepLinking: true});
+  </script>
+</body>
+</html>
+`))
+
+// GetOpenAPISpec returns the OpenAPI 3 document of the API
+// @Summary Get the OpenAPI document
+// @Description Return the OpenAPI 3 document describing every endpoint, its parameters, request and response schemas, authentication and error responses.
+// @Tags docs
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/openapi.json [get]
+func (h *Handler) GetOpenAPISpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", spec.JSON())
+}
+
+// APIDocs serves the interactive API reference
+// @Summary API reference
+// @Description Browse the OpenAPI document in Swagger UI.
+// @Tags docs
+// @Produce html
+// @Success 200 {string} string "API reference page"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/docs [get]
+func (h *Handler) APIDocs(c *gin.Context) {
+	var buf bytes.Buffer
+	if err := apiDocsPage.Execute(&buf, json.RawMessage(spec.JSON())); err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to render API reference"))
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/estimate"
+	"scriberr/pkg/logger"
+)
+
+// EstimateRequest describes a transcription to estimate
+type EstimateRequest struct {
+	DurationSeconds float64 `json:"duration_seconds" binding:"required,gt=0"`
+	Model           string  `json:"model" binding:"required"`
+	Device          string  `json:"device,omitempty"` // Defaults to the server's default device
+}
+
+// newEstimator creates the transcription time estimator, falling back to the
+// built-in factors when the configured file cannot be loaded
+func newEstimator(factorsPath string) *estimate.Estimator {
+	factors, err := estimate.LoadFactors(factorsPath)
+	if err != nil {
+		logger.Warn("Using built-in estimate factors", "path", factorsPath, "error", err)
+		factors, _ = estimate.LoadFactors("")
+	}
+	return estimate.NewEstimator(factors)
+}
+
+// EstimateTranscription predicts how long a transcription will take
+// @Summary Estimate transcription time
+// @Description Predict the processing time for audio of a given length. Once enough jobs have completed with the model and device, the 75th percentile of their processing speed is used; until then the configured factors are, with low confidence.
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param request body EstimateRequest true "Audio length, model and device"
+// @Success 200 {object} estimate.Estimate
+// @Failure 400 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/estimate [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) EstimateTranscription(c *gin.Context) {
+	var req EstimateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
+		return
+	}
+	if req.Device == "" {
+		req.Device = h.environment.DefaultWhisperDevice
+	}
+
+	result, err := h.estimator.Estimate(c.Request.Context(), req.DurationSeconds, req.Model, req.Device)
+	if err != nil {
+		logger.Error("Failed to estimate transcription time", "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to estimate transcription time"))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,335 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/events"
+	"scriberr/internal/metrics"
+	"scriberr/internal/websocket"
+	"scriberr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// eventSocketPingInterval is how often sockets are pinged
+	eventSocketPingInterval = 30 * time.Second
+	// eventSocketPongWait is how long a socket may stay silent before it is
+	// considered gone
+	eventSocketPongWait = 75 * time.Second
+	// eventSocketWriteWait bounds each write to a socket
+	eventSocketWriteWait = 10 * time.Second
+)
+
+// eventSocketBuffer is how many events are queued for a socket before
+// progress ticks are dropped
+const eventSocketBuffer = 256
+
+// EventFilter narrows the events a socket receives. Mine, FolderID and JobID
+// narrow job events; Types narrows every event.
+type EventFilter struct {
+	Mine     bool     `json:"mine,omitempty"`      // Only the caller's jobs
+	FolderID *uint    `json:"folder_id,omitempty"` // Only jobs in this folder
+	JobID    string   `json:"job_id,omitempty"`    // Only this job
+	Types    []string `json:"types,omitempty"`     // Only these event types
+}
+
+// EventSocketMessage is a message a client sends on the socket. Subscribe
+// replaces the connection's filter.
+type EventSocketMessage struct {
+	Type string `json:"type" example:"subscribe"`
+	EventFilter
+}
+
+// EventSocketReply is a message the server sends besides events: subscribed
+// with the filter in effect, or error
+type EventSocketReply struct {
+	Type   string       `json:"type"`
+	Filter *EventFilter `json:"filter,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// validate checks that every type is known
+func (f EventFilter) validate() error {
+	for _, eventType := range f.Types {
+		if !slices.Contains(events.Types, eventType) {
+			return fmt.Errorf("unknown event type %q, expected one of %s", eventType, strings.Join(events.Types, ", "))
+		}
+	}
+	return nil
+}
+
+// parseEventFilter reads a filter from the socket request's query
+func parseEventFilter(query url.Values) (EventFilter, error) {
+	var filter EventFilter
+	if raw := query.Get("mine"); raw != "" {
+		mine, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, errors.New("mine must be true or false")
+		}
+		filter.Mine = mine
+	}
+	if raw := query.Get("folder_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 0)
+		if err != nil {
+			return filter, errors.New("folder_id must be a folder ID")
+		}
+		folderID := uint(id)
+		filter.FolderID = &folderID
+	}
+	filter.JobID = query.Get("job_id")
+	if raw := query.Get("types"); raw != "" {
+		for _, eventType := range strings.Split(raw, ",") {
+			if eventType = strings.TrimSpace(eventType); eventType != "" {
+				filter.Types = append(filter.Types, eventType)
+			}
+		}
+	}
+	return filter, filter.validate()
+}
+
+// eventViewer is who a socket belongs to
+type eventViewer struct {
+	userID  uint
+	hasUser bool
+	admin   bool
+}
+
+// owns reports whether a job with this owner counts as the viewer's own. Jobs
+// without an owner predate accounts and belong to admins.
+func (v eventViewer) owns(owner *uint) bool {
+	if owner == nil {
+		return v.admin
+	}
+	return v.hasUser && *owner == v.userID
+}
+
+// canSee reports whether the viewer may receive an event at all: admins see
+// every job, members only their own. Worker and storage events go to everyone.
+func (v eventViewer) canSee(e events.Event) bool {
+	return e.JobID == "" || v.admin || (e.UserID != nil && v.owns(e.UserID))
+}
+
+// matches reports whether an event passes the filter
+func (f *EventFilter) matches(e events.Event, viewer eventViewer) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, e.Type) {
+		return false
+	}
+	if e.JobID == "" {
+		return true
+	}
+	if f.Mine && !viewer.owns(e.UserID) {
+		return false
+	}
+	if f.FolderID != nil && (e.FolderID == nil || *e.FolderID != *f.FolderID) {
+		return false
+	}
+	return f.JobID == "" || e.JobID == f.JobID
+}
+
+// eventSocketHub tracks the open sockets so they can be counted and closed
+// on shutdown
+type eventSocketHub struct {
+	mu      sync.Mutex
+	conns   map[*websocket.Conn]struct{}
+	closing bool
+}
+
+// add tracks a socket, returning false once the server is shutting down
+func (hub *eventSocketHub) add(conn *websocket.Conn) bool {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if hub.closing {
+		return false
+	}
+	if hub.conns == nil {
+		hub.conns = make(map[*websocket.Conn]struct{})
+	}
+	hub.conns[conn] = struct{}{}
+	hub.report()
+	return true
+}
+
+// remove stops tracking a socket
+func (hub *eventSocketHub) remove(conn *websocket.Conn) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	delete(hub.conns, conn)
+	hub.report()
+}
+
+// report publishes the number of open sockets. Callers must hold hub.mu.
+func (hub *eventSocketHub) report() {
+	metrics.SetGauge("scriberr_websocket_connections", "Open event WebSocket connections", float64(len(hub.conns)))
+}
+
+// CloseEventSockets closes every event socket and refuses new ones. The HTTP
+// server's shutdown does not close connections taken over by WebSockets, so
+// it is registered to run then.
+func (h *Handler) CloseEventSockets() {
+	h.eventSockets.mu.Lock()
+	h.eventSockets.closing = true
+	conns := make([]*websocket.Conn, 0, len(h.eventSockets.conns))
+	for conn := range h.eventSockets.conns {
+		conns = append(conns, conn)
+	}
+	h.eventSockets.mu.Unlock()
+
+	for _, conn := range conns {
+		conn.Close(websocket.CloseGoingAway, "server is shutting down")
+	}
+}
+
+// EventSocket godoc
+// @Summary Stream queue events over a WebSocket
+// @Description Upgrades to a WebSocket that receives queue-wide events as JSON: job.created, job.status, job.progress, worker.started, worker.stopped and storage.warning. Members receive events for their own jobs, admins for every job; worker and storage events go to everyone. Browsers, which cannot set headers on WebSockets, may pass the token or API key in the token query parameter. The query parameters set the initial filter; sending {"type":"subscribe", ...} with the same fields replaces it, and the server answers with {"type":"subscribed","filter":...}, as it does on connecting. The server pings every 30 seconds and closes sockets that stop answering. When a client falls behind, progress ticks are dropped; if status events still pile up, the socket is closed with code 1013 and the client should reconnect and refetch.
+// @Tags events
+// @Param mine query bool false "Only the caller's jobs"
+// @Param folder_id query int false "Only jobs in this folder"
+// @Param job_id query string false "Only this job"
+// @Param types query string false "Comma-separated event types to receive"
+// @Param token query string false "JWT or API key, for clients that cannot set headers"
+// @Success 101 {object} events.Event
+// @Failure 400 {object} apierror.Envelope
+// @Failure 401 {object} apierror.Envelope
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/ws [get]
+func (h *Handler) EventSocket(c *gin.Context) {
+	filter, err := parseEventFilter(c.Request.URL.Query())
+	if err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+
+	conn, err := websocket.Upgrade(c.Writer, c.Request)
+	if errors.Is(err, websocket.ErrBadHandshake) {
+		apierror.Abort(c, apierror.BadRequest("Expected a WebSocket upgrade request"))
+		return
+	}
+	if err != nil {
+		logger.Warn("Failed to upgrade event socket", "error", err)
+		return
+	}
+
+	viewer := eventViewer{admin: isAdmin(c)}
+	viewer.userID, viewer.hasUser = currentUserID(c)
+	h.serveEventSocket(conn, viewer, filter)
+}
+
+// serveEventSocket sends events to a socket until either side closes it
+func (h *Handler) serveEventSocket(conn *websocket.Conn, viewer eventViewer, filter EventFilter) {
+	if !h.eventSockets.add(conn) {
+		conn.Close(websocket.CloseGoingAway, "server is shutting down")
+		return
+	}
+	defer h.eventSockets.remove(conn)
+
+	const droppedMetric = "scriberr_websocket_dropped_events_total"
+	const droppedHelp = "Progress events dropped for slow WebSocket clients"
+	const slowMetric = "scriberr_websocket_slow_disconnects_total"
+	const slowHelp = "WebSocket clients disconnected for falling behind"
+	metrics.AddCounter(droppedMetric, droppedHelp, 0)
+	metrics.AddCounter(slowMetric, slowHelp, 0)
+
+	var current atomic.Pointer[EventFilter]
+	current.Store(&filter)
+	sub := events.Default.Subscribe(eventSocketBuffer,
+		func(e events.Event) bool { return viewer.canSee(e) && current.Load().matches(e, viewer) },
+		func() { metrics.AddCounter(droppedMetric, droppedHelp, 1) })
+	defer sub.Close()
+
+	if err := writeEventSocketJSON(conn, EventSocketReply{Type: "subscribed", Filter: &filter}); err != nil {
+		conn.Close(websocket.CloseGoingAway, "")
+		return
+	}
+
+	readerDone := make(chan error, 1)
+	go func() { readerDone <- readEventSocket(conn, &current) }()
+
+	ping := time.NewTicker(eventSocketPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-sub.Ready():
+			for _, e := range sub.Events() {
+				if err := writeEventSocketJSON(conn, e); err != nil {
+					conn.Close(websocket.CloseGoingAway, "")
+					return
+				}
+			}
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(eventSocketWriteWait))
+			if err := conn.WriteMessage(websocket.OpPing, nil); err != nil {
+				conn.Close(websocket.CloseGoingAway, "")
+				return
+			}
+		case <-sub.Done():
+			metrics.AddCounter(slowMetric, slowHelp, 1)
+			logger.Warn("Closing event socket that fell behind", "remote", conn.RemoteAddr().String(), "dropped", sub.Dropped())
+			conn.Close(websocket.CloseTryAgainLater, "too slow to keep up with events")
+			return
+		case err := <-readerDone:
+			if !errors.Is(err, websocket.ErrClosed) {
+				logger.Debug("Event socket read ended", "remote", conn.RemoteAddr().String(), "error", err)
+			}
+			conn.Close(websocket.CloseNormal, "")
+			return
+		}
+	}
+}
+
+// readEventSocket reads a socket's messages until it closes, keeping it
+// alive while pongs arrive and replacing its filter on subscribe
+func readEventSocket(conn *websocket.Conn, current *atomic.Pointer[EventFilter]) error {
+	conn.ReadLimit = 4 << 10
+	conn.SetReadDeadline(time.Now().Add(eventSocketPongWait))
+	conn.OnPong = func() { conn.SetReadDeadline(time.Now().Add(eventSocketPongWait)) }
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		conn.SetReadDeadline(time.Now().Add(eventSocketPongWait))
+
+		var message EventSocketMessage
+		reply := EventSocketReply{Type: "error"}
+		switch {
+		case json.Unmarshal(data, &message) != nil:
+			reply.Error = "Messages must be JSON objects"
+		case message.Type != "subscribe":
+			reply.Error = fmt.Sprintf("unknown message type %q, expected subscribe", message.Type)
+		default:
+			if err := message.EventFilter.validate(); err != nil {
+				reply.Error = err.Error()
+				break
+			}
+			filter := message.EventFilter
+			current.Store(&filter)
+			reply = EventSocketReply{Type: "subscribed", Filter: &filter}
+		}
+		if err := writeEventSocketJSON(conn, reply); err != nil {
+			return err
+		}
+	}
+}
+
+// writeEventSocketJSON sends v as a text message
+func writeEventSocketJSON(conn *websocket.Conn, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	conn.SetWriteDeadline(time.Now().Add(eventSocketWriteWait))
+	return conn.WriteMessage(websocket.OpText, data)
+}
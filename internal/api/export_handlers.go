@@ -0,0 +1,171 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/export"
+	"scriberr/internal/models"
+)
+
+// ExportTranscript renders a transcript as a subtitle or text file
+// @Summary Export a transcript
+// @Description Downloads the transcript as SRT or WebVTT subtitles, plain text or JSON. Speaker renames and transcript edits are applied. The response carries an ETag tied to the transcript revision, so edits invalidate cached downloads. Translations start with a note naming the source language, except in SRT, which has no comments. WebVTT exports note where each detected chapter starts.
+// @Tags transcription
+// @Produce plain
+// @Param id path string true "Transcription Job ID"
+// @Param format query string true "Export format (srt, vtt, txt, json)"
+// @Param max_chars query int false "Maximum characters per subtitle line, 0 for no limit" default(42)
+// @Param max_lines query int false "Maximum lines per subtitle cue, 0 for no limit" default(2)
+// @Param speakers query bool false "Prefix text with the speaker name when it changes" default(true)
+// @Param timing query string false "Cue timing: segment or word" default(segment)
+// @Param word_timestamps query bool false "Tag each aligned word with its start time in WebVTT cues, for karaoke-style highlighting" default(false)
+// @Param bom query bool false "Start the file with a UTF-8 byte order mark" default(false)
+// @Success 200 {file} file
+// @Success 304 "Not modified"
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/transcription/{id}/export [get]
+func (h *Handler) ExportTranscript(c *gin.Context) {
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid format. Must be one of srt, vtt, txt, json"))
+		return
+	}
+	opts, err := parseExportOptions(c)
+	if err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+
+	var job models.TranscriptionJob
+	if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", c.Param("id")).First(&job).Error; err != nil {
+		apierror.Abort(c, apierror.JobNotFound())
+		return
+	}
+	if job.Status != models.StatusCompleted || job.Transcript == nil {
+		apierror.Abort(c, apierror.BadRequest("Transcript not available"))
+		return
+	}
+
+	// Exports only change when the transcript or speaker names do, and both
+	// bump the transcript revision
+	etag := fmt.Sprintf(`"%s-%d-%x"`, job.ID, job.TranscriptRevision, sha256.Sum256([]byte(c.Request.URL.RawQuery)))
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	names, err := speakerDisplayNames(job.ID)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to get speaker mappings"))
+		return
+	}
+	segments, err := export.SegmentsFromTranscript([]byte(*job.Transcript), names)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to parse transcript"))
+		return
+	}
+
+	if job.Parameters.Task == models.TaskTranslate {
+		opts.Translation = true
+		opts.SourceLanguage = sourceLanguage(&job)
+	}
+	for _, chapter := range job.Chapters {
+		opts.Chapters = append(opts.Chapters, export.Chapter{Start: chapter.Start, Title: chapter.Title})
+	}
+
+	var buf bytes.Buffer
+	if err := export.Write(&buf, format, segments, opts); err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to export transcript"))
+		return
+	}
+
+	filename := exportFilename(&job) + "." + string(format)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Data(http.StatusOK, format.ContentType(), buf.Bytes())
+}
+
+// parseExportOptions reads the layout options of an export request
+func parseExportOptions(c *gin.Context) (export.Options, error) {
+	opts := export.DefaultOptions()
+
+	var err error
+	if opts.MaxCharsPerLine, err = strconv.Atoi(c.DefaultQuery("max_chars", strconv.Itoa(opts.MaxCharsPerLine))); err != nil || opts.MaxCharsPerLine < 0 {
+		return opts, fmt.Errorf("max_chars must be a non-negative integer")
+	}
+	if opts.MaxLinesPerCue, err = strconv.Atoi(c.DefaultQuery("max_lines", strconv.Itoa(opts.MaxLinesPerCue))); err != nil || opts.MaxLinesPerCue < 0 {
+		return opts, fmt.Errorf("max_lines must be a non-negative integer")
+	}
+	if opts.SpeakerPrefix, err = strconv.ParseBool(c.DefaultQuery("speakers", "true")); err != nil {
+		return opts, fmt.Errorf("speakers must be true or false")
+	}
+	if opts.BOM, err = strconv.ParseBool(c.DefaultQuery("bom", "false")); err != nil {
+		return opts, fmt.Errorf("bom must be true or false")
+	}
+	if opts.WordTimestamps, err = strconv.ParseBool(c.DefaultQuery("word_timestamps", "false")); err != nil {
+		return opts, fmt.Errorf("word_timestamps must be true or false")
+	}
+	switch c.DefaultQuery("timing", "segment") {
+	case "segment":
+	case "word":
+		opts.WordTiming = true
+	default:
+		return opts, fmt.Errorf("timing must be 'segment' or 'word'")
+	}
+	return opts, nil
+}
+
+// sourceLanguage is the language of a job's audio: the one it was submitted
+// with, or else the one the model detected
+func sourceLanguage(job *models.TranscriptionJob) string {
+	if !job.Parameters.DetectsLanguage() {
+		return strings.TrimSpace(*job.Parameters.Language)
+	}
+	if job.DetectedLanguage != nil {
+		return *job.DetectedLanguage
+	}
+	return ""
+}
+
+// exportFilename derives a download name from the job title, usually the name of
+// the uploaded file, falling back to the stored audio file's name
+func exportFilename(job *models.TranscriptionJob) string {
+	name := ""
+	if job.Title != nil {
+		name = *job.Title
+	}
+	if strings.TrimSpace(name) == "" {
+		name = filepath.Base(job.AudioPath)
+	}
+	// Drop a media extension, but not the tail of a title like "Dr. Smith"
+	if ext := filepath.Ext(name); len(ext) > 1 && len(ext) <= 5 && !strings.ContainsRune(ext, ' ') {
+		name = strings.TrimSuffix(name, ext)
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(strings.TrimSpace(name), ".")
+	if name == "" {
+		return "transcript"
+	}
+	return name
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+)
+
+// FolderRequest names a folder
+type FolderRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// FolderResponse is a folder and how many jobs are filed in it
+type FolderResponse struct {
+	models.JobFolder
+	JobCount int64 `json:"job_count"`
+}
+
+// ListFolders returns every job folder
+// @Summary List folders
+// @Description Lists the folders jobs can be filed in, by name, with the number of jobs in each
+// @Tags transcription
+// @Produce json
+// @Success 200 {object} map[string][]FolderResponse
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/folders [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) ListFolders(c *gin.Context) {
+	var folders []models.JobFolder
+	if err := database.DB.Order("name").Find(&folders).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to list folders"))
+		return
+	}
+	var counts []struct {
+		FolderID uint
+		Count    int64
+	}
+	if err := database.DB.Model(&models.TranscriptionJob{}).Scopes(ownedJobs(c)).Select("folder_id, COUNT(*) AS count").
+		Where("folder_id IS NOT NULL").Group("folder_id").Scan(&counts).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to list folders"))
+		return
+	}
+	jobCounts := make(map[uint]int64, len(counts))
+	for _, count := range counts {
+		jobCounts[count.FolderID] = count.Count
+	}
+
+	response := make([]FolderResponse, len(folders))
+	for i, folder := range folders {
+		response[i] = FolderResponse{JobFolder: folder, JobCount: jobCounts[folder.ID]}
+	}
+	c.JSON(http.StatusOK, gin.H{"folders": response})
+}
+
+// CreateFolder adds a job folder
+// @Summary Create a folder
+// @Description Creates a folder to file jobs in. Names are unique.
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param request body FolderRequest true "Folder"
+// @Success 201 {object} models.JobFolder
+// @Failure 400 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope
+// @Router /api/v1/folders [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) CreateFolder(c *gin.Context) {
+	name, ok := bindFolderName(c, 0)
+	if !ok {
+		return
+	}
+	folder := models.JobFolder{Name: name}
+	if err := database.DB.Create(&folder).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to create folder"))
+		return
+	}
+	c.JSON(http.StatusCreated, folder)
+}
+
+// RenameFolder changes a folder's name
+// @Summary Rename a folder
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param id path int true "Folder ID"
+// @Param request body FolderRequest true "New name"
+// @Success 200 {object} models.JobFolder
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope
+// @Router /api/v1/folders/{id} [patch]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) RenameFolder(c *gin.Context) {
+	folder, ok := loadFolder(c)
+	if !ok {
+		return
+	}
+	name, ok := bindFolderName(c, folder.ID)
+	if !ok {
+		return
+	}
+	folder.Name = name
+	if err := database.DB.Save(folder).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to rename folder"))
+		return
+	}
+	c.JSON(http.StatusOK, folder)
+}
+
+// DeleteFolder removes a folder, leaving its jobs in no folder
+// @Summary Delete a folder
+// @Description Deletes a folder. Its jobs are kept and no longer filed in any folder, and watch folders stop filing new jobs in it.
+// @Tags transcription
+// @Param id path int true "Folder ID"
+// @Success 204
+// @Failure 404 {object} apierror.Envelope
+// @Router /api/v1/folders/{id} [delete]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) DeleteFolder(c *gin.Context) {
+	folder, ok := loadFolder(c)
+	if !ok {
+		return
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Deleted jobs are unfiled too, so restoring one does not refer to
+		// the removed folder
+		if err := tx.Unscoped().Model(&models.TranscriptionJob{}).Where("folder_id = ?", folder.ID).Update("folder_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.WatchFolder{}).Where("job_folder_id = ?", folder.ID).Update("job_folder_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(folder).Error
+	})
+	if err != nil {
+		logger.Error("Failed to delete folder", "folder_id", folder.ID, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to delete folder"))
+		return
+	}
+	if err := h.watchFolders.Reload(); err != nil {
+		logger.Error("Failed to reload watch folders", "error", err)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// bindFolderName reads and validates a folder name, writing an error
+// response and returning false if it is invalid or used by another folder
+func bindFolderName(c *gin.Context, exceptID uint) (string, bool) {
+	var req FolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return "", false
+	}
+	name, err := models.ValidateFolderName(req.Name)
+	if err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return "", false
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.JobFolder{}).Where("name = ? AND id <> ?", name, exceptID).Count(&count).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to check folder name"))
+		return "", false
+	}
+	if count > 0 {
+		apierror.Abort(c, apierror.Conflict("A folder with this name already exists"))
+		return "", false
+	}
+	return name, true
+}
+
+// loadFolder fetches the folder named by the :id path parameter, writing an
+// error response and returning false if it does not exist
+func loadFolder(c *gin.Context) (*models.JobFolder, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid folder ID"))
+		return nil, false
+	}
+	var folder models.JobFolder
+	if err := database.DB.First(&folder, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.NotFound("Folder not found"))
+			return nil, false
+		}
+		apierror.Abort(c, apierror.Internal("Failed to get folder"))
+		return nil, false
+	}
+	return &folder, true
+}
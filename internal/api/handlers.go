@@ -6,24 +6,40 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
+	"scriberr/internal/api/apierror"
 	"scriberr/internal/auth"
 	"scriberr/internal/config"
 	"scriberr/internal/database"
+	"scriberr/internal/diskspace"
+	"scriberr/internal/estimate"
+	"scriberr/internal/ingest"
+	"scriberr/internal/metrics"
 	"scriberr/internal/models"
 	"scriberr/internal/processing"
 	"scriberr/internal/queue"
+	"scriberr/internal/ratelimit"
+	"scriberr/internal/storage"
 	"scriberr/internal/transcription"
+	"scriberr/internal/transcription/postprocess"
+	"scriberr/internal/transcription/registry"
+	"scriberr/internal/watchfolder"
 	"scriberr/pkg/logger"
+	"scriberr/pkg/middleware"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -34,24 +50,72 @@ import (
 type Handler struct {
 	config              *config.Config
 	authService         *auth.AuthService
+	oidc                *auth.OIDCService
 	taskQueue           *queue.TaskQueue
 	unifiedProcessor    *transcription.UnifiedJobProcessor
 	quickTranscription  *transcription.QuickTranscriptionService
 	multiTrackProcessor *processing.MultiTrackProcessor
+	downloader          *ingest.Downloader
+	modelManager        *transcription.ModelManager
+	whisperxEnv         *transcription.EnvironmentManager
+	watchFolders        *watchfolder.Service
+	estimator           *estimate.Estimator
+	diskUsage           *diskspace.Monitor
+	spaceGuard          *diskspace.Guard
+	shareLimiter        *ratelimit.Limiter
+	loginIPLimiter      *ratelimit.Bucket
+	loginUserLimiter    *ratelimit.Bucket
+	loginLockout        auth.LockoutPolicy
+	passwordLimiter     *ratelimit.Bucket
+	rateLimiter         *apiRateLimiter
 	environment         config.Environment
+	setupDone           atomic.Bool // First-run setup has finished
+	readiness           readinessCache
+	eventSockets        eventSocketHub
+	liveStreams         liveStreamHub
 }
 
 // NewHandler creates a new handler
 func NewHandler(cfg *config.Config, authService *auth.AuthService, taskQueue *queue.TaskQueue, unifiedProcessor *transcription.UnifiedJobProcessor, quickTranscription *transcription.QuickTranscriptionService) *Handler {
-	return &Handler{
+	h := &Handler{
 		config:              cfg,
 		authService:         authService,
+		oidc:                auth.NewOIDCService(cfg.OIDC),
 		taskQueue:           taskQueue,
 		unifiedProcessor:    unifiedProcessor,
 		quickTranscription:  quickTranscription,
 		multiTrackProcessor: processing.NewMultiTrackProcessor(),
+		downloader:          ingest.NewDownloader(cfg.YtDlpPath),
+		modelManager:        transcription.NewModelManager(cfg.UVPath, whisperXProjectPath()),
+		whisperxEnv:         transcription.NewEnvironmentManager(cfg.UVPath, whisperXProjectPath(), cfg.Environment),
+		estimator:           newEstimator(cfg.EstimateFactorsPath),
 		environment:         cfg.Environment,
 	}
+	// Models downloaded through the API land where WhisperX loads them from
+	if cfg.ModelCacheDir != "" {
+		h.modelManager.CacheDir = cfg.ModelCacheDir
+	}
+	if unifiedProcessor != nil {
+		unifiedProcessor.GetUnifiedService().SetModelManager(h.modelManager)
+		unifiedProcessor.GetUnifiedService().SetModelCacheDir(cfg.ModelCacheDir)
+		unifiedProcessor.GetUnifiedService().SetOutputDirectory(cfg.OutputDir, cfg.KeepOutputFiles)
+		unifiedProcessor.GetUnifiedService().SetHuggingFaceToken(cfg.HuggingFaceToken)
+		unifiedProcessor.GetUnifiedService().SetLanguageConfidenceThreshold(cfg.LanguageConfidenceThreshold)
+		unifiedProcessor.GetUnifiedService().SetLongAudioChunking(cfg.LongAudioThreshold, cfg.LongAudioChunkLength)
+	}
+	if taskQueue != nil {
+		h.whisperxEnv.HoldJobs = taskQueue.Hold
+	}
+	h.watchFolders = watchfolder.NewService(cfg, taskQueue, h.defaultTranscriptionParams)
+	h.diskUsage = diskspace.NewMonitor(h.storageCategories())
+	h.spaceGuard = diskspace.NewGuard(cfg.UploadDir, uint64(cfg.MinFreeSpaceMB)<<20)
+	h.shareLimiter = ratelimit.New(cfg.ShareRateLimit, time.Minute)
+	h.loginIPLimiter = ratelimit.NewBucket(cfg.LoginRateLimit, time.Minute)
+	h.loginUserLimiter = ratelimit.NewBucket(cfg.LoginRateLimit, time.Minute)
+	h.loginLockout = auth.LockoutPolicy{Threshold: cfg.LoginLockoutThreshold, Duration: cfg.LoginLockoutDuration}
+	h.passwordLimiter = ratelimit.NewBucket(cfg.LoginRateLimit, time.Minute)
+	h.rateLimiter = newAPIRateLimiter(cfg)
+	return h
 }
 
 // SubmitJobRequest represents the submit job request
@@ -73,13 +137,14 @@ type LoginResponse struct {
 	User  struct {
 		ID       uint   `json:"id"`
 		Username string `json:"username"`
+		Role     string `json:"role"`
 	} `json:"user"`
 }
 
 // RegisterRequest represents the registration request
 type RegisterRequest struct {
 	Username        string `json:"username" binding:"required,min=3,max=50"`
-	Password        string `json:"password" binding:"required,min=6"`
+	Password        string `json:"password" binding:"required"` // Must meet the password policy
 	ConfirmPassword string `json:"confirmPassword" binding:"required"`
 }
 
@@ -87,6 +152,10 @@ type RegisterRequest struct {
 type RegistrationStatusResponse struct {
 	// Match tests expecting snake_case key
 	RegistrationEnabled bool `json:"registration_enabled"`
+	OIDCEnabled         bool `json:"oidc_enabled"`
+	// Users are logged in by an authenticating proxy instead of the login page
+	HeaderAuthEnabled bool   `json:"header_auth_enabled"`
+	LogoutURL         string `json:"logout_url,omitempty"`
 }
 
 // ChangePasswordRequest represents the change password request
@@ -104,16 +173,21 @@ type ChangeUsernameRequest struct {
 
 // CreateAPIKeyRequest represents the create API key request
 type CreateAPIKeyRequest struct {
-	Name        string `json:"name" binding:"required,min=1,max=100"`
-	Description string `json:"description,omitempty"`
+	Name          string   `json:"name" binding:"required,min=1,max=100"`
+	Description   string   `json:"description,omitempty"`
+	Scopes        []string `json:"scopes,omitempty" binding:"omitempty,dive,oneof=read write admin"` // Defaults to read and write
+	ExpiresInDays int      `json:"expires_in_days,omitempty" binding:"omitempty,min=1"`
 }
 
-// CreateAPIKeyResponse represents the create API key response
+// CreateAPIKeyResponse represents the create API key response. Key is only
+// ever returned here; the server keeps just its hash.
 type CreateAPIKeyResponse struct {
-	ID          uint   `json:"id"`
-	Key         string `json:"key"`
-	Name        string `json:"name"`
-	Description string `json:"description,omitempty"`
+	ID          uint     `json:"id"`
+	Key         string   `json:"key"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Scopes      []string `json:"scopes"`
+	ExpiresAt   string   `json:"expires_at,omitempty"`
 }
 
 // YouTubeDownloadRequest represents the YouTube download request
@@ -152,14 +226,16 @@ type LLMConfigResponse struct {
 
 // APIKeyListResponse represents an API key in the list (without the actual key)
 type APIKeyListResponse struct {
-	ID          uint   `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description,omitempty"`
-	KeyPreview  string `json:"key_preview"`
-	IsActive    bool   `json:"is_active"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
-	LastUsed    string `json:"last_used,omitempty"`
+	ID          uint     `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	KeyPreview  string   `json:"key_preview"`
+	Scopes      []string `json:"scopes"`
+	IsActive    bool     `json:"is_active"`
+	ExpiresAt   string   `json:"expires_at,omitempty"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+	LastUsed    string   `json:"last_used,omitempty"`
 }
 
 // APIKeysWrapper wraps the API keys list response
@@ -170,10 +246,8 @@ type APIKeysWrapper struct {
 // transformAPIKeyForList converts a models.APIKey to APIKeyListResponse
 func transformAPIKeyForList(apiKey models.APIKey) APIKeyListResponse {
 	keyPreview := ""
-	if len(apiKey.Key) > 8 {
-		keyPreview = apiKey.Key[:8] + "..."
-	} else if apiKey.Key != "" {
-		keyPreview = apiKey.Key + "..."
+	if apiKey.KeyPrefix != "" {
+		keyPreview = apiKey.KeyPrefix + "..."
 	}
 
 	lastUsed := ""
@@ -186,12 +260,19 @@ func transformAPIKeyForList(apiKey models.APIKey) APIKeyListResponse {
 		description = *apiKey.Description
 	}
 
+	expiresAt := ""
+	if apiKey.ExpiresAt != nil {
+		expiresAt = apiKey.ExpiresAt.Format(time.RFC3339)
+	}
+
 	return APIKeyListResponse{
 		ID:          apiKey.ID,
 		Name:        apiKey.Name,
 		Description: description,
 		KeyPreview:  keyPreview,
+		Scopes:      apiKey.ScopeList(),
 		IsActive:    apiKey.IsActive,
+		ExpiresAt:   expiresAt,
 		CreatedAt:   apiKey.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:   apiKey.UpdatedAt.Format(time.RFC3339),
 		LastUsed:    lastUsed,
@@ -204,10 +285,15 @@ func transformAPIKeyForList(apiKey models.APIKey) APIKeyListResponse {
 // @Accept multipart/form-data
 // @Produce json
 // @Param audio formData file true "Audio file"
-// @Param title formData string false "Job title"
+// @Param title formData string false "Job title; defaults to the uploaded file's name"
+// @Param description formData string false "Job description, up to 5000 characters"
+// @Param metadata formData string false "JSON object of string key/value pairs, up to 50 entries"
 // @Success 200 {object} models.TranscriptionJob
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 413 {object} apierror.Envelope "Body is larger than MAX_UPLOAD_SIZE_MB"
+// @Failure 415 {object} apierror.Envelope "Not an accepted audio or video type"
+// @Failure 422 {object} apierror.Envelope "File is not transcribable audio, or title, description or metadata is too long"
+// @Failure 500 {object} apierror.Envelope
 // @Router /api/v1/transcription/upload [post]
 // @Security ApiKeyAuth
 // @Security BearerAuth
@@ -215,7 +301,7 @@ func (h *Handler) UploadAudio(c *gin.Context) {
 	// Parse multipart form
 	file, header, err := c.Request.FormFile("audio")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Audio file is required"})
+		apierror.Abort(c, apierror.Validation("Audio file is required"))
 		return
 	}
 	defer file.Close()
@@ -223,7 +309,7 @@ func (h *Handler) UploadAudio(c *gin.Context) {
 	// Create upload directory
 	uploadDir := h.config.UploadDir
 	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload directory"})
+		apierror.Abort(c, apierror.Internal("Failed to create upload directory"))
 		return
 	}
 
@@ -236,31 +322,54 @@ func (h *Handler) UploadAudio(c *gin.Context) {
 	// Save file
 	dst, err := os.Create(filePath)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		apierror.Abort(c, apierror.Internal("Failed to save file"))
 		return
 	}
 	defer dst.Close()
 
 	if _, err = io.Copy(dst, file); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		apierror.Abort(c, apierror.Internal("Failed to save file"))
+		return
+	}
+
+	h.createUploadedJob(c, jobID, filePath, c.PostForm("title"), header.Filename)
+}
+
+// createUploadedJob validates a saved upload and creates its job, queueing it
+// when the user has auto-transcription enabled, then writes the response.
+// An empty title defaults to the uploaded file's name. The file is removed if
+// the job cannot be created.
+func (h *Handler) createUploadedJob(c *gin.Context, jobID, filePath, title, filename string) {
+	if !h.validateUpload(c, filePath) {
+		os.Remove(filePath) // Clean up file
+		return
+	}
+	details, ok := formJobDetails(c, title, filename)
+	if !ok {
+		os.Remove(filePath)
 		return
 	}
 
 	// Create job record with "uploaded" status (not queued for transcription)
 	job := models.TranscriptionJob{
 		ID:        jobID,
+		UserID:    jobOwner(c),
 		AudioPath: filePath,
 		Status:    models.StatusUploaded, // New status for uploaded but not transcribed
 	}
+	details.apply(&job)
+	job.PhaseTimings = uploadTimings(c)
 
-	if title := c.PostForm("title"); title != "" {
-		job.Title = &title
-	}
+	h.detectAudioInfo(c, &job)
 
 	// Save to database
 	if err := database.DB.Create(&job).Error; err != nil {
 		os.Remove(filePath) // Clean up file
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create job"})
+		apierror.Abort(c, apierror.Internal("Failed to create job"))
+		return
+	}
+
+	if !h.normalizeUpload(c, &job) || !h.storeUpload(c, &job) {
 		return
 	}
 
@@ -273,23 +382,29 @@ func (h *Handler) UploadAudio(c *gin.Context) {
 			var profileFound bool
 
 			if user.DefaultProfileID != nil {
-				err = database.DB.Where("id = ?", *user.DefaultProfileID).First(&profile).Error
+				err = visibleProfiles(c).Where("id = ?", *user.DefaultProfileID).First(&profile).Error
 				profileFound = (err == nil)
 			}
 
-			// If no user default or user default not found, try to find a system default
+			// If no user default or user default not found, try their own default profile, then the shared one
 			if !profileFound {
-				err = database.DB.Where("is_default = ?", true).First(&profile).Error
+				err = visibleProfiles(c).Where("is_default = ?", true).Order("user_id IS NULL").First(&profile).Error
 				profileFound = (err == nil)
 			}
 
 			// If still no profile found, use the first available profile
 			if !profileFound {
-				err = database.DB.Order("created_at ASC").First(&profile).Error
+				err = visibleProfiles(c).Order("created_at ASC").First(&profile).Error
 				profileFound = (err == nil)
 			}
 
-			// If we found a profile, update the job and queue it
+			// If we found a profile and the user has quota left, update the job and queue it
+			if profileFound {
+				if exceeded, err := claimJobQuota(c, &job); err != nil || exceeded != nil {
+					logger.Info("Skipping auto-transcription", "job_id", jobID, "quota_exceeded", exceeded != nil, "error", err)
+					profileFound = false
+				}
+			}
 			if profileFound {
 				job.Parameters = profile.Parameters
 				job.Diarization = profile.Parameters.Diarize
@@ -311,16 +426,153 @@ func (h *Handler) UploadAudio(c *gin.Context) {
 	c.JSON(http.StatusOK, job)
 }
 
+// validateUpload checks that an uploaded file is an accepted audio or video
+// type by its first bytes, writing a 415 response if not, and that it can be
+// transcribed, writing a 422 response with the reason if not. It returns false
+// when a response was written. Files of an accepted type are taken unchecked
+// when ffprobe is not installed.
+func (h *Handler) validateUpload(c *gin.Context, path string) bool {
+	err := transcription.CheckMediaFile(path)
+	if err == nil {
+		err = transcription.ValidateAudioFile(c.Request.Context(), path)
+	}
+	if err == nil {
+		return true
+	}
+	var validationErr *transcription.ValidationError
+	if errors.As(err, &validationErr) && validationErr.Code == transcription.ValidationUnsupportedType {
+		apierror.Abort(c, apierror.UnsupportedMedia(validationErr.Message))
+		return false
+	}
+	if errors.As(err, &validationErr) {
+		apierror.Abort(c, apierror.New(http.StatusUnprocessableEntity, apierror.CodeInvalidAudio, validationErr.Message).WithDetails(map[string]any{"reason": validationErr.Code}))
+		return false
+	}
+	if errors.Is(err, transcription.ErrFFprobeUnavailable) {
+		logger.Warn("Skipping audio validation, ffprobe is not installed", "file", path)
+		return true
+	}
+	apierror.Abort(c, apierror.Internal("Failed to validate audio file"))
+	return false
+}
+
+// detectAudioInfo records the length, format, codec, sample rate and channel
+// count of a newly uploaded file on its job. A failed probe is not fatal; the
+// file is probed again when needed.
+func (h *Handler) detectAudioInfo(c *gin.Context, job *models.TranscriptionJob) {
+	info, err := transcription.ProbeAudio(c.Request.Context(), job.AudioPath)
+	if err != nil {
+		logger.Warn("Failed to probe audio", "job_id", job.ID, "error", err)
+		return
+	}
+	transcription.ApplyAudioInfo(job, info)
+}
+
+// Error codes for uploads that were saved but could not be prepared
+const (
+	codeTranscodeFailed = "transcode_failed" // ffmpeg could not convert the file
+	codeStorageFailed   = "storage_failed"   // The storage backend rejected the file
+)
+
+// normalizeUpload converts a newly created job's audio to 16 kHz mono WAV if
+// the pipeline cannot read it directly, unless conversion is deferred until
+// the job runs. A failed conversion marks the job failed and writes a 422
+// response.
+func (h *Handler) normalizeUpload(c *gin.Context, job *models.TranscriptionJob) bool {
+	// Audio in object storage cannot be converted when the job runs
+	if !h.config.NormalizeOnUpload && storage.Local() {
+		return true
+	}
+	if err := normalizeJob(c.Request.Context(), job); err != nil {
+		apierror.Abort(c, apierror.New(http.StatusUnprocessableEntity, apierror.CodeTranscodeFailed, err.Error()).WithDetails(map[string]any{"job_id": job.ID}))
+		return false
+	}
+	return true
+}
+
+// normalizeJob converts a job's audio, marking the job failed with the
+// conversion error if it cannot be converted
+func normalizeJob(ctx context.Context, job *models.TranscriptionJob) error {
+	err := transcription.NormalizeJobAudio(ctx, job)
+	if err == nil {
+		return nil
+	}
+	message := err.Error()
+	job.Status = models.StatusFailed
+	job.ErrorMessage = &message
+	if dbErr := database.DB.Model(&models.TranscriptionJob{}).Where("id = ?", job.ID).
+		Updates(map[string]interface{}{"status": job.Status, "error_message": message}).Error; dbErr != nil {
+		logger.Error("Failed to mark job failed", "job_id", job.ID, "error", dbErr)
+	}
+	return err
+}
+
+// storeUpload moves a newly created job's audio into the configured storage
+// backend and queues its waveform. A failure marks the job failed and writes
+// a 500 response.
+func (h *Handler) storeUpload(c *gin.Context, job *models.TranscriptionJob) bool {
+	if err := storeJob(c.Request.Context(), job); err != nil {
+		apierror.Abort(c, apierror.New(http.StatusInternalServerError, apierror.CodeStorageFailed, "Failed to store audio").WithCause(err).WithDetails(map[string]any{"job_id": job.ID}))
+		return false
+	}
+	h.queueWaveform(job.ID)
+	return true
+}
+
+// storeJob moves a job's audio from the upload directory into the configured
+// storage backend and records its new location, size and hash, marking the
+// job failed if it cannot. Audio stays on disk with local storage.
+func storeJob(ctx context.Context, job *models.TranscriptionJob) error {
+	size := storage.Size(ctx, job.AudioPath)
+	updates := map[string]interface{}{"file_size": size}
+	if hash, err := storage.Hash(ctx, job.AudioPath); err == nil {
+		updates["file_hash"] = hash
+		job.FileHash = &hash
+	} else {
+		logger.Warn("Failed to hash job audio", "job_id", job.ID, "error", err)
+	}
+	location, err := storage.Store(ctx, job.AudioPath, storage.JobKey(job.ID, job.AudioPath))
+	if err == nil {
+		updates["audio_path"] = location
+		err = database.DB.Model(&models.TranscriptionJob{}).Where("id = ?", job.ID).Updates(updates).Error
+		if err != nil && location != job.AudioPath {
+			storage.Remove(ctx, location)
+		}
+		job.FileSize = size
+	}
+	if err != nil {
+		logger.Error("Failed to store job audio", "job_id", job.ID, "error", err)
+		message := "failed to store audio: " + err.Error()
+		job.Status = models.StatusFailed
+		job.ErrorMessage = &message
+		if dbErr := database.DB.Model(&models.TranscriptionJob{}).Where("id = ?", job.ID).
+			Updates(map[string]interface{}{"status": job.Status, "error_message": message}).Error; dbErr != nil {
+			logger.Error("Failed to mark job failed", "job_id", job.ID, "error", dbErr)
+		}
+		return err
+	}
+	if location != job.AudioPath {
+		os.Remove(job.AudioPath)
+		job.AudioPath = location
+	}
+	return nil
+}
+
 // @Summary Upload video file for transcription
 // @Description Upload a video file, extract audio from it using ffmpeg, and create a transcription job
 // @Tags transcription
 // @Accept multipart/form-data
 // @Produce json
 // @Param video formData file true "Video file"
-// @Param title formData string false "Job title"
+// @Param title formData string false "Job title; defaults to the uploaded file's name"
+// @Param description formData string false "Job description, up to 5000 characters"
+// @Param metadata formData string false "JSON object of string key/value pairs, up to 50 entries"
 // @Success 200 {object} models.TranscriptionJob
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 413 {object} apierror.Envelope "Body is larger than MAX_UPLOAD_SIZE_MB"
+// @Failure 415 {object} apierror.Envelope "Not an accepted audio or video type"
+// @Failure 422 {object} apierror.Envelope "File is not transcribable audio, or title, description or metadata is too long"
+// @Failure 500 {object} apierror.Envelope
 // @Router /api/v1/transcription/upload-video [post]
 // @Security ApiKeyAuth
 // @Security BearerAuth
@@ -328,15 +580,19 @@ func (h *Handler) UploadVideo(c *gin.Context) {
 	// Parse multipart form
 	file, header, err := c.Request.FormFile("video")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Video file is required"})
+		apierror.Abort(c, apierror.Validation("Video file is required"))
 		return
 	}
 	defer file.Close()
+	details, ok := formJobDetails(c, c.PostForm("title"), header.Filename)
+	if !ok {
+		return
+	}
 
 	// Create upload directory
 	uploadDir := h.config.UploadDir
 	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload directory"})
+		apierror.Abort(c, apierror.Internal("Failed to create upload directory"))
 		return
 	}
 
@@ -349,7 +605,7 @@ func (h *Handler) UploadVideo(c *gin.Context) {
 	// Save temporary video file
 	dst, err := os.Create(tempVideoPath)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save video file"})
+		apierror.Abort(c, apierror.Internal("Failed to save video file"))
 		return
 	}
 	defer func() {
@@ -359,11 +615,15 @@ func (h *Handler) UploadVideo(c *gin.Context) {
 	}()
 
 	if _, err = io.Copy(dst, file); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save video file"})
+		apierror.Abort(c, apierror.Internal("Failed to save video file"))
 		return
 	}
 	dst.Close() // Close before ffmpeg processing
 
+	if !h.validateUpload(c, tempVideoPath) {
+		return
+	}
+
 	// Generate audio filename
 	audioFilename := fmt.Sprintf("%s.mp3", jobID)
 	audioPath := filepath.Join(uploadDir, audioFilename)
@@ -381,27 +641,30 @@ func (h *Handler) UploadVideo(c *gin.Context) {
 	if output, err := cmd.CombinedOutput(); err != nil {
 		// Clean up audio file if created
 		os.Remove(audioPath)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("Failed to extract audio from video: %v - %s", err, string(output)),
-		})
+		apierror.Abort(c, apierror.Internal("Failed to extract audio from video").WithCause(fmt.Errorf("%w: %s", err, output)))
 		return
 	}
 
 	// Create job record with "uploaded" status (not queued for transcription)
 	job := models.TranscriptionJob{
 		ID:        jobID,
+		UserID:    jobOwner(c),
 		AudioPath: audioPath,
 		Status:    models.StatusUploaded, // Same status as audio uploads
 	}
+	details.apply(&job)
+	job.PhaseTimings = uploadTimings(c)
 
-	if title := c.PostForm("title"); title != "" {
-		job.Title = &title
-	}
+	h.detectAudioInfo(c, &job)
 
 	// Save to database
 	if err := database.DB.Create(&job).Error; err != nil {
 		os.Remove(audioPath) // Clean up audio file
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create job"})
+		apierror.Abort(c, apierror.Internal("Failed to create job"))
+		return
+	}
+
+	if !h.storeUpload(c, &job) {
 		return
 	}
 
@@ -414,23 +677,29 @@ func (h *Handler) UploadVideo(c *gin.Context) {
 			var profileFound bool
 
 			if user.DefaultProfileID != nil {
-				err = database.DB.Where("id = ?", *user.DefaultProfileID).First(&profile).Error
+				err = visibleProfiles(c).Where("id = ?", *user.DefaultProfileID).First(&profile).Error
 				profileFound = (err == nil)
 			}
 
-			// If no user default or user default not found, try to find a system default
+			// If no user default or user default not found, try their own default profile, then the shared one
 			if !profileFound {
-				err = database.DB.Where("is_default = ?", true).First(&profile).Error
+				err = visibleProfiles(c).Where("is_default = ?", true).Order("user_id IS NULL").First(&profile).Error
 				profileFound = (err == nil)
 			}
 
 			// If still no profile found, use the first available profile
 			if !profileFound {
-				err = database.DB.Order("created_at ASC").First(&profile).Error
+				err = visibleProfiles(c).Order("created_at ASC").First(&profile).Error
 				profileFound = (err == nil)
 			}
 
-			// If we found a profile, update the job and queue it
+			// If we found a profile and the user has quota left, update the job and queue it
+			if profileFound {
+				if exceeded, err := claimJobQuota(c, &job); err != nil || exceeded != nil {
+					logger.Info("Skipping auto-transcription", "job_id", jobID, "quota_exceeded", exceeded != nil, "error", err)
+					profileFound = false
+				}
+			}
 			if profileFound {
 				job.Parameters = profile.Parameters
 				job.Diarization = profile.Parameters.Diarize
@@ -461,8 +730,11 @@ func (h *Handler) UploadVideo(c *gin.Context) {
 // @Param aup formData file true ".aup Audacity project file"
 // @Param tracks formData file true "Audio track files" multiple
 // @Success 200 {object} models.TranscriptionJob
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 413 {object} apierror.Envelope "Body is larger than MAX_UPLOAD_SIZE_MB"
+// @Failure 415 {object} apierror.Envelope "Not an accepted audio or video type"
+// @Failure 422 {object} apierror.Envelope "File is not transcribable audio"
+// @Failure 500 {object} apierror.Envelope
 // @Router /api/v1/transcription/upload-multitrack [post]
 // @Security ApiKeyAuth
 // @Security BearerAuth
@@ -470,34 +742,34 @@ func (h *Handler) UploadMultiTrack(c *gin.Context) {
 	// Check for required title
 	title := c.PostForm("title")
 	if title == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Title is required for multi-track uploads"})
+		apierror.Abort(c, apierror.Validation("Title is required for multi-track uploads"))
 		return
 	}
 
 	// Parse multipart form for .aup file
 	aupFile, aupHeader, err := c.Request.FormFile("aup")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": ".aup Audacity project file is required"})
+		apierror.Abort(c, apierror.Validation(".aup Audacity project file is required"))
 		return
 	}
 	defer aupFile.Close()
 
 	// Validate .aup file extension
 	if !strings.HasSuffix(strings.ToLower(aupHeader.Filename), ".aup") {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Project file must have .aup extension"})
+		apierror.Abort(c, apierror.Validation("Project file must have .aup extension"))
 		return
 	}
 
 	// Parse multipart form for audio tracks
 	form, err := c.MultipartForm()
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse multipart form"})
+		apierror.Abort(c, apierror.BadRequest("Failed to parse multipart form"))
 		return
 	}
 
 	tracks := form.File["tracks"]
 	if len(tracks) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one audio track is required"})
+		apierror.Abort(c, apierror.Validation("At least one audio track is required"))
 		return
 	}
 
@@ -510,7 +782,7 @@ func (h *Handler) UploadMultiTrack(c *gin.Context) {
 	tracksFolder := filepath.Join(multiTrackFolder, "tracks")
 
 	if err := os.MkdirAll(tracksFolder, 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload directory"})
+		apierror.Abort(c, apierror.Internal("Failed to create upload directory"))
 		return
 	}
 
@@ -519,14 +791,14 @@ func (h *Handler) UploadMultiTrack(c *gin.Context) {
 	aupDst, err := os.Create(aupFilePath)
 	if err != nil {
 		os.RemoveAll(multiTrackFolder) // Clean up on error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save .aup file"})
+		apierror.Abort(c, apierror.Internal("Failed to save .aup file"))
 		return
 	}
 	defer aupDst.Close()
 
 	if _, err = io.Copy(aupDst, aupFile); err != nil {
 		os.RemoveAll(multiTrackFolder) // Clean up on error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save .aup file"})
+		apierror.Abort(c, apierror.Internal("Failed to save .aup file"))
 		return
 	}
 	aupDst.Close()
@@ -540,7 +812,7 @@ func (h *Handler) UploadMultiTrack(c *gin.Context) {
 		trackFile, err := trackFileHeader.Open()
 		if err != nil {
 			os.RemoveAll(multiTrackFolder) // Clean up on error
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to open track file: %s", trackFileHeader.Filename)})
+			apierror.Abort(c, apierror.Internal(fmt.Sprintf("Failed to open track file: %s", trackFileHeader.Filename)))
 			return
 		}
 		defer trackFile.Close()
@@ -549,7 +821,7 @@ func (h *Handler) UploadMultiTrack(c *gin.Context) {
 		if !strings.HasPrefix(trackFileHeader.Header.Get("Content-Type"), "audio/") {
 			trackFile.Close()
 			os.RemoveAll(multiTrackFolder) // Clean up on error
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("File %s is not an audio file", trackFileHeader.Filename)})
+			apierror.Abort(c, apierror.BadRequest(fmt.Sprintf("File %s is not an audio file", trackFileHeader.Filename)))
 			return
 		}
 
@@ -559,7 +831,7 @@ func (h *Handler) UploadMultiTrack(c *gin.Context) {
 		if err != nil {
 			trackFile.Close()
 			os.RemoveAll(multiTrackFolder) // Clean up on error
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to save track file: %s", trackFileHeader.Filename)})
+			apierror.Abort(c, apierror.Internal(fmt.Sprintf("Failed to save track file: %s", trackFileHeader.Filename)))
 			return
 		}
 
@@ -567,13 +839,18 @@ func (h *Handler) UploadMultiTrack(c *gin.Context) {
 			trackDst.Close()
 			trackFile.Close()
 			os.RemoveAll(multiTrackFolder) // Clean up on error
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to save track file: %s", trackFileHeader.Filename)})
+			apierror.Abort(c, apierror.Internal(fmt.Sprintf("Failed to save track file: %s", trackFileHeader.Filename)))
 			return
 		}
 
 		trackDst.Close()
 		trackFile.Close()
 
+		if !h.validateUpload(c, trackPath) {
+			os.RemoveAll(multiTrackFolder) // Clean up on error
+			return
+		}
+
 		// Store first track path for main audio_path field (for backward compatibility)
 		if i == 0 {
 			firstTrackPath = trackPath
@@ -592,22 +869,31 @@ func (h *Handler) UploadMultiTrack(c *gin.Context) {
 		})
 	}
 
+	// The job's file size covers every track
+	trackPaths := make([]string, len(multiTrackFiles))
+	for i, track := range multiTrackFiles {
+		trackPaths[i] = track.FilePath
+	}
+
 	// Create transcription job record
 	job := models.TranscriptionJob{
 		ID:               jobID,
+		UserID:           jobOwner(c),
 		Title:            &title,
 		AudioPath:        firstTrackPath, // Point to first track initially
+		FileSize:         storage.Size(c.Request.Context(), trackPaths...),
 		Status:           models.StatusUploaded,
 		IsMultiTrack:     true,
 		AupFilePath:      &aupFilePath,
 		MultiTrackFolder: &multiTrackFolder,
 		MergeStatus:      "none", // No merge processing yet
+		PhaseTimings:     uploadTimings(c),
 	}
 
 	// Save job to database
 	if err := database.DB.Create(&job).Error; err != nil {
 		os.RemoveAll(multiTrackFolder) // Clean up on error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create job"})
+		apierror.Abort(c, apierror.Internal("Failed to create job"))
 		return
 	}
 
@@ -617,14 +903,14 @@ func (h *Handler) UploadMultiTrack(c *gin.Context) {
 			// Clean up job and files on error
 			database.DB.Delete(&job)
 			os.RemoveAll(multiTrackFolder)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create track file records"})
+			apierror.Abort(c, apierror.Internal("Failed to create track file records"))
 			return
 		}
 	}
 
 	// Load the complete job with multi-track files for response
 	if err := database.DB.Preload("MultiTrackFiles").First(&job, "id = ?", jobID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load complete job"})
+		apierror.Abort(c, apierror.Internal("Failed to load complete job"))
 		return
 	}
 
@@ -647,16 +933,20 @@ func (h *Handler) UploadMultiTrack(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Job ID"
 // @Success 200 {object} map[string]interface{}
-// @Failure 404 {object} map[string]string
+// @Failure 404 {object} apierror.Envelope
 // @Router /api/v1/transcription/{id}/merge-status [get]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (h *Handler) GetMergeStatus(c *gin.Context) {
 	jobID := c.Param("id")
 
+	if ok, err := canAccessJob(c, jobID); err != nil || !ok {
+		apierror.Abort(c, apierror.JobNotFound())
+		return
+	}
 	status, errorMsg, err := h.multiTrackProcessor.GetMergeStatus(jobID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		apierror.Abort(c, apierror.JobNotFound())
 		return
 	}
 
@@ -677,7 +967,7 @@ func (h *Handler) GetMergeStatus(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Job ID"
 // @Success 200 {object} map[string]interface{}
-// @Failure 404 {object} map[string]string
+// @Failure 404 {object} apierror.Envelope
 // @Router /api/v1/transcription/{id}/track-progress [get]
 // @Security ApiKeyAuth
 // @Security BearerAuth
@@ -686,14 +976,14 @@ func (h *Handler) GetTrackProgress(c *gin.Context) {
 
 	// Get the main job details
 	var job models.TranscriptionJob
-	if err := database.DB.Preload("MultiTrackFiles").Where("id = ?", jobID).First(&job).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+	if err := database.DB.Scopes(ownedJobs(c)).Preload("MultiTrackFiles").Where("id = ?", jobID).First(&job).Error; err != nil {
+		apierror.Abort(c, apierror.JobNotFound())
 		return
 	}
 
 	// Only provide track progress for multi-track jobs
 	if !job.IsMultiTrack {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Not a multi-track job"})
+		apierror.Abort(c, apierror.BadRequest("Not a multi-track job"))
 		return
 	}
 
@@ -769,42 +1059,72 @@ func (h *Handler) GetTrackProgress(c *gin.Context) {
 }
 
 // @Summary Submit a transcription job
-// @Description Submit an audio file for transcription with WhisperX
+// @Description Submit an audio file for transcription with WhisperX. Higher priority jobs start first; jobs of equal priority start in submission order. Jobs with scheduled_at stay scheduled until that time.
 // @Tags transcription
 // @Accept multipart/form-data
 // @Produce json
 // @Param audio formData file true "Audio file"
-// @Param title formData string false "Job title"
+// @Param title formData string false "Job title; defaults to the uploaded file's name"
+// @Param description formData string false "Job description, up to 5000 characters"
+// @Param metadata formData string false "JSON object of string key/value pairs, up to 50 entries"
 // @Param diarization formData boolean false "Enable speaker diarization"
 // @Param model formData string false "Whisper model" default(base)
-// @Param language formData string false "Language code"
-// @Param batch_size formData int false "Batch size" default(16)
-// @Param compute_type formData string false "Compute type" default(float16)
+// @Param backend formData string false "Engine running the Whisper model; faster-whisper uses less memory on the CPU" Enums(whisperx, faster-whisper) default(whisperx)
+// @Param engine formData string false "Hosted service to transcribe with instead of a local model" Enums(openai)
+// @Param language formData string false "Language code; empty or auto detects the language"
+// @Param task formData string false "transcribe, or translate to transcribe into English; translate cannot be used with language en" default(transcribe)
+// @Param batch_size formData int false "WhisperX batch size; defaults to 16 on CUDA, 8 on MPS and 4 on the CPU" minimum(1) maximum(256)
+// @Param threads formData int false "CPU threads for WhisperX; 0 leaves the choice to WhisperX" minimum(0) maximum(256)
+// @Param compute_type formData string false "Compute type. Defaults to float16 on CUDA and MPS and int8 on the CPU; the int8 types use less memory but may reduce accuracy" Enums(float32, float16, int8, int8_float16, int8_bfloat16)
 // @Param device formData string false "Device" default(auto)
 // @Param vad_filter formData boolean false "Enable VAD filter"
-// @Param vad_onset formData number false "VAD onset" default(0.500)
-// @Param vad_offset formData number false "VAD offset" default(0.363)
+// @Param vad_onset formData number false "VAD onset threshold, between 0 and 1 exclusive. Lower is more sensitive: quieter speech counts as voice" default(0.500)
+// @Param vad_offset formData number false "VAD offset threshold, between 0 and 1 exclusive. Lower keeps a speech segment open through short pauses" default(0.363)
 // @Param min_speakers formData int false "Minimum speakers for diarization"
 // @Param max_speakers formData int false "Maximum speakers for diarization"
+// @Param hf_token formData string false "HuggingFace token for pyannote diarization"
+// @Param initial_prompt formData string false "Names and terminology to prime the model with, up to 224 tokens"
+// @Param beam_size formData int false "Beam search size, 1 to 10" default(5)
+// @Param temperatures formData string false "Comma-separated temperature fallback schedule, rising in equal steps from 0.0 to 1.0" default(0,0.2,0.4,0.6,0.8,1.0)
+// @Param post_processors formData string false "JSON list of transcript post-processors run in order, e.g. [{\"name\":\"word_blocklist\",\"options\":{\"words\":[\"jane\"]}}]; built in are regex_replace (pattern, replacement), word_blocklist (words) and chapters (min_gap_seconds)"
+// @Param priority formData int false "Queue priority: -1 low, 0 normal, 1 high (admins only)" default(0)
+// @Param scheduled_at formData string false "RFC3339 time to queue the job at"
+// @Param depends_on formData []string false "IDs of jobs that must complete before this one starts, as repeated fields or one JSON array. The job fails if one of them fails." collectionFormat(multi)
+// @Param profile_id formData string false "Transcription profile to take the parameters from instead of the fields above"
+// @Param parameters formData string false "With profile_id, a JSON object of parameters overriding the profile's; each must be declared by the profile's model"
 // @Success 200 {object} models.TranscriptionJob
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 403 {object} apierror.Envelope "Only admins can submit high priority jobs"
+// @Failure 413 {object} apierror.Envelope "Body is larger than MAX_UPLOAD_SIZE_MB"
+// @Failure 415 {object} apierror.Envelope "Not an accepted audio or video type"
+// @Failure 422 {object} apierror.Envelope "File is not transcribable audio, translate was requested for English audio, title, description or metadata is too long, or depends_on names an unknown or failed job or forms a cycle"
+// @Failure 500 {object} apierror.Envelope
 // @Router /api/v1/transcription/submit [post]
+// @Router /api/v1/transcriptions [post]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (h *Handler) SubmitJob(c *gin.Context) {
 	// Parse multipart form
 	file, header, err := c.Request.FormFile("audio")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Audio file is required"})
+		apierror.Abort(c, apierror.Validation("Audio file is required"))
 		return
 	}
 	defer file.Close()
 
+	priority, ok := requestedPriority(c)
+	if !ok {
+		return
+	}
+	scheduledAt, ok := requestedSchedule(c)
+	if !ok {
+		return
+	}
+
 	// Create upload directory
 	uploadDir := h.config.UploadDir
 	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload directory"})
+		apierror.Abort(c, apierror.Internal("Failed to create upload directory"))
 		return
 	}
 
@@ -817,16 +1137,118 @@ func (h *Handler) SubmitJob(c *gin.Context) {
 	// Save file
 	dst, err := os.Create(filePath)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		apierror.Abort(c, apierror.Internal("Failed to save file"))
+		return
+	}
+	_, err = io.Copy(dst, file)
+	dst.Close()
+	if err != nil {
+		os.Remove(filePath)
+		apierror.Abort(c, apierror.Internal("Failed to save file"))
 		return
 	}
-	defer dst.Close()
 
-	if _, err = io.Copy(dst, file); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+	h.submitUploadedFile(c, jobID, filePath, header.Filename, priority, scheduledAt)
+}
+
+// submitUploadedFile validates a saved upload and creates and queues its job
+// from the form fields, removing the file if the job is rejected. filename is
+// the name the file was uploaded with, which titles jobs submitted without one.
+func (h *Handler) submitUploadedFile(c *gin.Context, jobID, filePath, filename string, priority int, scheduledAt *time.Time) {
+	if !h.validateUpload(c, filePath) {
+		os.Remove(filePath) // Clean up file
+		return
+	}
+	details, ok := formJobDetails(c, c.PostForm("title"), filename)
+	if !ok {
+		os.Remove(filePath)
+		return
+	}
+	dependsOn, ok := formDependencies(c)
+	if !ok || !checkDependencies(c, jobID, dependsOn) {
+		os.Remove(filePath)
+		return
+	}
+
+	var params models.WhisperXParams
+	if profileID := c.PostForm("profile_id"); profileID != "" {
+		params, ok = profileParameters(c, profileID, []byte(c.PostForm("parameters")))
+	} else {
+		params, ok = h.formParameters(c)
+	}
+	if !ok {
+		os.Remove(filePath)
+		return
+	}
+	if !validJobOptions(c, params) {
+		os.Remove(filePath)
+		return
+	}
+	if err := params.ValidateDiarization(); err != nil {
+		os.Remove(filePath)
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	if err := params.ValidateInitialPrompt(); err != nil {
+		os.Remove(filePath)
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+
+	// Create job
+	job := models.TranscriptionJob{
+		ID:          jobID,
+		UserID:      jobOwner(c),
+		AudioPath:   filePath,
+		Status:      models.StatusPending,
+		Diarization: params.Diarize,
+		Parameters:  params,
+		Priority:    priority,
+		DependsOn:   dependsOn,
+	}
+	if scheduledAt != nil {
+		job.Status = models.StatusScheduled
+		job.ScheduledAt = scheduledAt
+	}
+	details.apply(&job)
+	job.PhaseTimings = uploadTimings(c)
+
+	h.detectAudioInfo(c, &job)
+
+	if !h.enforceQuota(c, &job) {
+		os.Remove(filePath) // Clean up file
+		return
+	}
+
+	// Save to database
+	if err := database.DB.Create(&job).Error; err != nil {
+		os.Remove(filePath) // Clean up file
+		apierror.Abort(c, apierror.Internal("Failed to create job"))
+		return
+	}
+
+	if !h.normalizeUpload(c, &job) || !h.storeUpload(c, &job) {
+		return
+	}
+
+	// Scheduled jobs are queued by the scheduler once due
+	if job.Status == models.StatusScheduled {
+		c.JSON(http.StatusOK, job)
+		return
+	}
+
+	// Enqueue job
+	if err := h.taskQueue.SubmitWithPriority(c.Request.Context(), jobID, priority); err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to enqueue job"))
 		return
 	}
 
+	c.JSON(http.StatusOK, job)
+}
+
+// formParameters reads job parameters from the individual form fields of a
+// submission, writing an error response and returning false if one is invalid
+func (h *Handler) formParameters(c *gin.Context) (models.WhisperXParams, bool) {
 	// Parse parameters (accept both 'diarization' and 'diarize')
 	diarize := false
 	if v := c.PostForm("diarization"); v != "" {
@@ -834,72 +1256,104 @@ func (h *Handler) SubmitJob(c *gin.Context) {
 	} else {
 		diarize = getFormBoolWithDefault(c, "diarize", false)
 	}
-	defaultDevice := h.environment.DefaultWhisperDevice
+	device := getFormValueWithDefault(c, "device", h.environment.DefaultWhisperDevice)
 	params := models.WhisperXParams{
-		Model:       getFormValueWithDefault(c, "model", "base"),
-		BatchSize:   getFormIntWithDefault(c, "batch_size", 16),
-		ComputeType: getFormValueWithDefault(c, "compute_type", "int8"),
-		Device:      getFormValueWithDefault(c, "device", defaultDevice),
-		VadOnset:    getFormFloatWithDefault(c, "vad_onset", 0.500),
-		VadOffset:   getFormFloatWithDefault(c, "vad_offset", 0.363),
-		Diarize:     diarize,
+		Model:        getFormValueWithDefault(c, "model", "base"),
+		Backend:      c.PostForm("backend"),
+		Engine:       c.PostForm("engine"),
+		BatchSize:    getFormIntWithDefault(c, "batch_size", h.defaultBatchSize(device)),
+		ComputeType:  getFormValueWithDefault(c, "compute_type", h.defaultComputeType(device)),
+		Threads:      getFormIntWithDefault(c, "threads", 0),
+		Device:       device,
+		VadOnset:     getFormFloatWithDefault(c, "vad_onset", models.DefaultVADOnset),
+		VadOffset:    getFormFloatWithDefault(c, "vad_offset", models.DefaultVADOffset),
+		Diarize:      diarize,
+		BeamSize:     h.defaultBeamSize(),
+		Temperatures: h.defaultTemperatures(),
+	}
+
+	if !requestedDecoding(c, &params) {
+		return params, false
 	}
 
 	if lang := c.PostForm("language"); lang != "" {
 		params.Language = &lang
 	}
+	params.Task = getFormValueWithDefault(c, "task", models.TaskTranscribe)
 
 	if minSpeakers := c.PostForm("min_speakers"); minSpeakers != "" {
-		if min, err := strconv.Atoi(minSpeakers); err == nil {
-			params.MinSpeakers = &min
+		min, err := strconv.Atoi(minSpeakers)
+		if err != nil {
+			apierror.Abort(c, apierror.Validation("min_speakers must be an integer"))
+			return params, false
 		}
+		params.MinSpeakers = &min
 	}
 
 	if maxSpeakers := c.PostForm("max_speakers"); maxSpeakers != "" {
-		if max, err := strconv.Atoi(maxSpeakers); err == nil {
-			params.MaxSpeakers = &max
+		max, err := strconv.Atoi(maxSpeakers)
+		if err != nil {
+			apierror.Abort(c, apierror.Validation("max_speakers must be an integer"))
+			return params, false
 		}
+		params.MaxSpeakers = &max
 	}
 
 	if hfToken := c.PostForm("hf_token"); hfToken != "" {
 		params.HfToken = &hfToken
 	}
 
+	if prompt := c.PostForm("initial_prompt"); prompt != "" {
+		params.InitialPrompt = &prompt
+	}
+
+	params.SkipPreprocess = getFormBoolWithDefault(c, "skip_preprocess", false)
+	params.WordConfidenceThreshold = getFormFloatWithDefault(c, "word_confidence_threshold", 0)
+	params.MaskString = c.PostForm("mask_string")
+	if postProcessors := c.PostForm("post_processors"); postProcessors != "" {
+		if err := json.Unmarshal([]byte(postProcessors), &params.PostProcessors); err != nil {
+			apierror.Abort(c, apierror.Validation("post_processors must be a JSON list of {\"name\", \"options\"} objects"))
+			return params, false
+		}
+	}
+	params.Preprocessing.NormalizeAudio = getFormBoolWithDefault(c, "normalize_audio", false)
+	if highPass := c.PostForm("high_pass_filter_hz"); highPass != "" {
+		hz, err := strconv.Atoi(highPass)
+		if err != nil {
+			apierror.Abort(c, apierror.Validation("high_pass_filter_hz must be an integer"))
+			return params, false
+		}
+		params.Preprocessing.HighPassFilterHz = hz
+	}
+
 	// Parse and validate diarization model
 	diarizeModel := getFormValueWithDefault(c, "diarize_model", "pyannote")
 	if diarizeModel != "pyannote" && diarizeModel != "nvidia_sortformer" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid diarize_model. Must be 'pyannote' or 'nvidia_sortformer'"})
-		return
+		apierror.Abort(c, apierror.Validation("Invalid diarize_model. Must be 'pyannote' or 'nvidia_sortformer'"))
+		return params, false
 	}
 	params.DiarizeModel = diarizeModel
+	return params, true
+}
 
-	// Create job
-	job := models.TranscriptionJob{
-		ID:          jobID,
-		AudioPath:   filePath,
-		Status:      models.StatusPending,
-		Diarization: diarize,
-		Parameters:  params,
-	}
-
-	if title := c.PostForm("title"); title != "" {
-		job.Title = &title
+// requestedPriority parses the priority form field, writing an error
+// response and returning false when it is invalid. Only admins may submit
+// high priority jobs.
+func requestedPriority(c *gin.Context) (int, bool) {
+	value := c.PostForm("priority")
+	if value == "" {
+		return models.PriorityNormal, true
 	}
-
-	// Save to database
-	if err := database.DB.Create(&job).Error; err != nil {
-		os.Remove(filePath) // Clean up file
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create job"})
-		return
+	priority, err := strconv.Atoi(value)
+	if err != nil || !models.IsValidPriority(priority) {
+		apierror.Abort(c, apierror.Validation("priority must be -1 (low), 0 (normal) or 1 (high)"))
+		return 0, false
 	}
-
-	// Enqueue job
-	if err := h.taskQueue.EnqueueJob(jobID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enqueue job"})
-		return
+	if priority > models.PriorityNormal && c.GetString("role") != models.RoleAdmin {
+		apierror.Abort(c, apierror.Forbidden("Only admins can submit high priority jobs"))
+		return 0, false
 	}
-
-	c.JSON(http.StatusOK, job)
+	return priority, true
 }
 
 // @Summary Get job status
@@ -908,7 +1362,7 @@ func (h *Handler) SubmitJob(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Job ID"
 // @Success 200 {object} models.TranscriptionJob
-// @Failure 404 {object} map[string]string
+// @Failure 404 {object} apierror.Envelope
 // @Router /api/v1/transcription/{id}/status [get]
 // @Security ApiKeyAuth
 // @Security BearerAuth
@@ -918,10 +1372,14 @@ func (h *Handler) GetJobStatus(c *gin.Context) {
 	job, err := h.taskQueue.GetJobStatus(jobID)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			apierror.Abort(c, apierror.JobNotFound())
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get job status"})
+		apierror.Abort(c, apierror.Internal("Failed to get job status"))
+		return
+	}
+	if !ownsJob(c, job) {
+		apierror.Abort(c, apierror.JobNotFound())
 		return
 	}
 
@@ -933,71 +1391,133 @@ func (h *Handler) GetJobStatus(c *gin.Context) {
 // @Tags transcription
 // @Produce json
 // @Param id path string true "Job ID"
+// @Param revision query int false "Transcript revision to fetch (defaults to the current one)"
+// @Param granularity query string false "segment, or word to include per-word timings in each segment" default(segment)
 // @Success 200 {object} map[string]interface{}
-// @Failure 404 {object} map[string]string
-// @Failure 400 {object} map[string]string
+// @Failure 404 {object} apierror.Envelope
+// @Failure 400 {object} apierror.Envelope
 // @Router /api/v1/transcription/{id}/transcript [get]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (h *Handler) GetTranscript(c *gin.Context) {
 	jobID := c.Param("id")
+	granularity := c.DefaultQuery("granularity", granularitySegment)
+	if granularity != granularitySegment && granularity != granularityWord {
+		apierror.Abort(c, apierror.Validation("Invalid granularity. Must be 'segment' or 'word'"))
+		return
+	}
 
 	var job models.TranscriptionJob
-	if err := database.DB.Where("id = ?", jobID).First(&job).Error; err != nil {
+	if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", jobID).First(&job).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			apierror.Abort(c, apierror.JobNotFound())
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get job"})
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
 		return
 	}
 
 	if job.Status != models.StatusCompleted {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("Job not completed, current status: %s", job.Status),
-		})
+		apierror.Abort(c, apierror.BadRequest(fmt.Sprintf("Job not completed, current status: %s", job.Status)))
 		return
 	}
 
 	if job.Transcript == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Transcript not available"})
+		apierror.Abort(c, apierror.NotFound("Transcript not available"))
 		return
 	}
 
+	// Historical revisions are served from the edit history
+	transcriptJSON := *job.Transcript
+	revision := job.TranscriptRevision
+	if requested := c.Query("revision"); requested != "" {
+		var err error
+		revision, err = strconv.Atoi(requested)
+		if err != nil || revision < 0 {
+			apierror.Abort(c, apierror.Validation("Invalid revision"))
+			return
+		}
+		if revision > job.TranscriptRevision {
+			apierror.Abort(c, apierror.NotFound("Revision not found"))
+			return
+		}
+		if revision < job.TranscriptRevision {
+			snapshot, err := transcriptAtRevision(job.ID, revision)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					apierror.Abort(c, apierror.NotFound("Revision not found"))
+					return
+				}
+				apierror.Abort(c, apierror.Internal("Failed to get transcript revision"))
+				return
+			}
+			transcriptJSON = snapshot.Transcript
+		}
+	}
+
 	var transcript interface{}
-	if err := json.Unmarshal([]byte(*job.Transcript), &transcript); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse transcript"})
+	if err := json.Unmarshal([]byte(transcriptJSON), &transcript); err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to parse transcript"))
+		return
+	}
+	if transcriptMap, ok := transcript.(map[string]interface{}); ok {
+		applyTranscriptGranularity(transcriptMap, granularity)
+	}
+
+	speakerNames, err := speakerDisplayNames(job.ID)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to get speaker mappings"))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"job_id":     job.ID,
-		"title":      job.Title,
-		"transcript": transcript,
-		"created_at": job.CreatedAt,
-		"updated_at": job.UpdatedAt,
+		"job_id":              job.ID,
+		"title":               job.Title,
+		"transcript":          transcript,
+		"transcript_revision": revision,
+		"speaker_names":       speakerNames,
+		"created_at":          job.CreatedAt,
+		"updated_at":          job.UpdatedAt,
 	})
 }
 
 // @Summary List all transcription records
-// @Description Get a list of all transcription jobs with optional search and filtering
+// @Description Get a page of transcription jobs with optional search and filtering. Filters combine; a saved filter is narrowed by the other parameters given with it. Transcript text is left out unless requested with fields or full. Pages are fetched by cursor: pass next_cursor back with the same filters to get the next page. page is still accepted for offset paging.
 // @Tags transcription
 // @Produce json
-// @Param page query int false "Page number" default(1)
-// @Param limit query int false "Items per page" default(10)
+// @Param limit query int false "Items per page, at most 1000" default(10)
+// @Param cursor query string false "Opaque cursor from the previous page's next_cursor"
+// @Param page query int false "Page number, for offset paging without a cursor" default(1)
+// @Param sort query string false "Sort by created_at, duration, status or title" default(created_at)
+// @Param order query string false "asc or desc" default(desc)
+// @Param fields query string false "Comma-separated job fields to return, such as id,title,status,progress,duration_seconds,created_at; id is always included"
+// @Param full query bool false "Deprecated: return full jobs including transcripts, as before field selection"
 // @Param status query string false "Filter by status"
-// @Param q query string false "Search in title and audio filename"
+// @Param q query string false "Search in title, description and audio filename"
+// @Param title query string false "Search in title only"
+// @Param batch_id query string false "Filter by batch upload"
+// @Param tag query []string false "Filter by tag as key:value, or key for any value; repeat to require several tags" collectionFormat(multi)
+// @Param folder_id query int false "Filter by folder; 0 lists jobs in no folder"
+// @Param engine query string false "Filter by hosted engine, such as openai, or by the model family of local jobs, such as whisper"
+// @Param created_after query string false "Only jobs created at or after this time (RFC 3339 or YYYY-MM-DD, UTC)"
+// @Param created_before query string false "Only jobs created before this time (RFC 3339 or YYYY-MM-DD, UTC)"
+// @Param include_archived query bool false "Include archived jobs, which are left out by default"
+// @Param saved_filter query int false "Apply one of the caller's saved filters"
+// @Param all query bool false "Admins only: list every user's jobs rather than just their own and unowned ones" default(true)
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} apierror.Envelope
+// @Failure 403 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
 // @Router /api/v1/transcription/list [get]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (h *Handler) ListJobs(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	status := c.Query("status")
-	search := c.Query("q") // Add search parameter
+	batchID := c.Query("batch_id")
+	cursor := c.Query("cursor")
 
-	if page < 1 {
+	if page < 1 || cursor != "" {
 		page = 1
 	}
 	if limit < 1 || limit > 1000 {
@@ -1006,21 +1526,67 @@ func (h *Handler) ListJobs(c *gin.Context) {
 
 	offset := (page - 1) * limit
 
+	filter, err := jobFilterFromQuery(c)
+	if err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	if savedID := c.Query("saved_filter"); savedID != "" {
+		saved, ok := loadSavedFilter(c, savedID)
+		if !ok {
+			return
+		}
+		filter = saved.Filter.Merge(filter)
+	}
+	if err := filter.Validate(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+
+	listSort, err := parseJobListSort(c.Query("sort"), c.Query("order"))
+	if err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	fields, err := parseJobFields(c.Query("fields"))
+	if err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	full := false
+	if raw := c.Query("full"); raw != "" {
+		if full, err = strconv.ParseBool(raw); err != nil {
+			apierror.Abort(c, apierror.BadRequest(fmt.Sprintf("invalid full %q", raw)))
+			return
+		}
+	}
+	if full {
+		c.Header("Deprecation", "true")
+	}
+	all := isAdmin(c)
+	if raw := c.Query("all"); raw != "" {
+		if all, err = strconv.ParseBool(raw); err != nil {
+			apierror.Abort(c, apierror.BadRequest(fmt.Sprintf("invalid all %q", raw)))
+			return
+		}
+		if all && !isAdmin(c) {
+			apierror.Abort(c, apierror.Forbidden("Only admins can list every user's jobs"))
+			return
+		}
+	}
+
 	query := database.DB.Model(&models.TranscriptionJob{})
+	if !all {
+		query = query.Scopes(myJobs(c))
+	}
 
 	// Filter out temporary track jobs (they have IDs starting with "track_")
 	query = query.Where("id NOT LIKE 'track_%'")
 
-	// Apply status filter
-	if status != "" {
-		query = query.Where("status = ?", status)
-	}
-
-	// Apply search filter - search in title and audio_path
-	if search != "" {
-		searchPattern := "%" + search + "%"
-		query = query.Where("title LIKE ? COLLATE NOCASE OR audio_path LIKE ? COLLATE NOCASE", searchPattern, searchPattern)
+	if batchID != "" {
+		query = query.Where("batch_id = ?", batchID)
 	}
+	query = database.WhereJobFilter(query, filter)
 
 	var jobs []models.TranscriptionJob
 	var total int64
@@ -1028,69 +1594,189 @@ func (h *Handler) ListJobs(c *gin.Context) {
 	// Count total matching records
 	query.Count(&total)
 
-	// Apply pagination and ordering
-	if err := query.Preload("MultiTrackFiles").Offset(offset).Limit(limit).Order("created_at DESC").Find(&jobs).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list jobs"})
-		return
+	pageQuery := query.Session(&gorm.Session{})
+	if cursor != "" {
+		cursorSort, value, afterID, err := decodeJobListCursor(cursor)
+		if err != nil {
+			apierror.Abort(c, apierror.Validation(err.Error()))
+			return
+		}
+		if (c.Query("sort") != "" || c.Query("order") != "") && cursorSort != listSort {
+			apierror.Abort(c, apierror.BadRequest("The cursor was issued for a different sort"))
+			return
+		}
+		listSort = cursorSort
+		pageQuery = listSort.after(pageQuery, value, afterID)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"jobs": jobs,
-		"pagination": gin.H{
-			"page":   page,
-			"limit":  limit,
-			"total":  total,
-			"pages":  (total + int64(limit) - 1) / int64(limit),
-			"search": search, // Include search term in response
-		},
-	})
-}
-
-// @Summary Start transcription for uploaded file
-// @Description Start transcription for an already uploaded audio file
-// @Tags transcription
-// @Accept json
-// @Produce json
-// @Param id path string true "Job ID"
-// @Param parameters body models.WhisperXParams true "Transcription parameters"
-// @Success 200 {object} models.TranscriptionJob
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Router /api/v1/transcription/{id}/start [post]
-// @Security ApiKeyAuth
-// @Security BearerAuth
-func (h *Handler) StartTranscription(c *gin.Context) {
-	jobID := c.Param("id")
+	// Notes and transcripts can be long, so listings leave them out unless
+	// asked for; notes are only flagged
+	omit := []string{"notes"}
+	for _, column := range listHeavyColumns {
+		if !full && !slices.Contains(fields, column) {
+			omit = append(omit, column)
+		}
+	}
+	if err := listSort.orderBy(pageQuery).Omit(omit...).Preload("MultiTrackFiles").Offset(offset).Limit(limit).Find(&jobs).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to list jobs"))
+		return
+	}
+	if err := flagJobsWithNotes(jobs); err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to list jobs"))
+		return
+	}
+	if err := attachTags(jobs); err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to get tags"))
+		return
+	}
 
-	var job models.TranscriptionJob
-	if err := database.DB.Where("id = ?", jobID).First(&job).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+	// Remaining counts the jobs after this page, which the next cursor
+	// continues with
+	var remaining int64
+	var nextCursor string
+	if len(jobs) > 0 {
+		last := &jobs[len(jobs)-1]
+		if err := listSort.after(query.Session(&gorm.Session{}), listSort.sortValue(last), last.ID).Count(&remaining).Error; err != nil {
+			apierror.Abort(c, apierror.Internal("Failed to list jobs"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get job"})
-		return
+		if remaining > 0 {
+			if nextCursor, err = encodeJobListCursor(listSort, last); err != nil {
+				apierror.Abort(c, apierror.Internal("Failed to list jobs"))
+				return
+			}
+		}
 	}
 
-	// Allow transcription for uploaded, completed, and failed jobs (re-transcription)
-	if job.Status != models.StatusUploaded && job.Status != models.StatusCompleted && job.Status != models.StatusFailed {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot start transcription: job is currently processing or pending"})
+	pagination := gin.H{
+		"limit":     limit,
+		"total":     total,
+		"remaining": remaining,
+		"search":    filter.Query, // Include search term in response
+	}
+	if cursor == "" {
+		pagination["page"] = page
+		pagination["pages"] = (total + int64(limit) - 1) / int64(limit)
+	}
+	if nextCursor != "" {
+		pagination["next_cursor"] = nextCursor
+	}
+
+	if fields == nil {
+		c.JSON(http.StatusOK, gin.H{"jobs": jobs, "pagination": pagination})
 		return
 	}
+	selected, err := selectJobFields(jobs, fields)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to list jobs"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"jobs": selected, "pagination": pagination})
+}
 
-	// Parse transcription parameters from request body
-	var requestParams models.WhisperXParams
+// jobFilterFromQuery reads the job list filter parameters of a request
+func jobFilterFromQuery(c *gin.Context) (models.JobFilter, error) {
+	filter := models.JobFilter{
+		Status: models.JobStatus(c.Query("status")),
+		Query:  c.Query("q"),
+		Title:  c.Query("title"),
+		Tags:   c.QueryArray("tag"),
+		Engine: c.Query("engine"),
+	}
+	if folder := c.Query("folder_id"); folder != "" {
+		id, err := strconv.ParseUint(folder, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid folder_id %q", folder)
+		}
+		folderID := uint(id)
+		filter.FolderID = &folderID
+	}
+	if archived := c.Query("include_archived"); archived != "" {
+		include, err := strconv.ParseBool(archived)
+		if err != nil {
+			return filter, fmt.Errorf("invalid include_archived %q", archived)
+		}
+		filter.IncludeArchived = include
+	}
+	for _, bound := range []struct {
+		name  string
+		value **time.Time
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+	} {
+		if raw := c.Query(bound.name); raw != "" {
+			t, err := parseFilterTime(raw)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s %q: use RFC 3339 or YYYY-MM-DD", bound.name, raw)
+			}
+			*bound.value = &t
+		}
+	}
+	return filter, nil
+}
 
-	// Set defaults
-	defaultDevice := h.environment.DefaultWhisperDevice
-	requestParams = models.WhisperXParams{
+// parseFilterTime parses an RFC 3339 time, or a date meaning midnight UTC
+func parseFilterTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, raw)
+}
+
+// flagJobsWithNotes sets HasNotes on the listed jobs that have notes
+func flagJobsWithNotes(jobs []models.TranscriptionJob) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+	ids := make([]string, len(jobs))
+	for i, job := range jobs {
+		ids[i] = job.ID
+	}
+	var withNotes []string
+	if err := database.DB.Model(&models.TranscriptionJob{}).
+		Where("id IN ? AND notes IS NOT NULL", ids).Pluck("id", &withNotes).Error; err != nil {
+		return err
+	}
+	hasNotes := make(map[string]bool, len(withNotes))
+	for _, id := range withNotes {
+		hasNotes[id] = true
+	}
+	for i := range jobs {
+		jobs[i].HasNotes = hasNotes[jobs[i].ID]
+	}
+	return nil
+}
+
+// defaultComputeType returns the compute type for jobs on device that set
+// none, resolving auto to the device it runs on here
+func (h *Handler) defaultComputeType(device string) string {
+	if device == "auto" {
+		device = h.environment.AutoDevice()
+	}
+	return models.DefaultComputeType(device)
+}
+
+// defaultBatchSize returns the batch size for jobs on device that set none,
+// resolving auto to the device it runs on here
+func (h *Handler) defaultBatchSize(device string) int {
+	if device == "auto" {
+		device = h.environment.AutoDevice()
+	}
+	return transcription.DefaultBatchSizeForDevice(device)
+}
+
+// defaultTranscriptionParams returns the parameters used for fields a
+// transcription request leaves unset
+func (h *Handler) defaultTranscriptionParams() models.WhisperXParams {
+	return models.WhisperXParams{
 		ModelFamily:                    "whisper", // Default to whisper for backward compatibility
 		Model:                          "small",
 		ModelCacheOnly:                 false,
-		Device:                         defaultDevice,
+		Device:                         h.environment.DefaultWhisperDevice,
 		DeviceIndex:                    0,
-		BatchSize:                      8,
-		ComputeType:                    "float32",
+		BatchSize:                      h.defaultBatchSize(h.environment.DefaultWhisperDevice),
+		ComputeType:                    h.defaultComputeType(h.environment.DefaultWhisperDevice),
 		Threads:                        0,
 		OutputFormat:                   "all",
 		Verbose:                        true,
@@ -1107,7 +1793,8 @@ func (h *Handler) StartTranscription(c *gin.Context) {
 		SpeakerEmbeddings:              false,
 		Temperature:                    0,
 		BestOf:                         5,
-		BeamSize:                       5,
+		BeamSize:                       h.defaultBeamSize(),
+		Temperatures:                   h.defaultTemperatures(),
 		Patience:                       1.0,
 		LengthPenalty:                  1.0,
 		SuppressNumerals:               false,
@@ -1124,6 +1811,191 @@ func (h *Handler) StartTranscription(c *gin.Context) {
 		AttentionContextRight:          256,
 		IsMultiTrackEnabled:            false,
 	}
+}
+
+// defaultBeamSize returns the configured WhisperX beam size
+func (h *Handler) defaultBeamSize() int {
+	if h.config.WhisperXBeamSize > 0 {
+		return h.config.WhisperXBeamSize
+	}
+	return config.DefaultBeamSize
+}
+
+// defaultTemperatures returns the configured WhisperX temperature schedule
+func (h *Handler) defaultTemperatures() []float64 {
+	temperatures := h.config.WhisperXTemperatures
+	if len(temperatures) == 0 {
+		temperatures = config.DefaultTemperatures
+	}
+	return append([]float64(nil), temperatures...)
+}
+
+// requestedDecoding parses the beam_size and temperatures form fields into
+// params, writing an error response and returning false when they are
+// invalid. Temperatures are comma-separated.
+func requestedDecoding(c *gin.Context, params *models.WhisperXParams) bool {
+	if value := c.PostForm("beam_size"); value != "" {
+		beamSize, err := strconv.Atoi(value)
+		if err != nil {
+			apierror.Abort(c, apierror.Validation("beam_size must be an integer"))
+			return false
+		}
+		params.BeamSize = beamSize
+	}
+	if value := c.PostForm("temperatures"); value != "" {
+		var temperatures []float64
+		for _, entry := range strings.Split(value, ",") {
+			temperature, err := strconv.ParseFloat(strings.TrimSpace(entry), 64)
+			if err != nil {
+				apierror.Abort(c, apierror.Validation("temperatures must be a comma-separated list of numbers"))
+				return false
+			}
+			temperatures = append(temperatures, temperature)
+		}
+		params.Temperatures = temperatures
+	}
+	if err := params.ValidateDecoding(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return false
+	}
+	return true
+}
+
+// validJobOptions checks the task, language, compute type, batch size,
+// threads, preprocessing, word masking and VAD thresholds of a job, writing
+// an error response and returning false if they cannot be used
+func validJobOptions(c *gin.Context, params models.WhisperXParams) bool {
+	if !validTask(c, params) || !validLanguage(c, params) {
+		return false
+	}
+	if err := params.ValidateBackend(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return false
+	}
+	if err := params.ValidateComputeType(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return false
+	}
+	if err := params.ValidateBatchSize(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return false
+	}
+	if err := params.ValidateThreads(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return false
+	}
+	if err := params.Preprocessing.Validate(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return false
+	}
+	if err := params.ValidateWordConfidence(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return false
+	}
+	if err := params.ValidateVAD(); err != nil {
+		apierror.Abort(c, apierror.Unprocessable(err.Error()))
+		return false
+	}
+	if _, err := postprocess.Build(params.PostProcessors); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return false
+	}
+	return true
+}
+
+// validTask writes an error response and returns false for an unknown task
+// or a translation the model cannot do, or a 422 for translating audio that
+// is already English
+func validTask(c *gin.Context, params models.WhisperXParams) bool {
+	err := params.ValidateTask()
+	if errors.Is(err, models.ErrTranslateEnglish) {
+		apierror.Abort(c, apierror.Unprocessable(err.Error()))
+		return false
+	}
+	if err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return false
+	}
+	if params.Task == models.TaskTranslate {
+		modelID := transcription.TranscriptionModelID(params)
+		if capabilities, err := registry.GetRegistry().GetCapabilities(modelID); err == nil && !capabilities.Features["translation"] {
+			apierror.Abort(c, apierror.BadRequest(fmt.Sprintf("The %s model cannot translate; models that can: %s", modelID, strings.Join(translationModels(), ", "))))
+			return false
+		}
+	}
+	return true
+}
+
+// translationModels lists the models that can translate to English
+func translationModels() []string {
+	var modelIDs []string
+	for modelID, capabilities := range registry.GetRegistry().GetAllCapabilities() {
+		if capabilities.Features["translation"] {
+			modelIDs = append(modelIDs, modelID)
+		}
+	}
+	sort.Strings(modelIDs)
+	return modelIDs
+}
+
+// validLanguage writes an error response and returns false when language
+// detection is requested from a model that cannot detect the language
+func validLanguage(c *gin.Context, params models.WhisperXParams) bool {
+	if params.Language == nil || !strings.EqualFold(strings.TrimSpace(*params.Language), models.LanguageAuto) {
+		return true
+	}
+	modelID := transcription.TranscriptionModelID(params)
+	capabilities, err := registry.GetRegistry().GetCapabilities(modelID)
+	if err != nil || capabilities.Features["language_detection"] {
+		return true
+	}
+	apierror.Abort(c, apierror.BadRequest(fmt.Sprintf("The %s model cannot detect the language; set language to the language of the audio", modelID)))
+	return false
+}
+
+// @Summary Start transcription for uploaded file
+// @Description Start transcription for an already uploaded audio file
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param id path string true "Job ID"
+// @Param parameters body models.WhisperXParams true "Transcription parameters"
+// @Success 200 {object} models.TranscriptionJob
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 410 {object} apierror.Envelope "Audio was removed by retention cleanup"
+// @Failure 422 {object} apierror.Envelope "translate was requested for English audio"
+// @Router /api/v1/transcription/{id}/start [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) StartTranscription(c *gin.Context) {
+	jobID := c.Param("id")
+
+	var job models.TranscriptionJob
+	if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", jobID).First(&job).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apierror.Abort(c, apierror.JobNotFound())
+			return
+		}
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
+		return
+	}
+
+	// Allow transcription for uploaded, completed, and failed jobs (re-transcription)
+	if job.Status != models.StatusUploaded && job.Status != models.StatusCompleted && job.Status != models.StatusFailed {
+		apierror.Abort(c, apierror.BadRequest("Cannot start transcription: job is currently processing or pending"))
+		return
+	}
+	if job.AudioDeletedAt != nil {
+		apierror.Abort(c, apierror.Gone("Cannot start transcription: the job's audio was removed by retention cleanup"))
+		return
+	}
+
+	// Parse transcription parameters from request body
+	var requestParams models.WhisperXParams
+
+	// Set defaults
+	requestParams = h.defaultTranscriptionParams()
 
 	// Parse request body parameters, overriding defaults
 	if err := c.ShouldBindJSON(&requestParams); err != nil {
@@ -1146,26 +2018,46 @@ func (h *Handler) StartTranscription(c *gin.Context) {
 		// No language restriction needed - models support auto-detection
 
 		// NVIDIA models support diarization via Pyannote integration or NVIDIA Sortformer
-		if requestParams.Diarize && requestParams.DiarizeModel == "pyannote" && (requestParams.HfToken == nil || *requestParams.HfToken == "") {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Hugging Face token (hf_token) is required for Pyannote diarization"})
+		if requestParams.Diarize && requestParams.DiarizeModel == "pyannote" && (requestParams.HfToken == nil || *requestParams.HfToken == "") && h.config.HuggingFaceToken == "" {
+			apierror.Abort(c, apierror.Validation("Hugging Face token (hf_token) is required for Pyannote diarization"))
 			return
 		}
 	}
 
+	if err := requestParams.ValidateDiarization(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	if err := requestParams.ValidateDecoding(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	if err := requestParams.ValidateInitialPrompt(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	if !validJobOptions(c, requestParams) {
+		return
+	}
+
 	// Validate multi-track compatibility
 	if job.IsMultiTrack && !requestParams.IsMultiTrackEnabled {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Multi-track audio requires multi-track transcription to be enabled in the parameters"})
+		apierror.Abort(c, apierror.BadRequest("Multi-track audio requires multi-track transcription to be enabled in the parameters"))
 		return
 	}
 
 	if !job.IsMultiTrack && requestParams.IsMultiTrackEnabled {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Multi-track transcription cannot be used with single-track audio files"})
+		apierror.Abort(c, apierror.BadRequest("Multi-track transcription cannot be used with single-track audio files"))
 		return
 	}
 
 	// Multi-track transcription should automatically disable diarization
 	if requestParams.IsMultiTrackEnabled && requestParams.Diarize {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Diarization must be disabled when using multi-track transcription"})
+		apierror.Abort(c, apierror.Validation("Diarization must be disabled when using multi-track transcription"))
+		return
+	}
+
+	if !h.enforceQuota(c, &job) {
 		return
 	}
 
@@ -1181,30 +2073,18 @@ func (h *Handler) StartTranscription(c *gin.Context) {
 
 	// Save updated job
 	if err := database.DB.Save(&job).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update job"})
+		apierror.Abort(c, apierror.Internal("Failed to update job"))
 		return
 	}
 
 	// Enqueue job for transcription
 	if err := h.taskQueue.EnqueueJob(jobID); err != nil {
 		logger.Error("Failed to enqueue job", "job_id", jobID, "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enqueue job"})
+		apierror.Abort(c, apierror.Internal("Failed to enqueue job"))
 		return
 	}
 
-	// Log job started
-	params := make(map[string]any)
-	params["model"] = requestParams.Model
-	params["model_family"] = requestParams.ModelFamily
-	params["diarization"] = requestParams.Diarize
-	if requestParams.Diarize && requestParams.DiarizeModel != "" {
-		params["diarize_model"] = requestParams.DiarizeModel
-	}
-	params["language"] = requestParams.Language
-	params["device"] = requestParams.Device
-
-	filename := filepath.Base(job.AudioPath)
-	logger.JobStarted(jobID, filename, requestParams.ModelFamily, params)
+	logger.Info("Transcription queued", "job_id", jobID, "file", filepath.Base(job.AudioPath), "model_family", requestParams.ModelFamily)
 
 	c.JSON(http.StatusOK, job)
 }
@@ -1215,8 +2095,8 @@ func (h *Handler) StartTranscription(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Job ID"
 // @Success 200 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 400 {object} map[string]string
+// @Failure 404 {object} apierror.Envelope
+// @Failure 400 {object} apierror.Envelope
 // @Router /api/v1/transcription/{id}/kill [post]
 // @Security ApiKeyAuth
 // @Security BearerAuth
@@ -1224,24 +2104,24 @@ func (h *Handler) KillJob(c *gin.Context) {
 	jobID := c.Param("id")
 
 	var job models.TranscriptionJob
-	if err := database.DB.Where("id = ?", jobID).First(&job).Error; err != nil {
+	if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", jobID).First(&job).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			apierror.Abort(c, apierror.JobNotFound())
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get job"})
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
 		return
 	}
 
 	// Check if job is currently processing
 	if job.Status != models.StatusProcessing {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Job is not currently running"})
+		apierror.Abort(c, apierror.BadRequest("Job is not currently running"))
 		return
 	}
 
 	// Attempt to kill the job
 	if err := h.taskQueue.KillJob(jobID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Validation(err.Error()))
 		return
 	}
 
@@ -1257,15 +2137,15 @@ func (h *Handler) KillJob(c *gin.Context) {
 // @Param id path string true "Job ID"
 // @Param request body map[string]string true "Title update request"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
 // @Router /api/v1/transcription/{id}/title [put]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (h *Handler) UpdateTranscriptionTitle(c *gin.Context) {
 	jobID := c.Param("id")
 	if jobID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Job ID required"})
+		apierror.Abort(c, apierror.Validation("Job ID required"))
 		return
 	}
 
@@ -1273,23 +2153,23 @@ func (h *Handler) UpdateTranscriptionTitle(c *gin.Context) {
 		Title string `json:"title" binding:"required,min=1,max=255"`
 	}
 	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Validation(err.Error()))
 		return
 	}
 
 	var job models.TranscriptionJob
-	if err := database.DB.Where("id = ?", jobID).First(&job).Error; err != nil {
+	if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", jobID).First(&job).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			apierror.Abort(c, apierror.JobNotFound())
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get job"})
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
 		return
 	}
 
 	job.Title = &body.Title
 	if err := database.DB.Model(&job).Update("title", body.Title).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update title"})
+		apierror.Abort(c, apierror.Internal("Failed to update title"))
 		return
 	}
 
@@ -1302,137 +2182,211 @@ func (h *Handler) UpdateTranscriptionTitle(c *gin.Context) {
 	})
 }
 
-// @Summary Delete transcription job
-// @Description Delete a transcription job and its associated files
+// UpdateJobRequest lists the job fields to change; fields left out are kept
+type UpdateJobRequest struct {
+	Notes       *string           `json:"notes"`       // Empty clears the notes
+	Title       *string           `json:"title"`       // Empty clears the title, so the audio filename is used
+	Description *string           `json:"description"` // Empty clears the description
+	Metadata    map[string]string `json:"metadata"`    // Replaces all metadata; an empty object clears it
+	DependsOn   *[]string         `json:"depends_on"`  // Replaces the jobs this one waits for; an empty list clears them. Only before the job starts.
+}
+
+// UpdateJob updates editable fields of a transcription job
+// @Summary Update transcription job
+// @Description Update a job's notes, title, description, metadata or dependencies. Notes may be at most 4096 characters, titles 255 and descriptions 5000; metadata may have at most 50 entries with keys of up to 64 characters and values of up to 1024. An empty string clears a field. Dependencies can only change before the job starts, and may not make jobs wait for each other.
 // @Tags transcription
+// @Accept json
 // @Produce json
 // @Param id path string true "Job ID"
-// @Success 200 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Router /api/v1/transcription/{id} [delete]
+// @Param request body UpdateJobRequest true "Fields to update"
+// @Success 200 {object} models.TranscriptionJob
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope "Dependencies changed on a job that has started"
+// @Failure 422 {object} apierror.Envelope "Title, description or metadata is too long, or depends_on names an unknown or failed job or forms a cycle; details.field names the field"
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/{id} [patch]
 // @Security ApiKeyAuth
 // @Security BearerAuth
-func (h *Handler) DeleteJob(c *gin.Context) {
+func (h *Handler) UpdateJob(c *gin.Context) {
 	jobID := c.Param("id")
 
+	var req UpdateJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
+		return
+	}
+	if req.Notes == nil && req.Title == nil && req.Description == nil && req.Metadata == nil && req.DependsOn == nil {
+		apierror.Abort(c, apierror.Validation("No fields to update"))
+		return
+	}
+	if req.Notes != nil && utf8.RuneCountInString(*req.Notes) > models.MaxJobNotesLength {
+		apierror.Abort(c, apierror.Validation(fmt.Sprintf("Notes must be at most %d characters", models.MaxJobNotesLength)))
+		return
+	}
+	if err := validateJobDetails(req.Title, req.Description, req.Metadata); err != nil {
+		abortFieldError(c, err)
+		return
+	}
+
 	var job models.TranscriptionJob
-	if err := database.DB.Where("id = ?", jobID).First(&job).Error; err != nil {
+	if err := database.DB.Scopes(ownedJobs(c)).Select("id", "status").Where("id = ?", jobID).First(&job).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			apierror.Abort(c, apierror.JobNotFound())
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get job"})
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
 		return
 	}
-
-	// Prevent deletion of jobs that are currently processing
-	if job.Status == models.StatusProcessing {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot delete job that is currently processing"})
-		return
+	if req.DependsOn != nil {
+		switch job.Status {
+		case models.StatusUploaded, models.StatusDownloading, models.StatusScheduled, models.StatusPending:
+		default:
+			apierror.Abort(c, apierror.Conflict("Dependencies can only change before the job starts"))
+			return
+		}
+		if !checkDependencies(c, jobID, *req.DependsOn) {
+			return
+		}
 	}
 
-	// Delete the audio file from filesystem
-	if job.AudioPath != "" {
-		if err := os.Remove(job.AudioPath); err != nil && !os.IsNotExist(err) {
-			// Log the error but don't fail the request - database cleanup is more important
-			fmt.Printf("Warning: Failed to delete audio file %s: %v\n", job.AudioPath, err)
+	// Empty strings are stored as NULL, which a struct update with the
+	// columns selected writes
+	var columns []string
+	var updated models.TranscriptionJob
+	if req.Notes != nil {
+		columns = append(columns, "notes")
+		updated.Notes = emptyToNil(*req.Notes)
+	}
+	if req.Title != nil {
+		columns = append(columns, "title")
+		updated.Title = emptyToNil(*req.Title)
+	}
+	if req.Description != nil {
+		columns = append(columns, "description")
+		updated.Description = emptyToNil(*req.Description)
+	}
+	if req.Metadata != nil {
+		columns = append(columns, "metadata")
+		if len(req.Metadata) > 0 {
+			updated.Metadata = req.Metadata
 		}
 	}
-
-	// Delete multi-track files and folders if this is a multi-track job
-	if job.IsMultiTrack && job.MultiTrackFolder != nil {
-		if err := os.RemoveAll(*job.MultiTrackFolder); err != nil && !os.IsNotExist(err) {
-			fmt.Printf("Warning: Failed to delete multi-track folder %s: %v\n", *job.MultiTrackFolder, err)
+	if req.DependsOn != nil {
+		columns = append(columns, "depends_on")
+		if len(*req.DependsOn) > 0 {
+			updated.DependsOn = *req.DependsOn
 		}
 	}
-
-	// Delete merged audio file if it exists
-	if job.MergedAudioPath != nil && *job.MergedAudioPath != "" {
-		if err := os.Remove(*job.MergedAudioPath); err != nil && !os.IsNotExist(err) {
-			fmt.Printf("Warning: Failed to delete merged audio file %s: %v\n", *job.MergedAudioPath, err)
+	if err := database.DB.Model(&models.TranscriptionJob{}).Where("id = ?", jobID).Select(columns).Updates(&updated).Error; err != nil {
+		logger.Error("Failed to update job", "job_id", jobID, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to update job"))
+		return
+	}
+	// A job already in line waits for its new dependencies
+	if req.DependsOn != nil && job.Status == models.StatusPending && h.taskQueue != nil {
+		if err := h.taskQueue.EnqueueJob(jobID); err != nil {
+			logger.Warn("Failed to requeue job with new dependencies", "job_id", jobID, "error", err)
 		}
 	}
 
-	// Delete any transcript files
-	if job.Transcript != nil {
-		// Remove transcript directory if it exists (assume it's in data/transcripts)
-		transcriptDir := filepath.Join("data", "transcripts", jobID)
-		if err := os.RemoveAll(transcriptDir); err != nil && !os.IsNotExist(err) {
-			fmt.Printf("Warning: Failed to delete transcript directory %s: %v\n", transcriptDir, err)
-		}
+	if err := database.DB.Where("id = ?", jobID).First(&job).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
+		return
+	}
+	job.HasNotes = job.Notes != nil
+	c.JSON(http.StatusOK, job)
+}
+
+// emptyToNil returns nil for an empty string and a pointer to s otherwise
+func emptyToNil(s string) *string {
+	if s == "" {
+		return nil
 	}
+	return &s
+}
 
-	// Delete all related records first to avoid foreign key constraint failures
-	// Start a transaction to ensure atomicity
-	tx := database.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
+// @Summary Delete transcription job
+// @Description Delete a transcription job. The job and its files are kept so it can be restored until retention cleanup purges it, SCRIBERR_RETENTION_DAYS after deletion; purge_after reports when.
+// @Tags transcription
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} apierror.Envelope
+// @Failure 400 {object} apierror.Envelope
+// @Router /api/v1/transcription/{id} [delete]
+// @Router /api/v1/transcriptions/{id} [delete]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) DeleteJob(c *gin.Context) {
+	jobID := c.Param("id")
 
-	// Delete related records in order (children first)
-	if err := tx.Where("transcription_job_id = ?", jobID).Delete(&models.TranscriptionJobExecution{}).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete job execution records"})
+	var job models.TranscriptionJob
+	if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", jobID).First(&job).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apierror.Abort(c, apierror.JobNotFound())
+			return
+		}
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
 		return
 	}
 
-	if err := tx.Where("transcription_job_id = ?", jobID).Delete(&models.SpeakerMapping{}).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete speaker mappings"})
+	// Prevent deletion of jobs that are currently processing
+	if job.Status == models.StatusProcessing {
+		apierror.Abort(c, apierror.BadRequest("Cannot delete job that is currently processing"))
 		return
 	}
 
-	if err := tx.Where("transcription_job_id = ?", jobID).Delete(&models.MultiTrackFile{}).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete multi-track files"})
+	// Only mark the job deleted; cleanup removes it and its files later
+	if err := database.DB.Delete(&job).Error; err != nil {
+		logger.Error("Failed to delete job", "job_id", jobID, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to delete job"))
 		return
 	}
 
-	if err := tx.Where("transcription_id = ?", jobID).Delete(&models.Note{}).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notes"})
-		return
+	response := gin.H{"message": "Job deleted successfully"}
+	if days := h.config.DeletedJobRetentionDays; days > 0 {
+		response["purge_after"] = time.Now().AddDate(0, 0, days)
 	}
+	c.JSON(http.StatusOK, response)
+}
 
-	// Delete chat sessions and their messages
-	var chatSessions []models.ChatSession
-	if err := tx.Where("transcription_id = ?", jobID).Find(&chatSessions).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find chat sessions"})
-		return
-	}
+// @Summary Restore a deleted job
+// @Description Undo the deletion of a job that retention cleanup has not purged yet
+// @Tags transcription
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} models.TranscriptionJob
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/{id}/restore [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) RestoreJob(c *gin.Context) {
+	jobID := c.Param("id")
 
-	for _, session := range chatSessions {
-		if err := tx.Where("chat_session_id = ?", session.ID).Delete(&models.ChatMessage{}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete chat messages"})
+	var job models.TranscriptionJob
+	if err := database.DB.Unscoped().Scopes(ownedJobs(c)).Where("id = ?", jobID).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.JobNotFound())
 			return
 		}
-	}
-
-	if err := tx.Where("transcription_id = ?", jobID).Delete(&models.ChatSession{}).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete chat sessions"})
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
 		return
 	}
-
-	// Finally delete the main job record
-	if err := tx.Delete(&job).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete job from database"})
+	if !job.DeletedAt.Valid {
+		apierror.Abort(c, apierror.BadRequest("Job is not deleted"))
 		return
 	}
 
-	// Commit the transaction
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit deletion transaction"})
+	if err := database.DB.Unscoped().Model(&job).Update("deleted_at", nil).Error; err != nil {
+		logger.Error("Failed to restore job", "job_id", jobID, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to restore job"))
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Job deleted successfully"})
+	job.DeletedAt = gorm.DeletedAt{}
+	job.HasNotes = job.Notes != nil
+	c.JSON(http.StatusOK, job)
 }
 
 // @Summary Get transcription record by ID
@@ -1441,7 +2395,7 @@ func (h *Handler) DeleteJob(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Job ID"
 // @Success 200 {object} models.TranscriptionJob
-// @Failure 404 {object} map[string]string
+// @Failure 404 {object} apierror.Envelope
 // @Router /api/v1/transcription/{id} [get]
 // @Security ApiKeyAuth
 // @Security BearerAuth
@@ -1450,14 +2404,23 @@ func (h *Handler) GetJobByID(c *gin.Context) {
 	jobID := c.Param("id")
 
 	var job models.TranscriptionJob
-	if err := database.DB.Preload("MultiTrackFiles").Where("id = ?", jobID).First(&job).Error; err != nil {
+	if err := database.DB.Scopes(ownedJobs(c)).Preload("MultiTrackFiles").Where("id = ?", jobID).First(&job).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			apierror.Abort(c, apierror.JobNotFound())
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get job"})
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
 		return
 	}
+	tags, err := database.GetTags(job.ID)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to get tags"))
+		return
+	}
+	if len(tags) > 0 {
+		job.Tags = tags
+	}
+	job.HasNotes = job.Notes != nil
 
 	c.JSON(http.StatusOK, job)
 }
@@ -1468,7 +2431,7 @@ func (h *Handler) GetJobByID(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Job ID"
 // @Success 200 {object} models.TranscriptionJobExecution
-// @Failure 404 {object} map[string]string
+// @Failure 404 {object} apierror.Envelope
 // @Router /api/v1/transcription/{id}/execution [get]
 // @Security ApiKeyAuth
 // @Security BearerAuth
@@ -1477,12 +2440,12 @@ func (h *Handler) GetJobExecutionData(c *gin.Context) {
 
 	// Get the transcription job to check if it's multi-track
 	var job models.TranscriptionJob
-	if err := database.DB.Preload("MultiTrackFiles").Where("id = ?", jobID).First(&job).Error; err != nil {
+	if err := database.DB.Scopes(ownedJobs(c)).Preload("MultiTrackFiles").Where("id = ?", jobID).First(&job).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Transcription job not found"})
+			apierror.Abort(c, apierror.JobNotFound())
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get transcription job"})
+		apierror.Abort(c, apierror.Internal("Failed to get transcription job"))
 		return
 	}
 
@@ -1491,10 +2454,10 @@ func (h *Handler) GetJobExecutionData(c *gin.Context) {
 		Order("completed_at DESC").
 		First(&execution).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "No completed execution found for this job"})
+			apierror.Abort(c, apierror.NotFound("No completed execution found for this job"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get execution data"})
+		apierror.Abort(c, apierror.Internal("Failed to get execution data"))
 		return
 	}
 
@@ -1534,31 +2497,39 @@ func (h *Handler) GetJobExecutionData(c *gin.Context) {
 }
 
 // @Summary Get audio file
-// @Description Serve the audio file for a transcription job
+// @Description Serve the audio file for a transcription job. Audio kept in S3 storage is served by redirecting to a presigned URL.
 // @Tags transcription
 // @Produce audio/mpeg,audio/wav,audio/mp4
 // @Param id path string true "Job ID"
 // @Success 200 {file} binary
-// @Failure 404 {object} map[string]string
+// @Success 307 "Redirect to a presigned storage URL"
+// @Failure 404 {object} apierror.Envelope
+// @Failure 410 {object} apierror.Envelope "Audio was removed by retention cleanup"
 // @Router /api/v1/transcription/{id}/audio [get]
 // @Security ApiKeyAuth
 func (h *Handler) GetAudioFile(c *gin.Context) {
 	jobID := c.Param("id")
 
 	var job models.TranscriptionJob
-	if err := database.DB.Where("id = ?", jobID).First(&job).Error; err != nil {
+	if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", jobID).First(&job).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			apierror.Abort(c, apierror.JobNotFound())
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get job"})
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
 		return
 	}
 
-	// Debug logging
-	fmt.Printf("DEBUG: GetAudioFile for job %s\n", jobID)
-	fmt.Printf("DEBUG: Job status: %s\n", job.Status)
-	fmt.Printf("DEBUG: Audio path: '%s'\n", job.AudioPath)
+	h.serveJobAudio(c, &job)
+}
+
+// serveJobAudio writes a job's audio, preferring the merged audio of
+// multi-track jobs
+func (h *Handler) serveJobAudio(c *gin.Context, job *models.TranscriptionJob) {
+	if job.AudioDeletedAt != nil {
+		apierror.Abort(c, apierror.Gone("Audio was removed by retention cleanup"))
+		return
+	}
 
 	// For multi-track jobs, prefer merged audio if available
 	audioPath := job.AudioPath
@@ -1566,31 +2537,73 @@ func (h *Handler) GetAudioFile(c *gin.Context) {
 		// Check if merged audio file exists
 		if _, err := os.Stat(*job.MergedAudioPath); err == nil {
 			audioPath = *job.MergedAudioPath
-			fmt.Printf("DEBUG: Using merged audio: %s\n", audioPath)
 		} else {
-			fmt.Printf("DEBUG: Merged audio not found, falling back to original: %s\n", job.AudioPath)
+			logger.Debug("Merged audio not found, serving the original", "job_id", job.ID)
 		}
 	}
 
 	// Check if audio file exists
 	if audioPath == "" {
-		fmt.Printf("DEBUG: Audio path is empty\n")
-		c.JSON(http.StatusNotFound, gin.H{"error": "Audio file path not found"})
+		apierror.Abort(c, apierror.NotFound("Audio file path not found"))
+		return
+	}
+
+	// Audio in object storage is downloaded from the bucket directly when
+	// the backend can sign links, and streamed through the API otherwise
+	if storage.IsRemote(audioPath) {
+		h.serveStoredAudio(c, audioPath)
 		return
 	}
 
 	// Check if file exists on filesystem
 	if _, err := os.Stat(audioPath); os.IsNotExist(err) {
-		fmt.Printf("DEBUG: Audio file does not exist on disk: %s\n", audioPath)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Audio file not found on disk"})
+		apierror.Abort(c, apierror.NotFound("Audio file not found on disk"))
+		return
+	}
+
+	setAudioHeaders(c, audioPath)
+
+	// Serve the audio file
+	c.File(audioPath)
+}
+
+// signedAudioURLExpiry is how long audio download redirects stay valid
+const signedAudioURLExpiry = 15 * time.Minute
+
+// serveStoredAudio redirects to a signed URL for audio in object storage, or
+// streams it with range support when the backend cannot sign URLs
+func (h *Handler) serveStoredAudio(c *gin.Context, location string) {
+	ctx := c.Request.Context()
+	signedURL, err := storage.SignedURL(ctx, location, signedAudioURLExpiry)
+	if err == nil {
+		c.Redirect(http.StatusTemporaryRedirect, signedURL)
+		return
+	}
+	if !errors.Is(err, storage.ErrSignedURLUnsupported) {
+		logger.Error("Failed to sign audio URL", "location", location, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to get audio file"))
 		return
 	}
 
-	fmt.Printf("DEBUG: Audio file exists, serving: %s\n", audioPath)
+	reader, info, err := storage.Open(ctx, location)
+	if errors.Is(err, storage.ErrNotFound) {
+		apierror.Abort(c, apierror.NotFound("Audio file not found in storage"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to open stored audio", "location", location, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to get audio file"))
+		return
+	}
+	defer reader.Close()
+	setAudioHeaders(c, location)
+	http.ServeContent(c.Writer, c.Request, filepath.Base(location), info.LastModified, reader)
+}
 
-	// Set appropriate content type based on file extension
-	ext := filepath.Ext(job.AudioPath)
-	switch ext {
+// setAudioHeaders sets the content type for an audio file's extension and
+// the CORS headers audio players need
+func setAudioHeaders(c *gin.Context, path string) {
+	switch filepath.Ext(path) {
 	case ".mp3":
 		c.Header("Content-Type", "audio/mpeg")
 	case ".wav":
@@ -1603,13 +2616,13 @@ func (h *Handler) GetAudioFile(c *gin.Context) {
 		c.Header("Content-Type", "audio/mpeg")
 	}
 
-	// Add CORS headers for audio
+	// With an origin allow list, the CORS middleware has set the headers
+	if middleware.CORSRestricted(c) {
+		return
+	}
 	c.Header("Access-Control-Allow-Origin", "*")
 	c.Header("Access-Control-Allow-Methods", "GET")
 	c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, X-API-Key")
-
-	// Serve the audio file
-	c.File(job.AudioPath)
 }
 
 // @Summary Login
@@ -1619,57 +2632,88 @@ func (h *Handler) GetAudioFile(c *gin.Context) {
 // @Produce json
 // @Param credentials body LoginRequest true "User credentials"
 // @Success 200 {object} LoginResponse
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 401 {object} apierror.Envelope
+// @Failure 429 {object} apierror.Envelope
 // @Router /api/v1/auth/login [post]
 func (h *Handler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		apierror.Abort(c, apierror.Validation("Invalid request"))
+		return
+	}
+	if !h.allowLogin(c, req.Username) {
 		return
 	}
 
 	var user models.User
 	if err := database.DB.Where("username = ?", req.Username).First(&user).Error; err != nil {
 		logger.AuthEvent("login", req.Username, c.ClientIP(), false, logger.String("reason", "user_not_found"))
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		h.recordLoginFailure(c, req.Username)
+		apierror.Abort(c, apierror.Unauthorized("Invalid credentials"))
 		return
 	}
 
 	if !auth.CheckPassword(req.Password, user.Password) {
-		logger.AuthEvent("login", req.Username, c.ClientIP(), false, logger.String("reason", "invalid_password"))
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		logger.AuthEvent("login", user.Username, c.ClientIP(), false, logger.String("reason", "invalid_password"), "user_id", user.ID)
+		h.recordLoginFailure(c, user.Username)
+		apierror.Abort(c, apierror.Unauthorized("Invalid credentials"))
+		return
+	}
+
+	if user.Disabled {
+		logger.AuthEvent("login", user.Username, c.ClientIP(), false, logger.String("reason", "account_disabled"), "user_id", user.ID)
+		apierror.Abort(c, apierror.Forbidden("Account is disabled"))
 		return
 	}
 
 	token, err := h.authService.GenerateToken(&user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		apierror.Abort(c, apierror.Internal("Failed to generate token"))
 		return
 	}
 
 	// Set refresh token cookie
 	if err := h.issueRefreshToken(c, user.ID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		apierror.Abort(c, apierror.Internal("Failed to create session"))
 		return
 	}
 
 	response := LoginResponse{Token: token}
 	response.User.ID = user.ID
 	response.User.Username = user.Username
+	response.User.Role = user.Role
+
+	if auth.NeedsRehash(user.Password) {
+		if err := auth.UpgradePasswordHash(c.Request.Context(), &user, req.Password); err != nil {
+			logger.Warn("Failed to upgrade password hash", "user_id", user.ID, "error", err)
+		} else {
+			logger.Info("Upgraded password hash to argon2id", "user_id", user.ID)
+		}
+	}
 
-	logger.AuthEvent("login", req.Username, c.ClientIP(), true)
+	h.clearLoginFailures(c, user.Username)
+	logger.AuthEvent("login", user.Username, c.ClientIP(), true, "user_id", user.ID, "role", user.Role)
 	c.JSON(http.StatusOK, response)
 }
 
 // @Summary Logout user
-// @Description Logout user and invalidate token (client-side action)
+// @Description Logout user, revoke the presented access token and clear the refresh token. Behind an authenticating proxy, redirect_url is where to log out of the proxy.
 // @Tags auth
 // @Produce json
 // @Success 200 {object} map[string]string
 // @Security BearerAuth
 // @Router /api/v1/auth/logout [post]
 func (h *Handler) Logout(c *gin.Context) {
+	// Revoke the current access token so it cannot be reused before expiry
+	if parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2); len(parts) == 2 && parts[0] == "Bearer" {
+		if claims, err := h.authService.ValidateToken(parts[1]); err == nil && claims.ID != "" {
+			logger.AuthEvent("logout", claims.Username, c.ClientIP(), true, "user_id", claims.UserID)
+			if err := h.authService.RevokeClaims(c.Request.Context(), claims); err != nil {
+				logger.Warn("Failed to revoke access token on logout", "user_id", claims.UserID, "error", err)
+			}
+		}
+	}
 	// Best-effort refresh token revocation and cookie clear
 	if cookie, err := c.Cookie("scriberr_refresh_token"); err == nil {
 		h.revokeRefreshToken(cookie)
@@ -1684,6 +2728,11 @@ func (h *Handler) Logout(c *gin.Context) {
 		SameSite: http.SameSiteLaxMode,
 		Secure:   false,
 	})
+	// Behind an authenticating proxy, the proxy's session must end too
+	if url := h.config.HeaderAuth.LogoutURL; h.config.HeaderAuth.Enabled && url != "" {
+		c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully", "redirect_url": url})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
 
@@ -1694,90 +2743,90 @@ func (h *Handler) Logout(c *gin.Context) {
 // @Success 200 {object} RegistrationStatusResponse
 // @Router /api/v1/auth/registration-status [get]
 func (h *Handler) GetRegistrationStatus(c *gin.Context) {
-	var userCount int64
-	if err := database.DB.Model(&models.User{}).Count(&userCount).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check registration status"})
+	completed, err := h.setupCompleted(c)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to check registration status").WithCause(err))
 		return
 	}
 
 	response := RegistrationStatusResponse{
-		RegistrationEnabled: userCount == 0,
+		RegistrationEnabled: !completed,
+		OIDCEnabled:         h.oidc.Enabled(),
+		HeaderAuthEnabled:   h.config.HeaderAuth.Enabled,
+		LogoutURL:           h.config.HeaderAuth.LogoutURL,
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
 // @Summary Register initial admin user
-// @Description Register the initial admin user (only allowed when no users exist)
+// @Description Register the initial admin user, completing setup like POST /api/v1/setup (only allowed until setup has finished)
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body RegisterRequest true "Registration details"
 // @Success 201 {object} LoginResponse
-// @Failure 400 {object} map[string]string
-// @Failure 409 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope
 // @Router /api/v1/auth/register [post]
 func (h *Handler) Register(c *gin.Context) {
-	// Check if any users already exist
-	var userCount int64
-	if err := database.DB.Model(&models.User{}).Count(&userCount).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing users"})
+	completed, err := h.setupCompleted(c)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to check existing users").WithCause(err))
 		return
 	}
-
-	if userCount > 0 {
-		c.JSON(http.StatusConflict, gin.H{"error": "Registration is not allowed. Admin user already exists"})
+	if completed {
+		apierror.Abort(c, apierror.Conflict("Registration is not allowed. Admin user already exists"))
 		return
 	}
 
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
 		return
 	}
 
 	// Validate password confirmation
 	if req.Password != req.ConfirmPassword {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Passwords do not match"})
+		apierror.Abort(c, apierror.BadRequest("Passwords do not match"))
 		return
 	}
-
-	// Hash password
-	hashedPassword, err := auth.HashPassword(req.Password)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to secure password"})
+	if err := auth.ValidatePassword(req.Password, req.Username); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
 		return
 	}
 
-	// Create user
-	user := models.User{
-		Username: req.Username,
-		Password: hashedPassword,
+	// Registering the first admin completes setup
+	user, err := auth.CompleteSetup(c.Request.Context(), req.Username, req.Password, nil)
+	if errors.Is(err, auth.ErrSetupCompleted) {
+		apierror.Abort(c, apierror.Conflict("Registration is not allowed. Admin user already exists"))
+		return
 	}
-
-	if err := database.DB.Create(&user).Error; err != nil {
-		if database.DB.Error.Error() == "UNIQUE constraint failed: users.username" {
-			c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to create user").WithCause(err))
 		return
 	}
+	h.setupDone.Store(true)
+	h.loginNewAccount(c, user)
+}
 
-	// Generate token for immediate login
-	token, err := h.authService.GenerateToken(&user)
+// loginNewAccount answers a request that created an account with a 201 and
+// a token and refresh cookie logging the user in
+func (h *Handler) loginNewAccount(c *gin.Context, user *models.User) {
+	token, err := h.authService.GenerateToken(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate login token"})
+		apierror.Abort(c, apierror.Internal("Failed to generate login token"))
 		return
 	}
 	// Set refresh token cookie
 	if err := h.issueRefreshToken(c, user.ID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		apierror.Abort(c, apierror.Internal("Failed to create session"))
 		return
 	}
 	response := LoginResponse{Token: token}
 	response.User.ID = user.ID
 	response.User.Username = user.Username
+	response.User.Role = user.Role
 
 	c.JSON(http.StatusCreated, response)
 }
@@ -1792,40 +2841,55 @@ type RefreshTokenResponse struct {
 // @Tags auth
 // @Produce json
 // @Success 200 {object} RefreshTokenResponse
-// @Failure 401 {object} map[string]string
+// @Failure 401 {object} apierror.Envelope
 // @Router /api/v1/auth/refresh [post]
 func (h *Handler) Refresh(c *gin.Context) {
 	cookie, err := c.Cookie("scriberr_refresh_token")
 	if err != nil || cookie == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing refresh token"})
+		apierror.Abort(c, apierror.Unauthorized("Missing refresh token"))
 		return
 	}
 	userID, err := h.validateAndRotateRefreshToken(c, cookie)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		apierror.Abort(c, apierror.Unauthorized("Invalid refresh token"))
 		return
 	}
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		apierror.Abort(c, apierror.Unauthorized("User not found"))
+		return
+	}
+	if user.Disabled {
+		logger.AuthEvent("refresh", user.Username, c.ClientIP(), false, logger.String("reason", "account_disabled"), "user_id", user.ID)
+		apierror.Abort(c, apierror.Unauthorized("Account is disabled"))
 		return
 	}
 	token, err := h.authService.GenerateToken(&user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		apierror.Abort(c, apierror.Internal("Failed to generate token"))
 		return
 	}
+	logger.AuthEvent("refresh", user.Username, c.ClientIP(), true, "user_id", user.ID)
 	c.JSON(http.StatusOK, RefreshTokenResponse{Token: token})
 }
 
+// refreshTokenTTL returns the configured refresh token lifetime
+func (h *Handler) refreshTokenTTL() time.Duration {
+	if h.config.RefreshTokenTTL > 0 {
+		return h.config.RefreshTokenTTL
+	}
+	return config.DefaultRefreshTokenTTL
+}
+
 // issueRefreshToken creates a refresh token and sets cookie
 func (h *Handler) issueRefreshToken(c *gin.Context, userID uint) error {
+	ttl := h.refreshTokenTTL()
 	tokenValue := generateSecureAPIKey(64)
 	hashed := sha256Hex(tokenValue)
 	rt := models.RefreshToken{
 		UserID:    userID,
 		Hashed:    hashed,
-		ExpiresAt: time.Now().Add(14 * 24 * time.Hour),
+		ExpiresAt: time.Now().Add(ttl),
 		Revoked:   false,
 	}
 	if err := database.DB.Create(&rt).Error; err != nil {
@@ -1836,7 +2900,7 @@ func (h *Handler) issueRefreshToken(c *gin.Context, userID uint) error {
 		Value:    tokenValue,
 		Path:     "/",
 		Expires:  rt.ExpiresAt,
-		MaxAge:   int((14 * 24 * time.Hour).Seconds()),
+		MaxAge:   int(ttl.Seconds()),
 		HttpOnly: true,
 		SameSite: http.SameSiteLaxMode,
 		Secure:   false,
@@ -1854,8 +2918,16 @@ func (h *Handler) validateAndRotateRefreshToken(c *gin.Context, tokenValue strin
 	if rt.Revoked || time.Now().After(rt.ExpiresAt) {
 		return 0, fmt.Errorf("expired or revoked")
 	}
-	// Revoke current
-	_ = database.DB.Model(&rt).Update("revoked", true).Error
+	// Revoke current; the revoked=false guard makes concurrent reuse of the same token fail
+	result := database.DB.Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked = ?", rt.ID, false).
+		Update("revoked", true)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, fmt.Errorf("refresh token already used")
+	}
 	// Issue new
 	if err := h.issueRefreshToken(c, rt.UserID); err != nil {
 		return 0, err
@@ -1873,64 +2945,199 @@ func sha256Hex(s string) string {
 	return hex.EncodeToString(sum[:])
 }
 
+// OIDC flow cookies carry the state and nonce from login to callback
+const (
+	oidcStateCookie  = "scriberr_oidc_state"
+	oidcNonceCookie  = "scriberr_oidc_nonce"
+	oidcCookiePath   = "/api/v1/auth/oidc"
+	oidcCookieMaxAge = 10 * 60
+)
+
+// @Summary Start OIDC login
+// @Description Redirect the browser to the configured OpenID Connect provider
+// @Tags auth
+// @Success 302
+// @Failure 404 {object} apierror.Envelope
+// @Failure 502 {object} apierror.Envelope
+// @Router /api/v1/auth/oidc/login [get]
+func (h *Handler) OIDCLogin(c *gin.Context) {
+	if !h.oidc.Enabled() {
+		apierror.Abort(c, apierror.NotFound("OIDC login is not configured"))
+		return
+	}
+
+	state := generateSecureAPIKey(32)
+	nonce := generateSecureAPIKey(32)
+	redirectURL, err := h.oidc.AuthCodeURL(c.Request.Context(), state, nonce)
+	if err != nil {
+		logger.Error("Failed to start OIDC login", "error", err)
+		apierror.Abort(c, apierror.New(http.StatusBadGateway, apierror.CodeUpstreamFailed, "OIDC provider is unavailable"))
+		return
+	}
+
+	setOIDCCookie(c, oidcStateCookie, state, oidcCookieMaxAge)
+	setOIDCCookie(c, oidcNonceCookie, nonce, oidcCookieMaxAge)
+	c.Redirect(http.StatusFound, redirectURL)
+}
+
+// @Summary Complete OIDC login
+// @Description Exchange the provider's authorization code, create or update the linked user and return a JWT token
+// @Tags auth
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State returned by the provider"
+// @Success 200 {object} LoginResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 401 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Router /api/v1/auth/oidc/callback [get]
+func (h *Handler) OIDCCallback(c *gin.Context) {
+	if !h.oidc.Enabled() {
+		apierror.Abort(c, apierror.NotFound("OIDC login is not configured"))
+		return
+	}
+
+	if errParam := c.Query("error"); errParam != "" {
+		logger.AuthEvent("oidc_login", "", c.ClientIP(), false, logger.String("reason", errParam))
+		apierror.Abort(c, apierror.Unauthorized("OIDC login was denied: "+errParam))
+		return
+	}
+
+	state, stateErr := c.Cookie(oidcStateCookie)
+	nonce, nonceErr := c.Cookie(oidcNonceCookie)
+	setOIDCCookie(c, oidcStateCookie, "", -1)
+	setOIDCCookie(c, oidcNonceCookie, "", -1)
+	if stateErr != nil || nonceErr != nil || state == "" || c.Query("state") != state {
+		apierror.Abort(c, apierror.Validation("Invalid OIDC state"))
+		return
+	}
+	code := c.Query("code")
+	if code == "" {
+		apierror.Abort(c, apierror.Validation("Missing authorization code"))
+		return
+	}
+
+	identity, err := h.oidc.Exchange(c.Request.Context(), code, nonce)
+	if err != nil {
+		logger.AuthEvent("oidc_login", "", c.ClientIP(), false, logger.String("reason", err.Error()))
+		apierror.Abort(c, apierror.Unauthorized("OIDC authentication failed"))
+		return
+	}
+
+	user, err := auth.UpsertOIDCUser(c.Request.Context(), identity)
+	if errors.Is(err, auth.ErrAccountDisabled) {
+		logger.AuthEvent("oidc_login", identity.Subject, c.ClientIP(), false, logger.String("reason", "account_disabled"))
+		apierror.Abort(c, apierror.Forbidden("Account is disabled"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to link OIDC user", "subject", identity.Subject, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to create user"))
+		return
+	}
+
+	token, err := h.authService.GenerateToken(user)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to generate token"))
+		return
+	}
+	if err := h.issueRefreshToken(c, user.ID); err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to create session"))
+		return
+	}
+
+	response := LoginResponse{Token: token}
+	response.User.ID = user.ID
+	response.User.Username = user.Username
+	response.User.Role = user.Role
+
+	logger.AuthEvent("oidc_login", user.Username, c.ClientIP(), true, "user_id", user.ID, "role", user.Role)
+	c.JSON(http.StatusOK, response)
+}
+
+// setOIDCCookie sets or, with a negative maxAge, clears a short-lived OIDC flow cookie
+func setOIDCCookie(c *gin.Context, name, value string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     oidcCookiePath,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
 // @Summary Change user password
-// @Description Change the current user's password
+// @Description Change the current user's password. The new password must meet the password policy. Every other session of the user is ended; the response carries a new access token and refresh cookie for this one.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body ChangePasswordRequest true "Password change details"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 401 {object} apierror.Envelope
+// @Failure 429 {object} apierror.Envelope
 // @Security BearerAuth
 // @Router /api/v1/auth/change-password [post]
 func (h *Handler) ChangePassword(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierror.Abort(c, apierror.Unauthorized("User not authenticated"))
+		return
+	}
+	if !h.allowPasswordAttempt(c, "change", fmt.Sprint(userID)) {
 		return
 	}
 
 	var req ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
 		return
 	}
 
 	// Validate password confirmation
 	if req.NewPassword != req.ConfirmPassword {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "New passwords do not match"})
+		apierror.Abort(c, apierror.BadRequest("New passwords do not match"))
 		return
 	}
 
 	// Get current user
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		apierror.Abort(c, apierror.Unauthorized("User not found"))
 		return
 	}
 
 	// Verify current password
 	if !auth.CheckPassword(req.CurrentPassword, user.Password) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Current password is incorrect"})
+		logger.AuthEvent("password_change", user.Username, c.ClientIP(), false, logger.String("reason", "invalid_current_password"), "user_id", user.ID)
+		apierror.Abort(c, apierror.BadRequest("Current password is incorrect"))
+		return
+	}
+	if err := auth.ValidatePassword(req.NewPassword, user.Username); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
 		return
 	}
 
-	// Hash new password
-	hashedPassword, err := auth.HashPassword(req.NewPassword)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to secure new password"})
+	if err := auth.SetPassword(c.Request.Context(), user.ID, req.NewPassword); err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to update password"))
 		return
 	}
 
-	// Update password
-	if err := database.DB.Model(&user).Update("password", hashedPassword).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+	// Keep this session going with a token issued after the change
+	token, err := h.authService.GenerateToken(&user)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to generate token"))
+		return
+	}
+	if err := h.issueRefreshToken(c, user.ID); err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to create session"))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+	logger.AuthEvent("password_change", user.Username, c.ClientIP(), true, "user_id", user.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully", "token": token})
 }
 
 // @Summary Change username
@@ -1940,35 +3147,35 @@ func (h *Handler) ChangePassword(c *gin.Context) {
 // @Produce json
 // @Param request body ChangeUsernameRequest true "Username change details"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 409 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 401 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope
 // @Security BearerAuth
 // @Router /api/v1/auth/change-username [post]
 func (h *Handler) ChangeUsername(c *gin.Context) {
 	// Get user ID from context (set by auth middleware)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierror.Abort(c, apierror.Unauthorized("User not authenticated"))
 		return
 	}
 
 	var req ChangeUsernameRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
 		return
 	}
 
 	// Get current user
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		apierror.Abort(c, apierror.Unauthorized("User not found"))
 		return
 	}
 
 	// Verify password
 	if !auth.CheckPassword(req.Password, user.Password) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Password is incorrect"})
+		apierror.Abort(c, apierror.BadRequest("Password is incorrect"))
 		return
 	}
 
@@ -1976,13 +3183,13 @@ func (h *Handler) ChangeUsername(c *gin.Context) {
 	var existingUser models.User
 	result := database.DB.Where("username = ? AND id != ?", req.NewUsername, userID).First(&existingUser)
 	if result.Error == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
+		apierror.Abort(c, apierror.Conflict("Username already exists"))
 		return
 	}
 
 	// Update username
 	if err := database.DB.Model(&user).Update("username", req.NewUsername).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update username"})
+		apierror.Abort(c, apierror.Internal("Failed to update username"))
 		return
 	}
 
@@ -1998,8 +3205,8 @@ func (h *Handler) ChangeUsername(c *gin.Context) {
 // @Router /api/v1/api-keys [get]
 func (h *Handler) ListAPIKeys(c *gin.Context) {
 	var apiKeys []models.APIKey
-	if err := database.DB.Where("is_active = ?", true).Find(&apiKeys).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+	if err := ownedAPIKeys(c).Where("is_active = ?", true).Find(&apiKeys).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to fetch API keys"))
 		return
 	}
 
@@ -2012,41 +3219,68 @@ func (h *Handler) ListAPIKeys(c *gin.Context) {
 	c.JSON(http.StatusOK, APIKeysWrapper{APIKeys: responseKeys})
 }
 
+// @Summary Get API key
+// @Description Get a single API key owned by the current user (without exposing the actual key)
+// @Tags api-keys
+// @Produce json
+// @Param id path int true "API Key ID"
+// @Success 200 {object} APIKeyListResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Security BearerAuth
+// @Router /api/v1/api-keys/{id} [get]
+func (h *Handler) GetAPIKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid API key ID"))
+		return
+	}
+
+	var apiKey models.APIKey
+	if err := ownedAPIKeys(c).First(&apiKey, uint(id)).Error; err != nil {
+		apierror.Abort(c, apierror.NotFound("API key not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, transformAPIKeyForList(apiKey))
+}
+
 // @Summary Create API key
-// @Description Create a new API key for external API access
+// @Description Create a new API key for external API access. The key is returned once and cannot be retrieved later. Scopes limit what the key may do: read allows GET requests, write also allows changes, and admin also allows the admin routes of an admin's key. A key without scopes may read and write.
 // @Tags api-keys
 // @Accept json
 // @Produce json
 // @Param request body CreateAPIKeyRequest true "API key creation details"
-// @Success 201 {object} CreateAPIKeyResponse
-// @Failure 400 {object} map[string]string
+// @Success 200 {object} CreateAPIKeyResponse
+// @Failure 400 {object} apierror.Envelope
 // @Security BearerAuth
 // @Router /api/v1/api-keys [post]
 func (h *Handler) CreateAPIKey(c *gin.Context) {
 	var req CreateAPIKeyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
 		return
 	}
 
-	// Generate a secure API key
-	apiKey := generateSecureAPIKey(32)
-
-	// Create the API key record
-	newKey := models.APIKey{
-		Key:         apiKey,
-		Name:        req.Name,
-		Description: &req.Description,
-		IsActive:    true,
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	newKey, plaintext, err := h.authService.CreateAPIKey(c.Request.Context(), c.GetUint("user_id"), req.Name, req.Description, req.Scopes, ttl)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to create API key"))
+		return
 	}
 
-	if err := database.DB.Create(&newKey).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
-		return
+	response := CreateAPIKeyResponse{
+		ID:          newKey.ID,
+		Key:         plaintext,
+		Name:        newKey.Name,
+		Description: req.Description,
+		Scopes:      newKey.ScopeList(),
+	}
+	if newKey.ExpiresAt != nil {
+		response.ExpiresAt = newKey.ExpiresAt.Format(time.RFC3339)
 	}
 
-	// Return full model with 200 to match tests
-	c.JSON(http.StatusOK, newKey)
+	c.JSON(http.StatusOK, response)
 }
 
 // @Summary Delete API key
@@ -2054,50 +3288,60 @@ func (h *Handler) CreateAPIKey(c *gin.Context) {
 // @Tags api-keys
 // @Param id path int true "API Key ID"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
 // @Security BearerAuth
 // @Router /api/v1/api-keys/{id} [delete]
 func (h *Handler) DeleteAPIKey(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		apierror.Abort(c, apierror.Validation("Invalid API key ID"))
 		return
 	}
 
 	// Check if the API key exists
 	var apiKey models.APIKey
-	if err := database.DB.First(&apiKey, uint(id)).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+	if err := ownedAPIKeys(c).First(&apiKey, uint(id)).Error; err != nil {
+		apierror.Abort(c, apierror.NotFound("API key not found"))
 		return
 	}
 
 	// Delete the API key (soft delete by setting is_active to false)
 	if err := database.DB.Model(&apiKey).Update("is_active", false).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete API key"})
+		apierror.Abort(c, apierror.Internal("Failed to delete API key"))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "API key deleted successfully"})
 }
 
+// ownedAPIKeys scopes an API key query to the current user. Admins also see
+// keys created before keys had owners.
+func ownedAPIKeys(c *gin.Context) *gorm.DB {
+	userID := c.GetUint("user_id")
+	if c.GetString("role") == models.RoleAdmin {
+		return database.DB.Where("user_id = ? OR user_id IS NULL", userID)
+	}
+	return database.DB.Where("user_id = ?", userID)
+}
+
 // @Summary Get LLM configuration
 // @Description Get the current active LLM configuration
 // @Tags llm
 // @Produce json
 // @Success 200 {object} LLMConfigResponse
-// @Failure 404 {object} map[string]string
+// @Failure 404 {object} apierror.Envelope
 // @Security BearerAuth
 // @Router /api/v1/llm/config [get]
 func (h *Handler) GetLLMConfig(c *gin.Context) {
 	var config models.LLMConfig
 	if err := database.DB.Where("is_active = ?", true).First(&config).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "No active LLM configuration found"})
+			apierror.Abort(c, apierror.NotFound("No active LLM configuration found"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch LLM configuration"})
+		apierror.Abort(c, apierror.Internal("Failed to fetch LLM configuration"))
 		return
 	}
 
@@ -2121,23 +3365,23 @@ func (h *Handler) GetLLMConfig(c *gin.Context) {
 // @Produce json
 // @Param request body LLMConfigRequest true "LLM configuration details"
 // @Success 200 {object} LLMConfigResponse
-// @Failure 400 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
 // @Security BearerAuth
 // @Router /api/v1/llm/config [post]
 func (h *Handler) SaveLLMConfig(c *gin.Context) {
 	var req LLMConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
 		return
 	}
 
 	// Validate provider-specific requirements
 	if req.Provider == "ollama" && (req.BaseURL == nil || *req.BaseURL == "") {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Base URL is required for Ollama provider"})
+		apierror.Abort(c, apierror.Validation("Base URL is required for Ollama provider"))
 		return
 	}
 	if req.Provider == "openai" && (req.APIKey == nil || *req.APIKey == "") {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "API key is required for OpenAI provider"})
+		apierror.Abort(c, apierror.Validation("API key is required for OpenAI provider"))
 		return
 	}
 
@@ -2146,7 +3390,7 @@ func (h *Handler) SaveLLMConfig(c *gin.Context) {
 	err := database.DB.Where("is_active = ?", true).First(&existingConfig).Error
 
 	if err != nil && err != gorm.ErrRecordNotFound {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing configuration"})
+		apierror.Abort(c, apierror.Internal("Failed to check existing configuration"))
 		return
 	}
 
@@ -2162,7 +3406,7 @@ func (h *Handler) SaveLLMConfig(c *gin.Context) {
 		}
 
 		if err := database.DB.Create(&config).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create LLM configuration"})
+			apierror.Abort(c, apierror.Internal("Failed to create LLM configuration"))
 			return
 		}
 	} else {
@@ -2173,7 +3417,7 @@ func (h *Handler) SaveLLMConfig(c *gin.Context) {
 		existingConfig.IsActive = req.IsActive
 
 		if err := database.DB.Save(&existingConfig).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update LLM configuration"})
+			apierror.Abort(c, apierror.Internal("Failed to update LLM configuration"))
 			return
 		}
 		config = existingConfig
@@ -2240,7 +3484,8 @@ func (h *Handler) GetSupportedModels(c *gin.Context) {
 	})
 }
 
-// Health check endpoint
+// HealthCheck reports that the server is up. Kept for older probes; prefer
+// /api/health and /api/health/ready.
 // @Summary Health check
 // @Description Check if the API is healthy
 // @Tags health
@@ -2254,6 +3499,22 @@ func (h *Handler) HealthCheck(c *gin.Context) {
 	})
 }
 
+// Metrics endpoint
+// @Summary Metrics
+// @Description Service metrics, such as GPU memory, queue depth and transcription throughput, in the Prometheus text format
+// @Tags health
+// @Produce plain
+// @Success 200 {string} string "Prometheus metrics"
+// @Router /metrics [get]
+func (h *Handler) Metrics(c *gin.Context) {
+	h.updateStatsGauges()
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := metrics.WriteText(c.Writer); err != nil {
+		logger.Error("Failed to write metrics", "error", err)
+	}
+}
+
 // Helper functions
 func getFormValueWithDefault(c *gin.Context, key, defaultValue string) string {
 	if value := c.PostForm(key); value != "" {
@@ -2292,7 +3553,7 @@ func getFormBoolWithDefault(c *gin.Context, key string, defaultValue bool) bool
 // Profile API Handlers
 
 // @Summary List transcription profiles
-// @Description Get list of all transcription profiles
+// @Description Get the caller's transcription profiles and the shared ones
 // @Tags profiles
 // @Produce json
 // @Success 200 {array} models.TranscriptionProfile
@@ -2301,46 +3562,57 @@ func getFormBoolWithDefault(c *gin.Context, key string, defaultValue bool) bool
 // @Security BearerAuth
 func (h *Handler) ListProfiles(c *gin.Context) {
 	var profiles []models.TranscriptionProfile
-	if err := database.DB.Order("created_at DESC").Find(&profiles).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch profiles"})
+	if err := visibleProfiles(c).Order("created_at DESC").Find(&profiles).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to fetch profiles"))
 		return
 	}
 	c.JSON(http.StatusOK, profiles)
 }
 
 // @Summary Create transcription profile
-// @Description Create a new transcription profile
+// @Description Create a new transcription profile owned by the caller
 // @Tags profiles
 // @Accept json
 // @Produce json
 // @Param profile body models.TranscriptionProfile true "Profile data"
 // @Success 201 {object} models.TranscriptionProfile
-// @Failure 400 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
 // @Router /api/v1/profiles [post]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (h *Handler) CreateProfile(c *gin.Context) {
 	var profile models.TranscriptionProfile
 	if err := c.ShouldBindJSON(&profile); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		apierror.Abort(c, apierror.Validation("Invalid request data"))
 		return
 	}
+	profile.ID = ""
+	profile.UserID = nil
+	if userID, ok := currentUserID(c); ok {
+		profile.UserID = &userID
+	}
 
 	// Validate required fields
 	if profile.Name == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Profile name is required"})
+		apierror.Abort(c, apierror.Validation("Profile name is required"))
+		return
+	}
+	if err := profile.Parameters.ValidateDiarization(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	if !validJobOptions(c, profile.Parameters) {
 		return
 	}
 
-	// Check if profile name already exists
-	var existingProfile models.TranscriptionProfile
-	if err := database.DB.Where("name = ?", profile.Name).First(&existingProfile).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Profile name already exists"})
+	// Check if the owner already has a profile with this name
+	if taken, err := profileNameTaken(&profile); err != nil || taken {
+		apierror.Abort(c, apierror.BadRequest("Profile name already exists"))
 		return
 	}
 
 	if err := database.DB.Create(&profile).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create profile"})
+		apierror.Abort(c, apierror.Internal("Failed to create profile"))
 		return
 	}
 
@@ -2354,20 +3626,13 @@ func (h *Handler) CreateProfile(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Profile ID"
 // @Success 200 {object} models.TranscriptionProfile
-// @Failure 404 {object} map[string]string
+// @Failure 404 {object} apierror.Envelope
 // @Router /api/v1/profiles/{id} [get]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (h *Handler) GetProfile(c *gin.Context) {
-	profileID := c.Param("id")
-
-	var profile models.TranscriptionProfile
-	if err := database.DB.Where("id = ?", profileID).First(&profile).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get profile"})
+	profile, ok := findProfile(c, c.Param("id"))
+	if !ok {
 		return
 	}
 
@@ -2375,54 +3640,58 @@ func (h *Handler) GetProfile(c *gin.Context) {
 }
 
 // @Summary Update transcription profile
-// @Description Update a transcription profile
+// @Description Update a transcription profile. Shared profiles can only be changed by admins.
 // @Tags profiles
 // @Accept json
 // @Produce json
 // @Param id path string true "Profile ID"
 // @Param profile body models.TranscriptionProfile true "Updated profile data"
 // @Success 200 {object} models.TranscriptionProfile
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 403 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
 // @Router /api/v1/profiles/{id} [put]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (h *Handler) UpdateProfile(c *gin.Context) {
-	profileID := c.Param("id")
-
-	var existingProfile models.TranscriptionProfile
-	if err := database.DB.Where("id = ?", profileID).First(&existingProfile).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get profile"})
+	existingProfile, ok := findProfile(c, c.Param("id"))
+	if !ok || !canModifyProfile(c, existingProfile) {
 		return
 	}
 
 	var updatedProfile models.TranscriptionProfile
 	if err := c.ShouldBindJSON(&updatedProfile); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+		apierror.Abort(c, apierror.Validation("Invalid request data"))
 		return
 	}
 
 	// Validate required fields
 	if updatedProfile.Name == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Profile name is required"})
+		apierror.Abort(c, apierror.Validation("Profile name is required"))
+		return
+	}
+	if err := updatedProfile.Parameters.ValidateDiarization(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	if !validJobOptions(c, updatedProfile.Parameters) {
 		return
 	}
 
-	// Check if profile name already exists (excluding current profile)
-	var nameCheck models.TranscriptionProfile
-	if err := database.DB.Where("name = ? AND id != ?", updatedProfile.Name, profileID).First(&nameCheck).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Profile name already exists"})
+	// Ensure the ID and owner don't change
+	updatedProfile.ID = existingProfile.ID
+	updatedProfile.UserID = existingProfile.UserID
+	updatedProfile.CreatedAt = existingProfile.CreatedAt
+
+	// Check if the owner already has another profile with this name
+	if taken, err := profileNameTaken(&updatedProfile); err != nil || taken {
+		apierror.Abort(c, apierror.BadRequest("Profile name already exists"))
 		return
 	}
 
 	// Update the profile
-	updatedProfile.ID = profileID // Ensure ID doesn't change
 	if err := database.DB.Save(&updatedProfile).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		apierror.Abort(c, apierror.Internal("Failed to update profile"))
 		return
 	}
 
@@ -2430,30 +3699,42 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 }
 
 // @Summary Delete transcription profile
-// @Description Delete a transcription profile
+// @Description Delete a transcription profile. Profiles used by watch folders cannot be deleted; the 409 response lists them.
 // @Tags profiles
 // @Produce json
 // @Param id path string true "Profile ID"
 // @Success 200 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 403 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope "Profile is in use"
 // @Router /api/v1/profiles/{id} [delete]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (h *Handler) DeleteProfile(c *gin.Context) {
-	profileID := c.Param("id")
+	profile, ok := findProfile(c, c.Param("id"))
+	if !ok || !canModifyProfile(c, profile) {
+		return
+	}
 
-	var profile models.TranscriptionProfile
-	if err := database.DB.Where("id = ?", profileID).First(&profile).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get profile"})
+	dependents, err := profileDependents(profile.ID)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to check profile usage"))
+		return
+	}
+	if len(dependents) > 0 {
+		apierror.Abort(c, apierror.Conflict("Profile is used by watch folders").WithDetails(map[string]any{"dependents": dependents}))
 		return
 	}
 
-	if err := database.DB.Delete(&profile).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete profile"})
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Users falling back from a deleted default use the shared defaults
+		if err := tx.Model(&models.User{}).Where("default_profile_id = ?", profile.ID).Update("default_profile_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(profile).Error
+	})
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to delete profile"))
 		return
 	}
 
@@ -2462,39 +3743,35 @@ func (h *Handler) DeleteProfile(c *gin.Context) {
 
 // SetDefaultProfile sets a profile as the default profile
 // @Summary Set default transcription profile
-// @Description Mark the specified profile as the default profile
+// @Description Mark the specified profile as the default among its owner's profiles, or among the shared profiles
 // @Tags profiles
 // @Produce json
 // @Param id path string true "Profile ID"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 403 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
 // @Security ApiKeyAuth
 // @Security BearerAuth
 // @Router /api/v1/profiles/{id}/set-default [post]
 func (h *Handler) SetDefaultProfile(c *gin.Context) {
 	profileID := c.Param("id")
 	if profileID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Profile ID is required"})
+		apierror.Abort(c, apierror.Validation("Profile ID is required"))
 		return
 	}
 
 	// Find the profile
-	var profile models.TranscriptionProfile
-	if err := database.DB.Where("id = ?", profileID).First(&profile).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get profile"})
+	profile, ok := findProfile(c, profileID)
+	if !ok || !canModifyProfile(c, profile) {
 		return
 	}
 
 	// Set this profile as default (the BeforeSave hook will handle unsetting other defaults)
 	profile.IsDefault = true
-	if err := database.DB.Save(&profile).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set default profile"})
+	if err := database.DB.Save(profile).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to set default profile"))
 		return
 	}
 
@@ -2516,8 +3793,10 @@ type QuickTranscriptionRequest struct {
 // @Param parameters formData string false "JSON string of transcription parameters"
 // @Param profile_name formData string false "Profile name to use for transcription"
 // @Success 200 {object} transcription.QuickTranscriptionJob
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 413 {object} apierror.Envelope "Body is larger than MAX_UPLOAD_SIZE_MB"
+// @Failure 415 {object} apierror.Envelope "Not an accepted audio or video type"
+// @Failure 500 {object} apierror.Envelope
 // @Router /api/v1/transcription/quick [post]
 // @Security ApiKeyAuth
 // @Security BearerAuth
@@ -2525,7 +3804,7 @@ func (h *Handler) SubmitQuickTranscription(c *gin.Context) {
 	// Parse multipart form
 	file, header, err := c.Request.FormFile("audio")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Audio file is required"})
+		apierror.Abort(c, apierror.Validation("Audio file is required"))
 		return
 	}
 	defer file.Close()
@@ -2536,19 +3815,19 @@ func (h *Handler) SubmitQuickTranscription(c *gin.Context) {
 	if profileName := c.PostForm("profile_name"); profileName != "" {
 		// Load parameters from profile
 		var profile models.TranscriptionProfile
-		if err := database.DB.Where("name = ?", profileName).First(&profile).Error; err != nil {
+		if err := visibleProfiles(c).Where("name = ?", profileName).Order("user_id IS NULL").First(&profile).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
-				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Profile '%s' not found", profileName)})
+				apierror.Abort(c, apierror.BadRequest(fmt.Sprintf("Profile '%s' not found", profileName)))
 				return
 			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load profile"})
+			apierror.Abort(c, apierror.Internal("Failed to load profile"))
 			return
 		}
 		params = profile.Parameters
 	} else if parametersJSON := c.PostForm("parameters"); parametersJSON != "" {
 		// Parse parameters from JSON string
 		if err := json.Unmarshal([]byte(parametersJSON), &params); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parameters JSON"})
+			apierror.Abort(c, apierror.Validation("Invalid parameters JSON"))
 			return
 		}
 	} else {
@@ -2561,8 +3840,8 @@ func (h *Handler) SubmitQuickTranscription(c *gin.Context) {
 			// Device and computation
 			Device:      "cpu",
 			DeviceIndex: 0,
-			BatchSize:   8,
-			ComputeType: "float32",
+			BatchSize:   transcription.DefaultBatchSizeForDevice("cpu"),
+			ComputeType: models.DefaultComputeType("cpu"),
 			Threads:     0,
 
 			// Output settings
@@ -2609,10 +3888,48 @@ func (h *Handler) SubmitQuickTranscription(c *gin.Context) {
 		}
 	}
 
+	if err := params.ValidateDiarization(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	if err := params.ValidateVAD(); err != nil {
+		apierror.Abort(c, apierror.Unprocessable(err.Error()))
+		return
+	}
+	if err := params.ValidateComputeType(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	if err := params.ValidateBatchSize(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	if err := params.ValidateThreads(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	if err := params.ValidateBackend(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+
+	var validationErr *transcription.ValidationError
+	if err := transcription.CheckMediaType(header.Filename, file); errors.As(err, &validationErr) {
+		apierror.Abort(c, apierror.UnsupportedMedia(validationErr.Message))
+		return
+	} else if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to read audio file").WithCause(err))
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to read audio file").WithCause(err))
+		return
+	}
+
 	// Submit quick transcription job
 	job, err := h.quickTranscription.SubmitQuickJob(file, header.Filename, params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to submit quick transcription: %v", err)})
+		apierror.Abort(c, apierror.Internal("Failed to submit quick transcription").WithCause(err))
 		return
 	}
 
@@ -2625,7 +3942,7 @@ func (h *Handler) SubmitQuickTranscription(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Job ID"
 // @Success 200 {object} transcription.QuickTranscriptionJob
-// @Failure 404 {object} map[string]string
+// @Failure 404 {object} apierror.Envelope
 // @Router /api/v1/transcription/quick/{id} [get]
 // @Security ApiKeyAuth
 // @Security BearerAuth
@@ -2635,10 +3952,10 @@ func (h *Handler) GetQuickTranscriptionStatus(c *gin.Context) {
 	job, err := h.quickTranscription.GetQuickJob(jobID)
 	if err != nil {
 		if err.Error() == "job not found" || err.Error() == "job expired" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			apierror.Abort(c, apierror.NotFound(err.Error()))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get job status"})
+		apierror.Abort(c, apierror.Internal("Failed to get job status"))
 		return
 	}
 
@@ -2651,29 +3968,34 @@ func (h *Handler) GetQuickTranscriptionStatus(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body YouTubeDownloadRequest true "YouTube download request"
-// @Success 200 {object} models.Transcription
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Success 200 {object} models.TranscriptionJob
+// @Failure 400 {object} apierror.Envelope
+// @Failure 403 {object} apierror.Envelope "Host is not allowed"
+// @Failure 422 {object} apierror.Envelope "File is not transcribable audio"
+// @Failure 500 {object} apierror.Envelope
 // @Router /api/v1/transcription/youtube [post]
 // @Security ApiKeyAuth
 // @Security BearerAuth
 func (h *Handler) DownloadFromYouTube(c *gin.Context) {
 	var req YouTubeDownloadRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Validation(err.Error()))
 		return
 	}
 
 	// Validate YouTube URL
 	if !strings.Contains(req.URL, "youtube.com") && !strings.Contains(req.URL, "youtu.be") {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid YouTube URL"})
+		apierror.Abort(c, apierror.Validation("Invalid YouTube URL"))
+		return
+	}
+	if _, ok := h.checkMediaURL(c, req.URL); !ok {
 		return
 	}
 
 	// Create upload directory
 	uploadDir := h.config.UploadDir
 	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload directory"})
+		apierror.Abort(c, apierror.Internal("Failed to create upload directory"))
 		return
 	}
 
@@ -2709,7 +4031,7 @@ func (h *Handler) DownloadFromYouTube(c *gin.Context) {
 
 	// Execute download
 	if err := ytDlpCmd.Run(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to download YouTube audio: %v", err)})
+		apierror.Abort(c, apierror.Internal("Failed to download YouTube audio").WithCause(err))
 		return
 	}
 
@@ -2717,29 +4039,42 @@ func (h *Handler) DownloadFromYouTube(c *gin.Context) {
 	pattern := fmt.Sprintf("%s.*", jobID)
 	matches, err := filepath.Glob(filepath.Join(uploadDir, pattern))
 	if err != nil || len(matches) == 0 {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Downloaded file not found"})
+		apierror.Abort(c, apierror.Internal("Downloaded file not found"))
 		return
 	}
 
 	actualFilePath := matches[0]
 
+	if !h.validateUpload(c, actualFilePath) {
+		os.Remove(actualFilePath)
+		return
+	}
+
 	// Create transcription record
 	job := models.TranscriptionJob{
 		ID:        jobID,
+		UserID:    jobOwner(c),
 		AudioPath: actualFilePath,
 		Status:    models.StatusUploaded,
 	}
+	job.PhaseTimings = uploadTimings(c)
 
 	// Set title
 	if title != "" {
 		job.Title = &title
 	}
 
+	h.detectAudioInfo(c, &job)
+
 	// Save to database
 	if err := database.DB.Create(&job).Error; err != nil {
 		// Clean up downloaded file on database error
 		os.Remove(actualFilePath)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save transcription record"})
+		apierror.Abort(c, apierror.Internal("Failed to save transcription record"))
+		return
+	}
+
+	if !h.storeUpload(c, &job) {
 		return
 	}
 
@@ -2751,32 +4086,32 @@ func (h *Handler) DownloadFromYouTube(c *gin.Context) {
 // @Tags profiles
 // @Produce json
 // @Success 200 {object} models.TranscriptionProfile
-// @Failure 404 {object} map[string]string
+// @Failure 404 {object} apierror.Envelope
 // @Security BearerAuth
 // @Router /api/v1/user/default-profile [get]
 func (h *Handler) GetUserDefaultProfile(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierror.Abort(c, apierror.Unauthorized("User not authenticated"))
 		return
 	}
 
 	// Get user with default profile ID
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		apierror.Abort(c, apierror.Internal("Failed to get user"))
 		return
 	}
 
 	// If user has no default profile set, return the first available profile or no profile
 	if user.DefaultProfileID == nil {
 		var firstProfile models.TranscriptionProfile
-		if err := database.DB.Order("created_at ASC").First(&firstProfile).Error; err != nil {
+		if err := visibleProfiles(c).Order("created_at ASC").First(&firstProfile).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
-				c.JSON(http.StatusNotFound, gin.H{"error": "No profiles available"})
+				apierror.Abort(c, apierror.NotFound("No profiles available"))
 				return
 			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get profiles"})
+			apierror.Abort(c, apierror.Internal("Failed to get profiles"))
 			return
 		}
 		c.JSON(http.StatusOK, firstProfile)
@@ -2785,22 +4120,22 @@ func (h *Handler) GetUserDefaultProfile(c *gin.Context) {
 
 	// Get the user's default profile
 	var profile models.TranscriptionProfile
-	if err := database.DB.Where("id = ?", *user.DefaultProfileID).First(&profile).Error; err != nil {
+	if err := visibleProfiles(c).Where("id = ?", *user.DefaultProfileID).First(&profile).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			// Default profile no longer exists, fall back to first available
 			var firstProfile models.TranscriptionProfile
-			if err := database.DB.Order("created_at ASC").First(&firstProfile).Error; err != nil {
+			if err := visibleProfiles(c).Order("created_at ASC").First(&firstProfile).Error; err != nil {
 				if err == gorm.ErrRecordNotFound {
-					c.JSON(http.StatusNotFound, gin.H{"error": "No profiles available"})
+					apierror.Abort(c, apierror.NotFound("No profiles available"))
 					return
 				}
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get profiles"})
+				apierror.Abort(c, apierror.Internal("Failed to get profiles"))
 				return
 			}
 			c.JSON(http.StatusOK, firstProfile)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get default profile"})
+		apierror.Abort(c, apierror.Internal("Failed to get default profile"))
 		return
 	}
 
@@ -2819,37 +4154,37 @@ type SetUserDefaultProfileRequest struct {
 // @Produce json
 // @Param request body SetUserDefaultProfileRequest true "Default profile request"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
 // @Security BearerAuth
 // @Router /api/v1/user/default-profile [post]
 func (h *Handler) SetUserDefaultProfile(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierror.Abort(c, apierror.Unauthorized("User not authenticated"))
 		return
 	}
 
 	var req SetUserDefaultProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
 		return
 	}
 
 	// Verify the profile exists
 	var profile models.TranscriptionProfile
-	if err := database.DB.Where("id = ?", req.ProfileID).First(&profile).Error; err != nil {
+	if err := visibleProfiles(c).Where("id = ?", req.ProfileID).First(&profile).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+			apierror.Abort(c, apierror.NotFound("Profile not found"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify profile"})
+		apierror.Abort(c, apierror.Internal("Failed to verify profile"))
 		return
 	}
 
 	// Update user's default profile
 	if err := database.DB.Model(&models.User{}).Where("id = ?", userID).Update("default_profile_id", req.ProfileID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set default profile"})
+		apierror.Abort(c, apierror.Internal("Failed to set default profile"))
 		return
 	}
 
@@ -2872,20 +4207,20 @@ type UpdateUserSettingsRequest struct {
 // @Tags user
 // @Produce json
 // @Success 200 {object} UserSettingsResponse
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 401 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
 // @Security BearerAuth
 // @Router /api/v1/user/settings [get]
 func (h *Handler) GetUserSettings(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierror.Abort(c, apierror.Unauthorized("User not authenticated"))
 		return
 	}
 
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		apierror.Abort(c, apierror.Internal("Failed to get user"))
 		return
 	}
 
@@ -2904,27 +4239,27 @@ func (h *Handler) GetUserSettings(c *gin.Context) {
 // @Produce json
 // @Param request body UpdateUserSettingsRequest true "Settings update request"
 // @Success 200 {object} UserSettingsResponse
-// @Failure 400 {object} map[string]string
-// @Failure 401 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 401 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
 // @Security BearerAuth
 // @Router /api/v1/user/settings [put]
 func (h *Handler) UpdateUserSettings(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		apierror.Abort(c, apierror.Unauthorized("User not authenticated"))
 		return
 	}
 
 	var req UpdateUserSettingsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
 		return
 	}
 
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		apierror.Abort(c, apierror.Internal("Failed to get user"))
 		return
 	}
 
@@ -2935,7 +4270,7 @@ func (h *Handler) UpdateUserSettings(c *gin.Context) {
 
 	// Save updated user
 	if err := database.DB.Save(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update settings"})
+		apierror.Abort(c, apierror.Internal("Failed to update settings"))
 		return
 	}
 
@@ -2973,9 +4308,9 @@ type SpeakerMappingResponse struct {
 // @Produce json
 // @Param id path string true "Transcription Job ID"
 // @Success 200 {array} SpeakerMappingResponse
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
 // @Security BearerAuth
 // @Security ApiKeyAuth
 // @Router /api/v1/transcription/{id}/speakers [get]
@@ -2984,25 +4319,25 @@ func (h *Handler) GetSpeakerMappings(c *gin.Context) {
 
 	// Verify the transcription job exists and has diarization enabled
 	var job models.TranscriptionJob
-	if err := database.DB.Where("id = ?", jobID).First(&job).Error; err != nil {
+	if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", jobID).First(&job).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Transcription job not found"})
+			apierror.Abort(c, apierror.JobNotFound())
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get transcription job"})
+		apierror.Abort(c, apierror.Internal("Failed to get transcription job"))
 		return
 	}
 
 	// Check if diarization was enabled or if this is a multi-track job (which also has speakers)
 	if !job.Diarization && !job.Parameters.Diarize && !job.IsMultiTrack {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No speaker information available for this transcription"})
+		apierror.Abort(c, apierror.Validation("No speaker information available for this transcription"))
 		return
 	}
 
 	// Get speaker mappings
 	var mappings []models.SpeakerMapping
 	if err := database.DB.Where("transcription_job_id = ?", jobID).Find(&mappings).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get speaker mappings"})
+		apierror.Abort(c, apierror.Internal("Failed to get speaker mappings"))
 		return
 	}
 
@@ -3028,9 +4363,9 @@ func (h *Handler) GetSpeakerMappings(c *gin.Context) {
 // @Param id path string true "Transcription Job ID"
 // @Param request body SpeakerMappingsUpdateRequest true "Speaker mappings to update"
 // @Success 200 {array} SpeakerMappingResponse
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
 // @Security BearerAuth
 // @Security ApiKeyAuth
 // @Router /api/v1/transcription/{id}/speakers [post]
@@ -3039,24 +4374,24 @@ func (h *Handler) UpdateSpeakerMappings(c *gin.Context) {
 
 	var req SpeakerMappingsUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
 		return
 	}
 
 	// Verify the transcription job exists and has diarization enabled
 	var job models.TranscriptionJob
-	if err := database.DB.Where("id = ?", jobID).First(&job).Error; err != nil {
+	if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", jobID).First(&job).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Transcription job not found"})
+			apierror.Abort(c, apierror.JobNotFound())
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get transcription job"})
+		apierror.Abort(c, apierror.Internal("Failed to get transcription job"))
 		return
 	}
 
 	// Check if diarization was enabled or if this is a multi-track job (which also has speakers)
 	if !job.Diarization && !job.Parameters.Diarize && !job.IsMultiTrack {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No speaker information available for this transcription"})
+		apierror.Abort(c, apierror.Validation("No speaker information available for this transcription"))
 		return
 	}
 
@@ -3086,19 +4421,19 @@ func (h *Handler) UpdateSpeakerMappings(c *gin.Context) {
 			}
 			if err := tx.Create(&speakerMapping).Error; err != nil {
 				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create speaker mapping"})
+				apierror.Abort(c, apierror.Internal("Failed to create speaker mapping"))
 				return
 			}
 		} else if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query speaker mapping"})
+			apierror.Abort(c, apierror.Internal("Failed to query speaker mapping"))
 			return
 		} else {
 			// Update existing mapping
 			speakerMapping.CustomName = mapping.CustomName
 			if err := tx.Save(&speakerMapping).Error; err != nil {
 				tx.Rollback()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update speaker mapping"})
+				apierror.Abort(c, apierror.Internal("Failed to update speaker mapping"))
 				return
 			}
 		}
@@ -3106,6 +4441,13 @@ func (h *Handler) UpdateSpeakerMappings(c *gin.Context) {
 		updatedMappings = append(updatedMappings, speakerMapping)
 	}
 
+	if err := tx.Model(&models.TranscriptionJob{}).Where("id = ?", jobID).
+		UpdateColumn("transcript_revision", gorm.Expr("transcript_revision + 1")).Error; err != nil {
+		tx.Rollback()
+		apierror.Abort(c, apierror.Internal("Failed to update transcript revision"))
+		return
+	}
+
 	tx.Commit()
 
 	// Convert to response format
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/auth"
+	"scriberr/pkg/logger"
+	"scriberr/pkg/middleware"
+)
+
+// HeaderLogin starts a session for the user named by the authenticating proxy
+// @Summary Log in through the authenticating proxy
+// @Description With TRUSTED_HEADER_AUTH enabled, issue a token and refresh token for the user the reverse proxy names in its header, creating the account on first login. The web UI calls it instead of showing its login page.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} LoginResponse
+// @Failure 401 {object} apierror.Envelope
+// @Failure 403 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Router /api/v1/auth/header-login [post]
+func (h *Handler) HeaderLogin(c *gin.Context) {
+	if !h.config.HeaderAuth.Enabled {
+		apierror.Abort(c, apierror.NotFound("Header authentication is not configured"))
+		return
+	}
+	header := h.config.HeaderAuth.Header
+
+	username := middleware.HeaderUsername(c)
+	if username == "" {
+		logger.AuthEvent("header_login", "", c.ClientIP(), false, logger.String("reason", "missing_header"), "header", header)
+		apierror.Abort(c, apierror.Unauthorized("The authenticating proxy did not name a user"))
+		return
+	}
+
+	user, err := auth.UpsertHeaderUser(c.Request.Context(), username)
+	switch {
+	case errors.Is(err, auth.ErrAccountDisabled):
+		logger.AuthEvent("header_login", username, c.ClientIP(), false, logger.String("reason", "account_disabled"), "header", header)
+		apierror.Abort(c, apierror.Forbidden("Account is disabled"))
+		return
+	case errors.Is(err, auth.ErrInvalidHeaderUser):
+		logger.AuthEvent("header_login", username, c.ClientIP(), false, logger.String("reason", "invalid_username"), "header", header)
+		apierror.Abort(c, apierror.Unauthorized("Invalid username from authenticating proxy"))
+		return
+	case err != nil:
+		logger.Error("Failed to load header user", "username", username, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to create user"))
+		return
+	}
+
+	token, err := h.authService.GenerateToken(user)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to generate token"))
+		return
+	}
+	if err := h.issueRefreshToken(c, user.ID); err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to create session"))
+		return
+	}
+
+	response := LoginResponse{Token: token}
+	response.User.ID = user.ID
+	response.User.Username = user.Username
+	response.User.Role = user.Role
+
+	logger.AuthEvent("header_login", user.Username, c.ClientIP(), true, "user_id", user.ID, "role", user.Role, "header", header)
+	c.JSON(http.StatusOK, response)
+}
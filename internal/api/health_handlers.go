@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"scriberr/internal/database"
+	"scriberr/internal/queue"
+	"scriberr/internal/transcription"
+	"scriberr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// readinessCacheTTL is how long a readiness result is served before the
+	// checks run again, so aggressive probes do not hammer the database
+	readinessCacheTTL = 5 * time.Second
+	// databaseProbeTimeout bounds the database connectivity check
+	databaseProbeTimeout = 2 * time.Second
+	// queueHeartbeatMaxAge is how old the job scanner's heartbeat may get
+	// before the queue counts as stuck: three missed scans
+	queueHeartbeatMaxAge = 3 * queue.ScanInterval
+)
+
+// Readiness check states
+const (
+	checkOK   = "ok"
+	checkFail = "fail"
+)
+
+// ReadinessCheck is the result of one readiness check
+type ReadinessCheck struct {
+	Status string `json:"status"`           // "ok" or "fail"
+	Detail string `json:"detail,omitempty"` // What was found, such as the environment state
+	Error  string `json:"error,omitempty"`
+}
+
+// ReadinessResponse breaks readiness down by check
+type ReadinessResponse struct {
+	Status    string                    `json:"status"` // "ready" or "not_ready"
+	Checks    map[string]ReadinessCheck `json:"checks"`
+	CheckedAt time.Time                 `json:"checked_at"`
+}
+
+// readinessCache holds the last readiness result. The mutex is held while
+// the checks run, so concurrent probes wait for one run instead of starting
+// their own.
+type readinessCache struct {
+	mu     sync.Mutex
+	result *ReadinessResponse
+}
+
+// Liveness reports that the server is up without touching its dependencies
+// @Summary Liveness probe
+// @Description Report that the server process is up and serving requests. Cheap enough to call as often as needed; it checks no dependencies.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /api/health [get]
+func (h *Handler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readiness reports whether the server can do its work
+// @Summary Readiness probe
+// @Description Check database connectivity, that the upload directory is writable, the WhisperX environment state, that the job queue is running and that free disk space is above MIN_FREE_SPACE_MB. Returns 503 when any check fails. Results are cached for a few seconds.
+// @Tags health
+// @Produce json
+// @Success 200 {object} ReadinessResponse
+// @Failure 503 {object} ReadinessResponse
+// @Router /api/health/ready [get]
+func (h *Handler) Readiness(c *gin.Context) {
+	result := h.checkReadiness(c.Request.Context(), time.Now())
+	status := http.StatusOK
+	if result.Status != "ready" {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, result)
+}
+
+// checkReadiness runs the readiness checks, or returns the cached result
+// when it is younger than readinessCacheTTL
+func (h *Handler) checkReadiness(ctx context.Context, now time.Time) ReadinessResponse {
+	h.readiness.mu.Lock()
+	defer h.readiness.mu.Unlock()
+	if cached := h.readiness.result; cached != nil && now.Sub(cached.CheckedAt) < readinessCacheTTL {
+		return *cached
+	}
+
+	result := ReadinessResponse{Status: "ready", Checks: map[string]ReadinessCheck{}, CheckedAt: now}
+	for _, check := range []struct {
+		name string
+		run  func(context.Context) (string, error)
+	}{
+		{"database", h.checkDatabase},
+		{"upload_dir", h.checkUploadDir},
+		{"whisperx_env", h.checkWhisperXEnv},
+		{"queue", h.checkQueue},
+		{"disk_space", h.checkDiskSpace},
+	} {
+		detail, err := check.run(ctx)
+		if err != nil {
+			logger.Warn("Readiness check failed", "check", check.name, "error", err)
+			result.Status = "not_ready"
+			result.Checks[check.name] = ReadinessCheck{Status: checkFail, Detail: detail, Error: err.Error()}
+			continue
+		}
+		result.Checks[check.name] = ReadinessCheck{Status: checkOK, Detail: detail}
+	}
+
+	h.readiness.result = &result
+	return result
+}
+
+// checkDatabase runs a trivial query against the database
+func (h *Handler) checkDatabase(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, databaseProbeTimeout)
+	defer cancel()
+	if database.DB == nil {
+		return "", errors.New("database is not initialized")
+	}
+	return "", database.DB.WithContext(ctx).Exec("SELECT 1").Error
+}
+
+// checkUploadDir creates and removes a file in the upload directory
+func (h *Handler) checkUploadDir(context.Context) (string, error) {
+	f, err := os.CreateTemp(h.config.UploadDir, ".ready-*")
+	if err != nil {
+		return h.config.UploadDir, err
+	}
+	name := f.Name()
+	f.Close()
+	return h.config.UploadDir, os.Remove(name)
+}
+
+// checkWhisperXEnv fails only for a broken environment. Unknown, checking
+// and repairing states pass, so a first-start install or a repair does not
+// get the container restarted underneath it.
+func (h *Handler) checkWhisperXEnv(context.Context) (string, error) {
+	status := h.whisperxEnv.Status()
+	if status.State == transcription.EnvStateBroken {
+		return status.State, fmt.Errorf("whisperx environment is broken: %s", strings.Join(status.Problems, "; "))
+	}
+	return status.State, nil
+}
+
+// checkQueue fails when the job scanner has not run recently
+func (h *Handler) checkQueue(context.Context) (string, error) {
+	if h.taskQueue == nil {
+		return "", errors.New("job queue is not configured")
+	}
+	beat := h.taskQueue.LastHeartbeat()
+	if beat.IsZero() {
+		return "", errors.New("job queue is not running")
+	}
+	age := time.Since(beat).Round(time.Second)
+	if age > queueHeartbeatMaxAge {
+		return "last heartbeat " + age.String() + " ago", fmt.Errorf("job queue heartbeat is %s old", age)
+	}
+	return "last heartbeat " + age.String() + " ago", nil
+}
+
+// checkDiskSpace fails when the upload volume is below MIN_FREE_SPACE_MB
+func (h *Handler) checkDiskSpace(context.Context) (string, error) {
+	return "", h.spaceGuard.Check()
+}
@@ -0,0 +1,253 @@
+package api
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/export"
+	"scriberr/internal/models"
+	"scriberr/internal/storage"
+	"scriberr/pkg/logger"
+)
+
+// maxArchiveJobs is how many jobs one bulk download may bundle
+const maxArchiveJobs = 100
+
+// archiveFormats are the transcript exports every archive holds, in order
+var archiveFormats = []export.Format{export.FormatJSON, export.FormatSRT, export.FormatVTT, export.FormatTXT}
+
+// JobArchiveRequest lists the jobs to bundle into one zip
+type JobArchiveRequest struct {
+	IDs []string `json:"ids" binding:"required,min=1,max=100"`
+}
+
+// DownloadJobArchive streams a zip of everything a completed job produced
+// @Summary Download a job's files as a zip
+// @Description Streams a zip holding the transcript as transcript.json, transcript.srt, transcript.vtt and transcript.txt, the latest summary as summary.md when there is one, and with include=audio the source audio as audio plus its extension. Exports use the default layout with speaker names applied. The zip is named after the job title.
+// @Tags transcription
+// @Produce application/zip
+// @Param id path string true "Job ID"
+// @Param include query string false "audio to add the source audio"
+// @Success 200 {file} file
+// @Failure 400 {object} apierror.Envelope "Transcript not available, or include is not audio"
+// @Failure 404 {object} apierror.Envelope
+// @Router /api/v1/jobs/{id}/archive [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) DownloadJobArchive(c *gin.Context) {
+	includeAudio, ok := archiveIncludesAudio(c)
+	if !ok {
+		return
+	}
+	var job models.TranscriptionJob
+	if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", c.Param("id")).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.JobNotFound())
+			return
+		}
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
+		return
+	}
+	if job.Status != models.StatusCompleted || job.Transcript == nil {
+		apierror.Abort(c, apierror.BadRequest("Transcript not available"))
+		return
+	}
+
+	zw := startArchive(c, exportFilename(&job)+".zip")
+	if err := writeJobArchive(c.Request.Context(), zw, &job, "", includeAudio); err != nil {
+		logger.Error("Failed to write job archive", "job_id", job.ID, "error", err)
+		return
+	}
+	if err := zw.Close(); err != nil {
+		logger.Warn("Failed to finish job archive", "job_id", job.ID, "error", err)
+	}
+}
+
+// DownloadJobArchives streams one zip of several completed jobs' files
+// @Summary Download several jobs' files as a zip
+// @Description Streams a zip with a folder per job, in the order given, holding what GET /api/v1/jobs/{id}/archive returns for it. Folders are named after job titles; repeated titles are numbered. Every job must have completed.
+// @Tags transcription
+// @Accept json
+// @Produce application/zip
+// @Param include query string false "audio to add each job's source audio"
+// @Param request body JobArchiveRequest true "Jobs to bundle, at most 100"
+// @Success 200 {file} file
+// @Failure 400 {object} apierror.Envelope "Some jobs have no transcript yet; details.ids lists them"
+// @Failure 404 {object} apierror.Envelope
+// @Router /api/v1/jobs/archive [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) DownloadJobArchives(c *gin.Context) {
+	includeAudio, ok := archiveIncludesAudio(c)
+	if !ok {
+		return
+	}
+	var req JobArchiveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+
+	// Transcripts are loaded one job at a time while the zip is written
+	ids := uniqueIDs(req.IDs)
+	jobs, err := findBulkJobs(database.DB.Scopes(ownedJobs(c)).Select("id", "status"), ids)
+	if !bulkSucceeded(c, err, "download") {
+		return
+	}
+	var unfinished []string
+	for _, job := range jobs {
+		if job.Status != models.StatusCompleted {
+			unfinished = append(unfinished, job.ID)
+		}
+	}
+	if len(unfinished) > 0 {
+		apierror.Abort(c, apierror.BadRequest("Some jobs have no transcript yet").WithDetails(map[string]any{"ids": unfinished}))
+		return
+	}
+
+	zw := startArchive(c, "transcripts.zip")
+	folders := make(map[string]int, len(ids))
+	for _, id := range ids {
+		var job models.TranscriptionJob
+		if err := database.DB.Where("id = ?", id).First(&job).Error; err != nil || job.Transcript == nil {
+			logger.Warn("Skipping job in archive", "job_id", id, "error", err)
+			continue
+		}
+		folder := exportFilename(&job)
+		if folders[folder]++; folders[folder] > 1 {
+			folder = fmt.Sprintf("%s (%d)", folder, folders[folder])
+		}
+		if err := writeJobArchive(c.Request.Context(), zw, &job, folder, includeAudio); err != nil {
+			logger.Error("Failed to write job archive", "job_id", job.ID, "error", err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		logger.Warn("Failed to finish job archive", "error", err)
+	}
+}
+
+// archiveIncludesAudio reads the include query parameter, writing a 400 and
+// returning false if it names anything but audio
+func archiveIncludesAudio(c *gin.Context) (bool, bool) {
+	switch c.Query("include") {
+	case "":
+		return false, true
+	case "audio":
+		return true, true
+	default:
+		apierror.Abort(c, apierror.Validation("include must be audio"))
+		return false, false
+	}
+}
+
+// startArchive writes the headers of a zip download and returns a writer
+// streaming members straight to the response
+func startArchive(c *gin.Context, filename string) *zip.Writer {
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Header("Cache-Control", "private, no-store")
+	c.Status(http.StatusOK)
+	return zip.NewWriter(c.Writer)
+}
+
+// writeJobArchive adds a completed job's transcript exports, summary and,
+// with includeAudio, its audio to zw under dir. Audio is copied in chunks,
+// so memory use does not grow with its size. Audio that cannot be read is
+// left out.
+func writeJobArchive(ctx context.Context, zw *zip.Writer, job *models.TranscriptionJob, dir string, includeAudio bool) error {
+	modified := job.CreatedAt
+	if job.CompletedAt != nil {
+		modified = *job.CompletedAt
+	}
+
+	names, err := speakerDisplayNames(job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get speaker mappings: %w", err)
+	}
+	segments, err := export.SegmentsFromTranscript([]byte(*job.Transcript), names)
+	if err != nil {
+		return fmt.Errorf("failed to parse transcript: %w", err)
+	}
+	opts := export.DefaultOptions()
+	if job.Parameters.Task == models.TaskTranslate {
+		opts.Translation = true
+		opts.SourceLanguage = sourceLanguage(job)
+	}
+	for _, chapter := range job.Chapters {
+		opts.Chapters = append(opts.Chapters, export.Chapter{Start: chapter.Start, Title: chapter.Title})
+	}
+	for _, format := range archiveFormats {
+		member, err := createArchiveMember(zw, path.Join(dir, "transcript."+string(format)), modified, zip.Deflate)
+		if err != nil {
+			return err
+		}
+		if err := export.Write(member, format, segments, opts); err != nil {
+			return fmt.Errorf("failed to export %s: %w", format, err)
+		}
+	}
+
+	if summary := latestSummary(job); summary != "" {
+		member, err := createArchiveMember(zw, path.Join(dir, "summary.md"), modified, zip.Deflate)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(member, summary); err != nil {
+			return err
+		}
+	}
+
+	if !includeAudio || job.AudioDeletedAt != nil || job.AudioPath == "" {
+		return nil
+	}
+	audio, _, err := storage.Open(ctx, job.AudioPath)
+	if err != nil {
+		logger.Warn("Leaving audio out of job archive", "job_id", job.ID, "path", job.AudioPath, "error", err)
+		return nil
+	}
+	defer audio.Close()
+	// Audio is already compressed, so it is stored as is
+	member, err := createArchiveMember(zw, path.Join(dir, "audio"+strings.ToLower(filepath.Ext(job.AudioPath))), modified, zip.Store)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(member, audio); err != nil {
+		return fmt.Errorf("failed to copy audio: %w", err)
+	}
+	return nil
+}
+
+// createArchiveMember starts a zip member with a fixed modification time, so
+// the same job always produces the same archive
+func createArchiveMember(zw *zip.Writer, name string, modified time.Time, method uint16) (io.Writer, error) {
+	header := &zip.FileHeader{Name: name, Method: method, Modified: modified.UTC()}
+	header.SetMode(0644)
+	return zw.CreateHeader(header)
+}
+
+// latestSummary returns a job's most recent summary, or the one cached on
+// the job, or "" when it has none
+func latestSummary(job *models.TranscriptionJob) string {
+	var summaries []models.Summary
+	if err := database.DB.Where("transcription_id = ?", job.ID).Order("created_at DESC").Limit(1).Find(&summaries).Error; err == nil && len(summaries) > 0 {
+		return summaries[0].Content
+	}
+	if job.Summary != nil {
+		return *job.Summary
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+)
+
+// formDependencies reads the depends_on form field of a submission, given
+// as repeated fields or as one JSON array of job IDs. It writes an error
+// response and returns false if the array is malformed.
+func formDependencies(c *gin.Context) ([]string, bool) {
+	values := c.PostFormArray("depends_on")
+	if len(values) != 1 || !strings.HasPrefix(strings.TrimSpace(values[0]), "[") {
+		return values, true
+	}
+	var dependsOn []string
+	if err := json.Unmarshal([]byte(values[0]), &dependsOn); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid depends_on: "+err.Error()).WithDetails(map[string]any{"field": "depends_on"}))
+		return nil, false
+	}
+	return dependsOn, true
+}
+
+// checkDependencies validates the jobs jobID is to wait for: distinct jobs
+// the caller can see that have not failed, and that do not, through their own
+// dependencies, wait for jobID. It writes a 422 and returns false otherwise.
+func checkDependencies(c *gin.Context, jobID string, dependsOn []string) bool {
+	if len(dependsOn) == 0 {
+		return true
+	}
+	if err := models.ValidateJobDependencies(dependsOn); err != nil {
+		abortFieldError(c, err)
+		return false
+	}
+
+	err := models.FindDependencyCycle(jobID, dependsOn, jobDependencies)
+	var cycleErr *models.DependencyCycleError
+	if errors.As(err, &cycleErr) {
+		apierror.Abort(c, apierror.Unprocessable("depends_on would make jobs wait for each other forever").
+			WithDetails(map[string]any{"field": "depends_on", "cycle": cycleErr.Cycle}))
+		return false
+	}
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to check dependencies").WithCause(err))
+		return false
+	}
+
+	var jobs []models.TranscriptionJob
+	if err := database.DB.Scopes(ownedJobs(c)).Select("id", "status").Where("id IN ?", dependsOn).Find(&jobs).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to check dependencies").WithCause(err))
+		return false
+	}
+	statuses := make(map[string]models.JobStatus, len(jobs))
+	for _, job := range jobs {
+		statuses[job.ID] = job.Status
+	}
+	for _, id := range dependsOn {
+		status, found := statuses[id]
+		switch {
+		case !found:
+			abortFieldError(c, &models.FieldError{Field: "depends_on", Message: fmt.Sprintf("depends_on job %s was not found", id)})
+			return false
+		case status == models.StatusFailed:
+			abortFieldError(c, &models.FieldError{Field: "depends_on", Message: fmt.Sprintf("depends_on job %s has failed", id)})
+			return false
+		}
+	}
+	return true
+}
+
+// jobDependencies loads the jobs a job depends on. Deleted jobs have none.
+func jobDependencies(id string) ([]string, error) {
+	var jobs []models.TranscriptionJob
+	if err := database.DB.Select("id", "depends_on").Where("id = ?", id).Limit(1).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	return jobs[0].DependsOn, nil
+}
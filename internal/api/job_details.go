@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/models"
+)
+
+// jobDetails are the descriptive fields a job may be given when submitted
+type jobDetails struct {
+	Title       *string
+	Description *string
+	Metadata    map[string]string
+}
+
+// apply sets the details on a job about to be created
+func (d jobDetails) apply(job *models.TranscriptionJob) {
+	job.Title = d.Title
+	job.Description = d.Description
+	job.Metadata = d.Metadata
+}
+
+// formJobDetails reads the description and metadata form fields of a
+// submission, validating them with title. An empty title defaults to the
+// uploaded file's name. It writes an error response and returns false if a
+// field is invalid.
+func formJobDetails(c *gin.Context, title, filename string) (jobDetails, bool) {
+	var details jobDetails
+	if title == "" && filename != "" {
+		title = filepath.Base(filename)
+	}
+	if title != "" {
+		details.Title = &title
+	}
+	if description := c.PostForm("description"); description != "" {
+		details.Description = &description
+	}
+	if raw := c.PostForm("metadata"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &details.Metadata); err != nil {
+			apierror.Abort(c, apierror.Validation("Invalid metadata: "+err.Error()).WithDetails(map[string]any{"field": "metadata"}))
+			return details, false
+		}
+	}
+	if err := validateJobDetails(details.Title, details.Description, details.Metadata); err != nil {
+		abortFieldError(c, err)
+		return details, false
+	}
+	return details, true
+}
+
+// validateJobDetails checks whichever of a job's descriptive fields are set
+func validateJobDetails(title, description *string, metadata map[string]string) error {
+	if title != nil {
+		if err := models.ValidateJobTitle(*title); err != nil {
+			return err
+		}
+	}
+	if description != nil {
+		if err := models.ValidateJobDescription(*description); err != nil {
+			return err
+		}
+	}
+	return models.ValidateJobMetadata(metadata)
+}
+
+// abortFieldError writes a 422 naming the field a models.FieldError is about
+func abortFieldError(c *gin.Context, err error) {
+	var fieldErr *models.FieldError
+	if errors.As(err, &fieldErr) {
+		apierror.Abort(c, apierror.Unprocessable(fieldErr.Message).WithDetails(map[string]any{"field": fieldErr.Field}))
+		return
+	}
+	apierror.Abort(c, apierror.Validation(err.Error()))
+}
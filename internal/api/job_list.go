@@ -0,0 +1,203 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"scriberr/internal/models"
+)
+
+// jobSortExpressions maps the job list's sort keys to the expressions jobs
+// are ordered by. Missing durations and titles sort as -1 and "".
+var jobSortExpressions = map[string]string{
+	"created_at": "created_at",
+	"duration":   "COALESCE(duration_seconds, -1)",
+	"status":     "status",
+	"title":      "COALESCE(title, '') COLLATE NOCASE",
+}
+
+// listHeavyColumns hold transcript text, which job lists leave out unless a
+// field selection or the full flag asks for it
+var listHeavyColumns = []string{"transcript", "individual_transcripts"}
+
+// jobListSort is how the job list is ordered
+type jobListSort struct {
+	Key  string `json:"s"`
+	Desc bool   `json:"d"`
+}
+
+// jobListCursor marks the last job of a page: the next page starts after it
+type jobListCursor struct {
+	jobListSort
+	Value json.RawMessage `json:"v"`
+	ID    string          `json:"id"`
+}
+
+// parseJobListSort reads the sort and order parameters
+func parseJobListSort(sort, order string) (jobListSort, error) {
+	if sort == "" {
+		sort = "created_at"
+	}
+	if _, ok := jobSortExpressions[sort]; !ok {
+		return jobListSort{}, fmt.Errorf("invalid sort %q: use created_at, duration, status or title", sort)
+	}
+	switch order {
+	case "", "desc":
+		return jobListSort{Key: sort, Desc: true}, nil
+	case "asc":
+		return jobListSort{Key: sort}, nil
+	default:
+		return jobListSort{}, fmt.Errorf("invalid order %q: use asc or desc", order)
+	}
+}
+
+// orderBy orders a job query by the sort, breaking ties by ID
+func (s jobListSort) orderBy(query *gorm.DB) *gorm.DB {
+	direction := "ASC"
+	if s.Desc {
+		direction = "DESC"
+	}
+	return query.Order(jobSortExpressions[s.Key] + " " + direction + ", id " + direction)
+}
+
+// after narrows a job query to the jobs that come after job in the sort
+func (s jobListSort) after(query *gorm.DB, value interface{}, id string) *gorm.DB {
+	expr := jobSortExpressions[s.Key]
+	op := ">"
+	if s.Desc {
+		op = "<"
+	}
+	return query.Where(fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", expr, op, expr, op), value, value, id)
+}
+
+// sortValue returns the value job is ordered by
+func (s jobListSort) sortValue(job *models.TranscriptionJob) interface{} {
+	switch s.Key {
+	case "duration":
+		if job.DurationSeconds == nil {
+			return -1.0
+		}
+		return *job.DurationSeconds
+	case "status":
+		return string(job.Status)
+	case "title":
+		if job.Title == nil {
+			return ""
+		}
+		return *job.Title
+	default:
+		return job.CreatedAt
+	}
+}
+
+// encodeJobListCursor returns the opaque cursor of the page after job
+func encodeJobListCursor(sort jobListSort, job *models.TranscriptionJob) (string, error) {
+	value, err := json.Marshal(sort.sortValue(job))
+	if err != nil {
+		return "", err
+	}
+	raw, err := json.Marshal(jobListCursor{jobListSort: sort, Value: value, ID: job.ID})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeJobListCursor reads a cursor, returning its sort and the sort value
+// and ID of the job the page starts after
+func decodeJobListCursor(cursor string) (jobListSort, interface{}, string, error) {
+	invalid := fmt.Errorf("invalid cursor")
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return jobListSort{}, nil, "", invalid
+	}
+	var decoded jobListCursor
+	if err := json.Unmarshal(raw, &decoded); err != nil || decoded.ID == "" {
+		return jobListSort{}, nil, "", invalid
+	}
+	if _, ok := jobSortExpressions[decoded.Key]; !ok {
+		return jobListSort{}, nil, "", invalid
+	}
+
+	var value interface{}
+	switch decoded.Key {
+	case "created_at":
+		var t time.Time
+		err = json.Unmarshal(decoded.Value, &t)
+		value = t
+	case "duration":
+		var seconds float64
+		err = json.Unmarshal(decoded.Value, &seconds)
+		value = seconds
+	default:
+		var s string
+		err = json.Unmarshal(decoded.Value, &s)
+		value = s
+	}
+	if err != nil {
+		return jobListSort{}, nil, "", invalid
+	}
+	return decoded.jobListSort, value, decoded.ID, nil
+}
+
+// jobFieldNames are the JSON names of a job's fields, which the job list's
+// fields parameter selects from
+var jobFieldNames = func() map[string]bool {
+	names := make(map[string]bool)
+	t := reflect.TypeOf(models.TranscriptionJob{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}()
+
+// parseJobFields reads the fields parameter. The ID is always included.
+func parseJobFields(raw string) ([]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	fields := []string{"id"}
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || field == "id" {
+			continue
+		}
+		if !jobFieldNames[field] {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// selectJobFields returns the jobs with only the given JSON fields. Fields a
+// job has no value for are left out.
+func selectJobFields(jobs []models.TranscriptionJob, fields []string) ([]map[string]json.RawMessage, error) {
+	selected := make([]map[string]json.RawMessage, len(jobs))
+	for i := range jobs {
+		raw, err := json.Marshal(&jobs[i])
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &all); err != nil {
+			return nil, err
+		}
+		selected[i] = make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			if value, ok := all[field]; ok {
+				selected[i][field] = value
+			}
+		}
+	}
+	return selected, nil
+}
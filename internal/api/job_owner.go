@@ -0,0 +1,76 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+)
+
+// ownedJobs scopes a job query to the jobs the caller may see and change:
+// their own, or every job for admins. Other users' jobs are reported as not
+// found rather than forbidden, so their IDs are not revealed.
+func ownedJobs(c *gin.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if isAdmin(c) {
+			return db
+		}
+		return db.Where("transcription_jobs.user_id = ?", c.GetUint("user_id"))
+	}
+}
+
+// myJobs scopes a job query to the caller's own jobs. For admins this
+// includes jobs without an owner, such as those from watch folders.
+func myJobs(c *gin.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if isAdmin(c) {
+			return db.Where("transcription_jobs.user_id = ? OR transcription_jobs.user_id IS NULL", c.GetUint("user_id"))
+		}
+		return db.Where("transcription_jobs.user_id = ?", c.GetUint("user_id"))
+	}
+}
+
+// ownsJob reports whether the caller may see and change a loaded job
+func ownsJob(c *gin.Context, job *models.TranscriptionJob) bool {
+	if isAdmin(c) {
+		return true
+	}
+	userID, ok := currentUserID(c)
+	return ok && job.UserID != nil && *job.UserID == userID
+}
+
+// canAccessJob reports whether a job exists and the caller may see it
+func canAccessJob(c *gin.Context, jobID string) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.TranscriptionJob{}).Scopes(ownedJobs(c)).Where("id = ?", jobID).Count(&count).Error
+	return count > 0, err
+}
+
+// jobOwner returns the caller's user ID to record as the owner of a job they
+// create, or nil for callers without a user such as API keys without an owner
+func jobOwner(c *gin.Context) *uint {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return nil
+	}
+	return &userID
+}
+
+// isAdmin reports whether the caller has the admin role
+func isAdmin(c *gin.Context) bool {
+	return c.GetString("role") == models.RoleAdmin
+}
+
+// ofOwnedJobs scopes a query on rows that belong to a job through their
+// transcription_id column, such as notes and chat sessions, to the jobs the
+// caller may see
+func ofOwnedJobs(c *gin.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if isAdmin(c) {
+			return db
+		}
+		jobs := database.DB.Model(&models.TranscriptionJob{}).Select("id").Scopes(ownedJobs(c))
+		return db.Where("transcription_id IN (?)", jobs)
+	}
+}
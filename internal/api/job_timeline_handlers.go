@@ -0,0 +1,70 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+)
+
+// uploadStartKey holds when an upload began, for uploads that started before
+// the request completing them
+const uploadStartKey = "upload_start"
+
+// TimelinePhase is how long a job spent in one phase
+type TimelinePhase struct {
+	Phase      string `json:"phase" example:"queued"` // upload, queued, preprocessing, transcription or post_processing
+	DurationMS int64  `json:"duration_ms" example:"1230"`
+}
+
+// GetJobTimeline returns where a job's time went
+// @Summary Get a job's timeline
+// @Description Lists how long the job spent in each phase, in order: upload (receiving or downloading the audio), queued (waiting for a worker), preprocessing (preparing the audio and model), transcription (transcribing and diarizing) and post_processing (running post-processors and saving the transcript). Processing phases are those of the latest run. Phases the job has not finished, or skipped, are left out; jobs that reused a cached transcript have only upload.
+// @Tags transcription
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {array} TimelinePhase
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/{id}/timeline [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) GetJobTimeline(c *gin.Context) {
+	var job models.TranscriptionJob
+	if err := database.DB.Scopes(ownedJobs(c)).Select("id", "phase_timings").Where("id = ?", c.Param("id")).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.JobNotFound())
+			return
+		}
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
+		return
+	}
+
+	timeline := []TimelinePhase{}
+	for _, phase := range models.TimelinePhases {
+		if duration, ok := job.PhaseTimings[phase]; ok {
+			timeline = append(timeline, TimelinePhase{Phase: phase, DurationMS: duration})
+		}
+	}
+	c.JSON(http.StatusOK, timeline)
+}
+
+// uploadTimings returns the phase timings of a job created from the upload
+// the request finishes: the time since the upload began
+func uploadTimings(c *gin.Context) map[string]int64 {
+	start := c.GetTime(uploadStartKey)
+	if start.IsZero() {
+		start = logger.RequestStart(c)
+	}
+	if start.IsZero() {
+		return nil
+	}
+	return map[string]int64{models.TimelineUpload: time.Since(start).Milliseconds()}
+}
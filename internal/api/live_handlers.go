@@ -0,0 +1,505 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/live"
+	"scriberr/internal/metrics"
+	"scriberr/internal/models"
+	"scriberr/internal/transcription"
+	"scriberr/internal/transcription/interfaces"
+	"scriberr/internal/transcription/registry"
+	"scriberr/internal/websocket"
+	"scriberr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Audio formats live streams accept
+const (
+	liveFormatPCM  = "pcm"  // 16 kHz 16-bit little-endian mono PCM
+	liveFormatWebM = "webm" // WebM/Opus from MediaRecorder, or anything else ffmpeg reads
+)
+
+// Reasons a live stream ended
+const (
+	liveEndStopped     = "stopped"      // The client sent stop or closed the socket
+	liveEndMaxDuration = "max_duration" // The stream reached LIVE_MAX_SESSION_MINUTES
+	liveEndError       = "error"        // Transcription failed
+	liveEndShutdown    = "shutdown"     // The server is shutting down
+)
+
+// liveReadLimit is the largest audio chunk a client may send, in bytes
+const liveReadLimit = 1 << 20
+
+// LiveReady is the first message of a live stream
+type LiveReady struct {
+	Type               string `json:"type" example:"ready"`
+	Format             string `json:"format"`
+	SampleRate         int    `json:"sample_rate"`
+	MaxDurationSeconds int    `json:"max_duration_seconds"`
+}
+
+// LiveDone is the last message of a live stream. The final segments are
+// saved as a completed job unless no audio arrived.
+type LiveDone struct {
+	Type            string  `json:"type" example:"done"`
+	Reason          string  `json:"reason" example:"stopped"`
+	JobID           string  `json:"job_id,omitempty"`
+	Segments        int     `json:"segments"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// liveStreamHub tracks open live streams, limiting them per user, so they
+// can be counted and ended on shutdown
+type liveStreamHub struct {
+	mu      sync.Mutex
+	perUser map[uint]int
+	streams map[*websocket.Conn]*live.Session
+	closing bool
+}
+
+// acquire reserves one of a user's streams, returning false when the user
+// has limit open already
+func (hub *liveStreamHub) acquire(userID uint, limit int) bool {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if hub.perUser == nil {
+		hub.perUser = make(map[uint]int)
+	}
+	if hub.perUser[userID] >= limit {
+		return false
+	}
+	hub.perUser[userID]++
+	return true
+}
+
+// release frees a stream acquire reserved
+func (hub *liveStreamHub) release(userID uint) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if hub.perUser[userID]--; hub.perUser[userID] <= 0 {
+		delete(hub.perUser, userID)
+	}
+}
+
+// add tracks a stream, returning false once the server is shutting down
+func (hub *liveStreamHub) add(conn *websocket.Conn, session *live.Session) bool {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if hub.closing {
+		return false
+	}
+	if hub.streams == nil {
+		hub.streams = make(map[*websocket.Conn]*live.Session)
+	}
+	hub.streams[conn] = session
+	hub.report()
+	return true
+}
+
+// remove stops tracking a stream
+func (hub *liveStreamHub) remove(conn *websocket.Conn) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	delete(hub.streams, conn)
+	hub.report()
+}
+
+// report publishes the number of open streams. Callers must hold hub.mu.
+func (hub *liveStreamHub) report() {
+	metrics.SetGauge("scriberr_live_streams", "Open live transcription streams", float64(len(hub.streams)))
+}
+
+// CloseLiveStreams ends every live stream and refuses new ones. Streams
+// finish transcribing the audio they have and are saved, as when clients
+// stop them. Like event sockets, they are not closed by the HTTP server's
+// shutdown, so this is registered to run then.
+func (h *Handler) CloseLiveStreams() {
+	h.liveStreams.mu.Lock()
+	h.liveStreams.closing = true
+	sessions := make([]*live.Session, 0, len(h.liveStreams.streams))
+	for _, session := range h.liveStreams.streams {
+		sessions = append(sessions, session)
+	}
+	h.liveStreams.mu.Unlock()
+
+	for _, session := range sessions {
+		session.Close()
+	}
+}
+
+// liveStream is what a client asked to stream
+type liveStream struct {
+	userID   uint
+	hasUser  bool
+	format   string
+	language string
+	title    string
+}
+
+// liveSink passes decoded audio to the session and records what it accepts.
+// Audio arriving after the session closed is dropped, so the decoder is
+// never stuck writing to it.
+type liveSink struct {
+	mu        sync.Mutex
+	session   *live.Session
+	recording *live.Recording
+	finished  bool
+}
+
+func (s *liveSink) Write(pcm []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return len(pcm), nil
+	}
+	if _, err := s.session.Write(pcm); err != nil {
+		return len(pcm), nil
+	}
+	return s.recording.Write(pcm)
+}
+
+// finish closes the recording once the session is done with the audio
+func (s *liveSink) finish() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finished = true
+	return s.recording.Close()
+}
+
+// LiveTranscription godoc
+// @Summary Transcribe audio streamed over a WebSocket
+// @Description Upgrades to a WebSocket that transcribes audio while it is recorded, for meeting notes. Send audio as binary messages: 16 kHz 16-bit little-endian mono PCM with format=pcm, or WebM/Opus chunks from MediaRecorder with format=webm. The server answers with {"type":"ready",...}, then {"type":"partial","start","end","text"} for tentative text, which replaces the previous partial, and {"type":"final",...} for each segment that will not change. Send {"type":"stop"} to end the stream: the rest is transcribed, the final segments are saved as a completed job with the audio, and {"type":"done","job_id":...} is sent before the socket closes. Audio is transcribed with faster-whisper (LIVE_MODEL) on a sliding window; a client sending faster than it keeps up is slowed by no longer reading its messages. Streams end after LIVE_MAX_SESSION_MINUTES, and each user may have LIVE_MAX_STREAMS_PER_USER open at once.
+// @Tags transcription
+// @Param format query string false "Audio format" Enums(pcm, webm) default(pcm)
+// @Param language query string false "Language of the audio; detected when unset"
+// @Param title query string false "Title of the job the stream is saved as"
+// @Param token query string false "JWT or API key, for clients that cannot set headers"
+// @Success 101 {object} live.Event
+// @Failure 400 {object} apierror.Envelope
+// @Failure 401 {object} apierror.Envelope
+// @Failure 429 {object} apierror.Envelope
+// @Failure 503 {object} apierror.Envelope
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/transcription/live [get]
+func (h *Handler) LiveTranscription(c *gin.Context) {
+	stream := liveStream{format: c.DefaultQuery("format", liveFormatPCM), title: strings.TrimSpace(c.Query("title"))}
+	if stream.format != liveFormatPCM && stream.format != liveFormatWebM {
+		apierror.Abort(c, apierror.Validation("format must be pcm or webm"))
+		return
+	}
+	adapter, err := registry.GetRegistry().GetTranscriptionAdapter(models.BackendFasterWhisper)
+	if err != nil || !adapter.IsReady(c.Request.Context()) {
+		apierror.Abort(c, apierror.EngineUnavailable("Live transcription needs faster-whisper; set FASTER_WHISPER_PATH"))
+		return
+	}
+	if language := strings.TrimSpace(c.Query("language")); language != "" && !strings.EqualFold(language, models.LanguageAuto) {
+		if !slices.Contains(adapter.GetCapabilities().SupportedLanguages, language) {
+			apierror.Abort(c, apierror.Validation(fmt.Sprintf("Unsupported language %q", language)))
+			return
+		}
+		stream.language = language
+	}
+	if stream.format == liveFormatWebM {
+		if _, err := exec.LookPath("ffmpeg"); err != nil {
+			apierror.Abort(c, apierror.EngineUnavailable("Streaming WebM needs ffmpeg"))
+			return
+		}
+	}
+	if !websocket.IsUpgrade(c.Request) {
+		apierror.Abort(c, apierror.BadRequest("Expected a WebSocket upgrade request"))
+		return
+	}
+
+	stream.userID, stream.hasUser = currentUserID(c)
+	limit := h.config.Live.MaxStreamsPerUser
+	if !h.liveStreams.acquire(stream.userID, limit) {
+		apierror.Abort(c, apierror.New(http.StatusTooManyRequests, apierror.CodeRateLimited,
+			fmt.Sprintf("At most %d live streams may be open at once; stop one first", limit)))
+		return
+	}
+	defer h.liveStreams.release(stream.userID)
+
+	conn, err := websocket.Upgrade(c.Writer, c.Request)
+	if errors.Is(err, websocket.ErrBadHandshake) {
+		apierror.Abort(c, apierror.BadRequest("Expected a WebSocket upgrade request"))
+		return
+	}
+	if err != nil {
+		logger.Warn("Failed to upgrade live stream", "error", err)
+		return
+	}
+	h.serveLiveStream(conn, adapter, stream)
+}
+
+// liveDevice is the device live streams run on: LIVE_DEVICE, or the best
+// one available
+func (h *Handler) liveDevice() string {
+	if h.config.Live.Device != "" {
+		return h.config.Live.Device
+	}
+	return h.environment.AutoDevice()
+}
+
+// serveLiveStream transcribes a stream until it ends and saves it as a job
+func (h *Handler) serveLiveStream(conn *websocket.Conn, adapter interfaces.TranscriptionAdapter, stream liveStream) {
+	tempDir, err := os.MkdirTemp("", "scriberr-live-")
+	if err != nil {
+		logger.Error("Failed to create live stream directory", "error", err)
+		conn.Close(websocket.CloseInternalError, "failed to start the stream")
+		return
+	}
+	defer os.RemoveAll(tempDir)
+	recording, err := live.CreateRecording(filepath.Join(h.config.UploadDir, uuid.NewString()+".wav"))
+	if err != nil {
+		logger.Error("Failed to create live stream recording", "error", err)
+		conn.Close(websocket.CloseInternalError, "failed to start the stream")
+		return
+	}
+
+	device := h.liveDevice()
+	params := map[string]interface{}{
+		"model":        h.config.Live.Model,
+		"device":       device,
+		"compute_type": models.DefaultComputeType(device),
+		"task":         "transcribe",
+		"beam_size":    1,
+		"best_of":      1,
+	}
+	if h.config.ModelCacheDir != "" {
+		params["model_dir"] = h.config.ModelCacheDir
+	}
+	if stream.language != "" {
+		params["language"] = stream.language
+	}
+	engine := &live.AdapterEngine{Adapter: adapter, Params: params, TempDir: tempDir}
+
+	// Once the client is gone the rest is still transcribed and saved
+	var clientGone atomic.Bool
+	send := func(v any) error {
+		if !clientGone.Load() && writeEventSocketJSON(conn, v) != nil {
+			clientGone.Store(true)
+		}
+		return nil
+	}
+	session := live.NewSession(engine, live.DefaultOptions, func(e live.Event) error { return send(e) })
+	if !h.liveStreams.add(conn, session) {
+		recording.Close()
+		os.Remove(recording.Path())
+		conn.Close(websocket.CloseGoingAway, "server is shutting down")
+		return
+	}
+	defer h.liveStreams.remove(conn)
+
+	send(LiveReady{Type: "ready", Format: stream.format, SampleRate: live.SampleRate, MaxDurationSeconds: int(h.config.Live.MaxSessionDuration.Seconds())})
+	logger.Info("Live stream started", "user_id", stream.userID, "format", stream.format, "model", h.config.Live.Model, "device", device)
+
+	reason := liveEndStopped
+	var reasonMu sync.Mutex
+	endWith := func(r string) {
+		reasonMu.Lock()
+		if reason == liveEndStopped {
+			reason = r
+		}
+		reasonMu.Unlock()
+		session.Close()
+	}
+	limit := time.AfterFunc(h.config.Live.MaxSessionDuration, func() { endWith(liveEndMaxDuration) })
+	defer limit.Stop()
+
+	sink := &liveSink{session: session, recording: recording}
+	readerDone := make(chan struct{})
+	go func() {
+		defer close(readerDone)
+		if err := readLiveStream(conn, stream.format, sink, session); err != nil &&
+			!errors.Is(err, websocket.ErrClosed) {
+			logger.Debug("Live stream read ended", "remote", conn.RemoteAddr().String(), "error", err)
+		}
+	}()
+	stopPing := make(chan struct{})
+	go func() {
+		ping := time.NewTicker(eventSocketPingInterval)
+		defer ping.Stop()
+		for {
+			select {
+			case <-ping.C:
+				conn.SetWriteDeadline(time.Now().Add(eventSocketWriteWait))
+				conn.WriteMessage(websocket.OpPing, nil)
+			case <-stopPing:
+				return
+			}
+		}
+	}()
+
+	runErr := session.Run(context.Background())
+	close(stopPing)
+	h.liveStreams.mu.Lock()
+	if h.liveStreams.closing {
+		endWith(liveEndShutdown)
+	}
+	h.liveStreams.mu.Unlock()
+
+	done := LiveDone{Type: "done", Reason: reason, Segments: len(session.Segments()), DurationSeconds: session.Duration().Seconds()}
+	closeCode := websocket.CloseNormal
+	if runErr != nil {
+		logger.Error("Live transcription failed", "user_id", stream.userID, "error", runErr)
+		done.Reason = liveEndError
+		done.Error = "Transcription failed: " + runErr.Error()
+		closeCode = websocket.CloseInternalError
+	}
+	if err := sink.finish(); err != nil {
+		logger.Error("Failed to finish live stream recording", "path", recording.Path(), "error", err)
+	}
+	if recording.Size() == 0 {
+		os.Remove(recording.Path())
+	} else if job, err := h.saveLiveJob(stream, recording, session, engine.Language); err != nil {
+		logger.Error("Failed to save live stream", "user_id", stream.userID, "error", err)
+		done.Error = "Failed to save the transcript"
+	} else {
+		done.JobID = job.ID
+	}
+	logger.Info("Live stream ended", "user_id", stream.userID, "reason", done.Reason, "job_id", done.JobID, "duration_seconds", done.DurationSeconds)
+
+	send(done)
+	conn.Close(closeCode, "")
+	<-readerDone
+}
+
+// readLiveStream reads audio from a socket into sink, decoding it first for
+// WebM, until the client stops the stream or the socket closes. The session
+// is closed once the decoder has written the rest.
+func readLiveStream(conn *websocket.Conn, format string, sink *liveSink, session *live.Session) error {
+	defer session.Close()
+	var decoder *live.Decoder
+	if format == liveFormatWebM {
+		var err error
+		if decoder, err = live.StartDecoder(context.Background(), "ffmpeg", sink); err != nil {
+			return err
+		}
+		defer func() {
+			if err := decoder.Close(); err != nil {
+				logger.Warn("Live stream decoding failed", "error", err)
+			}
+		}()
+	}
+
+	conn.ReadLimit = liveReadLimit
+	conn.OnPong = func() { conn.SetReadDeadline(time.Now().Add(eventSocketPongWait)) }
+	for {
+		// Writing audio blocks while the engine catches up, so the deadline
+		// is set again before every read
+		conn.SetReadDeadline(time.Now().Add(eventSocketPongWait))
+		op, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if op == websocket.OpBinary {
+			if decoder != nil {
+				if _, err := decoder.Write(data); err != nil {
+					return err
+				}
+			} else {
+				sink.Write(data)
+			}
+			continue
+		}
+
+		var message struct {
+			Type string `json:"type"`
+		}
+		if json.Unmarshal(data, &message) == nil && message.Type == "stop" {
+			return nil
+		}
+		writeEventSocketJSON(conn, EventSocketReply{Type: "error", Error: `Send audio as binary messages and {"type":"stop"} to end the stream`})
+	}
+}
+
+// saveLiveJob saves a stream's final segments as a completed job with the
+// recorded audio, so it can be exported, searched and summarized like any
+// other transcript
+func (h *Handler) saveLiveJob(stream liveStream, recording *live.Recording, session *live.Session, language string) (*models.TranscriptionJob, error) {
+	segments := session.Segments()
+	result := &interfaces.TranscriptResult{
+		Language:  language,
+		Segments:  make([]interfaces.TranscriptSegment, len(segments)),
+		ModelUsed: h.config.Live.Model,
+	}
+	texts := make([]string, len(segments))
+	for i, segment := range segments {
+		result.Segments[i] = interfaces.TranscriptSegment{Start: segment.Start, End: segment.End, Text: segment.Text}
+		texts[i] = segment.Text
+	}
+	result.Text = strings.Join(texts, " ")
+	transcription.CompactTranscript(result)
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	transcript := string(data)
+
+	title := stream.title
+	if title == "" {
+		title = "Live transcription " + time.Now().Format("2006-01-02 15:04")
+	}
+	seconds := session.Duration().Seconds()
+	format, codec, sampleRate, channels := "wav", "pcm_s16le", live.SampleRate, 1
+	now := time.Now()
+	device := h.liveDevice()
+	job := &models.TranscriptionJob{
+		Title:            &title,
+		Status:           models.StatusCompleted,
+		AudioPath:        recording.Path(),
+		Transcript:       &transcript,
+		DurationSeconds:  &seconds,
+		AudioFormat:      &format,
+		AudioCodec:       &codec,
+		SampleRate:       &sampleRate,
+		Channels:         &channels,
+		Progress:         1,
+		ProcessedSeconds: seconds,
+		CompletedAt:      &now,
+		Parameters: models.WhisperXParams{
+			ModelFamily: "whisper",
+			Model:       h.config.Live.Model,
+			Backend:     models.BackendFasterWhisper,
+			Device:      device,
+			ComputeType: models.DefaultComputeType(device),
+			Task:        "transcribe",
+		},
+	}
+	if stream.hasUser {
+		job.UserID = &stream.userID
+	}
+	if language != "" {
+		job.DetectedLanguage = &language
+	}
+	if stream.language != "" {
+		job.Parameters.Language = &stream.language
+	}
+	if err := database.DB.Create(job).Error; err != nil {
+		os.Remove(recording.Path())
+		return nil, err
+	}
+	if err := storeJob(context.Background(), job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
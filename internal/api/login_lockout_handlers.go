@@ -0,0 +1,145 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/auth"
+	"scriberr/pkg/logger"
+)
+
+// LoginLockoutResponse describes the failed logins of a username
+type LoginLockoutResponse struct {
+	Username          string     `json:"username"`
+	Failures          int        `json:"failures"`
+	Locked            bool       `json:"locked"`
+	LockedUntil       *time.Time `json:"locked_until,omitempty"`
+	RetryAfterSeconds int        `json:"retry_after_seconds,omitempty"`
+	LastFailureAt     time.Time  `json:"last_failure_at"`
+	LastIP            string     `json:"last_ip"`
+}
+
+// allowLogin applies the per-IP and per-username login rate limits and the
+// username's lockout. It writes a 429 response with Retry-After and returns
+// false when the attempt is refused.
+func (h *Handler) allowLogin(c *gin.Context, username string) bool {
+	ip := c.ClientIP()
+	now := time.Now()
+	refuse := func(reason string, retryAfter time.Duration) bool {
+		logger.AuditEvent("login_refused", ip, false, "username", username, "reason", reason, "retry_after", retryAfter.String())
+		c.Header("Retry-After", strconv.Itoa(retrySeconds(retryAfter)))
+		apierror.Abort(c, apierror.RateLimited("Too many login attempts, try again later", retrySeconds(retryAfter)))
+		return false
+	}
+
+	if allowed, retryAfter := h.loginIPLimiter.Take(ip, now); !allowed {
+		return refuse("ip_rate_limited", retryAfter)
+	}
+	if allowed, retryAfter := h.loginUserLimiter.Take(username, now); !allowed {
+		return refuse("username_rate_limited", retryAfter)
+	}
+	locked, err := auth.LockedOut(c.Request.Context(), username, now)
+	if err != nil {
+		logger.Error("Failed to check login lockout", "username", username, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to log in"))
+		return false
+	}
+	if locked > 0 {
+		return refuse("locked_out", locked)
+	}
+	return true
+}
+
+// recordLoginFailure counts a failed login towards the username's lockout
+func (h *Handler) recordLoginFailure(c *gin.Context, username string) {
+	lock, err := h.loginLockout.RecordFailure(c.Request.Context(), username, c.ClientIP(), time.Now())
+	if err != nil {
+		logger.Error("Failed to record failed login", "username", username, "error", err)
+		return
+	}
+	if lock > 0 {
+		logger.AuditEvent("login_lockout", c.ClientIP(), false, "username", username, "locked_for", lock.String())
+	}
+}
+
+// clearLoginFailures forgets the failed logins of a username after it logs in
+func (h *Handler) clearLoginFailures(c *gin.Context, username string) {
+	if _, err := auth.ClearLockout(c.Request.Context(), username); err != nil {
+		logger.Warn("Failed to clear failed logins", "username", username, "error", err)
+	}
+}
+
+// retrySeconds rounds a Retry-After delay up to whole seconds
+func retrySeconds(d time.Duration) int {
+	return int(d.Seconds() + 0.999)
+}
+
+// ListLoginLockouts returns the usernames with recent failed logins
+// @Summary List login lockouts
+// @Description List usernames with recent failed logins, whether they are locked out and until when, most recent failure first (admin only)
+// @Tags admin
+// @Produce json
+// @Success 200 {array} LoginLockoutResponse
+// @Failure 403 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/lockouts [get]
+func (h *Handler) ListLoginLockouts(c *gin.Context) {
+	now := time.Now()
+	lockouts, err := auth.ListLockouts(c.Request.Context(), now)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to list lockouts"))
+		return
+	}
+
+	response := make([]LoginLockoutResponse, len(lockouts))
+	for i, lockout := range lockouts {
+		response[i] = LoginLockoutResponse{
+			Username:      lockout.Username,
+			Failures:      lockout.Failures,
+			LastFailureAt: lockout.LastFailureAt,
+			LastIP:        lockout.LastIP,
+		}
+		if lockout.LockedUntil != nil && now.Before(*lockout.LockedUntil) {
+			response[i].Locked = true
+			response[i].LockedUntil = lockout.LockedUntil
+			response[i].RetryAfterSeconds = retrySeconds(lockout.LockedUntil.Sub(now))
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ClearLoginLockout unlocks a username and forgets its failed logins
+// @Summary Clear a login lockout
+// @Description Unlock a username, forgetting its failed logins and resetting its login rate limit (admin only)
+// @Tags admin
+// @Produce json
+// @Param username path string true "Username"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/lockouts/{username} [delete]
+func (h *Handler) ClearLoginLockout(c *gin.Context) {
+	username := c.Param("username")
+	cleared, err := auth.ClearLockout(c.Request.Context(), username)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to clear lockout"))
+		return
+	}
+	if !cleared {
+		apierror.Abort(c, apierror.NotFound("No failed logins for this username"))
+		return
+	}
+	h.loginUserLimiter.Reset(username)
+
+	logger.AuditEvent("login_lockout_cleared", c.ClientIP(), true, "username", username, "admin", c.GetString("username"))
+	c.JSON(http.StatusOK, gin.H{"message": "Lockout cleared"})
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/transcription"
+	"scriberr/internal/transcription/registry"
+	"scriberr/pkg/logger"
+)
+
+// ModelDownloadProgress is the payload of a model download "progress" event
+type ModelDownloadProgress struct {
+	Downloaded int64   `json:"downloaded"`
+	Total      int64   `json:"total"`
+	Percentage float64 `json:"percentage"`
+}
+
+// whisperXProjectPath returns the uv project the WhisperX adapter runs from
+func whisperXProjectPath() string {
+	if adapter, err := registry.GetRegistry().GetTranscriptionAdapter("whisperx"); err == nil {
+		return adapter.GetModelPath()
+	}
+	return filepath.Join("whisperx-env", "WhisperX")
+}
+
+// ListModels lists the WhisperX models and whether each is downloaded
+// @Summary List WhisperX models
+// @Description List the Whisper models WhisperX can use, with their size, where they are stored and whether they are downloaded or downloading
+// @Tags models
+// @Produce json
+// @Success 200 {object} map[string][]transcription.ModelInfo
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/models [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) ListModels(c *gin.Context) {
+	infos, err := h.modelManager.ListModels()
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to list models"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"models": infos})
+}
+
+// DownloadModel downloads a WhisperX model, streaming progress as server-sent events
+// @Summary Download a WhisperX model
+// @Description Download a Whisper model so jobs using it start immediately. Progress is streamed as "progress" events, followed by a "done" or "error" event. Requests for a model that is already downloading, including by a job, follow that download. Closing the stream cancels the download unless something else is waiting for it.
+// @Tags models
+// @Produce text/event-stream
+// @Param name path string true "Model name, e.g. large-v3"
+// @Success 200 {string} string "Event stream"
+// @Failure 404 {object} apierror.Envelope
+// @Router /api/v1/models/{name}/download [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) DownloadModel(c *gin.Context) {
+	name := c.Param("name")
+	if !h.modelManager.Supports(name) {
+		apierror.Abort(c, apierror.NotFound("Unknown model"))
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	err := h.modelManager.DownloadModelWithProgress(c.Request.Context(), name, func(downloaded, total int64) {
+		progress := ModelDownloadProgress{Downloaded: downloaded, Total: total}
+		if total > 0 {
+			progress.Percentage = float64(downloaded) / float64(total) * 100
+		}
+		c.SSEvent("progress", progress)
+		c.Writer.Flush()
+	})
+	if err != nil {
+		logger.Error("Model download failed", "model", name, "error", err)
+		message := err.Error()
+		if errors.Is(err, transcription.ErrModelBusy) {
+			message = "Model is being deleted"
+		}
+		c.SSEvent("error", gin.H{"error": message})
+		c.Writer.Flush()
+		return
+	}
+	c.SSEvent("done", gin.H{"model": name})
+	c.Writer.Flush()
+}
+
+// DeleteModel removes a downloaded WhisperX model
+// @Summary Delete a WhisperX model
+// @Description Remove a downloaded Whisper model to free disk space
+// @Tags models
+// @Produce json
+// @Param name path string true "Model name, e.g. large-v3"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/models/{name} [delete]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) DeleteModel(c *gin.Context) {
+	err := h.modelManager.DeleteModel(c.Request.Context(), c.Param("name"))
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"message": "Model deleted successfully"})
+	case errors.Is(err, transcription.ErrUnknownModel):
+		apierror.Abort(c, apierror.NotFound("Unknown model"))
+	case errors.Is(err, transcription.ErrModelNotCached):
+		apierror.Abort(c, apierror.NotFound("Model is not downloaded"))
+	case errors.Is(err, transcription.ErrModelBusy):
+		apierror.Abort(c, apierror.Conflict("Model is being downloaded"))
+	default:
+		apierror.Abort(c, apierror.Internal("Failed to delete model"))
+	}
+}
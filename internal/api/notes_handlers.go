@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"scriberr/internal/api/apierror"
 	"scriberr/internal/database"
 	"scriberr/internal/models"
 )
@@ -36,33 +37,33 @@ type NoteUpdateRequest struct {
 // @Produce json
 // @Param id path string true "Transcription ID"
 // @Success 200 {array} models.Note
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
 // @Security ApiKeyAuth
 // @Security BearerAuth
 // @Router /api/v1/transcription/{id}/notes [get]
 func (h *Handler) ListNotes(c *gin.Context) {
 	transcriptionID := c.Param("id")
 	if transcriptionID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Transcription ID is required"})
+		apierror.Abort(c, apierror.Validation("Transcription ID is required"))
 		return
 	}
 
 	// Ensure transcription exists
 	var job models.TranscriptionJob
-	if err := database.DB.Where("id = ?", transcriptionID).First(&job).Error; err != nil {
+	if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", transcriptionID).First(&job).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Transcription not found"})
+			apierror.Abort(c, apierror.JobNotFound())
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transcription"})
+		apierror.Abort(c, apierror.Internal("Failed to fetch transcription"))
 		return
 	}
 
 	var notes []models.Note
 	if err := database.DB.Where("transcription_id = ?", transcriptionID).
 		Order("start_time ASC, created_at ASC").Find(&notes).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notes"})
+		apierror.Abort(c, apierror.Internal("Failed to fetch notes"))
 		return
 	}
 
@@ -78,8 +79,8 @@ func (h *Handler) ListNotes(c *gin.Context) {
 // @Param id path string true "Transcription ID"
 // @Param request body NoteCreateRequest true "Note create payload"
 // @Success 201 {object} models.Note
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
 // @Security ApiKeyAuth
 // @Security BearerAuth
 // @Router /api/v1/transcription/{id}/notes [post]
@@ -87,38 +88,38 @@ func (h *Handler) CreateNote(c *gin.Context) {
 	transcriptionID := c.Param("id")
 	if transcriptionID == "" {
 		log.Printf("notes.CreateNote: missing transcription ID")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Transcription ID is required"})
+		apierror.Abort(c, apierror.Validation("Transcription ID is required"))
 		return
 	}
 
 	var req NoteCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Printf("notes.CreateNote: invalid payload for transcription %s: %v", transcriptionID, err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload", "details": err.Error()})
+		apierror.Abort(c, apierror.Validation("invalid payload: "+err.Error()))
 		return
 	}
 
 	if req.EndWordIndex < req.StartWordIndex {
 		log.Printf("notes.CreateNote: invalid indices (start=%d end=%d) for transcription %s", req.StartWordIndex, req.EndWordIndex, transcriptionID)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "end_word_index must be >= start_word_index", "start_word_index": req.StartWordIndex, "end_word_index": req.EndWordIndex})
+		apierror.Abort(c, apierror.Validation("end_word_index must be >= start_word_index").WithDetails(map[string]any{"start_word_index": req.StartWordIndex, "end_word_index": req.EndWordIndex}))
 		return
 	}
 	if req.EndTime < req.StartTime {
 		log.Printf("notes.CreateNote: invalid times (start=%.3f end=%.3f) for transcription %s", req.StartTime, req.EndTime, transcriptionID)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "end_time must be >= start_time", "start_time": req.StartTime, "end_time": req.EndTime})
+		apierror.Abort(c, apierror.Validation("end_time must be >= start_time").WithDetails(map[string]any{"start_time": req.StartTime, "end_time": req.EndTime}))
 		return
 	}
 
 	// Ensure transcription exists
 	var job models.TranscriptionJob
-	if err := database.DB.Where("id = ?", transcriptionID).First(&job).Error; err != nil {
+	if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", transcriptionID).First(&job).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			log.Printf("notes.CreateNote: transcription %s not found", transcriptionID)
-			c.JSON(http.StatusNotFound, gin.H{"error": "Transcription not found"})
+			apierror.Abort(c, apierror.JobNotFound())
 			return
 		}
 		log.Printf("notes.CreateNote: failed to fetch transcription %s: %v", transcriptionID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transcription"})
+		apierror.Abort(c, apierror.Internal("Failed to fetch transcription"))
 		return
 	}
 
@@ -137,7 +138,7 @@ func (h *Handler) CreateNote(c *gin.Context) {
 
 	if err := database.DB.Create(&n).Error; err != nil {
 		log.Printf("notes.CreateNote: DB error creating note for transcription %s (start=%d end=%d startTime=%.3f endTime=%.3f): %v", transcriptionID, n.StartWordIndex, n.EndWordIndex, n.StartTime, n.EndTime, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create note"})
+		apierror.Abort(c, apierror.Internal("Failed to create note"))
 		return
 	}
 
@@ -153,19 +154,19 @@ func (h *Handler) CreateNote(c *gin.Context) {
 // @Produce json
 // @Param note_id path string true "Note ID"
 // @Success 200 {object} models.Note
-// @Failure 404 {object} map[string]string
+// @Failure 404 {object} apierror.Envelope
 // @Security ApiKeyAuth
 // @Security BearerAuth
 // @Router /api/v1/notes/{note_id} [get]
 func (h *Handler) GetNote(c *gin.Context) {
 	noteID := c.Param("note_id")
 	var n models.Note
-	if err := database.DB.Where("id = ?", noteID).First(&n).Error; err != nil {
+	if err := database.DB.Scopes(ofOwnedJobs(c)).Where("id = ?", noteID).First(&n).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Note not found"})
+			apierror.Abort(c, apierror.NotFound("Note not found"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch note"})
+		apierror.Abort(c, apierror.Internal("Failed to fetch note"))
 		return
 	}
 	c.JSON(http.StatusOK, n)
@@ -180,8 +181,8 @@ func (h *Handler) GetNote(c *gin.Context) {
 // @Param note_id path string true "Note ID"
 // @Param request body NoteUpdateRequest true "Note update payload"
 // @Success 200 {object} models.Note
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
 // @Security ApiKeyAuth
 // @Security BearerAuth
 // @Router /api/v1/notes/{note_id} [put]
@@ -189,17 +190,17 @@ func (h *Handler) UpdateNote(c *gin.Context) {
 	noteID := c.Param("note_id")
 	var req NoteUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Validation(err.Error()))
 		return
 	}
 
 	var n models.Note
-	if err := database.DB.Where("id = ?", noteID).First(&n).Error; err != nil {
+	if err := database.DB.Scopes(ofOwnedJobs(c)).Where("id = ?", noteID).First(&n).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Note not found"})
+			apierror.Abort(c, apierror.NotFound("Note not found"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch note"})
+		apierror.Abort(c, apierror.Internal("Failed to fetch note"))
 		return
 	}
 
@@ -207,7 +208,7 @@ func (h *Handler) UpdateNote(c *gin.Context) {
 	n.UpdatedAt = time.Now()
 
 	if err := database.DB.Save(&n).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update note"})
+		apierror.Abort(c, apierror.Internal("Failed to update note"))
 		return
 	}
 
@@ -221,13 +222,13 @@ func (h *Handler) UpdateNote(c *gin.Context) {
 // @Produce json
 // @Param note_id path string true "Note ID"
 // @Success 204 {string} string "No Content"
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} apierror.Envelope
 // @Security ApiKeyAuth
 // @Router /api/v1/notes/{note_id} [delete]
 func (h *Handler) DeleteNote(c *gin.Context) {
 	noteID := c.Param("note_id")
-	if err := database.DB.Delete(&models.Note{}, "id = ?", noteID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete note"})
+	if err := database.DB.Scopes(ofOwnedJobs(c)).Delete(&models.Note{}, "id = ?", noteID).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to delete note"))
 		return
 	}
 	// Tests expect 200 on deletion
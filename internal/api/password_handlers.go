@@ -0,0 +1,135 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/auth"
+	"scriberr/pkg/logger"
+)
+
+// ResetPasswordRequest sets a new password with a reset token
+type ResetPasswordRequest struct {
+	Token           string `json:"token" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required"`
+	ConfirmPassword string `json:"confirmPassword" binding:"required"`
+}
+
+// PasswordResetTokenResponse is a one-time password reset token. It is only
+// shown once; the administrator hands it to the user.
+type PasswordResetTokenResponse struct {
+	UserID    uint      `json:"user_id"`
+	Username  string    `json:"username"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// allowPasswordAttempt rate limits password changes and resets by key. It
+// writes a 429 response with Retry-After and returns false when the attempt
+// is refused.
+func (h *Handler) allowPasswordAttempt(c *gin.Context, action, key string) bool {
+	allowed, retryAfter := h.passwordLimiter.Take(action+":"+key, time.Now())
+	if allowed {
+		return true
+	}
+	logger.AuditEvent("password_refused", c.ClientIP(), false, "action", action, "key", key, "retry_after", retryAfter.String())
+	c.Header("Retry-After", strconv.Itoa(retrySeconds(retryAfter)))
+	apierror.Abort(c, apierror.RateLimited("Too many attempts, try again later", retrySeconds(retryAfter)))
+	return false
+}
+
+// IssuePasswordReset creates a one-time password reset token for a user
+// @Summary Issue a password reset token
+// @Description Create a one-time token the user can set a new password with at POST /api/v1/auth/reset until it expires (PASSWORD_RESET_TTL_MINUTES). Tokens issued earlier for the user stop working. The token is only returned once. (admin only)
+// @Tags admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 201 {object} PasswordResetTokenResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 429 {object} apierror.Envelope
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id}/reset-password [post]
+func (h *Handler) IssuePasswordReset(c *gin.Context) {
+	if !h.allowPasswordAttempt(c, "reset_issue", c.GetString("username")) {
+		return
+	}
+	user, ok := h.loadManagedUser(c)
+	if !ok {
+		return
+	}
+	if user.Disabled {
+		apierror.Abort(c, apierror.BadRequest("Account is disabled"))
+		return
+	}
+
+	adminID, _ := currentUserID(c)
+	token, expiresAt, err := auth.CreatePasswordReset(c.Request.Context(), user.ID, adminID, h.config.PasswordResetTTL, time.Now())
+	if err != nil {
+		logger.Error("Failed to create password reset token", "user_id", user.ID, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to create password reset token"))
+		return
+	}
+
+	logger.AuditEvent("password_reset_issued", c.ClientIP(), true, "user_id", user.ID, "username", user.Username,
+		"admin", c.GetString("username"), "expires_at", expiresAt)
+	c.JSON(http.StatusCreated, PasswordResetTokenResponse{
+		UserID:    user.ID,
+		Username:  user.Username,
+		Token:     token,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// ResetPassword sets a new password with a reset token
+// @Summary Reset password
+// @Description Set a new password with a one-time token issued by an administrator. Every session of the user is ended; log in with the new password afterwards.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 429 {object} apierror.Envelope
+// @Router /api/v1/auth/reset [post]
+func (h *Handler) ResetPassword(c *gin.Context) {
+	if !h.allowPasswordAttempt(c, "reset", c.ClientIP()) {
+		return
+	}
+
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
+		return
+	}
+	if req.NewPassword != req.ConfirmPassword {
+		apierror.Abort(c, apierror.BadRequest("New passwords do not match"))
+		return
+	}
+
+	user, err := auth.ResetPassword(c.Request.Context(), req.Token, req.NewPassword, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidResetToken), errors.Is(err, auth.ErrAccountDisabled):
+			logger.AuthEvent("password_reset", "", c.ClientIP(), false, logger.String("reason", "invalid_token"))
+			apierror.Abort(c, apierror.Validation("Invalid or expired reset token"))
+		case errors.Is(err, auth.ErrWeakPassword):
+			apierror.Abort(c, apierror.Validation(err.Error()))
+		default:
+			logger.Error("Failed to reset password", "error", err)
+			apierror.Abort(c, apierror.Internal("Failed to reset password"))
+		}
+		return
+	}
+
+	// The reset also lifts a lockout from guessing the old password
+	h.clearLoginFailures(c, user.Username)
+	logger.AuthEvent("password_reset", user.Username, c.ClientIP(), true, "user_id", user.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+}
@@ -0,0 +1,332 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/auth"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/internal/storage"
+	"scriberr/internal/transcription"
+	"scriberr/pkg/logger"
+)
+
+const (
+	// presignedUploadPrefix holds the staging objects of presigned uploads
+	// to object storage until they are completed
+	presignedUploadPrefix = "presigned"
+	// maxPresignedUploadExpiry is the longest S3 accepts for presigned URLs
+	maxPresignedUploadExpiry = 7 * 24 * time.Hour
+)
+
+// PresignUploadRequest describes a file the client will upload directly
+type PresignUploadRequest struct {
+	Filename string `json:"filename" binding:"required"` // Original file name; its extension is kept
+	Size     int64  `json:"size" binding:"required,gt=0"`
+}
+
+// PresignUploadResponse tells the client where to upload a file
+type PresignUploadResponse struct {
+	JobID       string    `json:"job_id"`     // ID of the job created on upload-complete
+	UploadURL   string    `json:"upload_url"` // S3 presigned URL, or /upload/{token} with local storage
+	UploadToken string    `json:"upload_token"`
+	Method      string    `json:"method"` // Always PUT
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// PresignUpload issues a URL the client uploads a file to without going
+// through the API
+// @Summary Get a presigned upload URL
+// @Description Start an upload that goes straight to storage. With STORAGE_BACKEND=s3 the URL is an S3 presigned PUT URL. With local storage it is /upload/{token}, which accepts the file with PUT, can be resumed with the Upload-Offset header, and reports the bytes received with GET. Call POST /api/v1/transcriptions/{id}/upload-complete once the file is uploaded. URLs expire after UPLOAD_SESSION_TTL_HOURS, or 7 days if that is longer.
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param request body PresignUploadRequest true "File to upload"
+// @Success 201 {object} PresignUploadResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 413 {object} apierror.Envelope "File is larger than MAX_UPLOAD_SIZE_MB"
+// @Failure 415 {object} apierror.Envelope "File name does not have an accepted audio or video extension"
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/presign [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) PresignUpload(c *gin.Context) {
+	var req PresignUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
+		return
+	}
+	if h.config.MaxUploadSize > 0 && req.Size > h.config.MaxUploadSize {
+		apierror.Abort(c, apierror.TooLarge("File is too large").WithDetails(map[string]any{"max_size": h.config.MaxUploadSize}))
+		return
+	}
+	if !transcription.IsSupportedMediaFile(req.Filename) {
+		apierror.Abort(c, apierror.UnsupportedMedia("Unsupported file type"))
+		return
+	}
+
+	expiry := h.presignedUploadExpiry()
+	expiresAt := time.Now().Add(expiry).Truncate(time.Second)
+	session := models.UploadSession{
+		ID:        uuid.New().String(),
+		Filename:  filepath.Base(req.Filename),
+		Size:      req.Size,
+		ExpiresAt: &expiresAt,
+	}
+	if userID, ok := currentUserID(c); ok {
+		session.UserID = &userID
+	}
+	response := PresignUploadResponse{
+		JobID:       session.ID,
+		UploadToken: h.authService.GenerateUploadToken(session.ID, expiresAt),
+		Method:      http.MethodPut,
+		ExpiresAt:   expiresAt,
+	}
+
+	if storage.Local() {
+		path := h.partialUploadPath(session.ID)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			apierror.Abort(c, apierror.Internal("Failed to create upload directory"))
+			return
+		}
+		file, err := os.Create(path)
+		if err != nil {
+			apierror.Abort(c, apierror.Internal("Failed to start upload"))
+			return
+		}
+		file.Close()
+		response.UploadURL = "/upload/" + response.UploadToken
+	} else {
+		key := presignedUploadPrefix + "/" + session.ID + filepath.Ext(session.Filename)
+		url, err := storage.Current().SignedUploadURL(c.Request.Context(), key, expiry)
+		if err != nil {
+			logger.Error("Failed to presign upload", "upload_id", session.ID, "error", err)
+			apierror.Abort(c, apierror.Internal("Failed to presign upload"))
+			return
+		}
+		session.ObjectKey = &key
+		response.UploadURL = url
+	}
+
+	if err := database.DB.Create(&session).Error; err != nil {
+		os.Remove(h.partialUploadPath(session.ID))
+		apierror.Abort(c, apierror.Internal("Failed to start upload"))
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetPresignedUpload reports how much of a presigned upload has been received
+// @Summary Get a presigned upload
+// @Description Get the bytes received so far by a presigned upload to local storage, which is the offset to resume from. No credentials are needed besides the token.
+// @Tags transcription
+// @Produce json
+// @Param token path string true "Upload token"
+// @Success 200 {object} models.UploadSession
+// @Failure 403 {object} apierror.Envelope "Invalid token"
+// @Failure 404 {object} apierror.Envelope
+// @Failure 410 {object} apierror.Envelope "Token has expired"
+// @Router /upload/{token} [get]
+func (h *Handler) GetPresignedUpload(c *gin.Context) {
+	session, ok := h.presignedUploadSession(c)
+	if !ok {
+		return
+	}
+	c.Header(uploadOffsetHeader, strconv.FormatInt(session.BytesReceived, 10))
+	c.JSON(http.StatusOK, session)
+}
+
+// WritePresignedUpload writes the request body to a presigned upload
+// @Summary Upload to a presigned URL
+// @Description Write the raw request body to a presigned upload to local storage. Without an Upload-Offset header the body is the whole file and replaces anything received before. With it, the body is appended at that offset, which must equal the bytes received so far, so interrupted uploads can be resumed.
+// @Tags transcription
+// @Accept application/octet-stream
+// @Produce json
+// @Param token path string true "Upload token"
+// @Param Upload-Offset header int false "Offset the body starts at"
+// @Success 200 {object} models.UploadSession
+// @Failure 400 {object} apierror.Envelope
+// @Failure 403 {object} apierror.Envelope "Invalid token"
+// @Failure 404 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope "Offset does not match the bytes received"
+// @Failure 410 {object} apierror.Envelope "Token has expired"
+// @Failure 413 {object} apierror.Envelope "Body extends past the declared size"
+// @Failure 500 {object} apierror.Envelope
+// @Router /upload/{token} [put]
+func (h *Handler) WritePresignedUpload(c *gin.Context) {
+	offset := int64(0)
+	header := c.GetHeader(uploadOffsetHeader)
+	if header != "" {
+		var err error
+		if offset, err = strconv.ParseInt(header, 10, 64); err != nil || offset < 0 {
+			apierror.Abort(c, apierror.Validation("Invalid Upload-Offset header"))
+			return
+		}
+	}
+
+	session, ok := h.presignedUploadSession(c)
+	if !ok {
+		return
+	}
+	if !lockUploadID(c, session.ID) {
+		return
+	}
+	defer activeUploads.Delete(session.ID)
+	// Another request may have written to the upload before the lock was taken
+	if err := database.DB.First(session, "id = ?", session.ID).Error; err != nil {
+		apierror.Abort(c, apierror.NotFound("Upload not found"))
+		return
+	}
+
+	// A whole-file upload starts over
+	if header == "" && session.BytesReceived > 0 {
+		if err := os.Truncate(h.partialUploadPath(session.ID), 0); err != nil {
+			apierror.Abort(c, apierror.Internal("Failed to restart upload"))
+			return
+		}
+		if err := database.DB.Model(session).Update("bytes_received", 0).Error; err != nil {
+			apierror.Abort(c, apierror.Internal("Failed to restart upload"))
+			return
+		}
+		session.BytesReceived = 0
+	}
+	h.appendUploadChunk(c, session, offset)
+}
+
+// CompletePresignedUpload creates and queues the job of a presigned upload
+// @Summary Complete a presigned upload
+// @Description Signal that the file has been uploaded to the presigned URL. The file is moved into place and the job is created and queued from the same form fields as POST /api/v1/transcriptions. Repeating the call after it succeeded returns the job again.
+// @Tags transcription
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Job ID returned by presign"
+// @Success 200 {object} models.TranscriptionJob
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope "Upload is incomplete"
+// @Failure 415 {object} apierror.Envelope "Not an accepted audio or video type"
+// @Failure 422 {object} apierror.Envelope "File is not transcribable audio"
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/{id}/upload-complete [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) CompletePresignedUpload(c *gin.Context) {
+	priority, ok := requestedPriority(c)
+	if !ok {
+		return
+	}
+	scheduledAt, ok := requestedSchedule(c)
+	if !ok {
+		return
+	}
+	if !lockUpload(c) {
+		return
+	}
+	defer activeUploads.Delete(c.Param("id"))
+
+	session, ok := h.findUploadSession(c)
+	if !ok {
+		// The session is removed on completion, so a retried call finds the job
+		var job models.TranscriptionJob
+		if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", c.Param("id")).First(&job).Error; err == nil && ownsUpload(c, job.UserID) {
+			c.JSON(http.StatusOK, job)
+			return
+		}
+		apierror.Abort(c, apierror.NotFound("Upload not found"))
+		return
+	}
+	filePath, ok := h.finishUpload(c, session)
+	if !ok {
+		return
+	}
+	h.submitUploadedFile(c, session.ID, filePath, session.Filename, priority, scheduledAt)
+}
+
+// presignedUploadExpiry is how long presigned upload URLs are valid. It does
+// not exceed the session TTL, so sessions outlive their URLs.
+func (h *Handler) presignedUploadExpiry() time.Duration {
+	expiry := h.config.UploadSessionTTL
+	if expiry <= 0 {
+		expiry = defaultUploadSessionTTL
+	}
+	return min(expiry, maxPresignedUploadExpiry)
+}
+
+// presignedUploadSession loads the local presigned upload of the token in the
+// request path, writing an error response and returning false if the token
+// is invalid or expired or the upload is gone
+func (h *Handler) presignedUploadSession(c *gin.Context) (*models.UploadSession, bool) {
+	uploadID, err := h.authService.ValidateUploadToken(c.Param("token"))
+	if errors.Is(err, auth.ErrUploadTokenExpired) {
+		apierror.Abort(c, apierror.Gone("Upload token has expired"))
+		return nil, false
+	}
+	if err != nil {
+		apierror.Abort(c, apierror.Forbidden("Invalid upload token"))
+		return nil, false
+	}
+
+	var session models.UploadSession
+	if err := database.DB.Where("id = ?", uploadID).First(&session).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn("Failed to load upload", "upload_id", uploadID, "error", err)
+		}
+		apierror.Abort(c, apierror.NotFound("Upload not found"))
+		return nil, false
+	}
+	if session.ObjectKey != nil {
+		apierror.Abort(c, apierror.BadRequest("This upload goes to object storage"))
+		return nil, false
+	}
+	return &session, true
+}
+
+// fetchPresignedObject copies the staging object of a presigned upload to
+// filePath and deletes it, writing an error response and returning false if
+// it has not been uploaded in full
+func (h *Handler) fetchPresignedObject(c *gin.Context, session *models.UploadSession, filePath string) bool {
+	ctx := c.Request.Context()
+	s := storage.Current()
+	info, err := s.Stat(ctx, *session.ObjectKey)
+	if errors.Is(err, storage.ErrNotFound) || (err == nil && info.Size != session.Size) {
+		received := int64(0)
+		if info != nil {
+			received = info.Size
+		}
+		apierror.Abort(c, apierror.Conflict("Upload is incomplete").WithDetails(map[string]any{"offset": received, "size": session.Size}))
+		return false
+	}
+	if err != nil {
+		logger.Error("Failed to stat presigned upload", "upload_id", session.ID, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to read upload"))
+		return false
+	}
+
+	reader, err := s.Download(ctx, *session.ObjectKey)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to read upload"))
+		return false
+	}
+	defer reader.Close()
+	if _, err := streamToFile(reader, filePath); err != nil {
+		logger.Error("Failed to fetch presigned upload", "upload_id", session.ID, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to save file"))
+		return false
+	}
+	// The job's audio is stored under its own key once it is created
+	if err := s.Delete(context.WithoutCancel(ctx), *session.ObjectKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		logger.Warn("Failed to delete presigned upload object", "upload_id", session.ID, "error", err)
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/internal/transcription"
+	"scriberr/internal/transcription/registry"
+)
+
+// ProfileDependent is something that uses a profile and keeps it from being deleted
+type ProfileDependent struct {
+	Type string `json:"type"` // "watch_folder"
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// visibleProfiles scopes a query to the caller's own profiles and the shared ones
+func visibleProfiles(c *gin.Context) *gorm.DB {
+	if userID, ok := currentUserID(c); ok {
+		return database.DB.Where("user_id = ? OR user_id IS NULL", userID)
+	}
+	return database.DB.Where("user_id IS NULL")
+}
+
+// findProfile loads a profile visible to the caller, writing an error response
+// and returning false if there is none with the ID
+func findProfile(c *gin.Context, profileID string) (*models.TranscriptionProfile, bool) {
+	var profile models.TranscriptionProfile
+	if err := visibleProfiles(c).Where("id = ?", profileID).First(&profile).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apierror.Abort(c, apierror.NotFound("Profile not found"))
+			return nil, false
+		}
+		apierror.Abort(c, apierror.Internal("Failed to get profile"))
+		return nil, false
+	}
+	return &profile, true
+}
+
+// canModifyProfile reports whether the caller may change a profile, writing a
+// 403 response if not. Owners change their own profiles; shared profiles are
+// changed by admins.
+func canModifyProfile(c *gin.Context, profile *models.TranscriptionProfile) bool {
+	if c.GetString("role") == models.RoleAdmin {
+		return true
+	}
+	if userID, ok := currentUserID(c); ok && profile.UserID != nil && *profile.UserID == userID {
+		return true
+	}
+	apierror.Abort(c, apierror.Forbidden("Only admins can change shared profiles"))
+	return false
+}
+
+// profileNameTaken reports whether the profile's owner already has another
+// profile with its name
+func profileNameTaken(profile *models.TranscriptionProfile) (bool, error) {
+	query := database.DB.Model(&models.TranscriptionProfile{}).Where("name = ? AND id != ?", profile.Name, profile.ID)
+	if profile.UserID != nil {
+		query = query.Where("user_id = ?", *profile.UserID)
+	} else {
+		query = query.Where("user_id IS NULL")
+	}
+	var count int64
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// profileDependents lists what uses a profile
+func profileDependents(profileID string) ([]ProfileDependent, error) {
+	var folders []models.WatchFolder
+	if err := database.DB.Where("profile_id = ?", profileID).Order("id").Find(&folders).Error; err != nil {
+		return nil, err
+	}
+	dependents := make([]ProfileDependent, 0, len(folders))
+	for _, folder := range folders {
+		dependents = append(dependents, ProfileDependent{Type: "watch_folder", ID: folder.ID, Name: folder.Path})
+	}
+	return dependents, nil
+}
+
+// parameterNames are the JSON names of models.WhisperXParams fields
+var parameterNames = func() map[string]bool {
+	names := map[string]bool{}
+	paramsType := reflect.TypeOf(models.WhisperXParams{})
+	for i := 0; i < paramsType.NumField(); i++ {
+		name, _, _ := strings.Cut(paramsType.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}()
+
+// profileParameters returns the parameters of the caller's profile with the
+// JSON object in rawOverrides applied, writing an error response and
+// returning false if the profile is not found or an override is invalid.
+// Overrides may only set parameters the adapter that runs the profile's
+// model family declares, with values its parameter schema accepts.
+func profileParameters(c *gin.Context, profileID string, rawOverrides []byte) (models.WhisperXParams, bool) {
+	var profile models.TranscriptionProfile
+	if err := visibleProfiles(c).Where("id = ?", profileID).First(&profile).Error; err != nil {
+		apierror.Abort(c, apierror.BadRequest("Profile not found"))
+		return models.WhisperXParams{}, false
+	}
+	params := profile.Parameters
+	if len(bytes.TrimSpace(rawOverrides)) == 0 {
+		return params, true
+	}
+
+	var overrides map[string]interface{}
+	if err := json.Unmarshal(rawOverrides, &overrides); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid parameter overrides: "+err.Error()))
+		return models.WhisperXParams{}, false
+	}
+	adapterID := transcription.TranscriptionModelID(params)
+	schema, err := registry.GetRegistry().GetParameterSchema(adapterID)
+	if err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return models.WhisperXParams{}, false
+	}
+	declared := map[string]bool{}
+	for _, parameter := range schema {
+		declared[parameter.Name] = true
+	}
+	for name := range overrides {
+		if !declared[name] || !parameterNames[name] {
+			apierror.Abort(c, apierror.BadRequest(fmt.Sprintf("Parameter %s cannot be overridden for the %s model", name, adapterID)))
+			return models.WhisperXParams{}, false
+		}
+	}
+	if err := registry.GetRegistry().ValidateModelParameters(adapterID, overrides); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid parameter overrides: "+err.Error()))
+		return models.WhisperXParams{}, false
+	}
+	if err := json.Unmarshal(rawOverrides, &params); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid parameter overrides: "+err.Error()))
+		return models.WhisperXParams{}, false
+	}
+	return params, true
+}
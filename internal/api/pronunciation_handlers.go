@@ -0,0 +1,113 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/lexicon"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+)
+
+// maxPronunciationCSVSize bounds dictionary uploads; a full dictionary of the
+// longest entries is about 200 KB
+const maxPronunciationCSVSize = 1 << 20
+
+// PronunciationDictionaryResponse reports a stored pronunciation dictionary
+type PronunciationDictionaryResponse struct {
+	Entries int `json:"entries"`
+}
+
+// UploadPronunciationDictionary replaces the caller's pronunciation dictionary
+// @Summary Upload pronunciation dictionary
+// @Description Replaces the authenticated user's pronunciation dictionary with a CSV of word,phonetic pairs, for names and terms Whisper tends to misspell. A first line of "word,phonetic" is skipped. At most 1000 entries; pronunciations must be printable ASCII. The words are added to the initial prompt of the user's WhisperX jobs, after the job's own prompt, so the model prefers their spelling; words past the prompt limit are left out.
+// @Tags user
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV of word,phonetic pairs"
+// @Success 200 {object} PronunciationDictionaryResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 401 {object} apierror.Envelope
+// @Failure 413 {object} apierror.Envelope "Body is larger than MAX_UPLOAD_SIZE_MB"
+// @Failure 500 {object} apierror.Envelope
+// @Security BearerAuth
+// @Router /api/v1/users/me/pronunciation-dictionary [post]
+func (h *Handler) UploadPronunciationDictionary(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		apierror.Abort(c, apierror.Unauthorized("User not authenticated"))
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		apierror.Abort(c, apierror.Validation("A CSV file is required"))
+		return
+	}
+	defer file.Close()
+	if header.Size > maxPronunciationCSVSize {
+		apierror.Abort(c, apierror.BadRequest("The dictionary file is too large"))
+		return
+	}
+
+	entries, err := lexicon.ParseCSV(io.LimitReader(file, maxPronunciationCSVSize))
+	if err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	for i := range entries {
+		entries[i].UserID = userID
+	}
+
+	// The file is written last, so a failure to write it leaves the old
+	// dictionary in place
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.PronunciationEntry{}).Error; err != nil {
+			return err
+		}
+		if err := tx.CreateInBatches(entries, 100).Error; err != nil {
+			return err
+		}
+		return lexicon.Write(userID, entries)
+	})
+	if err != nil {
+		logger.Error("Failed to store pronunciation dictionary", "user_id", userID, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to store dictionary"))
+		return
+	}
+
+	c.JSON(http.StatusOK, PronunciationDictionaryResponse{Entries: len(entries)})
+}
+
+// DeletePronunciationDictionary clears the caller's pronunciation dictionary
+// @Summary Delete pronunciation dictionary
+// @Description Removes the authenticated user's pronunciation dictionary. Jobs that start afterwards no longer get its words in their prompt.
+// @Tags user
+// @Success 204
+// @Failure 401 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Security BearerAuth
+// @Router /api/v1/users/me/pronunciation-dictionary [delete]
+func (h *Handler) DeletePronunciationDictionary(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		apierror.Abort(c, apierror.Unauthorized("User not authenticated"))
+		return
+	}
+
+	if err := database.DB.Where("user_id = ?", userID).Delete(&models.PronunciationEntry{}).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to delete dictionary"))
+		return
+	}
+	if err := lexicon.Remove(userID); err != nil {
+		logger.Error("Failed to remove pronunciation lexicon", "user_id", userID, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to delete dictionary"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
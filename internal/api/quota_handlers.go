@@ -0,0 +1,254 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/internal/quota"
+	"scriberr/pkg/logger"
+)
+
+// QuotaResponse describes a user's usage against one quota
+type QuotaResponse struct {
+	Period           string    `json:"period"`
+	MaxSeconds       int       `json:"max_seconds"`
+	UsedSeconds      int       `json:"used_seconds"`
+	RemainingSeconds int       `json:"remaining_seconds"`
+	ResetsAt         time.Time `json:"resets_at"`
+}
+
+// SetQuotaRequest sets the limit of a quota
+type SetQuotaRequest struct {
+	MaxSeconds int `json:"max_seconds" binding:"min=0"`
+}
+
+func newQuotaResponses(quotas []models.Quota) []QuotaResponse {
+	response := make([]QuotaResponse, len(quotas))
+	for i, q := range quotas {
+		remaining := q.MaxSeconds - q.UsedSeconds
+		if remaining < 0 {
+			remaining = 0
+		}
+		response[i] = QuotaResponse{
+			Period:           q.Period,
+			MaxSeconds:       q.MaxSeconds,
+			UsedSeconds:      q.UsedSeconds,
+			RemainingSeconds: remaining,
+			ResetsAt:         q.ResetsAt(),
+		}
+	}
+	return response
+}
+
+// GetCurrentUserQuota returns the caller's quota usage
+// @Summary Get current user's quota
+// @Description Returns the transcription time quotas of the authenticated user and how much of each has been used. An empty list means usage is unlimited.
+// @Tags user
+// @Produce json
+// @Success 200 {object} map[string][]QuotaResponse
+// @Failure 401 {object} apierror.Envelope
+// @Security BearerAuth
+// @Router /api/v1/users/me/quota [get]
+func (h *Handler) GetCurrentUserQuota(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		apierror.Abort(c, apierror.Unauthorized("User not authenticated"))
+		return
+	}
+
+	quotas, err := quota.ForUser(c.Request.Context(), userID)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to get quota"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"quotas": newQuotaResponses(quotas)})
+}
+
+// GetUserQuotas returns a user's quota usage
+// @Summary Get user quotas
+// @Description Returns the transcription time quotas of a user (admin only)
+// @Tags admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string][]QuotaResponse
+// @Failure 404 {object} apierror.Envelope
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id}/quotas [get]
+func (h *Handler) GetUserQuotas(c *gin.Context) {
+	user, ok := h.loadManagedUser(c)
+	if !ok {
+		return
+	}
+
+	quotas, err := quota.ForUser(c.Request.Context(), user.ID)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to get quotas"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"quotas": newQuotaResponses(quotas)})
+}
+
+// SetUserQuota creates or changes a user's quota for a period
+// @Summary Set user quota
+// @Description Limits how many seconds of audio a user may transcribe per day or month (admin only). Changing the limit keeps the usage recorded so far.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param period path string true "Quota period (daily or monthly)"
+// @Param request body SetQuotaRequest true "Quota limit"
+// @Success 200 {object} QuotaResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id}/quotas/{period} [put]
+func (h *Handler) SetUserQuota(c *gin.Context) {
+	period := c.Param("period")
+	if !models.IsValidQuotaPeriod(period) {
+		apierror.Abort(c, apierror.Validation("Period must be 'daily' or 'monthly'"))
+		return
+	}
+	var req SetQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
+		return
+	}
+	user, ok := h.loadManagedUser(c)
+	if !ok {
+		return
+	}
+
+	var q models.Quota
+	err := database.DB.Where("user_id = ? AND period = ?", user.ID, period).First(&q).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		q = models.Quota{
+			UserID:      user.ID,
+			Period:      period,
+			MaxSeconds:  req.MaxSeconds,
+			PeriodStart: models.QuotaPeriodStart(period, time.Now()),
+		}
+		err = database.DB.Create(&q).Error
+	case err == nil:
+		q.MaxSeconds = req.MaxSeconds
+		err = database.DB.Model(&q).Update("max_seconds", req.MaxSeconds).Error
+	}
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to save quota"))
+		return
+	}
+
+	logger.Info("Quota set by admin", "username", user.Username, "period", period, "max_seconds", req.MaxSeconds, "admin", c.GetString("username"))
+	c.JSON(http.StatusOK, newQuotaResponses([]models.Quota{q})[0])
+}
+
+// DeleteUserQuota removes a user's quota for a period
+// @Summary Delete user quota
+// @Description Removes a user's daily or monthly quota, lifting that limit (admin only)
+// @Tags admin
+// @Produce json
+// @Param id path int true "User ID"
+// @Param period path string true "Quota period (daily or monthly)"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} apierror.Envelope
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id}/quotas/{period} [delete]
+func (h *Handler) DeleteUserQuota(c *gin.Context) {
+	user, ok := h.loadManagedUser(c)
+	if !ok {
+		return
+	}
+
+	result := database.DB.Where("user_id = ? AND period = ?", user.ID, c.Param("period")).Delete(&models.Quota{})
+	if result.Error != nil {
+		apierror.Abort(c, apierror.Internal("Failed to delete quota"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		apierror.Abort(c, apierror.NotFound("Quota not found"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Quota deleted successfully"})
+}
+
+// enforceQuota records the caller as the user a job's usage is charged to and
+// checks that transcribing it fits within their quotas. It writes a 429
+// response and returns false when a quota would be exceeded.
+func (h *Handler) enforceQuota(c *gin.Context, job *models.TranscriptionJob) bool {
+	exceeded, err := claimJobQuota(c, job)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to check quota"))
+		return false
+	}
+	if exceeded != nil {
+		apierror.Abort(c, apierror.New(http.StatusTooManyRequests, apierror.CodeQuotaExceeded, "quota exceeded").WithDetails(map[string]any{
+			"used_seconds": exceeded.UsedSeconds,
+			"max_seconds":  exceeded.MaxSeconds,
+		}))
+		return false
+	}
+	return true
+}
+
+// claimJobQuota assigns job to the caller and returns the first of their quotas
+// it would exceed. Jobs still queued or running count against the quota too, so
+// submitting many jobs at once cannot overshoot it.
+func claimJobQuota(c *gin.Context, job *models.TranscriptionJob) (*models.Quota, error) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return nil, nil
+	}
+	job.UserID = &userID
+	return checkJobQuota(c.Request.Context(), userID, job)
+}
+
+// checkJobQuota returns the first of a user's quotas that job would exceed
+// together with their other queued and running jobs
+func checkJobQuota(ctx context.Context, userID uint, job *models.TranscriptionJob) (*models.Quota, error) {
+	quotas, err := quota.ForUser(ctx, userID)
+	if err != nil || len(quotas) == 0 {
+		return nil, err
+	}
+	seconds, err := quota.JobSeconds(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	inFlight, err := inFlightSeconds(ctx, userID, job.ID)
+	if err != nil {
+		return nil, err
+	}
+	return quota.Exceeded(quotas, seconds+inFlight), nil
+}
+
+// inFlightSeconds sums the audio length of a user's queued and running jobs,
+// other than excludeJobID, which are not yet charged to their quotas
+func inFlightSeconds(ctx context.Context, userID uint, excludeJobID string) (int, error) {
+	var total float64
+	err := database.DB.WithContext(ctx).Model(&models.TranscriptionJob{}).
+		Select("COALESCE(SUM(duration_seconds), 0)").
+		Where("user_id = ? AND id <> ? AND status IN ?", userID, excludeJobID,
+			[]models.JobStatus{models.StatusScheduled, models.StatusPending, models.StatusProcessing}).
+		Scan(&total).Error
+	return quota.Seconds(total), err
+}
+
+// currentUserID returns the ID of the authenticated caller
+func currentUserID(c *gin.Context) (uint, bool) {
+	current, exists := c.Get("user_id")
+	if !exists {
+		return 0, false
+	}
+	id, ok := current.(uint)
+	return id, ok
+}
@@ -0,0 +1,260 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/config"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/internal/ratelimit"
+	"scriberr/pkg/logger"
+	"scriberr/pkg/middleware"
+)
+
+// Route classes of the API rate limiter, each with its own budget
+const (
+	rateLimitRead   = "read"
+	rateLimitWrite  = "write"
+	rateLimitSubmit = "submit"
+	rateLimitLLM    = "llm"
+)
+
+// rateLimitPeriod is the period the rate limits are counted over
+const rateLimitPeriod = time.Minute
+
+// submitRoutes are the routes that create jobs or take uploads, relative to
+// the API version
+var submitRoutes = map[string]bool{
+	"POST /transcriptions":                     true,
+	"POST /transcriptions/stream":              true,
+	"POST /transcriptions/batch":               true,
+	"POST /transcriptions/presign":             true,
+	"POST /jobs/batch":                         true,
+	"POST /transcription/upload":               true,
+	"POST /transcription/upload-video":         true,
+	"POST /transcription/upload-multitrack":    true,
+	"POST /transcription/uploads":              true,
+	"POST /transcription/youtube":              true,
+	"POST /transcription/from-url":             true,
+	"POST /transcription/submit":               true,
+	"POST /transcription/:id/start":            true,
+	"POST /transcription/quick":                true,
+	"POST /transcriptions/:id/upload-complete": true,
+}
+
+// llmRoutes are the routes that call the LLM, relative to the API version
+var llmRoutes = map[string]bool{
+	"POST /transcriptions/:id/chat":              true,
+	"POST /chat/sessions/:session_id/messages":   true,
+	"POST /chat/sessions/:session_id/title/auto": true,
+	"POST /summarize/":                           true,
+}
+
+// apiPrefix matches the /api prefix of a route, with its version if any
+var apiPrefix = regexp.MustCompile(`^/api(/v[0-9]+)?`)
+
+// rateLimitClass returns the route class of a request to route
+func rateLimitClass(method, route string) string {
+	key := method + " " + apiPrefix.ReplaceAllString(route, "")
+	switch {
+	case llmRoutes[key]:
+		return rateLimitLLM
+	case submitRoutes[key]:
+		return rateLimitSubmit
+	case method == http.MethodGet || method == http.MethodHead:
+		return rateLimitRead
+	default:
+		return rateLimitWrite
+	}
+}
+
+// apiRateLimiter holds the API's rate limits and the buckets counting
+// requests against them
+type apiRateLimiter struct {
+	store ratelimit.Store
+
+	mu     sync.RWMutex
+	limits models.RateLimits
+}
+
+// newAPIRateLimiter creates the API rate limiter with the configured limits,
+// or the limits last set through the API
+func newAPIRateLimiter(cfg *config.Config) *apiRateLimiter {
+	limiter := &apiRateLimiter{
+		store: ratelimit.NewMemoryStore(),
+		limits: models.RateLimits{
+			Read:   cfg.RateLimitRead,
+			Write:  cfg.RateLimitWrite,
+			Submit: cfg.RateLimitSubmit,
+			LLM:    cfg.RateLimitLLM,
+		},
+	}
+	if database.DB == nil {
+		return limiter
+	}
+	var setting models.RateLimitSetting
+	if err := database.DB.First(&setting).Error; err == nil {
+		limiter.limits = setting.RateLimits
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Warn("Failed to load rate limits, using the configured ones", "error", err)
+	}
+	return limiter
+}
+
+// Limits returns the current rate limits
+func (l *apiRateLimiter) Limits() models.RateLimits {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.limits
+}
+
+// SetLimits replaces the rate limits; requests are counted against them at once
+func (l *apiRateLimiter) SetLimits(limits models.RateLimits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits = limits
+}
+
+// limit returns the requests per period allowed for a route class
+func (l *apiRateLimiter) limit(class string) int {
+	limits := l.Limits()
+	switch class {
+	case rateLimitRead:
+		return limits.Read
+	case rateLimitSubmit:
+		return limits.Submit
+	case rateLimitLLM:
+		return limits.LLM
+	default:
+		return limits.Write
+	}
+}
+
+// rateLimitIdentity returns who a request is counted against, resolved as
+// AuthMiddleware resolves it: the owner of a valid API key or JWT, the user
+// of the trusted proxy's header, or else the client IP. Credentials that do
+// not check out count against the client IP, so a client cannot get a fresh
+// budget by making up a new key for each request.
+func (h *Handler) rateLimitIdentity(c *gin.Context) string {
+	key, token := middleware.Credentials(c)
+	switch {
+	case key != "":
+		if apiKey, err := h.authService.LookupAPIKey(c.Request.Context(), key); err == nil {
+			return "api_key:" + strconv.FormatUint(uint64(apiKey.ID), 10)
+		}
+	case token != "":
+		if claims, err := h.authService.ValidateToken(token); err == nil {
+			return "user:" + strconv.FormatUint(uint64(claims.UserID), 10)
+		}
+	default:
+		if username := middleware.HeaderUsername(c); username != "" {
+			return "username:" + username
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// RateLimitAPI limits API requests per user, API key or client IP with a token
+// bucket per route class. Every API response carries the X-RateLimit-Limit,
+// X-RateLimit-Remaining and X-RateLimit-Reset headers, and refused requests
+// get a 429 with Retry-After.
+func (h *Handler) RateLimitAPI() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := negotiateVersion(c.Request.URL.Path); !ok {
+			c.Next()
+			return
+		}
+		class := rateLimitClass(c.Request.Method, c.FullPath())
+		limit := h.rateLimiter.limit(class)
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+
+		identity := h.rateLimitIdentity(c)
+		decision, err := h.rateLimiter.store.Take(c.Request.Context(), class+":"+identity, limit, rateLimitPeriod, time.Now())
+		if err != nil {
+			// Rather serve requests than refuse them all while the store is down
+			logger.Warn("Rate limit store failed, allowing request", "identity", identity, "class", class, "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(retrySeconds(decision.Reset)))
+		if !decision.Allowed {
+			logger.Warn("Rate limit exceeded",
+				"identity", identity,
+				"class", class,
+				"method", c.Request.Method,
+				"path", c.Request.URL.Path,
+				"limit", limit)
+			c.Header("Retry-After", strconv.Itoa(retrySeconds(decision.RetryAfter)))
+			apierror.Abort(c, apierror.RateLimited("Too many requests, try again later", retrySeconds(decision.RetryAfter)))
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetRateLimits returns the API rate limits
+// @Summary Get API rate limits
+// @Description Get the requests per minute the API allows each user, API key or client IP, by route class: read (GET), submit (job submissions and uploads), llm (chat and summarization) and write (everything else). Zero is unlimited. (admin only)
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.RateLimits
+// @Failure 403 {object} apierror.Envelope
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/settings/rate-limits [get]
+func (h *Handler) GetRateLimits(c *gin.Context) {
+	c.JSON(http.StatusOK, h.rateLimiter.Limits())
+}
+
+// UpdateRateLimits changes the API rate limits at runtime and stores them, so
+// they outlast restarts
+// @Summary Update API rate limits
+// @Description Set the requests per minute the API allows each user, API key or client IP, by route class. The limits apply at once and replace the configured ones; classes left out keep their limit. Zero is unlimited. (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body models.RateLimits true "Requests per minute by route class"
+// @Success 200 {object} models.RateLimits
+// @Failure 400 {object} apierror.Envelope
+// @Failure 403 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v1/admin/settings/rate-limits [put]
+func (h *Handler) UpdateRateLimits(c *gin.Context) {
+	// Classes left out of the request keep their limit
+	limits := h.rateLimiter.Limits()
+	if err := c.ShouldBindJSON(&limits); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	if err := limits.Validate(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+
+	setting := models.RateLimitSetting{ID: 1, RateLimits: limits}
+	if err := database.DB.Save(&setting).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to save rate limits").WithCause(err))
+		return
+	}
+	h.rateLimiter.SetLimits(limits)
+	logger.Info("API rate limits changed", "by", c.GetString("username"), "limits", fmt.Sprintf("%+v", limits))
+	c.JSON(http.StatusOK, limits)
+}
@@ -0,0 +1,477 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/internal/storage"
+	"scriberr/internal/transcription"
+	"scriberr/pkg/logger"
+)
+
+const (
+	// uploadOffsetHeader carries the offset a chunk starts at, and the bytes
+	// received so far in responses
+	uploadOffsetHeader = "Upload-Offset"
+	// partialUploadDir holds resumable uploads, under the upload directory
+	partialUploadDir = "partial"
+	// defaultUploadSessionTTL applies when UploadSessionTTL is unset
+	defaultUploadSessionTTL = 24 * time.Hour
+)
+
+// activeUploads holds the IDs of sessions with a chunk or completion in
+// flight, so concurrent requests cannot interleave writes to the same file
+var activeUploads sync.Map
+
+// CreateUploadRequest starts a resumable upload
+type CreateUploadRequest struct {
+	Filename string  `json:"filename" binding:"required"` // Original file name; its extension is kept
+	Size     int64   `json:"size" binding:"required,gt=0"`
+	Title    *string `json:"title,omitempty"`
+}
+
+// CreateUploadSession starts a resumable upload
+// @Summary Start a resumable upload
+// @Description Start uploading a large audio file in chunks. Send the chunks in order with PATCH /uploads/{id}, then create the job with POST /uploads/{id}/complete. Uploads that receive no chunk within UPLOAD_SESSION_TTL_HOURS are removed.
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param request body CreateUploadRequest true "File to upload"
+// @Success 201 {object} models.UploadSession
+// @Failure 400 {object} apierror.Envelope
+// @Failure 413 {object} apierror.Envelope "File is larger than MAX_UPLOAD_SIZE_MB"
+// @Failure 415 {object} apierror.Envelope "File name does not have an accepted audio or video extension"
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/transcription/uploads [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) CreateUploadSession(c *gin.Context) {
+	var req CreateUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
+		return
+	}
+	if h.config.MaxUploadSize > 0 && req.Size > h.config.MaxUploadSize {
+		apierror.Abort(c, apierror.TooLarge("File is too large").WithDetails(map[string]any{"max_size": h.config.MaxUploadSize}))
+		return
+	}
+	if !transcription.IsSupportedMediaFile(req.Filename) {
+		apierror.Abort(c, apierror.UnsupportedMedia("Unsupported file type"))
+		return
+	}
+
+	session := models.UploadSession{
+		ID:       uuid.New().String(),
+		Filename: filepath.Base(req.Filename),
+		Size:     req.Size,
+	}
+	if req.Title != nil && *req.Title != "" {
+		session.Title = req.Title
+	}
+	if userID, ok := currentUserID(c); ok {
+		session.UserID = &userID
+	}
+
+	path := h.partialUploadPath(session.ID)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to create upload directory"))
+		return
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to start upload"))
+		return
+	}
+	file.Close()
+
+	if err := database.DB.Create(&session).Error; err != nil {
+		os.Remove(path)
+		apierror.Abort(c, apierror.Internal("Failed to start upload"))
+		return
+	}
+
+	c.Header("Location", "/api/v1/transcription/uploads/"+session.ID)
+	c.Header(uploadOffsetHeader, "0")
+	c.JSON(http.StatusCreated, session)
+}
+
+// GetUploadSession reports how much of a resumable upload has been received
+// @Summary Get a resumable upload
+// @Description Get the bytes received so far, which is the offset to resume from after a failed chunk
+// @Tags transcription
+// @Produce json
+// @Param id path string true "Upload ID"
+// @Success 200 {object} models.UploadSession
+// @Failure 404 {object} apierror.Envelope
+// @Router /api/v1/transcription/uploads/{id} [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) GetUploadSession(c *gin.Context) {
+	session, ok := h.loadUploadSession(c)
+	if !ok {
+		return
+	}
+	c.Header(uploadOffsetHeader, strconv.FormatInt(session.BytesReceived, 10))
+	c.JSON(http.StatusOK, session)
+}
+
+// AppendUploadChunk writes the request body to a resumable upload
+// @Summary Upload a chunk
+// @Description Append the raw request body to the upload. The Upload-Offset header must equal the bytes received so far. If the connection drops, the bytes that arrived are kept; get the upload to find where to resume.
+// @Tags transcription
+// @Accept application/octet-stream
+// @Produce json
+// @Param id path string true "Upload ID"
+// @Param Upload-Offset header int true "Offset the chunk starts at"
+// @Success 200 {object} models.UploadSession
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope "Offset does not match the bytes received"
+// @Failure 413 {object} apierror.Envelope "Chunk extends past the declared size"
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/transcription/uploads/{id} [patch]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) AppendUploadChunk(c *gin.Context) {
+	offset, err := strconv.ParseInt(c.GetHeader(uploadOffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		apierror.Abort(c, apierror.Validation("Upload-Offset header is required"))
+		return
+	}
+	if !lockUpload(c) {
+		return
+	}
+	defer activeUploads.Delete(c.Param("id"))
+
+	session, ok := h.loadUploadSession(c)
+	if !ok {
+		return
+	}
+	h.appendUploadChunk(c, session, offset)
+}
+
+// appendUploadChunk writes the request body to an upload at offset, which must
+// equal the bytes received so far. The caller holds the upload's lock.
+func (h *Handler) appendUploadChunk(c *gin.Context, session *models.UploadSession, offset int64) {
+	c.Header(uploadOffsetHeader, strconv.FormatInt(session.BytesReceived, 10))
+	if offset != session.BytesReceived {
+		apierror.Abort(c, apierror.Conflict("Upload-Offset does not match the bytes received").WithDetails(map[string]any{"offset": session.BytesReceived}))
+		return
+	}
+	remaining := session.Size - offset
+	if c.Request.ContentLength > remaining {
+		apierror.Abort(c, apierror.TooLarge("Chunk extends past the declared size").WithDetails(map[string]any{"offset": offset}))
+		return
+	}
+
+	file, err := os.OpenFile(h.partialUploadPath(session.ID), os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to open upload"))
+		return
+	}
+	defer file.Close()
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to open upload"))
+		return
+	}
+
+	// Stream the body straight to disk; reading one byte past the remaining
+	// size detects chunks without a Content-Length that are too long
+	written, copyErr := io.Copy(file, io.LimitReader(c.Request.Body, remaining+1))
+	if written > remaining {
+		file.Truncate(offset)
+		apierror.Abort(c, apierror.TooLarge("Chunk extends past the declared size").WithDetails(map[string]any{"offset": offset}))
+		return
+	}
+
+	// Record what arrived, even from an interrupted chunk, so the client can
+	// resume from there. The data is synced first so the recorded offset
+	// never points past what is on disk.
+	if written > 0 {
+		if err := file.Sync(); err != nil {
+			apierror.Abort(c, apierror.Internal("Failed to save chunk"))
+			return
+		}
+		result := database.DB.Model(&models.UploadSession{}).
+			Where("id = ? AND bytes_received = ?", session.ID, offset).
+			Update("bytes_received", offset+written)
+		if result.Error != nil || result.RowsAffected == 0 {
+			apierror.Abort(c, apierror.Internal("Failed to save chunk"))
+			return
+		}
+		session.BytesReceived = offset + written
+	}
+	c.Header(uploadOffsetHeader, strconv.FormatInt(session.BytesReceived, 10))
+
+	if copyErr != nil {
+		logger.Warn("Upload chunk interrupted", "upload_id", session.ID, "received", session.BytesReceived, "error", copyErr)
+		apierror.Abort(c, apierror.BadRequest("Chunk was interrupted").WithDetails(map[string]any{"offset": session.BytesReceived}))
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// CompleteUpload creates a job from a fully received resumable upload
+// @Summary Complete a resumable upload
+// @Description Create the job once every byte has been received, exactly like a single-request upload. Repeating the call after it succeeded returns the job again.
+// @Tags transcription
+// @Produce json
+// @Param id path string true "Upload ID"
+// @Success 200 {object} models.TranscriptionJob
+// @Failure 404 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope "Upload is incomplete"
+// @Failure 415 {object} apierror.Envelope "Not an accepted audio or video type"
+// @Failure 422 {object} apierror.Envelope "File is not transcribable audio"
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/transcription/uploads/{id}/complete [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) CompleteUpload(c *gin.Context) {
+	if !lockUpload(c) {
+		return
+	}
+	defer activeUploads.Delete(c.Param("id"))
+
+	session, ok := h.findUploadSession(c)
+	if !ok {
+		// The session is removed on completion, so a retried call finds the job
+		var job models.TranscriptionJob
+		if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", c.Param("id")).First(&job).Error; err == nil && ownsUpload(c, job.UserID) {
+			c.JSON(http.StatusOK, job)
+			return
+		}
+		apierror.Abort(c, apierror.NotFound("Upload not found"))
+		return
+	}
+	filePath, ok := h.finishUpload(c, session)
+	if !ok {
+		return
+	}
+
+	title := ""
+	if session.Title != nil {
+		title = *session.Title
+	}
+	// The upload ID becomes the job ID
+	h.createUploadedJob(c, session.ID, filePath, title, session.Filename)
+}
+
+// finishUpload moves a fully received upload to the upload directory and
+// deletes its session, returning the file's path. It writes an error response
+// and returns false if bytes are missing. The caller holds the upload's lock.
+// The job's upload time is counted from when the session was created.
+func (h *Handler) finishUpload(c *gin.Context, session *models.UploadSession) (string, bool) {
+	c.Set(uploadStartKey, session.CreatedAt)
+	filePath := filepath.Join(h.config.UploadDir, session.ID+filepath.Ext(session.Filename))
+	if session.ObjectKey != nil {
+		if !h.fetchPresignedObject(c, session, filePath) {
+			return "", false
+		}
+	} else {
+		if session.BytesReceived != session.Size {
+			c.Header(uploadOffsetHeader, strconv.FormatInt(session.BytesReceived, 10))
+			apierror.Abort(c, apierror.Conflict("Upload is incomplete").WithDetails(map[string]any{"offset": session.BytesReceived, "size": session.Size}))
+			return "", false
+		}
+
+		// A crash after a write but before its offset was recorded can leave
+		// bytes past the end; they were received again afterwards
+		partialPath := h.partialUploadPath(session.ID)
+		if err := os.Truncate(partialPath, session.Size); err != nil {
+			apierror.Abort(c, apierror.Internal("Failed to save file"))
+			return "", false
+		}
+		if err := os.Rename(partialPath, filePath); err != nil {
+			apierror.Abort(c, apierror.Internal("Failed to save file"))
+			return "", false
+		}
+	}
+	if err := database.DB.Delete(session).Error; err != nil {
+		logger.Warn("Failed to delete completed upload", "upload_id", session.ID, "error", err)
+	}
+	return filePath, true
+}
+
+// CancelUpload discards a resumable upload
+// @Summary Cancel a resumable upload
+// @Description Delete an unfinished upload and the bytes received so far
+// @Tags transcription
+// @Produce json
+// @Param id path string true "Upload ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/transcription/uploads/{id} [delete]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) CancelUpload(c *gin.Context) {
+	if !lockUpload(c) {
+		return
+	}
+	defer activeUploads.Delete(c.Param("id"))
+
+	session, ok := h.loadUploadSession(c)
+	if !ok {
+		return
+	}
+	if err := h.removeUploadSession(session); err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to cancel upload"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Upload cancelled"})
+}
+
+// CleanupAbandonedUploads removes resumable uploads that have received no
+// chunk within the session TTL, along with partial files left without a
+// session. It returns the number of uploads removed.
+func (h *Handler) CleanupAbandonedUploads(ctx context.Context) (int, error) {
+	ttl := h.config.UploadSessionTTL
+	if ttl <= 0 {
+		ttl = defaultUploadSessionTTL
+	}
+	cutoff := time.Now().Add(-ttl)
+
+	var sessions []models.UploadSession
+	if err := database.DB.WithContext(ctx).Where("updated_at < ?", cutoff).Find(&sessions).Error; err != nil {
+		return 0, err
+	}
+	removed := 0
+	for i := range sessions {
+		if _, busy := activeUploads.LoadOrStore(sessions[i].ID, struct{}{}); busy {
+			continue
+		}
+		err := h.removeUploadSession(&sessions[i])
+		activeUploads.Delete(sessions[i].ID)
+		if err != nil {
+			return removed, err
+		}
+		removed++
+	}
+
+	entries, err := os.ReadDir(filepath.Join(h.config.UploadDir, partialUploadDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return removed, nil
+		}
+		return removed, err
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		var count int64
+		if err := database.DB.WithContext(ctx).Model(&models.UploadSession{}).Where("id = ?", entry.Name()).Count(&count).Error; err != nil || count > 0 {
+			continue
+		}
+		if err := os.Remove(filepath.Join(h.config.UploadDir, partialUploadDir, entry.Name())); err == nil {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// StartUploadCleanup removes abandoned resumable uploads every interval until
+// ctx is done
+func (h *Handler) StartUploadCleanup(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				removed, err := h.CleanupAbandonedUploads(ctx)
+				if err != nil {
+					logger.Warn("Failed to clean up abandoned uploads", "error", err)
+				} else if removed > 0 {
+					logger.Debug("Removed abandoned uploads", "count", removed)
+				}
+			}
+		}
+	}()
+}
+
+// partialUploadPath returns where a resumable upload's bytes are written
+func (h *Handler) partialUploadPath(id string) string {
+	return filepath.Join(h.config.UploadDir, partialUploadDir, id)
+}
+
+// removeUploadSession deletes an upload and its partial file or staging object
+func (h *Handler) removeUploadSession(session *models.UploadSession) error {
+	if err := os.Remove(h.partialUploadPath(session.ID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if session.ObjectKey != nil {
+		if err := storage.Current().Delete(context.Background(), *session.ObjectKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+	return database.DB.Delete(session).Error
+}
+
+// lockUpload claims the upload in the request path, writing a 409 response
+// and returning false if another request is already using it
+func lockUpload(c *gin.Context) bool {
+	return lockUploadID(c, c.Param("id"))
+}
+
+// lockUploadID claims an upload, writing a 409 response and returning false
+// if another request is already using it
+func lockUploadID(c *gin.Context, id string) bool {
+	if _, busy := activeUploads.LoadOrStore(id, struct{}{}); busy {
+		apierror.Abort(c, apierror.Conflict("Another request is writing to this upload"))
+		return false
+	}
+	return true
+}
+
+// loadUploadSession loads the caller's upload in the request path, writing a
+// 404 response and returning false if there is none
+func (h *Handler) loadUploadSession(c *gin.Context) (*models.UploadSession, bool) {
+	session, ok := h.findUploadSession(c)
+	if !ok {
+		apierror.Abort(c, apierror.NotFound("Upload not found"))
+	}
+	return session, ok
+}
+
+// findUploadSession loads the caller's upload in the request path
+func (h *Handler) findUploadSession(c *gin.Context) (*models.UploadSession, bool) {
+	var session models.UploadSession
+	if err := database.DB.Where("id = ?", c.Param("id")).First(&session).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn("Failed to load upload", "upload_id", c.Param("id"), "error", err)
+		}
+		return nil, false
+	}
+	if !ownsUpload(c, session.UserID) {
+		return nil, false
+	}
+	return &session, true
+}
+
+// ownsUpload reports whether the caller may use an upload started by owner
+func ownsUpload(c *gin.Context, owner *uint) bool {
+	if owner == nil {
+		return true
+	}
+	userID, ok := currentUserID(c)
+	return ok && userID == *owner
+}
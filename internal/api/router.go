@@ -1,7 +1,10 @@
 package api
 
 import (
+	"scriberr/internal/api/apierror"
 	"scriberr/internal/auth"
+	"scriberr/internal/models"
+	"scriberr/internal/transcription"
 	"scriberr/internal/web"
 	"scriberr/pkg/logger"
 	"scriberr/pkg/middleware"
@@ -19,6 +22,12 @@ func SetupRoutes(handler *Handler, authService *auth.AuthService) *gin.Engine {
 	
 	// Create Gin router without default middleware
 	router := gin.New()
+
+	// Only trust X-Forwarded-For from configured proxies for the client IP
+	if err := router.SetTrustedProxies(handler.config.TrustedProxies); err != nil {
+		logger.Warn("Ignoring invalid TRUSTED_PROXIES", "error", err)
+		_ = router.SetTrustedProxies(nil)
+	}
 	
 	// Add recovery middleware
 	router.Use(gin.Recovery())
@@ -26,190 +35,398 @@ func SetupRoutes(handler *Handler, authService *auth.AuthService) *gin.Engine {
 	// Add custom logger middleware
 	router.Use(logger.GinLogger())
 
+	// Liveness and readiness probes (no auth required). Registered before the
+	// header authentication middleware so local container probes are not
+	// refused for bypassing the proxy.
+	router.GET("/api/health", handler.Liveness)
+	router.GET("/api/health/ready", handler.Readiness)
+
+	// Behind an authenticating proxy, refuse requests that bypass it
+	if handler.config.HeaderAuth.Enabled {
+		router.Use(middleware.TrustedHeaderAuth(handler.config.HeaderAuth.Header, handler.config.TrustedProxies))
+	}
+
 	// Add compression middleware first for maximum benefit
 	router.Use(middleware.CompressionMiddleware())
 
-	// Add CORS middleware
-	router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key")
+	// Report the API version and add it to error envelopes
+	router.Use(versionMiddleware())
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
+	// Answer errors handlers record without writing them
+	router.Use(apierror.Middleware(handler.config.Production))
 
-		c.Next()
-	})
+	// Refuse request bodies larger than the upload or JSON limit
+	router.Use(middleware.BodyLimitMiddleware(handler.config.MaxJSONBodySize, handler.config.MaxUploadSize))
+
+	// Answer cross-origin requests from ALLOWED_ORIGINS
+	router.Use(middleware.CORSMiddleware(handler.config.AllowedOrigins))
+
+	// Limit API requests per user, API key or client IP
+	router.Use(handler.RateLimitAPI())
+
+	// Until the first admin exists, only setup is served under /api
+	router.Use(handler.RequireSetup())
 
 	// Health check endpoint (no auth required)
 	router.GET("/health", handler.HealthCheck)
 
+	// Prometheus metrics endpoint (no auth required)
+	router.GET("/metrics", handler.Metrics)
+
+	// Presigned uploads to local storage (the token authorizes them)
+	router.GET("/upload/:token", handler.GetPresignedUpload)
+	router.PUT("/upload/:token", middleware.NoCompressionMiddleware(), middleware.UploadBodyMiddleware(), handler.RequireFreeSpace(), handler.WritePresignedUpload)
+
 	// Swagger documentation
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// API v1 routes
-	v1 := router.Group("/api/v1")
+	// OpenAPI document and API reference (auth required)
+	docs := router.Group("/api")
+	docs.Use(middleware.AuthMiddleware(authService), middleware.RequireMethodScope())
 	{
-		// Authentication routes (no auth required)
-		auth := v1.Group("/auth")
-		{
-			auth.GET("/registration-status", handler.GetRegistrationStatus)
-			auth.POST("/register", handler.Register)
-			auth.POST("/login", handler.Login)
-			auth.POST("/refresh", handler.Refresh)
-			auth.POST("/logout", handler.Logout)
-
-			// Account management routes (require authentication)
-			authProtected := auth.Group("")
-			// Account management must require JWT (API keys do not represent a user)
-			authProtected.Use(middleware.JWTOnlyMiddleware(authService))
-			{
-				authProtected.POST("/change-password", handler.ChangePassword)
-				authProtected.POST("/change-username", handler.ChangeUsername)
-			}
-		}
+		docs.GET("/openapi.json", handler.GetOpenAPISpec)
+		docs.GET("/docs", handler.APIDocs)
+	}
 
-		// API Key management routes (require authentication)
-		apiKeys := v1.Group("/api-keys")
-		// API key management restricted to JWT-authenticated users
-		apiKeys.Use(middleware.JWTOnlyMiddleware(authService))
-		{
-			apiKeys.GET("/", handler.ListAPIKeys)
-			apiKeys.POST("/", handler.CreateAPIKey)
-			apiKeys.DELETE("/:id", handler.DeleteAPIKey)
-		}
+	// Versioned API routes, and the unversioned aliases of the current version
+	mountAPIVersions(router, handler, authService)
 
-		// Transcription routes (require authentication)
-		transcription := v1.Group("/transcription")
-		transcription.Use(middleware.AuthMiddleware(authService))
-		{
-			// File upload routes - disable compression for these
-			uploadRoutes := transcription.Group("")
-			uploadRoutes.Use(middleware.NoCompressionMiddleware())
-			{
-				uploadRoutes.POST("/upload", handler.UploadAudio)
-				uploadRoutes.POST("/upload-video", handler.UploadVideo)
-				uploadRoutes.POST("/upload-multitrack", handler.UploadMultiTrack)
-				uploadRoutes.GET("/:id/audio", handler.GetAudioFile) // Audio streaming shouldn't be compressed
-			}
-			
-			// Regular API routes with compression
-			transcription.POST("/youtube", handler.DownloadFromYouTube)
-			transcription.POST("/submit", handler.SubmitJob)
-			transcription.POST("/:id/start", handler.StartTranscription)
-			transcription.POST("/:id/kill", handler.KillJob)
-			transcription.GET("/:id/status", handler.GetJobStatus)
-			transcription.GET("/:id/transcript", handler.GetTranscript)
-			transcription.GET("/:id/execution", handler.GetJobExecutionData)
-			transcription.GET("/:id/merge-status", handler.GetMergeStatus)
-			transcription.GET("/:id/track-progress", handler.GetTrackProgress)
-			transcription.PUT("/:id/title", handler.UpdateTranscriptionTitle)
-			transcription.GET("/:id/summary", handler.GetSummaryForTranscription)
-			transcription.GET("/:id", handler.GetJobByID)
-			transcription.DELETE("/:id", handler.DeleteJob)
-			transcription.GET("/list", handler.ListJobs)
-			transcription.GET("/models", handler.GetSupportedModels)
-			// Notes for a transcription
-			transcription.GET("/:id/notes", handler.ListNotes)
-			transcription.POST("/:id/notes", handler.CreateNote)
-
-			// Speaker mappings for a transcription
-			transcription.GET("/:id/speakers", handler.GetSpeakerMappings)
-			transcription.POST("/:id/speakers", handler.UpdateSpeakerMappings)
-
-			// Quick transcription endpoints
-			transcription.POST("/quick", handler.SubmitQuickTranscription)
-			transcription.GET("/quick/:id", handler.GetQuickTranscriptionStatus)
-		}
+	// Set up static file serving for React app
+	web.SetupStaticRoutes(router, web.FrontendConfig{
+		MaxUploadSize:    handler.config.MaxUploadSize,
+		MaxJSONBodySize:  handler.config.MaxJSONBodySize,
+		UploadExtensions: transcription.MediaExtensions(),
+		UploadMIMETypes:  transcription.MediaTypes(),
+	})
 
-		// Profile routes (require authentication)
-		profiles := v1.Group("/profiles")
-		profiles.Use(middleware.AuthMiddleware(authService))
-		{
-			profiles.GET("/", handler.ListProfiles)
-			profiles.POST("/", handler.CreateProfile)
-			profiles.GET("/:id", handler.GetProfile)
-			profiles.PUT("/:id", handler.UpdateProfile)
-			profiles.DELETE("/:id", handler.DeleteProfile)
-			profiles.POST("/:id/set-default", handler.SetDefaultProfile)
-		}
+	return router
+}
 
-		// User routes (require authentication)
-		user := v1.Group("/user")
-		user.Use(middleware.JWTOnlyMiddleware(authService))
-		{
-			user.GET("/default-profile", handler.GetUserDefaultProfile)
-			user.POST("/default-profile", handler.SetUserDefaultProfile)
-			user.GET("/settings", handler.GetUserSettings)
-			user.PUT("/settings", handler.UpdateUserSettings)
-		}
+// registerV1Routes registers the routes of API v1 under v1
+func registerV1Routes(v1 *gin.RouterGroup, handler *Handler, authService *auth.AuthService) {
+	// First-run setup (no auth required)
+	v1.GET("/setup", handler.GetSetupStatus)
+	v1.POST("/setup", handler.CompleteSetup)
 
-		// Admin routes (require authentication)
-		admin := v1.Group("/admin")
-		admin.Use(middleware.AuthMiddleware(authService))
-		{
-			queue := admin.Group("/queue")
-			{
-				queue.GET("/stats", handler.GetQueueStats)
-			}
-		}
+	// Authentication routes (no auth required)
+	auth := v1.Group("/auth")
+	{
+		auth.GET("/registration-status", handler.GetRegistrationStatus)
+		auth.POST("/register", handler.Register)
+		auth.POST("/login", handler.Login)
+		auth.POST("/refresh", handler.Refresh)
+		auth.POST("/logout", handler.Logout)
+		auth.GET("/oidc/login", handler.OIDCLogin)
+		auth.GET("/oidc/callback", handler.OIDCCallback)
+		auth.POST("/header-login", handler.HeaderLogin)
+		auth.POST("/reset", handler.ResetPassword)
 
-		// LLM configuration routes (require authentication)
-		llm := v1.Group("/llm")
-		llm.Use(middleware.AuthMiddleware(authService))
+		// Account management routes (require authentication)
+		authProtected := auth.Group("")
+		// Account management must require JWT (API keys do not represent a user)
+		authProtected.Use(middleware.JWTOnlyMiddleware(authService))
 		{
-			llm.GET("/config", handler.GetLLMConfig)
-			llm.POST("/config", handler.SaveLLMConfig)
+			authProtected.POST("/change-password", handler.ChangePassword)
+			authProtected.POST("/change-username", handler.ChangeUsername)
 		}
+	}
+
+	// API Key management routes (require authentication)
+	apiKeys := v1.Group("/api-keys")
+	// API key management restricted to JWT-authenticated users
+	apiKeys.Use(middleware.JWTOnlyMiddleware(authService))
+	{
+		apiKeys.GET("/", handler.ListAPIKeys)
+		apiKeys.POST("/", handler.CreateAPIKey)
+		apiKeys.GET("/:id", handler.GetAPIKey)
+		apiKeys.DELETE("/:id", handler.DeleteAPIKey)
+	}
+
+	// Transcription planning routes (require authentication)
+	transcriptions := v1.Group("/transcriptions")
+	transcriptions.Use(middleware.AuthMiddleware(authService), middleware.RequireMethodScope())
+	{
+		transcriptions.POST("", middleware.NoCompressionMiddleware(), handler.RequireFreeSpace(), handler.SubmitJob)
+		transcriptions.POST("/stream", middleware.NoCompressionMiddleware(), handler.RequireFreeSpace(), handler.StreamUpload)
+		transcriptions.POST("/estimate", handler.EstimateTranscription)
+		transcriptions.POST("/batch", middleware.NoCompressionMiddleware(), handler.RequireFreeSpace(), handler.SubmitBatch)
+		transcriptions.GET("/batch/:batch_id", handler.GetBatchJobs)
+		transcriptions.POST("/presign", handler.RequireFreeSpace(), handler.PresignUpload)
+		transcriptions.POST("/:id/upload-complete", handler.CompletePresignedUpload)
+		transcriptions.PATCH("/:id", handler.UpdateJob)
+		transcriptions.PATCH("/:id/schedule", handler.UpdateJobSchedule)
+		transcriptions.PATCH("/:id/retention", handler.UpdateJobRetention)
+		transcriptions.POST("/:id/delete-audio", handler.DeleteJobAudio)
+		transcriptions.GET("/:id/diff/:other_id", handler.DiffTranscripts)
+		transcriptions.GET("/:id/chapters", handler.GetJobChapters)
+		transcriptions.GET("/:id/timeline", handler.GetJobTimeline)
+		transcriptions.POST("/:id/chat", handler.ChatWithTranscript)
+		transcriptions.POST("/:id/tags", handler.AddJobTag)
+		transcriptions.DELETE("/:id/tags/:key", handler.DeleteJobTag)
+		transcriptions.POST("/bulk/tags", handler.BulkTagJobs)
+		transcriptions.POST("/bulk/move", handler.BulkMoveJobs)
+		transcriptions.POST("/bulk/delete", handler.BulkDeleteJobs)
+		transcriptions.POST("/archive", handler.BulkArchiveJobs)
+		transcriptions.POST("/:id/archive", handler.ArchiveJob)
+		transcriptions.POST("/:id/unarchive", handler.UnarchiveJob)
+		transcriptions.DELETE("/:id", handler.DeleteJob)
+		transcriptions.POST("/:id/restore", handler.RestoreJob)
+		transcriptions.POST("/:id/share", handler.CreateShareLink)
+		transcriptions.GET("/:id/share", handler.ListShareLinks)
+		transcriptions.DELETE("/:id/share/:share_id", handler.RevokeShareLink)
+	}
+
+	// Public share links (the token authorizes them, rate limited per token)
+	share := v1.Group("/share")
+	share.Use(handler.LimitShareRate())
+	{
+		share.GET("/:token", handler.GetSharedTranscript)
+		share.GET("/:token/audio", middleware.NoCompressionMiddleware(), handler.GetSharedAudio) // Audio streaming shouldn't be compressed
+	}
+
+	// Tag and folder routes (require authentication)
+	tags := v1.Group("/tags")
+	tags.Use(middleware.AuthMiddleware(authService), middleware.RequireMethodScope())
+	{
+		tags.GET("", handler.ListTags)
+	}
+
+	folders := v1.Group("/folders")
+	folders.Use(middleware.AuthMiddleware(authService), middleware.RequireMethodScope())
+	{
+		folders.GET("", handler.ListFolders)
+		folders.POST("", handler.CreateFolder)
+		folders.PATCH("/:id", handler.RenameFolder)
+		folders.DELETE("/:id", handler.DeleteFolder)
+	}
 
-		// Summarization templates routes (require authentication)
-		summaries := v1.Group("/summaries")
-		summaries.Use(middleware.AuthMiddleware(authService))
+	// Batch upload and download routes (require authentication)
+	jobs := v1.Group("/jobs")
+	jobs.Use(middleware.AuthMiddleware(authService), middleware.RequireMethodScope())
+	{
+		jobs.POST("/batch", middleware.NoCompressionMiddleware(), handler.RequireFreeSpace(), handler.CreateBatch)
+		jobs.GET("/batch/:id", handler.GetBatchStatus)
+		jobs.GET("/:id/archive", middleware.NoCompressionMiddleware(), handler.DownloadJobArchive)
+		jobs.POST("/archive", middleware.NoCompressionMiddleware(), handler.DownloadJobArchives)
+	}
+
+	// Live queue events over a WebSocket (authenticated during the upgrade)
+	v1.GET("/ws", middleware.QueryTokenMiddleware(), middleware.NoCompressionMiddleware(), middleware.AuthMiddleware(authService), middleware.RequireScope(models.ScopeRead), handler.EventSocket)
+
+	// Live transcription of audio streamed over a WebSocket (authenticated during the upgrade)
+	v1.GET("/transcription/live", middleware.QueryTokenMiddleware(), middleware.NoCompressionMiddleware(), middleware.AuthMiddleware(authService), middleware.RequireScope(models.ScopeWrite), handler.RequireFreeSpace(), handler.LiveTranscription)
+
+	// Transcription routes (require authentication)
+	transcription := v1.Group("/transcription")
+	transcription.Use(middleware.AuthMiddleware(authService), middleware.RequireMethodScope())
+	{
+		// File upload routes - disable compression for these
+		uploadRoutes := transcription.Group("")
+		uploadRoutes.Use(middleware.NoCompressionMiddleware())
 		{
-			summaries.GET("/", handler.ListSummaryTemplates)
-			summaries.POST("/", handler.CreateSummaryTemplate)
-			summaries.GET("/:id", handler.GetSummaryTemplate)
-			summaries.PUT("/:id", handler.UpdateSummaryTemplate)
-			summaries.DELETE("/:id", handler.DeleteSummaryTemplate)
-			summaries.GET("/settings", handler.GetSummarySettings)
-			summaries.POST("/settings", handler.SaveSummarySettings)
+			uploadRoutes.POST("/upload", handler.RequireFreeSpace(), handler.UploadAudio)
+			uploadRoutes.POST("/upload-video", handler.RequireFreeSpace(), handler.UploadVideo)
+			uploadRoutes.POST("/upload-multitrack", handler.RequireFreeSpace(), handler.UploadMultiTrack)
+			uploadRoutes.POST("/uploads", handler.RequireFreeSpace(), handler.CreateUploadSession)
+			uploadRoutes.GET("/uploads/:id", handler.GetUploadSession)
+			uploadRoutes.PATCH("/uploads/:id", middleware.UploadBodyMiddleware(), handler.RequireFreeSpace(), handler.AppendUploadChunk)
+			uploadRoutes.POST("/uploads/:id/complete", handler.CompleteUpload)
+			uploadRoutes.DELETE("/uploads/:id", handler.CancelUpload)
+			uploadRoutes.GET("/:id/audio", handler.GetAudioFile) // Audio streaming shouldn't be compressed
 		}
+		
+		// Regular API routes with compression
+		transcription.POST("/youtube", handler.RequireFreeSpace(), handler.DownloadFromYouTube)
+		transcription.POST("/from-url", handler.RequireFreeSpace(), handler.CreateJobFromURL)
+		transcription.POST("/submit", handler.RequireFreeSpace(), handler.SubmitJob)
+		transcription.POST("/:id/start", handler.StartTranscription)
+		transcription.POST("/:id/kill", handler.KillJob)
+		transcription.GET("/:id/status", handler.GetJobStatus)
+		transcription.GET("/:id/transcript", handler.GetTranscript)
+		transcription.GET("/:id/transcript/revisions", handler.ListTranscriptRevisions)
+		transcription.GET("/:id/export", handler.ExportTranscript)
+		transcription.POST("/:id/transcript/revert/:revision", handler.RevertTranscript)
+		transcription.PUT("/:id/transcript/segments/:segIdx", handler.UpdateTranscriptSegment)
+		transcription.POST("/:id/transcript/segments/:segIdx/split", handler.SplitTranscriptSegment)
+		transcription.POST("/:id/transcript/segments/:segIdx/merge", handler.MergeTranscriptSegments)
+		transcription.GET("/:id/execution", handler.GetJobExecutionData)
+		transcription.GET("/:id/merge-status", handler.GetMergeStatus)
+		transcription.GET("/:id/track-progress", handler.GetTrackProgress)
+		transcription.PUT("/:id/title", handler.UpdateTranscriptionTitle)
+		transcription.GET("/:id/summary", handler.GetSummaryForTranscription)
+		transcription.GET("/:id/waveform", handler.GetWaveform)
+		transcription.GET("/:id", handler.GetJobByID)
+		transcription.DELETE("/:id", handler.DeleteJob)
+		transcription.GET("/list", handler.ListJobs)
+		transcription.GET("/models", handler.GetSupportedModels)
+		// Notes for a transcription
+		transcription.GET("/:id/notes", handler.ListNotes)
+		transcription.POST("/:id/notes", handler.CreateNote)
+
+		// Speaker mappings for a transcription
+		transcription.GET("/:id/speakers", handler.GetSpeakerMappings)
+		transcription.POST("/:id/speakers", handler.UpdateSpeakerMappings)
+		transcription.PATCH("/:id/speakers", handler.RenameSpeakers)
+		transcription.POST("/:id/speakers/merge", handler.MergeSpeakers)
+
+		// Quick transcription endpoints
+		transcription.POST("/quick", handler.RequireFreeSpace(), handler.SubmitQuickTranscription)
+		transcription.GET("/quick/:id", handler.GetQuickTranscriptionStatus)
+	}
+
+	// WhisperX model routes (require authentication; changes require admin)
+	modelRoutes := v1.Group("/models")
+	modelRoutes.Use(middleware.AuthMiddleware(authService), middleware.RequireMethodScope())
+	{
+		modelRoutes.GET("", handler.ListModels)
+		modelRoutes.POST("/:name/download", middleware.RequireRole(models.RoleAdmin), middleware.RequireScope(models.ScopeAdmin), handler.DownloadModel)
+		modelRoutes.DELETE("/:name", middleware.RequireRole(models.RoleAdmin), middleware.RequireScope(models.ScopeAdmin), handler.DeleteModel)
+	}
 
-		// Chat routes (require authentication)
-		chat := v1.Group("/chat")
-		chat.Use(middleware.AuthMiddleware(authService))
+	// Watch folder routes (require admin)
+	watchFolders := v1.Group("/watch-folders")
+	watchFolders.Use(middleware.AuthMiddleware(authService), middleware.RequireRole(models.RoleAdmin), middleware.RequireScope(models.ScopeAdmin))
+	{
+		watchFolders.GET("", handler.ListWatchFolders)
+		watchFolders.POST("", handler.CreateWatchFolder)
+		watchFolders.DELETE("/:id", handler.DeleteWatchFolder)
+	}
+
+	// Profile routes (require authentication)
+	profiles := v1.Group("/profiles")
+	profiles.Use(middleware.AuthMiddleware(authService), middleware.RequireMethodScope())
+	{
+		profiles.GET("/", handler.ListProfiles)
+		profiles.POST("/", handler.CreateProfile)
+		profiles.GET("/:id", handler.GetProfile)
+		profiles.PUT("/:id", handler.UpdateProfile)
+		profiles.DELETE("/:id", handler.DeleteProfile)
+		profiles.POST("/:id/set-default", handler.SetDefaultProfile)
+	}
+
+	// User routes (require authentication)
+	user := v1.Group("/user")
+	user.Use(middleware.JWTOnlyMiddleware(authService))
+	{
+		user.GET("/default-profile", handler.GetUserDefaultProfile)
+		user.POST("/default-profile", handler.SetUserDefaultProfile)
+		user.GET("/settings", handler.GetUserSettings)
+		user.PUT("/settings", handler.UpdateUserSettings)
+	}
+
+	// Self-service account routes (require authentication)
+	users := v1.Group("/users")
+	users.Use(middleware.JWTOnlyMiddleware(authService))
+	{
+		users.GET("/me", handler.GetCurrentUser)
+		users.PATCH("/me", handler.UpdateCurrentUser)
+		users.GET("/me/quota", handler.GetCurrentUserQuota)
+		users.POST("/me/pronunciation-dictionary", handler.UploadPronunciationDictionary)
+		users.DELETE("/me/pronunciation-dictionary", handler.DeletePronunciationDictionary)
+		users.GET("/me/saved-filters", handler.ListSavedFilters)
+		users.POST("/me/saved-filters", handler.CreateSavedFilter)
+		users.PUT("/me/saved-filters/:id", handler.UpdateSavedFilter)
+		users.DELETE("/me/saved-filters/:id", handler.DeleteSavedFilter)
+	}
+
+	// System routes (require authentication)
+	system := v1.Group("/system")
+	system.Use(middleware.AuthMiddleware(authService), middleware.RequireMethodScope())
+	{
+		system.GET("/storage", handler.GetStorageReport)
+		system.GET("/environment", handler.GetEnvironment)
+		system.GET("/whisperx-status", handler.GetWhisperXStatus)
+		system.POST("/setup", middleware.RequireRole(models.RoleAdmin), middleware.RequireScope(models.ScopeAdmin), handler.SetupWhisperX)
+	}
+
+	// Throughput statistics (admin only)
+	v1.GET("/stats", middleware.AuthMiddleware(authService), middleware.RequireRole(models.RoleAdmin), middleware.RequireScope(models.ScopeAdmin), handler.GetStats)
+
+	// Admin routes (require authentication)
+	admin := v1.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(authService), middleware.RequireRole(models.RoleAdmin), middleware.RequireScope(models.ScopeAdmin))
+	{
+		queue := admin.Group("/queue")
 		{
-			chat.GET("/models", handler.GetChatModels)
-			chat.POST("/sessions", handler.CreateChatSession)
-			chat.GET("/transcriptions/:transcription_id/sessions", handler.GetChatSessions)
-			chat.GET("/sessions/:session_id", handler.GetChatSession)
-			chat.POST("/sessions/:session_id/messages", handler.SendChatMessage)
-			chat.PUT("/sessions/:session_id/title", handler.UpdateChatSessionTitle)
-			chat.POST("/sessions/:session_id/title/auto", handler.AutoGenerateChatTitle)
-			chat.DELETE("/sessions/:session_id", handler.DeleteChatSession)
+			queue.GET("/stats", handler.GetQueueStats)
 		}
 
-		// Notes routes (require authentication)
-		notes := v1.Group("/notes")
-		notes.Use(middleware.AuthMiddleware(authService))
+		admin.POST("/whisperx-env/repair", handler.RepairWhisperXEnvironment)
+		admin.GET("/logs", handler.GetRecentLogs)
+		admin.GET("/storage", handler.GetStorageByUser)
+		admin.GET("/lockouts", handler.ListLoginLockouts)
+		admin.DELETE("/lockouts/:username", handler.ClearLoginLockout)
+		admin.GET("/settings/rate-limits", handler.GetRateLimits)
+		admin.PUT("/settings/rate-limits", handler.UpdateRateLimits)
+
+		adminCleanup := admin.Group("/cleanup")
 		{
-			notes.GET("/:note_id", handler.GetNote)
-			notes.PUT("/:note_id", handler.UpdateNote)
-			notes.DELETE("/:note_id", handler.DeleteNote)
+			adminCleanup.GET("/preview", handler.PreviewCleanup)
+			adminCleanup.POST("/run", handler.RunCleanup)
+			adminCleanup.GET("/stats", handler.GetCleanupStats)
 		}
 
-		// Summarization route (require authentication)
-		summarize := v1.Group("/summarize")
-		summarize.Use(middleware.AuthMiddleware(authService))
+		adminUsers := admin.Group("/users")
 		{
-			summarize.POST("/", handler.Summarize)
+			adminUsers.GET("", handler.ListUsers)
+			adminUsers.POST("", handler.CreateUser)
+			adminUsers.PATCH("/:id", handler.UpdateUser)
+			adminUsers.DELETE("/:id", handler.DeleteUser)
+			adminUsers.POST("/:id/reset-password", handler.IssuePasswordReset)
+			adminUsers.GET("/:id/quotas", handler.GetUserQuotas)
+			adminUsers.PUT("/:id/quotas/:period", handler.SetUserQuota)
+			adminUsers.DELETE("/:id/quotas/:period", handler.DeleteUserQuota)
 		}
 	}
 
-	// Set up static file serving for React app
-	web.SetupStaticRoutes(router)
+	// LLM configuration routes (require authentication)
+	llm := v1.Group("/llm")
+	llm.Use(middleware.AuthMiddleware(authService), middleware.RequireMethodScope())
+	{
+		llm.GET("/config", handler.GetLLMConfig)
+		llm.POST("/config", handler.SaveLLMConfig)
+	}
 
-	return router
+	// Summarization templates routes (require authentication)
+	summaries := v1.Group("/summaries")
+	summaries.Use(middleware.AuthMiddleware(authService), middleware.RequireMethodScope())
+	{
+		summaries.GET("/", handler.ListSummaryTemplates)
+		summaries.POST("/", handler.CreateSummaryTemplate)
+		summaries.GET("/:id", handler.GetSummaryTemplate)
+		summaries.PUT("/:id", handler.UpdateSummaryTemplate)
+		summaries.DELETE("/:id", handler.DeleteSummaryTemplate)
+		summaries.GET("/settings", handler.GetSummarySettings)
+		summaries.POST("/settings", handler.SaveSummarySettings)
+	}
+
+	// Chat routes (require authentication)
+	chat := v1.Group("/chat")
+	chat.Use(middleware.AuthMiddleware(authService), middleware.RequireMethodScope())
+	{
+		chat.GET("/models", handler.GetChatModels)
+		chat.POST("/sessions", handler.CreateChatSession)
+		chat.GET("/transcriptions/:transcription_id/sessions", handler.GetChatSessions)
+		chat.GET("/sessions/:session_id", handler.GetChatSession)
+		chat.POST("/sessions/:session_id/messages", handler.SendChatMessage)
+		chat.PUT("/sessions/:session_id/title", handler.UpdateChatSessionTitle)
+		chat.POST("/sessions/:session_id/title/auto", handler.AutoGenerateChatTitle)
+		chat.DELETE("/sessions/:session_id", handler.DeleteChatSession)
+	}
+
+	// Notes routes (require authentication)
+	notes := v1.Group("/notes")
+	notes.Use(middleware.AuthMiddleware(authService), middleware.RequireMethodScope())
+	{
+		notes.GET("/:note_id", handler.GetNote)
+		notes.PUT("/:note_id", handler.UpdateNote)
+		notes.DELETE("/:note_id", handler.DeleteNote)
+	}
+
+	// Summarization route (require authentication)
+	summarize := v1.Group("/summarize")
+	summarize.Use(middleware.AuthMiddleware(authService), middleware.RequireMethodScope())
+	{
+		summarize.POST("/", handler.Summarize)
+	}
 }
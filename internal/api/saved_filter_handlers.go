@@ -0,0 +1,195 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+)
+
+// SavedFilterRequest names a job list filter to save
+type SavedFilterRequest struct {
+	Name   string           `json:"name" binding:"required,max=100"`
+	Filter models.JobFilter `json:"filter"`
+}
+
+// ListSavedFilters returns the caller's saved job list filters
+// @Summary List saved filters
+// @Description Returns the job list filters the authenticated user saved, by name. Apply one with the saved_filter parameter of the job list.
+// @Tags user
+// @Produce json
+// @Success 200 {object} map[string][]models.SavedFilter
+// @Failure 401 {object} apierror.Envelope
+// @Security BearerAuth
+// @Router /api/v1/users/me/saved-filters [get]
+func (h *Handler) ListSavedFilters(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		apierror.Abort(c, apierror.Unauthorized("User not authenticated"))
+		return
+	}
+	filters := []models.SavedFilter{}
+	if err := database.DB.Where("user_id = ?", userID).Order("name").Find(&filters).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to list saved filters"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"filters": filters})
+}
+
+// CreateSavedFilter saves a job list filter for the caller
+// @Summary Save a filter
+// @Description Saves a job list filter under a name, which must be unique among the user's filters
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body SavedFilterRequest true "Filter to save"
+// @Success 201 {object} models.SavedFilter
+// @Failure 400 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope
+// @Security BearerAuth
+// @Router /api/v1/users/me/saved-filters [post]
+func (h *Handler) CreateSavedFilter(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		apierror.Abort(c, apierror.Unauthorized("User not authenticated"))
+		return
+	}
+	var req SavedFilterRequest
+	if !bindSavedFilter(c, &req) {
+		return
+	}
+
+	if !savedFilterNameFree(c, userID, req.Name, 0) {
+		return
+	}
+	saved := models.SavedFilter{UserID: userID, Name: req.Name, Filter: req.Filter}
+	if err := database.DB.Create(&saved).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to save filter"))
+		return
+	}
+	c.JSON(http.StatusCreated, saved)
+}
+
+// UpdateSavedFilter replaces one of the caller's saved filters
+// @Summary Update a saved filter
+// @Description Replaces the name and conditions of a saved filter
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param id path int true "Saved filter ID"
+// @Param request body SavedFilterRequest true "New name and filter"
+// @Success 200 {object} models.SavedFilter
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope
+// @Security BearerAuth
+// @Router /api/v1/users/me/saved-filters/{id} [put]
+func (h *Handler) UpdateSavedFilter(c *gin.Context) {
+	saved, ok := loadSavedFilter(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	var req SavedFilterRequest
+	if !bindSavedFilter(c, &req) {
+		return
+	}
+	if !savedFilterNameFree(c, saved.UserID, req.Name, saved.ID) {
+		return
+	}
+
+	saved.Name = req.Name
+	saved.Filter = req.Filter
+	if err := database.DB.Save(saved).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to update filter"))
+		return
+	}
+	c.JSON(http.StatusOK, saved)
+}
+
+// DeleteSavedFilter removes one of the caller's saved filters
+// @Summary Delete a saved filter
+// @Tags user
+// @Param id path int true "Saved filter ID"
+// @Success 204
+// @Failure 404 {object} apierror.Envelope
+// @Security BearerAuth
+// @Router /api/v1/users/me/saved-filters/{id} [delete]
+func (h *Handler) DeleteSavedFilter(c *gin.Context) {
+	saved, ok := loadSavedFilter(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if err := database.DB.Delete(saved).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to delete filter"))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// bindSavedFilter reads and validates a saved filter request, writing an
+// error response and returning false if it is invalid
+func bindSavedFilter(c *gin.Context, req *SavedFilterRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return false
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		apierror.Abort(c, apierror.Validation("Name is required"))
+		return false
+	}
+	if err := req.Filter.Validate(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return false
+	}
+	return true
+}
+
+// savedFilterNameFree writes a conflict response and returns false if the
+// user has another filter with name
+func savedFilterNameFree(c *gin.Context, userID uint, name string, exceptID uint) bool {
+	var count int64
+	if err := database.DB.Model(&models.SavedFilter{}).
+		Where("user_id = ? AND name = ? AND id <> ?", userID, name, exceptID).Count(&count).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to save filter"))
+		return false
+	}
+	if count > 0 {
+		apierror.Abort(c, apierror.Conflict("A saved filter with this name already exists"))
+		return false
+	}
+	return true
+}
+
+// loadSavedFilter fetches one of the caller's saved filters, writing an
+// error response and returning false if it does not exist
+func loadSavedFilter(c *gin.Context, rawID string) (*models.SavedFilter, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		apierror.Abort(c, apierror.Unauthorized("User not authenticated"))
+		return nil, false
+	}
+	id, err := strconv.ParseUint(rawID, 10, 64)
+	if err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid saved filter ID"))
+		return nil, false
+	}
+
+	var saved models.SavedFilter
+	if err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&saved).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.NotFound("Saved filter not found"))
+			return nil, false
+		}
+		apierror.Abort(c, apierror.Internal("Failed to get saved filter"))
+		return nil, false
+	}
+	return &saved, true
+}
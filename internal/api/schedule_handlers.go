@@ -0,0 +1,99 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/internal/queue"
+	"scriberr/pkg/logger"
+)
+
+// ScheduleRequest sets or clears when a job is queued
+type ScheduleRequest struct {
+	ScheduledAt *time.Time `json:"scheduled_at"` // RFC3339; null queues the job now
+}
+
+// requestedSchedule parses the scheduled_at form field, writing an error
+// response and returning false when it is invalid
+func requestedSchedule(c *gin.Context) (*time.Time, bool) {
+	value := c.PostForm("scheduled_at")
+	if value == "" {
+		return nil, true
+	}
+	scheduledAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		apierror.Abort(c, apierror.Validation("scheduled_at must be an RFC3339 timestamp"))
+		return nil, false
+	}
+	if !validSchedule(c, scheduledAt) {
+		return nil, false
+	}
+	return &scheduledAt, true
+}
+
+// validSchedule writes an error response and returns false for times that
+// have already passed
+func validSchedule(c *gin.Context, scheduledAt time.Time) bool {
+	if !scheduledAt.After(time.Now()) {
+		apierror.Abort(c, apierror.Validation("scheduled_at must be in the future"))
+		return false
+	}
+	return true
+}
+
+// UpdateJobSchedule sets or clears the schedule of a job that has not started
+// @Summary Update job schedule
+// @Description Set when a pending or scheduled job is queued, or clear its schedule with a null scheduled_at to queue it now
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param id path string true "Job ID"
+// @Param request body ScheduleRequest true "New schedule"
+// @Success 200 {object} models.TranscriptionJob
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope "Job has already started or finished"
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/{id}/schedule [patch]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) UpdateJobSchedule(c *gin.Context) {
+	jobID := c.Param("id")
+
+	var req ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
+		return
+	}
+	if req.ScheduledAt != nil && !validSchedule(c, *req.ScheduledAt) {
+		return
+	}
+
+	var job models.TranscriptionJob
+	if err := database.DB.Scopes(ownedJobs(c)).Select("id").Where("id = ?", jobID).First(&job).Error; err != nil {
+		apierror.Abort(c, apierror.JobNotFound())
+		return
+	}
+
+	if err := h.taskQueue.ScheduleJob(c.Request.Context(), jobID, req.ScheduledAt); err != nil {
+		if errors.Is(err, queue.ErrNotSchedulable) {
+			apierror.Abort(c, apierror.Conflict(err.Error()))
+			return
+		}
+		logger.Error("Failed to update job schedule", "job_id", jobID, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to update job schedule"))
+		return
+	}
+
+	if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", jobID).First(&job).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/auth"
+	"scriberr/internal/models"
+	"scriberr/internal/transcription/registry"
+)
+
+// setupOpenRoutes are the API routes, below the version, served before
+// setup finishes: setup itself, and the registration routes older frontends
+// create the first admin through
+var setupOpenRoutes = map[string]bool{
+	"/setup":                    true,
+	"/auth/registration-status": true,
+	"/auth/register":            true,
+}
+
+// SetupStatusResponse reports whether first-run setup is still needed
+type SetupStatusResponse struct {
+	SetupRequired bool `json:"setup_required"`
+	AdminExists   bool `json:"admin_exists"`
+}
+
+// SetupRequest creates the first admin, optionally with initial settings
+type SetupRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=50"`
+	Password string `json:"password" binding:"required"` // Must meet the password policy
+	// Initial settings for the shared default profile; when either is set the
+	// profile is created, with defaults for the rest
+	DefaultModel string `json:"default_model,omitempty" example:"small"`
+	Device       string `json:"device,omitempty" example:"cuda"` // cpu, cuda, mps or auto
+}
+
+// @Summary Get setup status
+// @Description Reports whether the instance still needs its first admin. Until setup finishes, other API routes answer 403 with code SETUP_REQUIRED.
+// @Tags setup
+// @Produce json
+// @Success 200 {object} SetupStatusResponse
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/setup [get]
+func (h *Handler) GetSetupStatus(c *gin.Context) {
+	completed, err := h.setupCompleted(c)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to check setup status").WithCause(err))
+		return
+	}
+	c.JSON(http.StatusOK, SetupStatusResponse{SetupRequired: !completed, AdminExists: completed})
+}
+
+// @Summary Complete setup
+// @Description Creates the first admin and logs them in. default_model and device, when given, become the shared default profile. Once setup has finished this answers 410 for good.
+// @Tags setup
+// @Accept json
+// @Produce json
+// @Param request body SetupRequest true "First admin and initial settings"
+// @Success 201 {object} LoginResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 410 {object} apierror.Envelope
+// @Router /api/v1/setup [post]
+func (h *Handler) CompleteSetup(c *gin.Context) {
+	completed, err := h.setupCompleted(c)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to check setup status").WithCause(err))
+		return
+	}
+	if completed {
+		apierror.Abort(c, apierror.Gone("Setup has already been completed"))
+		return
+	}
+
+	var req SetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
+		return
+	}
+	if err := auth.ValidatePassword(req.Password, req.Username); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	var profile *models.TranscriptionProfile
+	if req.DefaultModel != "" || req.Device != "" {
+		if profile = h.initialProfile(c, req); profile == nil {
+			return
+		}
+	}
+
+	var initialize func(tx *gorm.DB, admin *models.User) error
+	if profile != nil {
+		initialize = func(tx *gorm.DB, admin *models.User) error {
+			return tx.Create(profile).Error
+		}
+	}
+	admin, err := auth.CompleteSetup(c.Request.Context(), req.Username, req.Password, initialize)
+	if errors.Is(err, auth.ErrSetupCompleted) {
+		h.setupDone.Store(true)
+		apierror.Abort(c, apierror.Gone("Setup has already been completed"))
+		return
+	}
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to complete setup").WithCause(err))
+		return
+	}
+	h.setupDone.Store(true)
+	h.loginNewAccount(c, admin)
+}
+
+// initialProfile builds the shared default profile from the settings given
+// at setup, writing a 400 and returning nil if they are invalid
+func (h *Handler) initialProfile(c *gin.Context, req SetupRequest) *models.TranscriptionProfile {
+	params := h.defaultTranscriptionParams()
+	settings := map[string]interface{}{}
+	if req.DefaultModel != "" {
+		params.Model = req.DefaultModel
+		settings["model"] = req.DefaultModel
+	}
+	if req.Device != "" {
+		params.Device = req.Device
+		settings["device"] = req.Device
+	}
+	if err := registry.GetRegistry().ValidateModelParameters("whisperx", settings); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid initial settings: "+err.Error()))
+		return nil
+	}
+	return &models.TranscriptionProfile{Name: "Default", IsDefault: true, Parameters: params}
+}
+
+// setupCompleted reports whether first-run setup has finished. Behind an
+// authenticating proxy there is nothing to set up: the first user the proxy
+// names becomes the admin. Completion is remembered, as setup never reopens.
+func (h *Handler) setupCompleted(c *gin.Context) (bool, error) {
+	if h.config.HeaderAuth.Enabled || h.setupDone.Load() {
+		return true, nil
+	}
+	completed, err := auth.SetupCompleted(c.Request.Context())
+	if err != nil {
+		return false, err
+	}
+	if completed {
+		h.setupDone.Store(true)
+	}
+	return completed, nil
+}
+
+// RequireSetup answers API requests with a 403 until first-run setup has
+// finished, except those to the setup routes. Requests outside /api, such
+// as the frontend's, are served so it can show the setup wizard.
+func (h *Handler) RequireSetup() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if servedBeforeSetup(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		completed, err := h.setupCompleted(c)
+		if err != nil {
+			apierror.Abort(c, apierror.Internal("Failed to check setup status").WithCause(err))
+			return
+		}
+		if !completed {
+			apierror.Abort(c, apierror.SetupRequired())
+			return
+		}
+		c.Next()
+	}
+}
+
+// servedBeforeSetup reports whether a request path is outside the API or to
+// one of setupOpenRoutes, versioned or not
+func servedBeforeSetup(path string) bool {
+	if !strings.HasPrefix(path, "/api/") {
+		return true
+	}
+	route := strings.TrimPrefix(path, "/api")
+	if match := versionedPath.FindStringSubmatch(path); match != nil {
+		route = strings.TrimPrefix(route, "/"+match[1])
+	}
+	return setupOpenRoutes[strings.TrimSuffix(route, "/")]
+}
@@ -0,0 +1,336 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+)
+
+// shareTokenLength is the length of share link tokens, about 190 bits
+const shareTokenLength = 32
+
+// CreateShareLinkRequest sets when a share link expires and whether it
+// includes the audio. Without an expiry the link lasts until it is revoked.
+type CreateShareLinkRequest struct {
+	ExpiresInHours *int       `json:"expires_in_hours,omitempty" binding:"omitempty,min=1"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	IncludeAudio   bool       `json:"include_audio"`
+}
+
+// ShareLinkResponse is a new share link. The token is only returned here.
+type ShareLinkResponse struct {
+	models.ShareLink
+	Token string `json:"token"`
+	URL   string `json:"url"` // Page that shows the transcript, /share/{token}
+}
+
+// SharedTranscriptResponse is what a share link shows: the transcript, and
+// where to play the audio when the link includes it
+type SharedTranscriptResponse struct {
+	Title           *string           `json:"title,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+	DurationSeconds *float64          `json:"duration_seconds,omitempty"`
+	Transcript      json.RawMessage   `json:"transcript,omitempty"` // Absent until the job has completed
+	Speakers        map[string]string `json:"speakers,omitempty"`   // Custom names by original speaker label
+	AudioURL        string            `json:"audio_url,omitempty"`
+	ExpiresAt       *time.Time        `json:"expires_at,omitempty"`
+}
+
+// CreateShareLink creates a public link to a job's transcript
+// @Summary Share a transcript
+// @Description Creates a link that shows the job's transcript, and optionally plays its audio, to anyone who has it, without logging in. Set expires_in_hours or expires_at to make the link expire; otherwise it lasts until revoked. The token is only returned in this response.
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param id path string true "Job ID"
+// @Param request body CreateShareLinkRequest false "Expiry and audio"
+// @Success 201 {object} ShareLinkResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/{id}/share [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) CreateShareLink(c *gin.Context) {
+	var req CreateShareLinkRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierror.Abort(c, apierror.Validation(err.Error()))
+			return
+		}
+	}
+	now := time.Now()
+	var expiresAt *time.Time
+	switch {
+	case req.ExpiresInHours != nil && req.ExpiresAt != nil:
+		apierror.Abort(c, apierror.BadRequest("Set expires_in_hours or expires_at, not both"))
+		return
+	case req.ExpiresInHours != nil:
+		t := now.Add(time.Duration(*req.ExpiresInHours) * time.Hour)
+		expiresAt = &t
+	case req.ExpiresAt != nil:
+		if !req.ExpiresAt.After(now) {
+			apierror.Abort(c, apierror.Validation("expires_at must be in the future"))
+			return
+		}
+		expiresAt = req.ExpiresAt
+	}
+
+	job, ok := loadShareableJob(c)
+	if !ok {
+		return
+	}
+	if req.IncludeAudio && job.AudioDeletedAt != nil {
+		apierror.Abort(c, apierror.BadRequest("The job's audio was removed by retention cleanup"))
+		return
+	}
+
+	token := generateSecureAPIKey(shareTokenLength)
+	link := models.ShareLink{
+		JobID:        job.ID,
+		TokenHash:    sha256Hex(token),
+		TokenPrefix:  token[:8],
+		IncludeAudio: req.IncludeAudio,
+		ExpiresAt:    expiresAt,
+	}
+	if userID, ok := currentUserID(c); ok {
+		link.UserID = &userID
+	}
+	if err := database.DB.Create(&link).Error; err != nil {
+		logger.Error("Failed to create share link", "job_id", job.ID, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to create share link"))
+		return
+	}
+	c.JSON(http.StatusCreated, ShareLinkResponse{ShareLink: link, Token: token, URL: "/share/" + token})
+}
+
+// ListShareLinks returns a job's share links
+// @Summary List share links
+// @Description Lists the share links of a job, newest first, including expired and revoked ones, with how often each was opened. Tokens are not returned.
+// @Tags transcription
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} map[string][]models.ShareLink
+// @Failure 404 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/{id}/share [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) ListShareLinks(c *gin.Context) {
+	job, ok := loadShareableJob(c)
+	if !ok {
+		return
+	}
+	links := []models.ShareLink{}
+	if err := database.DB.Where("job_id = ?", job.ID).Order("created_at DESC, id DESC").Find(&links).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to list share links"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"links": links})
+}
+
+// RevokeShareLink stops a share link from working
+// @Summary Revoke a share link
+// @Description Revokes a share link, which then answers 410. Revoking a revoked link keeps its original revocation time.
+// @Tags transcription
+// @Param id path string true "Job ID"
+// @Param share_id path int true "Share link ID"
+// @Success 204
+// @Failure 404 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/{id}/share/{share_id} [delete]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) RevokeShareLink(c *gin.Context) {
+	shareID, err := strconv.ParseUint(c.Param("share_id"), 10, 64)
+	if err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid share link ID"))
+		return
+	}
+	if _, ok := loadShareableJob(c); !ok {
+		return
+	}
+	var link models.ShareLink
+	if err := database.DB.Where("id = ? AND job_id = ?", shareID, c.Param("id")).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.NotFound("Share link not found"))
+			return
+		}
+		apierror.Abort(c, apierror.Internal("Failed to get share link"))
+		return
+	}
+	if link.RevokedAt == nil {
+		if err := database.DB.Model(&link).Update("revoked_at", time.Now()).Error; err != nil {
+			logger.Error("Failed to revoke share link", "share_id", link.ID, "error", err)
+			apierror.Abort(c, apierror.Internal("Failed to revoke share link"))
+			return
+		}
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetSharedTranscript shows the transcript of a share link
+// @Summary Open a share link
+// @Description Returns the transcript a share link gives access to. No credentials are needed besides the token. Requests are limited per token to SHARE_RATE_LIMIT a minute.
+// @Tags share
+// @Produce json
+// @Param token path string true "Share token"
+// @Success 200 {object} SharedTranscriptResponse
+// @Failure 404 {object} apierror.Envelope
+// @Failure 410 {object} apierror.Envelope "Link has expired or been revoked"
+// @Failure 429 {object} apierror.Envelope
+// @Router /api/v1/share/{token} [get]
+func (h *Handler) GetSharedTranscript(c *gin.Context) {
+	link, job, ok := openShareLink(c, "transcript")
+	if !ok {
+		return
+	}
+
+	response := SharedTranscriptResponse{
+		Title:           job.Title,
+		CreatedAt:       job.CreatedAt,
+		DurationSeconds: job.DurationSeconds,
+		ExpiresAt:       link.ExpiresAt,
+	}
+	if job.Transcript != nil && json.Valid([]byte(*job.Transcript)) {
+		response.Transcript = json.RawMessage(*job.Transcript)
+	}
+	var mappings []models.SpeakerMapping
+	if err := database.DB.Where("transcription_job_id = ?", job.ID).Find(&mappings).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to get shared transcript"))
+		return
+	}
+	if len(mappings) > 0 {
+		response.Speakers = make(map[string]string, len(mappings))
+		for _, mapping := range mappings {
+			response.Speakers[mapping.OriginalSpeaker] = mapping.CustomName
+		}
+	}
+	if link.IncludeAudio && job.AudioDeletedAt == nil {
+		response.AudioURL = "/api/v1/share/" + c.Param("token") + "/audio"
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetSharedAudio plays the audio of a share link
+// @Summary Play shared audio
+// @Description Serves the audio of a share link that includes it, with range support, or redirects to a presigned URL when the audio is in object storage. No credentials are needed besides the token.
+// @Tags share
+// @Produce audio/mpeg,audio/wav,audio/mp4
+// @Param token path string true "Share token"
+// @Success 200 {file} binary
+// @Failure 403 {object} apierror.Envelope "Link does not include the audio"
+// @Failure 404 {object} apierror.Envelope
+// @Failure 410 {object} apierror.Envelope "Link has expired or been revoked"
+// @Failure 429 {object} apierror.Envelope
+// @Router /api/v1/share/{token}/audio [get]
+func (h *Handler) GetSharedAudio(c *gin.Context) {
+	link, job, ok := openShareLink(c, "audio")
+	if !ok {
+		return
+	}
+	if !link.IncludeAudio {
+		apierror.Abort(c, apierror.Forbidden("This link does not include the audio"))
+		return
+	}
+	h.serveJobAudio(c, job)
+}
+
+// LimitShareRate refuses share link requests with 429 once a token has been
+// used SHARE_RATE_LIMIT times in the current minute
+func (h *Handler) LimitShareRate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, retryAfter := h.shareLimiter.Allow(c.Param("token"), time.Now())
+		if !allowed {
+			logger.AuditEvent("share_access", c.ClientIP(), false,
+				"token_prefix", shareTokenPrefix(c.Param("token")), "path", c.Request.URL.Path, "reason", "rate_limited")
+			c.Header("Retry-After", strconv.Itoa(retrySeconds(retryAfter)))
+			apierror.Abort(c, apierror.RateLimited("Too many requests for this link", retrySeconds(retryAfter)))
+			return
+		}
+		c.Next()
+	}
+}
+
+// openShareLink loads the share link of the token in the request path and its
+// job, recording the access. It writes an error response and returns false if
+// the link does not exist, is no longer active or its job was deleted.
+func openShareLink(c *gin.Context, resource string) (*models.ShareLink, *models.TranscriptionJob, bool) {
+	token := c.Param("token")
+	audit := func(allowed bool, details ...any) {
+		base := []any{"resource", resource, "token_prefix", shareTokenPrefix(token)}
+		logger.AuditEvent("share_access", c.ClientIP(), allowed, append(base, details...)...)
+	}
+
+	var link models.ShareLink
+	if err := database.DB.Where("token_hash = ?", sha256Hex(token)).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			audit(false, "reason", "unknown_token")
+			apierror.Abort(c, apierror.NotFound("Share link not found"))
+			return nil, nil, false
+		}
+		apierror.Abort(c, apierror.Internal("Failed to get share link"))
+		return nil, nil, false
+	}
+	now := time.Now()
+	if link.RevokedAt != nil {
+		audit(false, "share_id", link.ID, "job_id", link.JobID, "reason", "revoked")
+		apierror.Abort(c, apierror.Gone("Share link has been revoked"))
+		return nil, nil, false
+	}
+	if !link.Active(now) {
+		audit(false, "share_id", link.ID, "job_id", link.JobID, "reason", "expired")
+		apierror.Abort(c, apierror.Gone("Share link has expired"))
+		return nil, nil, false
+	}
+
+	var job models.TranscriptionJob
+	if err := database.DB.Where("id = ?", link.JobID).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			audit(false, "share_id", link.ID, "job_id", link.JobID, "reason", "job_deleted")
+			apierror.Abort(c, apierror.Gone("The shared job has been deleted"))
+			return nil, nil, false
+		}
+		apierror.Abort(c, apierror.Internal("Failed to get shared transcript"))
+		return nil, nil, false
+	}
+
+	audit(true, "share_id", link.ID, "job_id", link.JobID)
+	if err := database.DB.Model(&link).UpdateColumns(map[string]interface{}{
+		"access_count":     gorm.Expr("access_count + 1"),
+		"last_accessed_at": now,
+	}).Error; err != nil {
+		logger.Warn("Failed to record share link access", "share_id", link.ID, "error", err)
+	}
+	return &link, &job, true
+}
+
+// loadShareableJob fetches the job named by the :id path parameter, writing
+// an error response and returning false if it does not exist
+func loadShareableJob(c *gin.Context) (*models.TranscriptionJob, bool) {
+	var job models.TranscriptionJob
+	if err := database.DB.Scopes(ownedJobs(c)).Select("id", "audio_deleted_at").Where("id = ?", c.Param("id")).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.JobNotFound())
+			return nil, false
+		}
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
+		return nil, false
+	}
+	return &job, true
+}
+
+// shareTokenPrefix is the part of a share token that is safe to log
+func shareTokenPrefix(token string) string {
+	if len(token) > 8 {
+		return token[:8]
+	}
+	return token
+}
@@ -0,0 +1,332 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+)
+
+// errTranscriptChanged is returned when the transcript was edited concurrently
+var errTranscriptChanged = errors.New("transcript was modified concurrently")
+
+// RenameSpeakersRequest maps machine speaker labels to display names. An empty
+// display name removes the rename and restores the machine label.
+type RenameSpeakersRequest struct {
+	Speakers map[string]string `json:"speakers" binding:"required"`
+}
+
+// MergeSpeakersRequest merges the source speaker into the target speaker
+type MergeSpeakersRequest struct {
+	Source string `json:"source" binding:"required"`
+	Target string `json:"target" binding:"required"`
+}
+
+// SpeakerEditResponse is returned after renaming or merging speakers
+type SpeakerEditResponse struct {
+	TranscriptRevision int                      `json:"transcript_revision"`
+	Mappings           []SpeakerMappingResponse `json:"mappings"`
+}
+
+// RenameSpeakers assigns display names to diarized speakers
+// @Summary Rename speakers in a transcription
+// @Description Maps machine speaker labels (e.g. SPEAKER_00) to display names. The machine labels stay in the transcript, so sending an empty name undoes a rename.
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param id path string true "Transcription Job ID"
+// @Param request body RenameSpeakersRequest true "Speaker id to display name"
+// @Success 200 {object} SpeakerEditResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/transcription/{id}/speakers [patch]
+func (h *Handler) RenameSpeakers(c *gin.Context) {
+	jobID := c.Param("id")
+
+	var req RenameSpeakersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
+		return
+	}
+	if len(req.Speakers) == 0 {
+		apierror.Abort(c, apierror.Validation("No speakers to rename"))
+		return
+	}
+
+	job, transcript, ok := loadSpeakerTranscript(c, jobID)
+	if !ok {
+		return
+	}
+	known := transcriptSpeakerIDs(transcript)
+	for speaker, name := range req.Speakers {
+		if !known[speaker] {
+			apierror.Abort(c, apierror.Validation("Unknown speaker: "+speaker))
+			return
+		}
+		if len(strings.TrimSpace(name)) > 100 {
+			apierror.Abort(c, apierror.Validation("Display name is too long for speaker: "+speaker))
+			return
+		}
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for speaker, name := range req.Speakers {
+			if err := setSpeakerName(tx, jobID, speaker, strings.TrimSpace(name)); err != nil {
+				return err
+			}
+		}
+		return bumpTranscriptRevision(tx, job, nil)
+	})
+	h.respondSpeakerEdit(c, job, err)
+}
+
+// MergeSpeakers folds one speaker into another
+// @Summary Merge two speakers in a transcription
+// @Description Reassigns every segment and word of the source speaker to the target speaker. Rewritten entries keep their machine label in original_speaker.
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param id path string true "Transcription Job ID"
+// @Param request body MergeSpeakersRequest true "Speakers to merge"
+// @Success 200 {object} SpeakerEditResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/transcription/{id}/speakers/merge [post]
+func (h *Handler) MergeSpeakers(c *gin.Context) {
+	jobID := c.Param("id")
+
+	var req MergeSpeakersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
+		return
+	}
+	if req.Source == req.Target {
+		apierror.Abort(c, apierror.BadRequest("Cannot merge a speaker into itself"))
+		return
+	}
+
+	job, transcript, ok := loadSpeakerTranscript(c, jobID)
+	if !ok {
+		return
+	}
+	known := transcriptSpeakerIDs(transcript)
+	for _, speaker := range []string{req.Source, req.Target} {
+		if !known[speaker] {
+			apierror.Abort(c, apierror.Validation("Unknown speaker: "+speaker))
+			return
+		}
+	}
+
+	mergeTranscriptSpeakers(transcript, req.Source, req.Target)
+	edit, err := newTranscriptRevision(c, models.TranscriptOpSpeakerMerge, nil,
+		fmt.Sprintf("Merged speaker %s into %s", req.Source, req.Target), transcript)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to encode transcript"))
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("transcription_job_id = ? AND original_speaker = ?", jobID, req.Source).
+			Delete(&models.SpeakerMapping{}).Error; err != nil {
+			return err
+		}
+		return bumpTranscriptRevision(tx, job, edit)
+	})
+	h.respondSpeakerEdit(c, job, err)
+}
+
+// respondSpeakerEdit writes the outcome of a rename or merge
+func (h *Handler) respondSpeakerEdit(c *gin.Context, job *models.TranscriptionJob, err error) {
+	if errors.Is(err, errTranscriptChanged) {
+		apierror.Abort(c, apierror.Conflict("Transcript was modified by another request, please retry"))
+		return
+	}
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to update speakers"))
+		return
+	}
+
+	var mappings []models.SpeakerMapping
+	if err := database.DB.Where("transcription_job_id = ?", job.ID).Order("original_speaker").Find(&mappings).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to get speaker mappings"))
+		return
+	}
+	response := SpeakerEditResponse{
+		TranscriptRevision: job.TranscriptRevision,
+		Mappings:           make([]SpeakerMappingResponse, len(mappings)),
+	}
+	for i, mapping := range mappings {
+		response.Mappings[i] = SpeakerMappingResponse{
+			ID:              mapping.ID,
+			OriginalSpeaker: mapping.OriginalSpeaker,
+			CustomName:      mapping.CustomName,
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// loadSpeakerTranscript is loadEditableTranscript for jobs that carry speaker
+// labels. It writes an error response and returns false on failure.
+func loadSpeakerTranscript(c *gin.Context, jobID string) (*models.TranscriptionJob, map[string]interface{}, bool) {
+	job, transcript, ok := loadEditableTranscript(c, jobID)
+	if !ok {
+		return nil, nil, false
+	}
+	if !job.Diarization && !job.Parameters.Diarize && !job.IsMultiTrack {
+		apierror.Abort(c, apierror.Validation("No speaker information available for this transcription"))
+		return nil, nil, false
+	}
+	return job, transcript, true
+}
+
+// bumpTranscriptRevision increments the job's transcript revision. When edit is
+// non-nil its transcript replaces the job's and is recorded in the revision
+// history. It fails with errTranscriptChanged if another edit landed since the
+// job was loaded.
+func bumpTranscriptRevision(tx *gorm.DB, job *models.TranscriptionJob, edit *models.TranscriptRevision) error {
+	updates := map[string]interface{}{"transcript_revision": job.TranscriptRevision + 1}
+	if edit != nil {
+		if err := recordOriginalTranscript(tx, job); err != nil {
+			return err
+		}
+		updates["transcript"] = edit.Transcript
+	}
+	result := tx.Model(&models.TranscriptionJob{}).
+		Where("id = ? AND transcript_revision = ?", job.ID, job.TranscriptRevision).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errTranscriptChanged
+	}
+	job.TranscriptRevision++
+	if edit == nil {
+		return nil
+	}
+
+	edit.TranscriptionJobID = job.ID
+	edit.Revision = job.TranscriptRevision
+	job.Transcript = &edit.Transcript
+	return tx.Create(edit).Error
+}
+
+// setSpeakerName creates, updates or, for an empty name, deletes a speaker mapping
+func setSpeakerName(tx *gorm.DB, jobID, speaker, name string) error {
+	if name == "" {
+		return tx.Where("transcription_job_id = ? AND original_speaker = ?", jobID, speaker).
+			Delete(&models.SpeakerMapping{}).Error
+	}
+
+	var mapping models.SpeakerMapping
+	err := tx.Where("transcription_job_id = ? AND original_speaker = ?", jobID, speaker).First(&mapping).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tx.Create(&models.SpeakerMapping{
+			TranscriptionJobID: jobID,
+			OriginalSpeaker:    speaker,
+			CustomName:         name,
+		}).Error
+	}
+	if err != nil {
+		return err
+	}
+	mapping.CustomName = name
+	return tx.Save(&mapping).Error
+}
+
+// transcriptEntries returns the segment and word objects of a decoded
+// transcript, including words nested in segments
+func transcriptEntries(transcript map[string]interface{}) []map[string]interface{} {
+	var entries []map[string]interface{}
+	for _, key := range []string{"segments", "word_segments"} {
+		items, _ := transcript[key].([]interface{})
+		for _, item := range items {
+			entry, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			entries = append(entries, entry)
+			words, _ := entry["words"].([]interface{})
+			for _, word := range words {
+				if w, ok := word.(map[string]interface{}); ok {
+					entries = append(entries, w)
+				}
+			}
+		}
+	}
+	return entries
+}
+
+// transcriptSpeakerIDs returns the set of speaker labels used in a transcript
+func transcriptSpeakerIDs(transcript map[string]interface{}) map[string]bool {
+	ids := make(map[string]bool)
+	for _, entry := range transcriptEntries(transcript) {
+		if speaker, ok := entry["speaker"].(string); ok && speaker != "" {
+			ids[speaker] = true
+		}
+	}
+	return ids
+}
+
+// mergeTranscriptSpeakers relabels every entry of source as target, remembering
+// the machine label in original_speaker, and folds source into target in the
+// speakers summary
+func mergeTranscriptSpeakers(transcript map[string]interface{}, source, target string) {
+	for _, entry := range transcriptEntries(transcript) {
+		if entry["speaker"] != source {
+			continue
+		}
+		if _, ok := entry["original_speaker"]; !ok {
+			entry["original_speaker"] = source
+		}
+		entry["speaker"] = target
+	}
+
+	speakers, ok := transcript["speakers"].([]interface{})
+	if !ok {
+		return
+	}
+	var sourceTime float64
+	kept := make([]interface{}, 0, len(speakers))
+	for _, item := range speakers {
+		speaker, ok := item.(map[string]interface{})
+		if ok && speaker["id"] == source {
+			sourceTime, _ = speaker["total_speaking_time"].(float64)
+			continue
+		}
+		kept = append(kept, item)
+	}
+	for _, item := range kept {
+		if speaker, ok := item.(map[string]interface{}); ok && speaker["id"] == target {
+			total, _ := speaker["total_speaking_time"].(float64)
+			speaker["total_speaking_time"] = total + sourceTime
+		}
+	}
+	transcript["speakers"] = kept
+}
+
+// speakerDisplayNames returns the display name for each renamed speaker of a job
+func speakerDisplayNames(jobID string) (map[string]string, error) {
+	var mappings []models.SpeakerMapping
+	if err := database.DB.Where("transcription_job_id = ?", jobID).Find(&mappings).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(mappings))
+	for _, mapping := range mappings {
+		names[mapping.OriginalSpeaker] = mapping.CustomName
+	}
+	return names, nil
+}
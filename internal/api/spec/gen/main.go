@@ -0,0 +1,33 @@
+// Command gen writes the OpenAPI document generated from the handler
+// annotations to internal/api/spec/openapi.json. Run it with go generate.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"scriberr/internal/api/spec"
+)
+
+func main() {
+	root := flag.String("root", "../../..", "Module root")
+	out := flag.String("out", "openapi.json", "File to write the document to")
+	flag.Parse()
+
+	doc, err := spec.Generate(*root)
+	if err != nil {
+		log.Fatalf("Failed to generate the OpenAPI document: %v", err)
+	}
+	if err := spec.Validate(doc); err != nil {
+		log.Fatalf("Generated OpenAPI document is invalid:\n%v", err)
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, append(data, '\n'), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,487 @@
+package spec
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Directories, relative to the module root, holding the handlers, the
+// general API annotations and the error envelope
+const (
+	handlersDir = "internal/api"
+	mainFile    = "cmd/server/main.go"
+	apiErrorDir = "internal/api/apierror"
+)
+
+var (
+	paramPattern    = regexp.MustCompile(`^(\S+)\s+(\w+)\s+(\S+)\s+(true|false)\s+"((?:[^"\\]|\\.)*)"\s*(.*)$`)
+	responsePattern = regexp.MustCompile(`^(\d{3}|default)\s*(?:\{(\w+)\}\s+(\S+))?\s*(?:"(.*)")?\s*$`)
+	routerPattern   = regexp.MustCompile(`^(\S+)\s+\[(\w+)\]`)
+	attrPattern     = regexp.MustCompile(`(?i)(default|enums)\(([^)]*)\)`)
+)
+
+// mimeAliases expands the short content types swag accepts
+var mimeAliases = map[string]string{
+	"json":                  "application/json",
+	"xml":                   "application/xml",
+	"plain":                 "text/plain",
+	"html":                  "text/html",
+	"mpfd":                  "multipart/form-data",
+	"x-www-form-urlencoded": "application/x-www-form-urlencoded",
+	"octet-stream":          "application/octet-stream",
+	"png":                   "image/png",
+	"jpeg":                  "image/jpeg",
+}
+
+// Generate builds the OpenAPI document from the handler annotations of the
+// module at root
+func Generate(root string) (*Document, error) {
+	module, err := modulePath(root)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{
+		root:   root,
+		module: module,
+		pkgs:   map[string]*goPackage{},
+		doc: &Document{
+			OpenAPI: "3.0.3",
+			Paths:   map[string]PathItem{},
+			Components: Components{
+				Schemas:         map[string]*Schema{},
+				SecuritySchemes: map[string]SecurityScheme{},
+			},
+		},
+	}
+	if err := g.readGeneralInfo(); err != nil {
+		return nil, err
+	}
+	if err := g.addErrorSchema(); err != nil {
+		return nil, err
+	}
+
+	pkg, err := g.load(module + "/" + handlersDir)
+	if err != nil {
+		return nil, err
+	}
+	tags := map[string]bool{}
+	for _, file := range pkg.files {
+		for _, decl := range file.ast.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Doc == nil {
+				continue
+			}
+			if err := g.addOperations(fn, file, tags); err != nil {
+				return nil, fmt.Errorf("%s: %w", fn.Name.Name, err)
+			}
+		}
+	}
+	for tag := range tags {
+		g.doc.Tags = append(g.doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(g.doc.Tags, func(i, j int) bool { return g.doc.Tags[i].Name < g.doc.Tags[j].Name })
+	return g.doc, nil
+}
+
+// addErrorSchema describes error responses with the apierror envelope and
+// the api_version the versioning middleware adds to it
+func (g *generator) addErrorSchema() error {
+	ref, err := g.namedSchema(g.module+"/"+apiErrorDir, "Envelope")
+	if err != nil {
+		return err
+	}
+	key := strings.TrimPrefix(ref.Ref, schemaRefPrefix)
+	schema := g.doc.Components.Schemas[key]
+	delete(g.doc.Components.Schemas, key)
+	schema.Description = "Error response"
+	schema.Properties["api_version"] = &Schema{Type: "string", Description: "API version the request was routed to"}
+	schema.Required = []string{"error", "code"}
+	g.doc.Components.Schemas[errorSchemaName] = schema
+	return nil
+}
+
+// modulePath reads the module path from go.mod
+func modulePath(root string) (string, error) {
+	f, err := os.Open(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.TrimSpace(module), nil
+		}
+	}
+	return "", fmt.Errorf("no module path in %s", filepath.Join(root, "go.mod"))
+}
+
+type generator struct {
+	root   string
+	module string
+	pkgs   map[string]*goPackage // By import path
+	doc    *Document
+}
+
+// readGeneralInfo reads the API title, version, license and security
+// schemes from the annotations on the server's main function
+func (g *generator) readGeneralInfo() error {
+	file, err := parser.ParseFile(token.NewFileSet(), filepath.Join(g.root, mainFile), nil, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+	var scheme string
+	var schemeDef SecurityScheme
+	flush := func() {
+		if scheme == "" {
+			return
+		}
+		// A JWT in the Authorization header is HTTP bearer authentication
+		if schemeDef.In == "header" && schemeDef.Name == "Authorization" {
+			schemeDef = SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: schemeDef.Description}
+		}
+		g.doc.Components.SecuritySchemes[scheme] = schemeDef
+		scheme = ""
+	}
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			key, value := annotation(comment.Text)
+			switch key {
+			case "@title":
+				g.doc.Info.Title = value
+			case "@version":
+				g.doc.Info.Version = value
+			case "@description":
+				if scheme != "" {
+					schemeDef.Description = value
+				} else {
+					g.doc.Info.Description = value
+				}
+			case "@license.name":
+				g.doc.Info.License = &License{Name: value}
+			case "@license.url":
+				if g.doc.Info.License != nil {
+					g.doc.Info.License.URL = value
+				}
+			case "@securitydefinitions.apikey":
+				flush()
+				scheme = value
+				schemeDef = SecurityScheme{Type: "apiKey"}
+			case "@in":
+				schemeDef.In = value
+			case "@name":
+				schemeDef.Name = value
+			}
+		}
+		flush()
+	}
+	return nil
+}
+
+// annotation splits a "// @Key value" comment
+func annotation(comment string) (string, string) {
+	text := strings.TrimSpace(strings.TrimPrefix(comment, "//"))
+	if !strings.HasPrefix(text, "@") {
+		return "", ""
+	}
+	key, value, _ := strings.Cut(text, " ")
+	return strings.ToLower(key), strings.TrimSpace(value)
+}
+
+// addOperations adds the operations a handler's annotations describe
+func (g *generator) addOperations(fn *ast.FuncDecl, file *goFile, tags map[string]bool) error {
+	op := &Operation{Responses: map[string]*Response{}}
+	var routes [][2]string
+	var accept, produce []string
+	var params, responses []string
+	var descriptions []string
+	for _, comment := range fn.Doc.List {
+		key, value := annotation(comment.Text)
+		switch key {
+		case "@summary":
+			op.Summary = value
+		case "@description":
+			descriptions = append(descriptions, value)
+		case "@tags":
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					op.Tags = append(op.Tags, tag)
+					tags[tag] = true
+				}
+			}
+		case "@accept":
+			accept = append(accept, mimeTypes(value)...)
+		case "@produce":
+			produce = append(produce, mimeTypes(value)...)
+		case "@param":
+			params = append(params, value)
+		case "@success", "@failure":
+			responses = append(responses, value)
+		case "@security":
+			op.Security = append(op.Security, map[string][]string{value: {}})
+		case "@router":
+			match := routerPattern.FindStringSubmatch(value)
+			if match == nil {
+				return fmt.Errorf("invalid @Router %q", value)
+			}
+			routes = append(routes, [2]string{match[1], strings.ToLower(match[2])})
+		}
+	}
+	if len(routes) == 0 {
+		return nil
+	}
+	op.Description = strings.Join(descriptions, "\n")
+
+	if err := g.addParameters(op, params, accept, file); err != nil {
+		return err
+	}
+	for _, value := range responses {
+		if err := g.addResponse(op, value, produce, file); err != nil {
+			return err
+		}
+	}
+	if len(op.Security) > 0 && op.Responses["401"] == nil {
+		op.Responses["401"] = errorResponse("Missing or invalid authentication")
+	}
+
+	for i, route := range routes {
+		routeOp := *op
+		routeOp.OperationID = fn.Name.Name
+		if i > 0 {
+			routeOp.OperationID += strconv.Itoa(i + 1)
+		}
+		item := g.doc.Paths[route[0]]
+		if item == nil {
+			item = PathItem{}
+			g.doc.Paths[route[0]] = item
+		}
+		if item[route[1]] != nil {
+			return fmt.Errorf("%s %s is documented twice", strings.ToUpper(route[1]), route[0])
+		}
+		item[route[1]] = &routeOp
+	}
+	return nil
+}
+
+// mimeTypes expands a comma-separated @Accept or @Produce list
+func mimeTypes(value string) []string {
+	var types []string
+	for _, mime := range strings.Split(value, ",") {
+		mime = strings.TrimSpace(mime)
+		if full, ok := mimeAliases[mime]; ok {
+			mime = full
+		}
+		if mime != "" {
+			types = append(types, mime)
+		}
+	}
+	return types
+}
+
+// addParameters turns @Param annotations into parameters and a request body
+func (g *generator) addParameters(op *Operation, params, accept []string, file *goFile) error {
+	form := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	formMime := "application/x-www-form-urlencoded"
+	for _, value := range params {
+		match := paramPattern.FindStringSubmatch(value)
+		if match == nil {
+			return fmt.Errorf("invalid @Param %q", value)
+		}
+		name, in, typ, required := match[1], match[2], match[3], match[4] == "true"
+		description := strings.ReplaceAll(match[5], `\"`, `"`)
+
+		switch in {
+		case "body":
+			schema, err := g.annotationSchema(typ, file)
+			if err != nil {
+				return err
+			}
+			content := map[string]MediaType{}
+			mimes := accept
+			if len(mimes) == 0 {
+				mimes = []string{"application/json"}
+			}
+			for _, mime := range mimes {
+				content[mime] = MediaType{Schema: schema}
+			}
+			op.RequestBody = &RequestBody{Description: description, Required: required, Content: content}
+		case "formData":
+			schema := primitiveSchema(typ)
+			if typ == "file" {
+				formMime = "multipart/form-data"
+			}
+			applyAttributes(schema, match[6])
+			schema.Description = description
+			form.Properties[name] = schema
+			if required {
+				form.Required = append(form.Required, name)
+			}
+		case "path", "query", "header":
+			schema := primitiveSchema(typ)
+			applyAttributes(schema, match[6])
+			op.Parameters = append(op.Parameters, Parameter{
+				Name:        name,
+				In:          in,
+				Description: description,
+				Required:    required || in == "path",
+				Schema:      schema,
+			})
+		default:
+			return fmt.Errorf("unsupported parameter location %q in %q", in, value)
+		}
+	}
+
+	if len(form.Properties) > 0 {
+		for _, mime := range accept {
+			if mime == "multipart/form-data" {
+				formMime = mime
+			}
+		}
+		op.RequestBody = &RequestBody{Required: len(form.Required) > 0, Content: map[string]MediaType{formMime: {Schema: form}}}
+	}
+	return nil
+}
+
+// primitiveSchema returns the schema of a non-body parameter type
+func primitiveSchema(typ string) *Schema {
+	if elem, ok := strings.CutPrefix(typ, "[]"); ok {
+		return &Schema{Type: "array", Items: primitiveSchema(elem)}
+	}
+	switch typ {
+	case "int", "integer", "int64", "uint":
+		return &Schema{Type: "integer"}
+	case "number", "float", "float64":
+		return &Schema{Type: "number"}
+	case "bool", "boolean":
+		return &Schema{Type: "boolean"}
+	case "file":
+		return &Schema{Type: "string", Format: "binary"}
+	default:
+		return &Schema{Type: "string"}
+	}
+}
+
+// applyAttributes applies default(...) and enums(...) from a @Param
+func applyAttributes(schema *Schema, attrs string) {
+	for _, match := range attrPattern.FindAllStringSubmatch(attrs, -1) {
+		switch strings.ToLower(match[1]) {
+		case "default":
+			if schema.Type == "array" {
+				schema.Default = nil
+				continue
+			}
+			schema.Default = typedValue(schema.Type, match[2])
+		case "enums":
+			for _, value := range strings.Split(match[2], ",") {
+				schema.Enum = append(schema.Enum, typedValue(schema.Type, strings.TrimSpace(value)))
+			}
+		}
+	}
+}
+
+// typedValue converts an annotation value to the schema's type
+func typedValue(typ, value string) interface{} {
+	switch typ {
+	case "integer":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// addResponse turns a @Success or @Failure annotation into a response
+func (g *generator) addResponse(op *Operation, value string, produce []string, file *goFile) error {
+	match := responsePattern.FindStringSubmatch(value)
+	if match == nil {
+		return fmt.Errorf("invalid response %q", value)
+	}
+	code, kind, typ, description := match[1], match[2], match[3], match[4]
+	if description == "" {
+		if status, err := strconv.Atoi(code); err == nil {
+			description = http.StatusText(status)
+		}
+	}
+	if description == "" {
+		description = "Response"
+	}
+
+	// Handlers answer errors with the apierror envelope
+	if status, _ := strconv.Atoi(code); status >= 400 && kind == "object" && typ == "apierror.Envelope" {
+		op.Responses[code] = errorResponse(description)
+		return nil
+	}
+
+	response := &Response{Description: description}
+	if kind != "" {
+		var schema *Schema
+		mimes := produce
+		switch kind {
+		case "string":
+			schema = &Schema{Type: "string"}
+			if len(mimes) == 0 {
+				mimes = []string{"text/plain"}
+			}
+		case "file":
+			schema = &Schema{Type: "string", Format: "binary"}
+			if len(mimes) == 0 {
+				mimes = []string{"application/octet-stream"}
+			}
+		case "object", "array":
+			var err error
+			if schema, err = g.annotationSchema(typ, file); err != nil {
+				return err
+			}
+			if kind == "array" {
+				schema = &Schema{Type: "array", Items: schema}
+			}
+		default:
+			return fmt.Errorf("unsupported response kind {%s} in %q", kind, value)
+		}
+		if len(mimes) == 0 {
+			mimes = []string{"application/json"}
+		}
+		response.Content = map[string]MediaType{}
+		for _, mime := range mimes {
+			response.Content[mime] = MediaType{Schema: schema}
+		}
+	}
+	op.Responses[code] = response
+	return nil
+}
+
+// errorResponse is a response with the error body handlers send
+func errorResponse(description string) *Response {
+	return &Response{
+		Description: description,
+		Content:     map[string]MediaType{"application/json": {Schema: &Schema{Ref: schemaRefPrefix + errorSchemaName}}},
+	}
+}
+
+// annotationSchema resolves a Go type named in an annotation, such as
+// models.TranscriptionJob or map[string][]QuotaResponse
+func (g *generator) annotationSchema(typ string, file *goFile) (*Schema, error) {
+	expr, err := parser.ParseExpr(typ)
+	if err != nil {
+		return nil, fmt.Errorf("invalid type %q: %w", typ, err)
+	}
+	return g.schemaFor(expr, file)
+}
@@ -0,0 +1,74 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDocumentIsCurrent(t *testing.T) {
+	doc, err := Generate("../../..")
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(append(data, '\n'), JSON()) {
+		t.Error("openapi.json is out of date with the handler annotations; run go generate ./internal/api/spec")
+	}
+}
+
+func TestDocumentIsValid(t *testing.T) {
+	var doc Document
+	if err := json.Unmarshal(JSON(), &doc); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+	if err := Validate(&doc); err != nil {
+		t.Errorf("openapi.json is invalid:\n%v", err)
+	}
+	if len(doc.Components.SecuritySchemes) != 2 {
+		t.Errorf("Expected the API key and JWT security schemes, got %v", doc.Components.SecuritySchemes)
+	}
+	if doc.Components.SecuritySchemes["BearerAuth"].Scheme != "bearer" {
+		t.Errorf("Expected JWTs as HTTP bearer authentication, got %+v", doc.Components.SecuritySchemes["BearerAuth"])
+	}
+}
+
+func TestValidateFindsProblems(t *testing.T) {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: "Test", Version: "1"},
+		Paths: map[string]PathItem{
+			"/jobs/{id}": {
+				"get": {
+					OperationID: "GetJob",
+					Responses: map[string]*Response{"200": {
+						Description: "OK",
+						Content:     map[string]MediaType{"application/json": {Schema: &Schema{Ref: schemaRefPrefix + "Missing"}}},
+					}},
+					Security: []map[string][]string{{"Unknown": {}}},
+				},
+				"post": {OperationID: "GetJob", Responses: map[string]*Response{}},
+			},
+		},
+		Components: Components{Schemas: map[string]*Schema{}, SecuritySchemes: map[string]SecurityScheme{}},
+	}
+	err := Validate(doc)
+	if err == nil {
+		t.Fatal("Expected problems")
+	}
+	for _, want := range []string{
+		"unresolved reference #/components/schemas/Missing",
+		"unknown security scheme Unknown",
+		"path parameter id is not declared",
+		"operationId GetJob is also used",
+		"POST /jobs/{id}: no responses",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in:\n%v", want, err)
+		}
+	}
+}
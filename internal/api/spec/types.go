@@ -0,0 +1,347 @@
+package spec
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// goPackage is a parsed package of the module
+type goPackage struct {
+	name   string
+	path   string
+	files  []*goFile
+	types  map[string]*typeDecl
+	consts map[string][]interface{} // Values of the string constants of each named type
+}
+
+// goFile is a parsed source file with its imports
+type goFile struct {
+	ast     *ast.File
+	pkg     *goPackage
+	imports map[string]string // Import path by the name the file uses
+}
+
+type typeDecl struct {
+	spec *ast.TypeSpec
+	file *goFile
+}
+
+// load parses the non-test files of a package of the module
+func (g *generator) load(importPath string) (*goPackage, error) {
+	if pkg, ok := g.pkgs[importPath]; ok {
+		return pkg, nil
+	}
+	dir := filepath.Join(g.root, filepath.FromSlash(strings.TrimPrefix(strings.TrimPrefix(importPath, g.module), "/")))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	pkg := &goPackage{path: importPath, types: map[string]*typeDecl{}, consts: map[string][]interface{}{}}
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		parsed, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		pkg.name = parsed.Name.Name
+		file := &goFile{ast: parsed, pkg: pkg, imports: map[string]string{}}
+		for _, imp := range parsed.Imports {
+			importPath, _ := strconv.Unquote(imp.Path.Value)
+			name := path.Base(importPath)
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			file.imports[name] = importPath
+		}
+		pkg.files = append(pkg.files, file)
+		collectDecls(pkg, file)
+	}
+	g.pkgs[importPath] = pkg
+	return pkg, nil
+}
+
+// collectDecls records the type declarations and typed string constants of
+// a file
+func collectDecls(pkg *goPackage, file *goFile) {
+	for _, decl := range file.ast.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				if _, ok := pkg.types[spec.Name.Name]; !ok {
+					pkg.types[spec.Name.Name] = &typeDecl{spec: spec, file: file}
+				}
+			case *ast.ValueSpec:
+				typ, ok := spec.Type.(*ast.Ident)
+				if gen.Tok != token.CONST || !ok {
+					continue
+				}
+				for _, value := range spec.Values {
+					if lit, ok := value.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+						s, _ := strconv.Unquote(lit.Value)
+						pkg.consts[typ.Name] = append(pkg.consts[typ.Name], s)
+					}
+				}
+			}
+		}
+	}
+}
+
+// externalSchemas describes the types from outside the module that appear
+// in requests and responses
+var externalSchemas = map[string]func() *Schema{
+	"time.Time":       func() *Schema { return &Schema{Type: "string", Format: "date-time"} },
+	"time.Duration":   func() *Schema { return &Schema{Type: "integer", Format: "int64", Description: "Nanoseconds"} },
+	"json.RawMessage": func() *Schema { return &Schema{} },
+	"gorm.DeletedAt":  func() *Schema { return &Schema{Type: "string", Format: "date-time", Nullable: true} },
+}
+
+// schemaFor returns the schema of a type expression appearing in file
+func (g *generator) schemaFor(expr ast.Expr, file *goFile) (*Schema, error) {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if schema := basicSchema(expr.Name); schema != nil {
+			return schema, nil
+		}
+		return g.namedSchema(file.pkg.path, expr.Name)
+	case *ast.SelectorExpr:
+		pkgName, ok := expr.X.(*ast.Ident)
+		if !ok {
+			return &Schema{}, nil
+		}
+		importPath, ok := file.imports[pkgName.Name]
+		if !ok && pkgName.Name == file.pkg.name {
+			importPath = file.pkg.path // Annotations may qualify the handlers' own package
+		}
+		if importPath == g.module || strings.HasPrefix(importPath, g.module+"/") {
+			return g.namedSchema(importPath, expr.Sel.Name)
+		}
+		if schema, ok := externalSchemas[pkgName.Name+"."+expr.Sel.Name]; ok {
+			return schema(), nil
+		}
+		return &Schema{}, nil
+	case *ast.StarExpr:
+		schema, err := g.schemaFor(expr.X, file)
+		if err == nil && schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema, err
+	case *ast.ArrayType:
+		if ident, ok := expr.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return &Schema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := g.schemaFor(expr.Elt, file)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case *ast.MapType:
+		values, err := g.schemaFor(expr.Value, file)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+	case *ast.StructType:
+		return g.structSchema(expr, file)
+	default:
+		// Interfaces and anything else can hold any value
+		return &Schema{}, nil
+	}
+}
+
+// basicSchema returns the schema of a predeclared type, or nil
+func basicSchema(name string) *Schema {
+	switch name {
+	case "string":
+		return &Schema{Type: "string"}
+	case "bool":
+		return &Schema{Type: "boolean"}
+	case "int", "int8", "int16", "int32", "uint", "uint8", "uint16", "uint32", "byte", "rune":
+		return &Schema{Type: "integer"}
+	case "int64", "uint64":
+		return &Schema{Type: "integer", Format: "int64"}
+	case "float32":
+		return &Schema{Type: "number", Format: "float"}
+	case "float64":
+		return &Schema{Type: "number", Format: "double"}
+	case "any", "error":
+		return &Schema{}
+	}
+	return nil
+}
+
+// namedSchema returns a reference to the component schema of a named struct
+// type, building it on first use. Other named types are inlined, with the
+// values of their string constants as the enum.
+func (g *generator) namedSchema(importPath, name string) (*Schema, error) {
+	pkg, err := g.load(importPath)
+	if err != nil {
+		return nil, err
+	}
+	decl, ok := pkg.types[name]
+	if !ok {
+		return nil, fmt.Errorf("type %s.%s not found", pkg.name, name)
+	}
+
+	st, ok := decl.spec.Type.(*ast.StructType)
+	if !ok {
+		schema, err := g.schemaFor(decl.spec.Type, decl.file)
+		if err != nil {
+			return nil, err
+		}
+		if values := pkg.consts[name]; len(values) > 0 && schema.Type == "string" {
+			schema.Enum = values
+		}
+		return schema, nil
+	}
+
+	key := pkg.name + "." + name
+	ref := &Schema{Ref: schemaRefPrefix + key}
+	if _, ok := g.doc.Components.Schemas[key]; ok {
+		return ref, nil
+	}
+	// Reserve the name first, so self-referencing types terminate
+	g.doc.Components.Schemas[key] = &Schema{}
+	schema, err := g.structSchema(st, decl.file)
+	if err != nil {
+		return nil, err
+	}
+	schema.Description = docText(decl.spec.Doc)
+	g.doc.Components.Schemas[key] = schema
+	return ref, nil
+}
+
+// structSchema returns the object schema of a struct's JSON encoding
+func (g *generator) structSchema(st *ast.StructType, file *goFile) (*Schema, error) {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, field := range st.Fields.List {
+		tag := reflect.StructTag("")
+		if field.Tag != nil {
+			value, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(value)
+		}
+		if tag.Get("swaggerignore") == "true" {
+			continue
+		}
+		jsonName, jsonOpts, _ := strings.Cut(tag.Get("json"), ",")
+		if jsonName == "-" && jsonOpts == "" {
+			continue
+		}
+
+		// Embedded structs without a JSON name contribute their fields
+		if len(field.Names) == 0 && jsonName == "" {
+			embedded, err := g.embeddedSchema(field.Type, file)
+			if err != nil {
+				return nil, err
+			}
+			for name, property := range embedded.Properties {
+				schema.Properties[name] = property
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{ast.NewIdent(embeddedName(field.Type))}
+		}
+		for _, ident := range names {
+			if !ident.IsExported() {
+				continue
+			}
+			name := jsonName
+			if name == "" {
+				name = ident.Name
+			}
+			property, err := g.fieldSchema(field, tag, jsonOpts, file)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", ident.Name, err)
+			}
+			schema.Properties[name] = property
+			if strings.Contains(tag.Get("binding"), "required") {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+	}
+	sort.Strings(schema.Required)
+	return schema, nil
+}
+
+// fieldSchema returns the schema of one struct field
+func (g *generator) fieldSchema(field *ast.Field, tag reflect.StructTag, jsonOpts string, file *goFile) (*Schema, error) {
+	var schema *Schema
+	if swaggerType := tag.Get("swaggertype"); swaggerType != "" {
+		schema = primitiveSchema(swaggerType)
+		if swaggerType == "object" {
+			schema = &Schema{Type: "object"}
+		}
+	} else {
+		var err error
+		if schema, err = g.schemaFor(field.Type, file); err != nil {
+			return nil, err
+		}
+	}
+	if strings.Contains(jsonOpts, "string") && schema.Ref == "" {
+		schema = &Schema{Type: "string", Nullable: schema.Nullable}
+	}
+	// A reference cannot carry a description in OpenAPI 3.0
+	if schema.Ref == "" {
+		schema.Description = docText(field.Doc)
+		if schema.Description == "" {
+			schema.Description = docText(field.Comment)
+		}
+	}
+	return schema, nil
+}
+
+// embeddedSchema returns the object schema of an embedded struct
+func (g *generator) embeddedSchema(expr ast.Expr, file *goFile) (*Schema, error) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	schema, err := g.schemaFor(expr, file)
+	if err != nil {
+		return nil, err
+	}
+	if schema.Ref != "" {
+		return g.doc.Components.Schemas[strings.TrimPrefix(schema.Ref, schemaRefPrefix)], nil
+	}
+	return schema, nil
+}
+
+// embeddedName returns the field name of an embedded type
+func embeddedName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(expr.X)
+	case *ast.SelectorExpr:
+		return expr.Sel.Name
+	case *ast.Ident:
+		return expr.Name
+	}
+	return ""
+}
+
+// docText returns a comment as a single line
+func docText(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(group.Text()), " ")
+}
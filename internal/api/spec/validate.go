@@ -0,0 +1,167 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// methods are the operations a path item may hold
+var methods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true,
+	"options": true, "head": true, "patch": true, "trace": true,
+}
+
+// Validate checks the structure of an OpenAPI 3.0 document: every operation
+// has a unique ID and responses, path parameters match the path template,
+// and every security requirement and schema reference resolves
+func Validate(doc *Document) error {
+	var problems []error
+	fail := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	if !strings.HasPrefix(doc.OpenAPI, "3.0.") {
+		fail("unsupported openapi version %q", doc.OpenAPI)
+	}
+	if doc.Info.Title == "" || doc.Info.Version == "" {
+		fail("info needs a title and a version")
+	}
+	for name, scheme := range doc.Components.SecuritySchemes {
+		switch scheme.Type {
+		case "apiKey":
+			if scheme.Name == "" || (scheme.In != "header" && scheme.In != "query" && scheme.In != "cookie") {
+				fail("security scheme %s needs a name and a location", name)
+			}
+		case "http":
+			if scheme.Scheme == "" {
+				fail("security scheme %s needs an HTTP scheme", name)
+			}
+		default:
+			fail("security scheme %s has unsupported type %q", name, scheme.Type)
+		}
+	}
+
+	var refs []string
+	for name, schema := range doc.Components.Schemas {
+		refs = appendRefs(refs, schema)
+		if schema == nil {
+			fail("schema %s is empty", name)
+		}
+	}
+
+	operationIDs := map[string]string{}
+	for path, item := range doc.Paths {
+		if !strings.HasPrefix(path, "/") {
+			fail("path %q must start with /", path)
+		}
+		templateParams := map[string]bool{}
+		for _, match := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+			templateParams[match[1]] = true
+		}
+
+		for method, op := range item {
+			where := strings.ToUpper(method) + " " + path
+			if !methods[method] {
+				fail("%s: unknown method", where)
+				continue
+			}
+			if op.OperationID == "" {
+				fail("%s: missing operationId", where)
+			} else if other, ok := operationIDs[op.OperationID]; ok {
+				fail("%s: operationId %s is also used by %s", where, op.OperationID, other)
+			} else {
+				operationIDs[op.OperationID] = where
+			}
+
+			declared := map[string]bool{}
+			for _, param := range op.Parameters {
+				switch param.In {
+				case "path":
+					declared[param.Name] = true
+					if !param.Required {
+						fail("%s: path parameter %s must be required", where, param.Name)
+					}
+					if !templateParams[param.Name] {
+						fail("%s: path parameter %s is not in the path", where, param.Name)
+					}
+				case "query", "header", "cookie":
+				default:
+					fail("%s: parameter %s has unknown location %q", where, param.Name, param.In)
+				}
+				if param.Schema == nil {
+					fail("%s: parameter %s has no schema", where, param.Name)
+				}
+				refs = appendRefs(refs, param.Schema)
+			}
+			for name := range templateParams {
+				if !declared[name] {
+					fail("%s: path parameter %s is not declared", where, name)
+				}
+			}
+
+			if op.RequestBody != nil {
+				if len(op.RequestBody.Content) == 0 {
+					fail("%s: request body has no content", where)
+				}
+				for _, media := range op.RequestBody.Content {
+					refs = appendRefs(refs, media.Schema)
+				}
+			}
+
+			if len(op.Responses) == 0 {
+				fail("%s: no responses", where)
+			}
+			for code, response := range op.Responses {
+				if status, err := strconv.Atoi(code); code != "default" && (err != nil || status < 100 || status > 599) {
+					fail("%s: invalid response code %q", where, code)
+				}
+				if response.Description == "" {
+					fail("%s: response %s needs a description", where, code)
+				}
+				for _, media := range response.Content {
+					refs = appendRefs(refs, media.Schema)
+				}
+			}
+
+			for _, requirement := range op.Security {
+				for name := range requirement {
+					if _, ok := doc.Components.SecuritySchemes[name]; !ok {
+						fail("%s: unknown security scheme %s", where, name)
+					}
+				}
+			}
+		}
+	}
+
+	for _, ref := range refs {
+		name, ok := strings.CutPrefix(ref, schemaRefPrefix)
+		if _, exists := doc.Components.Schemas[name]; !ok || !exists {
+			fail("unresolved reference %s", ref)
+		}
+	}
+
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Error() < problems[j].Error() })
+	return errors.Join(problems...)
+}
+
+// appendRefs appends the references a schema makes, at any depth
+func appendRefs(refs []string, schema *Schema) []string {
+	if schema == nil {
+		return refs
+	}
+	if schema.Ref != "" {
+		refs = append(refs, schema.Ref)
+	}
+	refs = appendRefs(refs, schema.Items)
+	refs = appendRefs(refs, schema.AdditionalProperties)
+	for _, property := range schema.Properties {
+		refs = appendRefs(refs, property)
+	}
+	return refs
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/metrics"
+	"scriberr/pkg/logger"
+)
+
+// StatsResponse reports transcription throughput and the current queue
+type StatsResponse struct {
+	From            *time.Time                `json:"from,omitempty"`
+	To              *time.Time                `json:"to,omitempty"`
+	Totals          database.JobStatTotals    `json:"totals"`
+	JobsPerDay      []database.DailyJobStats  `json:"jobs_per_day"`
+	RealtimeFactors []database.RealtimeFactor `json:"realtime_factors"`
+	Queue           QueueDepth                `json:"queue"`
+}
+
+// QueueDepth is how many jobs wait for a worker and for how long
+type QueueDepth struct {
+	Waiting           int     `json:"waiting"`
+	OldestWaitSeconds float64 `json:"oldest_wait_seconds"` // 0 when no job waits
+}
+
+// GetStats reports transcription throughput over a date range
+// @Summary Get transcription statistics
+// @Description Totals, jobs per UTC day and the average realtime factor of each model and device, over runs that completed or failed in the range, plus the current queue. The realtime factor is wall-clock seconds per audio second. Runs that were cancelled or timed out are not counted. The same numbers back the gauges on /metrics, over all time.
+// @Tags admin
+// @Produce json
+// @Param from query string false "Count runs that finished at or after this time, RFC 3339 or YYYY-MM-DD"
+// @Param to query string false "Count runs that finished before this time, RFC 3339 or YYYY-MM-DD"
+// @Success 200 {object} StatsResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/stats [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) GetStats(c *gin.Context) {
+	var response StatsResponse
+	for _, bound := range []struct {
+		name  string
+		value **time.Time
+	}{
+		{"from", &response.From},
+		{"to", &response.To},
+	} {
+		if raw := c.Query(bound.name); raw != "" {
+			t, err := parseFilterTime(raw)
+			if err != nil {
+				apierror.Abort(c, apierror.Validation("Invalid "+bound.name+": use RFC 3339 or YYYY-MM-DD"))
+				return
+			}
+			*bound.value = &t
+		}
+	}
+	var from, to time.Time
+	if response.From != nil {
+		from = *response.From
+	}
+	if response.To != nil {
+		to = *response.To
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		apierror.Abort(c, apierror.Validation("from must be before to"))
+		return
+	}
+
+	var err error
+	if response.Totals, err = database.SumJobStats(from, to); err == nil {
+		if response.JobsPerDay, err = database.DailyJobStatsBetween(from, to); err == nil {
+			response.RealtimeFactors, err = database.RealtimeFactorsBetween(from, to)
+		}
+	}
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to get statistics").WithCause(err))
+		return
+	}
+	response.Queue = h.queueDepth()
+	c.JSON(http.StatusOK, response)
+}
+
+// queueDepth reports the jobs waiting in the task queue
+func (h *Handler) queueDepth() QueueDepth {
+	if h.taskQueue == nil {
+		return QueueDepth{}
+	}
+	waiting, oldest := h.taskQueue.WaitingStats()
+	return QueueDepth{Waiting: waiting, OldestWaitSeconds: oldest.Seconds()}
+}
+
+// updateStatsGauges sets the throughput and queue gauges of /metrics from
+// the job stats of all time
+func (h *Handler) updateStatsGauges() {
+	queue := h.queueDepth()
+	metrics.SetGauge("scriberr_queue_waiting_jobs", "Jobs waiting for a worker", float64(queue.Waiting))
+	metrics.SetGauge("scriberr_queue_oldest_wait_seconds", "How long the job waiting longest has waited", queue.OldestWaitSeconds)
+
+	totals, err := database.SumJobStats(time.Time{}, time.Time{})
+	if err != nil {
+		logger.Warn("Failed to update job stats gauges", "error", err)
+		return
+	}
+	metrics.SetGauge("scriberr_jobs_finished", "Transcription runs that finished, by outcome", float64(totals.Completed), "status", "completed")
+	metrics.SetGauge("scriberr_jobs_finished", "Transcription runs that finished, by outcome", float64(totals.Failed), "status", "failed")
+	metrics.SetGauge("scriberr_job_failure_rate", "Failed share of finished transcription runs", totals.FailureRate)
+	metrics.SetGauge("scriberr_audio_processed_hours", "Hours of audio transcribed", totals.AudioHours)
+
+	factors, err := database.RealtimeFactorsBetween(time.Time{}, time.Time{})
+	if err != nil {
+		logger.Warn("Failed to update realtime factor gauges", "error", err)
+		return
+	}
+	for _, factor := range factors {
+		metrics.SetGauge("scriberr_realtime_factor", "Average wall-clock seconds per audio second of completed runs", factor.RealtimeFactor,
+			"model", factor.Model, "device", factor.Device)
+	}
+}
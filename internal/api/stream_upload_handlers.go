@@ -0,0 +1,183 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/pkg/logger"
+)
+
+const (
+	// maxStreamUploadBytes is the absolute limit on a streamed upload request
+	maxStreamUploadBytes = 1 << 30
+	// maxStreamFieldBytes limits each text field of a streamed upload
+	maxStreamFieldBytes = 64 << 10
+)
+
+var (
+	// errDuplicateAudio is returned when a streamed upload has more than one audio part
+	errDuplicateAudio = errors.New("only one audio file may be streamed per request")
+	// errFieldTooLarge is returned for text fields over maxStreamFieldBytes
+	errFieldTooLarge = errors.New("form field is too large")
+)
+
+// StreamUpload creates a transcription job from a streamed multipart upload
+// @Summary Stream an audio file for transcription
+// @Description Upload an audio file without buffering it, for example with Transfer-Encoding: chunked. The multipart body is read part by part and the audio is written straight to the upload directory. Accepts the same form fields as POST /api/v1/transcriptions; put them before the audio part. Requests are limited to 1 GiB, or MAX_UPLOAD_SIZE_MB if that is smaller. The job is returned once the file is fully written.
+// @Tags transcription
+// @Accept multipart/form-data
+// @Produce json
+// @Param audio formData file true "Audio file"
+// @Success 200 {object} models.TranscriptionJob
+// @Failure 400 {object} apierror.Envelope
+// @Failure 413 {object} apierror.Envelope "Upload is larger than the limit"
+// @Failure 415 {object} apierror.Envelope "Not an accepted audio or video type"
+// @Failure 422 {object} apierror.Envelope "File is not transcribable audio"
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/stream [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) StreamUpload(c *gin.Context) {
+	limit := int64(maxStreamUploadBytes)
+	if h.config.MaxUploadSize > 0 && h.config.MaxUploadSize < limit {
+		limit = h.config.MaxUploadSize
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		apierror.Abort(c, apierror.Validation("Multipart form with an audio file is required"))
+		return
+	}
+	if err := os.MkdirAll(h.config.UploadDir, 0755); err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to create upload directory"))
+		return
+	}
+
+	jobID := uuid.New().String()
+	var filePath, filename string
+	fields := url.Values{}
+	err = func() error {
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if part.FormName() != "audio" || part.FileName() == "" {
+				value, err := io.ReadAll(io.LimitReader(part, maxStreamFieldBytes+1))
+				part.Close()
+				if err != nil {
+					return err
+				}
+				if len(value) > maxStreamFieldBytes {
+					return fmt.Errorf("%w: %s", errFieldTooLarge, part.FormName())
+				}
+				fields.Add(part.FormName(), string(value))
+				continue
+			}
+			if filePath != "" {
+				part.Close()
+				return errDuplicateAudio
+			}
+			filename = part.FileName()
+			filePath = filepath.Join(h.config.UploadDir, jobID+filepath.Ext(filename))
+			written, err := streamToFile(part, filePath)
+			part.Close()
+			if err != nil {
+				return err
+			}
+			logger.Debug("Streamed upload written", "job_id", jobID, "bytes", written)
+		}
+	}()
+	if err != nil {
+		if filePath != "" {
+			os.Remove(filePath)
+		}
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			apierror.Abort(c, apierror.TooLarge("Upload is too large").WithDetails(map[string]any{"max_size": limit}))
+		case errors.Is(err, errDuplicateAudio), errors.Is(err, errFieldTooLarge):
+			apierror.Abort(c, apierror.Validation(err.Error()))
+		default:
+			logger.Warn("Streamed upload failed", "job_id", jobID, "error", err)
+			apierror.Abort(c, apierror.BadRequest("Failed to read upload: "+err.Error()))
+		}
+		return
+	}
+	if filePath == "" {
+		apierror.Abort(c, apierror.Validation("Audio file is required"))
+		return
+	}
+
+	// The body has been consumed, so serve the form fields from what was read
+	c.Request.Form = fields
+	c.Request.PostForm = fields
+	c.Request.MultipartForm = &multipart.Form{Value: fields}
+
+	priority, ok := requestedPriority(c)
+	if !ok {
+		os.Remove(filePath)
+		return
+	}
+	scheduledAt, ok := requestedSchedule(c)
+	if !ok {
+		os.Remove(filePath)
+		return
+	}
+	h.submitUploadedFile(c, jobID, filePath, filename, priority, scheduledAt)
+}
+
+// streamToFile writes src to a new file at path. Reads from the request and
+// writes to disk run concurrently through a pipe, so a slow disk does not
+// stall reading the next chunk and nothing is held in memory beyond the copy
+// buffers. The file is removed if anything fails.
+func streamToFile(src io.Reader, path string) (int64, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create file: %w", err)
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	type result struct {
+		written int64
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		written, err := io.Copy(file, pipeReader)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		// Unblock the reader side if writing failed
+		pipeReader.CloseWithError(err)
+		done <- result{written, err}
+	}()
+
+	_, readErr := io.Copy(pipeWriter, src)
+	pipeWriter.CloseWithError(readErr)
+	written := <-done
+
+	err = readErr
+	if err == nil {
+		err = written.err
+	}
+	if err != nil {
+		os.Remove(path)
+		return 0, err
+	}
+	return written.written, nil
+}
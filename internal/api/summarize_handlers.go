@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"scriberr/internal/api/apierror"
 	"scriberr/internal/database"
 	"scriberr/internal/llm"
 	"scriberr/internal/models"
@@ -32,21 +33,26 @@ type SummarizeRequest struct {
 // @Produce text/event-stream
 // @Param request body SummarizeRequest true "Summarize request"
 // @Success 200 {string} string "Event stream"
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Failure 503 {object} apierror.Envelope
 // @Security ApiKeyAuth
 // @Security BearerAuth
 // @Router /api/v1/summarize [post]
 func (h *Handler) Summarize(c *gin.Context) {
 	var req SummarizeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	if ok, err := canAccessJob(c, req.TranscriptionID); err != nil || !ok {
+		apierror.Abort(c, apierror.JobNotFound())
 		return
 	}
 
 	svc, provider, err := h.getLLMService()
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.EngineUnavailable(err.Error()))
 		return
 	}
 
@@ -198,24 +204,24 @@ func (h *Handler) Summarize(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Transcription ID"
 // @Success 200 {object} models.Summary
-// @Failure 404 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 404 {object} apierror.Envelope
+// @Failure 400 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
 // @Security ApiKeyAuth
 // @Security BearerAuth
 // @Router /api/v1/transcription/{id}/summary [get]
 func (h *Handler) GetSummaryForTranscription(c *gin.Context) {
 	tid := c.Param("id")
 	if tid == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Transcription ID required"})
+		apierror.Abort(c, apierror.Validation("Transcription ID required"))
 		return
 	}
 	var s models.Summary
-	if err := database.DB.Where("transcription_id = ?", tid).Order("created_at DESC").First(&s).Error; err != nil {
+	if err := database.DB.Scopes(ofOwnedJobs(c)).Where("transcription_id = ?", tid).Order("created_at DESC").First(&s).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			// Fallback: check if summary is cached on the job record
 			var job models.TranscriptionJob
-			if err2 := database.DB.Where("id = ?", tid).First(&job).Error; err2 == nil && job.Summary != nil && *job.Summary != "" {
+			if err2 := database.DB.Scopes(ownedJobs(c)).Where("id = ?", tid).First(&job).Error; err2 == nil && job.Summary != nil && *job.Summary != "" {
 				c.JSON(http.StatusOK, gin.H{
 					"transcription_id": tid,
 					"template_id":      nil,
@@ -226,10 +232,10 @@ func (h *Handler) GetSummaryForTranscription(c *gin.Context) {
 				})
 				return
 			}
-			c.JSON(http.StatusNotFound, gin.H{"error": "Summary not found"})
+			apierror.Abort(c, apierror.NotFound("Summary not found"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch summary"})
+		apierror.Abort(c, apierror.Internal("Failed to fetch summary"))
 		return
 	}
 	c.JSON(http.StatusOK, s)
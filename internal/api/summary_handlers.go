@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"scriberr/internal/api/apierror"
 	"scriberr/internal/database"
 	"scriberr/internal/models"
 )
@@ -39,7 +40,7 @@ type SummarySettingsResponse struct {
 func (h *Handler) ListSummaryTemplates(c *gin.Context) {
 	var items []models.SummaryTemplate
 	if err := database.DB.Order("created_at DESC").Find(&items).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch templates"})
+		apierror.Abort(c, apierror.Internal("Failed to fetch templates"))
 		return
 	}
 	c.JSON(http.StatusOK, items)
@@ -53,8 +54,8 @@ func (h *Handler) ListSummaryTemplates(c *gin.Context) {
 // @Produce json
 // @Param request body SummaryTemplateRequest true "Template payload"
 // @Success 201 {object} models.SummaryTemplate
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
 // @Security ApiKeyAuth
 // @Security BearerAuth
 // @Security BearerAuth
@@ -62,7 +63,7 @@ func (h *Handler) ListSummaryTemplates(c *gin.Context) {
 func (h *Handler) CreateSummaryTemplate(c *gin.Context) {
 	var req SummaryTemplateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Validation(err.Error()))
 		return
 	}
 	item := models.SummaryTemplate{
@@ -74,7 +75,7 @@ func (h *Handler) CreateSummaryTemplate(c *gin.Context) {
 		UpdatedAt:   time.Now(),
 	}
 	if err := database.DB.Create(&item).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create template"})
+		apierror.Abort(c, apierror.Internal("Failed to create template"))
 		return
 	}
 	c.JSON(http.StatusCreated, item)
@@ -87,8 +88,8 @@ func (h *Handler) CreateSummaryTemplate(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Template ID"
 // @Success 200 {object} models.SummaryTemplate
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
 // @Security ApiKeyAuth
 // @Security BearerAuth
 // @Security BearerAuth
@@ -98,10 +99,10 @@ func (h *Handler) GetSummaryTemplate(c *gin.Context) {
 	var item models.SummaryTemplate
 	if err := database.DB.Where("id = ?", id).First(&item).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+			apierror.Abort(c, apierror.NotFound("Template not found"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch template"})
+		apierror.Abort(c, apierror.Internal("Failed to fetch template"))
 		return
 	}
 	c.JSON(http.StatusOK, item)
@@ -116,9 +117,9 @@ func (h *Handler) GetSummaryTemplate(c *gin.Context) {
 // @Param id path string true "Template ID"
 // @Param request body SummaryTemplateRequest true "Template payload"
 // @Success 200 {object} models.SummaryTemplate
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
 // @Security ApiKeyAuth
 // @Security BearerAuth
 // @Security BearerAuth
@@ -127,16 +128,16 @@ func (h *Handler) UpdateSummaryTemplate(c *gin.Context) {
 	id := c.Param("id")
 	var req SummaryTemplateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Validation(err.Error()))
 		return
 	}
 	var item models.SummaryTemplate
 	if err := database.DB.Where("id = ?", id).First(&item).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+			apierror.Abort(c, apierror.NotFound("Template not found"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch template"})
+		apierror.Abort(c, apierror.Internal("Failed to fetch template"))
 		return
 	}
 	item.Name = req.Name
@@ -145,7 +146,7 @@ func (h *Handler) UpdateSummaryTemplate(c *gin.Context) {
 	item.Prompt = req.Prompt
 	item.UpdatedAt = time.Now()
 	if err := database.DB.Save(&item).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update template"})
+		apierror.Abort(c, apierror.Internal("Failed to update template"))
 		return
 	}
 	c.JSON(http.StatusOK, item)
@@ -158,13 +159,13 @@ func (h *Handler) UpdateSummaryTemplate(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Template ID"
 // @Success 204 {string} string "No Content"
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} apierror.Envelope
 // @Security ApiKeyAuth
 // @Router /api/v1/summaries/{id} [delete]
 func (h *Handler) DeleteSummaryTemplate(c *gin.Context) {
 	id := c.Param("id")
 	if err := database.DB.Delete(&models.SummaryTemplate{}, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete template"})
+		apierror.Abort(c, apierror.Internal("Failed to delete template"))
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -176,7 +177,7 @@ func (h *Handler) DeleteSummaryTemplate(c *gin.Context) {
 // @Tags summaries
 // @Produce json
 // @Success 200 {object} SummarySettingsResponse
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} apierror.Envelope
 // @Security ApiKeyAuth
 // @Router /api/v1/summaries/settings [get]
 func (h *Handler) GetSummarySettings(c *gin.Context) {
@@ -186,7 +187,7 @@ func (h *Handler) GetSummarySettings(c *gin.Context) {
 			c.JSON(http.StatusOK, SummarySettingsResponse{DefaultModel: ""})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settings"})
+		apierror.Abort(c, apierror.Internal("Failed to fetch settings"))
 		return
 	}
 	c.JSON(http.StatusOK, SummarySettingsResponse{DefaultModel: s.DefaultModel})
@@ -200,14 +201,14 @@ func (h *Handler) GetSummarySettings(c *gin.Context) {
 // @Produce json
 // @Param request body SummarySettingsRequest true "Settings payload"
 // @Success 200 {object} SummarySettingsResponse
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
 // @Security ApiKeyAuth
 // @Router /api/v1/summaries/settings [post]
 func (h *Handler) SaveSummarySettings(c *gin.Context) {
 	var req SummarySettingsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Abort(c, apierror.Validation(err.Error()))
 		return
 	}
 	var s models.SummarySetting
@@ -216,19 +217,19 @@ func (h *Handler) SaveSummarySettings(c *gin.Context) {
 			s.DefaultModel = req.DefaultModel
 			s.UpdatedAt = time.Now()
 			if err := database.DB.Create(&s).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save settings"})
+				apierror.Abort(c, apierror.Internal("Failed to save settings"))
 				return
 			}
 			c.JSON(http.StatusOK, SummarySettingsResponse{DefaultModel: s.DefaultModel})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save settings"})
+		apierror.Abort(c, apierror.Internal("Failed to save settings"))
 		return
 	}
 	s.DefaultModel = req.DefaultModel
 	s.UpdatedAt = time.Now()
 	if err := database.DB.Save(&s).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save settings"})
+		apierror.Abort(c, apierror.Internal("Failed to save settings"))
 		return
 	}
 	c.JSON(http.StatusOK, SummarySettingsResponse{DefaultModel: s.DefaultModel})
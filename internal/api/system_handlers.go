@@ -0,0 +1,299 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/diskspace"
+	"scriberr/internal/transcription"
+	"scriberr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StorageReportResponse describes disk space and what uses it
+type StorageReportResponse struct {
+	Volumes        []diskspace.Volume        `json:"volumes"`
+	Usage          []diskspace.CategoryUsage `json:"usage"`
+	UsageScannedAt time.Time                 `json:"usage_scanned_at"`
+	MinFreeBytes   uint64                    `json:"min_free_bytes"`
+	LowSpace       bool                      `json:"low_space"` // Uploads are being refused
+}
+
+// storageCategories lists where each kind of data Scriberr keeps lives
+func (h *Handler) storageCategories() []diskspace.Category {
+	db := h.config.DatabasePath
+	return []diskspace.Category{
+		{Name: "audio", Paths: []string{h.config.UploadDir}},
+		{Name: "transcripts_db", Paths: []string{db, db + "-wal", db + "-shm"}},
+		{Name: "whisper_models", Paths: []string{h.modelManager.CacheDir}},
+		{Name: "logs", Paths: []string{logger.FilePath()}},
+	}
+}
+
+// StartStorageMonitor measures disk usage now and every interval until ctx is done
+func (h *Handler) StartStorageMonitor(ctx context.Context, interval time.Duration) {
+	h.diskUsage.Start(ctx, interval)
+}
+
+// RequireFreeSpace refuses requests that store new files with 507 when the
+// upload volume is below MIN_FREE_SPACE_MB
+func (h *Handler) RequireFreeSpace() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := h.spaceGuard.Check(); err != nil {
+			apierror.Abort(c, apierror.New(http.StatusInsufficientStorage, apierror.CodeInsufficientStorage, err.Error()))
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetStorageReport reports free disk space and usage by kind of data
+// @Summary Get disk usage
+// @Description Report total and free space on the volumes holding uploads, the database and the WhisperX environment, and the space used by audio, the database, Whisper models and logs. Usage is measured in the background and may be a few minutes old.
+// @Tags system
+// @Produce json
+// @Success 200 {object} StorageReportResponse
+// @Router /api/v1/system/storage [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) GetStorageReport(c *gin.Context) {
+	response := StorageReportResponse{
+		Volumes:      []diskspace.Volume{},
+		MinFreeBytes: uint64(h.config.MinFreeSpaceMB) << 20,
+	}
+	for _, target := range []struct{ name, path string }{
+		{"uploads", h.config.UploadDir},
+		{"database", filepath.Dir(h.config.DatabasePath)},
+		{"whisperx_env", h.config.WhisperXEnv},
+	} {
+		volume, err := diskspace.Space(target.name, target.path)
+		if err != nil {
+			logger.Warn("Failed to read disk space", "path", target.path, "error", err)
+			continue
+		}
+		response.Volumes = append(response.Volumes, *volume)
+	}
+	response.Usage, response.UsageScannedAt = h.diskUsage.Usage()
+	response.LowSpace = errors.Is(h.spaceGuard.Check(), diskspace.ErrInsufficientSpace)
+	c.JSON(http.StatusOK, response)
+}
+
+// HostEnvironment is what Scriberr detected about the host at startup
+type HostEnvironment struct {
+	OS                  string `json:"os"`
+	Arch                string `json:"arch"`
+	SupportsNvidiaStack bool   `json:"supports_nvidia_stack"`
+	SupportsMPS         bool   `json:"supports_mps"`
+}
+
+// EnvironmentResponse reports the host and the health of the WhisperX environment
+type EnvironmentResponse struct {
+	Host     HostEnvironment                 `json:"host"`
+	WhisperX transcription.EnvironmentStatus `json:"whisperx"`
+}
+
+// StartEnvironmentCheck checks the WhisperX environment in the background,
+// logging any problems before the first job runs into them
+func (h *Handler) StartEnvironmentCheck(ctx context.Context) {
+	go func() {
+		status := h.whisperxEnv.Check(ctx)
+		switch status.State {
+		case transcription.EnvStateReady:
+			logger.Info("WhisperX environment is ready", "packages", status.Packages)
+		default:
+			logger.Warn("WhisperX environment has problems; repair it with POST /api/v1/admin/whisperx-env/repair",
+				"state", status.State, "problems", status.Problems)
+		}
+	}()
+}
+
+// GetEnvironment reports the host environment and the WhisperX environment health
+// @Summary Get environment status
+// @Description Report the detected host capabilities and whether the WhisperX Python environment exists, imports cleanly with the expected package versions and has a torch build matching the host. The status is from the last check unless refresh is true.
+// @Tags system
+// @Produce json
+// @Param refresh query bool false "Check the environment again now"
+// @Success 200 {object} EnvironmentResponse
+// @Router /api/v1/system/environment [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) GetEnvironment(c *gin.Context) {
+	status := h.whisperxEnv.Status()
+	if c.Query("refresh") == "true" {
+		status = h.whisperxEnv.Check(c.Request.Context())
+	}
+	c.JSON(http.StatusOK, EnvironmentResponse{
+		Host: HostEnvironment{
+			OS:                  h.environment.OS,
+			Arch:                h.environment.Arch,
+			SupportsNvidiaStack: h.environment.SupportsNvidiaStack,
+			SupportsMPS:         h.environment.SupportsMPS,
+		},
+		WhisperX: status,
+	})
+}
+
+// WhisperXStatusResponse reports whether WhisperX is installed correctly
+type WhisperXStatusResponse struct {
+	Installed bool   `json:"installed"`
+	Version   string `json:"version,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Step      string `json:"step,omitempty"` // Step of the check that failed: uv, environment, run or version
+}
+
+// GetWhisperXStatus checks that whisperx runs in its environment
+// @Summary Verify the WhisperX installation
+// @Description Run whisperx --version in the WhisperX virtual environment with uv and report the version, or why the installation is broken and how to fix it.
+// @Tags system
+// @Produce json
+// @Success 200 {object} WhisperXStatusResponse
+// @Router /api/v1/system/whisperx-status [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) GetWhisperXStatus(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
+	defer cancel()
+	version, err := transcription.WhisperXVersion(ctx, h.config.UVPath, filepath.Join(whisperXProjectPath(), ".venv"))
+	if err != nil {
+		response := WhisperXStatusResponse{Error: err.Error()}
+		var installErr *transcription.InstallError
+		if errors.As(err, &installErr) {
+			response.Step = installErr.Step
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	c.JSON(http.StatusOK, WhisperXStatusResponse{Installed: true, Version: version})
+}
+
+// WhisperXSetupOutput is a line uv printed while setting up WhisperX
+type WhisperXSetupOutput struct {
+	Stream string `json:"stream"` // stdout or stderr
+	Line   string `json:"line"`
+}
+
+// WhisperXSetupResult is how a WhisperX setup ended
+type WhisperXSetupResult struct {
+	Status string `json:"status"` // complete or error
+	Log    string `json:"log"`
+	Error  string `json:"error,omitempty"`
+	Step   string `json:"step,omitempty"` // Step that failed: uv, venv or install
+}
+
+// SetupWhisperX creates the WhisperX virtual environment and installs
+// WhisperX, streaming uv's output as server-sent events
+// @Summary Set up WhisperX
+// @Description Create a virtual environment at WHISPERX_ENV with uv venv and install the WhisperX version set by SCRIBERR_WHISPERX_VERSION into it with uv pip install. Each line uv prints is streamed as an "output" event, followed by a "done" event with status complete or error and the whole log. Closing the stream stops the setup. (admin only)
+// @Tags system
+// @Produce text/event-stream
+// @Success 200 {object} WhisperXSetupResult "Event stream"
+// @Failure 403 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope
+// @Failure 503 {object} apierror.Envelope
+// @Router /api/v1/system/setup [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) SetupWhisperX(c *gin.Context) {
+	if err := transcription.CheckUV(h.config.UVPath); err != nil {
+		apierror.Abort(c, apierror.EngineUnavailable(err.Error()))
+		return
+	}
+
+	started := false
+	var log strings.Builder
+	err := transcription.SetupWhisperX(c.Request.Context(), h.config.UVPath, h.config.WhisperXEnv, h.config.WhisperXVersion, func(stream, line string) {
+		if !started {
+			startEventStream(c)
+			started = true
+		}
+		log.WriteString(line + "\n")
+		c.SSEvent("output", WhisperXSetupOutput{Stream: stream, Line: line})
+		c.Writer.Flush()
+	})
+	if errors.Is(err, transcription.ErrSetupInProgress) {
+		apierror.Abort(c, apierror.Conflict("WhisperX setup is already running"))
+		return
+	}
+	if !started {
+		startEventStream(c)
+	}
+
+	result := WhisperXSetupResult{Status: "complete", Log: log.String()}
+	if err != nil {
+		logger.Error("WhisperX setup failed", "env", h.config.WhisperXEnv, "version", h.config.WhisperXVersion, "error", err)
+		result.Status = "error"
+		result.Error = err.Error()
+		var installErr *transcription.InstallError
+		if errors.As(err, &installErr) {
+			result.Step = installErr.Step
+		}
+	} else {
+		logger.Info("WhisperX set up", "env", h.config.WhisperXEnv, "version", h.config.WhisperXVersion)
+	}
+	c.SSEvent("done", result)
+	c.Writer.Flush()
+}
+
+// startEventStream sends the headers of a server-sent event stream
+func startEventStream(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+}
+
+// RepairWhisperXEnvironment reinstalls the WhisperX environment in the background
+// @Summary Repair the WhisperX environment
+// @Description Clone WhisperX if it is missing, reinstall its dependencies with uv and check the environment again. Runs in the background: follow the output in /api/v1/admin/logs and the state in /api/v1/system/environment. Jobs submitted meanwhile wait in the queue.
+// @Tags admin
+// @Produce json
+// @Success 202 {object} transcription.EnvironmentStatus
+// @Failure 409 {object} apierror.Envelope
+// @Router /api/v1/admin/whisperx-env/repair [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) RepairWhisperXEnvironment(c *gin.Context) {
+	if err := h.whisperxEnv.StartRepair(context.Background()); err != nil {
+		if errors.Is(err, transcription.ErrRepairInProgress) {
+			apierror.Abort(c, apierror.Conflict("A repair is already running"))
+			return
+		}
+		apierror.Abort(c, apierror.Internal("Failed to start repair"))
+		return
+	}
+	c.JSON(http.StatusAccepted, h.whisperxEnv.Status())
+}
+
+// GetRecentLogs returns recent log entries kept in memory
+// @Summary Get recent log entries
+// @Description Return the most recent log entries, oldest first. Poll with after set to the last seq seen to follow new entries, such as environment repair output.
+// @Tags admin
+// @Produce json
+// @Param after query int false "Only return entries with a greater seq"
+// @Param limit query int false "Most entries to return" default(200)
+// @Success 200 {object} map[string][]logger.Entry
+// @Failure 400 {object} apierror.Envelope
+// @Router /api/v1/admin/logs [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) GetRecentLogs(c *gin.Context) {
+	after, err := strconv.ParseUint(c.DefaultQuery("after", "0"), 10, 64)
+	if err != nil {
+		apierror.Abort(c, apierror.Validation("after must be a sequence number"))
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "200"))
+	if err != nil || limit < 1 {
+		apierror.Abort(c, apierror.Validation("limit must be a positive number"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": logger.Recent(after, limit)})
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+)
+
+// AddTagRequest is the tag to add to a job
+type AddTagRequest struct {
+	Key   string `json:"key" binding:"required"`
+	Value string `json:"value" binding:"required"`
+}
+
+// TagsResponse lists a job's tags
+type TagsResponse struct {
+	JobID string              `json:"job_id"`
+	Tags  map[string][]string `json:"tags"`
+}
+
+// AddJobTag labels a job with a custom tag
+// @Summary Add a tag to a job
+// @Description Label a job with a key and value, such as project, client or speaker. A key may have several values; adding a value the job already has does nothing.
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param id path string true "Job ID"
+// @Param request body AddTagRequest true "Tag to add"
+// @Success 200 {object} TagsResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/{id}/tags [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) AddJobTag(c *gin.Context) {
+	jobID := c.Param("id")
+
+	var req AddTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
+		return
+	}
+	if err := models.ValidateTag(req.Key, req.Value); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	if !tagJobExists(c, jobID) {
+		return
+	}
+
+	if err := database.AddTag(jobID, req.Key, req.Value); err != nil {
+		logger.Error("Failed to add tag", "job_id", jobID, "key", req.Key, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to add tag"))
+		return
+	}
+	respondWithTags(c, jobID)
+}
+
+// DeleteJobTag removes a tag from a job
+// @Summary Remove a tag from a job
+// @Description Remove every value of a tag key from a job, or only the given value
+// @Tags transcription
+// @Produce json
+// @Param id path string true "Job ID"
+// @Param key path string true "Tag key"
+// @Param value query string false "Only remove this value"
+// @Success 200 {object} TagsResponse
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/{id}/tags/{key} [delete]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) DeleteJobTag(c *gin.Context) {
+	jobID := c.Param("id")
+	key := c.Param("key")
+
+	if !tagJobExists(c, jobID) {
+		return
+	}
+
+	removed, err := database.RemoveTag(jobID, key, c.Query("value"))
+	if err != nil {
+		logger.Error("Failed to remove tag", "job_id", jobID, "key", key, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to remove tag"))
+		return
+	}
+	if removed == 0 {
+		apierror.Abort(c, apierror.NotFound("Tag not found"))
+		return
+	}
+	respondWithTags(c, jobID)
+}
+
+// ListTags returns every tag in use
+// @Summary List tags
+// @Description Lists every tag on a job, with the number of jobs that have it
+// @Tags transcription
+// @Produce json
+// @Success 200 {object} map[string][]database.TagCount
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/tags [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) ListTags(c *gin.Context) {
+	tags, err := database.ListTags(ownedJobs(c))
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to list tags"))
+		return
+	}
+	if tags == nil {
+		tags = []database.TagCount{}
+	}
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// tagJobExists writes a not found response and returns false if the job
+// does not exist
+func tagJobExists(c *gin.Context, jobID string) bool {
+	var job models.TranscriptionJob
+	if err := database.DB.Scopes(ownedJobs(c)).Select("id").Where("id = ?", jobID).First(&job).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apierror.Abort(c, apierror.JobNotFound())
+			return false
+		}
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
+		return false
+	}
+	return true
+}
+
+// respondWithTags writes a job's current tags
+func respondWithTags(c *gin.Context, jobID string) {
+	tags, err := database.GetTags(jobID)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to get tags"))
+		return
+	}
+	c.JSON(http.StatusOK, TagsResponse{JobID: jobID, Tags: tags})
+}
+
+// attachTags fills in the tags of jobs about to be returned
+func attachTags(jobs []models.TranscriptionJob) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+	ids := make([]string, len(jobs))
+	for i, job := range jobs {
+		ids[i] = job.ID
+	}
+	tags, err := database.GetTagsForJobs(ids)
+	if err != nil {
+		return err
+	}
+	for i := range jobs {
+		jobs[i].Tags = tags[jobs[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/config"
+	"scriberr/internal/database"
+	"scriberr/internal/export"
+	"scriberr/internal/llm"
+	"scriberr/internal/models"
+	"scriberr/internal/transcriptchat"
+	"scriberr/pkg/logger"
+)
+
+// TranscriptChatMessage is one turn of a transcript chat
+type TranscriptChatMessage struct {
+	Role    string `json:"role" binding:"required,oneof=user assistant"`
+	Content string `json:"content" binding:"required"`
+}
+
+// TranscriptChatRequest continues or starts a conversation about a transcript
+type TranscriptChatRequest struct {
+	SessionID string                  `json:"session_id,omitempty"` // Continue this conversation; a new one is started when empty
+	Model     string                  `json:"model,omitempty"`      // Required for a new conversation
+	Messages  []TranscriptChatMessage `json:"messages" binding:"required,min=1,dive"`
+}
+
+// TranscriptChatDone ends a transcript chat stream
+type TranscriptChatDone struct {
+	MessageID    uint `json:"message_id"`
+	DroppedTurns int  `json:"dropped_turns"` // Oldest turns left out of the prompt to fit the context limit
+}
+
+// ChatWithTranscript answers questions about a job's transcript
+// @Summary Chat with a transcript
+// @Description Appends messages to a conversation about a completed transcript and streams the answer. The passages of the transcript most relevant to the last message are given to the model with their timestamps, and the answer cites them. The stream sends a "session" event with the conversation, a "sources" event with the start and end of each passage, "message" events with the answer text, then "done" or "error". The oldest turns are left out when the conversation exceeds the context limit set by CHAT_CONTEXT_TOKENS. Conversations are stored as chat sessions of the job.
+// @Tags chat
+// @Accept json
+// @Produce text/event-stream
+// @Param id path string true "Job ID"
+// @Param request body TranscriptChatRequest true "Messages to add, ending with the user's question"
+// @Success 200 {string} string "Event stream"
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Failure 503 {object} apierror.Envelope
+// @Router /api/v1/transcriptions/{id}/chat [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) ChatWithTranscript(c *gin.Context) {
+	var req TranscriptChatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	if req.Messages[len(req.Messages)-1].Role != "user" {
+		apierror.Abort(c, apierror.Validation("The last message must be from the user"))
+		return
+	}
+
+	var job models.TranscriptionJob
+	if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", c.Param("id")).First(&job).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			apierror.Abort(c, apierror.JobNotFound())
+			return
+		}
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
+		return
+	}
+	if job.Status != models.StatusCompleted || job.Transcript == nil {
+		apierror.Abort(c, apierror.BadRequest("Transcript not available"))
+		return
+	}
+
+	var session models.ChatSession
+	var history []llm.ChatMessage
+	if req.SessionID != "" {
+		if err := database.DB.Where("id = ? AND transcription_id = ?", req.SessionID, job.ID).First(&session).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				apierror.Abort(c, apierror.NotFound("Chat session not found"))
+				return
+			}
+			apierror.Abort(c, apierror.Internal("Failed to get chat session"))
+			return
+		}
+		var previous []models.ChatMessage
+		if err := database.DB.Where("chat_session_id = ?", session.ID).Order("created_at ASC, id ASC").Find(&previous).Error; err != nil {
+			apierror.Abort(c, apierror.Internal("Failed to get messages"))
+			return
+		}
+		for _, msg := range previous {
+			history = append(history, llm.ChatMessage{Role: msg.Role, Content: msg.Content})
+		}
+		if req.Model == "" {
+			req.Model = session.Model
+		}
+	} else if req.Model == "" {
+		apierror.Abort(c, apierror.Validation("model is required to start a conversation"))
+		return
+	}
+	for _, msg := range req.Messages {
+		history = append(history, llm.ChatMessage{Role: msg.Role, Content: msg.Content})
+	}
+
+	svc, provider, err := h.getLLMService()
+	if err != nil {
+		apierror.Abort(c, apierror.EngineUnavailable(err.Error()))
+		return
+	}
+
+	names, err := speakerDisplayNames(job.ID)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to load speaker names"))
+		return
+	}
+	segments, err := export.SegmentsFromTranscript([]byte(*job.Transcript), names)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to read transcript").WithCause(err))
+		return
+	}
+	budget := h.config.ChatContextTokens
+	if budget <= 0 {
+		budget = config.DefaultChatContextTokens
+	}
+	prompt, err := transcriptchat.BuildPrompt(segments, history, budget)
+	if err != nil {
+		if errors.Is(err, transcriptchat.ErrQuestionTooLong) {
+			apierror.Abort(c, apierror.Validation(err.Error()))
+			return
+		}
+		apierror.Abort(c, apierror.Internal("Failed to build prompt").WithCause(err))
+		return
+	}
+	sources := make([]models.ChatSource, len(prompt.Sources))
+	for i, passage := range prompt.Sources {
+		sources[i] = models.ChatSource{Start: passage.Start, End: passage.End}
+	}
+
+	// Store the conversation before streaming, so the question is kept even
+	// if the model fails
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if session.ID == "" {
+			now := time.Now()
+			session = models.ChatSession{
+				JobID:           job.ID,
+				TranscriptionID: job.ID,
+				Title:           generateChatTitle(req.Messages[len(req.Messages)-1].Content),
+				Model:           req.Model,
+				Provider:        provider,
+				LastActivityAt:  &now,
+				IsActive:        true,
+			}
+			if err := tx.Create(&session).Error; err != nil {
+				return err
+			}
+		}
+		for _, msg := range req.Messages {
+			if err := tx.Create(&models.ChatMessage{ChatSessionID: session.ID, Role: msg.Role, Content: msg.Content}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&session).Updates(map[string]interface{}{
+			"last_activity_at": time.Now(),
+			"message_count":    gorm.Expr("message_count + ?", len(req.Messages)),
+		}).Error
+	}); err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to save messages"))
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	c.SSEvent("session", gin.H{"session_id": session.ID})
+	c.SSEvent("sources", sources)
+	c.Writer.Flush()
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Minute)
+	defer cancel()
+
+	answer, err := streamChatAnswer(ctx, c, svc, req.Model, prompt.Messages)
+	if answer == "" {
+		if err == nil {
+			err = errors.New("the model returned an empty answer")
+		}
+		logger.Error("Transcript chat failed", "job_id", job.ID, "session_id", session.ID, "error", err)
+		c.SSEvent("error", gin.H{"error": err.Error()})
+		c.Writer.Flush()
+		return
+	}
+
+	// Keep a partial answer when the stream broke off, as the other chat
+	// endpoints do
+	reply := models.ChatMessage{ChatSessionID: session.ID, Role: "assistant", Content: answer, Sources: sources}
+	if dbErr := database.DB.Create(&reply).Error; dbErr != nil {
+		logger.Error("Failed to save transcript chat answer", "session_id", session.ID, "error", dbErr)
+	} else {
+		now := time.Now()
+		database.DB.Model(&session).Updates(map[string]interface{}{
+			"last_activity_at": now,
+			"message_count":    gorm.Expr("message_count + ?", 1),
+		})
+	}
+	if err != nil {
+		c.SSEvent("error", gin.H{"error": err.Error()})
+	} else {
+		c.SSEvent("done", TranscriptChatDone{MessageID: reply.ID, DroppedTurns: prompt.Dropped})
+	}
+	c.Writer.Flush()
+}
+
+// streamChatAnswer sends the model's answer to messages as "message" events
+// and returns the text sent. Models that cannot stream are asked again
+// without streaming.
+func streamChatAnswer(ctx context.Context, c *gin.Context, svc llm.Service, model string, messages []llm.ChatMessage) (string, error) {
+	contentChan, errorChan := svc.ChatCompletionStream(ctx, model, messages, 0.0)
+	var answer strings.Builder
+	for {
+		select {
+		case content, ok := <-contentChan:
+			if !ok {
+				return answer.String(), nil
+			}
+			c.SSEvent("message", content)
+			c.Writer.Flush()
+			answer.WriteString(content)
+
+		case err, ok := <-errorChan:
+			if !ok {
+				errorChan = nil // Closed before the content; wait for the rest of it
+				continue
+			}
+			errStr := err.Error()
+			if answer.Len() > 0 || !(strings.Contains(errStr, "\"param\": \"stream\"") || strings.Contains(errStr, "unsupported_value") || strings.Contains(errStr, "must be verified to stream")) {
+				return answer.String(), err
+			}
+			resp, err := svc.ChatCompletion(ctx, model, messages, 0.0)
+			if err != nil {
+				return "", err
+			}
+			if resp == nil || len(resp.Choices) == 0 {
+				return "", errors.New("the model returned no answer")
+			}
+			content := resp.Choices[0].Message.Content
+			c.SSEvent("message", content)
+			c.Writer.Flush()
+			return content, nil
+
+		case <-ctx.Done():
+			return answer.String(), errors.New("request timeout")
+		}
+	}
+}
@@ -0,0 +1,495 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+)
+
+// UpdateSegmentRequest corrects the text and/or timing of one segment. Revision,
+// when set, must match the current transcript revision.
+type UpdateSegmentRequest struct {
+	Text     *string  `json:"text,omitempty"`
+	Start    *float64 `json:"start,omitempty"`
+	End      *float64 `json:"end,omitempty"`
+	Revision *int     `json:"revision,omitempty"`
+}
+
+// SplitSegmentRequest splits a segment in two. Offset is the character position
+// in the text where the second segment begins and Time is its start time.
+type SplitSegmentRequest struct {
+	Offset   int     `json:"offset" binding:"required"`
+	Time     float64 `json:"time" binding:"required"`
+	Revision *int    `json:"revision,omitempty"`
+}
+
+// MergeSegmentsRequest merges a segment with the one that follows it
+type MergeSegmentsRequest struct {
+	Revision *int `json:"revision,omitempty"`
+}
+
+// TranscriptEditResponse is returned after a transcript edit
+type TranscriptEditResponse struct {
+	TranscriptRevision int         `json:"transcript_revision"`
+	Transcript         interface{} `json:"transcript"`
+}
+
+// UpdateTranscriptSegment corrects a segment's text or timing
+// @Summary Edit a transcript segment
+// @Description Replaces the text and/or start and end times of a segment. New times may not overlap the neighbouring segments. Each edit is recorded as a new transcript revision.
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param id path string true "Transcription Job ID"
+// @Param segIdx path int true "Segment index"
+// @Param request body UpdateSegmentRequest true "Segment changes"
+// @Success 200 {object} TranscriptEditResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/transcription/{id}/transcript/segments/{segIdx} [put]
+func (h *Handler) UpdateTranscriptSegment(c *gin.Context) {
+	var req UpdateSegmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
+		return
+	}
+	if req.Text == nil && req.Start == nil && req.End == nil {
+		apierror.Abort(c, apierror.BadRequest("Nothing to update"))
+		return
+	}
+
+	job, transcript, segments, idx, ok := loadTranscriptSegment(c, req.Revision)
+	if !ok {
+		return
+	}
+	segment := segments[idx].(map[string]interface{})
+
+	var changes []string
+	if req.Text != nil {
+		text := strings.TrimSpace(*req.Text)
+		if text == "" {
+			apierror.Abort(c, apierror.Validation("Segment text cannot be empty"))
+			return
+		}
+		segment["text"] = text
+		// Word timings no longer match corrected text
+		delete(segment, "words")
+		changes = append(changes, "text")
+	}
+	if req.Start != nil || req.End != nil {
+		start, end := segmentTime(segment, "start"), segmentTime(segment, "end")
+		if req.Start != nil {
+			start = *req.Start
+		}
+		if req.End != nil {
+			end = *req.End
+		}
+		if start < 0 || start >= end {
+			apierror.Abort(c, apierror.Validation("Segment start must be non-negative and before its end"))
+			return
+		}
+		if idx > 0 && start < segmentTime(segments[idx-1].(map[string]interface{}), "end") {
+			apierror.Abort(c, apierror.BadRequest("Segment would overlap the previous segment"))
+			return
+		}
+		if idx < len(segments)-1 && end > segmentTime(segments[idx+1].(map[string]interface{}), "start") {
+			apierror.Abort(c, apierror.BadRequest("Segment would overlap the next segment"))
+			return
+		}
+		segment["start"] = start
+		segment["end"] = end
+		changes = append(changes, "timing")
+	}
+
+	description := fmt.Sprintf("Edited %s of segment %d", strings.Join(changes, " and "), idx)
+	h.saveTranscriptEdit(c, job, transcript, models.TranscriptOpEdit, &idx, description)
+}
+
+// SplitTranscriptSegment splits one segment into two
+// @Summary Split a transcript segment
+// @Description Splits a segment at a character offset in its text and a time within its bounds. Both halves keep the segment's speaker.
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param id path string true "Transcription Job ID"
+// @Param segIdx path int true "Segment index"
+// @Param request body SplitSegmentRequest true "Split position"
+// @Success 200 {object} TranscriptEditResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/transcription/{id}/transcript/segments/{segIdx}/split [post]
+func (h *Handler) SplitTranscriptSegment(c *gin.Context) {
+	var req SplitSegmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
+		return
+	}
+
+	job, transcript, segments, idx, ok := loadTranscriptSegment(c, req.Revision)
+	if !ok {
+		return
+	}
+	first := segments[idx].(map[string]interface{})
+
+	if req.Time <= segmentTime(first, "start") || req.Time >= segmentTime(first, "end") {
+		apierror.Abort(c, apierror.Validation("Split time must fall inside the segment"))
+		return
+	}
+	text, _ := first["text"].(string)
+	runes := []rune(text)
+	if req.Offset <= 0 || req.Offset >= len(runes) {
+		apierror.Abort(c, apierror.Validation("Split offset must fall inside the segment text"))
+		return
+	}
+	head := strings.TrimSpace(string(runes[:req.Offset]))
+	tail := strings.TrimSpace(string(runes[req.Offset:]))
+	if head == "" || tail == "" {
+		apierror.Abort(c, apierror.BadRequest("Both halves of a split segment need text"))
+		return
+	}
+
+	second := make(map[string]interface{}, len(first))
+	for key, value := range first {
+		second[key] = value
+	}
+	first["text"], first["end"] = head, req.Time
+	second["text"], second["start"] = tail, req.Time
+	if words, ok := first["words"].([]interface{}); ok {
+		var before, after []interface{}
+		for _, word := range words {
+			if entry, ok := word.(map[string]interface{}); ok && segmentTime(entry, "start") >= req.Time {
+				after = append(after, word)
+			} else {
+				before = append(before, word)
+			}
+		}
+		first["words"], second["words"] = before, after
+	}
+
+	segments = append(segments[:idx+1], append([]interface{}{second}, segments[idx+1:]...)...)
+	transcript["segments"] = segments
+
+	h.saveTranscriptEdit(c, job, transcript, models.TranscriptOpSplit, &idx, fmt.Sprintf("Split segment %d", idx))
+}
+
+// MergeTranscriptSegments joins a segment with the next one
+// @Summary Merge transcript segments
+// @Description Joins a segment with the segment that follows it. Both segments must belong to the same speaker.
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param id path string true "Transcription Job ID"
+// @Param segIdx path int true "Index of the first segment"
+// @Param request body MergeSegmentsRequest false "Expected revision"
+// @Success 200 {object} TranscriptEditResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/transcription/{id}/transcript/segments/{segIdx}/merge [post]
+func (h *Handler) MergeTranscriptSegments(c *gin.Context) {
+	var req MergeSegmentsRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
+			return
+		}
+	}
+
+	job, transcript, segments, idx, ok := loadTranscriptSegment(c, req.Revision)
+	if !ok {
+		return
+	}
+	if idx == len(segments)-1 {
+		apierror.Abort(c, apierror.BadRequest("The last segment has nothing to merge with"))
+		return
+	}
+	first := segments[idx].(map[string]interface{})
+	next := segments[idx+1].(map[string]interface{})
+	if first["speaker"] != next["speaker"] {
+		apierror.Abort(c, apierror.BadRequest("Cannot merge segments from different speakers"))
+		return
+	}
+
+	firstText, _ := first["text"].(string)
+	nextText, _ := next["text"].(string)
+	first["text"] = strings.TrimSpace(strings.TrimSpace(firstText) + " " + strings.TrimSpace(nextText))
+	first["end"] = next["end"]
+	if nextWords, ok := next["words"].([]interface{}); ok {
+		words, _ := first["words"].([]interface{})
+		first["words"] = append(words, nextWords...)
+	}
+
+	transcript["segments"] = append(segments[:idx+1], segments[idx+2:]...)
+
+	h.saveTranscriptEdit(c, job, transcript, models.TranscriptOpMerge, &idx, fmt.Sprintf("Merged segments %d and %d", idx, idx+1))
+}
+
+// ListTranscriptRevisions returns the edit history of a transcript
+// @Summary List transcript revisions
+// @Description Lists who edited the transcript, what they changed and when, newest first
+// @Tags transcription
+// @Produce json
+// @Param id path string true "Transcription Job ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} apierror.Envelope
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/transcription/{id}/transcript/revisions [get]
+func (h *Handler) ListTranscriptRevisions(c *gin.Context) {
+	jobID := c.Param("id")
+
+	var job models.TranscriptionJob
+	if err := database.DB.Scopes(ownedJobs(c)).Select("id", "transcript_revision").Where("id = ?", jobID).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.JobNotFound())
+			return
+		}
+		apierror.Abort(c, apierror.Internal("Failed to get transcription job"))
+		return
+	}
+
+	var revisions []models.TranscriptRevision
+	if err := database.DB.Omit("transcript").Where("transcription_job_id = ?", jobID).
+		Order("revision DESC").Find(&revisions).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to get transcript revisions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"transcript_revision": job.TranscriptRevision,
+		"revisions":           revisions,
+	})
+}
+
+// RevertTranscript restores an earlier revision of a transcript
+// @Summary Revert a transcript
+// @Description Restores the transcript text and segments of an earlier revision. The revert is itself recorded as a new revision, so it can be undone. Speaker names are not reverted.
+// @Tags transcription
+// @Produce json
+// @Param id path string true "Transcription Job ID"
+// @Param revision path int true "Revision to restore"
+// @Success 200 {object} TranscriptEditResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/transcription/{id}/transcript/revert/{revision} [post]
+func (h *Handler) RevertTranscript(c *gin.Context) {
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || revision < 0 {
+		apierror.Abort(c, apierror.Validation("Invalid revision"))
+		return
+	}
+
+	job, _, ok := loadEditableTranscript(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if revision >= job.TranscriptRevision {
+		apierror.Abort(c, apierror.BadRequest("Can only revert to an earlier revision"))
+		return
+	}
+
+	snapshot, err := transcriptAtRevision(job.ID, revision)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.NotFound("Revision not found"))
+			return
+		}
+		apierror.Abort(c, apierror.Internal("Failed to get transcript revision"))
+		return
+	}
+
+	var transcript map[string]interface{}
+	if err := json.Unmarshal([]byte(snapshot.Transcript), &transcript); err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to parse transcript"))
+		return
+	}
+	h.saveTranscriptEdit(c, job, transcript, models.TranscriptOpRevert, nil, fmt.Sprintf("Reverted to revision %d", revision))
+}
+
+// saveTranscriptEdit stores an edited transcript as a new revision and writes
+// the response. Exports are keyed on the transcript revision, so bumping it is
+// what invalidates previously generated downloads.
+func (h *Handler) saveTranscriptEdit(c *gin.Context, job *models.TranscriptionJob, transcript map[string]interface{}, operation string, segmentIndex *int, description string) {
+	if segments, ok := transcript["segments"].([]interface{}); ok {
+		transcript["text"] = joinSegmentText(segments)
+	}
+
+	edit, err := newTranscriptRevision(c, operation, segmentIndex, description, transcript)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to encode transcript"))
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		return bumpTranscriptRevision(tx, job, edit)
+	})
+	if errors.Is(err, errTranscriptChanged) {
+		apierror.Abort(c, apierror.Conflict("Transcript was modified by another request, please reload"))
+		return
+	}
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to save transcript"))
+		return
+	}
+
+	c.JSON(http.StatusOK, TranscriptEditResponse{
+		TranscriptRevision: job.TranscriptRevision,
+		Transcript:         transcript,
+	})
+}
+
+// loadEditableTranscript fetches a job with a transcript and decodes the
+// transcript generically so fields the editors do not know about survive a
+// rewrite. It writes an error response and returns false on failure.
+func loadEditableTranscript(c *gin.Context, jobID string) (*models.TranscriptionJob, map[string]interface{}, bool) {
+	var job models.TranscriptionJob
+	if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", jobID).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.JobNotFound())
+			return nil, nil, false
+		}
+		apierror.Abort(c, apierror.Internal("Failed to get transcription job"))
+		return nil, nil, false
+	}
+	if job.Transcript == nil {
+		apierror.Abort(c, apierror.BadRequest("Transcript not available"))
+		return nil, nil, false
+	}
+
+	var transcript map[string]interface{}
+	if err := json.Unmarshal([]byte(*job.Transcript), &transcript); err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to parse transcript"))
+		return nil, nil, false
+	}
+	return &job, transcript, true
+}
+
+// loadTranscriptSegment loads the transcript of the :id job and resolves the
+// :segIdx path parameter against its segments, checking the caller's expected
+// revision. It writes an error response and returns false on failure.
+func loadTranscriptSegment(c *gin.Context, expectedRevision *int) (*models.TranscriptionJob, map[string]interface{}, []interface{}, int, bool) {
+	idx, err := strconv.Atoi(c.Param("segIdx"))
+	if err != nil || idx < 0 {
+		apierror.Abort(c, apierror.Validation("Invalid segment index"))
+		return nil, nil, nil, 0, false
+	}
+
+	job, transcript, ok := loadEditableTranscript(c, c.Param("id"))
+	if !ok {
+		return nil, nil, nil, 0, false
+	}
+	if expectedRevision != nil && *expectedRevision != job.TranscriptRevision {
+		apierror.Abort(c, apierror.Conflict("Transcript was modified by another request, please reload"))
+		return nil, nil, nil, 0, false
+	}
+
+	segments, _ := transcript["segments"].([]interface{})
+	for _, segment := range segments {
+		if _, ok := segment.(map[string]interface{}); !ok {
+			apierror.Abort(c, apierror.Internal("Failed to parse transcript"))
+			return nil, nil, nil, 0, false
+		}
+	}
+	if idx >= len(segments) {
+		apierror.Abort(c, apierror.NotFound("Segment not found"))
+		return nil, nil, nil, 0, false
+	}
+	return job, transcript, segments, idx, true
+}
+
+// newTranscriptRevision builds a revision history entry for the authenticated
+// caller. The job and revision number are filled in when it is saved.
+func newTranscriptRevision(c *gin.Context, operation string, segmentIndex *int, description string, transcript map[string]interface{}) (*models.TranscriptRevision, error) {
+	encoded, err := json.Marshal(transcript)
+	if err != nil {
+		return nil, err
+	}
+
+	edit := &models.TranscriptRevision{
+		Operation:    operation,
+		SegmentIndex: segmentIndex,
+		Description:  description,
+		Username:     c.GetString("username"),
+		Transcript:   string(encoded),
+	}
+	if userID, ok := c.Get("user_id"); ok {
+		if id, ok := userID.(uint); ok {
+			edit.UserID = &id
+		}
+	}
+	return edit, nil
+}
+
+// recordOriginalTranscript stores the machine transcript in the revision history
+// before its first edit, under the revision the job had at that point
+func recordOriginalTranscript(tx *gorm.DB, job *models.TranscriptionJob) error {
+	if job.Transcript == nil {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&models.TranscriptRevision{}).Where("transcription_job_id = ?", job.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return tx.Create(&models.TranscriptRevision{
+		TranscriptionJobID: job.ID,
+		Revision:           job.TranscriptRevision,
+		Operation:          models.TranscriptOpOriginal,
+		Description:        "Original transcript",
+		Transcript:         *job.Transcript,
+	}).Error
+}
+
+// transcriptAtRevision returns the snapshot in effect at the given revision.
+// Speaker renames bump the revision without changing the transcript, so this is
+// the newest snapshot at or before it.
+func transcriptAtRevision(jobID string, revision int) (*models.TranscriptRevision, error) {
+	var snapshot models.TranscriptRevision
+	err := database.DB.Where("transcription_job_id = ? AND revision <= ?", jobID, revision).
+		Order("revision DESC").First(&snapshot).Error
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// segmentTime reads a numeric field of a decoded segment or word
+func segmentTime(entry map[string]interface{}, key string) float64 {
+	value, _ := entry[key].(float64)
+	return value
+}
+
+// joinSegmentText rebuilds the full transcript text from its segments
+func joinSegmentText(segments []interface{}) string {
+	parts := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		entry, _ := segment.(map[string]interface{})
+		if text, ok := entry["text"].(string); ok && strings.TrimSpace(text) != "" {
+			parts = append(parts, strings.TrimSpace(text))
+		}
+	}
+	return strings.Join(parts, " ")
+}
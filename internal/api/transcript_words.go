@@ -0,0 +1,84 @@
+package api
+
+// Transcript response granularities
+const (
+	granularitySegment = "segment"
+	granularityWord    = "word"
+)
+
+// applyTranscriptGranularity shapes a decoded transcript for a GET response.
+// Segment granularity drops word timings. Word granularity gives every
+// segment a "words" list, null when the words were never aligned, with each
+// word carrying its speaker. Transcripts stored before words were kept per
+// segment have their top-level word_segments distributed by start time.
+func applyTranscriptGranularity(transcript map[string]interface{}, granularity string) {
+	segments := transcriptSegments(transcript)
+	switch granularity {
+	case granularitySegment:
+		for _, segment := range segments {
+			delete(segment, "words")
+		}
+		delete(transcript, "word_segments")
+	case granularityWord:
+		distributeLegacyWords(segments, transcript["word_segments"])
+		delete(transcript, "word_segments")
+		for _, segment := range segments {
+			words, _ := segment["words"].([]interface{})
+			if len(words) == 0 {
+				segment["words"] = nil
+				continue
+			}
+			if speaker, ok := segment["speaker"].(string); ok {
+				for _, item := range words {
+					if word, ok := item.(map[string]interface{}); ok && word["speaker"] == nil {
+						word["speaker"] = speaker
+					}
+				}
+			}
+		}
+	}
+}
+
+// transcriptSegments returns the segment objects of a decoded transcript
+func transcriptSegments(transcript map[string]interface{}) []map[string]interface{} {
+	items, _ := transcript["segments"].([]interface{})
+	segments := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if segment, ok := item.(map[string]interface{}); ok {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// distributeLegacyWords moves top-level word timings into the segments they
+// start in, unless the segments already carry their own
+func distributeLegacyWords(segments []map[string]interface{}, wordSegments interface{}) {
+	words, _ := wordSegments.([]interface{})
+	if len(words) == 0 || len(segments) == 0 {
+		return
+	}
+	for _, segment := range segments {
+		if _, ok := segment["words"].([]interface{}); ok {
+			return
+		}
+	}
+
+	idx := 0
+	for _, item := range words {
+		word, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		start, _ := word["start"].(float64)
+		for idx < len(segments)-1 {
+			end, _ := segments[idx]["end"].(float64)
+			if start < end {
+				break
+			}
+			idx++
+		}
+		existing, _ := segments[idx]["words"].([]interface{})
+		segments[idx]["words"] = append(existing, word)
+	}
+}
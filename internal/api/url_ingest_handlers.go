@@ -0,0 +1,304 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/ingest"
+	"scriberr/internal/models"
+	"scriberr/internal/storage"
+	"scriberr/internal/transcription"
+	"scriberr/pkg/logger"
+)
+
+const (
+	// urlIngestTimeout bounds the download and conversion of remote media
+	urlIngestTimeout = 2 * time.Hour
+	// downloadProgressInterval throttles download progress writes to the job row
+	downloadProgressInterval = time.Second
+)
+
+// URLJobRequest creates a transcription job from a remote media URL
+type URLJobRequest struct {
+	URL         string            `json:"url" binding:"required"`
+	Title       *string           `json:"title,omitempty"`                           // Defaults to the media's title
+	Description *string           `json:"description,omitempty"`                     // Defaults to the media's description
+	Metadata    map[string]string `json:"metadata,omitempty"`                        // Caller defined key/value pairs
+	ProfileID   string            `json:"profile_id,omitempty"`                      // Transcription profile to take the parameters from
+	Parameters  json.RawMessage   `json:"parameters,omitempty" swaggertype:"object"` // Overrides for the default models.WhisperXParams, or the profile's
+}
+
+// CreateJobFromURL queues transcription of a YouTube video, podcast episode or
+// other page supported by yt-dlp
+// @Summary Transcribe media from a URL
+// @Description Downloads the best audio of a URL with yt-dlp, converts it and queues it for transcription. Parameters come from profile_id if given, with parameters as overrides. Title and description default to the media's own. The job is returned immediately with status "downloading"; download progress is reported in the job's progress field. Failed downloads fail the job with the downloader's error output.
+// @Tags transcription
+// @Accept json
+// @Produce json
+// @Param request body URLJobRequest true "Media URL and transcription parameters"
+// @Success 202 {object} models.TranscriptionJob
+// @Failure 400 {object} apierror.Envelope
+// @Failure 403 {object} apierror.Envelope "Host is not allowed"
+// @Failure 422 {object} apierror.Envelope "Title, description or metadata is too long; details.field names it"
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/transcription/from-url [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) CreateJobFromURL(c *gin.Context) {
+	var req URLJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
+		return
+	}
+	source, ok := h.checkMediaURL(c, req.URL)
+	if !ok {
+		return
+	}
+	if err := validateJobDetails(req.Title, req.Description, req.Metadata); err != nil {
+		abortFieldError(c, err)
+		return
+	}
+
+	params := h.defaultTranscriptionParams()
+	if req.ProfileID != "" {
+		if params, ok = profileParameters(c, req.ProfileID, req.Parameters); !ok {
+			return
+		}
+	} else if len(req.Parameters) > 0 {
+		if err := json.Unmarshal(req.Parameters, &params); err != nil {
+			apierror.Abort(c, apierror.Validation("Invalid parameters: "+err.Error()))
+			return
+		}
+	}
+	if err := params.ValidateDiarization(); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+	if !validJobOptions(c, params) {
+		return
+	}
+	if params.IsMultiTrackEnabled {
+		apierror.Abort(c, apierror.BadRequest("Multi-track transcription cannot be used with downloaded audio"))
+		return
+	}
+
+	if err := os.MkdirAll(h.config.UploadDir, 0755); err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to create upload directory"))
+		return
+	}
+
+	sourceURL := source.String()
+	job := models.TranscriptionJob{
+		ID:          uuid.New().String(),
+		Status:      models.StatusDownloading,
+		Diarization: params.Diarize,
+		Parameters:  params,
+		SourceURL:   &sourceURL,
+		Metadata:    req.Metadata,
+	}
+	if req.Title != nil && *req.Title != "" {
+		job.Title = req.Title
+	}
+	if req.Description != nil && *req.Description != "" {
+		job.Description = req.Description
+	}
+	if userID, ok := currentUserID(c); ok {
+		job.UserID = &userID
+	}
+	if err := database.DB.Create(&job).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to create job"))
+		return
+	}
+
+	go h.ingestURLJob(job.ID, sourceURL, job.Title == nil, job.Description == nil)
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// checkMediaURL validates a remote media URL against the configured host
+// policy, writing an error response and returning false if it is rejected
+func (h *Handler) checkMediaURL(c *gin.Context, rawURL string) (*url.URL, bool) {
+	policy := ingest.HostPolicy{Allowed: h.config.URLAllowedHosts, Denied: h.config.URLDeniedHosts}
+	source, err := policy.CheckURL(rawURL)
+	if err != nil {
+		if errors.Is(err, ingest.ErrHostNotAllowed) {
+			apierror.Abort(c, apierror.Forbidden(err.Error()))
+			return nil, false
+		}
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return nil, false
+	}
+	return source, true
+}
+
+// ingestURLJob downloads a URL job's media and queues it for transcription,
+// failing the job with the cause if anything goes wrong
+func (h *Handler) ingestURLJob(jobID, sourceURL string, useSourceTitle, useSourceDescription bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), urlIngestTimeout)
+	defer cancel()
+
+	started := time.Now()
+	if err := h.downloadURLJob(ctx, jobID, sourceURL, useSourceTitle, useSourceDescription); err != nil {
+		logger.Error("Failed to download media", "job_id", jobID, "url", sourceURL, "error", err)
+		message := err.Error()
+		if err := database.DB.Model(&models.TranscriptionJob{}).
+			Where("id = ? AND status = ?", jobID, models.StatusDownloading).
+			Updates(map[string]interface{}{
+				"status":        models.StatusFailed,
+				"error_message": message,
+				"progress":      0,
+			}).Error; err != nil {
+			logger.Error("Failed to mark download as failed", "job_id", jobID, "error", err)
+		}
+		return
+	}
+
+	if err := database.RecordPhaseTiming(jobID, models.TimelineUpload, time.Since(started)); err != nil {
+		logger.Warn("Failed to record download time", "job_id", jobID, "error", err)
+	}
+	h.queueWaveform(jobID)
+	if err := h.taskQueue.EnqueueJob(jobID); err != nil {
+		// The queue's scanner picks up pending jobs it could not accept now
+		logger.Warn("Failed to enqueue downloaded job", "job_id", jobID, "error", err)
+	}
+}
+
+// errJobRemoved is returned when a job is deleted while its media downloads
+var errJobRemoved = errors.New("job was removed during download")
+
+// downloadURLJob fetches, converts and validates a URL job's audio and moves
+// the job to pending
+func (h *Handler) downloadURLJob(ctx context.Context, jobID, sourceURL string, useSourceTitle, useSourceDescription bool) error {
+	info, err := h.downloader.Info(ctx, sourceURL)
+	if err != nil {
+		return fmt.Errorf("failed to read media information: %w", err)
+	}
+	metadata, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	updates := map[string]interface{}{"source_metadata": string(metadata)}
+	if useSourceTitle && info.Title != "" {
+		updates["title"] = info.Title
+	}
+	if useSourceDescription && info.Description != "" {
+		updates["description"] = models.TruncateJobDescription(info.Description)
+	}
+	if err := database.DB.Model(&models.TranscriptionJob{}).Where("id = ?", jobID).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	progress := &downloadProgress{jobID: jobID}
+	downloaded, err := h.downloader.Download(ctx, sourceURL, filepath.Join(h.config.UploadDir, jobID+".source"), progress.Report)
+	if err != nil {
+		return fmt.Errorf("failed to download media: %w", err)
+	}
+	defer os.Remove(downloaded)
+
+	audioPath := filepath.Join(h.config.UploadDir, jobID+".m4a")
+	keepAudio := false
+	defer func() {
+		if !keepAudio {
+			os.Remove(audioPath)
+		}
+	}()
+	if err := h.downloader.Normalize(ctx, downloaded, audioPath); err != nil {
+		return fmt.Errorf("failed to convert media: %w", err)
+	}
+	if err := transcription.ValidateAudioFile(ctx, audioPath); err != nil && !errors.Is(err, transcription.ErrFFprobeUnavailable) {
+		return err
+	}
+
+	var job models.TranscriptionJob
+	if err := database.DB.Where("id = ?", jobID).First(&job).Error; err != nil {
+		return errJobRemoved
+	}
+	job.AudioPath = audioPath
+	if duration, err := transcription.DetectAudioDuration(ctx, audioPath); err == nil {
+		seconds := duration.Seconds()
+		job.DurationSeconds = &seconds
+	}
+	if job.UserID != nil {
+		exceeded, err := checkJobQuota(ctx, *job.UserID, &job)
+		if err != nil {
+			return fmt.Errorf("failed to check quota: %w", err)
+		}
+		if exceeded != nil {
+			return fmt.Errorf("quota exceeded: %d of %d %s seconds used", exceeded.UsedSeconds, exceeded.MaxSeconds, exceeded.Period)
+		}
+	}
+
+	job.FileSize = storage.Size(ctx, audioPath)
+	location, err := storage.Store(ctx, audioPath, storage.JobKey(jobID, audioPath))
+	if err != nil {
+		return fmt.Errorf("failed to store audio: %w", err)
+	}
+	job.AudioPath = location
+	stored := false
+	defer func() {
+		if !stored && location != audioPath {
+			storage.Remove(ctx, location)
+		}
+	}()
+
+	result := database.DB.Model(&models.TranscriptionJob{}).
+		Where("id = ? AND status = ?", jobID, models.StatusDownloading).
+		Updates(map[string]interface{}{
+			"audio_path":       job.AudioPath,
+			"duration_seconds": job.DurationSeconds,
+			"file_size":        job.FileSize,
+			"status":           models.StatusPending,
+			"progress":         0,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errJobRemoved
+	}
+	stored = true
+	keepAudio = location == audioPath // The upload directory copy is no longer needed
+	return nil
+}
+
+// downloadProgress records download progress in the job's progress field, the
+// same field transcription progress is reported in
+type downloadProgress struct {
+	jobID string
+
+	mu        sync.Mutex
+	fraction  float64
+	lastWrite time.Time
+}
+
+// Report stores the downloaded fraction, at most once per downloadProgressInterval
+func (p *downloadProgress) Report(fraction float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if fraction <= p.fraction {
+		return
+	}
+	p.fraction = fraction
+	if fraction < 1 && time.Since(p.lastWrite) < downloadProgressInterval {
+		return
+	}
+	p.lastWrite = time.Now()
+	if err := database.DB.Model(&models.TranscriptionJob{}).
+		Where("id = ? AND status = ?", p.jobID, models.StatusDownloading).
+		Update("progress", fraction).Error; err != nil {
+		logger.Warn("Failed to update download progress", "job_id", p.jobID, "error", err)
+	}
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/auth"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+)
+
+const maxDisplayNameLength = 100
+
+// UserProfileResponse describes the authenticated user
+type UserProfileResponse struct {
+	ID          uint      `json:"id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// UpdateUserProfileRequest changes the display name and/or password. Omitted
+// fields are left untouched.
+type UpdateUserProfileRequest struct {
+	DisplayName     *string `json:"display_name,omitempty"`
+	CurrentPassword *string `json:"current_password,omitempty"`
+	NewPassword     *string `json:"new_password,omitempty"`
+}
+
+func newUserProfileResponse(user *models.User) UserProfileResponse {
+	return UserProfileResponse{
+		ID:          user.ID,
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Role:        user.Role,
+		CreatedAt:   user.CreatedAt,
+	}
+}
+
+// GetCurrentUser returns the profile of the authenticated user
+// @Summary Get current user
+// @Description Get the authenticated user's profile
+// @Tags user
+// @Produce json
+// @Success 200 {object} UserProfileResponse
+// @Failure 401 {object} apierror.Envelope
+// @Security BearerAuth
+// @Router /api/v1/users/me [get]
+func (h *Handler) GetCurrentUser(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierror.Abort(c, apierror.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		apierror.Abort(c, apierror.Unauthorized("User not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, newUserProfileResponse(&user))
+}
+
+// UpdateCurrentUser changes the authenticated user's display name and/or password
+// @Summary Update current user
+// @Description Change the display name and/or password of the authenticated user. Changing the password requires current_password.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body UpdateUserProfileRequest true "Profile changes"
+// @Success 200 {object} UserProfileResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 401 {object} apierror.Envelope
+// @Security BearerAuth
+// @Router /api/v1/users/me [patch]
+func (h *Handler) UpdateCurrentUser(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		apierror.Abort(c, apierror.Unauthorized("User not authenticated"))
+		return
+	}
+
+	var req UpdateUserProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid request: "+err.Error()))
+		return
+	}
+	if req.DisplayName == nil && req.NewPassword == nil {
+		apierror.Abort(c, apierror.BadRequest("Nothing to update"))
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		apierror.Abort(c, apierror.Unauthorized("User not found"))
+		return
+	}
+
+	updates := map[string]interface{}{}
+
+	if req.DisplayName != nil {
+		displayName := strings.TrimSpace(*req.DisplayName)
+		if displayName == "" {
+			apierror.Abort(c, apierror.Validation("Display name cannot be empty"))
+			return
+		}
+		if len(displayName) > maxDisplayNameLength {
+			apierror.Abort(c, apierror.Validation("Display name is too long"))
+			return
+		}
+		updates["display_name"] = displayName
+	}
+
+	if req.NewPassword != nil {
+		if !h.allowPasswordAttempt(c, "change", fmt.Sprint(user.ID)) {
+			return
+		}
+		if req.CurrentPassword == nil || *req.CurrentPassword == "" {
+			apierror.Abort(c, apierror.Validation("Current password is required to change the password"))
+			return
+		}
+		if !auth.CheckPassword(*req.CurrentPassword, user.Password) {
+			logger.AuthEvent("password_change", user.Username, c.ClientIP(), false, logger.String("reason", "invalid_current_password"), "user_id", user.ID)
+			apierror.Abort(c, apierror.BadRequest("Current password is incorrect"))
+			return
+		}
+		if err := auth.ValidatePassword(*req.NewPassword, user.Username); err != nil {
+			apierror.Abort(c, apierror.Validation(err.Error()))
+			return
+		}
+	}
+
+	if len(updates) > 0 {
+		if err := database.DB.Model(&user).Updates(updates).Error; err != nil {
+			apierror.Abort(c, apierror.Internal("Failed to update user"))
+			return
+		}
+	}
+
+	if req.NewPassword != nil {
+		if err := auth.SetPassword(c.Request.Context(), user.ID, *req.NewPassword); err != nil {
+			apierror.Abort(c, apierror.Internal("Failed to update password"))
+			return
+		}
+		// Other sessions end; this one continues through a new refresh token
+		if err := h.issueRefreshToken(c, user.ID); err != nil {
+			apierror.Abort(c, apierror.Internal("Failed to create session"))
+			return
+		}
+		logger.AuthEvent("password_change", user.Username, c.ClientIP(), true, "user_id", user.ID)
+	}
+
+	c.JSON(http.StatusOK, newUserProfileResponse(&user))
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"scriberr/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIVersion is the current version of the API. Unversioned /api paths are
+// deprecated aliases of its routes.
+const APIVersion = "v1"
+
+// apiVersionKey holds the API version a request was routed to
+const apiVersionKey = "api_version"
+
+// Unversioned /api paths are deprecated since unversionedDeprecated and will
+// stop working after unversionedSunset
+var (
+	unversionedDeprecated = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+	unversionedSunset     = time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+)
+
+// apiVersions lists the served API versions with the function registering
+// the routes of each. A new version registers its own route table, pointing
+// at the handlers of an older one wherever the behavior is unchanged.
+var apiVersions = []struct {
+	name   string
+	routes func(*gin.RouterGroup, *Handler, *auth.AuthService)
+}{
+	{"v1", registerV1Routes},
+}
+
+var versionedPath = regexp.MustCompile(`^/api/(v[0-9]+)(/|$)`)
+
+// mountAPIVersions registers every API version under /api/<version>, and the
+// routes of APIVersion under /api as deprecated aliases for older clients
+func mountAPIVersions(router *gin.Engine, handler *Handler, authService *auth.AuthService) {
+	for _, version := range apiVersions {
+		version.routes(router.Group("/api/"+version.name), handler, authService)
+		if version.name == APIVersion {
+			version.routes(router.Group("/api", deprecatedAlias()), handler, authService)
+		}
+	}
+}
+
+// negotiateVersion returns the API version a path addresses: the version in
+// it, or APIVersion for unversioned /api paths. ok is false outside the API.
+func negotiateVersion(path string) (version string, ok bool) {
+	if match := versionedPath.FindStringSubmatch(path); match != nil {
+		return match[1], true
+	}
+	if path == "/api" || strings.HasPrefix(path, "/api/") {
+		return APIVersion, true
+	}
+	return "", false
+}
+
+// RequestAPIVersion returns the API version a request was routed to, or ""
+// outside the API
+func RequestAPIVersion(c *gin.Context) string {
+	return c.GetString(apiVersionKey)
+}
+
+// versionMiddleware reports the API version of API requests in the
+// API-Version header and adds it to JSON error envelopes as api_version
+func versionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version, ok := negotiateVersion(c.Request.URL.Path)
+		if !ok {
+			c.Next()
+			return
+		}
+		c.Set(apiVersionKey, version)
+		c.Header("API-Version", version)
+
+		writer := &versionedErrorWriter{ResponseWriter: c.Writer, version: version}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+		writer.flush()
+	}
+}
+
+// deprecatedAlias marks responses to unversioned /api paths as deprecated,
+// pointing at the versioned path that replaces them
+func deprecatedAlias() gin.HandlerFunc {
+	deprecation := fmt.Sprintf("@%d", unversionedDeprecated.Unix())
+	sunset := unversionedSunset.Format(http.TimeFormat)
+	return func(c *gin.Context) {
+		successor := "/api/" + APIVersion + strings.TrimPrefix(c.Request.URL.Path, "/api")
+		c.Header("Deprecation", deprecation)
+		c.Header("Sunset", sunset)
+		c.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+		c.Next()
+	}
+}
+
+// versionedErrorWriter holds back JSON error responses so the API version
+// can be added to their envelope. Other responses pass straight through.
+type versionedErrorWriter struct {
+	gin.ResponseWriter
+	version string
+	body    bytes.Buffer
+}
+
+func (w *versionedErrorWriter) holdsBack() bool {
+	return w.Status() >= http.StatusBadRequest && strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+}
+
+func (w *versionedErrorWriter) Write(data []byte) (int, error) {
+	if w.holdsBack() {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *versionedErrorWriter) WriteString(s string) (int, error) {
+	if w.holdsBack() {
+		return w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// flush writes a held back error response, with api_version added to
+// envelopes that have an error field
+func (w *versionedErrorWriter) flush() {
+	if w.body.Len() == 0 {
+		return
+	}
+	data := w.body.Bytes()
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(data, &envelope); err == nil && envelope["error"] != nil && envelope[apiVersionKey] == nil {
+		envelope[apiVersionKey], _ = json.Marshal(w.version)
+		if versioned, err := json.Marshal(envelope); err == nil {
+			data = versioned
+		}
+	}
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.Write(data)
+}
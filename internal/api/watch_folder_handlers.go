@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+)
+
+// CreateWatchFolderRequest adds a watched folder
+type CreateWatchFolderRequest struct {
+	Path         string  `json:"path" binding:"required"`
+	ProfileID    *string `json:"profile_id,omitempty"`
+	AfterProcess string  `json:"after_process,omitempty"` // "marker" (default) or "move"
+	Recursive    *bool   `json:"recursive,omitempty"`     // Defaults to true
+	UsePolling   bool    `json:"use_polling"`
+
+	// Retention for jobs from the folder; unset follows the global policy
+	// and 0 keeps them forever
+	AudioRetentionDays *int `json:"audio_retention_days,omitempty"`
+	JobRetentionDays   *int `json:"job_retention_days,omitempty"`
+
+	// Tags ("key:value") and job folder applied to every job from the folder
+	Tags        []string `json:"tags,omitempty"`
+	JobFolderID *uint    `json:"job_folder_id,omitempty"`
+}
+
+// StartWatchFolders starts watching the configured and stored watch folders
+func (h *Handler) StartWatchFolders(ctx context.Context) error {
+	return h.watchFolders.Start(ctx)
+}
+
+// ListWatchFolders reports every watch folder and its watcher status
+// @Summary List watch folders
+// @Description List the folders from WATCH_DIRS and those added through the API, with watcher status and the last scan error
+// @Tags watch-folders
+// @Produce json
+// @Success 200 {object} map[string][]watchfolder.FolderStatus
+// @Router /api/v1/watch-folders [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) ListWatchFolders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"folders": h.watchFolders.Status()})
+}
+
+// CreateWatchFolder adds a folder whose audio files are transcribed automatically
+// @Summary Add a watch folder
+// @Description Watch a server directory and transcribe audio files placed in it, optionally with a transcription profile. Jobs get the folder's tags and are filed in its job folder.
+// @Tags watch-folders
+// @Accept json
+// @Produce json
+// @Param request body CreateWatchFolderRequest true "Watch folder"
+// @Success 201 {object} models.WatchFolder
+// @Failure 400 {object} apierror.Envelope
+// @Failure 409 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/watch-folders [post]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) CreateWatchFolder(c *gin.Context) {
+	var req CreateWatchFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Abort(c, apierror.Validation(err.Error()))
+		return
+	}
+
+	if !filepath.IsAbs(req.Path) {
+		apierror.Abort(c, apierror.Validation("Path must be absolute"))
+		return
+	}
+	path := filepath.Clean(req.Path)
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		apierror.Abort(c, apierror.BadRequest("Path is not a directory"))
+		return
+	}
+
+	folder := models.WatchFolder{
+		Path:         path,
+		AfterProcess: req.AfterProcess,
+		Recursive:    true,
+		UsePolling:   req.UsePolling,
+		Enabled:      true,
+	}
+	if folder.AfterProcess == "" {
+		folder.AfterProcess = models.WatchAfterMarker
+	}
+	if !models.IsValidWatchAfterProcess(folder.AfterProcess) {
+		apierror.Abort(c, apierror.Validation("after_process must be marker or move"))
+		return
+	}
+	if req.Recursive != nil {
+		folder.Recursive = *req.Recursive
+	}
+	if !validRetentionDays(c, req.AudioRetentionDays, req.JobRetentionDays) {
+		return
+	}
+	folder.AudioRetentionDays = req.AudioRetentionDays
+	folder.JobRetentionDays = req.JobRetentionDays
+	if req.ProfileID != nil && *req.ProfileID != "" {
+		var profile models.TranscriptionProfile
+		if err := database.DB.Where("id = ?", *req.ProfileID).First(&profile).Error; err != nil {
+			apierror.Abort(c, apierror.BadRequest("Profile not found"))
+			return
+		}
+		folder.ProfileID = &profile.ID
+	}
+	for _, tag := range req.Tags {
+		if _, _, err := models.ParseTag(tag); err != nil {
+			apierror.Abort(c, apierror.Validation(err.Error()))
+			return
+		}
+	}
+	folder.Tags = req.Tags
+	if req.JobFolderID != nil {
+		var jobFolder models.JobFolder
+		if err := database.DB.First(&jobFolder, *req.JobFolderID).Error; err != nil {
+			apierror.Abort(c, apierror.BadRequest("Job folder not found"))
+			return
+		}
+		folder.JobFolderID = &jobFolder.ID
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.WatchFolder{}).Where("path = ?", path).Count(&count).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to create watch folder"))
+		return
+	}
+	if count > 0 {
+		apierror.Abort(c, apierror.Conflict("Folder is already watched"))
+		return
+	}
+	if err := database.DB.Create(&folder).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to create watch folder"))
+		return
+	}
+
+	if err := h.watchFolders.Reload(); err != nil {
+		logger.Error("Failed to reload watch folders", "error", err)
+	}
+	c.JSON(http.StatusCreated, folder)
+}
+
+// DeleteWatchFolder stops watching a folder added through the API
+// @Summary Remove a watch folder
+// @Description Stop watching a folder. Files already picked up keep their jobs.
+// @Tags watch-folders
+// @Produce json
+// @Param id path int true "Watch folder ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 500 {object} apierror.Envelope
+// @Router /api/v1/watch-folders/{id} [delete]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) DeleteWatchFolder(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		apierror.Abort(c, apierror.Validation("Invalid watch folder ID"))
+		return
+	}
+
+	var folder models.WatchFolder
+	if err := database.DB.First(&folder, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.NotFound("Watch folder not found"))
+			return
+		}
+		apierror.Abort(c, apierror.Internal("Failed to delete watch folder"))
+		return
+	}
+	if err := database.DB.Delete(&folder).Error; err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to delete watch folder"))
+		return
+	}
+
+	if err := h.watchFolders.Reload(); err != nil {
+		logger.Error("Failed to reload watch folders", "error", err)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Watch folder deleted successfully"})
+}
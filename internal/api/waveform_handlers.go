@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/internal/storage"
+	"scriberr/internal/waveform"
+	"scriberr/pkg/logger"
+)
+
+// Waveform sample count bounds
+const (
+	defaultWaveformSamples = 2000
+	maxWaveformSamples     = 100000
+)
+
+// waveformRetrySeconds is the Retry-After sent while peaks are generated
+const waveformRetrySeconds = 2
+
+// WaveformPendingResponse is returned while a job's peaks are generated
+type WaveformPendingResponse struct {
+	Status string `json:"status"` // Always "generating"
+}
+
+// GetWaveform returns the peaks the audio player draws for a job
+// @Summary Get audio waveform peaks
+// @Description Returns the minimum and maximum amplitude of each of up to `samples` buckets of the job's audio, in the audiowaveform JSON format or, with format=dat, its binary .dat format. Amplitudes are 8-bit. Peaks are generated once, in the background while no transcription is running, and regenerated when the audio changes, as when it is re-normalized; until they are ready the response is 202 with a Retry-After header.
+// @Tags transcription
+// @Produce json,octet-stream
+// @Param id path string true "Job ID"
+// @Param samples query int false "Number of buckets, at most 100000. Shorter audio returns fewer." default(2000)
+// @Param format query string false "json or dat" default(json)
+// @Success 200 {object} waveform.JSONPeaks
+// @Success 202 {object} WaveformPendingResponse
+// @Failure 400 {object} apierror.Envelope
+// @Failure 404 {object} apierror.Envelope
+// @Failure 410 {object} apierror.Envelope "Audio was removed by retention cleanup"
+// @Failure 422 {object} apierror.Envelope "The audio could not be decoded"
+// @Failure 503 {object} apierror.Envelope "ffmpeg is not installed"
+// @Router /api/v1/transcription/{id}/waveform [get]
+// @Security ApiKeyAuth
+// @Security BearerAuth
+func (h *Handler) GetWaveform(c *gin.Context) {
+	samples := defaultWaveformSamples
+	if value := c.Query("samples"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxWaveformSamples {
+			apierror.Abort(c, apierror.Validation("samples must be a whole number from 1 to 100000"))
+			return
+		}
+		samples = n
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "dat" {
+		apierror.Abort(c, apierror.Validation("Invalid format. Must be one of json, dat"))
+		return
+	}
+
+	var job models.TranscriptionJob
+	if err := database.DB.Scopes(ownedJobs(c)).Where("id = ?", c.Param("id")).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Abort(c, apierror.JobNotFound())
+			return
+		}
+		apierror.Abort(c, apierror.Internal("Failed to get job"))
+		return
+	}
+	audioPath := waveformAudioPath(&job)
+
+	var cached models.JobWaveform
+	err := database.DB.Where("job_id = ?", job.ID).First(&cached).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Abort(c, apierror.Internal("Failed to get waveform"))
+		return
+	}
+	found := err == nil
+
+	// Peaks outlive the audio they were generated from, so they can still
+	// be drawn after retention cleanup
+	if job.AudioDeletedAt != nil && (!found || cached.Error != nil) {
+		apierror.Abort(c, apierror.Gone("Audio was removed by retention cleanup"))
+		return
+	}
+	if !found || (!cached.Fresh(audioPath) && job.AudioDeletedAt == nil) {
+		if audioPath == "" {
+			apierror.Abort(c, apierror.NotFound("Audio file path not found"))
+			return
+		}
+		if !waveform.Available() {
+			apierror.Abort(c, apierror.EngineUnavailable("Generating waveforms needs ffmpeg"))
+			return
+		}
+		h.queueWaveform(job.ID)
+		c.Header("Retry-After", strconv.Itoa(waveformRetrySeconds))
+		c.JSON(http.StatusAccepted, WaveformPendingResponse{Status: "generating"})
+		return
+	}
+	if cached.Error != nil {
+		apierror.Abort(c, apierror.New(http.StatusUnprocessableEntity, apierror.CodeTranscodeFailed, "Audio could not be decoded").WithDetails(map[string]any{"job_id": job.ID, "reason": *cached.Error}))
+		return
+	}
+
+	peaks, err := cachedPeaks(&cached, samples)
+	if err != nil {
+		logger.Error("Failed to decode cached waveform", "job_id", job.ID, "error", err)
+		apierror.Abort(c, apierror.Internal("Failed to read waveform"))
+		return
+	}
+	peaks = peaks.Resample(samples)
+	if format == "dat" {
+		data, err := peaks.MarshalBinary()
+		if err != nil {
+			apierror.Abort(c, apierror.Internal("Failed to encode waveform"))
+			return
+		}
+		c.Data(http.StatusOK, "application/octet-stream", data)
+		return
+	}
+	c.JSON(http.StatusOK, peaks.JSON())
+}
+
+// cachedPeaks decodes the coarsest cached zoom level with at least samples
+// buckets, or the detailed level when none has
+func cachedPeaks(cached *models.JobWaveform, samples int) (waveform.Peaks, error) {
+	var overview waveform.Peaks
+	if err := overview.UnmarshalBinary(cached.Overview); err != nil {
+		return overview, err
+	}
+	if overview.Len() >= samples {
+		return overview, nil
+	}
+	var detail waveform.Peaks
+	err := detail.UnmarshalBinary(cached.Detail)
+	return detail, err
+}
+
+// waveformAudioPath returns the audio a job's peaks are drawn from, the
+// merged audio of multi-track jobs when it exists
+func waveformAudioPath(job *models.TranscriptionJob) string {
+	if job.IsMultiTrack && job.MergedAudioPath != nil && *job.MergedAudioPath != "" {
+		if _, err := os.Stat(*job.MergedAudioPath); err == nil {
+			return *job.MergedAudioPath
+		}
+	}
+	return job.AudioPath
+}
+
+// queueWaveform generates a job's peaks in the background when ffmpeg is
+// installed. Without a task queue they are generated at once.
+func (h *Handler) queueWaveform(jobID string) {
+	if !waveform.Available() {
+		return
+	}
+	if h.taskQueue == nil {
+		go generateWaveform(context.Background(), jobID)
+		return
+	}
+	h.taskQueue.SubmitBackground("waveform:"+jobID, func(ctx context.Context) {
+		generateWaveform(ctx, jobID)
+	})
+}
+
+// generateWaveform computes and caches a job's peaks, unless fresh ones are
+// already cached. Audio ffmpeg cannot decode is cached as an error so it is
+// not retried until the audio changes.
+func generateWaveform(ctx context.Context, jobID string) {
+	var job models.TranscriptionJob
+	if err := database.DB.Where("id = ?", jobID).First(&job).Error; err != nil {
+		return
+	}
+	audioPath := waveformAudioPath(&job)
+	if audioPath == "" || job.AudioDeletedAt != nil {
+		return
+	}
+	var cached models.JobWaveform
+	if err := database.DB.Where("job_id = ?", jobID).First(&cached).Error; err == nil && cached.Fresh(audioPath) {
+		return
+	}
+
+	localPath, release, err := storage.Materialize(ctx, audioPath, os.TempDir())
+	if err != nil {
+		logger.Error("Failed to read audio for waveform", "job_id", jobID, "error", err)
+		return
+	}
+	defer release()
+
+	entry := models.JobWaveform{JobID: jobID, AudioPath: audioPath}
+	detail, overview, err := waveform.Generate(ctx, localPath)
+	switch {
+	case ctx.Err() != nil:
+		return
+	case errors.Is(err, waveform.ErrFFmpegUnavailable):
+		logger.Warn("Skipping waveform generation, ffmpeg is not installed", "job_id", jobID)
+		return
+	case err != nil:
+		logger.Warn("Failed to generate waveform", "job_id", jobID, "error", err)
+		message := err.Error()
+		entry.Error = &message
+	default:
+		if entry.Detail, err = detail.MarshalBinary(); err == nil {
+			entry.Overview, err = overview.MarshalBinary()
+		}
+		if err != nil {
+			logger.Error("Failed to encode waveform", "job_id", jobID, "error", err)
+			return
+		}
+	}
+
+	// The job may have been deleted while its audio was decoded
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").Where("id = ?", jobID).First(&models.TranscriptionJob{}).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&entry).Error
+	})
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("Failed to save waveform", "job_id", jobID, "error", err)
+	}
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyPrefix marks a bearer token as an API key rather than a JWT
+const APIKeyPrefix = "sk_"
+
+// apiKeyPreviewLength is how much of the plaintext key is kept for display
+const apiKeyPreviewLength = 12
+
+var (
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	ErrAPIKeyExpired = errors.New("API key has expired")
+)
+
+// IsAPIKey reports whether a bearer token looks like an API key
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// HashAPIKey returns the SHA-256 hex digest under which an API key is stored
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateAPIKey creates an API key owned by userID and returns the plaintext key.
+// Only the hash is stored, so the plaintext cannot be recovered later. A
+// non-positive ttl creates a key that never expires.
+func (as *AuthService) GenerateAPIKey(ctx context.Context, userID uint, label string, scopes []string, ttl time.Duration) (string, error) {
+	_, plaintext, err := as.CreateAPIKey(ctx, userID, label, "", scopes, ttl)
+	return plaintext, err
+}
+
+// CreateAPIKey is like GenerateAPIKey but also returns the stored record
+func (as *AuthService) CreateAPIKey(ctx context.Context, userID uint, label, description string, scopes []string, ttl time.Duration) (*models.APIKey, string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	plaintext := APIKeyPrefix + hex.EncodeToString(raw)
+
+	apiKey := &models.APIKey{
+		UserID:    &userID,
+		KeyHash:   HashAPIKey(plaintext),
+		KeyPrefix: plaintext[:apiKeyPreviewLength],
+		Name:      label,
+		IsActive:  true,
+	}
+	apiKey.SetScopes(scopes)
+	if description != "" {
+		apiKey.Description = &description
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	if err := database.DB.WithContext(ctx).Create(apiKey).Error; err != nil {
+		return nil, "", err
+	}
+	return apiKey, plaintext, nil
+}
+
+// AuthenticateAPIKey looks up an active, unexpired API key by its plaintext value
+// and records its use
+func (as *AuthService) AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKey, error) {
+	apiKey, err := as.LookupAPIKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	apiKey.LastUsed = &now
+	database.DB.WithContext(ctx).Model(apiKey).UpdateColumn("last_used", now)
+
+	return apiKey, nil
+}
+
+// LookupAPIKey is like AuthenticateAPIKey but does not record the key's use
+func (as *AuthService) LookupAPIKey(ctx context.Context, key string) (*models.APIKey, error) {
+	if key == "" {
+		return nil, ErrInvalidAPIKey
+	}
+
+	var apiKey models.APIKey
+	err := database.DB.WithContext(ctx).Where("key_hash = ? AND is_active = ?", HashAPIKey(key), true).First(&apiKey).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	if apiKey.IsExpired() {
+		return nil, ErrAPIKeyExpired
+	}
+	return &apiKey, nil
+}
@@ -1,31 +1,49 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"time"
 
+	"scriberr/internal/config"
+	"scriberr/internal/database"
 	"scriberr/internal/models"
+	"scriberr/pkg/logger"
 
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // AuthService handles authentication operations
 type AuthService struct {
 	jwtSecret []byte
+	accessTTL time.Duration
 }
 
-// NewAuthService creates a new authentication service
-func NewAuthService(jwtSecret string) *AuthService {
+// NewAuthService creates a new authentication service. A non-positive
+// accessTTL falls back to config.DefaultAccessTokenTTL.
+func NewAuthService(jwtSecret string, accessTTL time.Duration) *AuthService {
+	if accessTTL <= 0 {
+		accessTTL = config.DefaultAccessTokenTTL
+	}
 	return &AuthService{
 		jwtSecret: []byte(jwtSecret),
+		accessTTL: accessTTL,
 	}
 }
 
+// AccessTokenTTL returns the lifetime of issued access tokens
+func (as *AuthService) AccessTokenTTL() time.Duration {
+	return as.accessTTL
+}
+
 // Claims represents JWT claims
 type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
+	Role     string `json:"role"`
 	jwt.RegisteredClaims
 }
 
@@ -34,8 +52,10 @@ func (as *AuthService) GenerateToken(user *models.User) (string, error) {
 	claims := &Claims{
 		UserID:   user.ID,
 		Username: user.Username,
+		Role:     user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(as.accessTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -61,14 +81,101 @@ func (as *AuthService) ValidateToken(tokenString string) (*Claims, error) {
 	return nil, errors.New("invalid token")
 }
 
-// HashPassword hashes a password using bcrypt
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
+// RevokeToken adds a token's jti to the blocklist. Since the original expiry is
+// unknown here, the entry is kept for a full access token lifetime.
+func (as *AuthService) RevokeToken(ctx context.Context, jti string) error {
+	return as.revoke(ctx, jti, 0, time.Now().Add(as.accessTTL))
+}
+
+// RevokeClaims adds the token described by claims to the blocklist
+func (as *AuthService) RevokeClaims(ctx context.Context, claims *Claims) error {
+	expiresAt := time.Now().Add(as.accessTTL)
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	return as.revoke(ctx, claims.ID, claims.UserID, expiresAt)
+}
+
+func (as *AuthService) revoke(ctx context.Context, jti string, userID uint, expiresAt time.Time) error {
+	if jti == "" {
+		return errors.New("token has no jti claim")
+	}
+	entry := models.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		RevokedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+	return database.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&entry).Error
+}
+
+// IsTokenRevoked reports whether the given jti is on the blocklist
+func (as *AuthService) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	if jti == "" {
+		return false, nil
+	}
+	var count int64
+	if err := database.DB.WithContext(ctx).Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ErrAccountDisabled is returned when a disabled or deleted account tries to authenticate
+var ErrAccountDisabled = errors.New("account is disabled")
+
+// ErrSessionExpired is returned for access tokens issued before the user's
+// password last changed
+var ErrSessionExpired = errors.New("session ended by a password change")
+
+// CheckSession returns ErrAccountDisabled unless the token's user is active,
+// and ErrSessionExpired if the password changed after the token was issued.
+// Tokens issued before an account was disabled, deleted or given a new
+// password are rejected through this check.
+func (as *AuthService) CheckSession(ctx context.Context, claims *Claims) error {
+	var user models.User
+	err := database.DB.WithContext(ctx).Unscoped().Select("id", "disabled", "deleted_at", "password_changed_at").First(&user, claims.UserID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrAccountDisabled
+	}
+	if err != nil {
+		return err
+	}
+	if !user.IsActive() {
+		return ErrAccountDisabled
+	}
+	// Issue times have whole seconds; tokens issued in the second of the
+	// change, such as the one handed out with it, stay valid
+	if user.PasswordChangedAt != nil && claims.IssuedAt != nil &&
+		claims.IssuedAt.Time.Before(user.PasswordChangedAt.Truncate(time.Second)) {
+		return ErrSessionExpired
+	}
+	return nil
+}
+
+// CleanupRevokedTokens deletes blocklist entries whose tokens have expired anyway
+func (as *AuthService) CleanupRevokedTokens(ctx context.Context) (int64, error) {
+	result := database.DB.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&models.RevokedToken{})
+	return result.RowsAffected, result.Error
 }
 
-// CheckPassword checks if a password matches its hash
-func CheckPassword(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+// StartRevocationCleanup periodically purges expired blocklist entries until ctx is done
+func (as *AuthService) StartRevocationCleanup(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				removed, err := as.CleanupRevokedTokens(ctx)
+				if err != nil {
+					logger.Warn("Failed to clean up revoked tokens", "error", err)
+				} else if removed > 0 {
+					logger.Debug("Cleaned up revoked tokens", "count", removed)
+				}
+			}
+		}
+	}()
 }
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidHeaderUser is returned for usernames from a proxy header that
+// cannot be an account's username
+var ErrInvalidHeaderUser = errors.New("invalid username in authentication header")
+
+// UpsertHeaderUser returns the user an authenticating reverse proxy named,
+// creating the account on first login. New accounts get the user role,
+// except when they are the first account on the instance, mirroring
+// registration.
+func UpsertHeaderUser(ctx context.Context, username string) (*models.User, error) {
+	if username == "" || len(username) > maxUsernameLength {
+		return nil, ErrInvalidHeaderUser
+	}
+
+	var user models.User
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Soft-deleted accounts keep their username and stay locked out
+		err := tx.Unscoped().Where("username = ?", username).First(&user).Error
+		if err == nil {
+			if !user.IsActive() {
+				return ErrAccountDisabled
+			}
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var userCount int64
+		if err := tx.Unscoped().Model(&models.User{}).Count(&userCount).Error; err != nil {
+			return err
+		}
+		role := models.RoleUser
+		if userCount == 0 {
+			role = models.RoleAdmin
+		}
+
+		user = models.User{Username: username, Role: role}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		logger.Info("Created user from proxy header login", "username", username, "role", role)
+		return nil
+	})
+	if errors.Is(err, ErrAccountDisabled) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert header user: %w", err)
+	}
+	return &user, nil
+}
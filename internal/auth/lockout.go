@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxLockout caps how long repeated failed logins lock a username. Failures
+// are also forgotten once this long has passed since the last one.
+const MaxLockout = 24 * time.Hour
+
+// LockoutPolicy decides when consecutive failed logins lock a username
+type LockoutPolicy struct {
+	Threshold int           // Failures that lock the username
+	Duration  time.Duration // Length of the first lockout; each further failure doubles it
+}
+
+// lockFor returns how long a username with failures consecutive failures is
+// locked out, zero below the threshold
+func (p LockoutPolicy) lockFor(failures int) time.Duration {
+	if p.Threshold <= 0 || failures < p.Threshold {
+		return 0
+	}
+	lock := p.Duration
+	for i := p.Threshold; i < failures && lock < MaxLockout; i++ {
+		lock *= 2
+	}
+	return min(lock, MaxLockout)
+}
+
+// RecordFailure counts a failed login for username from ip at now. It
+// returns the lockout that started, zero if the username is not locked out.
+func (p LockoutPolicy) RecordFailure(ctx context.Context, username, ip string, now time.Time) (time.Duration, error) {
+	var lock time.Duration
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var lockout models.LoginLockout
+		err := tx.Where("username = ?", username).First(&lockout).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if stale(&lockout, now) {
+			lockout = models.LoginLockout{}
+		}
+
+		lockout.Username = username
+		lockout.Failures++
+		lockout.LastFailureAt = now
+		lockout.LastIP = ip
+		if lock = p.lockFor(lockout.Failures); lock > 0 {
+			until := now.Add(lock)
+			lockout.LockedUntil = &until
+		}
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&lockout).Error
+	})
+	return lock, err
+}
+
+// LockedOut returns how long username stays locked out after now, zero if it
+// is not locked out
+func LockedOut(ctx context.Context, username string, now time.Time) (time.Duration, error) {
+	var lockout models.LoginLockout
+	err := database.DB.WithContext(ctx).Where("username = ?", username).First(&lockout).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil || lockout.LockedUntil == nil || !now.Before(*lockout.LockedUntil) {
+		return 0, err
+	}
+	return lockout.LockedUntil.Sub(now), nil
+}
+
+// ClearLockout forgets the failed logins of username, reporting whether there
+// were any
+func ClearLockout(ctx context.Context, username string) (bool, error) {
+	result := database.DB.WithContext(ctx).Where("username = ?", username).Delete(&models.LoginLockout{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListLockouts returns the usernames with recent failed logins, most recent
+// first, dropping the ones whose failures were forgotten
+func ListLockouts(ctx context.Context, now time.Time) ([]models.LoginLockout, error) {
+	db := database.DB.WithContext(ctx)
+	if err := db.Where("(locked_until IS NULL OR locked_until <= ?) AND last_failure_at < ?", now, now.Add(-MaxLockout)).
+		Delete(&models.LoginLockout{}).Error; err != nil {
+		return nil, err
+	}
+	var lockouts []models.LoginLockout
+	err := db.Order("last_failure_at DESC").Find(&lockouts).Error
+	return lockouts, err
+}
+
+// stale reports whether a lockout has expired and its last failure is old
+// enough to be forgotten
+func stale(lockout *models.LoginLockout, now time.Time) bool {
+	if lockout.LockedUntil != nil && now.Before(*lockout.LockedUntil) {
+		return false
+	}
+	return now.Sub(lockout.LastFailureAt) > MaxLockout
+}
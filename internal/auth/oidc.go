@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"scriberr/internal/config"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+// maxUsernameLength mirrors the size of the users.username column
+const maxUsernameLength = 50
+
+var (
+	ErrOIDCDisabled     = errors.New("OIDC login is not configured")
+	ErrOIDCNonceInvalid = errors.New("OIDC nonce mismatch")
+)
+
+// OIDCIdentity is the subset of ID token claims Scriberr uses
+type OIDCIdentity struct {
+	Issuer            string `json:"-"`
+	Subject           string `json:"-"`
+	Email             string `json:"email"`
+	PreferredUsername string `json:"preferred_username"`
+	Name              string `json:"name"`
+}
+
+// OIDCService performs the authorization code flow against an OpenID Connect
+// provider. Provider discovery happens on first use so that an unreachable
+// provider does not prevent the server from starting.
+type OIDCService struct {
+	cfg config.OIDCConfig
+
+	mu       sync.Mutex
+	oauth2   *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewOIDCService creates an OIDC service for the given provider settings
+func NewOIDCService(cfg config.OIDCConfig) *OIDCService {
+	return &OIDCService{cfg: cfg}
+}
+
+// Enabled reports whether OIDC login is configured
+func (s *OIDCService) Enabled() bool {
+	return s != nil && s.cfg.Enabled()
+}
+
+// discover fetches the provider metadata once and caches the resulting clients
+func (s *OIDCService) discover(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	if !s.Enabled() {
+		return nil, nil, ErrOIDCDisabled
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.oauth2 != nil {
+		return s.oauth2, s.verifier, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, s.cfg.ProviderURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to discover OIDC provider: %w", err)
+	}
+	s.oauth2 = &oauth2.Config{
+		ClientID:     s.cfg.ClientID,
+		ClientSecret: s.cfg.ClientSecret,
+		RedirectURL:  s.cfg.CallbackURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+	}
+	s.verifier = provider.Verifier(&oidc.Config{ClientID: s.cfg.ClientID})
+	return s.oauth2, s.verifier, nil
+}
+
+// AuthCodeURL returns the provider URL the browser should be sent to
+func (s *OIDCService) AuthCodeURL(ctx context.Context, state, nonce string) (string, error) {
+	oauthConfig, _, err := s.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return oauthConfig.AuthCodeURL(state, oidc.Nonce(nonce)), nil
+}
+
+// Exchange trades an authorization code for tokens and returns the verified
+// identity from the ID token
+func (s *OIDCService) Exchange(ctx context.Context, code, nonce string) (*OIDCIdentity, error) {
+	oauthConfig, verifier, err := s.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauthConfig.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("token response did not include an id_token")
+	}
+
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify id_token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, ErrOIDCNonceInvalid
+	}
+
+	identity := &OIDCIdentity{Issuer: idToken.Issuer, Subject: idToken.Subject}
+	if err := idToken.Claims(identity); err != nil {
+		return nil, fmt.Errorf("failed to parse id_token claims: %w", err)
+	}
+	return identity, nil
+}
+
+// UpsertOIDCUser returns the user linked to the identity's issuer and subject,
+// creating one on first login. New accounts get the user role, except when they
+// are the first account on the instance, mirroring registration.
+func UpsertOIDCUser(ctx context.Context, identity *OIDCIdentity) (*models.User, error) {
+	if identity == nil || identity.Issuer == "" || identity.Subject == "" {
+		return nil, errors.New("OIDC identity is missing issuer or subject")
+	}
+
+	var user models.User
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Where("oidc_issuer = ? AND oidc_subject = ?", identity.Issuer, identity.Subject).First(&user).Error
+		if err == nil {
+			if !user.IsActive() {
+				return ErrAccountDisabled
+			}
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		username, err := availableUsername(tx, identity.username())
+		if err != nil {
+			return err
+		}
+
+		var userCount int64
+		if err := tx.Unscoped().Model(&models.User{}).Count(&userCount).Error; err != nil {
+			return err
+		}
+		role := models.RoleUser
+		if userCount == 0 {
+			role = models.RoleAdmin
+		}
+
+		issuer, subject := identity.Issuer, identity.Subject
+		user = models.User{
+			Username:    username,
+			Role:        role,
+			OIDCIssuer:  &issuer,
+			OIDCSubject: &subject,
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		logger.Info("Created user from OIDC login", "username", username, "issuer", issuer)
+		return nil
+	})
+	if errors.Is(err, ErrAccountDisabled) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert OIDC user: %w", err)
+	}
+	return &user, nil
+}
+
+// username picks the most readable username the provider offered
+func (id *OIDCIdentity) username() string {
+	switch {
+	case id.PreferredUsername != "":
+		return id.PreferredUsername
+	case id.Email != "":
+		return id.Email
+	default:
+		return "oidc-" + id.Subject
+	}
+}
+
+// availableUsername truncates base to fit the username column and appends a
+// numeric suffix until it no longer collides with an existing account
+func availableUsername(tx *gorm.DB, base string) (string, error) {
+	base = strings.TrimSpace(base)
+	if len(base) > maxUsernameLength {
+		base = base[:maxUsernameLength]
+	}
+
+	candidate := base
+	for i := 2; ; i++ {
+		var count int64
+		// Soft-deleted accounts still hold their username
+		if err := tx.Unscoped().Model(&models.User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		suffix := fmt.Sprintf("-%d", i)
+		trimmed := base
+		if len(trimmed)+len(suffix) > maxUsernameLength {
+			trimmed = trimmed[:maxUsernameLength-len(suffix)]
+		}
+		candidate = trimmed + suffix
+	}
+}
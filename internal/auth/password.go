@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// argon2id parameters for new password hashes, per the OWASP recommendation
+const (
+	argon2Memory  = 19 * 1024 // KiB
+	argon2Time    = 2
+	argon2Threads = 1
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+// argon2Prefix starts every argon2id hash in PHC string format
+const argon2Prefix = "$argon2id$"
+
+// Password policy limits
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 128
+)
+
+// ErrWeakPassword is returned for passwords that do not meet the policy
+var ErrWeakPassword = errors.New("password does not meet the policy")
+
+// commonPasswords are rejected outright; they are the first guesses of any
+// attacker
+var commonPasswords = map[string]bool{
+	"password": true, "password1": true, "password123": true, "passw0rd": true,
+	"12345678": true, "123456789": true, "1234567890": true, "87654321": true,
+	"qwerty123": true, "qwertyuiop": true, "iloveyou": true, "letmein1": true,
+	"welcome1": true, "admin123": true, "changeme": true, "abc12345": true,
+	"11111111": true, "00000000": true, "football": true, "baseball": true,
+	"sunshine": true, "princess": true, "trustno1": true, "superman": true,
+	"scriberr": true, "scriberr123": true,
+}
+
+// ValidatePassword checks a new password against the policy: 8 to 128
+// characters, not the username and not a commonly used password. Errors wrap
+// ErrWeakPassword and say what is wrong.
+func ValidatePassword(password, username string) error {
+	length := utf8.RuneCountInString(password)
+	_, firstSize := utf8.DecodeRuneInString(password)
+	switch {
+	case length < MinPasswordLength:
+		return fmt.Errorf("%w: it must be at least %d characters", ErrWeakPassword, MinPasswordLength)
+	case length > MaxPasswordLength:
+		return fmt.Errorf("%w: it must be at most %d characters", ErrWeakPassword, MaxPasswordLength)
+	case username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)):
+		return fmt.Errorf("%w: it must not contain the username", ErrWeakPassword)
+	case commonPasswords[strings.ToLower(password)]:
+		return fmt.Errorf("%w: it is too common", ErrWeakPassword)
+	case strings.Count(password, password[:firstSize])*firstSize == len(password):
+		return fmt.Errorf("%w: it must not repeat a single character", ErrWeakPassword)
+	}
+	return nil
+}
+
+// HashPassword hashes a password with argon2id
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2Prefix, argon2.Version, argon2Memory, argon2Time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// CheckPassword checks if a password matches its hash. Both argon2id hashes
+// and the bcrypt hashes of older installations are accepted.
+func CheckPassword(password, hash string) bool {
+	if !strings.HasPrefix(hash, argon2Prefix) {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	}
+
+	var version int
+	var memory, iterations uint32
+	var threads uint8
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false
+	}
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &threads); err != nil || threads == 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(want) == 0 {
+		return false
+	}
+	got := argon2.IDKey([]byte(password), salt, iterations, memory, threads, uint32(len(want)))
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// NeedsRehash reports whether a hash was made with a weaker scheme or
+// weaker parameters than HashPassword uses now
+func NeedsRehash(hash string) bool {
+	current := fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$", argon2Prefix, argon2.Version, argon2Memory, argon2Time, argon2Threads)
+	return !strings.HasPrefix(hash, current)
+}
+
+// UpgradePasswordHash rehashes a user's password with the current scheme
+// after they logged in with it. Sessions are left alone, as the password
+// itself did not change.
+func UpgradePasswordHash(ctx context.Context, user *models.User, password string) error {
+	hashed, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	return database.DB.WithContext(ctx).Model(user).Update("password", hashed).Error
+}
+
+// SetPassword replaces a user's password and ends every session of the user:
+// refresh tokens are revoked and access tokens issued before now are no
+// longer accepted. The caller validates the password against the policy.
+func SetPassword(ctx context.Context, userID uint, password string) error {
+	return database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return setPassword(tx, userID, password, time.Now())
+	})
+}
+
+func setPassword(tx *gorm.DB, userID uint, password string, now time.Time) error {
+	hashed, err := HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"password":            hashed,
+		"password_changed_at": now,
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.RefreshToken{}).Where("user_id = ? AND revoked = ?", userID, false).Update("revoked", true).Error
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// DefaultPasswordResetTTL is used when no explicit reset token lifetime is
+// configured
+const DefaultPasswordResetTTL = time.Hour
+
+// ErrInvalidResetToken is returned for password reset tokens that are
+// unknown, expired or already used
+var ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+
+// CreatePasswordReset issues a one-time token that lets userID set a new
+// password until ttl has passed, and returns it with its expiry. Tokens issued
+// earlier for the user stop working. Only the token's hash is stored. A
+// non-positive ttl falls back to DefaultPasswordResetTTL.
+func CreatePasswordReset(ctx context.Context, userID, adminID uint, ttl time.Duration, now time.Time) (string, time.Time, error) {
+	if ttl <= 0 {
+		ttl = DefaultPasswordResetTTL
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(raw)
+	expiresAt := now.Add(ttl)
+
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND used_at IS NULL", userID).Delete(&models.PasswordResetToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordResetToken{
+			UserID:    userID,
+			Hashed:    hashResetToken(token),
+			ExpiresAt: expiresAt,
+			CreatedBy: adminID,
+		}).Error
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// ResetPassword sets a new password with a reset token, using the token up.
+// The password is checked against the policy before the token is used, so a
+// rejected password can be retried. Like SetPassword, it ends every session
+// of the user. It returns the user whose password was reset.
+func ResetPassword(ctx context.Context, token, password string, now time.Time) (*models.User, error) {
+	var user models.User
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var reset models.PasswordResetToken
+		err := tx.Where("hashed = ? AND used_at IS NULL AND expires_at > ?", hashResetToken(token), now).First(&reset).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidResetToken
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.First(&user, reset.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidResetToken
+			}
+			return err
+		}
+		if !user.IsActive() {
+			return ErrAccountDisabled
+		}
+		if err := ValidatePassword(password, user.Username); err != nil {
+			return err
+		}
+
+		// Only one request can use the token up
+		result := tx.Model(&models.PasswordResetToken{}).Where("id = ? AND used_at IS NULL", reset.ID).Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidResetToken
+		}
+		return setPassword(tx, user.ID, password, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// hashResetToken returns the SHA-256 hex digest under which a reset token is
+// stored
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
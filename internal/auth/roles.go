@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// EnsureAdminUser guarantees that an admin account exists. On first run (no users)
+// an admin is seeded from the given credentials when both are set. Installations
+// that predate roles have their oldest account promoted to admin.
+func EnsureAdminUser(username, password string) error {
+	var adminCount int64
+	if err := database.DB.Model(&models.User{}).Where("role = ?", models.RoleAdmin).Count(&adminCount).Error; err != nil {
+		return fmt.Errorf("failed to count admin users: %w", err)
+	}
+	if adminCount > 0 {
+		return nil
+	}
+
+	var oldest models.User
+	err := database.DB.Order("id ASC").First(&oldest).Error
+	switch {
+	case err == nil:
+		if err := database.DB.Model(&oldest).Update("role", models.RoleAdmin).Error; err != nil {
+			return fmt.Errorf("failed to promote user to admin: %w", err)
+		}
+		logger.Info("Promoted existing user to admin", "username", oldest.Username)
+		return nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return fmt.Errorf("failed to look up users: %w", err)
+	}
+
+	if username == "" || password == "" {
+		logger.Debug("No users exist and ADMIN_USERNAME/ADMIN_PASSWORD not set; waiting for registration")
+		return nil
+	}
+
+	hashed, err := HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash admin password: %w", err)
+	}
+	admin := models.User{
+		Username: username,
+		Password: hashed,
+		Role:     models.RoleAdmin,
+	}
+	if err := database.DB.Create(&admin).Error; err != nil {
+		return fmt.Errorf("failed to create admin user: %w", err)
+	}
+	logger.Info("Seeded admin user", "username", username)
+	return nil
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSetupCompleted is returned when creating the first admin after setup
+// has finished
+var ErrSetupCompleted = errors.New("setup has already been completed")
+
+// SetupCompleted reports whether first-run setup has finished. Instances
+// that already have an admin, such as one seeded from ADMIN_USERNAME and
+// ADMIN_PASSWORD, are marked completed the first time they are checked.
+func SetupCompleted(ctx context.Context) (bool, error) {
+	db := database.DB.WithContext(ctx)
+	var completed int64
+	if err := db.Model(&models.SetupState{}).Count(&completed).Error; err != nil {
+		return false, fmt.Errorf("failed to check setup state: %w", err)
+	}
+	if completed > 0 {
+		return true, nil
+	}
+
+	var admins int64
+	if err := db.Model(&models.User{}).Where("role = ?", models.RoleAdmin).Count(&admins).Error; err != nil {
+		return false, fmt.Errorf("failed to count admin users: %w", err)
+	}
+	if admins == 0 {
+		return false, nil
+	}
+	if err := markSetupCompleted(db); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CompleteSetup creates the first admin and finishes setup. initialize, if
+// not nil, records initial settings in the same transaction, so a failure
+// leaves setup open. It returns ErrSetupCompleted once setup has finished or
+// any account exists.
+func CompleteSetup(ctx context.Context, username, password string, initialize func(tx *gorm.DB, admin *models.User) error) (*models.User, error) {
+	hashed, err := HashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash admin password: %w", err)
+	}
+
+	admin := models.User{Username: username, Password: hashed, Role: models.RoleAdmin}
+	err = database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var completed, users int64
+		if err := tx.Model(&models.SetupState{}).Count(&completed).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.User{}).Count(&users).Error; err != nil {
+			return err
+		}
+		if completed > 0 || users > 0 {
+			return ErrSetupCompleted
+		}
+
+		if err := tx.Create(&admin).Error; err != nil {
+			return err
+		}
+		if initialize != nil {
+			if err := initialize(tx, &admin); err != nil {
+				return err
+			}
+		}
+		// The single row's fixed ID makes a concurrent setup fail here
+		return tx.Create(&models.SetupState{ID: 1, CompletedAt: time.Now()}).Error
+	})
+	if errors.Is(err, ErrSetupCompleted) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete setup: %w", err)
+	}
+	logger.Info("Setup completed", "admin", username)
+	return &admin, nil
+}
+
+// markSetupCompleted records that setup has finished, if it is not recorded
+// already
+func markSetupCompleted(db *gorm.DB) error {
+	state := models.SetupState{ID: 1, CompletedAt: time.Now()}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&state).Error; err != nil {
+		return fmt.Errorf("failed to record setup state: %w", err)
+	}
+	return nil
+}
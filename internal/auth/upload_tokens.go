@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUploadTokenInvalid is returned for upload tokens that were not issued by this server
+	ErrUploadTokenInvalid = errors.New("invalid upload token")
+	// ErrUploadTokenExpired is returned for upload tokens past their expiry
+	ErrUploadTokenExpired = errors.New("upload token has expired")
+)
+
+// GenerateUploadToken returns a token that authorizes writing to an upload
+// until expiresAt. It has the form <upload ID>.<expiry>.<signature>, so it
+// is safe in URL paths.
+func (as *AuthService) GenerateUploadToken(uploadID string, expiresAt time.Time) string {
+	payload := uploadID + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+	return payload + "." + as.uploadTokenSignature(payload)
+}
+
+// ValidateUploadToken checks an upload token's signature and expiry and
+// returns the upload ID it was issued for
+func (as *AuthService) ValidateUploadToken(token string) (string, error) {
+	payload, signature, ok := cutLast(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(as.uploadTokenSignature(payload))) {
+		return "", ErrUploadTokenInvalid
+	}
+	uploadID, expiry, ok := cutLast(payload, ".")
+	if !ok || uploadID == "" {
+		return "", ErrUploadTokenInvalid
+	}
+	expiresAt, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		return "", ErrUploadTokenInvalid
+	}
+	if !time.Now().Before(time.Unix(expiresAt, 0)) {
+		return "", ErrUploadTokenExpired
+	}
+	return uploadID, nil
+}
+
+// uploadTokenSignature signs a token payload with a key derived from the JWT
+// secret, so upload tokens cannot pass for other signed values
+func (as *AuthService) uploadTokenSignature(payload string) string {
+	key := hmac.New(sha256.New, as.jwtSecret)
+	key.Write([]byte("upload-token"))
+	mac := hmac.New(sha256.New, key.Sum(nil))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// cutLast slices s around the last instance of sep
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
@@ -0,0 +1,426 @@
+// Package cleanup applies the retention policy: it removes the audio of
+// transcribed jobs, and whole jobs, once they are old enough
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"scriberr/internal/database"
+	"scriberr/internal/diskspace"
+	"scriberr/internal/models"
+	"scriberr/internal/storage"
+	"scriberr/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// Actions cleanup takes on a job
+const (
+	ActionDeleteAudio = "delete_audio" // Remove the audio but keep the transcript
+	ActionDeleteJob   = "delete_job"   // Remove the job and everything attached to it
+	ActionPurgeJob    = "purge_job"    // Permanently remove a job deleted through the API
+)
+
+// outputDir holds the WhisperX output directories of jobs that kept them
+var outputDir = filepath.Join("data", "output")
+
+// ConfigureOutputDir sets where jobs' WhisperX output files are kept, so they
+// are removed with the job
+func ConfigureOutputDir(dir string) {
+	if dir != "" {
+		outputDir = dir
+	}
+}
+
+// Policy is the global retention. Zero days disables a rule; jobs and
+// watched folders can override the audio and job rules.
+type Policy struct {
+	AudioRetentionDays   int `json:"audio_retention_days"`
+	JobRetentionDays     int `json:"job_retention_days"`
+	DeletedRetentionDays int `json:"deleted_retention_days"` // How long deleted jobs can be restored
+}
+
+// Action is something cleanup will do to a job
+type Action struct {
+	JobID  string    `json:"job_id"`
+	Title  *string   `json:"title,omitempty"`
+	Action string    `json:"action"`
+	DueAt  time.Time `json:"due_at"` // When the job became eligible
+	Bytes  int64     `json:"bytes"`  // Space removing it reclaims
+}
+
+// retentionDays returns a job's override, or the global setting without one
+func retentionDays(override *int, global int) int {
+	if override != nil {
+		return *override
+	}
+	return global
+}
+
+// finishedAt is when a job's retention period starts. Jobs completed before
+// completion times were recorded fall back to their last update.
+func finishedAt(job *models.TranscriptionJob) time.Time {
+	if job.CompletedAt != nil {
+		return *job.CompletedAt
+	}
+	return job.UpdatedAt
+}
+
+// due returns what cleanup should do to a job at now, or "" to leave it
+func due(job *models.TranscriptionJob, policy Policy, now time.Time) (string, time.Time) {
+	// Deleted jobs are only purged, pinned or not
+	if job.DeletedAt.Valid {
+		if days := policy.DeletedRetentionDays; days > 0 {
+			if dueAt := job.DeletedAt.Time.AddDate(0, 0, days); !dueAt.After(now) {
+				return ActionPurgeJob, dueAt
+			}
+		}
+		return "", time.Time{}
+	}
+	if job.Pinned {
+		return "", time.Time{}
+	}
+	finished := finishedAt(job)
+	if job.Status == models.StatusCompleted || job.Status == models.StatusFailed {
+		if days := retentionDays(job.JobRetentionDays, policy.JobRetentionDays); days > 0 {
+			if dueAt := finished.AddDate(0, 0, days); !dueAt.After(now) {
+				return ActionDeleteJob, dueAt
+			}
+		}
+	}
+	// Audio is only removed once it has been transcribed
+	if job.Status == models.StatusCompleted && job.AudioDeletedAt == nil {
+		if days := retentionDays(job.AudioRetentionDays, policy.AudioRetentionDays); days > 0 {
+			if dueAt := finished.AddDate(0, 0, days); !dueAt.After(now) {
+				return ActionDeleteAudio, dueAt
+			}
+		}
+	}
+	return "", time.Time{}
+}
+
+// previewColumns are the job columns cleanup decides on
+var previewColumns = []string{"id", "title", "status", "audio_path", "is_multi_track", "multi_track_folder", "merged_audio_path",
+	"pinned", "audio_retention_days", "job_retention_days", "audio_deleted_at", "completed_at", "updated_at", "deleted_at"}
+
+// Preview lists what a cleanup run at now would remove, oldest first
+func Preview(ctx context.Context, policy Policy, now time.Time) ([]Action, error) {
+	var jobs []models.TranscriptionJob
+	if err := database.DB.WithContext(ctx).Select(previewColumns).
+		Where("pinned = ? AND status IN ?", false, []models.JobStatus{models.StatusCompleted, models.StatusFailed}).
+		Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	if policy.DeletedRetentionDays > 0 {
+		var deleted []models.TranscriptionJob
+		if err := database.DB.WithContext(ctx).Unscoped().Select(previewColumns).
+			Where("deleted_at IS NOT NULL AND deleted_at <= ?", now.AddDate(0, 0, -policy.DeletedRetentionDays)).
+			Find(&deleted).Error; err != nil {
+			return nil, fmt.Errorf("failed to list deleted jobs: %w", err)
+		}
+		jobs = append(jobs, deleted...)
+	}
+
+	actions := []Action{}
+	for i := range jobs {
+		job := &jobs[i]
+		action, dueAt := due(job, policy, now)
+		if action == "" {
+			continue
+		}
+		bytes := audioBytes(ctx, job)
+		if action != ActionDeleteAudio && job.AudioDeletedAt != nil {
+			bytes = 0
+		}
+		actions = append(actions, Action{JobID: job.ID, Title: job.Title, Action: action, DueAt: dueAt, Bytes: bytes})
+	}
+	sort.Slice(actions, func(i, j int) bool { return actions[i].DueAt.Before(actions[j].DueAt) })
+	return actions, nil
+}
+
+// Run removes everything Preview lists and records the run. Runs that find
+// nothing to remove are not recorded.
+func Run(ctx context.Context, policy Policy, now time.Time) (*models.CleanupRun, error) {
+	actions, err := Preview(ctx, policy, now)
+	if err != nil {
+		return nil, err
+	}
+	run := &models.CleanupRun{StartedAt: now}
+	if len(actions) == 0 {
+		run.FinishedAt = time.Now()
+		return run, nil
+	}
+
+	for _, action := range actions {
+		if ctx.Err() != nil {
+			break
+		}
+		// Reload the job in case it was pinned, restarted, deleted or
+		// restored since
+		var job models.TranscriptionJob
+		if err := database.DB.WithContext(ctx).Unscoped().Where("id = ?", action.JobID).First(&job).Error; err != nil {
+			continue
+		}
+		if current, _ := due(&job, policy, now); current != action.Action {
+			continue
+		}
+
+		var reclaimed int64
+		if action.Action == ActionDeleteAudio {
+			reclaimed, err = RemoveAudio(ctx, &job)
+		} else {
+			reclaimed, err = DeleteJob(ctx, &job)
+		}
+		if err != nil {
+			logger.Warn("Retention cleanup failed", "job_id", job.ID, "action", action.Action, "error", err)
+			run.Failed++
+			continue
+		}
+		logger.Info("Retention cleanup removed job data", "job_id", job.ID, "action", action.Action, "bytes", reclaimed)
+		if action.Action == ActionDeleteAudio {
+			run.AudioDeleted++
+		} else {
+			run.JobsDeleted++
+		}
+		run.ReclaimedBytes += reclaimed
+	}
+
+	run.FinishedAt = time.Now()
+	if err := database.DB.WithContext(ctx).Create(run).Error; err != nil {
+		return run, fmt.Errorf("failed to record cleanup run: %w", err)
+	}
+	return run, nil
+}
+
+// Start runs cleanup every interval until ctx is done
+func Start(ctx context.Context, policy Policy, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run, err := Run(ctx, policy, time.Now())
+				if err != nil {
+					logger.Warn("Retention cleanup failed", "error", err)
+				} else if run.AudioDeleted+run.JobsDeleted > 0 {
+					logger.Info("Retention cleanup finished", "audio_deleted", run.AudioDeleted,
+						"jobs_deleted", run.JobsDeleted, "reclaimed_bytes", run.ReclaimedBytes)
+				}
+			}
+		}
+	}()
+}
+
+// Stats totals what cleanup has removed
+type Stats struct {
+	Policy         Policy             `json:"policy"`
+	Runs           int64              `json:"runs"`
+	AudioDeleted   int64              `json:"audio_deleted"`
+	JobsDeleted    int64              `json:"jobs_deleted"`
+	Failed         int64              `json:"failed"`
+	ReclaimedBytes int64              `json:"reclaimed_bytes"`
+	LastRun        *models.CleanupRun `json:"last_run,omitempty"`
+}
+
+// Summarize totals the recorded cleanup runs
+func Summarize(ctx context.Context, policy Policy) (*Stats, error) {
+	var totals struct {
+		Runs, AudioDeleted, JobsDeleted, Failed, ReclaimedBytes int64
+	}
+	if err := database.DB.WithContext(ctx).Model(&models.CleanupRun{}).
+		Select("COUNT(*) AS runs, COALESCE(SUM(audio_deleted), 0) AS audio_deleted, COALESCE(SUM(jobs_deleted), 0) AS jobs_deleted, " +
+			"COALESCE(SUM(failed), 0) AS failed, COALESCE(SUM(reclaimed_bytes), 0) AS reclaimed_bytes").
+		Scan(&totals).Error; err != nil {
+		return nil, fmt.Errorf("failed to total cleanup runs: %w", err)
+	}
+	stats := &Stats{
+		Policy:         policy,
+		Runs:           totals.Runs,
+		AudioDeleted:   totals.AudioDeleted,
+		JobsDeleted:    totals.JobsDeleted,
+		Failed:         totals.Failed,
+		ReclaimedBytes: totals.ReclaimedBytes,
+	}
+	var last models.CleanupRun
+	result := database.DB.WithContext(ctx).Order("started_at DESC").Limit(1).Find(&last)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get the last cleanup run: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		stats.LastRun = &last
+	}
+	return stats, nil
+}
+
+// audioPaths lists the files holding a job's audio
+func audioPaths(job *models.TranscriptionJob) []string {
+	var paths []string
+	if job.AudioPath != "" {
+		paths = append(paths, job.AudioPath)
+	}
+	if job.MergedAudioPath != nil && *job.MergedAudioPath != "" {
+		paths = append(paths, *job.MergedAudioPath)
+	}
+	return paths
+}
+
+// audioBytes is the space a job's audio takes up
+func audioBytes(ctx context.Context, job *models.TranscriptionJob) int64 {
+	var total int64
+	for _, path := range audioPaths(job) {
+		if job.IsMultiTrack && job.MultiTrackFolder != nil && isWithin(path, *job.MultiTrackFolder) {
+			continue // Counted with the folder
+		}
+		if info, err := storage.Stat(ctx, path); err == nil {
+			total += info.Size
+		}
+	}
+	if job.IsMultiTrack && job.MultiTrackFolder != nil {
+		total += diskspace.PathSize(*job.MultiTrackFolder)
+	}
+	return total
+}
+
+// isWithin reports whether path is inside dir
+func isWithin(path, dir string) bool {
+	return strings.HasPrefix(filepath.Clean(path), filepath.Clean(dir)+string(filepath.Separator))
+}
+
+// removeAudioFiles deletes a job's audio, returning the first failure
+func removeAudioFiles(ctx context.Context, job *models.TranscriptionJob) error {
+	var firstErr error
+	for _, path := range audioPaths(job) {
+		if err := storage.Remove(ctx, path); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to delete %s: %w", path, err)
+		}
+	}
+	if job.IsMultiTrack && job.MultiTrackFolder != nil {
+		if err := os.RemoveAll(*job.MultiTrackFolder); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to delete %s: %w", *job.MultiTrackFolder, err)
+		}
+	}
+	return firstErr
+}
+
+// RemoveAudio deletes a job's audio and marks it removed, keeping the
+// transcript. It returns the bytes reclaimed.
+func RemoveAudio(ctx context.Context, job *models.TranscriptionJob) (int64, error) {
+	bytes := audioBytes(ctx, job)
+	if err := removeAudioFiles(ctx, job); err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	if err := database.DB.WithContext(ctx).Model(&models.TranscriptionJob{}).
+		Where("id = ?", job.ID).Update("audio_deleted_at", now).Error; err != nil {
+		return bytes, fmt.Errorf("failed to mark audio deleted: %w", err)
+	}
+	job.AudioDeletedAt = &now
+	return bytes, nil
+}
+
+// DeleteJob deletes a job with its files and every record attached to it,
+// returning the bytes reclaimed. Files that cannot be removed are logged
+// rather than failing the deletion.
+func DeleteJob(ctx context.Context, job *models.TranscriptionJob) (int64, error) {
+	return DeleteJobs(ctx, []models.TranscriptionJob{*job})
+}
+
+// DeleteJobs deletes several jobs in one transaction, so either all of them
+// or none are deleted, and then removes their files. It returns the bytes
+// reclaimed.
+func DeleteJobs(ctx context.Context, jobs []models.TranscriptionJob) (int64, error) {
+	var bytes int64
+	for i := range jobs {
+		if jobs[i].AudioDeletedAt == nil {
+			bytes += audioBytes(ctx, &jobs[i])
+		}
+	}
+
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range jobs {
+			if err := deleteJobRecords(tx, &jobs[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Files go once the records are gone, so a failed deletion keeps them
+	for i := range jobs {
+		removeJobFiles(ctx, &jobs[i])
+	}
+	return bytes, nil
+}
+
+// deleteJobRecords deletes a job and the records attached to it, children
+// before parents to avoid foreign key constraint failures
+func deleteJobRecords(tx *gorm.DB, job *models.TranscriptionJob) error {
+	related := []struct {
+		column string
+		model  interface{}
+		name   string
+	}{
+		{"transcription_job_id", &models.TranscriptionJobExecution{}, "job execution records"},
+		{"transcription_job_id", &models.SpeakerMapping{}, "speaker mappings"},
+		{"transcription_job_id", &models.TranscriptRevision{}, "transcript revisions"},
+		{"transcription_job_id", &models.MultiTrackFile{}, "multi-track files"},
+		{"transcription_id", &models.Note{}, "notes"},
+		{"job_id", &models.Tag{}, "tags"},
+		{"job_id", &models.JobWaveform{}, "waveforms"},
+	}
+	for _, r := range related {
+		if err := tx.Where(r.column+" = ?", job.ID).Delete(r.model).Error; err != nil {
+			return fmt.Errorf("failed to delete %s: %w", r.name, err)
+		}
+	}
+
+	// Delete chat sessions and their messages
+	sessions := tx.Model(&models.ChatSession{}).Select("id").Where("transcription_id = ?", job.ID)
+	if err := tx.Where("chat_session_id IN (?)", sessions).Delete(&models.ChatMessage{}).Error; err != nil {
+		return fmt.Errorf("failed to delete chat messages: %w", err)
+	}
+	if err := tx.Where("transcription_id = ?", job.ID).Delete(&models.ChatSession{}).Error; err != nil {
+		return fmt.Errorf("failed to delete chat sessions: %w", err)
+	}
+
+	// Finally delete the main job record, for good if it was soft deleted
+	if err := tx.Unscoped().Delete(job).Error; err != nil {
+		return fmt.Errorf("failed to delete job: %w", err)
+	}
+	return nil
+}
+
+// removeJobFiles removes a deleted job's audio, transcript directory and
+// saved chunks, logging failures
+func removeJobFiles(ctx context.Context, job *models.TranscriptionJob) {
+	if err := removeAudioFiles(ctx, job); err != nil {
+		logger.Warn("Failed to delete job audio", "job_id", job.ID, "error", err)
+	}
+
+	// Output files are only left behind when SCRIBERR_KEEP_OUTPUT_FILES is set
+	// or a run failed
+	jobOutputDir := filepath.Join(outputDir, job.ID)
+	if err := os.RemoveAll(jobOutputDir); err != nil && !os.IsNotExist(err) {
+		logger.Warn("Failed to delete job output directory", "path", jobOutputDir, "error", err)
+	}
+
+	// Chunks saved by an unfinished run are kept in data/temp/chunks
+	if job.HasPartial {
+		partialDir := filepath.Join("data", "temp", "chunks", job.ID)
+		if err := os.RemoveAll(partialDir); err != nil {
+			logger.Warn("Failed to delete saved chunks", "path", partialDir, "error", err)
+		}
+	}
+}
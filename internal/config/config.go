@@ -3,12 +3,14 @@ package config
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"scriberr/pkg/logger"
@@ -19,24 +21,236 @@ type Config struct {
 	// Server configuration
 	Port string
 	Host string
+	// Production hides internal details such as file paths and SQL from
+	// API error responses. SCRIBERR_ENV=development shows them.
+	Production bool
 
 	// Database configuration
 	DatabasePath string
 
 	// JWT configuration
-	JWTSecret string
+	JWTSecret       string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// Initial admin account seeded on first run
+	AdminUsername string
+	AdminPassword string
+
+	// Login attempts per minute allowed from one client IP, and separately
+	// for one username, before login answers 429
+	LoginRateLimit int
+	// Consecutive failed logins that lock a username, and how long the first
+	// lockout lasts; every further failure doubles it
+	LoginLockoutThreshold int
+	LoginLockoutDuration  time.Duration
+	// How long a password reset token issued by an administrator stays valid
+	PasswordResetTTL time.Duration
+	// Proxies whose X-Forwarded-For header is trusted for the client IP; with
+	// none, the client IP is the address of the connection
+	TrustedProxies []string
+	// Origins of external frontends and browser extensions allowed to call
+	// the API from a browser; with none, any origin may without credentials
+	AllowedOrigins []string
 
 	// File storage
-	UploadDir string
+	UploadDir        string
+	MaxUploadSize    int64         // Largest upload in bytes, multipart or resumable
+	MaxJSONBodySize  int64         // Largest body of requests that are not uploads, in bytes
+	UploadSessionTTL time.Duration // Resumable uploads idle this long are removed
+	// Uploads and URL ingestion are refused when the upload volume has less
+	// free space than this
+	MinFreeSpaceMB int
+	// Where uploaded audio is kept once jobs are created
+	Storage StorageConfig
+	// Transcode uploads the pipeline cannot read directly to 16 kHz mono WAV
+	// when they are uploaded, rather than just before their job runs
+	NormalizeOnUpload bool
+
+	// Retention: completed jobs lose their audio after AudioRetentionDays and
+	// are deleted after JobRetentionDays, checked every CleanupInterval. Zero
+	// days disables the rule; jobs and watched folders can override both.
+	AudioRetentionDays int
+	JobRetentionDays   int
+	CleanupInterval    time.Duration
+	// Deleted jobs can be restored for this many days before cleanup purges
+	// them with their files; zero keeps them until restored
+	DeletedJobRetentionDays int
+	// Remove a job's audio as soon as it is transcribed, keeping only the
+	// transcript
+	DeleteAudioAfterTranscription bool
+	// Run every job instead of reusing the transcript of a completed job with
+	// the same audio and options
+	DisableResultCache bool
+
+	// Requests each public share link answers per minute; zero is unlimited
+	ShareRateLimit int
+
+	// Requests per minute the API allows each user, API key or client IP, by
+	// route class; zero is unlimited. The settings API can change them.
+	RateLimitRead   int
+	RateLimitWrite  int
+	RateLimitSubmit int
+	RateLimitLLM    int
+
+	// Jobs that may run at once on each device ("cpu", "cuda" or "mps");
+	// devices left out are limited only by the number of workers
+	MaxConcurrentJobsPerDevice map[string]int
+	// How long shutdown waits for transcription processes to exit after
+	// SIGTERM before killing them
+	ShutdownTimeout time.Duration
+	// Jobs still running after JobTimeout, such as WhisperX hung on
+	// malformed audio, are stopped and queued again up to JobTimeoutRetries
+	// times before they fail
+	JobTimeout        time.Duration
+	JobTimeoutRetries int
 
 	// Python/WhisperX configuration
 	UVPath      string
 	WhisperXEnv string
+	// WhisperX version POST /api/v1/system/setup installs
+	WhisperXVersion string
+
+	// Directory WhisperX downloads and loads Whisper models from
+	ModelCacheDir string
+
+	// Directory WhisperX writes each job's output files to, in a directory
+	// per job. They are removed once imported unless KeepOutputFiles is set.
+	OutputDir       string
+	KeepOutputFiles bool
+
+	// HuggingFace token pyannote diarization models are downloaded with for
+	// jobs that do not carry their own
+	HuggingFaceToken string
+
+	// Decoding defaults for jobs that do not set beam_size or temperatures
+	WhisperXBeamSize     int
+	WhisperXTemperatures []float64
+
+	// Jobs whose detected language has less confidence than this (0.0 - 1.0)
+	// are flagged with a warning
+	LanguageConfidenceThreshold float64
+
+	// WhisperX jobs longer than LongAudioThreshold are transcribed in chunks
+	// of about LongAudioChunkLength, so they cannot run out of memory on the
+	// whole file
+	LongAudioThreshold   time.Duration
+	LongAudioChunkLength time.Duration
+
+	// Approximate VRAM in MB each Whisper model needs; WhisperX jobs fall back
+	// to the CPU when the GPU has less free memory than this
+	ModelVRAMMB map[string]int
+
+	// YAML file of processing speed per model and device for time estimates;
+	// the built-in factors are used when empty
+	EstimateFactorsPath string
+
+	// Remote media ingestion
+	YtDlpPath       string
+	URLAllowedHosts []string // When set, only these hosts and their subdomains may be fetched
+	URLDeniedHosts  []string // Hosts and subdomains that may never be fetched
+
+	// Watched folders; folders can also be added through the API
+	WatchDirs         []string      // Folders whose new audio files are transcribed
+	WatchAfterProcess string        // "marker" or "move", for folders from WatchDirs
+	WatchPollInterval time.Duration // Rescan interval, which catches changes filesystem events miss
+	WatchSettleTime   time.Duration // How long a file's size must stay unchanged before it is picked up
+	WatchTags         []string      // key:value tags for jobs from WatchDirs
+
+	// Approximate prompt tokens transcript chat may send the LLM; the oldest
+	// turns of a conversation are dropped to stay within it
+	ChatContextTokens int
+
+	// Hosted OpenAI-compatible transcription
+	OpenAIBaseURL            string
+	OpenAIAPIKey             string
+	OpenAITranscriptionModel string
+
+	// faster-whisper executable run for jobs with the faster-whisper backend
+	FasterWhisperPath string
+
+	// Live transcription of audio streamed over a WebSocket
+	Live LiveConfig
+
+	// Single sign-on via an OpenID Connect provider
+	OIDC OIDCConfig
+	// Login through an authenticating reverse proxy, such as Authelia
+	HeaderAuth HeaderAuthConfig
 
 	// Environment capabilities
 	Environment Environment
 }
 
+// LiveConfig configures live transcription, which runs faster-whisper on a
+// sliding window of the audio a client streams
+type LiveConfig struct {
+	Model              string        // faster-whisper model; tiny and base keep up on a CPU
+	Device             string        // Device the model runs on; empty uses the best one available
+	MaxSessionDuration time.Duration // Streams are ended and saved after this long
+	MaxStreamsPerUser  int           // Streams one user may have open at once
+}
+
+// OIDCConfig configures login through an external OpenID Connect provider
+type OIDCConfig struct {
+	ProviderURL  string
+	ClientID     string
+	ClientSecret string
+	CallbackURL  string
+}
+
+// HeaderAuthConfig trusts a reverse proxy that authenticates users to name
+// them in a header. Only requests from TrustedProxies are then served, so the
+// header cannot be forged by going around the proxy.
+type HeaderAuthConfig struct {
+	Enabled   bool
+	Header    string // Header carrying the username, such as Remote-User
+	LogoutURL string // Where the web UI goes after logging out, such as the proxy's logout page
+}
+
+// StorageConfig selects where audio is kept: "local" keeps files in
+// UploadDir, "s3" in an S3-compatible bucket such as MinIO
+type StorageConfig struct {
+	Backend         string
+	Bucket          string
+	Endpoint        string // Empty for AWS
+	Region          string
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	PathStyle       bool // Address buckets by path, as MinIO expects
+}
+
+// Enabled reports whether enough settings are present to offer OIDC login
+func (c OIDCConfig) Enabled() bool {
+	return c.ProviderURL != "" && c.ClientID != "" && c.CallbackURL != ""
+}
+
+// Default token lifetimes used when the corresponding env vars are unset or invalid.
+const (
+	DefaultAccessTokenTTL  = 15 * time.Minute
+	DefaultRefreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// DefaultBeamSize is the WhisperX beam search size
+const DefaultBeamSize = 5
+
+// DefaultTemperatures is the WhisperX temperature fallback schedule
+var DefaultTemperatures = []float64{0.0, 0.2, 0.4, 0.6, 0.8, 1.0}
+
+// DefaultLanguageConfidenceThreshold is the language detection confidence
+// below which jobs are flagged
+const DefaultLanguageConfidenceThreshold = 0.5
+
+// Long audio chunking defaults
+const (
+	DefaultLongAudioThreshold   = 2 * time.Hour
+	DefaultLongAudioChunkLength = 30 * time.Minute
+)
+
+// DefaultChatContextTokens is the transcript chat prompt budget
+const DefaultChatContextTokens = 8000
+
 // Environment describes host capabilities detected at startup.
 type Environment struct {
 	OS                   string
@@ -58,17 +272,164 @@ func Load() *Config {
 	environment = detectEnvironment()
 
 	return &Config{
-		Port:         getEnv("PORT", "8080"),
-		Host:         getEnv("HOST", "localhost"),
-		DatabasePath: getEnv("DATABASE_PATH", "data/scriberr.db"),
-		JWTSecret:    getJWTSecret(),
-		UploadDir:    getEnv("UPLOAD_DIR", "data/uploads"),
-		UVPath:       findUVPath(),
-		WhisperXEnv:  getEnv("WHISPERX_ENV", "data/whisperx-env"),
-		Environment:  environment,
+		Port:            getEnv("PORT", "8080"),
+		Host:            getEnv("HOST", "localhost"),
+		Production:      !strings.EqualFold(getEnv("SCRIBERR_ENV", "production"), "development"),
+		DatabasePath:    getEnv("DATABASE_PATH", "data/scriberr.db"),
+		JWTSecret:       getJWTSecret(),
+		AccessTokenTTL:  time.Duration(getEnvInt("JWT_ACCESS_TTL_MINUTES", 15)) * time.Minute,
+		RefreshTokenTTL: time.Duration(getEnvInt("JWT_REFRESH_TTL_DAYS", 7)) * 24 * time.Hour,
+		AdminUsername:   os.Getenv("ADMIN_USERNAME"),
+		AdminPassword:   os.Getenv("ADMIN_PASSWORD"),
+		UploadDir:       getEnv("UPLOAD_DIR", "data/uploads"),
+		UVPath:          findUVPath(),
+		WhisperXEnv:     getEnv("WHISPERX_ENV", "data/whisperx-env"),
+		WhisperXVersion: getEnv("SCRIBERR_WHISPERX_VERSION", getEnv("WHISPERX_VERSION", "3.4.2")),
+		Environment:     environment,
+
+		LoginRateLimit:        getEnvInt("LOGIN_RATE_LIMIT", 10),
+		LoginLockoutThreshold: getEnvInt("LOGIN_LOCKOUT_THRESHOLD", 5),
+		LoginLockoutDuration:  time.Duration(getEnvInt("LOGIN_LOCKOUT_SECONDS", 60)) * time.Second,
+		PasswordResetTTL:      time.Duration(getEnvInt("PASSWORD_RESET_TTL_MINUTES", 60)) * time.Minute,
+		TrustedProxies:        getEnvList("TRUSTED_PROXIES"),
+		AllowedOrigins:        getEnvList("ALLOWED_ORIGINS"),
+
+		EstimateFactorsPath: os.Getenv("ESTIMATE_FACTORS_PATH"),
+
+		MaxUploadSize:    int64(getEnvInt("MAX_UPLOAD_SIZE_MB", 4096)) << 20,
+		MaxJSONBodySize:  int64(getEnvInt("MAX_JSON_BODY_SIZE_KB", 1024)) << 10,
+		UploadSessionTTL: time.Duration(getEnvInt("UPLOAD_SESSION_TTL_HOURS", 24)) * time.Hour,
+
+		NormalizeOnUpload: getEnvBool("NORMALIZE_ON_UPLOAD", true),
+		MinFreeSpaceMB:    getEnvInt("MIN_FREE_SPACE_MB", 500),
+
+		Storage: StorageConfig{
+			Backend:         getEnv("STORAGE_BACKEND", "local"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			Endpoint:        firstEnv("S3_ENDPOINT", "AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"),
+			Region:          getEnv("S3_REGION", getEnv("AWS_REGION", getEnv("AWS_DEFAULT_REGION", "us-east-1"))),
+			Prefix:          os.Getenv("S3_PREFIX"),
+			AccessKeyID:     firstEnv("S3_ACCESS_KEY_ID", "AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: firstEnv("S3_SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+			PathStyle:       getEnvBool("S3_PATH_STYLE", false),
+		},
+
+		AudioRetentionDays:            getEnvInt("AUDIO_RETENTION_DAYS", 0),
+		JobRetentionDays:              getEnvInt("JOB_RETENTION_DAYS", 0),
+		CleanupInterval:               time.Duration(getEnvInt("CLEANUP_INTERVAL_MINUTES", 60)) * time.Minute,
+		DeletedJobRetentionDays:       getEnvInt("SCRIBERR_RETENTION_DAYS", 30),
+		DeleteAudioAfterTranscription: getEnvBool("SCRIBERR_DELETE_AUDIO_AFTER", false),
+		DisableResultCache:            getEnvBool("SCRIBERR_DISABLE_RESULT_CACHE", false),
+
+		ShareRateLimit: getEnvInt("SHARE_RATE_LIMIT", 60),
+
+		RateLimitRead:   getEnvInt("RATE_LIMIT_READ", 600),
+		RateLimitWrite:  getEnvInt("RATE_LIMIT_WRITE", 120),
+		RateLimitSubmit: getEnvInt("RATE_LIMIT_SUBMIT", 30),
+		RateLimitLLM:    getEnvInt("RATE_LIMIT_LLM", 20),
+
+		MaxConcurrentJobsPerDevice: deviceJobLimits(),
+		ShutdownTimeout:            time.Duration(getEnvInt("SHUTDOWN_TIMEOUT_SECONDS", 30)) * time.Second,
+		JobTimeout:                 time.Duration(getEnvInt("JOB_TIMEOUT_MINUTES", 60)) * time.Minute,
+		JobTimeoutRetries:          getEnvInt("JOB_TIMEOUT_RETRIES", 0),
+
+		ModelCacheDir: getEnv("WHISPERX_MODEL_CACHE", "data/models"),
+
+		OutputDir:       getEnv("WHISPERX_OUTPUT_DIR", "data/output"),
+		KeepOutputFiles: getEnvBool("SCRIBERR_KEEP_OUTPUT_FILES", false),
+
+		HuggingFaceToken: os.Getenv("HF_TOKEN"),
+
+		WhisperXBeamSize:     getEnvInt("WHISPERX_BEAM_SIZE", DefaultBeamSize),
+		WhisperXTemperatures: getEnvFloatList("WHISPERX_TEMPERATURES", DefaultTemperatures),
+
+		LanguageConfidenceThreshold: getEnvFraction("LANGUAGE_CONFIDENCE_THRESHOLD", DefaultLanguageConfidenceThreshold),
+
+		LongAudioThreshold:   time.Duration(getEnvInt("LONG_AUDIO_THRESHOLD_MINUTES", int(DefaultLongAudioThreshold.Minutes()))) * time.Minute,
+		LongAudioChunkLength: time.Duration(getEnvInt("LONG_AUDIO_CHUNK_MINUTES", int(DefaultLongAudioChunkLength.Minutes()))) * time.Minute,
+
+		YtDlpPath:       findYtDlpPath(),
+		ModelVRAMMB:     getModelVRAM("MODEL_VRAM_MB"),
+		URLAllowedHosts: getEnvList("URL_ALLOWED_HOSTS"),
+		URLDeniedHosts:  getEnvList("URL_DENIED_HOSTS"),
+
+		WatchDirs:         getEnvList("WATCH_DIRS"),
+		WatchAfterProcess: getEnv("WATCH_AFTER_PROCESS", "marker"),
+		WatchPollInterval: time.Duration(getEnvInt("WATCH_POLL_INTERVAL_SECONDS", 60)) * time.Second,
+		WatchSettleTime:   time.Duration(getEnvInt("WATCH_SETTLE_SECONDS", 10)) * time.Second,
+		WatchTags:         getEnvList("WATCH_TAGS"),
+
+		ChatContextTokens: getEnvInt("CHAT_CONTEXT_TOKENS", DefaultChatContextTokens),
+
+		OpenAIBaseURL:            getEnv("OPENAI_BASE_URL", "https://api.openai.com/v1"),
+		OpenAIAPIKey:             os.Getenv("OPENAI_API_KEY"),
+		OpenAITranscriptionModel: getEnv("OPENAI_TRANSCRIPTION_MODEL", "whisper-1"),
+
+		FasterWhisperPath: getEnv("FASTER_WHISPER_PATH", "faster-whisper-xxl"),
+
+		Live: LiveConfig{
+			Model:              getEnv("LIVE_MODEL", "base"),
+			Device:             os.Getenv("LIVE_DEVICE"),
+			MaxSessionDuration: time.Duration(getEnvInt("LIVE_MAX_SESSION_MINUTES", 60)) * time.Minute,
+			MaxStreamsPerUser:  getEnvInt("LIVE_MAX_STREAMS_PER_USER", 1),
+		},
+
+		OIDC: OIDCConfig{
+			ProviderURL:  os.Getenv("OIDC_PROVIDER_URL"),
+			ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			CallbackURL:  os.Getenv("OIDC_CALLBACK_URL"),
+		},
+		HeaderAuth: HeaderAuthConfig{
+			Enabled:   getEnvBool("TRUSTED_HEADER_AUTH", false),
+			Header:    getEnv("TRUSTED_HEADER_AUTH_HEADER", "Remote-User"),
+			LogoutURL: os.Getenv("TRUSTED_HEADER_AUTH_LOGOUT_URL"),
+		},
 	}
 }
 
+// Validate prepares the directories the configuration points at, failing
+// when one cannot be created
+func (c *Config) Validate() error {
+	if c.ModelCacheDir != "" {
+		if err := os.MkdirAll(c.ModelCacheDir, 0755); err != nil {
+			return fmt.Errorf("failed to create model cache directory %s: %w", c.ModelCacheDir, err)
+		}
+	}
+	if c.OutputDir != "" {
+		if err := checkWritableDir(c.OutputDir); err != nil {
+			return fmt.Errorf("output directory %s is not writable: %w", c.OutputDir, err)
+		}
+	}
+	if c.HuggingFaceToken == "" {
+		logger.Warn("HF_TOKEN is not set; diarization jobs must carry their own HuggingFace token")
+	}
+	return nil
+}
+
+// checkWritableDir creates dir if needed and checks a file can be written in it
+func checkWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	probe, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return err
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+// redact keeps the first characters of a secret, enough to tell which one
+// is configured
+func redact(secret string) string {
+	if len(secret) <= 8 {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:8] + "..."
+}
+
 // EnvironmentInfo returns detected environment capabilities.
 func EnvironmentInfo() Environment {
 	return environment
@@ -82,6 +443,137 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// defaultModelVRAMMB is the VRAM each Whisper model needs, per the Whisper model card
+var defaultModelVRAMMB = map[string]int{
+	"tiny":      1000,
+	"tiny.en":   1000,
+	"base":      1000,
+	"base.en":   1000,
+	"small":     2000,
+	"small.en":  2000,
+	"medium":    5000,
+	"medium.en": 5000,
+	"large":     10000,
+	"large-v1":  10000,
+	"large-v2":  10000,
+	"large-v3":  10000,
+}
+
+// getModelVRAM returns the model VRAM table, with overrides from a
+// comma-separated list of model=MB pairs, e.g. "large-v3=8000,medium=4500"
+func getModelVRAM(key string) map[string]int {
+	table := make(map[string]int, len(defaultModelVRAMMB))
+	for model, mb := range defaultModelVRAMMB {
+		table[model] = mb
+	}
+	for _, entry := range getEnvList(key) {
+		model, value, ok := strings.Cut(entry, "=")
+		mb, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || err != nil || mb <= 0 {
+			logger.Warn("Ignoring invalid model VRAM entry", "key", key, "entry", entry)
+			continue
+		}
+		table[strings.TrimSpace(model)] = mb
+	}
+	return table
+}
+
+// firstEnv returns the first of several environment variables that is set,
+// so settings can fall back to the standard AWS variables
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// getEnvInt gets a positive integer environment variable with a default value
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		logger.Warn("Ignoring invalid integer env var", "key", key, "value", value)
+		return defaultValue
+	}
+	return parsed
+}
+
+// deviceJobLimits reads the per-device job limits from SCRIBERR_MAX_JOBS_CPU,
+// SCRIBERR_MAX_JOBS_CUDA and SCRIBERR_MAX_JOBS_MPS
+func deviceJobLimits() map[string]int {
+	limits := map[string]int{}
+	for _, device := range []string{"cpu", "cuda", "mps"} {
+		if limit := getEnvInt("SCRIBERR_MAX_JOBS_"+strings.ToUpper(device), 0); limit > 0 {
+			limits[device] = limit
+		}
+	}
+	return limits
+}
+
+// getEnvBool gets a boolean environment variable with a default value
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		logger.Warn("Ignoring invalid boolean env var", "key", key, "value", value)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvFraction gets a number between 0 and 1 from an environment variable
+// with a default value
+func getEnvFraction(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || parsed < 0 || parsed > 1 {
+		logger.Warn("Ignoring invalid fraction env var", "key", key, "value", value)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvFloatList parses a comma-separated list of numbers, falling back to
+// the default when any entry is invalid
+func getEnvFloatList(key string, defaultValue []float64) []float64 {
+	entries := getEnvList(key)
+	if len(entries) == 0 {
+		return defaultValue
+	}
+	values := make([]float64, 0, len(entries))
+	for _, entry := range entries {
+		value, err := strconv.ParseFloat(entry, 64)
+		if err != nil {
+			logger.Warn("Ignoring invalid number list env var", "key", key, "value", os.Getenv(key))
+			return defaultValue
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// getEnvList splits a comma-separated environment variable, dropping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 // getJWTSecret gets JWT secret from env or generates a secure random one
 func getJWTSecret() string {
 	if secret := os.Getenv("JWT_SECRET"); secret != "" {
@@ -121,6 +613,21 @@ func findUVPath() string {
 	return "uv"
 }
 
+// findYtDlpPath finds the yt-dlp downloader
+func findYtDlpPath() string {
+	if path := os.Getenv("YTDLP_PATH"); path != "" {
+		return path
+	}
+
+	if path, err := exec.LookPath("yt-dlp"); err == nil {
+		logger.Debug("Found yt-dlp", "path", path)
+		return path
+	}
+
+	logger.Debug("yt-dlp not found in PATH, using fallback", "fallback", "yt-dlp")
+	return "yt-dlp"
+}
+
 func detectEnvironment() Environment {
 	goos := runtime.GOOS
 	arch := runtime.GOARCH
@@ -165,6 +672,27 @@ func detectEnvironment() Environment {
 	}
 }
 
+// AutoDevice is the device models set to "auto" run on: CUDA where the
+// NVIDIA stack is supported, then MPS, then the CPU
+func (e Environment) AutoDevice() string {
+	switch {
+	case e.SupportsNvidiaStack:
+		return "cuda"
+	case e.SupportsMPS:
+		return "mps"
+	}
+	return "cpu"
+}
+
+// DefaultThreads is a suggested number of WhisperX CPU threads for the host:
+// one per CPU when transcription runs on the CPU, and 0 (auto) otherwise
+func DefaultThreads(env Environment) int {
+	if env.DefaultWhisperDevice == "cpu" {
+		return runtime.NumCPU()
+	}
+	return 0
+}
+
 // Snapshot returns a map view of the loaded configuration suitable for logging.
 func (c *Config) Snapshot() map[string]any {
 	if c == nil {
@@ -174,11 +702,105 @@ func (c *Config) Snapshot() map[string]any {
 	return map[string]any{
 		"port":          c.Port,
 		"host":          c.Host,
+		"production":    c.Production,
 		"database_path": c.DatabasePath,
 		"jwt_secret":    c.JWTSecret,
+		"access_ttl":    c.AccessTokenTTL.String(),
+		"refresh_ttl":   c.RefreshTokenTTL.String(),
+		"admin_user":    c.AdminUsername,
 		"upload_dir":    c.UploadDir,
+		"uploads": map[string]any{
+			"max_size_mb": c.MaxUploadSize >> 20,
+			"max_json_kb": c.MaxJSONBodySize >> 10,
+			"session_ttl": c.UploadSessionTTL.String(),
+			"normalize":   c.NormalizeOnUpload,
+			"min_free_mb": c.MinFreeSpaceMB,
+		},
+		"login": map[string]any{
+			"rate_limit":        c.LoginRateLimit,
+			"lockout_threshold": c.LoginLockoutThreshold,
+			"lockout_duration":  c.LoginLockoutDuration.String(),
+			"reset_token_ttl":   c.PasswordResetTTL.String(),
+			"trusted_proxies":   c.TrustedProxies,
+		},
 		"uv_path":       c.UVPath,
 		"whisperx_env":  c.WhisperXEnv,
+		"model_vram_mb": c.ModelVRAMMB,
+		"ytdlp_path":    c.YtDlpPath,
+		"watch": map[string]any{
+			"dirs":          c.WatchDirs,
+			"after_process": c.WatchAfterProcess,
+			"poll_interval": c.WatchPollInterval.String(),
+			"settle_time":   c.WatchSettleTime.String(),
+			"tags":          c.WatchTags,
+		},
+		"language_confidence_threshold": c.LanguageConfidenceThreshold,
+		"url_hosts": map[string]any{
+			"allowed": c.URLAllowedHosts,
+			"denied":  c.URLDeniedHosts,
+		},
+		"storage": map[string]any{
+			"backend":    c.Storage.Backend,
+			"bucket":     c.Storage.Bucket,
+			"endpoint":   c.Storage.Endpoint,
+			"region":     c.Storage.Region,
+			"prefix":     c.Storage.Prefix,
+			"path_style": c.Storage.PathStyle,
+			"secret_set": c.Storage.SecretAccessKey != "",
+		},
+		"retention": map[string]any{
+			"audio_days":                       c.AudioRetentionDays,
+			"job_days":                         c.JobRetentionDays,
+			"deleted_days":                     c.DeletedJobRetentionDays,
+			"delete_audio_after_transcription": c.DeleteAudioAfterTranscription,
+			"cleanup_interval":                 c.CleanupInterval.String(),
+		},
+		"result_cache":     !c.DisableResultCache,
+		"share_rate_limit": c.ShareRateLimit,
+		"allowed_origins":  c.AllowedOrigins,
+		"rate_limits": map[string]any{
+			"read":   c.RateLimitRead,
+			"write":  c.RateLimitWrite,
+			"submit": c.RateLimitSubmit,
+			"llm":    c.RateLimitLLM,
+		},
+		"max_jobs_per_device": c.MaxConcurrentJobsPerDevice,
+		"shutdown_timeout":    c.ShutdownTimeout.String(),
+		"job_timeout":         c.JobTimeout.String(),
+		"job_timeout_retries": c.JobTimeoutRetries,
+		"whisperx": map[string]any{
+			"version":      c.WhisperXVersion,
+			"beam_size":    c.WhisperXBeamSize,
+			"temperatures": c.WhisperXTemperatures,
+			"model_cache":  c.ModelCacheDir,
+			"output_dir":   c.OutputDir,
+			"keep_output":  c.KeepOutputFiles,
+		},
+		"hf_token":              redact(c.HuggingFaceToken),
+		"estimate_factors_path": c.EstimateFactorsPath,
+		"openai": map[string]any{
+			"base_url":            c.OpenAIBaseURL,
+			"transcription_model": c.OpenAITranscriptionModel,
+			"api_key_set":         c.OpenAIAPIKey != "",
+		},
+		"faster_whisper_path": c.FasterWhisperPath,
+		"live": map[string]any{
+			"model":                c.Live.Model,
+			"device":               c.Live.Device,
+			"max_session_duration": c.Live.MaxSessionDuration.String(),
+			"max_streams_per_user": c.Live.MaxStreamsPerUser,
+		},
+		"oidc": map[string]any{
+			"enabled":      c.OIDC.Enabled(),
+			"provider_url": c.OIDC.ProviderURL,
+			"client_id":    c.OIDC.ClientID,
+			"callback_url": c.OIDC.CallbackURL,
+		},
+		"header_auth": map[string]any{
+			"enabled":    c.HeaderAuth.Enabled,
+			"header":     c.HeaderAuth.Header,
+			"logout_url": c.HeaderAuth.LogoutURL,
+		},
 		"environment": map[string]any{
 			"os":                     c.Environment.OS,
 			"arch":                   c.Environment.Arch,
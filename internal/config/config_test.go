@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSnapshotRedactsHuggingFaceToken(t *testing.T) {
+	cfg := &Config{HuggingFaceToken: "hf_abcdefghijklmnop"}
+	if token := cfg.Snapshot()["hf_token"]; token != "hf_abcde..." {
+		t.Errorf("Expected only the first 8 characters of the token, got %v", token)
+	}
+
+	// Short tokens are masked completely
+	cfg.HuggingFaceToken = "hf_abc"
+	if token := cfg.Snapshot()["hf_token"]; token != "******" {
+		t.Errorf("Expected a short token to be masked, got %v", token)
+	}
+
+	cfg.HuggingFaceToken = ""
+	if token := cfg.Snapshot()["hf_token"]; token != "" {
+		t.Errorf("Expected no token, got %v", token)
+	}
+}
+
+func TestDefaultThreads(t *testing.T) {
+	if got := DefaultThreads(Environment{DefaultWhisperDevice: "cpu"}); got != runtime.NumCPU() {
+		t.Errorf("Expected one thread per CPU on the CPU, got %d", got)
+	}
+	if got := DefaultThreads(Environment{DefaultWhisperDevice: "cuda"}); got != 0 {
+		t.Errorf("Expected auto on CUDA, got %d", got)
+	}
+}
+
+func TestValidateChecksOutputDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "output")
+	if err := (&Config{OutputDir: dir}).Validate(); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Fatalf("Expected the output directory to be created, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected the write check to leave nothing behind, got %d entries", len(entries))
+	}
+
+	// A file where the directory should be cannot be written to
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&Config{OutputDir: file}).Validate(); err == nil {
+		t.Error("Expected an output directory that is a file to be rejected")
+	}
+}
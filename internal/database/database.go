@@ -1,7 +1,9 @@
 package database
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"time"
@@ -57,6 +59,13 @@ func Initialize(dbPath string) error {
 	sqlDB.SetConnMaxLifetime(30 * time.Minute) // Reset connections every 30 minutes
 	sqlDB.SetConnMaxIdleTime(5 * time.Minute)  // Close idle connections after 5 minutes
 
+	// Audio length used to be stored as audio_duration
+	if DB.Migrator().HasColumn(&models.TranscriptionJob{}, "audio_duration") && !DB.Migrator().HasColumn(&models.TranscriptionJob{}, "duration_seconds") {
+		if err := DB.Migrator().RenameColumn(&models.TranscriptionJob{}, "audio_duration", "duration_seconds"); err != nil {
+			return fmt.Errorf("failed to rename audio_duration column: %v", err)
+		}
+	}
+
 	// Auto migrate the schema
 	if err := DB.AutoMigrate(
 		&models.TranscriptionJob{},
@@ -74,6 +83,24 @@ func Initialize(dbPath string) error {
 		&models.Summary{},
 		&models.Note{},
 		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.LoginLockout{},
+		&models.SetupState{},
+		&models.PasswordResetToken{},
+		&models.TranscriptRevision{},
+		&models.Quota{},
+		&models.WatchFolder{},
+		&models.WatchedFile{},
+		&models.UploadSession{},
+		&models.CleanupRun{},
+		&models.Tag{},
+		&models.PronunciationEntry{},
+		&models.JobFolder{},
+		&models.SavedFilter{},
+		&models.ShareLink{},
+		&models.RateLimitSetting{},
+		&models.JobWaveform{},
+		&models.JobStat{},
 	); err != nil {
 		return fmt.Errorf("failed to auto migrate: %v", err)
 	}
@@ -83,9 +110,84 @@ func Initialize(dbPath string) error {
 		return fmt.Errorf("failed to create unique constraint for speaker mappings: %v", err)
 	}
 
+	if err := migrateLegacyAPIKeys(); err != nil {
+		return fmt.Errorf("failed to migrate API keys: %v", err)
+	}
+
+	if err := assignUnownedJobs(); err != nil {
+		return fmt.Errorf("failed to assign job owners: %v", err)
+	}
+
 	return nil
 }
 
+// assignUnownedJobs gives jobs without an owner, such as those created before
+// jobs had owners, to the first admin account. Until an admin exists they stay
+// unowned.
+func assignUnownedJobs() error {
+	var admin models.User
+	err := DB.Where("role = ?", models.RoleAdmin).Order("id").Limit(1).Find(&admin).Error
+	if err != nil || admin.ID == 0 {
+		return err
+	}
+	return DB.Unscoped().Model(&models.TranscriptionJob{}).
+		Where("user_id IS NULL").Update("user_id", admin.ID).Error
+}
+
+// migrateLegacyAPIKeys hashes API keys stored in plaintext by earlier versions
+// and drops the plaintext column. The digest must match auth.HashAPIKey.
+func migrateLegacyAPIKeys() error {
+	// HasColumn matches "key" inside "PRIMARY KEY" on SQLite, so inspect the columns directly
+	migrator := DB.Migrator()
+	columns, err := migrator.ColumnTypes(&models.APIKey{})
+	if err != nil {
+		return err
+	}
+	hasLegacyColumn := false
+	for _, column := range columns {
+		if column.Name() == "key" {
+			hasLegacyColumn = true
+			break
+		}
+	}
+	if !hasLegacyColumn {
+		return nil
+	}
+
+	var legacy []struct {
+		models.APIKey
+		Key string
+	}
+	if err := DB.Table("api_keys").Find(&legacy).Error; err != nil {
+		return err
+	}
+
+	// SQLite cannot drop a UNIQUE column in place, so rebuild the table
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropTable("api_keys"); err != nil {
+			return err
+		}
+		if err := tx.Migrator().CreateTable(&models.APIKey{}); err != nil {
+			return err
+		}
+		for _, row := range legacy {
+			apiKey := row.APIKey
+			if apiKey.KeyHash == "" {
+				sum := sha256.Sum256([]byte(row.Key))
+				apiKey.KeyHash = hex.EncodeToString(sum[:])
+				apiKey.KeyPrefix = row.Key
+				if len(apiKey.KeyPrefix) > 8 {
+					apiKey.KeyPrefix = apiKey.KeyPrefix[:8]
+				}
+			}
+			if err := tx.Create(&apiKey).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // Close closes the database connection gracefully
 func Close() error {
 	if DB == nil {
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"scriberr/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// WhereJobFilter narrows a job query to the jobs matching filter. The
+// filter's tags must already be valid.
+func WhereJobFilter(query *gorm.DB, filter models.JobFilter) *gorm.DB {
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Query != "" {
+		pattern := "%" + filter.Query + "%"
+		query = query.Where("title LIKE ? COLLATE NOCASE OR description LIKE ? COLLATE NOCASE OR audio_path LIKE ? COLLATE NOCASE", pattern, pattern, pattern)
+	}
+	if filter.Title != "" {
+		query = query.Where("title LIKE ? COLLATE NOCASE", "%"+filter.Title+"%")
+	}
+	for _, tag := range filter.Tags {
+		key, value, _ := models.ParseTagFilter(tag)
+		query = WhereTagged(query, key, value)
+	}
+	if filter.FolderID != nil {
+		if *filter.FolderID == 0 {
+			query = query.Where("folder_id IS NULL")
+		} else {
+			query = query.Where("folder_id = ?", *filter.FolderID)
+		}
+	}
+	if filter.Engine == models.EngineOpenAI {
+		query = query.Where("engine = ?", filter.Engine)
+	} else if filter.Engine != "" {
+		query = query.Where("model_family = ? AND COALESCE(engine, '') = ''", filter.Engine)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	if !filter.IncludeArchived {
+		query = query.Where("archived_at IS NULL")
+	}
+	return query
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"scriberr/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// JobStatTotals sums the runs that finished in a range
+type JobStatTotals struct {
+	Completed   int64   `json:"completed"`
+	Failed      int64   `json:"failed"`
+	FailureRate float64 `json:"failure_rate"` // Failed share of finished runs, 0.0 - 1.0
+	AudioHours  float64 `json:"audio_hours"`  // Audio transcribed by completed runs
+}
+
+// DailyJobStats sums the runs that finished on one UTC day
+type DailyJobStats struct {
+	Day        string  `json:"day" example:"2026-10-18"`
+	Completed  int64   `json:"completed"`
+	Failed     int64   `json:"failed"`
+	AudioHours float64 `json:"audio_hours"`
+}
+
+// RealtimeFactor is how long completed runs on a model and device took per
+// second of audio. Below 1 is faster than realtime.
+type RealtimeFactor struct {
+	Model          string  `json:"model"`
+	Device         string  `json:"device"`
+	Jobs           int64   `json:"jobs"`
+	RealtimeFactor float64 `json:"realtime_factor"`
+}
+
+// jobStatsBetween scopes a job stats query to runs that finished at or after
+// from and before to. Zero times leave that end open.
+func jobStatsBetween(from, to time.Time) *gorm.DB {
+	query := DB.Model(&models.JobStat{})
+	if !from.IsZero() {
+		query = query.Where("finished_at >= ?", from.UTC())
+	}
+	if !to.IsZero() {
+		query = query.Where("finished_at < ?", to.UTC())
+	}
+	return query
+}
+
+// completedAudioHours sums the audio of completed runs, in hours
+var completedAudioHours = fmt.Sprintf("COALESCE(SUM(CASE WHEN status = '%s' THEN audio_seconds ELSE 0 END), 0) / 3600.0", models.StatusCompleted)
+
+// SumJobStats totals the runs that finished between from and to
+func SumJobStats(from, to time.Time) (JobStatTotals, error) {
+	var totals JobStatTotals
+	err := jobStatsBetween(from, to).
+		Select("COUNT(CASE WHEN status = ? THEN 1 END) AS completed, COUNT(CASE WHEN status = ? THEN 1 END) AS failed, "+completedAudioHours+" AS audio_hours",
+			models.StatusCompleted, models.StatusFailed).
+		Scan(&totals).Error
+	if err != nil {
+		return totals, fmt.Errorf("failed to sum job stats: %w", err)
+	}
+	if finished := totals.Completed + totals.Failed; finished > 0 {
+		totals.FailureRate = float64(totals.Failed) / float64(finished)
+	}
+	return totals, nil
+}
+
+// DailyJobStatsBetween totals the runs that finished between from and to by
+// UTC day, oldest first. Days without runs are left out.
+func DailyJobStatsBetween(from, to time.Time) ([]DailyJobStats, error) {
+	days := []DailyJobStats{}
+	err := jobStatsBetween(from, to).
+		Select("strftime('%Y-%m-%d', finished_at) AS day, COUNT(CASE WHEN status = ? THEN 1 END) AS completed, COUNT(CASE WHEN status = ? THEN 1 END) AS failed, "+completedAudioHours+" AS audio_hours",
+			models.StatusCompleted, models.StatusFailed).
+		Group("day").Order("day").
+		Scan(&days).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to group job stats by day: %w", err)
+	}
+	return days, nil
+}
+
+// RealtimeFactorsBetween averages the realtime factor of runs that completed
+// between from and to for each model and device, like the progress
+// estimator does
+func RealtimeFactorsBetween(from, to time.Time) ([]RealtimeFactor, error) {
+	factors := []RealtimeFactor{}
+	err := jobStatsBetween(from, to).
+		Select("model, device, COUNT(*) AS jobs, AVG(wall_seconds / audio_seconds) AS realtime_factor").
+		Where("status = ? AND audio_seconds > 0", models.StatusCompleted).
+		Group("model, device").Order("model, device").
+		Scan(&factors).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to average realtime factors: %w", err)
+	}
+	return factors, nil
+}
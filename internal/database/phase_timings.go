@@ -0,0 +1,46 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"scriberr/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// phaseTimingsObject is the job's phase timings, or an empty object for jobs
+// that have none
+const phaseTimingsObject = "CASE WHEN json_type(phase_timings) = 'object' THEN phase_timings ELSE '{}' END"
+
+// RecordPhaseTiming stores how long a job spent in a timeline phase,
+// replacing an earlier time for it. Each phase is set in a single statement,
+// so phases recorded at the same time do not overwrite each other.
+func RecordPhaseTiming(jobID, phase string, duration time.Duration) error {
+	err := DB.Model(&models.TranscriptionJob{}).Where("id = ?", jobID).
+		UpdateColumn("phase_timings", gorm.Expr("json_set("+phaseTimingsObject+", ?, ?)", "$."+phase, duration.Milliseconds())).Error
+	if err != nil {
+		return fmt.Errorf("failed to record %s timing: %w", phase, err)
+	}
+	return nil
+}
+
+// StartRunTimings records how long a job waited for a worker and drops the
+// processing phases of its previous run, keeping its upload time
+func StartRunTimings(jobID string, queued time.Duration) error {
+	var processing []string
+	args := []any{}
+	for _, phase := range models.TimelinePhases {
+		if phase != models.TimelineUpload && phase != models.TimelineQueued {
+			processing = append(processing, "?")
+			args = append(args, "$."+phase)
+		}
+	}
+	args = append(args, "$."+models.TimelineQueued, queued.Milliseconds())
+	expr := "json_set(json_remove(" + phaseTimingsObject + ", " + strings.Join(processing, ", ") + "), ?, ?)"
+	if err := DB.Model(&models.TranscriptionJob{}).Where("id = ?", jobID).UpdateColumn("phase_timings", gorm.Expr(expr, args...)).Error; err != nil {
+		return fmt.Errorf("failed to record queue timing: %w", err)
+	}
+	return nil
+}
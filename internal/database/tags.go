@@ -0,0 +1,144 @@
+package database
+
+import (
+	"fmt"
+
+	"scriberr/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AddTag labels a job with a key and value. Adding a tag the job already has
+// does nothing.
+func AddTag(jobID, key, value string) error {
+	tag := models.Tag{JobID: jobID, Key: key, Value: value}
+	if err := DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&tag).Error; err != nil {
+		return fmt.Errorf("failed to add tag: %w", err)
+	}
+	return nil
+}
+
+// TagJobs adds "key:value" tags to every job in jobIDs using tx. Tags a job
+// already has are skipped.
+func TagJobs(tx *gorm.DB, jobIDs []string, tags []string) error {
+	var rows []models.Tag
+	for _, tag := range tags {
+		key, value, err := models.ParseTag(tag)
+		if err != nil {
+			return err
+		}
+		for _, jobID := range jobIDs {
+			rows = append(rows, models.Tag{JobID: jobID, Key: key, Value: value})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(rows, 100).Error; err != nil {
+		return fmt.Errorf("failed to add tags: %w", err)
+	}
+	return nil
+}
+
+// UntagJobs removes tags from every job in jobIDs using tx. Each tag is
+// "key:value", or "key" to remove every value of the key.
+func UntagJobs(tx *gorm.DB, jobIDs []string, tags []string) error {
+	for _, tag := range tags {
+		key, value, err := models.ParseTagFilter(tag)
+		if err != nil {
+			return err
+		}
+		query := tx.Where("job_id IN ? AND key = ?", jobIDs, key)
+		if value != "" {
+			query = query.Where("value = ?", value)
+		}
+		if err := query.Delete(&models.Tag{}).Error; err != nil {
+			return fmt.Errorf("failed to remove tags: %w", err)
+		}
+	}
+	return nil
+}
+
+// TagCount is a tag and how many jobs have it
+type TagCount struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	JobCount int64  `json:"job_count"`
+}
+
+// ListTags returns every tag in use with the number of jobs that have it,
+// ordered by key and value. Scopes narrow the jobs counted.
+func ListTags(scopes ...func(*gorm.DB) *gorm.DB) ([]TagCount, error) {
+	var counts []TagCount
+	live := DB.Model(&models.TranscriptionJob{}).Scopes(scopes...).Select("id")
+	if err := DB.Model(&models.Tag{}).Select("key, value, COUNT(*) AS job_count").
+		Where("job_id IN (?)", live).Group("key, value").Order("key, value").Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	return counts, nil
+}
+
+// RemoveTag removes a value of a job's tag, or every value of the key when
+// value is empty. It returns the number of tags removed.
+func RemoveTag(jobID, key, value string) (int64, error) {
+	conditions := map[string]interface{}{"job_id": jobID, "key": key}
+	if value != "" {
+		conditions["value"] = value
+	}
+	result := DB.Where(conditions).Delete(&models.Tag{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to remove tag: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// GetTags returns a job's tags, the values of each key in the order they
+// were added
+func GetTags(jobID string) (map[string][]string, error) {
+	tags, err := GetTagsForJobs([]string{jobID})
+	if err != nil {
+		return nil, err
+	}
+	if tags[jobID] == nil {
+		return map[string][]string{}, nil
+	}
+	return tags[jobID], nil
+}
+
+// GetTagsForJobs returns the tags of several jobs keyed by job ID. Jobs
+// without tags are left out.
+func GetTagsForJobs(jobIDs []string) (map[string]map[string][]string, error) {
+	var tags []models.Tag
+	if err := DB.Where("job_id IN ?", jobIDs).Order("id").Find(&tags).Error; err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	byJob := make(map[string]map[string][]string)
+	for _, tag := range tags {
+		if byJob[tag.JobID] == nil {
+			byJob[tag.JobID] = make(map[string][]string)
+		}
+		byJob[tag.JobID][tag.Key] = append(byJob[tag.JobID][tag.Key], tag.Value)
+	}
+	return byJob, nil
+}
+
+// ListJobsByTag returns the jobs tagged with a key and value, newest first.
+// An empty value matches any value of the key.
+func ListJobsByTag(key, value string) ([]models.TranscriptionJob, error) {
+	var jobs []models.TranscriptionJob
+	if err := WhereTagged(DB.Model(&models.TranscriptionJob{}), key, value).Order("created_at DESC").Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list jobs by tag: %w", err)
+	}
+	return jobs, nil
+}
+
+// WhereTagged narrows a job query to the jobs tagged with a key and value, or
+// with any value of the key when value is empty
+func WhereTagged(query *gorm.DB, key, value string) *gorm.DB {
+	conditions := map[string]interface{}{"key": key}
+	if value != "" {
+		conditions["value"] = value
+	}
+	return query.Where("id IN (?)", DB.Model(&models.Tag{}).Select("job_id").Where(conditions))
+}
@@ -0,0 +1,212 @@
+// Package diff compares transcripts word by word
+package diff
+
+import (
+	"math"
+	"strings"
+)
+
+// Op is the kind of change an Edit makes
+type Op int
+
+// Edit operations
+const (
+	Equal Op = iota
+	Insert
+	Delete
+)
+
+// Edit is a run of words kept, inserted or deleted
+type Edit struct {
+	Op    Op
+	Words []string
+}
+
+// Result summarizes how one transcript differs from another
+type Result struct {
+	Added        []string `json:"added"`         // Runs of words only in the new transcript
+	Removed      []string `json:"removed"`       // Runs of words only in the old transcript
+	UnchangedPct float64  `json:"unchanged_pct"` // Share of words the transcripts have in common, 0.0 - 1.0
+}
+
+// Compare diffs the words of two transcripts. Words are split on whitespace
+// and compared exactly, so changes of case or punctuation count as changes.
+func Compare(old, new string) Result {
+	a, b := strings.Fields(old), strings.Fields(new)
+	result := Result{Added: []string{}, Removed: []string{}, UnchangedPct: 1}
+
+	unchanged := 0
+	for _, edit := range Words(a, b) {
+		switch edit.Op {
+		case Equal:
+			unchanged += len(edit.Words)
+		case Insert:
+			result.Added = append(result.Added, strings.Join(edit.Words, " "))
+		case Delete:
+			result.Removed = append(result.Removed, strings.Join(edit.Words, " "))
+		}
+	}
+	if total := len(a) + len(b); total > 0 {
+		result.UnchangedPct = math.Round(2*float64(unchanged)/float64(total)*100) / 100
+	}
+	return result
+}
+
+// Words returns the shortest list of edits that turns a into b, with
+// neighbouring edits of the same kind merged. It uses Myers' algorithm,
+// recursing on the middle snake so memory stays linear in the input.
+func Words(a, b []string) []Edit {
+	return merge(diff(a, b, nil))
+}
+
+// diff appends the edits turning a into b to edits
+func diff(a, b []string, edits []Edit) []Edit {
+	// Common prefix and suffix are kept as they are
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	if prefix > 0 {
+		edits = append(edits, Edit{Op: Equal, Words: a[:prefix]})
+		a, b = a[prefix:], b[prefix:]
+	}
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	switch {
+	case len(a) == 0 && len(b) == 0:
+	case len(a) == 0:
+		edits = append(edits, Edit{Op: Insert, Words: b})
+	case len(b) == 0:
+		edits = append(edits, Edit{Op: Delete, Words: a})
+	default:
+		if x, y, ok := middleSnake(a, b); ok {
+			edits = diff(a[:x], b[:y], edits)
+			edits = diff(a[x:], b[y:], edits)
+		} else {
+			edits = append(edits, Edit{Op: Delete, Words: a}, Edit{Op: Insert, Words: b})
+		}
+	}
+
+	if suffix > 0 {
+		edits = append(edits, Edit{Op: Equal, Words: common})
+	}
+	return edits
+}
+
+// middleSnake finds where the forward and reverse searches for the shortest
+// edit path meet, returning the point in a and b to split the problem at.
+// It reports false when the inputs have no word in common.
+func middleSnake(a, b []string) (int, int, bool) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	forward := make([]int, 2*maxD+2)
+	reverse := make([]int, 2*maxD+2)
+	for i := range forward {
+		forward[i], reverse[i] = -1, -1
+	}
+	forward[offset+1], reverse[offset+1] = 0, 0
+
+	delta := n - m
+	odd := delta%2 != 0
+	// Diagonals that have run off the edge of the grid are not searched again
+	var fStart, fEnd, rStart, rEnd int
+	for d := 0; d < maxD; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && forward[i-1] < forward[i+1]) {
+				x = forward[i+1]
+			} else {
+				x = forward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[i] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				if j := offset + delta - k; j >= 0 && j < len(reverse) && reverse[j] != -1 && x >= n-reverse[j] {
+					return x, y, true
+				}
+			}
+		}
+
+		for k := -d + rStart; k <= d-rEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && reverse[i-1] < reverse[i+1]) {
+				x = reverse[i+1]
+			} else {
+				x = reverse[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x++
+				y++
+			}
+			reverse[i] = x
+			switch {
+			case x > n:
+				rEnd += 2
+			case y > m:
+				rStart += 2
+			case !odd:
+				if j := offset + delta - k; j >= 0 && j < len(forward) && forward[j] != -1 {
+					fx := forward[j]
+					if fx >= n-x {
+						return fx, offset + fx - j, true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// merge joins neighbouring edits of the same kind, reporting each changed
+// stretch between kept words as one deletion followed by one insertion
+func merge(edits []Edit) []Edit {
+	var merged []Edit
+	var deleted, inserted []string
+	flush := func() {
+		if len(deleted) > 0 {
+			merged = append(merged, Edit{Op: Delete, Words: deleted})
+		}
+		if len(inserted) > 0 {
+			merged = append(merged, Edit{Op: Insert, Words: inserted})
+		}
+		deleted, inserted = nil, nil
+	}
+	for _, edit := range edits {
+		switch edit.Op {
+		case Delete:
+			deleted = append(deleted, edit.Words...)
+		case Insert:
+			inserted = append(inserted, edit.Words...)
+		case Equal:
+			if len(edit.Words) == 0 {
+				continue
+			}
+			flush()
+			if last := len(merged) - 1; last >= 0 && merged[last].Op == Equal {
+				merged[last].Words = append(append([]string(nil), merged[last].Words...), edit.Words...)
+				continue
+			}
+			merged = append(merged, edit)
+		}
+	}
+	flush()
+	return merged
+}
@@ -0,0 +1,144 @@
+package diff
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name      string
+		old, new  string
+		added     []string
+		removed   []string
+		unchanged float64
+	}{
+		{
+			name:      "identical",
+			old:       "the quick brown fox",
+			new:       "the  quick brown\nfox",
+			added:     []string{},
+			removed:   []string{},
+			unchanged: 1,
+		},
+		{
+			name:      "word replaced",
+			old:       "we moved to the coast in may",
+			new:       "we moved to the cost in may",
+			added:     []string{"cost"},
+			removed:   []string{"coast"},
+			unchanged: 0.86,
+		},
+		{
+			name:      "words inserted and removed",
+			old:       "so um I think that we should go",
+			new:       "so I think that we should really go now",
+			added:     []string{"really", "now"},
+			removed:   []string{"um"},
+			unchanged: 0.82,
+		},
+		{
+			name:      "punctuation counts",
+			old:       "Hello, world.",
+			new:       "hello world",
+			added:     []string{"hello world"},
+			removed:   []string{"Hello, world."},
+			unchanged: 0,
+		},
+		{
+			name:      "empty old transcript",
+			old:       "",
+			new:       "new words",
+			added:     []string{"new words"},
+			removed:   []string{},
+			unchanged: 0,
+		},
+		{
+			name:      "both empty",
+			added:     []string{},
+			removed:   []string{},
+			unchanged: 1,
+		},
+	}
+	for _, tt := range tests {
+		got := Compare(tt.old, tt.new)
+		if !reflect.DeepEqual(got.Added, tt.added) || !reflect.DeepEqual(got.Removed, tt.removed) {
+			t.Errorf("%s: expected added %q removed %q, got added %q removed %q", tt.name, tt.added, tt.removed, got.Added, got.Removed)
+		}
+		if got.UnchangedPct != tt.unchanged {
+			t.Errorf("%s: expected unchanged_pct %.2f, got %.2f", tt.name, tt.unchanged, got.UnchangedPct)
+		}
+	}
+}
+
+func TestWordsMergesRuns(t *testing.T) {
+	edits := Words(strings.Fields("a b c d e"), strings.Fields("a x y d e f"))
+	want := []Edit{
+		{Op: Equal, Words: []string{"a"}},
+		{Op: Delete, Words: []string{"b", "c"}},
+		{Op: Insert, Words: []string{"x", "y"}},
+		{Op: Equal, Words: []string{"d", "e"}},
+		{Op: Insert, Words: []string{"f"}},
+	}
+	if !reflect.DeepEqual(edits, want) {
+		t.Errorf("Expected %v, got %v", want, edits)
+	}
+}
+
+// TestWordsIsMinimal checks random word lists against a dynamic programming
+// longest common subsequence: applying the edits must give the new list, and
+// the words kept must be as many as the lists have in common
+func TestWordsIsMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	vocabulary := []string{"a", "b", "c", "d"}
+	randomWords := func() []string {
+		words := make([]string, rng.Intn(30))
+		for i := range words {
+			words[i] = vocabulary[rng.Intn(len(vocabulary))]
+		}
+		return words
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := randomWords(), randomWords()
+		var fromA, toB []string
+		kept := 0
+		for _, edit := range Words(a, b) {
+			switch edit.Op {
+			case Equal:
+				kept += len(edit.Words)
+				fromA = append(fromA, edit.Words...)
+				toB = append(toB, edit.Words...)
+			case Delete:
+				fromA = append(fromA, edit.Words...)
+			case Insert:
+				toB = append(toB, edit.Words...)
+			}
+		}
+		if strings.Join(fromA, " ") != strings.Join(a, " ") || strings.Join(toB, " ") != strings.Join(b, " ") {
+			t.Fatalf("Edits do not rebuild the inputs for %v and %v", a, b)
+		}
+		if want := lcsLength(a, b); kept != want {
+			t.Fatalf("Expected %d words kept for %v and %v, got %d", want, a, b, kept)
+		}
+	}
+}
+
+func lcsLength(a, b []string) int {
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] == b[j-1] {
+				table[i][j] = table[i-1][j-1] + 1
+			} else {
+				table[i][j] = max(table[i-1][j], table[i][j-1])
+			}
+		}
+	}
+	return table[len(a)][len(b)]
+}
@@ -0,0 +1,198 @@
+// Package diskspace reports free space on the volumes Scriberr writes to and
+// how much of it each kind of data uses
+package diskspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"scriberr/internal/events"
+	"scriberr/pkg/logger"
+)
+
+// ErrInsufficientSpace is returned when a volume has less free space than required
+var ErrInsufficientSpace = errors.New("insufficient free disk space")
+
+// Volume is the space on the filesystem holding a path
+type Volume struct {
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	TotalBytes uint64 `json:"total_bytes"`
+	FreeBytes  uint64 `json:"free_bytes"`
+	UsedBytes  uint64 `json:"used_bytes"`
+}
+
+// Space reports the filesystem holding path. Paths that do not exist yet are
+// measured on their nearest existing parent, where they will be created.
+func Space(name, path string) (*Volume, error) {
+	existing, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, err := os.Stat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		existing = parent
+	}
+	total, free, err := volumeSpace(existing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read free space of %s: %w", path, err)
+	}
+	volume := &Volume{Name: name, Path: path, TotalBytes: total, FreeBytes: free}
+	if total > free {
+		volume.UsedBytes = total - free
+	}
+	return volume, nil
+}
+
+// Category is a kind of data and where it is kept
+type Category struct {
+	Name  string
+	Paths []string // Files or directories, counted recursively
+}
+
+// CategoryUsage is how much space a category takes up
+type CategoryUsage struct {
+	Name  string `json:"name"`
+	Bytes int64  `json:"bytes"`
+}
+
+// Monitor walks each category's paths in the background and caches their
+// size, since walking large upload folders is too slow for every request
+type Monitor struct {
+	categories []Category
+
+	mu        sync.RWMutex
+	usage     []CategoryUsage
+	scannedAt time.Time
+}
+
+// NewMonitor creates a monitor for the given categories
+func NewMonitor(categories []Category) *Monitor {
+	return &Monitor{categories: categories}
+}
+
+// Refresh walks every category now
+func (m *Monitor) Refresh() {
+	usage := make([]CategoryUsage, 0, len(m.categories))
+	for _, category := range m.categories {
+		var bytes int64
+		for _, path := range category.Paths {
+			bytes += PathSize(path)
+		}
+		usage = append(usage, CategoryUsage{Name: category.Name, Bytes: bytes})
+	}
+
+	m.mu.Lock()
+	m.usage = usage
+	m.scannedAt = time.Now()
+	m.mu.Unlock()
+}
+
+// Usage returns the cached sizes and when they were measured. The first call
+// before any scan measures them itself.
+func (m *Monitor) Usage() ([]CategoryUsage, time.Time) {
+	m.mu.RLock()
+	usage, scannedAt := m.usage, m.scannedAt
+	m.mu.RUnlock()
+	if scannedAt.IsZero() {
+		m.Refresh()
+		return m.Usage()
+	}
+	return usage, scannedAt
+}
+
+// Start refreshes the sizes now and then every interval until ctx is done
+func (m *Monitor) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		m.Refresh()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.Refresh()
+			}
+		}
+	}()
+}
+
+// PathSize totals the regular files at or under path, skipping anything
+// unreadable. Symlinks are not followed, so files they point at are not
+// counted twice.
+func PathSize(path string) int64 {
+	var total int64
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+// warningInterval limits how often a low disk space warning is logged
+const warningInterval = time.Hour
+
+// Guard refuses new work when a volume runs low on space
+type Guard struct {
+	path         string
+	minFreeBytes uint64
+
+	mu         sync.Mutex
+	lastWarned time.Time
+}
+
+// NewGuard creates a guard requiring minFreeBytes free on the volume holding
+// path. Zero disables the guard.
+func NewGuard(path string, minFreeBytes uint64) *Guard {
+	return &Guard{path: path, minFreeBytes: minFreeBytes}
+}
+
+// Check returns ErrInsufficientSpace when the volume has less free space than
+// required, logging a warning at most once an hour. Volumes whose space
+// cannot be read are allowed.
+func (g *Guard) Check() error {
+	if g == nil || g.minFreeBytes == 0 {
+		return nil
+	}
+	volume, err := Space("", g.path)
+	if err != nil {
+		logger.Debug("Could not check free disk space", "path", g.path, "error", err)
+		return nil
+	}
+	if volume.FreeBytes >= g.minFreeBytes {
+		return nil
+	}
+
+	err = fmt.Errorf("%w: %d MB free on the upload volume, %d MB required", ErrInsufficientSpace, volume.FreeBytes>>20, g.minFreeBytes>>20)
+	g.mu.Lock()
+	if time.Since(g.lastWarned) >= warningInterval {
+		g.lastWarned = time.Now()
+		logger.Warn("Low disk space; refusing uploads",
+			"path", g.path,
+			"free_mb", volume.FreeBytes>>20,
+			"min_free_mb", g.minFreeBytes>>20)
+		events.Publish(events.Event{Type: events.TypeStorageWarning, Message: err.Error()})
+	}
+	g.mu.Unlock()
+	return err
+}
@@ -0,0 +1,74 @@
+package diskspace
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSpace(t *testing.T) {
+	dir := t.TempDir()
+	volume, err := Space("uploads", filepath.Join(dir, "not", "created", "yet"))
+	if err != nil {
+		t.Fatalf("Space failed: %v", err)
+	}
+	if volume.TotalBytes == 0 || volume.FreeBytes > volume.TotalBytes {
+		t.Errorf("Expected free space within a non-empty volume, got %+v", volume)
+	}
+	if volume.Name != "uploads" {
+		t.Errorf("Expected the volume name to be kept, got %q", volume.Name)
+	}
+}
+
+func TestMonitorUsage(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "audio", "nested"), 0755)
+	os.WriteFile(filepath.Join(dir, "audio", "a.wav"), make([]byte, 100), 0644)
+	os.WriteFile(filepath.Join(dir, "audio", "nested", "b.wav"), make([]byte, 50), 0644)
+	os.WriteFile(filepath.Join(dir, "scriberr.db"), make([]byte, 30), 0644)
+
+	monitor := NewMonitor([]Category{
+		{Name: "audio", Paths: []string{filepath.Join(dir, "audio")}},
+		{Name: "transcripts_db", Paths: []string{filepath.Join(dir, "scriberr.db"), filepath.Join(dir, "scriberr.db-wal")}},
+	})
+	usage, scannedAt := monitor.Usage()
+	if scannedAt.IsZero() {
+		t.Fatal("Expected the first call to scan")
+	}
+	want := map[string]int64{"audio": 150, "transcripts_db": 30}
+	for _, category := range usage {
+		if category.Bytes != want[category.Name] {
+			t.Errorf("Expected %s to use %d bytes, got %d", category.Name, want[category.Name], category.Bytes)
+		}
+	}
+
+	// Sizes are cached until the next refresh
+	os.WriteFile(filepath.Join(dir, "audio", "c.wav"), make([]byte, 10), 0644)
+	if usage, _ := monitor.Usage(); usage[0].Bytes != 150 {
+		t.Errorf("Expected the cached size, got %d", usage[0].Bytes)
+	}
+	monitor.Refresh()
+	if usage, _ := monitor.Usage(); usage[0].Bytes != 160 {
+		t.Errorf("Expected the refreshed size, got %d", usage[0].Bytes)
+	}
+}
+
+func TestGuard(t *testing.T) {
+	dir := t.TempDir()
+	if err := NewGuard(dir, 0).Check(); err != nil {
+		t.Errorf("Expected a zero threshold to disable the guard, got %v", err)
+	}
+	if err := NewGuard(dir, 1).Check(); err != nil {
+		t.Errorf("Expected a one byte threshold to pass, got %v", err)
+	}
+	guard := NewGuard(dir, 1<<62)
+	if err := guard.Check(); !errors.Is(err, ErrInsufficientSpace) {
+		t.Fatalf("Expected ErrInsufficientSpace, got %v", err)
+	}
+	warned := guard.lastWarned
+	guard.Check()
+	if guard.lastWarned != warned {
+		t.Error("Expected the warning to be logged once per interval")
+	}
+}
@@ -0,0 +1,16 @@
+//go:build linux || darwin
+
+package diskspace
+
+import "syscall"
+
+// volumeSpace reports the size and the space available to unprivileged users
+// of the filesystem holding path
+func volumeSpace(path string) (total, free uint64, err error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, 0, err
+	}
+	blockSize := uint64(stat.Bsize)
+	return stat.Blocks * blockSize, stat.Bavail * blockSize, nil
+}
@@ -1,6 +1,7 @@
 package dropzone
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -12,6 +13,7 @@ import (
 	"scriberr/internal/config"
 	"scriberr/internal/database"
 	"scriberr/internal/models"
+	"scriberr/internal/storage"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/google/uuid"
@@ -241,6 +243,7 @@ func (s *Service) uploadFile(sourcePath, originalFilename string) error {
 		AudioPath: destPath,
 		Status:    models.StatusUploaded,
 		Title:     &originalFilename, // Use original filename as title
+		FileSize:  storage.Size(context.Background(), destPath),
 	}
 
 	// Save to database
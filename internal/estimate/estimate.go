@@ -0,0 +1,166 @@
+// Package estimate predicts how long a transcription will take from the
+// audio length, using the processing speed of past jobs when there are
+// enough of them and configured throughput factors otherwise
+package estimate
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+)
+
+// Confidence levels
+const (
+	ConfidenceLow    = "low"    // From configured factors
+	ConfidenceMedium = "medium" // From a few past jobs
+	ConfidenceHigh   = "high"   // From many past jobs
+)
+
+const (
+	// historyLimit is how many recent jobs of a model and device are considered
+	historyLimit = 100
+	// historyPercentile is the ratio percentile used, so most jobs finish
+	// within the estimate
+	historyPercentile = 0.75
+	// minHistorySamples is how many past jobs are needed to use history
+	minHistorySamples = 3
+	// highConfidenceSamples is how many past jobs give a high confidence
+	highConfidenceSamples = 20
+)
+
+//go:embed factors.yaml
+var defaultFactors []byte
+
+// Factors holds the processing seconds per second of audio for each model
+// and device
+type Factors struct {
+	DefaultRatio float64                       `yaml:"default_ratio"`
+	Models       map[string]map[string]float64 `yaml:"models"`
+}
+
+// LoadFactors reads factors from a YAML file, or the built-in factors when
+// path is empty
+func LoadFactors(path string) (*Factors, error) {
+	data := defaultFactors
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("failed to read estimate factors: %w", err)
+		}
+	}
+	var factors Factors
+	if err := yaml.Unmarshal(data, &factors); err != nil {
+		return nil, fmt.Errorf("failed to parse estimate factors: %w", err)
+	}
+	if factors.DefaultRatio <= 0 {
+		factors.DefaultRatio = 1
+	}
+	return &factors, nil
+}
+
+// Ratio returns the factor for a model and device. English-only models share
+// the factors of their multilingual counterpart.
+func (f *Factors) Ratio(model, device string) float64 {
+	for _, name := range []string{model, strings.TrimSuffix(model, ".en")} {
+		if ratio, ok := f.Models[name][device]; ok && ratio > 0 {
+			return ratio
+		}
+	}
+	return f.DefaultRatio
+}
+
+// Estimate is the predicted processing time of a transcription
+type Estimate struct {
+	EstimatedSeconds float64 `json:"estimated_seconds"`
+	Confidence       string  `json:"confidence"`
+	Samples          int     `json:"samples"` // Past jobs the estimate is based on
+}
+
+// Estimator predicts processing times
+type Estimator struct {
+	factors *Factors
+}
+
+// NewEstimator creates an estimator falling back to factors
+func NewEstimator(factors *Factors) *Estimator {
+	return &Estimator{factors: factors}
+}
+
+// Estimate predicts how long audio of durationSeconds takes to transcribe
+// with a model on a device
+func (e *Estimator) Estimate(ctx context.Context, durationSeconds float64, model, device string) (Estimate, error) {
+	ratios, err := historicalRatios(ctx, model, device)
+	if err != nil {
+		return Estimate{}, err
+	}
+
+	if len(ratios) < minHistorySamples {
+		return Estimate{
+			EstimatedSeconds: roundSeconds(durationSeconds * e.factors.Ratio(model, device)),
+			Confidence:       ConfidenceLow,
+			Samples:          len(ratios),
+		}, nil
+	}
+
+	confidence := ConfidenceMedium
+	if len(ratios) >= highConfidenceSamples {
+		confidence = ConfidenceHigh
+	}
+	return Estimate{
+		EstimatedSeconds: roundSeconds(durationSeconds * percentile(ratios, historyPercentile)),
+		Confidence:       confidence,
+		Samples:          len(ratios),
+	}, nil
+}
+
+// historicalRatios returns processing time over audio length for the most
+// recent completed jobs run with a model on a device
+func historicalRatios(ctx context.Context, model, device string) ([]float64, error) {
+	var rows []struct {
+		ProcessingDuration int64
+		DurationSeconds    float64
+	}
+	err := database.DB.WithContext(ctx).Model(&models.TranscriptionJobExecution{}).
+		Select("transcription_job_executions.processing_duration, transcription_jobs.duration_seconds").
+		Joins("JOIN transcription_jobs ON transcription_jobs.id = transcription_job_executions.transcription_job_id").
+		Where("transcription_job_executions.status = ? AND transcription_job_executions.actual_model = ? AND transcription_job_executions.actual_device = ?",
+			models.StatusCompleted, model, device).
+		Where("transcription_job_executions.processing_duration > 0 AND transcription_jobs.duration_seconds > 0").
+		Order("transcription_job_executions.completed_at DESC").
+		Limit(historyLimit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load job history: %w", err)
+	}
+
+	ratios := make([]float64, len(rows))
+	for i, row := range rows {
+		ratios[i] = float64(row.ProcessingDuration) / 1000 / row.DurationSeconds
+	}
+	return ratios, nil
+}
+
+// percentile returns the nearest-rank percentile p of values
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// roundSeconds rounds to a tenth of a second
+func roundSeconds(seconds float64) float64 {
+	return math.Round(seconds*10) / 10
+}
@@ -0,0 +1,63 @@
+package estimate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDefaultFactors(t *testing.T) {
+	factors, err := LoadFactors("")
+	if err != nil {
+		t.Fatalf("LoadFactors: %v", err)
+	}
+	if got := factors.Ratio("small", "cuda"); got != 0.04 {
+		t.Errorf("small/cuda ratio = %v, want 0.04", got)
+	}
+	if got, want := factors.Ratio("small.en", "cpu"), factors.Ratio("small", "cpu"); got != want {
+		t.Errorf("small.en/cpu ratio = %v, want %v", got, want)
+	}
+	if got := factors.Ratio("unknown", "cpu"); got != factors.DefaultRatio {
+		t.Errorf("unknown model ratio = %v, want default %v", got, factors.DefaultRatio)
+	}
+}
+
+func TestLoadFactorsFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "factors.yaml")
+	if err := os.WriteFile(path, []byte("models:\n  tiny:\n    cpu: 0.5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	factors, err := LoadFactors(path)
+	if err != nil {
+		t.Fatalf("LoadFactors: %v", err)
+	}
+	if got := factors.Ratio("tiny", "cpu"); got != 0.5 {
+		t.Errorf("tiny/cpu ratio = %v, want 0.5", got)
+	}
+	if got := factors.Ratio("tiny", "cuda"); got != 1 {
+		t.Errorf("missing ratio = %v, want 1 when default_ratio is unset", got)
+	}
+
+	if err := os.WriteFile(path, []byte("models: [not a map"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFactors(path); err == nil {
+		t.Error("expected an error for invalid YAML")
+	}
+	if _, err := LoadFactors(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{0.4, 0.1, 0.3, 0.2}
+	if got := percentile(values, 0.75); got != 0.3 {
+		t.Errorf("p75 = %v, want 0.3", got)
+	}
+	if values[0] != 0.4 {
+		t.Error("percentile reordered its input")
+	}
+	if got := percentile([]float64{0.7}, 0.75); got != 0.7 {
+		t.Errorf("p75 of one value = %v, want 0.7", got)
+	}
+}
@@ -0,0 +1,252 @@
+// Package events is the in-process bus queue-wide events are published on:
+// jobs created, changing status and making progress, workers starting and
+// stopping, and storage warnings. Subscribers such as dashboard sockets each
+// get a bounded queue, so a slow one never holds up publishers.
+package events
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	TypeJobCreated     = "job.created"
+	TypeJobStatus      = "job.status"
+	TypeJobProgress    = "job.progress"
+	TypeWorkerStarted  = "worker.started"
+	TypeWorkerStopped  = "worker.stopped"
+	TypeStorageWarning = "storage.warning"
+)
+
+// Types lists every event type
+var Types = []string{TypeJobCreated, TypeJobStatus, TypeJobProgress, TypeWorkerStarted, TypeWorkerStopped, TypeStorageWarning}
+
+// ErrSlowSubscriber ends a subscription whose queue filled up with events
+// that may not be dropped
+var ErrSlowSubscriber = errors.New("subscriber is too slow to keep up with events")
+
+// Event is something that happened in the queue
+type Event struct {
+	Type     string    `json:"type"`
+	JobID    string    `json:"job_id,omitempty"`
+	UserID   *uint     `json:"user_id,omitempty"`   // Owner of the job
+	FolderID *uint     `json:"folder_id,omitempty"` // Folder the job is filed in
+	Status   string    `json:"status,omitempty"`
+	Progress *float64  `json:"progress,omitempty"` // 0.0 - 1.0
+	WorkerID *int      `json:"worker_id,omitempty"`
+	Message  string    `json:"message,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// Droppable reports whether the event may be left out for a slow subscriber.
+// Only progress ticks may, as the next tick or status supersedes them.
+func (e Event) Droppable() bool {
+	return e.Type == TypeJobProgress
+}
+
+// Bus delivers published events to its subscribers. It is safe for
+// concurrent use.
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+// NewBus creates a bus without subscribers
+func NewBus() *Bus {
+	return &Bus{subs: make(map[*Subscription]struct{})}
+}
+
+// Default is the bus the queue publishes on
+var Default = NewBus()
+
+// Publish publishes an event on the default bus
+func Publish(e Event) {
+	Default.Publish(e)
+}
+
+// Publish delivers an event to every subscriber whose filter accepts it,
+// without waiting for any of them
+func (b *Bus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subs {
+		sub.deliver(e)
+	}
+}
+
+// Subscribers returns the number of subscriptions, so publishers can skip
+// building events nobody receives
+func (b *Bus) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
+
+// Subscribe subscribes to the events filter accepts, or all with a nil
+// filter, queueing up to size of them. When the queue is full, progress
+// ticks are dropped, calling onDrop if set; when it is full of events that
+// may not be dropped, the subscription ends with ErrSlowSubscriber.
+func (b *Bus) Subscribe(size int, filter func(Event) bool, onDrop func()) *Subscription {
+	if size < 1 {
+		size = 1
+	}
+	sub := &Subscription{
+		bus:    b,
+		filter: filter,
+		onDrop: onDrop,
+		size:   size,
+		ready:  make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+// Subscription is a subscriber's queue of events
+type Subscription struct {
+	bus    *Bus
+	filter func(Event) bool
+	onDrop func()
+	size   int
+
+	mu      sync.Mutex
+	queue   []Event
+	ready   chan struct{}
+	done    chan struct{}
+	err     error
+	closed  bool
+	dropped uint64
+}
+
+// Ready is signalled when events are queued
+func (s *Subscription) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Done is closed when the subscription ends
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns ErrSlowSubscriber if the subscription ended because the
+// subscriber fell behind, or nil
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Events takes the queued events, oldest first
+func (s *Subscription) Events() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	queued := s.queue
+	s.queue = nil
+	return queued
+}
+
+// Dropped returns the number of progress ticks dropped so far
+func (s *Subscription) Dropped() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Close ends the subscription
+func (s *Subscription) Close() {
+	s.end(nil)
+	s.leave()
+}
+
+// end marks the subscription ended, reporting whether it was still open
+func (s *Subscription) end(err error) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.closed = true
+	s.err = err
+	s.queue = nil
+	close(s.done)
+	return true
+}
+
+// leave removes the subscription from its bus
+func (s *Subscription) leave() {
+	s.bus.mu.Lock()
+	delete(s.bus.subs, s)
+	s.bus.mu.Unlock()
+}
+
+// deliver queues an event the subscription accepts
+func (s *Subscription) deliver(e Event) {
+	if s.filter != nil && !s.filter(e) {
+		return
+	}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	// A newer tick for a job replaces the one still queued
+	if e.Droppable() {
+		for i, queued := range s.queue {
+			if queued.Type == e.Type && queued.JobID == e.JobID {
+				s.queue = append(s.queue[:i], s.queue[i+1:]...)
+				s.drop()
+				break
+			}
+		}
+	}
+	if len(s.queue) >= s.size && !s.makeRoom(e) {
+		s.mu.Unlock()
+		// Publish holds the bus's read lock, so leave it from elsewhere
+		if s.end(ErrSlowSubscriber) {
+			go s.leave()
+		}
+		return
+	}
+	if len(s.queue) < s.size {
+		s.queue = append(s.queue, e)
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// makeRoom frees a place in the full queue for e by dropping a progress
+// tick, either e itself or a queued one. It returns false if nothing can be
+// dropped. Callers must hold s.mu.
+func (s *Subscription) makeRoom(e Event) bool {
+	if e.Droppable() {
+		s.drop()
+		return true
+	}
+	for i, queued := range s.queue {
+		if queued.Droppable() {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			s.drop()
+			return true
+		}
+	}
+	return false
+}
+
+// drop counts a dropped progress tick. Callers must hold s.mu.
+func (s *Subscription) drop() {
+	s.dropped++
+	if s.onDrop != nil {
+		s.onDrop()
+	}
+}
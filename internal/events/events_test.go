@@ -0,0 +1,88 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func progressTick(jobID string, progress float64) Event {
+	return Event{Type: TypeJobProgress, JobID: jobID, Progress: &progress}
+}
+
+func TestPublishFilters(t *testing.T) {
+	bus := NewBus()
+	all := bus.Subscribe(10, nil, nil)
+	jobA := bus.Subscribe(10, func(e Event) bool { return e.JobID == "a" }, nil)
+
+	bus.Publish(Event{Type: TypeJobCreated, JobID: "a"})
+	bus.Publish(Event{Type: TypeJobCreated, JobID: "b"})
+
+	select {
+	case <-all.Ready():
+	default:
+		t.Fatal("Expected the subscription to be signalled")
+	}
+	if got := all.Events(); len(got) != 2 || got[0].JobID != "a" || got[1].JobID != "b" || got[0].Time.IsZero() {
+		t.Errorf("Expected both events in order with their time, got %+v", got)
+	}
+	if got := jobA.Events(); len(got) != 1 || got[0].JobID != "a" {
+		t.Errorf("Expected only job a, got %+v", got)
+	}
+
+	jobA.Close()
+	if bus.Subscribers() != 1 {
+		t.Errorf("Expected 1 subscriber after closing one, got %d", bus.Subscribers())
+	}
+	bus.Publish(Event{Type: TypeJobCreated, JobID: "a"})
+	if got := jobA.Events(); len(got) != 0 {
+		t.Errorf("Expected nothing after closing, got %+v", got)
+	}
+}
+
+func TestSlowSubscriberDropsProgress(t *testing.T) {
+	bus := NewBus()
+	drops := 0
+	sub := bus.Subscribe(3, nil, func() { drops++ })
+
+	// A newer tick for a job replaces the queued one
+	bus.Publish(progressTick("a", 0.1))
+	bus.Publish(progressTick("a", 0.2))
+	bus.Publish(progressTick("b", 0.1))
+	bus.Publish(Event{Type: TypeJobStatus, JobID: "c", Status: "completed"})
+	// The queue is full: a tick is dropped, and a status makes room by
+	// dropping a queued tick
+	bus.Publish(progressTick("c", 0.5))
+	bus.Publish(Event{Type: TypeJobStatus, JobID: "a", Status: "completed"})
+
+	got := sub.Events()
+	if len(got) != 3 || got[0].JobID != "b" || got[1].Status != "completed" || got[2].JobID != "a" || got[2].Type != TypeJobStatus {
+		t.Fatalf("Expected b's tick and both statuses, got %+v", got)
+	}
+	if drops != 3 || sub.Dropped() != 3 {
+		t.Errorf("Expected 3 drops, got %d and %d", drops, sub.Dropped())
+	}
+}
+
+func TestSlowSubscriberEnds(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe(2, nil, nil)
+	for _, job := range []string{"a", "b", "c"} {
+		bus.Publish(Event{Type: TypeJobStatus, JobID: job, Status: "completed"})
+	}
+
+	select {
+	case <-sub.Done():
+	default:
+		t.Fatal("Expected the subscription to end rather than drop a status")
+	}
+	if sub.Err() != ErrSlowSubscriber {
+		t.Errorf("Expected ErrSlowSubscriber, got %v", sub.Err())
+	}
+	deadline := time.Now().Add(time.Second)
+	for bus.Subscribers() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if bus.Subscribers() != 0 {
+		t.Error("Expected the ended subscription to leave the bus")
+	}
+}
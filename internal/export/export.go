@@ -0,0 +1,356 @@
+// Package export renders transcripts as subtitle and text files
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Default cue layout, following common broadcast subtitle guidelines
+const (
+	DefaultMaxCharsPerLine = 42
+	DefaultMaxLinesPerCue  = 2
+)
+
+// utf8BOM is the byte order mark some subtitle players need to detect UTF-8
+const utf8BOM = "\ufeff"
+
+// Segment is a span of transcript text attributed to one speaker
+type Segment struct {
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Speaker string  `json:"speaker,omitempty"` // Display name; empty when not diarized
+	Text    string  `json:"text"`
+	Words   []Word  `json:"words,omitempty"`
+}
+
+// Word is a single aligned word
+type Word struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"word"`
+}
+
+// Options controls how segments are laid out as cues
+type Options struct {
+	MaxCharsPerLine int  // Zero means unlimited
+	MaxLinesPerCue  int  // Zero means unlimited
+	SpeakerPrefix   bool // Prefix text with "Speaker: " whenever the speaker changes
+	WordTiming      bool // Time cues from aligned words instead of spreading the segment evenly
+	WordTimestamps  bool // Mark each aligned word's start inside WebVTT cues; implies WordTiming
+	BOM             bool // Start the output with a UTF-8 byte order mark
+
+	// Translation marks the transcript as an English translation of audio in
+	// SourceLanguage, which is empty when unknown. SRT has no comments, so
+	// only the other formats note it.
+	Translation    bool
+	SourceLanguage string
+
+	// Chapters are marked with NOTE comments in WebVTT; the other formats
+	// leave them out
+	Chapters []Chapter
+}
+
+// Chapter marks where a topic of the transcript starts
+type Chapter struct {
+	Start float64 // Seconds
+	Title string  // May be empty
+}
+
+// DefaultOptions returns the recommended subtitle layout
+func DefaultOptions() Options {
+	return Options{
+		MaxCharsPerLine: DefaultMaxCharsPerLine,
+		MaxLinesPerCue:  DefaultMaxLinesPerCue,
+		SpeakerPrefix:   true,
+	}
+}
+
+// Cue is one timed subtitle
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+	Lines []string
+
+	lineTokens [][]token // The words of each line, for inline word timestamps
+}
+
+// token is a word of cue text with its timing, when known
+type token struct {
+	text  string
+	start float64
+	end   float64
+	timed bool
+}
+
+// BuildCues lays segments out as subtitle cues. Long segments are split across
+// several cues. Times are rounded to whole milliseconds and adjusted so that no
+// cue starts before zero, ends before it starts or overlaps the previous cue.
+func BuildCues(segments []Segment, opts Options) []Cue {
+	var cues []Cue
+	previousSpeaker := ""
+	for _, segment := range segments {
+		tokens := segmentTokens(segment, opts.WordTiming || opts.WordTimestamps)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		if opts.SpeakerPrefix && segment.Speaker != "" && segment.Speaker != previousSpeaker {
+			label := tokens[0]
+			label.text = segment.Speaker + ":"
+			label.end = label.start
+			tokens = append([]token{label}, tokens...)
+		}
+		previousSpeaker = segment.Speaker
+
+		lines := wrapTokens(tokens, opts.MaxCharsPerLine)
+		cues = append(cues, segmentCues(segment, lines, opts.MaxLinesPerCue)...)
+	}
+	return normalizeCues(cues)
+}
+
+// segmentTokens splits a segment into words, keeping word timings when requested
+// and available
+func segmentTokens(segment Segment, wordTiming bool) []token {
+	var tokens []token
+	if wordTiming && len(segment.Words) > 0 {
+		for _, word := range segment.Words {
+			for _, field := range strings.Fields(cleanText(word.Text)) {
+				tokens = append(tokens, token{text: field, start: word.Start, end: word.End, timed: true})
+			}
+		}
+		return tokens
+	}
+	for _, field := range strings.Fields(cleanText(segment.Text)) {
+		tokens = append(tokens, token{text: field})
+	}
+	return tokens
+}
+
+// line is one wrapped line of cue text and the tokens it was built from
+type line struct {
+	text   string
+	tokens []token
+}
+
+// wrapTokens greedily fills lines of at most maxChars runes. Tokens longer than
+// a line are broken across lines.
+func wrapTokens(tokens []token, maxChars int) []line {
+	var lines []line
+	var current line
+	for _, tok := range tokens {
+		for _, piece := range splitLongToken(tok, maxChars) {
+			candidate := piece.text
+			if current.text != "" {
+				candidate = current.text + " " + piece.text
+			}
+			if maxChars > 0 && utf8.RuneCountInString(candidate) > maxChars && len(current.tokens) > 0 {
+				lines = append(lines, current)
+				current = line{}
+				candidate = piece.text
+			}
+			current.text = candidate
+			current.tokens = append(current.tokens, piece)
+		}
+	}
+	if len(current.tokens) > 0 {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// splitLongToken breaks a token that cannot fit on any line into line-sized pieces
+func splitLongToken(tok token, maxChars int) []token {
+	runes := []rune(tok.text)
+	if maxChars <= 0 || len(runes) <= maxChars {
+		return []token{tok}
+	}
+	var pieces []token
+	for len(runes) > 0 {
+		n := min(maxChars, len(runes))
+		piece := tok
+		piece.text = string(runes[:n])
+		pieces = append(pieces, piece)
+		runes = runes[n:]
+	}
+	return pieces
+}
+
+// segmentCues groups a segment's lines into cues of at most maxLines lines.
+// Cues built from aligned words take their times from the words; otherwise the
+// segment's time is shared out in proportion to the text in each cue.
+func segmentCues(segment Segment, lines []line, maxLines int) []Cue {
+	if maxLines <= 0 {
+		maxLines = len(lines)
+	}
+
+	totalChars := 0
+	for _, l := range lines {
+		totalChars += utf8.RuneCountInString(l.text)
+	}
+	duration := segment.End - segment.Start
+
+	var cues []Cue
+	charsBefore := 0
+	for i := 0; i < len(lines); i += maxLines {
+		group := lines[i:min(i+maxLines, len(lines))]
+		cue := Cue{}
+		chars := 0
+		for _, l := range group {
+			cue.Lines = append(cue.Lines, l.text)
+			cue.lineTokens = append(cue.lineTokens, l.tokens)
+			chars += utf8.RuneCountInString(l.text)
+		}
+
+		first, last := group[0].tokens[0], group[len(group)-1].tokens[len(group[len(group)-1].tokens)-1]
+		if first.timed && last.timed {
+			cue.Start, cue.End = toDuration(first.start), toDuration(last.end)
+		} else {
+			start := segment.Start + duration*float64(charsBefore)/float64(totalChars)
+			end := segment.Start + duration*float64(charsBefore+chars)/float64(totalChars)
+			cue.Start, cue.End = toDuration(start), toDuration(end)
+		}
+		charsBefore += chars
+		cues = append(cues, cue)
+	}
+	return cues
+}
+
+// minCueDuration keeps zero-length cues visible for at least one frame
+const minCueDuration = 40 * time.Millisecond
+
+// normalizeCues clamps cue times so that they are non-negative, strictly
+// increasing and do not overlap
+func normalizeCues(cues []Cue) []Cue {
+	var previousEnd time.Duration
+	for i := range cues {
+		cue := &cues[i]
+		if cue.Start < 0 {
+			cue.Start = 0
+		}
+		if cue.Start < previousEnd {
+			cue.Start = previousEnd
+		}
+		if cue.End <= cue.Start {
+			cue.End = cue.Start + minCueDuration
+		}
+		previousEnd = cue.End
+	}
+	return cues
+}
+
+// toDuration converts seconds to a duration rounded to the millisecond
+func toDuration(seconds float64) time.Duration {
+	if seconds <= 0 || math.IsNaN(seconds) {
+		return 0
+	}
+	return time.Duration(math.Round(seconds*1000)) * time.Millisecond
+}
+
+// cleanText removes byte order marks and collapses whitespace, including line
+// breaks that would otherwise end a cue early
+func cleanText(text string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(text, utf8BOM, "")), " ")
+}
+
+// transcriptJSON is the stored transcript layout read by SegmentsFromTranscript
+type transcriptJSON struct {
+	Segments []struct {
+		Start   float64    `json:"start"`
+		End     float64    `json:"end"`
+		Text    string     `json:"text"`
+		Speaker *string    `json:"speaker"`
+		Words   []wordJSON `json:"words"`
+		Masked  string     `json:"masked_text"`
+	} `json:"segments"`
+	WordSegments []wordJSON `json:"word_segments"`
+	Speakers     []struct {
+		ID    string `json:"id"`
+		Label string `json:"label"`
+	} `json:"speakers"`
+}
+
+type wordJSON struct {
+	Start  float64 `json:"start"`
+	End    float64 `json:"end"`
+	Word   string  `json:"word"`
+	Masked string  `json:"masked_word"`
+}
+
+// text returns the word as it should be exported, masked when its
+// confidence fell below the job's threshold
+func (w wordJSON) text() string {
+	if w.Masked != "" {
+		return w.Masked
+	}
+	return w.Word
+}
+
+// SegmentsFromTranscript decodes a stored transcript. Speakers are shown by
+// their display name from names, falling back to the transcript's own label.
+// Top-level word timings are attached to the segment they fall in, and
+// segments with low-confidence words masked are exported masked.
+func SegmentsFromTranscript(data []byte, names map[string]string) ([]Segment, error) {
+	var transcript transcriptJSON
+	if err := json.Unmarshal(data, &transcript); err != nil {
+		return nil, fmt.Errorf("failed to parse transcript: %w", err)
+	}
+
+	labels := make(map[string]string, len(transcript.Speakers))
+	for _, speaker := range transcript.Speakers {
+		labels[speaker.ID] = speaker.Label
+	}
+
+	segments := make([]Segment, len(transcript.Segments))
+	for i, s := range transcript.Segments {
+		text := s.Text
+		if s.Masked != "" {
+			text = s.Masked
+		}
+		segments[i] = Segment{Start: s.Start, End: s.End, Text: cleanText(text)}
+		if s.Speaker != nil && *s.Speaker != "" {
+			segments[i].Speaker = speakerName(*s.Speaker, names, labels)
+		}
+		for _, w := range s.Words {
+			segments[i].Words = append(segments[i].Words, Word{Start: w.Start, End: w.End, Text: w.text()})
+		}
+	}
+
+	attachWords(segments, transcript.WordSegments)
+	return segments, nil
+}
+
+func speakerName(id string, names, labels map[string]string) string {
+	if name := names[id]; name != "" {
+		return name
+	}
+	if label := labels[id]; label != "" {
+		return label
+	}
+	return id
+}
+
+// attachWords assigns top-level word timings to the segments that do not carry
+// their own, by start time
+func attachWords(segments []Segment, words []wordJSON) {
+	if len(segments) == 0 {
+		return
+	}
+	for _, segment := range segments {
+		if len(segment.Words) > 0 {
+			return
+		}
+	}
+
+	idx := 0
+	for _, w := range words {
+		for idx < len(segments)-1 && w.Start >= segments[idx].End {
+			idx++
+		}
+		segments[idx].Words = append(segments[idx].Words, Word{Start: w.Start, End: w.End, Text: w.text()})
+	}
+}
@@ -0,0 +1,467 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestFormatTimestamp(t *testing.T) {
+	cases := []struct {
+		in   time.Duration
+		sep  byte
+		want string
+	}{
+		{0, ',', "00:00:00,000"},
+		{-5 * time.Second, ',', "00:00:00,000"},
+		{1500 * time.Millisecond, ',', "00:00:01,500"},
+		{61*time.Minute + 1*time.Second + 7*time.Millisecond, '.', "01:01:01.007"},
+		{100*time.Hour + 999*time.Millisecond, '.', "100:00:00.999"},
+	}
+	for _, tc := range cases {
+		if got := formatTimestamp(tc.in, tc.sep); got != tc.want {
+			t.Errorf("formatTimestamp(%v) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestToDurationRounding(t *testing.T) {
+	cases := []struct {
+		in   float64
+		want time.Duration
+	}{
+		{0, 0},
+		{-0.25, 0},
+		{0.0004, 0},
+		{0.0005, time.Millisecond},
+		{1.2344, 1234 * time.Millisecond},
+		{1.2346, 1235 * time.Millisecond},
+		{2.9999, 3 * time.Second},
+		{4.35, 4350 * time.Millisecond}, // 4.35*1000 is 4349.999... in floating point
+	}
+	for _, tc := range cases {
+		if got := toDuration(tc.in); got != tc.want {
+			t.Errorf("toDuration(%v) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func assertValidCues(t *testing.T, cues []Cue) {
+	t.Helper()
+	var previousEnd time.Duration
+	for i, cue := range cues {
+		if cue.Start < 0 {
+			t.Errorf("cue %d starts before zero: %v", i, cue.Start)
+		}
+		if cue.End <= cue.Start {
+			t.Errorf("cue %d ends at %v, not after its start %v", i, cue.End, cue.Start)
+		}
+		if cue.Start < previousEnd {
+			t.Errorf("cue %d starts at %v, overlapping previous cue ending %v", i, cue.Start, previousEnd)
+		}
+		if cue.Start%time.Millisecond != 0 || cue.End%time.Millisecond != 0 {
+			t.Errorf("cue %d times are not whole milliseconds: %v --> %v", i, cue.Start, cue.End)
+		}
+		previousEnd = cue.End
+	}
+}
+
+func TestBuildCuesFixesBadTimes(t *testing.T) {
+	segments := []Segment{
+		{Start: -1.2, End: 1.0, Text: "starts before zero"},
+		{Start: 0.5, End: 2.0, Text: "overlaps the first"},
+		{Start: 3.0, End: 3.0, Text: "zero length"},
+		{Start: 5.0, End: 4.0, Text: "ends before it starts"},
+		{Start: 4.0004, End: 4.0006, Text: "rounds to the same millisecond"},
+	}
+	cues := BuildCues(segments, Options{})
+	if len(cues) != len(segments) {
+		t.Fatalf("expected %d cues, got %d", len(segments), len(cues))
+	}
+	assertValidCues(t, cues)
+
+	if cues[0].Start != 0 || cues[0].End != time.Second {
+		t.Errorf("first cue = %v --> %v, want 0s --> 1s", cues[0].Start, cues[0].End)
+	}
+	if cues[1].Start != time.Second {
+		t.Errorf("overlapping cue should start when the previous ends, got %v", cues[1].Start)
+	}
+	if cues[2].End-cues[2].Start != minCueDuration {
+		t.Errorf("zero-length cue should last %v, got %v", minCueDuration, cues[2].End-cues[2].Start)
+	}
+}
+
+func TestBuildCuesSplitsLongSegments(t *testing.T) {
+	text := "the quick brown fox jumps over the lazy dog and keeps running far across the wide green field"
+	segments := []Segment{{Start: 10, End: 20, Text: text}}
+	cues := BuildCues(segments, Options{MaxCharsPerLine: 20, MaxLinesPerCue: 2})
+
+	if len(cues) < 2 {
+		t.Fatalf("expected the segment to be split into several cues, got %d", len(cues))
+	}
+	assertValidCues(t, cues)
+
+	var words []string
+	for i, cue := range cues {
+		if len(cue.Lines) == 0 || len(cue.Lines) > 2 {
+			t.Errorf("cue %d has %d lines, want 1-2", i, len(cue.Lines))
+		}
+		for _, l := range cue.Lines {
+			if utf8.RuneCountInString(l) > 20 {
+				t.Errorf("cue %d line %q is longer than 20 characters", i, l)
+			}
+			words = append(words, strings.Fields(l)...)
+		}
+	}
+	if got := strings.Join(words, " "); got != text {
+		t.Errorf("split cues lost text:\n got %q\nwant %q", got, text)
+	}
+
+	if cues[0].Start != 10*time.Second {
+		t.Errorf("first cue should start with the segment, got %v", cues[0].Start)
+	}
+	if last := cues[len(cues)-1]; last.End != 20*time.Second {
+		t.Errorf("last cue should end with the segment, got %v", last.End)
+	}
+}
+
+func TestBuildCuesBreaksOverlongWords(t *testing.T) {
+	cues := BuildCues([]Segment{{Start: 0, End: 1, Text: "supercalifragilisticexpialidocious ok"}}, Options{MaxCharsPerLine: 10})
+	for _, cue := range cues {
+		for _, l := range cue.Lines {
+			if utf8.RuneCountInString(l) > 10 {
+				t.Errorf("line %q is longer than 10 characters", l)
+			}
+		}
+	}
+}
+
+func TestBuildCuesCountsRunesNotBytes(t *testing.T) {
+	cues := BuildCues([]Segment{{Start: 0, End: 1, Text: "日本語の字幕 テスト"}}, Options{MaxCharsPerLine: 10, MaxLinesPerCue: 1})
+	if len(cues) != 1 || len(cues[0].Lines) != 1 {
+		t.Fatalf("expected a single line cue, got %+v", cues)
+	}
+}
+
+func TestBuildCuesWordTiming(t *testing.T) {
+	segments := []Segment{{
+		Start: 0, End: 10, Text: "one two three four",
+		Words: []Word{
+			{Start: 1.0, End: 1.5, Text: "one"},
+			{Start: 2.0, End: 2.5, Text: "two"},
+			{Start: 6.0, End: 6.5, Text: "three"},
+			{Start: 7.0, End: 7.25, Text: "four"},
+		},
+	}}
+	cues := BuildCues(segments, Options{MaxCharsPerLine: 8, MaxLinesPerCue: 1, WordTiming: true})
+	want := []struct {
+		start, end time.Duration
+		text       string
+	}{
+		{time.Second, 2500 * time.Millisecond, "one two"},
+		{6 * time.Second, 6500 * time.Millisecond, "three"},
+		{7 * time.Second, 7250 * time.Millisecond, "four"},
+	}
+	if len(cues) != len(want) {
+		t.Fatalf("expected %d cues, got %+v", len(want), cues)
+	}
+	for i, w := range want {
+		if cues[i].Start != w.start || cues[i].End != w.end || cues[i].Lines[0] != w.text {
+			t.Errorf("cue %d = %v --> %v %q, want %v --> %v %q", i, cues[i].Start, cues[i].End, cues[i].Lines[0], w.start, w.end, w.text)
+		}
+	}
+
+	// Without word timing the segment time is shared out evenly
+	cues = BuildCues(segments, Options{MaxCharsPerLine: 8, MaxLinesPerCue: 1})
+	if cues[0].Start != 0 {
+		t.Errorf("segment-timed cue should start with the segment, got %v", cues[0].Start)
+	}
+}
+
+func TestBuildCuesSpeakerPrefix(t *testing.T) {
+	segments := []Segment{
+		{Start: 0, End: 1, Speaker: "Alice", Text: "hello"},
+		{Start: 1, End: 2, Speaker: "Alice", Text: "again"},
+		{Start: 2, End: 3, Speaker: "Bob", Text: "hi"},
+	}
+	cues := BuildCues(segments, Options{SpeakerPrefix: true})
+	got := []string{cues[0].Lines[0], cues[1].Lines[0], cues[2].Lines[0]}
+	want := []string{"Alice: hello", "again", "Bob: hi"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("cue %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	cues = BuildCues(segments, Options{})
+	if cues[0].Lines[0] != "hello" {
+		t.Errorf("speaker prefix should be omitted, got %q", cues[0].Lines[0])
+	}
+}
+
+func TestWriteSRT(t *testing.T) {
+	segments := []Segment{
+		{Start: 0, End: 1.5, Text: "first\nline"},
+		{Start: 1.5, End: 3.0004, Text: "second"},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatSRT, segments, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	want := "1\n00:00:00,000 --> 00:00:01,500\nfirst line\n\n2\n00:00:01,500 --> 00:00:03,000\nsecond\n"
+	if buf.String() != want {
+		t.Errorf("unexpected SRT:\n%q\nwant\n%q", buf.String(), want)
+	}
+}
+
+func TestSegmentsFromTranscriptMasked(t *testing.T) {
+	transcript := `{"segments":[
+		{"start":0,"end":2,"text":"hello there","masked_text":"hello [inaudible]","words":[
+			{"start":0,"end":0.5,"word":"hello","score":0.9},
+			{"start":0.6,"end":1.9,"word":"there","score":0.2,"masked_word":"[inaudible]"}]},
+		{"start":2,"end":3,"text":"general"}]}`
+
+	segments, err := SegmentsFromTranscript([]byte(transcript), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if segments[0].Words[1].Text != "[inaudible]" {
+		t.Errorf("expected the masked word, got %q", segments[0].Words[1].Text)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatSRT, segments, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	want := "1\n00:00:00,000 --> 00:00:02,000\nhello [inaudible]\n\n2\n00:00:02,000 --> 00:00:03,000\ngeneral\n"
+	if buf.String() != want {
+		t.Errorf("unexpected SRT:\n%q\nwant\n%q", buf.String(), want)
+	}
+}
+
+func TestWriteVTT(t *testing.T) {
+	segments := []Segment{{Start: 0, End: 1, Speaker: "A&B", Text: "x < y"}}
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatVTT, segments, Options{SpeakerPrefix: true}); err != nil {
+		t.Fatal(err)
+	}
+	want := "WEBVTT\n\n00:00:00.000 --> 00:00:01.000\nA&amp;B: x &lt; y\n"
+	if buf.String() != want {
+		t.Errorf("unexpected VTT:\n%q\nwant\n%q", buf.String(), want)
+	}
+}
+
+func TestWriteVTTChapters(t *testing.T) {
+	segments := []Segment{
+		{Start: 0, End: 2, Text: "Welcome."},
+		{Start: 5, End: 7, Text: "First topic."},
+		{Start: 7, End: 9, Text: "More on it."},
+	}
+	chapters := []Chapter{{Start: 0}, {Start: 5, Title: "Budget --> review"}}
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatVTT, segments, Options{Chapters: chapters}); err != nil {
+		t.Fatal(err)
+	}
+	want := "WEBVTT\n" +
+		"\nNOTE Chapter 1 (00:00:00.000)\n" +
+		"\n00:00:00.000 --> 00:00:02.000\nWelcome.\n" +
+		"\nNOTE Chapter 2 (00:00:05.000): Budget review\n" +
+		"\n00:00:05.000 --> 00:00:07.000\nFirst topic.\n" +
+		"\n00:00:07.000 --> 00:00:09.000\nMore on it.\n"
+	if buf.String() != want {
+		t.Errorf("unexpected VTT:\n%q\nwant\n%q", buf.String(), want)
+	}
+
+	// SRT has no comments, so chapters are left out
+	buf.Reset()
+	if err := Write(&buf, FormatSRT, segments, Options{Chapters: chapters}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "Chapter") {
+		t.Errorf("SRT should not mention chapters:\n%s", buf.String())
+	}
+}
+
+func TestWriteVTTWordTimestamps(t *testing.T) {
+	segments := []Segment{{
+		Start: 0, End: 4, Speaker: "Alice", Text: "fish & chips now",
+		Words: []Word{
+			{Start: 0.5, End: 1.0, Text: "fish"},
+			{Start: 1.2, End: 1.4, Text: "&"},
+			{Start: 1.4, End: 2.0, Text: "chips"},
+			{Start: 1.3, End: 2.5, Text: "now"}, // Out of order, left untagged
+		},
+	}}
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatVTT, segments, Options{SpeakerPrefix: true, WordTimestamps: true}); err != nil {
+		t.Fatal(err)
+	}
+	want := "WEBVTT\n\n00:00:00.500 --> 00:00:02.500\n" +
+		"Alice: fish <00:00:01.200>&amp; <00:00:01.400>chips now\n"
+	if buf.String() != want {
+		t.Errorf("unexpected VTT:\n%q\nwant\n%q", buf.String(), want)
+	}
+
+	// Segments without aligned words are written without tags
+	segments[0].Words = nil
+	buf.Reset()
+	if err := Write(&buf, FormatVTT, segments, Options{WordTimestamps: true}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "<0") {
+		t.Errorf("unaligned words should not be tagged:\n%s", buf.String())
+	}
+}
+
+func TestBOMHandling(t *testing.T) {
+	segments := []Segment{{Start: 0, End: 1, Text: "\ufeffhello\ufeff world"}}
+	for _, format := range []Format{FormatSRT, FormatVTT, FormatTXT, FormatJSON} {
+		var plain, marked bytes.Buffer
+		if err := Write(&plain, format, segments, Options{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := Write(&marked, format, segments, Options{BOM: true}); err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(plain.String(), utf8BOM) {
+			t.Errorf("%s: byte order marks from the transcript text should be stripped: %q", format, plain.String())
+		}
+		if !strings.Contains(plain.String(), "hello world") {
+			t.Errorf("%s: expected cleaned text in %q", format, plain.String())
+		}
+		if !strings.HasPrefix(marked.String(), utf8BOM) || strings.Count(marked.String(), utf8BOM) != 1 {
+			t.Errorf("%s: expected exactly one leading byte order mark in %q", format, marked.String())
+		}
+		if marked.String()[len(utf8BOM):] != plain.String() {
+			t.Errorf("%s: the byte order mark should be the only difference", format)
+		}
+	}
+
+	var buf bytes.Buffer
+	_ = Write(&buf, FormatVTT, segments, Options{BOM: true})
+	if !strings.HasPrefix(buf.String(), utf8BOM+"WEBVTT\n") {
+		t.Errorf("VTT header must directly follow the byte order mark: %q", buf.String())
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	segments := []Segment{
+		{Speaker: "Alice", Text: "hello"},
+		{Speaker: "Alice", Text: "  "},
+		{Speaker: "Alice", Text: "again"},
+		{Speaker: "Bob", Text: "hi"},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatTXT, segments, Options{SpeakerPrefix: true}); err != nil {
+		t.Fatal(err)
+	}
+	want := "Alice: hello\n\nagain\n\nBob: hi\n"
+	if buf.String() != want {
+		t.Errorf("unexpected text:\n%q\nwant\n%q", buf.String(), want)
+	}
+}
+
+func TestWriteJSONWordTiming(t *testing.T) {
+	segments := []Segment{{Start: 0, End: 1, Text: "hi", Words: []Word{{Start: 0, End: 1, Text: "hi"}}}}
+	for _, wordTiming := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := Write(&buf, FormatJSON, segments, Options{WordTiming: wordTiming}); err != nil {
+			t.Fatal(err)
+		}
+		var decoded struct {
+			Segments []Segment `json:"segments"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if got := len(decoded.Segments[0].Words) > 0; got != wordTiming {
+			t.Errorf("word timing %v: words included = %v", wordTiming, got)
+		}
+	}
+	if len(segments[0].Words) != 1 {
+		t.Error("WriteJSON must not modify its input")
+	}
+}
+
+func TestTranslationNote(t *testing.T) {
+	segments := []Segment{{Start: 0, End: 1, Text: "hello"}}
+	opts := Options{Translation: true, SourceLanguage: "nb"}
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatVTT, "WEBVTT\n\nNOTE Translated to English from nb\n\n00:00:00.000 --> 00:00:01.000\nhello\n"},
+		{FormatTXT, "[Translated to English from nb]\n\nhello\n"},
+		{FormatSRT, "1\n00:00:00,000 --> 00:00:01,000\nhello\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := Write(&buf, tt.format, segments, opts); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%s: got\n%q\nwant\n%q", tt.format, buf.String(), tt.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJSON, segments, opts); err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["task"] != "translate" || decoded["source_language"] != "nb" {
+		t.Errorf("Expected the task and source language in JSON, got %v", decoded)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, name := range []string{"srt", "VTT", "txt", "json"} {
+		if _, err := ParseFormat(name); err != nil {
+			t.Errorf("ParseFormat(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := ParseFormat("docx"); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
+
+func TestSegmentsFromTranscript(t *testing.T) {
+	transcript := `{"segments":[
+		{"start":0,"end":2,"text":" hello\ufeff there ","speaker":"SPEAKER_00"},
+		{"start":2,"end":4,"text":"general","speaker":"SPEAKER_01"},
+		{"start":4,"end":5,"text":"kenobi"}],
+		"word_segments":[
+		{"start":0,"end":0.5,"word":"hello"},{"start":0.6,"end":1.9,"word":"there"},
+		{"start":2,"end":3,"word":"general"},{"start":4.1,"end":4.9,"word":"kenobi"}],
+		"speakers":[{"id":"SPEAKER_00","label":"Speaker 1"},{"id":"SPEAKER_01","label":"Speaker 2"}]}`
+
+	segments, err := SegmentsFromTranscript([]byte(transcript), map[string]string{"SPEAKER_01": "Obi-Wan"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != 3 {
+		t.Fatalf("expected 3 segments, got %d", len(segments))
+	}
+	if segments[0].Text != "hello there" {
+		t.Errorf("text should be cleaned, got %q", segments[0].Text)
+	}
+	if segments[0].Speaker != "Speaker 1" || segments[1].Speaker != "Obi-Wan" || segments[2].Speaker != "" {
+		t.Errorf("unexpected speakers %q %q %q", segments[0].Speaker, segments[1].Speaker, segments[2].Speaker)
+	}
+	wantWords := []int{2, 1, 1}
+	for i, n := range wantWords {
+		if len(segments[i].Words) != n {
+			t.Errorf("segment %d has %d words, want %d", i, len(segments[i].Words), n)
+		}
+	}
+
+	if _, err := SegmentsFromTranscript([]byte("not json"), nil); err == nil {
+		t.Error("expected an error for an invalid transcript")
+	}
+}
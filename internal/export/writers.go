@@ -0,0 +1,224 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Format is an export file format
+type Format string
+
+const (
+	FormatSRT  Format = "srt"
+	FormatVTT  Format = "vtt"
+	FormatTXT  Format = "txt"
+	FormatJSON Format = "json"
+)
+
+// ParseFormat validates an export format name
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(strings.ToLower(name)); format {
+	case FormatSRT, FormatVTT, FormatTXT, FormatJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q", name)
+	}
+}
+
+// ContentType returns the MIME type of files in this format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatSRT:
+		return "application/x-subrip; charset=utf-8"
+	case FormatVTT:
+		return "text/vtt; charset=utf-8"
+	case FormatJSON:
+		return "application/json; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// Write renders segments in the given format
+func Write(w io.Writer, format Format, segments []Segment, opts Options) error {
+	switch format {
+	case FormatSRT:
+		return WriteSRT(w, BuildCues(segments, opts), opts)
+	case FormatVTT:
+		return WriteVTT(w, BuildCues(segments, opts), opts)
+	case FormatTXT:
+		return WriteText(w, segments, opts)
+	case FormatJSON:
+		return WriteJSON(w, segments, opts)
+	default:
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// WriteSRT writes cues as a SubRip file
+func WriteSRT(w io.Writer, cues []Cue, opts Options) error {
+	bw := bufio.NewWriter(w)
+	if opts.BOM {
+		bw.WriteString(utf8BOM)
+	}
+	for i, cue := range cues {
+		if i > 0 {
+			bw.WriteString("\n")
+		}
+		fmt.Fprintf(bw, "%d\n%s --> %s\n", i+1, formatTimestamp(cue.Start, ','), formatTimestamp(cue.End, ','))
+		for _, l := range cue.Lines {
+			bw.WriteString(l + "\n")
+		}
+	}
+	return bw.Flush()
+}
+
+// vttEscaper escapes the characters WebVTT treats as markup in cue text
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// WriteVTT writes cues as a WebVTT file. With opts.WordTimestamps, aligned
+// words are preceded by timestamp tags so players can highlight them as
+// they are spoken. Each chapter is noted before the first cue starting in it.
+func WriteVTT(w io.Writer, cues []Cue, opts Options) error {
+	bw := bufio.NewWriter(w)
+	if opts.BOM {
+		bw.WriteString(utf8BOM)
+	}
+	bw.WriteString("WEBVTT\n")
+	if opts.Translation {
+		bw.WriteString("\nNOTE " + translationNote(opts) + "\n")
+	}
+	chapter := 0
+	for _, cue := range cues {
+		for ; chapter < len(opts.Chapters) && toDuration(opts.Chapters[chapter].Start) <= cue.Start; chapter++ {
+			bw.WriteString("\nNOTE " + chapterNote(chapter, opts.Chapters[chapter]) + "\n")
+		}
+		fmt.Fprintf(bw, "\n%s --> %s\n", formatTimestamp(cue.Start, '.'), formatTimestamp(cue.End, '.'))
+		if opts.WordTimestamps && len(cue.lineTokens) == len(cue.Lines) {
+			writeTimedVTTLines(bw, cue)
+			continue
+		}
+		for _, l := range cue.Lines {
+			bw.WriteString(vttEscaper.Replace(l) + "\n")
+		}
+	}
+	return bw.Flush()
+}
+
+// chapterNote describes the chapter at index i. WebVTT comments may not
+// contain "-->", so it is removed from titles.
+func chapterNote(i int, chapter Chapter) string {
+	note := fmt.Sprintf("Chapter %d (%s)", i+1, formatTimestamp(toDuration(chapter.Start), '.'))
+	if title := strings.Join(strings.Fields(strings.ReplaceAll(chapter.Title, "-->", "")), " "); title != "" {
+		note += ": " + title
+	}
+	return note
+}
+
+// writeTimedVTTLines writes a cue's lines with a timestamp tag before each
+// timed word. WebVTT requires tags to fall strictly inside the cue and to
+// increase, so words that would break that order are written untagged.
+func writeTimedVTTLines(bw *bufio.Writer, cue Cue) {
+	last := cue.Start
+	for _, tokens := range cue.lineTokens {
+		for i, tok := range tokens {
+			if i > 0 {
+				bw.WriteString(" ")
+			}
+			if start := toDuration(tok.start); tok.timed && start > last && start < cue.End {
+				bw.WriteString("<" + formatTimestamp(start, '.') + ">")
+				last = start
+			}
+			bw.WriteString(vttEscaper.Replace(tok.text))
+		}
+		bw.WriteString("\n")
+	}
+}
+
+// WriteText writes one paragraph per segment, prefixed with the speaker when it
+// changes and opts.SpeakerPrefix is set
+func WriteText(w io.Writer, segments []Segment, opts Options) error {
+	bw := bufio.NewWriter(w)
+	if opts.BOM {
+		bw.WriteString(utf8BOM)
+	}
+	previousSpeaker := ""
+	first := true
+	if opts.Translation {
+		bw.WriteString("[" + translationNote(opts) + "]\n")
+		first = false
+	}
+	for _, segment := range segments {
+		text := cleanText(segment.Text)
+		if text == "" {
+			continue
+		}
+		if opts.SpeakerPrefix && segment.Speaker != "" && segment.Speaker != previousSpeaker {
+			text = segment.Speaker + ": " + text
+		}
+		previousSpeaker = segment.Speaker
+		if !first {
+			bw.WriteString("\n")
+		}
+		first = false
+		bw.WriteString(text + "\n")
+	}
+	return bw.Flush()
+}
+
+// WriteJSON writes the segments as JSON. Word timings are included only when
+// opts.WordTiming is set.
+func WriteJSON(w io.Writer, segments []Segment, opts Options) error {
+	out := make([]Segment, len(segments))
+	for i, segment := range segments {
+		out[i] = segment
+		out[i].Text = cleanText(segment.Text)
+		if !opts.WordTiming {
+			out[i].Words = nil
+		}
+	}
+	if opts.BOM {
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return err
+		}
+	}
+	document := jsonDocument{Segments: out}
+	if opts.Translation {
+		document.Task = "translate"
+		document.SourceLanguage = opts.SourceLanguage
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+// jsonDocument is the layout of JSON exports
+type jsonDocument struct {
+	Task           string    `json:"task,omitempty"`            // "translate" for translations
+	SourceLanguage string    `json:"source_language,omitempty"` // Language of the translated audio
+	Segments       []Segment `json:"segments"`
+}
+
+// translationNote describes a translated transcript
+func translationNote(opts Options) string {
+	if opts.SourceLanguage == "" {
+		return "Translated to English"
+	}
+	return "Translated to English from " + opts.SourceLanguage
+}
+
+// formatTimestamp renders HH:MM:SS followed by sep and milliseconds
+func formatTimestamp(d time.Duration, sep byte) string {
+	if d < 0 {
+		d = 0
+	}
+	ms := d.Milliseconds()
+	hours := ms / 3600000
+	minutes := ms / 60000 % 60
+	seconds := ms / 1000 % 60
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", hours, minutes, seconds, sep, ms%1000)
+}
@@ -0,0 +1,174 @@
+// Package gpu reports NVIDIA GPU memory and decides whether a model fits on it
+package gpu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"scriberr/internal/metrics"
+	"scriberr/pkg/logger"
+)
+
+// execCommandContext builds nvidia-smi commands; tests replace it to fake the tool
+var execCommandContext = exec.CommandContext
+
+// ErrNoGPU is returned when nvidia-smi is missing or reports no GPUs
+var ErrNoGPU = errors.New("no NVIDIA GPU available")
+
+// queryTimeout bounds a single nvidia-smi call
+const queryTimeout = 10 * time.Second
+
+// DeviceMemory is the memory of one GPU in MB
+type DeviceMemory struct {
+	Index   int
+	TotalMB int
+	FreeMB  int
+}
+
+// GPUMemoryInfo returns the total and free memory of the first GPU in MB
+func GPUMemoryInfo(ctx context.Context) (totalMB, freeMB int, err error) {
+	devices, err := QueryMemory(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	return devices[0].TotalMB, devices[0].FreeMB, nil
+}
+
+// QueryMemory returns the memory of every GPU nvidia-smi reports
+func QueryMemory(ctx context.Context) ([]DeviceMemory, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	cmd := execCommandContext(ctx, "nvidia-smi", "--query-gpu=memory.total,memory.free", "--format=csv,noprint_wrappers,nounits")
+	output, err := cmd.Output()
+	if err != nil {
+		var execErr *exec.Error
+		if errors.As(err, &execErr) {
+			return nil, ErrNoGPU
+		}
+		return nil, fmt.Errorf("nvidia-smi failed: %w", err)
+	}
+	return parseMemoryOutput(string(output))
+}
+
+// parseMemoryOutput parses one "total, free" line per GPU
+func parseMemoryOutput(output string) ([]DeviceMemory, error) {
+	var devices []DeviceMemory
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("unexpected nvidia-smi output: %q", line)
+		}
+		total, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("unexpected nvidia-smi output: %q", line)
+		}
+		free, err := strconv.Atoi(strings.TrimSpace(fields[1]))
+		if err != nil {
+			return nil, fmt.Errorf("unexpected nvidia-smi output: %q", line)
+		}
+		devices = append(devices, DeviceMemory{Index: len(devices), TotalMB: total, FreeMB: free})
+	}
+	if len(devices) == 0 {
+		return nil, ErrNoGPU
+	}
+	return devices, nil
+}
+
+var (
+	requirementsMu sync.RWMutex
+	requirements   map[string]int
+)
+
+// ConfigureModelRequirements sets the VRAM in MB each model needs
+func ConfigureModelRequirements(table map[string]int) {
+	requirementsMu.Lock()
+	defer requirementsMu.Unlock()
+	requirements = table
+}
+
+// RequiredMB returns the VRAM a model needs, or 0 if it is not in the table
+func RequiredMB(model string) int {
+	requirementsMu.RLock()
+	defer requirementsMu.RUnlock()
+	return requirements[model]
+}
+
+// CheckFits reports whether a model fits in the free memory of a GPU, along
+// with the figures it compared. Models missing from the requirement table
+// always fit.
+func CheckFits(ctx context.Context, model string, deviceIndex int) (fits bool, required int, memory DeviceMemory, err error) {
+	required = RequiredMB(model)
+	if required == 0 {
+		return true, 0, DeviceMemory{}, nil
+	}
+	devices, err := QueryMemory(ctx)
+	if err != nil {
+		return false, required, DeviceMemory{}, err
+	}
+	if deviceIndex < 0 || deviceIndex >= len(devices) {
+		return false, required, DeviceMemory{}, fmt.Errorf("GPU %d not found, %d available", deviceIndex, len(devices))
+	}
+	memory = devices[deviceIndex]
+	return memory.FreeMB >= required, required, memory, nil
+}
+
+// GPUMonitor periodically publishes GPU memory to the metrics endpoint
+type GPUMonitor struct {
+	interval time.Duration
+}
+
+// NewGPUMonitor creates a monitor that samples every interval
+func NewGPUMonitor(interval time.Duration) *GPUMonitor {
+	return &GPUMonitor{interval: interval}
+}
+
+// Start samples GPU memory until ctx is done. It stops early on machines
+// without an NVIDIA GPU.
+func (m *GPUMonitor) Start(ctx context.Context) {
+	go func() {
+		if !m.sample(ctx) {
+			logger.Debug("GPU monitor disabled, no NVIDIA GPU found")
+			return
+		}
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.sample(ctx)
+			}
+		}
+	}()
+}
+
+// sample publishes one reading, returning false if there is no GPU to monitor
+func (m *GPUMonitor) sample(ctx context.Context) bool {
+	devices, err := QueryMemory(ctx)
+	if errors.Is(err, ErrNoGPU) {
+		return false
+	}
+	if err != nil {
+		logger.Warn("Failed to read GPU memory", "error", err)
+		return true
+	}
+	for _, device := range devices {
+		index := strconv.Itoa(device.Index)
+		metrics.SetGauge("scriberr_gpu_memory_total_mb", "Total GPU memory in MB", float64(device.TotalMB), "gpu", index)
+		metrics.SetGauge("scriberr_gpu_memory_free_mb", "Free GPU memory in MB", float64(device.FreeMB), "gpu", index)
+		metrics.SetGauge("scriberr_gpu_memory_used_mb", "Used GPU memory in MB", float64(device.TotalMB-device.FreeMB), "gpu", index)
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package gpu
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"scriberr/internal/metrics"
+)
+
+// fakeNvidiaSMI makes execCommandContext run TestNvidiaSMIHelperProcess, which
+// prints output and exits with exitCode
+func fakeNvidiaSMI(t *testing.T, output string, exitCode int) *[]string {
+	t.Helper()
+	var args []string
+	original := execCommandContext
+	execCommandContext = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		args = append([]string{name}, arg...)
+		cmd := exec.CommandContext(ctx, os.Args[0], "-test.run=TestNvidiaSMIHelperProcess")
+		cmd.Env = append(os.Environ(),
+			"GO_WANT_NVIDIA_SMI_HELPER=1",
+			"NVIDIA_SMI_OUTPUT="+output,
+			fmt.Sprintf("NVIDIA_SMI_EXIT=%d", exitCode))
+		return cmd
+	}
+	t.Cleanup(func() { execCommandContext = original })
+	return &args
+}
+
+// TestNvidiaSMIHelperProcess stands in for nvidia-smi when run by fakeNvidiaSMI
+func TestNvidiaSMIHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_NVIDIA_SMI_HELPER") != "1" {
+		return
+	}
+	fmt.Print(os.Getenv("NVIDIA_SMI_OUTPUT"))
+	if os.Getenv("NVIDIA_SMI_EXIT") != "0" {
+		fmt.Fprint(os.Stderr, "NVIDIA-SMI has failed because it couldn't communicate with the NVIDIA driver")
+		os.Exit(9)
+	}
+	os.Exit(0)
+}
+
+func TestGPUMemoryInfo(t *testing.T) {
+	args := fakeNvidiaSMI(t, "24564, 20110\n12288, 512\n", 0)
+
+	total, free, err := GPUMemoryInfo(context.Background())
+	if err != nil {
+		t.Fatalf("GPUMemoryInfo failed: %v", err)
+	}
+	if total != 24564 || free != 20110 {
+		t.Errorf("Expected 24564/20110 MB, got %d/%d", total, free)
+	}
+	want := "nvidia-smi --query-gpu=memory.total,memory.free --format=csv,noprint_wrappers,nounits"
+	if got := strings.Join(*args, " "); got != want {
+		t.Errorf("Unexpected nvidia-smi invocation:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestGPUMemoryInfoFailure(t *testing.T) {
+	fakeNvidiaSMI(t, "", 1)
+	if _, _, err := GPUMemoryInfo(context.Background()); err == nil || errors.Is(err, ErrNoGPU) {
+		t.Errorf("Expected an nvidia-smi failure, got %v", err)
+	}
+}
+
+func TestGPUMemoryInfoWithoutNvidiaSMI(t *testing.T) {
+	original := execCommandContext
+	execCommandContext = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "scriberr-missing-nvidia-smi", arg...)
+	}
+	t.Cleanup(func() { execCommandContext = original })
+
+	if _, _, err := GPUMemoryInfo(context.Background()); !errors.Is(err, ErrNoGPU) {
+		t.Errorf("Expected ErrNoGPU, got %v", err)
+	}
+}
+
+func TestParseMemoryOutput(t *testing.T) {
+	devices, err := parseMemoryOutput("8192, 4096\r\n\r\n16384, 16000\r\n")
+	if err != nil {
+		t.Fatalf("parseMemoryOutput failed: %v", err)
+	}
+	if len(devices) != 2 || devices[1] != (DeviceMemory{Index: 1, TotalMB: 16384, FreeMB: 16000}) {
+		t.Errorf("Unexpected devices: %+v", devices)
+	}
+
+	if _, err := parseMemoryOutput(""); !errors.Is(err, ErrNoGPU) {
+		t.Errorf("Expected ErrNoGPU for empty output, got %v", err)
+	}
+	for _, output := range []string{"[N/A], 100", "8192", "8192, 100, 3"} {
+		if _, err := parseMemoryOutput(output); err == nil {
+			t.Errorf("Expected an error for %q", output)
+		}
+	}
+}
+
+func TestCheckFits(t *testing.T) {
+	ConfigureModelRequirements(map[string]int{"large-v3": 10000, "small": 2000})
+	t.Cleanup(func() { ConfigureModelRequirements(nil) })
+	fakeNvidiaSMI(t, "24564, 20110\n12288, 3000\n", 0)
+
+	tests := []struct {
+		model       string
+		deviceIndex int
+		fits        bool
+	}{
+		{"large-v3", 0, true},
+		{"large-v3", 1, false},
+		{"small", 1, true},
+		{"unlisted", 1, true},
+	}
+	for _, tt := range tests {
+		fits, _, memory, err := CheckFits(context.Background(), tt.model, tt.deviceIndex)
+		if err != nil {
+			t.Fatalf("CheckFits(%s, %d) failed: %v", tt.model, tt.deviceIndex, err)
+		}
+		if fits != tt.fits {
+			t.Errorf("CheckFits(%s, %d) = %v with %+v, want %v", tt.model, tt.deviceIndex, fits, memory, tt.fits)
+		}
+	}
+
+	if _, _, _, err := CheckFits(context.Background(), "large-v3", 2); err == nil {
+		t.Error("Expected an error for a missing GPU")
+	}
+}
+
+func TestGPUMonitorSample(t *testing.T) {
+	fakeNvidiaSMI(t, "24564, 20110\n", 0)
+
+	if !NewGPUMonitor(0).sample(context.Background()) {
+		t.Fatal("Expected the monitor to keep running with a GPU present")
+	}
+	var buf bytes.Buffer
+	if err := metrics.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# TYPE scriberr_gpu_memory_free_mb gauge",
+		`scriberr_gpu_memory_free_mb{gpu="0"} 20110`,
+		`scriberr_gpu_memory_total_mb{gpu="0"} 24564`,
+		`scriberr_gpu_memory_used_mb{gpu="0"} 4454`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+}
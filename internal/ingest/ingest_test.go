@@ -0,0 +1,182 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeTool makes execCommandContext run TestToolHelperProcess, which prints
+// stdout and stderr and exits with exitCode
+func fakeTool(t *testing.T, stdout, stderr string, exitCode int) *[]string {
+	t.Helper()
+	var args []string
+	original := execCommandContext
+	execCommandContext = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		args = append([]string{name}, arg...)
+		cmd := exec.CommandContext(ctx, os.Args[0], "-test.run=TestToolHelperProcess")
+		cmd.Env = append(os.Environ(),
+			"GO_WANT_TOOL_HELPER=1",
+			"TOOL_STDOUT="+stdout,
+			"TOOL_STDERR="+stderr,
+			fmt.Sprintf("TOOL_EXIT=%d", exitCode))
+		return cmd
+	}
+	t.Cleanup(func() { execCommandContext = original })
+	return &args
+}
+
+// TestToolHelperProcess stands in for yt-dlp and ffmpeg when run by fakeTool
+func TestToolHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_TOOL_HELPER") != "1" {
+		return
+	}
+	fmt.Print(os.Getenv("TOOL_STDOUT"))
+	fmt.Fprint(os.Stderr, os.Getenv("TOOL_STDERR"))
+	if os.Getenv("TOOL_EXIT") != "0" {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestHostPolicy(t *testing.T) {
+	policy := HostPolicy{
+		Allowed: []string{"youtube.com", "youtu.be", "*.example.org"},
+		Denied:  []string{"private.example.org"},
+	}
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{"https://www.youtube.com/watch?v=abc", true},
+		{"https://youtu.be/abc", true},
+		{"https://YOUTUBE.COM./watch?v=abc", true},
+		{"https://podcasts.example.org/episode/1", true},
+		{"https://example.org/episode/1", true},
+		{"https://private.example.org/episode/1", false},
+		{"https://cdn.private.example.org/episode/1", false},
+		{"https://notyoutube.com/watch?v=abc", false},
+		{"https://vimeo.com/123", false},
+	}
+	for _, tt := range tests {
+		_, err := policy.CheckURL(tt.url)
+		if tt.allowed && err != nil {
+			t.Errorf("%s: expected to be allowed, got %v", tt.url, err)
+		}
+		if !tt.allowed && !errors.Is(err, ErrHostNotAllowed) {
+			t.Errorf("%s: expected ErrHostNotAllowed, got %v", tt.url, err)
+		}
+	}
+}
+
+func TestHostPolicyInvalidURL(t *testing.T) {
+	for _, raw := range []string{"", "not a url", "file:///etc/passwd", "ftp://example.com/a.mp3", "https://"} {
+		_, err := HostPolicy{}.CheckURL(raw)
+		if err == nil || errors.Is(err, ErrHostNotAllowed) {
+			t.Errorf("%q: expected an invalid URL error, got %v", raw, err)
+		}
+	}
+	if _, err := (HostPolicy{}).CheckURL("https://vimeo.com/123"); err != nil {
+		t.Errorf("Expected an empty policy to allow any host, got %v", err)
+	}
+}
+
+func TestParseProgressLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		fraction float64
+		ok       bool
+	}{
+		{"scriberr-progress 512 1024 NA", 0.5, true},
+		{"scriberr-progress 300 NA 1200", 0.25, true},
+		{"scriberr-progress 2048 1024 NA", 1, true},
+		{"scriberr-progress 100 NA NA", 0, true},
+		{"[youtube] abc: Downloading webpage", 0, false},
+		{"scriberr-progress 1 2", 0, false},
+	}
+	for _, tt := range tests {
+		fraction, ok := parseProgressLine(tt.line)
+		if ok != tt.ok || fraction != tt.fraction {
+			t.Errorf("%q: expected (%v, %v), got (%v, %v)", tt.line, tt.fraction, tt.ok, fraction, ok)
+		}
+	}
+}
+
+func TestInfo(t *testing.T) {
+	args := fakeTool(t, `{"id":"abc","title":"A talk","uploader":"Someone","duration":754.0,"extractor":"youtube"}`, "", 0)
+
+	info, err := NewDownloader("yt-dlp").Info(context.Background(), "https://youtu.be/abc")
+	if err != nil {
+		t.Fatalf("Info failed: %v", err)
+	}
+	if info.ID != "abc" || info.Title != "A talk" || info.Uploader != "Someone" || info.Duration != 754 {
+		t.Errorf("Unexpected info: %+v", info)
+	}
+	if got := strings.Join(*args, " "); !strings.HasSuffix(got, "-- https://youtu.be/abc") {
+		t.Errorf("Expected the URL after --, got %s", got)
+	}
+}
+
+func TestInfoCapturesStderr(t *testing.T) {
+	fakeTool(t, "", "ERROR: [youtube] abc: Video unavailable. The uploader has not made this video available in your country", 1)
+
+	_, err := NewDownloader("yt-dlp").Info(context.Background(), "https://youtu.be/abc")
+	var toolErr *ToolError
+	if !errors.As(err, &toolErr) {
+		t.Fatalf("Expected a ToolError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "not made this video available in your country") {
+		t.Errorf("Expected the error to include yt-dlp's stderr, got %q", err.Error())
+	}
+}
+
+func TestDownload(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "job")
+	if err := os.WriteFile(base+".webm", []byte("audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(base+".webm.part", []byte("partial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fakeTool(t, "[youtube] abc: Downloading webpage\nscriberr-progress 250 1000 NA\nscriberr-progress 1000 1000 NA\n", "", 0)
+
+	var reported []float64
+	path, err := NewDownloader("yt-dlp").Download(context.Background(), "https://youtu.be/abc", base, func(f float64) {
+		reported = append(reported, f)
+	})
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	if path != base+".webm" {
+		t.Errorf("Expected %s, got %s", base+".webm", path)
+	}
+	if len(reported) != 2 || reported[0] != 0.25 || reported[1] != 1 {
+		t.Errorf("Unexpected progress: %v", reported)
+	}
+}
+
+func TestDownloadFailure(t *testing.T) {
+	fakeTool(t, "", "ERROR: [youtube] abc: Sign in to confirm your age", 1)
+
+	_, err := NewDownloader("yt-dlp").Download(context.Background(), "https://youtu.be/abc", filepath.Join(t.TempDir(), "job"), nil)
+	if err == nil || !strings.Contains(err.Error(), "Sign in to confirm your age") {
+		t.Errorf("Expected the error to include yt-dlp's stderr, got %v", err)
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	var buf tailBuffer
+	buf.Write([]byte(strings.Repeat("a", maxStderrBytes)))
+	buf.Write([]byte("the end\n"))
+
+	got := buf.String()
+	if len(got) > maxStderrBytes || !strings.HasSuffix(got, "the end") {
+		t.Errorf("Expected the last %d bytes ending in the final line, got %d bytes", maxStderrBytes, len(got))
+	}
+}
@@ -0,0 +1,26 @@
+package ingest
+
+import (
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+)
+
+// FailInterruptedDownloads marks jobs left downloading by a previous run as
+// failed, since their download cannot be resumed
+func FailInterruptedDownloads() error {
+	message := "Download interrupted by a server restart"
+	result := database.DB.Model(&models.TranscriptionJob{}).
+		Where("status = ?", models.StatusDownloading).
+		Updates(map[string]interface{}{
+			"status":        models.StatusFailed,
+			"error_message": message,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		logger.Warn("Failed interrupted downloads", "count", result.RowsAffected)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// Package ingest downloads remote media with yt-dlp and prepares it for
+// transcription
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrHostNotAllowed is returned for URLs whose host the policy rejects
+var ErrHostNotAllowed = errors.New("host is not allowed")
+
+// HostPolicy restricts which hosts media may be fetched from. Entries match the
+// host itself and its subdomains. An empty allowlist allows every host not on
+// the denylist.
+type HostPolicy struct {
+	Allowed []string
+	Denied  []string
+}
+
+// CheckURL parses a media URL and checks it against the policy
+func (p HostPolicy) CheckURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid URL: scheme must be http or https")
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return nil, fmt.Errorf("invalid URL: missing host")
+	}
+
+	if matchesHost(host, p.Denied) {
+		return nil, fmt.Errorf("%w: %s", ErrHostNotAllowed, host)
+	}
+	if len(p.Allowed) > 0 && !matchesHost(host, p.Allowed) {
+		return nil, fmt.Errorf("%w: %s", ErrHostNotAllowed, host)
+	}
+	return u, nil
+}
+
+// matchesHost reports whether host is one of patterns or a subdomain of one
+func matchesHost(host string, patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(pattern)), "*.")
+		if pattern == "" {
+			continue
+		}
+		if host == pattern || strings.HasSuffix(host, "."+pattern) {
+			return true
+		}
+	}
+	return false
+}
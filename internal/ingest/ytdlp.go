@@ -0,0 +1,225 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// execCommandContext builds tool commands; tests replace it to fake yt-dlp and ffmpeg
+var execCommandContext = exec.CommandContext
+
+// maxStderrBytes bounds how much tool output is kept for error messages
+const maxStderrBytes = 4096
+
+// progressPrefix marks the download progress lines requested from yt-dlp
+const progressPrefix = "scriberr-progress"
+
+// ToolError is a failed yt-dlp or ffmpeg run, with the end of its stderr, which
+// explains failures such as geo-blocks, missing videos or age gates
+type ToolError struct {
+	Tool   string
+	Stderr string
+	Err    error
+}
+
+func (e *ToolError) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("%s failed: %v", e.Tool, e.Err)
+	}
+	return fmt.Sprintf("%s failed: %v: %s", e.Tool, e.Err, e.Stderr)
+}
+
+func (e *ToolError) Unwrap() error {
+	return e.Err
+}
+
+// MediaInfo is the metadata yt-dlp reports for a URL
+type MediaInfo struct {
+	ID          string  `json:"id"`
+	Title       string  `json:"title"`
+	Description string  `json:"description,omitempty"`
+	Uploader    string  `json:"uploader,omitempty"`
+	Duration    float64 `json:"duration,omitempty"`
+	WebpageURL  string  `json:"webpage_url,omitempty"`
+	Extractor   string  `json:"extractor,omitempty"`
+	UploadDate  string  `json:"upload_date,omitempty"`
+}
+
+// Downloader fetches remote media with yt-dlp and normalizes it with ffmpeg
+type Downloader struct {
+	YtDlpPath  string
+	FFmpegPath string
+}
+
+// NewDownloader creates a downloader using the given yt-dlp binary
+func NewDownloader(ytDlpPath string) *Downloader {
+	return &Downloader{YtDlpPath: ytDlpPath, FFmpegPath: "ffmpeg"}
+}
+
+// Info fetches the metadata of a single video or episode
+func (d *Downloader) Info(ctx context.Context, rawURL string) (*MediaInfo, error) {
+	cmd := execCommandContext(ctx, d.YtDlpPath, "--dump-single-json", "--no-playlist", "--no-warnings", "--", rawURL)
+	var stderr tailBuffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, &ToolError{Tool: "yt-dlp", Stderr: stderr.String(), Err: err}
+	}
+	var info MediaInfo
+	if err := json.Unmarshal(output, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse yt-dlp metadata: %w", err)
+	}
+	return &info, nil
+}
+
+// Download fetches the best audio stream of rawURL to outputBase plus the
+// extension yt-dlp picks, returning the file's path. progress is called with
+// the downloaded fraction as it advances.
+func (d *Downloader) Download(ctx context.Context, rawURL, outputBase string, progress func(fraction float64)) (string, error) {
+	cmd := execCommandContext(ctx, d.YtDlpPath,
+		"--format", "bestaudio/best",
+		"--no-playlist",
+		"--no-warnings",
+		"--newline",
+		"--progress-template", "download:"+progressPrefix+" %(progress.downloaded_bytes)s %(progress.total_bytes)s %(progress.total_bytes_estimate)s",
+		"--output", outputBase+".%(ext)s",
+		"--", rawURL)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	stderrPipe, err := cmd.StderrPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", &ToolError{Tool: "yt-dlp", Err: err}
+	}
+
+	// yt-dlp versions differ in which stream progress goes to, so read both
+	var stderr tailBuffer
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		scanProgress(stdout, progress, io.Discard)
+	}()
+	go func() {
+		defer wg.Done()
+		scanProgress(stderrPipe, progress, &stderr)
+	}()
+	wg.Wait()
+
+	if err := cmd.Wait(); err != nil {
+		return "", &ToolError{Tool: "yt-dlp", Stderr: stderr.String(), Err: err}
+	}
+
+	matches, err := filepath.Glob(globEscape(outputBase) + ".*")
+	if err != nil || len(matches) == 0 {
+		return "", fmt.Errorf("yt-dlp did not produce a file")
+	}
+	for _, match := range matches {
+		// Skip leftovers of interrupted downloads
+		if !strings.HasSuffix(match, ".part") && !strings.HasSuffix(match, ".ytdl") {
+			return match, nil
+		}
+	}
+	return "", fmt.Errorf("yt-dlp did not produce a file")
+}
+
+// Normalize converts a downloaded file to 16 kHz mono AAC in an M4A container,
+// which every transcription backend accepts
+func (d *Downloader) Normalize(ctx context.Context, src, dst string) error {
+	cmd := execCommandContext(ctx, d.FFmpegPath,
+		"-nostdin", "-v", "error", "-y",
+		"-i", src,
+		"-vn",
+		"-ac", "1",
+		"-ar", "16000",
+		"-c:a", "aac",
+		"-b:a", "64k",
+		dst)
+	var stderr tailBuffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return &ToolError{Tool: "ffmpeg", Stderr: stderr.String(), Err: err}
+	}
+	return nil
+}
+
+// scanProgress reads tool output line by line, reporting progress lines and
+// copying everything else to rest
+func scanProgress(r io.Reader, progress func(float64), rest io.Writer) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if fraction, ok := parseProgressLine(line); ok {
+			if progress != nil {
+				progress(fraction)
+			}
+			continue
+		}
+		fmt.Fprintln(rest, line)
+	}
+}
+
+// parseProgressLine reads a progress template line of the form
+// "scriberr-progress <downloaded> <total> <estimated total>", where unknown
+// values are "NA"
+func parseProgressLine(line string) (float64, bool) {
+	fields := strings.Fields(line)
+	if len(fields) != 4 || fields[0] != progressPrefix {
+		return 0, false
+	}
+	downloaded, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return 0, true
+	}
+	total, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil || total <= 0 {
+		if total, err = strconv.ParseFloat(fields[3], 64); err != nil || total <= 0 {
+			return 0, true
+		}
+	}
+	return min(downloaded/total, 1), true
+}
+
+// globEscape escapes glob metacharacters in a path
+func globEscape(path string) string {
+	replacer := strings.NewReplacer("*", `\*`, "?", `\?`, "[", `\[`)
+	return replacer.Replace(path)
+}
+
+// tailBuffer keeps the last maxStderrBytes written to it
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf.Write(p)
+	if extra := t.buf.Len() - maxStderrBytes; extra > 0 {
+		t.buf.Next(extra)
+	}
+	return len(p), nil
+}
+
+// String returns the captured output without surrounding whitespace
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return strings.TrimSpace(t.buf.String())
+}
@@ -0,0 +1,167 @@
+// Package lexicon manages users' pronunciation dictionaries. Whisper has no
+// lexicon input, so a dictionary steers decoding through the initial prompt:
+// listing the words there makes the model prefer their spelling when it
+// hears something like them. Each user's dictionary is kept in the database
+// and written to a lexicon file that transcription reads.
+package lexicon
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"scriberr/internal/models"
+)
+
+// Dir is where lexicon files are written, one per user
+var Dir = "data/lexicons"
+
+// Path returns the lexicon file of a user
+func Path(userID uint) string {
+	return filepath.Join(Dir, fmt.Sprintf("%d.tsv", userID))
+}
+
+// ParseCSV reads word,phonetic pairs, one per line. A first line of
+// "word,phonetic" is taken as a header and skipped.
+func ParseCSV(r io.Reader) ([]models.PronunciationEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	var entries []models.PronunciationEntry
+	seen := make(map[string]int)
+	first := true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		word, phonetic := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		if first {
+			first = false
+			if strings.EqualFold(word, "word") && strings.EqualFold(phonetic, "phonetic") {
+				continue
+			}
+		}
+		if err := validateEntry(word, phonetic); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		key := strings.ToLower(word)
+		if previous, ok := seen[key]; ok {
+			return nil, fmt.Errorf("line %d: %q is already on line %d", line, word, previous)
+		}
+		seen[key] = line
+		if len(entries) == models.MaxPronunciationEntries {
+			return nil, fmt.Errorf("a dictionary can have at most %d entries", models.MaxPronunciationEntries)
+		}
+		entries = append(entries, models.PronunciationEntry{Word: word, Phonetic: phonetic})
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("the dictionary has no entries")
+	}
+	return entries, nil
+}
+
+// validateEntry checks a word and its pronunciation
+func validateEntry(word, phonetic string) error {
+	if word == "" {
+		return errors.New("word is empty")
+	}
+	if phonetic == "" {
+		return fmt.Errorf("no pronunciation for %q", word)
+	}
+	if utf8.RuneCountInString(word) > models.MaxPronunciationFieldLength || len(phonetic) > models.MaxPronunciationFieldLength {
+		return fmt.Errorf("entries are limited to %d characters", models.MaxPronunciationFieldLength)
+	}
+	for _, r := range word {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("word %q contains a control character", word)
+		}
+	}
+	for _, r := range phonetic {
+		if r < ' ' || r > '~' {
+			return fmt.Errorf("pronunciation of %q must be printable ASCII", word)
+		}
+	}
+	return nil
+}
+
+// Write replaces the lexicon file of a user with entries
+func Write(userID uint, entries []models.PronunciationEntry) error {
+	if err := os.MkdirAll(Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create lexicon directory: %w", err)
+	}
+	var b strings.Builder
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "%s\t%s\n", entry.Word, entry.Phonetic)
+	}
+	// Write to a temporary file first so jobs never read half a lexicon
+	path := Path(userID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write lexicon: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write lexicon: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes the lexicon file of a user, if there is one
+func Remove(userID uint) error {
+	if err := os.Remove(Path(userID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lexicon: %w", err)
+	}
+	return nil
+}
+
+// Words returns the words of a user's lexicon file in dictionary order, or
+// nil when the user has no dictionary
+func Words(userID uint) ([]string, error) {
+	file, err := os.Open(Path(userID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lexicon: %w", err)
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if word, _, _ := strings.Cut(scanner.Text(), "\t"); word != "" {
+			words = append(words, word)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read lexicon: %w", err)
+	}
+	return words, nil
+}
+
+// Prompt adds words to a job's initial prompt as a glossary. The job's own
+// prompt comes first; glossary words past the prompt limit are later left
+// out by models.SanitizeInitialPrompt.
+func Prompt(prompt string, words []string) string {
+	if len(words) == 0 {
+		return prompt
+	}
+	glossary := "Glossary: " + strings.Join(words, ", ") + "."
+	if prompt = strings.TrimSpace(prompt); prompt == "" {
+		return glossary
+	}
+	return prompt + " " + glossary
+}
@@ -0,0 +1,113 @@
+package lexicon
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"scriberr/internal/models"
+)
+
+func TestParseCSV(t *testing.T) {
+	csv := "word,phonetic\nXeljanz, ZEL-jans\n\"Nguyen, Anh\",win ahn\nquinoa,KEEN-wah\n"
+	entries, err := ParseCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("ParseCSV failed: %v", err)
+	}
+	want := []models.PronunciationEntry{
+		{Word: "Xeljanz", Phonetic: "ZEL-jans"},
+		{Word: "Nguyen, Anh", Phonetic: "win ahn"},
+		{Word: "quinoa", Phonetic: "KEEN-wah"},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("Expected %+v, got %+v", want, entries)
+	}
+
+	// Without a header every line is an entry
+	entries, err = ParseCSV(strings.NewReader("Xeljanz,ZEL-jans"))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("Expected one entry, got %+v, %v", entries, err)
+	}
+}
+
+func TestParseCSVRejectsInvalidEntries(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+		want string
+	}{
+		{"empty", "word,phonetic\n", "no entries"},
+		{"extra column", "Xeljanz,ZEL-jans,drug\n", "invalid CSV"},
+		{"empty word", "quinoa,KEEN-wah\n ,ZEL-jans\n", "line 2: word is empty"},
+		{"no pronunciation", "Xeljanz,\n", "no pronunciation"},
+		{"non-ASCII pronunciation", "Zoë,ZOH-ē\n", "printable ASCII"},
+		{"duplicate", "Xeljanz,ZEL-jans\nquinoa,KEEN-wah\nxeljanz,zel-jans\n", `line 3: "xeljanz" is already on line 1`},
+		{"long word", strings.Repeat("a", 101) + ",a\n", "limited to 100"},
+	}
+	for _, tt := range tests {
+		_, err := ParseCSV(strings.NewReader(tt.csv))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestParseCSVLimitsEntries(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < models.MaxPronunciationEntries; i++ {
+		fmt.Fprintf(&b, "word%d,wurd\n", i)
+	}
+	if _, err := ParseCSV(strings.NewReader(b.String())); err != nil {
+		t.Errorf("Expected %d entries to be accepted, got %v", models.MaxPronunciationEntries, err)
+	}
+	b.WriteString("one-more,wun-mor\n")
+	if _, err := ParseCSV(strings.NewReader(b.String())); err == nil || !strings.Contains(err.Error(), "at most 1000") {
+		t.Errorf("Expected the entry limit to be enforced, got %v", err)
+	}
+}
+
+func TestLexiconFileFeedsPrompt(t *testing.T) {
+	Dir = t.TempDir()
+	t.Cleanup(func() { Dir = "data/lexicons" })
+
+	if words, err := Words(7); err != nil || words != nil {
+		t.Errorf("Expected no words without a dictionary, got %v, %v", words, err)
+	}
+
+	entries := []models.PronunciationEntry{{Word: "Xeljanz", Phonetic: "ZEL-jans"}, {Word: "Nguyen, Anh", Phonetic: "win ahn"}}
+	if err := Write(7, entries); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	words, err := Words(7)
+	if err != nil {
+		t.Fatalf("Words failed: %v", err)
+	}
+	if want := []string{"Xeljanz", "Nguyen, Anh"}; !reflect.DeepEqual(words, want) {
+		t.Errorf("Expected words %q, got %q", want, words)
+	}
+
+	prompt := Prompt("A rheumatology consult.", words)
+	if want := "A rheumatology consult. Glossary: Xeljanz, Nguyen, Anh."; prompt != want {
+		t.Errorf("Expected prompt %q, got %q", want, prompt)
+	}
+	if got := models.SanitizeInitialPrompt(prompt); got != prompt {
+		t.Errorf("Expected the prompt to pass sanitizing unchanged, got %q", got)
+	}
+	if got := Prompt("", words); got != "Glossary: Xeljanz, Nguyen, Anh." {
+		t.Errorf("Unexpected prompt without a job prompt: %q", got)
+	}
+	if got := Prompt("Just this.", nil); got != "Just this." {
+		t.Errorf("Expected the job prompt alone, got %q", got)
+	}
+
+	if err := Remove(7); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if words, _ := Words(7); words != nil {
+		t.Errorf("Expected no words after removal, got %v", words)
+	}
+	if err := Remove(7); err != nil {
+		t.Errorf("Expected removing a missing lexicon to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package live
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"scriberr/internal/transcription/interfaces"
+)
+
+// AdapterEngine transcribes windows with a transcription adapter such as
+// faster-whisper, writing each window to a WAV file for it. Every window
+// starts the engine afresh, so small models keep the delay down.
+type AdapterEngine struct {
+	Adapter interfaces.TranscriptionAdapter
+	Params  map[string]interface{}
+	TempDir string // Where windows and the adapter's output are written
+
+	// Language is the language the engine last detected or was given
+	Language string
+
+	passes int
+}
+
+// Transcribe transcribes one window
+func (e *AdapterEngine) Transcribe(ctx context.Context, pcm []byte) ([]Segment, error) {
+	e.passes++
+	name := fmt.Sprintf("window-%d", e.passes)
+	path := filepath.Join(e.TempDir, name+".wav")
+	if err := os.WriteFile(path, EncodeWAV(pcm), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write window: %w", err)
+	}
+	defer os.Remove(path)
+
+	input := interfaces.AudioInput{
+		FilePath:   path,
+		Format:     "wav",
+		SampleRate: SampleRate,
+		Channels:   1,
+		Duration:   time.Duration(float64(len(pcm)) / bytesPerSecond * float64(time.Second)),
+		Size:       int64(wavHeaderSize + len(pcm)),
+	}
+	procCtx := interfaces.ProcessingContext{
+		JobID:           name,
+		OutputDirectory: e.TempDir,
+		TempDirectory:   e.TempDir,
+		Metadata:        map[string]string{},
+	}
+	result, err := e.Adapter.Transcribe(ctx, input, e.Params, procCtx)
+	if err != nil {
+		return nil, err
+	}
+	if result.Language != "" {
+		e.Language = result.Language
+	}
+	segments := make([]Segment, len(result.Segments))
+	for i, segment := range result.Segments {
+		segments[i] = Segment{Start: segment.Start, End: segment.End, Text: segment.Text}
+	}
+	return segments, nil
+}
+
+// Decoder decodes a compressed stream, such as the WebM/Opus a browser's
+// MediaRecorder produces, to 16 kHz mono PCM with ffmpeg
+type Decoder struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr bytes.Buffer
+}
+
+// StartDecoder starts ffmpeg, which writes the PCM it decodes to out
+func StartDecoder(ctx context.Context, ffmpegPath string, out io.Writer) (*Decoder, error) {
+	d := &Decoder{}
+	d.cmd = exec.CommandContext(ctx, ffmpegPath,
+		"-hide_banner", "-loglevel", "error",
+		"-i", "pipe:0",
+		"-f", "s16le", "-ac", "1", "-ar", fmt.Sprint(SampleRate),
+		"pipe:1")
+	stdin, err := d.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	d.stdin = stdin
+	d.cmd.Stdout = out
+	d.cmd.Stderr = &d.stderr
+	if err := d.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+	return d, nil
+}
+
+// Write feeds a chunk of the compressed stream to ffmpeg
+func (d *Decoder) Write(chunk []byte) (int, error) {
+	return d.stdin.Write(chunk)
+}
+
+// Close ends the stream and waits for ffmpeg to write the rest of the PCM
+func (d *Decoder) Close() error {
+	d.stdin.Close()
+	if err := d.cmd.Wait(); err != nil {
+		return fmt.Errorf("ffmpeg failed: %w: %s", err, bytes.TrimSpace(d.stderr.Bytes()))
+	}
+	return nil
+}
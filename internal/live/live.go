@@ -0,0 +1,259 @@
+// Package live transcribes audio streamed from a microphone while it is being
+// recorded. Incoming audio is buffered into a sliding window that is
+// transcribed again as it grows. Segments become final once later audio
+// follows them, and the window then slides past them, so a slow engine only
+// ever sees a bounded amount of audio at a time.
+package live
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SampleRate is the rate of the 16-bit mono PCM sessions take
+const SampleRate = 16000
+
+// bytesPerSecond is the size of a second of audio
+const bytesPerSecond = SampleRate * 2
+
+// ErrClosed is returned for audio written after the session was closed
+var ErrClosed = errors.New("live: session is closed")
+
+// Segment is a stretch of transcribed speech, timed from the start of the
+// stream in seconds
+type Segment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// Engine transcribes a window of 16 kHz 16-bit little-endian mono PCM,
+// returning segments timed from the start of the window
+type Engine interface {
+	Transcribe(ctx context.Context, pcm []byte) ([]Segment, error)
+}
+
+// Event types
+const (
+	// EventPartial is the tentative text after the final segments, which
+	// replaces the previous partial
+	EventPartial = "partial"
+	// EventFinal is a segment that will not change again
+	EventFinal = "final"
+)
+
+// Event is a partial or final transcript sent while the stream runs
+type Event struct {
+	Type string `json:"type"`
+	Segment
+}
+
+// Options tune the sliding window
+type Options struct {
+	Step          time.Duration // New audio needed before the window is transcribed again
+	FinalizeAfter time.Duration // Window length from which all but its last segment are final
+	MaxWindow     time.Duration // Longest window; one this long is final as a whole
+	MaxPending    time.Duration // Audio waiting to be transcribed before Write blocks
+}
+
+// DefaultOptions suit faster-whisper's tiny and base models on a CPU
+var DefaultOptions = Options{
+	Step:          2 * time.Second,
+	FinalizeAfter: 10 * time.Second,
+	MaxWindow:     25 * time.Second,
+	MaxPending:    30 * time.Second,
+}
+
+// durationBytes is the even number of bytes d of audio takes
+func durationBytes(d time.Duration) int64 {
+	return int64(d.Seconds()*SampleRate) * 2
+}
+
+// Session transcribes one stream. Audio is written to it while Run
+// transcribes the window and emits events.
+type Session struct {
+	engine Engine
+	opts   Options
+	emit   func(Event) error
+
+	mu        sync.Mutex
+	cond      *sync.Cond
+	buf       []byte // Audio from committed on
+	committed int64  // Bytes of audio before buf, covered by final segments
+	passEnd   int64  // Where the last transcribed window ended
+	closed    bool
+	final     []Segment
+}
+
+// NewSession creates a session that transcribes with engine and sends its
+// events to emit. Run must be called to transcribe.
+func NewSession(engine Engine, opts Options, emit func(Event) error) *Session {
+	s := &Session{engine: engine, opts: opts, emit: emit}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// total returns the bytes of audio received. Callers must hold s.mu.
+func (s *Session) total() int64 {
+	return s.committed + int64(len(s.buf))
+}
+
+// Write adds 16 kHz 16-bit little-endian mono PCM to the stream. It blocks
+// while more than MaxPending of audio waits to be transcribed, so a client
+// sending faster than the engine keeps up is slowed down rather than
+// buffered without bound.
+func (s *Session) Write(pcm []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for !s.closed && s.total()-s.passEnd > durationBytes(s.opts.MaxPending) {
+		s.cond.Wait()
+	}
+	if s.closed {
+		return 0, ErrClosed
+	}
+	s.buf = append(s.buf, pcm...)
+	s.cond.Broadcast()
+	return len(pcm), nil
+}
+
+// Close ends the stream. Run transcribes the audio received so far, makes
+// every segment final and returns.
+func (s *Session) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.cond.Broadcast()
+}
+
+// Segments returns the final segments so far
+func (s *Session) Segments() []Segment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Segment(nil), s.final...)
+}
+
+// Duration returns the length of the audio received
+func (s *Session) Duration() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Duration(float64(s.total()) / bytesPerSecond * float64(time.Second))
+}
+
+// Run transcribes the stream until it is closed and every segment is final.
+// It returns the engine's or emit's error, or ctx's once it is done; the
+// session is closed when Run returns.
+func (s *Session) Run(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, func() {
+		s.mu.Lock()
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	})
+	defer stop()
+	defer s.Close()
+
+	for {
+		s.mu.Lock()
+		for !s.closed && ctx.Err() == nil && s.total()-s.passEnd < durationBytes(s.opts.Step) {
+			s.cond.Wait()
+		}
+		if err := ctx.Err(); err != nil {
+			s.mu.Unlock()
+			return err
+		}
+		start, end := s.committed, s.total()&^1
+		if maxWindow := durationBytes(s.opts.MaxWindow); end-start > maxWindow {
+			end = start + maxWindow
+		}
+		last := s.closed && end == s.total()&^1
+		window := s.buf[:end-start]
+		s.mu.Unlock()
+
+		if end == start && last {
+			return nil
+		}
+		full := end-start >= durationBytes(s.opts.MaxWindow)
+		var segments []Segment
+		if end > start {
+			var err error
+			if segments, err = s.engine.Transcribe(ctx, window); err != nil {
+				return err
+			}
+		}
+
+		committed, err := s.publish(segments, start, end, full, last)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.buf = append([]byte(nil), s.buf[committed-s.committed:]...)
+		s.committed = committed
+		s.passEnd = end
+		s.cond.Broadcast()
+		s.mu.Unlock()
+		if last {
+			return nil
+		}
+	}
+}
+
+// publish emits the segments of the window from start to end, in bytes,
+// and returns where the final ones end. Every segment of a full window or the
+// last one is final; otherwise all but the last are once the window is long
+// enough. The last window has no partial.
+func (s *Session) publish(segments []Segment, start, end int64, full, last bool) (int64, error) {
+	all := full || last
+	offset := float64(start) / bytesPerSecond
+	length := float64(end-start) / bytesPerSecond
+	timed := make([]Segment, 0, len(segments))
+	for _, segment := range segments {
+		text := strings.TrimSpace(segment.Text)
+		if text == "" {
+			continue
+		}
+		timed = append(timed, Segment{
+			Start: offset + min(max(segment.Start, 0), length),
+			End:   offset + min(max(segment.End, 0), length),
+			Text:  text,
+		})
+	}
+
+	finals := 0
+	switch {
+	case all:
+		finals = len(timed)
+	case end-start >= durationBytes(s.opts.FinalizeAfter) && len(timed) > 1:
+		finals = len(timed) - 1
+	}
+	for _, segment := range timed[:finals] {
+		if err := s.emit(Event{Type: EventFinal, Segment: segment}); err != nil {
+			return 0, err
+		}
+	}
+	s.mu.Lock()
+	s.final = append(s.final, timed[:finals]...)
+	s.mu.Unlock()
+
+	committed := start
+	switch {
+	case all:
+		committed = end
+	case finals > 0:
+		committed = min(int64(timed[finals-1].End*SampleRate)*2, end)
+	}
+	if last {
+		return committed, nil
+	}
+
+	partial := Event{Type: EventPartial}
+	if rest := timed[finals:]; len(rest) > 0 {
+		texts := make([]string, len(rest))
+		for i, segment := range rest {
+			texts[i] = segment.Text
+		}
+		partial.Segment = Segment{Start: rest[0].Start, End: rest[len(rest)-1].End, Text: strings.Join(texts, " ")}
+	}
+	return committed, s.emit(partial)
+}
@@ -0,0 +1,203 @@
+package live
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// secondEngine reports a segment for every whole second of a window
+type secondEngine struct {
+	mu      sync.Mutex
+	windows []int
+	release chan struct{} // If set, each pass waits for it
+}
+
+func (e *secondEngine) Transcribe(ctx context.Context, pcm []byte) ([]Segment, error) {
+	if e.release != nil {
+		<-e.release
+	}
+	e.mu.Lock()
+	e.windows = append(e.windows, len(pcm))
+	e.mu.Unlock()
+	var segments []Segment
+	for i := 0; i < len(pcm)/bytesPerSecond; i++ {
+		segments = append(segments, Segment{Start: float64(i), End: float64(i + 1), Text: fmt.Sprintf(" %d ", i)})
+	}
+	return segments, nil
+}
+
+// collect records a session's events
+type collect struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (c *collect) emit(e Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, e)
+	return nil
+}
+
+func seconds(n float64) []byte {
+	return make([]byte, int(n*SampleRate)*2)
+}
+
+var testOptions = Options{Step: time.Second, FinalizeAfter: 3 * time.Second, MaxWindow: 5 * time.Second, MaxPending: 4 * time.Second}
+
+func TestSessionFinalizesSegments(t *testing.T) {
+	engine := &secondEngine{}
+	events := &collect{}
+	session := NewSession(engine, testOptions, events.emit)
+	done := make(chan error, 1)
+	go func() { done <- session.Run(context.Background()) }()
+
+	for i := 0; i < 7; i++ {
+		if _, err := session.Write(seconds(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	session.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	segments := session.Segments()
+	if len(segments) != 7 {
+		t.Fatalf("got %d final segments, want 7: %+v", len(segments), segments)
+	}
+	for i, segment := range segments {
+		// Segments are timed from the start of the stream, not the window
+		if segment.Start != float64(i) || segment.End != float64(i+1) || segment.Text == "" {
+			t.Errorf("segment %d = %+v", i, segment)
+		}
+	}
+	if got := session.Duration(); got != 7*time.Second {
+		t.Errorf("Duration() = %v, want 7s", got)
+	}
+
+	// No window is longer than MaxWindow, and the last event is final
+	for _, size := range engine.windows {
+		if size > int(durationBytes(testOptions.MaxWindow)) {
+			t.Errorf("window of %d bytes is longer than MaxWindow", size)
+		}
+	}
+	if last := events.events[len(events.events)-1]; last.Type != EventFinal {
+		t.Errorf("last event is %q, want final", last.Type)
+	}
+	if _, err := session.Write(seconds(1)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Write after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestSessionPartials(t *testing.T) {
+	events := &collect{}
+	session := NewSession(&secondEngine{}, testOptions, events.emit)
+	done := make(chan error, 1)
+	go func() { done <- session.Run(context.Background()) }()
+
+	session.Write(seconds(2))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		events.mu.Lock()
+		n := len(events.events)
+		events.mu.Unlock()
+		if n > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	session.Close()
+	<-done
+
+	// A short window is only partial
+	first := events.events[0]
+	if first.Type != EventPartial || first.Text != "0 1" || first.End != 2 {
+		t.Errorf("first event = %+v, want the partial 0 1", first)
+	}
+}
+
+func TestSessionBackpressure(t *testing.T) {
+	engine := &secondEngine{release: make(chan struct{})}
+	session := NewSession(engine, testOptions, (&collect{}).emit)
+	done := make(chan error, 1)
+	go func() { done <- session.Run(context.Background()) }()
+
+	// Nothing is transcribed yet, so writes past MaxPending block
+	session.Write(seconds(4))
+	written := make(chan struct{})
+	go func() {
+		session.Write(seconds(1))
+		session.Write(seconds(1))
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Fatal("Write did not block with MaxPending waiting")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(engine.release)
+	select {
+	case <-written:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Write stayed blocked after the engine caught up")
+	}
+	session.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSessionEngineError(t *testing.T) {
+	failing := engineFunc(func(context.Context, []byte) ([]Segment, error) { return nil, errors.New("model crashed") })
+	session := NewSession(failing, testOptions, (&collect{}).emit)
+	session.Write(seconds(1))
+	session.Close()
+	if err := session.Run(context.Background()); err == nil || err.Error() != "model crashed" {
+		t.Errorf("Run() = %v, want the engine's error", err)
+	}
+}
+
+type engineFunc func(context.Context, []byte) ([]Segment, error)
+
+func (f engineFunc) Transcribe(ctx context.Context, pcm []byte) ([]Segment, error) {
+	return f(ctx, pcm)
+}
+
+func TestRecording(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stream.wav")
+	recording, err := CreateRecording(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recording.Write(seconds(1))
+	recording.Write([]byte{1}) // Half a sample
+	if err := recording.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != wavHeaderSize+bytesPerSecond {
+		t.Fatalf("recording is %d bytes, want %d", len(data), wavHeaderSize+bytesPerSecond)
+	}
+	if string(data[:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		t.Errorf("recording does not start with a WAV header: %q", data[:12])
+	}
+	if size := binary.LittleEndian.Uint32(data[40:44]); size != bytesPerSecond {
+		t.Errorf("data size = %d, want %d", size, bytesPerSecond)
+	}
+	if rate := binary.LittleEndian.Uint32(data[24:28]); rate != SampleRate {
+		t.Errorf("sample rate = %d, want %d", rate, SampleRate)
+	}
+}
@@ -0,0 +1,91 @@
+package live
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// wavHeaderSize is the size of a canonical WAV header
+const wavHeaderSize = 44
+
+// WAVHeader returns the header of a 16 kHz 16-bit mono WAV file with
+// dataSize bytes of samples
+func WAVHeader(dataSize uint32) []byte {
+	header := make([]byte, 0, wavHeaderSize)
+	header = append(header, "RIFF"...)
+	header = binary.LittleEndian.AppendUint32(header, 36+dataSize)
+	header = append(header, "WAVEfmt "...)
+	header = binary.LittleEndian.AppendUint32(header, 16) // Size of the fmt chunk
+	header = binary.LittleEndian.AppendUint16(header, 1)  // PCM
+	header = binary.LittleEndian.AppendUint16(header, 1)  // Mono
+	header = binary.LittleEndian.AppendUint32(header, SampleRate)
+	header = binary.LittleEndian.AppendUint32(header, bytesPerSecond)
+	header = binary.LittleEndian.AppendUint16(header, 2)  // Bytes per sample
+	header = binary.LittleEndian.AppendUint16(header, 16) // Bits per sample
+	header = append(header, "data"...)
+	return binary.LittleEndian.AppendUint32(header, dataSize)
+}
+
+// EncodeWAV wraps 16 kHz 16-bit mono PCM in a WAV file
+func EncodeWAV(pcm []byte) []byte {
+	return append(WAVHeader(uint32(len(pcm))), pcm...)
+}
+
+// Recording writes a stream's audio to a WAV file as it arrives, so the job
+// made from the stream has its audio
+type Recording struct {
+	file *os.File
+	size int64
+}
+
+// CreateRecording creates a WAV file at path to record to
+func CreateRecording(path string) (*Recording, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Write(WAVHeader(0)); err != nil {
+		file.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	return &Recording{file: file}, nil
+}
+
+// Write appends PCM to the recording
+func (r *Recording) Write(pcm []byte) (int, error) {
+	n, err := r.file.Write(pcm)
+	r.size += int64(n)
+	return n, err
+}
+
+// Size returns the bytes of audio recorded
+func (r *Recording) Size() int64 {
+	return r.size
+}
+
+// Path returns the recording's file
+func (r *Recording) Path() string {
+	return r.file.Name()
+}
+
+// Close writes the final sizes into the header and closes the file. A
+// trailing half sample is dropped.
+func (r *Recording) Close() error {
+	size := r.size &^ 1
+	if err := r.file.Truncate(wavHeaderSize + size); err != nil {
+		r.file.Close()
+		return fmt.Errorf("failed to truncate recording: %w", err)
+	}
+	if _, err := r.file.Seek(0, io.SeekStart); err != nil {
+		r.file.Close()
+		return err
+	}
+	if _, err := r.file.Write(WAVHeader(uint32(size))); err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
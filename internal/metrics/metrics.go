@@ -0,0 +1,105 @@
+// Package metrics keeps process-wide gauges and counters and renders them in
+// the Prometheus text exposition format
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type gauge struct {
+	help   string
+	kind   string             // gauge or counter
+	values map[string]float64 // Keyed by rendered label set
+}
+
+var (
+	mu     sync.RWMutex
+	gauges = make(map[string]*gauge)
+)
+
+// SetGauge sets a gauge value for the given labels, given as name/value pairs
+func SetGauge(name, help string, value float64, labels ...string) {
+	key := renderLabels(labels)
+
+	mu.Lock()
+	defer mu.Unlock()
+	g, ok := gauges[name]
+	if !ok {
+		g = &gauge{help: help, kind: "gauge", values: make(map[string]float64)}
+		gauges[name] = g
+	}
+	g.values[key] = value
+}
+
+// AddCounter adds delta to a counter for the given labels, given as
+// name/value pairs. Adding zero makes the counter visible before it counts
+// anything.
+func AddCounter(name, help string, delta float64, labels ...string) {
+	key := renderLabels(labels)
+
+	mu.Lock()
+	defer mu.Unlock()
+	g, ok := gauges[name]
+	if !ok {
+		g = &gauge{help: help, kind: "counter", values: make(map[string]float64)}
+		gauges[name] = g
+	}
+	g.values[key] += delta
+}
+
+// DeleteGauge removes a gauge or counter and all its values
+func DeleteGauge(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(gauges, name)
+}
+
+// WriteText writes every gauge and counter in the Prometheus text format,
+// sorted by name
+func WriteText(w io.Writer) error {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(gauges))
+	for name := range gauges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		g := gauges[name]
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, g.help, name, g.kind); err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(g.values))
+		for key := range g.values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value := strconv.FormatFloat(g.values[key], 'g', -1, 64)
+			if _, err := fmt.Fprintf(w, "%s%s %s\n", name, key, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// renderLabels formats name/value pairs as {a="1",b="2"}
+func renderLabels(labels []string) string {
+	if len(labels) < 2 {
+		return ""
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labels[i], replacer.Replace(labels[i+1])))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
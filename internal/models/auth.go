@@ -14,3 +14,41 @@ type RefreshToken struct {
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 }
+
+// RevokedToken records a JWT (by its jti claim) that must no longer be accepted
+type RevokedToken struct {
+	JTI       string    `json:"jti" gorm:"primaryKey;type:varchar(36)"`
+	UserID    uint      `json:"user_id" gorm:"index"`
+	RevokedAt time.Time `json:"revoked_at" gorm:"not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"` // Entry can be purged after this time
+}
+
+// LoginLockout counts the consecutive failed logins for a username and
+// records how long it is locked out. Usernames without an account are
+// tracked too, so lockouts do not reveal which accounts exist.
+type LoginLockout struct {
+	Username      string     `json:"username" gorm:"primaryKey;type:varchar(255)"`
+	Failures      int        `json:"failures" gorm:"not null;default:0"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty" gorm:"index"`
+	LastFailureAt time.Time  `json:"last_failure_at" gorm:"not null;index"`
+	LastIP        string     `json:"last_ip" gorm:"type:varchar(64)"`
+}
+
+// SetupState records that first-run setup has finished (single row). Once it
+// exists setup stays closed, even if every admin is later removed.
+type SetupState struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	CompletedAt time.Time `json:"completed_at" gorm:"not null"`
+}
+
+// PasswordResetToken is a one-time token an administrator issued to let a
+// user set a new password. Only the SHA-256 hash of the token is stored.
+type PasswordResetToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	Hashed    string     `json:"-" gorm:"not null;uniqueIndex;type:varchar(128)"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null;index"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedBy uint       `json:"created_by"` // Administrator who issued the token
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
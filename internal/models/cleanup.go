@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// CleanupRun records what one pass of retention cleanup removed
+type CleanupRun struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	StartedAt      time.Time `json:"started_at" gorm:"not null;index"`
+	FinishedAt     time.Time `json:"finished_at"`
+	AudioDeleted   int       `json:"audio_deleted" gorm:"not null;default:0"` // Jobs whose audio was removed
+	JobsDeleted    int       `json:"jobs_deleted" gorm:"not null;default:0"`
+	Failed         int       `json:"failed" gorm:"not null;default:0"`
+	ReclaimedBytes int64     `json:"reclaimed_bytes" gorm:"not null;default:0"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxFolderNameLength is the longest folder name, in bytes
+const MaxFolderNameLength = 100
+
+// JobFolder groups transcription jobs. A job is filed in at most one folder.
+type JobFolder struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"type:varchar(100);not null;uniqueIndex"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// ValidateFolderName trims a folder name and checks it
+func ValidateFolderName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", fmt.Errorf("folder name is required")
+	case len(name) > MaxFolderNameLength:
+		return "", fmt.Errorf("folder name must be at most %d characters", MaxFolderNameLength)
+	}
+	return name, nil
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxJobDependencies is how many jobs one job may wait for
+const MaxJobDependencies = 50
+
+// DependencyCycleError is a dependency that would make jobs wait for each
+// other forever
+type DependencyCycleError struct {
+	Cycle []string // Job IDs from the job back to itself
+}
+
+func (e *DependencyCycleError) Error() string {
+	return "dependency cycle: " + strings.Join(e.Cycle, " -> ")
+}
+
+// ValidateJobDependencies checks the number of dependencies and that a job
+// does not list one twice
+func ValidateJobDependencies(dependsOn []string) error {
+	if len(dependsOn) > MaxJobDependencies {
+		return &FieldError{Field: "depends_on", Message: fmt.Sprintf("depends_on may list at most %d jobs", MaxJobDependencies)}
+	}
+	seen := make(map[string]bool, len(dependsOn))
+	for _, id := range dependsOn {
+		if id == "" {
+			return &FieldError{Field: "depends_on", Message: "depends_on may not contain empty job IDs"}
+		}
+		if seen[id] {
+			return &FieldError{Field: "depends_on", Message: fmt.Sprintf("depends_on lists job %s twice", id)}
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// FindDependencyCycle searches depth first from the jobs jobID would depend
+// on for a path back to jobID, loading each job's dependencies with lookup.
+// It returns a *DependencyCycleError describing the first cycle found.
+func FindDependencyCycle(jobID string, dependsOn []string, lookup func(id string) ([]string, error)) error {
+	visited := make(map[string]bool)
+	path := []string{jobID}
+	var visit func(ids []string) error
+	visit = func(ids []string) error {
+		for _, id := range ids {
+			if id == jobID {
+				cycle := append(append([]string{}, path...), id)
+				return &DependencyCycleError{Cycle: cycle}
+			}
+			if visited[id] {
+				continue
+			}
+			visited[id] = true
+			next, err := lookup(id)
+			if err != nil {
+				return err
+			}
+			path = append(path, id)
+			if err := visit(next); err != nil {
+				return err
+			}
+			path = path[:len(path)-1]
+		}
+		return nil
+	}
+	return visit(dependsOn)
+}
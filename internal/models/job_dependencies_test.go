@@ -0,0 +1,63 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFindDependencyCycle(t *testing.T) {
+	// a <- b <- c, and d waits for both b and c
+	graph := map[string][]string{
+		"a": nil,
+		"b": {"a"},
+		"c": {"b"},
+		"d": {"b", "c"},
+	}
+	lookups := 0
+	lookup := func(id string) ([]string, error) {
+		lookups++
+		return graph[id], nil
+	}
+
+	if err := FindDependencyCycle("e", []string{"c", "d"}, lookup); err != nil {
+		t.Errorf("Expected a chain to have no cycle, got %v", err)
+	}
+	if lookups != 4 {
+		t.Errorf("Expected each job to be looked up once, got %d lookups", lookups)
+	}
+
+	tests := []struct {
+		name      string
+		jobID     string
+		dependsOn []string
+		want      []string
+	}{
+		{"self", "a", []string{"a"}, []string{"a", "a"}},
+		{"direct", "a", []string{"b"}, []string{"a", "b", "a"}},
+		{"indirect", "a", []string{"d"}, []string{"a", "d", "b", "a"}},
+	}
+	for _, tt := range tests {
+		err := FindDependencyCycle(tt.jobID, tt.dependsOn, lookup)
+		var cycleErr *DependencyCycleError
+		if !errors.As(err, &cycleErr) {
+			t.Errorf("%s: expected a cycle, got %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(cycleErr.Cycle, tt.want) {
+			t.Errorf("%s: expected cycle %v, got %v", tt.name, tt.want, cycleErr.Cycle)
+		}
+	}
+}
+
+func TestValidateJobDependencies(t *testing.T) {
+	if err := ValidateJobDependencies([]string{"a", "b"}); err != nil {
+		t.Errorf("Expected distinct dependencies to be valid, got %v", err)
+	}
+	for _, dependsOn := range [][]string{{"a", "a"}, {""}, make([]string, MaxJobDependencies+1)} {
+		var fieldErr *FieldError
+		if err := ValidateJobDependencies(dependsOn); !errors.As(err, &fieldErr) || fieldErr.Field != "depends_on" {
+			t.Errorf("Expected %v to be rejected, got %v", dependsOn, err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"scriberr/internal/events"
+)
+
+// AfterCreate announces a new job on the event bus
+func (tj *TranscriptionJob) AfterCreate(tx *gorm.DB) error {
+	if events.Default.Subscribers() == 0 {
+		return nil
+	}
+	events.Publish(events.Event{
+		Type:     events.TypeJobCreated,
+		JobID:    tj.ID,
+		UserID:   tj.UserID,
+		FolderID: tj.FolderID,
+		Status:   string(tj.Status),
+	})
+	return nil
+}
+
+// AfterUpdate announces the status and progress written for a job on the
+// event bus. Most updates name the job only in their condition, as in
+// Model(&TranscriptionJob{}).Where("id = ?", id), so its ID is read from
+// there and its owner looked up.
+func (tj *TranscriptionJob) AfterUpdate(tx *gorm.DB) error {
+	if events.Default.Subscribers() == 0 {
+		return nil
+	}
+	status, progress := updatedJobValues(tx.Statement)
+	if status == "" && progress == nil {
+		return nil
+	}
+	jobID := tj.ID
+	if jobID == "" {
+		jobID = whereJobID(tx.Statement)
+	}
+	if jobID == "" {
+		return nil
+	}
+
+	owner := struct {
+		UserID   *uint
+		FolderID *uint
+	}{tj.UserID, tj.FolderID}
+	if tj.ID == "" {
+		if err := tx.Session(&gorm.Session{NewDB: true}).Model(&TranscriptionJob{}).
+			Select("user_id", "folder_id").Where("id = ?", jobID).Take(&owner).Error; err != nil {
+			return nil
+		}
+	}
+
+	if status != "" {
+		events.Publish(events.Event{Type: events.TypeJobStatus, JobID: jobID, UserID: owner.UserID, FolderID: owner.FolderID, Status: status})
+	}
+	if progress != nil {
+		events.Publish(events.Event{Type: events.TypeJobProgress, JobID: jobID, UserID: owner.UserID, FolderID: owner.FolderID, Progress: progress})
+	}
+	return nil
+}
+
+// updatedJobValues returns the status and progress an update writes, if any
+func updatedJobValues(stmt *gorm.Statement) (status string, progress *float64) {
+	switch dest := stmt.Dest.(type) {
+	case map[string]interface{}:
+		if value, ok := dest["status"]; ok {
+			status = fmt.Sprint(value)
+		}
+		if value, ok := dest["progress"].(float64); ok {
+			progress = &value
+		}
+	case *TranscriptionJob:
+		// Saves write every field; struct updates only the ones set
+		status = string(dest.Status)
+	}
+	return status, progress
+}
+
+// whereJobID returns the job ID an update's condition selects by, or ""
+func whereJobID(stmt *gorm.Statement) string {
+	where, ok := stmt.Clauses["WHERE"].Expression.(clause.Where)
+	if !ok {
+		return ""
+	}
+	for _, expr := range where.Exprs {
+		if e, ok := expr.(clause.Expr); ok && len(e.Vars) == 1 &&
+			(e.SQL == "id = ?" || e.SQL == "transcription_jobs.id = ?") {
+			if id, ok := e.Vars[0].(string); ok {
+				return id
+			}
+		}
+	}
+	return ""
+}
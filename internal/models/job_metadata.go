@@ -0,0 +1,70 @@
+package models
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Limits on the descriptive fields of a job, in characters
+const (
+	MaxJobTitleLength         = 255
+	MaxJobDescriptionLength   = 5000
+	MaxJobMetadataEntries     = 50
+	MaxJobMetadataKeyLength   = 64
+	MaxJobMetadataValueLength = 1024
+)
+
+// FieldError is a job field whose value cannot be stored
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// ValidateJobTitle checks a job title is within MaxJobTitleLength
+func ValidateJobTitle(title string) error {
+	if utf8.RuneCountInString(title) > MaxJobTitleLength {
+		return &FieldError{Field: "title", Message: fmt.Sprintf("title must be at most %d characters", MaxJobTitleLength)}
+	}
+	return nil
+}
+
+// ValidateJobDescription checks a job description is within
+// MaxJobDescriptionLength
+func ValidateJobDescription(description string) error {
+	if utf8.RuneCountInString(description) > MaxJobDescriptionLength {
+		return &FieldError{Field: "description", Message: fmt.Sprintf("description must be at most %d characters", MaxJobDescriptionLength)}
+	}
+	return nil
+}
+
+// ValidateJobMetadata checks a job's metadata has at most
+// MaxJobMetadataEntries entries with non-empty keys of at most
+// MaxJobMetadataKeyLength characters and values of at most
+// MaxJobMetadataValueLength
+func ValidateJobMetadata(metadata map[string]string) error {
+	if len(metadata) > MaxJobMetadataEntries {
+		return &FieldError{Field: "metadata", Message: fmt.Sprintf("metadata may have at most %d entries", MaxJobMetadataEntries)}
+	}
+	for key, value := range metadata {
+		if key == "" || utf8.RuneCountInString(key) > MaxJobMetadataKeyLength {
+			return &FieldError{Field: "metadata", Message: fmt.Sprintf("metadata keys must be 1 to %d characters", MaxJobMetadataKeyLength)}
+		}
+		if utf8.RuneCountInString(value) > MaxJobMetadataValueLength {
+			return &FieldError{Field: "metadata." + key, Message: fmt.Sprintf("metadata value %q must be at most %d characters", key, MaxJobMetadataValueLength)}
+		}
+	}
+	return nil
+}
+
+// TruncateJobDescription cuts a description taken from a media source to
+// MaxJobDescriptionLength
+func TruncateJobDescription(description string) string {
+	if utf8.RuneCountInString(description) <= MaxJobDescriptionLength {
+		return description
+	}
+	return string([]rune(description)[:MaxJobDescriptionLength])
+}
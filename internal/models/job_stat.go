@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// JobStat records one transcription run that completed or failed, for
+// throughput statistics. Stats are kept after their job is deleted.
+type JobStat struct {
+	ID           uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	JobID        string    `json:"job_id" gorm:"type:varchar(36);index"`
+	UserID       *uint     `json:"user_id,omitempty" gorm:"index"`
+	Status       JobStatus `json:"status" gorm:"type:varchar(20);not null"` // StatusCompleted or StatusFailed
+	Model        string    `json:"model" gorm:"type:varchar(50);index:idx_job_stats_model_device"`
+	Device       string    `json:"device" gorm:"type:varchar(20);index:idx_job_stats_model_device"` // Device the run was placed on
+	Engine       string    `json:"engine" gorm:"type:varchar(30)"`                                  // Adapter that transcribed, such as whisperx
+	AudioSeconds float64   `json:"audio_seconds" gorm:"type:real;not null;default:0"`
+	WallSeconds  float64   `json:"wall_seconds" gorm:"type:real;not null;default:0"` // Time the run took
+	FinishedAt   time.Time `json:"finished_at" gorm:"not null;index"`                // UTC
+}
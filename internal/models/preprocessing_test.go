@@ -0,0 +1,33 @@
+package models
+
+import "testing"
+
+func TestPreprocessingFilterChain(t *testing.T) {
+	tests := []struct {
+		preprocessing Preprocessing
+		want          string
+	}{
+		{Preprocessing{}, ""},
+		{Preprocessing{NormalizeAudio: true}, "loudnorm"},
+		{Preprocessing{HighPassFilterHz: 80}, "highpass=f=80"},
+		{Preprocessing{NormalizeAudio: true, HighPassFilterHz: 120}, "highpass=f=120,loudnorm"},
+	}
+	for _, tt := range tests {
+		if got := tt.preprocessing.FilterChain(); got != tt.want {
+			t.Errorf("FilterChain() for %+v = %q, want %q", tt.preprocessing, got, tt.want)
+		}
+	}
+}
+
+func TestPreprocessingValidate(t *testing.T) {
+	for _, hz := range []int{0, 80, maxHighPassFilterHz} {
+		if err := (Preprocessing{HighPassFilterHz: hz}).Validate(); err != nil {
+			t.Errorf("Expected %d Hz to be valid, got %v", hz, err)
+		}
+	}
+	for _, hz := range []int{-1, maxHighPassFilterHz + 1} {
+		if err := (Preprocessing{HighPassFilterHz: hz}).Validate(); err == nil {
+			t.Errorf("Expected %d Hz to be rejected", hz)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MaxInitialPromptTokens is the most prompt Whisper conditions on; it keeps
+// half of its 448 token context for the prompt
+const MaxInitialPromptTokens = 224
+
+// promptMetacharacters are removed from prompts so they cannot be read as
+// shell syntax by wrappers that run WhisperX through a shell
+const promptMetacharacters = "`$\\;|&<>\""
+
+// EstimatePromptTokens approximates the Whisper tokens in a prompt without
+// its tokenizer: runs of letters and digits count one token per four
+// characters and every other character counts as a token of its own
+func EstimatePromptTokens(prompt string) int {
+	tokens := 0
+	run := 0
+	flush := func() {
+		tokens += (run + 3) / 4
+		run = 0
+	}
+	for _, r := range prompt {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			run++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+	return tokens
+}
+
+// SanitizeInitialPrompt strips control characters and shell metacharacters
+// from a prompt, collapses its whitespace and drops words past
+// MaxInitialPromptTokens
+func SanitizeInitialPrompt(prompt string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		if strings.ContainsRune(promptMetacharacters, r) {
+			return -1
+		}
+		return r
+	}, prompt)
+
+	var kept []string
+	tokens := 0
+	for _, word := range strings.Fields(cleaned) {
+		tokens += EstimatePromptTokens(word)
+		if tokens > MaxInitialPromptTokens {
+			break
+		}
+		kept = append(kept, word)
+	}
+	return strings.Join(kept, " ")
+}
+
+// ValidateInitialPrompt checks that the initial prompt fits in the tokens
+// Whisper conditions on
+func (p WhisperXParams) ValidateInitialPrompt() error {
+	if p.InitialPrompt == nil {
+		return nil
+	}
+	if tokens := EstimatePromptTokens(*p.InitialPrompt); tokens > MaxInitialPromptTokens {
+		return fmt.Errorf("initial_prompt is about %d tokens; the limit is %d", tokens, MaxInitialPromptTokens)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeInitialPromptLimitsLength(t *testing.T) {
+	prompt := SanitizeInitialPrompt(strings.Repeat("word ", 500))
+	if tokens := EstimatePromptTokens(prompt); tokens != MaxInitialPromptTokens {
+		t.Errorf("Expected the prompt to be cut to %d tokens, got %d", MaxInitialPromptTokens, tokens)
+	}
+}
+
+func TestValidateInitialPrompt(t *testing.T) {
+	short := "Scriberr, WhisperX and pyannote."
+	long := strings.Repeat("terminology ", 100)
+	if err := (WhisperXParams{InitialPrompt: &short}).ValidateInitialPrompt(); err != nil {
+		t.Errorf("Expected a short prompt to be valid, got %v", err)
+	}
+	if err := (WhisperXParams{InitialPrompt: &long}).ValidateInitialPrompt(); err == nil {
+		t.Error("Expected a prompt over the token limit to be rejected")
+	}
+}
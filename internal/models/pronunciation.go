@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Pronunciation dictionary limits
+const (
+	MaxPronunciationEntries     = 1000
+	MaxPronunciationFieldLength = 100
+)
+
+// PronunciationEntry is a word of a user's pronunciation dictionary and how
+// it is pronounced
+type PronunciationEntry struct {
+	ID        uint      `json:"-" gorm:"primaryKey"`
+	UserID    uint      `json:"-" gorm:"not null;index"`
+	Word      string    `json:"word" gorm:"type:varchar(100);not null"`
+	Phonetic  string    `json:"phonetic" gorm:"type:varchar(100);not null"` // ASCII respelling, e.g. ZEL-jans
+	CreatedAt time.Time `json:"-" gorm:"autoCreateTime"`
+}
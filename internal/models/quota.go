@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+)
+
+// Quota periods
+const (
+	QuotaPeriodDaily   = "daily"
+	QuotaPeriodMonthly = "monthly"
+)
+
+// Quota limits how many seconds of audio a user may transcribe per period
+type Quota struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_quotas_user_period"`
+	Period      string    `json:"period" gorm:"type:varchar(10);not null;uniqueIndex:idx_quotas_user_period"`
+	MaxSeconds  int       `json:"max_seconds" gorm:"not null"`
+	UsedSeconds int       `json:"used_seconds" gorm:"not null;default:0"`
+	PeriodStart time.Time `json:"period_start" gorm:"not null"` // Start of the period UsedSeconds counts towards
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// IsValidQuotaPeriod reports whether period is a supported quota period
+func IsValidQuotaPeriod(period string) bool {
+	return period == QuotaPeriodDaily || period == QuotaPeriodMonthly
+}
+
+// QuotaPeriodStart returns the start of the period containing t, in UTC
+func QuotaPeriodStart(period string, t time.Time) time.Time {
+	t = t.UTC()
+	if period == QuotaPeriodMonthly {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ResetsAt returns when the quota's current period ends
+func (q *Quota) ResetsAt() time.Time {
+	if q.Period == QuotaPeriodMonthly {
+		return q.PeriodStart.AddDate(0, 1, 0)
+	}
+	return q.PeriodStart.AddDate(0, 0, 1)
+}
+
+// Allows reports whether seconds more usage fits within the quota
+func (q *Quota) Allows(seconds int) bool {
+	return q.UsedSeconds+seconds <= q.MaxSeconds
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxRateLimit is the largest number of requests per minute a route class
+// may be allowed
+const MaxRateLimit = 100000
+
+// RateLimits are the requests per minute the API allows each user, API key or
+// client IP, by route class. Zero disables the limit of a class.
+type RateLimits struct {
+	Read   int `json:"read" gorm:"not null;default:0"`   // GET requests
+	Write  int `json:"write" gorm:"not null;default:0"`  // Other requests not in a class below
+	Submit int `json:"submit" gorm:"not null;default:0"` // Job submissions and uploads
+	LLM    int `json:"llm" gorm:"not null;default:0"`    // Chat and summarization
+}
+
+// Validate checks that every limit is within range
+func (l RateLimits) Validate() error {
+	for name, limit := range map[string]int{"read": l.Read, "write": l.Write, "submit": l.Submit, "llm": l.LLM} {
+		if limit < 0 || limit > MaxRateLimit {
+			return fmt.Errorf("%s must be between 0 and %d", name, MaxRateLimit)
+		}
+	}
+	return nil
+}
+
+// RateLimitSetting stores the rate limits set through the API, which replace
+// the configured ones (single row)
+type RateLimitSetting struct {
+	ID         uint `json:"-" gorm:"primaryKey"`
+	RateLimits `gorm:"embedded"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
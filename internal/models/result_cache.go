@@ -0,0 +1,19 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// OptionsHash returns a SHA-256 of the serialised parameters, hex encoded.
+// Jobs of the same audio whose options hash the same produce the same
+// transcript, so one's result can stand in for the other's.
+func (p WhisperXParams) OptionsHash() string {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// JobFilter selects jobs in the job list. Empty fields match every job.
+type JobFilter struct {
+	Status        JobStatus  `json:"status,omitempty"`
+	Query         string     `json:"q,omitempty"`         // Search in title, description and audio filename
+	Title         string     `json:"title,omitempty"`     // Search in title only
+	Tags          []string   `json:"tags,omitempty"`      // key:value, or key for any value; jobs must have all of them
+	FolderID      *uint      `json:"folder_id,omitempty"` // 0 matches jobs in no folder
+	Engine        string     `json:"engine,omitempty"`    // Hosted engine, such as openai, or model family, such as whisper
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+
+	IncludeArchived bool `json:"include_archived,omitempty"` // Archived jobs are left out otherwise
+}
+
+// Validate checks the filter's tags and date range
+func (f JobFilter) Validate() error {
+	for _, tag := range f.Tags {
+		if _, _, err := ParseTagFilter(tag); err != nil {
+			return err
+		}
+	}
+	if f.CreatedAfter != nil && f.CreatedBefore != nil && !f.CreatedAfter.Before(*f.CreatedBefore) {
+		return fmt.Errorf("created_after must be before created_before")
+	}
+	return nil
+}
+
+// Merge returns the filter narrowed by other: other's fields replace the
+// ones it sets, and its tags are required as well
+func (f JobFilter) Merge(other JobFilter) JobFilter {
+	if other.Status != "" {
+		f.Status = other.Status
+	}
+	if other.Query != "" {
+		f.Query = other.Query
+	}
+	if other.Title != "" {
+		f.Title = other.Title
+	}
+	f.Tags = append(append([]string(nil), f.Tags...), other.Tags...)
+	if other.FolderID != nil {
+		f.FolderID = other.FolderID
+	}
+	if other.Engine != "" {
+		f.Engine = other.Engine
+	}
+	if other.CreatedAfter != nil {
+		f.CreatedAfter = other.CreatedAfter
+	}
+	if other.CreatedBefore != nil {
+		f.CreatedBefore = other.CreatedBefore
+	}
+	if other.IncludeArchived {
+		f.IncludeArchived = true
+	}
+	return f
+}
+
+// SavedFilter is a job list filter a user saved under a name
+type SavedFilter struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"-" gorm:"not null;uniqueIndex:idx_saved_filters_user_name"`
+	Name      string    `json:"name" gorm:"type:varchar(100);not null;uniqueIndex:idx_saved_filters_user_name"`
+	Filter    JobFilter `json:"filter" gorm:"serializer:json;type:text"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// ShareLink gives anyone holding its token read access to one job's
+// transcript, and its audio when IncludeAudio is set, without logging in.
+// Only a hash of the token is stored.
+type ShareLink struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	JobID          string     `json:"job_id" gorm:"type:varchar(36);not null;index"`
+	UserID         *uint      `json:"user_id,omitempty" gorm:"index"` // Who created the link
+	TokenHash      string     `json:"-" gorm:"uniqueIndex;type:varchar(64);not null"`
+	TokenPrefix    string     `json:"token_prefix" gorm:"type:varchar(16)"`
+	IncludeAudio   bool       `json:"include_audio" gorm:"type:boolean;not null"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"` // Nil never expires
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	AccessCount    int64      `json:"access_count" gorm:"not null;default:0"`
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// Active reports whether the link can still be used at now
+func (l *ShareLink) Active(now time.Time) bool {
+	return l.RevokedAt == nil && (l.ExpiresAt == nil || now.Before(*l.ExpiresAt))
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Tag length limits
+const (
+	MaxTagKeyLength   = 50
+	MaxTagValueLength = 200
+)
+
+// Tag is a custom label on a transcription job, such as project, client or
+// speaker. A job may have several values for the same key.
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	JobID     string    `json:"job_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_tags_job_key_value"`
+	Key       string    `json:"key" gorm:"type:varchar(50);not null;uniqueIndex:idx_tags_job_key_value;index:idx_tags_key_value"`
+	Value     string    `json:"value" gorm:"type:varchar(200);not null;uniqueIndex:idx_tags_job_key_value;index:idx_tags_key_value"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// ValidateTag checks a tag key and value. Keys may not contain ':', which
+// separates the key from the value in tag filters.
+func ValidateTag(key, value string) error {
+	switch {
+	case key == "":
+		return fmt.Errorf("tag key is required")
+	case strings.Contains(key, ":"):
+		return fmt.Errorf("tag key must not contain ':'")
+	case len(key) > MaxTagKeyLength:
+		return fmt.Errorf("tag key must be at most %d characters", MaxTagKeyLength)
+	case value == "":
+		return fmt.Errorf("tag value is required")
+	case len(value) > MaxTagValueLength:
+		return fmt.Errorf("tag value must be at most %d characters", MaxTagValueLength)
+	}
+	return nil
+}
+
+// ParseTagFilter splits a "key:value" tag filter. A filter without ':'
+// matches any value of the key and returns an empty value.
+func ParseTagFilter(filter string) (string, string, error) {
+	key, value, _ := strings.Cut(filter, ":")
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+	if key == "" {
+		return "", "", fmt.Errorf("tag filter %q has no key", filter)
+	}
+	return key, value, nil
+}
+
+// ParseTag splits and validates a "key:value" tag
+func ParseTag(tag string) (string, string, error) {
+	key, value, err := ParseTagFilter(tag)
+	if err != nil {
+		return "", "", err
+	}
+	if err := ValidateTag(key, value); err != nil {
+		return "", "", fmt.Errorf("tag %q: %w", tag, err)
+	}
+	return key, value, nil
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// Transcript edit operations recorded in the revision history
+const (
+	TranscriptOpOriginal     = "original"
+	TranscriptOpEdit         = "edit"
+	TranscriptOpSplit        = "split"
+	TranscriptOpMerge        = "merge"
+	TranscriptOpSpeakerMerge = "speaker_merge"
+	TranscriptOpRevert       = "revert"
+)
+
+// TranscriptRevision is a snapshot of a transcript taken after a user edit.
+// Revision matches TranscriptionJob.TranscriptRevision at the time of the edit;
+// the machine transcript is stored as revision 0 when it is first edited.
+type TranscriptRevision struct {
+	ID                 uint   `json:"id" gorm:"primaryKey"`
+	TranscriptionJobID string `json:"transcription_job_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_transcript_revisions_job_revision"`
+	Revision           int    `json:"revision" gorm:"not null;uniqueIndex:idx_transcript_revisions_job_revision"`
+
+	// What changed
+	Operation    string `json:"operation" gorm:"type:varchar(20);not null"`
+	SegmentIndex *int   `json:"segment_index,omitempty"`
+	Description  string `json:"description" gorm:"type:text"`
+
+	// Who changed it
+	UserID   *uint  `json:"user_id,omitempty"`
+	Username string `json:"username,omitempty" gorm:"type:varchar(50)"`
+
+	// Full transcript JSON after the change
+	Transcript string `json:"-" gorm:"type:text;not null"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
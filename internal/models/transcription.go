@@ -1,6 +1,12 @@
 package models
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -24,6 +30,47 @@ type TranscriptionJob struct {
 	MergeStatus           string `json:"merge_status" gorm:"type:varchar(20);default:'none'"` // none, pending, processing, completed, failed
 	MergeError            *string `json:"merge_error,omitempty" gorm:"type:text"`
 	IndividualTranscripts *string `json:"individual_transcripts,omitempty" gorm:"type:text"` // JSON-serialized map[string]*string
+	DurationSeconds       *float64 `json:"duration_seconds,omitempty" gorm:"column:duration_seconds;type:real"` // Audio length in seconds, detected at upload
+	FileSize              *int64   `json:"file_size,omitempty" gorm:"column:file_size"` // Bytes of stored audio, recorded at upload
+	AudioFormat           *string  `json:"audio_format,omitempty" gorm:"type:varchar(50)"`  // Container detected by ffprobe
+	AudioCodec            *string  `json:"audio_codec,omitempty" gorm:"type:varchar(50)"`   // Codec of the transcribed audio stream
+	SampleRate            *int     `json:"sample_rate,omitempty"`                           // Hz
+	Channels              *int     `json:"channels,omitempty"`
+	DetectedLanguage      *string  `json:"detected_language,omitempty" gorm:"type:varchar(10)"` // Language the model reported transcribing
+	LanguageConfidence    *float64 `json:"language_confidence,omitempty" gorm:"type:real"`     // Confidence of the language detection pass, 0.0 - 1.0
+	Warning               *string  `json:"warning,omitempty" gorm:"type:text"`                  // Problem with the last run that did not fail it, such as an uncertain language
+	Phase                 string   `json:"phase,omitempty" gorm:"type:varchar(30)"`          // Step a processing job is on, such as downloading_model
+	Progress              float64  `json:"progress" gorm:"type:real;default:0"`              // 0.0 - 1.0
+	ProcessedSeconds      float64  `json:"processed_seconds" gorm:"type:real;default:0"`     // Audio seconds transcribed so far
+	HasPartial            bool     `json:"has_partial" gorm:"not null;default:false"`        // Chunks of an unfinished run are saved, so the next run resumes after them
+	ResumedFromSeconds    *float64 `json:"resumed_from_seconds,omitempty" gorm:"type:real"`  // Where the last run picked up after reusing saved chunks
+	TranscriptRevision    int      `json:"transcript_revision" gorm:"not null;default:0"`    // Bumped whenever the transcript is edited
+	Priority              int      `json:"priority" gorm:"not null;default:0;index"`         // Queue priority; higher priority jobs start first
+	TimeoutRetries        int      `json:"timeout_retries" gorm:"not null;default:0"`        // Times the job was queued again after running past the job timeout
+	BatchID               *string  `json:"batch_id,omitempty" gorm:"type:varchar(36);index"` // Groups jobs created by one batch upload
+	ScheduledAt           *time.Time `json:"scheduled_at,omitempty" gorm:"index"`            // When a scheduled job becomes pending
+	UserID                *uint    `json:"user_id,omitempty" gorm:"index"`                   // User who queued the job; quota usage is charged to them
+	SourceURL             *string  `json:"source_url,omitempty" gorm:"type:text"`            // Remote page the audio was downloaded from
+	SourceMetadata        *string  `json:"source_metadata,omitempty" gorm:"type:text"`       // JSON metadata reported by the downloader
+	FileHash              *string  `json:"file_hash,omitempty" gorm:"type:varchar(64);index"` // SHA-256 of the audio, hex encoded; jobs of the same audio share results
+	CachedFromJobID       *string  `json:"cached_from_job_id,omitempty" gorm:"type:varchar(36)"` // Completed job whose transcript this one reused instead of running
+	CompletedAt           *time.Time `json:"completed_at,omitempty"`                         // When transcription last succeeded; starts the retention clock
+	PeakRSSMB             *int     `json:"peak_rss_mb,omitempty" gorm:"column:peak_rss_mb"`  // Largest resident set while the last run ran, in MiB
+	CPUSeconds            *float64 `json:"cpu_seconds,omitempty" gorm:"column:cpu_seconds;type:real"` // CPU time the last run used, including its model processes
+	Pinned                bool     `json:"pinned" gorm:"not null;default:false"`             // Pinned jobs are never removed by retention cleanup
+	AudioRetentionDays    *int     `json:"audio_retention_days,omitempty"`                   // Overrides the global audio retention; 0 keeps audio forever
+	JobRetentionDays      *int     `json:"job_retention_days,omitempty"`                     // Overrides the global job retention; 0 keeps the job forever
+	AudioDeletedAt        *time.Time `json:"audio_deleted_at,omitempty"`                     // When retention cleanup removed the audio
+	Notes                 *string  `json:"notes,omitempty" gorm:"type:text"`                 // Freeform memo about the job, at most MaxJobNotesLength characters
+	HasNotes              bool     `json:"has_notes" gorm:"-"`                               // Set by the API so job listings can flag notes without loading them
+	Description           *string  `json:"description,omitempty" gorm:"type:text"`           // What the recording is about, at most MaxJobDescriptionLength characters
+	Metadata              map[string]string `json:"metadata,omitempty" gorm:"serializer:json;type:text"` // Caller defined key/value pairs, see ValidateJobMetadata
+	DependsOn             []string `json:"depends_on,omitempty" gorm:"serializer:json;type:text"` // Jobs that must complete before this one starts
+	Chapters              []Chapter `json:"-" gorm:"serializer:json;type:text"`              // Topic markers found by the chapters post-processor
+	PhaseTimings          map[string]int64 `json:"phase_timings,omitempty" gorm:"serializer:json;type:text"` // Milliseconds spent in each timeline phase, see TimelinePhases
+	FolderID              *uint    `json:"folder_id,omitempty" gorm:"index"`                 // Folder the job is filed in; jobs are in at most one
+	ArchivedAt            *time.Time `json:"archived_at,omitempty" gorm:"index"`             // Archived jobs are left out of the job list unless asked for
+	DeletedAt             gorm.DeletedAt `json:"-" gorm:"index"`                             // Deleted jobs can be restored until retention cleanup purges them
 	CreatedAt        time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt        time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 
@@ -32,23 +79,71 @@ type TranscriptionJob struct {
 
 	// Relationships
 	MultiTrackFiles []MultiTrackFile `json:"multi_track_files,omitempty" gorm:"foreignKey:TranscriptionJobID"`
+
+	// Tag values keyed by tag key; stored in the tags table and filled in
+	// when jobs are returned by the API
+	Tags map[string][]string `json:"tags,omitempty" gorm:"-"`
 }
 
 // JobStatus represents the status of a transcription job
 type JobStatus string
 
 const (
-	StatusUploaded   JobStatus = "uploaded"
-	StatusPending    JobStatus = "pending"
-	StatusProcessing JobStatus = "processing"
-	StatusCompleted  JobStatus = "completed"
-	StatusFailed     JobStatus = "failed"
+	StatusUploaded    JobStatus = "uploaded"
+	StatusDownloading JobStatus = "downloading" // Fetching remote media before queueing
+	StatusScheduled   JobStatus = "scheduled"   // Waiting for its scheduled_at time before queueing
+	StatusPending     JobStatus = "pending"
+	StatusProcessing  JobStatus = "processing"
+	StatusCompleted   JobStatus = "completed"
+	StatusFailed      JobStatus = "failed"
+)
+
+// Processing phases reported alongside StatusProcessing. An empty phase means
+// the job is transcribing.
+const (
+	PhaseDownloadingModel  = "downloading_model"  // Fetching the Whisper model before the first run that needs it
+	PhaseDetectingLanguage = "detecting_language" // Detecting the spoken language of jobs that do not set one
+)
+
+// Timeline phases a job goes through, whose durations are kept in
+// PhaseTimings. Processing phases are those of the latest run.
+const (
+	TimelineUpload         = "upload"          // Receiving or downloading the audio
+	TimelineQueued         = "queued"          // Waiting for a worker
+	TimelinePreprocessing  = "preprocessing"   // Preparing the audio and model
+	TimelineTranscription  = "transcription"   // Transcribing and diarizing
+	TimelinePostProcessing = "post_processing" // Running post-processors and saving the transcript
+)
+
+// TimelinePhases lists the timeline phases in the order jobs go through them
+var TimelinePhases = []string{TimelineUpload, TimelineQueued, TimelinePreprocessing, TimelineTranscription, TimelinePostProcessing}
+
+// Job priorities. Workers start the highest priority pending job first, and
+// the oldest among jobs of equal priority.
+const (
+	PriorityLow    = -1
+	PriorityNormal = 0
+	PriorityHigh   = 1 // Only admins may submit high priority jobs
 )
 
+// IsValidPriority reports whether priority is a supported job priority
+func IsValidPriority(priority int) bool {
+	return priority >= PriorityLow && priority <= PriorityHigh
+}
+
+// MaxJobNotesLength is the longest memo a job may have, in characters
+const MaxJobNotesLength = 4096
+
 // WhisperXParams contains parameters for WhisperX transcription
 type WhisperXParams struct {
 	// Model family (whisper or nvidia)
 	ModelFamily string `json:"model_family" gorm:"type:varchar(20);default:'whisper'"`
+	// Engine running Whisper models: BackendWhisperX, or BackendFasterWhisper
+	// for lower memory use on the CPU. Empty selects WhisperX.
+	Backend string `json:"backend,omitempty" gorm:"type:varchar(20)"`
+	// Hosted service transcribing in place of a local model: EngineOpenAI.
+	// Empty runs the model locally.
+	Engine string `json:"engine,omitempty" gorm:"type:varchar(20)"`
 
 	// Model parameters
 	Model          string  `json:"model" gorm:"type:varchar(50);default:'small'"`
@@ -59,7 +154,7 @@ type WhisperXParams struct {
 	Device      string `json:"device" gorm:"type:varchar(20);default:'cpu'"`
 	DeviceIndex int    `json:"device_index" gorm:"type:int;default:0"`
 	BatchSize   int    `json:"batch_size" gorm:"type:int;default:8"`
-	ComputeType string `json:"compute_type" gorm:"type:varchar(20);default:'float32'"`
+	ComputeType string `json:"compute_type" gorm:"type:varchar(20);default:'float32'"` // One of ComputeTypes
 	Threads     int    `json:"threads" gorm:"type:int;default:0"`
 
 	// Output settings
@@ -68,7 +163,7 @@ type WhisperXParams struct {
 
 	// Task and language
 	Task     string  `json:"task" gorm:"type:varchar(20);default:'transcribe'"`
-	Language *string `json:"language,omitempty" gorm:"type:varchar(10)"`
+	Language *string `json:"language,omitempty" gorm:"type:varchar(10)"` // Empty or "auto" detects the language
 
 	// Alignment settings
 	AlignModel           *string `json:"align_model,omitempty" gorm:"type:varchar(100)"`
@@ -78,8 +173,8 @@ type WhisperXParams struct {
 
 	// VAD (Voice Activity Detection) settings
 	VadMethod string  `json:"vad_method" gorm:"type:varchar(20);default:'pyannote'"`
-	VadOnset  float64 `json:"vad_onset" gorm:"type:real;default:0.5"`
-	VadOffset float64 `json:"vad_offset" gorm:"type:real;default:0.363"`
+	VadOnset  float64 `json:"vad_onset" gorm:"type:real;default:0.5"`    // Speech starts above this probability; lower is more sensitive. In (0, 1); 0 is the default
+	VadOffset float64 `json:"vad_offset" gorm:"type:real;default:0.363"` // Speech ends below this probability. In (0, 1); 0 is the default
 	ChunkSize int     `json:"chunk_size" gorm:"type:int;default:30"`
 
 	// Diarization settings
@@ -105,6 +200,10 @@ type WhisperXParams struct {
 	LogprobThreshold               float64 `json:"logprob_threshold" gorm:"type:real;default:-1.0"`
 	NoSpeechThreshold              float64 `json:"no_speech_threshold" gorm:"type:real;default:0.6"`
 
+	// Temperatures is the decoding fallback schedule, tried in order when a
+	// segment fails the compression ratio or log probability thresholds
+	Temperatures []float64 `json:"temperatures,omitempty" gorm:"serializer:json;type:text"`
+
 	// Output formatting
 	MaxLineWidth      *int   `json:"max_line_width,omitempty" gorm:"type:int"`
 	MaxLineCount      *int   `json:"max_line_count,omitempty" gorm:"type:int"`
@@ -121,6 +220,312 @@ type WhisperXParams struct {
 
 	// Multi-track transcription settings
 	IsMultiTrackEnabled bool `json:"is_multi_track_enabled" gorm:"type:boolean;default:false"`
+
+	// Audio cleanup applied before transcription
+	Preprocessing Preprocessing `json:"preprocessing" gorm:"embedded;embeddedPrefix:preprocess_"`
+	SkipPreprocess bool        `json:"skip_preprocess" gorm:"type:boolean;default:false"` // Input is already 16 kHz mono WAV, so skip resampling
+
+	// Words scored below WordConfidenceThreshold are masked with MaskString
+	// in exports; 0 keeps every word
+	WordConfidenceThreshold float64 `json:"word_confidence_threshold" gorm:"type:real;default:0"`
+	MaskString              string  `json:"mask_string,omitempty" gorm:"type:varchar(50)"`
+
+	// Text transformations run in order on the finished transcript
+	PostProcessors []PostProcessorConfig `json:"post_processors,omitempty" gorm:"serializer:json;type:text"`
+}
+
+// ChapterMarker is the type of a Chapter
+const ChapterMarker = "chapter"
+
+// Chapter marks where a topic of a transcript starts
+type Chapter struct {
+	Type  string  `json:"type"`  // Always ChapterMarker
+	Title string  `json:"title"` // Empty until named
+	Start float64 `json:"start"` // Seconds
+}
+
+// PostProcessorConfig names a registered transcript post-processor and its
+// options, such as {"name": "regex_replace", "options": {"pattern": "...",
+// "replacement": "..."}}
+type PostProcessorConfig struct {
+	Name    string          `json:"name"`
+	Options json.RawMessage `json:"options,omitempty"`
+}
+
+// DefaultMaskString replaces low-confidence words when a job sets no mask
+const DefaultMaskString = "[inaudible]"
+
+// WordMask returns the text that replaces low-confidence words
+func (p WhisperXParams) WordMask() string {
+	if p.MaskString != "" {
+		return p.MaskString
+	}
+	return DefaultMaskString
+}
+
+// ValidateWordConfidence checks that the word confidence threshold is a
+// probability
+func (p WhisperXParams) ValidateWordConfidence() error {
+	if p.WordConfidenceThreshold < 0 || p.WordConfidenceThreshold > 1 {
+		return errors.New("word_confidence_threshold must be between 0 and 1")
+	}
+	return nil
+}
+
+// maxHighPassFilterHz is the highest high-pass cutoff accepted; higher
+// cutoffs remove the speech itself
+const maxHighPassFilterHz = 1000
+
+// Preprocessing cleans up poor recordings with ffmpeg filters before they
+// are transcribed
+type Preprocessing struct {
+	NormalizeAudio   bool `json:"normalize_audio" gorm:"type:boolean;default:false"` // Even out loudness with the loudnorm filter
+	HighPassFilterHz int  `json:"high_pass_filter_hz" gorm:"type:int;default:0"`     // Cut rumble and hum below this frequency; 0 disables
+}
+
+// FilterChain returns the ffmpeg audio filters to apply, or "" for none.
+// Low frequencies are cut before loudness is measured, so rumble does not
+// skew normalization.
+func (p Preprocessing) FilterChain() string {
+	var filters []string
+	if p.HighPassFilterHz > 0 {
+		filters = append(filters, fmt.Sprintf("highpass=f=%d", p.HighPassFilterHz))
+	}
+	if p.NormalizeAudio {
+		filters = append(filters, "loudnorm")
+	}
+	return strings.Join(filters, ",")
+}
+
+// Validate checks the high-pass cutoff
+func (p Preprocessing) Validate() error {
+	if p.HighPassFilterHz < 0 || p.HighPassFilterHz > maxHighPassFilterHz {
+		return fmt.Errorf("high_pass_filter_hz must be between 0 and %d", maxHighPassFilterHz)
+	}
+	return nil
+}
+
+// ValidateDiarization checks that the speaker count bounds are usable
+func (p WhisperXParams) ValidateDiarization() error {
+	if p.MinSpeakers != nil && *p.MinSpeakers < 1 {
+		return errors.New("min_speakers must be at least 1")
+	}
+	if p.MaxSpeakers != nil && *p.MaxSpeakers < 1 {
+		return errors.New("max_speakers must be at least 1")
+	}
+	if p.MinSpeakers != nil && p.MaxSpeakers != nil && *p.MinSpeakers > *p.MaxSpeakers {
+		return errors.New("min_speakers cannot be greater than max_speakers")
+	}
+	return nil
+}
+
+// ValidateDecoding checks the beam size and temperature schedule. WhisperX
+// takes the schedule as a starting temperature and a fixed increment, so the
+// temperatures must rise in equal steps.
+func (p WhisperXParams) ValidateDecoding() error {
+	if p.BeamSize < 1 || p.BeamSize > 10 {
+		return errors.New("beam_size must be between 1 and 10")
+	}
+	for i, temperature := range p.Temperatures {
+		if temperature < 0 || temperature > 1 {
+			return errors.New("temperatures must be between 0.0 and 1.0")
+		}
+		if i > 0 && temperature <= p.Temperatures[i-1] {
+			return errors.New("temperatures must be in increasing order")
+		}
+		if i > 1 && math.Abs((temperature-p.Temperatures[i-1])-(p.Temperatures[1]-p.Temperatures[0])) > 1e-6 {
+			return errors.New("temperatures must increase in equal steps")
+		}
+	}
+	return nil
+}
+
+// Default WhisperX voice activity detection thresholds
+const (
+	DefaultVADOnset  = 0.500
+	DefaultVADOffset = 0.363
+)
+
+// VADThresholds returns the voice activity detection onset and offset,
+// falling back to the defaults for thresholds left at zero. A lower onset
+// detects quieter speech; a lower offset keeps a speech segment open longer.
+func (p WhisperXParams) VADThresholds() (onset, offset float64) {
+	onset, offset = p.VadOnset, p.VadOffset
+	if onset == 0 {
+		onset = DefaultVADOnset
+	}
+	if offset == 0 {
+		offset = DefaultVADOffset
+	}
+	return onset, offset
+}
+
+// ValidateVAD checks that the voice activity detection thresholds lie
+// strictly between 0 and 1. Zero selects the default.
+func (p WhisperXParams) ValidateVAD() error {
+	onset, offset := p.VADThresholds()
+	if onset <= 0 || onset >= 1 {
+		return errors.New("vad_onset must be between 0.0 and 1.0 (exclusive)")
+	}
+	if offset <= 0 || offset >= 1 {
+		return errors.New("vad_offset must be between 0.0 and 1.0 (exclusive)")
+	}
+	return nil
+}
+
+// WhisperX batch sizes jobs may set. Larger batches transcribe faster but
+// take more memory; jobs that set none use the default for their device.
+const (
+	MinBatchSize = 1
+	MaxBatchSize = 256
+)
+
+// ValidateBatchSize checks that the batch size is within range. Zero
+// selects the device default.
+func (p WhisperXParams) ValidateBatchSize() error {
+	if p.BatchSize != 0 && (p.BatchSize < MinBatchSize || p.BatchSize > MaxBatchSize) {
+		return fmt.Errorf("batch_size must be between %d and %d", MinBatchSize, MaxBatchSize)
+	}
+	return nil
+}
+
+// CPU threads jobs may set. Zero leaves the choice to WhisperX.
+const (
+	MinThreads = 1
+	MaxThreads = 256
+)
+
+// ValidateThreads checks that the number of CPU threads is within range.
+// Zero is auto and omits the --threads flag.
+func (p WhisperXParams) ValidateThreads() error {
+	if p.Threads != 0 && (p.Threads < MinThreads || p.Threads > MaxThreads) {
+		return fmt.Errorf("threads must be between %d and %d, or 0 for auto", MinThreads, MaxThreads)
+	}
+	return nil
+}
+
+// ComputeTypes are the compute types WhisperX accepts. The int8 types
+// quantize the model, using less memory and running faster at some cost in
+// accuracy.
+var ComputeTypes = []string{"float32", "float16", "int8", "int8_float16", "int8_bfloat16"}
+
+// DefaultComputeType returns the compute type for jobs on device that set
+// none: float16 on CUDA and MPS, and int8 on the CPU, which has no fast
+// float16 computation
+func DefaultComputeType(device string) string {
+	switch device {
+	case "cuda", "mps":
+		return "float16"
+	default:
+		return "int8"
+	}
+}
+
+// ComputeTypeOrDefault returns the job's compute type, or the default for
+// its device when it sets none
+func (p WhisperXParams) ComputeTypeOrDefault() string {
+	if p.ComputeType != "" {
+		return p.ComputeType
+	}
+	return DefaultComputeType(p.Device)
+}
+
+// ValidateComputeType checks that the compute type is one WhisperX accepts.
+// An empty compute type selects the device default.
+func (p WhisperXParams) ValidateComputeType() error {
+	if p.ComputeType == "" || slices.Contains(ComputeTypes, p.ComputeType) {
+		return nil
+	}
+	return fmt.Errorf("compute_type must be one of %s", strings.Join(ComputeTypes, ", "))
+}
+
+// Backends that run Whisper models
+const (
+	BackendWhisperX      = "whisperx"
+	BackendFasterWhisper = "faster-whisper"
+)
+
+// Hosted transcription engines
+const (
+	EngineOpenAI = "openai" // The OpenAI audio API or a compatible endpoint
+)
+
+// ValidateBackend checks that the backend and engine are known, and that a
+// backend other than WhisperX is only chosen for Whisper models
+func (p WhisperXParams) ValidateBackend() error {
+	if p.Engine != "" && p.Engine != EngineOpenAI {
+		return fmt.Errorf("engine must be empty or %q", EngineOpenAI)
+	}
+	switch p.Backend {
+	case "", BackendWhisperX:
+		return nil
+	case BackendFasterWhisper:
+		if p.ModelFamily != "" && p.ModelFamily != "whisper" {
+			return fmt.Errorf("backend %s runs only the whisper model family", p.Backend)
+		}
+		return nil
+	default:
+		return fmt.Errorf("backend must be %q or %q", BackendWhisperX, BackendFasterWhisper)
+	}
+}
+
+// AdapterID returns the ID of the transcription adapter that runs jobs with
+// these parameters: the adapter of their hosted engine if they name one, else
+// that of their model family, or for Whisper models that of their backend
+func (p WhisperXParams) AdapterID() string {
+	if p.Engine == EngineOpenAI {
+		return EngineOpenAI
+	}
+	switch p.ModelFamily {
+	case "nvidia_parakeet":
+		return "parakeet"
+	case "nvidia_canary":
+		return "canary"
+	default:
+		// "whisper" and the default fallback
+		if p.Backend == BackendFasterWhisper {
+			return BackendFasterWhisper
+		}
+		return BackendWhisperX
+	}
+}
+
+// LanguageAuto asks the model to detect the spoken language
+const LanguageAuto = "auto"
+
+// DetectsLanguage reports whether the job leaves the language to the model
+func (p WhisperXParams) DetectsLanguage() bool {
+	if p.Language == nil {
+		return true
+	}
+	language := strings.TrimSpace(*p.Language)
+	return language == "" || strings.EqualFold(language, LanguageAuto)
+}
+
+// Tasks WhisperX can perform
+const (
+	TaskTranscribe = "transcribe"
+	TaskTranslate  = "translate" // Transcribe into English
+)
+
+// ErrTranslateEnglish is returned for translation jobs whose audio is
+// already English
+var ErrTranslateEnglish = errors.New("task translate produces English and cannot be used with language en")
+
+// ValidateTask checks the task, and that translation is not requested for
+// English audio
+func (p WhisperXParams) ValidateTask() error {
+	switch p.Task {
+	case "", TaskTranscribe:
+		return nil
+	case TaskTranslate:
+		if p.Language != nil && strings.EqualFold(strings.TrimSpace(*p.Language), "en") {
+			return ErrTranslateEnglish
+		}
+		return nil
+	default:
+		return fmt.Errorf("task must be %q or %q", TaskTranscribe, TaskTranslate)
+	}
 }
 
 // BeforeCreate sets the ID if not already set
@@ -133,21 +538,43 @@ func (tj *TranscriptionJob) BeforeCreate(tx *gorm.DB) error {
 
 // User represents a user for authentication
 type User struct {
-	ID                       uint      `json:"id" gorm:"primaryKey"`
-	Username                 string    `json:"username" gorm:"uniqueIndex;not null;type:varchar(50)"`
-	Password                 string    `json:"-" gorm:"not null;type:varchar(255)"`
-	DefaultProfileID         *string   `json:"default_profile_id,omitempty" gorm:"type:varchar(36)"`
-	AutoTranscriptionEnabled bool      `json:"auto_transcription_enabled" gorm:"not null;default:false"`
-	CreatedAt                time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt                time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID                       uint           `json:"id" gorm:"primaryKey"`
+	Username                 string         `json:"username" gorm:"uniqueIndex;not null;type:varchar(50)"`
+	Password                 string         `json:"-" gorm:"not null;type:varchar(255)"`
+	DisplayName              string         `json:"display_name" gorm:"type:varchar(100);not null;default:''"`
+	DefaultProfileID         *string        `json:"default_profile_id,omitempty" gorm:"type:varchar(36)"`
+	AutoTranscriptionEnabled bool           `json:"auto_transcription_enabled" gorm:"not null;default:false"`
+	Role                     string         `json:"role" gorm:"type:varchar(20);not null;default:'user'"`
+	OIDCIssuer               *string        `json:"-" gorm:"column:oidc_issuer;type:varchar(255);uniqueIndex:idx_users_oidc_identity"`
+	OIDCSubject              *string        `json:"-" gorm:"column:oidc_subject;type:varchar(255);uniqueIndex:idx_users_oidc_identity"`
+	Disabled                 bool           `json:"disabled" gorm:"not null;default:false"`
+	PasswordChangedAt        *time.Time     `json:"-"`
+	CreatedAt                time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt                time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt                gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// IsActive reports whether the account may authenticate
+func (u *User) IsActive() bool {
+	return !u.Disabled && !u.DeletedAt.Valid
 }
 
+// User roles
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
 // APIKey represents an API key for external authentication
 type APIKey struct {
 	ID          uint       `json:"id" gorm:"primaryKey"`
-	Key         string     `json:"key" gorm:"uniqueIndex;not null;type:varchar(255)"`
+	UserID      *uint      `json:"user_id,omitempty" gorm:"index"`
+	KeyHash     string     `json:"-" gorm:"uniqueIndex;type:varchar(64)"`
+	KeyPrefix   string     `json:"key_prefix" gorm:"type:varchar(16)"`
 	Name        string     `json:"name" gorm:"not null;type:varchar(100)"`
 	Description *string    `json:"description,omitempty" gorm:"type:text"`
+	Scopes      string     `json:"-" gorm:"type:text"` // Comma-separated; empty means read and write
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" gorm:"index"`
 	// IsActive should persist explicit false values; avoid default tag to prevent
 	// GORM from overriding false with DB defaults during inserts.
 	IsActive  bool       `json:"is_active" gorm:"type:boolean;not null"`
@@ -156,20 +583,71 @@ type APIKey struct {
 	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
-// BeforeCreate sets the API key if not already set
-func (ak *APIKey) BeforeCreate(tx *gorm.DB) error {
-	if ak.Key == "" {
-		ak.Key = uuid.New().String()
+// API key scopes. Each scope includes the ones before it: write keys may
+// also read, and admin keys may also write.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
+// APIKeyScopes lists the scopes an API key may be given, least to most powerful
+var APIKeyScopes = []string{ScopeRead, ScopeWrite, ScopeAdmin}
+
+// HasScope reports whether the key grants scope. Keys without scopes may read
+// and write, but only keys given the admin scope reach admin routes.
+func (ak *APIKey) HasScope(scope string) bool {
+	granted := ak.ScopeList()
+	if len(granted) == 0 {
+		granted = []string{ScopeWrite}
+	}
+	required := slices.Index(APIKeyScopes, scope)
+	if required < 0 {
+		return false
+	}
+	for _, g := range granted {
+		if slices.Index(APIKeyScopes, g) >= required {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeList returns the key's scopes
+func (ak *APIKey) ScopeList() []string {
+	if ak.Scopes == "" {
+		return []string{}
 	}
-	return nil
+	return strings.Split(ak.Scopes, ",")
+}
+
+// SetScopes stores scopes, dropping blanks and duplicates
+func (ak *APIKey) SetScopes(scopes []string) {
+	seen := make(map[string]bool, len(scopes))
+	cleaned := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || seen[scope] {
+			continue
+		}
+		seen[scope] = true
+		cleaned = append(cleaned, scope)
+	}
+	ak.Scopes = strings.Join(cleaned, ",")
+}
+
+// IsExpired reports whether the key has passed its expiry time
+func (ak *APIKey) IsExpired() bool {
+	return ak.ExpiresAt != nil && time.Now().After(*ak.ExpiresAt)
 }
 
 // TranscriptionProfile represents a saved transcription configuration profile
 type TranscriptionProfile struct {
 	ID          string         `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	UserID      *uint          `json:"user_id,omitempty" gorm:"index"` // Owner; profiles without one are shared with every user
 	Name        string         `json:"name" gorm:"type:varchar(255);not null"`
 	Description *string        `json:"description,omitempty" gorm:"type:text"`
-	IsDefault   bool           `json:"is_default" gorm:"type:boolean;default:false"`
+	IsDefault   bool           `json:"is_default" gorm:"type:boolean;default:false"` // Default among the owner's profiles, or among shared ones
 	Parameters  WhisperXParams `json:"parameters" gorm:"embedded"`
 	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
@@ -183,11 +661,18 @@ func (tp *TranscriptionProfile) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
-// BeforeSave ensures only one profile can be default
+// BeforeSave ensures each owner has at most one default profile, and that
+// at most one shared profile is the default
 func (tp *TranscriptionProfile) BeforeSave(tx *gorm.DB) error {
 	if tp.IsDefault {
-		// Set all other profiles to not default
-		if err := tx.Model(&TranscriptionProfile{}).Where("id != ?", tp.ID).Update("is_default", false).Error; err != nil {
+		// Set the owner's other profiles to not default
+		query := tx.Model(&TranscriptionProfile{}).Where("id != ?", tp.ID)
+		if tp.UserID != nil {
+			query = query.Where("user_id = ?", *tp.UserID)
+		} else {
+			query = query.Where("user_id IS NULL")
+		}
+		if err := query.Update("is_default", false).Error; err != nil {
 			return err
 		}
 	}
@@ -256,12 +741,20 @@ type ChatMessage struct {
 	Role          string    `json:"role" gorm:"type:varchar(20);not null"` // "user" or "assistant"
 	Content       string    `json:"content" gorm:"type:text;not null"`
 	TokensUsed    *int      `json:"tokens_used,omitempty" gorm:"type:integer"`
+	Sources       []ChatSource `json:"sources,omitempty" gorm:"serializer:json;type:text"` // Transcript passages an answer was based on
 	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
 
 	// Relationships
 	ChatSession ChatSession `json:"chat_session,omitempty" gorm:"foreignKey:ChatSessionID"`
 }
 
+// ChatSource is a stretch of the transcript given to the model as context
+// for an answer, so the answer can link back into the audio
+type ChatSource struct {
+	Start float64 `json:"start"` // Seconds
+	End   float64 `json:"end"`
+}
+
 // BeforeCreate sets both session IDs to the same value for compatibility
 func (cm *ChatMessage) BeforeCreate(tx *gorm.DB) error {
 	if cm.SessionID == "" {
@@ -297,6 +790,9 @@ type TranscriptionJobExecution struct {
 	MergeEndTime      *time.Time `json:"merge_end_time,omitempty" gorm:"type:datetime"`
 	MergeDuration     *int64     `json:"merge_duration,omitempty"` // Merge phase duration in milliseconds
 
+	// Time spent applying the job's audio preprocessing, in milliseconds
+	PreprocessingDuration *int64 `json:"preprocessing_duration,omitempty"`
+
 	// Parameters used for this execution (may differ from job parameters due to profiles)
 	ActualParameters WhisperXParams `json:"actual_parameters" gorm:"embedded;embeddedPrefix:actual_"`
 
@@ -0,0 +1,147 @@
+package models
+
+import "testing"
+
+func TestValidateDiarization(t *testing.T) {
+	n := func(v int) *int { return &v }
+	valid := []WhisperXParams{
+		{},
+		{MaxSpeakers: n(3)},
+		{MinSpeakers: n(2)},
+		{MinSpeakers: n(2), MaxSpeakers: n(2)},
+		{MinSpeakers: n(1), MaxSpeakers: n(5)},
+	}
+	for i, params := range valid {
+		if err := params.ValidateDiarization(); err != nil {
+			t.Errorf("Expected valid case %d to pass, got %v", i, err)
+		}
+	}
+
+	invalid := []WhisperXParams{
+		{MinSpeakers: n(0)},
+		{MaxSpeakers: n(0)},
+		{MinSpeakers: n(3), MaxSpeakers: n(2)},
+	}
+	for i, params := range invalid {
+		if err := params.ValidateDiarization(); err == nil {
+			t.Errorf("Expected invalid case %d to be rejected", i)
+		}
+	}
+}
+
+func TestValidateVAD(t *testing.T) {
+	valid := []WhisperXParams{
+		{},
+		{VadOnset: 0.5, VadOffset: 0.363},
+		{VadOnset: 0.01, VadOffset: 0.99},
+	}
+	for i, params := range valid {
+		if err := params.ValidateVAD(); err != nil {
+			t.Errorf("Expected valid case %d to pass, got %v", i, err)
+		}
+	}
+
+	invalid := []WhisperXParams{
+		{VadOnset: 1},
+		{VadOnset: -0.2},
+		{VadOffset: 1.5},
+		{VadOnset: 0.5, VadOffset: -1},
+	}
+	for i, params := range invalid {
+		if err := params.ValidateVAD(); err == nil {
+			t.Errorf("Expected invalid case %d to be rejected", i)
+		}
+	}
+
+	// Unset thresholds fall back to the defaults
+	if onset, offset := (WhisperXParams{}).VADThresholds(); onset != DefaultVADOnset || offset != DefaultVADOffset {
+		t.Errorf("Expected default thresholds, got %v and %v", onset, offset)
+	}
+}
+
+func TestValidateBatchSize(t *testing.T) {
+	for _, batchSize := range []int{0, MinBatchSize, 16, MaxBatchSize} {
+		if err := (WhisperXParams{BatchSize: batchSize}).ValidateBatchSize(); err != nil {
+			t.Errorf("Expected batch size %d to be accepted, got %v", batchSize, err)
+		}
+	}
+	for _, batchSize := range []int{-1, MaxBatchSize + 1} {
+		if err := (WhisperXParams{BatchSize: batchSize}).ValidateBatchSize(); err == nil {
+			t.Errorf("Expected batch size %d to be rejected", batchSize)
+		}
+	}
+}
+
+func TestValidateThreads(t *testing.T) {
+	for _, threads := range []int{0, MinThreads, 8, MaxThreads} {
+		if err := (WhisperXParams{Threads: threads}).ValidateThreads(); err != nil {
+			t.Errorf("Expected %d threads to be accepted, got %v", threads, err)
+		}
+	}
+	for _, threads := range []int{-1, MaxThreads + 1} {
+		if err := (WhisperXParams{Threads: threads}).ValidateThreads(); err == nil {
+			t.Errorf("Expected %d threads to be rejected", threads)
+		}
+	}
+}
+
+func TestValidateBackend(t *testing.T) {
+	for _, params := range []WhisperXParams{
+		{},
+		{Backend: BackendWhisperX, ModelFamily: "nvidia_parakeet"},
+		{Backend: BackendFasterWhisper},
+		{Backend: BackendFasterWhisper, ModelFamily: "whisper"},
+	} {
+		if err := params.ValidateBackend(); err != nil {
+			t.Errorf("Expected %+v to be accepted, got %v", params, err)
+		}
+	}
+	for _, params := range []WhisperXParams{
+		{Backend: "whisper.cpp"},
+		{Backend: BackendFasterWhisper, ModelFamily: "nvidia_canary"},
+		{Engine: "deepgram"},
+	} {
+		if err := params.ValidateBackend(); err == nil {
+			t.Errorf("Expected %+v to be rejected", params)
+		}
+	}
+}
+
+func TestAdapterID(t *testing.T) {
+	for want, params := range map[string]WhisperXParams{
+		"whisperx":       {},
+		"faster-whisper": {Backend: BackendFasterWhisper},
+		"parakeet":       {ModelFamily: "nvidia_parakeet"},
+		"canary":         {ModelFamily: "nvidia_canary"},
+		"openai":         {Engine: EngineOpenAI, ModelFamily: "whisper"},
+	} {
+		if got := params.AdapterID(); got != want {
+			t.Errorf("Expected %+v to run on %s, got %s", params, want, got)
+		}
+	}
+}
+
+func TestComputeType(t *testing.T) {
+	for device, want := range map[string]string{"cuda": "float16", "mps": "float16", "cpu": "int8", "": "int8"} {
+		if got := DefaultComputeType(device); got != want {
+			t.Errorf("Expected compute type %s on %q, got %s", want, device, got)
+		}
+		if got := (WhisperXParams{Device: device}).ComputeTypeOrDefault(); got != want {
+			t.Errorf("Expected unset compute type to default to %s on %q, got %s", want, device, got)
+		}
+	}
+	if got := (WhisperXParams{Device: "cuda", ComputeType: "int8_float16"}).ComputeTypeOrDefault(); got != "int8_float16" {
+		t.Errorf("Expected the job's compute type to be kept, got %s", got)
+	}
+
+	for _, computeType := range append([]string{""}, ComputeTypes...) {
+		if err := (WhisperXParams{ComputeType: computeType}).ValidateComputeType(); err != nil {
+			t.Errorf("Expected %q to be accepted, got %v", computeType, err)
+		}
+	}
+	for _, computeType := range []string{"float64", "INT8", "bfloat16"} {
+		if err := (WhisperXParams{ComputeType: computeType}).ValidateComputeType(); err == nil {
+			t.Errorf("Expected %q to be rejected", computeType)
+		}
+	}
+}
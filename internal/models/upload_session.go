@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// UploadSession tracks a resumable upload whose chunks are appended to a
+// partial file in the upload directory until it is completed, or a presigned
+// upload that the client sends to a signed URL
+type UploadSession struct {
+	ID            string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	UserID        *uint      `json:"user_id,omitempty" gorm:"index"` // Uploader; other users cannot see the session
+	Filename      string     `json:"filename" gorm:"type:text;not null"`
+	Title         *string    `json:"title,omitempty" gorm:"type:text"`
+	Size          int64      `json:"size" gorm:"not null"`             // Declared total size in bytes
+	BytesReceived int64      `json:"offset" gorm:"not null;default:0"` // Bytes written so far; the next chunk starts here
+	ObjectKey     *string    `json:"-" gorm:"type:text"`               // Staging object of a presigned upload straight to object storage
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`             // When the presigned URL of the upload expires
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"autoUpdateTime;index"` // Last chunk; sessions idle past the TTL are removed
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+// What happens to a watched file once its job is created
+const (
+	WatchAfterMarker = "marker" // Leave the file in place with a sidecar marker
+	WatchAfterMove   = "move"   // Move the file to a done/ subfolder
+)
+
+// WatchFolder is a directory whose audio files are transcribed automatically
+type WatchFolder struct {
+	ID           uint    `json:"id" gorm:"primaryKey"`
+	Path         string  `json:"path" gorm:"type:text;not null;uniqueIndex"`
+	ProfileID    *string `json:"profile_id,omitempty" gorm:"type:varchar(36)"` // Transcription profile for new jobs; defaults when unset
+	AfterProcess string  `json:"after_process" gorm:"type:varchar(10);not null;default:'marker'"`
+	Recursive    bool    `json:"recursive" gorm:"type:boolean;not null"`
+	UsePolling   bool    `json:"use_polling" gorm:"type:boolean;default:false"` // Skip filesystem events, e.g. on network mounts
+	Enabled      bool    `json:"enabled" gorm:"type:boolean;not null"`
+
+	// Retention for jobs created from the folder, overriding the global policy
+	AudioRetentionDays *int `json:"audio_retention_days,omitempty"`
+	JobRetentionDays   *int `json:"job_retention_days,omitempty"`
+
+	// Organization applied to jobs created from the folder
+	Tags        []string `json:"tags,omitempty" gorm:"serializer:json;type:text"` // key:value tags
+	JobFolderID *uint    `json:"job_folder_id,omitempty"`                        // Folder the jobs are filed in
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// IsValidWatchAfterProcess reports whether action is a supported after-process action
+func IsValidWatchAfterProcess(action string) bool {
+	return action == WatchAfterMarker || action == WatchAfterMove
+}
+
+// WatchedFile records a file picked up from a watch folder by content hash, so
+// rescans and copies of the same recording do not create duplicate jobs
+type WatchedFile struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Hash      string    `json:"hash" gorm:"type:varchar(64);not null;uniqueIndex"` // SHA-256 of the file content
+	Path      string    `json:"path" gorm:"type:text;not null"`
+	JobID     string    `json:"job_id" gorm:"type:varchar(36);not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
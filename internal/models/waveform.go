@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// JobWaveform caches the peaks drawn by the audio player for a job, in the
+// audiowaveform .dat format. Peaks generated from audio at a path other than
+// the job's current one are stale, as when the audio has been re-normalized.
+type JobWaveform struct {
+	JobID     string    `json:"job_id" gorm:"primaryKey;type:varchar(36)"`
+	AudioPath string    `json:"audio_path" gorm:"type:text;not null"` // Audio the peaks were generated from
+	Detail    []byte    `json:"-"`
+	Overview  []byte    `json:"-"`
+	Error     *string   `json:"error,omitempty" gorm:"type:text"` // Why the audio could not be decoded
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName keeps the table name short
+func (JobWaveform) TableName() string {
+	return "waveforms"
+}
+
+// Fresh reports whether the peaks were generated from the audio at audioPath
+func (w *JobWaveform) Fresh(audioPath string) bool {
+	return w.AudioPath == audioPath
+}
@@ -0,0 +1,84 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	"scriberr/pkg/logger"
+)
+
+// backgroundPollInterval is how often a waiting background task checks
+// whether transcription has gone idle
+const backgroundPollInterval = 500 * time.Millisecond
+
+// backgroundTask is low-priority work run between transcriptions
+type backgroundTask struct {
+	key string
+	run func(ctx context.Context)
+}
+
+// SubmitBackground queues low-priority work, such as generating a
+// waveform, that runs one task at a time and only while no job is running or
+// waiting, so it never delays transcription. A task with the key of one
+// already waiting is dropped; it returns whether the task was queued.
+func (tq *TaskQueue) SubmitBackground(key string, run func(ctx context.Context)) bool {
+	tq.backgroundMutex.Lock()
+	defer tq.backgroundMutex.Unlock()
+	if tq.backgroundKeys == nil {
+		tq.backgroundKeys = make(map[string]bool)
+	}
+	if tq.backgroundKeys[key] {
+		return false
+	}
+	tq.backgroundKeys[key] = true
+	tq.background = append(tq.background, backgroundTask{key: key, run: run})
+	select {
+	case tq.backgroundReady <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// nextBackground removes and returns the oldest background task
+func (tq *TaskQueue) nextBackground() (backgroundTask, bool) {
+	tq.backgroundMutex.Lock()
+	defer tq.backgroundMutex.Unlock()
+	if len(tq.background) == 0 {
+		return backgroundTask{}, false
+	}
+	task := tq.background[0]
+	tq.background = tq.background[1:]
+	delete(tq.backgroundKeys, task.key)
+	return task, true
+}
+
+// idle reports whether no job is running or waiting for a worker
+func (tq *TaskQueue) idle() bool {
+	tq.jobsMutex.RLock()
+	running := len(tq.runningJobs)
+	tq.jobsMutex.RUnlock()
+	return running == 0 && tq.waitingCount() == 0
+}
+
+// backgroundWorker runs background tasks whenever transcription is idle
+func (tq *TaskQueue) backgroundWorker() {
+	defer tq.wg.Done()
+
+	ticker := time.NewTicker(backgroundPollInterval)
+	defer ticker.Stop()
+	for {
+		if tq.idle() {
+			if task, ok := tq.nextBackground(); ok {
+				logger.Debug("Running background task", "key", task.key)
+				task.run(tq.ctx)
+				continue
+			}
+		}
+		select {
+		case <-tq.backgroundReady:
+		case <-ticker.C:
+		case <-tq.ctx.Done():
+			return
+		}
+	}
+}
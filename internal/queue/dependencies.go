@@ -0,0 +1,66 @@
+package queue
+
+import (
+	"fmt"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+)
+
+// dependenciesSettled reports whether every job a waiting job depends on
+// has completed or failed, so the job can leave the queue. Deleted
+// dependencies count as failed. Callers hold waitingMutex.
+func (tq *TaskQueue) dependenciesSettled(job *queuedJob) bool {
+	if len(job.dependsOn) == 0 {
+		return true
+	}
+	var unsettled int64
+	if err := database.DB.Model(&models.TranscriptionJob{}).
+		Where("id IN ? AND status NOT IN ?", job.dependsOn, []models.JobStatus{models.StatusCompleted, models.StatusFailed}).
+		Count(&unsettled).Error; err != nil {
+		logger.Warn("Failed to check job dependencies", "job_id", job.id, "error", err)
+		return false
+	}
+	if unsettled > 0 {
+		if !job.waitingOnDependencies {
+			logger.Debug("Job waiting for its dependencies", "job_id", job.id, "depends_on", job.dependsOn)
+			job.waitingOnDependencies = true
+		}
+		return false
+	}
+	return true
+}
+
+// failedDependency returns why a job whose dependencies have settled cannot
+// run, or "" when all of them completed
+func failedDependency(jobID string) string {
+	var job models.TranscriptionJob
+	if err := database.DB.Select("id", "depends_on").Where("id = ?", jobID).First(&job).Error; err != nil || len(job.DependsOn) == 0 {
+		return ""
+	}
+	var completed []string
+	if err := database.DB.Model(&models.TranscriptionJob{}).
+		Where("id IN ? AND status = ?", job.DependsOn, models.StatusCompleted).
+		Pluck("id", &completed).Error; err != nil {
+		return fmt.Sprintf("failed to check dependencies: %v", err)
+	}
+	done := make(map[string]bool, len(completed))
+	for _, id := range completed {
+		done[id] = true
+	}
+	for _, id := range job.DependsOn {
+		if !done[id] {
+			return fmt.Sprintf("dependency %s did not complete", id)
+		}
+	}
+	return ""
+}
+
+// recheckDependencies wakes workers to look again at jobs waiting for their
+// dependencies, after a job completes or fails
+func (tq *TaskQueue) recheckDependencies() {
+	tq.waitingMutex.Lock()
+	tq.waitingCond.Broadcast()
+	tq.waitingMutex.Unlock()
+}
@@ -0,0 +1,84 @@
+package queue
+
+import (
+	"scriberr/pkg/logger"
+)
+
+// LimitJobsPerDevice caps how many jobs run at once on each device, such as
+// "cuda", so parallel models do not run out of memory. Devices without a
+// positive limit are unlimited. Jobs set to the "auto" device, or to none,
+// count against autoDevice. Call it before Start.
+func (tq *TaskQueue) LimitJobsPerDevice(limits map[string]int, autoDevice string) {
+	for device, limit := range limits {
+		if limit > 0 {
+			tq.deviceSlots.Store(device, make(chan struct{}, limit))
+			tq.deviceLimited = true
+		}
+	}
+	tq.autoDevice = autoDevice
+}
+
+// jobDevice returns the device a job set to device runs on
+func (tq *TaskQueue) jobDevice(device string) string {
+	if (device == "" || device == "auto") && tq.autoDevice != "" {
+		return tq.autoDevice
+	}
+	return device
+}
+
+// nextRunnable returns the first job in line whose device has a free slot and
+// whose dependencies have settled, or nil if no waiting job can start.
+// Callers hold waitingMutex.
+func (tq *TaskQueue) nextRunnable() *queuedJob {
+	if len(tq.waiting) == 0 {
+		return nil
+	}
+	if !tq.deviceLimited && len(tq.waiting[0].dependsOn) == 0 {
+		return tq.waiting[0]
+	}
+	var next *queuedJob
+	for _, job := range tq.waiting {
+		if tq.deviceLimited && !tq.deviceFree(job.device) {
+			if !job.blocked {
+				logger.Debug("Job waiting for a device slot", "job_id", job.id, "device", job.device)
+				job.blocked = true
+			}
+			continue
+		}
+		if next != nil && !tq.waiting.Less(job.index, next.index) {
+			continue
+		}
+		if tq.dependenciesSettled(job) {
+			next = job
+		}
+	}
+	return next
+}
+
+// deviceFree reports whether a device can start another job
+func (tq *TaskQueue) deviceFree(device string) bool {
+	slots, limited := tq.deviceSlots.Load(device)
+	if !limited {
+		return true
+	}
+	return len(slots.(chan struct{})) < cap(slots.(chan struct{}))
+}
+
+// claimDevice takes a slot on a device, which must be free, and returns a
+// function giving it back. Callers hold waitingMutex, so no other worker can
+// take the slot in between.
+func (tq *TaskQueue) claimDevice(device string) func() {
+	value, limited := tq.deviceSlots.Load(device)
+	if !limited {
+		return func() {}
+	}
+	slots := value.(chan struct{})
+	slots <- struct{}{}
+	return func() {
+		<-slots
+		// Wake workers waiting for the slot
+		tq.waitingMutex.Lock()
+		tq.waitingCond.Broadcast()
+		tq.waitingMutex.Unlock()
+	}
+}
@@ -1,6 +1,7 @@
 package queue
 
 import (
+	"container/heap"
 	"context"
 	"fmt"
 	"log"
@@ -12,8 +13,12 @@ import (
 	"sync/atomic"
 	"time"
 
+	"scriberr/internal/cleanup"
 	"scriberr/internal/database"
+	"scriberr/internal/events"
 	"scriberr/internal/models"
+	"scriberr/internal/quota"
+	"scriberr/internal/transcription/process"
 	"scriberr/pkg/logger"
 )
 
@@ -23,12 +28,75 @@ type RunningJob struct {
 	Process *exec.Cmd
 }
 
+// queueCapacity is how many jobs may wait for a worker
+const queueCapacity = 200
+
+// queuedJob is a job waiting for a worker
+type queuedJob struct {
+	id        string
+	priority  int
+	createdAt time.Time
+	queuedAt  time.Time // When the job joined the queue
+	seq       uint64    // Enqueue order, for jobs created at the same time
+	index     int       // Position in the heap
+	device    string    // Device the job runs on, for per-device limits
+	blocked   bool      // Already logged as waiting for a device slot
+
+	dependsOn             []string // Jobs that must complete or fail before this one leaves the queue
+	waitingOnDependencies bool     // Already logged as waiting for its dependencies
+}
+
+// jobHeap orders waiting jobs like the pending job scan: highest priority
+// first, then oldest first
+type jobHeap []*queuedJob
+
+func (h jobHeap) Len() int { return len(h) }
+
+func (h jobHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	if !h[i].createdAt.Equal(h[j].createdAt) {
+		return h[i].createdAt.Before(h[j].createdAt)
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h jobHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *jobHeap) Push(x any) {
+	job := x.(*queuedJob)
+	job.index = len(*h)
+	*h = append(*h, job)
+}
+
+func (h *jobHeap) Pop() any {
+	old := *h
+	job := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return job
+}
+
+// ScanInterval is how often the job scanner looks for pending jobs
+const ScanInterval = 10 * time.Second
+
 // TaskQueue manages transcription job processing
 type TaskQueue struct {
 	minWorkers     int
 	maxWorkers     int
 	currentWorkers int64 // Use atomic for thread-safe access
-	jobChannel     chan string
+	waiting        jobHeap
+	waitingIndex   map[string]*queuedJob
+	waitingMutex   sync.Mutex
+	waitingCond    *sync.Cond // Signalled when a job is queued or the queue stops
+	nextSeq        uint64
+	stopped        bool
+	holds          int // Workers start no jobs while positive
 	ctx            context.Context
 	cancel         context.CancelFunc
 	wg             sync.WaitGroup
@@ -37,6 +105,19 @@ type TaskQueue struct {
 	jobsMutex      sync.RWMutex
 	autoScale      bool
 	lastScaleTime  time.Time
+	deleteAudio    bool     // Remove audio once a job completes
+	cacheResults   bool     // Complete jobs from identical completed ones
+	deviceSlots    sync.Map // Device name to a semaphore channel of its job limit
+	deviceLimited  bool
+	autoDevice     string // Device jobs set to "auto" count against
+	jobTimeout     time.Duration
+	timeoutRetries int   // Times a job that timed out is queued again
+	heartbeat      int64 // Unix nanoseconds of the job scanner's last pass; use atomic
+
+	background      []backgroundTask // Low-priority work waiting for the queue to go idle
+	backgroundKeys  map[string]bool  // Keys of the waiting background tasks
+	backgroundMutex sync.Mutex
+	backgroundReady chan struct{} // Signalled when a background task is queued
 }
 
 // JobProcessor defines the interface for processing jobs
@@ -93,18 +174,21 @@ func NewTaskQueue(legacyWorkers int, processor JobProcessor) *TaskQueue {
 		autoScale = false // Disable auto-scaling if min == max
 	}
 
-	return &TaskQueue{
-		minWorkers:     min,
-		maxWorkers:     max,
-		currentWorkers: int64(min),
-		jobChannel:     make(chan string, 200), // Increased buffer for better throughput
-		ctx:            ctx,
-		cancel:         cancel,
-		processor:      processor,
-		runningJobs:    make(map[string]*RunningJob),
-		autoScale:      autoScale,
-		lastScaleTime:  time.Now(),
+	tq := &TaskQueue{
+		minWorkers:      min,
+		maxWorkers:      max,
+		currentWorkers:  int64(min),
+		waitingIndex:    make(map[string]*queuedJob),
+		ctx:             ctx,
+		cancel:          cancel,
+		processor:       processor,
+		runningJobs:     make(map[string]*RunningJob),
+		autoScale:       autoScale,
+		lastScaleTime:   time.Now(),
+		backgroundReady: make(chan struct{}, 1),
 	}
+	tq.waitingCond = sync.NewCond(&tq.waitingMutex)
+	return tq
 }
 
 // Start starts the task queue workers
@@ -122,10 +206,19 @@ func (tq *TaskQueue) Start() {
 		go tq.worker(i)
 	}
 
-	// Start the job scanner
+	// Start the job scanner, which keeps the heartbeat from here on
+	tq.beat()
 	tq.wg.Add(1)
 	go tq.jobScanner()
 
+	// Start releasing scheduled jobs
+	tq.wg.Add(1)
+	go tq.scheduler()
+
+	// Start running low-priority work between jobs
+	tq.wg.Add(1)
+	go tq.backgroundWorker()
+
 	// Start auto-scaling monitor if enabled
 	if tq.autoScale {
 		tq.wg.Add(1)
@@ -137,123 +230,287 @@ func (tq *TaskQueue) Start() {
 func (tq *TaskQueue) Stop() {
 	logger.Debug("Stopping task queue")
 	tq.cancel()
-	close(tq.jobChannel)
+	tq.waitingMutex.Lock()
+	tq.stopped = true
+	tq.waitingCond.Broadcast()
+	tq.waitingMutex.Unlock()
 	tq.wg.Wait()
 	logger.Debug("Task queue stopped")
 }
 
-// EnqueueJob adds a job to the queue
-func (tq *TaskQueue) EnqueueJob(jobID string) (err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			err = fmt.Errorf("queue is shutting down")
-		}
-	}()
-
-	select {
-	case <-tq.ctx.Done():
-		return fmt.Errorf("queue is shutting down")
-	default:
+// EnqueueJob adds a job to the queue at the priority stored on the job. A job
+// the result cache completes is not queued.
+func (tq *TaskQueue) EnqueueJob(jobID string) error {
+	if tq.completeFromCache(jobID, false) {
+		return nil
 	}
+	return tq.enqueue(queueOrder(jobID))
+}
 
-	select {
-	case tq.jobChannel <- jobID:
+// SubmitWithPriority stores a job's priority and adds it to the queue. A job
+// that is already waiting is moved to its new place in line.
+func (tq *TaskQueue) SubmitWithPriority(ctx context.Context, jobID string, priority int) error {
+	if !models.IsValidPriority(priority) {
+		return fmt.Errorf("invalid priority %d", priority)
+	}
+	var job models.TranscriptionJob
+	if err := database.DB.WithContext(ctx).Select("id", "created_at", "device", "depends_on").Where("id = ?", jobID).First(&job).Error; err != nil {
+		return fmt.Errorf("failed to load job %s: %w", jobID, err)
+	}
+	if err := database.DB.WithContext(ctx).Model(&job).Update("priority", priority).Error; err != nil {
+		return fmt.Errorf("failed to set priority of job %s: %w", jobID, err)
+	}
+	job.Priority = priority
+	if tq.completeFromCache(jobID, false) {
 		return nil
-	case <-tq.ctx.Done():
+	}
+	return tq.enqueue(&job)
+}
+
+// queueOrder loads what placing a job in line needs: its priority, creation
+// time, device and dependencies. Jobs missing from the database are treated as normal
+// priority jobs created now.
+func queueOrder(jobID string) *models.TranscriptionJob {
+	var jobs []models.TranscriptionJob
+	if err := database.DB.Select("id", "priority", "created_at", "device", "depends_on").Where("id = ?", jobID).Limit(1).Find(&jobs).Error; err != nil || len(jobs) == 0 {
+		return &models.TranscriptionJob{ID: jobID, Priority: models.PriorityNormal, CreatedAt: time.Now()}
+	}
+	return &jobs[0]
+}
+
+// enqueue adds a job to the waiting jobs, or updates its priority and
+// dependencies if it is already waiting
+func (tq *TaskQueue) enqueue(job *models.TranscriptionJob) error {
+	tq.waitingMutex.Lock()
+	defer tq.waitingMutex.Unlock()
+
+	if tq.stopped || tq.ctx.Err() != nil {
 		return fmt.Errorf("queue is shutting down")
-	default:
+	}
+	if waiting, exists := tq.waitingIndex[job.ID]; exists {
+		waiting.priority = job.Priority
+		waiting.dependsOn = job.DependsOn
+		heap.Fix(&tq.waiting, waiting.index)
+		tq.waitingCond.Signal()
+		return nil
+	}
+	if len(tq.waiting) >= queueCapacity {
 		return fmt.Errorf("queue is full")
 	}
+
+	tq.nextSeq++
+	waiting := &queuedJob{
+		id:        job.ID,
+		priority:  job.Priority,
+		createdAt: job.CreatedAt,
+		queuedAt:  time.Now(),
+		seq:       tq.nextSeq,
+		device:    tq.jobDevice(job.Parameters.Device),
+		dependsOn: job.DependsOn,
+	}
+	heap.Push(&tq.waiting, waiting)
+	tq.waitingIndex[job.ID] = waiting
+	tq.waitingCond.Signal()
+	return nil
+}
+
+// dequeue waits for the next job whose device has a free slot and whose
+// dependencies have settled, and claims the slot. It returns the job with a function releasing the slot, or false once
+// the queue stops.
+func (tq *TaskQueue) dequeue() (*queuedJob, func(), bool) {
+	tq.waitingMutex.Lock()
+	defer tq.waitingMutex.Unlock()
+
+	for !tq.stopped {
+		if tq.holds == 0 {
+			if job := tq.nextRunnable(); job != nil {
+				heap.Remove(&tq.waiting, job.index)
+				delete(tq.waitingIndex, job.id)
+				return job, tq.claimDevice(job.device), true
+			}
+		}
+		tq.waitingCond.Wait()
+	}
+	return nil, nil, false
 }
 
-// worker processes jobs from the channel
+// Hold stops workers from starting jobs until the returned release is
+// called. Jobs keep queuing meanwhile and running jobs are not affected.
+// Holds nest; workers resume once every hold is released.
+func (tq *TaskQueue) Hold() (release func()) {
+	tq.waitingMutex.Lock()
+	tq.holds++
+	tq.waitingMutex.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			tq.waitingMutex.Lock()
+			tq.holds--
+			tq.waitingCond.Broadcast()
+			tq.waitingMutex.Unlock()
+		})
+	}
+}
+
+// waitingCount returns how many jobs are waiting for a worker
+func (tq *TaskQueue) waitingCount() int {
+	tq.waitingMutex.Lock()
+	defer tq.waitingMutex.Unlock()
+	return len(tq.waiting)
+}
+
+// worker processes jobs from the queue, highest priority first
 func (tq *TaskQueue) worker(id int) {
 	defer tq.wg.Done()
 
 	logger.Debug("Worker started", "worker_id", id)
+	events.Publish(events.Event{Type: events.TypeWorkerStarted, WorkerID: &id})
 
 	for {
-		select {
-		case jobID, ok := <-tq.jobChannel:
-			if !ok {
-				logger.Debug("Worker stopped", "worker_id", id)
-				return
-			}
-
-			logger.WorkerOperation(id, jobID, "start")
+		job, release, ok := tq.dequeue()
+		if !ok {
+			logger.Debug("Worker stopped", "worker_id", id)
+			events.Publish(events.Event{Type: events.TypeWorkerStopped, WorkerID: &id})
+			return
+		}
+		jobID := job.id
 
-			// Update job status to processing
-			if err := tq.updateJobStatus(jobID, models.StatusProcessing); err != nil {
-				logger.Error("Failed to update job status", "worker_id", id, "job_id", jobID, "error", err)
-				continue
+		// Jobs whose dependencies failed fail without running
+		if reason := failedDependency(jobID); reason != "" {
+			logger.Warn("Job dependency failed", "worker_id", id, "job_id", jobID, "reason", reason)
+			if err := tq.updateJobError(jobID, reason); err != nil {
+				logger.Error("Failed to record dependency error", "worker_id", id, "job_id", jobID, "error", err)
 			}
-
-			// Create context for this job and track it
-			jobCtx, jobCancel := context.WithCancel(tq.ctx)
-			runningJob := &RunningJob{
-				Cancel:  jobCancel,
-				Process: nil, // Will be set by registerProcess callback
+			if err := tq.updateJobStatus(jobID, models.StatusFailed); err != nil {
+				logger.Error("Failed to mark job as failed", "worker_id", id, "job_id", jobID, "error", err)
 			}
+			release()
+			continue
+		}
 
-			tq.jobsMutex.Lock()
-			tq.runningJobs[jobID] = runningJob
-			tq.jobsMutex.Unlock()
+		// Jobs the result cache completes do not run
+		if tq.completeFromCache(jobID, true) {
+			release()
+			continue
+		}
 
-			// Register process callback
-			registerProcess := func(cmd *exec.Cmd) {
-				tq.jobsMutex.Lock()
-				if job, exists := tq.runningJobs[jobID]; exists {
-					job.Process = cmd
-				}
-				tq.jobsMutex.Unlock()
-			}
+		logger.WorkerOperation(id, jobID, "start")
+		if err := database.StartRunTimings(jobID, time.Since(job.queuedAt)); err != nil {
+			logger.Warn("Failed to record queue wait", "worker_id", id, "job_id", jobID, "error", err)
+		}
 
-			// Process the job with process registration
-			err := tq.processor.ProcessJobWithProcess(jobCtx, jobID, registerProcess)
+		// Update job status to processing
+		if err := tq.updateJobStatus(jobID, models.StatusProcessing); err != nil {
+			logger.Error("Failed to update job status", "worker_id", id, "job_id", jobID, "error", err)
+			release()
+			continue
+		}
+
+		// Create context for this job and track it
+		jobCtx, jobCancel := tq.jobContext(jobID)
+		runningJob := &RunningJob{
+			Cancel:  jobCancel,
+			Process: nil, // Will be set by registerProcess callback
+		}
 
-			// Remove job from running jobs
+		tq.jobsMutex.Lock()
+		tq.runningJobs[jobID] = runningJob
+		tq.jobsMutex.Unlock()
+
+		// Register process callback
+		registerProcess := func(cmd *exec.Cmd) {
 			tq.jobsMutex.Lock()
-			delete(tq.runningJobs, jobID)
+			if job, exists := tq.runningJobs[jobID]; exists {
+				job.Process = cmd
+			}
 			tq.jobsMutex.Unlock()
+		}
 
-			// Handle result
-			if err != nil {
-				if jobCtx.Err() == context.Canceled {
-					logger.Info("Job cancelled", "worker_id", id, "job_id", jobID)
-					if err := tq.updateJobStatus(jobID, models.StatusFailed); err != nil {
-						logger.Error("Failed to mark cancelled job as failed", "worker_id", id, "job_id", jobID, "error", err)
-					}
-					if err := tq.updateJobError(jobID, "Job was cancelled by user"); err != nil {
-						logger.Error("Failed to record cancellation error", "worker_id", id, "job_id", jobID, "error", err)
-					}
-				} else {
-					logger.Error("Job processing failed", "worker_id", id, "job_id", jobID, "error", err)
-					if statusErr := tq.updateJobStatus(jobID, models.StatusFailed); statusErr != nil {
-						logger.Error("Failed to mark job as failed after error", "worker_id", id, "job_id", jobID, "error", statusErr)
-					}
-					if updateErr := tq.updateJobError(jobID, err.Error()); updateErr != nil {
-						logger.Error("Failed to record job error", "worker_id", id, "job_id", jobID, "error", updateErr)
-					}
+		// Process the job with process registration
+		started := time.Now()
+		err := tq.processor.ProcessJobWithProcess(jobCtx, jobID, registerProcess)
+
+		// Remove job from running jobs. Why the job's context ended is read
+		// before releasing it, which cancels it.
+		tq.jobsMutex.Lock()
+		delete(tq.runningJobs, jobID)
+		tq.jobsMutex.Unlock()
+		ctxErr := jobCtx.Err()
+		jobCancel()
+
+		// Handle result
+		if err != nil {
+			if process.Default.Stopping() {
+				// Left processing, so the next start requeues it
+				logger.Info("Job interrupted by shutdown", "worker_id", id, "job_id", jobID)
+			} else if ctxErr == context.DeadlineExceeded {
+				tq.handleTimeout(id, jobID)
+			} else if ctxErr == context.Canceled {
+				logger.Info("Job cancelled", "worker_id", id, "job_id", jobID)
+				if err := tq.updateJobStatus(jobID, models.StatusFailed); err != nil {
+					logger.Error("Failed to mark cancelled job as failed", "worker_id", id, "job_id", jobID, "error", err)
+				}
+				if err := tq.updateJobError(jobID, "Job was cancelled by user"); err != nil {
+					logger.Error("Failed to record cancellation error", "worker_id", id, "job_id", jobID, "error", err)
 				}
 			} else {
-				logger.Debug("Job processed successfully", "worker_id", id, "job_id", jobID)
-				if err := tq.updateJobStatus(jobID, models.StatusCompleted); err != nil {
-					logger.Error("Failed to mark job as completed", "worker_id", id, "job_id", jobID, "error", err)
+				logger.Error("Job processing failed", "worker_id", id, "job_id", jobID, "error", err)
+				if statusErr := tq.updateJobStatus(jobID, models.StatusFailed); statusErr != nil {
+					logger.Error("Failed to mark job as failed after error", "worker_id", id, "job_id", jobID, "error", statusErr)
 				}
+				if updateErr := tq.updateJobError(jobID, err.Error()); updateErr != nil {
+					logger.Error("Failed to record job error", "worker_id", id, "job_id", jobID, "error", updateErr)
+				}
+				tq.recordJobStats(jobID, models.StatusFailed, started)
+			}
+		} else {
+			logger.Debug("Job processed successfully", "worker_id", id, "job_id", jobID)
+			if err := tq.updateJobStatus(jobID, models.StatusCompleted); err != nil {
+				logger.Error("Failed to mark job as completed", "worker_id", id, "job_id", jobID, "error", err)
+			}
+			tq.recordJobStats(jobID, models.StatusCompleted, started)
+			if err := quota.ChargeJob(tq.ctx, jobID); err != nil {
+				logger.Error("Failed to charge job to quota", "worker_id", id, "job_id", jobID, "error", err)
+			}
+			if tq.deleteAudio {
+				tq.removeAudio(jobID)
 			}
-
-		case <-tq.ctx.Done():
-			logger.Debug("Worker stopped", "worker_id", id, "reason", "context_cancelled")
-			return
 		}
+		release()
 	}
 }
 
+// DeleteAudioAfterTranscription makes workers remove a job's audio once the
+// job completes, keeping the transcript. Call it before Start.
+func (tq *TaskQueue) DeleteAudioAfterTranscription(enabled bool) {
+	tq.deleteAudio = enabled
+}
+
+// removeAudio deletes a completed job's audio. Failures are logged and leave
+// the job completed.
+func (tq *TaskQueue) removeAudio(jobID string) {
+	var job models.TranscriptionJob
+	if err := database.DB.Where("id = ?", jobID).First(&job).Error; err != nil {
+		logger.Warn("Failed to load job to delete its audio", "job_id", jobID, "error", err)
+		return
+	}
+	if job.Pinned {
+		return
+	}
+	bytes, err := cleanup.RemoveAudio(tq.ctx, &job)
+	if err != nil {
+		logger.Warn("Failed to delete audio after transcription", "job_id", jobID, "path", job.AudioPath, "error", err)
+		return
+	}
+	logger.Info("Deleted audio after transcription", "job_id", jobID, "path", job.AudioPath, "bytes", bytes)
+}
+
 // jobScanner scans for pending jobs and adds them to the queue
 func (tq *TaskQueue) jobScanner() {
 	defer tq.wg.Done()
 
-	ticker := time.NewTicker(10 * time.Second) // Scan every 10 seconds
+	ticker := time.NewTicker(ScanInterval)
 	defer ticker.Stop()
 
 	logger.Debug("Job scanner started")
@@ -262,6 +519,7 @@ func (tq *TaskQueue) jobScanner() {
 		select {
 		case <-ticker.C:
 			tq.scanPendingJobs()
+			tq.beat()
 		case <-tq.ctx.Done():
 			logger.Debug("Job scanner stopped")
 			return
@@ -269,25 +527,42 @@ func (tq *TaskQueue) jobScanner() {
 	}
 }
 
+// beat records that the job scanner is alive
+func (tq *TaskQueue) beat() {
+	atomic.StoreInt64(&tq.heartbeat, time.Now().UnixNano())
+}
+
+// LastHeartbeat returns when the job scanner last ran, or the zero time when
+// the queue was never started. The scanner runs every ScanInterval, so an
+// older heartbeat means the queue is stuck or stopped.
+func (tq *TaskQueue) LastHeartbeat() time.Time {
+	beat := atomic.LoadInt64(&tq.heartbeat)
+	if beat == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, beat)
+}
+
 // scanPendingJobs finds pending jobs and enqueues them
 func (tq *TaskQueue) scanPendingJobs() {
 	var jobs []models.TranscriptionJob
 
-	if err := database.DB.Where("status = ?", models.StatusPending).Find(&jobs).Error; err != nil {
+	if err := database.DB.Where("status = ?", models.StatusPending).
+		Order("priority DESC, created_at ASC").Find(&jobs).Error; err != nil {
 		logger.Error("Failed to scan pending jobs", "error", err)
 		return
 	}
 
-enqueueLoop:
 	for _, job := range jobs {
-		select {
-		case tq.jobChannel <- job.ID:
-			logger.Debug("Enqueued pending job", "job_id", job.ID)
-		default:
-			logger.Warn("Queue full, skipping job", "job_id", job.ID)
-			break enqueueLoop
+		if err := tq.enqueue(&job); err != nil {
+			logger.Warn("Failed to enqueue pending job", "job_id", job.ID, "error", err)
+			break
 		}
+		logger.Debug("Enqueued pending job", "job_id", job.ID)
 	}
+
+	// Dependencies may have settled outside the queue, such as by deletion
+	tq.recheckDependencies()
 }
 
 // KillJob aggressively terminates a running job
@@ -301,7 +576,34 @@ func (tq *TaskQueue) KillJob(jobID string) error {
 	}
 
 	logger.Info("Killing job", "job_id", jobID)
+	tq.terminate(jobID, runningJob)
 
+	// Immediately update job status without waiting for process to finish
+	go func() {
+		if err := tq.updateJobStatus(jobID, models.StatusFailed); err != nil {
+			logger.Error("Failed to mark killed job as failed", "job_id", jobID, "error", err)
+		}
+		if err := tq.updateJobError(jobID, "Job was forcefully terminated by user"); err != nil {
+			logger.Error("Failed to record forced termination error", "job_id", jobID, "error", err)
+		}
+	}()
+
+	return nil
+}
+
+// cancelJob stops a running job like KillJob, leaving its status to the
+// worker running it
+func (tq *TaskQueue) cancelJob(jobID string) {
+	tq.jobsMutex.Lock()
+	defer tq.jobsMutex.Unlock()
+	if runningJob, exists := tq.runningJobs[jobID]; exists {
+		tq.terminate(jobID, runningJob)
+	}
+}
+
+// terminate kills a running job's process tree and cancels its context.
+// Callers hold jobsMutex.
+func (tq *TaskQueue) terminate(jobID string, runningJob *RunningJob) {
 	// Check if this is a multi-track job and handle accordingly
 	if mtProcessor, ok := tq.processor.(MultiTrackJobProcessor); ok && mtProcessor.IsMultiTrackJob(jobID) {
 		logger.Debug("Terminating multi-track job", "job_id", jobID)
@@ -323,18 +625,6 @@ func (tq *TaskQueue) KillJob(jobID string) error {
 
 	// Also cancel the context for cleanup
 	runningJob.Cancel()
-
-	// Immediately update job status without waiting for process to finish
-	go func() {
-		if err := tq.updateJobStatus(jobID, models.StatusFailed); err != nil {
-			logger.Error("Failed to mark killed job as failed", "job_id", jobID, "error", err)
-		}
-		if err := tq.updateJobError(jobID, "Job was forcefully terminated by user"); err != nil {
-			logger.Error("Failed to record forced termination error", "job_id", jobID, "error", err)
-		}
-	}()
-
-	return nil
 }
 
 // IsJobRunning checks if a job is currently being processed
@@ -346,11 +636,20 @@ func (tq *TaskQueue) IsJobRunning(jobID string) bool {
 	return exists
 }
 
-// updateJobStatus updates the status of a job
+// updateJobStatus updates the status of a job, noting when it completed.
+// Jobs that depend on a job that completes or fails are checked again.
 func (tq *TaskQueue) updateJobStatus(jobID string, status models.JobStatus) error {
-	return database.DB.Model(&models.TranscriptionJob{}).
+	updates := map[string]interface{}{"status": status}
+	if status == models.StatusCompleted {
+		updates["completed_at"] = time.Now()
+	}
+	err := database.DB.Model(&models.TranscriptionJob{}).
 		Where("id = ?", jobID).
-		Update("status", status).Error
+		Updates(updates).Error
+	if err == nil && (status == models.StatusCompleted || status == models.StatusFailed) {
+		tq.recheckDependencies()
+	}
+	return err
 }
 
 // updateJobError updates the error message of a job
@@ -397,7 +696,7 @@ func (tq *TaskQueue) checkAndScale() {
 		return
 	}
 
-	queueSize := len(tq.jobChannel)
+	queueSize := tq.waitingCount()
 	currentWorkers := int(atomic.LoadInt64(&tq.currentWorkers))
 
 	tq.jobsMutex.RLock()
@@ -430,8 +729,9 @@ func (tq *TaskQueue) checkAndScale() {
 
 // GetQueueStats returns queue statistics
 func (tq *TaskQueue) GetQueueStats() map[string]interface{} {
-	var pendingCount, processingCount, completedCount, failedCount int64
+	var scheduledCount, pendingCount, processingCount, completedCount, failedCount int64
 
+	database.DB.Model(&models.TranscriptionJob{}).Where("status = ?", models.StatusScheduled).Count(&scheduledCount)
 	database.DB.Model(&models.TranscriptionJob{}).Where("status = ?", models.StatusPending).Count(&pendingCount)
 	database.DB.Model(&models.TranscriptionJob{}).Where("status = ?", models.StatusProcessing).Count(&processingCount)
 	database.DB.Model(&models.TranscriptionJob{}).Where("status = ?", models.StatusCompleted).Count(&completedCount)
@@ -440,15 +740,20 @@ func (tq *TaskQueue) GetQueueStats() map[string]interface{} {
 	tq.jobsMutex.RLock()
 	runningJobsCount := len(tq.runningJobs)
 	tq.jobsMutex.RUnlock()
+	tq.waitingMutex.Lock()
+	held := tq.holds > 0
+	tq.waitingMutex.Unlock()
 
 	return map[string]interface{}{
-		"queue_size":      len(tq.jobChannel),
-		"queue_capacity":  cap(tq.jobChannel),
+		"queue_size":      tq.waitingCount(),
+		"held":            held,
+		"queue_capacity":  queueCapacity,
 		"current_workers": int(atomic.LoadInt64(&tq.currentWorkers)),
 		"min_workers":     tq.minWorkers,
 		"max_workers":     tq.maxWorkers,
 		"auto_scale":      tq.autoScale,
 		"running_jobs":    runningJobsCount,
+		"scheduled_jobs":  scheduledCount,
 		"pending_jobs":    pendingCount,
 		"processing_jobs": processingCount,
 		"completed_jobs":  completedCount,
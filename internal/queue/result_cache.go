@@ -0,0 +1,144 @@
+package queue
+
+import (
+	"time"
+
+	"scriberr/internal/database"
+	"scriberr/internal/metrics"
+	"scriberr/internal/models"
+	"scriberr/internal/storage"
+	"scriberr/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// CacheResults makes the queue complete jobs whose audio and options match a
+// completed job of the same owner with that job's transcript instead of
+// running them. Call it before Start.
+func (tq *TaskQueue) CacheResults(enabled bool) {
+	tq.cacheResults = enabled
+}
+
+// completeFromCache completes a pending job with the transcript of an earlier
+// job of the same owner, audio and options, returning whether it did. Only the
+// owner's jobs are reused, so the job's owner can open the job it was
+// completed from. Jobs are checked before they are queued, so hits take no
+// worker; uploads are hashed as they are stored, and hashAudio hashes audio
+// still without one, which workers do for the jobs they take. Jobs that wait
+// for others are only checked once those complete. Transcripts edited since
+// they were made are not reused, and cache hits are not charged to quotas as
+// they use no compute.
+func (tq *TaskQueue) completeFromCache(jobID string, hashAudio bool) bool {
+	if !tq.cacheResults {
+		return false
+	}
+	var jobs []models.TranscriptionJob
+	if err := database.DB.Where("id = ? AND status = ?", jobID, models.StatusPending).Limit(1).Find(&jobs).Error; err != nil || len(jobs) == 0 {
+		return false
+	}
+	job := &jobs[0]
+	if job.IsMultiTrack || (!hashAudio && len(job.DependsOn) > 0) {
+		return false
+	}
+	if job.FileHash == nil {
+		if !hashAudio {
+			return false
+		}
+		hash, err := storage.Hash(tq.ctx, job.AudioPath)
+		if err != nil {
+			logger.Warn("Failed to hash job audio", "job_id", job.ID, "error", err)
+			return false
+		}
+		if err := database.DB.Model(&models.TranscriptionJob{}).Where("id = ?", job.ID).Update("file_hash", hash).Error; err != nil {
+			logger.Warn("Failed to record job audio hash", "job_id", job.ID, "error", err)
+		}
+		job.FileHash = &hash
+	}
+
+	sameOwner := database.DB.Where("user_id IS NULL")
+	if job.UserID != nil {
+		sameOwner = database.DB.Where("user_id = ?", *job.UserID)
+	}
+	parameters, err := parameterColumns()
+	if err != nil {
+		logger.Warn("Failed to list job parameter columns", "error", err)
+		return false
+	}
+	var candidates []models.TranscriptionJob
+	if err := database.DB.Select(parameters).Where(sameOwner).
+		Where("file_hash = ? AND status = ? AND id <> ? AND is_multi_track = ? AND transcript IS NOT NULL AND transcript_revision = 0",
+			*job.FileHash, models.StatusCompleted, job.ID, false).
+		Order("completed_at DESC").Find(&candidates).Error; err != nil {
+		logger.Warn("Failed to look up cached results", "job_id", job.ID, "error", err)
+		return false
+	}
+	options := job.Parameters.OptionsHash()
+	sourceID := ""
+	for _, candidate := range candidates {
+		if candidate.Parameters.OptionsHash() == options {
+			sourceID = candidate.ID
+			break
+		}
+	}
+	if sourceID == "" {
+		return false
+	}
+	source := &models.TranscriptionJob{}
+	if err := database.DB.Where("id = ?", sourceID).First(source).Error; err != nil {
+		logger.Warn("Failed to load cached result", "job_id", job.ID, "source_job_id", sourceID, "error", err)
+		return false
+	}
+
+	now := time.Now()
+	columns := []string{"status", "transcript", "detected_language", "language_confidence", "chapters",
+		"cached_from_job_id", "progress", "processed_seconds", "phase", "error_message", "completed_at"}
+	completed := models.TranscriptionJob{
+		Status:             models.StatusCompleted,
+		Transcript:         source.Transcript,
+		DetectedLanguage:   source.DetectedLanguage,
+		LanguageConfidence: source.LanguageConfidence,
+		Chapters:           source.Chapters,
+		CachedFromJobID:    &source.ID,
+		Progress:           1,
+		ProcessedSeconds:   source.ProcessedSeconds,
+		CompletedAt:        &now,
+	}
+	if job.DurationSeconds == nil && source.DurationSeconds != nil {
+		columns = append(columns, "duration_seconds")
+		completed.DurationSeconds = source.DurationSeconds
+	}
+	// Another path may have started the job meanwhile
+	result := database.DB.Model(&models.TranscriptionJob{}).Where("id = ? AND status = ?", job.ID, models.StatusPending).
+		Select(columns).Updates(&completed)
+	if result.Error != nil {
+		logger.Warn("Failed to complete job from cache", "job_id", job.ID, "error", result.Error)
+		return false
+	}
+	if result.RowsAffected == 0 {
+		return false
+	}
+
+	logger.Info("Result cache hit", "job_id", job.ID, "source_job_id", source.ID, "file_hash", *job.FileHash)
+	metrics.AddCounter("scriberr_result_cache_hits_total", "Jobs completed with the transcript of an identical job", 1)
+	tq.recheckDependencies()
+	if tq.deleteAudio {
+		tq.removeAudio(job.ID)
+	}
+	return true
+}
+
+// parameterColumns lists the ID and the columns of a job's embedded
+// parameters, which is all matching cached results needs
+func parameterColumns() ([]string, error) {
+	stmt := &gorm.Statement{DB: database.DB}
+	if err := stmt.Parse(&models.TranscriptionJob{}); err != nil {
+		return nil, err
+	}
+	columns := []string{"id"}
+	for _, field := range stmt.Schema.Fields {
+		if len(field.BindNames) > 1 && field.BindNames[0] == "Parameters" && field.DBName != "" {
+			columns = append(columns, field.DBName)
+		}
+	}
+	return columns, nil
+}
@@ -0,0 +1,124 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+)
+
+// scheduleInterval is how often scheduled jobs are checked for release
+const scheduleInterval = time.Minute
+
+// ErrNotSchedulable is returned when rescheduling a job that has already
+// started or finished
+var ErrNotSchedulable = errors.New("only pending or scheduled jobs can be scheduled")
+
+// scheduler releases scheduled jobs whose time has come
+func (tq *TaskQueue) scheduler() {
+	defer tq.wg.Done()
+
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+
+	logger.Debug("Job scheduler started")
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := tq.ReleaseScheduledJobs(tq.ctx, time.Now()); err != nil {
+				logger.Error("Failed to release scheduled jobs", "error", err)
+			}
+		case <-tq.ctx.Done():
+			logger.Debug("Job scheduler stopped")
+			return
+		}
+	}
+}
+
+// ReleaseScheduledJobs moves scheduled jobs due by now to pending and queues
+// them, returning how many were released
+func (tq *TaskQueue) ReleaseScheduledJobs(ctx context.Context, now time.Time) (int, error) {
+	var jobs []models.TranscriptionJob
+	if err := database.DB.WithContext(ctx).Select("id", "priority", "created_at", "device", "depends_on").
+		Where("status = ? AND scheduled_at <= ?", models.StatusScheduled, now).
+		Order("priority DESC, scheduled_at ASC").Find(&jobs).Error; err != nil {
+		return 0, fmt.Errorf("failed to find scheduled jobs: %w", err)
+	}
+
+	released := 0
+	for _, job := range jobs {
+		// The schedule may have changed since the jobs were listed
+		result := database.DB.WithContext(ctx).Model(&models.TranscriptionJob{}).
+			Where("id = ? AND status = ? AND scheduled_at <= ?", job.ID, models.StatusScheduled, now).
+			Update("status", models.StatusPending)
+		if result.Error != nil {
+			return released, fmt.Errorf("failed to release job %s: %w", job.ID, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		released++
+		logger.Info("Released scheduled job", "job_id", job.ID)
+
+		// Pending jobs that cannot be queued now are picked up by the scanner
+		if err := tq.enqueue(&job); err != nil {
+			logger.Warn("Failed to enqueue scheduled job", "job_id", job.ID, "error", err)
+		}
+	}
+	return released, nil
+}
+
+// ScheduleJob sets when a pending or scheduled job is queued. A nil time
+// clears the schedule and queues the job now.
+func (tq *TaskQueue) ScheduleJob(ctx context.Context, jobID string, at *time.Time) error {
+	if at == nil {
+		result := database.DB.WithContext(ctx).Model(&models.TranscriptionJob{}).
+			Where("id = ? AND status IN ?", jobID, []models.JobStatus{models.StatusPending, models.StatusScheduled}).
+			Updates(map[string]interface{}{"status": models.StatusPending, "scheduled_at": nil})
+		if result.Error != nil {
+			return fmt.Errorf("failed to clear schedule of job %s: %w", jobID, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotSchedulable
+		}
+		return tq.EnqueueJob(jobID)
+	}
+
+	// Take the job out of line first so no worker starts it meanwhile
+	wasWaiting := tq.remove(jobID)
+	result := database.DB.WithContext(ctx).Model(&models.TranscriptionJob{}).
+		Where("id = ? AND status IN ?", jobID, []models.JobStatus{models.StatusPending, models.StatusScheduled}).
+		Updates(map[string]interface{}{"status": models.StatusScheduled, "scheduled_at": *at})
+	if result.Error != nil || result.RowsAffected == 0 {
+		if wasWaiting {
+			if err := tq.EnqueueJob(jobID); err != nil {
+				logger.Warn("Failed to requeue job", "job_id", jobID, "error", err)
+			}
+		}
+		if result.Error != nil {
+			return fmt.Errorf("failed to schedule job %s: %w", jobID, result.Error)
+		}
+		return ErrNotSchedulable
+	}
+	return nil
+}
+
+// remove takes a job out of the waiting jobs, reporting whether it was waiting
+func (tq *TaskQueue) remove(jobID string) bool {
+	tq.waitingMutex.Lock()
+	defer tq.waitingMutex.Unlock()
+
+	job, exists := tq.waitingIndex[jobID]
+	if !exists {
+		return false
+	}
+	heap.Remove(&tq.waiting, job.index)
+	delete(tq.waitingIndex, jobID)
+	return true
+}
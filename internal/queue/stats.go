@@ -0,0 +1,49 @@
+package queue
+
+import (
+	"time"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+)
+
+// recordJobStats stores a run that completed or failed in the job stats
+// table, for throughput statistics
+func (tq *TaskQueue) recordJobStats(jobID string, status models.JobStatus, started time.Time) {
+	var job models.TranscriptionJob
+	if err := database.DB.Select("id", "user_id", "duration_seconds", "model", "model_family", "backend", "engine", "device").
+		Where("id = ?", jobID).First(&job).Error; err != nil {
+		logger.Warn("Failed to load job for stats", "job_id", jobID, "error", err)
+		return
+	}
+	stat := models.JobStat{
+		JobID:       job.ID,
+		UserID:      job.UserID,
+		Status:      status,
+		Model:       job.Parameters.Model,
+		Device:      tq.jobDevice(job.Parameters.Device),
+		Engine:      job.Parameters.AdapterID(),
+		WallSeconds: time.Since(started).Seconds(),
+		FinishedAt:  time.Now().UTC(),
+	}
+	if job.DurationSeconds != nil {
+		stat.AudioSeconds = *job.DurationSeconds
+	}
+	if err := database.DB.Create(&stat).Error; err != nil {
+		logger.Warn("Failed to record job stats", "job_id", jobID, "error", err)
+	}
+}
+
+// WaitingStats returns how many jobs are waiting for a worker and how long
+// the one waiting longest has waited
+func (tq *TaskQueue) WaitingStats() (depth int, oldestWait time.Duration) {
+	tq.waitingMutex.Lock()
+	defer tq.waitingMutex.Unlock()
+	for _, job := range tq.waiting {
+		if wait := time.Since(job.queuedAt); wait > oldestWait {
+			oldestWait = wait
+		}
+	}
+	return len(tq.waiting), oldestWait
+}
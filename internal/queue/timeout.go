@@ -0,0 +1,78 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// SetJobTimeout stops jobs still running after timeout, such as WhisperX
+// hung on malformed audio, and fails them. A job that times out is queued
+// again up to retries times first. A zero timeout lets jobs run forever.
+// Call it before Start.
+func (tq *TaskQueue) SetJobTimeout(timeout time.Duration, retries int) {
+	tq.jobTimeout = timeout
+	tq.timeoutRetries = retries
+}
+
+// jobContext returns the context a job runs in, which ends with the queue,
+// when the job is killed or when it runs past the job timeout. The returned
+// function must be called once the job returns.
+func (tq *TaskQueue) jobContext(jobID string) (context.Context, context.CancelFunc) {
+	if tq.jobTimeout <= 0 {
+		return context.WithCancel(tq.ctx)
+	}
+	ctx, cancel := context.WithTimeout(tq.ctx, tq.jobTimeout)
+	stop := context.AfterFunc(ctx, func() {
+		if ctx.Err() == context.DeadlineExceeded {
+			tq.cancelJob(jobID)
+		}
+	})
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// handleTimeout requeues a job that ran past the job timeout while it has
+// retries left, and fails it otherwise
+func (tq *TaskQueue) handleTimeout(workerID int, jobID string) {
+	var job models.TranscriptionJob
+	if err := database.DB.Select("id", "timeout_retries").Where("id = ?", jobID).First(&job).Error; err != nil {
+		logger.Error("Failed to load timed out job", "worker_id", workerID, "job_id", jobID, "error", err)
+		return
+	}
+
+	requeue := job.TimeoutRetries < tq.timeoutRetries
+	logger.Warn("Job timed out", "worker_id", workerID, "job_id", jobID, "timeout", tq.jobTimeout,
+		"retries", job.TimeoutRetries, "requeued", requeue)
+
+	if requeue {
+		err := database.DB.Model(&models.TranscriptionJob{}).Where("id = ?", jobID).Updates(map[string]interface{}{
+			"status":          models.StatusPending,
+			"timeout_retries": gorm.Expr("timeout_retries + 1"),
+		}).Error
+		if err != nil {
+			logger.Error("Failed to requeue timed out job", "worker_id", workerID, "job_id", jobID, "error", err)
+			return
+		}
+		// A pending job the queue has no room for is picked up by the scanner
+		if err := tq.EnqueueJob(jobID); err != nil {
+			logger.Warn("Failed to enqueue timed out job", "worker_id", workerID, "job_id", jobID, "error", err)
+		}
+		return
+	}
+
+	if err := tq.updateJobStatus(jobID, models.StatusFailed); err != nil {
+		logger.Error("Failed to mark timed out job as failed", "worker_id", workerID, "job_id", jobID, "error", err)
+	}
+	if err := tq.updateJobError(jobID, fmt.Sprintf("timeout: job ran longer than %s", tq.jobTimeout)); err != nil {
+		logger.Error("Failed to record timeout error", "worker_id", workerID, "job_id", jobID, "error", err)
+	}
+}
@@ -0,0 +1,139 @@
+// Package quota enforces per-user limits on transcribed audio time
+package quota
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/internal/storage"
+	"scriberr/internal/transcription"
+	"scriberr/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// ForUser returns the quotas configured for a user
+func ForUser(ctx context.Context, userID uint) ([]models.Quota, error) {
+	var quotas []models.Quota
+	err := database.DB.WithContext(ctx).Where("user_id = ?", userID).Order("period").Find(&quotas).Error
+	return quotas, err
+}
+
+// Exceeded returns the first quota that seconds more usage would exceed, or
+// nil if the usage fits within all of them
+func Exceeded(quotas []models.Quota, seconds int) *models.Quota {
+	for i := range quotas {
+		if !quotas[i].Allows(seconds) {
+			return &quotas[i]
+		}
+	}
+	return nil
+}
+
+// Seconds rounds an audio duration up to whole seconds of quota usage
+func Seconds(duration float64) int {
+	return int(math.Ceil(duration))
+}
+
+// estimatedBytesPerSecond matches 16kHz mono 16-bit PCM, the same estimate the
+// transcription service falls back to when ffprobe is unavailable
+const estimatedBytesPerSecond = 32000
+
+// JobSeconds returns the audio length of a job. Jobs uploaded before durations
+// were detected at upload time are probed and the result is stored.
+func JobSeconds(ctx context.Context, job *models.TranscriptionJob) (int, error) {
+	if job.DurationSeconds != nil && *job.DurationSeconds > 0 {
+		return Seconds(*job.DurationSeconds), nil
+	}
+
+	// Audio in object storage is estimated from its size rather than downloaded
+	if storage.IsRemote(job.AudioPath) {
+		reader, info, err := storage.Open(ctx, job.AudioPath)
+		if err != nil {
+			return 0, fmt.Errorf("failed to open stored audio: %w", err)
+		}
+		reader.Close()
+		return Seconds(float64(info.Size / estimatedBytesPerSecond)), nil
+	}
+
+	info, err := os.Stat(job.AudioPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat audio file: %w", err)
+	}
+	detected, err := transcription.DetectAudioDuration(ctx, job.AudioPath)
+	if err != nil || detected <= 0 {
+		// Estimate without storing, so a later probe can still record the real length
+		return Seconds(float64(info.Size() / estimatedBytesPerSecond)), nil
+	}
+
+	duration := detected.Seconds()
+	job.DurationSeconds = &duration
+	if job.ID != "" {
+		if err := database.DB.WithContext(ctx).Model(&models.TranscriptionJob{}).
+			Where("id = ?", job.ID).Update("duration_seconds", duration).Error; err != nil {
+			logger.Warn("Failed to store audio duration", "job_id", job.ID, "error", err)
+		}
+	}
+	return Seconds(duration), nil
+}
+
+// ChargeJob adds a completed job's audio length to its user's quotas
+func ChargeJob(ctx context.Context, jobID string) error {
+	var job models.TranscriptionJob
+	if err := database.DB.WithContext(ctx).Where("id = ?", jobID).First(&job).Error; err != nil {
+		return err
+	}
+	if job.UserID == nil {
+		return nil
+	}
+
+	seconds, err := JobSeconds(ctx, &job)
+	if err != nil {
+		return err
+	}
+	return database.DB.WithContext(ctx).Model(&models.Quota{}).
+		Where("user_id = ?", *job.UserID).
+		Update("used_seconds", gorm.Expr("used_seconds + ?", seconds)).Error
+}
+
+// ResetExpired zeroes the usage of every quota whose period ended before now
+func ResetExpired(ctx context.Context, now time.Time) (int64, error) {
+	var reset int64
+	for _, period := range []string{models.QuotaPeriodDaily, models.QuotaPeriodMonthly} {
+		start := models.QuotaPeriodStart(period, now)
+		result := database.DB.WithContext(ctx).Model(&models.Quota{}).
+			Where("period = ? AND period_start < ?", period, start).
+			Updates(map[string]interface{}{"used_seconds": 0, "period_start": start})
+		if result.Error != nil {
+			return reset, result.Error
+		}
+		reset += result.RowsAffected
+	}
+	return reset, nil
+}
+
+// StartResetLoop periodically resets quotas whose period has ended until ctx is done
+func StartResetLoop(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				reset, err := ResetExpired(ctx, time.Now())
+				if err != nil {
+					logger.Warn("Failed to reset expired quotas", "error", err)
+				} else if reset > 0 {
+					logger.Debug("Reset expired quotas", "count", reset)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket per key: each key may burst up to the capacity,
+// and tokens refill evenly over the period. It is safe for concurrent use.
+type Bucket struct {
+	capacity float64
+	rate     float64 // Tokens per second
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewBucket creates a token bucket allowing each key limit requests per
+// period, in bursts of up to limit. A limit of zero or less allows
+// everything.
+func NewBucket(limit int, period time.Duration) *Bucket {
+	return &Bucket{
+		capacity: float64(limit),
+		rate:     float64(limit) / period.Seconds(),
+		buckets:  make(map[string]*bucket),
+	}
+}
+
+// Take takes a token for key at now and reports whether one was left. When
+// none was, it also returns how long until the next token.
+func (l *Bucket) Take(key string, now time.Time) (bool, time.Duration) {
+	if l.capacity <= 0 {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= pruneThreshold {
+			l.prune(now)
+		}
+		b = &bucket{tokens: l.capacity, updated: now}
+		l.buckets[key] = b
+	}
+	l.refill(b, now)
+	if b.tokens < 1 {
+		wait := time.Duration(math.Ceil((1 - b.tokens) / l.rate * float64(time.Second)))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// Reset refills a key's bucket
+func (l *Bucket) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.buckets, key)
+}
+
+// refill adds the tokens earned since the bucket was last updated
+func (l *Bucket) refill(b *bucket, now time.Time) {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(l.capacity, b.tokens+elapsed*l.rate)
+		b.updated = now
+	}
+}
+
+// prune drops the buckets that have refilled, as they are the same as new ones
+func (l *Bucket) prune(now time.Time) {
+	for key, b := range l.buckets {
+		l.refill(b, now)
+		if b.tokens >= l.capacity {
+			delete(l.buckets, key)
+		}
+	}
+}
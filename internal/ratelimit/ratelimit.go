@@ -0,0 +1,65 @@
+// Package ratelimit limits requests per key, in fixed windows or token buckets
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// pruneThreshold is how many keys are tracked before windows that have
+// ended are dropped
+const pruneThreshold = 1024
+
+// Limiter allows each key a number of requests per window. It is safe for
+// concurrent use.
+type Limiter struct {
+	limit  int
+	window time.Duration
+
+	mu      sync.Mutex
+	windows map[string]*window
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+// New creates a limiter allowing limit requests per window for each key. A
+// limit of zero or less allows everything.
+func New(limit int, period time.Duration) *Limiter {
+	return &Limiter{limit: limit, window: period, windows: make(map[string]*window)}
+}
+
+// Allow records a request for key at now and reports whether it is within the
+// limit. When it is not, it also returns how long until the key's window ends.
+func (l *Limiter) Allow(key string, now time.Time) (bool, time.Duration) {
+	if l.limit <= 0 {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w, ok := l.windows[key]
+	if !ok || !now.Before(w.start.Add(l.window)) {
+		if !ok && len(l.windows) >= pruneThreshold {
+			l.prune(now)
+		}
+		w = &window{start: now}
+		l.windows[key] = w
+	}
+	if w.count >= l.limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+	w.count++
+	return true, 0
+}
+
+// prune drops the windows that have ended
+func (l *Limiter) prune(now time.Time) {
+	for key, w := range l.windows {
+		if !now.Before(w.start.Add(l.window)) {
+			delete(l.windows, key)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestAllow(t *testing.T) {
+	limiter := New(2, time.Minute)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.Allow("a", now); !ok {
+			t.Fatalf("Expected request %d to be allowed", i+1)
+		}
+	}
+	ok, retry := limiter.Allow("a", now.Add(20*time.Second))
+	if ok {
+		t.Fatal("Expected the third request in the window to be refused")
+	}
+	if retry != 40*time.Second {
+		t.Errorf("Expected to retry after 40s, got %v", retry)
+	}
+	if ok, _ := limiter.Allow("b", now); !ok {
+		t.Error("Expected other keys to have their own limit")
+	}
+	if ok, _ := limiter.Allow("a", now.Add(time.Minute)); !ok {
+		t.Error("Expected a new window to allow requests again")
+	}
+}
+
+func TestAllowUnlimited(t *testing.T) {
+	limiter := New(0, time.Minute)
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		if ok, _ := limiter.Allow("a", now); !ok {
+			t.Fatal("Expected a zero limit to allow everything")
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	limiter := New(1, time.Minute)
+	now := time.Now()
+	for i := 0; i < pruneThreshold; i++ {
+		limiter.Allow(fmt.Sprint(i), now)
+	}
+	limiter.Allow("late", now.Add(2*time.Minute))
+	if len(limiter.windows) != 1 {
+		t.Errorf("Expected ended windows to be pruned, %d remain", len(limiter.windows))
+	}
+}
+
+func TestBucketTake(t *testing.T) {
+	bucket := NewBucket(3, time.Minute)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := bucket.Take("a", now); !ok {
+			t.Fatalf("Expected request %d of the burst to be allowed", i+1)
+		}
+	}
+	ok, retry := bucket.Take("a", now)
+	if ok {
+		t.Fatal("Expected an empty bucket to refuse")
+	}
+	if retry != 20*time.Second {
+		t.Errorf("Expected to retry after 20s, got %v", retry)
+	}
+	if ok, _ := bucket.Take("a", now.Add(20*time.Second)); !ok {
+		t.Error("Expected a refilled token to be allowed")
+	}
+	if ok, _ := bucket.Take("a", now.Add(20*time.Second)); ok {
+		t.Error("Expected only one token to have refilled")
+	}
+
+	bucket.Reset("a")
+	if ok, _ := bucket.Take("a", now.Add(20*time.Second)); !ok {
+		t.Error("Expected a reset bucket to be full")
+	}
+	if ok, _ := NewBucket(0, time.Minute).Take("a", now); !ok {
+		t.Error("Expected a zero limit to allow everything")
+	}
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		decision, err := store.Take(ctx, "a", 3, time.Minute, now)
+		if err != nil || !decision.Allowed {
+			t.Fatalf("Expected request %d of the burst to be allowed, got %+v, %v", i+1, decision, err)
+		}
+		if decision.Remaining != 2-i || decision.Limit != 3 {
+			t.Errorf("Expected %d of 3 left, got %+v", 2-i, decision)
+		}
+	}
+	decision, _ := store.Take(ctx, "a", 3, time.Minute, now)
+	if decision.Allowed || decision.RetryAfter != 20*time.Second || decision.Reset != time.Minute {
+		t.Errorf("Expected a refusal retrying after 20s and full after 1m, got %+v", decision)
+	}
+
+	// A lower limit caps the tokens left; a higher one lets them refill further
+	store.Take(ctx, "b", 10, time.Minute, now)
+	if decision, _ := store.Take(ctx, "b", 2, time.Minute, now); !decision.Allowed || decision.Remaining != 1 {
+		t.Errorf("Expected the lowered limit to leave 1 token, got %+v", decision)
+	}
+	if decision, _ := store.Take(ctx, "a", 6, time.Minute, now.Add(30*time.Second)); !decision.Allowed || decision.Remaining != 2 {
+		t.Errorf("Expected 3 tokens refilled at the raised rate, got %+v", decision)
+	}
+
+	if decision, _ := store.Take(ctx, "a", 0, time.Minute, now); !decision.Allowed {
+		t.Error("Expected a zero limit to allow everything")
+	}
+}
@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Decision is the outcome of taking a token from a Store
+type Decision struct {
+	Allowed    bool
+	Limit      int           // Capacity of the bucket
+	Remaining  int           // Whole tokens left after the request
+	RetryAfter time.Duration // Until the next token, when the request was refused
+	Reset      time.Duration // Until the bucket is full again
+}
+
+// Store keeps token buckets for limits that may change while requests are
+// counted. MemoryStore serves a single server; a store shared by several
+// servers implements the same interface.
+type Store interface {
+	// Take takes a token for key at now from a bucket holding up to limit
+	// tokens, which refill evenly over period. A limit of zero or less
+	// allows everything.
+	Take(ctx context.Context, key string, limit int, period time.Duration, now time.Time) (Decision, error)
+}
+
+// MemoryStore is a Store keeping buckets in memory. It is safe for concurrent
+// use.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*storedBucket
+}
+
+type storedBucket struct {
+	bucket
+	capacity float64
+	rate     float64
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*storedBucket)}
+}
+
+// Take implements Store. A bucket whose limit changed keeps its tokens, up to
+// the new capacity.
+func (s *MemoryStore) Take(ctx context.Context, key string, limit int, period time.Duration, now time.Time) (Decision, error) {
+	if limit <= 0 {
+		return Decision{Allowed: true}, nil
+	}
+	capacity := float64(limit)
+	rate := capacity / period.Seconds()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		if len(s.buckets) >= pruneThreshold {
+			s.prune(now)
+		}
+		b = &storedBucket{bucket: bucket{tokens: capacity, updated: now}}
+		s.buckets[key] = b
+	}
+	b.capacity, b.rate = capacity, rate
+	b.refill(now)
+	b.tokens = math.Min(b.tokens, capacity)
+
+	decision := Decision{Limit: limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = secondsDuration((1 - b.tokens) / rate)
+	}
+	decision.Remaining = int(b.tokens)
+	decision.Reset = secondsDuration((capacity - b.tokens) / rate)
+	return decision, nil
+}
+
+// refill adds the tokens earned since the bucket was last updated
+func (b *storedBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.capacity, b.tokens+elapsed*b.rate)
+		b.updated = now
+	}
+}
+
+// prune drops the buckets that have refilled, as they are the same as new ones
+func (s *MemoryStore) prune(now time.Time) {
+	for key, b := range s.buckets {
+		b.refill(now)
+		if b.tokens >= b.capacity {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// secondsDuration converts seconds to a duration, rounding up to whole
+// nanoseconds
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
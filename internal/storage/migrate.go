@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+)
+
+// JobKey is the key a job's audio is stored under
+func JobKey(jobID, path string) string {
+	return jobID + filepath.Ext(path)
+}
+
+// MigrationResult counts what MigrateLocalFiles did
+type MigrationResult struct {
+	Moved   int `json:"moved"`
+	Missing int `json:"missing"` // Jobs whose audio file no longer exists
+	Failed  int `json:"failed"`
+}
+
+// MigrateLocalFiles moves the audio of jobs still on disk into the configured
+// backend and points the jobs at it. Jobs being processed and multi-track
+// jobs, whose tracks are read from their folder, are left alone. Migration
+// can be rerun; jobs already moved are skipped.
+func MigrateLocalFiles(ctx context.Context) (*MigrationResult, error) {
+	if Local() {
+		return nil, fmt.Errorf("the configured storage backend is local; nothing to migrate")
+	}
+
+	var jobs []models.TranscriptionJob
+	if err := database.DB.WithContext(ctx).Select("id", "audio_path").
+		Where("audio_path <> '' AND audio_path NOT LIKE ?", "%://%").
+		Where("status <> ? AND is_multi_track = ?", models.StatusProcessing, false).
+		Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	result := &MigrationResult{}
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		if _, err := os.Stat(job.AudioPath); os.IsNotExist(err) {
+			result.Missing++
+			continue
+		}
+		location, err := Store(ctx, job.AudioPath, JobKey(job.ID, job.AudioPath))
+		if err != nil {
+			logger.Warn("Failed to migrate job audio", "job_id", job.ID, "error", err)
+			result.Failed++
+			continue
+		}
+		if err := database.DB.WithContext(ctx).Model(&models.TranscriptionJob{}).
+			Where("id = ?", job.ID).Update("audio_path", location).Error; err != nil {
+			logger.Warn("Failed to record migrated job audio", "job_id", job.ID, "location", location, "error", err)
+			result.Failed++
+			continue
+		}
+		os.Remove(job.AudioPath)
+		result.Moved++
+	}
+	return result, nil
+}
@@ -0,0 +1,279 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3Client is the part of the S3 API the storage uses. *s3.Client
+// implements it; tests substitute an in-memory bucket.
+type S3Client interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+// S3Presigner signs links to objects. *s3.PresignClient implements it.
+type S3Presigner interface {
+	PresignGetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error)
+	PresignPutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error)
+}
+
+// S3Options configures an S3-compatible bucket
+type S3Options struct {
+	Endpoint        string // e.g. http://minio:9000; defaults to AWS for the region
+	Region          string // Defaults to us-east-1
+	Bucket          string
+	Prefix          string // Prepended to every key
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Set with temporary credentials
+	PathStyle       bool   // Address the bucket in the path, as MinIO expects, rather than the host name
+
+	// Client and Presigner replace the SDK clients built from the options
+	// above
+	Client    S3Client
+	Presigner S3Presigner
+}
+
+// S3Storage keeps files in an S3-compatible bucket through the AWS SDK.
+// Objects are uploaded in one request, so files are limited to 5 GB.
+type S3Storage struct {
+	opts      S3Options
+	client    S3Client
+	presigner S3Presigner
+}
+
+// NewS3 creates storage for a bucket
+func NewS3(opts S3Options) (*S3Storage, error) {
+	if opts.Bucket == "" {
+		return nil, errors.New("S3 storage requires a bucket")
+	}
+	if opts.AccessKeyID == "" || opts.SecretAccessKey == "" {
+		return nil, errors.New("S3 storage requires an access key and secret")
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+	var baseEndpoint *string
+	if opts.Endpoint != "" {
+		endpoint, err := url.Parse(opts.Endpoint)
+		if err != nil || endpoint.Host == "" {
+			return nil, fmt.Errorf("invalid S3 endpoint %q", opts.Endpoint)
+		}
+		baseEndpoint = aws.String(strings.TrimSuffix(opts.Endpoint, "/"))
+	}
+	opts.Prefix = strings.Trim(opts.Prefix, "/")
+
+	client := s3.New(s3.Options{
+		Region:       opts.Region,
+		Credentials:  credentials.NewStaticCredentialsProvider(opts.AccessKeyID, opts.SecretAccessKey, opts.SessionToken),
+		BaseEndpoint: baseEndpoint,
+		UsePathStyle: opts.PathStyle,
+		// S3-compatible stores such as MinIO do not all accept the checksums
+		// the SDK adds by default
+		RequestChecksumCalculation: aws.RequestChecksumCalculationWhenRequired,
+		ResponseChecksumValidation: aws.ResponseChecksumValidationWhenRequired,
+	})
+	s := &S3Storage{opts: opts, client: opts.Client, presigner: opts.Presigner}
+	if s.client == nil {
+		s.client = client
+	}
+	if s.presigner == nil {
+		s.presigner = s3.NewPresignClient(client)
+	}
+	return s, nil
+}
+
+// objectKey returns the bucket key of a storage key
+func (s *S3Storage) objectKey(key string) string {
+	if s.opts.Prefix != "" {
+		return s.opts.Prefix + "/" + key
+	}
+	return key
+}
+
+// notFound reports whether S3 answered that an object does not exist
+func notFound(err error) bool {
+	var noSuchKey *types.NoSuchKey
+	var missing *types.NotFound
+	var response *awshttp.ResponseError
+	return errors.As(err, &noSuchKey) || errors.As(err, &missing) ||
+		(errors.As(err, &response) && response.HTTPStatusCode() == http.StatusNotFound)
+}
+
+// Upload writes an object of the given size. The body is sent unsigned, so
+// it need not be seekable.
+func (s *S3Storage) Upload(ctx context.Context, key string, r io.Reader, size int64) error {
+	if size < 0 {
+		return errors.New("S3 uploads need the object size")
+	}
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.opts.Bucket),
+		Key:           aws.String(s.objectKey(key)),
+		Body:          r,
+		ContentLength: aws.Int64(size),
+	}, s3.WithAPIOptions(v4.SwapComputePayloadSHA256ForUnsignedPayloadMiddleware))
+	if err != nil {
+		return fmt.Errorf("failed to upload %s to S3: %w", key, err)
+	}
+	return nil
+}
+
+// Download opens an object; reads fetch from the current offset with range
+// requests, so the reader can also seek
+func (s *S3Storage) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &s3Object{ctx: ctx, storage: s, key: key, size: info.Size}, nil
+}
+
+// Delete removes an object. S3 reports success for missing objects.
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.opts.Bucket),
+		Key:    aws.String(s.objectKey(key)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete %s from S3: %w", key, err)
+	}
+	return nil
+}
+
+// Exists reports whether an object is in the bucket
+func (s *S3Storage) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := s.Stat(ctx, key)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Stat describes an object
+func (s *S3Storage) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	out, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.opts.Bucket),
+		Key:    aws.String(s.objectKey(key)),
+	})
+	if notFound(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s in S3: %w", key, err)
+	}
+	return &ObjectInfo{Size: aws.ToInt64(out.ContentLength), LastModified: aws.ToTime(out.LastModified)}, nil
+}
+
+// SignedURL returns a presigned GET URL for an object
+func (s *S3Storage) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	req, err := s.presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.opts.Bucket),
+		Key:    aws.String(s.objectKey(key)),
+	}, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign download of %s: %w", key, err)
+	}
+	return req.URL, nil
+}
+
+// SignedUploadURL returns a presigned PUT URL that writes an object
+func (s *S3Storage) SignedUploadURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	req, err := s.presigner.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.opts.Bucket),
+		Key:    aws.String(s.objectKey(key)),
+	}, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign upload of %s: %w", key, err)
+	}
+	return req.URL, nil
+}
+
+// Location returns the s3:// URI of a key
+func (s *S3Storage) Location(key string) string {
+	return "s3://" + s.opts.Bucket + "/" + key
+}
+
+// Key returns the key of an s3:// URI in this bucket
+func (s *S3Storage) Key(location string) (string, bool) {
+	key, ok := strings.CutPrefix(location, "s3://"+s.opts.Bucket+"/")
+	return key, ok && key != ""
+}
+
+// s3Object reads an object from an offset, reopening the download after seeks
+type s3Object struct {
+	ctx     context.Context
+	storage *S3Storage
+	key     string
+	size    int64
+	offset  int64
+	body    io.ReadCloser
+}
+
+func (o *s3Object) Read(p []byte) (int, error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+	if o.body == nil {
+		out, err := o.storage.client.GetObject(o.ctx, &s3.GetObjectInput{
+			Bucket: aws.String(o.storage.opts.Bucket),
+			Key:    aws.String(o.storage.objectKey(o.key)),
+			Range:  aws.String(fmt.Sprintf("bytes=%d-", o.offset)),
+		})
+		if notFound(err) {
+			return 0, ErrNotFound
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to download %s from S3: %w", o.key, err)
+		}
+		o.body = out.Body
+	}
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	return n, err
+}
+
+func (o *s3Object) Seek(offset int64, whence int) (int64, error) {
+	var next int64
+	switch whence {
+	case io.SeekStart:
+		next = offset
+	case io.SeekCurrent:
+		next = o.offset + offset
+	case io.SeekEnd:
+		next = o.size + offset
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if next < 0 {
+		return 0, errors.New("negative position")
+	}
+	if next != o.offset && o.body != nil {
+		o.body.Close()
+		o.body = nil
+	}
+	o.offset = next
+	return next, nil
+}
+
+func (o *s3Object) Close() error {
+	if o.body != nil {
+		return o.body.Close()
+	}
+	return nil
+}
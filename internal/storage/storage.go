@@ -0,0 +1,296 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"scriberr/internal/config"
+)
+
+// ErrNotFound is returned when an object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// ErrSignedURLUnsupported is returned by backends that cannot hand out
+// direct download or upload links
+var ErrSignedURLUnsupported = errors.New("signed URLs are not supported by this storage backend")
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Size         int64
+	LastModified time.Time
+}
+
+// StorageBackend keeps audio files. Keys are slash-separated paths relative
+// to the backend's root.
+type StorageBackend interface {
+	Upload(ctx context.Context, key string, r io.Reader, size int64) error
+	// Download opens an object for reading. The reader is an
+	// io.ReadSeekCloser, which serving audio with range requests needs.
+	Download(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	Exists(ctx context.Context, key string) (bool, error)
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// SignedURL returns a link that downloads the object without credentials
+	// until it expires
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+	// SignedUploadURL returns a link that writes the object with a PUT
+	// request without credentials until it expires
+	SignedUploadURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+
+	// Location is what jobs record as the audio path of an object
+	Location(key string) string
+	// Key returns the key of a location this backend holds, or false for
+	// locations elsewhere
+	Key(location string) (string, bool)
+}
+
+// New creates the backend selected by the storage configuration. Local
+// storage keeps files under uploadDir.
+func New(cfg config.StorageConfig, uploadDir string) (StorageBackend, error) {
+	switch cfg.Backend {
+	case "", "local":
+		return NewLocal(uploadDir), nil
+	case "s3":
+		return NewS3(S3Options{
+			Endpoint:        cfg.Endpoint,
+			Region:          cfg.Region,
+			Bucket:          cfg.Bucket,
+			Prefix:          cfg.Prefix,
+			AccessKeyID:     cfg.AccessKeyID,
+			SecretAccessKey: cfg.SecretAccessKey,
+			SessionToken:    cfg.SessionToken,
+			PathStyle:       cfg.PathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
+
+var (
+	currentMutex sync.RWMutex
+	current      StorageBackend
+)
+
+// Configure sets the backend new uploads are stored in
+func Configure(s StorageBackend) {
+	currentMutex.Lock()
+	defer currentMutex.Unlock()
+	current = s
+}
+
+// Current returns the configured backend, or local storage when none is set
+func Current() StorageBackend {
+	currentMutex.RLock()
+	defer currentMutex.RUnlock()
+	if current == nil {
+		return NewLocal("")
+	}
+	return current
+}
+
+// Local reports whether the configured backend keeps files on disk
+func Local() bool {
+	_, ok := Current().(*LocalStorage)
+	return ok
+}
+
+// IsRemote reports whether a job's audio path refers to object storage
+// rather than the local filesystem
+func IsRemote(location string) bool {
+	return strings.Contains(location, "://")
+}
+
+// resolve finds the key of a remote location in the configured backend
+func resolve(location string) (StorageBackend, string, error) {
+	s := Current()
+	key, ok := s.Key(location)
+	if !ok {
+		return nil, "", fmt.Errorf("%s is not held by the configured storage backend", location)
+	}
+	return s, key, nil
+}
+
+// Store copies a local file into the configured backend under key, returning
+// the location to record on the job. Callers remove the local file once the
+// location is recorded. With local storage the file stays where it is and its
+// path is returned.
+func Store(ctx context.Context, localPath, key string) (string, error) {
+	if Local() {
+		return localPath, nil
+	}
+	s := Current()
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", localPath, err)
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to stat %s: %w", localPath, err)
+	}
+	if err := s.Upload(ctx, key, file, stat.Size()); err != nil {
+		return "", fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	return s.Location(key), nil
+}
+
+// Open opens a job's audio, whether on disk or in object storage
+func Open(ctx context.Context, location string) (io.ReadSeekCloser, *ObjectInfo, error) {
+	if !IsRemote(location) {
+		file, err := os.Open(location)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, ErrNotFound
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		stat, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		return file, &ObjectInfo{Size: stat.Size(), LastModified: stat.ModTime()}, nil
+	}
+
+	s, key, err := resolve(location)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	reader, err := s.Download(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	seeker, ok := reader.(io.ReadSeekCloser)
+	if !ok {
+		reader.Close()
+		return nil, nil, fmt.Errorf("%s cannot be read with range requests", location)
+	}
+	return seeker, info, nil
+}
+
+// Stat describes a job's audio, whether on disk or in object storage
+func Stat(ctx context.Context, location string) (*ObjectInfo, error) {
+	if !IsRemote(location) {
+		stat, err := os.Stat(location)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &ObjectInfo{Size: stat.Size(), LastModified: stat.ModTime()}, nil
+	}
+	s, key, err := resolve(location)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stat(ctx, key)
+}
+
+// Size returns the total size in bytes of the audio at locations, or nil if
+// any of them cannot be read. Jobs record it as their file size.
+func Size(ctx context.Context, locations ...string) *int64 {
+	var total int64
+	for _, location := range locations {
+		info, err := Stat(ctx, location)
+		if err != nil {
+			return nil
+		}
+		total += info.Size
+	}
+	return &total
+}
+
+// Hash returns the SHA-256 of the audio at location, hex encoded. Jobs record
+// it so that the same audio submitted again can be recognised.
+func Hash(ctx context.Context, location string) (string, error) {
+	reader, _, err := Open(ctx, location)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Remove deletes a job's audio, treating missing files as already removed
+func Remove(ctx context.Context, location string) error {
+	if !IsRemote(location) {
+		if err := os.Remove(location); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	s, key, err := resolve(location)
+	if err != nil {
+		return err
+	}
+	if err := s.Delete(ctx, key); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// SignedURL returns a temporary download link for a job's audio, or
+// ErrSignedURLUnsupported for files on disk
+func SignedURL(ctx context.Context, location string, expiry time.Duration) (string, error) {
+	if !IsRemote(location) {
+		return "", ErrSignedURLUnsupported
+	}
+	s, key, err := resolve(location)
+	if err != nil {
+		return "", err
+	}
+	return s.SignedURL(ctx, key, expiry)
+}
+
+// Materialize returns a local path holding a job's audio, which tools that
+// only read files need. Audio in object storage is downloaded to tempDir and
+// removed by the returned release function; local paths are returned as is.
+func Materialize(ctx context.Context, location, tempDir string) (string, func(), error) {
+	if !IsRemote(location) {
+		return location, func() {}, nil
+	}
+
+	reader, _, err := Open(ctx, location)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to open %s: %w", location, err)
+	}
+	defer reader.Close()
+
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return "", nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	file, err := os.CreateTemp(tempDir, "audio-*"+filepath.Ext(location))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	release := func() { os.Remove(file.Name()) }
+	if _, err := io.Copy(file, reader); err != nil {
+		file.Close()
+		release()
+		return "", nil, fmt.Errorf("failed to download %s: %w", location, err)
+	}
+	if err := file.Close(); err != nil {
+		release()
+		return "", nil, err
+	}
+	return file.Name(), release, nil
+}
@@ -0,0 +1,325 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	s := NewLocal(t.TempDir())
+
+	if err := s.Upload(ctx, "jobs/a.wav", strings.NewReader("audio"), 5); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if exists, err := s.Exists(ctx, "jobs/a.wav"); err != nil || !exists {
+		t.Fatalf("Expected the object to exist, got %v, %v", exists, err)
+	}
+	info, err := s.Stat(ctx, "jobs/a.wav")
+	if err != nil || info.Size != 5 {
+		t.Fatalf("Expected a 5 byte object, got %+v, %v", info, err)
+	}
+	reader, err := s.Download(ctx, "jobs/a.wav")
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	reader.(io.Seeker).Seek(2, io.SeekStart)
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(data) != "dio" {
+		t.Errorf("Expected to read from the seek offset, got %q", data)
+	}
+
+	if key, ok := s.Key(s.Location("jobs/a.wav")); !ok || key != "jobs/a.wav" {
+		t.Errorf("Expected the location to map back to its key, got %q, %v", key, ok)
+	}
+	if _, ok := s.Key("s3://bucket/a.wav"); ok {
+		t.Error("Expected an s3 location not to be held by local storage")
+	}
+	if err := s.Upload(ctx, "../escape.wav", strings.NewReader(""), 0); err == nil {
+		t.Error("Expected a key outside the root to be rejected")
+	}
+	if _, err := s.SignedURL(ctx, "jobs/a.wav", time.Minute); !errors.Is(err, ErrSignedURLUnsupported) {
+		t.Errorf("Expected signed URLs to be unsupported, got %v", err)
+	}
+
+	if err := s.Delete(ctx, "jobs/a.wav"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := s.Stat(ctx, "jobs/a.wav"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if exists, err := s.Exists(ctx, "jobs/a.wav"); err != nil || exists {
+		t.Errorf("Expected the object to be gone, got %v, %v", exists, err)
+	}
+}
+
+func TestS3SignedURL(t *testing.T) {
+	s, _ := newMockS3(t)
+	ctx := context.Background()
+
+	download, err := s.SignedURL(ctx, "job 1.wav", 24*time.Hour)
+	if err != nil {
+		t.Fatalf("SignedURL failed: %v", err)
+	}
+	upload, err := s.SignedUploadURL(ctx, "job 1.wav", time.Hour)
+	if err != nil {
+		t.Fatalf("SignedUploadURL failed: %v", err)
+	}
+	for _, c := range []struct {
+		signed, operation, expires string
+	}{
+		{download, "GetObject", "86400"},
+		{upload, "PutObject", "3600"},
+	} {
+		u, err := url.Parse(c.signed)
+		if err != nil {
+			t.Fatalf("Expected a URL, got %q", c.signed)
+		}
+		if u.Host != "audio.s3.us-east-1.amazonaws.com" || u.EscapedPath() != "/scriberr/job%201.wav" {
+			t.Errorf("Expected the object under the bucket and prefix, got %s", c.signed)
+		}
+		query := u.Query()
+		if query.Get("x-id") != c.operation || query.Get("X-Amz-Expires") != c.expires ||
+			!strings.HasPrefix(query.Get("X-Amz-Credential"), "key/") || query.Get("X-Amz-Signature") == "" {
+			t.Errorf("Expected a %s URL signed for %s seconds, got %s", c.operation, c.expires, c.signed)
+		}
+	}
+}
+
+// mockS3 is an in-memory S3 client
+type mockS3 struct {
+	mutex   sync.Mutex
+	objects map[string][]byte
+	ranges  []string
+	err     error // Returned by every call when set
+}
+
+func (m *mockS3) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	data, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.objects[aws.ToString(params.Bucket)+"/"+aws.ToString(params.Key)] = data
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (m *mockS3) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	data, ok := m.objects[aws.ToString(params.Bucket)+"/"+aws.ToString(params.Key)]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+	var start int
+	if params.Range != nil {
+		m.ranges = append(m.ranges, *params.Range)
+		fmt.Sscanf(*params.Range, "bytes=%d-", &start)
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(data[start:]))}, nil
+}
+
+func (m *mockS3) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	data, ok := m.objects[aws.ToString(params.Bucket)+"/"+aws.ToString(params.Key)]
+	if !ok {
+		return nil, &types.NotFound{}
+	}
+	return &s3.HeadObjectOutput{ContentLength: aws.Int64(int64(len(data)))}, nil
+}
+
+func (m *mockS3) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.objects, aws.ToString(params.Bucket)+"/"+aws.ToString(params.Key))
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func newMockS3(t *testing.T) (*S3Storage, *mockS3) {
+	mock := &mockS3{objects: map[string][]byte{}}
+	s, err := NewS3(S3Options{
+		Bucket:          "audio",
+		Prefix:          "scriberr",
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+		Client:          mock,
+	})
+	if err != nil {
+		t.Fatalf("NewS3 failed: %v", err)
+	}
+	return s, mock
+}
+
+func TestS3Storage(t *testing.T) {
+	ctx := context.Background()
+	s, mock := newMockS3(t)
+
+	if err := s.Upload(ctx, "job 1.wav", strings.NewReader("0123456789"), 10); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if _, ok := mock.objects["audio/scriberr/job 1.wav"]; !ok {
+		t.Fatalf("Expected the object under the bucket and prefix, got %v", mock.objects)
+	}
+	if info, err := s.Stat(ctx, "job 1.wav"); err != nil || info.Size != 10 {
+		t.Fatalf("Expected a 10 byte object, got %+v, %v", info, err)
+	}
+	if exists, err := s.Exists(ctx, "job 1.wav"); err != nil || !exists {
+		t.Fatalf("Expected the object to exist, got %v, %v", exists, err)
+	}
+
+	reader, err := s.Download(ctx, "job 1.wav")
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	defer reader.Close()
+	if _, err := reader.(io.Seeker).Seek(6, io.SeekStart); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil || string(data) != "6789" {
+		t.Errorf("Expected to read from the seek offset, got %q, %v", data, err)
+	}
+	if len(mock.ranges) != 1 || mock.ranges[0] != "bytes=6-" {
+		t.Errorf("Expected one range request from byte 6, got %v", mock.ranges)
+	}
+
+	if err := s.Delete(ctx, "job 1.wav"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := s.Stat(ctx, "job 1.wav"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if exists, err := s.Exists(ctx, "job 1.wav"); err != nil || exists {
+		t.Errorf("Expected the object to be gone, got %v, %v", exists, err)
+	}
+	if _, err := s.Download(ctx, "job 1.wav"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound downloading a missing object, got %v", err)
+	}
+
+	mock.err = &smithy.GenericAPIError{Code: "AccessDenied", Message: "Access Denied"}
+	if err := s.Upload(ctx, "a.wav", strings.NewReader("x"), 1); err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("Expected the S3 error code, got %v", err)
+	}
+	if _, err := s.Exists(ctx, "a.wav"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected other S3 errors not to read as missing, got %v", err)
+	}
+}
+
+func TestS3SessionToken(t *testing.T) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+	}))
+	t.Cleanup(server.Close)
+	s, err := NewS3(S3Options{
+		Endpoint:        server.URL,
+		Bucket:          "audio",
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+		SessionToken:    "token/with+chars",
+		PathStyle:       true,
+	})
+	if err != nil {
+		t.Fatalf("NewS3 failed: %v", err)
+	}
+
+	if err := s.Upload(context.Background(), "a.wav", strings.NewReader("x"), 1); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if header.Get("X-Amz-Security-Token") != "token/with+chars" {
+		t.Errorf("Expected the session token header, got %q", header.Get("X-Amz-Security-Token"))
+	}
+	if !strings.Contains(header.Get("Authorization"), "x-amz-security-token") {
+		t.Errorf("Expected the session token to be signed, got %s", header.Get("Authorization"))
+	}
+
+	signed, _ := s.SignedURL(context.Background(), "a.wav", time.Hour)
+	if !strings.Contains(signed, "X-Amz-Security-Token=token%2Fwith%2Bchars&") {
+		t.Errorf("Expected the session token before the signature, got %s", signed)
+	}
+}
+
+func TestStoreAndMaterialize(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newMockS3(t)
+	Configure(s)
+	t.Cleanup(func() { Configure(nil) })
+
+	localPath := filepath.Join(t.TempDir(), "upload.wav")
+	os.WriteFile(localPath, []byte("audio data"), 0644)
+
+	location, err := Store(ctx, localPath, JobKey("job-1", localPath))
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if location != "s3://audio/job-1.wav" || !IsRemote(location) {
+		t.Fatalf("Expected an s3 location, got %q", location)
+	}
+
+	if size := Size(ctx, location, localPath); size == nil || *size != 20 {
+		t.Errorf("Expected the object and local file to total 20 bytes, got %v", size)
+	}
+	if size := Size(ctx, location, filepath.Join(t.TempDir(), "missing.wav")); size != nil {
+		t.Errorf("Expected no size when a file is missing, got %d", *size)
+	}
+
+	tempDir := t.TempDir()
+	path, release, err := Materialize(ctx, location, tempDir)
+	if err != nil {
+		t.Fatalf("Materialize failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "audio data" || filepath.Ext(path) != ".wav" {
+		t.Errorf("Expected a local .wav copy of the object, got %s holding %q", path, data)
+	}
+	release()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected release to remove the local copy")
+	}
+
+	// Local paths are used where they are
+	if path, _, err := Materialize(ctx, localPath, tempDir); err != nil || path != localPath {
+		t.Errorf("Expected the local path back, got %q, %v", path, err)
+	}
+
+	if err := Remove(ctx, location); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, _, err := Open(ctx, location); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after removal, got %v", err)
+	}
+	if err := Remove(ctx, location); err != nil {
+		t.Errorf("Expected removing a missing object to succeed, got %v", err)
+	}
+}
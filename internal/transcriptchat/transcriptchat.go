@@ -0,0 +1,272 @@
+// Package transcriptchat answers questions about a single transcript. The
+// passages most relevant to a question are retrieved and put in the prompt
+// with their timestamps, so the model can cite where in the audio its
+// answer comes from.
+package transcriptchat
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"scriberr/internal/export"
+	"scriberr/internal/llm"
+)
+
+// Retrieval defaults
+const (
+	DefaultPassageSeconds = 45.0 // Length of transcript covered by each passage
+	DefaultMaxPassages    = 6    // Passages put in each prompt
+)
+
+// passageShare is the part of the budget the retrieved passages may use; the
+// rest is left for the conversation
+const passageShare = 0.6
+
+// messageOverheadTokens approximates the tokens each message adds beyond its
+// content
+const messageOverheadTokens = 4
+
+// ErrQuestionTooLong is returned when the latest message alone does not fit
+// the context budget
+var ErrQuestionTooLong = errors.New("message is too long for the model's context")
+
+// Passage is a stretch of consecutive transcript segments
+type Passage struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// Passages groups segments into passages of about passageSeconds each,
+// prefixing each speaker's text with their name when it changes
+func Passages(segments []export.Segment, passageSeconds float64) []Passage {
+	var passages []Passage
+	var current *Passage
+	var lines []string
+	speaker := ""
+	flush := func() {
+		if current != nil {
+			current.Text = strings.Join(lines, "\n")
+			passages = append(passages, *current)
+		}
+		current, lines, speaker = nil, nil, ""
+	}
+	for _, segment := range segments {
+		text := strings.TrimSpace(segment.Text)
+		if text == "" {
+			continue
+		}
+		if current != nil && segment.End-current.Start > passageSeconds {
+			flush()
+		}
+		if current == nil {
+			current = &Passage{Start: segment.Start}
+		}
+		current.End = segment.End
+		if segment.Speaker != "" && segment.Speaker != speaker {
+			text = segment.Speaker + ": " + text
+			speaker = segment.Speaker
+		}
+		lines = append(lines, text)
+	}
+	flush()
+	return passages
+}
+
+// BM25 parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// termPattern matches the terms passages are indexed by
+var termPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// stopWords are common English words that say nothing about relevance
+var stopWords = map[string]bool{
+	"a": true, "about": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "did": true, "do": true, "does": true, "for": true,
+	"from": true, "had": true, "has": true, "have": true, "he": true, "her": true, "his": true,
+	"how": true, "i": true, "in": true, "is": true, "it": true, "its": true, "me": true,
+	"of": true, "on": true, "or": true, "our": true, "she": true, "so": true, "that": true,
+	"the": true, "their": true, "them": true, "they": true, "this": true, "to": true, "up": true,
+	"was": true, "we": true, "were": true, "what": true, "when": true, "where": true,
+	"which": true, "who": true, "why": true, "will": true, "with": true, "you": true,
+	"say": true, "said": true, "says": true, "talk": true, "talked": true, "tell": true,
+}
+
+// terms splits text into lower-case index terms
+func terms(text string) []string {
+	var out []string
+	for _, term := range termPattern.FindAllString(strings.ToLower(text), -1) {
+		if !stopWords[term] {
+			out = append(out, term)
+		}
+	}
+	return out
+}
+
+// Retrieve ranks passages by BM25 relevance to query and returns up to limit
+// of them, most relevant first. When no passage shares a term with the
+// query, such as for "summarize this", the opening passages are returned.
+func Retrieve(passages []Passage, query string, limit int) []Passage {
+	if len(passages) == 0 || limit <= 0 {
+		return nil
+	}
+
+	frequencies := make([]map[string]int, len(passages))
+	lengths := make([]int, len(passages))
+	documentFrequency := make(map[string]int)
+	totalLength := 0
+	for i, passage := range passages {
+		frequencies[i] = make(map[string]int)
+		for _, term := range terms(passage.Text) {
+			if frequencies[i][term] == 0 {
+				documentFrequency[term]++
+			}
+			frequencies[i][term]++
+			lengths[i]++
+		}
+		totalLength += lengths[i]
+	}
+	averageLength := math.Max(float64(totalLength)/float64(len(passages)), 1)
+
+	queryTerms := make(map[string]bool)
+	for _, term := range terms(query) {
+		queryTerms[term] = true
+	}
+
+	type scored struct {
+		index int
+		score float64
+	}
+	var ranked []scored
+	for i := range passages {
+		score := 0.0
+		for term := range queryTerms {
+			tf := float64(frequencies[i][term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(documentFrequency[term])
+			idf := math.Log(1 + (float64(len(passages))-df+0.5)/(df+0.5))
+			score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(lengths[i])/averageLength))
+		}
+		if score > 0 {
+			ranked = append(ranked, scored{index: i, score: score})
+		}
+	}
+	if len(ranked) == 0 {
+		return append([]Passage(nil), passages[:min(limit, len(passages))]...)
+	}
+	sort.SliceStable(ranked, func(a, b int) bool { return ranked[a].score > ranked[b].score })
+
+	out := make([]Passage, 0, min(limit, len(ranked)))
+	for _, r := range ranked[:min(limit, len(ranked))] {
+		out = append(out, passages[r.index])
+	}
+	return out
+}
+
+// Prompt is the conversation to send to the model and the passages it cites
+type Prompt struct {
+	Messages []llm.ChatMessage
+	Sources  []Passage // In transcript order
+	Dropped  int       // Oldest turns left out to fit the budget
+}
+
+// BuildPrompt retrieves the passages relevant to the last message of history
+// and fits them and the conversation into budget tokens. Passages may use
+// most of the budget; the oldest turns are then dropped until the rest fits.
+func BuildPrompt(segments []export.Segment, history []llm.ChatMessage, budget int) (*Prompt, error) {
+	if len(history) == 0 {
+		return nil, errors.New("no message to answer")
+	}
+
+	question := history[len(history)-1].Content
+	ranked := Retrieve(Passages(segments, DefaultPassageSeconds), question, DefaultMaxPassages)
+	sources := inTranscriptOrder(ranked)
+	system := systemPrompt(sources)
+	for len(ranked) > 1 && messageTokens(system) > int(float64(budget)*passageShare) {
+		ranked = ranked[:len(ranked)-1]
+		sources = inTranscriptOrder(ranked)
+		system = systemPrompt(sources)
+	}
+
+	turns, dropped, err := TrimHistory(history, budget-messageTokens(system))
+	if err != nil {
+		return nil, err
+	}
+	messages := append([]llm.ChatMessage{{Role: "system", Content: system}}, turns...)
+	return &Prompt{Messages: messages, Sources: sources, Dropped: dropped}, nil
+}
+
+// TrimHistory drops the oldest turns of a conversation until it fits budget
+// tokens, returning the turns kept and how many were dropped. The latest
+// message is always kept, and the kept turns never start with a reply.
+func TrimHistory(history []llm.ChatMessage, budget int) ([]llm.ChatMessage, int, error) {
+	if len(history) == 0 {
+		return nil, 0, nil
+	}
+	used := 0
+	first := len(history)
+	for first > 0 {
+		tokens := messageTokens(history[first-1].Content)
+		if used+tokens > budget {
+			break
+		}
+		used += tokens
+		first--
+	}
+	if first == len(history) {
+		return nil, 0, ErrQuestionTooLong
+	}
+	for first < len(history)-1 && history[first].Role == "assistant" {
+		first++
+	}
+	return history[first:], first, nil
+}
+
+// EstimateTokens approximates the tokens a text takes in most chat models,
+// at about four characters each
+func EstimateTokens(text string) int {
+	return (len([]rune(text)) + 3) / 4
+}
+
+// messageTokens approximates the tokens a message with content takes
+func messageTokens(content string) int {
+	return EstimateTokens(content) + messageOverheadTokens
+}
+
+// inTranscriptOrder returns a copy of passages sorted by start time
+func inTranscriptOrder(passages []Passage) []Passage {
+	sorted := append([]Passage(nil), passages...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+	return sorted
+}
+
+// systemPrompt instructs the model to answer from passages and cite them
+func systemPrompt(passages []Passage) string {
+	var b strings.Builder
+	b.WriteString("You answer questions about a recorded conversation using only the transcript excerpts below. ")
+	b.WriteString("Each excerpt starts with its timestamp in square brackets. ")
+	b.WriteString("Cite the excerpts you use by their timestamp, for example [12:34], and say so if the excerpts do not answer the question.\n")
+	for _, passage := range passages {
+		fmt.Fprintf(&b, "\n[%s]\n%s\n", Timestamp(passage.Start), passage.Text)
+	}
+	return b.String()
+}
+
+// Timestamp formats seconds as M:SS, or H:MM:SS from an hour on
+func Timestamp(seconds float64) string {
+	total := int(math.Max(seconds, 0))
+	hours, minutes, secs := total/3600, total/60%60, total%60
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, secs)
+	}
+	return fmt.Sprintf("%d:%02d", minutes, secs)
+}
@@ -0,0 +1,118 @@
+package transcriptchat
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"scriberr/internal/export"
+	"scriberr/internal/llm"
+)
+
+func TestPassagesGroupSegmentsAndNameSpeakers(t *testing.T) {
+	segments := []export.Segment{
+		{Start: 0, End: 10, Speaker: "Ana", Text: "Welcome back."},
+		{Start: 10, End: 20, Speaker: "Ana", Text: "Today: the budget."},
+		{Start: 20, End: 40, Speaker: "Ben", Text: "Thanks."},
+		{Start: 40, End: 50, Speaker: "Ben", Text: "  "}, // Skipped
+		{Start: 50, End: 60, Speaker: "Ben", Text: "Hiring next."},
+	}
+	got := Passages(segments, 45)
+	want := []Passage{
+		{Start: 0, End: 40, Text: "Ana: Welcome back.\nToday: the budget.\nBen: Thanks."},
+		{Start: 50, End: 60, Text: "Ben: Hiring next."},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected passages %+v, got %+v", want, got)
+	}
+}
+
+func TestRetrieveRanksByRelevance(t *testing.T) {
+	passages := []Passage{
+		{Start: 0, Text: "We opened with introductions and the weather."},
+		{Start: 45, Text: "The budget for marketing grows next year."},
+		{Start: 90, Text: "Hiring: two engineers. The budget for hiring is fixed, hiring starts in May."},
+		{Start: 135, Text: "Closing remarks."},
+	}
+
+	got := Retrieve(passages, "What did they say about hiring?", 2)
+	if len(got) != 1 || got[0].Start != 90 {
+		t.Errorf("Expected only the hiring passage, got %+v", got)
+	}
+
+	got = Retrieve(passages, "How is the budget for hiring?", 3)
+	if len(got) != 2 || got[0].Start != 90 || got[1].Start != 45 {
+		t.Errorf("Expected the hiring passage before the marketing one, got %+v", got)
+	}
+
+	// Nothing in common with the transcript falls back to its opening
+	got = Retrieve(passages, "Summarize this please", 2)
+	if len(got) != 2 || got[0].Start != 0 || got[1].Start != 45 {
+		t.Errorf("Expected the first passages, got %+v", got)
+	}
+
+	if got := Retrieve(nil, "hiring", 3); got != nil {
+		t.Errorf("Expected no passages, got %+v", got)
+	}
+}
+
+func TestTrimHistoryDropsOldestTurns(t *testing.T) {
+	long := strings.Repeat("x", 400) // About 100 tokens
+	history := []llm.ChatMessage{
+		{Role: "user", Content: long},
+		{Role: "assistant", Content: long},
+		{Role: "user", Content: long},
+		{Role: "assistant", Content: long},
+		{Role: "user", Content: "And the budget?"},
+	}
+
+	kept, dropped, err := TrimHistory(history, 1000)
+	if err != nil || dropped != 0 || len(kept) != 5 {
+		t.Errorf("Expected everything to fit, got %d kept, %d dropped, %v", len(kept), dropped, err)
+	}
+
+	// Room for the question and the two turns before it
+	kept, dropped, err = TrimHistory(history, 220)
+	if err != nil || dropped != 2 || !reflect.DeepEqual(kept, history[2:]) {
+		t.Errorf("Expected the oldest exchange to be dropped, got %d kept, %d dropped, %v", len(kept), dropped, err)
+	}
+
+	// A reply left at the start without its question is dropped as well
+	kept, dropped, err = TrimHistory(history, 120)
+	if err != nil || dropped != 4 || !reflect.DeepEqual(kept, history[4:]) {
+		t.Errorf("Expected only the question to be kept, got %d kept, %d dropped, %v", len(kept), dropped, err)
+	}
+
+	if _, _, err := TrimHistory(history, 3); !errors.Is(err, ErrQuestionTooLong) {
+		t.Errorf("Expected ErrQuestionTooLong, got %v", err)
+	}
+}
+
+func TestBuildPromptCitesPassages(t *testing.T) {
+	segments := []export.Segment{
+		{Start: 0, End: 30, Text: "Introductions."},
+		{Start: 60, End: 90, Text: "The launch moves to March."},
+		{Start: 3700, End: 3720, Text: "The launch party is in April."},
+	}
+	history := []llm.ChatMessage{{Role: "user", Content: "When is the launch?"}}
+
+	prompt, err := BuildPrompt(segments, history, 8000)
+	if err != nil {
+		t.Fatalf("BuildPrompt failed: %v", err)
+	}
+	if len(prompt.Sources) != 2 || prompt.Sources[0].Start != 60 || prompt.Sources[1].Start != 3700 {
+		t.Errorf("Expected the launch passages in transcript order, got %+v", prompt.Sources)
+	}
+	system := prompt.Messages[0]
+	if system.Role != "system" || !strings.Contains(system.Content, "[1:00]\nThe launch moves to March.") ||
+		!strings.Contains(system.Content, "[1:01:40]\nThe launch party is in April.") {
+		t.Errorf("Expected timestamped passages in the system prompt, got %q", system.Content)
+	}
+	if strings.Contains(system.Content, "Introductions") {
+		t.Errorf("Expected unrelated passages to be left out")
+	}
+	if !reflect.DeepEqual(prompt.Messages[1:], history) || prompt.Dropped != 0 {
+		t.Errorf("Expected the question to follow the system prompt, got %+v", prompt.Messages[1:])
+	}
+}
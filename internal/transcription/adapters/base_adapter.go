@@ -46,6 +46,14 @@ func CheckEnvironmentReady(envPath, importStatement string) bool {
 	return ready
 }
 
+// ResetEnvironmentCache forgets the results of CheckEnvironmentReady, so
+// environments are checked again after they are repaired
+func ResetEnvironmentCache() {
+	envCacheMutex.Lock()
+	defer envCacheMutex.Unlock()
+	envCache = make(map[string]bool)
+}
+
 // BaseAdapter provides common functionality for all model adapters
 type BaseAdapter struct {
 	modelID      string
@@ -168,6 +176,19 @@ func (b *BaseAdapter) validateParameterValue(schema interfaces.ParameterSchema,
 		}
 		// Additional slice validation could be added here
 
+	case "[]float":
+		if reflect.TypeOf(value).Kind() != reflect.Slice {
+			return fmt.Errorf("expected slice, got %T", value)
+		}
+		for _, item := range b.GetFloatSliceParameter(map[string]interface{}{schema.Name: value}, schema.Name) {
+			if schema.Min != nil && item < *schema.Min {
+				return fmt.Errorf("value %g is below minimum %g", item, *schema.Min)
+			}
+			if schema.Max != nil && item > *schema.Max {
+				return fmt.Errorf("value %g is above maximum %g", item, *schema.Max)
+			}
+		}
+
 	default:
 		return fmt.Errorf("unsupported parameter type: %s", schema.Type)
 	}
@@ -357,6 +378,24 @@ func (b *BaseAdapter) GetStringSliceParameter(params map[string]interface{}, par
 	return []string{}
 }
 
+// GetFloatSliceParameter safely gets a []float64 parameter
+func (b *BaseAdapter) GetFloatSliceParameter(params map[string]interface{}, paramName string) []float64 {
+	value := b.GetParameterWithDefault(params, paramName)
+	if slice, ok := value.([]float64); ok {
+		return slice
+	}
+	if interfaceSlice, ok := value.([]interface{}); ok {
+		var floatSlice []float64
+		for _, item := range interfaceSlice {
+			if floatVal, err := b.convertToFloat(item); err == nil {
+				floatSlice = append(floatSlice, floatVal)
+			}
+		}
+		return floatSlice
+	}
+	return []float64{}
+}
+
 // CreateTempDirectory creates a temporary directory for processing
 func (b *BaseAdapter) CreateTempDirectory(procCtx interfaces.ProcessingContext) (string, error) {
 	tempDir := filepath.Join(procCtx.TempDirectory, b.modelID, procCtx.JobID)
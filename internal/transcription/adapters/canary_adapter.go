@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"scriberr/internal/transcription/interfaces"
+	"scriberr/internal/transcription/process"
 	"scriberr/internal/transcription/registry"
 	"scriberr/pkg/logger"
 )
@@ -556,7 +557,7 @@ func (c *CanaryAdapter) Transcribe(ctx context.Context, input interfaces.AudioIn
 
 	logger.Info("Executing Canary command", "args", strings.Join(args, " "))
 
-	output, err := cmd.CombinedOutput()
+	output, err := process.CombinedOutput(cmd)
 	if ctx.Err() == context.Canceled {
 		return nil, fmt.Errorf("transcription was cancelled")
 	}
@@ -688,7 +689,6 @@ func (c *CanaryAdapter) parseResult(tempDir string, input interfaces.AudioInput,
 			Start: word.Start,
 			End:   word.End,
 			Word:  word.Word,
-			Score: 1.0, // Canary doesn't provide word-level scores
 		}
 	}
 
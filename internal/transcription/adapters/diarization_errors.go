@@ -0,0 +1,45 @@
+package adapters
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrDiarizationModelAccess is returned when the pyannote pipeline cannot be
+// loaded from HuggingFace, almost always because of the access token
+var ErrDiarizationModelAccess = errors.New("failed to load pyannote diarization model")
+
+// pyannoteAccessFailures maps markers found in python output to the advice
+// shown on the job. Order matters: the first match wins.
+var pyannoteAccessFailures = []struct {
+	markers []string
+	advice  string
+}{
+	{
+		markers: []string{"GatedRepoError", "Cannot access gated repo", "accept the user conditions", "403 Client Error"},
+		advice:  "the HuggingFace account for this token has not accepted the user conditions for pyannote/speaker-diarization-3.1 and pyannote/segmentation-3.0; accept them on huggingface.co and retry",
+	},
+	{
+		markers: []string{"Invalid user token", "401 Client Error", "Unauthorized for url", "Invalid credentials in Authorization header"},
+		advice:  "the HuggingFace token (hf_token) was rejected; check that it is valid and has read access",
+	},
+	{
+		markers: []string{"'NoneType' object has no attribute", "Could not download 'pyannote", "LocalEntryNotFoundError", "token=YOUR_AUTH_TOKEN"},
+		advice:  "no usable HuggingFace token (hf_token) was provided; set one on the job or profile and make sure it has accepted the pyannote model conditions",
+	},
+}
+
+// ClassifyDiarizationFailure inspects the output of a failed python run and
+// returns a targeted error when the pyannote model could not be loaded. It
+// returns nil when the output does not look like a model access problem.
+func ClassifyDiarizationFailure(output string) error {
+	for _, failure := range pyannoteAccessFailures {
+		for _, marker := range failure.markers {
+			if strings.Contains(output, marker) {
+				return fmt.Errorf("%w: %s", ErrDiarizationModelAccess, failure.advice)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,448 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"scriberr/internal/models"
+	"scriberr/internal/transcription/interfaces"
+	"scriberr/internal/transcription/process"
+	"scriberr/internal/transcription/registry"
+	"scriberr/pkg/logger"
+)
+
+// FasterWhisperSchema is the output schema of faster-whisper results, the
+// JSON openai-whisper writes
+const FasterWhisperSchema = "faster-whisper"
+
+// defaultFasterWhisperPath is the faster-whisper executable looked up on the PATH
+const defaultFasterWhisperPath = "faster-whisper-xxl"
+
+var (
+	fasterWhisperPathMu sync.RWMutex
+	fasterWhisperPath   = defaultFasterWhisperPath
+)
+
+// ConfigureFasterWhisper sets the faster-whisper executable the adapter runs.
+// An empty path keeps the default, faster-whisper-xxl on the PATH.
+func ConfigureFasterWhisper(path string) {
+	fasterWhisperPathMu.Lock()
+	defer fasterWhisperPathMu.Unlock()
+	if path == "" {
+		path = defaultFasterWhisperPath
+	}
+	fasterWhisperPath = path
+}
+
+// FasterWhisperAdapter implements the TranscriptionAdapter interface for the
+// standalone faster-whisper CLI. It runs the same Whisper models as WhisperX
+// with less memory on the CPU, but neither aligns nor diarizes; speakers are
+// diarized by a separate model.
+type FasterWhisperAdapter struct {
+	*BaseAdapter
+}
+
+// NewFasterWhisperAdapter creates a new faster-whisper adapter
+func NewFasterWhisperAdapter() *FasterWhisperAdapter {
+	capabilities := interfaces.ModelCapabilities{
+		ModelID:            "faster-whisper",
+		ModelFamily:        "whisper",
+		DisplayName:        "faster-whisper",
+		Description:        "OpenAI Whisper on CTranslate2 with word-level timestamps and low memory use",
+		Version:            "1.0.0",
+		SupportedLanguages: whisperLanguages,
+		SupportedFormats:   []string{"wav", "mp3", "flac", "m4a", "ogg", "wma"},
+		RequiresGPU:        false,
+		MemoryRequirement:  1024,
+		Features: map[string]bool{
+			"timestamps":         true,
+			"word_level":         true,
+			"diarization":        false,
+			"translation":        true,
+			"language_detection": true,
+			"vad":                true,
+		},
+		Metadata: map[string]string{
+			"engine":    "openai_whisper",
+			"framework": "ctranslate2",
+			"license":   "MIT",
+		},
+	}
+
+	schema := []interfaces.ParameterSchema{
+		{
+			Name:        "model",
+			Type:        "string",
+			Required:    false,
+			Default:     "small",
+			Options:     []string{"tiny", "tiny.en", "base", "base.en", "small", "small.en", "medium", "medium.en", "large", "large-v1", "large-v2", "large-v3"},
+			Description: "Whisper model size to use",
+			Group:       "basic",
+		},
+		{
+			Name:        "model_dir",
+			Type:        "string",
+			Required:    false,
+			Default:     nil,
+			Description: "Directory models are loaded from and downloaded to",
+			Group:       "advanced",
+		},
+		{
+			Name:        "device",
+			Type:        "string",
+			Required:    false,
+			Default:     "cpu",
+			Options:     []string{"cpu", "cuda", "mps", "auto"},
+			Description: "Device to use for computation; MPS runs on the CPU",
+			Group:       "basic",
+		},
+		{
+			Name:        "compute_type",
+			Type:        "string",
+			Required:    false,
+			Default:     "int8",
+			Options:     models.ComputeTypes,
+			Description: "Computation precision; the int8 types use less memory but may reduce accuracy",
+			Group:       "advanced",
+		},
+		{
+			Name:        "threads",
+			Type:        "int",
+			Required:    false,
+			Default:     0,
+			Min:         &[]float64{0}[0],
+			Max:         &[]float64{models.MaxThreads}[0],
+			Description: "Number of CPU threads (0 = auto)",
+			Group:       "advanced",
+		},
+		{
+			Name:        "language",
+			Type:        "string",
+			Required:    false,
+			Default:     nil,
+			Description: "Language code (auto-detect if not specified)",
+			Group:       "basic",
+		},
+		{
+			Name:        "task",
+			Type:        "string",
+			Required:    false,
+			Default:     "transcribe",
+			Options:     []string{"transcribe", "translate"},
+			Description: "Task to perform",
+			Group:       "basic",
+		},
+		{
+			Name:        "temperature",
+			Type:        "float",
+			Required:    false,
+			Default:     0.0,
+			Min:         &[]float64{0.0}[0],
+			Max:         &[]float64{1.0}[0],
+			Description: "Sampling temperature",
+			Group:       "quality",
+		},
+		{
+			Name:        "temperatures",
+			Type:        "[]float",
+			Required:    false,
+			Default:     nil,
+			Min:         &[]float64{0.0}[0],
+			Max:         &[]float64{1.0}[0],
+			Description: "Temperature fallback schedule, rising in equal steps",
+			Group:       "quality",
+		},
+		{
+			Name:        "best_of",
+			Type:        "int",
+			Required:    false,
+			Default:     5,
+			Min:         &[]float64{1}[0],
+			Max:         &[]float64{10}[0],
+			Description: "Number of candidates to consider",
+			Group:       "quality",
+		},
+		{
+			Name:        "beam_size",
+			Type:        "int",
+			Required:    false,
+			Default:     5,
+			Min:         &[]float64{1}[0],
+			Max:         &[]float64{10}[0],
+			Description: "Beam search size",
+			Group:       "quality",
+		},
+		{
+			Name:        "patience",
+			Type:        "float",
+			Required:    false,
+			Default:     1.0,
+			Min:         &[]float64{0.0}[0],
+			Max:         &[]float64{2.0}[0],
+			Description: "Beam search patience",
+			Group:       "quality",
+		},
+		{
+			Name:        "initial_prompt",
+			Type:        "string",
+			Required:    false,
+			Default:     nil,
+			Description: "Text that primes the model with names and terminology",
+			Group:       "quality",
+		},
+		{
+			Name:        "vad_onset",
+			Type:        "float",
+			Required:    false,
+			Default:     0.5,
+			Min:         &[]float64{0.0}[0],
+			Max:         &[]float64{1.0}[0],
+			Description: "Silero VAD speech threshold; lower is more sensitive and picks up quieter speech",
+			Group:       "advanced",
+		},
+	}
+
+	return &FasterWhisperAdapter{
+		BaseAdapter: NewBaseAdapter("faster-whisper", "", capabilities, schema),
+	}
+}
+
+// GetSupportedModels returns the list of Whisper models supported
+func (f *FasterWhisperAdapter) GetSupportedModels() []string {
+	return []string{
+		"tiny", "tiny.en",
+		"base", "base.en",
+		"small", "small.en",
+		"medium", "medium.en",
+		"large", "large-v1", "large-v2", "large-v3",
+	}
+}
+
+// executable returns the configured faster-whisper executable
+func (f *FasterWhisperAdapter) executable() string {
+	fasterWhisperPathMu.RLock()
+	defer fasterWhisperPathMu.RUnlock()
+	return fasterWhisperPath
+}
+
+// PrepareEnvironment has nothing to install; faster-whisper is a standalone
+// executable the administrator provides
+func (f *FasterWhisperAdapter) PrepareEnvironment(ctx context.Context) error {
+	if path, err := exec.LookPath(f.executable()); err != nil {
+		logger.Info("faster-whisper executable not found; faster-whisper jobs will fail", "executable", f.executable())
+	} else {
+		logger.Info("faster-whisper executable found", "path", path)
+	}
+	f.initialized = true
+	return nil
+}
+
+// IsReady reports whether the faster-whisper executable can be found
+func (f *FasterWhisperAdapter) IsReady(ctx context.Context) bool {
+	_, err := exec.LookPath(f.executable())
+	return err == nil
+}
+
+// Transcribe processes audio using faster-whisper
+func (f *FasterWhisperAdapter) Transcribe(ctx context.Context, input interfaces.AudioInput, params map[string]interface{}, procCtx interfaces.ProcessingContext) (*interfaces.TranscriptResult, error) {
+	startTime := time.Now()
+	f.LogProcessingStart(input, procCtx)
+	defer func() {
+		f.LogProcessingEnd(procCtx, time.Since(startTime), nil)
+	}()
+
+	if err := f.ValidateAudioInput(input); err != nil {
+		return nil, fmt.Errorf("invalid audio input: %w", err)
+	}
+	if err := f.ValidateParameters(params); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	tempDir, err := f.CreateTempDirectory(procCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer f.CleanupTempDirectory(tempDir)
+
+	args := f.buildFasterWhisperArgs(input, params, tempDir)
+
+	// Stream output so segment timestamps can drive progress
+	cmd := exec.CommandContext(ctx, f.executable(), args...)
+	output := newProgressWriter(input.Duration.Seconds(), procCtx.ReportProgress)
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	logger.Info("Executing faster-whisper command", "executable", f.executable(), "args", strings.Join(args, " "))
+
+	err = process.Run(cmd)
+	if ctx.Err() == context.Canceled {
+		return nil, fmt.Errorf("transcription was cancelled")
+	}
+	if err != nil {
+		logger.Error("faster-whisper execution failed", "output", output.String(), "error", err)
+		return nil, fmt.Errorf("faster-whisper execution failed: %w", err)
+	}
+
+	result, err := f.parseResult(tempDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse result: %w", err)
+	}
+
+	result.ProcessingTime = time.Since(startTime)
+	result.ModelUsed = f.GetStringParameter(params, "model")
+	result.Metadata = f.CreateDefaultMetadata(params)
+
+	logger.Info("faster-whisper transcription completed",
+		"segments", len(result.Segments),
+		"words", len(result.WordSegments),
+		"processing_time", result.ProcessingTime)
+
+	return result, nil
+}
+
+// buildFasterWhisperArgs builds the command arguments for faster-whisper
+func (f *FasterWhisperAdapter) buildFasterWhisperArgs(input interfaces.AudioInput, params map[string]interface{}, outputDir string) []string {
+	args := []string{
+		input.FilePath,
+		"--output_dir", outputDir,
+		"--output_format", "json",
+		"--word_timestamps", "True",
+		"--beep_off",
+	}
+
+	// Core parameters. faster-whisper runs on CUDA or the CPU only, and the
+	// CPU has no float16 computation.
+	args = append(args, "--model", f.GetStringParameter(params, "model"))
+	if modelDir := f.GetStringParameter(params, "model_dir"); modelDir != "" {
+		args = append(args, "--model_dir", modelDir)
+	}
+	device := f.GetStringParameter(params, "device")
+	computeType := f.GetStringParameter(params, "compute_type")
+	if device != "cuda" {
+		device = "cpu"
+		if computeType == "float16" {
+			computeType = "int8"
+		}
+	}
+	args = append(args, "--device", device)
+	args = append(args, "--compute_type", computeType)
+	if threads := f.GetIntParameter(params, "threads"); threads > 0 {
+		args = append(args, "--threads", strconv.Itoa(threads))
+	}
+
+	// Task and language
+	args = append(args, "--task", f.GetStringParameter(params, "task"))
+	if language := f.GetStringParameter(params, "language"); language != "" && language != models.LanguageAuto {
+		args = append(args, "--language", language)
+	}
+
+	// VAD settings
+	args = append(args, "--vad_filter", "True")
+	args = append(args, "--vad_threshold", fmt.Sprintf("%.3f", f.GetFloatParameter(params, "vad_onset")))
+
+	// Quality settings
+	args = append(args, temperatureArgs(f.GetFloatSliceParameter(params, "temperatures"), f.GetFloatParameter(params, "temperature"))...)
+	args = append(args, "--best_of", strconv.Itoa(f.GetIntParameter(params, "best_of")))
+	args = append(args, "--beam_size", strconv.Itoa(f.GetIntParameter(params, "beam_size")))
+	args = append(args, "--patience", fmt.Sprintf("%.2f", f.GetFloatParameter(params, "patience")))
+	if prompt := models.SanitizeInitialPrompt(f.GetStringParameter(params, "initial_prompt")); prompt != "" {
+		args = append(args, "--initial_prompt", prompt)
+	}
+
+	return args
+}
+
+// parseResult parses the faster-whisper JSON output file
+func (f *FasterWhisperAdapter) parseResult(outputDir string) (*interfaces.TranscriptResult, error) {
+	files, err := filepath.Glob(filepath.Join(outputDir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find result files: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no result files found in %s", outputDir)
+	}
+
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read result file: %w", err)
+	}
+	result, err := ParseFasterWhisperOutput(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(files[0]), err)
+	}
+	return result, nil
+}
+
+type fasterWhisperOutput struct {
+	Text     string `json:"text"`
+	Language string `json:"language"`
+	Segments []struct {
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		Text  string  `json:"text"`
+		Words []struct {
+			Start       float64 `json:"start"`
+			End         float64 `json:"end"`
+			Word        string  `json:"word"`
+			Probability float64 `json:"probability"`
+		} `json:"words"`
+	} `json:"segments"`
+}
+
+// ParseFasterWhisperOutput parses a faster-whisper JSON result. Like WhisperX
+// results, it holds the segments without words and every word in
+// WordSegments, with the word's probability as its score.
+func ParseFasterWhisperOutput(data []byte) (*interfaces.TranscriptResult, error) {
+	var output fasterWhisperOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON result: %w", err)
+	}
+	if output.Segments == nil {
+		return nil, fmt.Errorf("result has no segments")
+	}
+
+	result := &interfaces.TranscriptResult{
+		Language:      output.Language,
+		Segments:      make([]interfaces.TranscriptSegment, len(output.Segments)),
+		SchemaVersion: FasterWhisperSchema,
+	}
+
+	var textParts []string
+	for i, seg := range output.Segments {
+		result.Segments[i] = interfaces.TranscriptSegment{
+			Start: seg.Start,
+			End:   seg.End,
+			Text:  seg.Text,
+		}
+		textParts = append(textParts, strings.TrimSpace(seg.Text))
+		for _, word := range seg.Words {
+			result.WordSegments = append(result.WordSegments, interfaces.TranscriptWord{
+				Start: word.Start,
+				End:   word.End,
+				Word:  strings.TrimSpace(word.Word),
+				Score: word.Probability,
+			})
+		}
+	}
+
+	if text := strings.TrimSpace(output.Text); text != "" {
+		result.Text = text
+	} else {
+		result.Text = strings.Join(textParts, " ")
+	}
+
+	return result, nil
+}
+
+// init registers the faster-whisper adapter
+func init() {
+	registry.RegisterTranscriptionAdapter("faster-whisper", NewFasterWhisperAdapter())
+}
@@ -0,0 +1,162 @@
+package adapters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"scriberr/internal/transcription/interfaces"
+	"scriberr/internal/transcription/registry"
+)
+
+func TestBuildFasterWhisperArgs(t *testing.T) {
+	adapter := NewFasterWhisperAdapter()
+	input := interfaces.AudioInput{FilePath: "/tmp/audio.wav"}
+
+	args := adapter.buildFasterWhisperArgs(input, map[string]interface{}{
+		"model":          "large-v3",
+		"model_dir":      "data/models",
+		"device":         "cuda",
+		"compute_type":   "float16",
+		"threads":        4,
+		"language":       "fr",
+		"task":           "translate",
+		"beam_size":      3,
+		"temperatures":   []float64{0, 0.5, 1},
+		"vad_onset":      0.4,
+		"initial_prompt": "Scriberr, `WhisperX`",
+	}, "/tmp/out")
+	if args[0] != "/tmp/audio.wav" {
+		t.Errorf("Expected the audio file first, got %v", args)
+	}
+	command := strings.Join(args, " ")
+	for _, flag := range []string{
+		"--output_dir /tmp/out", "--output_format json", "--word_timestamps True",
+		"--model large-v3", "--model_dir data/models", "--device cuda", "--compute_type float16", "--threads 4",
+		"--language fr", "--task translate", "--vad_filter True", "--vad_threshold 0.400",
+		"--temperature 0.00", "--temperature_increment_on_fallback 0.50", "--beam_size 3", "--best_of 5",
+		"--initial_prompt Scriberr, WhisperX",
+	} {
+		if !strings.Contains(command, flag) {
+			t.Errorf("Expected %q in %s", flag, command)
+		}
+	}
+}
+
+func TestBuildFasterWhisperArgsDefaults(t *testing.T) {
+	adapter := NewFasterWhisperAdapter()
+	input := interfaces.AudioInput{FilePath: "/tmp/audio.wav"}
+
+	// faster-whisper has no MPS support, and the CPU no float16
+	args := adapter.buildFasterWhisperArgs(input, map[string]interface{}{"device": "mps", "compute_type": "float16", "language": "auto"}, "/tmp/out")
+	command := strings.Join(args, " ")
+	for _, flag := range []string{"--model small", "--device cpu", "--compute_type int8", "--task transcribe", "--temperature 0.00"} {
+		if !strings.Contains(command, flag) {
+			t.Errorf("Expected %q in %s", flag, command)
+		}
+	}
+	for _, flag := range []string{"--language", "--model_dir", "--threads", "--initial_prompt", "--temperature_increment_on_fallback"} {
+		if strings.Contains(command, flag) {
+			t.Errorf("Expected no %s in %s", flag, command)
+		}
+	}
+}
+
+func TestParseFasterWhisperOutput(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "faster_whisper.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := ParseFasterWhisperOutput(data)
+	if err != nil {
+		t.Fatalf("ParseFasterWhisperOutput failed: %v", err)
+	}
+
+	if result.SchemaVersion != FasterWhisperSchema || result.Language != "en" {
+		t.Errorf("Expected schema %s in en, got %s in %s", FasterWhisperSchema, result.SchemaVersion, result.Language)
+	}
+	if result.Text != "Hello there, this is Scriberr. It has two speakers." {
+		t.Errorf("Unexpected text %q", result.Text)
+	}
+	if len(result.Segments) != 2 || len(result.WordSegments) != 9 {
+		t.Fatalf("Expected 2 segments and 9 words, got %d and %d", len(result.Segments), len(result.WordSegments))
+	}
+	if segment := result.Segments[1]; segment.Start != 3.1 || segment.End != 4.72 || segment.Words != nil {
+		t.Errorf("Expected the second segment at 3.1-4.72 without words, got %+v", segment)
+	}
+	if word := result.WordSegments[4]; word.Word != "Scriberr." || word.Score != 0.64 || word.Start != 1.3 || word.End != 2.48 {
+		t.Errorf("Expected the word trimmed with its probability as score, got %+v", word)
+	}
+
+	for _, invalid := range []string{`{"text":"no segments"}`, `not json`} {
+		if _, err := ParseFasterWhisperOutput([]byte(invalid)); err == nil {
+			t.Errorf("Expected %s to fail to parse", invalid)
+		}
+	}
+}
+
+func TestFasterWhisperTranscribe(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Runs a shell script as faster-whisper")
+	}
+	dir := t.TempDir()
+	fixture, err := filepath.Abs(filepath.Join("testdata", "faster_whisper.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The fake faster-whisper prints a segment line and writes the fixture
+	// to the output directory it is given
+	script := filepath.Join(dir, "faster-whisper-xxl")
+	if err := os.WriteFile(script, []byte(`#!/bin/sh
+while [ "$1" != "--output_dir" ]; do shift; done
+echo "[00:00.000 --> 00:02.480]  Hello there, this is Scriberr."
+cp "`+fixture+`" "$2/audio.json"
+`), 0755); err != nil {
+		t.Fatal(err)
+	}
+	audio := filepath.Join(dir, "audio.wav")
+	if err := os.WriteFile(audio, []byte("RIFF"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ConfigureFasterWhisper(script)
+	defer ConfigureFasterWhisper("")
+
+	adapter := NewFasterWhisperAdapter()
+	if !adapter.IsReady(context.Background()) {
+		t.Fatal("Expected the adapter to be ready with the executable present")
+	}
+	var progress float64
+	result, err := adapter.Transcribe(context.Background(),
+		interfaces.AudioInput{FilePath: audio, Format: "wav", Size: 4, Duration: 5 * time.Second},
+		map[string]interface{}{"model": "tiny"},
+		interfaces.ProcessingContext{JobID: "job", TempDirectory: dir, ProgressCallback: func(seconds float64) { progress = seconds }})
+	if err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if len(result.Segments) != 2 || result.ModelUsed != "tiny" {
+		t.Errorf("Expected the fixture's 2 segments from tiny, got %d from %s", len(result.Segments), result.ModelUsed)
+	}
+	if progress != 2.48 {
+		t.Errorf("Expected progress from the segment line, got %v", progress)
+	}
+
+	ConfigureFasterWhisper(filepath.Join(dir, "missing"))
+	if adapter.IsReady(context.Background()) {
+		t.Error("Expected the adapter not to be ready without the executable")
+	}
+}
+
+func TestFasterWhisperRegistered(t *testing.T) {
+	adapter, err := registry.GetRegistry().GetTranscriptionAdapter("faster-whisper")
+	if err != nil {
+		t.Fatalf("Expected faster-whisper to be registered: %v", err)
+	}
+	if _, ok := adapter.(*FasterWhisperAdapter); !ok {
+		t.Errorf("Expected a FasterWhisperAdapter, got %T", adapter)
+	}
+}
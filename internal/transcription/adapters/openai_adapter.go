@@ -0,0 +1,494 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/internal/transcription/interfaces"
+	"scriberr/internal/transcription/registry"
+	"scriberr/pkg/logger"
+)
+
+const (
+	openAIDefaultBaseURL = "https://api.openai.com/v1"
+	openAIDefaultModel   = "whisper-1"
+	// openAIMaxUploadBytes is the API's per-request file size limit
+	openAIMaxUploadBytes = 25 * 1024 * 1024
+	// openAIChunkSeconds is the chunk length used when audio exceeds the upload
+	// limit. Chunks are re-encoded at 64 kbps mono, so 10 minutes is about 4.8 MB.
+	openAIChunkSeconds = 600
+)
+
+// OpenAISettings configures the OpenAI-compatible transcription endpoint
+type OpenAISettings struct {
+	BaseURL string
+	APIKey  string
+	Model   string
+}
+
+var (
+	openAISettingsMu sync.RWMutex
+	openAISettings   OpenAISettings
+)
+
+// ConfigureOpenAI sets the endpoint and credentials used by the OpenAI adapter.
+// When no API key is configured the adapter falls back to the active OpenAI LLM
+// configuration.
+func ConfigureOpenAI(settings OpenAISettings) {
+	openAISettingsMu.Lock()
+	defer openAISettingsMu.Unlock()
+	openAISettings = settings
+}
+
+// OpenAIAdapter transcribes audio with an OpenAI-compatible hosted API
+type OpenAIAdapter struct {
+	*BaseAdapter
+	client     *http.Client
+	ffmpegPath string
+}
+
+// openAIVerboseResponse is the verbose_json transcription response
+type openAIVerboseResponse struct {
+	Text     string  `json:"text"`
+	Language string  `json:"language"`
+	Duration float64 `json:"duration"`
+	Segments []struct {
+		Start        float64 `json:"start"`
+		End          float64 `json:"end"`
+		Text         string  `json:"text"`
+		AvgLogprob   float64 `json:"avg_logprob"`
+		NoSpeechProb float64 `json:"no_speech_prob"`
+	} `json:"segments"`
+	Words []struct {
+		Word  string  `json:"word"`
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+	} `json:"words"`
+}
+
+// openAIErrorResponse is the error body returned by OpenAI-compatible APIs
+type openAIErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    string `json:"code"`
+	} `json:"error"`
+}
+
+// NewOpenAIAdapter creates a new OpenAI-compatible transcription adapter
+func NewOpenAIAdapter() *OpenAIAdapter {
+	capabilities := interfaces.ModelCapabilities{
+		ModelID:            "openai",
+		ModelFamily:        "openai",
+		DisplayName:        "OpenAI API",
+		Description:        "Hosted transcription through the OpenAI audio API or a compatible endpoint",
+		Version:            "1.0.0",
+		SupportedLanguages: []string{"auto"},
+		SupportedFormats:   []string{"mp3", "mp4", "mpeg", "mpga", "m4a", "wav", "webm", "flac", "ogg"},
+		RequiresGPU:        false,
+		MemoryRequirement:  0,
+		Features: map[string]bool{
+			"timestamps":         true,
+			"word_level":         true,
+			"diarization":        false,
+			"translation":        true,
+			"language_detection": true,
+			"remote":             true,
+		},
+		Metadata: map[string]string{
+			"engine":  "openai_api",
+			"license": "Proprietary",
+		},
+	}
+
+	schema := []interfaces.ParameterSchema{
+		{
+			Name:        "model",
+			Type:        "string",
+			Required:    false,
+			Default:     "",
+			Description: "Hosted model name (defaults to the configured model)",
+			Group:       "basic",
+		},
+		{
+			Name:        "language",
+			Type:        "string",
+			Required:    false,
+			Default:     nil,
+			Description: "Language code (auto-detect if not specified)",
+			Group:       "basic",
+		},
+		{
+			Name:        "task",
+			Type:        "string",
+			Required:    false,
+			Default:     "transcribe",
+			Options:     []string{"transcribe", "translate"},
+			Description: "Transcribe, or translate to English",
+			Group:       "basic",
+		},
+		{
+			Name:        "prompt",
+			Type:        "string",
+			Required:    false,
+			Default:     "",
+			Description: "Optional text to guide the model's style or vocabulary",
+			Group:       "advanced",
+		},
+		{
+			Name:        "temperature",
+			Type:        "float",
+			Required:    false,
+			Default:     0.0,
+			Min:         &[]float64{0.0}[0],
+			Max:         &[]float64{1.0}[0],
+			Description: "Sampling temperature",
+			Group:       "quality",
+		},
+	}
+
+	return &OpenAIAdapter{
+		BaseAdapter: NewBaseAdapter("openai", "", capabilities, schema),
+		client:      &http.Client{Timeout: 10 * time.Minute},
+		ffmpegPath:  "ffmpeg",
+	}
+}
+
+// GetSupportedModels returns the hosted models known to work with this adapter
+func (o *OpenAIAdapter) GetSupportedModels() []string {
+	return []string{"whisper-1", "gpt-4o-transcribe", "gpt-4o-mini-transcribe"}
+}
+
+// PrepareEnvironment has nothing to install for a hosted API
+func (o *OpenAIAdapter) PrepareEnvironment(ctx context.Context) error {
+	o.initialized = true
+	return nil
+}
+
+// IsReady reports whether an API key is available
+func (o *OpenAIAdapter) IsReady(ctx context.Context) bool {
+	return o.resolveSettings().APIKey != ""
+}
+
+// resolveSettings returns the configured settings, falling back to the active
+// OpenAI LLM configuration for the API key and base URL
+func (o *OpenAIAdapter) resolveSettings() OpenAISettings {
+	openAISettingsMu.RLock()
+	settings := openAISettings
+	openAISettingsMu.RUnlock()
+
+	if settings.APIKey == "" && database.DB != nil {
+		var llmConfig models.LLMConfig
+		if err := database.DB.Where("provider = ? AND is_active = ?", "openai", true).First(&llmConfig).Error; err == nil {
+			if llmConfig.APIKey != nil {
+				settings.APIKey = *llmConfig.APIKey
+			}
+			if settings.BaseURL == "" && llmConfig.BaseURL != nil {
+				settings.BaseURL = *llmConfig.BaseURL
+			}
+		}
+	}
+
+	if settings.BaseURL == "" {
+		settings.BaseURL = openAIDefaultBaseURL
+	}
+	settings.BaseURL = strings.TrimRight(settings.BaseURL, "/")
+	if settings.Model == "" {
+		settings.Model = openAIDefaultModel
+	}
+	return settings
+}
+
+// Transcribe uploads audio to the hosted API, splitting it into chunks when it
+// exceeds the upload limit
+func (o *OpenAIAdapter) Transcribe(ctx context.Context, input interfaces.AudioInput, params map[string]interface{}, procCtx interfaces.ProcessingContext) (*interfaces.TranscriptResult, error) {
+	startTime := time.Now()
+	o.LogProcessingStart(input, procCtx)
+
+	result, err := o.transcribe(ctx, input, params, procCtx)
+	o.LogProcessingEnd(procCtx, time.Since(startTime), err)
+	if err != nil {
+		return nil, err
+	}
+	result.ProcessingTime = time.Since(startTime)
+	return result, nil
+}
+
+func (o *OpenAIAdapter) transcribe(ctx context.Context, input interfaces.AudioInput, params map[string]interface{}, procCtx interfaces.ProcessingContext) (*interfaces.TranscriptResult, error) {
+	settings := o.resolveSettings()
+	if settings.APIKey == "" {
+		return nil, fmt.Errorf("OpenAI transcription is not configured: set OPENAI_API_KEY or an active OpenAI LLM configuration")
+	}
+	if model := o.GetStringParameter(params, "model"); model != "" {
+		settings.Model = model
+	}
+
+	if err := o.ValidateAudioInput(input); err != nil {
+		return nil, fmt.Errorf("invalid audio input: %w", err)
+	}
+
+	chunks := []audioChunk{{path: input.FilePath}}
+	if input.Size > openAIMaxUploadBytes {
+		tempDir, err := o.CreateTempDirectory(procCtx)
+		if err != nil {
+			return nil, err
+		}
+		defer o.CleanupTempDirectory(tempDir)
+
+		chunks, err = o.splitAudio(ctx, input, tempDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split audio for upload: %w", err)
+		}
+	}
+
+	logger.Info("Sending audio to hosted transcription API",
+		"job_id", procCtx.JobID,
+		"base_url", settings.BaseURL,
+		"model", settings.Model,
+		"chunks", len(chunks))
+
+	result := &interfaces.TranscriptResult{}
+	var texts []string
+	for i, chunk := range chunks {
+		body, err := o.requestTranscription(ctx, settings, chunk.path, params)
+		if err != nil {
+			if len(chunks) > 1 {
+				return nil, fmt.Errorf("chunk %d of %d: %w", i+1, len(chunks), err)
+			}
+			return nil, err
+		}
+
+		chunkResult, err := ParseOpenAIVerboseJSON(body, chunk.offset)
+		if err != nil {
+			return nil, err
+		}
+		result.Segments = append(result.Segments, chunkResult.Segments...)
+		result.WordSegments = append(result.WordSegments, chunkResult.WordSegments...)
+		if text := strings.TrimSpace(chunkResult.Text); text != "" {
+			texts = append(texts, text)
+		}
+		if result.Language == "" {
+			result.Language = chunkResult.Language
+		}
+
+		processed := chunk.offset + chunk.duration
+		if chunk.duration == 0 {
+			processed = input.Duration.Seconds()
+		}
+		procCtx.ReportProgress(processed)
+	}
+
+	result.Text = strings.Join(texts, " ")
+	result.ModelUsed = settings.Model
+	result.Metadata = o.CreateDefaultMetadata(params)
+	result.Metadata["engine"] = "openai_api"
+	result.Metadata["chunks"] = strconv.Itoa(len(chunks))
+
+	return result, nil
+}
+
+// audioChunk is a piece of the input audio and its position in the original
+type audioChunk struct {
+	path     string
+	offset   float64
+	duration float64
+}
+
+// splitAudio re-encodes the input into fixed-length compressed chunks
+func (o *OpenAIAdapter) splitAudio(ctx context.Context, input interfaces.AudioInput, tempDir string) ([]audioChunk, error) {
+	total := input.Duration.Seconds()
+	if total <= 0 {
+		return nil, fmt.Errorf("audio duration is unknown")
+	}
+
+	count := int(math.Ceil(total / openAIChunkSeconds))
+	chunks := make([]audioChunk, 0, count)
+	for i := 0; i < count; i++ {
+		offset := float64(i * openAIChunkSeconds)
+		duration := math.Min(openAIChunkSeconds, total-offset)
+		path := filepath.Join(tempDir, fmt.Sprintf("chunk_%03d.mp3", i))
+
+		cmd := exec.CommandContext(ctx, o.ffmpegPath,
+			"-y", "-v", "error",
+			"-ss", strconv.FormatFloat(offset, 'f', 3, 64),
+			"-t", strconv.FormatFloat(duration, 'f', 3, 64),
+			"-i", input.FilePath,
+			"-ac", "1", "-ar", "16000", "-b:a", "64k",
+			path)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("ffmpeg failed on chunk %d: %w: %s", i, err, strings.TrimSpace(string(output)))
+		}
+		chunks = append(chunks, audioChunk{path: path, offset: offset, duration: duration})
+	}
+	return chunks, nil
+}
+
+// requestTranscription uploads one file and returns the raw verbose_json body
+func (o *OpenAIAdapter) requestTranscription(ctx context.Context, settings OpenAISettings, path string, params map[string]interface{}) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audio: %w", err)
+	}
+	defer file.Close()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, fmt.Errorf("failed to read audio: %w", err)
+	}
+
+	task := o.GetStringParameter(params, "task")
+	fields := map[string]string{
+		"model":           settings.Model,
+		"response_format": "verbose_json",
+		"temperature":     strconv.FormatFloat(o.GetFloatParameter(params, "temperature"), 'f', -1, 64),
+	}
+	if prompt := o.GetStringParameter(params, "prompt"); prompt != "" {
+		fields["prompt"] = prompt
+	}
+	// The translations endpoint always outputs English and rejects these fields
+	if task != "translate" {
+		if language := o.GetStringParameter(params, "language"); language != "" && language != "auto" {
+			fields["language"] = language
+		}
+	}
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			return nil, err
+		}
+	}
+	if task != "translate" {
+		for _, granularity := range []string{"segment", "word"} {
+			if err := writer.WriteField("timestamp_granularities[]", granularity); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	endpoint := settings.BaseURL + "/audio/transcriptions"
+	if task == "translate" {
+		endpoint = settings.BaseURL + "/audio/translations"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+settings.APIKey)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		if ctx.Err() == context.Canceled {
+			return nil, fmt.Errorf("transcription was cancelled")
+		}
+		return nil, fmt.Errorf("request to transcription API failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transcription API response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, openAIStatusError(resp.StatusCode, respBody, settings.APIKey)
+	}
+	return respBody, nil
+}
+
+// openAIStatusError turns an error response into a message suitable for the job
+// record. The API key is scrubbed in case the server echoes it back.
+func openAIStatusError(status int, body []byte, apiKey string) error {
+	var apiErr openAIErrorResponse
+	message := strings.TrimSpace(string(body))
+	if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Error.Message != "" {
+		message = apiErr.Error.Message
+	}
+	if apiKey != "" {
+		message = strings.ReplaceAll(message, apiKey, "[REDACTED]")
+	}
+	if len(message) > 300 {
+		message = message[:300] + "..."
+	}
+
+	switch {
+	case status == http.StatusUnauthorized:
+		return fmt.Errorf("transcription API rejected the API key (401): %s", message)
+	case status == http.StatusPaymentRequired || apiErr.Error.Code == "insufficient_quota":
+		return fmt.Errorf("transcription API quota or billing limit reached (%d): %s", status, message)
+	case status == http.StatusTooManyRequests:
+		return fmt.Errorf("transcription API rate limit exceeded (429), try again later: %s", message)
+	case status == http.StatusRequestEntityTooLarge:
+		return fmt.Errorf("audio exceeds the transcription API upload limit (413): %s", message)
+	default:
+		return fmt.Errorf("transcription API returned status %d: %s", status, message)
+	}
+}
+
+// ParseOpenAIVerboseJSON maps a verbose_json transcription response into the
+// internal schema, shifting timestamps by offset seconds
+func ParseOpenAIVerboseJSON(data []byte, offset float64) (*interfaces.TranscriptResult, error) {
+	var resp openAIVerboseResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse transcription API response: %w", err)
+	}
+	if resp.Segments == nil && resp.Text == "" {
+		return nil, errors.New("transcription API response contained no transcript")
+	}
+
+	result := &interfaces.TranscriptResult{
+		Text:     resp.Text,
+		Language: resp.Language,
+	}
+	for _, seg := range resp.Segments {
+		result.Segments = append(result.Segments, interfaces.TranscriptSegment{
+			Start: seg.Start + offset,
+			End:   seg.End + offset,
+			Text:  strings.TrimSpace(seg.Text),
+		})
+	}
+	for _, word := range resp.Words {
+		result.WordSegments = append(result.WordSegments, interfaces.TranscriptWord{
+			Start: word.Start + offset,
+			End:   word.End + offset,
+			Word:  word.Word,
+		})
+	}
+	// Plain responses from some compatible servers carry no segments
+	if len(result.Segments) == 0 && resp.Text != "" {
+		result.Segments = []interfaces.TranscriptSegment{{
+			Start: offset,
+			End:   offset + resp.Duration,
+			Text:  strings.TrimSpace(resp.Text),
+		}}
+	}
+	return result, nil
+}
+
+// Register the OpenAI adapter
+func init() {
+	registry.RegisterTranscriptionAdapter("openai", NewOpenAIAdapter())
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"scriberr/internal/transcription/interfaces"
+	"scriberr/internal/transcription/process"
 	"scriberr/internal/transcription/registry"
 	"scriberr/pkg/logger"
 )
@@ -497,7 +498,7 @@ func (p *ParakeetAdapter) Transcribe(ctx context.Context, input interfaces.Audio
 
 	logger.Info("Executing Parakeet command", "args", strings.Join(args, " "))
 
-	output, err := cmd.CombinedOutput()
+	output, err := process.CombinedOutput(cmd)
 	if ctx.Err() == context.Canceled {
 		return nil, fmt.Errorf("transcription was cancelled")
 	}
@@ -606,7 +607,6 @@ func (p *ParakeetAdapter) parseResult(tempDir string, input interfaces.AudioInpu
 			Start: word.Start,
 			End:   word.End,
 			Word:  word.Word,
-			Score: 1.0, // Parakeet doesn't provide word-level scores
 		}
 	}
 
@@ -0,0 +1,102 @@
+package adapters
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// segmentTimestampPattern matches verbose segment lines such as
+	// "Transcript: [12.345 --> 15.678] text" (WhisperX) or
+	// "[00:12.345 --> 00:15.678] text" (faster-whisper / openai-whisper)
+	segmentTimestampPattern = regexp.MustCompile(`\[\s*([0-9:.]+)\s*-->\s*([0-9:.]+)\s*\]`)
+	// percentProgressPattern matches "Progress: 42.50%..." lines from --print_progress
+	percentProgressPattern = regexp.MustCompile(`Progress:\s*([0-9]+(?:\.[0-9]+)?)%`)
+)
+
+// ParseProgressLine extracts the number of audio seconds processed from a line of
+// transcription tool output. totalSeconds is used to convert percentage output.
+func ParseProgressLine(line string, totalSeconds float64) (float64, bool) {
+	if m := segmentTimestampPattern.FindStringSubmatch(line); m != nil {
+		if end, ok := parseTimestamp(m[2]); ok {
+			return end, true
+		}
+	}
+	if totalSeconds > 0 {
+		if m := percentProgressPattern.FindStringSubmatch(line); m != nil {
+			if pct, err := strconv.ParseFloat(m[1], 64); err == nil {
+				return totalSeconds * pct / 100, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// parseTimestamp parses "SS.mmm", "MM:SS.mmm" or "HH:MM:SS.mmm" into seconds
+func parseTimestamp(value string) (float64, bool) {
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0, false
+	}
+	seconds := 0.0
+	for _, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, false
+		}
+		seconds = seconds*60 + v
+	}
+	return seconds, true
+}
+
+// progressWriter scans process output line by line, reporting progress while
+// retaining the full output for error logging
+type progressWriter struct {
+	mu           sync.Mutex
+	output       bytes.Buffer
+	pending      []byte
+	totalSeconds float64
+	report       func(processedSeconds float64)
+}
+
+func newProgressWriter(totalSeconds float64, report func(processedSeconds float64)) *progressWriter {
+	return &progressWriter{totalSeconds: totalSeconds, report: report}
+}
+
+// Write implements io.Writer
+func (p *progressWriter) Write(data []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.output.Write(data)
+	p.pending = append(p.pending, data...)
+	for {
+		// tqdm-style output uses carriage returns instead of newlines
+		idx := bytes.IndexAny(p.pending, "\r\n")
+		if idx < 0 {
+			break
+		}
+		p.handleLine(string(p.pending[:idx]))
+		p.pending = p.pending[idx+1:]
+	}
+	return len(data), nil
+}
+
+func (p *progressWriter) handleLine(line string) {
+	if p.report == nil {
+		return
+	}
+	if processed, ok := ParseProgressLine(line, p.totalSeconds); ok {
+		p.report(processed)
+	}
+}
+
+// String returns everything written so far
+func (p *progressWriter) String() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.output.String()
+}
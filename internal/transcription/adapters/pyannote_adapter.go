@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"scriberr/internal/transcription/interfaces"
+	"scriberr/internal/transcription/process"
 	"scriberr/internal/transcription/registry"
 	"scriberr/pkg/logger"
 )
@@ -603,12 +604,15 @@ func (p *PyAnnoteAdapter) Diarize(ctx context.Context, input interfaces.AudioInp
 
 	logger.Info("Executing PyAnnote command", "args", strings.Join(args, " "))
 
-	output, err := cmd.CombinedOutput()
+	output, err := process.CombinedOutput(cmd)
 	if ctx.Err() == context.Canceled {
 		return nil, fmt.Errorf("diarization was cancelled")
 	}
 	if err != nil {
 		logger.Error("PyAnnote execution failed", "output", string(output), "error", err)
+		if diarizeErr := ClassifyDiarizationFailure(string(output)); diarizeErr != nil {
+			return nil, diarizeErr
+		}
 		return nil, fmt.Errorf("PyAnnote execution failed: %w", err)
 	}
 
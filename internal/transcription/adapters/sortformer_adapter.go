@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"scriberr/internal/transcription/interfaces"
+	"scriberr/internal/transcription/process"
 	"scriberr/internal/transcription/registry"
 	"scriberr/pkg/logger"
 )
@@ -674,7 +675,7 @@ func (s *SortformerAdapter) Diarize(ctx context.Context, input interfaces.AudioI
 
 	logger.Info("Executing Sortformer command", "args", strings.Join(args, " "))
 
-	output, err := cmd.CombinedOutput()
+	output, err := process.CombinedOutput(cmd)
 	if ctx.Err() == context.Canceled {
 		return nil, fmt.Errorf("diarization was cancelled")
 	}
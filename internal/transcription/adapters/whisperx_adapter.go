@@ -11,11 +11,26 @@ import (
 	"strings"
 	"time"
 
+	"scriberr/internal/gpu"
+	"scriberr/internal/models"
 	"scriberr/internal/transcription/interfaces"
+	"scriberr/internal/transcription/process"
 	"scriberr/internal/transcription/registry"
 	"scriberr/pkg/logger"
 )
 
+// whisperLanguages are the languages Whisper models transcribe
+var whisperLanguages = []string{
+	"en", "zh", "de", "es", "ru", "ko", "fr", "ja", "pt", "tr", "pl", "ca", "nl",
+	"ar", "sv", "it", "id", "hi", "fi", "vi", "he", "uk", "el", "ms", "cs", "ro",
+	"da", "hu", "ta", "no", "th", "ur", "hr", "bg", "lt", "la", "mi", "ml", "cy",
+	"sk", "te", "fa", "lv", "bn", "sr", "az", "sl", "kn", "et", "mk", "br", "eu",
+	"is", "hy", "ne", "mn", "bs", "kk", "sq", "sw", "gl", "mr", "pa", "si", "km",
+	"sn", "yo", "so", "af", "oc", "ka", "be", "tg", "sd", "gu", "am", "yi", "lo",
+	"uz", "fo", "ht", "ps", "tk", "nn", "mt", "sa", "lb", "my", "bo", "tl", "mg",
+	"as", "tt", "haw", "ln", "ha", "ba", "jw", "su", "auto",
+}
+
 // WhisperXAdapter implements the TranscriptionAdapter interface for WhisperX
 type WhisperXAdapter struct {
 	*BaseAdapter
@@ -27,24 +42,15 @@ func NewWhisperXAdapter() *WhisperXAdapter {
 	envPath := "whisperx-env"
 
 	capabilities := interfaces.ModelCapabilities{
-		ModelID:     "whisperx",
-		ModelFamily: "whisper",
-		DisplayName: "WhisperX",
-		Description: "OpenAI Whisper with speaker diarization and word-level timestamps",
-		Version:     "3.0.0",
-		SupportedLanguages: []string{
-			"en", "zh", "de", "es", "ru", "ko", "fr", "ja", "pt", "tr", "pl", "ca", "nl",
-			"ar", "sv", "it", "id", "hi", "fi", "vi", "he", "uk", "el", "ms", "cs", "ro",
-			"da", "hu", "ta", "no", "th", "ur", "hr", "bg", "lt", "la", "mi", "ml", "cy",
-			"sk", "te", "fa", "lv", "bn", "sr", "az", "sl", "kn", "et", "mk", "br", "eu",
-			"is", "hy", "ne", "mn", "bs", "kk", "sq", "sw", "gl", "mr", "pa", "si", "km",
-			"sn", "yo", "so", "af", "oc", "ka", "be", "tg", "sd", "gu", "am", "yi", "lo",
-			"uz", "fo", "ht", "ps", "tk", "nn", "mt", "sa", "lb", "my", "bo", "tl", "mg",
-			"as", "tt", "haw", "ln", "ha", "ba", "jw", "su", "auto",
-		},
-		SupportedFormats:  []string{"wav", "mp3", "flac", "m4a", "ogg", "wma"},
-		RequiresGPU:       false, // Optional GPU support
-		MemoryRequirement: 2048,  // 2GB base requirement
+		ModelID:            "whisperx",
+		ModelFamily:        "whisper",
+		DisplayName:        "WhisperX",
+		Description:        "OpenAI Whisper with speaker diarization and word-level timestamps",
+		Version:            "3.0.0",
+		SupportedLanguages: whisperLanguages,
+		SupportedFormats:   []string{"wav", "mp3", "flac", "m4a", "ogg", "wma"},
+		RequiresGPU:        false, // Optional GPU support
+		MemoryRequirement:  2048,  // 2GB base requirement
 		Features: map[string]bool{
 			"timestamps":         true,
 			"word_level":         true,
@@ -97,10 +103,10 @@ func NewWhisperXAdapter() *WhisperXAdapter {
 			Name:        "batch_size",
 			Type:        "int",
 			Required:    false,
-			Default:     8,
-			Min:         &[]float64{1}[0],
-			Max:         &[]float64{64}[0],
-			Description: "Batch size for processing",
+			Default:     16,
+			Min:         &[]float64{models.MinBatchSize}[0],
+			Max:         &[]float64{models.MaxBatchSize}[0],
+			Description: "Batch size for processing; use 8 on MPS and 4 on the CPU to save memory",
 			Group:       "advanced",
 		},
 		{
@@ -108,8 +114,8 @@ func NewWhisperXAdapter() *WhisperXAdapter {
 			Type:        "string",
 			Required:    false,
 			Default:     "float32",
-			Options:     []string{"float16", "float32", "int8"},
-			Description: "Computation precision",
+			Options:     models.ComputeTypes,
+			Description: "Computation precision; the int8 types use less memory but may reduce accuracy",
 			Group:       "advanced",
 		},
 		{
@@ -118,7 +124,7 @@ func NewWhisperXAdapter() *WhisperXAdapter {
 			Required:    false,
 			Default:     0,
 			Min:         &[]float64{0}[0],
-			Max:         &[]float64{32}[0],
+			Max:         &[]float64{models.MaxThreads}[0],
 			Description: "Number of CPU threads (0 = auto)",
 			Group:       "advanced",
 		},
@@ -200,6 +206,16 @@ func NewWhisperXAdapter() *WhisperXAdapter {
 			Description: "Sampling temperature",
 			Group:       "quality",
 		},
+		{
+			Name:        "temperatures",
+			Type:        "[]float",
+			Required:    false,
+			Default:     nil,
+			Min:         &[]float64{0.0}[0],
+			Max:         &[]float64{1.0}[0],
+			Description: "Temperature fallback schedule, rising in equal steps",
+			Group:       "quality",
+		},
 		{
 			Name:        "best_of",
 			Type:        "int",
@@ -230,6 +246,14 @@ func NewWhisperXAdapter() *WhisperXAdapter {
 			Description: "Beam search patience",
 			Group:       "quality",
 		},
+		{
+			Name:        "initial_prompt",
+			Type:        "string",
+			Required:    false,
+			Default:     nil,
+			Description: "Text that primes the model with names and terminology",
+			Group:       "quality",
+		},
 
 		// VAD settings
 		{
@@ -248,7 +272,7 @@ func NewWhisperXAdapter() *WhisperXAdapter {
 			Default:     0.5,
 			Min:         &[]float64{0.0}[0],
 			Max:         &[]float64{1.0}[0],
-			Description: "VAD onset threshold",
+			Description: "VAD onset threshold; lower is more sensitive and picks up quieter speech",
 			Group:       "advanced",
 		},
 		{
@@ -258,7 +282,7 @@ func NewWhisperXAdapter() *WhisperXAdapter {
 			Default:     0.363,
 			Min:         &[]float64{0.0}[0],
 			Max:         &[]float64{1.0}[0],
-			Description: "VAD offset threshold",
+			Description: "VAD offset threshold; lower keeps speech segments open through short pauses",
 			Group:       "advanced",
 		},
 	}
@@ -335,6 +359,12 @@ func (w *WhisperXAdapter) cloneWhisperX() error {
 
 // updateWhisperXDependencies modifies WhisperX pyproject.toml
 func (w *WhisperXAdapter) updateWhisperXDependencies(whisperxPath string) error {
+	return PatchWhisperXDependencies(whisperxPath)
+}
+
+// PatchWhisperXDependencies pins the WhisperX project's dependencies to the
+// versions Scriberr runs with
+func PatchWhisperXDependencies(whisperxPath string) error {
 	pyprojectPath := filepath.Join(whisperxPath, "pyproject.toml")
 
 	data, err := os.ReadFile(pyprojectPath)
@@ -388,36 +418,54 @@ func (w *WhisperXAdapter) Transcribe(ctx context.Context, input interfaces.Audio
 		return nil, fmt.Errorf("invalid parameters: %w", err)
 	}
 
-	// Create temporary directory
-	tempDir, err := w.CreateTempDirectory(procCtx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	// WhisperX writes to the job's output directory, which the caller cleans
+	// up once the result is imported, or to a temporary one without it
+	outputDir := procCtx.OutputDirectory
+	if outputDir == "" {
+		tempDir, err := w.CreateTempDirectory(procCtx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temp directory: %w", err)
+		}
+		defer w.CleanupTempDirectory(tempDir)
+		outputDir = tempDir
+	} else if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
 	}
-	defer w.CleanupTempDirectory(tempDir)
+
+	params = w.fallBackToCPUIfNeeded(ctx, params)
 
 	// Build WhisperX command
-	args, err := w.buildWhisperXArgs(input, params, tempDir)
+	args, err := w.buildWhisperXArgs(input, params, outputDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build command: %w", err)
 	}
 
-	// Execute WhisperX
+	// Execute WhisperX, streaming output so segment timestamps can drive progress
 	cmd := exec.CommandContext(ctx, "uv", args...)
 	cmd.Env = append(os.Environ(), "PYTHONUNBUFFERED=1")
+	output := newProgressWriter(input.Duration.Seconds(), procCtx.ReportProgress)
+	cmd.Stdout = output
+	cmd.Stderr = output
 
-	logger.Info("Executing WhisperX command", "args", strings.Join(args, " "))
+	logModelCache(w.GetStringParameter(params, "model_dir"), w.GetStringParameter(params, "model"))
+	logger.Info("Executing WhisperX command", "args", strings.Join(redactArgs(args), " "))
 
-	output, err := cmd.CombinedOutput()
+	err = process.Run(cmd)
 	if ctx.Err() == context.Canceled {
 		return nil, fmt.Errorf("transcription was cancelled")
 	}
 	if err != nil {
-		logger.Error("WhisperX execution failed", "output", string(output), "error", err)
+		logger.Error("WhisperX execution failed", "output", output.String(), "error", err)
+		if w.GetBoolParameter(params, "diarize") {
+			if diarizeErr := ClassifyDiarizationFailure(output.String()); diarizeErr != nil {
+				return nil, diarizeErr
+			}
+		}
 		return nil, fmt.Errorf("WhisperX execution failed: %w", err)
 	}
 
 	// Parse result
-	result, err := w.parseResult(tempDir, input, params)
+	result, err := w.parseResult(outputDir, input, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse result: %w", err)
 	}
@@ -434,6 +482,111 @@ func (w *WhisperXAdapter) Transcribe(ctx context.Context, input interfaces.Audio
 	return result, nil
 }
 
+// languageDetectionSeconds is how much audio from the start of a file the
+// language detection pass listens to; Whisper detects from one 30 second window
+const languageDetectionSeconds = 30
+
+// detectLanguageScript runs Whisper's language detection on the start of a
+// file with the job's model and prints the result as JSON. Transcribing
+// returns the detected language before any segment is decoded, so the
+// segments are never consumed.
+const detectLanguageScript = `
+import json, sys
+from faster_whisper import WhisperModel
+from whisperx.audio import SAMPLE_RATE, load_audio
+
+audio = load_audio(sys.argv[1])[: SAMPLE_RATE * int(sys.argv[2])]
+model = WhisperModel(sys.argv[3], device=sys.argv[4], device_index=int(sys.argv[5]), compute_type=sys.argv[6], download_root=sys.argv[7] or None)
+_, info = model.transcribe(audio)
+print(json.dumps({"language": info.language, "confidence": info.language_probability}))
+`
+
+// DetectLanguage runs Whisper's language detection on the first 30 seconds
+// of the audio with the model the job transcribes with
+func (w *WhisperXAdapter) DetectLanguage(ctx context.Context, input interfaces.AudioInput, params map[string]interface{}) (*interfaces.LanguageDetection, error) {
+	params = w.fallBackToCPUIfNeeded(ctx, params)
+	device := w.GetStringParameter(params, "device")
+	computeType := w.GetStringParameter(params, "compute_type")
+	// faster-whisper runs on CUDA or the CPU only
+	if device != "cuda" && device != "auto" {
+		device = "cpu"
+		if computeType == "float16" {
+			computeType = "int8"
+		}
+	}
+
+	args := []string{
+		"run", "--native-tls", "--project", filepath.Join(w.envPath, "WhisperX"), "python", "-c", detectLanguageScript,
+		input.FilePath,
+		strconv.Itoa(languageDetectionSeconds),
+		w.GetStringParameter(params, "model"),
+		device,
+		strconv.Itoa(w.GetIntParameter(params, "device_index")),
+		computeType,
+		w.GetStringParameter(params, "model_dir"),
+	}
+	cmd := exec.CommandContext(ctx, "uv", args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := process.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("language detection failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseLanguageDetection(output)
+}
+
+// parseLanguageDetection reads the JSON line the detection script prints
+// last; libraries may log before it
+func parseLanguageDetection(output []byte) (*interfaces.LanguageDetection, error) {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	var detection interfaces.LanguageDetection
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &detection); err != nil {
+		return nil, fmt.Errorf("failed to parse language detection output: %w", err)
+	}
+	detection.Language = strings.ToLower(strings.TrimSpace(detection.Language))
+	if detection.Language == "" {
+		return nil, fmt.Errorf("language detection reported no language")
+	}
+	return &detection, nil
+}
+
+// fallBackToCPUIfNeeded switches a GPU job to the CPU when the selected GPU has
+// less free memory than the model needs, rather than letting it fail with OOM
+func (w *WhisperXAdapter) fallBackToCPUIfNeeded(ctx context.Context, params map[string]interface{}) map[string]interface{} {
+	device := w.GetStringParameter(params, "device")
+	if device != "cuda" && device != "auto" {
+		return params
+	}
+
+	model := w.GetStringParameter(params, "model")
+	fits, required, memory, err := gpu.CheckFits(ctx, model, w.GetIntParameter(params, "device_index"))
+	if err != nil {
+		logger.Debug("Could not check GPU memory, keeping device", "device", device, "error", err)
+		return params
+	}
+	if fits {
+		return params
+	}
+
+	logger.Warn("Not enough free GPU memory for model, falling back to CPU",
+		"model", model,
+		"required_mb", required,
+		"free_mb", memory.FreeMB,
+		"total_mb", memory.TotalMB,
+		"gpu", memory.Index)
+
+	fallback := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		fallback[key] = value
+	}
+	fallback["device"] = "cpu"
+	// CPUs do not support float16 computation
+	if w.GetStringParameter(params, "compute_type") == "float16" {
+		fallback["compute_type"] = "int8"
+	}
+	return fallback
+}
+
 // buildWhisperXArgs builds the command arguments for WhisperX
 func (w *WhisperXAdapter) buildWhisperXArgs(input interfaces.AudioInput, params map[string]interface{}, outputDir string) ([]string, error) {
 	whisperxPath := filepath.Join(w.envPath, "WhisperX")
@@ -446,6 +599,9 @@ func (w *WhisperXAdapter) buildWhisperXArgs(input interfaces.AudioInput, params
 
 	// Core parameters
 	args = append(args, "--model", w.GetStringParameter(params, "model"))
+	if modelDir := w.GetStringParameter(params, "model_dir"); modelDir != "" {
+		args = append(args, "--model_dir", modelDir)
+	}
 	args = append(args, "--device", w.GetStringParameter(params, "device"))
 	args = append(args, "--device_index", strconv.Itoa(w.GetIntParameter(params, "device_index")))
 	args = append(args, "--batch_size", strconv.Itoa(w.GetIntParameter(params, "batch_size")))
@@ -459,9 +615,14 @@ func (w *WhisperXAdapter) buildWhisperXArgs(input interfaces.AudioInput, params
 	args = append(args, "--output_format", "all")
 	args = append(args, "--verbose", "True")
 
-	// Task and language
+	// Task and language. The aligner aligns text in the audio's language, so
+	// English translations are not aligned to words.
 	args = append(args, "--task", w.GetStringParameter(params, "task"))
-	if language := w.GetStringParameter(params, "language"); language != "" {
+	if w.GetStringParameter(params, "task") == models.TaskTranslate {
+		args = append(args, "--no_align")
+	}
+	// WhisperX detects the language when none is given; it rejects "auto"
+	if language := w.GetStringParameter(params, "language"); language != "" && language != "auto" {
 		args = append(args, "--language", language)
 	}
 
@@ -486,17 +647,21 @@ func (w *WhisperXAdapter) buildWhisperXArgs(input interfaces.AudioInput, params
 		if maxSpeakers := w.GetIntParameter(params, "max_speakers"); maxSpeakers > 0 {
 			args = append(args, "--max_speakers", strconv.Itoa(maxSpeakers))
 		}
+
+		// HuggingFace token for the gated pyannote models
+		if hfToken := w.GetStringParameter(params, "hf_token"); hfToken != "" {
+			args = append(args, "--hf_token", hfToken)
+		}
 	}
 
 	// Quality settings
-	args = append(args, "--temperature", fmt.Sprintf("%.2f", w.GetFloatParameter(params, "temperature")))
+	args = append(args, temperatureArgs(w.GetFloatSliceParameter(params, "temperatures"), w.GetFloatParameter(params, "temperature"))...)
 	args = append(args, "--best_of", strconv.Itoa(w.GetIntParameter(params, "best_of")))
 	args = append(args, "--beam_size", strconv.Itoa(w.GetIntParameter(params, "beam_size")))
 	args = append(args, "--patience", fmt.Sprintf("%.2f", w.GetFloatParameter(params, "patience")))
-
-	// HuggingFace token
-	if hfToken := w.GetStringParameter(params, "hf_token"); hfToken != "" {
-		args = append(args, "--hf_token", hfToken)
+	if prompt := models.SanitizeInitialPrompt(w.GetStringParameter(params, "initial_prompt")); prompt != "" {
+		logger.Debug("Using initial prompt", "prompt", prompt)
+		args = append(args, "--initial_prompt", prompt)
 	}
 
 	// Disable print progress for cleaner output
@@ -505,6 +670,48 @@ func (w *WhisperXAdapter) buildWhisperXArgs(input interfaces.AudioInput, params
 	return args, nil
 }
 
+// redactArgs masks the HuggingFace token in command arguments for logging
+func redactArgs(args []string) []string {
+	redacted := append([]string(nil), args...)
+	for i := 0; i+1 < len(redacted); i++ {
+		if redacted[i] == "--hf_token" {
+			redacted[i+1] = "***"
+		}
+	}
+	return redacted
+}
+
+// logModelCache logs whether WhisperX will find models in modelDir or has
+// to download the model first
+func logModelCache(modelDir, model string) {
+	if modelDir == "" {
+		return
+	}
+	entries, err := os.ReadDir(modelDir)
+	if err == nil && len(entries) > 0 {
+		logger.Info("Model cache hit", "model_dir", modelDir, "model", model)
+		return
+	}
+	logger.Info("Model cache miss, WhisperX will download the model", "model_dir", modelDir, "model", model)
+}
+
+// temperatureArgs passes a temperature schedule to WhisperX, which takes it
+// as a starting temperature and the increment tried on each fallback up to
+// 1.0. Without a schedule only the single temperature is used.
+func temperatureArgs(temperatures []float64, temperature float64) []string {
+	if len(temperatures) == 0 {
+		return []string{"--temperature", fmt.Sprintf("%.2f", temperature)}
+	}
+	increment := "None"
+	if len(temperatures) > 1 {
+		increment = fmt.Sprintf("%.2f", temperatures[1]-temperatures[0])
+	}
+	return []string{
+		"--temperature", fmt.Sprintf("%.2f", temperatures[0]),
+		"--temperature_increment_on_fallback", increment,
+	}
+}
+
 // parseResult parses the WhisperX output files
 func (w *WhisperXAdapter) parseResult(outputDir string, input interfaces.AudioInput, params map[string]interface{}) (*interfaces.TranscriptResult, error) {
 	// Find JSON result files
@@ -525,67 +732,10 @@ func (w *WhisperXAdapter) parseResult(outputDir string, input interfaces.AudioIn
 		return nil, fmt.Errorf("failed to read result file: %w", err)
 	}
 
-	// Parse WhisperX JSON format
-	var whisperxResult struct {
-		Segments []struct {
-			Start   float64 `json:"start"`
-			End     float64 `json:"end"`
-			Text    string  `json:"text"`
-			Speaker *string `json:"speaker,omitempty"`
-		} `json:"segments"`
-		Word []struct {
-			Start   float64 `json:"start"`
-			End     float64 `json:"end"`
-			Word    string  `json:"word"`
-			Score   float64 `json:"score"`
-			Speaker *string `json:"speaker,omitempty"`
-		} `json:"word_segments,omitempty"`
-		Language string `json:"language"`
-		Text     string `json:"text,omitempty"`
-	}
-
-	if err := json.Unmarshal(data, &whisperxResult); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON result: %w", err)
-	}
-
-	// Convert to standard format
-	result := &interfaces.TranscriptResult{
-		Language:     whisperxResult.Language,
-		Segments:     make([]interfaces.TranscriptSegment, len(whisperxResult.Segments)),
-		WordSegments: make([]interfaces.TranscriptWord, len(whisperxResult.Word)),
-		Confidence:   0.0, // WhisperX doesn't provide overall confidence
-	}
-
-	// Convert segments
-	var textParts []string
-	for i, seg := range whisperxResult.Segments {
-		result.Segments[i] = interfaces.TranscriptSegment{
-			Start:   seg.Start,
-			End:     seg.End,
-			Text:    seg.Text,
-			Speaker: seg.Speaker,
-		}
-		textParts = append(textParts, seg.Text)
-	}
-
-	// Convert words
-	for i, word := range whisperxResult.Word {
-		result.WordSegments[i] = interfaces.TranscriptWord{
-			Start:   word.Start,
-			End:     word.End,
-			Word:    word.Word,
-			Score:   word.Score,
-			Speaker: word.Speaker,
-		}
-	}
-
-	// Set full text
-	if whisperxResult.Text != "" {
-		result.Text = whisperxResult.Text
-	} else {
-		result.Text = strings.Join(textParts, " ")
+	result, err := ParseWhisperXOutput(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(resultFile), err)
 	}
-
 	return result, nil
 }
 
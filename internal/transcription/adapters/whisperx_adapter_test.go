@@ -0,0 +1,273 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scriberr/internal/transcription/interfaces"
+)
+
+func TestBuildWhisperXArgsDecoding(t *testing.T) {
+	adapter := NewWhisperXAdapter()
+	input := interfaces.AudioInput{FilePath: "/tmp/audio.wav"}
+
+	args, err := adapter.buildWhisperXArgs(input, map[string]interface{}{
+		"beam_size":    3,
+		"temperatures": []float64{0.1, 0.4, 0.7, 1.0},
+	}, "/tmp/out")
+	if err != nil {
+		t.Fatalf("buildWhisperXArgs failed: %v", err)
+	}
+	command := strings.Join(args, " ")
+	for _, flag := range []string{"--beam_size 3", "--temperature 0.10", "--temperature_increment_on_fallback 0.30"} {
+		if !strings.Contains(command, flag) {
+			t.Errorf("Expected %q in %s", flag, command)
+		}
+	}
+
+	// Temperatures from a stored job decode as []interface{}
+	args, _ = adapter.buildWhisperXArgs(input, map[string]interface{}{
+		"temperatures": []interface{}{0.5},
+	}, "/tmp/out")
+	if command := strings.Join(args, " "); !strings.Contains(command, "--temperature 0.50 --temperature_increment_on_fallback None") {
+		t.Errorf("Expected a single temperature without fallback, got %s", command)
+	}
+
+	// Without a schedule the single temperature is passed on its own
+	args, _ = adapter.buildWhisperXArgs(input, map[string]interface{}{"temperature": 0.2}, "/tmp/out")
+	if command := strings.Join(args, " "); !strings.Contains(command, "--temperature 0.20") || strings.Contains(command, "--temperature_increment_on_fallback") {
+		t.Errorf("Expected only --temperature, got %s", command)
+	}
+}
+
+func TestValidateTemperatures(t *testing.T) {
+	adapter := NewWhisperXAdapter()
+	if err := adapter.ValidateParameters(map[string]interface{}{"temperatures": []float64{0, 0.5, 1}}); err != nil {
+		t.Errorf("Valid temperatures failed validation: %v", err)
+	}
+	if err := adapter.ValidateParameters(map[string]interface{}{"temperatures": []float64{0, 1.5}}); err == nil {
+		t.Error("Expected temperatures above 1.0 to fail validation")
+	}
+}
+
+func TestBuildWhisperXArgsInitialPrompt(t *testing.T) {
+	adapter := NewWhisperXAdapter()
+	input := interfaces.AudioInput{FilePath: "/tmp/audio.wav"}
+
+	args, err := adapter.buildWhisperXArgs(input, map[string]interface{}{
+		"initial_prompt": "Kubernetes, etcd and\n`rm -rf /`; O'Neil $HOME | \"quoted\"",
+	}, "/tmp/out")
+	if err != nil {
+		t.Fatalf("buildWhisperXArgs failed: %v", err)
+	}
+	var prompt string
+	for i, arg := range args {
+		if arg == "--initial_prompt" && i+1 < len(args) {
+			prompt = args[i+1]
+		}
+	}
+	if want := "Kubernetes, etcd and rm -rf / O'Neil HOME quoted"; prompt != want {
+		t.Errorf("Expected the sanitized prompt %q as one argument, got %q", want, prompt)
+	}
+
+	args, _ = adapter.buildWhisperXArgs(input, map[string]interface{}{"initial_prompt": " \n\t"}, "/tmp/out")
+	if strings.Contains(strings.Join(args, " "), "--initial_prompt") {
+		t.Error("Expected no --initial_prompt for a blank prompt")
+	}
+}
+
+func TestBuildWhisperXArgsLanguage(t *testing.T) {
+	adapter := NewWhisperXAdapter()
+	input := interfaces.AudioInput{FilePath: "/tmp/audio.wav"}
+
+	for language, want := range map[string]string{"": "", "auto": "", "fr": "--language fr"} {
+		args, err := adapter.buildWhisperXArgs(input, map[string]interface{}{"language": language}, "/tmp/out")
+		if err != nil {
+			t.Fatalf("buildWhisperXArgs failed: %v", err)
+		}
+		command := strings.Join(args, " ")
+		if want == "" && strings.Contains(command, "--language") {
+			t.Errorf("Expected no --language for %q so WhisperX detects it, got %s", language, command)
+		}
+		if want != "" && !strings.Contains(command, want) {
+			t.Errorf("Expected %q in %s", want, command)
+		}
+	}
+}
+
+func TestBuildWhisperXArgsModelDir(t *testing.T) {
+	adapter := NewWhisperXAdapter()
+	input := interfaces.AudioInput{FilePath: "/tmp/audio.wav"}
+
+	args, err := adapter.buildWhisperXArgs(input, map[string]interface{}{"model": "small", "model_dir": "data/models"}, "/tmp/out")
+	if err != nil {
+		t.Fatalf("buildWhisperXArgs failed: %v", err)
+	}
+	if command := strings.Join(args, " "); !strings.Contains(command, "--model_dir data/models") {
+		t.Errorf("Expected --model_dir data/models in %s", command)
+	}
+
+	args, _ = adapter.buildWhisperXArgs(input, map[string]interface{}{"model": "small"}, "/tmp/out")
+	if strings.Contains(strings.Join(args, " "), "--model_dir") {
+		t.Error("Expected no --model_dir without a model directory")
+	}
+}
+
+func TestBuildWhisperXArgsThreads(t *testing.T) {
+	adapter := NewWhisperXAdapter()
+	input := interfaces.AudioInput{FilePath: "/tmp/audio.wav"}
+
+	args, err := adapter.buildWhisperXArgs(input, map[string]interface{}{"model": "small", "threads": 8}, "/tmp/out")
+	if err != nil {
+		t.Fatalf("buildWhisperXArgs failed: %v", err)
+	}
+	if command := strings.Join(args, " "); !strings.Contains(command, "--threads 8") {
+		t.Errorf("Expected --threads 8 in %s", command)
+	}
+
+	// Zero is auto and leaves the choice to WhisperX
+	args, _ = adapter.buildWhisperXArgs(input, map[string]interface{}{"model": "small", "threads": 0}, "/tmp/out")
+	if strings.Contains(strings.Join(args, " "), "--threads") {
+		t.Error("Expected no --threads when set to auto")
+	}
+}
+
+func TestBuildWhisperXArgsHuggingFaceToken(t *testing.T) {
+	adapter := NewWhisperXAdapter()
+	input := interfaces.AudioInput{FilePath: "/tmp/audio.wav"}
+
+	args, err := adapter.buildWhisperXArgs(input, map[string]interface{}{"diarize": true, "hf_token": "hf_secret"}, "/tmp/out")
+	if err != nil {
+		t.Fatalf("buildWhisperXArgs failed: %v", err)
+	}
+	if command := strings.Join(args, " "); !strings.Contains(command, "--hf_token hf_secret") {
+		t.Errorf("Expected --hf_token hf_secret in %s", command)
+	}
+	if logged := strings.Join(redactArgs(args), " "); strings.Contains(logged, "hf_secret") {
+		t.Errorf("Expected the token to be masked for logging, got %s", logged)
+	}
+
+	// The token is only needed to download the diarization models
+	args, _ = adapter.buildWhisperXArgs(input, map[string]interface{}{"hf_token": "hf_secret"}, "/tmp/out")
+	if strings.Contains(strings.Join(args, " "), "--hf_token") {
+		t.Error("Expected no --hf_token without diarization")
+	}
+}
+
+func TestParseWhisperXResultLanguage(t *testing.T) {
+	outputDir := t.TempDir()
+	output := `{"segments":[{"start":0,"end":1.5,"text":" Bonjour à tous"}],"language":"fr"}`
+	if err := os.WriteFile(filepath.Join(outputDir, "audio.json"), []byte(output), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewWhisperXAdapter().parseResult(outputDir, interfaces.AudioInput{FilePath: "/tmp/audio.wav"}, nil)
+	if err != nil {
+		t.Fatalf("parseResult failed: %v", err)
+	}
+	if result.Language != "fr" {
+		t.Errorf("Expected the detected language fr, got %q", result.Language)
+	}
+}
+
+func TestParseLanguageDetection(t *testing.T) {
+	output := "Downloading model...\n{\"language\": \"NB\", \"confidence\": 0.94}\n"
+	detection, err := parseLanguageDetection([]byte(output))
+	if err != nil {
+		t.Fatalf("parseLanguageDetection failed: %v", err)
+	}
+	if detection.Language != "nb" || detection.Confidence != 0.94 {
+		t.Errorf("Expected nb with confidence 0.94, got %s with %.2f", detection.Language, detection.Confidence)
+	}
+
+	for _, output := range []string{"", "Traceback (most recent call last):", `{"language": "", "confidence": 0.2}`} {
+		if _, err := parseLanguageDetection([]byte(output)); err == nil {
+			t.Errorf("Expected an error for output %q", output)
+		}
+	}
+}
+
+func TestBuildWhisperXArgsSpeakers(t *testing.T) {
+	adapter := NewWhisperXAdapter()
+	input := interfaces.AudioInput{FilePath: "/tmp/audio.wav"}
+
+	// Only the bound that is set is passed; WhisperX picks the other
+	args, err := adapter.buildWhisperXArgs(input, map[string]interface{}{"diarize": true, "max_speakers": 3}, "/tmp/out")
+	if err != nil {
+		t.Fatalf("buildWhisperXArgs failed: %v", err)
+	}
+	command := strings.Join(args, " ")
+	if !strings.Contains(command, "--max_speakers 3") {
+		t.Errorf("Expected --max_speakers 3 in %s", command)
+	}
+	if strings.Contains(command, "--min_speakers") {
+		t.Errorf("Expected no --min_speakers, got %s", command)
+	}
+
+	args, _ = adapter.buildWhisperXArgs(input, map[string]interface{}{"diarize": true, "min_speakers": 2, "max_speakers": 4}, "/tmp/out")
+	if command := strings.Join(args, " "); !strings.Contains(command, "--min_speakers 2") || !strings.Contains(command, "--max_speakers 4") {
+		t.Errorf("Expected both speaker bounds in %s", command)
+	}
+
+	// Speaker counts mean nothing without diarization
+	args, _ = adapter.buildWhisperXArgs(input, map[string]interface{}{"max_speakers": 3}, "/tmp/out")
+	if strings.Contains(strings.Join(args, " "), "_speakers") {
+		t.Error("Expected no speaker bounds without diarization")
+	}
+}
+
+func TestBuildWhisperXArgsVAD(t *testing.T) {
+	adapter := NewWhisperXAdapter()
+	input := interfaces.AudioInput{FilePath: "/tmp/audio.wav"}
+
+	args, err := adapter.buildWhisperXArgs(input, map[string]interface{}{"vad_onset": 0.25, "vad_offset": 0.1}, "/tmp/out")
+	if err != nil {
+		t.Fatalf("buildWhisperXArgs failed: %v", err)
+	}
+	command := strings.Join(args, " ")
+	if !strings.Contains(command, "--vad_onset 0.250") || !strings.Contains(command, "--vad_offset 0.100") {
+		t.Errorf("Expected the VAD thresholds in %s", command)
+	}
+
+	// Unset thresholds use the WhisperX defaults
+	args, _ = adapter.buildWhisperXArgs(input, map[string]interface{}{}, "/tmp/out")
+	command = strings.Join(args, " ")
+	if !strings.Contains(command, "--vad_onset 0.500") || !strings.Contains(command, "--vad_offset 0.363") {
+		t.Errorf("Expected the default VAD thresholds in %s", command)
+	}
+}
+
+func TestBuildWhisperXArgsTask(t *testing.T) {
+	adapter := NewWhisperXAdapter()
+	input := interfaces.AudioInput{FilePath: "/tmp/audio.wav"}
+
+	for _, task := range []string{"transcribe", "translate"} {
+		args, err := adapter.buildWhisperXArgs(input, map[string]interface{}{"task": task, "language": "de"}, "/tmp/out")
+		if err != nil {
+			t.Fatalf("buildWhisperXArgs failed: %v", err)
+		}
+		command := strings.Join(args, " ")
+		if !strings.Contains(command, "--task "+task) {
+			t.Errorf("Expected --task %s in %s", task, command)
+		}
+		// Translations are not aligned with the source language's aligner
+		if aligned := !strings.Contains(command, "--no_align"); aligned != (task == "transcribe") {
+			t.Errorf("Expected alignment only when transcribing, got %s", command)
+		}
+	}
+}
+
+func TestBuildWhisperXArgsOutputDir(t *testing.T) {
+	adapter := NewWhisperXAdapter()
+	input := interfaces.AudioInput{FilePath: "/tmp/audio.wav"}
+
+	args, err := adapter.buildWhisperXArgs(input, map[string]interface{}{"model": "small"}, "data/output/job-1")
+	if err != nil {
+		t.Fatalf("buildWhisperXArgs failed: %v", err)
+	}
+	if command := strings.Join(args, " "); !strings.Contains(command, "--output_dir data/output/job-1") {
+		t.Errorf("Expected --output_dir data/output/job-1 in %s", command)
+	}
+}
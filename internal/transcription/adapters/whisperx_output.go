@@ -0,0 +1,105 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"scriberr/internal/transcription/interfaces"
+)
+
+// WhisperX output schema versions
+const (
+	// WhisperXSchemaV1 lists aligned words once, in a top-level word_segments array
+	WhisperXSchemaV1 = "whisperx-v1"
+	// WhisperXSchemaV2 nests aligned words in the segment they belong to
+	WhisperXSchemaV2 = "whisperx-v2"
+)
+
+type whisperXWord struct {
+	Start   *float64 `json:"start"`
+	End     *float64 `json:"end"`
+	Word    string   `json:"word"`
+	Score   float64  `json:"score"`
+	Speaker *string  `json:"speaker,omitempty"`
+}
+
+type whisperXOutput struct {
+	Segments []struct {
+		Start   float64        `json:"start"`
+		End     float64        `json:"end"`
+		Text    string         `json:"text"`
+		Speaker *string        `json:"speaker,omitempty"`
+		Words   []whisperXWord `json:"words,omitempty"`
+	} `json:"segments"`
+	WordSegments []whisperXWord `json:"word_segments,omitempty"`
+	Language     string         `json:"language"`
+	Text         string         `json:"text,omitempty"`
+}
+
+// ParseWhisperXOutput parses a WhisperX JSON result in any of the schemas
+// WhisperX has written. Outputs with a top-level word_segments array are
+// read as WhisperXSchemaV1, and outputs carrying words only inside their
+// segments as WhisperXSchemaV2. Either way the result holds the segments
+// without words and every aligned word in WordSegments, which is how the
+// rest of the pipeline expects adapter results.
+func ParseWhisperXOutput(data []byte) (*interfaces.TranscriptResult, error) {
+	var output whisperXOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON result: %w", err)
+	}
+	if output.Segments == nil {
+		return nil, fmt.Errorf("result has no segments")
+	}
+
+	result := &interfaces.TranscriptResult{
+		Language:      output.Language,
+		Segments:      make([]interfaces.TranscriptSegment, len(output.Segments)),
+		Confidence:    0.0, // WhisperX doesn't provide overall confidence
+		SchemaVersion: WhisperXSchemaV1,
+	}
+
+	words := output.WordSegments
+	if words == nil {
+		for _, seg := range output.Segments {
+			if seg.Words != nil {
+				result.SchemaVersion = WhisperXSchemaV2
+			}
+			words = append(words, seg.Words...)
+		}
+	}
+
+	var textParts []string
+	for i, seg := range output.Segments {
+		result.Segments[i] = interfaces.TranscriptSegment{
+			Start:   seg.Start,
+			End:     seg.End,
+			Text:    seg.Text,
+			Speaker: seg.Speaker,
+		}
+		textParts = append(textParts, seg.Text)
+	}
+
+	// Tokens the aligner could not place, such as numerals, come without
+	// times and are left out rather than given invented ones.
+	for _, word := range words {
+		if word.Start == nil || word.End == nil {
+			continue
+		}
+		result.WordSegments = append(result.WordSegments, interfaces.TranscriptWord{
+			Start:   *word.Start,
+			End:     *word.End,
+			Word:    word.Word,
+			Score:   word.Score,
+			Speaker: word.Speaker,
+		})
+	}
+
+	if output.Text != "" {
+		result.Text = output.Text
+	} else {
+		result.Text = strings.Join(textParts, " ")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"scriberr/internal/transcription/interfaces"
+)
+
+func parseWhisperXFixture(t *testing.T, name string) *interfaces.TranscriptResult {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := ParseWhisperXOutput(data)
+	if err != nil {
+		t.Fatalf("ParseWhisperXOutput(%s) failed: %v", name, err)
+	}
+	return result
+}
+
+func TestParseWhisperXOutputSchemas(t *testing.T) {
+	v1 := parseWhisperXFixture(t, "whisperx_v1.json")
+	v2 := parseWhisperXFixture(t, "whisperx_v2.json")
+
+	if v1.SchemaVersion != WhisperXSchemaV1 || v2.SchemaVersion != WhisperXSchemaV2 {
+		t.Errorf("Expected schema versions %s and %s, got %s and %s", WhisperXSchemaV1, WhisperXSchemaV2, v1.SchemaVersion, v2.SchemaVersion)
+	}
+	if len(v1.Segments) != 2 || len(v1.WordSegments) != 8 {
+		t.Fatalf("Expected 2 segments and the 8 timed words, got %d and %d", len(v1.Segments), len(v1.WordSegments))
+	}
+	for _, segment := range v1.Segments {
+		if segment.Words != nil {
+			t.Errorf("Expected the words only at the top level, got %v in segment %q", segment.Words, segment.Text)
+		}
+	}
+	if v1.Text != " Hello there, this is Scriberr.  It has 2 speakers." {
+		t.Errorf("Expected the text joined from the segments, got %q", v1.Text)
+	}
+
+	v2.SchemaVersion = v1.SchemaVersion
+	if !reflect.DeepEqual(v1, v2) {
+		t.Errorf("Expected both schemas to parse to the same transcript:\n%+v\n%+v", v1, v2)
+	}
+}
+
+func TestParseWhisperXOutputWithoutWords(t *testing.T) {
+	result, err := ParseWhisperXOutput([]byte(`{"segments":[{"start":0,"end":1.5,"text":" Hi"}],"language":"en","text":"Hi"}`))
+	if err != nil {
+		t.Fatalf("ParseWhisperXOutput failed: %v", err)
+	}
+	if result.SchemaVersion != WhisperXSchemaV1 || result.WordSegments != nil || result.Text != "Hi" {
+		t.Errorf("Expected an unaligned v1 transcript, got %+v", result)
+	}
+
+	for _, output := range []string{"", "[]", `{"language":"en"}`} {
+		if _, err := ParseWhisperXOutput([]byte(output)); err == nil {
+			t.Errorf("Expected an error for output %q", output)
+		}
+	}
+}
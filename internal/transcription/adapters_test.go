@@ -2,11 +2,19 @@ package transcription
 
 import (
 	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"scriberr/internal/config"
 	"scriberr/internal/models"
+	"scriberr/internal/transcription/adapters"
 	"scriberr/internal/transcription/interfaces"
 	"scriberr/internal/transcription/registry"
 )
@@ -371,6 +379,231 @@ func TestParameterConversion(t *testing.T) {
 	}
 }
 
+func TestWhisperXParamsModelCacheDir(t *testing.T) {
+	service := NewUnifiedTranscriptionService()
+	service.SetModelCacheDir("data/models")
+
+	paramMap := service.convertToWhisperXParams(models.WhisperXParams{Model: "small"})
+	if paramMap["model_dir"] != "data/models" {
+		t.Errorf("Expected the model cache as model_dir, got '%v'", paramMap["model_dir"])
+	}
+
+	// A job's own model directory wins over the cache
+	paramMap = service.convertToWhisperXParams(models.WhisperXParams{Model: "small", ModelDir: stringPtr("/models")})
+	if paramMap["model_dir"] != "/models" {
+		t.Errorf("Expected the job's model_dir, got '%v'", paramMap["model_dir"])
+	}
+}
+
+func TestDiarizationHuggingFaceToken(t *testing.T) {
+	service := NewUnifiedTranscriptionService()
+	service.SetHuggingFaceToken("hf_config")
+
+	params := models.WhisperXParams{Model: "small", Diarize: true}
+	if token := service.convertToWhisperXParams(params)["hf_token"]; token != "hf_config" {
+		t.Errorf("Expected the configured token for WhisperX, got '%v'", token)
+	}
+	if token := service.convertToPyannoteParams(params)["hf_token"]; token != "hf_config" {
+		t.Errorf("Expected the configured token for pyannote, got '%v'", token)
+	}
+
+	// A job's own token wins over the configured one
+	params.HfToken = stringPtr("hf_job")
+	if token := service.convertToWhisperXParams(params)["hf_token"]; token != "hf_job" {
+		t.Errorf("Expected the job's token, got '%v'", token)
+	}
+}
+
+func TestParseProgressLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		total  float64
+		want   float64
+		wantOK bool
+	}{
+		{"Transcript: [12.345 --> 15.5]  Hello there", 100, 15.5, true},
+		{"[00:12.000 --> 01:05.250]  faster-whisper segment", 100, 65.25, true},
+		{"[01:00:00.000 --> 01:00:30.000] long file", 7200, 3630, true},
+		{"Progress: 42.50%...", 200, 85, true},
+		{"Progress: 42.50%...", 0, 0, false},
+		{"Performing VAD...", 100, 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := adapters.ParseProgressLine(tt.line, tt.total)
+		if ok != tt.wantOK {
+			t.Errorf("ParseProgressLine(%q) ok = %v, want %v", tt.line, ok, tt.wantOK)
+			continue
+		}
+		if ok && math.Abs(got-tt.want) > 1e-6 {
+			t.Errorf("ParseProgressLine(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateProcessedSeconds(t *testing.T) {
+	// 10s elapsed at 0.5x realtime covers 20s of audio
+	if got := estimateProcessedSeconds(10*time.Second, 0.5, 100); got != 20 {
+		t.Errorf("Expected 20 processed seconds, got %v", got)
+	}
+
+	// Estimates never claim completion
+	if got := estimateProcessedSeconds(time.Hour, 0.5, 100); got != 100*maxEstimatedProgress {
+		t.Errorf("Expected estimate capped at %v, got %v", 100*maxEstimatedProgress, got)
+	}
+
+	if got := estimateProcessedSeconds(time.Minute, 0, 100); got != 0 {
+		t.Errorf("Expected 0 for unknown realtime factor, got %v", got)
+	}
+}
+
+func TestParseOpenAIVerboseJSON(t *testing.T) {
+	body := []byte(`{
+		"text": "Hello there. General Kenobi.",
+		"language": "english",
+		"duration": 4.5,
+		"segments": [
+			{"start": 0.0, "end": 1.5, "text": " Hello there."},
+			{"start": 2.0, "end": 4.5, "text": " General Kenobi."}
+		],
+		"words": [{"word": "Hello", "start": 0.0, "end": 0.6}]
+	}`)
+
+	// Second chunk of a split upload
+	result, err := adapters.ParseOpenAIVerboseJSON(body, 600)
+	if err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(result.Segments) != 2 {
+		t.Fatalf("Expected 2 segments, got %d", len(result.Segments))
+	}
+	if result.Segments[1].Start != 602 || result.Segments[1].End != 604.5 {
+		t.Errorf("Expected offset segment 602-604.5, got %v-%v", result.Segments[1].Start, result.Segments[1].End)
+	}
+	if result.Segments[0].Text != "Hello there." {
+		t.Errorf("Expected trimmed segment text, got %q", result.Segments[0].Text)
+	}
+	if len(result.WordSegments) != 1 || result.WordSegments[0].End != 600.6 {
+		t.Errorf("Expected offset word timing, got %+v", result.WordSegments)
+	}
+
+	if _, err := adapters.ParseOpenAIVerboseJSON([]byte(`{}`), 0); err == nil {
+		t.Error("Expected error for empty response")
+	}
+}
+
+func TestClassifyDiarizationFailure(t *testing.T) {
+	gated := "huggingface_hub.errors.GatedRepoError: 403 Client Error.\nCannot access gated repo for url https://huggingface.co/pyannote/speaker-diarization-3.1"
+	err := adapters.ClassifyDiarizationFailure(gated)
+	if !errors.Is(err, adapters.ErrDiarizationModelAccess) {
+		t.Fatalf("Expected model access error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "user conditions") {
+		t.Errorf("Expected advice about accepting model conditions, got %q", err.Error())
+	}
+
+	missing := "Could not download 'pyannote/speaker-diarization-3.1' pipeline.\nAttributeError: 'NoneType' object has no attribute 'to'"
+	if err := adapters.ClassifyDiarizationFailure(missing); !errors.Is(err, adapters.ErrDiarizationModelAccess) {
+		t.Errorf("Expected model access error for missing token, got %v", err)
+	}
+
+	if err := adapters.ClassifyDiarizationFailure("torch.OutOfMemoryError: CUDA out of memory"); err != nil {
+		t.Errorf("Expected unrelated failures to be left alone, got %v", err)
+	}
+}
+
+func TestSummarizeSpeakers(t *testing.T) {
+	segments := []interfaces.TranscriptSegment{
+		{Start: 0, End: 2, Text: "Hi", Speaker: stringPtr("SPEAKER_01")},
+		{Start: 2, End: 5, Text: "Hello", Speaker: stringPtr("SPEAKER_00")},
+		{Start: 5, End: 6, Text: "Unattributed"},
+		{Start: 6, End: 7.5, Text: "Bye", Speaker: stringPtr("SPEAKER_01")},
+	}
+
+	speakers := summarizeSpeakers(segments)
+	if len(speakers) != 2 {
+		t.Fatalf("Expected 2 speakers, got %d", len(speakers))
+	}
+	if speakers[0].ID != "SPEAKER_01" || speakers[0].Label != "Speaker 1" || speakers[0].TotalSpeakingTime != 3.5 {
+		t.Errorf("Unexpected first speaker: %+v", speakers[0])
+	}
+	if speakers[1].ID != "SPEAKER_00" || speakers[1].Label != "Speaker 2" || speakers[1].TotalSpeakingTime != 3 {
+		t.Errorf("Unexpected second speaker: %+v", speakers[1])
+	}
+
+	if speakers := summarizeSpeakers([]interfaces.TranscriptSegment{{Start: 0, End: 1}}); len(speakers) != 0 {
+		t.Errorf("Expected no speakers without diarization, got %+v", speakers)
+	}
+}
+
+func TestOpenAIAdapterTranscribe(t *testing.T) {
+	const apiKey = "sk-test-secret-key"
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/transcriptions" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer "+apiKey {
+			t.Errorf("Missing bearer token")
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("Failed to parse upload: %v", err)
+		}
+		if r.FormValue("response_format") != "verbose_json" || r.FormValue("language") != "en" {
+			t.Errorf("Unexpected form values: %v", r.MultipartForm.Value)
+		}
+
+		w.WriteHeader(status)
+		switch status {
+		case http.StatusOK:
+			w.Write([]byte(`{"text": "hi", "language": "english", "segments": [{"start": 0, "end": 1, "text": "hi"}]}`))
+		case http.StatusTooManyRequests:
+			w.Write([]byte(`{"error": {"message": "Rate limit reached", "type": "requests"}}`))
+		case http.StatusPaymentRequired:
+			w.Write([]byte(`{"error": {"message": "Key ` + apiKey + ` has no credit"}}`))
+		}
+	}))
+	defer server.Close()
+
+	adapters.ConfigureOpenAI(adapters.OpenAISettings{BaseURL: server.URL + "/v1/", APIKey: apiKey})
+	defer adapters.ConfigureOpenAI(adapters.OpenAISettings{})
+
+	audioPath := filepath.Join(t.TempDir(), "audio.wav")
+	if err := os.WriteFile(audioPath, []byte("RIFF"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	input := interfaces.AudioInput{FilePath: audioPath, Format: "wav", Size: 4, Duration: time.Second}
+	params := map[string]interface{}{"language": "en"}
+	procCtx := interfaces.ProcessingContext{JobID: "openai-test", TempDirectory: t.TempDir()}
+
+	adapter := adapters.NewOpenAIAdapter()
+	if !adapter.IsReady(context.Background()) {
+		t.Error("Expected adapter to be ready with an API key configured")
+	}
+
+	result, err := adapter.Transcribe(context.Background(), input, params, procCtx)
+	if err != nil {
+		t.Fatalf("Transcription failed: %v", err)
+	}
+	if result.Text != "hi" || len(result.Segments) != 1 || result.ModelUsed != "whisper-1" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	status = http.StatusTooManyRequests
+	if _, err := adapter.Transcribe(context.Background(), input, params, procCtx); err == nil || !strings.Contains(err.Error(), "rate limit") {
+		t.Errorf("Expected rate limit error, got %v", err)
+	}
+
+	status = http.StatusPaymentRequired
+	_, err = adapter.Transcribe(context.Background(), input, params, procCtx)
+	if err == nil || !strings.Contains(err.Error(), "quota or billing") {
+		t.Errorf("Expected billing error, got %v", err)
+	}
+	if err != nil && strings.Contains(err.Error(), apiKey) {
+		t.Errorf("Error message leaked the API key: %v", err)
+	}
+}
+
 // Helper functions
 func stringPtr(s string) *string {
 	return &s
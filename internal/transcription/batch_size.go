@@ -0,0 +1,30 @@
+package transcription
+
+import "scriberr/internal/models"
+
+// DefaultBatchSize is the WhisperX batch size on CUDA and unknown devices
+const DefaultBatchSize = 16
+
+// DefaultBatchSizeForDevice returns the WhisperX batch size for jobs on
+// device that set none. Batches take memory in proportion to their size, so
+// MPS, which shares memory with the system, gets 8, and the CPU, where
+// larger batches do not run faster, gets 4.
+func DefaultBatchSizeForDevice(device string) int {
+	switch device {
+	case "mps":
+		return 8
+	case "cpu":
+		return 4
+	default:
+		return DefaultBatchSize
+	}
+}
+
+// batchSizeOrDefault returns the job's batch size, or the default for its
+// device when it sets none
+func batchSizeOrDefault(params models.WhisperXParams) int {
+	if params.BatchSize > 0 {
+		return params.BatchSize
+	}
+	return DefaultBatchSizeForDevice(params.Device)
+}
@@ -0,0 +1,22 @@
+package transcription
+
+import (
+	"testing"
+
+	"scriberr/internal/models"
+)
+
+func TestDefaultBatchSizeForDevice(t *testing.T) {
+	for device, want := range map[string]int{"cuda": 16, "mps": 8, "cpu": 4, "": 16} {
+		if got := DefaultBatchSizeForDevice(device); got != want {
+			t.Errorf("DefaultBatchSizeForDevice(%q) = %d, want %d", device, got, want)
+		}
+	}
+
+	if got := batchSizeOrDefault(models.WhisperXParams{Device: "cpu"}); got != 4 {
+		t.Errorf("Expected the CPU default for a job without a batch size, got %d", got)
+	}
+	if got := batchSizeOrDefault(models.WhisperXParams{Device: "cpu", BatchSize: 32}); got != 32 {
+		t.Errorf("Expected the job's own batch size, got %d", got)
+	}
+}
@@ -0,0 +1,524 @@
+package transcription
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"scriberr/internal/transcription/interfaces"
+	"scriberr/internal/transcription/registry"
+	"scriberr/pkg/logger"
+)
+
+// Chunked transcription defaults
+const (
+	defaultChunkWorkers = 2
+	// defaultChunkOverlap is the audio neighbouring chunks share, so words cut
+	// at one chunk's end are heard whole by the next
+	defaultChunkOverlap = 5.0
+	// chunkBoundarySearch is how far either side of a chunk's nominal end,
+	// as a fraction of the chunk length, a pause is looked for to cut at
+	chunkBoundarySearch = 0.1
+)
+
+// silenceDetectFilter finds pauses of half a second or more, quieter than
+// -35 dB, to cut chunks at
+const silenceDetectFilter = "silencedetect=noise=-35dB:d=0.5"
+
+// AudioChunk is one piece of a file split for chunked transcription
+type AudioChunk struct {
+	Index    int
+	Path     string  // 16 kHz mono WAV holding the chunk's audio
+	Offset   float64 // Start of the chunk in the original file, in seconds
+	Duration float64 // Seconds
+
+	// Progress receives the seconds of this chunk transcribed so far
+	Progress func(processedSeconds float64)
+}
+
+// ChunkTranscriber transcribes one chunk, returning segments timed from the
+// start of the chunk
+type ChunkTranscriber func(ctx context.Context, chunk AudioChunk) ([]interfaces.Segment, error)
+
+// TranscribeOptions configures ChunkedTranscription
+type TranscribeOptions struct {
+	NumWorkers     int     // Chunks transcribed at once; defaults to 2
+	OverlapSeconds float64 // Audio shared by neighbouring chunks; defaults to 5
+	TempDirectory  string  // Where chunk files are written; defaults to the system temp directory
+	JobID          string  // Names the chunks' temporary directories
+
+	// Transcribe transcribes each chunk; defaults to WhisperX with Params
+	Transcribe ChunkTranscriber
+	Params     map[string]interface{}
+
+	// ProgressCallback receives the seconds of the whole file transcribed so far
+	ProgressCallback func(processedSeconds float64)
+
+	// ResumeDirectory keeps each chunk's segments once it is transcribed, so
+	// a run that fails at chunk N can be repeated from chunk N. Saved chunks
+	// are reused only when ResumeKey and the chunk's position match. The
+	// directory is removed when the whole file has been transcribed.
+	ResumeDirectory string
+	ResumeKey       string
+
+	// OnChunkSaved is called once a chunk is saved for resuming, and
+	// OnResume with the offset of the first chunk left to transcribe when
+	// saved chunks are reused
+	OnChunkSaved func(chunk AudioChunk)
+	OnResume     func(fromSeconds float64)
+}
+
+// ChunkResult holds the segments transcribed from one chunk, with the offset
+// that places them in the original file
+type ChunkResult struct {
+	Index    int
+	Offset   float64
+	Segments []interfaces.Segment
+}
+
+// ChunkedTranscription transcribes a long file by splitting it with ffmpeg
+// into overlapping chunks of about chunkDurationSeconds, cut at a pause near
+// each chunk's end where there is one. Up to NumWorkers chunks are
+// transcribed at once and their segments stitched into one timeline. Speech
+// in the overlap between two chunks is kept from only one of them.
+func ChunkedTranscription(ctx context.Context, filePath string, chunkDurationSeconds int, opts TranscribeOptions) ([]interfaces.Segment, error) {
+	if chunkDurationSeconds <= 0 {
+		return nil, fmt.Errorf("chunk duration must be positive")
+	}
+	if opts.NumWorkers <= 0 {
+		opts.NumWorkers = defaultChunkWorkers
+	}
+	if opts.OverlapSeconds <= 0 {
+		opts.OverlapSeconds = defaultChunkOverlap
+	}
+	chunkSeconds := float64(chunkDurationSeconds)
+	if opts.OverlapSeconds >= chunkSeconds {
+		return nil, fmt.Errorf("chunk overlap of %.1fs must be shorter than the %ds chunks", opts.OverlapSeconds, chunkDurationSeconds)
+	}
+	if opts.Transcribe == nil {
+		transcribe, err := whisperXChunkTranscriber(opts)
+		if err != nil {
+			return nil, err
+		}
+		opts.Transcribe = transcribe
+	}
+
+	duration, err := DetectAudioDuration(ctx, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect duration: %w", err)
+	}
+	if duration <= 0 {
+		return nil, fmt.Errorf("cannot split audio of unknown length")
+	}
+	silences, err := detectSilences(ctx, filePath)
+	if err != nil {
+		logger.Warn("Failed to detect pauses, cutting chunks at fixed lengths", "file", filePath, "error", err)
+	}
+	chunks := planChunks(duration.Seconds(), chunkSeconds, opts.OverlapSeconds, silences)
+
+	chunkDir, err := os.MkdirTemp(opts.TempDirectory, "chunks-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chunk directory: %w", err)
+	}
+	defer os.RemoveAll(chunkDir)
+	for i := range chunks {
+		chunks[i].Path = filepath.Join(chunkDir, fmt.Sprintf("chunk_%03d.wav", i))
+	}
+	logger.Info("Starting chunked transcription", "file", filePath, "chunks", len(chunks), "workers", opts.NumWorkers)
+
+	// Report the sum of the chunks' progress, capped at the file's length
+	var progressMutex sync.Mutex
+	chunkProgress := make([]float64, len(chunks))
+	for i := range chunks {
+		chunks[i].Progress = func(processedSeconds float64) {
+			if opts.ProgressCallback == nil {
+				return
+			}
+			progressMutex.Lock()
+			defer progressMutex.Unlock()
+			chunkProgress[i] = math.Min(processedSeconds, chunks[i].Duration)
+			total := 0.0
+			for _, seconds := range chunkProgress {
+				total += seconds
+			}
+			opts.ProgressCallback(math.Min(total, duration.Seconds()))
+		}
+	}
+
+	// Chunks an earlier run saved are not transcribed again
+	results := make([]ChunkResult, len(chunks))
+	var pending []int
+	for i := range chunks {
+		if saved, ok := loadChunkResult(opts, chunks[i]); ok {
+			chunks[i].Progress(chunks[i].Duration)
+			results[i] = saved
+			continue
+		}
+		pending = append(pending, i)
+	}
+	if reused := len(chunks) - len(pending); reused > 0 {
+		resumeFrom := duration.Seconds()
+		if len(pending) > 0 {
+			resumeFrom = chunks[pending[0]].Offset
+		}
+		logger.Info("Resuming chunked transcription", "file", filePath, "reused_chunks", reused, "resume_from", resumeFrom)
+		if opts.OnResume != nil {
+			opts.OnResume(resumeFrom)
+		}
+	}
+
+	workCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var firstErr error
+	var errMutex sync.Mutex
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.NumWorkers && w < len(pending); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				segments, err := transcribeChunk(workCtx, filePath, chunks[i], opts.Transcribe)
+				if err != nil {
+					errMutex.Lock()
+					if firstErr == nil {
+						firstErr = fmt.Errorf("chunk %d at %.1fs: %w", i, chunks[i].Offset, err)
+					}
+					errMutex.Unlock()
+					cancel() // Stop the other chunks; the transcript is incomplete
+					continue
+				}
+				results[i] = ChunkResult{Index: i, Offset: chunks[i].Offset, Segments: segments}
+				if err := saveChunkResult(opts, chunks[i], results[i]); err != nil {
+					logger.Warn("Failed to save transcribed chunk for resuming", "chunk", i, "error", err)
+				} else if opts.ResumeDirectory != "" && opts.OnChunkSaved != nil {
+					opts.OnChunkSaved(chunks[i])
+				}
+			}
+		}()
+	}
+	for _, i := range pending {
+		if workCtx.Err() != nil {
+			break
+		}
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if opts.ResumeDirectory != "" {
+		os.RemoveAll(opts.ResumeDirectory)
+	}
+	return stitchChunks(results, opts.OverlapSeconds), nil
+}
+
+// planChunks lays out chunks of about chunkSeconds, each running overlap
+// seconds into the next. A chunk ends at the pause in silences nearest its
+// nominal end, when one is close enough, so words are not cut in half. A
+// file no longer than one chunk is one chunk.
+func planChunks(totalSeconds, chunkSeconds, overlap float64, silences []float64) []AudioChunk {
+	var chunks []AudioChunk
+	offset := 0.0
+	for {
+		end := offset + chunkSeconds
+		if end+overlap >= totalSeconds {
+			return append(chunks, AudioChunk{Index: len(chunks), Offset: offset, Duration: totalSeconds - offset})
+		}
+		end = nearestSilence(end, silences, chunkSeconds*chunkBoundarySearch)
+		length := math.Min(end-offset+overlap, totalSeconds-offset)
+		chunks = append(chunks, AudioChunk{Index: len(chunks), Offset: offset, Duration: length})
+		offset = end
+	}
+}
+
+// nearestSilence returns the pause closest to target, or target itself when
+// no pause is within window seconds of it
+func nearestSilence(target float64, silences []float64, window float64) float64 {
+	best, bestDistance := target, window
+	for _, silence := range silences {
+		if distance := math.Abs(silence - target); distance <= bestDistance {
+			best, bestDistance = silence, distance
+		}
+	}
+	return best
+}
+
+// detectSilences returns the middle of each pause in the file, in seconds,
+// found with ffmpeg's silencedetect filter
+func detectSilences(ctx context.Context, filePath string) ([]float64, error) {
+	cmd := execCommandContext(ctx, "ffmpeg", "-nostdin", "-hide_banner", "-nostats",
+		"-i", filePath, "-af", silenceDetectFilter, "-f", "null", "-")
+	ConfigureCmdSysProcAttr(cmd)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, ErrFFmpegUnavailable
+		}
+		return nil, &FFmpegError{Stderr: stderrTail(stderr.String()), Err: err}
+	}
+	return parseSilences(stderr.String()), nil
+}
+
+// parseSilences reads the pauses silencedetect logs as silence_start and
+// silence_end pairs, returning the middle of each. A pause still open when
+// the file ends has no middle and is skipped.
+func parseSilences(output string) []float64 {
+	var silences []float64
+	start := math.NaN()
+	for _, line := range strings.Split(output, "\n") {
+		if _, value, ok := strings.Cut(line, "silence_start: "); ok {
+			if seconds, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				start = seconds
+			}
+			continue
+		}
+		if _, value, ok := strings.Cut(line, "silence_end: "); ok && !math.IsNaN(start) {
+			value, _, _ = strings.Cut(value, " ")
+			if end, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				silences = append(silences, (math.Max(start, 0)+end)/2)
+			}
+			start = math.NaN()
+		}
+	}
+	return silences
+}
+
+// savedChunk is a transcribed chunk kept for resuming
+type savedChunk struct {
+	Key      string               `json:"key"`
+	Offset   float64              `json:"offset"`
+	Duration float64              `json:"duration"`
+	Segments []interfaces.Segment `json:"segments"`
+}
+
+// chunkResultPath is where a chunk's segments are kept for resuming
+func chunkResultPath(dir string, index int) string {
+	return filepath.Join(dir, fmt.Sprintf("chunk_%03d.json", index))
+}
+
+// saveChunkResult keeps a transcribed chunk for a later run to reuse
+func saveChunkResult(opts TranscribeOptions, chunk AudioChunk, result ChunkResult) error {
+	if opts.ResumeDirectory == "" {
+		return nil
+	}
+	if err := os.MkdirAll(opts.ResumeDirectory, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(savedChunk{Key: opts.ResumeKey, Offset: chunk.Offset, Duration: chunk.Duration, Segments: result.Segments})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(chunkResultPath(opts.ResumeDirectory, chunk.Index), data, 0644)
+}
+
+// loadChunkResult returns the segments an earlier run saved for the chunk,
+// if it was planned the same way under the same key
+func loadChunkResult(opts TranscribeOptions, chunk AudioChunk) (ChunkResult, bool) {
+	if opts.ResumeDirectory == "" {
+		return ChunkResult{}, false
+	}
+	data, err := os.ReadFile(chunkResultPath(opts.ResumeDirectory, chunk.Index))
+	if err != nil {
+		return ChunkResult{}, false
+	}
+	var saved savedChunk
+	if err := json.Unmarshal(data, &saved); err != nil || saved.Key != opts.ResumeKey ||
+		math.Abs(saved.Offset-chunk.Offset) > 0.001 || math.Abs(saved.Duration-chunk.Duration) > 0.001 {
+		return ChunkResult{}, false
+	}
+	return ChunkResult{Index: chunk.Index, Offset: chunk.Offset, Segments: saved.Segments}, true
+}
+
+// transcribeChunk extracts a chunk from the file and transcribes it
+func transcribeChunk(ctx context.Context, filePath string, chunk AudioChunk, transcribe ChunkTranscriber) ([]interfaces.Segment, error) {
+	defer os.Remove(chunk.Path)
+	if err := runFFmpeg(ctx, chunk.Path,
+		"-ss", fmt.Sprintf("%.3f", chunk.Offset),
+		"-t", fmt.Sprintf("%.3f", chunk.Duration),
+		"-i", filePath); err != nil {
+		return nil, fmt.Errorf("failed to extract chunk: %w", err)
+	}
+	return transcribe(ctx, chunk)
+}
+
+// stitchChunks merges the segments of each chunk into one timeline, shifting
+// them by their chunk's offset. Neighbouring chunks both hear the audio they
+// overlap on, so the overlap is split at its midpoint and each segment is
+// kept only by the chunk whose side of the split holds the segment's middle.
+// A segment spanning the split is kept by the earlier chunk, and the later
+// chunk's copy of it, cut short at the chunk's start, is dropped. Chunks
+// often break their segments differently, so words the earlier chunk ends on
+// that the later chunk starts with are then dropped from the later chunk.
+func stitchChunks(results []ChunkResult, overlap float64) []interfaces.Segment {
+	results = append([]ChunkResult(nil), results...)
+	sort.Slice(results, func(i, j int) bool { return results[i].Index < results[j].Index })
+
+	var merged []interfaces.Segment
+	for i, result := range results {
+		lower, upper := math.Inf(-1), math.Inf(1)
+		if i > 0 {
+			lower = result.Offset + overlap/2
+			if len(merged) > 0 {
+				lower = math.Max(lower, merged[len(merged)-1].End)
+			}
+		}
+		if i < len(results)-1 {
+			upper = results[i+1].Offset + overlap/2
+		}
+		var kept []interfaces.Segment
+		for _, segment := range result.Segments {
+			segment = shiftSegment(segment, result.Offset)
+			if middle := (segment.Start + segment.End) / 2; middle < lower || middle >= upper {
+				continue
+			}
+			kept = append(kept, segment)
+		}
+		if i > 0 {
+			kept = trimRepeatedWords(merged, kept, result.Offset, result.Offset+overlap)
+		}
+		merged = append(merged, kept...)
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Start < merged[j].Start })
+	return merged
+}
+
+// trimRepeatedWords drops the words that next starts with when previous
+// already ends on them. Only words in the overlap between overlapStart and
+// overlapEnd are compared, and the longest run that is both a suffix of
+// previous and a prefix of next is dropped. Words match ignoring case and
+// punctuation.
+func trimRepeatedWords(previous, next []interfaces.Segment, overlapStart, overlapEnd float64) []interfaces.Segment {
+	var tail []string
+	for _, segment := range previous {
+		if segment.End > overlapStart {
+			tail = append(tail, strings.Fields(segment.Text)...)
+		}
+	}
+	var head []string
+	for _, segment := range next {
+		if segment.Start < overlapEnd {
+			head = append(head, strings.Fields(segment.Text)...)
+		}
+	}
+
+	longest := len(tail)
+	if len(head) < longest {
+		longest = len(head)
+	}
+	repeated := 0
+	for n := longest; n > 0; n-- {
+		if wordsMatch(tail[len(tail)-n:], head[:n]) {
+			repeated = n
+			break
+		}
+	}
+	if repeated == 0 {
+		return next
+	}
+
+	trimmed := make([]interfaces.Segment, 0, len(next))
+	for _, segment := range next {
+		fields := strings.Fields(segment.Text)
+		if repeated >= len(fields) {
+			repeated -= len(fields)
+			continue
+		}
+		if repeated > 0 {
+			if len(segment.Words) == len(fields) {
+				segment.Words = segment.Words[repeated:]
+				segment.Start = segment.Words[0].Start
+			}
+			segment.Text = strings.Join(fields[repeated:], " ")
+			repeated = 0
+		}
+		trimmed = append(trimmed, segment)
+	}
+	return trimmed
+}
+
+// wordsMatch reports whether two runs of words are the same, ignoring case
+// and surrounding punctuation
+func wordsMatch(a, b []string) bool {
+	for i := range a {
+		if normalizeWord(a[i]) != normalizeWord(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeWord(word string) string {
+	return strings.ToLower(strings.TrimFunc(word, func(r rune) bool { return unicode.IsPunct(r) }))
+}
+
+// shiftSegment moves a segment and its words later by offset seconds
+func shiftSegment(segment interfaces.Segment, offset float64) interfaces.Segment {
+	segment.Start += offset
+	segment.End += offset
+	if segment.Words != nil {
+		words := make([]interfaces.Word, len(segment.Words))
+		for i, word := range segment.Words {
+			word.Start += offset
+			word.End += offset
+			words[i] = word
+		}
+		segment.Words = words
+	}
+	return segment
+}
+
+// whisperXChunkTranscriber transcribes chunks with the registered WhisperX
+// adapter. Each chunk gets its own job ID so their temporary files do not
+// collide.
+func whisperXChunkTranscriber(opts TranscribeOptions) (ChunkTranscriber, error) {
+	adapter, err := registry.GetRegistry().GetTranscriptionAdapter("whisperx")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get WhisperX adapter: %w", err)
+	}
+	return func(ctx context.Context, chunk AudioChunk) ([]interfaces.Segment, error) {
+		input := interfaces.AudioInput{
+			FilePath:   chunk.Path,
+			Format:     normalizedFormat,
+			SampleRate: normalizedSampleRate,
+			Channels:   normalizedChannels,
+			Duration:   time.Duration(chunk.Duration * float64(time.Second)),
+			Metadata:   map[string]string{},
+		}
+		if stat, err := os.Stat(chunk.Path); err == nil {
+			input.Size = stat.Size()
+		}
+		// Without an output directory each chunk's output goes to a temporary
+		// one, away from the saved chunk results
+		procCtx := interfaces.ProcessingContext{
+			JobID:            fmt.Sprintf("%s-chunk-%03d", opts.JobID, chunk.Index),
+			TempDirectory:    filepath.Dir(chunk.Path),
+			Metadata:         map[string]string{},
+			ProgressCallback: chunk.Progress,
+		}
+		result, err := adapter.Transcribe(ctx, input, opts.Params, procCtx)
+		if err != nil {
+			return nil, err
+		}
+		return result.Segments, nil
+	}, nil
+}
@@ -0,0 +1,325 @@
+package transcription
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"scriberr/internal/transcription/interfaces"
+)
+
+func segment(start, end float64, text string) interfaces.Segment {
+	return interfaces.Segment{Start: start, End: end, Text: text}
+}
+
+// threeChunkResults is 80 seconds of audio in 30 second chunks overlapping by
+// 4 seconds. Each chunk hears the speech in its overlaps, timed from its own
+// start.
+func threeChunkResults() []ChunkResult {
+	return []ChunkResult{
+		{Index: 0, Offset: 0, Segments: []interfaces.Segment{
+			segment(0, 10, "one"),
+			segment(10, 20, "two"),
+			segment(20, 29, "three"),
+			segment(29, 31.5, "four"), // In the overlap, before its middle
+			segment(31.5, 34, "five"), // Cut off at the chunk's end
+		}},
+		{Index: 1, Offset: 30, Segments: []interfaces.Segment{
+			segment(-1, 1.5, "four"), // Heard again, from the chunk's start
+			segment(1.5, 4, "five"),
+			segment(4, 15, "six"),
+			segment(15, 29.5, "seven"),
+			segment(29.5, 34, "eight"),
+		}},
+		{Index: 2, Offset: 60, Segments: []interfaces.Segment{
+			{Start: 0, End: 4, Text: "eight", Words: []interfaces.Word{{Start: 0, End: 4, Word: "eight"}}},
+			segment(4, 12, "nine"),
+			segment(12, 20, "ten"),
+		}},
+	}
+}
+
+func TestStitchChunks(t *testing.T) {
+	merged := stitchChunks(threeChunkResults(), 4)
+
+	var texts []string
+	for i, s := range merged {
+		texts = append(texts, s.Text)
+		if i > 0 && s.Start < merged[i-1].End {
+			t.Errorf("Segment %q at %.1fs overlaps %q ending at %.1fs", s.Text, s.Start, merged[i-1].Text, merged[i-1].End)
+		}
+	}
+	want := "one two three four five six seven eight nine ten"
+	if got := strings.Join(texts, " "); got != want {
+		t.Fatalf("Expected %q, got %q", want, got)
+	}
+
+	// Offsets are applied to segments and their words
+	if merged[4].Start != 31.5 || merged[4].End != 34 {
+		t.Errorf("Expected five at 31.5-34s, got %.1f-%.1f", merged[4].Start, merged[4].End)
+	}
+	if eight := merged[7]; eight.Start != 59.5 || eight.End != 64 {
+		t.Errorf("Expected eight from the second chunk at 59.5-64s, got %.1f-%.1f", eight.Start, eight.End)
+	}
+	if merged[0].Start != 0 || merged[len(merged)-1].End != 80 {
+		t.Errorf("Expected the transcript to span 0-80s, got %.1f-%.1f", merged[0].Start, merged[len(merged)-1].End)
+	}
+}
+
+func TestStitchChunksKeepsInput(t *testing.T) {
+	results := threeChunkResults()
+	results[0], results[2] = results[2], results[0]
+	merged := stitchChunks(results, 4)
+	if len(merged) != 10 || merged[len(merged)-1].Text != "ten" {
+		t.Fatalf("Expected chunks to be stitched in index order, got %d segments", len(merged))
+	}
+	if words := results[0].Segments[0].Words; words[0].Start != 0 {
+		t.Errorf("Expected the chunk's words to be left unshifted, got %.1f", words[0].Start)
+	}
+}
+
+func TestStitchChunksTrimsRepeatedWords(t *testing.T) {
+	tests := []struct {
+		name  string
+		first []interfaces.Segment
+		next  []interfaces.Segment
+		want  []string
+	}{
+		{
+			name:  "repeated run split differently",
+			first: []interfaces.Segment{segment(20, 30.5, "and that is why we")},
+			next:  []interfaces.Segment{segment(1, 3, "Why we moved"), segment(3, 6, "to the coast.")},
+			want:  []string{"and that is why we", "moved", "to the coast."},
+		},
+		{
+			name:  "whole segment repeated",
+			first: []interfaces.Segment{segment(20, 30.5, "we left early. It rained")},
+			next:  []interfaces.Segment{segment(1, 2, "it rained,"), segment(2, 5, "all day")},
+			want:  []string{"we left early. It rained", "all day"},
+		},
+		{
+			name:  "nothing repeated",
+			first: []interfaces.Segment{segment(20, 30.5, "the end of one thought")},
+			next:  []interfaces.Segment{segment(1, 4, "and a new one")},
+			want:  []string{"the end of one thought", "and a new one"},
+		},
+		{
+			name:  "repeat after the overlap is kept",
+			first: []interfaces.Segment{segment(20, 30.5, "again")},
+			next:  []interfaces.Segment{segment(1, 3, "so"), segment(6, 8, "again")},
+			want:  []string{"again", "so", "again"},
+		},
+	}
+	for _, tt := range tests {
+		results := []ChunkResult{{Index: 0, Offset: 0, Segments: tt.first}, {Index: 1, Offset: 30, Segments: tt.next}}
+		var texts []string
+		for _, s := range stitchChunks(results, 4) {
+			texts = append(texts, s.Text)
+		}
+		if strings.Join(texts, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, texts)
+		}
+	}
+}
+
+func TestStitchChunksTrimsRepeatedWordTimings(t *testing.T) {
+	results := []ChunkResult{
+		{Index: 0, Offset: 0, Segments: []interfaces.Segment{segment(25, 30.5, "see you")}},
+		{Index: 1, Offset: 30, Segments: []interfaces.Segment{{Start: 0.2, End: 5, Text: "you tomorrow morning", Words: []interfaces.Word{
+			{Start: 0.2, End: 0.6, Word: "you"}, {Start: 0.8, End: 2, Word: "tomorrow"}, {Start: 2.2, End: 5, Word: "morning"},
+		}}}},
+	}
+	merged := stitchChunks(results, 4)
+	if len(merged) != 2 {
+		t.Fatalf("Expected 2 segments, got %d", len(merged))
+	}
+	if second := merged[1]; second.Text != "tomorrow morning" || len(second.Words) != 2 || second.Start != 30.8 {
+		t.Errorf("Expected the repeated word and its timing to be dropped, got %+v", second)
+	}
+}
+
+func TestPlanChunksAtSilences(t *testing.T) {
+	// Pauses near 28 and 61 seconds move the cuts; the one at 45 is too far
+	// from a nominal end to be used
+	chunks := planChunks(95, 30, 5, []float64{28, 45, 61})
+	offsets := []float64{0, 28, 61}
+	durations := []float64{33, 38, 34}
+	if len(chunks) != len(offsets) {
+		t.Fatalf("Expected %d chunks, got %+v", len(offsets), chunks)
+	}
+	for i, chunk := range chunks {
+		if chunk.Offset != offsets[i] || chunk.Duration != durations[i] {
+			t.Errorf("Chunk %d is %+v, want offset %.0f duration %.0f", i, chunk, offsets[i], durations[i])
+		}
+	}
+}
+
+func TestParseSilences(t *testing.T) {
+	output := `[silencedetect @ 0x55d0] silence_start: -0.01
+[silencedetect @ 0x55d0] silence_end: 1.5 | silence_duration: 1.51
+size=N/A time=00:01:00.00 bitrate=N/A speed= 500x
+[silencedetect @ 0x55d0] silence_start: 29.25
+[silencedetect @ 0x55d0] silence_end: 30.75 | silence_duration: 1.5
+[silencedetect @ 0x55d0] silence_start: 59.5
+`
+	silences := parseSilences(output)
+	if len(silences) != 2 || silences[0] != 0.75 || silences[1] != 30 {
+		t.Errorf("Expected pauses at 0.75s and 30s, got %v", silences)
+	}
+}
+
+func TestPlanChunks(t *testing.T) {
+	tests := []struct {
+		total     float64
+		offsets   []float64
+		durations []float64
+	}{
+		{90, []float64{0, 30, 60}, []float64{35, 35, 30}},
+		{62, []float64{0, 30}, []float64{35, 32}},
+		{20, []float64{0}, []float64{20}},
+		{33, []float64{0}, []float64{33}},
+	}
+	for _, tt := range tests {
+		chunks := planChunks(tt.total, 30, 5, nil)
+		if len(chunks) != len(tt.offsets) {
+			t.Errorf("%.0fs: expected %d chunks, got %d", tt.total, len(tt.offsets), len(chunks))
+			continue
+		}
+		for i, chunk := range chunks {
+			if chunk.Index != i || chunk.Offset != tt.offsets[i] || chunk.Duration != tt.durations[i] {
+				t.Errorf("%.0fs: chunk %d is %+v", tt.total, i, chunk)
+			}
+		}
+		if last := chunks[len(chunks)-1]; last.Offset+last.Duration != tt.total {
+			t.Errorf("%.0fs: chunks end at %.1fs", tt.total, last.Offset+last.Duration)
+		}
+	}
+}
+
+func TestChunkedTranscription(t *testing.T) {
+	// ffprobe reports 80 seconds; the fake ffmpeg succeeds without output
+	fakeFFprobe(t, "80.0\n", 0)
+	results := threeChunkResults()
+
+	var mutex sync.Mutex
+	var transcribed []int
+	var progress float64
+	segments, err := ChunkedTranscription(context.Background(), "/tmp/long.wav", 30, TranscribeOptions{
+		NumWorkers:     3,
+		OverlapSeconds: 4,
+		TempDirectory:  t.TempDir(),
+		Transcribe: func(ctx context.Context, chunk AudioChunk) ([]interfaces.Segment, error) {
+			mutex.Lock()
+			transcribed = append(transcribed, chunk.Index)
+			mutex.Unlock()
+			if chunk.Offset != results[chunk.Index].Offset {
+				return nil, fmt.Errorf("unexpected offset %.1f", chunk.Offset)
+			}
+			chunk.Progress(chunk.Duration)
+			return results[chunk.Index].Segments, nil
+		},
+		ProgressCallback: func(processedSeconds float64) {
+			mutex.Lock()
+			progress = math.Max(progress, processedSeconds)
+			mutex.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatalf("ChunkedTranscription failed: %v", err)
+	}
+	if len(transcribed) != 3 {
+		t.Errorf("Expected 3 chunks to be transcribed, got %v", transcribed)
+	}
+	if len(segments) != 10 || segments[len(segments)-1].End != 80 {
+		t.Errorf("Expected 10 segments ending at 80s, got %d", len(segments))
+	}
+	if progress != 80 {
+		t.Errorf("Expected progress to reach 80s, got %.1f", progress)
+	}
+}
+
+func TestChunkedTranscriptionChunkFailure(t *testing.T) {
+	fakeFFprobe(t, "80.0\n", 0)
+
+	_, err := ChunkedTranscription(context.Background(), "/tmp/long.wav", 30, TranscribeOptions{
+		NumWorkers:    1,
+		TempDirectory: t.TempDir(),
+		Transcribe: func(ctx context.Context, chunk AudioChunk) ([]interfaces.Segment, error) {
+			if chunk.Index == 1 {
+				return nil, fmt.Errorf("out of memory")
+			}
+			return nil, nil
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "chunk 1 at 30.0s: out of memory") {
+		t.Errorf("Expected the failing chunk's error, got %v", err)
+	}
+
+	if _, err := ChunkedTranscription(context.Background(), "/tmp/long.wav", 5, TranscribeOptions{OverlapSeconds: 5}); err == nil {
+		t.Error("Expected an error for an overlap as long as the chunks")
+	}
+}
+
+func TestChunkedTranscriptionResumes(t *testing.T) {
+	fakeFFprobe(t, "80.0\n", 0)
+	results := threeChunkResults()
+	resumeDir := filepath.Join(t.TempDir(), "job-1")
+
+	var saved []int
+	var resumedFrom float64
+	run := func(failAt int, resume string) ([]int, []interfaces.Segment, error) {
+		var transcribed []int
+		segments, err := ChunkedTranscription(context.Background(), "/tmp/long.wav", 30, TranscribeOptions{
+			NumWorkers:      1,
+			OverlapSeconds:  4,
+			TempDirectory:   t.TempDir(),
+			ResumeDirectory: resume,
+			ResumeKey:       "large-v3",
+			Transcribe: func(ctx context.Context, chunk AudioChunk) ([]interfaces.Segment, error) {
+				transcribed = append(transcribed, chunk.Index)
+				if chunk.Index == failAt {
+					return nil, fmt.Errorf("out of memory")
+				}
+				return results[chunk.Index].Segments, nil
+			},
+			OnChunkSaved: func(chunk AudioChunk) { saved = append(saved, chunk.Index) },
+			OnResume:     func(fromSeconds float64) { resumedFrom = fromSeconds },
+		})
+		return transcribed, segments, err
+	}
+
+	if transcribed, _, err := run(2, resumeDir); err == nil || len(transcribed) != 3 {
+		t.Fatalf("Expected the run to fail at chunk 2, transcribed %v: %v", transcribed, err)
+	}
+	if len(saved) != 2 || resumedFrom != 0 {
+		t.Errorf("Expected chunks 0 and 1 to be saved without resuming, got %v from %.1f", saved, resumedFrom)
+	}
+	transcribed, segments, err := run(-1, resumeDir)
+	if err != nil {
+		t.Fatalf("Resumed run failed: %v", err)
+	}
+	if len(transcribed) != 1 || transcribed[0] != 2 {
+		t.Errorf("Expected only chunk 2 to be transcribed again, got %v", transcribed)
+	}
+	if resumedFrom != 60 {
+		t.Errorf("Expected the run to resume from 60s, got %.1f", resumedFrom)
+	}
+	if _, err := os.Stat(resumeDir); !os.IsNotExist(err) {
+		t.Errorf("Expected the saved chunks to be removed, got %v", err)
+	}
+
+	// Assembling saved and new chunks gives the same transcript as one run
+	_, uninterrupted, err := run(-1, "")
+	if err != nil {
+		t.Fatalf("Uninterrupted run failed: %v", err)
+	}
+	if !reflect.DeepEqual(segments, uninterrupted) {
+		t.Errorf("Expected the resumed transcript to match an uninterrupted run:\n%v\n%v", segments, uninterrupted)
+	}
+}
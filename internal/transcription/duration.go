@@ -0,0 +1,62 @@
+package transcription
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// execCommandContext builds the ffprobe command; tests replace it to fake ffprobe output
+var execCommandContext = exec.CommandContext
+
+// DetectAudioDuration returns the length of an audio or video file as reported
+// by ffprobe
+func DetectAudioDuration(ctx context.Context, filePath string) (time.Duration, error) {
+	cmd := execCommandContext(ctx, "ffprobe",
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		filePath)
+	ConfigureCmdSysProcAttr(cmd)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe failed for %s: %w", filePath, err)
+	}
+	return parseFFprobeDuration(string(output))
+}
+
+// parseFFprobeDuration reads the first usable duration in ffprobe output. Plain
+// values ("12.5") and key=value lines ("duration=12.5") are accepted. Section
+// markers such as "[FORMAT]" and "N/A", which ffprobe prints for inputs without
+// a known length, are skipped.
+func parseFFprobeDuration(output string) (time.Duration, error) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		value := strings.TrimSpace(scanner.Text())
+		if key, v, ok := strings.Cut(value, "="); ok {
+			if strings.TrimSpace(key) != "duration" {
+				continue
+			}
+			value = strings.TrimSpace(v)
+		}
+		if value == "" || value == "N/A" || strings.HasPrefix(value, "[") {
+			continue
+		}
+
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ffprobe duration %q: %w", value, err)
+		}
+		if seconds < 0 || math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+			return 0, fmt.Errorf("invalid ffprobe duration %q", value)
+		}
+		return time.Duration(math.Round(seconds * float64(time.Second))), nil
+	}
+	return 0, fmt.Errorf("ffprobe reported no duration")
+}
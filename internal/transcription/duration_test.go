@@ -0,0 +1,110 @@
+package transcription
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeFFprobe makes execCommandContext run TestFFprobeHelperProcess, which prints
+// output and exits with exitCode
+func fakeFFprobe(t *testing.T, output string, exitCode int) *[]string {
+	t.Helper()
+	var args []string
+	var mutex sync.Mutex // Commands may be built concurrently
+	original := execCommandContext
+	execCommandContext = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		mutex.Lock()
+		args = append([]string{name}, arg...)
+		mutex.Unlock()
+		cmd := exec.CommandContext(ctx, os.Args[0], "-test.run=TestFFprobeHelperProcess")
+		cmd.Env = append(os.Environ(),
+			"GO_WANT_FFPROBE_HELPER=1",
+			"FFPROBE_OUTPUT="+output,
+			fmt.Sprintf("FFPROBE_EXIT=%d", exitCode))
+		return cmd
+	}
+	t.Cleanup(func() { execCommandContext = original })
+	return &args
+}
+
+// TestFFprobeHelperProcess stands in for ffprobe when run by fakeFFprobe
+func TestFFprobeHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_FFPROBE_HELPER") != "1" {
+		return
+	}
+	fmt.Print(os.Getenv("FFPROBE_OUTPUT"))
+	if os.Getenv("FFPROBE_EXIT") != "0" {
+		fmt.Fprint(os.Stderr, "audio.mp3: Invalid data found when processing input")
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestDetectAudioDuration(t *testing.T) {
+	args := fakeFFprobe(t, "125.432000\n", 0)
+
+	duration, err := DetectAudioDuration(context.Background(), "/tmp/audio.mp3")
+	if err != nil {
+		t.Fatalf("DetectAudioDuration failed: %v", err)
+	}
+	if want := 125432 * time.Millisecond; duration != want {
+		t.Errorf("Expected %v, got %v", want, duration)
+	}
+
+	want := "ffprobe -v error -show_entries format=duration -of default=noprint_wrappers=1:nokey=1 /tmp/audio.mp3"
+	if got := strings.Join(*args, " "); got != want {
+		t.Errorf("Unexpected ffprobe invocation:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestDetectAudioDurationFailure(t *testing.T) {
+	fakeFFprobe(t, "", 1)
+
+	if _, err := DetectAudioDuration(context.Background(), "/tmp/audio.mp3"); err == nil {
+		t.Error("Expected an error when ffprobe fails")
+	}
+}
+
+func TestParseFFprobeDuration(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   time.Duration
+	}{
+		{"fractional seconds", "3.500000\n", 3500 * time.Millisecond},
+		{"whole seconds", "60", time.Minute},
+		{"windows line ending", "42.25\r\n", 42250 * time.Millisecond},
+		{"surrounding whitespace", "  \n  7.125  \n", 7125 * time.Millisecond},
+		{"key value", "duration=90.000000\n", 90 * time.Second},
+		{"key value with wrapper", "[FORMAT]\nduration=1.5\n[/FORMAT]\n", 1500 * time.Millisecond},
+		{"unknown first", "N/A\n12.0\n", 12 * time.Second},
+		{"zero", "0.000000\n", 0},
+		{"long recording", "36000.5", 10*time.Hour + 500*time.Millisecond},
+		{"sub millisecond", "0.0004", 400 * time.Microsecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFFprobeDuration(tt.output)
+			if err != nil {
+				t.Fatalf("parseFFprobeDuration(%q) failed: %v", tt.output, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseFFprobeDuration(%q) = %v, want %v", tt.output, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFFprobeDurationInvalid(t *testing.T) {
+	for _, output := range []string{"", "\n", "N/A\n", "abc", "-1.5", "NaN", "Inf", "bitrate=128000\n"} {
+		if got, err := parseFFprobeDuration(output); err == nil {
+			t.Errorf("parseFFprobeDuration(%q) = %v, expected an error", output, got)
+		}
+	}
+}
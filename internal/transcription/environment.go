@@ -0,0 +1,370 @@
+package transcription
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"scriberr/internal/config"
+	"scriberr/internal/transcription/adapters"
+	"scriberr/pkg/logger"
+)
+
+// WhisperX environment states
+const (
+	EnvStateUnknown   = "unknown"
+	EnvStateChecking  = "checking"
+	EnvStateReady     = "ready"
+	EnvStateDegraded  = "degraded" // Works, but torch does not match the hardware
+	EnvStateBroken    = "broken"
+	EnvStateRepairing = "repairing"
+)
+
+// ErrRepairInProgress is returned when a repair is requested while one runs
+var ErrRepairInProgress = errors.New("environment repair already in progress")
+
+// whisperXRepository is cloned when the WhisperX project is missing
+const whisperXRepository = "https://github.com/m-bain/WhisperX.git"
+
+// environmentPackages are the packages whose installed versions are reported
+var environmentPackages = []string{"whisperx", "torch", "torchaudio", "faster-whisper", "ctranslate2", "pyannote.audio", "transformers"}
+
+// expectedPackageVersions are the versions bootstrap pins
+var expectedPackageVersions = map[string]string{
+	"ctranslate2": "4.6.0",
+}
+
+// environmentProbeScript reports package versions, whether WhisperX imports
+// and how torch was built, as one line of JSON
+const environmentProbeScript = `import json, sys
+from importlib import metadata
+result = {"packages": {}, "errors": {}}
+for name in sys.argv[1:]:
+    try:
+        result["packages"][name] = metadata.version(name)
+    except metadata.PackageNotFoundError:
+        result["packages"][name] = None
+try:
+    import whisperx
+except Exception as e:
+    result["errors"]["whisperx"] = "%s: %s" % (type(e).__name__, e)
+try:
+    import torch
+    result["torch"] = {
+        "cuda_version": torch.version.cuda,
+        "cuda_available": torch.cuda.is_available(),
+        "mps_built": torch.backends.mps.is_built(),
+        "mps_available": torch.backends.mps.is_available(),
+    }
+except Exception as e:
+    result["errors"]["torch"] = "%s: %s" % (type(e).__name__, e)
+print(json.dumps(result))`
+
+// TorchBuild describes the installed torch build
+type TorchBuild struct {
+	CUDAVersion   *string `json:"cuda_version"` // CUDA version torch was built for; null for CPU builds
+	CUDAAvailable bool    `json:"cuda_available"`
+	MPSBuilt      bool    `json:"mps_built"`
+	MPSAvailable  bool    `json:"mps_available"`
+}
+
+// EnvironmentStatus reports the health of the WhisperX Python environment
+type EnvironmentStatus struct {
+	State       string             `json:"state"`
+	ProjectPath string             `json:"project_path"`
+	VenvExists  bool               `json:"venv_exists"`
+	Packages    map[string]*string `json:"packages"` // Installed versions; null when a package is missing
+	Torch       *TorchBuild        `json:"torch,omitempty"`
+	Device      string             `json:"device"` // Default device detected for this host
+	Problems    []string           `json:"problems"`
+	CheckedAt   *time.Time         `json:"checked_at,omitempty"`
+	RepairedAt  *time.Time         `json:"repaired_at,omitempty"`
+	RepairError string             `json:"repair_error,omitempty"`
+}
+
+// environmentProbe is the output of environmentProbeScript
+type environmentProbe struct {
+	Packages map[string]*string `json:"packages"`
+	Errors   map[string]string  `json:"errors"`
+	Torch    *TorchBuild        `json:"torch"`
+}
+
+// EnvironmentManager checks and repairs the uv-managed WhisperX environment
+type EnvironmentManager struct {
+	UVPath      string
+	ProjectPath string // WhisperX uv project; its venv is in .venv
+	Environment config.Environment
+	// HoldJobs, if set, keeps queued jobs from starting while a repair runs
+	HoldJobs func() (release func())
+
+	mu     sync.Mutex
+	status EnvironmentStatus
+}
+
+// NewEnvironmentManager creates a manager for the WhisperX project at projectPath
+func NewEnvironmentManager(uvPath, projectPath string, env config.Environment) *EnvironmentManager {
+	return &EnvironmentManager{
+		UVPath:      uvPath,
+		ProjectPath: projectPath,
+		Environment: env,
+		status: EnvironmentStatus{
+			State:       EnvStateUnknown,
+			ProjectPath: projectPath,
+			Packages:    map[string]*string{},
+			Device:      env.DefaultWhisperDevice,
+			Problems:    []string{},
+		},
+	}
+}
+
+// Status returns the result of the last check
+func (m *EnvironmentManager) Status() EnvironmentStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status
+}
+
+// Check verifies the venv exists, the packages import with the expected
+// versions and torch matches the host. While a repair runs it returns the
+// current status, since the repair checks again when it finishes.
+func (m *EnvironmentManager) Check(ctx context.Context) EnvironmentStatus {
+	m.mu.Lock()
+	if m.status.State == EnvStateRepairing {
+		status := m.status
+		m.mu.Unlock()
+		return status
+	}
+	m.status.State = EnvStateChecking
+	m.mu.Unlock()
+
+	return m.finishCheck(m.probe(ctx))
+}
+
+// finishCheck stores a check result, keeping the repair history
+func (m *EnvironmentManager) finishCheck(status EnvironmentStatus) EnvironmentStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status.RepairedAt = m.status.RepairedAt
+	status.RepairError = m.status.RepairError
+	m.status = status
+	return status
+}
+
+// probe inspects the environment
+func (m *EnvironmentManager) probe(ctx context.Context) EnvironmentStatus {
+	now := time.Now()
+	status := EnvironmentStatus{
+		State:       EnvStateReady,
+		ProjectPath: m.ProjectPath,
+		Packages:    map[string]*string{},
+		Device:      m.Environment.DefaultWhisperDevice,
+		Problems:    []string{},
+		CheckedAt:   &now,
+	}
+	broken := func(problem string) {
+		status.State = EnvStateBroken
+		status.Problems = append(status.Problems, problem)
+	}
+
+	if info, err := os.Stat(filepath.Join(m.ProjectPath, ".venv")); err != nil || !info.IsDir() {
+		broken("The WhisperX virtual environment does not exist at " + filepath.Join(m.ProjectPath, ".venv"))
+		return status
+	}
+	status.VenvExists = true
+
+	args := append([]string{"run", "--native-tls", "--project", m.ProjectPath, "python", "-c", environmentProbeScript}, environmentPackages...)
+	cmd := execCommandContext(ctx, m.UVPath, args...)
+	var stdout bytes.Buffer
+	var stderr tailWriter
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		broken(fmt.Sprintf("The environment probe failed: %v: %s", err, stderr.String()))
+		return status
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	var probe environmentProbe
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &probe); err != nil {
+		broken("The environment probe returned unreadable output: " + strings.TrimSpace(stdout.String()))
+		return status
+	}
+
+	if probe.Packages != nil {
+		status.Packages = probe.Packages
+	}
+	status.Torch = probe.Torch
+	for _, name := range []string{"whisperx", "torch"} {
+		if status.Packages[name] == nil {
+			broken(name + " is not installed")
+		} else if message, failed := probe.Errors[name]; failed {
+			broken(fmt.Sprintf("Importing %s failed: %s", name, message))
+		}
+	}
+	for name, expected := range expectedPackageVersions {
+		if version := status.Packages[name]; version != nil && *version != expected {
+			broken(fmt.Sprintf("%s %s is installed but %s is required", name, *version, expected))
+		}
+	}
+	if status.State == EnvStateReady {
+		if problems := m.torchMismatches(probe.Torch); len(problems) > 0 {
+			status.State = EnvStateDegraded
+			status.Problems = append(status.Problems, problems...)
+		}
+	}
+	return status
+}
+
+// torchMismatches compares the torch build with the detected host
+func (m *EnvironmentManager) torchMismatches(torch *TorchBuild) []string {
+	if torch == nil {
+		return nil
+	}
+	var problems []string
+	switch {
+	case m.Environment.DefaultWhisperDevice == "cuda" && torch.CUDAVersion == nil:
+		problems = append(problems, "The default device is CUDA but torch was built without CUDA")
+	case m.Environment.DefaultWhisperDevice == "cuda" && !torch.CUDAAvailable:
+		problems = append(problems, "torch was built for CUDA "+*torch.CUDAVersion+" but no CUDA device is available")
+	case !m.Environment.SupportsNvidiaStack && torch.CUDAVersion != nil:
+		problems = append(problems, "torch was built for CUDA but this host cannot use CUDA")
+	}
+	if m.Environment.SupportsMPS && !torch.MPSBuilt {
+		problems = append(problems, "This host supports MPS but torch was built without it")
+	}
+	return problems
+}
+
+// Repair reinstalls the environment and checks it again, cloning WhisperX
+// first if the project is missing. Output is logged line by line so it shows
+// in the recent log entries. Queued jobs wait until the repair finishes.
+func (m *EnvironmentManager) Repair(ctx context.Context) error {
+	if err := m.beginRepair(); err != nil {
+		return err
+	}
+	return m.repair(ctx)
+}
+
+// StartRepair starts a repair in the background, returning
+// ErrRepairInProgress if one is already running
+func (m *EnvironmentManager) StartRepair(ctx context.Context) error {
+	if err := m.beginRepair(); err != nil {
+		return err
+	}
+	go m.repair(ctx)
+	return nil
+}
+
+func (m *EnvironmentManager) beginRepair() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.status.State == EnvStateRepairing {
+		return ErrRepairInProgress
+	}
+	m.status.State = EnvStateRepairing
+	m.status.RepairError = ""
+	return nil
+}
+
+func (m *EnvironmentManager) repair(ctx context.Context) error {
+	if m.HoldJobs != nil {
+		release := m.HoldJobs()
+		defer release()
+	}
+	logger.Info("Repairing WhisperX environment", "project_path", m.ProjectPath)
+
+	err := m.reinstall(ctx)
+	adapters.ResetEnvironmentCache()
+	now := time.Now()
+
+	m.mu.Lock()
+	m.status.RepairedAt = &now
+	if err != nil {
+		m.status.RepairError = err.Error()
+	}
+	m.mu.Unlock()
+
+	status := m.finishCheck(m.probe(ctx))
+	if err != nil {
+		logger.Error("WhisperX environment repair failed", "error", err)
+		return err
+	}
+	logger.Info("WhisperX environment repaired", "state", status.State, "problems", status.Problems)
+	return nil
+}
+
+// reinstall clones WhisperX if needed and syncs its dependencies
+func (m *EnvironmentManager) reinstall(ctx context.Context) error {
+	if _, err := os.Stat(filepath.Join(m.ProjectPath, "pyproject.toml")); err != nil {
+		if err := os.MkdirAll(filepath.Dir(m.ProjectPath), 0755); err != nil {
+			return fmt.Errorf("failed to create environment directory: %w", err)
+		}
+		if err := os.RemoveAll(m.ProjectPath); err != nil {
+			return fmt.Errorf("failed to remove incomplete WhisperX project: %w", err)
+		}
+		if err := m.runLogged(ctx, "clone", "", "git", "clone", whisperXRepository, m.ProjectPath); err != nil {
+			return err
+		}
+		if err := adapters.PatchWhisperXDependencies(m.ProjectPath); err != nil {
+			return err
+		}
+	}
+	return m.runLogged(ctx, "sync", m.ProjectPath, m.UVPath, "sync", "--all-extras", "--dev", "--native-tls")
+}
+
+// runLogged runs a command, logging each line of its output
+func (m *EnvironmentManager) runLogged(ctx context.Context, step, dir, name string, args ...string) error {
+	cmd := execCommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	output := &logLineWriter{step: step}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	err := cmd.Run()
+	output.Flush()
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", step, err)
+	}
+	return nil
+}
+
+// logLineWriter logs each complete line written to it
+type logLineWriter struct {
+	step    string
+	mu      sync.Mutex
+	pending []byte
+}
+
+func (w *logLineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexAny(w.pending, "\r\n")
+		if i < 0 {
+			break
+		}
+		w.log(string(w.pending[:i]))
+		w.pending = w.pending[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush logs any final line without a newline
+func (w *logLineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.log(string(w.pending))
+	w.pending = nil
+}
+
+func (w *logLineWriter) log(line string) {
+	if line = strings.TrimSpace(line); line != "" {
+		logger.Info("WhisperX environment setup", "step", w.step, "output", line)
+	}
+}
@@ -0,0 +1,180 @@
+package transcription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scriberr/internal/config"
+	"scriberr/pkg/logger"
+)
+
+const healthyProbe = `{"packages": {"whisperx": "3.4.2", "torch": "2.7.1", "ctranslate2": "4.6.0", "pyannote.audio": null},
+"errors": {}, "torch": {"cuda_version": null, "cuda_available": false, "mps_built": false, "mps_available": false}}`
+
+// fakeEnvironmentUV makes execCommandContext run TestEnvironmentHelperProcess,
+// which prints probe as the environment probe output, or fails syncing when
+// syncExit is non-zero. It returns the commands run.
+func fakeEnvironmentUV(t *testing.T, probe string, syncExit int) *[]string {
+	t.Helper()
+	var commands []string
+	original := execCommandContext
+	execCommandContext = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		commands = append(commands, name+" "+arg[0])
+		cmd := exec.CommandContext(ctx, os.Args[0], "-test.run=TestEnvironmentHelperProcess")
+		cmd.Env = append(os.Environ(),
+			"GO_WANT_ENV_HELPER=1",
+			"ENV_COMMAND="+arg[0],
+			"ENV_PROBE="+strings.ReplaceAll(probe, "\n", " "),
+			fmt.Sprintf("ENV_SYNC_EXIT=%d", syncExit))
+		return cmd
+	}
+	t.Cleanup(func() { execCommandContext = original })
+	return &commands
+}
+
+// TestEnvironmentHelperProcess stands in for uv running the probe or syncing
+func TestEnvironmentHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_ENV_HELPER") != "1" {
+		return
+	}
+	switch os.Getenv("ENV_COMMAND") {
+	case "sync":
+		fmt.Println("Resolved 142 packages in 1.2s")
+		fmt.Fprint(os.Stderr, "Installed torch==2.7.1")
+		if os.Getenv("ENV_SYNC_EXIT") != "0" {
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "UserWarning: torchaudio backend is deprecated")
+		fmt.Println(os.Getenv("ENV_PROBE"))
+	}
+	os.Exit(0)
+}
+
+// newTestEnvironment creates a manager for a WhisperX project with a venv
+func newTestEnvironment(t *testing.T, device string) *EnvironmentManager {
+	project := filepath.Join(t.TempDir(), "WhisperX")
+	if err := os.MkdirAll(filepath.Join(project, ".venv"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, "pyproject.toml"), []byte("[project]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return NewEnvironmentManager("uv", project, config.Environment{OS: "linux", Arch: "amd64", SupportsNvidiaStack: true, DefaultWhisperDevice: device})
+}
+
+func TestEnvironmentCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		device   string
+		probe    string
+		state    string
+		problems []string
+	}{
+		{"healthy", "cpu", healthyProbe, EnvStateReady, nil},
+		{"wrong version", "cpu", strings.Replace(healthyProbe, `"ctranslate2": "4.6.0"`, `"ctranslate2": "4.4.0"`, 1), EnvStateBroken,
+			[]string{"ctranslate2 4.4.0 is installed but 4.6.0 is required"}},
+		{"import error", "cpu", strings.Replace(healthyProbe, `"errors": {}`, `"errors": {"whisperx": "ImportError: libcudnn.so.9"}`, 1), EnvStateBroken,
+			[]string{"Importing whisperx failed: ImportError: libcudnn.so.9"}},
+		{"missing package", "cpu", strings.Replace(healthyProbe, `"whisperx": "3.4.2"`, `"whisperx": null`, 1), EnvStateBroken,
+			[]string{"whisperx is not installed"}},
+		{"cpu torch on cuda host", "cuda", healthyProbe, EnvStateDegraded,
+			[]string{"The default device is CUDA but torch was built without CUDA"}},
+		{"unreadable output", "cpu", "Traceback", EnvStateBroken, []string{"The environment probe returned unreadable output: Traceback"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestEnvironment(t, tt.device)
+			fakeEnvironmentUV(t, tt.probe, 0)
+
+			status := m.Check(context.Background())
+			if status.State != tt.state {
+				t.Errorf("Expected state %s, got %s (%v)", tt.state, status.State, status.Problems)
+			}
+			if strings.Join(status.Problems, "; ") != strings.Join(tt.problems, "; ") {
+				t.Errorf("Expected problems %q, got %q", tt.problems, status.Problems)
+			}
+			if status.CheckedAt == nil || m.Status().State != tt.state {
+				t.Errorf("Expected the check result to be stored, got %+v", m.Status())
+			}
+		})
+	}
+}
+
+func TestEnvironmentCheckMissingVenv(t *testing.T) {
+	m := NewEnvironmentManager("uv", filepath.Join(t.TempDir(), "WhisperX"), config.Environment{})
+	commands := fakeEnvironmentUV(t, healthyProbe, 0)
+
+	status := m.Check(context.Background())
+	if status.State != EnvStateBroken || status.VenvExists {
+		t.Errorf("Expected a missing venv to be broken, got %+v", status)
+	}
+	if len(*commands) != 0 {
+		t.Errorf("Expected no probe without a venv, ran %v", *commands)
+	}
+}
+
+func TestEnvironmentRepair(t *testing.T) {
+	m := newTestEnvironment(t, "cpu")
+	commands := fakeEnvironmentUV(t, healthyProbe, 0)
+	var holds, releases int
+	m.HoldJobs = func() func() {
+		holds++
+		return func() { releases++ }
+	}
+
+	if err := m.Repair(context.Background()); err != nil {
+		t.Fatalf("Repair failed: %v", err)
+	}
+	if got := strings.Join(*commands, ", "); got != "uv sync, uv run" {
+		t.Errorf("Expected a sync then a probe, ran %s", got)
+	}
+	if holds != 1 || releases != 1 {
+		t.Errorf("Expected jobs to be held during the repair, got %d holds and %d releases", holds, releases)
+	}
+	status := m.Status()
+	if status.State != EnvStateReady || status.RepairedAt == nil || status.RepairError != "" {
+		t.Errorf("Expected a ready environment after the repair, got %+v", status)
+	}
+
+	var logged []string
+	for _, entry := range logger.Recent(0, 0) {
+		if entry.Message == "WhisperX environment setup" {
+			logged = append(logged, fmt.Sprint(entry.Fields["output"]))
+		}
+	}
+	if got := strings.Join(logged, " | "); !strings.Contains(got, "Resolved 142 packages in 1.2s") || !strings.Contains(got, "Installed torch==2.7.1") {
+		t.Errorf("Expected the sync output in the recent log entries, got %q", got)
+	}
+}
+
+func TestEnvironmentRepairFailure(t *testing.T) {
+	m := newTestEnvironment(t, "cpu")
+	fakeEnvironmentUV(t, healthyProbe, 1)
+
+	if err := m.Repair(context.Background()); err == nil {
+		t.Fatal("Expected the failed sync to be reported")
+	}
+	if status := m.Status(); status.RepairError == "" {
+		t.Errorf("Expected the repair error to be stored, got %+v", status)
+	}
+}
+
+func TestEnvironmentRepairInProgress(t *testing.T) {
+	m := newTestEnvironment(t, "cpu")
+	if err := m.beginRepair(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.StartRepair(context.Background()); !errors.Is(err, ErrRepairInProgress) {
+		t.Errorf("Expected ErrRepairInProgress, got %v", err)
+	}
+	if status := m.Check(context.Background()); status.State != EnvStateRepairing {
+		t.Errorf("Expected checks to leave a running repair alone, got %s", status.State)
+	}
+}
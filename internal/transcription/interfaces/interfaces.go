@@ -62,6 +62,8 @@ type TranscriptSegment struct {
 	Text     string  `json:"text"`
 	Speaker  *string `json:"speaker,omitempty"`
 	Language *string `json:"language,omitempty"`
+	Words    []TranscriptWord `json:"words"` // Aligned words; null when the model cannot align words
+	MaskedText string `json:"masked_text,omitempty"` // Text with low-confidence words masked; set only when a word was masked
 }
 
 // TranscriptWord represents word-level timing information
@@ -69,20 +71,30 @@ type TranscriptWord struct {
 	Start   float64 `json:"start"`
 	End     float64 `json:"end"`
 	Word    string  `json:"word"`
-	Score   float64 `json:"score"`
+	Score   float64 `json:"score,omitempty"` // Alignment confidence; omitted when the model does not report one
+	MaskedWord string `json:"masked_word,omitempty"` // Replacement shown for a word below the job's confidence threshold
 	Speaker *string `json:"speaker,omitempty"`
 }
 
+// TranscriptSpeaker summarizes one diarized speaker across a transcript
+type TranscriptSpeaker struct {
+	ID                string  `json:"id"`                  // Raw diarization label, e.g. "SPEAKER_00"
+	Label             string  `json:"label"`               // Display label, e.g. "Speaker 1"
+	TotalSpeakingTime float64 `json:"total_speaking_time"` // Seconds
+}
+
 // TranscriptResult represents the output of transcription
 type TranscriptResult struct {
 	Text         string             `json:"text"`
 	Language     string             `json:"language"`
 	Segments     []TranscriptSegment `json:"segments"`
 	WordSegments []TranscriptWord   `json:"word_segments,omitempty"`
+	Speakers     []TranscriptSpeaker `json:"speakers,omitempty"`
 	Confidence   float64            `json:"confidence"`
 	ProcessingTime time.Duration    `json:"processing_time"`
 	ModelUsed    string             `json:"model_used"`
 	Metadata     map[string]string  `json:"metadata"`
+	SchemaVersion string            `json:"schema_version,omitempty"` // Output format the result was parsed from, when known
 }
 
 // DiarizationSegment represents speaker diarization information
@@ -110,6 +122,16 @@ type ProcessingContext struct {
 	OutputDirectory string            `json:"output_directory"`
 	TempDirectory   string            `json:"temp_directory"`
 	Metadata        map[string]string `json:"metadata"`
+
+	// ProgressCallback receives the number of audio seconds processed so far
+	ProgressCallback func(processedSeconds float64) `json:"-"`
+}
+
+// ReportProgress forwards processed audio seconds to the progress callback, if any
+func (p ProcessingContext) ReportProgress(processedSeconds float64) {
+	if p.ProgressCallback != nil {
+		p.ProgressCallback(processedSeconds)
+	}
 }
 
 // ModelAdapter is the base interface that all model adapters must implement
@@ -147,6 +169,20 @@ type TranscriptionAdapter interface {
 	GetSupportedModels() []string
 }
 
+// LanguageDetection is the spoken language a model detected in audio
+type LanguageDetection struct {
+	Language   string  `json:"language"`   // Language code, e.g. "nb"
+	Confidence float64 `json:"confidence"` // Probability the model gave the language, 0.0 - 1.0
+}
+
+// LanguageDetector is implemented by transcription adapters that can detect
+// the spoken language before transcribing. Adapters that cannot detect the
+// language leave the language_detection feature out of their capabilities.
+type LanguageDetector interface {
+	// DetectLanguage detects the language from the start of the audio
+	DetectLanguage(ctx context.Context, input AudioInput, params map[string]interface{}) (*LanguageDetection, error)
+}
+
 // DiarizationAdapter handles speaker diarization
 type DiarizationAdapter interface {
 	ModelAdapter
@@ -0,0 +1,146 @@
+package transcription
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// mediaSniffLength is how much of a file SniffMediaType looks at
+const mediaSniffLength = 512
+
+// mediaSignature recognizes a file type by its first bytes
+type mediaSignature struct {
+	mimeType string
+	match    func(header []byte) bool
+}
+
+// prefixAt matches files with prefix at offset
+func prefixAt(offset int, prefix string) func([]byte) bool {
+	return func(header []byte) bool {
+		return len(header) >= offset+len(prefix) && string(header[offset:offset+len(prefix)]) == prefix
+	}
+}
+
+// riff matches RIFF and IFF containers of form type
+func riff(magic, form string) func([]byte) bool {
+	return func(header []byte) bool {
+		return prefixAt(0, magic)(header) && prefixAt(8, form)(header)
+	}
+}
+
+// ftyp matches ISO base media files whose major brand starts with one of brands
+func ftyp(brands ...string) func([]byte) bool {
+	return func(header []byte) bool {
+		if !prefixAt(4, "ftyp")(header) || len(header) < 12 {
+			return false
+		}
+		for _, brand := range brands {
+			if strings.HasPrefix(string(header[8:12]), brand) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// frameSync matches files starting with an MPEG audio frame: AAC in ADTS
+// when adts is set, whose layer bits are zero, and MP1-3 otherwise
+func frameSync(adts bool) func([]byte) bool {
+	return func(header []byte) bool {
+		if len(header) < 2 || header[0] != 0xFF || header[1]&0xE0 != 0xE0 {
+			return false
+		}
+		return (header[1]&0x06 == 0) == adts
+	}
+}
+
+// mediaSignatures lists the audio and video types accepted for upload, most
+// specific first
+var mediaSignatures = []mediaSignature{
+	{"audio/mpeg", prefixAt(0, "ID3")},
+	{"audio/aac", frameSync(true)},
+	{"audio/mpeg", frameSync(false)},
+	{"audio/wav", riff("RIFF", "WAVE")},
+	{"audio/wav", riff("RF64", "WAVE")},
+	{"video/x-msvideo", riff("RIFF", "AVI ")},
+	{"audio/aiff", riff("FORM", "AIFF")},
+	{"audio/aiff", riff("FORM", "AIFC")},
+	{"audio/flac", prefixAt(0, "fLaC")},
+	{"audio/ogg", prefixAt(0, "OggS")},
+	{"audio/amr", prefixAt(0, "#!AMR")},
+	{"audio/x-dss", prefixAt(0, "\x02dss")},
+	{"audio/x-dss", prefixAt(0, "\x03ds2")},
+	{"audio/mp4", ftyp("M4A", "M4B")},
+	{"video/quicktime", ftyp("qt")},
+	{"video/3gpp", ftyp("3gp", "3g2")},
+	{"video/mp4", ftyp("")},
+	{"video/webm", func(header []byte) bool {
+		return prefixAt(0, "\x1A\x45\xDF\xA3")(header) && bytes.Contains(header, []byte("webm"))
+	}},
+	{"video/x-matroska", prefixAt(0, "\x1A\x45\xDF\xA3")},
+	{"video/x-ms-asf", prefixAt(0, "\x30\x26\xB2\x75\x8E\x66\xCF\x11")},
+	{"video/x-flv", prefixAt(0, "FLV")},
+}
+
+// SniffMediaType returns the MIME type of an audio or video file from its
+// first bytes, or "" when they are not those of a type accepted for upload.
+// Clients' Content-Type headers are not trusted for this.
+func SniffMediaType(header []byte) string {
+	for _, signature := range mediaSignatures {
+		if signature.match(header) {
+			return signature.mimeType
+		}
+	}
+	return ""
+}
+
+// CheckMediaType checks that an uploaded file named name has an accepted
+// extension and that r, its content, starts like an accepted audio or video
+// type, returning a *ValidationError if not. It reads up to 512 bytes of r.
+func CheckMediaType(name string, r io.Reader) error {
+	if !IsSupportedMediaFile(name) {
+		return &ValidationError{Code: ValidationUnsupportedType, Message: "Unsupported file type"}
+	}
+	header := make([]byte, mediaSniffLength)
+	n, err := io.ReadFull(r, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+	if SniffMediaType(header[:n]) == "" {
+		return &ValidationError{Code: ValidationUnsupportedType, Message: "The file is not audio or video"}
+	}
+	return nil
+}
+
+// CheckMediaFile checks a saved upload like CheckMediaType
+func CheckMediaFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return CheckMediaType(path, file)
+}
+
+// MediaExtensions returns the file extensions accepted for upload, sorted
+func MediaExtensions() []string {
+	extensions := make([]string, 0, len(mediaExtensions))
+	for ext := range mediaExtensions {
+		extensions = append(extensions, ext)
+	}
+	slices.Sort(extensions)
+	return extensions
+}
+
+// MediaTypes returns the MIME types accepted for upload, sorted
+func MediaTypes() []string {
+	var types []string
+	for _, signature := range mediaSignatures {
+		types = append(types, signature.mimeType)
+	}
+	slices.Sort(types)
+	return slices.Compact(types)
+}
@@ -0,0 +1,77 @@
+package transcription
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSniffMediaType(t *testing.T) {
+	tests := map[string]string{
+		"ID3\x04\x00\x00\x00\x00\x00\x00":          "audio/mpeg",
+		"\xFF\xFB\x90\x64":                         "audio/mpeg",
+		"\xFF\xF1\x50\x80":                         "audio/aac",
+		"RIFF\x24\x00\x00\x00WAVEfmt ":             "audio/wav",
+		"RIFF\x24\x00\x00\x00AVI LIST":             "video/x-msvideo",
+		"FORM\x00\x00\x00\x00AIFFCOMM":             "audio/aiff",
+		"fLaC\x00\x00\x00\x22":                     "audio/flac",
+		"OggS\x00\x02\x00\x00":                     "audio/ogg",
+		"#!AMR\n":                                  "audio/amr",
+		"\x00\x00\x00\x20ftypM4A \x00\x00\x00\x00": "audio/mp4",
+		"\x00\x00\x00\x14ftypqt  \x00\x00\x00\x00": "video/quicktime",
+		"\x00\x00\x00\x20ftypisom\x00\x00\x02\x00": "video/mp4",
+		"\x1A\x45\xDF\xA3\x9F\x42\x82\x84webm":     "video/webm",
+		"\x1A\x45\xDF\xA3\xA3\x42\x82\x88matroska": "video/x-matroska",
+		"\x30\x26\xB2\x75\x8E\x66\xCF\x11\xA6\xD9": "video/x-ms-asf",
+		"%PDF-1.7":             "",
+		"dummy audio data":     "",
+		"<html><body>":         "",
+		"":                     "",
+		"\x00\x00\x00\x20ftyp": "",
+	}
+	for header, want := range tests {
+		if got := SniffMediaType([]byte(header)); got != want {
+			t.Errorf("SniffMediaType(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestCheckMediaType(t *testing.T) {
+	var validationErr *ValidationError
+	if err := CheckMediaType("notes.txt", strings.NewReader("ID3\x04")); !errors.As(err, &validationErr) || validationErr.Code != ValidationUnsupportedType {
+		t.Errorf("Expected an unsupported extension to be rejected, got %v", err)
+	}
+	// The Content-Type a client claims plays no part, only the content
+	if err := CheckMediaType("talk.mp3", strings.NewReader("<?php echo 1; ?>")); !errors.As(err, &validationErr) || validationErr.Code != ValidationUnsupportedType {
+		t.Errorf("Expected content that is not audio to be rejected, got %v", err)
+	}
+	if err := CheckMediaType("talk.MP3", strings.NewReader("ID3\x04\x00")); err != nil {
+		t.Errorf("Expected an MP3 to be accepted, got %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "tone.wav")
+	if err := os.WriteFile(path, []byte("RIFF\x24\x00\x00\x00WAVEfmt "), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckMediaFile(path); err != nil {
+		t.Errorf("Expected a WAV file to be accepted, got %v", err)
+	}
+	if err := CheckMediaFile(filepath.Join(t.TempDir(), "missing.wav")); err == nil || errors.As(err, &validationErr) {
+		t.Errorf("Expected a read error for a missing file, got %v", err)
+	}
+}
+
+func TestMediaTypesListed(t *testing.T) {
+	types := MediaTypes()
+	for i := 1; i < len(types); i++ {
+		if types[i-1] >= types[i] {
+			t.Fatalf("Expected sorted, unique types, got %v", types)
+		}
+	}
+	extensions := MediaExtensions()
+	if len(extensions) != len(mediaExtensions) || extensions[0] != ".3gp" {
+		t.Errorf("Expected every extension sorted, got %v", extensions)
+	}
+}
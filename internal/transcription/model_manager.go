@@ -0,0 +1,393 @@
+package transcription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"scriberr/internal/diskspace"
+)
+
+var (
+	// ErrUnknownModel is returned for model names WhisperX does not support
+	ErrUnknownModel = errors.New("unknown model")
+	// ErrModelNotCached is returned when deleting a model that is not downloaded
+	ErrModelNotCached = errors.New("model is not downloaded")
+	// ErrModelBusy is returned when deleting a model that is being downloaded,
+	// or downloading one that is being deleted
+	ErrModelBusy = errors.New("model is busy")
+)
+
+// whisperModel is a Whisper model WhisperX can load, with the Hugging Face
+// repository faster-whisper downloads it from
+type whisperModel struct {
+	name string
+	repo string
+	size int64 // Approximate download size in bytes
+}
+
+// whisperModels lists the models WhisperX accepts, in the order they are shown
+var whisperModels = []whisperModel{
+	{"tiny", "Systran/faster-whisper-tiny", 75_000_000},
+	{"tiny.en", "Systran/faster-whisper-tiny.en", 75_000_000},
+	{"base", "Systran/faster-whisper-base", 145_000_000},
+	{"base.en", "Systran/faster-whisper-base.en", 145_000_000},
+	{"small", "Systran/faster-whisper-small", 484_000_000},
+	{"small.en", "Systran/faster-whisper-small.en", 484_000_000},
+	{"medium", "Systran/faster-whisper-medium", 1_530_000_000},
+	{"medium.en", "Systran/faster-whisper-medium.en", 1_530_000_000},
+	{"large", "Systran/faster-whisper-large-v3", 3_090_000_000},
+	{"large-v1", "Systran/faster-whisper-large-v1", 3_090_000_000},
+	{"large-v2", "Systran/faster-whisper-large-v2", 3_090_000_000},
+	{"large-v3", "Systran/faster-whisper-large-v3", 3_090_000_000},
+}
+
+// downloadModelScript fetches a model with faster-whisper's own downloader, so
+// it lands exactly where WhisperX looks for it. WhisperX's CLI has no
+// download-only mode.
+const downloadModelScript = "import sys; from faster_whisper.utils import download_model; download_model(sys.argv[1])"
+
+// modelProgressInterval is how often download progress is measured
+const modelProgressInterval = 500 * time.Millisecond
+
+// ModelInfo describes a WhisperX model and whether it is downloaded
+type ModelInfo struct {
+	Name        string `json:"name"`
+	Size        int64  `json:"size"` // Size on disk if cached, otherwise the approximate download size
+	Cached      bool   `json:"cached"`
+	Downloading bool   `json:"downloading"`
+	Path        string `json:"path"` // Cache directory the model is or will be stored in
+}
+
+// ModelManager lists, downloads and deletes the Whisper models in the Hugging
+// Face cache WhisperX loads them from
+type ModelManager struct {
+	UVPath      string
+	ProjectPath string // WhisperX uv project
+	CacheDir    string // Hugging Face hub cache
+
+	mu        sync.Mutex
+	busy      map[string]bool           // Repositories being deleted
+	downloads map[string]*modelDownload // Downloads in flight, by repository
+}
+
+// modelDownload is a download shared by every caller that needs the model
+// while it runs. It is cancelled when the last of them gives up.
+type modelDownload struct {
+	done    chan struct{}
+	err     error // Set before done is closed
+	cancel  context.CancelFunc
+	waiters int
+
+	listenersMu sync.Mutex
+	listeners   map[int]func(downloaded, total int64)
+	nextID      int
+}
+
+// subscribe registers a progress callback and returns its id
+func (d *modelDownload) subscribe(progress func(downloaded, total int64)) int {
+	d.listenersMu.Lock()
+	defer d.listenersMu.Unlock()
+	d.nextID++
+	if progress != nil {
+		d.listeners[d.nextID] = progress
+	}
+	return d.nextID
+}
+
+// unsubscribe removes a progress callback; it is not called again once this returns
+func (d *modelDownload) unsubscribe(id int) {
+	d.listenersMu.Lock()
+	defer d.listenersMu.Unlock()
+	delete(d.listeners, id)
+}
+
+func (d *modelDownload) report(downloaded, total int64) {
+	d.listenersMu.Lock()
+	defer d.listenersMu.Unlock()
+	for _, progress := range d.listeners {
+		progress(downloaded, total)
+	}
+}
+
+// NewModelManager creates a model manager for the WhisperX project at
+// projectPath, using the Hugging Face cache location from the environment
+func NewModelManager(uvPath, projectPath string) *ModelManager {
+	return &ModelManager{
+		UVPath:      uvPath,
+		ProjectPath: projectPath,
+		CacheDir:    huggingFaceCacheDir(),
+		busy:        make(map[string]bool),
+		downloads:   make(map[string]*modelDownload),
+	}
+}
+
+// huggingFaceCacheDir resolves the hub cache the same way huggingface_hub does
+func huggingFaceCacheDir() string {
+	if dir := os.Getenv("HF_HUB_CACHE"); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("HF_HOME"); dir != "" {
+		return filepath.Join(dir, "hub")
+	}
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "huggingface", "hub")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".cache", "huggingface", "hub")
+	}
+	return filepath.Join(home, ".cache", "huggingface", "hub")
+}
+
+// ListModels reports every supported model and whether it is downloaded
+func (m *ModelManager) ListModels() ([]ModelInfo, error) {
+	infos := make([]ModelInfo, 0, len(whisperModels))
+	for _, model := range whisperModels {
+		info := ModelInfo{Name: model.name, Size: model.size, Path: m.repoDir(model)}
+		cached, err := m.isCached(model)
+		if err != nil {
+			return nil, err
+		}
+		if cached {
+			info.Cached = true
+			info.Size = diskspace.PathSize(m.repoDir(model))
+		}
+		m.mu.Lock()
+		_, info.Downloading = m.downloads[model.repo]
+		m.mu.Unlock()
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// Supports reports whether name is a model the manager can download
+func (m *ModelManager) Supports(name string) bool {
+	_, ok := findWhisperModel(name)
+	return ok
+}
+
+// IsCached reports whether a model is downloaded
+func (m *ModelManager) IsCached(name string) (bool, error) {
+	model, ok := findWhisperModel(name)
+	if !ok {
+		return false, fmt.Errorf("%w: %s", ErrUnknownModel, name)
+	}
+	return m.isCached(model)
+}
+
+// DownloadModel downloads a model into the cache
+func (m *ModelManager) DownloadModel(ctx context.Context, name string) error {
+	return m.DownloadModelWithProgress(ctx, name, nil)
+}
+
+// DownloadModelWithProgress downloads a model into the cache, periodically
+// calling progress with the bytes downloaded so far and the expected total.
+// Callers asking for a model that is already downloading wait for that
+// download instead of starting another. Cancelling ctx stops waiting; the
+// download itself stops once no caller is waiting for it.
+func (m *ModelManager) DownloadModelWithProgress(ctx context.Context, name string, progress func(downloaded, total int64)) error {
+	model, ok := findWhisperModel(name)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownModel, name)
+	}
+
+	m.mu.Lock()
+	if m.busy[model.repo] {
+		m.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrModelBusy, model.name)
+	}
+	if m.downloads == nil {
+		m.downloads = make(map[string]*modelDownload)
+	}
+	download, ok := m.downloads[model.repo]
+	if !ok {
+		downloadCtx, cancel := context.WithCancel(context.Background())
+		download = &modelDownload{
+			done:      make(chan struct{}),
+			cancel:    cancel,
+			listeners: make(map[int]func(downloaded, total int64)),
+		}
+		m.downloads[model.repo] = download
+		go m.runDownload(downloadCtx, model, download)
+	}
+	download.waiters++
+	id := download.subscribe(progress)
+	m.mu.Unlock()
+
+	select {
+	case <-download.done:
+		download.unsubscribe(id)
+		return download.err
+	case <-ctx.Done():
+		download.unsubscribe(id)
+		m.mu.Lock()
+		download.waiters--
+		if download.waiters == 0 {
+			download.cancel()
+			// Let the next caller start afresh rather than join a cancelled download
+			if m.downloads[model.repo] == download {
+				delete(m.downloads, model.repo)
+			}
+		}
+		m.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// runDownload runs a shared download and records its result
+func (m *ModelManager) runDownload(ctx context.Context, model whisperModel, download *modelDownload) {
+	err := m.download(ctx, model, download.report)
+	download.cancel()
+
+	m.mu.Lock()
+	if m.downloads[model.repo] == download {
+		delete(m.downloads, model.repo)
+	}
+	m.mu.Unlock()
+	download.err = err
+	close(download.done)
+}
+
+// download fetches a model with uv, reporting progress until it finishes
+func (m *ModelManager) download(ctx context.Context, model whisperModel, progress func(downloaded, total int64)) error {
+	cmd := execCommandContext(ctx, m.UVPath, "run", "--native-tls", "--project", m.ProjectPath,
+		"python", "-c", downloadModelScript, model.name)
+	cmd.Env = append(cmd.Environ(), "HF_HUB_CACHE="+m.CacheDir)
+	var output tailWriter
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start model download: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	ticker := time.NewTicker(modelProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return fmt.Errorf("model download failed: %w: %s", err, output.String())
+			}
+			if cached, _ := m.isCached(model); !cached {
+				return fmt.Errorf("model download finished without model files: %s", output.String())
+			}
+			size := diskspace.PathSize(m.repoDir(model))
+			progress(size, size)
+			return nil
+		case <-ticker.C:
+			size := diskspace.PathSize(m.repoDir(model))
+			if size > model.size {
+				size = model.size
+			}
+			progress(size, model.size)
+		}
+	}
+}
+
+// DeleteModel removes a downloaded model from the cache
+func (m *ModelManager) DeleteModel(ctx context.Context, name string) error {
+	model, ok := findWhisperModel(name)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownModel, name)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := m.acquire(model); err != nil {
+		return err
+	}
+	defer m.release(model)
+
+	if _, err := os.Stat(m.repoDir(model)); errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrModelNotCached, name)
+	}
+	if err := os.RemoveAll(m.repoDir(model)); err != nil {
+		return fmt.Errorf("failed to delete model: %w", err)
+	}
+	return nil
+}
+
+// acquire marks a model's repository busy, failing if it is already being
+// downloaded or deleted. Aliases such as large and large-v3 share a repository.
+func (m *ModelManager) acquire(model whisperModel) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.busy == nil {
+		m.busy = make(map[string]bool)
+	}
+	if _, downloading := m.downloads[model.repo]; downloading || m.busy[model.repo] {
+		return fmt.Errorf("%w: %s", ErrModelBusy, model.name)
+	}
+	m.busy[model.repo] = true
+	return nil
+}
+
+func (m *ModelManager) release(model whisperModel) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.busy, model.repo)
+}
+
+// repoDir is the cache directory of a model's repository
+func (m *ModelManager) repoDir(model whisperModel) string {
+	return filepath.Join(m.CacheDir, "models--"+strings.ReplaceAll(model.repo, "/", "--"))
+}
+
+// isCached reports whether a snapshot of the model's weights is in the cache
+func (m *ModelManager) isCached(model whisperModel) (bool, error) {
+	matches, err := filepath.Glob(filepath.Join(m.repoDir(model), "snapshots", "*", "model.bin"))
+	if err != nil {
+		return false, err
+	}
+	for _, match := range matches {
+		// Snapshot files are symlinks into blobs; a dangling link is not a model
+		if _, err := os.Stat(match); err == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func findWhisperModel(name string) (whisperModel, bool) {
+	for _, model := range whisperModels {
+		if model.name == name {
+			return model, true
+		}
+	}
+	return whisperModel{}, false
+}
+
+// tailWriter keeps the last part of a command's output for error messages
+type tailWriter struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (t *tailWriter) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if extra := len(t.buf) - 2048; extra > 0 {
+		t.buf = t.buf[extra:]
+	}
+	return len(p), nil
+}
+
+func (t *tailWriter) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return strings.TrimSpace(string(t.buf))
+}
@@ -0,0 +1,262 @@
+package transcription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeUV makes execCommandContext run TestUVHelperProcess, which stands in for
+// uv downloading a model into the Hugging Face cache, or failing with exitCode
+func fakeUV(t *testing.T, exitCode int) *[]string {
+	t.Helper()
+	var args []string
+	original := execCommandContext
+	execCommandContext = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		args = append([]string{name}, arg...)
+		cmd := exec.CommandContext(ctx, os.Args[0], "-test.run=TestUVHelperProcess")
+		cmd.Env = append(os.Environ(),
+			"GO_WANT_UV_HELPER=1",
+			"UV_MODEL="+arg[len(arg)-1],
+			fmt.Sprintf("UV_EXIT=%d", exitCode))
+		return cmd
+	}
+	t.Cleanup(func() { execCommandContext = original })
+	return &args
+}
+
+// TestUVHelperProcess writes a model snapshot the way huggingface_hub lays it out
+func TestUVHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_UV_HELPER") != "1" {
+		return
+	}
+	if delay, err := time.ParseDuration(os.Getenv("UV_DELAY")); err == nil {
+		time.Sleep(delay)
+	}
+	if os.Getenv("UV_EXIT") != "0" {
+		fmt.Fprint(os.Stderr, "huggingface_hub.errors.LocalEntryNotFoundError: cannot reach huggingface.co")
+		os.Exit(1)
+	}
+	model, _ := findWhisperModel(os.Getenv("UV_MODEL"))
+	repo := filepath.Join(os.Getenv("HF_HUB_CACHE"), "models--"+strings.ReplaceAll(model.repo, "/", "--"))
+	writeSnapshot(repo)
+	os.Exit(0)
+}
+
+// writeSnapshot creates a cached model repository with a 1 KB model.bin
+func writeSnapshot(repo string) {
+	blobs := filepath.Join(repo, "blobs")
+	snapshot := filepath.Join(repo, "snapshots", "0123abcd")
+	if err := os.MkdirAll(blobs, 0755); err != nil {
+		panic(err)
+	}
+	if err := os.MkdirAll(snapshot, 0755); err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile(filepath.Join(blobs, "f00d"), make([]byte, 1024), 0644); err != nil {
+		panic(err)
+	}
+	if err := os.Symlink(filepath.Join("..", "..", "blobs", "f00d"), filepath.Join(snapshot, "model.bin")); err != nil {
+		panic(err)
+	}
+}
+
+// slowUV wraps fakeUV so each download takes delay, counting the downloads started
+func slowUV(t *testing.T, delay time.Duration) *int32 {
+	t.Helper()
+	fakeUV(t, 0)
+	var calls int32
+	inner := execCommandContext
+	execCommandContext = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		atomic.AddInt32(&calls, 1)
+		cmd := inner(ctx, name, arg...)
+		cmd.Env = append(cmd.Env, "UV_DELAY="+delay.String())
+		return cmd
+	}
+	return &calls
+}
+
+func newTestModelManager(t *testing.T) *ModelManager {
+	m := NewModelManager("uv", "whisperx-env/WhisperX")
+	m.CacheDir = t.TempDir()
+	return m
+}
+
+func findModelInfo(infos []ModelInfo, name string) (ModelInfo, bool) {
+	for _, info := range infos {
+		if info.Name == name {
+			return info, true
+		}
+	}
+	return ModelInfo{}, false
+}
+
+func TestModelManagerListModels(t *testing.T) {
+	m := newTestModelManager(t)
+	writeSnapshot(filepath.Join(m.CacheDir, "models--Systran--faster-whisper-small"))
+
+	infos, err := m.ListModels()
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+	if len(infos) != len(whisperModels) {
+		t.Fatalf("Expected %d models, got %d", len(whisperModels), len(infos))
+	}
+	small, _ := findModelInfo(infos, "small")
+	if !small.Cached || small.Size != 1024 {
+		t.Errorf("Expected small to be cached with its size on disk, got %+v", small)
+	}
+	tiny, _ := findModelInfo(infos, "tiny")
+	if tiny.Cached || tiny.Size == 0 {
+		t.Errorf("Expected tiny to be uncached with an estimated size, got %+v", tiny)
+	}
+}
+
+func TestModelManagerDownloadModel(t *testing.T) {
+	m := newTestModelManager(t)
+	args := fakeUV(t, 0)
+
+	var downloaded, total int64
+	err := m.DownloadModelWithProgress(context.Background(), "large", func(d, tot int64) {
+		downloaded, total = d, tot
+	})
+	if err != nil {
+		t.Fatalf("DownloadModel failed: %v", err)
+	}
+	want := "uv run --native-tls --project whisperx-env/WhisperX python -c " + downloadModelScript + " large"
+	if got := strings.Join(*args, " "); got != want {
+		t.Errorf("Unexpected uv invocation:\n got %s\nwant %s", got, want)
+	}
+	if downloaded != 1024 || total != 1024 {
+		t.Errorf("Expected final progress 1024/1024, got %d/%d", downloaded, total)
+	}
+
+	// large is an alias of large-v3, so both show as cached
+	infos, err := m.ListModels()
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+	for _, name := range []string{"large", "large-v3"} {
+		if info, _ := findModelInfo(infos, name); !info.Cached {
+			t.Errorf("Expected %s to be cached", name)
+		}
+	}
+}
+
+func TestModelManagerDownloadFailure(t *testing.T) {
+	m := newTestModelManager(t)
+	fakeUV(t, 1)
+
+	err := m.DownloadModel(context.Background(), "tiny")
+	if err == nil || !strings.Contains(err.Error(), "cannot reach huggingface.co") {
+		t.Errorf("Expected the error to include uv's output, got %v", err)
+	}
+}
+
+func TestModelManagerUnknownModel(t *testing.T) {
+	m := newTestModelManager(t)
+	fakeUV(t, 0)
+
+	if err := m.DownloadModel(context.Background(), "gigantic"); !errors.Is(err, ErrUnknownModel) {
+		t.Errorf("Expected ErrUnknownModel, got %v", err)
+	}
+	if err := m.DeleteModel(context.Background(), "../etc"); !errors.Is(err, ErrUnknownModel) {
+		t.Errorf("Expected ErrUnknownModel, got %v", err)
+	}
+}
+
+func TestModelManagerBusyModel(t *testing.T) {
+	m := newTestModelManager(t)
+	fakeUV(t, 0)
+
+	if err := m.acquire(whisperModels[len(whisperModels)-1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DownloadModel(context.Background(), "large"); !errors.Is(err, ErrModelBusy) {
+		t.Errorf("Expected ErrModelBusy while large-v3 is downloading, got %v", err)
+	}
+}
+
+func TestModelManagerDeleteModel(t *testing.T) {
+	m := newTestModelManager(t)
+	repo := filepath.Join(m.CacheDir, "models--Systran--faster-whisper-base.en")
+	writeSnapshot(repo)
+
+	if err := m.DeleteModel(context.Background(), "base.en"); err != nil {
+		t.Fatalf("DeleteModel failed: %v", err)
+	}
+	if _, err := os.Stat(repo); !os.IsNotExist(err) {
+		t.Errorf("Expected the model to be removed, got %v", err)
+	}
+	if err := m.DeleteModel(context.Background(), "base.en"); !errors.Is(err, ErrModelNotCached) {
+		t.Errorf("Expected ErrModelNotCached, got %v", err)
+	}
+}
+
+func TestModelManagerSharedDownload(t *testing.T) {
+	m := newTestModelManager(t)
+	calls := slowUV(t, 300*time.Millisecond)
+
+	// large and large-v3 share a repository, so both callers wait on one download
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, name := range []string{"large", "large-v3"} {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			errs[i] = m.DownloadModel(context.Background(), name)
+		}(i, name)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("Download %d failed: %v", i, err)
+		}
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("Expected one download, got %d", got)
+	}
+	if err := m.DeleteModel(context.Background(), "large"); err != nil {
+		t.Errorf("Expected the model to be deletable once downloaded, got %v", err)
+	}
+}
+
+func TestModelManagerDownloadOutlivesCancelledWaiter(t *testing.T) {
+	m := newTestModelManager(t)
+	calls := slowUV(t, 300*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() { first <- m.DownloadModel(ctx, "base") }()
+	time.Sleep(50 * time.Millisecond)
+
+	second := make(chan error, 1)
+	go func() { second <- m.DownloadModel(context.Background(), "base") }()
+	time.Sleep(50 * time.Millisecond)
+	if err := m.DeleteModel(context.Background(), "base"); !errors.Is(err, ErrModelBusy) {
+		t.Errorf("Expected ErrModelBusy while downloading, got %v", err)
+	}
+	cancel()
+
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the cancelled caller to stop waiting, got %v", err)
+	}
+	if err := <-second; err != nil {
+		t.Errorf("Expected the download to finish for the remaining caller, got %v", err)
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("Expected one download, got %d", got)
+	}
+	if cached, _ := m.IsCached("base"); !cached {
+		t.Error("Expected base to be cached")
+	}
+}
@@ -175,6 +175,7 @@ func (mt *MultiTrackTranscriber) ProcessMultiTrackTranscription(ctx context.Cont
 		"merge_duration_ms", mergeDuration)
 
 	// Serialize merged transcript to JSON
+	CompactTranscript(mergedTranscript)
 	mergedTranscriptJSON, err := json.Marshal(mergedTranscript)
 	if err != nil {
 		return fmt.Errorf("failed to serialize merged transcript: %w", err)
@@ -199,6 +200,7 @@ func (mt *MultiTrackTranscriber) ProcessMultiTrackTranscription(ctx context.Cont
 		"transcript":             &mergedTranscriptStr,
 		"individual_transcripts": &individualTranscriptsStr,
 		"status":                 models.StatusCompleted,
+		"detected_language":      detectedLanguage(mergedTranscript),
 	}
 
 	if err := mt.db.Model(&models.TranscriptionJob{}).Where("id = ?", jobID).Updates(updates).Error; err != nil {
@@ -240,8 +242,11 @@ func (mt *MultiTrackTranscriber) transcribeIndividualTrack(ctx context.Context,
 	if trackParams.Device == "" {
 		trackParams.Device = "cpu"
 	}
+	if trackParams.BatchSize == 0 {
+		trackParams.BatchSize = DefaultBatchSizeForDevice(trackParams.Device)
+	}
 	if trackParams.ComputeType == "" {
-		trackParams.ComputeType = "float32"
+		trackParams.ComputeType = models.DefaultComputeType(trackParams.Device)
 	}
 	if trackParams.Task == "" {
 		trackParams.Task = "transcribe"
@@ -0,0 +1,187 @@
+package transcription
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+)
+
+// Normalized audio is 16 kHz mono 16-bit PCM WAV, what speech models expect
+const (
+	normalizedFormat     = "wav"
+	normalizedCodec      = "pcm_s16le"
+	normalizedSampleRate = 16000
+	normalizedChannels   = 1
+)
+
+// stderrTailBytes is how much of ffmpeg's error output is kept for job errors
+const stderrTailBytes = 2048
+
+// ErrFFmpegUnavailable is returned when ffmpeg is not installed, so files
+// cannot be normalized
+var ErrFFmpegUnavailable = errors.New("ffmpeg is not available")
+
+// nativeCodecs lists, per ffprobe container name, the codecs every
+// transcription backend reads without conversion. PCM in WAV is matched by
+// prefix.
+var nativeCodecs = map[string]map[string]bool{
+	"wav":                     {},
+	"mp3":                     {"mp3": true, "mp3float": true},
+	"flac":                    {"flac": true},
+	"ogg":                     {"vorbis": true, "opus": true},
+	"mov,mp4,m4a,3gp,3g2,mj2": {"aac": true, "alac": true},
+}
+
+// NeedsNormalization reports whether audio in a container and codec must be
+// transcoded before transcription
+func NeedsNormalization(format, codec string) bool {
+	codecs, ok := nativeCodecs[format]
+	if !ok {
+		return true
+	}
+	if format == "wav" {
+		return !strings.HasPrefix(codec, "pcm_")
+	}
+	return !codecs[codec]
+}
+
+// FFmpegError carries the end of ffmpeg's error output for a failed run
+type FFmpegError struct {
+	Stderr string
+	Err    error
+}
+
+func (e *FFmpegError) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("ffmpeg failed: %v", e.Err)
+	}
+	return fmt.Sprintf("ffmpeg failed: %v: %s", e.Err, e.Stderr)
+}
+
+func (e *FFmpegError) Unwrap() error {
+	return e.Err
+}
+
+// NormalizeAudio transcodes the first audio stream of src to 16 kHz mono WAV
+// at dst
+func NormalizeAudio(ctx context.Context, src, dst string) error {
+	return runFFmpeg(ctx, dst, "-i", src)
+}
+
+// PreprocessAudio writes src to dst as 16 kHz mono WAV with the given ffmpeg
+// audio filters applied
+func PreprocessAudio(ctx context.Context, src, dst, filters string) error {
+	return runFFmpeg(ctx, dst, "-i", src, "-af", filters)
+}
+
+// runFFmpeg writes the first audio stream of the input described by
+// inputArgs to dst as 16 kHz mono WAV, removing dst if ffmpeg fails
+func runFFmpeg(ctx context.Context, dst string, inputArgs ...string) error {
+	args := append([]string{"-nostdin", "-v", "error", "-y"}, inputArgs...)
+	args = append(args,
+		"-vn",
+		"-map", "0:a:0",
+		"-ac", fmt.Sprint(normalizedChannels),
+		"-ar", fmt.Sprint(normalizedSampleRate),
+		"-c:a", normalizedCodec,
+		dst)
+	cmd := execCommandContext(ctx, "ffmpeg", args...)
+	ConfigureCmdSysProcAttr(cmd)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(dst)
+		if errors.Is(err, exec.ErrNotFound) {
+			return ErrFFmpegUnavailable
+		}
+		return &FFmpegError{Stderr: stderrTail(stderr.String()), Err: err}
+	}
+	return nil
+}
+
+// stderrTail returns the last stderrTailBytes of tool output
+func stderrTail(output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) > stderrTailBytes {
+		output = output[len(output)-stderrTailBytes:]
+	}
+	return output
+}
+
+// ApplyAudioInfo records probed audio properties on a job
+func ApplyAudioInfo(job *models.TranscriptionJob, info *AudioInfo) {
+	job.AudioFormat = &info.Format
+	job.AudioCodec = &info.Codec
+	job.SampleRate = &info.SampleRate
+	job.Channels = &info.Channels
+	if info.DurationSeconds > 0 {
+		job.DurationSeconds = &info.DurationSeconds
+	}
+}
+
+// NormalizeJobAudio transcodes a job's audio to 16 kHz mono WAV if the
+// pipeline cannot read it directly, replacing the original file and updating
+// the job. Jobs are left unchanged when ffprobe or ffmpeg are not installed.
+func NormalizeJobAudio(ctx context.Context, job *models.TranscriptionJob) error {
+	if job.AudioFormat == nil || job.AudioCodec == nil {
+		info, err := ProbeAudio(ctx, job.AudioPath)
+		if errors.Is(err, ErrFFprobeUnavailable) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		ApplyAudioInfo(job, info)
+	}
+	if !NeedsNormalization(*job.AudioFormat, *job.AudioCodec) {
+		return nil
+	}
+
+	src := job.AudioPath
+	dst := strings.TrimSuffix(src, filepath.Ext(src)) + ".wav"
+	if dst == src {
+		dst = strings.TrimSuffix(src, filepath.Ext(src)) + "_normalized.wav"
+	}
+	logger.Info("Normalizing audio", "job_id", job.ID, "format", *job.AudioFormat, "codec", *job.AudioCodec)
+	if err := NormalizeAudio(ctx, src, dst); err != nil {
+		if errors.Is(err, ErrFFmpegUnavailable) {
+			logger.Warn("Skipping audio normalization, ffmpeg is not installed", "job_id", job.ID)
+			return nil
+		}
+		return fmt.Errorf("audio normalization failed: %w", err)
+	}
+
+	info := &AudioInfo{
+		Format:     normalizedFormat,
+		Codec:      normalizedCodec,
+		SampleRate: normalizedSampleRate,
+		Channels:   normalizedChannels,
+	}
+	ApplyAudioInfo(job, info)
+	job.AudioPath = dst
+	if err := database.DB.WithContext(ctx).Model(&models.TranscriptionJob{}).Where("id = ?", job.ID).
+		Updates(map[string]interface{}{
+			"audio_path":   job.AudioPath,
+			"audio_format": job.AudioFormat,
+			"audio_codec":  job.AudioCodec,
+			"sample_rate":  job.SampleRate,
+			"channels":     job.Channels,
+		}).Error; err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("failed to record normalized audio: %w", err)
+	}
+	if err := os.Remove(src); err != nil {
+		logger.Warn("Failed to remove original audio", "path", src, "error", err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package transcription
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scriberr/internal/models"
+	"scriberr/internal/transcription/interfaces"
+)
+
+func TestNeedsNormalization(t *testing.T) {
+	tests := []struct {
+		format, codec string
+		want          bool
+	}{
+		{"wav", "pcm_s16le", false},
+		{"wav", "adpcm_ima_wav", true},
+		{"mp3", "mp3float", false},
+		{"flac", "flac", false},
+		{"ogg", "opus", false},
+		{"mov,mp4,m4a,3gp,3g2,mj2", "aac", false},
+		{"matroska,webm", "opus", true},
+		{"amr", "amr_nb", true},
+		{"dss", "dss_sp", true},
+	}
+	for _, tt := range tests {
+		if got := NeedsNormalization(tt.format, tt.codec); got != tt.want {
+			t.Errorf("NeedsNormalization(%q, %q) = %v, want %v", tt.format, tt.codec, got, tt.want)
+		}
+	}
+}
+
+func TestProbeAudio(t *testing.T) {
+	fakeFFprobe(t, `{"streams":[{"codec_type":"audio","codec_name":"opus","sample_rate":"48000","channels":2}],"format":{"format_name":"matroska,webm","duration":"42.5"}}`, 0)
+
+	info, err := ProbeAudio(context.Background(), "/tmp/audio.mkv")
+	if err != nil {
+		t.Fatalf("ProbeAudio failed: %v", err)
+	}
+	want := AudioInfo{Format: "matroska,webm", Codec: "opus", SampleRate: 48000, Channels: 2, DurationSeconds: 42.5}
+	if *info != want {
+		t.Errorf("Expected %+v, got %+v", want, *info)
+	}
+}
+
+func TestProbeAudioNamesFormatWithoutAudio(t *testing.T) {
+	fakeFFprobe(t, `{"streams":[{"codec_type":"video","codec_name":"png"}],"format":{"format_name":"png_pipe","duration":"N/A"}}`, 0)
+
+	_, err := ProbeAudio(context.Background(), "/tmp/image.mp3")
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Code != ValidationNoAudioStream {
+		t.Fatalf("Expected a %s validation error, got %v", ValidationNoAudioStream, err)
+	}
+	if !strings.Contains(validationErr.Message, "png_pipe") {
+		t.Errorf("Expected the message to name the detected format, got %q", validationErr.Message)
+	}
+}
+
+func TestNormalizeAudioFailure(t *testing.T) {
+	args := fakeFFprobe(t, "", 1)
+
+	err := NormalizeAudio(context.Background(), "/tmp/audio.dss", "/tmp/audio.wav")
+	var ffmpegErr *FFmpegError
+	if !errors.As(err, &ffmpegErr) {
+		t.Fatalf("Expected an FFmpegError, got %v", err)
+	}
+	if !strings.Contains(ffmpegErr.Stderr, "Invalid data found") {
+		t.Errorf("Expected ffmpeg's stderr, got %q", ffmpegErr.Stderr)
+	}
+	if got := strings.Join(*args, " "); !strings.Contains(got, "-ac 1 -ar 16000 -c:a pcm_s16le /tmp/audio.wav") {
+		t.Errorf("Unexpected ffmpeg invocation: %s", got)
+	}
+}
+
+func TestStderrTail(t *testing.T) {
+	long := strings.Repeat("x", stderrTailBytes) + "last line"
+	if got := stderrTail(long); len(got) != stderrTailBytes || !strings.HasSuffix(got, "last line") {
+		t.Errorf("Expected the last %d bytes, got %d ending %q", stderrTailBytes, len(got), got[len(got)-9:])
+	}
+	if got := stderrTail("  short\n"); got != "short" {
+		t.Errorf("Expected trimmed output, got %q", got)
+	}
+}
+
+func TestPreprocessAudioFilterChain(t *testing.T) {
+	args := fakeFFprobe(t, "", 0)
+	preprocessing := models.Preprocessing{NormalizeAudio: true, HighPassFilterHz: 80}
+
+	if err := PreprocessAudio(context.Background(), "/tmp/audio.mp3", "/tmp/audio_preprocessed.wav", preprocessing.FilterChain()); err != nil {
+		t.Fatalf("PreprocessAudio failed: %v", err)
+	}
+	got := strings.Join(*args, " ")
+	if !strings.HasPrefix(got, "ffmpeg ") || !strings.Contains(got, "-i /tmp/audio.mp3 -af highpass=f=80,loudnorm") {
+		t.Errorf("Unexpected ffmpeg invocation: %s", got)
+	}
+	if !strings.HasSuffix(got, "/tmp/audio_preprocessed.wav") {
+		t.Errorf("Expected the filtered audio to be written to the output path: %s", got)
+	}
+}
+
+func TestPreprocessAudioRecordsDuration(t *testing.T) {
+	args := fakeFFprobe(t, "", 0)
+	service := &UnifiedTranscriptionService{tempDirectory: t.TempDir()}
+	execution := &models.TranscriptionJobExecution{}
+	input := interfaces.AudioInput{FilePath: "/tmp/audio.mp3", Format: "mp3"}
+
+	filtered, err := service.preprocessAudio(context.Background(), "job-1", input, "loudnorm", execution)
+	if err != nil {
+		t.Fatalf("preprocessAudio failed: %v", err)
+	}
+	want := filepath.Join(service.tempDirectory, "job-1_preprocessed.wav")
+	if filtered.FilePath != want || filtered.TempFilePath != want || filtered.Format != normalizedFormat {
+		t.Errorf("Expected the job to use the filtered file %s, got %+v", want, filtered)
+	}
+	if execution.PreprocessingDuration == nil {
+		t.Error("Expected the preprocessing duration to be recorded on the execution")
+	}
+	if got := strings.Join(*args, " "); !strings.Contains(got, "-af loudnorm") {
+		t.Errorf("Unexpected ffmpeg invocation: %s", got)
+	}
+}
+
+func TestPreprocessAudioFailure(t *testing.T) {
+	fakeFFprobe(t, "", 1)
+	service := &UnifiedTranscriptionService{tempDirectory: t.TempDir()}
+
+	_, err := service.preprocessAudio(context.Background(), "job-1", interfaces.AudioInput{FilePath: "/tmp/audio.mp3"}, "highpass=f=80", &models.TranscriptionJobExecution{})
+	var ffmpegErr *FFmpegError
+	if !errors.As(err, &ffmpegErr) {
+		t.Fatalf("Expected an FFmpegError, got %v", err)
+	}
+}
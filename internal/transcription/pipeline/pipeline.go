@@ -13,6 +13,9 @@ import (
 	"scriberr/pkg/logger"
 )
 
+// execCommandContext builds external commands; tests replace it to fake ffmpeg
+var execCommandContext = exec.CommandContext
+
 // ProcessingPipeline handles the full processing workflow with preprocessing
 type ProcessingPipeline struct {
 	preprocessors  []interfaces.Preprocessor
@@ -111,7 +114,7 @@ func (a *AudioFormatPreprocessor) Process(ctx context.Context, input interfaces.
 	}
 
 	// Execute FFmpeg
-	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	cmd := execCommandContext(ctx, "ffmpeg", args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		logger.Error("FFmpeg conversion failed", "output", string(output), "error", err)
@@ -0,0 +1,65 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"scriberr/internal/transcription/interfaces"
+)
+
+// fakeFFmpeg replaces execCommandContext with a command that succeeds without
+// running ffmpeg, returning the arguments it was called with
+func fakeFFmpeg(t *testing.T) *[]string {
+	t.Helper()
+	var args []string
+	original := execCommandContext
+	execCommandContext = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		args = append([]string{name}, arg...)
+		cmd := exec.CommandContext(ctx, os.Args[0], "-test.run=TestFFmpegHelperProcess")
+		cmd.Env = append(os.Environ(), "GO_WANT_FFMPEG_HELPER=1")
+		return cmd
+	}
+	t.Cleanup(func() { execCommandContext = original })
+	return &args
+}
+
+// TestFFmpegHelperProcess stands in for ffmpeg when run by fakeFFmpeg
+func TestFFmpegHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_FFMPEG_HELPER") != "1" {
+		return
+	}
+	os.Exit(0)
+}
+
+func TestAudioFormatPreprocessorResamples(t *testing.T) {
+	args := fakeFFmpeg(t)
+	input := interfaces.AudioInput{FilePath: "/tmp/audio.flac", Format: "flac", SampleRate: 48000, Channels: 2}
+
+	converted, err := (&AudioFormatPreprocessor{}).Process(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	got := strings.Join(*args, " ")
+	if !strings.HasPrefix(got, "ffmpeg -i /tmp/audio.flac") || !strings.Contains(got, "-ar 16000 -ac 1 -c:a pcm_s16le") {
+		t.Errorf("Unexpected ffmpeg invocation: %s", got)
+	}
+	if converted.TempFilePath != "/tmp/audio_converted.wav" || converted.SampleRate != 16000 || converted.Channels != 1 {
+		t.Errorf("Expected a temporary 16 kHz mono file, got %+v", converted)
+	}
+}
+
+func TestAudioFormatPreprocessorKeepsResampledInput(t *testing.T) {
+	args := fakeFFmpeg(t)
+	input := interfaces.AudioInput{FilePath: "/tmp/audio.wav", Format: "wav", SampleRate: 16000, Channels: 1}
+
+	converted, err := (&AudioFormatPreprocessor{}).Process(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if len(*args) != 0 || converted.FilePath != input.FilePath {
+		t.Errorf("Expected 16 kHz mono WAV to be used as is, ran %v", *args)
+	}
+}
@@ -0,0 +1,151 @@
+package postprocess
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"scriberr/internal/transcription/interfaces"
+)
+
+// Names of the built-in post-processors
+const (
+	RegexReplace  = "regex_replace"
+	WordBlocklist = "word_blocklist"
+)
+
+// Redacted replaces the words a WordBlocklistProcessor removes
+const Redacted = "[redacted]"
+
+func init() {
+	Register(RegexReplace, newRegexReplaceFromOptions)
+	Register(WordBlocklist, newWordBlocklistFromOptions)
+}
+
+// RegexReplaceProcessor finds and replaces text in each segment. The
+// replacement may refer to groups as $1 or ${name}. Word timings are left as
+// they are, since a match can span several words.
+type RegexReplaceProcessor struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// NewRegexReplaceProcessor compiles pattern, which uses Go regular
+// expression syntax
+func NewRegexReplaceProcessor(pattern, replacement string) (*RegexReplaceProcessor, error) {
+	if pattern == "" {
+		return nil, errors.New("pattern is required")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return &RegexReplaceProcessor{Pattern: re, Replacement: replacement}, nil
+}
+
+func newRegexReplaceFromOptions(options json.RawMessage) (PostProcessor, error) {
+	var opts struct {
+		Pattern     string `json:"pattern"`
+		Replacement string `json:"replacement"`
+	}
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	return NewRegexReplaceProcessor(opts.Pattern, opts.Replacement)
+}
+
+// Process replaces every match in the segment text and masked text
+func (p *RegexReplaceProcessor) Process(ctx context.Context, segments []Segment) ([]Segment, error) {
+	out := make([]Segment, len(segments))
+	for i, segment := range segments {
+		segment.Text = p.Pattern.ReplaceAllString(segment.Text, p.Replacement)
+		if segment.MaskedText != "" {
+			segment.MaskedText = p.Pattern.ReplaceAllString(segment.MaskedText, p.Replacement)
+		}
+		out[i] = segment
+	}
+	return out, nil
+}
+
+// wordPattern matches the words a blocklist is checked against; punctuation
+// around and inside a word, such as the apostrophe in "Jane's", is kept
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}_]+`)
+
+// WordBlocklistProcessor replaces listed words with Redacted, ignoring case.
+// Both segment text and word timings are redacted.
+type WordBlocklistProcessor struct {
+	words map[string]bool
+}
+
+// NewWordBlocklistProcessor blocks each of words. Entries must be single
+// words.
+func NewWordBlocklistProcessor(words []string) (*WordBlocklistProcessor, error) {
+	if len(words) == 0 {
+		return nil, errors.New("words is required")
+	}
+	blocked := make(map[string]bool, len(words))
+	for _, word := range words {
+		if word == "" || wordPattern.FindString(word) != word {
+			return nil, fmt.Errorf("%q is not a single word", word)
+		}
+		blocked[strings.ToLower(word)] = true
+	}
+	return &WordBlocklistProcessor{words: blocked}, nil
+}
+
+func newWordBlocklistFromOptions(options json.RawMessage) (PostProcessor, error) {
+	var opts struct {
+		Words []string `json:"words"`
+	}
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	return NewWordBlocklistProcessor(opts.Words)
+}
+
+// Process redacts blocked words in segment text, masked text and words
+func (p *WordBlocklistProcessor) Process(ctx context.Context, segments []Segment) ([]Segment, error) {
+	out := make([]Segment, len(segments))
+	for i, segment := range segments {
+		segment.Text = p.redact(segment.Text)
+		segment.MaskedText = p.redact(segment.MaskedText)
+		if segment.Words != nil {
+			words := make([]interfaces.TranscriptWord, len(segment.Words))
+			for j, word := range segment.Words {
+				word.Word = p.redact(word.Word)
+				word.MaskedWord = p.redact(word.MaskedWord)
+				words[j] = word
+			}
+			segment.Words = words
+		}
+		out[i] = segment
+	}
+	return out, nil
+}
+
+// redact replaces the blocked words in text
+func (p *WordBlocklistProcessor) redact(text string) string {
+	return wordPattern.ReplaceAllStringFunc(text, func(word string) string {
+		if p.words[strings.ToLower(word)] {
+			return Redacted
+		}
+		return word
+	})
+}
+
+// decodeOptions parses a post-processor's options, rejecting unknown fields
+// so misspelt options are not silently ignored
+func decodeOptions(options json.RawMessage, v interface{}) error {
+	if len(options) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(options)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid options: %w", err)
+	}
+	return nil
+}
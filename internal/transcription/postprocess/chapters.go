@@ -0,0 +1,84 @@
+package postprocess
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"scriberr/internal/models"
+)
+
+// Chapters is the name of the chapter detection post-processor
+const Chapters = "chapters"
+
+// DefaultChapterGapSeconds is the pause that starts a new chapter when a job
+// does not set one
+const DefaultChapterGapSeconds = 2.0
+
+func init() {
+	Register(Chapters, newChapterDetectorFromOptions)
+}
+
+// ChapterFinder is implemented by post-processors that divide a transcript
+// into chapters as they process it
+type ChapterFinder interface {
+	Chapters() []models.Chapter
+}
+
+// ChapterDetector starts a new chapter wherever a sentence ends and the next
+// segment follows after a pause of at least MinGapSeconds. The first chapter
+// starts with the first segment. Segments are passed through unchanged.
+type ChapterDetector struct {
+	MinGapSeconds float64
+
+	chapters []models.Chapter
+}
+
+// NewChapterDetector creates a detector splitting at pauses of at least
+// minGapSeconds
+func NewChapterDetector(minGapSeconds float64) (*ChapterDetector, error) {
+	if minGapSeconds <= 0 {
+		return nil, errors.New("min_gap_seconds must be greater than 0")
+	}
+	return &ChapterDetector{MinGapSeconds: minGapSeconds}, nil
+}
+
+func newChapterDetectorFromOptions(options json.RawMessage) (PostProcessor, error) {
+	opts := struct {
+		MinGapSeconds float64 `json:"min_gap_seconds"`
+	}{MinGapSeconds: DefaultChapterGapSeconds}
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	return NewChapterDetector(opts.MinGapSeconds)
+}
+
+// Process finds the chapters of segments, replacing any found before
+func (d *ChapterDetector) Process(ctx context.Context, segments []Segment) ([]Segment, error) {
+	d.chapters = nil
+	for i, segment := range segments {
+		if i > 0 {
+			previous := segments[i-1]
+			if segment.Start-previous.End < d.MinGapSeconds || !endsSentence(previous.Text) {
+				continue
+			}
+		}
+		d.chapters = append(d.chapters, models.Chapter{Type: models.ChapterMarker, Start: segment.Start})
+	}
+	return segments, nil
+}
+
+// Chapters returns the chapters found by the last Process call
+func (d *ChapterDetector) Chapters() []models.Chapter {
+	return d.chapters
+}
+
+// endsSentence reports whether text ends with sentence punctuation, allowing
+// for closing quotes and brackets after it
+func endsSentence(text string) bool {
+	text = strings.TrimRight(strings.TrimSpace(text), `"')]”’»`)
+	last, _ := utf8.DecodeLastRuneInString(text)
+	return strings.ContainsRune(".!?…。！？", last)
+}
@@ -0,0 +1,82 @@
+package postprocess
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func chapterStarts(t *testing.T, detector *ChapterDetector, segments []Segment) []float64 {
+	t.Helper()
+	out, err := detector.Process(context.Background(), segments)
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if !reflect.DeepEqual(out, segments) {
+		t.Errorf("Expected segments to pass through unchanged")
+	}
+	var starts []float64
+	for _, chapter := range detector.Chapters() {
+		if chapter.Type != "chapter" || chapter.Title != "" {
+			t.Errorf("Unexpected chapter marker %+v", chapter)
+		}
+		starts = append(starts, chapter.Start)
+	}
+	return starts
+}
+
+func TestChapterDetectorSplitsAtPausesAfterSentences(t *testing.T) {
+	segments := []Segment{
+		{Start: 0.5, End: 4, Text: "Welcome to the show."},
+		{Start: 4.2, End: 8, Text: "Today we talk budgets."}, // Short pause
+		{Start: 12, End: 15, Text: "First, the numbers"},     // Long pause after a sentence
+		{Start: 19, End: 22, Text: "for last year."},         // Long pause mid-sentence
+		{Start: 22.5, End: 25, Text: "They were good!"},
+		{Start: 27.5, End: 30, Text: `Next, hiring." `}, // Exactly the minimum gap
+		{Start: 31, End: 33, Text: "We grew?"},
+		{Start: 40, End: 42, Text: "Thanks for listening."},
+	}
+	detector, err := NewChapterDetector(2.5)
+	if err != nil {
+		t.Fatalf("Failed to create detector: %v", err)
+	}
+	if want := []float64{0.5, 12, 27.5, 40}; !reflect.DeepEqual(chapterStarts(t, detector, segments), want) {
+		t.Errorf("Expected chapters at %v, got %v", want, detector.Chapters())
+	}
+
+	// A longer minimum merges the shorter pauses away
+	detector, _ = NewChapterDetector(5)
+	if want := []float64{0.5, 40}; !reflect.DeepEqual(chapterStarts(t, detector, segments), want) {
+		t.Errorf("Expected chapters at %v, got %v", want, detector.Chapters())
+	}
+
+	// Processing again replaces the earlier chapters
+	if starts := chapterStarts(t, detector, nil); starts != nil {
+		t.Errorf("Expected no chapters for an empty transcript, got %v", starts)
+	}
+}
+
+func TestChapterDetectorOptions(t *testing.T) {
+	processors, err := Build(configs(t, `[{"name":"chapters"}]`))
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	detector, ok := processors[0].(*ChapterDetector)
+	if !ok || detector.MinGapSeconds != DefaultChapterGapSeconds {
+		t.Errorf("Expected a detector with the default gap, got %#v", processors[0])
+	}
+
+	processors, err = Build(configs(t, `[{"name":"chapters","options":{"min_gap_seconds":4.5}}]`))
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if detector := processors[0].(*ChapterDetector); detector.MinGapSeconds != 4.5 {
+		t.Errorf("Expected a 4.5 second gap, got %v", detector.MinGapSeconds)
+	}
+
+	_, err = Build(configs(t, `[{"name":"chapters","options":{"min_gap_seconds":0}}]`))
+	if err == nil || !strings.Contains(err.Error(), "min_gap_seconds") {
+		t.Errorf("Expected a zero gap to be rejected, got %v", err)
+	}
+}
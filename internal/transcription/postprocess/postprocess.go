@@ -0,0 +1,87 @@
+// Package postprocess runs user-configured text transformations, such as
+// acronym expansion or redaction, on finished transcripts
+package postprocess
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"scriberr/internal/models"
+	"scriberr/internal/transcription/interfaces"
+)
+
+// Segment is a transcript segment passed through post-processors
+type Segment = interfaces.TranscriptSegment
+
+// PostProcessor transforms the segments of a finished transcript
+type PostProcessor interface {
+	Process(ctx context.Context, segments []Segment) ([]Segment, error)
+}
+
+// Factory builds a post-processor from the JSON options a job gives it
+type Factory func(options json.RawMessage) (PostProcessor, error)
+
+var (
+	mu        sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+// Register makes a post-processor available to jobs under name, replacing
+// any registered before with the same name
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	factories[name] = factory
+}
+
+// Names returns the registered post-processor names in order
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Build creates the post-processors a job configures, in order. It fails on
+// an unknown name or invalid options, so configs can be checked before a job
+// is queued.
+func Build(configs []models.PostProcessorConfig) ([]PostProcessor, error) {
+	processors := make([]PostProcessor, 0, len(configs))
+	for i, config := range configs {
+		mu.RLock()
+		factory, ok := factories[config.Name]
+		mu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("post_processors[%d]: unknown post-processor %q", i, config.Name)
+		}
+		processor, err := factory(config.Options)
+		if err != nil {
+			return nil, fmt.Errorf("post_processors[%d] (%s): %w", i, config.Name, err)
+		}
+		processors = append(processors, processor)
+	}
+	return processors, nil
+}
+
+// Run passes segments through each post-processor in turn, each one seeing
+// the output of the one before. It stops at the first error.
+func Run(ctx context.Context, processors []PostProcessor, segments []Segment) ([]Segment, error) {
+	for i, processor := range processors {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var err error
+		segments, err = processor.Process(ctx, segments)
+		if err != nil {
+			return nil, fmt.Errorf("post-processor %d failed: %w", i, err)
+		}
+	}
+	return segments, nil
+}
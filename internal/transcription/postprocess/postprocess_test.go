@@ -0,0 +1,187 @@
+package postprocess
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"scriberr/internal/models"
+	"scriberr/internal/transcription/interfaces"
+)
+
+func configs(t *testing.T, raw string) []models.PostProcessorConfig {
+	t.Helper()
+	var configs []models.PostProcessorConfig
+	if err := json.Unmarshal([]byte(raw), &configs); err != nil {
+		t.Fatalf("Failed to parse configs: %v", err)
+	}
+	return configs
+}
+
+func texts(segments []Segment) []string {
+	out := make([]string, len(segments))
+	for i, segment := range segments {
+		out[i] = segment.Text
+	}
+	return out
+}
+
+func TestRunAppliesProcessorsInOrder(t *testing.T) {
+	segments := []Segment{{Text: "Ask the FBI about it"}}
+	expand := `{"name":"regex_replace","options":{"pattern":"\\bFBI\\b","replacement":"Federal Bureau of Investigation"}}`
+	block := `{"name":"word_blocklist","options":{"words":["bureau"]}}`
+
+	// Expanding first exposes a word for the blocklist to catch
+	processors, err := Build(configs(t, "["+expand+","+block+"]"))
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	out, err := Run(context.Background(), processors, segments)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if want := []string{"Ask the Federal [redacted] of Investigation about it"}; !reflect.DeepEqual(texts(out), want) {
+		t.Errorf("Expected %q, got %q", want, texts(out))
+	}
+
+	// In the other order the blocklist runs before the word exists
+	processors, err = Build(configs(t, "["+block+","+expand+"]"))
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	out, err = Run(context.Background(), processors, segments)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if want := []string{"Ask the Federal Bureau of Investigation about it"}; !reflect.DeepEqual(texts(out), want) {
+		t.Errorf("Expected %q, got %q", want, texts(out))
+	}
+
+	if segments[0].Text != "Ask the FBI about it" {
+		t.Errorf("Input segments were modified: %q", segments[0].Text)
+	}
+}
+
+type recordingProcessor struct {
+	name  string
+	calls *[]string
+	err   error
+}
+
+func (p recordingProcessor) Process(ctx context.Context, segments []Segment) ([]Segment, error) {
+	*p.calls = append(*p.calls, p.name)
+	return segments, p.err
+}
+
+func TestRunStopsAtFirstError(t *testing.T) {
+	var calls []string
+	failure := errors.New("lookup service unavailable")
+	processors := []PostProcessor{
+		recordingProcessor{name: "first", calls: &calls},
+		recordingProcessor{name: "second", calls: &calls, err: failure},
+		recordingProcessor{name: "third", calls: &calls},
+	}
+
+	out, err := Run(context.Background(), processors, []Segment{{Text: "hello"}})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Expected the processor's error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "post-processor 1") {
+		t.Errorf("Expected the error to name the failing processor, got %v", err)
+	}
+	if out != nil {
+		t.Errorf("Expected no segments on error, got %v", out)
+	}
+	if want := []string{"first", "second"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("Expected calls %v, got %v", want, calls)
+	}
+}
+
+func TestRunStopsWhenCancelled(t *testing.T) {
+	var calls []string
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Run(ctx, []PostProcessor{recordingProcessor{name: "first", calls: &calls}}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("Expected no processor to run, got %v", calls)
+	}
+}
+
+func TestBuildRejectsInvalidConfigs(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"unknown name", `[{"name":"shout"}]`, `unknown post-processor "shout"`},
+		{"missing pattern", `[{"name":"regex_replace"}]`, "pattern is required"},
+		{"bad pattern", `[{"name":"regex_replace","options":{"pattern":"("}}]`, "invalid pattern"},
+		{"unknown option", `[{"name":"regex_replace","options":{"regex":"a"}}]`, "invalid options"},
+		{"no words", `[{"name":"word_blocklist","options":{"words":[]}}]`, "words is required"},
+		{"phrase", `[{"name":"word_blocklist","options":{"words":["jane doe"]}}]`, "not a single word"},
+		{"second entry", `[{"name":"word_blocklist","options":{"words":["jane"]}},{"name":"nope"}]`, "post_processors[1]"},
+	}
+	for _, tt := range tests {
+		_, err := Build(configs(t, tt.raw))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestRegexReplaceUsesGroups(t *testing.T) {
+	processor, err := NewRegexReplaceProcessor(`(\d{3})-(\d{4})`, "XXX-$2")
+	if err != nil {
+		t.Fatalf("Failed to build processor: %v", err)
+	}
+	out, _ := processor.Process(context.Background(), []Segment{{Text: "Call 555-1234 or 555-9876", MaskedText: "Call [inaudible] 555-1234"}})
+	if out[0].Text != "Call XXX-1234 or XXX-9876" {
+		t.Errorf("Unexpected text %q", out[0].Text)
+	}
+	if out[0].MaskedText != "Call [inaudible] XXX-1234" {
+		t.Errorf("Unexpected masked text %q", out[0].MaskedText)
+	}
+}
+
+func TestWordBlocklistRedactsTextAndWords(t *testing.T) {
+	processor, err := NewWordBlocklistProcessor([]string{"Jane", "josé"})
+	if err != nil {
+		t.Fatalf("Failed to build processor: %v", err)
+	}
+	segments := []Segment{{
+		Text: "JANE's call with José, not Janet.",
+		Words: []interfaces.TranscriptWord{
+			{Word: "JANE's", Start: 0, End: 0.5},
+			{Word: "call"},
+			{Word: "with"},
+			{Word: "José,"},
+			{Word: "not"},
+			{Word: "Janet."},
+		},
+	}}
+	out, _ := processor.Process(context.Background(), segments)
+
+	if want := "[redacted]'s call with [redacted], not Janet."; out[0].Text != want {
+		t.Errorf("Expected text %q, got %q", want, out[0].Text)
+	}
+	var words []string
+	for _, word := range out[0].Words {
+		words = append(words, word.Word)
+	}
+	if want := []string{"[redacted]'s", "call", "with", "[redacted],", "not", "Janet."}; !reflect.DeepEqual(words, want) {
+		t.Errorf("Expected words %q, got %q", want, words)
+	}
+	if out[0].Words[0].End != 0.5 {
+		t.Errorf("Expected word timings to be kept")
+	}
+	if segments[0].Words[0].Word != "JANE's" {
+		t.Errorf("Input words were modified: %q", segments[0].Words[0].Word)
+	}
+}
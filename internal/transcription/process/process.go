@@ -0,0 +1,219 @@
+// Package process tracks the model processes transcription runs, such as
+// WhisperX, so that shutdown can stop starting them and signal the ones
+// still running
+package process
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// ErrShuttingDown is returned for processes started after shutdown began
+var ErrShuttingDown = errors.New("not starting process: server is shutting down")
+
+// Tracker keeps the processes started through it until they exit
+type Tracker struct {
+	mu       sync.Mutex
+	running  map[*tracked]struct{}
+	stopping bool
+	idle     chan struct{} // Closed when the last process exits, while waited on
+	usage    map[*Usage]struct{}
+}
+
+// Usage is the resources used by processes that exited while it was collected
+type Usage struct {
+	CPU     time.Duration // User and system CPU time
+	PeakRSS uint64        // Bytes of the largest resident set of any of them
+}
+
+// tracked is one running process
+type tracked struct {
+	signal func(os.Signal) error
+}
+
+// Default tracks the processes of the transcription adapters
+var Default = NewTracker()
+
+// NewTracker creates a tracker with no processes
+func NewTracker() *Tracker {
+	return &Tracker{running: make(map[*tracked]struct{}), usage: make(map[*Usage]struct{})}
+}
+
+// Run starts cmd and waits for it like cmd.Run, tracking it meanwhile
+func Run(cmd *exec.Cmd) error {
+	return Default.Run(cmd)
+}
+
+// Output runs cmd and returns its standard output like cmd.Output
+func Output(cmd *exec.Cmd) ([]byte, error) {
+	return Default.Output(cmd)
+}
+
+// CombinedOutput runs cmd and returns its standard output and error like
+// cmd.CombinedOutput
+func CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	return Default.CombinedOutput(cmd)
+}
+
+// Run starts cmd and waits for it like cmd.Run, tracking it meanwhile. It
+// returns ErrShuttingDown without starting cmd once the tracker is stopped.
+func (t *Tracker) Run(cmd *exec.Cmd) error {
+	t.mu.Lock()
+	if t.stopping {
+		t.mu.Unlock()
+		return ErrShuttingDown
+	}
+	if err := cmd.Start(); err != nil {
+		t.mu.Unlock()
+		return err
+	}
+	p := &tracked{signal: cmd.Process.Signal}
+	t.running[p] = struct{}{}
+	t.mu.Unlock()
+
+	defer t.finish(p)
+	err := cmd.Wait()
+	t.record(cmd.ProcessState)
+	return err
+}
+
+// Output runs cmd like cmd.Output, tracking it meanwhile
+func (t *Tracker) Output(cmd *exec.Cmd) ([]byte, error) {
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	err := t.Run(cmd)
+	return stdout.Bytes(), err
+}
+
+// CombinedOutput runs cmd like cmd.CombinedOutput, tracking it meanwhile
+func (t *Tracker) CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := t.Run(cmd)
+	return output.Bytes(), err
+}
+
+// Track registers a process started elsewhere, which signal reaches. Call
+// the returned function once it exits.
+func (t *Tracker) Track(signal func(os.Signal) error) (func(), error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopping {
+		return nil, ErrShuttingDown
+	}
+	p := &tracked{signal: signal}
+	t.running[p] = struct{}{}
+	var once sync.Once
+	return func() { once.Do(func() { t.finish(p) }) }, nil
+}
+
+// finish forgets an exited process
+func (t *Tracker) finish(p *tracked) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.running, p)
+	if len(t.running) == 0 && t.idle != nil {
+		close(t.idle)
+		t.idle = nil
+	}
+}
+
+// Collect adds up the usage of the processes run through the tracker that
+// exit from now until the returned function is called, which returns it.
+// Collections that overlap each count every process exiting meanwhile.
+func (t *Tracker) Collect() func() Usage {
+	usage := &Usage{}
+	t.mu.Lock()
+	t.usage[usage] = struct{}{}
+	t.mu.Unlock()
+	return func() Usage {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.usage, usage)
+		return *usage
+	}
+}
+
+// record adds an exited process to the usage being collected
+func (t *Tracker) record(state *os.ProcessState) {
+	if state == nil {
+		return
+	}
+	cpu := state.UserTime() + state.SystemTime()
+	rss := peakRSS(state)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for usage := range t.usage {
+		usage.CPU += cpu
+		usage.PeakRSS = max(usage.PeakRSS, rss)
+	}
+}
+
+// Stop refuses new processes from now on and returns how many are running
+func (t *Tracker) Stop() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopping = true
+	return len(t.running)
+}
+
+// Stopping reports whether Stop was called
+func (t *Tracker) Stopping() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stopping
+}
+
+// Running returns how many processes are running
+func (t *Tracker) Running() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.running)
+}
+
+// Signal sends sig to every running process, returning the first failure
+func (t *Tracker) Signal(sig os.Signal) error {
+	t.mu.Lock()
+	processes := make([]*tracked, 0, len(t.running))
+	for p := range t.running {
+		processes = append(processes, p)
+	}
+	t.mu.Unlock()
+
+	var firstErr error
+	for _, p := range processes {
+		// A process that exited meanwhile cannot be signalled, which is fine
+		if err := p.signal(sig); err != nil && !errors.Is(err, os.ErrProcessDone) && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Wait blocks until no process is running or ctx is done. Call Stop first,
+// or new processes may keep it waiting.
+func (t *Tracker) Wait(ctx context.Context) error {
+	t.mu.Lock()
+	if len(t.running) == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	if t.idle == nil {
+		t.idle = make(chan struct{})
+	}
+	idle := t.idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,74 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRunTracksProcess(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+	tracker := NewTracker()
+
+	output, err := tracker.CombinedOutput(exec.Command("echo", "hello"))
+	if err != nil || strings.TrimSpace(string(output)) != "hello" {
+		t.Fatalf("Expected hello, got %q (%v)", output, err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- tracker.Run(exec.Command("sleep", "10")) }()
+	deadline := time.Now().Add(2 * time.Second)
+	for tracker.Running() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := tracker.Stop(); got != 1 {
+		t.Fatalf("Expected 1 running process, got %d", got)
+	}
+	if err := tracker.Run(exec.Command("echo")); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("Expected ErrShuttingDown after Stop, got %v", err)
+	}
+
+	if err := tracker.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("Signal failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := tracker.Wait(ctx); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	if err := <-done; err == nil {
+		t.Error("Expected the terminated process to report an error")
+	}
+}
+
+func TestCollectUsage(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	tracker := NewTracker()
+	before := tracker.Collect()() // Nothing ran yet
+	if before != (Usage{}) {
+		t.Fatalf("Expected no usage before any process, got %+v", before)
+	}
+
+	collect := tracker.Collect()
+	if err := tracker.Run(exec.Command("sh", "-c", "i=0; while [ $i -lt 100000 ]; do i=$((i+1)); done")); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	usage := collect()
+	if usage.CPU <= 0 || usage.PeakRSS == 0 {
+		t.Errorf("Expected the process's CPU time and resident set, got %+v", usage)
+	}
+
+	// Processes exiting after collection ends are not counted
+	_ = tracker.Run(exec.Command("sh", "-c", "true"))
+	if after := collect(); after != usage {
+		t.Errorf("Expected usage to stay %+v, got %+v", usage, after)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build darwin
+// +build darwin
+
+package process
+
+import (
+	"os"
+	"syscall"
+)
+
+// peakRSS returns the largest resident set of an exited process in bytes
+func peakRSS(state *os.ProcessState) uint64 {
+	if usage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		return uint64(usage.Maxrss) // Already bytes on macOS
+	}
+	return 0
+}
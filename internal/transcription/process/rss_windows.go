@@ -0,0 +1,12 @@
+//go:build windows
+// +build windows
+
+package process
+
+import "os"
+
+// peakRSS returns zero, as Windows does not report the resident set of
+// exited processes here
+func peakRSS(state *os.ProcessState) uint64 {
+	return 0
+}
@@ -0,0 +1,148 @@
+package transcription
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+)
+
+const (
+	// progressWriteInterval throttles progress writes to the job row
+	progressWriteInterval = time.Second
+	// defaultRealtimeFactor is assumed when no history exists for a model/device pair
+	defaultRealtimeFactor = 0.5
+	// maxEstimatedProgress caps time-based estimates so they never claim completion
+	maxEstimatedProgress = 0.95
+)
+
+// progressTracker persists transcription progress for a job
+type progressTracker struct {
+	jobID        string
+	totalSeconds float64
+	startedAt    time.Time
+
+	mu        sync.Mutex
+	lastWrite time.Time
+	processed float64
+	parsed    bool // true once the tool has emitted parseable progress
+}
+
+// newProgressTracker creates a tracker and resets any progress left over from a previous run
+func newProgressTracker(jobID string, totalSeconds float64) *progressTracker {
+	t := &progressTracker{
+		jobID:        jobID,
+		totalSeconds: totalSeconds,
+		startedAt:    time.Now(),
+	}
+	t.write(0, 0)
+	return t
+}
+
+// Report records processed audio seconds parsed from tool output
+func (t *progressTracker) Report(processedSeconds float64) {
+	t.mu.Lock()
+	t.parsed = true
+	t.update(processedSeconds, 1.0)
+	t.mu.Unlock()
+}
+
+// update stores progress and writes it at most once per progressWriteInterval.
+// Callers must hold t.mu.
+func (t *progressTracker) update(processedSeconds, maxProgress float64) {
+	if t.totalSeconds <= 0 || processedSeconds <= t.processed {
+		return
+	}
+	if processedSeconds > t.totalSeconds {
+		processedSeconds = t.totalSeconds
+	}
+	t.processed = processedSeconds
+
+	if time.Since(t.lastWrite) < progressWriteInterval {
+		return
+	}
+	t.lastWrite = time.Now()
+	progress := processedSeconds / t.totalSeconds
+	if progress > maxProgress {
+		progress = maxProgress
+	}
+	t.write(progress, processedSeconds)
+}
+
+// Complete marks the job as fully processed
+func (t *progressTracker) Complete() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.processed = t.totalSeconds
+	t.write(1, t.totalSeconds)
+}
+
+func (t *progressTracker) write(progress, processedSeconds float64) {
+	if err := database.DB.Model(&models.TranscriptionJob{}).
+		Where("id = ?", t.jobID).
+		Updates(map[string]interface{}{
+			"progress":          progress,
+			"processed_seconds": processedSeconds,
+		}).Error; err != nil {
+		logger.Warn("Failed to update job progress", "job_id", t.jobID, "error", err)
+	}
+}
+
+// RunEstimator estimates progress from elapsed time and the historical realtime
+// factor until the tool emits parseable progress or ctx is done
+func (t *progressTracker) RunEstimator(ctx context.Context, realtimeFactor float64) {
+	if t.totalSeconds <= 0 || realtimeFactor <= 0 {
+		return
+	}
+	ticker := time.NewTicker(progressWriteInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.mu.Lock()
+			if t.parsed {
+				t.mu.Unlock()
+				return
+			}
+			estimated := estimateProcessedSeconds(time.Since(t.startedAt), realtimeFactor, t.totalSeconds)
+			t.update(estimated, maxEstimatedProgress)
+			t.mu.Unlock()
+		}
+	}
+}
+
+// estimateProcessedSeconds converts elapsed wall time into processed audio seconds
+func estimateProcessedSeconds(elapsed time.Duration, realtimeFactor, totalSeconds float64) float64 {
+	if realtimeFactor <= 0 {
+		return 0
+	}
+	processed := elapsed.Seconds() / realtimeFactor
+	if limit := totalSeconds * maxEstimatedProgress; processed > limit {
+		processed = limit
+	}
+	return processed
+}
+
+// historicalRealtimeFactor returns the average processing-time / audio-duration
+// ratio of completed executions for the given model and device
+func historicalRealtimeFactor(model, device string) float64 {
+	var result struct {
+		Factor *float64
+	}
+	err := database.DB.Table("transcription_job_executions AS e").
+		Select("AVG((e.processing_duration / 1000.0) / j.duration_seconds) AS factor").
+		Joins("JOIN transcription_jobs AS j ON j.id = e.transcription_job_id").
+		Where("e.status = ? AND e.actual_model = ? AND e.actual_device = ?", models.StatusCompleted, model, device).
+		Where("e.processing_duration IS NOT NULL AND j.duration_seconds > 0").
+		Scan(&result).Error
+	if err != nil || result.Factor == nil || *result.Factor <= 0 {
+		return defaultRealtimeFactor
+	}
+	return *result.Factor
+}
@@ -27,7 +27,7 @@ func (u *UnifiedJobProcessor) Initialize(ctx context.Context) error {
 // ProcessJob implements the legacy JobProcessor interface
 func (u *UnifiedJobProcessor) ProcessJob(ctx context.Context, jobID string) error {
 	logger.Info("Processing job with unified processor", "job_id", jobID)
-	return u.unifiedService.ProcessJob(ctx, jobID)
+	return u.measure(ctx, jobID)
 }
 
 // ProcessJobWithProcess implements the enhanced JobProcessor interface with process registration
@@ -41,7 +41,16 @@ func (u *UnifiedJobProcessor) ProcessJobWithProcess(ctx context.Context, jobID s
 	// Register a nil process for backward compatibility
 	registerProcess(nil)
 	
-	return u.unifiedService.ProcessJob(ctx, jobID)
+	return u.measure(ctx, jobID)
+}
+
+// measure processes a job and records the resources it used on the job
+func (u *UnifiedJobProcessor) measure(ctx context.Context, jobID string) error {
+	usage, err := MeasureJob(func() error {
+		return u.unifiedService.ProcessJob(ctx, jobID)
+	})
+	recordResourceUsage(jobID, usage)
+	return err
 }
 
 // GetUnifiedService returns the underlying unified service for direct access to new features
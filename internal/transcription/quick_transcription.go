@@ -43,7 +43,7 @@ type QuickTranscriptionService struct {
 // NewQuickTranscriptionService creates a new quick transcription service
 func NewQuickTranscriptionService(cfg *config.Config, unifiedProcessor *UnifiedJobProcessor) (*QuickTranscriptionService, error) {
 	// Create temporary directory for quick transcriptions
-	tempDir := filepath.Join(cfg.UploadDir, "quick_transcriptions")
+	tempDir := filepath.Join(cfg.UploadDir, quickTranscriptionsDir)
 	if err := os.MkdirAll(tempDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create temp directory: %v", err)
 	}
@@ -0,0 +1,83 @@
+package transcription
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// quickTranscriptionsDir is the upload subdirectory quick transcriptions keep
+// their audio in
+const quickTranscriptionsDir = "quick_transcriptions"
+
+// interruptedMessage is recorded on executions a server stop cut short
+const interruptedMessage = "Interrupted by a server restart"
+
+// RecoverInterruptedJobs puts back in the queue the jobs a previous server
+// was processing when it stopped, so they run again rather than staying
+// processing forever. Chunks those runs saved are reused, so long jobs
+// continue where they left off. The temporary jobs that multi-track and
+// quick transcriptions create are deleted instead, as nothing is waiting for
+// them any more. It returns the number of jobs requeued.
+func RecoverInterruptedJobs() (int, error) {
+	var jobs []models.TranscriptionJob
+	if err := database.DB.Select("id", "audio_path", "has_partial").
+		Where("status = ?", models.StatusProcessing).Find(&jobs).Error; err != nil {
+		return 0, fmt.Errorf("failed to find interrupted jobs: %w", err)
+	}
+
+	requeued := 0
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&models.TranscriptionJobExecution{}).
+			Where("status = ?", models.StatusProcessing).
+			Updates(map[string]interface{}{
+				"status":        models.StatusFailed,
+				"completed_at":  now,
+				"error_message": interruptedMessage,
+			}).Error; err != nil {
+			return err
+		}
+
+		for _, job := range jobs {
+			if isTemporaryJob(job) {
+				if err := tx.Where("transcription_job_id = ?", job.ID).Delete(&models.TranscriptionJobExecution{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Delete(&models.TranscriptionJob{}, "id = ?", job.ID).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err := tx.Model(&models.TranscriptionJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+				"status":            models.StatusPending,
+				"phase":             "",
+				"progress":          0,
+				"processed_seconds": 0,
+			}).Error; err != nil {
+				return err
+			}
+			logger.Info("Requeued interrupted job", "job_id", job.ID, "has_partial", job.HasPartial)
+			requeued++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue interrupted jobs: %w", err)
+	}
+	return requeued, nil
+}
+
+// isTemporaryJob reports whether a job was created for one track of a
+// multi-track job or for a quick transcription, rather than queued by a user
+func isTemporaryJob(job models.TranscriptionJob) bool {
+	return strings.HasPrefix(job.ID, "track_") ||
+		filepath.Base(filepath.Dir(job.AudioPath)) == quickTranscriptionsDir
+}
@@ -0,0 +1,63 @@
+package transcription
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+)
+
+func TestRecoverInterruptedJobs(t *testing.T) {
+	if err := database.Initialize(filepath.Join(t.TempDir(), "scriberr.db")); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	jobs := []models.TranscriptionJob{
+		{ID: "interrupted", Status: models.StatusProcessing, AudioPath: "data/uploads/long.wav", HasPartial: true, Progress: 0.6, Phase: models.PhaseDetectingLanguage},
+		{ID: "finished", Status: models.StatusCompleted, AudioPath: "data/uploads/done.wav"},
+		{ID: "track_multi_speaker1_ab12cd34", Status: models.StatusProcessing, AudioPath: "data/uploads/multi/speaker1.wav"},
+		{ID: "quick", Status: models.StatusProcessing, AudioPath: filepath.Join("data/uploads", quickTranscriptionsDir, "quick.wav")},
+	}
+	for i := range jobs {
+		if err := database.DB.Create(&jobs[i]).Error; err != nil {
+			t.Fatalf("Failed to create job: %v", err)
+		}
+	}
+	execution := models.TranscriptionJobExecution{TranscriptionJobID: "interrupted", StartedAt: time.Now(), Status: models.StatusProcessing}
+	if err := database.DB.Create(&execution).Error; err != nil {
+		t.Fatalf("Failed to create execution: %v", err)
+	}
+
+	requeued, err := RecoverInterruptedJobs()
+	if err != nil {
+		t.Fatalf("RecoverInterruptedJobs failed: %v", err)
+	}
+	if requeued != 1 {
+		t.Errorf("Expected 1 job requeued, got %d", requeued)
+	}
+
+	var job models.TranscriptionJob
+	database.DB.First(&job, "id = ?", "interrupted")
+	if job.Status != models.StatusPending || job.Progress != 0 || job.Phase != "" || !job.HasPartial {
+		t.Errorf("Expected a pending job that keeps its partial, got status %s progress %.1f phase %q has_partial %v",
+			job.Status, job.Progress, job.Phase, job.HasPartial)
+	}
+	var finished models.TranscriptionJob
+	database.DB.First(&finished, "id = ?", "finished")
+	if finished.Status != models.StatusCompleted {
+		t.Errorf("Expected the completed job to be left alone, got %s", finished.Status)
+	}
+	var temporary int64
+	database.DB.Model(&models.TranscriptionJob{}).Where("id IN ?", []string{"track_multi_speaker1_ab12cd34", "quick"}).Count(&temporary)
+	if temporary != 0 {
+		t.Errorf("Expected the temporary jobs to be deleted, %d remain", temporary)
+	}
+
+	database.DB.First(&execution, "id = ?", execution.ID)
+	if execution.Status != models.StatusFailed || execution.ErrorMessage == nil || *execution.ErrorMessage != interruptedMessage {
+		t.Errorf("Expected the interrupted execution to be failed, got %s", execution.Status)
+	}
+}
@@ -0,0 +1,56 @@
+package transcription
+
+import (
+	"time"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/internal/transcription/process"
+	"scriberr/pkg/logger"
+)
+
+// ResourceUsage is what running a job cost
+type ResourceUsage struct {
+	PeakRSSMB  int     // Largest resident set of the server or a model process it ran, in MiB
+	CPUSeconds float64 // User and system CPU time of the server and its model processes
+}
+
+// MeasureJob runs fn and returns the resources used meanwhile. The CPU time
+// covers the server and the model processes that exited meanwhile, so jobs
+// running side by side count each other's use. The server's peak resident
+// set is the peak since it started.
+func MeasureJob(fn func() error) (ResourceUsage, error) {
+	collect := process.Default.Collect()
+	cpuBefore, _ := selfUsage()
+	err := fn()
+	cpuAfter, serverPeak := selfUsage()
+	children := collect()
+
+	peak := serverPeak
+	if children.PeakRSS > peak {
+		peak = children.PeakRSS
+	}
+	usage := ResourceUsage{
+		PeakRSSMB:  int(peak >> 20),
+		CPUSeconds: (cpuAfter - cpuBefore + children.CPU).Seconds(),
+	}
+	return usage, err
+}
+
+// recordResourceUsage stores the resources a job's last run used on the job
+func recordResourceUsage(jobID string, usage ResourceUsage) {
+	err := database.DB.Model(&models.TranscriptionJob{}).Where("id = ?", jobID).Updates(map[string]interface{}{
+		"peak_rss_mb": usage.PeakRSSMB,
+		"cpu_seconds": usage.CPUSeconds,
+	}).Error
+	if err != nil {
+		logger.Warn("Failed to record job resource usage", "job_id", jobID, "error", err)
+		return
+	}
+	logger.Debug("Job resource usage", "job_id", jobID, "peak_rss_mb", usage.PeakRSSMB, "cpu_seconds", usage.CPUSeconds)
+}
+
+// cpuTime converts a syscall timeval to a duration
+func cpuTime(sec, usec int64) time.Duration {
+	return time.Duration(sec)*time.Second + time.Duration(usec)*time.Microsecond
+}
@@ -0,0 +1,20 @@
+//go:build darwin
+// +build darwin
+
+package transcription
+
+import (
+	"syscall"
+	"time"
+)
+
+// selfUsage returns the server's CPU time so far and its peak resident set
+// in bytes, from getrusage
+func selfUsage() (time.Duration, uint64) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, 0
+	}
+	cpu := cpuTime(int64(usage.Utime.Sec), int64(usage.Utime.Usec)) + cpuTime(int64(usage.Stime.Sec), int64(usage.Stime.Usec))
+	return cpu, uint64(usage.Maxrss) // Bytes on macOS
+}
@@ -0,0 +1,37 @@
+//go:build linux
+// +build linux
+
+package transcription
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// selfUsage returns the server's CPU time so far and its peak resident set
+// in bytes, read from VmHWM in /proc/self/status
+func selfUsage() (time.Duration, uint64) {
+	var cpu time.Duration
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err == nil {
+		cpu = cpuTime(int64(usage.Utime.Sec), int64(usage.Utime.Usec)) + cpuTime(int64(usage.Stime.Sec), int64(usage.Stime.Usec))
+	}
+
+	file, err := os.Open("/proc/self/status")
+	if err != nil {
+		return cpu, 0
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "VmHWM:"); ok {
+			kb, _ := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(value), " kB"), 10, 64)
+			return cpu, kb << 10
+		}
+	}
+	return cpu, 0
+}
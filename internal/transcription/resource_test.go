@@ -0,0 +1,39 @@
+package transcription
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"scriberr/internal/transcription/process"
+)
+
+func TestMeasureJob(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	failure := errors.New("job failed")
+	usage, err := MeasureJob(func() error {
+		// Burn CPU in a model-like child process and in the server itself
+		if err := process.Run(exec.Command("sh", "-c", "i=0; while [ $i -lt 100000 ]; do i=$((i+1)); done")); err != nil {
+			t.Fatalf("Child process failed: %v", err)
+		}
+		sum := 0
+		for start := time.Now(); time.Since(start) < 50*time.Millisecond; {
+			sum++
+		}
+		_ = sum
+		return failure
+	})
+
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected the job's error, got %v", err)
+	}
+	if usage.CPUSeconds <= 0 {
+		t.Errorf("Expected CPU time to be measured, got %v", usage.CPUSeconds)
+	}
+	if usage.PeakRSSMB <= 0 {
+		t.Errorf("Expected the peak resident set to be measured, got %d MiB", usage.PeakRSSMB)
+	}
+}
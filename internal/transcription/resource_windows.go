@@ -0,0 +1,17 @@
+//go:build windows
+// +build windows
+
+package transcription
+
+import (
+	"runtime"
+	"time"
+)
+
+// selfUsage returns no CPU time, which is not read on Windows, and the
+// memory the Go runtime holds from the system in place of the resident set
+func selfUsage() (time.Duration, uint64) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return 0, stats.Sys
+}
@@ -0,0 +1,48 @@
+package transcription
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"scriberr/internal/transcription/process"
+	"scriberr/pkg/logger"
+)
+
+// GracefulShutdown stops the model processes of in-flight jobs without
+// cutting them off mid-write: it refuses new processes, sends the running
+// ones SIGTERM, waits up to timeout for them to exit and then kills the rest.
+// Jobs it stops stay processing, so they are requeued on the next start.
+func GracefulShutdown(ctx context.Context, timeout time.Duration) error {
+	return gracefulShutdown(ctx, process.Default, timeout)
+}
+
+// gracefulShutdown is GracefulShutdown for the processes of tracker
+func gracefulShutdown(ctx context.Context, tracker *process.Tracker, timeout time.Duration) error {
+	running := tracker.Stop()
+	logger.Info("Stopped starting transcription processes", "running", running)
+	if running == 0 {
+		return nil
+	}
+
+	logger.Info("Sending SIGTERM to transcription processes", "count", running, "timeout", timeout)
+	if err := tracker.Signal(syscall.SIGTERM); err != nil {
+		logger.Warn("Failed to signal a transcription process", "error", err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if tracker.Wait(waitCtx) == nil {
+		logger.Info("Transcription processes exited")
+		return nil
+	}
+
+	stragglers := tracker.Running()
+	logger.Info("Killing transcription processes that did not exit", "count", stragglers)
+	if err := tracker.Signal(os.Kill); err != nil {
+		logger.Warn("Failed to kill a transcription process", "error", err)
+	}
+	return fmt.Errorf("%d transcription processes did not exit within %s and were killed", stragglers, timeout)
+}
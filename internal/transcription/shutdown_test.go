@@ -0,0 +1,95 @@
+package transcription
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"scriberr/internal/transcription/process"
+)
+
+// fakeProcess records the signals it gets and exits on the ones in exitOn
+type fakeProcess struct {
+	name    string
+	exitOn  os.Signal
+	mu      *sync.Mutex
+	signals *[]string
+	finish  func()
+}
+
+func (p *fakeProcess) signal(sig os.Signal) error {
+	p.mu.Lock()
+	*p.signals = append(*p.signals, p.name+":"+sig.String())
+	p.mu.Unlock()
+	if sig == p.exitOn || sig == os.Kill {
+		go p.finish()
+	}
+	return nil
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	tracker := process.NewTracker()
+	var mu sync.Mutex
+	var signals []string
+	start := func(name string, exitOn os.Signal) {
+		p := &fakeProcess{name: name, exitOn: exitOn, mu: &mu, signals: &signals}
+		finish, err := tracker.Track(p.signal)
+		if err != nil {
+			t.Fatalf("Track failed: %v", err)
+		}
+		p.finish = finish
+	}
+	start("polite", syscall.SIGTERM)
+	start("stubborn", nil)
+
+	err := gracefulShutdown(context.Background(), tracker, 50*time.Millisecond)
+	if err == nil {
+		t.Error("Expected an error for the killed process")
+	}
+
+	mu.Lock()
+	got := slices.Clone(signals)
+	mu.Unlock()
+	slices.Sort(got[:2]) // Both get SIGTERM, in no particular order
+	want := []string{"polite:terminated", "stubborn:terminated", "stubborn:killed"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected signals %v, got %v", want, got)
+	}
+	if err := tracker.Wait(context.Background()); err != nil || tracker.Running() != 0 {
+		t.Errorf("Expected every process to exit, %d still running", tracker.Running())
+	}
+
+	// Nothing new starts once shutdown began
+	if _, err := tracker.Track(func(os.Signal) error { return nil }); !errors.Is(err, process.ErrShuttingDown) {
+		t.Errorf("Expected ErrShuttingDown, got %v", err)
+	}
+}
+
+func TestGracefulShutdownWithoutKilling(t *testing.T) {
+	tracker := process.NewTracker()
+	var mu sync.Mutex
+	var signals []string
+	p := &fakeProcess{name: "polite", exitOn: syscall.SIGTERM, mu: &mu, signals: &signals}
+	finish, err := tracker.Track(p.signal)
+	if err != nil {
+		t.Fatalf("Track failed: %v", err)
+	}
+	p.finish = finish
+
+	if err := gracefulShutdown(context.Background(), tracker, time.Second); err != nil {
+		t.Errorf("Expected a clean shutdown, got %v", err)
+	}
+	if want := []string{"polite:terminated"}; !slices.Equal(signals, want) {
+		t.Errorf("Expected signals %v, got %v", want, signals)
+	}
+
+	// Without processes there is nothing to signal
+	if err := gracefulShutdown(context.Background(), process.NewTracker(), time.Second); err != nil {
+		t.Errorf("Expected no error without processes, got %v", err)
+	}
+}
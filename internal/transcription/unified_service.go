@@ -2,7 +2,10 @@ package transcription
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -13,11 +16,16 @@ import (
 
 	"scriberr/internal/config"
 	"scriberr/internal/database"
+	"scriberr/internal/lexicon"
 	"scriberr/internal/models"
+	"scriberr/internal/storage"
 	"scriberr/internal/transcription/interfaces"
 	"scriberr/internal/transcription/pipeline"
+	"scriberr/internal/transcription/postprocess"
 	"scriberr/internal/transcription/registry"
 	"scriberr/pkg/logger"
+
+	"gorm.io/gorm"
 )
 
 // UnifiedTranscriptionService provides a unified interface for all transcription and diarization models
@@ -30,6 +38,22 @@ type UnifiedTranscriptionService struct {
 	outputDirectory       string
 	defaultModelIDs       map[string]string      // Default model IDs for each task type
 	multiTrackTranscriber *MultiTrackTranscriber // For termination support
+	modelManager          *ModelManager          // Downloads missing Whisper models before WhisperX runs
+
+	// Directory WhisperX loads Whisper models from unless the job sets one
+	modelCacheDir string
+	// Job output directories are removed once imported unless this is set
+	keepOutputFiles bool
+	// HuggingFace token for diarization jobs that do not carry their own
+	huggingFaceToken string
+
+	// Detected languages with less confidence than this get a warning
+	languageConfidenceThreshold float64
+
+	// WhisperX jobs longer than longAudioThreshold are transcribed in chunks
+	// of about chunkLength
+	longAudioThreshold time.Duration
+	chunkLength        time.Duration
 }
 
 // NewUnifiedTranscriptionService creates a new unified transcription service
@@ -40,11 +64,14 @@ func NewUnifiedTranscriptionService() *UnifiedTranscriptionService {
 		preprocessors:   make(map[string]interfaces.Preprocessor),
 		postprocessors:  make(map[string]interfaces.Postprocessor),
 		tempDirectory:   "data/temp",
-		outputDirectory: "data/transcripts",
+		outputDirectory: "data/output",
 		defaultModelIDs: map[string]string{
 			"transcription": "whisperx",
 			"diarization":   "pyannote",
 		},
+		languageConfidenceThreshold: config.DefaultLanguageConfidenceThreshold,
+		longAudioThreshold:          config.DefaultLongAudioThreshold,
+		chunkLength:                 config.DefaultLongAudioChunkLength,
 	}
 }
 
@@ -110,7 +137,8 @@ func (u *UnifiedTranscriptionService) ProcessJob(ctx context.Context, jobID stri
 		database.DB.Save(execution)
 	}
 
-	// Check for multi-track processing
+	// Check for multi-track processing. Its phases are not told apart, so
+	// the whole run counts as transcription.
 	if job.IsMultiTrack && job.Parameters.IsMultiTrackEnabled {
 		logger.Info("Processing multi-track job", "job_id", jobID)
 		if err := u.processMultiTrackJob(ctx, &job); err != nil {
@@ -118,9 +146,10 @@ func (u *UnifiedTranscriptionService) ProcessJob(ctx context.Context, jobID stri
 			updateExecutionStatus(models.StatusFailed, errMsg)
 			return fmt.Errorf("multi-track processing failed: %w", err)
 		}
+		recordPhaseTiming(jobID, models.TimelineTranscription, startTime)
 	} else {
 		// Process single track
-		if err := u.processSingleTrackJob(ctx, &job); err != nil {
+		if err := u.processSingleTrackJob(ctx, &job, execution); err != nil {
 			errMsg := fmt.Sprintf("single-track processing failed: %v", err)
 			updateExecutionStatus(models.StatusFailed, errMsg)
 			return fmt.Errorf("single-track processing failed: %w", err)
@@ -133,9 +162,28 @@ func (u *UnifiedTranscriptionService) ProcessJob(ctx context.Context, jobID stri
 	return nil
 }
 
-// processSingleTrackJob handles single audio file transcription
-func (u *UnifiedTranscriptionService) processSingleTrackJob(ctx context.Context, job *models.TranscriptionJob) error {
+// processSingleTrackJob handles single audio file transcription, recording
+// preprocessing time on the execution and the time each phase took on the job
+func (u *UnifiedTranscriptionService) processSingleTrackJob(ctx context.Context, job *models.TranscriptionJob, execution *models.TranscriptionJobExecution) error {
 	logger.Info("Processing single-track job", "job_id", job.ID, "model_family", job.Parameters.ModelFamily)
+	phaseStart := time.Now()
+	// Only a run that reuses saved chunks resumes from somewhere
+	u.updateJobFields(job.ID, map[string]interface{}{"resumed_from_seconds": nil})
+
+	// Audio in object storage is downloaded for the models, which read files.
+	// It was converted before it was stored; uploads kept on disk in their
+	// original format are converted now.
+	audioPath, release, err := storage.Materialize(ctx, job.AudioPath, u.tempDirectory)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if !storage.IsRemote(job.AudioPath) {
+		if err := NormalizeJobAudio(ctx, job); err != nil {
+			return err
+		}
+		audioPath = job.AudioPath
+	}
 
 	// Create processing context
 	procCtx := interfaces.ProcessingContext{
@@ -145,17 +193,40 @@ func (u *UnifiedTranscriptionService) processSingleTrackJob(ctx context.Context,
 		Metadata:        map[string]string{},
 	}
 
-	// Create output directory
+	// Create output directory, emptied of what a failed earlier run kept
+	if err := os.RemoveAll(procCtx.OutputDirectory); err != nil {
+		return fmt.Errorf("failed to clear output directory: %w", err)
+	}
 	if err := os.MkdirAll(procCtx.OutputDirectory, 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
 	// Create audio input
-	audioInput, err := u.createAudioInput(job.AudioPath)
+	audioInput, err := u.createAudioInput(audioPath)
 	if err != nil {
 		return fmt.Errorf("failed to create audio input: %w", err)
 	}
 
+	if err := u.ensureModel(ctx, job); err != nil {
+		return err
+	}
+
+	// Track progress against the duration detected at upload, probing now for
+	// jobs created before durations were recorded
+	var totalSeconds float64
+	if job.DurationSeconds != nil && *job.DurationSeconds > 0 {
+		totalSeconds = *job.DurationSeconds
+	} else if totalSeconds = audioInput.Duration.Seconds(); totalSeconds > 0 {
+		if err := database.DB.Model(&models.TranscriptionJob{}).Where("id = ?", job.ID).Update("duration_seconds", totalSeconds).Error; err != nil {
+			logger.Warn("Failed to store audio duration", "job_id", job.ID, "error", err)
+		}
+	}
+	tracker := newProgressTracker(job.ID, totalSeconds)
+	procCtx.ProgressCallback = tracker.Report
+	estimatorCtx, stopEstimator := context.WithCancel(ctx)
+	defer stopEstimator()
+	go tracker.RunEstimator(estimatorCtx, historicalRealtimeFactor(job.Parameters.Model, job.Parameters.Device))
+
 	// Determine models to use first
 	transcriptionModelID, diarizationModelID, err := u.selectModels(job.Parameters)
 	if err != nil {
@@ -178,8 +249,16 @@ func (u *UnifiedTranscriptionService) processSingleTrackJob(ctx context.Context,
 		}
 	}
 
-	// Apply preprocessing
-	preprocessedInput, err = u.pipeline.ProcessAudio(ctx, audioInput, capabilities)
+	// Resample to 16 kHz mono up front so the models don't each do it, unless
+	// the job says the input already is
+	preprocessedInput = audioInput
+	if job.Parameters.SkipPreprocess {
+		logger.Info("Skipping audio resampling", "job_id", job.ID)
+	} else {
+		resampleStart := time.Now()
+		preprocessedInput, err = u.pipeline.ProcessAudio(ctx, audioInput, capabilities)
+		logger.Performance("audio_resample", time.Since(resampleStart), "job_id", job.ID)
+	}
 	if err != nil {
 		logger.Warn("Audio preprocessing failed, using original", "error", err)
 		preprocessedInput = audioInput
@@ -208,8 +287,24 @@ func (u *UnifiedTranscriptionService) processSingleTrackJob(ctx context.Context,
 		}
 	}()
 
+	// Apply the audio cleanup the job asked for
+	if filters := job.Parameters.Preprocessing.FilterChain(); filters != "" {
+		filtered, err := u.preprocessAudio(ctx, job.ID, preprocessedInput, filters, execution)
+		if err != nil {
+			return err
+		}
+		if filtered.TempFilePath != "" {
+			tempFilesToCleanup = append(tempFilesToCleanup, filtered.TempFilePath)
+		}
+		preprocessedInput = filtered
+	}
+
+	recordPhaseTiming(job.ID, models.TimelinePreprocessing, phaseStart)
+	phaseStart = time.Now()
+
 	var transcriptResult *interfaces.TranscriptResult
 	var diarizationResult *interfaces.DiarizationResult
+	var chunked bool // Chunks are transcribed without diarization
 
 	// Perform transcription using the preprocessed audio
 	if transcriptionModelID != "" {
@@ -221,8 +316,23 @@ func (u *UnifiedTranscriptionService) processSingleTrackJob(ctx context.Context,
 
 		// Convert parameters for this specific model
 		params := u.convertParametersForModel(job.Parameters, transcriptionModelID)
+		if transcriptionModelID == "whisperx" || transcriptionModelID == "faster-whisper" {
+			applyPronunciationLexicon(job, params)
+		}
 
-		transcriptResult, err = transcriptionAdapter.Transcribe(ctx, preprocessedInput, params, procCtx)
+		// Transcribe in the detected language rather than letting the model
+		// guess again
+		detection := u.detectLanguage(ctx, job, transcriptionAdapter, preprocessedInput, params)
+		if detection != nil {
+			params["language"] = detection.Language
+		}
+		logger.JobStarted(job.ID, filepath.Base(job.AudioPath), transcriptionModelID, jobLogParams(job.Parameters, detection))
+
+		if chunked = u.transcribesInChunks(transcriptionModelID, totalSeconds); chunked {
+			transcriptResult, err = u.transcribeInChunks(ctx, job, preprocessedInput, params, tracker.Report)
+		} else {
+			transcriptResult, err = transcriptionAdapter.Transcribe(ctx, preprocessedInput, params, procCtx)
+		}
 		if err != nil {
 			return fmt.Errorf("transcription failed: %w", err)
 		}
@@ -233,7 +343,7 @@ func (u *UnifiedTranscriptionService) processSingleTrackJob(ctx context.Context,
 		// Convert parameters for diarization model
 		diarizationParams := u.convertParametersForModel(job.Parameters, diarizationModelID)
 
-		if !u.transcriptionIncludesDiarization(transcriptionModelID, diarizationParams) {
+		if chunked || !u.transcriptionIncludesDiarization(transcriptionModelID, diarizationParams) {
 			logger.Info("Running separate diarization", "model_id", diarizationModelID)
 			diarizationAdapter, err := u.registry.GetDiarizationAdapter(diarizationModelID)
 			if err != nil {
@@ -253,16 +363,107 @@ func (u *UnifiedTranscriptionService) processSingleTrackJob(ctx context.Context,
 		}
 	}
 
+	recordPhaseTiming(job.ID, models.TimelineTranscription, phaseStart)
+	phaseStart = time.Now()
+
 	// Save results to database
 	if transcriptResult != nil {
-		if err := u.saveTranscriptionResults(job.ID, transcriptResult); err != nil {
+		if threshold := job.Parameters.WordConfidenceThreshold; threshold > 0 {
+			masked := MaskLowConfidenceWords(transcriptResult, threshold, job.Parameters.WordMask())
+			logger.Info("Masked low-confidence words", "job_id", job.ID, "threshold", threshold, "masked", masked)
+		}
+		var chapters []models.Chapter
+		if len(job.Parameters.PostProcessors) > 0 {
+			if chapters, err = postProcessTranscript(ctx, transcriptResult, job.Parameters.PostProcessors); err != nil {
+				return fmt.Errorf("post-processing failed: %w", err)
+			}
+			logger.Info("Post-processed transcript", "job_id", job.ID, "post_processors", len(job.Parameters.PostProcessors), "chapters", len(chapters))
+		}
+		if err := u.saveTranscriptionResults(job.ID, transcriptResult, chapters); err != nil {
 			return fmt.Errorf("failed to save transcription results: %w", err)
 		}
 	}
+	if !u.keepOutputFiles {
+		if err := os.RemoveAll(procCtx.OutputDirectory); err != nil {
+			logger.Warn("Failed to remove job output files", "job_id", job.ID, "path", procCtx.OutputDirectory, "error", err)
+		}
+	}
+	recordPhaseTiming(job.ID, models.TimelinePostProcessing, phaseStart)
 
+	stopEstimator()
+	tracker.Complete()
 	return nil
 }
 
+// postProcessTranscript runs a job's post-processors over the transcript
+// segments in order and rebuilds the full text from the result. It returns
+// the chapters found by the last chapter post-processor, if any.
+func postProcessTranscript(ctx context.Context, result *interfaces.TranscriptResult, configs []models.PostProcessorConfig) ([]models.Chapter, error) {
+	processors, err := postprocess.Build(configs)
+	if err != nil {
+		return nil, err
+	}
+	AttachWordsToSegments(result)
+	segments, err := postprocess.Run(ctx, processors, result.Segments)
+	if err != nil {
+		return nil, err
+	}
+	result.Segments = segments
+
+	var chapters []models.Chapter
+	for _, processor := range processors {
+		if finder, ok := processor.(postprocess.ChapterFinder); ok {
+			chapters = finder.Chapters()
+		}
+	}
+	for i := range chapters {
+		chapters[i].Start = roundTo(chapters[i].Start, 1000)
+	}
+
+	texts := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		if text := strings.TrimSpace(segment.Text); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	result.Text = strings.Join(texts, " ")
+	return chapters, nil
+}
+
+// preprocessAudio applies ffmpeg audio filters to a temporary copy of the
+// input, recording how long it took on the execution. Jobs are transcribed
+// without cleanup when ffmpeg is not installed.
+func (u *UnifiedTranscriptionService) preprocessAudio(ctx context.Context, jobID string, input interfaces.AudioInput, filters string, execution *models.TranscriptionJobExecution) (interfaces.AudioInput, error) {
+	if err := os.MkdirAll(u.tempDirectory, 0755); err != nil {
+		return input, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	output := filepath.Join(u.tempDirectory, jobID+"_preprocessed.wav")
+
+	logger.Info("Preprocessing audio", "job_id", jobID, "filters", filters)
+	startTime := time.Now()
+	err := PreprocessAudio(ctx, input.FilePath, output, filters)
+	duration := time.Since(startTime).Milliseconds()
+	execution.PreprocessingDuration = &duration
+	if errors.Is(err, ErrFFmpegUnavailable) {
+		logger.Warn("Skipping audio preprocessing, ffmpeg is not installed", "job_id", jobID)
+		return input, nil
+	}
+	if err != nil {
+		return input, fmt.Errorf("audio preprocessing failed: %w", err)
+	}
+
+	filtered := input
+	filtered.FilePath = output
+	filtered.TempFilePath = output
+	filtered.Format = normalizedFormat
+	filtered.SampleRate = normalizedSampleRate
+	filtered.Channels = normalizedChannels
+	if stat, err := os.Stat(output); err == nil {
+		filtered.Size = stat.Size()
+	}
+	return filtered, nil
+}
+
 // processMultiTrackJob handles multi-track audio processing
 func (u *UnifiedTranscriptionService) processMultiTrackJob(ctx context.Context, job *models.TranscriptionJob) error {
 	logger.Info("Processing multi-track job", "job_id", job.ID, "track_count", len(job.MultiTrackFiles))
@@ -297,20 +498,224 @@ func (u *UnifiedTranscriptionService) IsMultiTrackJob(jobID string) bool {
 	return job.IsMultiTrack
 }
 
+// SetModelManager shares the model manager the API downloads models with, so
+// jobs and API requests needing the same model wait on one download
+func (u *UnifiedTranscriptionService) SetModelManager(manager *ModelManager) {
+	u.modelManager = manager
+}
+
+// ensureModel downloads the job's Whisper model if it is not cached yet,
+// reporting the downloading_model phase meanwhile. WhisperX would otherwise
+// download it itself with no sign of progress.
+func (u *UnifiedTranscriptionService) ensureModel(ctx context.Context, job *models.TranscriptionJob) error {
+	if u.modelManager == nil || !u.modelManager.Supports(job.Parameters.Model) {
+		return nil
+	}
+	if TranscriptionModelID(job.Parameters) != "whisperx" {
+		return nil
+	}
+	cached, err := u.modelManager.IsCached(job.Parameters.Model)
+	if err != nil || cached {
+		return nil
+	}
+
+	logger.Info("Downloading Whisper model before transcription", "job_id", job.ID, "model", job.Parameters.Model)
+	setJobPhase(job.ID, models.PhaseDownloadingModel)
+	defer setJobPhase(job.ID, "")
+	if err := u.modelManager.DownloadModel(ctx, job.Parameters.Model); err != nil {
+		return fmt.Errorf("failed to download model %s: %w", job.Parameters.Model, err)
+	}
+	return nil
+}
+
+// SetModelCacheDir sets the directory WhisperX loads Whisper models from for
+// jobs that do not choose their own
+func (u *UnifiedTranscriptionService) SetModelCacheDir(dir string) {
+	u.modelCacheDir = dir
+}
+
+// SetOutputDirectory sets the directory jobs' output files are written to,
+// and whether they are kept once imported into the database
+func (u *UnifiedTranscriptionService) SetOutputDirectory(dir string, keep bool) {
+	if dir != "" {
+		u.outputDirectory = dir
+	}
+	u.keepOutputFiles = keep
+}
+
+// SetHuggingFaceToken sets the token pyannote models are downloaded with for
+// jobs that do not carry their own
+func (u *UnifiedTranscriptionService) SetHuggingFaceToken(token string) {
+	u.huggingFaceToken = token
+}
+
+// SetLanguageConfidenceThreshold sets the detection confidence below which
+// jobs get a warning
+func (u *UnifiedTranscriptionService) SetLanguageConfidenceThreshold(threshold float64) {
+	u.languageConfidenceThreshold = threshold
+}
+
+// SetLongAudioChunking sets the length above which WhisperX jobs are
+// transcribed in chunks, and the length of the chunks
+func (u *UnifiedTranscriptionService) SetLongAudioChunking(threshold, chunkLength time.Duration) {
+	u.longAudioThreshold = threshold
+	u.chunkLength = chunkLength
+}
+
+// transcribesInChunks reports whether audio of totalSeconds is long enough
+// to be transcribed in chunks by the model
+func (u *UnifiedTranscriptionService) transcribesInChunks(modelID string, totalSeconds float64) bool {
+	return modelID == "whisperx" && u.longAudioThreshold > 0 && u.chunkLength > 0 &&
+		totalSeconds > u.longAudioThreshold.Seconds()
+}
+
+// transcribeInChunks transcribes a long file with WhisperX in chunks, so a
+// model that runs out of memory on the whole file can still finish it.
+// Chunks are transcribed one at a time on a GPU and two at once on the CPU.
+// Diarization is left to the caller, which runs it over the whole file so
+// speakers keep the same labels from chunk to chunk. Chunks already
+// transcribed by an earlier, failed run of the job with the same parameters
+// are reused.
+func (u *UnifiedTranscriptionService) transcribeInChunks(ctx context.Context, job *models.TranscriptionJob, input interfaces.AudioInput, params map[string]interface{}, progress func(float64)) (*interfaces.TranscriptResult, error) {
+	chunkParams := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		chunkParams[key] = value
+	}
+	chunkParams["diarize"] = false
+
+	// The resume key covers everything that changes what a chunk transcribes
+	keyData, err := json.Marshal(map[string]interface{}{"params": chunkParams, "filters": job.Parameters.Preprocessing.FilterChain()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build chunk resume key: %w", err)
+	}
+	key := sha256.Sum256(keyData)
+
+	workers := 1
+	if job.Parameters.Device == "cpu" {
+		workers = defaultChunkWorkers
+	}
+	logger.Info("Transcribing long audio in chunks", "job_id", job.ID, "chunk_length", u.chunkLength, "workers", workers)
+	segments, err := ChunkedTranscription(ctx, input.FilePath, int(u.chunkLength.Seconds()), TranscribeOptions{
+		NumWorkers:       workers,
+		TempDirectory:    u.tempDirectory,
+		JobID:            job.ID,
+		Params:           chunkParams,
+		ProgressCallback: progress,
+		ResumeDirectory:  filepath.Join(u.tempDirectory, "chunks", job.ID),
+		ResumeKey:        hex.EncodeToString(key[:]),
+		OnChunkSaved: func(AudioChunk) {
+			u.updateJobFields(job.ID, map[string]interface{}{"has_partial": true})
+		},
+		OnResume: func(fromSeconds float64) {
+			u.updateJobFields(job.ID, map[string]interface{}{"resumed_from_seconds": fromSeconds})
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	u.updateJobFields(job.ID, map[string]interface{}{"has_partial": false})
+
+	texts := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		texts = append(texts, strings.TrimSpace(segment.Text))
+	}
+	result := &interfaces.TranscriptResult{
+		Text:      strings.Join(texts, " "),
+		Segments:  segments,
+		ModelUsed: "whisperx",
+		Metadata:  map[string]string{"chunked": "true"},
+	}
+	if language, ok := chunkParams["language"].(string); ok {
+		result.Language = language
+	}
+	return result, nil
+}
+
+// updateJobFields stores fields of a running job, logging rather than
+// failing the job when they cannot be saved
+func (u *UnifiedTranscriptionService) updateJobFields(jobID string, fields map[string]interface{}) {
+	if err := database.DB.Model(&models.TranscriptionJob{}).Where("id = ?", jobID).Updates(fields).Error; err != nil {
+		logger.Warn("Failed to update job", "job_id", jobID, "fields", fields, "error", err)
+	}
+}
+
+// detectLanguage runs the adapter's language detection pass for jobs that
+// leave the language to the model, recording the language and confidence on
+// the job. A confidence below the threshold flags the job with a warning
+// rather than failing it, so users can re-run it with the language set. It
+// returns nil when the job sets the language, the adapter cannot run a
+// separate pass, or the pass fails; the model then detects the language
+// itself while transcribing.
+func (u *UnifiedTranscriptionService) detectLanguage(ctx context.Context, job *models.TranscriptionJob, adapter interfaces.TranscriptionAdapter, input interfaces.AudioInput, params map[string]interface{}) *interfaces.LanguageDetection {
+	// Results of an earlier run no longer apply
+	updates := map[string]interface{}{"language_confidence": nil, "warning": nil}
+	var detection *interfaces.LanguageDetection
+	if detector, ok := adapter.(interfaces.LanguageDetector); ok && job.Parameters.DetectsLanguage() {
+		setJobPhase(job.ID, models.PhaseDetectingLanguage)
+		detected, err := detector.DetectLanguage(ctx, input, params)
+		setJobPhase(job.ID, "")
+		if err != nil {
+			logger.Warn("Language detection failed, leaving it to the model", "job_id", job.ID, "error", err)
+		} else {
+			detection = detected
+			updates["detected_language"] = detected.Language
+			updates["language_confidence"] = detected.Confidence
+			if detected.Confidence < u.languageConfidenceThreshold {
+				updates["warning"] = fmt.Sprintf("Detected language %s with low confidence (%.2f); re-run with the language set if the transcript is wrong", detected.Language, detected.Confidence)
+				logger.Warn("Detected language with low confidence", "job_id", job.ID, "language", detected.Language, "confidence", detected.Confidence)
+			}
+		}
+	}
+	if err := database.DB.Model(&models.TranscriptionJob{}).Where("id = ?", job.ID).Updates(updates).Error; err != nil {
+		logger.Warn("Failed to store language detection", "job_id", job.ID, "error", err)
+	}
+	return detection
+}
+
+// jobLogParams describes a job's parameters for the job started log
+func jobLogParams(params models.WhisperXParams, detection *interfaces.LanguageDetection) map[string]any {
+	logParams := map[string]any{
+		"model":        params.Model,
+		"model_family": params.ModelFamily,
+		"diarization":  params.Diarize,
+		"language":     params.Language,
+		"device":       params.Device,
+	}
+	if params.Diarize && params.DiarizeModel != "" {
+		logParams["diarize_model"] = params.DiarizeModel
+	}
+	if detection != nil {
+		logParams["detected_language"] = detection.Language
+		logParams["language_confidence"] = detection.Confidence
+	}
+	return logParams
+}
+
+// setJobPhase records the processing step a job is on
+func setJobPhase(jobID, phase string) {
+	if err := database.DB.Model(&models.TranscriptionJob{}).Where("id = ?", jobID).Update("phase", phase).Error; err != nil {
+		logger.Warn("Failed to update job phase", "job_id", jobID, "phase", phase, "error", err)
+	}
+}
+
+// recordPhaseTiming records the time since start as the time a job spent in
+// a timeline phase
+func recordPhaseTiming(jobID, phase string, start time.Time) {
+	if err := database.RecordPhaseTiming(jobID, phase, time.Since(start)); err != nil {
+		logger.Warn("Failed to record phase timing", "job_id", jobID, "phase", phase, "error", err)
+	}
+}
+
+// TranscriptionModelID returns the ID of the transcription adapter that runs
+// jobs with the given parameters, see WhisperXParams.AdapterID
+func TranscriptionModelID(params models.WhisperXParams) string {
+	return params.AdapterID()
+}
+
 // selectModels determines which models to use based on job parameters
 func (u *UnifiedTranscriptionService) selectModels(params models.WhisperXParams) (transcriptionModelID, diarizationModelID string, err error) {
 	env := config.EnvironmentInfo()
-	// Determine transcription model
-	switch params.ModelFamily {
-	case "nvidia_parakeet":
-		transcriptionModelID = "parakeet"
-	case "nvidia_canary":
-		transcriptionModelID = "canary"
-	case "whisper":
-		transcriptionModelID = "whisperx"
-	default:
-		transcriptionModelID = "whisperx" // Default fallback
-	}
+	transcriptionModelID = TranscriptionModelID(params)
 
 	// Determine diarization model if needed
 	if params.Diarize {
@@ -485,6 +890,24 @@ func (u *UnifiedTranscriptionService) createAudioInput(audioPath string) (interf
 	return audioInput, nil
 }
 
+// applyPronunciationLexicon adds the words of the job owner's pronunciation
+// dictionary to the initial prompt WhisperX is given
+func applyPronunciationLexicon(job *models.TranscriptionJob, params map[string]interface{}) {
+	if job.UserID == nil {
+		return
+	}
+	words, err := lexicon.Words(*job.UserID)
+	if err != nil {
+		logger.Warn("Failed to read pronunciation lexicon", "job_id", job.ID, "error", err)
+		return
+	}
+	if len(words) == 0 {
+		return
+	}
+	prompt, _ := params["initial_prompt"].(string)
+	params["initial_prompt"] = lexicon.Prompt(prompt, words)
+}
+
 // parametersToMap converts WhisperXParams to a generic parameter map
 // convertParametersForModel converts WhisperX parameters to model-specific parameters
 func (u *UnifiedTranscriptionService) convertParametersForModel(params models.WhisperXParams, modelID string) map[string]interface{} {
@@ -495,10 +918,14 @@ func (u *UnifiedTranscriptionService) convertParametersForModel(params models.Wh
 		return u.convertToCanaryParams(params)
 	case "whisperx":
 		return u.convertToWhisperXParams(params)
+	case "faster-whisper":
+		return u.convertToFasterWhisperParams(params)
 	case "pyannote":
 		return u.convertToPyannoteParams(params)
 	case "sortformer":
 		return u.convertToSortformerParams(params)
+	case "openai":
+		return u.convertToOpenAIParams(params)
 	default:
 		// Fallback to legacy conversion
 		return u.parametersToMap(params)
@@ -540,16 +967,28 @@ func (u *UnifiedTranscriptionService) convertToCanaryParams(params models.Whispe
 	return paramMap
 }
 
+// convertToFasterWhisperParams converts to faster-whisper parameters, which
+// are WhisperX's without alignment and diarization; speakers are diarized by
+// a separate model
+func (u *UnifiedTranscriptionService) convertToFasterWhisperParams(params models.WhisperXParams) map[string]interface{} {
+	paramMap := u.convertToWhisperXParams(params)
+	for _, name := range []string{"batch_size", "diarize", "diarize_model", "min_speakers", "max_speakers", "hf_token", "align_model", "vad_method", "vad_offset"} {
+		delete(paramMap, name)
+	}
+	return paramMap
+}
+
 // convertToWhisperXParams converts to WhisperX-specific parameters
 func (u *UnifiedTranscriptionService) convertToWhisperXParams(params models.WhisperXParams) map[string]interface{} {
 	// For WhisperX, we use the standard WhisperX parameters (no NVIDIA-specific ones)
+	vadOnset, vadOffset := params.VADThresholds()
 	paramMap := map[string]interface{}{
 		// Core parameters
 		"model":        params.Model,
 		"device":       params.Device,
 		"device_index": params.DeviceIndex,
-		"batch_size":   params.BatchSize,
-		"compute_type": params.ComputeType,
+		"batch_size":   batchSizeOrDefault(params),
+		"compute_type": params.ComputeTypeOrDefault(),
 		"threads":      params.Threads,
 
 		// Task and language
@@ -567,8 +1006,8 @@ func (u *UnifiedTranscriptionService) convertToWhisperXParams(params models.Whis
 
 		// VAD settings
 		"vad_method": params.VadMethod,
-		"vad_onset":  params.VadOnset,
-		"vad_offset": params.VadOffset,
+		"vad_onset":  vadOnset,
+		"vad_offset": vadOffset,
 	}
 
 	// Handle pointer fields - only add if not nil
@@ -581,11 +1020,15 @@ func (u *UnifiedTranscriptionService) convertToWhisperXParams(params models.Whis
 	if params.MaxSpeakers != nil {
 		paramMap["max_speakers"] = *params.MaxSpeakers
 	}
-	if params.HfToken != nil {
+	if params.HfToken != nil && *params.HfToken != "" {
 		paramMap["hf_token"] = *params.HfToken
+	} else if u.huggingFaceToken != "" {
+		paramMap["hf_token"] = u.huggingFaceToken
 	}
 	if params.ModelDir != nil {
 		paramMap["model_dir"] = *params.ModelDir
+	} else if u.modelCacheDir != "" {
+		paramMap["model_dir"] = u.modelCacheDir
 	}
 	if params.AlignModel != nil {
 		paramMap["align_model"] = *params.AlignModel
@@ -596,6 +1039,9 @@ func (u *UnifiedTranscriptionService) convertToWhisperXParams(params models.Whis
 	if params.InitialPrompt != nil {
 		paramMap["initial_prompt"] = *params.InitialPrompt
 	}
+	if len(params.Temperatures) > 0 {
+		paramMap["temperatures"] = params.Temperatures
+	}
 
 	return paramMap
 }
@@ -613,8 +1059,10 @@ func (u *UnifiedTranscriptionService) convertToPyannoteParams(params models.Whis
 	if params.MaxSpeakers != nil {
 		paramMap["max_speakers"] = *params.MaxSpeakers
 	}
-	if params.HfToken != nil {
+	if params.HfToken != nil && *params.HfToken != "" {
 		paramMap["hf_token"] = *params.HfToken
+	} else if u.huggingFaceToken != "" {
+		paramMap["hf_token"] = u.huggingFaceToken
 	}
 
 	return paramMap
@@ -629,14 +1077,32 @@ func (u *UnifiedTranscriptionService) convertToSortformerParams(params models.Wh
 	}
 }
 
+// convertToOpenAIParams converts to hosted OpenAI API parameters. The hosted
+// model comes from configuration, since job models name local Whisper sizes.
+func (u *UnifiedTranscriptionService) convertToOpenAIParams(params models.WhisperXParams) map[string]interface{} {
+	paramMap := map[string]interface{}{
+		"task":        params.Task,
+		"temperature": params.Temperature,
+	}
+
+	if params.Language != nil {
+		paramMap["language"] = *params.Language
+	}
+	if params.InitialPrompt != nil {
+		paramMap["prompt"] = *params.InitialPrompt
+	}
+
+	return paramMap
+}
+
 func (u *UnifiedTranscriptionService) parametersToMap(params models.WhisperXParams) map[string]interface{} {
 	paramMap := map[string]interface{}{
 		// Core parameters
 		"model":        params.Model,
 		"device":       params.Device,
 		"device_index": params.DeviceIndex,
-		"batch_size":   params.BatchSize,
-		"compute_type": params.ComputeType,
+		"batch_size":   batchSizeOrDefault(params),
+		"compute_type": params.ComputeTypeOrDefault(),
 		"threads":      params.Threads,
 
 		// Language and task
@@ -679,8 +1145,7 @@ func (u *UnifiedTranscriptionService) parametersToMap(params models.WhisperXPara
 	paramMap["beam_size"] = params.BeamSize
 	paramMap["patience"] = params.Patience
 	paramMap["vad_method"] = params.VadMethod
-	paramMap["vad_onset"] = params.VadOnset
-	paramMap["vad_offset"] = params.VadOffset
+	paramMap["vad_onset"], paramMap["vad_offset"] = params.VADThresholds()
 	paramMap["context_left"] = params.AttentionContextLeft
 	paramMap["context_right"] = params.AttentionContextRight
 	paramMap["timestamps"] = true
@@ -762,18 +1227,77 @@ func (u *UnifiedTranscriptionService) findBestSpeakerForSegment(start, end float
 	return bestSpeaker
 }
 
-// saveTranscriptionResults saves the transcription results to the database
-func (u *UnifiedTranscriptionService) saveTranscriptionResults(jobID string, result *interfaces.TranscriptResult) error {
+// summarizeSpeakers lists the distinct speakers in order of first appearance
+// with their total speaking time
+func summarizeSpeakers(segments []interfaces.TranscriptSegment) []interfaces.TranscriptSpeaker {
+	var speakers []interfaces.TranscriptSpeaker
+	index := make(map[string]int)
+	for _, segment := range segments {
+		if segment.Speaker == nil || *segment.Speaker == "" {
+			continue
+		}
+		i, ok := index[*segment.Speaker]
+		if !ok {
+			i = len(speakers)
+			index[*segment.Speaker] = i
+			speakers = append(speakers, interfaces.TranscriptSpeaker{
+				ID:    *segment.Speaker,
+				Label: fmt.Sprintf("Speaker %d", i+1),
+			})
+		}
+		speakers[i].TotalSpeakingTime += max(0, segment.End-segment.Start)
+	}
+	return speakers
+}
+
+// detectedLanguage is the language a result reports, or nil when the model
+// did not report one
+func detectedLanguage(result *interfaces.TranscriptResult) *string {
+	language := strings.ToLower(strings.TrimSpace(result.Language))
+	if language == "" || language == "unknown" {
+		return nil
+	}
+	return &language
+}
+
+// saveTranscriptionResults saves the transcription results and chapters to
+// the database, clearing the chapters of an earlier run when there are none
+func (u *UnifiedTranscriptionService) saveTranscriptionResults(jobID string, result *interfaces.TranscriptResult, chapters []models.Chapter) error {
+	if len(result.Speakers) == 0 {
+		result.Speakers = summarizeSpeakers(result.Segments)
+	}
+	CompactTranscript(result)
+
 	// Convert result to JSON string for database storage
 	resultJSON, err := u.convertTranscriptResultToJSON(result)
 	if err != nil {
 		return fmt.Errorf("failed to convert result to JSON: %w", err)
 	}
-
-	// Update the job in the database
-	if err := database.DB.Model(&models.TranscriptionJob{}).
-		Where("id = ?", jobID).
-		Update("transcript", resultJSON).Error; err != nil {
+	var chaptersJSON interface{}
+	if len(chapters) > 0 {
+		data, err := json.Marshal(chapters)
+		if err != nil {
+			return fmt.Errorf("failed to convert chapters to JSON: %w", err)
+		}
+		chaptersJSON = string(data)
+	}
+
+	// Update the job in the database. A fresh transcript replaces any edit
+	// history, and bumping the revision invalidates exports of the old one.
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.TranscriptionJob{}).
+			Where("id = ?", jobID).
+			Updates(map[string]interface{}{
+				"transcript":          resultJSON,
+				"transcript_revision": gorm.Expr("transcript_revision + 1"),
+				"detected_language":   detectedLanguage(result),
+				"chapters":            chaptersJSON,
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Where("transcription_job_id = ?", jobID).Delete(&models.TranscriptRevision{}).Error
+	})
+	if err != nil {
 		return fmt.Errorf("failed to update job transcript: %w", err)
 	}
 
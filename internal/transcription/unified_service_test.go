@@ -0,0 +1,282 @@
+package transcription
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"scriberr/internal/database"
+	"scriberr/internal/lexicon"
+	"scriberr/internal/models"
+	"scriberr/internal/transcription/interfaces"
+)
+
+func TestSaveTranscriptionResultsDetectedLanguage(t *testing.T) {
+	if err := database.Initialize(filepath.Join(t.TempDir(), "scriberr.db")); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	job := models.TranscriptionJob{ID: "job-fr", Status: models.StatusProcessing, AudioPath: "audio.wav"}
+	if err := database.DB.Create(&job).Error; err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+
+	service := &UnifiedTranscriptionService{}
+	result := &interfaces.TranscriptResult{
+		Language: "fr",
+		Text:     "Bonjour à tous",
+		Segments: []interfaces.TranscriptSegment{{Start: 0, End: 1.5, Text: "Bonjour à tous"}},
+	}
+	if err := service.saveTranscriptionResults(job.ID, result, nil); err != nil {
+		t.Fatalf("saveTranscriptionResults failed: %v", err)
+	}
+	if err := database.DB.First(&job, "id = ?", job.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if job.DetectedLanguage == nil || *job.DetectedLanguage != "fr" {
+		t.Errorf("Expected detected_language fr, got %v", job.DetectedLanguage)
+	}
+
+	// A rerun that reports no language clears the old one
+	result.Language = ""
+	if err := service.saveTranscriptionResults(job.ID, result, nil); err != nil {
+		t.Fatalf("saveTranscriptionResults failed: %v", err)
+	}
+	database.DB.First(&job, "id = ?", job.ID)
+	if job.DetectedLanguage != nil {
+		t.Errorf("Expected detected_language to be cleared, got %q", *job.DetectedLanguage)
+	}
+}
+
+func TestSaveTranscriptionResultsChapters(t *testing.T) {
+	if err := database.Initialize(filepath.Join(t.TempDir(), "scriberr.db")); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	job := models.TranscriptionJob{ID: "job-chapters", Status: models.StatusProcessing, AudioPath: "audio.wav"}
+	if err := database.DB.Create(&job).Error; err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+
+	service := &UnifiedTranscriptionService{}
+	result := &interfaces.TranscriptResult{Segments: []interfaces.TranscriptSegment{{Start: 0, End: 1, Text: "Hello."}}}
+	chapters := []models.Chapter{{Type: models.ChapterMarker, Start: 0}, {Type: models.ChapterMarker, Start: 42.5}}
+	if err := service.saveTranscriptionResults(job.ID, result, chapters); err != nil {
+		t.Fatalf("saveTranscriptionResults failed: %v", err)
+	}
+	var saved models.TranscriptionJob
+	if err := database.DB.First(&saved, "id = ?", job.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(saved.Chapters, chapters) {
+		t.Errorf("Expected chapters %+v, got %+v", chapters, saved.Chapters)
+	}
+
+	// A run without chapter detection clears the old chapters
+	if err := service.saveTranscriptionResults(job.ID, result, nil); err != nil {
+		t.Fatalf("saveTranscriptionResults failed: %v", err)
+	}
+	var resaved models.TranscriptionJob
+	if err := database.DB.First(&resaved, "id = ?", job.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(resaved.Chapters) != 0 {
+		t.Errorf("Expected chapters to be cleared, got %+v", resaved.Chapters)
+	}
+}
+
+func TestEnsureModelReportsDownloadingPhase(t *testing.T) {
+	if err := database.Initialize(filepath.Join(t.TempDir(), "scriberr.db")); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	calls := slowUV(t, 500*time.Millisecond)
+
+	job := models.TranscriptionJob{ID: "job-model", Status: models.StatusProcessing, AudioPath: "audio.wav"}
+	job.Parameters.ModelFamily = "whisper"
+	job.Parameters.Model = "tiny"
+	if err := database.DB.Create(&job).Error; err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+
+	service := &UnifiedTranscriptionService{modelManager: newTestModelManager(t)}
+	done := make(chan error, 1)
+	go func() { done <- service.ensureModel(context.Background(), &job) }()
+
+	var phase string
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline) && phase == ""; time.Sleep(20 * time.Millisecond) {
+		database.DB.Model(&models.TranscriptionJob{}).Where("id = ?", job.ID).Select("phase").Scan(&phase)
+	}
+	if phase != models.PhaseDownloadingModel {
+		t.Errorf("Expected phase %s while the model downloads, got %q", models.PhaseDownloadingModel, phase)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("ensureModel failed: %v", err)
+	}
+	database.DB.Model(&models.TranscriptionJob{}).Where("id = ?", job.ID).Select("phase").Scan(&phase)
+	if phase != "" {
+		t.Errorf("Expected the phase to be cleared, got %q", phase)
+	}
+
+	// A cached model is not downloaded again
+	if err := service.ensureModel(context.Background(), &job); err != nil {
+		t.Fatalf("ensureModel failed: %v", err)
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("Expected one download, got %d", got)
+	}
+}
+
+// detectingAdapter is a transcription adapter whose detection pass reports a
+// fixed result
+type detectingAdapter struct {
+	interfaces.TranscriptionAdapter
+	detection *interfaces.LanguageDetection
+	calls     int
+}
+
+func (a *detectingAdapter) DetectLanguage(ctx context.Context, input interfaces.AudioInput, params map[string]interface{}) (*interfaces.LanguageDetection, error) {
+	a.calls++
+	return a.detection, nil
+}
+
+func TestDetectLanguage(t *testing.T) {
+	if err := database.Initialize(filepath.Join(t.TempDir(), "scriberr.db")); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	auto := models.LanguageAuto
+	job := models.TranscriptionJob{ID: "job-auto", Status: models.StatusProcessing, AudioPath: "audio.wav"}
+	job.Parameters.Language = &auto
+	if err := database.DB.Create(&job).Error; err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+	service := &UnifiedTranscriptionService{languageConfidenceThreshold: 0.5}
+	adapter := &detectingAdapter{detection: &interfaces.LanguageDetection{Language: "nb", Confidence: 0.94}}
+
+	detection := service.detectLanguage(context.Background(), &job, adapter, interfaces.AudioInput{}, nil)
+	if detection == nil || detection.Language != "nb" {
+		t.Fatalf("Expected nb to be detected, got %v", detection)
+	}
+	database.DB.First(&job, "id = ?", job.ID)
+	if job.DetectedLanguage == nil || *job.DetectedLanguage != "nb" || job.LanguageConfidence == nil || *job.LanguageConfidence != 0.94 {
+		t.Errorf("Expected nb (0.94) to be stored, got %v (%v)", job.DetectedLanguage, job.LanguageConfidence)
+	}
+	if job.Warning != nil {
+		t.Errorf("Expected no warning for a confident detection, got %q", *job.Warning)
+	}
+
+	// An uncertain detection flags the job without failing it
+	adapter.detection = &interfaces.LanguageDetection{Language: "da", Confidence: 0.41}
+	if detection := service.detectLanguage(context.Background(), &job, adapter, interfaces.AudioInput{}, nil); detection == nil {
+		t.Fatal("Expected the uncertain language to be returned")
+	}
+	database.DB.First(&job, "id = ?", job.ID)
+	if job.Warning == nil || !strings.Contains(*job.Warning, "da") {
+		t.Errorf("Expected a low confidence warning, got %v", job.Warning)
+	}
+
+	// Re-running with the language set skips detection and clears the warning
+	forced := "nb"
+	job.Parameters.Language = &forced
+	if detection := service.detectLanguage(context.Background(), &job, adapter, interfaces.AudioInput{}, nil); detection != nil {
+		t.Errorf("Expected no detection for a forced language, got %v", detection)
+	}
+	if adapter.calls != 2 {
+		t.Errorf("Expected two detection passes, got %d", adapter.calls)
+	}
+	database.DB.First(&job, "id = ?", job.ID)
+	if job.Warning != nil || job.LanguageConfidence != nil {
+		t.Errorf("Expected the warning and confidence to be cleared, got %v and %v", job.Warning, job.LanguageConfidence)
+	}
+}
+
+func TestTranscribesInChunks(t *testing.T) {
+	service := &UnifiedTranscriptionService{longAudioThreshold: 2 * time.Hour, chunkLength: 30 * time.Minute}
+	tests := []struct {
+		modelID string
+		seconds float64
+		want    bool
+	}{
+		{"whisperx", 3 * 3600, true},
+		{"whisperx", 2 * 3600, false},
+		{"whisperx", 0, false},
+		{"parakeet", 3 * 3600, false},
+	}
+	for _, tt := range tests {
+		if got := service.transcribesInChunks(tt.modelID, tt.seconds); got != tt.want {
+			t.Errorf("transcribesInChunks(%q, %.0f) = %v, want %v", tt.modelID, tt.seconds, got, tt.want)
+		}
+	}
+}
+
+func TestPostProcessTranscript(t *testing.T) {
+	result := &interfaces.TranscriptResult{
+		Text: "Jane called. Ask Jane later.",
+		Segments: []interfaces.TranscriptSegment{
+			{Start: 0, End: 1, Text: " Jane called."},
+			{Start: 1, End: 2, Text: " Ask Jane later."},
+		},
+		WordSegments: []interfaces.TranscriptWord{
+			{Start: 0, End: 0.4, Word: "Jane"},
+			{Start: 0.4, End: 0.9, Word: "called."},
+			{Start: 1.2, End: 1.5, Word: "Jane"},
+		},
+	}
+	configs := []models.PostProcessorConfig{
+		{Name: "word_blocklist", Options: []byte(`{"words":["jane"]}`)},
+		{Name: "chapters"},
+	}
+	chapters, err := postProcessTranscript(context.Background(), result, configs)
+	if err != nil {
+		t.Fatalf("postProcessTranscript failed: %v", err)
+	}
+	if want := []models.Chapter{{Type: models.ChapterMarker, Start: 0}}; !reflect.DeepEqual(chapters, want) {
+		t.Errorf("Expected chapters %+v, got %+v", want, chapters)
+	}
+	if want := "[redacted] called. Ask [redacted] later."; result.Text != want {
+		t.Errorf("Expected text %q, got %q", want, result.Text)
+	}
+	if result.WordSegments != nil || result.Segments[1].Words[0].Word != "[redacted]" {
+		t.Errorf("Expected word timings to be attached to segments and redacted, got %+v", result.Segments)
+	}
+
+	_, err = postProcessTranscript(context.Background(), result, []models.PostProcessorConfig{{Name: "missing"}})
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("Expected an unknown post-processor to fail, got %v", err)
+	}
+}
+
+func TestApplyPronunciationLexicon(t *testing.T) {
+	lexicon.Dir = t.TempDir()
+	t.Cleanup(func() { lexicon.Dir = "data/lexicons" })
+	if err := lexicon.Write(3, []models.PronunciationEntry{{Word: "Xeljanz", Phonetic: "ZEL-jans"}}); err != nil {
+		t.Fatalf("Failed to write lexicon: %v", err)
+	}
+	userID := uint(3)
+
+	params := map[string]interface{}{"initial_prompt": "A rheumatology consult."}
+	applyPronunciationLexicon(&models.TranscriptionJob{ID: "job", UserID: &userID}, params)
+	if want := "A rheumatology consult. Glossary: Xeljanz."; params["initial_prompt"] != want {
+		t.Errorf("Expected prompt %q, got %v", want, params["initial_prompt"])
+	}
+
+	params = map[string]interface{}{}
+	applyPronunciationLexicon(&models.TranscriptionJob{ID: "job"}, params)
+	if _, ok := params["initial_prompt"]; ok {
+		t.Errorf("Expected jobs without an owner to keep their prompt, got %v", params["initial_prompt"])
+	}
+
+	otherUser := uint(4)
+	applyPronunciationLexicon(&models.TranscriptionJob{ID: "job", UserID: &otherUser}, params)
+	if _, ok := params["initial_prompt"]; ok {
+		t.Errorf("Expected owners without a dictionary to keep their prompt, got %v", params["initial_prompt"])
+	}
+}
@@ -0,0 +1,184 @@
+package transcription
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Audio validation error codes
+const (
+	ValidationCorruptFile      = "corrupt_file"
+	ValidationNoAudioStream    = "no_audio_stream"
+	ValidationUnsupportedCodec = "unsupported_codec"
+	ValidationLowSampleRate    = "low_sample_rate"
+	ValidationEmptyAudio       = "empty_audio"
+	ValidationUnsupportedType  = "unsupported_type"
+)
+
+// minSampleRate is the lowest sample rate speech models can transcribe usefully
+const minSampleRate = 8000
+
+// ErrFFprobeUnavailable is returned when ffprobe is not installed, so files
+// cannot be validated
+var ErrFFprobeUnavailable = errors.New("ffprobe is not available")
+
+// ValidationError explains why an uploaded file cannot be transcribed
+type ValidationError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// supportedCodecs are the audio codecs the transcription pipeline decodes.
+// PCM and ADPCM variants are matched by prefix.
+var supportedCodecs = map[string]bool{
+	"aac": true, "ac3": true, "alac": true, "amr_nb": true, "amr_wb": true,
+	"ape": true, "dss_sp": true, "dts": true, "eac3": true, "flac": true, "gsm": true,
+	"gsm_ms": true, "mp1": true, "mp2": true, "mp3": true, "mp3float": true,
+	"opus": true, "speex": true, "truehd": true, "tta": true, "vorbis": true,
+	"wavpack": true, "wmalossless": true, "wmapro": true, "wmav1": true,
+	"wmav2": true,
+}
+
+func isSupportedCodec(codec string) bool {
+	return supportedCodecs[codec] || strings.HasPrefix(codec, "pcm_") || strings.HasPrefix(codec, "adpcm_")
+}
+
+// mediaExtensions are the audio and video file types accepted for transcription
+var mediaExtensions = map[string]bool{
+	".mp3": true, ".wav": true, ".flac": true, ".m4a": true, ".m4b": true, ".aac": true, ".ogg": true,
+	".opus": true, ".wma": true, ".aif": true, ".aiff": true, ".amr": true, ".dss": true,
+	".mp4": true, ".avi": true, ".mov": true, ".mkv": true, ".webm": true, ".wmv": true, ".flv": true, ".3gp": true,
+}
+
+// IsSupportedMediaFile reports whether a file name has an audio or video
+// extension accepted for transcription
+func IsSupportedMediaFile(name string) bool {
+	return mediaExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// ffprobeStreams is the part of ffprobe's JSON output read by ProbeAudio
+type ffprobeStreams struct {
+	Streams []struct {
+		CodecType  string `json:"codec_type"`
+		CodecName  string `json:"codec_name"`
+		SampleRate string `json:"sample_rate"`
+		Channels   int    `json:"channels"`
+	} `json:"streams"`
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+	} `json:"format"`
+}
+
+// AudioInfo describes the audio stream ProbeAudio found in a file
+type AudioInfo struct {
+	Format          string  // Container, as named by ffprobe ("wav", "matroska,webm")
+	Codec           string  // Codec of the audio stream that will be transcribed
+	SampleRate      int     // Hz
+	Channels        int     // Zero when ffprobe does not report it
+	DurationSeconds float64 // Zero when the container does not record a length
+}
+
+// ValidateAudioFile checks with ffprobe that a file is a readable container
+// with an audio stream the pipeline can decode, sampled at 8 kHz or more and
+// with a non-zero duration. Problems with the file are reported as a
+// *ValidationError; ErrFFprobeUnavailable means the check could not be run.
+func ValidateAudioFile(ctx context.Context, filePath string) error {
+	_, err := ProbeAudio(ctx, filePath)
+	return err
+}
+
+// ProbeAudio validates a file like ValidateAudioFile and describes its audio
+func ProbeAudio(ctx context.Context, filePath string) (*AudioInfo, error) {
+	cmd := execCommandContext(ctx, "ffprobe",
+		"-v", "error",
+		"-show_entries", "stream=codec_type,codec_name,sample_rate,channels:format=format_name,duration",
+		"-of", "json",
+		filePath)
+	ConfigureCmdSysProcAttr(cmd)
+
+	output, err := cmd.Output()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, ErrFFprobeUnavailable
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &ValidationError{Code: ValidationCorruptFile, Message: "The file could not be read as audio or video"}
+	}
+	return parseProbeOutput(output)
+}
+
+// parseProbeOutput validates ffprobe's JSON description of a file
+func parseProbeOutput(output []byte) (*AudioInfo, error) {
+	var probe ffprobeStreams
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, &ValidationError{Code: ValidationCorruptFile, Message: "The file could not be read as audio or video"}
+	}
+
+	// Use the first decodable audio stream; containers may carry several
+	info := &AudioInfo{Format: probe.Format.FormatName}
+	var unsupported []string
+	found := false
+	for _, stream := range probe.Streams {
+		if stream.CodecType != "audio" {
+			continue
+		}
+		found = true
+		if !isSupportedCodec(stream.CodecName) {
+			name := stream.CodecName
+			if name == "" {
+				name = "unknown"
+			}
+			unsupported = append(unsupported, name)
+			continue
+		}
+		info.Codec = stream.CodecName
+		info.SampleRate, _ = strconv.Atoi(stream.SampleRate)
+		info.Channels = stream.Channels
+		unsupported = nil
+		break
+	}
+
+	switch {
+	case !found:
+		format := info.Format
+		if format == "" {
+			format = "unknown"
+		}
+		return nil, &ValidationError{
+			Code:    ValidationNoAudioStream,
+			Message: fmt.Sprintf("The file does not contain an audio stream (detected format: %s)", format),
+		}
+	case len(unsupported) > 0:
+		return nil, &ValidationError{
+			Code:    ValidationUnsupportedCodec,
+			Message: fmt.Sprintf("Unsupported audio codec: %s", strings.Join(unsupported, ", ")),
+		}
+	case info.SampleRate < minSampleRate:
+		return nil, &ValidationError{
+			Code:    ValidationLowSampleRate,
+			Message: fmt.Sprintf("Audio sample rate %d Hz is below the minimum of %d Hz", info.SampleRate, minSampleRate),
+		}
+	}
+
+	// Some streamable formats report no duration ("N/A"); only a known zero is rejected
+	if duration, err := strconv.ParseFloat(probe.Format.Duration, 64); err == nil {
+		if duration <= 0 {
+			return nil, &ValidationError{Code: ValidationEmptyAudio, Message: "The audio has no duration"}
+		}
+		info.DurationSeconds = duration
+	}
+	return info, nil
+}
@@ -0,0 +1,85 @@
+package transcription
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestValidateAudioFile(t *testing.T) {
+	args := fakeFFprobe(t, `{"streams":[{"codec_type":"audio","codec_name":"mp3","sample_rate":"44100"}],"format":{"duration":"12.5"}}`, 0)
+
+	if err := ValidateAudioFile(context.Background(), "/tmp/audio.mp3"); err != nil {
+		t.Errorf("Expected a valid file, got %v", err)
+	}
+	if got := strings.Join(*args, " "); !strings.Contains(got, "-of json /tmp/audio.mp3") {
+		t.Errorf("Unexpected ffprobe invocation: %s", got)
+	}
+}
+
+func TestValidateAudioFileCorrupt(t *testing.T) {
+	fakeFFprobe(t, "", 1)
+
+	err := ValidateAudioFile(context.Background(), "/tmp/audio.mp3")
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Code != ValidationCorruptFile {
+		t.Errorf("Expected a %s validation error, got %v", ValidationCorruptFile, err)
+	}
+}
+
+func TestValidateAudioFileWithoutFFprobe(t *testing.T) {
+	original := execCommandContext
+	execCommandContext = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "scriberr-missing-ffprobe", arg...)
+	}
+	t.Cleanup(func() { execCommandContext = original })
+
+	if err := ValidateAudioFile(context.Background(), "/tmp/audio.mp3"); !errors.Is(err, ErrFFprobeUnavailable) {
+		t.Errorf("Expected ErrFFprobeUnavailable, got %v", err)
+	}
+}
+
+func TestCheckProbeOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		code   string // Empty for a valid file
+	}{
+		{"wav", `{"streams":[{"codec_type":"audio","codec_name":"pcm_s16le","sample_rate":"16000"}],"format":{"duration":"3.0"}}`, ""},
+		{"video with audio", `{"streams":[{"codec_type":"video","codec_name":"h264"},{"codec_type":"audio","codec_name":"aac","sample_rate":"48000"}],"format":{"duration":"60.1"}}`, ""},
+		{"second audio stream decodable", `{"streams":[{"codec_type":"audio","codec_name":"qdm2","sample_rate":"22050"},{"codec_type":"audio","codec_name":"opus","sample_rate":"48000"}],"format":{"duration":"5"}}`, ""},
+		{"unknown duration", `{"streams":[{"codec_type":"audio","codec_name":"mp3","sample_rate":"44100"}],"format":{"duration":"N/A"}}`, ""},
+		{"telephone audio", `{"streams":[{"codec_type":"audio","codec_name":"adpcm_ima_wav","sample_rate":"8000"}],"format":{"duration":"9"}}`, ""},
+		{"video only", `{"streams":[{"codec_type":"video","codec_name":"h264"}],"format":{"duration":"10"}}`, ValidationNoAudioStream},
+		{"no streams", `{"streams":[],"format":{}}`, ValidationNoAudioStream},
+		{"unsupported codec", `{"streams":[{"codec_type":"audio","codec_name":"qdm2","sample_rate":"22050"}],"format":{"duration":"5"}}`, ValidationUnsupportedCodec},
+		{"unnamed codec", `{"streams":[{"codec_type":"audio","sample_rate":"22050"}],"format":{"duration":"5"}}`, ValidationUnsupportedCodec},
+		{"low sample rate", `{"streams":[{"codec_type":"audio","codec_name":"pcm_u8","sample_rate":"4000"}],"format":{"duration":"5"}}`, ValidationLowSampleRate},
+		{"missing sample rate", `{"streams":[{"codec_type":"audio","codec_name":"mp3"}],"format":{"duration":"5"}}`, ValidationLowSampleRate},
+		{"zero duration", `{"streams":[{"codec_type":"audio","codec_name":"flac","sample_rate":"44100"}],"format":{"duration":"0.000000"}}`, ValidationEmptyAudio},
+		{"not json", `garbage`, ValidationCorruptFile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseProbeOutput([]byte(tt.output))
+			if tt.code == "" {
+				if err != nil {
+					t.Errorf("Expected a valid file, got %v", err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected a ValidationError with code %s, got %v", tt.code, err)
+			}
+			if validationErr.Code != tt.code {
+				t.Errorf("Expected code %s, got %s (%s)", tt.code, validationErr.Code, validationErr.Message)
+			}
+			if validationErr.Message == "" {
+				t.Error("Expected a message")
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package transcription
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Steps of the WhisperX setup an InstallError can fail at, besides InstallStepUV
+const (
+	InstallStepVenv    = "venv"    // uv venv failed
+	InstallStepInstall = "install" // uv pip install failed
+)
+
+// ErrSetupInProgress is returned when a WhisperX setup is already running
+var ErrSetupInProgress = errors.New("WhisperX setup is already running")
+
+// whisperXSetupMu keeps two setups from installing into the same venv at once
+var whisperXSetupMu sync.Mutex
+
+// CheckUV returns an *InstallError unless uvPath is an executable
+func CheckUV(uvPath string) error {
+	if _, err := exec.LookPath(uvPath); err != nil {
+		return &InstallError{Step: InstallStepUV, Err: err}
+	}
+	return nil
+}
+
+// setupStep is a uv command the WhisperX setup runs
+type setupStep struct {
+	step string
+	args []string
+}
+
+// whisperXSetupSteps are the uv commands that install WhisperX version into
+// a new venv at envPath, in order
+func whisperXSetupSteps(envPath, version string) []setupStep {
+	return []setupStep{
+		{InstallStepVenv, []string{"venv", "--native-tls", envPath}},
+		{InstallStepInstall, []string{"pip", "install", "--native-tls", "--python", envPath, "whisperx==" + version}},
+	}
+}
+
+// SetupWhisperX creates a venv at envPath with uv and installs WhisperX
+// version into it. Each line the commands print is passed to output with the
+// stream it came from, stdout or stderr. Failures are *InstallError, or
+// ErrSetupInProgress while another setup runs.
+func SetupWhisperX(ctx context.Context, uvPath, envPath, version string, output func(stream, line string)) error {
+	if err := CheckUV(uvPath); err != nil {
+		return err
+	}
+	if !whisperXSetupMu.TryLock() {
+		return ErrSetupInProgress
+	}
+	defer whisperXSetupMu.Unlock()
+
+	for _, step := range whisperXSetupSteps(envPath, version) {
+		cmd := execCommandContext(ctx, uvPath, step.args...)
+		var tail tailWriter
+		// Lines of both streams go to output one at a time
+		var mu sync.Mutex
+		stdout := &lineFuncWriter{mu: &mu, emit: func(line string) { output("stdout", line) }, tail: &tail}
+		stderr := &lineFuncWriter{mu: &mu, emit: func(line string) { output("stderr", line) }, tail: &tail}
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		err := cmd.Run()
+		stdout.Flush()
+		stderr.Flush()
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				err = ctxErr
+			}
+			return &InstallError{Step: step.step, Output: tail.String(), Err: fmt.Errorf("uv %s failed: %w", step.args[0], err)}
+		}
+	}
+	return nil
+}
+
+// lineFuncWriter passes each complete line written to it to emit, and keeps
+// the output in tail
+type lineFuncWriter struct {
+	mu      *sync.Mutex
+	emit    func(line string)
+	tail    *tailWriter
+	pending []byte
+}
+
+func (w *lineFuncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.tail.Write(p)
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexAny(w.pending, "\r\n")
+		if i < 0 {
+			break
+		}
+		w.send(string(w.pending[:i]))
+		w.pending = w.pending[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush passes on any final line without a newline
+func (w *lineFuncWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.send(string(w.pending))
+	w.pending = nil
+}
+
+func (w *lineFuncWriter) send(line string) {
+	if line = strings.TrimRight(line, " \t"); strings.TrimSpace(line) != "" {
+		w.emit(line)
+	}
+}
@@ -0,0 +1,108 @@
+package transcription
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeSetupUV makes execCommandContext run TestSetupHelperProcess in place of
+// uv, failing the command named fail. It returns the uv arguments of each
+// command run.
+func fakeSetupUV(t *testing.T, fail string) *[][]string {
+	t.Helper()
+	var commands [][]string
+	original := execCommandContext
+	execCommandContext = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		commands = append(commands, arg)
+		cmd := exec.CommandContext(ctx, os.Args[0], "-test.run=TestSetupHelperProcess")
+		cmd.Env = append(os.Environ(), "GO_WANT_SETUP_HELPER=1", "SETUP_COMMAND="+arg[0], "SETUP_FAIL="+fail)
+		return cmd
+	}
+	t.Cleanup(func() { execCommandContext = original })
+	return &commands
+}
+
+// TestSetupHelperProcess stands in for uv venv and uv pip install
+func TestSetupHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_SETUP_HELPER") != "1" {
+		return
+	}
+	command := os.Getenv("SETUP_COMMAND")
+	switch command {
+	case "venv":
+		os.Stderr.WriteString("Using CPython 3.11.9\nCreating virtual environment at: env\n")
+	case "pip":
+		os.Stderr.WriteString("Resolved 97 packages in 2.1s\n")
+		os.Stdout.WriteString(" + whisperx==3.4.2")
+	}
+	if os.Getenv("SETUP_FAIL") == command {
+		os.Stderr.WriteString("error: No solution found when resolving dependencies\n")
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestSetupWhisperX(t *testing.T) {
+	commands := fakeSetupUV(t, "")
+	env := t.TempDir() + "/env"
+
+	var lines []string
+	err := SetupWhisperX(context.Background(), os.Args[0], env, "3.4.2", func(stream, line string) {
+		lines = append(lines, stream+": "+line)
+	})
+	if err != nil {
+		t.Fatalf("SetupWhisperX failed: %v", err)
+	}
+	expected := [][]string{
+		{"venv", "--native-tls", env},
+		{"pip", "install", "--native-tls", "--python", env, "whisperx==3.4.2"},
+	}
+	if !reflect.DeepEqual(*commands, expected) {
+		t.Errorf("Expected uv to run %v, got %v", expected, *commands)
+	}
+	if len(lines) != 4 || lines[0] != "stderr: Using CPython 3.11.9" || lines[3] != "stdout:  + whisperx==3.4.2" {
+		t.Errorf("Expected each line of output with its stream, got %q", lines)
+	}
+}
+
+func TestSetupWhisperXFailures(t *testing.T) {
+	env := t.TempDir() + "/env"
+	discard := func(stream, line string) {}
+
+	commands := fakeSetupUV(t, "pip")
+	var installErr *InstallError
+	err := SetupWhisperX(context.Background(), os.Args[0], env, "3.4.2", discard)
+	if !errors.As(err, &installErr) || installErr.Step != InstallStepInstall {
+		t.Fatalf("Expected the install step to fail, got %v", err)
+	}
+	if !strings.Contains(installErr.Output, "No solution found") {
+		t.Errorf("Expected uv's output in the error, got %q", installErr.Output)
+	}
+
+	// A failed venv stops the setup before installing
+	commands = fakeSetupUV(t, "venv")
+	err = SetupWhisperX(context.Background(), os.Args[0], env, "3.4.2", discard)
+	if !errors.As(err, &installErr) || installErr.Step != InstallStepVenv {
+		t.Errorf("Expected the venv step to fail, got %v", err)
+	}
+	if len(*commands) != 1 {
+		t.Errorf("Expected only uv venv to run, got %v", *commands)
+	}
+
+	err = SetupWhisperX(context.Background(), "uv-that-does-not-exist", env, "3.4.2", discard)
+	if !errors.As(err, &installErr) || installErr.Step != InstallStepUV {
+		t.Errorf("Expected a missing uv, got %v", err)
+	}
+
+	whisperXSetupMu.Lock()
+	err = SetupWhisperX(context.Background(), os.Args[0], env, "3.4.2", discard)
+	whisperXSetupMu.Unlock()
+	if !errors.Is(err, ErrSetupInProgress) {
+		t.Errorf("Expected a running setup to refuse another, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package transcription
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// Steps of the WhisperX installation check an InstallError can fail at
+const (
+	InstallStepUV          = "uv"          // uv is not installed
+	InstallStepEnvironment = "environment" // The virtual environment does not exist
+	InstallStepRun         = "run"         // whisperx could not be run
+	InstallStepVersion     = "version"     // whisperx printed no version
+)
+
+// InstallError describes why WhisperX is not installed correctly: the step of
+// the check that failed and what the command printed
+type InstallError struct {
+	Step   string
+	Output string
+	Err    error
+}
+
+func (e *InstallError) Error() string {
+	msg := installStepMessages[e.Step]
+	if msg == "" {
+		msg = "WhisperX installation check failed"
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	if e.Output != "" {
+		msg += ": " + e.Output
+	}
+	return msg
+}
+
+func (e *InstallError) Unwrap() error {
+	return e.Err
+}
+
+// installStepMessages says what to do about each failed step
+var installStepMessages = map[string]string{
+	InstallStepUV:          "uv was not found; install uv or set UV_PATH",
+	InstallStepEnvironment: "the WhisperX virtual environment does not exist; repair it with POST /api/v1/admin/whisperx-env/repair",
+	InstallStepRun:         "whisperx failed to run in its environment; repair it with POST /api/v1/admin/whisperx-env/repair",
+	InstallStepVersion:     "whisperx did not print a version",
+	InstallStepVenv:        "uv failed to create the WhisperX virtual environment",
+	InstallStepInstall:     "uv failed to install WhisperX; the output names the package that failed",
+}
+
+// whisperXVersionPattern finds the version in whisperx --version output
+var whisperXVersionPattern = regexp.MustCompile(`\b\d+\.\d+(\.\d+)?([.\-+]?[0-9A-Za-z.]+)?\b`)
+
+// VerifyWhisperXInstall checks that whisperx runs in the virtual environment at
+// envPath and reports its version. Failures are *InstallError.
+func VerifyWhisperXInstall(ctx context.Context, uvPath, envPath string) error {
+	_, err := WhisperXVersion(ctx, uvPath, envPath)
+	return err
+}
+
+// WhisperXVersion runs whisperx --version with uv in the virtual environment at
+// envPath and returns the version it prints. Failures are *InstallError.
+func WhisperXVersion(ctx context.Context, uvPath, envPath string) (string, error) {
+	if err := CheckUV(uvPath); err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(envPath); err != nil {
+		return "", &InstallError{Step: InstallStepEnvironment, Err: err}
+	} else if !info.IsDir() {
+		return "", &InstallError{Step: InstallStepEnvironment, Err: fmt.Errorf("%s is not a directory", envPath)}
+	}
+
+	cmd := execCommandContext(ctx, uvPath, "run", "--native-tls", "--python", envPath, "whisperx", "--version")
+	var stdout bytes.Buffer
+	var stderr tailWriter
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		return "", &InstallError{Step: InstallStepRun, Output: stderr.String(), Err: err}
+	}
+
+	output := bytes.TrimSpace(stdout.Bytes())
+	version := whisperXVersionPattern.Find(output)
+	if version == nil {
+		return "", &InstallError{Step: InstallStepVersion, Output: string(output), Err: errors.New("no version in output")}
+	}
+	return string(version), nil
+}
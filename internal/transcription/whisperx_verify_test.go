@@ -0,0 +1,104 @@
+package transcription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// fakeWhisperXVersion makes execCommandContext run
+// TestWhisperXVersionHelperProcess, which prints output and exits with exit.
+// It returns the arguments uv was run with.
+func fakeWhisperXVersion(t *testing.T, output string, exit int) *[]string {
+	t.Helper()
+	var args []string
+	original := execCommandContext
+	execCommandContext = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		args = arg
+		cmd := exec.CommandContext(ctx, os.Args[0], "-test.run=TestWhisperXVersionHelperProcess")
+		cmd.Env = append(os.Environ(),
+			"GO_WANT_WHISPERX_HELPER=1",
+			"WHISPERX_OUTPUT="+output,
+			fmt.Sprintf("WHISPERX_EXIT=%d", exit))
+		return cmd
+	}
+	t.Cleanup(func() { execCommandContext = original })
+	return &args
+}
+
+// TestWhisperXVersionHelperProcess stands in for uv running whisperx --version
+func TestWhisperXVersionHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_WHISPERX_HELPER") != "1" {
+		return
+	}
+	if os.Getenv("WHISPERX_EXIT") != "0" {
+		fmt.Fprint(os.Stderr, "ModuleNotFoundError: No module named 'whisperx'")
+		os.Exit(1)
+	}
+	fmt.Println(os.Getenv("WHISPERX_OUTPUT"))
+	os.Exit(0)
+}
+
+func TestWhisperXVersion(t *testing.T) {
+	uv := os.Args[0] // Any executable will do, as the command is faked
+	env := t.TempDir()
+
+	tests := []struct {
+		name    string
+		output  string
+		exit    int
+		version string
+		step    string
+	}{
+		{"bare version", "3.1.1", 0, "3.1.1", ""},
+		{"named version", "whisperx 3.4.2.post1", 0, "3.4.2.post1", ""},
+		{"no version", "usage: whisperx [-h] audio", 0, "", InstallStepVersion},
+		{"not installed", "", 1, "", InstallStepRun},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := fakeWhisperXVersion(t, tt.output, tt.exit)
+			version, err := WhisperXVersion(context.Background(), uv, env)
+			if got := strings.Join(*args, " "); got != "run --native-tls --python "+env+" whisperx --version" {
+				t.Errorf("Unexpected uv arguments %q", got)
+			}
+			if tt.step == "" {
+				if err != nil || version != tt.version {
+					t.Errorf("Expected version %s, got %q and %v", tt.version, version, err)
+				}
+				return
+			}
+			var installErr *InstallError
+			if !errors.As(err, &installErr) || installErr.Step != tt.step {
+				t.Fatalf("Expected an InstallError at step %s, got %v", tt.step, err)
+			}
+			if tt.step == InstallStepRun && !strings.Contains(installErr.Output, "No module named 'whisperx'") {
+				t.Errorf("Expected the command's output in the error, got %q", installErr.Output)
+			}
+		})
+	}
+}
+
+func TestVerifyWhisperXInstallMissing(t *testing.T) {
+	args := fakeWhisperXVersion(t, "3.1.1", 0)
+
+	var installErr *InstallError
+	err := VerifyWhisperXInstall(context.Background(), "uv-that-does-not-exist", t.TempDir())
+	if !errors.As(err, &installErr) || installErr.Step != InstallStepUV {
+		t.Errorf("Expected a missing uv, got %v", err)
+	}
+	err = VerifyWhisperXInstall(context.Background(), os.Args[0], t.TempDir()+"/missing")
+	if !errors.As(err, &installErr) || installErr.Step != InstallStepEnvironment {
+		t.Errorf("Expected a missing environment, got %v", err)
+	}
+	if *args != nil {
+		t.Errorf("Expected whisperx not to run, got %v", *args)
+	}
+	if err := VerifyWhisperXInstall(context.Background(), os.Args[0], t.TempDir()); err != nil {
+		t.Errorf("Expected the installation to verify, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package transcription
+
+import (
+	"math"
+	"strings"
+
+	"scriberr/internal/transcription/interfaces"
+)
+
+// CompactTranscript prepares a transcript for storage. Top-level word timings
+// are moved into the segment they start in, times are rounded to the
+// millisecond and scores to three decimals, and a word's speaker is dropped
+// when it matches its segment's. Segments without aligned words keep a nil
+// word list, which is stored as null.
+func CompactTranscript(result *interfaces.TranscriptResult) {
+	AttachWordsToSegments(result)
+
+	for i := range result.Segments {
+		segment := &result.Segments[i]
+		for j := range segment.Words {
+			word := &segment.Words[j]
+			word.Start = roundTo(word.Start, 1000)
+			word.End = roundTo(word.End, 1000)
+			word.Score = roundTo(word.Score, 1000)
+			if word.Speaker != nil && segment.Speaker != nil && *word.Speaker == *segment.Speaker {
+				word.Speaker = nil
+			}
+		}
+	}
+}
+
+// AttachWordsToSegments assigns a transcript's top-level word timings to the
+// segments they start in and clears the top-level list. Transcripts whose
+// segments already carry words, or that have no segments, are left as they are.
+func AttachWordsToSegments(result *interfaces.TranscriptResult) {
+	if len(result.WordSegments) == 0 || len(result.Segments) == 0 {
+		return
+	}
+	for _, segment := range result.Segments {
+		if segment.Words != nil {
+			return
+		}
+	}
+
+	idx := 0
+	for _, word := range result.WordSegments {
+		if word.End < word.Start {
+			continue // Never store times the aligner did not produce
+		}
+		for idx < len(result.Segments)-1 && word.Start >= result.Segments[idx].End {
+			idx++
+		}
+		result.Segments[idx].Words = append(result.Segments[idx].Words, word)
+	}
+	result.WordSegments = nil
+}
+
+// roundTo rounds v to 1/scale
+func roundTo(v, scale float64) float64 {
+	return math.Round(v*scale) / scale
+}
+
+// MaskLowConfidenceWords sets the masked text of every segment holding a
+// word scored below threshold, replacing those words with mask. A word scored
+// exactly at the threshold is kept, as is any word without a score, so
+// models that do not report confidence are never masked. The original text
+// is left in place. It returns the number of words masked.
+func MaskLowConfidenceWords(result *interfaces.TranscriptResult, threshold float64, mask string) int {
+	if threshold <= 0 {
+		return 0
+	}
+	AttachWordsToSegments(result)
+
+	masked := 0
+	for i := range result.Segments {
+		segment := &result.Segments[i]
+		texts := make([]string, len(segment.Words))
+		segmentMasked := false
+		for j := range segment.Words {
+			word := &segment.Words[j]
+			texts[j] = strings.TrimSpace(word.Word)
+			if word.Score > 0 && word.Score < threshold {
+				word.MaskedWord = mask
+				texts[j] = mask
+				segmentMasked = true
+				masked++
+			}
+		}
+		if segmentMasked {
+			segment.MaskedText = strings.Join(texts, " ")
+		}
+	}
+	return masked
+}
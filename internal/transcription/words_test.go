@@ -0,0 +1,171 @@
+package transcription
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"scriberr/internal/transcription/interfaces"
+)
+
+func TestCompactTranscript(t *testing.T) {
+	alice, bob := "SPEAKER_00", "SPEAKER_01"
+	result := &interfaces.TranscriptResult{
+		Segments: []interfaces.TranscriptSegment{
+			{Start: 0, End: 2, Text: "hello there", Speaker: &alice},
+			{Start: 2, End: 4, Text: "hi", Speaker: &bob},
+			{Start: 4, End: 6, Text: "unaligned"},
+		},
+		WordSegments: []interfaces.TranscriptWord{
+			{Start: 0.1234567, End: 0.5, Word: "hello", Score: 0.98765, Speaker: &alice},
+			{Start: 0.6, End: 1.2, Word: "there", Score: 0.5, Speaker: &bob},
+			{Start: 2.1, End: 2.0, Word: "bogus"},
+			{Start: 2.2, End: 2.6, Word: "hi", Speaker: &bob},
+		},
+	}
+
+	CompactTranscript(result)
+
+	if result.WordSegments != nil {
+		t.Errorf("Expected top-level words to be moved into segments, got %v", result.WordSegments)
+	}
+	first := result.Segments[0].Words
+	if len(first) != 2 {
+		t.Fatalf("Expected 2 words in the first segment, got %v", first)
+	}
+	if first[0].Start != 0.123 || first[0].Score != 0.988 {
+		t.Errorf("Expected rounded time and score, got %v and %v", first[0].Start, first[0].Score)
+	}
+	if first[0].Speaker != nil {
+		t.Errorf("Expected speaker matching the segment to be dropped, got %v", *first[0].Speaker)
+	}
+	if first[1].Speaker == nil || *first[1].Speaker != bob {
+		t.Errorf("Expected differing speaker to be kept, got %v", first[1].Speaker)
+	}
+	if words := result.Segments[1].Words; len(words) != 1 || words[0].Word != "hi" {
+		t.Errorf("Expected only the timed word in the second segment, got %v", words)
+	}
+	if result.Segments[2].Words != nil {
+		t.Errorf("Expected no words for an unaligned segment, got %v", result.Segments[2].Words)
+	}
+
+	data, err := json.Marshal(result.Segments[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if words, ok := decoded["words"]; !ok || words != nil {
+		t.Errorf("Expected unaligned words to be stored as null, got %s", data)
+	}
+}
+
+func TestCompactTranscriptKeepsSegmentWords(t *testing.T) {
+	result := &interfaces.TranscriptResult{
+		Segments: []interfaces.TranscriptSegment{
+			{Start: 0, End: 1, Text: "a", Words: []interfaces.TranscriptWord{{Start: 0, End: 1, Word: "a"}}},
+		},
+		WordSegments: []interfaces.TranscriptWord{{Start: 0, End: 1, Word: "b"}},
+	}
+	CompactTranscript(result)
+	if len(result.Segments[0].Words) != 1 || result.Segments[0].Words[0].Word != "a" {
+		t.Errorf("Expected the segment's own words to be kept, got %v", result.Segments[0].Words)
+	}
+}
+
+// TestCompactTranscriptSize measures storage for a three-hour diarized
+// transcript at 2.5 words per second
+func TestCompactTranscriptSize(t *testing.T) {
+	const seconds, wordsPerSecond, segmentSeconds = 3 * 60 * 60, 2.5, 6
+	speakers := []string{"SPEAKER_00", "SPEAKER_01"}
+	build := func() *interfaces.TranscriptResult {
+		result := &interfaces.TranscriptResult{}
+		for start := 0; start < seconds; start += segmentSeconds {
+			speaker := speakers[start/segmentSeconds%2]
+			result.Segments = append(result.Segments, interfaces.TranscriptSegment{
+				Start: float64(start), End: float64(start + segmentSeconds),
+				Text:    "lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod tempor incididunt ut labore",
+				Speaker: &speaker,
+			})
+			for i := 0; i < segmentSeconds*wordsPerSecond; i++ {
+				wordStart := float64(start) + float64(i)/wordsPerSecond + 0.0123456789
+				result.WordSegments = append(result.WordSegments, interfaces.TranscriptWord{
+					Start: wordStart, End: wordStart + 0.3123456789,
+					Word: fmt.Sprintf("word%d", i), Score: 0.876543210, Speaker: &speaker,
+				})
+			}
+		}
+		return result
+	}
+
+	legacy, err := json.Marshal(build())
+	if err != nil {
+		t.Fatal(err)
+	}
+	compactResult := build()
+	words := len(compactResult.WordSegments)
+	CompactTranscript(compactResult)
+	compact, err := json.Marshal(compactResult)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Logf("%d words: %d bytes stored as word_segments, %d bytes compacted (%.1f bytes/word)",
+		words, len(legacy), len(compact), float64(len(compact))/float64(words))
+	if len(compact) > len(legacy)*7/10 {
+		t.Errorf("Expected compaction to save at least 30%%, got %d of %d bytes", len(compact), len(legacy))
+	}
+	if len(compact) > 2500000 {
+		t.Errorf("Expected a three-hour transcript to fit in 2.5MB, got %d bytes", len(compact))
+	}
+}
+
+func TestMaskLowConfidenceWords(t *testing.T) {
+	result := &interfaces.TranscriptResult{
+		Segments: []interfaces.TranscriptSegment{
+			{Start: 0, End: 2, Text: "the quick brown fox"},
+			{Start: 2, End: 4, Text: "jumps over"},
+			{Start: 4, End: 6, Text: "unscored words"},
+		},
+		WordSegments: []interfaces.TranscriptWord{
+			{Start: 0, End: 0.4, Word: "the", Score: 0.9},
+			{Start: 0.5, End: 0.9, Word: "quick", Score: 0.5},  // At the threshold
+			{Start: 1.0, End: 1.4, Word: "brown", Score: 0.49}, // Just below
+			{Start: 1.5, End: 1.9, Word: "fox", Score: 0.001},
+			{Start: 2.0, End: 2.5, Word: "jumps", Score: 0.51},
+			{Start: 2.6, End: 3.0, Word: "over", Score: 1},
+			{Start: 4.0, End: 4.5, Word: "unscored"},
+			{Start: 4.6, End: 5.0, Word: "words"},
+		},
+	}
+
+	if masked := MaskLowConfidenceWords(result, 0.5, "[inaudible]"); masked != 2 {
+		t.Errorf("Expected 2 masked words, got %d", masked)
+	}
+	first := result.Segments[0]
+	if first.MaskedText != "the quick [inaudible] [inaudible]" {
+		t.Errorf("Unexpected masked text %q", first.MaskedText)
+	}
+	if first.Text != "the quick brown fox" || first.Words[2].Word != "brown" {
+		t.Errorf("Expected the original text to be kept, got %q and %q", first.Text, first.Words[2].Word)
+	}
+	if first.Words[1].MaskedWord != "" || first.Words[2].MaskedWord != "[inaudible]" {
+		t.Errorf("Expected only words below the threshold to be masked, got %+v", first.Words)
+	}
+	for _, segment := range result.Segments[1:] {
+		if segment.MaskedText != "" {
+			t.Errorf("Expected %q to be left unmasked, got %q", segment.Text, segment.MaskedText)
+		}
+	}
+}
+
+func TestMaskLowConfidenceWordsDisabled(t *testing.T) {
+	result := &interfaces.TranscriptResult{
+		Segments: []interfaces.TranscriptSegment{{Start: 0, End: 1, Text: "hello", Words: []interfaces.TranscriptWord{{Word: "hello", Score: 0.01}}}},
+	}
+	if masked := MaskLowConfidenceWords(result, 0, "***"); masked != 0 || result.Segments[0].MaskedText != "" {
+		t.Errorf("Expected a zero threshold to keep every word, masked %d", masked)
+	}
+}
@@ -0,0 +1,471 @@
+package watchfolder
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/internal/storage"
+	"scriberr/internal/transcription"
+	"scriberr/pkg/logger"
+)
+
+const (
+	// markerSuffix names the sidecar left next to processed files
+	markerSuffix = ".scriberr"
+	// doneDir is the subfolder processed files are moved to
+	doneDir = "done"
+	// eventDebounce groups bursts of filesystem events into one scan
+	eventDebounce = time.Second
+)
+
+// observation is a file's size and modification time when first seen unchanged
+type observation struct {
+	size    int64
+	modTime time.Time
+	since   time.Time
+}
+
+// watcher watches a single folder
+type watcher struct {
+	service *Service
+	folder  Folder
+	cancel  context.CancelFunc
+	trigger chan struct{}
+
+	scanMu  sync.Mutex             // Serializes scans
+	pending map[string]observation // Files waiting to settle
+	failed  map[string]time.Time   // Files that could not be processed, by modification time
+
+	mu     sync.Mutex
+	status FolderStatus
+}
+
+func newWatcher(s *Service, folder Folder) *watcher {
+	mode := ModeEvents
+	if folder.UsePolling {
+		mode = ModePolling
+	}
+	return &watcher{
+		service: s,
+		folder:  folder,
+		trigger: make(chan struct{}, 1),
+		pending: make(map[string]observation),
+		failed:  make(map[string]time.Time),
+		status:  FolderStatus{Folder: folder, Mode: mode},
+	}
+}
+
+func (w *watcher) start(parent context.Context) {
+	ctx, cancel := context.WithCancel(parent)
+	w.cancel = cancel
+	go w.run(ctx)
+}
+
+func (w *watcher) stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+}
+
+func (w *watcher) snapshot() FolderStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+func (w *watcher) setError(err error) {
+	now := time.Now()
+	w.mu.Lock()
+	w.status.LastError = err.Error()
+	w.status.LastErrorAt = &now
+	w.mu.Unlock()
+	logger.Warn("Watch folder error", "path", w.folder.Path, "error", err)
+}
+
+// run scans on filesystem events and every poll interval until ctx is done
+func (w *watcher) run(ctx context.Context) {
+	w.mu.Lock()
+	w.status.Watching = true
+	w.mu.Unlock()
+	defer func() {
+		w.mu.Lock()
+		w.status.Watching = false
+		w.mu.Unlock()
+	}()
+
+	if !w.folder.UsePolling {
+		fsWatcher, err := w.watchEvents()
+		if err != nil {
+			// Network mounts often do not deliver events, so keep polling
+			w.setError(fmt.Errorf("filesystem events unavailable, polling instead: %w", err))
+			w.mu.Lock()
+			w.status.Mode = ModePolling
+			w.mu.Unlock()
+		} else {
+			defer fsWatcher.Close()
+			go w.forwardEvents(ctx, fsWatcher)
+		}
+	}
+
+	poll := time.NewTicker(w.pollInterval())
+	defer poll.Stop()
+	settle := time.NewTimer(0)
+	defer settle.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		case <-settle.C:
+		case <-w.trigger:
+			// Let a burst of events pass before scanning
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(eventDebounce):
+			}
+		}
+
+		if w.scan(ctx) > 0 {
+			// Rescan once the waiting files should have settled
+			settle.Reset(w.service.config.WatchSettleTime + eventDebounce)
+		}
+	}
+}
+
+// watchEvents registers the folder, and its subfolders if recursive, for
+// filesystem events
+func (w *watcher) watchEvents() (*fsnotify.Watcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.addDirs(fsWatcher, w.folder.Path); err != nil {
+		fsWatcher.Close()
+		return nil, err
+	}
+	return fsWatcher, nil
+}
+
+func (w *watcher) addDirs(fsWatcher *fsnotify.Watcher, root string) error {
+	if !w.folder.Recursive {
+		return fsWatcher.Add(root)
+	}
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if w.skipDir(path) {
+			return filepath.SkipDir
+		}
+		return fsWatcher.Add(path)
+	})
+}
+
+// forwardEvents turns filesystem events into scan triggers, watching new
+// subfolders as they appear
+func (w *watcher) forwardEvents(ctx context.Context, fsWatcher *fsnotify.Watcher) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-fsWatcher.Events:
+			if !ok {
+				return
+			}
+			if w.folder.Recursive && event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() && !w.skipDir(event.Name) {
+					if err := w.addDirs(fsWatcher, event.Name); err != nil {
+						w.setError(fmt.Errorf("failed to watch %s: %w", event.Name, err))
+					}
+				}
+			}
+			if event.Has(fsnotify.Create) || event.Has(fsnotify.Write) || event.Has(fsnotify.Rename) {
+				select {
+				case w.trigger <- struct{}{}:
+				default:
+				}
+			}
+		case err, ok := <-fsWatcher.Errors:
+			if !ok {
+				return
+			}
+			w.setError(err)
+		}
+	}
+}
+
+func (w *watcher) pollInterval() time.Duration {
+	if interval := w.service.config.WatchPollInterval; interval > 0 {
+		return interval
+	}
+	return time.Minute
+}
+
+// skipDir reports whether a directory below the root should be ignored
+func (w *watcher) skipDir(path string) bool {
+	if filepath.Clean(path) == filepath.Clean(w.folder.Path) {
+		return false
+	}
+	name := filepath.Base(path)
+	return strings.HasPrefix(name, ".") || filepath.Clean(path) == filepath.Join(filepath.Clean(w.folder.Path), doneDir)
+}
+
+// scan picks up every settled audio file and returns how many are still
+// waiting to settle
+func (w *watcher) scan(ctx context.Context) int {
+	w.scanMu.Lock()
+	defer w.scanMu.Unlock()
+
+	now := time.Now()
+	seen := make(map[string]bool)
+	var ready []string
+
+	err := filepath.WalkDir(w.folder.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == w.folder.Path {
+				return err
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if w.skipDir(path) || (!w.folder.Recursive && path != w.folder.Path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || strings.HasPrefix(d.Name(), ".") || !transcription.IsSupportedMediaFile(path) {
+			return nil
+		}
+		if _, err := os.Stat(path + markerSuffix); err == nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if modTime, ok := w.failed[path]; ok && modTime.Equal(info.ModTime()) {
+			return nil
+		}
+		seen[path] = true
+
+		// A file is ready once its size and modification time have stayed the
+		// same for the settle time, so partial copies are not picked up
+		previous, ok := w.pending[path]
+		if !ok || previous.size != info.Size() || !previous.modTime.Equal(info.ModTime()) {
+			w.pending[path] = observation{size: info.Size(), modTime: info.ModTime(), since: now}
+			return nil
+		}
+		if now.Sub(previous.since) >= w.service.config.WatchSettleTime {
+			ready = append(ready, path)
+		}
+		return nil
+	})
+
+	w.mu.Lock()
+	w.status.LastScanAt = &now
+	w.mu.Unlock()
+	if err != nil {
+		w.setError(fmt.Errorf("failed to scan folder: %w", err))
+		return 0
+	}
+
+	for path := range w.pending {
+		if !seen[path] {
+			delete(w.pending, path)
+		}
+	}
+	for _, path := range ready {
+		if ctx.Err() != nil {
+			break
+		}
+		delete(w.pending, path)
+		if err := w.process(ctx, path); err != nil {
+			if info, statErr := os.Stat(path); statErr == nil {
+				w.failed[path] = info.ModTime()
+			}
+			w.setError(fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	return len(w.pending)
+}
+
+// process creates a job for a settled file, unless a file with the same
+// content was already picked up, then marks the file as processed
+func (w *watcher) process(ctx context.Context, path string) error {
+	hash, err := hashFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to hash file: %w", err)
+	}
+
+	var existing models.WatchedFile
+	err = database.DB.Where("hash = ?", hash).First(&existing).Error
+	if err == nil {
+		logger.Debug("Skipping already transcribed file", "path", path, "job_id", existing.JobID)
+		return w.markProcessed(path, existing.JobID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if err := transcription.ValidateAudioFile(ctx, path); err != nil && !errors.Is(err, transcription.ErrFFprobeUnavailable) {
+		return err
+	}
+	params, err := w.params()
+	if err != nil {
+		return err
+	}
+
+	uploadDir := w.service.config.UploadDir
+	if err := os.MkdirAll(uploadDir, 0755); err != nil {
+		return fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	jobID := uuid.New().String()
+	audioPath := filepath.Join(uploadDir, jobID+strings.ToLower(filepath.Ext(path)))
+	if err := copyFile(path, audioPath); err != nil {
+		os.Remove(audioPath)
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+
+	title := filepath.Base(path)
+	job := models.TranscriptionJob{
+		ID:          jobID,
+		Title:       &title,
+		Status:      models.StatusPending,
+		AudioPath:   audioPath,
+		Diarization: params.Diarize,
+		Parameters:  params,
+
+		AudioRetentionDays: w.folder.AudioRetentionDays,
+		JobRetentionDays:   w.folder.JobRetentionDays,
+		FolderID:           w.folder.JobFolderID,
+		FileSize:           storage.Size(ctx, audioPath),
+		FileHash:           &hash,
+	}
+	if duration, err := transcription.DetectAudioDuration(ctx, audioPath); err == nil {
+		seconds := duration.Seconds()
+		job.DurationSeconds = &seconds
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&job).Error; err != nil {
+			return err
+		}
+		if err := database.TagJobs(tx, []string{jobID}, w.folder.Tags); err != nil {
+			return err
+		}
+		return tx.Create(&models.WatchedFile{Hash: hash, Path: path, JobID: jobID}).Error
+	})
+	if err != nil {
+		os.Remove(audioPath)
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+
+	if err := w.service.taskQueue.EnqueueJob(jobID); err != nil {
+		// The queue's scanner picks up pending jobs it could not accept now
+		logger.Warn("Failed to enqueue watch folder job", "job_id", jobID, "error", err)
+	}
+	w.mu.Lock()
+	w.status.JobsCreated++
+	w.mu.Unlock()
+	logger.Info("Queued watch folder file", "path", path, "job_id", jobID)
+
+	return w.markProcessed(path, jobID)
+}
+
+// params returns the transcription parameters from the folder's profile, or
+// the defaults
+func (w *watcher) params() (models.WhisperXParams, error) {
+	if w.folder.ProfileID == nil {
+		return w.service.defaults(), nil
+	}
+	var profile models.TranscriptionProfile
+	if err := database.DB.Where("id = ?", *w.folder.ProfileID).First(&profile).Error; err != nil {
+		return models.WhisperXParams{}, fmt.Errorf("failed to load profile %s: %w", *w.folder.ProfileID, err)
+	}
+	return profile.Parameters, nil
+}
+
+// markProcessed moves a file to done/, keeping its path below the folder, or
+// writes a sidecar marker next to it
+func (w *watcher) markProcessed(path, jobID string) error {
+	if w.folder.AfterProcess == models.WatchAfterMove {
+		rel, err := filepath.Rel(w.folder.Path, path)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(w.folder.Path, doneDir, rel)
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return fmt.Errorf("failed to create done folder: %w", err)
+		}
+		if err := os.Rename(path, dest); err != nil {
+			return fmt.Errorf("failed to move file to done folder: %w", err)
+		}
+		return nil
+	}
+
+	marker, err := json.Marshal(map[string]string{"job_id": jobID})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+markerSuffix, marker, 0644); err != nil {
+		return fmt.Errorf("failed to write marker: %w", err)
+	}
+	return nil
+}
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func copyFile(src, dst string) error {
+	source, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	dest, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dest, source); err != nil {
+		dest.Close()
+		return err
+	}
+	if err := dest.Sync(); err != nil {
+		dest.Close()
+		return err
+	}
+	return dest.Close()
+}
@@ -0,0 +1,254 @@
+// Package watchfolder transcribes audio files placed in watched directories,
+// such as a NAS share of recordings
+package watchfolder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"scriberr/internal/config"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/pkg/logger"
+)
+
+// TaskQueue enqueues transcription jobs
+type TaskQueue interface {
+	EnqueueJob(jobID string) error
+}
+
+// Folder sources
+const (
+	SourceConfig   = "config"   // From WATCH_DIRS
+	SourceDatabase = "database" // Added through the API
+)
+
+// Watch modes
+const (
+	ModeEvents  = "events"  // Filesystem events plus periodic rescans
+	ModePolling = "polling" // Periodic rescans only
+)
+
+// Folder is a watched directory and what to do with its files
+type Folder struct {
+	ID           uint    `json:"id,omitempty"` // Zero for folders from WATCH_DIRS
+	Source       string  `json:"source"`
+	Path         string  `json:"path"`
+	ProfileID    *string `json:"profile_id,omitempty"`
+	AfterProcess string  `json:"after_process"`
+	Recursive    bool    `json:"recursive"`
+	UsePolling   bool    `json:"use_polling"`
+
+	// Retention overrides given to the folder's jobs
+	AudioRetentionDays *int `json:"audio_retention_days,omitempty"`
+	JobRetentionDays   *int `json:"job_retention_days,omitempty"`
+
+	// Tags and folder given to the folder's jobs
+	Tags        []string `json:"tags,omitempty"`
+	JobFolderID *uint    `json:"job_folder_id,omitempty"`
+}
+
+// key identifies a folder across reloads
+func (f Folder) key() string {
+	if f.Source == SourceDatabase {
+		return fmt.Sprintf("%s:%d", f.Source, f.ID)
+	}
+	return f.Source + ":" + f.Path
+}
+
+// equal reports whether two folders have the same settings
+func (f Folder) equal(other Folder) bool {
+	if !slices.Equal(f.Tags, other.Tags) {
+		return false
+	}
+	f.Tags, other.Tags = nil, nil
+	return reflect.DeepEqual(f, other)
+}
+
+// FolderStatus reports how a folder's watcher is doing
+type FolderStatus struct {
+	Folder
+	Watching    bool       `json:"watching"`
+	Mode        string     `json:"mode"`
+	LastScanAt  *time.Time `json:"last_scan_at,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+	JobsCreated int        `json:"jobs_created"`
+}
+
+// Service runs a watcher for every configured and stored watch folder
+type Service struct {
+	config    *config.Config
+	taskQueue TaskQueue
+	defaults  func() models.WhisperXParams
+
+	mu       sync.Mutex
+	ctx      context.Context
+	watchers map[string]*watcher
+}
+
+// NewService creates a watch folder service. defaults supplies the
+// transcription parameters for folders without a profile.
+func NewService(cfg *config.Config, taskQueue TaskQueue, defaults func() models.WhisperXParams) *Service {
+	return &Service{
+		config:    cfg,
+		taskQueue: taskQueue,
+		defaults:  defaults,
+		watchers:  make(map[string]*watcher),
+	}
+}
+
+// Start watches every folder until ctx is done
+func (s *Service) Start(ctx context.Context) error {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.mu.Unlock()
+	return s.Reload()
+}
+
+// Reload starts watchers for new folders and stops those for removed or
+// changed ones. Folders are only loaded, not watched, before Start.
+func (s *Service) Reload() error {
+	folders, err := s.loadFolders()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wanted := make(map[string]Folder, len(folders))
+	for _, folder := range folders {
+		wanted[folder.key()] = folder
+	}
+	for key, w := range s.watchers {
+		if folder, ok := wanted[key]; !ok || !folder.equal(w.folder) {
+			w.stop()
+			delete(s.watchers, key)
+		}
+	}
+	for key, folder := range wanted {
+		if _, ok := s.watchers[key]; ok {
+			continue
+		}
+		w := newWatcher(s, folder)
+		s.watchers[key] = w
+		if s.ctx != nil {
+			w.start(s.ctx)
+		}
+	}
+	return nil
+}
+
+// loadFolders combines the WATCH_DIRS folders with the enabled stored ones
+func (s *Service) loadFolders() ([]Folder, error) {
+	var folders []Folder
+	afterProcess := s.config.WatchAfterProcess
+	if !models.IsValidWatchAfterProcess(afterProcess) {
+		afterProcess = models.WatchAfterMarker
+	}
+	for _, path := range s.config.WatchDirs {
+		folders = append(folders, Folder{
+			Source:       SourceConfig,
+			Path:         path,
+			AfterProcess: afterProcess,
+			Recursive:    true,
+			Tags:         validTags(s.config.WatchTags),
+		})
+	}
+
+	var stored []models.WatchFolder
+	if err := database.DB.Where("enabled = ?", true).Order("id").Find(&stored).Error; err != nil {
+		return nil, fmt.Errorf("failed to load watch folders: %w", err)
+	}
+	for _, folder := range stored {
+		folders = append(folders, Folder{
+			ID:           folder.ID,
+			Source:       SourceDatabase,
+			Path:         folder.Path,
+			ProfileID:    folder.ProfileID,
+			AfterProcess: folder.AfterProcess,
+			Recursive:    folder.Recursive,
+			UsePolling:   folder.UsePolling,
+
+			AudioRetentionDays: folder.AudioRetentionDays,
+			JobRetentionDays:   folder.JobRetentionDays,
+
+			Tags:        folder.Tags,
+			JobFolderID: folder.JobFolderID,
+		})
+	}
+	return folders, nil
+}
+
+// validTags returns the valid key:value tags of WATCH_TAGS, logging the rest
+func validTags(tags []string) []string {
+	var valid []string
+	for _, tag := range tags {
+		if _, _, err := models.ParseTag(tag); err != nil {
+			logger.Warn("Ignoring invalid WATCH_TAGS entry", "tag", tag, "error", err)
+			continue
+		}
+		valid = append(valid, tag)
+	}
+	return valid
+}
+
+// Status reports every folder's watcher, configured folders first
+func (s *Service) Status() []FolderStatus {
+	s.mu.Lock()
+	statuses := make([]FolderStatus, 0, len(s.watchers))
+	for _, w := range s.watchers {
+		statuses = append(statuses, w.snapshot())
+	}
+	s.mu.Unlock()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Source != statuses[j].Source {
+			return statuses[i].Source == SourceConfig
+		}
+		if statuses[i].ID != statuses[j].ID {
+			return statuses[i].ID < statuses[j].ID
+		}
+		return statuses[i].Path < statuses[j].Path
+	})
+	return statuses
+}
+
+// ErrFolderNotFound is returned when scanning a folder that is not watched
+var ErrFolderNotFound = errors.New("watch folder not found")
+
+// Scan scans a folder immediately, picking up files that have settled
+func (s *Service) Scan(ctx context.Context, path string) error {
+	s.mu.Lock()
+	var target *watcher
+	for _, w := range s.watchers {
+		if w.folder.Path == path {
+			target = w
+			break
+		}
+	}
+	s.mu.Unlock()
+	if target == nil {
+		return fmt.Errorf("%w: %s", ErrFolderNotFound, path)
+	}
+	target.scan(ctx)
+	return nil
+}
+
+// Stop stops every watcher
+func (s *Service) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, w := range s.watchers {
+		w.stop()
+		delete(s.watchers, key)
+	}
+	logger.Debug("Watch folders stopped")
+}
@@ -0,0 +1,244 @@
+// Package waveform computes the peaks an audio player draws: the lowest and
+// highest amplitude of each bucket of samples, at a detailed and an overview
+// zoom level. Peaks are encoded like the BBC's audiowaveform tool, as JSON or
+// its binary .dat format, with 8-bit amplitudes.
+package waveform
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"strings"
+)
+
+// SampleRate is the rate audio is decoded at. Peaks a player can draw do
+// not need more.
+const SampleRate = 8000
+
+// Samples per bucket of the two zoom levels: 100 buckets a second for
+// zooming in, one a second for the whole recording
+const (
+	DetailSamplesPerPixel   = SampleRate / 100
+	OverviewSamplesPerPixel = SampleRate
+)
+
+// datVersion is the audiowaveform binary format version written
+const datVersion = 1
+
+// datFlag8Bit marks .dat data as 8-bit
+const datFlag8Bit = 1
+
+// datHeaderSize is the size of a version 1 .dat header
+const datHeaderSize = 20
+
+// stderrTailBytes is how much of ffmpeg's error output errors keep
+const stderrTailBytes = 2048
+
+// ErrFFmpegUnavailable is returned when ffmpeg is not installed
+var ErrFFmpegUnavailable = errors.New("ffmpeg is not available")
+
+// Peaks are the minimum and maximum of each bucket of one zoom level
+type Peaks struct {
+	SampleRate      int
+	SamplesPerPixel int
+	Data            []int8 // Minimum and maximum of each bucket in turn
+}
+
+// Len returns the number of buckets
+func (p Peaks) Len() int {
+	return len(p.Data) / 2
+}
+
+// Duration returns the length of the audio the peaks cover, in seconds
+func (p Peaks) Duration() float64 {
+	if p.SampleRate == 0 {
+		return 0
+	}
+	return float64(p.Len()*p.SamplesPerPixel) / float64(p.SampleRate)
+}
+
+// Resample merges the peaks into n buckets. Peaks with n buckets or fewer
+// are returned unchanged.
+func (p Peaks) Resample(n int) Peaks {
+	length := p.Len()
+	if n <= 0 || n >= length {
+		return p
+	}
+	resampled := Peaks{
+		SampleRate:      p.SampleRate,
+		SamplesPerPixel: int(math.Round(float64(length*p.SamplesPerPixel) / float64(n))),
+		Data:            make([]int8, 0, 2*n),
+	}
+	for i := 0; i < n; i++ {
+		low, high := p.span(i*length/n, (i+1)*length/n)
+		resampled.Data = append(resampled.Data, low, high)
+	}
+	return resampled
+}
+
+// JSONPeaks are peaks in the audiowaveform JSON format
+type JSONPeaks struct {
+	Version         int    `json:"version"`
+	Channels        int    `json:"channels"`
+	SampleRate      int    `json:"sample_rate"`
+	SamplesPerPixel int    `json:"samples_per_pixel"`
+	Bits            int    `json:"bits"`
+	Length          int    `json:"length"`
+	Data            []int8 `json:"data"` // Minimum and maximum of each bucket in turn
+}
+
+// JSON returns the peaks in the audiowaveform JSON layout
+func (p Peaks) JSON() JSONPeaks {
+	data := p.Data
+	if data == nil {
+		data = []int8{}
+	}
+	return JSONPeaks{
+		Version:         2,
+		Channels:        1,
+		SampleRate:      p.SampleRate,
+		SamplesPerPixel: p.SamplesPerPixel,
+		Bits:            8,
+		Length:          p.Len(),
+		Data:            data,
+	}
+}
+
+// MarshalBinary encodes the peaks in the audiowaveform .dat format
+func (p Peaks) MarshalBinary() ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, datHeaderSize+len(p.Data)))
+	header := []int32{datVersion, datFlag8Bit, int32(p.SampleRate), int32(p.SamplesPerPixel), int32(p.Len())}
+	if err := binary.Write(buf, binary.LittleEndian, header); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(buf, binary.LittleEndian, p.Data[:2*p.Len()]); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes peaks written by MarshalBinary
+func (p *Peaks) UnmarshalBinary(data []byte) error {
+	if len(data) < datHeaderSize {
+		return errors.New("waveform data is too short")
+	}
+	var header [5]int32
+	if err := binary.Read(bytes.NewReader(data[:datHeaderSize]), binary.LittleEndian, &header); err != nil {
+		return err
+	}
+	if header[0] != datVersion || header[1]&datFlag8Bit == 0 {
+		return fmt.Errorf("unsupported waveform data version %d", header[0])
+	}
+	length := int(header[4])
+	if length < 0 || len(data)-datHeaderSize != 2*length {
+		return fmt.Errorf("waveform data holds %d bytes of peaks, want %d", len(data)-datHeaderSize, 2*length)
+	}
+	p.SampleRate = int(header[2])
+	p.SamplesPerPixel = int(header[3])
+	p.Data = make([]int8, 2*length)
+	for i, b := range data[datHeaderSize:] {
+		p.Data[i] = int8(b)
+	}
+	return nil
+}
+
+// Compute reads 16-bit little-endian mono PCM at SampleRate, returning its
+// detail and overview peaks
+func Compute(r io.Reader) (detail, overview Peaks, err error) {
+	detail = Peaks{SampleRate: SampleRate, SamplesPerPixel: DetailSamplesPerPixel}
+	reader := bufio.NewReaderSize(r, 64<<10)
+	var sample [2]byte
+	count := 0
+	var low, high int8
+	for {
+		if _, err := io.ReadFull(reader, sample[:]); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return detail, overview, err
+		}
+		// The high byte of a sample is its 8-bit amplitude
+		amplitude := int8(int16(binary.LittleEndian.Uint16(sample[:])) >> 8)
+		if count == 0 {
+			low, high = amplitude, amplitude
+		} else {
+			low, high = min(low, amplitude), max(high, amplitude)
+		}
+		if count++; count == DetailSamplesPerPixel {
+			detail.Data = append(detail.Data, low, high)
+			count = 0
+		}
+	}
+	if count > 0 {
+		detail.Data = append(detail.Data, low, high)
+	}
+
+	return detail, detail.group(OverviewSamplesPerPixel / DetailSamplesPerPixel), nil
+}
+
+// group merges every n buckets into one, the last of them holding what is
+// left over
+func (p Peaks) group(n int) Peaks {
+	grouped := Peaks{SampleRate: p.SampleRate, SamplesPerPixel: n * p.SamplesPerPixel}
+	for start := 0; start < p.Len(); start += n {
+		low, high := p.span(start, min(start+n, p.Len()))
+		grouped.Data = append(grouped.Data, low, high)
+	}
+	return grouped
+}
+
+// span returns the lowest and highest amplitude of buckets start to end,
+// which must hold at least one bucket
+func (p Peaks) span(start, end int) (low, high int8) {
+	low, high = p.Data[2*start], p.Data[2*start+1]
+	for i := start + 1; i < end; i++ {
+		low = min(low, p.Data[2*i])
+		high = max(high, p.Data[2*i+1])
+	}
+	return low, high
+}
+
+// Available reports whether ffmpeg, which Generate runs, is installed
+func Available() bool {
+	_, err := exec.LookPath("ffmpeg")
+	return err == nil
+}
+
+// Generate decodes the first audio stream of the file at path with ffmpeg
+// and computes its peaks
+func Generate(ctx context.Context, path string) (detail, overview Peaks, err error) {
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-nostdin", "-v", "error", "-i", path,
+		"-vn", "-map", "0:a:0", "-ac", "1", "-ar", fmt.Sprint(SampleRate), "-f", "s16le", "-")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return detail, overview, err
+	}
+	if err := cmd.Start(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return detail, overview, ErrFFmpegUnavailable
+		}
+		return detail, overview, err
+	}
+	detail, overview, err = Compute(stdout)
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return detail, overview, err
+	}
+	if err := cmd.Wait(); err != nil {
+		output := strings.TrimSpace(stderr.String())
+		if len(output) > stderrTailBytes {
+			output = output[len(output)-stderrTailBytes:]
+		}
+		return detail, overview, fmt.Errorf("ffmpeg failed: %w: %s", err, output)
+	}
+	return detail, overview, nil
+}
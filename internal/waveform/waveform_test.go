@@ -0,0 +1,88 @@
+package waveform
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// pcm encodes 16-bit samples as little-endian bytes
+func pcm(samples ...int16) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, samples)
+	return buf.Bytes()
+}
+
+func TestCompute(t *testing.T) {
+	// Two and a half seconds: a quiet second, a loud one, then half a quiet one
+	var samples []int16
+	for i := 0; i < 5*SampleRate/2; i++ {
+		amplitude := int16(256)
+		if i >= SampleRate && i < 2*SampleRate {
+			amplitude = 32512
+		}
+		if i%2 == 1 {
+			amplitude = -amplitude
+		}
+		samples = append(samples, amplitude)
+	}
+
+	detail, overview, err := Compute(bytes.NewReader(pcm(samples...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.Len() != 250 || detail.SamplesPerPixel != DetailSamplesPerPixel {
+		t.Fatalf("detail has %d buckets of %d samples, want 250 of %d", detail.Len(), detail.SamplesPerPixel, DetailSamplesPerPixel)
+	}
+	if overview.Len() != 3 || overview.SamplesPerPixel != OverviewSamplesPerPixel {
+		t.Fatalf("overview has %d buckets of %d samples, want 3 of %d", overview.Len(), overview.SamplesPerPixel, OverviewSamplesPerPixel)
+	}
+	if want := []int8{-1, 1, -127, 127, -1, 1}; !reflect.DeepEqual(overview.Data, want) {
+		t.Errorf("overview = %v, want %v", overview.Data, want)
+	}
+	if got := detail.Duration(); got != 2.5 {
+		t.Errorf("Duration() = %v, want 2.5", got)
+	}
+}
+
+func TestResample(t *testing.T) {
+	peaks := Peaks{SampleRate: SampleRate, SamplesPerPixel: 80, Data: []int8{-1, 1, -5, 2, -2, 9, 0, 0}}
+	resampled := peaks.Resample(2)
+	if want := []int8{-5, 2, -2, 9}; !reflect.DeepEqual(resampled.Data, want) {
+		t.Errorf("Resample(2) = %v, want %v", resampled.Data, want)
+	}
+	if resampled.SamplesPerPixel != 160 {
+		t.Errorf("SamplesPerPixel = %d, want 160", resampled.SamplesPerPixel)
+	}
+
+	// Peaks are never stretched
+	if got := peaks.Resample(10); got.Len() != 4 {
+		t.Errorf("Resample(10) has %d buckets, want 4", got.Len())
+	}
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	peaks := Peaks{SampleRate: SampleRate, SamplesPerPixel: 80, Data: []int8{-128, 127, -3, 4}}
+	data, err := peaks.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != datHeaderSize+4 {
+		t.Fatalf("encoded %d bytes, want %d", len(data), datHeaderSize+4)
+	}
+	if length := binary.LittleEndian.Uint32(data[16:20]); length != 2 {
+		t.Errorf("header length = %d, want 2", length)
+	}
+
+	var decoded Peaks
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, peaks) {
+		t.Errorf("decoded %+v, want %+v", decoded, peaks)
+	}
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Error("Expected truncated data to be rejected")
+	}
+}
@@ -1,16 +1,20 @@
 package web
 
 import (
+	"bytes"
 	"embed"
+	"encoding/json"
 	"io/fs"
 	"mime"
 	"net/http"
 	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"scriberr/internal/api/apierror"
 	"scriberr/pkg/logger"
 )
 
@@ -48,13 +52,42 @@ func GetIndexHTML() ([]byte, error) {
 	return staticFiles.ReadFile(path.Join(distDir, indexHTMLFilename))
 }
 
+// FrontendConfig is the server configuration the web app reads from
+// window.__SCRIBERR_CONFIG__, so it can check uploads before sending them
+type FrontendConfig struct {
+	MaxUploadSize    int64    `json:"max_upload_size"`    // Bytes; zero means unlimited
+	MaxJSONBodySize  int64    `json:"max_json_body_size"` // Bytes; zero means unlimited
+	UploadExtensions []string `json:"upload_extensions"`  // Accepted file extensions, with the dot
+	UploadMIMETypes  []string `json:"upload_mime_types"`  // Accepted types, as detected from the file's first bytes
+}
+
+// injectConfig adds a script setting window.__SCRIBERR_CONFIG__ to the head
+// of index, or to the document when it has no head
+func injectConfig(index []byte, cfg FrontendConfig) ([]byte, error) {
+	data, err := json.Marshal(cfg) // Escapes <, > and &, so it cannot end the script
+	if err != nil {
+		return nil, err
+	}
+	script := []byte("<script>window.__SCRIBERR_CONFIG__=" + string(data) + "</script>")
+	for _, tag := range []string{"</head>", "</html>"} {
+		if i := bytes.Index(index, []byte(tag)); i >= 0 {
+			return slices.Concat(index[:i], script, index[i:]), nil
+		}
+	}
+	return append(slices.Clip(index), script...), nil
+}
+
 func serveEmbeddedFile(c *gin.Context, relPath, cacheControl, contentTypeOverride string) bool {
 	data, err := staticFiles.ReadFile(path.Join(distDir, relPath))
 	if err != nil {
 		logger.Get().Error("failed to read embedded file", logger.String("request_path", c.Request.URL.Path), logger.String("embedded_path", relPath), logger.ErrorField(err))
 		return false
 	}
+	serveData(c, relPath, data, cacheControl, contentTypeOverride)
+	return true
+}
 
+func serveData(c *gin.Context, relPath string, data []byte, cacheControl, contentTypeOverride string) {
 	contentType := contentTypeOverride
 	if contentType == "" {
 		contentType = mime.TypeByExtension(filepath.Ext(relPath))
@@ -70,15 +103,23 @@ func serveEmbeddedFile(c *gin.Context, relPath, cacheControl, contentTypeOverrid
 
 	if c.Request.Method == http.MethodHead {
 		c.Status(http.StatusOK)
-		return true
+		return
 	}
 
 	c.Data(http.StatusOK, contentType, data)
-	return true
 }
 
-// SetupStaticRoutes configures static file serving in Gin
-func SetupStaticRoutes(router *gin.Engine) {
+// SetupStaticRoutes configures static file serving in Gin, injecting
+// frontendConfig into the web app's index.html
+func SetupStaticRoutes(router *gin.Engine, frontendConfig FrontendConfig) {
+	index, err := GetIndexHTML()
+	if err == nil {
+		index, err = injectConfig(index, frontendConfig)
+	}
+	if err != nil {
+		logger.Get().Error("failed to prepare index.html", logger.ErrorField(err))
+	}
+
 	assetsHandler := http.StripPrefix(assetsPrefix, GetAssetsHandler())
 	serveAsset := func(c *gin.Context) {
 		if strings.Contains(c.Param("filepath"), "..") {
@@ -109,8 +150,9 @@ func SetupStaticRoutes(router *gin.Engine) {
 	router.HEAD("/"+thumbFilename, serveTopLevel(thumbFilename))
 
 	router.NoRoute(func(c *gin.Context) {
-		if strings.HasPrefix(c.Request.URL.Path, "/api") {
-			c.JSON(http.StatusNotFound, gin.H{"error": "API endpoint not found"})
+		// Unknown API paths, versioned or not, get a JSON error
+		if p := c.Request.URL.Path; p == "/api" || strings.HasPrefix(p, "/api/") {
+			apierror.Abort(c, apierror.NotFound("API endpoint not found"))
 			return
 		}
 
@@ -119,8 +161,10 @@ func SetupStaticRoutes(router *gin.Engine) {
 			return
 		}
 
-		if !serveEmbeddedFile(c, indexHTMLFilename, cacheIndex, "text/html; charset=utf-8") {
+		if index == nil {
 			c.String(http.StatusInternalServerError, "Error loading page")
+			return
 		}
+		serveData(c, indexHTMLFilename, index, cacheIndex, "text/html; charset=utf-8")
 	})
 }
@@ -17,7 +17,7 @@ func setupStaticRouter(t *testing.T) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	router.Use(gin.Recovery())
-	SetupStaticRoutes(router)
+	SetupStaticRoutes(router, FrontendConfig{MaxUploadSize: 4 << 30})
 	return router
 }
 
@@ -151,6 +151,39 @@ func TestSpaFallbackServesIndex(t *testing.T) {
 	}
 }
 
+func TestIndexInjectsFrontendConfig(t *testing.T) {
+	router := setupStaticRouter(t)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	body := rec.Body.String()
+	prefix := "<script>window.__SCRIBERR_CONFIG__="
+	start := strings.Index(body, prefix)
+	end := strings.Index(body, "</script>")
+	if start < 0 || end < start {
+		t.Fatalf("expected the config script in index.html, got %q", body)
+	}
+	var cfg FrontendConfig
+	if err := json.Unmarshal([]byte(body[start+len(prefix):end]), &cfg); err != nil {
+		t.Fatalf("expected the config as JSON: %v", err)
+	}
+	if cfg.MaxUploadSize != 4<<30 {
+		t.Fatalf("expected max_upload_size %d, got %d", int64(4<<30), cfg.MaxUploadSize)
+	}
+}
+
+func TestInjectConfig(t *testing.T) {
+	cfg := FrontendConfig{UploadExtensions: []string{"</script>"}}
+	got, err := injectConfig([]byte("<html><head><title>x</title></head><body></body></html>"), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<html><head><title>x</title><script>window.__SCRIBERR_CONFIG__={"max_upload_size":0,"max_json_body_size":0,"upload_extensions":["\u003c/script\u003e"],"upload_mime_types":null}</script></head><body></body></html>`
+	if string(got) != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
 func TestSpaFallbackHead(t *testing.T) {
 	router := setupStaticRouter(t)
 	rec := httptest.NewRecorder()
@@ -185,20 +218,22 @@ func TestNoRouteRejectsNonIdempotentMethods(t *testing.T) {
 
 func TestApiFallbackUnaffected(t *testing.T) {
 	router := setupStaticRouter(t)
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/api/unknown", nil)
-	router.ServeHTTP(rec, req)
-
-	if rec.Code != http.StatusNotFound {
-		t.Fatalf("expected 404 for API fallback, got %d", rec.Code)
-	}
-
-	var payload map[string]any
-	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
-		t.Fatalf("expected JSON error payload, got: %v", err)
-	}
-
-	if payload["error"] != "API endpoint not found" {
-		t.Fatalf("unexpected error payload: %+v", payload)
+	for _, path := range []string{"/api/unknown", "/api/v1/unknown"} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("expected 404 for API fallback %s, got %d", path, rec.Code)
+		}
+
+		var payload map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+			t.Fatalf("expected JSON error payload for %s, got: %v", path, err)
+		}
+
+		if payload["error"] != "API endpoint not found" {
+			t.Fatalf("unexpected error payload for %s: %+v", path, payload)
+		}
 	}
 }
@@ -0,0 +1,304 @@
+// Package websocket is a minimal RFC 6455 WebSocket server: the handshake,
+// text messages, and the ping, pong and close control frames. It has no
+// extensions or subprotocols, which is all pushing events to browsers needs.
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Message and control frame opcodes
+const (
+	OpContinuation = 0
+	OpText         = 1
+	OpBinary       = 2
+	OpClose        = 8
+	OpPing         = 9
+	OpPong         = 10
+)
+
+// Close codes
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseInvalidData     = 1007
+	ClosePolicyViolation = 1008
+	CloseTooBig          = 1009
+	CloseInternalError   = 1011
+	CloseTryAgainLater   = 1013
+)
+
+// acceptGUID is appended to the client's key to compute Sec-WebSocket-Accept
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var (
+	// ErrBadHandshake is returned for requests that are not WebSocket upgrades
+	ErrBadHandshake = errors.New("websocket: not a valid upgrade request")
+	// ErrClosed is returned once the peer closed the connection
+	ErrClosed = errors.New("websocket: connection closed")
+	// errProtocol is a frame the protocol does not allow
+	errProtocol = errors.New("websocket: protocol error")
+	// errTooBig is a message larger than the read limit
+	errTooBig = errors.New("websocket: message too big")
+)
+
+// IsUpgrade reports whether r asks to switch to the WebSocket protocol
+func IsUpgrade(r *http.Request) bool {
+	return headerHasToken(r.Header, "Connection", "upgrade") && headerHasToken(r.Header, "Upgrade", "websocket")
+}
+
+// AcceptKey returns the Sec-WebSocket-Accept value for a client's
+// Sec-WebSocket-Key
+func AcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerHasToken reports whether a comma-separated header contains token,
+// ignoring case
+func headerHasToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Upgrade completes the handshake for r and takes over its connection. It
+// returns ErrBadHandshake without writing anything for requests that are not
+// version 13 upgrades, so callers can answer them.
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); r.Method != http.MethodGet || !IsUpgrade(r) ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" || err != nil || len(decoded) != 16 {
+		return nil, ErrBadHandshake
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("websocket: response does not support hijacking")
+	}
+	netConn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("websocket: hijack failed: %w", err)
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + AcceptKey(key) + "\r\n\r\n"
+	if _, err := netConn.Write([]byte(response)); err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("websocket: handshake failed: %w", err)
+	}
+	return newConn(netConn, rw.Reader), nil
+}
+
+// Conn is a server's WebSocket connection. One goroutine may read while
+// others write.
+type Conn struct {
+	conn net.Conn
+	r    *bufio.Reader
+
+	// ReadLimit is the largest message read, in bytes
+	ReadLimit int64
+	// OnPong, if set, is called by ReadMessage for each pong received
+	OnPong func()
+
+	wmu       sync.Mutex
+	closeSent bool
+}
+
+func newConn(conn net.Conn, r *bufio.Reader) *Conn {
+	if r == nil {
+		r = bufio.NewReader(conn)
+	}
+	return &Conn{conn: conn, r: r, ReadLimit: 64 << 10}
+}
+
+// SetReadDeadline sets when reads time out
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets when writes time out
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// RemoteAddr returns the peer's address
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// WriteMessage writes a message or control frame with opcode op
+func (c *Conn) WriteMessage(op int, data []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closeSent {
+		return ErrClosed
+	}
+	if op == OpClose {
+		c.closeSent = true
+	}
+	return c.writeFrame(op, data)
+}
+
+// writeFrame writes an unmasked, unfragmented frame. Callers must hold c.wmu.
+func (c *Conn) writeFrame(op int, data []byte) error {
+	header := make([]byte, 2, 10+len(data))
+	header[0] = 0x80 | byte(op)
+	switch n := len(data); {
+	case n <= 125:
+		header[1] = byte(n)
+	case n <= 0xFFFF:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	_, err := c.conn.Write(append(header, data...))
+	return err
+}
+
+// Close sends a close frame with code and reason, if none was sent yet, and
+// closes the connection
+func (c *Conn) Close(code int, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason...)
+	if len(payload) > 125 {
+		payload = payload[:125]
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	c.WriteMessage(OpClose, payload)
+	return c.conn.Close()
+}
+
+// ReadMessage reads the next text or binary message. It answers pings,
+// calls OnPong for pongs and returns ErrClosed when the peer closes the
+// connection. Frames breaking the protocol close it.
+func (c *Conn) ReadMessage() (op int, data []byte, err error) {
+	var message []byte
+	messageOp := -1
+	for {
+		fin, frameOp, payload, err := c.readFrame(int64(len(message)))
+		if err != nil {
+			return 0, nil, c.failRead(err)
+		}
+		switch frameOp {
+		case OpPing:
+			c.WriteMessage(OpPong, payload)
+			continue
+		case OpPong:
+			if c.OnPong != nil {
+				c.OnPong()
+			}
+			continue
+		case OpClose:
+			code := CloseNormal
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+			}
+			c.WriteMessage(OpClose, binary.BigEndian.AppendUint16(nil, uint16(code)))
+			return 0, nil, ErrClosed
+		case OpText, OpBinary:
+			if messageOp != -1 {
+				return 0, nil, c.failRead(errProtocol)
+			}
+			messageOp = frameOp
+		case OpContinuation:
+			if messageOp == -1 {
+				return 0, nil, c.failRead(errProtocol)
+			}
+		default:
+			return 0, nil, c.failRead(errProtocol)
+		}
+
+		message = append(message, payload...)
+		if fin {
+			if messageOp == OpText && !utf8.Valid(message) {
+				c.Close(CloseInvalidData, "text message is not UTF-8")
+				return 0, nil, errProtocol
+			}
+			return messageOp, message, nil
+		}
+	}
+}
+
+// failRead closes the connection for a frame breaking the protocol and
+// returns err
+func (c *Conn) failRead(err error) error {
+	switch {
+	case errors.Is(err, errTooBig):
+		c.Close(CloseTooBig, "message too big")
+	case errors.Is(err, errProtocol):
+		c.Close(CloseProtocolError, "protocol error")
+	}
+	return err
+}
+
+// readFrame reads a frame of a message of which read bytes were read so far
+func (c *Conn) readFrame(read int64) (fin bool, op int, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = header[0]&0x80 != 0
+	op = int(header[0] & 0x0F)
+	// Without extensions the reserved bits are zero, and clients mask frames
+	if header[0]&0x70 != 0 || header[1]&0x80 == 0 {
+		return false, 0, nil, errProtocol
+	}
+
+	length := int64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(c.r, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(c.r, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint64(extended[:]))
+	}
+	control := op >= OpClose
+	if control && (length > 125 || !fin) {
+		return false, 0, nil, errProtocol
+	}
+	if length < 0 || (!control && read+length > c.ReadLimit) {
+		return false, 0, nil, errTooBig
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
@@ -0,0 +1,201 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAcceptKey(t *testing.T) {
+	// The example handshake of RFC 6455 section 1.3
+	if got := AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Unexpected accept key %s", got)
+	}
+}
+
+// clientFrame encodes a masked frame as a client sends it
+func clientFrame(fin bool, op int, payload []byte) []byte {
+	first := byte(op)
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, 0x80|byte(n))
+	default:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	return frame
+}
+
+// readServerFrame reads an unmasked frame a server sends
+func readServerFrame(t *testing.T, r io.Reader) (int, []byte) {
+	t.Helper()
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		t.Fatalf("Failed to read frame: %v", err)
+	}
+	if header[0]&0x80 == 0 || header[1]&0x80 != 0 {
+		t.Fatalf("Expected a final unmasked frame, got header %x", header)
+	}
+	length := int(header[1] & 0x7F)
+	if length == 126 {
+		var extended [2]byte
+		io.ReadFull(r, extended[:])
+		length = int(binary.BigEndian.Uint16(extended[:]))
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatalf("Failed to read payload: %v", err)
+	}
+	return int(header[0] & 0x0F), payload
+}
+
+func pipe(t *testing.T) (*Conn, net.Conn) {
+	server, client := net.Pipe()
+	t.Cleanup(func() { server.Close(); client.Close() })
+	return newConn(server, nil), client
+}
+
+func TestReadMessage(t *testing.T) {
+	conn, client := pipe(t)
+	pongs := 0
+	conn.OnPong = func() { pongs++ }
+
+	go func() {
+		// A fragmented message with a ping and a pong between its frames
+		client.Write(clientFrame(false, OpText, []byte(`{"type":`)))
+		client.Write(clientFrame(true, OpPing, []byte("hi")))
+		client.Write(clientFrame(true, OpPong, nil))
+		client.Write(clientFrame(true, OpContinuation, []byte(`"subscribe"}`)))
+	}()
+	// The ping is answered while the message is read
+	pong := make(chan []byte, 1)
+	go func() {
+		op, payload := readServerFrame(t, client)
+		if op != OpPong {
+			t.Errorf("Expected a pong, got opcode %d", op)
+		}
+		pong <- payload
+	}()
+
+	op, data, err := conn.ReadMessage()
+	if err != nil || op != OpText || string(data) != `{"type":"subscribe"}` {
+		t.Fatalf("Expected the reassembled text message, got %d %q %v", op, data, err)
+	}
+	if got := <-pong; string(got) != "hi" {
+		t.Errorf("Expected the pong to echo the ping, got %q", got)
+	}
+	if pongs != 1 {
+		t.Errorf("Expected OnPong to be called once, got %d", pongs)
+	}
+}
+
+func TestReadMessageClose(t *testing.T) {
+	conn, client := pipe(t)
+	go client.Write(clientFrame(true, OpClose, binary.BigEndian.AppendUint16(nil, CloseGoingAway)))
+	reply := make(chan []byte, 1)
+	go func() {
+		_, payload := readServerFrame(t, client)
+		reply <- payload
+	}()
+
+	if _, _, err := conn.ReadMessage(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected ErrClosed, got %v", err)
+	}
+	if got := <-reply; binary.BigEndian.Uint16(got) != CloseGoingAway {
+		t.Errorf("Expected the close code echoed, got %x", got)
+	}
+	if err := conn.WriteMessage(OpText, []byte("late")); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected writes after closing to fail, got %v", err)
+	}
+}
+
+func TestReadMessageProtocolErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame []byte
+		code  int
+	}{
+		{"unmasked", []byte{0x81, 0x02, 'h', 'i'}, CloseProtocolError},
+		{"continuation first", clientFrame(true, OpContinuation, []byte("hi")), CloseProtocolError},
+		{"too big", clientFrame(true, OpText, bytes.Repeat([]byte("a"), 200)), CloseTooBig},
+		{"invalid UTF-8", clientFrame(true, OpText, []byte{0xff, 0xfe}), CloseInvalidData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, client := pipe(t)
+			conn.ReadLimit = 100
+			go client.Write(tt.frame)
+			closed := make(chan []byte, 1)
+			go func() {
+				_, payload := readServerFrame(t, client)
+				closed <- payload
+			}()
+			if _, _, err := conn.ReadMessage(); err == nil {
+				t.Fatal("Expected the frame to be refused")
+			}
+			if got := <-closed; int(binary.BigEndian.Uint16(got)) != tt.code {
+				t.Errorf("Expected close code %d, got %d", tt.code, binary.BigEndian.Uint16(got))
+			}
+		})
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer conn.Close(CloseNormal, "")
+		conn.WriteMessage(OpText, bytes.Repeat([]byte("e"), 300))
+	}))
+	defer server.Close()
+
+	// Plain requests are refused
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a plain request, got %d", resp.StatusCode)
+	}
+
+	netConn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer netConn.Close()
+	netConn.SetDeadline(time.Now().Add(5 * time.Second))
+	netConn.Write([]byte("GET / HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n"))
+	reader := bufio.NewReader(netConn)
+	resp, err = http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Expected 101 with the accept key, got %d %v", resp.StatusCode, resp.Header)
+	}
+	op, payload := readServerFrame(t, reader)
+	if op != OpText || len(payload) != 300 {
+		t.Errorf("Expected a 300 byte text message, got opcode %d with %d bytes", op, len(payload))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -63,7 +65,7 @@ func Init(level string) {
 		atomicLevel,
 	)
 
-	cores := []zapcore.Core{consoleCore}
+	cores := []zapcore.Core{consoleCore, &ringCore{LevelEnabler: atomicLevel, buffer: recent}}
 
 	if fileSyncer := openLogFile(); fileSyncer != nil {
 		jsonEncoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
@@ -106,11 +108,16 @@ func capitalPaddedLevelEncoder(level zapcore.Level, enc zapcore.PrimitiveArrayEn
 	}
 }
 
-func openLogFile() zapcore.WriteSyncer {
-	path := strings.TrimSpace(os.Getenv("LOG_FILE"))
-	if path == "" {
-		path = defaultLogFile
+// FilePath returns the file logs are written to, set by LOG_FILE
+func FilePath() string {
+	if path := strings.TrimSpace(os.Getenv("LOG_FILE")); path != "" {
+		return path
 	}
+	return defaultLogFile
+}
+
+func openLogFile() zapcore.WriteSyncer {
+	path := FilePath()
 
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		fmt.Fprintf(os.Stderr, "failed to create log directory %q: %v\n", filepath.Dir(path), err)
@@ -229,6 +236,19 @@ func AuthEvent(event, username, ip string, success bool, details ...any) {
 	}
 }
 
+// AuditEvent records access that is kept for auditing, such as a public share
+// link being opened. Entries carry audit=true so they can be filtered out of
+// the log stream.
+func AuditEvent(event, ip string, allowed bool, details ...any) {
+	base := fieldsToAny([]Field{
+		Bool("audit", true),
+		String("event", event),
+		String("ip", ip),
+		Bool("allowed", allowed),
+	})
+	Info("Audit event", append(base, details...)...)
+}
+
 // WorkerOperation logs queue worker lifecycle events at debug level.
 func WorkerOperation(workerID int, jobID string, operation string, details ...any) {
 	base := fieldsToAny([]Field{
@@ -263,19 +283,80 @@ func HTTPRequest(method, path string, status int, duration time.Duration, userAg
 	Info("HTTP request", fieldsToAny(fields)...)
 }
 
-// GinLogger emits structured logs for HTTP requests and attaches a request-scoped logger.
+// RequestIDHeader carries the ID of a request, both from clients that set
+// their own and back to the client
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey holds the request ID in the Gin context
+const requestIDKey = "request_id"
+
+// requestStartKey holds when GinLogger started handling the request
+const requestStartKey = "request_start"
+
+// maxRequestIDLength bounds client-supplied request IDs, which are logged
+const maxRequestIDLength = 64
+
+// RequestID returns the ID GinLogger assigned to the request, or "" outside it
+func RequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// RequestStart returns when GinLogger started handling the request, or the
+// zero time outside it
+func RequestStart(c *gin.Context) time.Time {
+	return c.GetTime(requestStartKey)
+}
+
+// requestID returns the client's request ID when it is short and printable,
+// or a new one
+func requestID(c *gin.Context) string {
+	id := c.GetHeader(RequestIDHeader)
+	if id == "" || len(id) > maxRequestIDLength {
+		return uuid.NewString()
+	}
+	for _, r := range id {
+		if r <= ' ' || r > '~' {
+			return uuid.NewString()
+		}
+	}
+	return id
+}
+
+// redactQuery hides the value of a token query parameter, which WebSocket
+// clients authenticate with
+func redactQuery(raw string) string {
+	if !strings.Contains(raw, "token=") {
+		return raw
+	}
+	// Malformed pairs are left out, which is fine for a log line
+	values, _ := url.ParseQuery(raw)
+	if !values.Has("token") {
+		return raw
+	}
+	values.Set("token", "REDACTED")
+	return values.Encode()
+}
+
+// GinLogger emits structured logs for HTTP requests and attaches a request-scoped
+// logger carrying the request ID, which is also returned in X-Request-ID.
 func GinLogger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
-		raw := c.Request.URL.RawQuery
+		raw := redactQuery(c.Request.URL.RawQuery)
 		if raw != "" {
 			path += "?" + raw
 		}
 
+		id := requestID(c)
+		c.Set(requestIDKey, id)
+		c.Set(requestStartKey, start)
+		c.Header(RequestIDHeader, id)
+
 		reqLogger := With(
 			String("method", c.Request.Method),
 			String("path", path),
+			String("request_id", id),
 		)
 
 		ctx := WithLogger(c.Request.Context(), reqLogger)
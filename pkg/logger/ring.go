@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ringSize is how many recent log entries are kept in memory
+const ringSize = 1000
+
+// Entry is a log entry kept in memory so the UI can follow recent activity,
+// such as environment setup, without reading the log file
+type Entry struct {
+	Seq     uint64         `json:"seq"`
+	Time    time.Time      `json:"time"`
+	Level   string         `json:"level"`
+	Message string         `json:"message"`
+	Fields  map[string]any `json:"fields,omitempty"`
+}
+
+// ringBuffer holds the most recent entries, oldest first
+type ringBuffer struct {
+	mu      sync.Mutex
+	entries []Entry
+	start   int
+	nextSeq uint64
+}
+
+var recent = &ringBuffer{}
+
+func (r *ringBuffer) add(entry Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextSeq++
+	entry.Seq = r.nextSeq
+	if len(r.entries) < ringSize {
+		r.entries = append(r.entries, entry)
+		return
+	}
+	r.entries[r.start] = entry
+	r.start = (r.start + 1) % ringSize
+}
+
+func (r *ringBuffer) since(afterSeq uint64, limit int) []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := []Entry{}
+	for i := range r.entries {
+		entry := r.entries[(r.start+i)%len(r.entries)]
+		if entry.Seq <= afterSeq {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries
+}
+
+// Recent returns up to limit of the most recent entries with a sequence
+// number above afterSeq, oldest first. A limit of 0 returns all of them.
+func Recent(afterSeq uint64, limit int) []Entry {
+	return recent.since(afterSeq, limit)
+}
+
+// ringCore is a zap core that records entries in the ring buffer
+type ringCore struct {
+	zapcore.LevelEnabler
+	fields []zapcore.Field
+	buffer *ringBuffer
+}
+
+func (c *ringCore) With(fields []zapcore.Field) zapcore.Core {
+	combined := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	combined = append(combined, c.fields...)
+	combined = append(combined, fields...)
+	return &ringCore{LevelEnabler: c.LevelEnabler, fields: combined, buffer: c.buffer}
+}
+
+func (c *ringCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *ringCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(encoder)
+	}
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	record := Entry{
+		Time:    entry.Time,
+		Level:   entry.Level.String(),
+		Message: entry.Message,
+	}
+	if len(encoder.Fields) > 0 {
+		record.Fields = encoder.Fields
+	}
+	c.buffer.add(record)
+	return nil
+}
+
+func (c *ringCore) Sync() error { return nil }
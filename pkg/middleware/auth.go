@@ -1,10 +1,11 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
-	"time"
 
+	"scriberr/internal/api/apierror"
 	"scriberr/internal/auth"
 	"scriberr/internal/database"
 	"scriberr/internal/models"
@@ -16,113 +17,277 @@ import (
 func AuthMiddleware(authService *auth.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Check for API key first
-		apiKey := c.GetHeader("X-API-Key")
-		if apiKey != "" {
-			if validateAPIKey(apiKey) {
-				c.Set("auth_type", "api_key")
-				c.Set("api_key", apiKey)
+		if key := extractAPIKey(c); key != "" {
+			if authenticateAPIKey(c, authService, key) {
 				c.Next()
-				return
 			}
+			return
 		}
 
 		// Check for JWT token
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing authentication"})
-			c.Abort()
+			// Fall back to the user named by a trusted authenticating proxy
+			if username := HeaderUsername(c); username != "" {
+				if authenticateHeaderUser(c, username) {
+					c.Next()
+				}
+				return
+			}
+			apierror.Abort(c, apierror.Unauthorized("Missing authentication"))
 			return
 		}
 
 		// Extract token from "Bearer <token>"
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
-			c.Abort()
+			apierror.Abort(c, apierror.Unauthorized("Invalid authorization header format"))
 			return
 		}
 
 		token := parts[1]
 		claims, err := authService.ValidateToken(token)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+			apierror.Abort(c, apierror.Unauthorized("Invalid token"))
+			return
+		}
+
+		if rejectRevokedToken(c, authService, claims) || rejectInactiveUser(c, authService, claims) {
 			return
 		}
 
 		c.Set("auth_type", "jwt")
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("role", claims.Role)
+		c.Next()
+	}
+}
+
+// QueryTokenMiddleware accepts a JWT or API key in the token query parameter
+// of requests without credentials, for WebSocket clients such as browsers
+// that cannot set headers on the upgrade request. It runs before
+// AuthMiddleware.
+func QueryTokenMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Query("token")
+		if token != "" && c.GetHeader("Authorization") == "" && c.GetHeader("X-API-Key") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
 		c.Next()
 	}
 }
 
-// validateAPIKey validates an API key against the database and updates last used timestamp
-func validateAPIKey(key string) bool {
-	var apiKey models.APIKey
-	result := database.DB.Where("key = ? AND is_active = ?", key, true).First(&apiKey)
-	if result.Error != nil {
+// rejectRevokedToken aborts the request if the token's jti is on the blocklist
+func rejectRevokedToken(c *gin.Context, authService *auth.AuthService, claims *auth.Claims) bool {
+	revoked, err := authService.IsTokenRevoked(c.Request.Context(), claims.ID)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal("Failed to validate token"))
+		return true
+	}
+	if revoked {
+		apierror.Abort(c, apierror.Unauthorized("Token has been revoked"))
+		return true
+	}
+	return false
+}
+
+// rejectInactiveUser aborts the request if the account was disabled or
+// deleted, or its password changed since the token was issued
+func rejectInactiveUser(c *gin.Context, authService *auth.AuthService, claims *auth.Claims) bool {
+	err := authService.CheckSession(c.Request.Context(), claims)
+	if err == nil {
 		return false
 	}
+	switch {
+	case errors.Is(err, auth.ErrAccountDisabled):
+		apierror.Abort(c, apierror.Unauthorized("Account is disabled"))
+	case errors.Is(err, auth.ErrSessionExpired):
+		apierror.Abort(c, apierror.Unauthorized("Session ended by a password change"))
+	default:
+		apierror.Abort(c, apierror.Internal("Failed to validate account"))
+	}
+	return true
+}
 
-	// Update last used timestamp
-	now := time.Now()
-	apiKey.LastUsed = &now
-	database.DB.Save(&apiKey)
+// extractAPIKey returns the API key sent in the X-API-Key header or as an
+// "Authorization: Bearer sk_..." token
+func extractAPIKey(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+	if len(parts) == 2 && parts[0] == "Bearer" && auth.IsAPIKey(parts[1]) {
+		return parts[1]
+	}
+	return ""
+}
+
+// Credentials returns the API key or bearer token a request authenticates
+// with, read as AuthMiddleware reads them. A token query parameter on a
+// request without credentials counts too, as QueryTokenMiddleware makes it
+// the bearer token.
+func Credentials(c *gin.Context) (apiKey, token string) {
+	if key := extractAPIKey(c); key != "" {
+		return key, ""
+	}
+	if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+		return "", strings.TrimPrefix(authHeader, "Bearer ")
+	}
+	token = c.Query("token")
+	if auth.IsAPIKey(token) {
+		return token, ""
+	}
+	return "", token
+}
+
+// authenticateAPIKey validates an API key and populates the request context with
+// its owner. It aborts the request and returns false when the key is rejected.
+func authenticateAPIKey(c *gin.Context, authService *auth.AuthService, key string) bool {
+	apiKey, err := authService.AuthenticateAPIKey(c.Request.Context(), key)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrAPIKeyExpired):
+			apierror.Abort(c, apierror.Unauthorized("API key has expired"))
+		case errors.Is(err, auth.ErrInvalidAPIKey):
+			apierror.Abort(c, apierror.Unauthorized("Invalid API key"))
+		default:
+			apierror.Abort(c, apierror.Internal("Failed to validate API key"))
+		}
+		return false
+	}
+
+	// Keys created before keys had owners keep acting as an admin, though
+	// without the admin scope they stay off the admin routes
+	role := models.RoleAdmin
+	if apiKey.UserID != nil {
+		var owner models.User
+		if err := database.DB.First(&owner, *apiKey.UserID).Error; err != nil {
+			apierror.Abort(c, apierror.Unauthorized("Invalid API key"))
+			return false
+		}
+		if !owner.IsActive() {
+			apierror.Abort(c, apierror.Unauthorized("Account is disabled"))
+			return false
+		}
+		role = owner.Role
+		c.Set("user_id", owner.ID)
+		c.Set("username", owner.Username)
+	}
 
+	c.Set("auth_type", "api_key")
+	c.Set("api_key", apiKey)
+	c.Set("role", role)
 	return true
 }
 
-// APIKeyOnlyMiddleware only allows API key authentication
-func APIKeyOnlyMiddleware() gin.HandlerFunc {
+// APIKeyMiddleware only allows API key authentication, via X-API-Key or an
+// sk_-prefixed bearer token
+func APIKeyMiddleware(authService *auth.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		apiKey := c.GetHeader("X-API-Key")
-		if apiKey == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "API key required"})
-			c.Abort()
+		key := extractAPIKey(c)
+		if key == "" {
+			apierror.Abort(c, apierror.Unauthorized("API key required"))
 			return
 		}
 
-		if !validateAPIKey(apiKey) {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
-			c.Abort()
-			return
+		if authenticateAPIKey(c, authService, key) {
+			c.Next()
 		}
-
-		c.Set("auth_type", "api_key")
-		c.Set("api_key", apiKey)
-		c.Next()
 	}
 }
 
-// JWTOnlyMiddleware only allows JWT authentication
+// JWTOnlyMiddleware only allows JWT authentication, or the user named by a
+// trusted authenticating proxy, which is an interactive login too
 func JWTOnlyMiddleware(authService *auth.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
+			if username := HeaderUsername(c); username != "" {
+				if authenticateHeaderUser(c, username) {
+					c.Next()
+				}
+				return
+			}
+			apierror.Abort(c, apierror.Unauthorized("Authorization header required"))
 			return
 		}
 
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
-			c.Abort()
+			apierror.Abort(c, apierror.Unauthorized("Invalid authorization header format"))
 			return
 		}
 
 		token := parts[1]
 		claims, err := authService.ValidateToken(token)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+			apierror.Abort(c, apierror.Unauthorized("Invalid token"))
+			return
+		}
+
+		if rejectRevokedToken(c, authService, claims) || rejectInactiveUser(c, authService, claims) {
 			return
 		}
 
 		c.Set("auth_type", "jwt")
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("role", claims.Role)
 		c.Next()
 	}
 }
+
+// RequireRole only allows requests whose authenticated role is one of roles.
+// It must run after an authentication middleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		apierror.Abort(c, apierror.Forbidden("Insufficient permissions"))
+	}
+}
+
+// RequireScope only allows API key requests whose key grants scope. Requests
+// authenticated otherwise are let through, as scopes only narrow API keys.
+// It must run after an authentication middleware.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !rejectMissingScope(c, scope) {
+			c.Next()
+		}
+	}
+}
+
+// RequireMethodScope is RequireScope with the read scope for GET and HEAD
+// requests and the write scope for everything else
+func RequireMethodScope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scope := models.ScopeWrite
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			scope = models.ScopeRead
+		}
+		if !rejectMissingScope(c, scope) {
+			c.Next()
+		}
+	}
+}
+
+// rejectMissingScope aborts the request if it used an API key that does not
+// grant scope
+func rejectMissingScope(c *gin.Context, scope string) bool {
+	value, ok := c.Get("api_key")
+	if !ok {
+		return false
+	}
+	if apiKey, ok := value.(*models.APIKey); ok && apiKey.HasScope(scope) {
+		return false
+	}
+	apierror.Abort(c, apierror.Forbidden("API key lacks the "+scope+" scope"))
+	return true
+}
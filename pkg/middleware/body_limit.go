@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"scriberr/internal/api/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Keys holding the body limits of a request in the Gin context
+const (
+	bodyLimitKey   = "body_limit"
+	uploadLimitKey = "upload_body_limit"
+)
+
+// BodyLimitMiddleware bounds request bodies. Multipart bodies may be up to
+// uploadLimit bytes, and all others up to limit bytes unless the route raises
+// theirs with UploadBodyMiddleware. A body that goes past its limit is
+// answered with 413 as soon as it does, and nothing after it is read. A limit
+// of zero or less disables the bound.
+func BodyLimitMiddleware(limit, uploadLimit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		c.Set(uploadLimitKey, uploadLimit)
+		if strings.HasPrefix(c.ContentType(), "multipart/") {
+			c.Set(bodyLimitKey, uploadLimit)
+		} else {
+			c.Set(bodyLimitKey, limit)
+		}
+		c.Request.Body = &limitedBody{c: c, body: c.Request.Body}
+		c.Next()
+	}
+}
+
+// UploadBodyMiddleware raises the body limit of routes that take a file as
+// the raw request body to the upload limit
+func UploadBodyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limit, ok := c.Get(uploadLimitKey); ok {
+			c.Set(bodyLimitKey, limit)
+		}
+		c.Next()
+	}
+}
+
+// limitedBody applies the request's body limit on the first read, so route
+// middleware can still change it after BodyLimitMiddleware has run
+type limitedBody struct {
+	c      *gin.Context
+	body   io.ReadCloser
+	reader io.Reader
+	limit  int64
+	err    error
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if b.reader == nil {
+		b.limit = b.c.GetInt64(bodyLimitKey)
+		if b.limit <= 0 {
+			b.reader = b.body
+		} else if b.c.Request.ContentLength > b.limit {
+			// The declared length is already too large; read none of it
+			return 0, b.tooLarge()
+		} else {
+			b.reader = http.MaxBytesReader(b.c.Writer, b.body, b.limit)
+		}
+	}
+
+	n, err := b.reader.Read(p)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return n, b.tooLarge()
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
+
+// tooLarge answers the request with 413. Handlers then fail to read the body,
+// and the error responses they write are dropped, since one was written.
+func (b *limitedBody) tooLarge() error {
+	b.err = &http.MaxBytesError{Limit: b.limit}
+	apierror.Abort(b.c, apierror.TooLarge("Request body is too large").WithDetails(map[string]any{"max_size": b.limit}))
+	return b.err
+}
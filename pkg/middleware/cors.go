@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"scriberr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// corsRestrictedKey marks requests served with an origin allow list
+const corsRestrictedKey = "cors_restricted"
+
+const (
+	corsAllowMethods = "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS"
+	corsAllowHeaders = "Origin, Accept, Accept-Encoding, Content-Type, Content-Length, X-CSRF-Token, Authorization, X-API-Key, Upload-Offset"
+	// Response headers API clients read besides the CORS safelisted ones
+	corsExposeHeaders = "API-Version, Content-Disposition, Deprecation, ETag, Link, Location, Retry-After, Sunset, Upload-Offset, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset"
+	// How long browsers may cache a preflight, in seconds
+	corsMaxAge = "600"
+)
+
+// originPattern is an entry of the origin allow list
+type originPattern struct {
+	scheme string // Of a wildcard entry
+	suffix string // Host and port of a wildcard entry after the *, as in .example.com:8443
+	exact  string // Origin of an exact entry
+	any    bool   // The entry is *
+}
+
+// match reports whether origin matches the entry
+func (p originPattern) match(origin string) bool {
+	switch {
+	case p.any:
+		return true
+	case p.exact != "":
+		return origin == p.exact
+	}
+	scheme, host, ok := strings.Cut(origin, "://")
+	if !ok || scheme != p.scheme {
+		return false
+	}
+	label, ok := strings.CutSuffix(host, p.suffix)
+	return ok && label != "" && !strings.ContainsAny(label, "/:")
+}
+
+// parseOriginPatterns parses the origin allow list: exact origins such as
+// https://app.example.com or chrome-extension://<id>, origins with a wildcard
+// subdomain such as https://*.example.com, or * for any origin
+func parseOriginPatterns(origins []string) []originPattern {
+	var patterns []originPattern
+	for _, entry := range origins {
+		origin := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(entry), "/"))
+		scheme, host, ok := strings.Cut(origin, "://")
+		switch {
+		case origin == "*":
+			patterns = append(patterns, originPattern{any: true})
+		case !ok || scheme == "" || host == "" || strings.Contains(host, "/"):
+			logger.Warn("Ignoring invalid allowed origin", "origin", entry)
+		case strings.HasPrefix(host, "*."):
+			patterns = append(patterns, originPattern{scheme: scheme, suffix: host[1:]})
+		case strings.Contains(host, "*"):
+			logger.Warn("Ignoring allowed origin with a wildcard that is not a whole subdomain", "origin", entry)
+		default:
+			patterns = append(patterns, originPattern{exact: origin})
+		}
+	}
+	return patterns
+}
+
+// CORSMiddleware answers cross-origin requests from allowedOrigins. Listed
+// origins are echoed back, and only exact entries may send credentials;
+// other origins get no CORS headers and their preflights are refused. Every
+// response varies by Origin. Without allowed origins any origin may call the
+// API without credentials, as before allow lists existed.
+func CORSMiddleware(allowedOrigins []string) gin.HandlerFunc {
+	if len(allowedOrigins) == 0 {
+		return func(c *gin.Context) {
+			c.Header("Access-Control-Allow-Origin", "*")
+			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key, Upload-Offset")
+
+			if c.Request.Method == "OPTIONS" {
+				c.AbortWithStatus(204)
+				return
+			}
+
+			c.Next()
+		}
+	}
+
+	patterns := parseOriginPatterns(allowedOrigins)
+	return func(c *gin.Context) {
+		c.Set(corsRestrictedKey, true)
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && origin != "" && c.GetHeader("Access-Control-Request-Method") != ""
+		if preflight {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+		}
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		allowed, credentials := false, false
+		lowered := strings.ToLower(origin)
+		for _, pattern := range patterns {
+			if pattern.match(lowered) {
+				allowed = true
+				if pattern.exact != "" {
+					credentials = true
+					break
+				}
+			}
+		}
+		if !allowed {
+			if preflight {
+				logger.Debug("Refused CORS preflight from an origin that is not allowed", "origin", origin, "path", c.Request.URL.Path)
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		header.Set("Access-Control-Allow-Origin", origin)
+		if credentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if preflight {
+			header.Set("Access-Control-Allow-Methods", corsAllowMethods)
+			header.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			header.Set("Access-Control-Max-Age", corsMaxAge)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		header.Set("Access-Control-Expose-Headers", corsExposeHeaders)
+		c.Next()
+	}
+}
+
+// CORSRestricted reports whether the request is served with an origin allow
+// list, so handlers must not allow other origins themselves
+func CORSRestricted(c *gin.Context) bool {
+	return c.GetBool(corsRestrictedKey)
+}
@@ -1,7 +1,9 @@
 package tests
 
 import (
+	"archive/zip"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,17 +11,31 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"scriberr/internal/api"
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/auth"
+	"scriberr/internal/config"
+	"scriberr/internal/database"
+	"scriberr/internal/estimate"
+	"scriberr/internal/lexicon"
 	"scriberr/internal/models"
 	"scriberr/internal/queue"
+	"scriberr/internal/quota"
 	"scriberr/internal/transcription"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type APIHandlerTestSuite struct {
@@ -102,6 +118,91 @@ func (suite *APIHandlerTestSuite) TestHealthCheck() {
 	assert.Equal(suite.T(), "healthy", response["status"])
 }
 
+// Test liveness and readiness probes
+func (suite *APIHandlerTestSuite) TestHealthProbes() {
+	probe := func(router *gin.Engine, url string) (int, api.ReadinessResponse) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", url, nil)
+		router.ServeHTTP(w, req)
+		var response api.ReadinessResponse
+		assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response
+	}
+
+	code, live := probe(suite.router, "/api/health")
+	assert.Equal(suite.T(), http.StatusOK, code)
+	assert.Equal(suite.T(), "ok", live.Status)
+
+	// The suite never starts its queue, so readiness fails on the queue alone
+	code, ready := probe(suite.router, "/api/health/ready")
+	assert.Equal(suite.T(), http.StatusServiceUnavailable, code)
+	assert.Equal(suite.T(), "not_ready", ready.Status)
+	assert.Equal(suite.T(), "fail", ready.Checks["queue"].Status)
+	assert.NotEmpty(suite.T(), ready.Checks["queue"].Error)
+	for _, name := range []string{"database", "upload_dir", "whisperx_env", "disk_space"} {
+		assert.Equal(suite.T(), "ok", ready.Checks[name].Status, name)
+	}
+
+	// With a running queue every check passes
+	tq := queue.NewTaskQueue(1, suite.unifiedProcessor)
+	tq.Start()
+	defer tq.Stop()
+	router := api.SetupRoutes(api.NewHandler(suite.helper.Config, suite.helper.AuthService, tq, suite.unifiedProcessor, suite.quickTranscription), suite.helper.AuthService)
+	code, ready = probe(router, "/api/health/ready")
+	assert.Equal(suite.T(), http.StatusOK, code)
+	assert.Equal(suite.T(), "ready", ready.Status)
+	assert.Len(suite.T(), ready.Checks, 5)
+
+	// A second probe within the cache window gets the same result
+	_, again := probe(router, "/api/health/ready")
+	assert.True(suite.T(), ready.CheckedAt.Equal(again.CheckedAt))
+}
+
+// Test that unversioned /api paths still work as deprecated aliases of v1
+func (suite *APIHandlerTestSuite) TestUnversionedAliases() {
+	get := func(url string, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", url, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+
+	versioned := get("/api/v1/auth/registration-status", "")
+	assert.Equal(suite.T(), http.StatusOK, versioned.Code)
+	assert.Equal(suite.T(), "v1", versioned.Header().Get("API-Version"))
+	assert.Empty(suite.T(), versioned.Header().Get("Deprecation"))
+
+	alias := get("/api/auth/registration-status", "")
+	assert.Equal(suite.T(), http.StatusOK, alias.Code)
+	assert.JSONEq(suite.T(), versioned.Body.String(), alias.Body.String())
+	assert.Equal(suite.T(), "v1", alias.Header().Get("API-Version"))
+	assert.Regexp(suite.T(), `^@\d+$`, alias.Header().Get("Deprecation"))
+	sunset, err := http.ParseTime(alias.Header().Get("Sunset"))
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), sunset.After(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(suite.T(), `</api/v1/auth/registration-status>; rel="successor-version"`, alias.Header().Get("Link"))
+
+	// Aliases keep their authentication
+	assert.Equal(suite.T(), http.StatusUnauthorized, get("/api/transcription/list", "").Code)
+	w := get("/api/transcription/list", suite.helper.TestToken)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.NotEmpty(suite.T(), w.Header().Get("Deprecation"))
+
+	// Error envelopes carry the API version, also for unknown paths
+	for _, url := range []string{"/api/transcription/list", "/api/v1/transcription/list", "/api/v1/unknown", "/api/unknown"} {
+		w := get(url, "")
+		var envelope map[string]interface{}
+		assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &envelope), url)
+		assert.NotEmpty(suite.T(), envelope["error"], url)
+		assert.Equal(suite.T(), "v1", envelope["api_version"], url)
+	}
+	assert.Equal(suite.T(), http.StatusNotFound, get("/api/v1/unknown", "").Code)
+	assert.Equal(suite.T(), http.StatusNotFound, get("/api/unknown", "").Code)
+}
+
 // Test user registration
 func (suite *APIHandlerTestSuite) TestRegisterUser() {
 	registerData := map[string]string{
@@ -178,7 +279,7 @@ func (suite *APIHandlerTestSuite) TestAPIKeyManagement() {
 
 	// Should contain at least our test API key (check by key preview)
 	found := false
-	testKeyPreview := suite.helper.TestAPIKey[:8] + "..."
+	testKeyPreview := suite.helper.TestAPIKey[:12] + "..."
 	for _, key := range wrappedResponse.APIKeys {
 		if key.KeyPreview == testKeyPreview {
 			found = true
@@ -196,17 +297,72 @@ func (suite *APIHandlerTestSuite) TestAPIKeyManagement() {
 	w = suite.makeAuthenticatedRequest("POST", "/api/v1/api-keys/", createData, true)
 	assert.Equal(suite.T(), 200, w.Code)
 
-	var createResponse models.APIKey
+	var createResponse api.CreateAPIKeyResponse
 	err = json.Unmarshal(w.Body.Bytes(), &createResponse)
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), "Test Created Key", createResponse.Name)
-	assert.NotEmpty(suite.T(), createResponse.Key)
+	assert.True(suite.T(), strings.HasPrefix(createResponse.Key, "sk_"))
+
+	// The new key authenticates as a bearer token
+	req, _ := http.NewRequest("GET", "/api/v1/transcription/list", nil)
+	req.Header.Set("Authorization", "Bearer "+createResponse.Key)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), 200, w.Code)
+
+	// Fetch it back without exposing the key
+	w = suite.makeAuthenticatedRequest("GET", fmt.Sprintf("/api/v1/api-keys/%d", createResponse.ID), nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.NotContains(suite.T(), w.Body.String(), createResponse.Key)
 
 	// Delete the created API key
 	w = suite.makeAuthenticatedRequest("DELETE", fmt.Sprintf("/api/v1/api-keys/%d", createResponse.ID), nil, true)
 	assert.Equal(suite.T(), 200, w.Code)
 }
 
+// Test that an API key may only make the requests its scopes allow
+func (suite *APIHandlerTestSuite) TestAPIKeyScopes() {
+	createKey := func(scopes []string) string {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/api-keys/", map[string]interface{}{"name": "Scoped Key", "scopes": scopes}, true)
+		require.Equal(suite.T(), 200, w.Code, w.Body.String())
+		var created api.CreateAPIKeyResponse
+		require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &created))
+		return created.Key
+	}
+	request := func(method, path, key string) int {
+		req, _ := http.NewRequest(method, path, strings.NewReader(`{"title":"Scoped"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w.Code
+	}
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Scoped Job")
+	jobPath := "/api/v1/transcriptions/" + job.ID
+
+	// Read keys may only read
+	readKey := createKey([]string{"read"})
+	assert.Equal(suite.T(), 200, request("GET", "/api/v1/transcription/list", readKey))
+	assert.Equal(suite.T(), 403, request("PATCH", jobPath, readKey))
+	assert.Equal(suite.T(), 403, request("GET", "/api/v1/admin/queue/stats", readKey))
+
+	// Write keys and keys without scopes may also change things, but not administer
+	for _, key := range []string{createKey([]string{"write"}), createKey(nil)} {
+		assert.Equal(suite.T(), 200, request("GET", "/api/v1/transcription/list", key))
+		assert.Equal(suite.T(), 200, request("PATCH", jobPath, key))
+		assert.Equal(suite.T(), 403, request("GET", "/api/v1/admin/queue/stats", key))
+	}
+
+	// Admin keys reach admin routes
+	adminKey := createKey([]string{"admin"})
+	assert.Equal(suite.T(), 200, request("GET", "/api/v1/admin/queue/stats", adminKey))
+	assert.Equal(suite.T(), 200, request("PATCH", jobPath, adminKey))
+
+	// Unknown scopes are rejected
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/api-keys/", map[string]interface{}{"name": "Bad Key", "scopes": []string{"everything"}}, true)
+	assert.Equal(suite.T(), 400, w.Code)
+}
+
 // Test transcription job listing
 func (suite *APIHandlerTestSuite) TestListTranscriptionJobs() {
 	// Create a test job first
@@ -265,6 +421,19 @@ func (suite *APIHandlerTestSuite) TestGetJobStatus() {
 	assert.Equal(suite.T(), models.StatusPending, response.Status)
 }
 
+// Test that the language a model detected is returned with the job status
+func (suite *APIHandlerTestSuite) TestGetJobStatusDetectedLanguage() {
+	testJob := suite.helper.CreateTestTranscriptionJob(suite.T(), "Detected Language")
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(testJob).Updates(map[string]interface{}{
+		"status":            models.StatusCompleted,
+		"detected_language": "fr",
+	}).Error)
+
+	w := suite.makeAuthenticatedRequest("GET", fmt.Sprintf("/api/v1/transcription/%s/status", testJob.ID), nil, false)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"detected_language":"fr"`)
+}
+
 // Test updating transcription title
 func (suite *APIHandlerTestSuite) TestUpdateTranscriptionTitle() {
 	testJob := suite.helper.CreateTestTranscriptionJob(suite.T(), "Original Title")
@@ -444,7 +613,7 @@ func (suite *APIHandlerTestSuite) TestTranscriptionSubmit() {
 	assert.NoError(suite.T(), err)
 	defer os.Remove(tmpFile.Name())
 
-	_, err = tmpFile.WriteString("dummy audio data for API handler testing")
+	_, err = tmpFile.Write(fakeMP3("dummy audio data for API handler testing"))
 	assert.NoError(suite.T(), err)
 	assert.NoError(suite.T(), tmpFile.Close())
 
@@ -487,45 +656,2970 @@ func (suite *APIHandlerTestSuite) TestTranscriptionSubmit() {
 	assert.Equal(suite.T(), models.StatusPending, response.Status)
 }
 
-// Test error responses for non-existent resources
-func (suite *APIHandlerTestSuite) TestNotFoundErrors() {
-	endpoints := []string{
-		"/api/v1/transcription/nonexistent-job",
-		"/api/v1/transcription/nonexistent-job/status",
-		"/api/v1/transcription/nonexistent-job/transcript",
-		"/api/v1/profiles/nonexistent-profile",
-		"/api/v1/notes/nonexistent-note",
+// Test that only admins can submit high priority jobs
+func (suite *APIHandlerTestSuite) TestTranscriptionSubmitPriority() {
+	member := &models.User{ID: suite.helper.TestUser.ID, Username: suite.helper.TestUser.Username, Role: models.RoleUser}
+	memberToken, err := suite.helper.AuthService.GenerateToken(member)
+	assert.NoError(suite.T(), err)
+
+	submit := func(token, priority string) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, err := writer.CreateFormFile("audio", "test.mp3")
+		assert.NoError(suite.T(), err)
+		_, err = part.Write(fakeMP3("dummy audio data"))
+		assert.NoError(suite.T(), err)
+		if priority != "" {
+			assert.NoError(suite.T(), writer.WriteField("priority", priority))
+		}
+		assert.NoError(suite.T(), writer.Close())
+
+		req, err := http.NewRequest("POST", "/api/v1/transcriptions", body)
+		assert.NoError(suite.T(), err)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+	priorityOf := func(w *httptest.ResponseRecorder) int {
+		assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+		var job models.TranscriptionJob
+		assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+		var stored models.TranscriptionJob
+		assert.NoError(suite.T(), suite.helper.GetDB().First(&stored, "id = ?", job.ID).Error)
+		assert.Equal(suite.T(), job.Priority, stored.Priority)
+		return stored.Priority
 	}
 
-	for _, endpoint := range endpoints {
-		w := suite.makeAuthenticatedRequest("GET", endpoint, nil, false)
-		assert.Equal(suite.T(), 404, w.Code, "Endpoint %s should return 404", endpoint)
+	assert.Equal(suite.T(), models.PriorityNormal, priorityOf(submit(memberToken, "")))
+	assert.Equal(suite.T(), models.PriorityLow, priorityOf(submit(memberToken, "-1")))
+	assert.Equal(suite.T(), http.StatusForbidden, submit(memberToken, "1").Code)
+	assert.Equal(suite.T(), models.PriorityHigh, priorityOf(submit(suite.helper.TestToken, "1")))
+	assert.Equal(suite.T(), http.StatusBadRequest, submit(suite.helper.TestToken, "2").Code)
+	assert.Equal(suite.T(), http.StatusBadRequest, submit(suite.helper.TestToken, "urgent").Code)
+}
+
+func (suite *APIHandlerTestSuite) TestTranscriptionSchedule() {
+	submit := func(scheduledAt string) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, err := writer.CreateFormFile("audio", "test.mp3")
+		assert.NoError(suite.T(), err)
+		_, err = part.Write(fakeMP3("dummy audio data"))
+		assert.NoError(suite.T(), err)
+		assert.NoError(suite.T(), writer.WriteField("scheduled_at", scheduledAt))
+		assert.NoError(suite.T(), writer.Close())
+
+		req, err := http.NewRequest("POST", "/api/v1/transcriptions", body)
+		assert.NoError(suite.T(), err)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+
+	scheduledAt := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Second)
+	w := submit(scheduledAt.Format(time.RFC3339))
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	var job models.TranscriptionJob
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), models.StatusScheduled, job.Status)
+	if assert.NotNil(suite.T(), job.ScheduledAt) {
+		assert.True(suite.T(), scheduledAt.Equal(*job.ScheduledAt))
 	}
+
+	assert.Equal(suite.T(), http.StatusBadRequest, submit("tonight").Code)
+	assert.Equal(suite.T(), http.StatusBadRequest, submit(time.Now().Add(-time.Hour).Format(time.RFC3339)).Code)
+
+	// Reschedule, then clear the schedule to queue the job now
+	path := "/api/v1/transcriptions/" + job.ID + "/schedule"
+	later := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	w = suite.makeAuthenticatedRequest("PATCH", path, map[string]interface{}{"scheduled_at": later}, true)
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), models.StatusScheduled, job.Status)
+	assert.True(suite.T(), later.Equal(*job.ScheduledAt))
+
+	w = suite.makeAuthenticatedRequest("PATCH", path, map[string]interface{}{"scheduled_at": nil}, true)
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	job = models.TranscriptionJob{}
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), models.StatusPending, job.Status)
+	assert.Nil(suite.T(), job.ScheduledAt)
+
+	w = suite.makeAuthenticatedRequest("PATCH", path, map[string]interface{}{"scheduled_at": "yesterday"}, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	w = suite.makeAuthenticatedRequest("PATCH", path, map[string]interface{}{"scheduled_at": time.Now().Add(-time.Hour)}, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	completed := suite.helper.CreateTestTranscriptionJob(suite.T(), "Completed Schedule Job")
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(completed).Update("status", models.StatusCompleted).Error)
+	w = suite.makeAuthenticatedRequest("PATCH", "/api/v1/transcriptions/"+completed.ID+"/schedule", map[string]interface{}{"scheduled_at": later}, true)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+	w = suite.makeAuthenticatedRequest("PATCH", "/api/v1/transcriptions/missing/schedule", map[string]interface{}{"scheduled_at": later}, true)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
 }
 
-// Test invalid request data
-func (suite *APIHandlerTestSuite) TestInvalidRequestData() {
-	// Test invalid JSON for login
+// submitTranscription submits a dummy file with form fields
+func (suite *APIHandlerTestSuite) submitTranscription(fields map[string]string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("audio", "test.mp3")
+	assert.NoError(suite.T(), err)
+	_, err = part.Write(fakeMP3("dummy audio data"))
+	assert.NoError(suite.T(), err)
+	for key, value := range fields {
+		assert.NoError(suite.T(), writer.WriteField(key, value))
+	}
+	assert.NoError(suite.T(), writer.Close())
+
+	req, err := http.NewRequest("POST", "/api/v1/transcriptions", body)
+	assert.NoError(suite.T(), err)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
 	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("POST", "/api/v1/auth/login", strings.NewReader("invalid json"))
-	req.Header.Set("Content-Type", "application/json")
 	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *APIHandlerTestSuite) TestTranscriptionDecodingParams() {
+	submit := suite.submitTranscription
+
+	// Defaults apply when the fields are left out
+	w := submit(nil)
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	var job models.TranscriptionJob
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), 5, job.Parameters.BeamSize)
+	assert.Equal(suite.T(), []float64{0, 0.2, 0.4, 0.6, 0.8, 1}, job.Parameters.Temperatures)
+
+	w = submit(map[string]string{"beam_size": "8", "temperatures": "0, 0.5, 1.0"})
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	job = models.TranscriptionJob{}
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), 8, job.Parameters.BeamSize)
+	assert.Equal(suite.T(), []float64{0, 0.5, 1}, job.Parameters.Temperatures)
+
+	var stored models.TranscriptionJob
+	assert.NoError(suite.T(), suite.helper.GetDB().Where("id = ?", job.ID).First(&stored).Error)
+	assert.Equal(suite.T(), []float64{0, 0.5, 1}, stored.Parameters.Temperatures)
+
+	for _, fields := range []map[string]string{
+		{"beam_size": "0"},
+		{"beam_size": "11"},
+		{"beam_size": "wide"},
+		{"temperatures": "0,1.5"},
+		{"temperatures": "-0.2,0"},
+		{"temperatures": "0,warm"},
+		{"temperatures": "0.4,0.2"},
+		{"temperatures": "0,0.1,0.5"},
+	} {
+		assert.Equal(suite.T(), http.StatusBadRequest, submit(fields).Code, fields)
+	}
+}
+
+func (suite *APIHandlerTestSuite) TestTranscriptionInitialPrompt() {
+	prompt := "Scriberr, WhisperX and pyannote"
+	w := suite.submitTranscription(map[string]string{"initial_prompt": prompt})
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	var job models.TranscriptionJob
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	if assert.NotNil(suite.T(), job.Parameters.InitialPrompt) {
+		assert.Equal(suite.T(), prompt, *job.Parameters.InitialPrompt)
+	}
+
+	w = suite.submitTranscription(map[string]string{"initial_prompt": strings.Repeat("terminology ", 100)})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "initial_prompt")
+}
+
+func (suite *APIHandlerTestSuite) TestTranscriptionTask() {
+	w := suite.submitTranscription(map[string]string{"task": "translate", "language": "fr"})
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	var job models.TranscriptionJob
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), models.TaskTranslate, job.Parameters.Task)
+
+	var stored models.TranscriptionJob
+	assert.NoError(suite.T(), suite.helper.GetDB().Where("id = ?", job.ID).First(&stored).Error)
+	assert.Equal(suite.T(), models.TaskTranslate, stored.Parameters.Task)
+
+	// Translating English audio into English is refused
+	w = suite.submitTranscription(map[string]string{"task": "translate", "language": "en"})
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code, w.Body.String())
+
+	w = suite.submitTranscription(map[string]string{"task": "summarize"})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	// Models without translation support are refused, naming those with it
+	parakeet := suite.helper.CreateTestTranscriptionJob(suite.T(), "Translate with Parakeet")
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(parakeet).Update("status", models.StatusUploaded).Error)
+	w = suite.makeAuthenticatedRequest("POST", fmt.Sprintf("/api/v1/transcription/%s/start", parakeet.ID), map[string]interface{}{
+		"task": "translate", "language": "de", "model_family": "nvidia_parakeet",
+	}, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "models that can: canary, faster-whisper, openai, whisperx")
+
+	uploaded := suite.helper.CreateTestTranscriptionJob(suite.T(), "Translate on start")
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(uploaded).Update("status", models.StatusUploaded).Error)
+	w = suite.makeAuthenticatedRequest("POST", fmt.Sprintf("/api/v1/transcription/%s/start", uploaded.ID), map[string]interface{}{
+		"task": "translate", "language": "en",
+	}, true)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code, w.Body.String())
+}
+
+// Test that VAD thresholds are stored and out-of-range values are refused
+func (suite *APIHandlerTestSuite) TestTranscriptionVAD() {
+	w := suite.submitTranscription(map[string]string{"vad_onset": "0.3", "vad_offset": "0.2"})
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	var job models.TranscriptionJob
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), 0.3, job.Parameters.VadOnset)
+	assert.Equal(suite.T(), 0.2, job.Parameters.VadOffset)
+
+	for _, fields := range []map[string]string{
+		{"vad_onset": "1"},
+		{"vad_onset": "1.5"},
+		{"vad_onset": "-0.1"},
+		{"vad_offset": "1.0"},
+	} {
+		w = suite.submitTranscription(fields)
+		assert.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code, fields)
+		assert.Contains(suite.T(), w.Body.String(), "vad_")
+	}
+
+	uploaded := suite.helper.CreateTestTranscriptionJob(suite.T(), "VAD on start")
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(uploaded).Update("status", models.StatusUploaded).Error)
+	w = suite.makeAuthenticatedRequest("POST", fmt.Sprintf("/api/v1/transcription/%s/start", uploaded.ID), map[string]interface{}{
+		"vad_offset": 2.0,
+	}, true)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code, w.Body.String())
+}
+
+// Test that the compute type defaults to the device's and is validated
+func (suite *APIHandlerTestSuite) TestTranscriptionComputeType() {
+	w := suite.submitTranscription(map[string]string{"device": "cpu"})
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	var job models.TranscriptionJob
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), "int8", job.Parameters.ComputeType)
+
+	w = suite.submitTranscription(map[string]string{"device": "cuda"})
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), "float16", job.Parameters.ComputeType)
+
+	w = suite.submitTranscription(map[string]string{"compute_type": "int8_bfloat16"})
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), "int8_bfloat16", job.Parameters.ComputeType)
+
+	w = suite.submitTranscription(map[string]string{"compute_type": "float64"})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "compute_type must be one of")
+}
+
+// Test that the batch size defaults per device and is kept within range
+func (suite *APIHandlerTestSuite) TestTranscriptionBatchSize() {
+	var job models.TranscriptionJob
+	for device, want := range map[string]int{"cpu": 4, "mps": 8, "cuda": 16} {
+		w := suite.submitTranscription(map[string]string{"device": device})
+		assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+		assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+		assert.Equal(suite.T(), want, job.Parameters.BatchSize, device)
+	}
+
+	w := suite.submitTranscription(map[string]string{"batch_size": "256"})
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), 256, job.Parameters.BatchSize)
+
+	for _, batchSize := range []string{"-1", "257"} {
+		w = suite.submitTranscription(map[string]string{"batch_size": batchSize})
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, batchSize)
+		assert.Contains(suite.T(), w.Body.String(), "batch_size must be between 1 and 256")
+	}
+}
+
+// Test that the backend is stored and only known backends are accepted
+func (suite *APIHandlerTestSuite) TestTranscriptionBackend() {
+	w := suite.submitTranscription(map[string]string{"backend": models.BackendFasterWhisper})
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	var job models.TranscriptionJob
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), models.BackendFasterWhisper, job.Parameters.Backend)
+	assert.Equal(suite.T(), "faster-whisper", transcription.TranscriptionModelID(job.Parameters))
+
+	w = suite.submitTranscription(map[string]string{"backend": "whisper.cpp"})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "backend must be")
+}
+
+// Test that the engine field selects the hosted adapter
+func (suite *APIHandlerTestSuite) TestTranscriptionEngine() {
+	w := suite.submitTranscription(map[string]string{"engine": models.EngineOpenAI})
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	var job models.TranscriptionJob
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), models.EngineOpenAI, job.Parameters.Engine)
+	assert.Equal(suite.T(), "whisper", job.Parameters.ModelFamily)
+	assert.Equal(suite.T(), "openai", transcription.TranscriptionModelID(job.Parameters))
+
+	w = suite.submitTranscription(map[string]string{"engine": "deepgram"})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "engine must be")
+}
+
+// Test that language auto-detection is only accepted for models that can detect
+func (suite *APIHandlerTestSuite) TestTranscriptionLanguageAuto() {
+	w := suite.submitTranscription(map[string]string{"language": "auto"})
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+
+	uploaded := suite.helper.CreateTestTranscriptionJob(suite.T(), "Parakeet auto")
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(uploaded).Update("status", models.StatusUploaded).Error)
+	w = suite.makeAuthenticatedRequest("POST", fmt.Sprintf("/api/v1/transcription/%s/start", uploaded.ID), map[string]interface{}{
+		"model_family": "nvidia_parakeet", "language": "auto",
+	}, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "cannot detect the language")
+
+	// Leaving the language unset keeps the model's default
+	w = suite.makeAuthenticatedRequest("POST", fmt.Sprintf("/api/v1/transcription/%s/start", uploaded.ID), map[string]interface{}{
+		"model_family": "nvidia_parakeet",
+	}, true)
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+}
+
+// Test that audio preprocessing options are stored and the filter is range checked
+func (suite *APIHandlerTestSuite) TestTranscriptionPreprocessing() {
+	w := suite.submitTranscription(map[string]string{"normalize_audio": "true", "high_pass_filter_hz": "80", "skip_preprocess": "true"})
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	var job models.TranscriptionJob
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), models.Preprocessing{NormalizeAudio: true, HighPassFilterHz: 80}, job.Parameters.Preprocessing)
+	assert.True(suite.T(), job.Parameters.SkipPreprocess)
+
+	w = suite.submitTranscription(map[string]string{"high_pass_filter_hz": "5000"})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "high_pass_filter_hz")
+
+	uploaded := suite.helper.CreateTestTranscriptionJob(suite.T(), "Preprocess on start")
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(uploaded).Update("status", models.StatusUploaded).Error)
+	w = suite.makeAuthenticatedRequest("POST", fmt.Sprintf("/api/v1/transcription/%s/start", uploaded.ID), map[string]interface{}{
+		"preprocessing": map[string]interface{}{"high_pass_filter_hz": -10},
+	}, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code, w.Body.String())
+}
+
+// Test that the word confidence threshold is stored and must be a probability
+func (suite *APIHandlerTestSuite) TestTranscriptionWordConfidenceThreshold() {
+	w := suite.submitTranscription(map[string]string{"word_confidence_threshold": "0.4", "mask_string": "***"})
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	var job models.TranscriptionJob
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), 0.4, job.Parameters.WordConfidenceThreshold)
+	assert.Equal(suite.T(), "***", job.Parameters.WordMask())
+
+	w = suite.submitTranscription(map[string]string{"word_confidence_threshold": "1.5"})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "word_confidence_threshold")
+}
+
+// Test that post-processors are stored in order and checked on submission
+func (suite *APIHandlerTestSuite) TestTranscriptionPostProcessors() {
+	postProcessors := `[{"name":"regex_replace","options":{"pattern":"\\bNASA\\b","replacement":"National Aeronautics and Space Administration"}},{"name":"word_blocklist","options":{"words":["jane"]}}]`
+	w := suite.submitTranscription(map[string]string{"post_processors": postProcessors})
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	var job models.TranscriptionJob
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	if assert.Len(suite.T(), job.Parameters.PostProcessors, 2) {
+		assert.Equal(suite.T(), "regex_replace", job.Parameters.PostProcessors[0].Name)
+		assert.Equal(suite.T(), "word_blocklist", job.Parameters.PostProcessors[1].Name)
+	}
+
+	for _, invalid := range []string{
+		`not json`,
+		`[{"name":"shout"}]`,
+		`[{"name":"regex_replace","options":{"pattern":"("}}]`,
+		`[{"name":"word_blocklist","options":{"word":["jane"]}}]`,
+	} {
+		w = suite.submitTranscription(map[string]string{"post_processors": invalid})
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, invalid)
+	}
+}
+
+// Test reading detected chapters and their markers in WebVTT exports
+func (suite *APIHandlerTestSuite) TestJobChapters() {
+	testJob := suite.helper.CreateTestTranscriptionJob(suite.T(), "Chapters")
+	chaptersURL := fmt.Sprintf("/api/v1/transcriptions/%s/chapters", testJob.ID)
+
+	w := suite.makeAuthenticatedRequest("GET", chaptersURL, nil, false)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code, "Pending jobs have no chapters yet")
+
+	transcript := `{"segments":[{"start":0,"end":2,"text":"Welcome."},{"start":6,"end":8,"text":"Next topic."}]}`
+	testJob.Status = models.StatusCompleted
+	testJob.Transcript = &transcript
+	testJob.Chapters = []models.Chapter{{Type: models.ChapterMarker, Start: 0}, {Type: models.ChapterMarker, Start: 6}}
+	assert.NoError(suite.T(), suite.helper.GetDB().Save(testJob).Error)
+
+	w = suite.makeAuthenticatedRequest("GET", chaptersURL, nil, false)
+	assert.Equal(suite.T(), 200, w.Code)
+	var response api.ChaptersResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), testJob.Chapters, response.Chapters)
+	assert.Contains(suite.T(), w.Body.String(), `"type":"chapter"`)
+
+	w = suite.makeAuthenticatedRequest("GET", fmt.Sprintf("/api/v1/transcription/%s/export?format=vtt", testJob.ID), nil, false)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "NOTE Chapter 1 (00:00:00.000)\n\n00:00:00.000 --> ")
+	assert.Contains(suite.T(), w.Body.String(), "NOTE Chapter 2 (00:00:06.000)\n\n00:00:06.000 --> ")
+
+	w = suite.makeAuthenticatedRequest("GET", "/api/v1/transcriptions/missing-job/chapters", nil, false)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+// Test answering questions about a transcript through a fake Ollama server
+func (suite *APIHandlerTestSuite) TestChatWithTranscript() {
+	var prompts [][]map[string]string
+	llmServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []map[string]string `json:"messages"`
+		}
+		assert.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&body))
+		prompts = append(prompts, body.Messages)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"March, see "},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"[1:00]."},"done":true}`)
+	}))
+	defer llmServer.Close()
+	llmConfig := &models.LLMConfig{Provider: "ollama", BaseURL: &llmServer.URL, IsActive: true}
+	assert.NoError(suite.T(), suite.helper.GetDB().Create(llmConfig).Error)
+	defer suite.helper.GetDB().Delete(llmConfig)
+
+	testJob := suite.helper.CreateTestTranscriptionJob(suite.T(), "Planning call")
+	chatURL := fmt.Sprintf("/api/v1/transcriptions/%s/chat", testJob.ID)
+	question := map[string]interface{}{
+		"model":    "llama3",
+		"messages": []map[string]string{{"role": "user", "content": "When is the launch?"}},
+	}
+
+	w := suite.makeAuthenticatedRequest("POST", chatURL, question, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code, "Pending jobs have no transcript to chat about")
+
+	transcript := `{"segments":[{"start":0,"end":30,"text":"Introductions all round."},{"start":60,"end":90,"text":"The launch moves to March."}]}`
+	testJob.Status = models.StatusCompleted
+	testJob.Transcript = &transcript
+	assert.NoError(suite.T(), suite.helper.GetDB().Save(testJob).Error)
+
+	w = suite.makeAuthenticatedRequest("POST", chatURL, question, true)
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	assert.Equal(suite.T(), "text/event-stream", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.Contains(suite.T(), body, "event:sources\ndata:[{\"start\":60,\"end\":90}]\n")
+	assert.Contains(suite.T(), body, "event:message\ndata:March, see \n")
+	assert.Contains(suite.T(), body, "event:message\ndata:[1:00].\n")
+	assert.Contains(suite.T(), body, "event:done\n")
+
+	// The prompt cites the relevant passage by its timestamp
+	assert.Len(suite.T(), prompts, 1)
+	assert.Equal(suite.T(), "system", prompts[0][0]["role"])
+	assert.Contains(suite.T(), prompts[0][0]["content"], "[1:00]\nThe launch moves to March.")
+	assert.NotContains(suite.T(), prompts[0][0]["content"], "Introductions")
+	assert.Equal(suite.T(), "When is the launch?", prompts[0][1]["content"])
+
+	// The conversation is stored with the sources of the answer
+	var session models.ChatSession
+	assert.NoError(suite.T(), suite.helper.GetDB().Where("transcription_id = ?", testJob.ID).First(&session).Error)
+	assert.Equal(suite.T(), "When is the launch?", session.Title)
+	assert.Equal(suite.T(), 2, session.MessageCount)
+	w = suite.makeAuthenticatedRequest("GET", "/api/v1/chat/sessions/"+session.ID, nil, false)
+	assert.Equal(suite.T(), 200, w.Code)
+	var stored api.ChatSessionWithMessages
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &stored))
+	if assert.Len(suite.T(), stored.Messages, 2) {
+		assert.Equal(suite.T(), "March, see [1:00].", stored.Messages[1].Content)
+		assert.Equal(suite.T(), []models.ChatSource{{Start: 60, End: 90}}, stored.Messages[1].Sources)
+	}
+
+	// Continuing the conversation sends the earlier turns
+	followUp := map[string]interface{}{
+		"session_id": session.ID,
+		"messages":   []map[string]string{{"role": "user", "content": "And the introductions?"}},
+	}
+	w = suite.makeAuthenticatedRequest("POST", chatURL, followUp, true)
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	if assert.Len(suite.T(), prompts, 2) && assert.Len(suite.T(), prompts[1], 4) {
+		assert.Contains(suite.T(), prompts[1][0]["content"], "Introductions all round.")
+		assert.Equal(suite.T(), "March, see [1:00].", prompts[1][2]["content"])
+	}
+
+	// Sessions of other jobs cannot be continued
+	otherJob := suite.helper.CreateTestTranscriptionJob(suite.T(), "Other call")
+	otherJob.Status = models.StatusCompleted
+	otherJob.Transcript = &transcript
+	assert.NoError(suite.T(), suite.helper.GetDB().Save(otherJob).Error)
+	w = suite.makeAuthenticatedRequest("POST", fmt.Sprintf("/api/v1/transcriptions/%s/chat", otherJob.ID), followUp, true)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = suite.makeAuthenticatedRequest("POST", chatURL, map[string]interface{}{
+		"model":    "llama3",
+		"messages": []map[string]string{{"role": "assistant", "content": "Hello"}},
+	}, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code, "The last message must be a question")
+}
+
+// Test the word diff between two transcripts of the caller's
+func (suite *APIHandlerTestSuite) TestDiffTranscripts() {
+	owned := func(title, transcript string, userID uint) *models.TranscriptionJob {
+		job := suite.helper.CreateTestTranscriptionJob(suite.T(), title)
+		assert.NoError(suite.T(), suite.helper.GetDB().Model(job).Updates(map[string]interface{}{
+			"status": models.StatusCompleted, "transcript": transcript, "user_id": userID,
+		}).Error)
+		return job
+	}
+	userID := suite.helper.TestUser.ID
+	first := owned("Small model", `{"segments":[{"text":"we moved to the coast"},{"text":"in may"}]}`, userID)
+	second := owned("Large model", `{"segments":[{"text":"we moved to the cost in"},{"text":"early may"}]}`, userID)
+
+	w := suite.makeAuthenticatedRequest("GET", fmt.Sprintf("/api/v1/transcriptions/%s/diff/%s", first.ID, second.ID), nil, false)
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	var result struct {
+		Added        []string `json:"added"`
+		Removed      []string `json:"removed"`
+		UnchangedPct float64  `json:"unchanged_pct"`
+	}
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(suite.T(), []string{"cost", "early"}, result.Added)
+	assert.Equal(suite.T(), []string{"coast"}, result.Removed)
+	assert.Equal(suite.T(), 0.8, result.UnchangedPct)
+
+	// Someone else's transcript cannot be compared
+	others := owned("Someone else's", `{"segments":[{"text":"private"}]}`, userID+100)
+	w = suite.makeAuthenticatedRequest("GET", fmt.Sprintf("/api/v1/transcriptions/%s/diff/%s", first.ID, others.ID), nil, false)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	pending := suite.helper.CreateTestTranscriptionJob(suite.T(), "Pending")
+	w = suite.makeAuthenticatedRequest("GET", fmt.Sprintf("/api/v1/transcriptions/%s/diff/%s", first.ID, pending.ID), nil, false)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	w = suite.makeAuthenticatedRequest("GET", fmt.Sprintf("/api/v1/transcriptions/%s/diff/missing", first.ID), nil, false)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+// Test setting job notes and how they appear in job details and listings
+func (suite *APIHandlerTestSuite) TestJobNotes() {
+	testJob := suite.helper.CreateTestTranscriptionJob(suite.T(), "Notes Job")
+	jobURL := fmt.Sprintf("/api/v1/transcription/%s", testJob.ID)
+	patchURL := fmt.Sprintf("/api/v1/transcriptions/%s", testJob.ID)
+
+	getJob := func() map[string]interface{} {
+		w := suite.makeAuthenticatedRequest("GET", jobURL, nil, false)
+		assert.Equal(suite.T(), 200, w.Code)
+		var job map[string]interface{}
+		assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+		return job
+	}
+	listedJob := func() map[string]interface{} {
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/transcription/list?limit=1000&q=Notes%20Job", nil, false)
+		assert.Equal(suite.T(), 200, w.Code)
+		var response struct {
+			Jobs []map[string]interface{} `json:"jobs"`
+		}
+		assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+		for _, job := range response.Jobs {
+			if job["id"] == testJob.ID {
+				return job
+			}
+		}
+		suite.T().Fatalf("Job %s not listed", testJob.ID)
+		return nil
+	}
+
+	// A new job has no notes
+	job := getJob()
+	assert.NotContains(suite.T(), job, "notes")
+	assert.Equal(suite.T(), false, job["has_notes"])
+	assert.Equal(suite.T(), false, listedJob()["has_notes"])
+
+	w := suite.makeAuthenticatedRequest("PATCH", patchURL, map[string]string{"notes": "Interview with Jane, 2024-03-15"}, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), "Interview with Jane, 2024-03-15", job["notes"])
+	assert.Equal(suite.T(), true, job["has_notes"])
+
+	job = getJob()
+	assert.Equal(suite.T(), "Interview with Jane, 2024-03-15", job["notes"])
+	listed := listedJob()
+	assert.Equal(suite.T(), true, listed["has_notes"])
+	assert.NotContains(suite.T(), listed, "notes", "Listings should not load full notes")
+
+	// The limit counts characters, not bytes
+	w = suite.makeAuthenticatedRequest("PATCH", patchURL, map[string]string{"notes": strings.Repeat("é", 4096)}, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	w = suite.makeAuthenticatedRequest("PATCH", patchURL, map[string]string{"notes": strings.Repeat("a", 4097)}, true)
+	assert.Equal(suite.T(), 400, w.Code)
+	assert.Equal(suite.T(), strings.Repeat("é", 4096), getJob()["notes"], "Rejected notes should not be saved")
 
+	w = suite.makeAuthenticatedRequest("PATCH", patchURL, map[string]interface{}{}, true)
 	assert.Equal(suite.T(), 400, w.Code)
+	w = suite.makeAuthenticatedRequest("PATCH", "/api/v1/transcriptions/missing-job", map[string]string{"notes": "memo"}, true)
+	assert.Equal(suite.T(), 404, w.Code)
 
-	// Test missing required fields
-	emptyLogin := map[string]string{}
-	w = suite.makeAuthenticatedRequest("POST", "/api/v1/auth/login", emptyLogin, false)
-	assert.True(suite.T(), w.Code >= 400, "Should return error for empty login data")
+	// Empty notes clear them
+	w = suite.makeAuthenticatedRequest("PATCH", patchURL, map[string]string{"notes": ""}, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	job = getJob()
+	assert.NotContains(suite.T(), job, "notes")
+	assert.Equal(suite.T(), false, job["has_notes"])
 }
 
-// Test logout
-func (suite *APIHandlerTestSuite) TestLogout() {
-	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("POST", "/api/v1/auth/logout", nil)
-	suite.router.ServeHTTP(w, req)
+// Test job titles, descriptions and metadata at submission, through PATCH and in the job list
+func (suite *APIHandlerTestSuite) TestJobMetadata() {
+	// Submitted jobs are titled after their file unless given a title
+	w := suite.submitTranscription(map[string]string{
+		"description": "Quarterly planning call",
+		"metadata":    `{"client":"Acme","project":"Q3"}`,
+	})
+	assert.Equal(suite.T(), 200, w.Code)
+	var job models.TranscriptionJob
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	if assert.NotNil(suite.T(), job.Title) {
+		assert.Equal(suite.T(), "test.mp3", *job.Title)
+	}
+	if assert.NotNil(suite.T(), job.Description) {
+		assert.Equal(suite.T(), "Quarterly planning call", *job.Description)
+	}
+	assert.Equal(suite.T(), map[string]string{"client": "Acme", "project": "Q3"}, job.Metadata)
+
+	w = suite.submitTranscription(map[string]string{"title": strings.Repeat("t", 256)})
+	assert.Equal(suite.T(), 422, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"field":"title"`)
+	w = suite.submitTranscription(map[string]string{"metadata": "[1]"})
+	assert.Equal(suite.T(), 400, w.Code)
+
+	patchURL := fmt.Sprintf("/api/v1/transcriptions/%s", job.ID)
+	w = suite.makeAuthenticatedRequest("PATCH", patchURL, map[string]interface{}{
+		"title":    "Planning: Q3 Kickoff",
+		"metadata": map[string]string{"client": "Acme"},
+	}, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	var updated models.TranscriptionJob
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(suite.T(), "Planning: Q3 Kickoff", *updated.Title)
+	assert.Equal(suite.T(), "Quarterly planning call", *updated.Description, "Fields left out should be kept")
+	assert.Equal(suite.T(), map[string]string{"client": "Acme"}, updated.Metadata)
+
+	// Over-long values are rejected naming the field, and nothing is saved
+	for field, value := range map[string]interface{}{
+		"title":       strings.Repeat("é", 256),
+		"description": strings.Repeat("d", 5001),
+		"metadata":    map[string]string{"notes": strings.Repeat("v", 1025)},
+	} {
+		w = suite.makeAuthenticatedRequest("PATCH", patchURL, map[string]interface{}{field: value, "notes": "memo"}, true)
+		assert.Equal(suite.T(), 422, w.Code, field)
+		var response struct {
+			Details map[string]string `json:"details"`
+		}
+		assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Contains(suite.T(), response.Details["field"], field)
+	}
+	tooMany := map[string]string{}
+	for i := 0; i <= models.MaxJobMetadataEntries; i++ {
+		tooMany[fmt.Sprintf("key%d", i)] = "value"
+	}
+	w = suite.makeAuthenticatedRequest("PATCH", patchURL, map[string]interface{}{"metadata": tooMany}, true)
+	assert.Equal(suite.T(), 422, w.Code)
+
+	var stored models.TranscriptionJob
+	assert.NoError(suite.T(), suite.helper.GetDB().First(&stored, "id = ?", job.ID).Error)
+	assert.Equal(suite.T(), "Planning: Q3 Kickoff", *stored.Title)
+	assert.Nil(suite.T(), stored.Notes)
+
+	// The list filters on a title substring, and q also searches descriptions
+	assert.Contains(suite.T(), suite.listJobIDs("title=q3%20kick", true), job.ID)
+	assert.NotContains(suite.T(), suite.listJobIDs("title=planning%20call", true), job.ID)
+	assert.Contains(suite.T(), suite.listJobIDs("q=planning%20call", true), job.ID)
+
+	// Empty values clear the description and metadata
+	w = suite.makeAuthenticatedRequest("PATCH", patchURL, map[string]interface{}{"description": "", "metadata": map[string]string{}}, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	var cleared models.TranscriptionJob
+	assert.NoError(suite.T(), suite.helper.GetDB().First(&cleared, "id = ?", job.ID).Error)
+	assert.Nil(suite.T(), cleared.Description)
+	assert.Empty(suite.T(), cleared.Metadata)
+}
+
+// Test that jobs can wait for others, and that cycles are rejected
+func (suite *APIHandlerTestSuite) TestJobDependencies() {
+	submitJob := func(fields map[string]string) models.TranscriptionJob {
+		w := suite.submitTranscription(fields)
+		assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+		var job models.TranscriptionJob
+		assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+		return job
+	}
+
+	// A linear chain: first <- second <- third
+	first := submitJob(nil)
+	second := submitJob(map[string]string{"depends_on": first.ID})
+	third := submitJob(map[string]string{"depends_on": fmt.Sprintf(`["%s"]`, second.ID)})
+	assert.Equal(suite.T(), []string{first.ID}, second.DependsOn)
+	assert.Equal(suite.T(), []string{second.ID}, third.DependsOn)
+	var stored models.TranscriptionJob
+	assert.NoError(suite.T(), suite.helper.GetDB().Where("id = ?", third.ID).First(&stored).Error)
+	assert.Equal(suite.T(), []string{second.ID}, stored.DependsOn)
+
+	w := suite.submitTranscription(map[string]string{"depends_on": "missing-job"})
+	assert.Equal(suite.T(), 422, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"field":"depends_on"`)
+	w = suite.submitTranscription(map[string]string{"depends_on": `["unterminated"`})
+	assert.Equal(suite.T(), 400, w.Code)
+
+	// Making the head of the chain wait for its tail closes a cycle
+	w = suite.makeAuthenticatedRequest("PATCH", "/api/v1/transcriptions/"+first.ID, map[string]interface{}{"depends_on": []string{third.ID}}, true)
+	assert.Equal(suite.T(), 422, w.Code)
+	var errBody struct {
+		Details struct {
+			Field string   `json:"field"`
+			Cycle []string `json:"cycle"`
+		} `json:"details"`
+	}
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &errBody))
+	assert.Equal(suite.T(), "depends_on", errBody.Details.Field)
+	assert.Equal(suite.T(), []string{first.ID, third.ID, second.ID, first.ID}, errBody.Details.Cycle)
+
+	w = suite.makeAuthenticatedRequest("PATCH", "/api/v1/transcriptions/"+first.ID, map[string]interface{}{"depends_on": []string{first.ID}}, true)
+	assert.Equal(suite.T(), 422, w.Code, "A job cannot wait for itself")
+
+	// Dependencies can be cleared, but not changed once a job has started
+	w = suite.makeAuthenticatedRequest("PATCH", "/api/v1/transcriptions/"+third.ID, map[string]interface{}{"depends_on": []string{}}, true)
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	assert.NoError(suite.T(), suite.helper.GetDB().Where("id = ?", third.ID).First(&stored).Error)
+	assert.Empty(suite.T(), stored.DependsOn)
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(&models.TranscriptionJob{}).Where("id = ?", third.ID).Update("status", models.StatusProcessing).Error)
+	w = suite.makeAuthenticatedRequest("PATCH", "/api/v1/transcriptions/"+third.ID, map[string]interface{}{"depends_on": []string{first.ID}}, true)
+	assert.Equal(suite.T(), 409, w.Code)
+}
+
+// Test tagging jobs and filtering the job list by tag
+func (suite *APIHandlerTestSuite) TestJobTags() {
+	acme := suite.helper.CreateTestTranscriptionJob(suite.T(), "Tagged Acme")
+	globex := suite.helper.CreateTestTranscriptionJob(suite.T(), "Tagged Globex")
+
+	addTag := func(jobID, key, value string) *httptest.ResponseRecorder {
+		return suite.makeAuthenticatedRequest("POST", fmt.Sprintf("/api/v1/transcriptions/%s/tags", jobID),
+			map[string]string{"key": key, "value": value}, true)
+	}
+	assert.Equal(suite.T(), 200, addTag(acme.ID, "client", "acme-tags").Code)
+	assert.Equal(suite.T(), 200, addTag(acme.ID, "project", "launch-tags").Code)
+	assert.Equal(suite.T(), 200, addTag(globex.ID, "client", "globex-tags").Code)
+	w := addTag(globex.ID, "project", "launch-tags")
+	assert.Equal(suite.T(), 200, w.Code)
+	var tags api.TagsResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &tags))
+	assert.Equal(suite.T(), map[string][]string{"client": {"globex-tags"}, "project": {"launch-tags"}}, tags.Tags)
+
+	assert.Equal(suite.T(), 400, addTag(acme.ID, "client:name", "acme").Code)
+	assert.Equal(suite.T(), 404, addTag("missing-job", "client", "acme").Code)
+
+	listIDs := func(query string) []string {
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/transcription/list?limit=100&"+query, nil, false)
+		assert.Equal(suite.T(), 200, w.Code)
+		var response struct {
+			Jobs []models.TranscriptionJob `json:"jobs"`
+		}
+		assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+		var ids []string
+		for _, job := range response.Jobs {
+			ids = append(ids, job.ID)
+			if job.ID == acme.ID {
+				assert.Equal(suite.T(), []string{"acme-tags"}, job.Tags["client"])
+			}
+		}
+		return ids
+	}
+	assert.Equal(suite.T(), []string{acme.ID}, listIDs("tag=client:acme-tags"))
+	assert.ElementsMatch(suite.T(), []string{acme.ID, globex.ID}, listIDs("tag=project:launch-tags"))
+	assert.Equal(suite.T(), []string{globex.ID}, listIDs("tag=project:launch-tags&tag=client:globex-tags"))
+	assert.Empty(suite.T(), listIDs("tag=client:initech-tags"))
+
+	w = suite.makeAuthenticatedRequest("GET", "/api/v1/transcription/list?tag=:acme", nil, false)
+	assert.Equal(suite.T(), 400, w.Code)
+
+	w = suite.makeAuthenticatedRequest("DELETE", fmt.Sprintf("/api/v1/transcriptions/%s/tags/client", acme.ID), nil, false)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.Empty(suite.T(), listIDs("tag=client:acme-tags"))
+	w = suite.makeAuthenticatedRequest("DELETE", fmt.Sprintf("/api/v1/transcriptions/%s/tags/client", acme.ID), nil, false)
+	assert.Equal(suite.T(), 404, w.Code)
+
+	w = suite.makeAuthenticatedRequest("GET", fmt.Sprintf("/api/v1/transcription/%s", acme.ID), nil, false)
+	var job models.TranscriptionJob
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), map[string][]string{"project": {"launch-tags"}}, job.Tags)
+}
+
+// listJobIDs returns the IDs of the jobs the job list returns for query
+func (suite *APIHandlerTestSuite) listJobIDs(query string, useJWT bool) []string {
+	w := suite.makeAuthenticatedRequest("GET", "/api/v1/transcription/list?limit=100&"+query, nil, useJWT)
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	var response struct {
+		Jobs []models.TranscriptionJob `json:"jobs"`
+	}
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	var ids []string
+	for _, job := range response.Jobs {
+		ids = append(ids, job.ID)
+	}
+	return ids
+}
+
+// Test folder CRUD and filtering the job list by folder, engine and date
+func (suite *APIHandlerTestSuite) TestJobFolders() {
+	first := suite.helper.CreateTestTranscriptionJob(suite.T(), "Filed Interview")
+	second := suite.helper.CreateTestTranscriptionJob(suite.T(), "Filed Podcast")
+
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/folders", map[string]string{"name": "  Interviews "}, false)
+	assert.Equal(suite.T(), 201, w.Code)
+	var folder models.JobFolder
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &folder))
+	assert.Equal(suite.T(), "Interviews", folder.Name)
 
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/folders", map[string]string{"name": "Interviews"}, false)
+	assert.Equal(suite.T(), 409, w.Code)
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/folders", map[string]string{"name": " "}, false)
+	assert.Equal(suite.T(), 400, w.Code)
+
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/transcriptions/bulk/move",
+		map[string]interface{}{"ids": []string{first.ID, first.ID}, "folder_id": folder.ID}, false)
+	assert.Equal(suite.T(), 200, w.Code)
+	var bulk api.BulkResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &bulk))
+	assert.Equal(suite.T(), 1, bulk.Updated)
+
+	assert.Equal(suite.T(), []string{first.ID}, suite.listJobIDs(fmt.Sprintf("folder_id=%d", folder.ID), false))
+	unfiled := suite.listJobIDs("folder_id=0", false)
+	assert.Contains(suite.T(), unfiled, second.ID)
+	assert.NotContains(suite.T(), unfiled, first.ID)
+
+	w = suite.makeAuthenticatedRequest("GET", "/api/v1/folders", nil, false)
+	assert.Equal(suite.T(), 200, w.Code)
+	var folders struct {
+		Folders []api.FolderResponse `json:"folders"`
+	}
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &folders))
+	if assert.Len(suite.T(), folders.Folders, 1) {
+		assert.Equal(suite.T(), int64(1), folders.Folders[0].JobCount)
+	}
+
+	w = suite.makeAuthenticatedRequest("PATCH", fmt.Sprintf("/api/v1/folders/%d", folder.ID), map[string]string{"name": "Guests"}, false)
+	assert.Equal(suite.T(), 200, w.Code)
+	w = suite.makeAuthenticatedRequest("PATCH", "/api/v1/folders/999", map[string]string{"name": "Nowhere"}, false)
+	assert.Equal(suite.T(), 404, w.Code)
+
+	// Engine and creation date narrow the list
+	db := suite.helper.GetDB()
+	assert.NoError(suite.T(), db.Model(first).Updates(map[string]interface{}{
+		"engine": models.EngineOpenAI, "created_at": time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC),
+	}).Error)
+	assert.Equal(suite.T(), []string{first.ID}, suite.listJobIDs("engine=openai", false))
+	assert.Equal(suite.T(), []string{first.ID}, suite.listJobIDs("created_after=2024-03-01&created_before=2024-04-01", false))
+	assert.Empty(suite.T(), suite.listJobIDs("created_after=2024-03-11T00:00:00Z&created_before=2024-04-01", false))
+	w = suite.makeAuthenticatedRequest("GET", "/api/v1/transcription/list?created_after=2024-04-01&created_before=2024-03-01", nil, false)
+	assert.Equal(suite.T(), 400, w.Code)
+	w = suite.makeAuthenticatedRequest("GET", "/api/v1/transcription/list?created_after=yesterday", nil, false)
+	assert.Equal(suite.T(), 400, w.Code)
+
+	// Deleting the folder keeps its jobs, unfiled
+	w = suite.makeAuthenticatedRequest("DELETE", fmt.Sprintf("/api/v1/folders/%d", folder.ID), nil, false)
+	assert.Equal(suite.T(), 204, w.Code)
+	var job models.TranscriptionJob
+	assert.NoError(suite.T(), db.First(&job, "id = ?", first.ID).Error)
+	assert.Nil(suite.T(), job.FolderID)
+}
+
+// Test that bulk operations apply to every listed job or to none
+func (suite *APIHandlerTestSuite) TestBulkJobOperations() {
+	first := suite.helper.CreateTestTranscriptionJob(suite.T(), "Bulk One")
+	second := suite.helper.CreateTestTranscriptionJob(suite.T(), "Bulk Two")
+	ids := []string{first.ID, second.ID}
+
+	bulkTag := func(ids []string, add, remove []string) *httptest.ResponseRecorder {
+		return suite.makeAuthenticatedRequest("POST", "/api/v1/transcriptions/bulk/tags",
+			map[string]interface{}{"ids": ids, "add": add, "remove": remove}, false)
+	}
+	w := bulkTag(ids, []string{"client:bulk-acme", "project:bulk-launch"}, nil)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.ElementsMatch(suite.T(), ids, suite.listJobIDs("tag=client:bulk-acme", false))
+
+	// A missing job fails the whole request
+	w = bulkTag([]string{first.ID, "missing-job"}, []string{"client:bulk-initech"}, nil)
+	assert.Equal(suite.T(), 404, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "missing-job")
+	assert.Empty(suite.T(), suite.listJobIDs("tag=client:bulk-initech", false))
+
+	assert.Equal(suite.T(), 400, bulkTag(ids, []string{"client"}, nil).Code)
+	assert.Equal(suite.T(), 400, bulkTag(ids, nil, nil).Code)
+	assert.Equal(suite.T(), 400, bulkTag(nil, []string{"client:bulk-acme"}, nil).Code)
+
+	w = bulkTag([]string{second.ID}, []string{"client:bulk-globex"}, []string{"client"})
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.Equal(suite.T(), []string{first.ID}, suite.listJobIDs("tag=client:bulk-acme", false))
+	assert.Equal(suite.T(), []string{second.ID}, suite.listJobIDs("tag=client:bulk-globex", false))
+
+	w = suite.makeAuthenticatedRequest("GET", "/api/v1/tags", nil, false)
+	assert.Equal(suite.T(), 200, w.Code)
+	var tags struct {
+		Tags []database.TagCount `json:"tags"`
+	}
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &tags))
+	assert.Contains(suite.T(), tags.Tags, database.TagCount{Key: "project", Value: "bulk-launch", JobCount: 2})
+
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/transcriptions/bulk/move",
+		map[string]interface{}{"ids": ids, "folder_id": 999}, false)
+	assert.Equal(suite.T(), 404, w.Code)
+
+	// A processing job keeps the others from being deleted
+	db := suite.helper.GetDB()
+	assert.NoError(suite.T(), db.Model(second).Update("status", models.StatusProcessing).Error)
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/transcriptions/bulk/delete", map[string]interface{}{"ids": ids}, false)
+	assert.Equal(suite.T(), 400, w.Code)
+	var count int64
+	db.Model(&models.TranscriptionJob{}).Where("id IN ?", ids).Count(&count)
+	assert.Equal(suite.T(), int64(2), count)
+
+	assert.NoError(suite.T(), db.Model(second).Update("status", models.StatusCompleted).Error)
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/transcriptions/bulk/delete", map[string]interface{}{"ids": ids}, false)
+	assert.Equal(suite.T(), 200, w.Code)
+	db.Model(&models.TranscriptionJob{}).Where("id IN ?", ids).Count(&count)
+	assert.Equal(suite.T(), int64(0), count)
+
+	// Deleted jobs keep their tags for a restore, but leave the tag list
+	db.Model(&models.Tag{}).Where("job_id IN ?", ids).Count(&count)
+	assert.Equal(suite.T(), int64(4), count)
+	w = suite.makeAuthenticatedRequest("GET", "/api/v1/tags", nil, false)
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &tags))
+	assert.NotContains(suite.T(), tags.Tags, database.TagCount{Key: "project", Value: "bulk-launch", JobCount: 2})
+}
+
+// Test cursor paging, sorting and field selection of the job list
+func (suite *APIHandlerTestSuite) TestListJobsPaging() {
+	db := suite.helper.GetDB()
+	base := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	durations := []interface{}{120.0, nil, 30.0, 90.0, 60.0}
+	titles := []string{"Paged delta", "Paged alpha", "Paged echo", "Paged bravo", "Paged charlie"}
+	jobs := make([]*models.TranscriptionJob, len(titles))
+	for i, title := range titles {
+		jobs[i] = suite.helper.CreateTestTranscriptionJob(suite.T(), title)
+		assert.NoError(suite.T(), db.Model(jobs[i]).Updates(map[string]interface{}{
+			"created_at": base.Add(time.Duration(i) * time.Hour), "duration_seconds": durations[i], "transcript": `{"text":"long"}`,
+		}).Error)
+	}
+
+	type listResponse struct {
+		Jobs       []map[string]json.RawMessage `json:"jobs"`
+		Pagination struct {
+			Total      int64  `json:"total"`
+			Remaining  int64  `json:"remaining"`
+			NextCursor string `json:"next_cursor"`
+		} `json:"pagination"`
+	}
+	list := func(query string) (listResponse, *httptest.ResponseRecorder) {
+		var response listResponse
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/transcription/list?q=Paged&"+query, nil, false)
+		if w.Code == 200 {
+			assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+		}
+		return response, w
+	}
+	// walk follows the cursors of a listing, returning the titles in order
+	walk := func(query string) []string {
+		var titles []string
+		cursor := ""
+		for pages := 0; pages < 10; pages++ {
+			response, w := list(query + "&limit=2&cursor=" + cursor)
+			if !assert.Equal(suite.T(), 200, w.Code, w.Body.String()) {
+				return titles
+			}
+			assert.Equal(suite.T(), int64(5), response.Pagination.Total)
+			for _, job := range response.Jobs {
+				var title string
+				json.Unmarshal(job["title"], &title)
+				titles = append(titles, title)
+			}
+			assert.Equal(suite.T(), int64(5-len(titles)), response.Pagination.Remaining)
+			if response.Pagination.NextCursor == "" {
+				return titles
+			}
+			cursor = response.Pagination.NextCursor
+		}
+		return titles
+	}
+
+	assert.Equal(suite.T(), []string{"Paged charlie", "Paged bravo", "Paged echo", "Paged alpha", "Paged delta"}, walk("fields=title"))
+	assert.Equal(suite.T(), []string{"Paged alpha", "Paged bravo", "Paged charlie", "Paged delta", "Paged echo"}, walk("fields=title&sort=title&order=asc"))
+	assert.Equal(suite.T(), []string{"Paged alpha", "Paged echo", "Paged charlie", "Paged bravo", "Paged delta"}, walk("fields=title&sort=duration&order=asc"))
+
+	// Only the requested fields are returned, and never the transcript by default
+	response, w := list("fields=title,status,progress,duration_seconds,created_at&limit=1")
+	assert.Equal(suite.T(), 200, w.Code)
+	if assert.Len(suite.T(), response.Jobs, 1) {
+		var keys []string
+		for key := range response.Jobs[0] {
+			keys = append(keys, key)
+		}
+		assert.ElementsMatch(suite.T(), []string{"id", "title", "status", "progress", "duration_seconds", "created_at"}, keys)
+	}
+	response, _ = list("limit=5")
+	for _, job := range response.Jobs {
+		assert.NotContains(suite.T(), job, "transcript")
+		assert.Contains(suite.T(), job, "audio_path")
+	}
+	response, w = list("limit=5&full=true")
+	assert.Equal(suite.T(), "true", w.Header().Get("Deprecation"))
+	for _, job := range response.Jobs {
+		assert.Contains(suite.T(), job, "transcript")
+	}
+	response, _ = list("limit=1&fields=transcript")
+	if assert.Len(suite.T(), response.Jobs, 1) {
+		assert.Contains(suite.T(), response.Jobs[0], "transcript")
+	}
+
+	_, w = list("fields=title,secret")
+	assert.Equal(suite.T(), 400, w.Code)
+	_, w = list("sort=size")
+	assert.Equal(suite.T(), 400, w.Code)
+	_, w = list("order=sideways")
+	assert.Equal(suite.T(), 400, w.Code)
+	_, w = list("cursor=not-a-cursor")
+	assert.Equal(suite.T(), 400, w.Code)
+	response, _ = list("limit=2")
+	_, w = list("sort=title&cursor=" + response.Pagination.NextCursor)
+	assert.Equal(suite.T(), 400, w.Code)
+}
+
+// Test that archived jobs are left out of the job list unless asked for
+func (suite *APIHandlerTestSuite) TestArchiveJobs() {
+	first := suite.helper.CreateTestTranscriptionJob(suite.T(), "Archive One")
+	second := suite.helper.CreateTestTranscriptionJob(suite.T(), "Archive Two")
+	third := suite.helper.CreateTestTranscriptionJob(suite.T(), "Archive Three")
+	listed := func(query string) []string { return suite.listJobIDs("q=Archive&"+query, false) }
+
+	w := suite.makeAuthenticatedRequest("POST", fmt.Sprintf("/api/v1/transcriptions/%s/archive", first.ID), nil, false)
 	assert.Equal(suite.T(), 200, w.Code)
+	var job models.TranscriptionJob
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	if !assert.NotNil(suite.T(), job.ArchivedAt) {
+		return
+	}
+	archivedAt := *job.ArchivedAt
+
+	assert.ElementsMatch(suite.T(), []string{second.ID, third.ID}, listed(""))
+	assert.ElementsMatch(suite.T(), []string{first.ID, second.ID, third.ID}, listed("include_archived=true"))
+	w = suite.makeAuthenticatedRequest("GET", "/api/v1/transcription/list?include_archived=maybe", nil, false)
+	assert.Equal(suite.T(), 400, w.Code)
+
+	// Archived jobs are still available on their own
+	w = suite.makeAuthenticatedRequest("GET", fmt.Sprintf("/api/v1/transcription/%s", first.ID), nil, false)
+	assert.Equal(suite.T(), 200, w.Code)
+
+	// Archiving again keeps the first archive time
+	w = suite.makeAuthenticatedRequest("POST", fmt.Sprintf("/api/v1/transcriptions/%s/archive", first.ID), nil, false)
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.True(suite.T(), archivedAt.Equal(*job.ArchivedAt))
+
+	// Bulk archiving is all or nothing
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/transcriptions/archive",
+		map[string]interface{}{"job_ids": []string{second.ID, "missing-job"}}, false)
+	assert.Equal(suite.T(), 404, w.Code)
+	assert.ElementsMatch(suite.T(), []string{second.ID, third.ID}, listed(""))
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/transcriptions/archive", map[string]interface{}{"job_ids": []string{}}, false)
+	assert.Equal(suite.T(), 400, w.Code)
+
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/transcriptions/archive",
+		map[string]interface{}{"job_ids": []string{first.ID, second.ID}}, false)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.Equal(suite.T(), []string{third.ID}, listed(""))
+
+	w = suite.makeAuthenticatedRequest("POST", fmt.Sprintf("/api/v1/transcriptions/%s/unarchive", second.ID), nil, false)
+	assert.Equal(suite.T(), 200, w.Code)
+	var unarchived models.TranscriptionJob
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &unarchived))
+	assert.Nil(suite.T(), unarchived.ArchivedAt)
+	assert.ElementsMatch(suite.T(), []string{second.ID, third.ID}, listed(""))
+
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/transcriptions/missing-job/archive", nil, false)
+	assert.Equal(suite.T(), 404, w.Code)
+}
+
+// Test saving job list filters and applying them to the list
+func (suite *APIHandlerTestSuite) TestSavedFilters() {
+	acme := suite.helper.CreateTestTranscriptionJob(suite.T(), "Saved Acme")
+	other := suite.helper.CreateTestTranscriptionJob(suite.T(), "Saved Other")
+	assert.NoError(suite.T(), suite.helper.GetDB().Create(&models.Tag{JobID: acme.ID, Key: "client", Value: "saved-acme"}).Error)
+
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/users/me/saved-filters", map[string]interface{}{
+		"name": "Acme", "filter": map[string]interface{}{"tags": []string{"client:saved-acme"}},
+	}, true)
+	assert.Equal(suite.T(), 201, w.Code)
+	var saved models.SavedFilter
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &saved))
+
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/users/me/saved-filters", map[string]interface{}{"name": "Acme"}, true)
+	assert.Equal(suite.T(), 409, w.Code)
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/users/me/saved-filters", map[string]interface{}{
+		"name": "Broken", "filter": map[string]interface{}{"tags": []string{":acme"}},
+	}, true)
+	assert.Equal(suite.T(), 400, w.Code)
+
+	assert.Equal(suite.T(), []string{acme.ID}, suite.listJobIDs(fmt.Sprintf("saved_filter=%d", saved.ID), true))
+	// Parameters narrow the saved filter further
+	assert.Empty(suite.T(), suite.listJobIDs(fmt.Sprintf("saved_filter=%d&status=completed", saved.ID), true))
+	w = suite.makeAuthenticatedRequest("GET", "/api/v1/transcription/list?saved_filter=999", nil, true)
+	assert.Equal(suite.T(), 404, w.Code)
+
+	w = suite.makeAuthenticatedRequest("PUT", fmt.Sprintf("/api/v1/users/me/saved-filters/%d", saved.ID), map[string]interface{}{
+		"name": "Everyone", "filter": map[string]interface{}{"q": "Saved Other"},
+	}, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.Equal(suite.T(), []string{other.ID}, suite.listJobIDs(fmt.Sprintf("saved_filter=%d", saved.ID), true))
+
+	w = suite.makeAuthenticatedRequest("GET", "/api/v1/users/me/saved-filters", nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	var list struct {
+		Filters []models.SavedFilter `json:"filters"`
+	}
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &list))
+	if assert.Len(suite.T(), list.Filters, 1) {
+		assert.Equal(suite.T(), "Everyone", list.Filters[0].Name)
+	}
+
+	w = suite.makeAuthenticatedRequest("DELETE", fmt.Sprintf("/api/v1/users/me/saved-filters/%d", saved.ID), nil, true)
+	assert.Equal(suite.T(), 204, w.Code)
+	w = suite.makeAuthenticatedRequest("DELETE", fmt.Sprintf("/api/v1/users/me/saved-filters/%d", saved.ID), nil, true)
+	assert.Equal(suite.T(), 404, w.Code)
+}
+
+// Test that a job resumed from saved chunks reports where it picked up
+func (suite *APIHandlerTestSuite) TestGetJobResumedFromPartial() {
+	testJob := suite.helper.CreateTestTranscriptionJob(suite.T(), "Resumed")
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(testJob).Updates(map[string]interface{}{
+		"has_partial":          true,
+		"resumed_from_seconds": 3600.5,
+	}).Error)
+
+	w := suite.makeAuthenticatedRequest("GET", fmt.Sprintf("/api/v1/transcription/%s", testJob.ID), nil, false)
+	assert.Equal(suite.T(), 200, w.Code)
+	var job models.TranscriptionJob
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.True(suite.T(), job.HasPartial)
+	assert.Equal(suite.T(), 3600.5, *job.ResumedFromSeconds)
+}
+
+// Test that the detection confidence and warning are returned with the job
+func (suite *APIHandlerTestSuite) TestGetJobLanguageConfidence() {
+	testJob := suite.helper.CreateTestTranscriptionJob(suite.T(), "Uncertain Language")
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(testJob).Updates(map[string]interface{}{
+		"detected_language":   "nb",
+		"language_confidence": 0.41,
+		"warning":             "Detected language nb with low confidence (0.41)",
+	}).Error)
+
+	w := suite.makeAuthenticatedRequest("GET", fmt.Sprintf("/api/v1/transcription/%s", testJob.ID), nil, false)
+	assert.Equal(suite.T(), 200, w.Code)
+	var job models.TranscriptionJob
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), "nb", *job.DetectedLanguage)
+	assert.Equal(suite.T(), 0.41, *job.LanguageConfidence)
+	assert.NotNil(suite.T(), job.Warning)
+}
+
+func (suite *APIHandlerTestSuite) TestTranscriptionSubmitDiarizationOptions() {
+	submit := func(fields map[string]string) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, err := writer.CreateFormFile("audio", "test.mp3")
+		assert.NoError(suite.T(), err)
+		_, err = part.Write(fakeMP3("dummy audio data"))
+		assert.NoError(suite.T(), err)
+		for k, v := range fields {
+			assert.NoError(suite.T(), writer.WriteField(k, v))
+		}
+		assert.NoError(suite.T(), writer.Close())
+
+		req, err := http.NewRequest("POST", "/api/v1/transcription/submit", body)
+		assert.NoError(suite.T(), err)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("X-API-Key", suite.helper.TestAPIKey)
+
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+
+	invalid := []map[string]string{
+		{"diarization": "true", "min_speakers": "3", "max_speakers": "2"},
+		{"diarization": "true", "min_speakers": "0"},
+		{"diarization": "true", "max_speakers": "-1"},
+		{"diarization": "true", "min_speakers": "two"},
+	}
+	for _, fields := range invalid {
+		w := submit(fields)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, "fields %v", fields)
+	}
+
+	w := submit(map[string]string{
+		"diarization":  "true",
+		"min_speakers": "2",
+		"max_speakers": "4",
+		"hf_token":     "hf_job_override",
+	})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response models.TranscriptionJob
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(suite.T(), response.Diarization)
+	if assert.NotNil(suite.T(), response.Parameters.MinSpeakers) && assert.NotNil(suite.T(), response.Parameters.MaxSpeakers) {
+		assert.Equal(suite.T(), 2, *response.Parameters.MinSpeakers)
+		assert.Equal(suite.T(), 4, *response.Parameters.MaxSpeakers)
+	}
+	if assert.NotNil(suite.T(), response.Parameters.HfToken) {
+		assert.Equal(suite.T(), "hf_job_override", *response.Parameters.HfToken)
+	}
+}
+
+// Test renaming, undoing renames and merging diarized speakers
+func (suite *APIHandlerTestSuite) TestRenameAndMergeSpeakers() {
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Diarized Job")
+	transcript := `{"text":"a b c","segments":[` +
+		`{"start":0,"end":2,"text":"a","speaker":"SPEAKER_00"},` +
+		`{"start":2,"end":3,"text":"b","speaker":"SPEAKER_01"},` +
+		`{"start":3,"end":5,"text":"c","speaker":"SPEAKER_02"}],` +
+		`"word_segments":[{"start":2,"end":3,"word":"b","score":0.9,"speaker":"SPEAKER_01"}],` +
+		`"speakers":[{"id":"SPEAKER_00","label":"Speaker 1","total_speaking_time":2},` +
+		`{"id":"SPEAKER_01","label":"Speaker 2","total_speaking_time":1},` +
+		`{"id":"SPEAKER_02","label":"Speaker 3","total_speaking_time":2}]}`
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(job).Updates(map[string]interface{}{
+		"status":      models.StatusCompleted,
+		"diarization": true,
+		"transcript":  transcript,
+	}).Error)
+	path := fmt.Sprintf("/api/v1/transcription/%s/speakers", job.ID)
+
+	// Rename two speakers
+	w := suite.makeAuthenticatedRequest("PATCH", path, map[string]interface{}{
+		"speakers": map[string]string{"SPEAKER_00": "Alice", "SPEAKER_01": "Bob"},
+	}, false)
+	assert.Equal(suite.T(), 200, w.Code)
+	var edit api.SpeakerEditResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &edit))
+	assert.Equal(suite.T(), 1, edit.TranscriptRevision)
+	assert.Len(suite.T(), edit.Mappings, 2)
+
+	// Unknown speakers are rejected
+	w = suite.makeAuthenticatedRequest("PATCH", path, map[string]interface{}{
+		"speakers": map[string]string{"SPEAKER_09": "Nobody"},
+	}, false)
+	assert.Equal(suite.T(), 400, w.Code)
+
+	// An empty name undoes the rename
+	w = suite.makeAuthenticatedRequest("PATCH", path, map[string]interface{}{
+		"speakers": map[string]string{"SPEAKER_00": ""},
+	}, false)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &edit))
+	assert.Equal(suite.T(), 2, edit.TranscriptRevision)
+	if assert.Len(suite.T(), edit.Mappings, 1) {
+		assert.Equal(suite.T(), "SPEAKER_01", edit.Mappings[0].OriginalSpeaker)
+		assert.Equal(suite.T(), "Bob", edit.Mappings[0].CustomName)
+	}
+
+	// Merging into itself is rejected
+	w = suite.makeAuthenticatedRequest("POST", path+"/merge", map[string]string{"source": "SPEAKER_00", "target": "SPEAKER_00"}, false)
+	assert.Equal(suite.T(), 400, w.Code)
+
+	// Merge SPEAKER_01 into SPEAKER_02
+	w = suite.makeAuthenticatedRequest("POST", path+"/merge", map[string]string{"source": "SPEAKER_01", "target": "SPEAKER_02"}, false)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &edit))
+	assert.Equal(suite.T(), 3, edit.TranscriptRevision)
+	assert.Empty(suite.T(), edit.Mappings)
+
+	w = suite.makeAuthenticatedRequest("GET", fmt.Sprintf("/api/v1/transcription/%s/transcript?granularity=word", job.ID), nil, false)
+	assert.Equal(suite.T(), 200, w.Code)
+	var response struct {
+		TranscriptRevision int `json:"transcript_revision"`
+		Transcript         struct {
+			Segments []struct {
+				Speaker         string `json:"speaker"`
+				OriginalSpeaker string `json:"original_speaker"`
+				Words           []struct {
+					Speaker string `json:"speaker"`
+				} `json:"words"`
+			} `json:"segments"`
+			Speakers []struct {
+				ID                string  `json:"id"`
+				TotalSpeakingTime float64 `json:"total_speaking_time"`
+			} `json:"speakers"`
+		} `json:"transcript"`
+	}
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), 3, response.TranscriptRevision)
+	if assert.Len(suite.T(), response.Transcript.Segments, 3) {
+		assert.Equal(suite.T(), "SPEAKER_02", response.Transcript.Segments[1].Speaker)
+		assert.Equal(suite.T(), "SPEAKER_01", response.Transcript.Segments[1].OriginalSpeaker)
+		assert.Empty(suite.T(), response.Transcript.Segments[2].OriginalSpeaker)
+	}
+	if assert.Len(suite.T(), response.Transcript.Segments, 3) && assert.Len(suite.T(), response.Transcript.Segments[1].Words, 1) {
+		assert.Equal(suite.T(), "SPEAKER_02", response.Transcript.Segments[1].Words[0].Speaker)
+	}
+	if assert.Len(suite.T(), response.Transcript.Speakers, 2) {
+		assert.Equal(suite.T(), "SPEAKER_02", response.Transcript.Speakers[1].ID)
+		assert.Equal(suite.T(), 3.0, response.Transcript.Speakers[1].TotalSpeakingTime)
+	}
+
+	// The merged-away speaker no longer exists
+	w = suite.makeAuthenticatedRequest("POST", path+"/merge", map[string]string{"source": "SPEAKER_01", "target": "SPEAKER_00"}, false)
+	assert.Equal(suite.T(), 400, w.Code)
+}
+
+// Test error responses for non-existent resources
+func (suite *APIHandlerTestSuite) TestNotFoundErrors() {
+	endpoints := []string{
+		"/api/v1/transcription/nonexistent-job",
+		"/api/v1/transcription/nonexistent-job/status",
+		"/api/v1/transcription/nonexistent-job/transcript",
+		"/api/v1/profiles/nonexistent-profile",
+		"/api/v1/notes/nonexistent-note",
+	}
+
+	for _, endpoint := range endpoints {
+		w := suite.makeAuthenticatedRequest("GET", endpoint, nil, false)
+		assert.Equal(suite.T(), 404, w.Code, "Endpoint %s should return 404", endpoint)
+	}
+}
+
+// Test invalid request data
+func (suite *APIHandlerTestSuite) TestInvalidRequestData() {
+	// Test invalid JSON for login
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/auth/login", strings.NewReader("invalid json"))
+	req.Header.Set("Content-Type", "application/json")
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), 400, w.Code)
+
+	// Test missing required fields
+	emptyLogin := map[string]string{}
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/auth/login", emptyLogin, false)
+	assert.True(suite.T(), w.Code >= 400, "Should return error for empty login data")
+}
+
+// Test logout
+func (suite *APIHandlerTestSuite) TestLogout() {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/auth/logout", nil)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), 200, w.Code)
+}
+
+// loginForRefreshCookie logs in the test user and returns the refresh token cookie
+func (suite *APIHandlerTestSuite) loginForRefreshCookie() *http.Cookie {
+	loginData := map[string]string{
+		"username": suite.helper.TestUser.Username,
+		"password": "testpassword123",
+	}
+	jsonData, _ := json.Marshal(loginData)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), 200, w.Code)
+
+	return findCookie(w.Result().Cookies(), "scriberr_refresh_token")
+}
+
+// refreshWithCookie calls the refresh endpoint with the given cookie
+func (suite *APIHandlerTestSuite) refreshWithCookie(cookie *http.Cookie) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/auth/refresh", nil)
+	req.AddCookie(cookie)
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func findCookie(cookies []*http.Cookie, name string) *http.Cookie {
+	for _, cookie := range cookies {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+// Test refresh token rotation
+func (suite *APIHandlerTestSuite) TestRefreshTokenRotation() {
+	original := suite.loginForRefreshCookie()
+	if !assert.NotNil(suite.T(), original) {
+		return
+	}
+
+	// First refresh succeeds and rotates the cookie
+	w := suite.refreshWithCookie(original)
+	assert.Equal(suite.T(), 200, w.Code)
+
+	var response api.RefreshTokenResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.NotEmpty(suite.T(), response.Token)
+
+	claims, err := suite.helper.AuthService.ValidateToken(response.Token)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.helper.TestUser.ID, claims.UserID)
+
+	rotated := findCookie(w.Result().Cookies(), "scriberr_refresh_token")
+	if !assert.NotNil(suite.T(), rotated) {
+		return
+	}
+	assert.NotEqual(suite.T(), original.Value, rotated.Value)
+	assert.InDelta(suite.T(), suite.helper.Config.RefreshTokenTTL.Seconds(), float64(rotated.MaxAge), 1)
+
+	// Reusing the old refresh token is rejected
+	w = suite.refreshWithCookie(original)
+	assert.Equal(suite.T(), 401, w.Code)
+
+	// The rotated token is still valid
+	w = suite.refreshWithCookie(rotated)
+	assert.Equal(suite.T(), 200, w.Code)
+}
+
+// Test refresh token revocation on logout
+func (suite *APIHandlerTestSuite) TestRefreshTokenRevokedOnLogout() {
+	cookie := suite.loginForRefreshCookie()
+	if !assert.NotNil(suite.T(), cookie) {
+		return
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/auth/logout", nil)
+	req.AddCookie(cookie)
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), 200, w.Code)
+
+	w = suite.refreshWithCookie(cookie)
+	assert.Equal(suite.T(), 401, w.Code)
+}
+
+// Test that a token revoked by logout is rejected afterwards
+func (suite *APIHandlerTestSuite) TestLogoutRevokesAccessToken() {
+	token, err := suite.helper.AuthService.GenerateToken(suite.helper.TestUser)
+	assert.NoError(suite.T(), err)
+
+	doRequest := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(suite.T(), 200, doRequest("GET", "/api/v1/api-keys/").Code)
+	assert.Equal(suite.T(), 200, doRequest("GET", "/api/v1/transcription/list").Code)
+
+	assert.Equal(suite.T(), 200, doRequest("POST", "/api/v1/auth/logout").Code)
+
+	assert.Equal(suite.T(), 401, doRequest("GET", "/api/v1/api-keys/").Code)
+	assert.Equal(suite.T(), 401, doRequest("GET", "/api/v1/transcription/list").Code)
+
+	// Other tokens for the same user remain valid
+	w := suite.makeAuthenticatedRequest("GET", "/api/v1/api-keys/", nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+}
+
+// Test the self-service profile endpoints, including the password change flow
+func (suite *APIHandlerTestSuite) TestCurrentUserProfile() {
+	hashed, err := auth.HashPassword("original-pass")
+	assert.NoError(suite.T(), err)
+	member := &models.User{Username: "profile-member", Password: hashed, Role: models.RoleUser}
+	assert.NoError(suite.T(), suite.helper.GetDB().Create(member).Error)
+	token, err := suite.helper.AuthService.GenerateToken(member)
+	assert.NoError(suite.T(), err)
+
+	doRequest := func(method string, body interface{}) *httptest.ResponseRecorder {
+		var reader io.Reader
+		if body != nil {
+			jsonBody, _ := json.Marshal(body)
+			reader = bytes.NewBuffer(jsonBody)
+		}
+		req, _ := http.NewRequest(method, "/api/v1/users/me", reader)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := doRequest("GET", nil)
+	assert.Equal(suite.T(), 200, w.Code)
+	var profile api.UserProfileResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &profile))
+	assert.Equal(suite.T(), member.ID, profile.ID)
+	assert.Equal(suite.T(), "profile-member", profile.Username)
+	assert.Equal(suite.T(), models.RoleUser, profile.Role)
+	assert.Empty(suite.T(), profile.DisplayName)
+
+	// Display name updates
+	assert.Equal(suite.T(), 400, doRequest("PATCH", map[string]string{"display_name": "   "}).Code)
+	w = doRequest("PATCH", map[string]string{"display_name": "Profile Member"})
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &profile))
+	assert.Equal(suite.T(), "Profile Member", profile.DisplayName)
+
+	// Password changes are validated before anything is stored
+	assert.Equal(suite.T(), 400, doRequest("PATCH", map[string]string{"current_password": "original-pass", "new_password": "short"}).Code)
+	assert.Equal(suite.T(), 400, doRequest("PATCH", map[string]string{"new_password": "long-enough-pass"}).Code)
+	assert.Equal(suite.T(), 400, doRequest("PATCH", map[string]string{"current_password": "wrong-pass", "new_password": "long-enough-pass"}).Code)
+
+	var stored models.User
+	assert.NoError(suite.T(), suite.helper.GetDB().First(&stored, member.ID).Error)
+	assert.True(suite.T(), auth.CheckPassword("original-pass", stored.Password))
+
+	w = doRequest("PATCH", map[string]string{"current_password": "original-pass", "new_password": "long-enough-pass"})
+	assert.Equal(suite.T(), 200, w.Code)
+
+	assert.NoError(suite.T(), suite.helper.GetDB().First(&stored, member.ID).Error)
+	assert.True(suite.T(), auth.CheckPassword("long-enough-pass", stored.Password))
+	assert.False(suite.T(), auth.CheckPassword("original-pass", stored.Password))
+	assert.Equal(suite.T(), "Profile Member", stored.DisplayName)
+
+	// Login works with the new password only
+	login := func(password string) int {
+		body, _ := json.Marshal(map[string]string{"username": "profile-member", "password": password})
+		req, _ := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(suite.T(), 401, login("original-pass"))
+	assert.Equal(suite.T(), 200, login("long-enough-pass"))
+}
+
+// Test that admin-only routes reject non-admin users
+func (suite *APIHandlerTestSuite) TestRequireAdminRole() {
+	member := &models.User{ID: suite.helper.TestUser.ID, Username: suite.helper.TestUser.Username, Role: models.RoleUser}
+	token, err := suite.helper.AuthService.GenerateToken(member)
+	assert.NoError(suite.T(), err)
+
+	doRequest := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(suite.T(), 403, doRequest("/api/v1/admin/queue/stats").Code)
+	assert.Equal(suite.T(), 200, doRequest("/api/v1/api-keys/").Code)
+	assert.Equal(suite.T(), 200, doRequest("/api/v1/transcription/list").Code)
+
+	w := suite.makeAuthenticatedRequest("GET", "/api/v1/admin/queue/stats", nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+}
+
+// Test segment edits, revision history and revert
+func (suite *APIHandlerTestSuite) TestTranscriptSegmentEditing() {
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Editable Job")
+	transcript := `{"text":"helo world. second part. third","segments":[` +
+		`{"start":0,"end":2,"text":"helo world.","speaker":"SPEAKER_00"},` +
+		`{"start":2,"end":4,"text":"second part.","speaker":"SPEAKER_00"},` +
+		`{"start":4,"end":6,"text":"third","speaker":"SPEAKER_01"}],"language":"en"}`
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(job).Updates(map[string]interface{}{
+		"status":     models.StatusCompleted,
+		"transcript": transcript,
+	}).Error)
+	base := fmt.Sprintf("/api/v1/transcription/%s/transcript", job.ID)
+
+	type segment struct {
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		Text  string  `json:"text"`
+	}
+	var edit struct {
+		TranscriptRevision int `json:"transcript_revision"`
+		Transcript         struct {
+			Text     string    `json:"text"`
+			Language string    `json:"language"`
+			Segments []segment `json:"segments"`
+		} `json:"transcript"`
+	}
+	decode := func(w *httptest.ResponseRecorder) {
+		assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+		assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &edit))
+	}
+
+	// Correct a recognition error
+	decode(suite.makeAuthenticatedRequest("PUT", base+"/segments/0", map[string]interface{}{"text": " hello world. ", "revision": 0}, true))
+	assert.Equal(suite.T(), 1, edit.TranscriptRevision)
+	assert.Equal(suite.T(), "hello world.", edit.Transcript.Segments[0].Text)
+	assert.Equal(suite.T(), "hello world. second part. third", edit.Transcript.Text)
+	assert.Equal(suite.T(), "en", edit.Transcript.Language)
+
+	// Invalid edits are rejected without creating revisions
+	assert.Equal(suite.T(), 409, suite.makeAuthenticatedRequest("PUT", base+"/segments/0", map[string]interface{}{"text": "stale", "revision": 0}, true).Code)
+	assert.Equal(suite.T(), 400, suite.makeAuthenticatedRequest("PUT", base+"/segments/0", map[string]interface{}{"text": "  "}, true).Code)
+	assert.Equal(suite.T(), 400, suite.makeAuthenticatedRequest("PUT", base+"/segments/1", map[string]interface{}{"start": 1.5}, true).Code)
+	assert.Equal(suite.T(), 400, suite.makeAuthenticatedRequest("PUT", base+"/segments/1", map[string]interface{}{"end": 4.5}, true).Code)
+	assert.Equal(suite.T(), 400, suite.makeAuthenticatedRequest("PUT", base+"/segments/1", map[string]interface{}{"start": 3, "end": 3}, true).Code)
+	assert.Equal(suite.T(), 404, suite.makeAuthenticatedRequest("PUT", base+"/segments/7", map[string]interface{}{"text": "x"}, true).Code)
+
+	// Adjust timing within the gap to the neighbours
+	decode(suite.makeAuthenticatedRequest("PUT", base+"/segments/1", map[string]interface{}{"start": 2.5, "end": 3.5}, true))
+	assert.Equal(suite.T(), 2, edit.TranscriptRevision)
+	assert.Equal(suite.T(), segment{Start: 2.5, End: 3.5, Text: "second part."}, edit.Transcript.Segments[1])
+
+	// Split and merge
+	assert.Equal(suite.T(), 400, suite.makeAuthenticatedRequest("POST", base+"/segments/0/split", map[string]interface{}{"offset": 6, "time": 2.5}, true).Code)
+	decode(suite.makeAuthenticatedRequest("POST", base+"/segments/0/split", map[string]interface{}{"offset": 6, "time": 1}, true))
+	assert.Equal(suite.T(), 3, edit.TranscriptRevision)
+	if assert.Len(suite.T(), edit.Transcript.Segments, 4) {
+		assert.Equal(suite.T(), segment{Start: 0, End: 1, Text: "hello"}, edit.Transcript.Segments[0])
+		assert.Equal(suite.T(), segment{Start: 1, End: 2, Text: "world."}, edit.Transcript.Segments[1])
+	}
+	assert.Equal(suite.T(), 400, suite.makeAuthenticatedRequest("POST", base+"/segments/2/merge", nil, true).Code)
+	assert.Equal(suite.T(), 400, suite.makeAuthenticatedRequest("POST", base+"/segments/3/merge", nil, true).Code)
+	decode(suite.makeAuthenticatedRequest("POST", base+"/segments/1/merge", nil, true))
+	assert.Equal(suite.T(), 4, edit.TranscriptRevision)
+	if assert.Len(suite.T(), edit.Transcript.Segments, 3) {
+		assert.Equal(suite.T(), segment{Start: 1, End: 3.5, Text: "world. second part."}, edit.Transcript.Segments[1])
+	}
+
+	// History records every edit after the machine transcript
+	w := suite.makeAuthenticatedRequest("GET", base+"/revisions", nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	var history struct {
+		TranscriptRevision int                         `json:"transcript_revision"`
+		Revisions          []models.TranscriptRevision `json:"revisions"`
+	}
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &history))
+	assert.Equal(suite.T(), 4, history.TranscriptRevision)
+	if assert.Len(suite.T(), history.Revisions, 5) {
+		assert.Equal(suite.T(), models.TranscriptOpMerge, history.Revisions[0].Operation)
+		assert.Equal(suite.T(), suite.helper.TestUser.Username, history.Revisions[0].Username)
+		assert.Equal(suite.T(), models.TranscriptOpOriginal, history.Revisions[4].Operation)
+		assert.Equal(suite.T(), 0, history.Revisions[4].Revision)
+	}
+
+	// Historical versions can be fetched and restored
+	w = suite.makeAuthenticatedRequest("GET", base+"?revision=0", nil, true)
+	decode(w)
+	assert.Equal(suite.T(), 0, edit.TranscriptRevision)
+	assert.Equal(suite.T(), "helo world.", edit.Transcript.Segments[0].Text)
+	assert.Equal(suite.T(), 404, suite.makeAuthenticatedRequest("GET", base+"?revision=9", nil, true).Code)
+	assert.Equal(suite.T(), 400, suite.makeAuthenticatedRequest("POST", base+"/revert/4", nil, true).Code)
+
+	decode(suite.makeAuthenticatedRequest("POST", base+"/revert/1", nil, true))
+	assert.Equal(suite.T(), 5, edit.TranscriptRevision)
+	assert.Equal(suite.T(), segment{Start: 0, End: 2, Text: "hello world."}, edit.Transcript.Segments[0])
+	assert.Equal(suite.T(), segment{Start: 2, End: 4, Text: "second part."}, edit.Transcript.Segments[1])
+
+	decode(suite.makeAuthenticatedRequest("GET", base, nil, true))
+	assert.Equal(suite.T(), 5, edit.TranscriptRevision)
+	assert.Len(suite.T(), edit.Transcript.Segments, 3)
+}
+
+// Test subtitle and text exports
+func (suite *APIHandlerTestSuite) TestExportTranscript() {
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Team Meeting.mp3")
+	transcript := `{"text":"hello there. general kenobi","segments":[` +
+		`{"start":0,"end":1.5,"text":"hello there.","speaker":"SPEAKER_00"},` +
+		`{"start":1.5,"end":3,"text":"general kenobi","speaker":"SPEAKER_01"}],` +
+		`"word_segments":[{"start":0.1,"end":0.5,"word":"hello"},{"start":0.6,"end":1.2,"word":"there."},` +
+		`{"start":1.6,"end":2.1,"word":"general"},{"start":2.2,"end":2.9,"word":"kenobi"}],` +
+		`"speakers":[{"id":"SPEAKER_00","label":"Speaker 1"},{"id":"SPEAKER_01","label":"Speaker 2"}]}`
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(job).Updates(map[string]interface{}{
+		"status":      models.StatusCompleted,
+		"diarization": true,
+		"transcript":  transcript,
+	}).Error)
+	assert.NoError(suite.T(), suite.helper.GetDB().Create(&models.SpeakerMapping{
+		TranscriptionJobID: job.ID, OriginalSpeaker: "SPEAKER_01", CustomName: "Obi-Wan",
+	}).Error)
+	base := fmt.Sprintf("/api/v1/transcription/%s/export", job.ID)
+
+	w := suite.makeAuthenticatedRequest("GET", base+"?format=srt", nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.Equal(suite.T(), "application/x-subrip; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), `attachment; filename="Team Meeting.srt"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(suite.T(), "1\n00:00:00,000 --> 00:00:01,500\nSpeaker 1: hello there.\n\n"+
+		"2\n00:00:01,500 --> 00:00:03,000\nObi-Wan: general kenobi\n", w.Body.String())
+
+	w = suite.makeAuthenticatedRequest("GET", base+"?format=vtt&timing=word&speakers=false&max_chars=7&max_lines=1", nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.Equal(suite.T(), "WEBVTT\n\n00:00:00.100 --> 00:00:00.500\nhello\n\n00:00:00.600 --> 00:00:01.200\nthere.\n\n"+
+		"00:00:01.600 --> 00:00:02.100\ngeneral\n\n00:00:02.200 --> 00:00:02.900\nkenobi\n", w.Body.String())
+
+	w = suite.makeAuthenticatedRequest("GET", base+"?format=txt&bom=true", nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.Equal(suite.T(), "\ufeffSpeaker 1: hello there.\n\nObi-Wan: general kenobi\n", w.Body.String())
+
+	w = suite.makeAuthenticatedRequest("GET", base+"?format=json", nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"speaker": "Obi-Wan"`)
+
+	assert.Equal(suite.T(), 400, suite.makeAuthenticatedRequest("GET", base+"?format=docx", nil, true).Code)
+	assert.Equal(suite.T(), 400, suite.makeAuthenticatedRequest("GET", base+"?format=srt&max_chars=-1", nil, true).Code)
+	assert.Equal(suite.T(), 400, suite.makeAuthenticatedRequest("GET", base+"?format=srt&timing=char", nil, true).Code)
+
+	// Cached downloads are revalidated against the transcript revision
+	w = suite.makeAuthenticatedRequest("GET", base+"?format=srt", nil, true)
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(suite.T(), etag)
+	req, _ := http.NewRequest("GET", base+"?format=srt", nil)
+	req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), 304, w.Code)
+
+	edit := suite.makeAuthenticatedRequest("PUT", fmt.Sprintf("/api/v1/transcription/%s/transcript/segments/0", job.ID), map[string]string{"text": "hello there!"}, true)
+	assert.Equal(suite.T(), 200, edit.Code)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.NotEqual(suite.T(), etag, w.Header().Get("ETag"))
+	assert.Contains(suite.T(), w.Body.String(), "Speaker 1: hello there!")
+}
+
+// Test that exports of translations say so and name the source language
+func (suite *APIHandlerTestSuite) TestExportTranslation() {
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Podcast.mp3")
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(job).Updates(map[string]interface{}{
+		"status":            models.StatusCompleted,
+		"task":              models.TaskTranslate,
+		"detected_language": "nb",
+		"transcript":        `{"text":"good morning","segments":[{"start":0,"end":1,"text":"good morning"}]}`,
+	}).Error)
+	base := fmt.Sprintf("/api/v1/transcription/%s/export", job.ID)
+
+	w := suite.makeAuthenticatedRequest("GET", base+"?format=txt", nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.Equal(suite.T(), "[Translated to English from nb]\n\ngood morning\n", w.Body.String())
+
+	w = suite.makeAuthenticatedRequest("GET", base+"?format=vtt", nil, true)
+	assert.Contains(suite.T(), w.Body.String(), "NOTE Translated to English from nb")
+}
+
+// archiveMembers reads a zip response into its member names and contents
+func (suite *APIHandlerTestSuite) archiveMembers(w *httptest.ResponseRecorder) ([]string, map[string]string) {
+	reader, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if !assert.NoError(suite.T(), err) {
+		return nil, nil
+	}
+	var names []string
+	contents := make(map[string]string)
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+		rc, err := file.Open()
+		assert.NoError(suite.T(), err)
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		contents[file.Name] = string(data)
+	}
+	return names, contents
+}
+
+func (suite *APIHandlerTestSuite) TestJobArchive() {
+	completeJob := func(title string) *models.TranscriptionJob {
+		job := suite.helper.CreateTestTranscriptionJob(suite.T(), title)
+		job.AudioPath = filepath.Join(suite.T().TempDir(), "source.MP3")
+		assert.NoError(suite.T(), os.WriteFile(job.AudioPath, []byte("fake audio bytes"), 0644))
+		assert.NoError(suite.T(), suite.helper.GetDB().Model(job).Updates(map[string]interface{}{
+			"status":     models.StatusCompleted,
+			"audio_path": job.AudioPath,
+			"transcript": `{"text":"hello there","segments":[{"start":0,"end":1.5,"text":"hello there"}]}`,
+		}).Error)
+		return job
+	}
+	job := completeJob("Board Meeting.mp3")
+	assert.NoError(suite.T(), suite.helper.GetDB().Create(&models.Summary{
+		TranscriptionID: job.ID, Model: "test", Content: "# Summary\nThey said hello.",
+	}).Error)
+	base := fmt.Sprintf("/api/v1/jobs/%s/archive", job.ID)
+
+	w := suite.makeAuthenticatedRequest("GET", base, nil, true)
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	assert.Equal(suite.T(), "application/zip", w.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), `attachment; filename="Board Meeting.zip"`, w.Header().Get("Content-Disposition"))
+	names, contents := suite.archiveMembers(w)
+	assert.Equal(suite.T(), []string{"transcript.json", "transcript.srt", "transcript.vtt", "transcript.txt", "summary.md"}, names)
+	assert.Equal(suite.T(), "1\n00:00:00,000 --> 00:00:01,500\nhello there\n", contents["transcript.srt"])
+	assert.Equal(suite.T(), "# Summary\nThey said hello.", contents["summary.md"])
+
+	w = suite.makeAuthenticatedRequest("GET", base+"?include=audio", nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	names, contents = suite.archiveMembers(w)
+	assert.Contains(suite.T(), names, "audio.mp3")
+	assert.Equal(suite.T(), "fake audio bytes", contents["audio.mp3"])
+
+	assert.Equal(suite.T(), 400, suite.makeAuthenticatedRequest("GET", base+"?include=video", nil, true).Code)
+	pending := suite.helper.CreateTestTranscriptionJob(suite.T(), "Pending")
+	assert.Equal(suite.T(), 400, suite.makeAuthenticatedRequest("GET", "/api/v1/jobs/"+pending.ID+"/archive", nil, true).Code)
+	assert.Equal(suite.T(), 404, suite.makeAuthenticatedRequest("GET", "/api/v1/jobs/missing/archive", nil, true).Code)
+
+	// Bulk downloads put each job in a folder, numbering repeated titles
+	other := completeJob("Board Meeting.wav")
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/jobs/archive?include=audio", map[string]interface{}{"ids": []string{job.ID, other.ID}}, true)
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	assert.Equal(suite.T(), "attachment; filename=transcripts.zip", w.Header().Get("Content-Disposition"))
+	names, _ = suite.archiveMembers(w)
+	assert.Equal(suite.T(), []string{
+		"Board Meeting/transcript.json", "Board Meeting/transcript.srt", "Board Meeting/transcript.vtt", "Board Meeting/transcript.txt",
+		"Board Meeting/summary.md", "Board Meeting/audio.mp3",
+		"Board Meeting (2)/transcript.json", "Board Meeting (2)/transcript.srt", "Board Meeting (2)/transcript.vtt", "Board Meeting (2)/transcript.txt",
+		"Board Meeting (2)/audio.mp3",
+	}, names)
+
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/jobs/archive", map[string]interface{}{"ids": []string{job.ID, pending.ID}}, true)
+	assert.Equal(suite.T(), 400, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), pending.ID)
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/jobs/archive", map[string]interface{}{"ids": []string{job.ID, "missing"}}, true)
+	assert.Equal(suite.T(), 404, w.Code)
+}
+
+// Test that statistics aggregate the job stats in a date range
+func (suite *APIHandlerTestSuite) TestStats() {
+	day := time.Date(2020, 1, 1, 9, 0, 0, 0, time.UTC)
+	for _, stat := range []models.JobStat{
+		{Status: models.StatusCompleted, Model: "small", Device: "cpu", AudioSeconds: 1800, WallSeconds: 900, FinishedAt: day},
+		{Status: models.StatusCompleted, Model: "small", Device: "cpu", AudioSeconds: 3600, WallSeconds: 3600, FinishedAt: day.Add(time.Hour)},
+		{Status: models.StatusFailed, Model: "small", Device: "cpu", AudioSeconds: 600, WallSeconds: 30, FinishedAt: day.Add(2 * time.Hour)},
+		{Status: models.StatusCompleted, Model: "large", Device: "cuda", AudioSeconds: 1800, WallSeconds: 360, FinishedAt: day.Add(24 * time.Hour)},
+		{Status: models.StatusCompleted, Model: "large", Device: "cuda", AudioSeconds: 60, WallSeconds: 60, FinishedAt: day.Add(72 * time.Hour)},
+	} {
+		assert.NoError(suite.T(), suite.helper.GetDB().Create(&stat).Error)
+	}
+
+	w := suite.makeAuthenticatedRequest("GET", "/api/stats?from=2020-01-01&to=2020-01-03", nil, true)
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	var stats api.StatsResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(suite.T(), database.JobStatTotals{Completed: 3, Failed: 1, FailureRate: 0.25, AudioHours: 2}, stats.Totals)
+	assert.Equal(suite.T(), []database.DailyJobStats{
+		{Day: "2020-01-01", Completed: 2, Failed: 1, AudioHours: 1.5},
+		{Day: "2020-01-02", Completed: 1, AudioHours: 0.5},
+	}, stats.JobsPerDay)
+	assert.Equal(suite.T(), []database.RealtimeFactor{
+		{Model: "large", Device: "cuda", Jobs: 1, RealtimeFactor: 0.2},
+		{Model: "small", Device: "cpu", Jobs: 2, RealtimeFactor: 0.75},
+	}, stats.RealtimeFactors)
+
+	w = suite.makeAuthenticatedRequest("GET", "/api/v1/stats?from=2030-01-01", nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Empty(suite.T(), stats.JobsPerDay)
+	assert.Zero(suite.T(), stats.Totals.FailureRate, "No runs means no failures")
+
+	for _, query := range []string{"from=yesterday", "to=2020-13-01", "from=2020-01-03&to=2020-01-01"} {
+		assert.Equal(suite.T(), 400, suite.makeAuthenticatedRequest("GET", "/api/stats?"+query, nil, true).Code, query)
+	}
+
+	// The same numbers back the metrics gauges
+	w = suite.makeAuthenticatedRequest("GET", "/metrics", nil, false)
+	assert.Equal(suite.T(), 200, w.Code)
+	for _, gauge := range []string{
+		`scriberr_jobs_finished{status="failed"} `,
+		`scriberr_realtime_factor{model="small",device="cpu"} 0.75`,
+		"scriberr_audio_processed_hours ",
+		"scriberr_job_failure_rate ",
+		"scriberr_queue_waiting_jobs ",
+		"scriberr_queue_oldest_wait_seconds ",
+	} {
+		assert.Contains(suite.T(), w.Body.String(), gauge)
+	}
+}
+
+// Test segment and word granularity of transcript responses and word-timed VTT
+func (suite *APIHandlerTestSuite) TestTranscriptWordGranularity() {
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Word Timing Job")
+	transcript := `{"text":"hello there unaligned","segments":[` +
+		`{"start":0,"end":2,"text":"hello there","speaker":"SPEAKER_00","words":[` +
+		`{"start":0.1,"end":0.5,"word":"hello","score":0.9},{"start":0.6,"end":1.2,"word":"there"}]},` +
+		`{"start":2,"end":4,"text":"unaligned","speaker":"SPEAKER_01","words":null}]}`
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(job).Updates(map[string]interface{}{
+		"status":     models.StatusCompleted,
+		"transcript": transcript,
+	}).Error)
+	base := fmt.Sprintf("/api/v1/transcription/%s/transcript", job.ID)
+
+	type wordResponse struct {
+		Transcript struct {
+			Segments []struct {
+				Words *[]struct {
+					Start   float64 `json:"start"`
+					Word    string  `json:"word"`
+					Speaker string  `json:"speaker"`
+				} `json:"words"`
+			} `json:"segments"`
+			WordSegments interface{} `json:"word_segments"`
+		} `json:"transcript"`
+	}
+
+	// Segment granularity is the default and carries no word timings
+	w := suite.makeAuthenticatedRequest("GET", base, nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.NotContains(suite.T(), w.Body.String(), `"words"`)
+
+	w = suite.makeAuthenticatedRequest("GET", base+"?granularity=word", nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	var response wordResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	if assert.Len(suite.T(), response.Transcript.Segments, 2) && assert.NotNil(suite.T(), response.Transcript.Segments[0].Words) {
+		words := *response.Transcript.Segments[0].Words
+		if assert.Len(suite.T(), words, 2) {
+			assert.Equal(suite.T(), "there", words[1].Word)
+			assert.Equal(suite.T(), "SPEAKER_00", words[1].Speaker)
+		}
+		assert.Nil(suite.T(), response.Transcript.Segments[1].Words)
+	}
+
+	w = suite.makeAuthenticatedRequest("GET", base+"?granularity=letter", nil, true)
+	assert.Equal(suite.T(), 400, w.Code)
+
+	// Word-timed WebVTT tags each aligned word after the first
+	w = suite.makeAuthenticatedRequest("GET", fmt.Sprintf("/api/v1/transcription/%s/export?format=vtt&speakers=false&word_timestamps=true", job.ID), nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "00:00:00.100 --> 00:00:01.200\nhello <00:00:00.600>there\n")
+	w = suite.makeAuthenticatedRequest("GET", fmt.Sprintf("/api/v1/transcription/%s/export?format=vtt&word_timestamps=maybe", job.ID), nil, true)
+	assert.Equal(suite.T(), 400, w.Code)
+
+	// Transcripts that keep words at the top level are grouped by segment
+	legacy := `{"text":"a b","segments":[{"start":0,"end":1,"text":"a"},{"start":1,"end":2,"text":"b"}],` +
+		`"word_segments":[{"start":0.2,"end":0.8,"word":"a"},{"start":1.1,"end":1.9,"word":"b"}]}`
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(job).Update("transcript", legacy).Error)
+	w = suite.makeAuthenticatedRequest("GET", base+"?granularity=word", nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	response = wordResponse{}
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Nil(suite.T(), response.Transcript.WordSegments)
+	if assert.Len(suite.T(), response.Transcript.Segments, 2) && assert.NotNil(suite.T(), response.Transcript.Segments[1].Words) {
+		words := *response.Transcript.Segments[1].Words
+		if assert.Len(suite.T(), words, 1) {
+			assert.Equal(suite.T(), "b", words[0].Word)
+		}
+	}
+}
+
+// Test that transcription quotas are enforced, charged and reset
+// Test uploading and clearing the caller's pronunciation dictionary
+func (suite *APIHandlerTestSuite) TestPronunciationDictionary() {
+	lexicon.Dir = suite.T().TempDir()
+	defer func() { lexicon.Dir = "data/lexicons" }()
+	userID := suite.helper.TestUser.ID
+	dictionaryURL := "/api/v1/users/me/pronunciation-dictionary"
+
+	upload := func(csv string) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, err := writer.CreateFormFile("file", "dictionary.csv")
+		assert.NoError(suite.T(), err)
+		_, err = part.Write([]byte(csv))
+		assert.NoError(suite.T(), err)
+		assert.NoError(suite.T(), writer.Close())
+		req, _ := http.NewRequest("POST", dictionaryURL, body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+	storedWords := func() []string {
+		var entries []models.PronunciationEntry
+		assert.NoError(suite.T(), suite.helper.GetDB().Where("user_id = ?", userID).Order("id").Find(&entries).Error)
+		var words []string
+		for _, entry := range entries {
+			words = append(words, entry.Word)
+		}
+		return words
+	}
+
+	w := upload("word,phonetic\nXeljanz,ZEL-jans\nquinoa,KEEN-wah\n")
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	assert.JSONEq(suite.T(), `{"entries":2}`, w.Body.String())
+	assert.Equal(suite.T(), []string{"Xeljanz", "quinoa"}, storedWords())
+	words, err := lexicon.Words(userID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"Xeljanz", "quinoa"}, words)
+	assert.Contains(suite.T(), lexicon.Prompt("", words), "Xeljanz, quinoa")
+
+	// A new upload replaces the dictionary; an invalid one leaves it alone
+	w = upload("Nguyen,win\n")
+	assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+	assert.Equal(suite.T(), []string{"Nguyen"}, storedWords())
+
+	w = upload("Zoë,ZOH-ē\n")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "printable ASCII")
+	assert.Equal(suite.T(), []string{"Nguyen"}, storedWords())
+	words, _ = lexicon.Words(userID)
+	assert.Equal(suite.T(), []string{"Nguyen"}, words)
+
+	w = suite.makeAuthenticatedRequest("POST", dictionaryURL, nil, true)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code, "A file is required")
+
+	w = suite.makeAuthenticatedRequest("DELETE", dictionaryURL, nil, true)
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+	assert.Empty(suite.T(), storedWords())
+	words, _ = lexicon.Words(userID)
+	assert.Nil(suite.T(), words)
+}
+
+func (suite *APIHandlerTestSuite) TestTranscriptionQuota() {
+	hashed, err := auth.HashPassword("quota-pass")
+	assert.NoError(suite.T(), err)
+	member := &models.User{Username: "quota-member", Password: hashed, Role: models.RoleUser}
+	assert.NoError(suite.T(), suite.helper.GetDB().Create(member).Error)
+	token, err := suite.helper.AuthService.GenerateToken(member)
+	assert.NoError(suite.T(), err)
+
+	doRequest := func(method, path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+	newJob := func(status models.JobStatus, seconds float64) *models.TranscriptionJob {
+		job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Quota Job")
+		assert.NoError(suite.T(), suite.helper.GetDB().Model(job).Updates(map[string]interface{}{
+			"status":           status,
+			"duration_seconds": seconds,
+			"user_id":          member.ID,
+		}).Error)
+		return job
+	}
+
+	// No quota means no limit
+	w := doRequest("GET", "/api/v1/users/me/quota")
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.JSONEq(suite.T(), `{"quotas":[]}`, w.Body.String())
+
+	// Admins set quotas per period
+	quotaPath := fmt.Sprintf("/api/v1/admin/users/%d/quotas/", member.ID)
+	assert.Equal(suite.T(), 400, suite.makeAuthenticatedRequest("PUT", quotaPath+"weekly", map[string]int{"max_seconds": 100}, true).Code)
+	assert.Equal(suite.T(), 403, doRequest("PUT", quotaPath+"daily").Code)
+	w = suite.makeAuthenticatedRequest("PUT", quotaPath+"daily", map[string]int{"max_seconds": 100}, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	w = suite.makeAuthenticatedRequest("PUT", quotaPath+"monthly", map[string]int{"max_seconds": 1000}, true)
+	assert.Equal(suite.T(), 200, w.Code)
+
+	// A job longer than the remaining daily allowance is rejected
+	long := newJob(models.StatusUploaded, 120.4)
+	w = doRequest("POST", fmt.Sprintf("/api/v1/transcription/%s/start", long.ID))
+	assert.Equal(suite.T(), 429, w.Code)
+	var exceeded apierror.Envelope
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &exceeded))
+	assert.Equal(suite.T(), apierror.CodeQuotaExceeded, exceeded.Code)
+	assert.Equal(suite.T(), map[string]any{"used_seconds": float64(0), "max_seconds": float64(100)}, exceeded.Details)
+	var stored models.TranscriptionJob
+	assert.NoError(suite.T(), suite.helper.GetDB().First(&stored, "id = ?", long.ID).Error)
+	assert.Equal(suite.T(), models.StatusUploaded, stored.Status)
+
+	// Queued jobs count against the quota before they are charged
+	short := newJob(models.StatusUploaded, 50)
+	queued := newJob(models.StatusPending, 60)
+	assert.Equal(suite.T(), 429, doRequest("POST", fmt.Sprintf("/api/v1/transcription/%s/start", short.ID)).Code)
+
+	// Completed jobs are charged to their user
+	assert.NoError(suite.T(), quota.ChargeJob(context.Background(), queued.ID))
+	w = doRequest("GET", "/api/v1/users/me/quota")
+	assert.Equal(suite.T(), 200, w.Code)
+	var usage struct {
+		Quotas []api.QuotaResponse `json:"quotas"`
+	}
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &usage))
+	if assert.Len(suite.T(), usage.Quotas, 2) {
+		assert.Equal(suite.T(), models.QuotaPeriodDaily, usage.Quotas[0].Period)
+		assert.Equal(suite.T(), 60, usage.Quotas[0].UsedSeconds)
+		assert.Equal(suite.T(), 40, usage.Quotas[0].RemainingSeconds)
+		assert.Equal(suite.T(), 60, usage.Quotas[1].UsedSeconds)
+	}
+
+	// Only the daily quota resets at the start of the next day
+	reset, err := quota.ResetExpired(context.Background(), time.Now().UTC().AddDate(0, 0, 1))
+	assert.NoError(suite.T(), err)
+	assert.GreaterOrEqual(suite.T(), reset, int64(1))
+	quotas, err := quota.ForUser(context.Background(), member.ID)
+	assert.NoError(suite.T(), err)
+	if assert.Len(suite.T(), quotas, 2) {
+		assert.Equal(suite.T(), 0, quotas[0].UsedSeconds)
+		if time.Now().UTC().AddDate(0, 0, 1).Month() == time.Now().UTC().Month() {
+			assert.Equal(suite.T(), 60, quotas[1].UsedSeconds)
+		}
+	}
+
+	// Removing a quota lifts the limit
+	assert.Equal(suite.T(), 200, suite.makeAuthenticatedRequest("DELETE", quotaPath+"daily", nil, true).Code)
+	assert.Equal(suite.T(), 404, suite.makeAuthenticatedRequest("DELETE", quotaPath+"daily", nil, true).Code)
+	w = suite.makeAuthenticatedRequest("GET", fmt.Sprintf("/api/v1/admin/users/%d/quotas", member.ID), nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &usage))
+	assert.Len(suite.T(), usage.Quotas, 1)
+}
+
+// Test creating jobs from remote media URLs
+func (suite *APIHandlerTestSuite) TestCreateJobFromURL() {
+	cfg := suite.helper.Config
+	cfg.URLDeniedHosts = []string{"blocked.example.com"}
+	defer func() { cfg.URLDeniedHosts = nil }()
+
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/transcription/from-url", map[string]string{"url": "file:///etc/passwd"}, false)
+	assert.Equal(suite.T(), 400, w.Code)
+
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/transcription/from-url", map[string]string{"url": "https://media.blocked.example.com/talk"}, false)
+	assert.Equal(suite.T(), 403, w.Code)
+
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/transcription/from-url", map[string]interface{}{
+		"url":         "https://media.example.com/talk",
+		"title":       "Remote Talk",
+		"description": strings.Repeat("d", models.MaxJobDescriptionLength+1),
+	}, false)
+	assert.Equal(suite.T(), 422, w.Code)
+
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/transcription/from-url", map[string]interface{}{
+		"url":        "https://media.example.com/talk",
+		"title":      "Remote Talk",
+		"metadata":   map[string]string{"series": "Conference"},
+		"parameters": map[string]interface{}{"language": "en"},
+	}, false)
+	assert.Equal(suite.T(), 202, w.Code)
+
+	var job models.TranscriptionJob
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), models.StatusDownloading, job.Status)
+	if assert.NotNil(suite.T(), job.SourceURL) {
+		assert.Equal(suite.T(), "https://media.example.com/talk", *job.SourceURL)
+	}
+	if assert.NotNil(suite.T(), job.Parameters.Language) {
+		assert.Equal(suite.T(), "en", *job.Parameters.Language)
+	}
+
+	// Without a usable yt-dlp the download fails the job with the cause
+	var stored models.TranscriptionJob
+	assert.Eventually(suite.T(), func() bool {
+		if err := suite.helper.GetDB().First(&stored, "id = ?", job.ID).Error; err != nil {
+			return false
+		}
+		return stored.Status == models.StatusFailed
+	}, 10*time.Second, 50*time.Millisecond)
+	if assert.NotNil(suite.T(), stored.ErrorMessage) {
+		assert.Contains(suite.T(), *stored.ErrorMessage, "yt-dlp")
+	}
+	assert.Equal(suite.T(), "Remote Talk", *stored.Title)
+	assert.Equal(suite.T(), map[string]string{"series": "Conference"}, stored.Metadata)
+}
+
+// Test WhisperX model listing and management access
+func (suite *APIHandlerTestSuite) TestModelManagement() {
+	w := suite.makeAuthenticatedRequest("GET", "/api/v1/models", nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	var response struct {
+		Models []transcription.ModelInfo `json:"models"`
+	}
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	names := make([]string, 0, len(response.Models))
+	for _, model := range response.Models {
+		names = append(names, model.Name)
+		assert.Greater(suite.T(), model.Size, int64(0))
+		assert.NotEmpty(suite.T(), model.Path)
+	}
+	assert.Contains(suite.T(), names, "large-v3")
+	assert.Contains(suite.T(), names, "tiny.en")
+
+	assert.Equal(suite.T(), 404, suite.makeAuthenticatedRequest("POST", "/api/v1/models/gigantic/download", nil, true).Code)
+	assert.Equal(suite.T(), 404, suite.makeAuthenticatedRequest("DELETE", "/api/v1/models/gigantic", nil, true).Code)
+
+	// Only admins may change which models are downloaded
+	member := &models.User{ID: suite.helper.TestUser.ID, Username: suite.helper.TestUser.Username, Role: models.RoleUser}
+	token, err := suite.helper.AuthService.GenerateToken(member)
+	assert.NoError(suite.T(), err)
+	for _, method := range []string{"POST", "DELETE"} {
+		path := "/api/v1/models/tiny"
+		if method == "POST" {
+			path += "/download"
+		}
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		assert.Equal(suite.T(), 403, w.Code, method)
+	}
+}
+
+// Test that admins can manage accounts and that disabled or deleted accounts lose access
+// Test that time estimates use past jobs once there are enough of them
+func (suite *APIHandlerTestSuite) TestEstimateTranscription() {
+	request := map[string]any{"duration_seconds": 600, "model": "medium", "device": "cuda"}
+	estimateFor := func(body map[string]any) estimate.Estimate {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/transcriptions/estimate", body, true)
+		assert.Equal(suite.T(), 200, w.Code, w.Body.String())
+		var result estimate.Estimate
+		assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &result))
+		return result
+	}
+
+	// Without history the configured factor is used
+	result := estimateFor(request)
+	assert.Equal(suite.T(), 48.0, result.EstimatedSeconds)
+	assert.Equal(suite.T(), estimate.ConfidenceLow, result.Confidence)
+	assert.Equal(suite.T(), 0, result.Samples)
+
+	seed := func(device string, status models.JobStatus, processingSeconds int64) {
+		job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Estimate history")
+		duration := 100.0
+		assert.NoError(suite.T(), suite.helper.GetDB().Model(job).Update("duration_seconds", duration).Error)
+		completedAt := time.Now()
+		processingMs := processingSeconds * 1000
+		execution := models.TranscriptionJobExecution{
+			TranscriptionJobID: job.ID,
+			StartedAt:          completedAt.Add(-time.Duration(processingSeconds) * time.Second),
+			CompletedAt:        &completedAt,
+			ProcessingDuration: &processingMs,
+			ActualParameters:   models.WhisperXParams{Model: "medium", Device: device},
+			Status:             status,
+		}
+		assert.NoError(suite.T(), suite.helper.GetDB().Create(&execution).Error)
+	}
+	for _, seconds := range []int64{10, 40, 20, 30} {
+		seed("cuda", models.StatusCompleted, seconds)
+	}
+	// Failed jobs and other devices are ignored
+	seed("cuda", models.StatusFailed, 500)
+	seed("cpu", models.StatusCompleted, 500)
+
+	// The 75th percentile of 0.1, 0.2, 0.3 and 0.4 seconds per audio second
+	result = estimateFor(request)
+	assert.Equal(suite.T(), 180.0, result.EstimatedSeconds)
+	assert.Equal(suite.T(), estimate.ConfidenceMedium, result.Confidence)
+	assert.Equal(suite.T(), 4, result.Samples)
+
+	for _, body := range []map[string]any{
+		{"model": "medium", "device": "cuda"},
+		{"duration_seconds": -1, "model": "medium"},
+		{"duration_seconds": 60},
+	} {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/transcriptions/estimate", body, true)
+		assert.Equal(suite.T(), 400, w.Code)
+	}
+}
+
+func (suite *APIHandlerTestSuite) TestAdminUserManagement() {
+	login := func(username, password string) (int, string) {
+		body, _ := json.Marshal(map[string]string{"username": username, "password": password})
+		req, _ := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		var response api.LoginResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response.Token
+	}
+	currentUser := func(token string) int {
+		req, _ := http.NewRequest("GET", "/api/v1/users/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Create an account through the admin API
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/admin/users", map[string]string{"username": "managed-member", "password": "short"}, true)
+	assert.Equal(suite.T(), 400, w.Code)
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/admin/users", map[string]string{"username": "managed-member", "password": "managed-pass", "role": "owner"}, true)
+	assert.Equal(suite.T(), 400, w.Code)
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/admin/users", map[string]string{"username": "managed-member", "password": "managed-pass", "display_name": "Managed"}, true)
+	assert.Equal(suite.T(), 201, w.Code)
+	var created api.AdminUserResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(suite.T(), models.RoleUser, created.Role)
+	assert.Equal(suite.T(), "Managed", created.DisplayName)
+	assert.False(suite.T(), created.Disabled)
+
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/admin/users", map[string]string{"username": "managed-member", "password": "managed-pass"}, true)
+	assert.Equal(suite.T(), 409, w.Code)
+
+	w = suite.makeAuthenticatedRequest("GET", "/api/v1/admin/users?limit=100", nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	var list struct {
+		Users      []api.AdminUserResponse `json:"users"`
+		Pagination map[string]interface{}  `json:"pagination"`
+	}
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(suite.T(), float64(len(list.Users)), list.Pagination["total"])
+	listed := false
+	for _, user := range list.Users {
+		listed = listed || user.ID == created.ID
+	}
+	assert.True(suite.T(), listed)
+
+	// The new account can authenticate but cannot manage users
+	code, token := login("managed-member", "managed-pass")
+	assert.Equal(suite.T(), 200, code)
+	assert.Equal(suite.T(), 200, currentUser(token))
+	req, _ := http.NewRequest("GET", "/api/v1/admin/users", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), 403, w.Code)
+
+	// Disabling rejects the existing token and further logins
+	path := fmt.Sprintf("/api/v1/admin/users/%d", created.ID)
+	w = suite.makeAuthenticatedRequest("PATCH", path, map[string]bool{"disabled": true}, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.Equal(suite.T(), 401, currentUser(token))
+	code, _ = login("managed-member", "managed-pass")
+	assert.Equal(suite.T(), 403, code)
+
+	// Re-enabling restores access
+	w = suite.makeAuthenticatedRequest("PATCH", path, map[string]bool{"disabled": false}, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	code, token = login("managed-member", "managed-pass")
+	assert.Equal(suite.T(), 200, code)
+	assert.Equal(suite.T(), 200, currentUser(token))
+
+	// Admins cannot lock themselves out
+	self := fmt.Sprintf("/api/v1/admin/users/%d", suite.helper.TestUser.ID)
+	assert.Equal(suite.T(), 400, suite.makeAuthenticatedRequest("PATCH", self, map[string]bool{"disabled": true}, true).Code)
+	assert.Equal(suite.T(), 400, suite.makeAuthenticatedRequest("PATCH", self, map[string]string{"role": models.RoleUser}, true).Code)
+	assert.Equal(suite.T(), 400, suite.makeAuthenticatedRequest("DELETE", self, nil, true).Code)
+
+	// Soft deletion keeps the row but blocks access and reserves the username
+	w = suite.makeAuthenticatedRequest("DELETE", path, nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.Equal(suite.T(), 401, currentUser(token))
+	code, _ = login("managed-member", "managed-pass")
+	assert.Equal(suite.T(), 401, code)
+	assert.Equal(suite.T(), 404, suite.makeAuthenticatedRequest("PATCH", path, map[string]bool{"disabled": false}, true).Code)
+
+	var deleted models.User
+	assert.NoError(suite.T(), suite.helper.GetDB().Unscoped().First(&deleted, created.ID).Error)
+	assert.True(suite.T(), deleted.DeletedAt.Valid)
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/admin/users", map[string]string{"username": "managed-member", "password": "managed-pass"}, true)
+	assert.Equal(suite.T(), 409, w.Code)
+}
+
+// Test that API keys are scoped to their owner and expire
+func (suite *APIHandlerTestSuite) TestAPIKeyOwnershipAndExpiry() {
+	ctx := context.Background()
+	other := models.User{Username: "apikeyowner", Password: "x", Role: models.RoleUser}
+	assert.NoError(suite.T(), suite.helper.DB.Create(&other).Error)
+	defer suite.helper.DB.Delete(&other)
+
+	key, err := suite.helper.AuthService.GenerateAPIKey(ctx, other.ID, "ci", []string{models.ScopeWrite}, time.Hour)
+	assert.NoError(suite.T(), err)
+
+	doRequest := func(header, value string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/transcription/list", nil)
+		req.Header.Set(header, value)
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(suite.T(), 200, doRequest("Authorization", "Bearer "+key).Code)
+	assert.Equal(suite.T(), 200, doRequest("X-API-Key", key).Code)
+
+	// Keys inherit their owner's role
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/admin/queue/stats", nil)
+	req.Header.Set("Authorization", "Bearer "+key)
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), 403, w.Code)
+
+	// Another user's keys are not visible
+	var stored models.APIKey
+	assert.NoError(suite.T(), suite.helper.DB.Where("key_hash = ?", auth.HashAPIKey(key)).First(&stored).Error)
+	w = suite.makeAuthenticatedRequest("GET", fmt.Sprintf("/api/v1/api-keys/%d", stored.ID), nil, true)
+	assert.Equal(suite.T(), 404, w.Code)
+
+	// Expired keys are rejected
+	assert.NoError(suite.T(), suite.helper.DB.Model(&stored).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	w = doRequest("Authorization", "Bearer "+key)
+	assert.Equal(suite.T(), 401, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "expired")
+
+	assert.Equal(suite.T(), 401, doRequest("Authorization", "Bearer sk_doesnotexist").Code)
+}
+
+// Test refresh without a token
+func (suite *APIHandlerTestSuite) TestRefreshMissingToken() {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/auth/refresh", nil)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), 401, w.Code)
+}
+
+// Test public share links to a transcript
+func (suite *APIHandlerTestSuite) TestShareLinks() {
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Shared Meeting")
+	audioPath := suite.T().TempDir() + "/shared.wav"
+	assert.NoError(suite.T(), os.WriteFile(audioPath, []byte("RIFF....WAVE"), 0644))
+	transcript := `{"segments":[{"start":0,"end":1,"text":"Hello","speaker":"SPEAKER_00"}]}`
+	suite.helper.GetDB().Model(job).Updates(map[string]interface{}{"audio_path": audioPath, "transcript": transcript})
+	suite.helper.GetDB().Create(&models.SpeakerMapping{TranscriptionJobID: job.ID, OriginalSpeaker: "SPEAKER_00", CustomName: "Alice"})
+	shareURL := fmt.Sprintf("/api/v1/transcriptions/%s/share", job.ID)
+	public := func(router *gin.Engine, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	create := func(body interface{}) api.ShareLinkResponse {
+		w := suite.makeAuthenticatedRequest("POST", shareURL, body, true)
+		assert.Equal(suite.T(), 201, w.Code, w.Body.String())
+		var link api.ShareLinkResponse
+		assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &link))
+		return link
+	}
+
+	// Creating needs credentials and a valid expiry
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", shareURL, nil)
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), 401, w.Code)
+	w = suite.makeAuthenticatedRequest("POST", shareURL, map[string]interface{}{"expires_in_hours": 1, "expires_at": time.Now().Add(time.Hour)}, true)
+	assert.Equal(suite.T(), 400, w.Code)
+	w = suite.makeAuthenticatedRequest("POST", shareURL, map[string]interface{}{"expires_at": time.Now().Add(-time.Hour)}, true)
+	assert.Equal(suite.T(), 400, w.Code)
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/transcriptions/missing/share", nil, true)
+	assert.Equal(suite.T(), 404, w.Code)
+
+	// Anyone with the token can read the transcript, without the audio
+	textOnly := create(map[string]interface{}{"expires_in_hours": 24})
+	assert.Equal(suite.T(), "/share/"+textOnly.Token, textOnly.URL)
+	assert.NotNil(suite.T(), textOnly.ExpiresAt)
+	w = public(suite.router, "/api/v1/share/"+textOnly.Token)
+	assert.Equal(suite.T(), 200, w.Code)
+	var shared api.SharedTranscriptResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &shared))
+	assert.Equal(suite.T(), "Shared Meeting", *shared.Title)
+	assert.JSONEq(suite.T(), transcript, string(shared.Transcript))
+	assert.Equal(suite.T(), map[string]string{"SPEAKER_00": "Alice"}, shared.Speakers)
+	assert.Empty(suite.T(), shared.AudioURL)
+	assert.Equal(suite.T(), 403, public(suite.router, "/api/v1/share/"+textOnly.Token+"/audio").Code)
+	assert.Equal(suite.T(), 404, public(suite.router, "/api/v1/share/not-a-token").Code)
+
+	// The token only opens the share routes
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/api/v1/transcription/%s", job.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+textOnly.Token)
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), 401, w.Code)
+
+	// Links with audio point at a ranged audio endpoint
+	withAudio := create(map[string]interface{}{"include_audio": true})
+	assert.Nil(suite.T(), withAudio.ExpiresAt)
+	w = public(suite.router, "/api/v1/share/"+withAudio.Token)
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &shared))
+	assert.Equal(suite.T(), "/api/v1/share/"+withAudio.Token+"/audio", shared.AudioURL)
+	w = public(suite.router, shared.AudioURL)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.Equal(suite.T(), "RIFF....WAVE", w.Body.String())
+
+	// Accesses are counted and tokens are never listed
+	w = suite.makeAuthenticatedRequest("GET", shareURL, nil, false)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.NotContains(suite.T(), w.Body.String(), withAudio.Token)
+	var listed struct {
+		Links []models.ShareLink `json:"links"`
+	}
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &listed))
+	if assert.Len(suite.T(), listed.Links, 2) {
+		assert.Equal(suite.T(), withAudio.ID, listed.Links[0].ID)
+		assert.Equal(suite.T(), int64(2), listed.Links[0].AccessCount)
+		assert.NotNil(suite.T(), listed.Links[0].LastAccessedAt)
+	}
+
+	// Multi-track jobs share their merged audio
+	mergedPath := suite.T().TempDir() + "/merged.wav"
+	assert.NoError(suite.T(), os.WriteFile(mergedPath, []byte("RIFF..MERGED"), 0644))
+	suite.helper.GetDB().Model(job).Updates(map[string]interface{}{"is_multi_track": true, "merged_audio_path": mergedPath})
+	w = public(suite.router, "/api/v1/share/"+withAudio.Token+"/audio")
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.Equal(suite.T(), "RIFF..MERGED", w.Body.String())
+
+	// Revoked and expired links are gone
+	w = suite.makeAuthenticatedRequest("DELETE", fmt.Sprintf("%s/%d", shareURL, withAudio.ID), nil, false)
+	assert.Equal(suite.T(), 204, w.Code)
+	assert.Equal(suite.T(), 410, public(suite.router, "/api/v1/share/"+withAudio.Token).Code)
+	assert.Equal(suite.T(), 410, public(suite.router, "/api/v1/share/"+withAudio.Token+"/audio").Code)
+	w = suite.makeAuthenticatedRequest("DELETE", fmt.Sprintf("%s/%d", shareURL, 999999), nil, false)
+	assert.Equal(suite.T(), 404, w.Code)
+
+	suite.helper.GetDB().Model(&models.ShareLink{}).Where("id = ?", textOnly.ID).Update("expires_at", time.Now().Add(-time.Minute))
+	assert.Equal(suite.T(), 410, public(suite.router, "/api/v1/share/"+textOnly.Token).Code)
+
+	// Links to deleted jobs are gone too
+	live := create(nil)
+	w = suite.makeAuthenticatedRequest("DELETE", fmt.Sprintf("/api/v1/transcriptions/%s", job.ID), nil, false)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.Equal(suite.T(), 410, public(suite.router, "/api/v1/share/"+live.Token).Code)
+
+	// Each token is rate limited on its own
+	cfg := *suite.helper.Config
+	cfg.ShareRateLimit = 2
+	limited := api.SetupRoutes(api.NewHandler(&cfg, suite.helper.AuthService, suite.taskQueue, suite.unifiedProcessor, suite.quickTranscription), suite.helper.AuthService)
+	assert.Equal(suite.T(), 410, public(limited, "/api/v1/share/"+live.Token).Code)
+	assert.Equal(suite.T(), 410, public(limited, "/api/v1/share/"+live.Token).Code)
+	w = public(limited, "/api/v1/share/"+live.Token)
+	assert.Equal(suite.T(), 429, w.Code)
+	assert.NotEmpty(suite.T(), w.Header().Get("Retry-After"))
+	assert.Equal(suite.T(), 404, public(limited, "/api/v1/share/other-token").Code)
+}
+
+// Test login rate limiting and lockouts after repeated failures
+func (suite *APIHandlerTestSuite) TestLoginLockout() {
+	hashed, err := auth.HashPassword("lockout-pass")
+	assert.NoError(suite.T(), err)
+	member := &models.User{Username: "lockout-user", Password: hashed, Role: models.RoleUser}
+	assert.NoError(suite.T(), suite.helper.GetDB().Create(member).Error)
+
+	login := func(router *gin.Engine, username, password, forwardedFor string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"username": username, "password": password})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	cfg := *suite.helper.Config
+	cfg.LoginRateLimit = 100
+	cfg.LoginLockoutThreshold = 2
+	cfg.LoginLockoutDuration = time.Minute
+	router := api.SetupRoutes(api.NewHandler(&cfg, suite.helper.AuthService, suite.taskQueue, suite.unifiedProcessor, suite.quickTranscription), suite.helper.AuthService)
+
+	// The second consecutive failure locks the account, even for the right password
+	assert.Equal(suite.T(), 401, login(router, "lockout-user", "wrong", "").Code)
+	assert.Equal(suite.T(), 401, login(router, "lockout-user", "wrong", "").Code)
+	w := login(router, "lockout-user", "lockout-pass", "")
+	assert.Equal(suite.T(), 429, w.Code)
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), retryAfter > 0 && retryAfter <= 60, "Retry-After %d", retryAfter)
+
+	// Unknown usernames are counted the same way
+	assert.Equal(suite.T(), 401, login(router, "lockout-nobody", "wrong", "").Code)
+
+	w = suite.makeAuthenticatedRequest("GET", "/api/v1/admin/lockouts", nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	var lockouts []api.LoginLockoutResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &lockouts))
+	byName := map[string]api.LoginLockoutResponse{}
+	for _, lockout := range lockouts {
+		byName[lockout.Username] = lockout
+	}
+	assert.True(suite.T(), byName["lockout-user"].Locked)
+	assert.Equal(suite.T(), 2, byName["lockout-user"].Failures)
+	assert.False(suite.T(), byName["lockout-nobody"].Locked)
+	assert.Equal(suite.T(), 1, byName["lockout-nobody"].Failures)
+
+	// Members cannot see or clear lockouts
+	token, err := suite.helper.AuthService.GenerateToken(member)
+	assert.NoError(suite.T(), err)
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/admin/lockouts", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), 403, w.Code)
+
+	// Clearing the lockout lets the user log in again
+	assert.Equal(suite.T(), 200, suite.makeAuthenticatedRequest("DELETE", "/api/v1/admin/lockouts/lockout-user", nil, true).Code)
+	assert.Equal(suite.T(), 404, suite.makeAuthenticatedRequest("DELETE", "/api/v1/admin/lockouts/lockout-user", nil, true).Code)
+	assert.Equal(suite.T(), 200, login(router, "lockout-user", "lockout-pass", "").Code)
+
+	// A successful login forgets earlier failures
+	assert.Equal(suite.T(), 401, login(router, "lockout-user", "wrong", "").Code)
+	assert.Equal(suite.T(), 200, login(router, "lockout-user", "lockout-pass", "").Code)
+	assert.Equal(suite.T(), 401, login(router, "lockout-user", "wrong", "").Code)
+	assert.Equal(suite.T(), 200, login(router, "lockout-user", "lockout-pass", "").Code)
+
+	// Each client IP is rate limited, and X-Forwarded-For from untrusted peers is ignored
+	cfg.LoginRateLimit = 2
+	limited := api.SetupRoutes(api.NewHandler(&cfg, suite.helper.AuthService, suite.taskQueue, suite.unifiedProcessor, suite.quickTranscription), suite.helper.AuthService)
+	assert.Equal(suite.T(), 200, login(limited, "lockout-user", "lockout-pass", "").Code)
+	assert.Equal(suite.T(), 200, login(limited, "lockout-user", "lockout-pass", "203.0.113.1").Code)
+	w = login(limited, "lockout-user", "lockout-pass", "203.0.113.2")
+	assert.Equal(suite.T(), 429, w.Code)
+	assert.NotEmpty(suite.T(), w.Header().Get("Retry-After"))
+
+	assert.NoError(suite.T(), suite.helper.GetDB().Where("username LIKE ?", "lockout-%").Delete(&models.LoginLockout{}).Error)
+}
+
+// Test logging in through an authenticating reverse proxy
+func (suite *APIHandlerTestSuite) TestTrustedHeaderAuth() {
+	cfg := *suite.helper.Config
+	cfg.HeaderAuth = config.HeaderAuthConfig{Enabled: true, Header: "Remote-User", LogoutURL: "https://auth.example.com/logout"}
+	cfg.TrustedProxies = []string{"192.0.2.0/24"}
+	router := api.SetupRoutes(api.NewHandler(&cfg, suite.helper.AuthService, suite.taskQueue, suite.unifiedProcessor, suite.quickTranscription), suite.helper.AuthService)
+
+	request := func(method, path, remoteAddr, remoteUser, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		req.RemoteAddr = remoteAddr
+		if remoteUser != "" {
+			req.Header.Set("Remote-User", remoteUser)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	const proxy = "192.0.2.10:40000"
+
+	// Requests that go around the proxy are refused, whatever they claim
+	assert.Equal(suite.T(), 403, request("GET", "/api/v1/transcription/list", "198.51.100.7:40000", "header-alice", "").Code)
+	assert.Equal(suite.T(), 403, request("GET", "/api/v1/auth/registration-status", "198.51.100.7:40000", "", "").Code)
+
+	// The proxy's header authenticates API requests, creating the account
+	assert.Equal(suite.T(), 401, request("GET", "/api/v1/transcription/list", proxy, "", "").Code)
+	assert.Equal(suite.T(), 200, request("GET", "/api/v1/transcription/list", proxy, "header-alice", "").Code)
+	var alice models.User
+	assert.NoError(suite.T(), suite.helper.GetDB().Where("username = ?", "header-alice").First(&alice).Error)
+	assert.Equal(suite.T(), models.RoleUser, alice.Role)
+
+	// The web UI learns to skip its login page and gets a session instead
+	w := request("GET", "/api/v1/auth/registration-status", proxy, "", "")
+	assert.Equal(suite.T(), 200, w.Code)
+	var status api.RegistrationStatusResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &status))
+	assert.True(suite.T(), status.HeaderAuthEnabled)
+	assert.Equal(suite.T(), "https://auth.example.com/logout", status.LogoutURL)
+
+	w = request("POST", "/api/v1/auth/header-login", proxy, "header-alice", "")
+	assert.Equal(suite.T(), 200, w.Code)
+	var login api.LoginResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &login))
+	assert.Equal(suite.T(), alice.ID, login.User.ID)
+	assert.NotEmpty(suite.T(), w.Header().Get("Set-Cookie"))
+	assert.Equal(suite.T(), 200, request("GET", "/api/v1/transcription/list", proxy, "", login.Token).Code)
+	assert.Equal(suite.T(), 401, request("POST", "/api/v1/auth/header-login", proxy, "", "").Code)
+
+	// Logging out points the web UI at the proxy's logout page
+	w = request("POST", "/api/v1/auth/logout", proxy, "header-alice", login.Token)
+	assert.Equal(suite.T(), 200, w.Code)
+	var logout map[string]string
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &logout))
+	assert.Equal(suite.T(), "https://auth.example.com/logout", logout["redirect_url"])
+
+	// Disabled accounts stay locked out
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(&alice).Update("disabled", true).Error)
+	assert.Equal(suite.T(), 403, request("POST", "/api/v1/auth/header-login", proxy, "header-alice", "").Code)
+	assert.Equal(suite.T(), 401, request("GET", "/api/v1/transcription/list", proxy, "header-alice", "").Code)
+
+	// Without header authentication the header means nothing
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/transcription/list", nil)
+	req.Header.Set("Remote-User", suite.helper.TestUser.Username)
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), 401, w.Code)
+	assert.Equal(suite.T(), 404, suite.makeAuthenticatedRequest("POST", "/api/v1/auth/header-login", nil, true).Code)
+}
+
+// Test the per-user storage summary for admins
+func (suite *APIHandlerTestSuite) TestStorageByUser() {
+	summary := func() api.StorageUsageResponse {
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/admin/storage", nil, true)
+		assert.Equal(suite.T(), 200, w.Code)
+		var response api.StorageUsageResponse
+		assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+	before := summary()
+
+	hashed, err := auth.HashPassword("storage-pass")
+	assert.NoError(suite.T(), err)
+	alice := &models.User{Username: "storage-alice", Password: hashed, Role: models.RoleUser}
+	bob := &models.User{Username: "storage-bob", Password: hashed, Role: models.RoleUser}
+	assert.NoError(suite.T(), suite.helper.GetDB().Create(alice).Error)
+	assert.NoError(suite.T(), suite.helper.GetDB().Create(bob).Error)
+	newJob := func(user *models.User, size int64) *models.TranscriptionJob {
+		job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Storage Job")
+		assert.NoError(suite.T(), suite.helper.GetDB().Model(job).Updates(map[string]interface{}{"user_id": user.ID, "file_size": size}).Error)
+		return job
+	}
+	newJob(alice, 100)
+	deleted := newJob(alice, 250)
+	assert.NoError(suite.T(), suite.helper.GetDB().Delete(deleted).Error)
+	audioRemoved := newJob(alice, 4000)
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(audioRemoved).Update("audio_deleted_at", time.Now()).Error)
+	newJob(bob, 1000)
+
+	// Deleted jobs count until purged; removed audio does not
+	after := summary()
+	assert.Equal(suite.T(), before.TotalBytes+1350, after.TotalBytes)
+	usage := map[string]api.UserStorageUsage{}
+	order := []string{}
+	for _, user := range after.Users {
+		usage[user.Username] = user
+		order = append(order, user.Username)
+	}
+	if assert.NotNil(suite.T(), usage["storage-alice"].UserID) {
+		assert.Equal(suite.T(), alice.ID, *usage["storage-alice"].UserID)
+	}
+	assert.Equal(suite.T(), int64(3), usage["storage-alice"].JobCount)
+	assert.Equal(suite.T(), int64(350), usage["storage-alice"].Bytes)
+	assert.Equal(suite.T(), int64(1), usage["storage-bob"].JobCount)
+	assert.Equal(suite.T(), int64(1000), usage["storage-bob"].Bytes)
+	assert.Less(suite.T(), slices.Index(order, "storage-bob"), slices.Index(order, "storage-alice"), "Expected the largest users first")
+
+	// Only admins see it
+	token, err := suite.helper.AuthService.GenerateToken(bob)
+	assert.NoError(suite.T(), err)
+	req, _ := http.NewRequest("GET", "/api/v1/admin/storage", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), 403, w.Code)
+}
+
+func (suite *APIHandlerTestSuite) TestJobOwnership() {
+	hashed, err := auth.HashPassword("owner-pass")
+	assert.NoError(suite.T(), err)
+	alice := &models.User{Username: "owner-alice", Password: hashed, Role: models.RoleUser}
+	bob := &models.User{Username: "owner-bob", Password: hashed, Role: models.RoleUser}
+	assert.NoError(suite.T(), suite.helper.GetDB().Create(alice).Error)
+	assert.NoError(suite.T(), suite.helper.GetDB().Create(bob).Error)
+	tokens := map[*models.User]string{}
+	for _, user := range []*models.User{alice, bob} {
+		token, err := suite.helper.AuthService.GenerateToken(user)
+		assert.NoError(suite.T(), err)
+		tokens[user] = token
+	}
+	doRequest := func(user *models.User, method, path string, body interface{}) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+tokens[user])
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+	listIDs := func(w *httptest.ResponseRecorder) []string {
+		assert.Equal(suite.T(), 200, w.Code)
+		var response struct {
+			Jobs []models.TranscriptionJob `json:"jobs"`
+		}
+		assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+		ids := []string{}
+		for _, job := range response.Jobs {
+			ids = append(ids, job.ID)
+		}
+		return ids
+	}
+
+	aliceJob := suite.helper.CreateTestTranscriptionJob(suite.T(), "Alice Job")
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(aliceJob).Update("user_id", alice.ID).Error)
+	unowned := suite.helper.CreateTestTranscriptionJob(suite.T(), "Unowned Job")
+	note := models.Note{ID: "owner-note", TranscriptionID: aliceJob.ID, EndWordIndex: 1, Quote: "hello", Content: "mine"}
+	assert.NoError(suite.T(), suite.helper.GetDB().Create(&note).Error)
+	session := models.ChatSession{ID: "owner-session", JobID: aliceJob.ID, TranscriptionID: aliceJob.ID, Title: "Chat", Model: "gpt"}
+	assert.NoError(suite.T(), suite.helper.GetDB().Create(&session).Error)
+
+	// Users see only their own jobs
+	ids := listIDs(doRequest(alice, "GET", "/api/v1/transcription/list?limit=1000", nil))
+	assert.Equal(suite.T(), []string{aliceJob.ID}, ids)
+	assert.Empty(suite.T(), listIDs(doRequest(bob, "GET", "/api/v1/transcription/list?limit=1000", nil)))
+	assert.Equal(suite.T(), 403, doRequest(bob, "GET", "/api/v1/transcription/list?all=true", nil).Code)
+	assert.Equal(suite.T(), 200, doRequest(alice, "GET", "/api/v1/transcription/"+aliceJob.ID, nil).Code)
+
+	// Other users' jobs and what hangs off them are not found
+	for _, path := range []string{
+		"/api/v1/transcription/" + aliceJob.ID,
+		"/api/v1/transcription/" + unowned.ID,
+		"/api/v1/transcription/" + aliceJob.ID + "/notes",
+		"/api/v1/notes/" + note.ID,
+		"/api/v1/chat/sessions/" + session.ID,
+	} {
+		assert.Equal(suite.T(), 404, doRequest(bob, "GET", path, nil).Code, path)
+	}
+	assert.Equal(suite.T(), 404, doRequest(bob, "PUT", "/api/v1/notes/"+note.ID, map[string]string{"content": "theirs"}).Code)
+	assert.Equal(suite.T(), 404, doRequest(bob, "DELETE", "/api/v1/chat/sessions/"+session.ID, nil).Code)
+	assert.Equal(suite.T(), 404, doRequest(bob, "DELETE", "/api/v1/transcription/"+aliceJob.ID, nil).Code)
+	var sessions []models.ChatSession
+	w := doRequest(bob, "GET", "/api/v1/chat/transcriptions/"+aliceJob.ID+"/sessions", nil)
+	assert.Equal(suite.T(), 200, w.Code)
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &sessions))
+	assert.Empty(suite.T(), sessions)
+	var stored models.Note
+	assert.NoError(suite.T(), suite.helper.GetDB().First(&stored, "id = ?", note.ID).Error)
+	assert.Equal(suite.T(), "mine", stored.Content)
+	assert.NoError(suite.T(), suite.helper.GetDB().First(&models.TranscriptionJob{}, "id = ?", aliceJob.ID).Error)
+
+	// Admins see every job by default, or their own and unowned ones
+	ids = listIDs(suite.makeAuthenticatedRequest("GET", "/api/v1/transcription/list?limit=1000", nil, true))
+	assert.Contains(suite.T(), ids, aliceJob.ID)
+	assert.Contains(suite.T(), ids, unowned.ID)
+	ids = listIDs(suite.makeAuthenticatedRequest("GET", "/api/v1/transcription/list?limit=1000&all=false", nil, true))
+	assert.NotContains(suite.T(), ids, aliceJob.ID)
+	assert.Contains(suite.T(), ids, unowned.ID)
+	assert.Equal(suite.T(), 400, suite.makeAuthenticatedRequest("GET", "/api/v1/transcription/list?all=maybe", nil, true).Code)
+}
+
+func (suite *APIHandlerTestSuite) TestDeleteJobAudio() {
+	audioPath := suite.T().TempDir() + "/delete-me.wav"
+	assert.NoError(suite.T(), os.WriteFile(audioPath, []byte("RIFF....WAVE"), 0644))
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Delete Audio Job")
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(job).Update("audio_path", audioPath).Error)
+	path := "/api/v1/transcriptions/" + job.ID + "/delete-audio"
+
+	// Audio stays until the transcript exists
+	assert.Equal(suite.T(), 409, suite.makeAuthenticatedRequest("POST", path, nil, true).Code)
+	assert.FileExists(suite.T(), audioPath)
+
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(job).Update("status", models.StatusCompleted).Error)
+	w := suite.makeAuthenticatedRequest("POST", path, nil, true)
+	assert.Equal(suite.T(), 200, w.Code)
+	var response models.TranscriptionJob
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.NotNil(suite.T(), response.AudioDeletedAt)
+	assert.NoFileExists(suite.T(), audioPath)
+	assert.Equal(suite.T(), 410, suite.makeAuthenticatedRequest("GET", "/api/v1/transcription/"+job.ID+"/audio", nil, true).Code)
+
+	// Deleting again succeeds
+	assert.Equal(suite.T(), 200, suite.makeAuthenticatedRequest("POST", path, nil, true).Code)
+	assert.Equal(suite.T(), 404, suite.makeAuthenticatedRequest("POST", "/api/v1/transcriptions/missing/delete-audio", nil, true).Code)
+}
+
+// Test changing a password, which ends other sessions, and the admin-issued
+// one-time password reset
+func (suite *APIHandlerTestSuite) TestPasswordChangeAndReset() {
+	// An account from before argon2id, with a bcrypt hash
+	legacy, err := bcrypt.GenerateFromPassword([]byte("first-secret"), bcrypt.MinCost)
+	assert.NoError(suite.T(), err)
+	member := &models.User{Username: "pw-member", Password: string(legacy), Role: models.RoleUser}
+	assert.NoError(suite.T(), suite.helper.GetDB().Create(member).Error)
+
+	post := func(router *gin.Engine, path, token string, body interface{}) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", path, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	getProfile := func(token string) int {
+		req, _ := http.NewRequest("GET", "/api/v1/users/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w.Code
+	}
+	login := func(password string) *httptest.ResponseRecorder {
+		return post(suite.router, "/api/v1/auth/login", "", map[string]string{"username": "pw-member", "password": password})
+	}
+
+	// Logging in upgrades the hash to argon2id
+	w := login("first-secret")
+	assert.Equal(suite.T(), 200, w.Code)
+	otherSession := findCookie(w.Result().Cookies(), "scriberr_refresh_token")
+	var stored models.User
+	assert.NoError(suite.T(), suite.helper.GetDB().First(&stored, member.ID).Error)
+	assert.True(suite.T(), strings.HasPrefix(stored.Password, "$argon2id$"))
+	assert.True(suite.T(), auth.CheckPassword("first-secret", stored.Password))
+
+	// A token issued a minute ago, before the password change below
+	oldToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &auth.Claims{
+		UserID:   member.ID,
+		Username: member.Username,
+		Role:     member.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "pw-member-old-session",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		},
+	}).SignedString([]byte(suite.helper.Config.JWTSecret))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 200, getProfile(oldToken))
+
+	change := func(current, next string) *httptest.ResponseRecorder {
+		return post(suite.router, "/api/v1/auth/change-password", oldToken, map[string]string{
+			"currentPassword": current, "newPassword": next, "confirmPassword": next,
+		})
+	}
+	assert.Equal(suite.T(), 400, change("wrong-secret", "second-secret").Code)
+	assert.Equal(suite.T(), 400, change("first-secret", "password123").Code)
+	assert.Equal(suite.T(), 400, change("first-secret", "pw-member-2024").Code)
+	w = change("first-secret", "second-secret")
+	assert.Equal(suite.T(), 200, w.Code)
+	var changed map[string]string
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &changed))
+
+	// Other sessions end; the one that changed the password goes on
+	assert.Equal(suite.T(), 401, getProfile(oldToken))
+	assert.Equal(suite.T(), 401, suite.refreshWithCookie(otherSession).Code)
+	assert.Equal(suite.T(), 200, getProfile(changed["token"]))
+	session := findCookie(w.Result().Cookies(), "scriberr_refresh_token")
+	assert.NotNil(suite.T(), session)
+	assert.Equal(suite.T(), 401, login("first-secret").Code)
+	assert.Equal(suite.T(), 200, login("second-secret").Code)
+
+	// Only admins issue reset tokens
+	resetPath := fmt.Sprintf("/api/v1/admin/users/%d/reset-password", member.ID)
+	assert.Equal(suite.T(), 403, post(suite.router, resetPath, changed["token"], nil).Code)
+	assert.Equal(suite.T(), 404, suite.makeAuthenticatedRequest("POST", "/api/v1/admin/users/99999/reset-password", nil, true).Code)
+
+	// A newer token replaces an older one
+	w = suite.makeAuthenticatedRequest("POST", resetPath, nil, true)
+	assert.Equal(suite.T(), 201, w.Code)
+	var replaced api.PasswordResetTokenResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &replaced))
+	w = suite.makeAuthenticatedRequest("POST", resetPath, nil, true)
+	assert.Equal(suite.T(), 201, w.Code)
+	var issued api.PasswordResetTokenResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &issued))
+	assert.Equal(suite.T(), member.ID, issued.UserID)
+	assert.NotEmpty(suite.T(), issued.Token)
+	assert.WithinDuration(suite.T(), time.Now().Add(auth.DefaultPasswordResetTTL), issued.ExpiresAt, time.Minute)
+
+	reset := func(token, password string) int {
+		return post(suite.router, "/api/v1/auth/reset", "", map[string]string{
+			"token": token, "newPassword": password, "confirmPassword": password,
+		}).Code
+	}
+	assert.Equal(suite.T(), 400, reset(replaced.Token, "third-secret"))
+	// A rejected password leaves the token usable
+	assert.Equal(suite.T(), 400, reset(issued.Token, "short"))
+	assert.Equal(suite.T(), 200, reset(issued.Token, "third-secret"))
+	assert.Equal(suite.T(), 400, reset(issued.Token, "fourth-secret"))
+
+	// The reset ends every session
+	assert.Equal(suite.T(), 401, suite.refreshWithCookie(session).Code)
+	assert.Equal(suite.T(), 401, login("second-secret").Code)
+	assert.Equal(suite.T(), 200, login("third-secret").Code)
+
+	// Reset attempts are rate limited per client
+	cfg := *suite.helper.Config
+	cfg.LoginRateLimit = 2
+	router := api.SetupRoutes(api.NewHandler(&cfg, suite.helper.AuthService, suite.taskQueue, suite.unifiedProcessor, suite.quickTranscription), suite.helper.AuthService)
+	for i := 0; i < 2; i++ {
+		assert.Equal(suite.T(), 400, post(router, "/api/v1/auth/reset", "", map[string]string{
+			"token": "bogus", "newPassword": "fifth-secret", "confirmPassword": "fifth-secret",
+		}).Code)
+	}
+	w = post(router, "/api/v1/auth/reset", "", map[string]string{
+		"token": "bogus", "newPassword": "fifth-secret", "confirmPassword": "fifth-secret",
+	})
+	assert.Equal(suite.T(), 429, w.Code)
+	assert.NotEmpty(suite.T(), w.Header().Get("Retry-After"))
 }
 
 func TestAPIHandlerTestSuite(t *testing.T) {
@@ -1,16 +1,19 @@
 package tests
 
 import (
+	"context"
 	"strings"
 	"testing"
 	"time"
 
 	"scriberr/internal/auth"
+	"scriberr/internal/config"
 	"scriberr/internal/models"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthServiceTestSuite struct {
@@ -81,7 +84,7 @@ func (suite *AuthServiceTestSuite) TestValidateTokenInvalid() {
 // Test JWT token validation with expired token
 func (suite *AuthServiceTestSuite) TestValidateTokenExpired() {
 	// Create a custom auth service with short-lived tokens for testing
-	authService := auth.NewAuthService("test-secret")
+	authService := auth.NewAuthService("test-secret", config.DefaultAccessTokenTTL)
 
 	// Manually create an expired token
 	claims := &auth.Claims{
@@ -108,14 +111,14 @@ func (suite *AuthServiceTestSuite) TestValidateTokenExpired() {
 // Test JWT token validation with wrong secret
 func (suite *AuthServiceTestSuite) TestValidateTokenWrongSecret() {
 	// Generate token with one secret
-	authService1 := auth.NewAuthService("secret1")
+	authService1 := auth.NewAuthService("secret1", config.DefaultAccessTokenTTL)
 	user := &models.User{ID: 1, Username: "testuser"}
 
 	token, err := authService1.GenerateToken(user)
 	assert.NoError(suite.T(), err)
 
 	// Try to validate with different secret
-	authService2 := auth.NewAuthService("secret2")
+	authService2 := auth.NewAuthService("secret2", config.DefaultAccessTokenTTL)
 	claims, err := authService2.ValidateToken(token)
 
 	assert.Error(suite.T(), err)
@@ -139,7 +142,8 @@ func (suite *AuthServiceTestSuite) TestHashPassword() {
 		assert.NotEmpty(suite.T(), hash)
 		assert.NotEqual(suite.T(), password, hash, "Hash should not equal original password")
 		assert.True(suite.T(), len(hash) > 50, "Hash should be reasonably long")
-		assert.True(suite.T(), strings.HasPrefix(hash, "$2a$"), "Should use bcrypt format")
+		assert.True(suite.T(), strings.HasPrefix(hash, "$argon2id$"), "Should use argon2id format")
+		assert.False(suite.T(), auth.NeedsRehash(hash))
 	}
 }
 
@@ -182,6 +186,29 @@ func (suite *AuthServiceTestSuite) TestCheckPasswordInvalidHash() {
 	}
 }
 
+// Test that bcrypt hashes from older installations still verify and are
+// flagged for an upgrade
+func (suite *AuthServiceTestSuite) TestLegacyBcryptHash() {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("legacy-password"), bcrypt.MinCost)
+	assert.NoError(suite.T(), err)
+
+	assert.True(suite.T(), auth.CheckPassword("legacy-password", string(legacy)))
+	assert.False(suite.T(), auth.CheckPassword("other-password", string(legacy)))
+	assert.True(suite.T(), auth.NeedsRehash(string(legacy)))
+
+	// Argon2id hashes with weaker parameters are upgraded too
+	assert.True(suite.T(), auth.NeedsRehash("$argon2id$v=19$m=4096,t=1,p=1$c2FsdHNhbHQ$aGFzaA"))
+}
+
+// Test the password policy
+func (suite *AuthServiceTestSuite) TestValidatePassword() {
+	assert.NoError(suite.T(), auth.ValidatePassword("correct horse battery", "alice"))
+	for _, password := range []string{"short", "password123", "aaaaaaaaaa", "my-alice-pass", strings.Repeat("x1", 65)} {
+		err := auth.ValidatePassword(password, "alice")
+		assert.ErrorIs(suite.T(), err, auth.ErrWeakPassword, "Expected %q to be rejected", password)
+	}
+}
+
 // Test hash consistency (same password should produce different hashes due to salt)
 func (suite *AuthServiceTestSuite) TestHashConsistency() {
 	password := "testpassword123"
@@ -242,13 +269,14 @@ func (suite *AuthServiceTestSuite) TestTokenExpiryTime() {
 	claims, err := suite.helper.AuthService.ValidateToken(token)
 	assert.NoError(suite.T(), err)
 
-	// Token should expire 24 hours from generation
-	expectedExpiry := beforeGeneration.Add(24 * time.Hour)
+	// Token should expire after the configured access TTL
+	ttl := suite.helper.Config.AccessTokenTTL
+	expectedExpiry := beforeGeneration.Add(ttl)
 	actualExpiry := claims.ExpiresAt.Time
 
 	// Allow some tolerance for processing time
 	assert.True(suite.T(), actualExpiry.After(expectedExpiry.Add(-1*time.Minute)))
-	assert.True(suite.T(), actualExpiry.Before(afterGeneration.Add(24*time.Hour).Add(1*time.Minute)))
+	assert.True(suite.T(), actualExpiry.Before(afterGeneration.Add(ttl).Add(1*time.Minute)))
 
 	// Issue time should be around now
 	assert.True(suite.T(), claims.IssuedAt.Time.After(beforeGeneration.Add(-1*time.Minute)))
@@ -265,7 +293,7 @@ func (suite *AuthServiceTestSuite) TestNewAuthService() {
 	}
 
 	for _, secret := range secrets {
-		authService := auth.NewAuthService(secret)
+		authService := auth.NewAuthService(secret, config.DefaultAccessTokenTTL)
 		assert.NotNil(suite.T(), authService)
 
 		// Test that the service works with a user
@@ -280,6 +308,147 @@ func (suite *AuthServiceTestSuite) TestNewAuthService() {
 	}
 }
 
+// Test that issued tokens carry a unique jti claim
+func (suite *AuthServiceTestSuite) TestTokenHasJTI() {
+	user := &models.User{ID: 1, Username: "testuser"}
+
+	token1, err := suite.helper.AuthService.GenerateToken(user)
+	assert.NoError(suite.T(), err)
+	token2, err := suite.helper.AuthService.GenerateToken(user)
+	assert.NoError(suite.T(), err)
+
+	claims1, err := suite.helper.AuthService.ValidateToken(token1)
+	assert.NoError(suite.T(), err)
+	claims2, err := suite.helper.AuthService.ValidateToken(token2)
+	assert.NoError(suite.T(), err)
+
+	assert.NotEmpty(suite.T(), claims1.ID)
+	assert.NotEqual(suite.T(), claims1.ID, claims2.ID)
+}
+
+// Test token revocation and blocklist cleanup
+func (suite *AuthServiceTestSuite) TestRevokeToken() {
+	ctx := context.Background()
+	authService := suite.helper.AuthService
+
+	user := &models.User{ID: 1, Username: "testuser"}
+	token, err := authService.GenerateToken(user)
+	assert.NoError(suite.T(), err)
+	claims, err := authService.ValidateToken(token)
+	assert.NoError(suite.T(), err)
+
+	revoked, err := authService.IsTokenRevoked(ctx, claims.ID)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), revoked)
+
+	assert.NoError(suite.T(), authService.RevokeToken(ctx, claims.ID))
+	// Revoking twice is harmless
+	assert.NoError(suite.T(), authService.RevokeToken(ctx, claims.ID))
+
+	revoked, err = authService.IsTokenRevoked(ctx, claims.ID)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), revoked)
+
+	assert.Error(suite.T(), authService.RevokeToken(ctx, ""))
+
+	// Expired entries are purged, live ones are kept
+	expired := models.RevokedToken{
+		JTI:       "expired-jti",
+		RevokedAt: time.Now().Add(-2 * time.Hour),
+		ExpiresAt: time.Now().Add(-1 * time.Hour),
+	}
+	assert.NoError(suite.T(), suite.helper.DB.Create(&expired).Error)
+
+	removed, err := authService.CleanupRevokedTokens(ctx)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), removed)
+
+	revoked, err = authService.IsTokenRevoked(ctx, "expired-jti")
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), revoked)
+
+	revoked, err = authService.IsTokenRevoked(ctx, claims.ID)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), revoked)
+}
+
+// Test API key generation stores only a hash
+func (suite *AuthServiceTestSuite) TestGenerateAPIKey() {
+	ctx := context.Background()
+	authService := suite.helper.AuthService
+
+	key, err := authService.GenerateAPIKey(ctx, suite.helper.TestUser.ID, "ci", []string{"read", "read", " write "}, 0)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), auth.IsAPIKey(key))
+
+	var stored models.APIKey
+	assert.NoError(suite.T(), suite.helper.DB.Where("key_hash = ?", auth.HashAPIKey(key)).First(&stored).Error)
+	assert.NotEqual(suite.T(), key, stored.KeyHash)
+	assert.True(suite.T(), strings.HasPrefix(key, stored.KeyPrefix))
+	assert.Equal(suite.T(), []string{"read", "write"}, stored.ScopeList())
+	assert.Nil(suite.T(), stored.ExpiresAt)
+
+	authenticated, err := authService.AuthenticateAPIKey(ctx, key)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), stored.ID, authenticated.ID)
+	assert.NotNil(suite.T(), authenticated.LastUsed)
+
+	_, err = authService.AuthenticateAPIKey(ctx, key+"x")
+	assert.ErrorIs(suite.T(), err, auth.ErrInvalidAPIKey)
+
+	// Expiry
+	shortLived, err := authService.GenerateAPIKey(ctx, suite.helper.TestUser.ID, "short", nil, time.Hour)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.helper.DB.Model(&models.APIKey{}).
+		Where("key_hash = ?", auth.HashAPIKey(shortLived)).
+		Update("expires_at", time.Now().Add(-time.Second)).Error)
+	_, err = authService.AuthenticateAPIKey(ctx, shortLived)
+	assert.ErrorIs(suite.T(), err, auth.ErrAPIKeyExpired)
+}
+
+// Test that repeated failed logins lock a username for exponentially longer
+func (suite *AuthServiceTestSuite) TestLoginLockoutBackoff() {
+	ctx := context.Background()
+	policy := auth.LockoutPolicy{Threshold: 3, Duration: time.Minute}
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		lock, err := policy.RecordFailure(ctx, "backoff-user", "192.0.2.1", now)
+		assert.NoError(suite.T(), err)
+		assert.Zero(suite.T(), lock)
+	}
+	lock, err := policy.RecordFailure(ctx, "backoff-user", "192.0.2.1", now)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), time.Minute, lock)
+
+	remaining, err := auth.LockedOut(ctx, "backoff-user", now.Add(20*time.Second))
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 40*time.Second, remaining)
+
+	// Each failure after the lockout ends doubles the next one
+	now = now.Add(time.Minute)
+	lock, _ = policy.RecordFailure(ctx, "backoff-user", "192.0.2.1", now)
+	assert.Equal(suite.T(), 2*time.Minute, lock)
+	now = now.Add(2 * time.Minute)
+	lock, _ = policy.RecordFailure(ctx, "backoff-user", "192.0.2.1", now)
+	assert.Equal(suite.T(), 4*time.Minute, lock)
+
+	// Failures are forgotten long after the lockout ends
+	now = now.Add(4*time.Minute + auth.MaxLockout + time.Second)
+	remaining, err = auth.LockedOut(ctx, "backoff-user", now)
+	assert.NoError(suite.T(), err)
+	assert.Zero(suite.T(), remaining)
+	lockouts, err := auth.ListLockouts(ctx, now)
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), lockouts)
+	lock, _ = policy.RecordFailure(ctx, "backoff-user", "192.0.2.1", now)
+	assert.Zero(suite.T(), lock)
+
+	cleared, err := auth.ClearLockout(ctx, "backoff-user")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), cleared)
+}
+
 func TestAuthServiceTestSuite(t *testing.T) {
 	suite.Run(t, new(AuthServiceTestSuite))
 }
@@ -0,0 +1,254 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"scriberr/internal/api"
+	"scriberr/internal/models"
+	"scriberr/internal/queue"
+	"scriberr/internal/transcription"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type BatchUploadTestSuite struct {
+	suite.Suite
+	helper *TestHelper
+	router *gin.Engine
+	audio  []byte
+}
+
+func (suite *BatchUploadTestSuite) SetupSuite() {
+	suite.helper = NewTestHelper(suite.T(), "batch_upload_test.db")
+	unifiedProcessor := transcription.NewUnifiedJobProcessor()
+	handler := api.NewHandler(suite.helper.Config, suite.helper.AuthService, queue.NewTaskQueue(1, unifiedProcessor), unifiedProcessor, nil)
+	suite.router = api.SetupRoutes(handler, suite.helper.AuthService)
+	suite.audio = writeWAV(suite.T(), filepath.Join(suite.T().TempDir(), "tone.wav"), 440)
+}
+
+func (suite *BatchUploadTestSuite) TearDownSuite() {
+	suite.helper.Cleanup()
+}
+
+// upload posts files as one batch, with names and contents in the same order
+func (suite *BatchUploadTestSuite) upload(query string, names []string, contents [][]byte, fields map[string]string, token string) *httptest.ResponseRecorder {
+	return suite.post("/api/v1/jobs/batch"+query, "audio", names, contents, fields, token)
+}
+
+// submit posts files as one all-or-nothing batch submission
+func (suite *BatchUploadTestSuite) submit(names []string, contents [][]byte, fields map[string]string) *httptest.ResponseRecorder {
+	return suite.post("/api/v1/transcriptions/batch", "files", names, contents, fields, suite.helper.TestToken)
+}
+
+func (suite *BatchUploadTestSuite) post(path, fileField string, names []string, contents [][]byte, fields map[string]string, token string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for i, name := range names {
+		part, err := writer.CreateFormFile(fileField, name)
+		require.NoError(suite.T(), err)
+		_, err = part.Write(contents[i])
+		require.NoError(suite.T(), err)
+	}
+	for key, value := range fields {
+		require.NoError(suite.T(), writer.WriteField(key, value))
+	}
+	require.NoError(suite.T(), writer.Close())
+
+	req, _ := http.NewRequest("POST", path, body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *BatchUploadTestSuite) get(path string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *BatchUploadTestSuite) jobCount() int64 {
+	var count int64
+	suite.helper.GetDB().Model(&models.TranscriptionJob{}).Count(&count)
+	return count
+}
+
+// Test that valid files become jobs and invalid ones are reported in place
+func (suite *BatchUploadTestSuite) TestBatchUpload() {
+	names := []string{"first.wav", "notes.txt", "second.wav"}
+	contents := [][]byte{suite.audio, []byte("not audio"), suite.audio}
+	w := suite.upload("", names, contents, map[string]string{"parameters": `{"model": "tiny", "language": "de"}`}, suite.helper.TestToken)
+	require.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+
+	var response api.BatchUploadResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(suite.T(), response.JobIDs, 3)
+	require.NotNil(suite.T(), response.JobIDs[0])
+	assert.Nil(suite.T(), response.JobIDs[1])
+	require.NotNil(suite.T(), response.JobIDs[2])
+	assert.Equal(suite.T(), "unsupported_type", response.Files[1].Code)
+	assert.Equal(suite.T(), "notes.txt", response.Files[1].Filename)
+
+	for i, id := range []string{*response.JobIDs[0], *response.JobIDs[2]} {
+		var job models.TranscriptionJob
+		require.NoError(suite.T(), suite.helper.GetDB().First(&job, "id = ?", id).Error)
+		assert.Equal(suite.T(), response.BatchID, *job.BatchID)
+		assert.Equal(suite.T(), models.StatusPending, job.Status)
+		assert.Equal(suite.T(), names[i*2], *job.Title)
+		assert.Equal(suite.T(), "tiny", job.Parameters.Model)
+		assert.Equal(suite.T(), "de", *job.Parameters.Language)
+		stored, err := os.ReadFile(job.AudioPath)
+		require.NoError(suite.T(), err)
+		assert.Equal(suite.T(), suite.audio, stored)
+	}
+
+	// The job list filters by batch
+	w = suite.get("/api/v1/transcription/list?batch_id=" + response.BatchID)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var list struct {
+		Jobs []models.TranscriptionJob `json:"jobs"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(suite.T(), list.Jobs, 2)
+
+	// Aggregate progress counts finished jobs as done
+	db := suite.helper.GetDB().Model(&models.TranscriptionJob{})
+	require.NoError(suite.T(), db.Where("id = ?", *response.JobIDs[0]).Update("status", models.StatusCompleted).Error)
+	require.NoError(suite.T(), suite.helper.GetDB().Model(&models.TranscriptionJob{}).Where("id = ?", *response.JobIDs[2]).
+		Updates(map[string]any{"status": models.StatusProcessing, "progress": 0.5}).Error)
+	w = suite.get("/api/v1/jobs/batch/" + response.BatchID)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var status api.BatchStatus
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(suite.T(), 2, status.Total)
+	assert.Equal(suite.T(), 1, status.Statuses[models.StatusCompleted])
+	assert.Equal(suite.T(), 1, status.Statuses[models.StatusProcessing])
+	assert.InDelta(suite.T(), 0.75, status.Progress, 0.001)
+	assert.False(suite.T(), status.Done)
+	assert.Len(suite.T(), status.Jobs, 2)
+
+	assert.Equal(suite.T(), http.StatusNotFound, suite.get("/api/v1/jobs/batch/missing").Code)
+}
+
+// Test that an atomic batch creates nothing when any file is invalid
+func (suite *BatchUploadTestSuite) TestAtomicBatch() {
+	before := suite.jobCount()
+	uploads, err := os.ReadDir(suite.helper.Config.UploadDir)
+	require.NoError(suite.T(), err)
+
+	names := []string{"good.wav", "bad.pdf"}
+	contents := [][]byte{suite.audio, []byte("%PDF")}
+	w := suite.upload("?atomic=true", names, contents, nil, suite.helper.TestToken)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code)
+	var response struct {
+		Details struct {
+			Files []api.BatchFileResult `json:"files"`
+		} `json:"details"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(suite.T(), response.Details.Files, 2)
+	assert.Empty(suite.T(), response.Details.Files[0].JobID)
+	assert.Equal(suite.T(), "unsupported_type", response.Details.Files[1].Code)
+
+	assert.Equal(suite.T(), before, suite.jobCount())
+	after, err := os.ReadDir(suite.helper.Config.UploadDir)
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), after, len(uploads))
+
+	// The same files succeed partially without atomic
+	w = suite.upload("", names, contents, nil, suite.helper.TestToken)
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.Equal(suite.T(), before+1, suite.jobCount())
+}
+
+// Test request validation
+func (suite *BatchUploadTestSuite) TestBatchValidation() {
+	token := suite.helper.TestToken
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.upload("", nil, nil, map[string]string{"title": "empty"}, token).Code)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, suite.upload("", []string{"a.txt"}, [][]byte{[]byte("text")}, nil, token).Code)
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.upload("", []string{"a.wav"}, [][]byte{suite.audio}, map[string]string{"parameters": "{"}, token).Code)
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.upload("", []string{"a.wav"}, [][]byte{suite.audio}, map[string]string{"parameters": `{"is_multi_track_enabled": true}`}, token).Code)
+
+	member := &models.User{ID: suite.helper.TestUser.ID, Username: suite.helper.TestUser.Username, Role: models.RoleUser}
+	memberToken, err := suite.helper.AuthService.GenerateToken(member)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusForbidden, suite.upload("", []string{"a.wav"}, [][]byte{suite.audio}, map[string]string{"priority": "1"}, memberToken).Code)
+}
+
+// Test that a batch submission creates a job per file and can be listed
+func (suite *BatchUploadTestSuite) TestBatchSubmission() {
+	names := []string{"lecture-1.wav", "lecture-2.wav"}
+	w := suite.submit(names, [][]byte{suite.audio, suite.audio}, map[string]string{"options": `{"model": "base"}`})
+	require.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+
+	var response api.BatchSubmissionResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.NotEmpty(suite.T(), response.BatchID)
+	require.Len(suite.T(), response.Jobs, 2)
+	for i, job := range response.Jobs {
+		assert.Equal(suite.T(), names[i], job.File)
+		assert.NotEmpty(suite.T(), job.JobID)
+	}
+
+	w = suite.get("/api/v1/transcriptions/batch/" + response.BatchID)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var batch api.BatchJobsResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &batch))
+	require.Len(suite.T(), batch.Jobs, 2)
+	for _, job := range batch.Jobs {
+		assert.Equal(suite.T(), response.BatchID, *job.BatchID)
+		assert.Equal(suite.T(), "base", job.Parameters.Model)
+	}
+
+	assert.Equal(suite.T(), http.StatusNotFound, suite.get("/api/v1/transcriptions/batch/missing").Code)
+}
+
+// Test that one invalid file keeps every job of a batch submission from being created
+func (suite *BatchUploadTestSuite) TestBatchSubmissionAtomicity() {
+	before := suite.jobCount()
+	uploads, err := os.ReadDir(suite.helper.Config.UploadDir)
+	require.NoError(suite.T(), err)
+
+	names := []string{"one.wav", "two.wav", "slides.pdf"}
+	w := suite.submit(names, [][]byte{suite.audio, suite.audio, []byte("%PDF")}, nil)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code)
+	var response struct {
+		Details struct {
+			Files []api.BatchFileResult `json:"files"`
+		} `json:"details"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(suite.T(), response.Details.Files, 3)
+	assert.Equal(suite.T(), "unsupported_type", response.Details.Files[2].Code)
+
+	assert.Equal(suite.T(), before, suite.jobCount())
+	after, err := os.ReadDir(suite.helper.Config.UploadDir)
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), after, len(uploads), "Expected the saved files to be removed")
+
+	// Batch submissions are limited to 20 files
+	names, contents := make([]string, 21), make([][]byte, 21)
+	for i := range names {
+		names[i], contents[i] = "part.wav", suite.audio
+	}
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.submit(names, contents, nil).Code)
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.submit([]string{"a.wav"}, [][]byte{suite.audio}, map[string]string{"options": "{"}).Code)
+	assert.Equal(suite.T(), before, suite.jobCount())
+}
+
+func TestBatchUploadTestSuite(t *testing.T) {
+	suite.Run(t, new(BatchUploadTestSuite))
+}
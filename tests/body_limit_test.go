@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"scriberr/internal/api"
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/models"
+	"scriberr/internal/queue"
+	"scriberr/internal/transcription"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	testJSONLimit   = 1 << 10
+	testUploadLimit = 64 << 10
+)
+
+type BodyLimitTestSuite struct {
+	suite.Suite
+	helper *TestHelper
+	router *gin.Engine
+}
+
+func (suite *BodyLimitTestSuite) SetupSuite() {
+	suite.helper = NewTestHelper(suite.T(), "body_limit_test.db")
+	suite.helper.Config.MaxJSONBodySize = testJSONLimit
+	suite.helper.Config.MaxUploadSize = testUploadLimit
+	unifiedProcessor := transcription.NewUnifiedJobProcessor()
+	handler := api.NewHandler(suite.helper.Config, suite.helper.AuthService, queue.NewTaskQueue(1, unifiedProcessor), unifiedProcessor, nil)
+	suite.router = api.SetupRoutes(handler, suite.helper.AuthService)
+}
+
+func (suite *BodyLimitTestSuite) TearDownSuite() {
+	suite.helper.Cleanup()
+}
+
+// countingReader counts the bytes read from it
+type countingReader struct {
+	r    io.Reader
+	read int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += int64(n)
+	return n, err
+}
+
+// send makes a request whose body has no declared length unless
+// contentLength is positive, and returns the response and the bytes the
+// server read of the body
+func (suite *BodyLimitTestSuite) send(method, url, contentType string, body []byte, contentLength int64) (*httptest.ResponseRecorder, int64) {
+	reader := &countingReader{r: bytes.NewReader(body)}
+	req, _ := http.NewRequest(method, url, reader)
+	req.ContentLength = -1
+	if contentLength > 0 {
+		req.ContentLength = contentLength
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w, reader.read
+}
+
+// multipartAudio returns a multipart body with content as the audio file
+func multipartAudio(filename string, content []byte) ([]byte, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("audio", filename)
+	part.Write(content)
+	writer.Close()
+	return body.Bytes(), writer.FormDataContentType()
+}
+
+func (suite *BodyLimitTestSuite) assertTooLarge(w *httptest.ResponseRecorder, limit int64) {
+	require.Equal(suite.T(), http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+	var envelope apierror.Envelope
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &envelope), "Expected one error envelope")
+	assert.Equal(suite.T(), apierror.CodePayloadTooLarge, envelope.Code)
+	assert.Equal(suite.T(), float64(limit), envelope.Details["max_size"])
+}
+
+// Test that JSON bodies past the limit are refused after reading no more
+// than the limit
+func (suite *BodyLimitTestSuite) TestJSONLimit() {
+	huge := []byte(`{"ids":["` + strings.Repeat("a", 100*testJSONLimit) + `"]}`)
+	w, read := suite.send("POST", "/api/v1/transcriptions/bulk/tags", "application/json", huge, 0)
+	suite.assertTooLarge(w, testJSONLimit)
+	assert.LessOrEqual(suite.T(), read, int64(testJSONLimit+1), "Expected the server to stop reading at the limit")
+
+	// A declared length past the limit is refused before reading anything
+	w, read = suite.send("POST", "/api/v1/transcriptions/bulk/tags", "application/json", huge, int64(len(huge)))
+	suite.assertTooLarge(w, testJSONLimit)
+	assert.Zero(suite.T(), read)
+
+	// Claiming another content type does not escape the limit
+	w, read = suite.send("POST", "/api/v1/transcriptions/bulk/tags", "text/plain", huge, 0)
+	suite.assertTooLarge(w, testJSONLimit)
+	assert.LessOrEqual(suite.T(), read, int64(testJSONLimit+1))
+
+	w, _ = suite.send("POST", "/api/v1/transcriptions/bulk/tags", "application/json", []byte(`{"ids":["a"],"add":["x"]}`), 0)
+	assert.NotEqual(suite.T(), http.StatusRequestEntityTooLarge, w.Code)
+}
+
+// Test that multipart uploads may exceed the JSON limit but not the upload
+// limit, and that the server stops reading at it
+func (suite *BodyLimitTestSuite) TestUploadLimit() {
+	body, contentType := multipartAudio("talk.mp3", fakeMP3(strings.Repeat("a", 8*testJSONLimit)))
+	w, _ := suite.send("POST", "/api/v1/transcription/upload", contentType, body, 0)
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+
+	body, contentType = multipartAudio("talk.mp3", fakeMP3(strings.Repeat("a", 50*testUploadLimit)))
+	w, read := suite.send("POST", "/api/v1/transcription/upload", contentType, body, 0)
+	suite.assertTooLarge(w, testUploadLimit)
+	assert.LessOrEqual(suite.T(), read, int64(testUploadLimit+1), "Expected the server to stop reading at the limit")
+}
+
+// Test that routes taking raw file chunks get the upload limit
+func (suite *BodyLimitTestSuite) TestRawUploadLimit() {
+	chunk := fakeMP3(strings.Repeat("a", 8*testJSONLimit))
+	payload, _ := json.Marshal(map[string]any{"filename": "talk.mp3", "size": len(chunk)})
+	w, _ := suite.send("POST", "/api/v1/transcription/uploads", "application/json", payload, 0)
+	require.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+	var session models.UploadSession
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &session))
+
+	req, _ := http.NewRequest("PATCH", "/api/v1/transcription/uploads/"+session.ID, bytes.NewReader(chunk))
+	req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Offset", "0")
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), strconv.Itoa(len(chunk)), w.Header().Get("Upload-Offset"))
+}
+
+// Test that uploads are checked by their content, not their name or
+// Content-Type
+func (suite *BodyLimitTestSuite) TestUploadTypeAllowlist() {
+	var before int64
+	suite.helper.GetDB().Model(&models.TranscriptionJob{}).Count(&before)
+
+	for _, upload := range []struct{ filename, content string }{
+		{"talk.mp3", "<?php system($_GET['c']); ?>"},
+		{"notes.txt", "ID3\x04\x00 looks like audio"},
+	} {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		header := make(map[string][]string)
+		header["Content-Disposition"] = []string{`form-data; name="audio"; filename="` + upload.filename + `"`}
+		header["Content-Type"] = []string{"audio/mpeg"}
+		part, _ := writer.CreatePart(header)
+		part.Write([]byte(upload.content))
+		writer.Close()
+
+		w, _ := suite.send("POST", "/api/v1/transcription/upload", writer.FormDataContentType(), body.Bytes(), 0)
+		require.Equal(suite.T(), http.StatusUnsupportedMediaType, w.Code, upload.filename)
+		var envelope apierror.Envelope
+		require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &envelope))
+		assert.Equal(suite.T(), apierror.CodeUnsupportedMedia, envelope.Code)
+	}
+
+	var after int64
+	suite.helper.GetDB().Model(&models.TranscriptionJob{}).Count(&after)
+	assert.Equal(suite.T(), before, after, "Expected no jobs for refused uploads")
+
+	payload := []byte(`{"filename":"slides.pdf","size":100}`)
+	w, _ := suite.send("POST", "/api/v1/transcription/uploads", "application/json", payload, 0)
+	assert.Equal(suite.T(), http.StatusUnsupportedMediaType, w.Code)
+}
+
+func TestBodyLimitTestSuite(t *testing.T) {
+	suite.Run(t, new(BodyLimitTestSuite))
+}
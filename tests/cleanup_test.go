@@ -0,0 +1,236 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"scriberr/internal/api"
+	"scriberr/internal/cleanup"
+	"scriberr/internal/models"
+	"scriberr/internal/queue"
+	"scriberr/internal/transcription"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type CleanupTestSuite struct {
+	suite.Suite
+	helper *TestHelper
+	router *gin.Engine
+}
+
+func (suite *CleanupTestSuite) SetupSuite() {
+	suite.helper = NewTestHelper(suite.T(), "cleanup_test.db")
+	suite.helper.Config.AudioRetentionDays = 7
+	suite.helper.Config.JobRetentionDays = 30
+	suite.helper.Config.DeletedJobRetentionDays = 30
+	unifiedProcessor := transcription.NewUnifiedJobProcessor()
+	handler := api.NewHandler(suite.helper.Config, suite.helper.AuthService, queue.NewTaskQueue(1, unifiedProcessor), unifiedProcessor, nil)
+	suite.router = api.SetupRoutes(handler, suite.helper.AuthService)
+}
+
+func (suite *CleanupTestSuite) TearDownSuite() {
+	suite.helper.Cleanup()
+}
+
+func (suite *CleanupTestSuite) request(method, path string, body interface{}) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, _ := http.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// completedJob creates a job that finished daysAgo days ago with size bytes of audio
+func (suite *CleanupTestSuite) completedJob(title string, daysAgo, size int) *models.TranscriptionJob {
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), title)
+	path := filepath.Join(suite.helper.Config.UploadDir, job.ID+".wav")
+	require.NoError(suite.T(), os.WriteFile(path, make([]byte, size), 0644))
+	completedAt := time.Now().AddDate(0, 0, -daysAgo)
+	require.NoError(suite.T(), suite.helper.GetDB().Model(job).Updates(map[string]interface{}{
+		"status":       models.StatusCompleted,
+		"audio_path":   path,
+		"transcript":   `{"text":"hello"}`,
+		"completed_at": completedAt,
+	}).Error)
+	job.AudioPath = path
+	return job
+}
+
+func (suite *CleanupTestSuite) getJob(id string) (*models.TranscriptionJob, error) {
+	var job models.TranscriptionJob
+	err := suite.helper.GetDB().Where("id = ?", id).First(&job).Error
+	return &job, err
+}
+
+// Test that the preview lists due jobs without removing anything and a run
+// removes exactly what it listed
+func (suite *CleanupTestSuite) TestPreviewAndRun() {
+	audioDue := suite.completedJob("Audio due", 10, 1000)
+	jobDue := suite.completedJob("Job due", 40, 500)
+	suite.helper.CreateTestNote(suite.T(), jobDue.ID)
+	pinned := suite.completedJob("Pinned", 40, 100)
+	require.NoError(suite.T(), suite.helper.GetDB().Model(pinned).Update("pinned", true).Error)
+	recent := suite.completedJob("Recent", 1, 100)
+	keepAudio := suite.completedJob("Keep audio", 10, 100)
+	require.NoError(suite.T(), suite.helper.GetDB().Model(keepAudio).Update("audio_retention_days", 0).Error)
+
+	w := suite.request("GET", "/api/v1/admin/cleanup/preview", nil)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var preview api.CleanupPreviewResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &preview))
+	require.Len(suite.T(), preview.Actions, 2)
+	assert.Equal(suite.T(), jobDue.ID, preview.Actions[0].JobID)
+	assert.Equal(suite.T(), cleanup.ActionDeleteJob, preview.Actions[0].Action)
+	assert.Equal(suite.T(), audioDue.ID, preview.Actions[1].JobID)
+	assert.Equal(suite.T(), cleanup.ActionDeleteAudio, preview.Actions[1].Action)
+	assert.Equal(suite.T(), int64(1500), preview.ReclaimableBytes)
+	assert.FileExists(suite.T(), audioDue.AudioPath, "Expected the preview not to delete anything")
+
+	w = suite.request("POST", "/api/v1/admin/cleanup/run", nil)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var run models.CleanupRun
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &run))
+	assert.Equal(suite.T(), 1, run.AudioDeleted)
+	assert.Equal(suite.T(), 1, run.JobsDeleted)
+	assert.Equal(suite.T(), int64(1500), run.ReclaimedBytes)
+
+	// The transcript outlives its audio
+	job, err := suite.getJob(audioDue.ID)
+	require.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), job.AudioDeletedAt)
+	assert.NotNil(suite.T(), job.Transcript)
+	assert.NoFileExists(suite.T(), audioDue.AudioPath)
+	w = suite.request("GET", "/api/v1/transcription/"+audioDue.ID+"/audio", nil)
+	assert.Equal(suite.T(), http.StatusGone, w.Code)
+	w = suite.request("POST", "/api/v1/transcription/"+audioDue.ID+"/start", nil)
+	assert.Equal(suite.T(), http.StatusGone, w.Code)
+
+	_, err = suite.getJob(jobDue.ID)
+	assert.Error(suite.T(), err, "Expected the old job to be deleted")
+	var notes int64
+	suite.helper.GetDB().Model(&models.Note{}).Where("transcription_id = ?", jobDue.ID).Count(&notes)
+	assert.Zero(suite.T(), notes)
+
+	for _, kept := range []*models.TranscriptionJob{pinned, recent, keepAudio} {
+		assert.FileExists(suite.T(), kept.AudioPath, "Expected %s to be kept", *kept.Title)
+	}
+
+	w = suite.request("GET", "/api/v1/admin/cleanup/stats", nil)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var stats cleanup.Stats
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(suite.T(), int64(1500), stats.ReclaimedBytes)
+	assert.Equal(suite.T(), int64(1), stats.JobsDeleted)
+	assert.Equal(suite.T(), 7, stats.Policy.AudioRetentionDays)
+	require.NotNil(suite.T(), stats.LastRun)
+
+	// Nothing is left to do
+	w = suite.request("GET", "/api/v1/admin/cleanup/preview", nil)
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &preview))
+	assert.Empty(suite.T(), preview.Actions)
+}
+
+// Test that deleted jobs can be restored until cleanup purges them
+func (suite *CleanupTestSuite) TestSoftDeleteAndRestore() {
+	job := suite.completedJob("Deleted", 1, 300)
+	path := "/api/v1/transcriptions/" + job.ID
+
+	w := suite.request("DELETE", path, nil)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(suite.T(), w.Body.String(), "purge_after")
+	assert.Equal(suite.T(), http.StatusNotFound, suite.request("GET", "/api/v1/transcription/"+job.ID, nil).Code)
+	assert.FileExists(suite.T(), job.AudioPath, "Expected deletion to keep the audio")
+
+	w = suite.request("POST", path+"/restore", nil)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	w = suite.request("GET", "/api/v1/transcription/"+job.ID, nil)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var restored models.TranscriptionJob
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &restored))
+	assert.Equal(suite.T(), job.AudioPath, restored.AudioPath)
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.request("POST", path+"/restore", nil).Code)
+	assert.Equal(suite.T(), http.StatusNotFound, suite.request("POST", "/api/v1/transcriptions/missing/restore", nil).Code)
+
+	// Deleted jobs are purged once the retention window has passed
+	require.Equal(suite.T(), http.StatusOK, suite.request("DELETE", path, nil).Code)
+	policy := cleanup.Policy{DeletedRetentionDays: 30}
+	setDeletedDaysAgo := func(days int) {
+		require.NoError(suite.T(), suite.helper.GetDB().Unscoped().Model(&models.TranscriptionJob{}).
+			Where("id = ?", job.ID).Update("deleted_at", time.Now().AddDate(0, 0, -days)).Error)
+	}
+	setDeletedDaysAgo(29)
+	run, err := cleanup.Run(context.Background(), policy, time.Now())
+	require.NoError(suite.T(), err)
+	assert.Zero(suite.T(), run.JobsDeleted)
+	assert.FileExists(suite.T(), job.AudioPath)
+
+	setDeletedDaysAgo(31)
+	actions, err := cleanup.Preview(context.Background(), policy, time.Now())
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), actions, 1)
+	assert.Equal(suite.T(), cleanup.ActionPurgeJob, actions[0].Action)
+	assert.Equal(suite.T(), int64(300), actions[0].Bytes)
+
+	// Retention without a deleted job window keeps them
+	run, err = cleanup.Run(context.Background(), cleanup.Policy{}, time.Now())
+	require.NoError(suite.T(), err)
+	assert.Zero(suite.T(), run.JobsDeleted)
+
+	run, err = cleanup.Run(context.Background(), policy, time.Now())
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, run.JobsDeleted)
+	assert.NoFileExists(suite.T(), job.AudioPath)
+	var count int64
+	suite.helper.GetDB().Unscoped().Model(&models.TranscriptionJob{}).Where("id = ?", job.ID).Count(&count)
+	assert.Zero(suite.T(), count)
+	assert.Equal(suite.T(), http.StatusNotFound, suite.request("POST", path+"/restore", nil).Code)
+}
+
+// Test pinning a job and overriding its retention
+func (suite *CleanupTestSuite) TestUpdateJobRetention() {
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Retention")
+
+	w := suite.request("PATCH", "/api/v1/transcriptions/"+job.ID+"/retention", gin.H{"pinned": true, "audio_retention_days": 3})
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	updated, err := suite.getJob(job.ID)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), updated.Pinned)
+	require.NotNil(suite.T(), updated.AudioRetentionDays)
+	assert.Equal(suite.T(), 3, *updated.AudioRetentionDays)
+
+	// -1 returns to the global policy
+	w = suite.request("PATCH", "/api/v1/transcriptions/"+job.ID+"/retention", gin.H{"audio_retention_days": -1})
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	updated, err = suite.getJob(job.ID)
+	require.NoError(suite.T(), err)
+	assert.Nil(suite.T(), updated.AudioRetentionDays)
+	assert.True(suite.T(), updated.Pinned)
+
+	w = suite.request("PATCH", "/api/v1/transcriptions/"+job.ID+"/retention", gin.H{"job_retention_days": -5})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	w = suite.request("PATCH", "/api/v1/transcriptions/missing/retention", gin.H{"pinned": true})
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func TestCleanupTestSuite(t *testing.T) {
+	suite.Run(t, new(CleanupTestSuite))
+}
@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"scriberr/internal/api"
+	"scriberr/internal/models"
+	"scriberr/internal/queue"
+	"scriberr/internal/transcription"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type CORSTestSuite struct {
+	suite.Suite
+	helper *TestHelper
+	router *gin.Engine
+}
+
+func (suite *CORSTestSuite) SetupSuite() {
+	suite.helper = NewTestHelper(suite.T(), "cors_test.db")
+	suite.router = suite.newRouter("https://app.example.com", "https://*.scriberr.dev", "chrome-extension://abcdefghijklmnop")
+}
+
+func (suite *CORSTestSuite) TearDownSuite() {
+	suite.helper.Cleanup()
+}
+
+func (suite *CORSTestSuite) newRouter(origins ...string) *gin.Engine {
+	cfg := *suite.helper.Config
+	cfg.AllowedOrigins = origins
+	unifiedProcessor := transcription.NewUnifiedJobProcessor()
+	handler := api.NewHandler(&cfg, suite.helper.AuthService, queue.NewTaskQueue(1, unifiedProcessor), unifiedProcessor, nil)
+	return api.SetupRoutes(handler, suite.helper.AuthService)
+}
+
+// preflight sends the preflight a browser sends before a multipart upload
+// with credentials to POST /api/jobs
+func (suite *CORSTestSuite) preflight(router *gin.Engine, origin string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("OPTIONS", "/api/jobs", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "authorization,x-api-key")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *CORSTestSuite) get(router *gin.Engine, origin string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/api/v1/transcription/list", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// Test that a listed origin may upload with credentials
+func (suite *CORSTestSuite) TestPreflightAllowedOrigin() {
+	w := suite.preflight(suite.router, "https://app.example.com")
+	require.Equal(suite.T(), http.StatusNoContent, w.Code)
+	assert.Equal(suite.T(), "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(suite.T(), "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Contains(suite.T(), w.Header().Get("Access-Control-Allow-Methods"), "POST")
+	assert.Contains(suite.T(), w.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+	assert.Contains(suite.T(), w.Header().Get("Access-Control-Allow-Headers"), "X-API-Key")
+	assert.Contains(suite.T(), w.Header().Values("Vary"), "Origin")
+
+	w = suite.preflight(suite.router, "chrome-extension://abcdefghijklmnop")
+	require.Equal(suite.T(), http.StatusNoContent, w.Code)
+	assert.Equal(suite.T(), "chrome-extension://abcdefghijklmnop", w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+// Test that subdomains of a wildcard entry are allowed, without credentials
+func (suite *CORSTestSuite) TestPreflightWildcardOrigin() {
+	w := suite.preflight(suite.router, "https://notes.scriberr.dev")
+	require.Equal(suite.T(), http.StatusNoContent, w.Code)
+	assert.Equal(suite.T(), "https://notes.scriberr.dev", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(suite.T(), w.Header().Get("Access-Control-Allow-Credentials"))
+
+	for _, origin := range []string{"https://scriberr.dev", "http://notes.scriberr.dev", "https://notes.scriberr.dev:8443", "https://evilscriberr.dev"} {
+		assert.Equal(suite.T(), http.StatusForbidden, suite.preflight(suite.router, origin).Code, origin)
+	}
+}
+
+// Test that other origins get no CORS headers
+func (suite *CORSTestSuite) TestPreflightDisallowedOrigin() {
+	w := suite.preflight(suite.router, "https://evil.example.com")
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	assert.Empty(suite.T(), w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(suite.T(), w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Empty(suite.T(), w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Contains(suite.T(), w.Header().Values("Vary"), "Origin")
+}
+
+// Test the headers of requests that are not preflights
+func (suite *CORSTestSuite) TestSimpleRequests() {
+	w := suite.get(suite.router, "https://app.example.com")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(suite.T(), w.Header().Get("Access-Control-Expose-Headers"), "X-RateLimit-Remaining")
+
+	// Other origins are served, but the browser keeps the response from them
+	w = suite.get(suite.router, "https://evil.example.com")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Empty(suite.T(), w.Header().Get("Access-Control-Allow-Origin"))
+
+	// Caches must not reuse a response for another origin
+	w = suite.get(suite.router, "")
+	assert.Contains(suite.T(), w.Header().Values("Vary"), "Origin")
+}
+
+// Test that the streamed chat response keeps the origin checks of the
+// middleware
+func (suite *CORSTestSuite) TestChatStream() {
+	llmServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Hello"},"done":true}`)
+	}))
+	defer llmServer.Close()
+	llmConfig := &models.LLMConfig{Provider: "ollama", BaseURL: &llmServer.URL, IsActive: true}
+	require.NoError(suite.T(), suite.helper.GetDB().Create(llmConfig).Error)
+	defer suite.helper.GetDB().Delete(llmConfig)
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Chat CORS")
+	session := suite.helper.CreateTestChatSession(suite.T(), job.ID)
+
+	send := func(router *gin.Engine, origin string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/v1/chat/sessions/"+session.ID+"/messages", strings.NewReader(`{"content":"Hi"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(suite.router, "https://evil.example.com")
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(suite.T(), w.Body.String(), "Hello")
+	assert.Empty(suite.T(), w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = send(suite.router, "https://app.example.com")
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = send(suite.newRouter(), "https://evil.example.com")
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), "*", w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+// Test that without allowed origins the API answers any origin as before
+func (suite *CORSTestSuite) TestNoAllowedOrigins() {
+	router := suite.newRouter()
+	w := suite.preflight(router, "https://evil.example.com")
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+	assert.Equal(suite.T(), "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(suite.T(), w.Header().Get("Access-Control-Allow-Credentials"))
+
+	w = suite.get(router, "https://app.example.com")
+	assert.Equal(suite.T(), "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(suite.T(), w.Header().Values("Vary"))
+}
+
+func TestCORSTestSuite(t *testing.T) {
+	suite.Run(t, new(CORSTestSuite))
+}
@@ -47,6 +47,34 @@ func (suite *DatabaseTestSuite) TestDatabaseInitialization() {
 	database.DB = originalDB
 }
 
+// Test that initialization gives jobs without an owner to the first admin
+func (suite *DatabaseTestSuite) TestAssignUnownedJobs() {
+	testDbPath := "test_init_owners.db"
+	defer os.Remove(testDbPath)
+	originalDB := database.DB
+	defer func() { database.DB = originalDB }()
+
+	assert.NoError(suite.T(), database.Initialize(testDbPath))
+	member := models.User{Username: "member", Password: "x", Role: models.RoleUser}
+	admin := models.User{Username: "admin", Password: "x", Role: models.RoleAdmin}
+	assert.NoError(suite.T(), database.DB.Create(&member).Error)
+	assert.NoError(suite.T(), database.DB.Create(&admin).Error)
+	unowned := models.TranscriptionJob{ID: "unowned-job", AudioPath: "a.mp3", Status: models.StatusCompleted}
+	owned := models.TranscriptionJob{ID: "owned-job", AudioPath: "b.mp3", Status: models.StatusCompleted, UserID: &member.ID}
+	assert.NoError(suite.T(), database.DB.Create(&unowned).Error)
+	assert.NoError(suite.T(), database.DB.Create(&owned).Error)
+	assert.NoError(suite.T(), database.Close())
+
+	assert.NoError(suite.T(), database.Initialize(testDbPath))
+	defer database.Close()
+	var jobs []models.TranscriptionJob
+	assert.NoError(suite.T(), database.DB.Order("id").Find(&jobs).Error)
+	if assert.Len(suite.T(), jobs, 2) {
+		assert.Equal(suite.T(), member.ID, *jobs[0].UserID, "Owned jobs should keep their owner")
+		assert.Equal(suite.T(), admin.ID, *jobs[1].UserID, "Unowned jobs should go to the admin")
+	}
+}
+
 // Test database initialization with invalid path
 func (suite *DatabaseTestSuite) TestDatabaseInitializationInvalidPath() {
 	// Try to initialize with an invalid path (directory doesn't exist and can't be created)
@@ -110,7 +138,7 @@ func (suite *DatabaseTestSuite) TestAPIKeyCRUD() {
 
 	// Create
 	apiKey := models.APIKey{
-		Key:         "test-api-key-crud-12345",
+		KeyHash:     "test-api-key-crud-12345",
 		Name:        "Test CRUD API Key",
 		Description: stringPtr("Test description"),
 		IsActive:    true,
@@ -122,9 +150,9 @@ func (suite *DatabaseTestSuite) TestAPIKeyCRUD() {
 
 	// Read
 	var foundKey models.APIKey
-	result = db.Where("key = ?", "test-api-key-crud-12345").First(&foundKey)
+	result = db.Where("key_hash = ?", "test-api-key-crud-12345").First(&foundKey)
 	assert.NoError(suite.T(), result.Error)
-	assert.Equal(suite.T(), apiKey.Key, foundKey.Key)
+	assert.Equal(suite.T(), apiKey.KeyHash, foundKey.KeyHash)
 	assert.Equal(suite.T(), apiKey.Name, foundKey.Name)
 	assert.True(suite.T(), foundKey.IsActive)
 
@@ -374,12 +402,12 @@ func (suite *DatabaseTestSuite) TestUniqueConstraints() {
 
 	// Test API key uniqueness
 	apiKey1 := models.APIKey{
-		Key:      "unique-api-key-test",
+		KeyHash:  "unique-api-key-test",
 		Name:     "First Key",
 		IsActive: true,
 	}
 	apiKey2 := models.APIKey{
-		Key:      "unique-api-key-test", // Same key
+		KeyHash:  "unique-api-key-test", // Same key
 		Name:     "Second Key",
 		IsActive: true,
 	}
@@ -388,7 +416,7 @@ func (suite *DatabaseTestSuite) TestUniqueConstraints() {
 	assert.NoError(suite.T(), result.Error)
 
 	result = db.Create(&apiKey2)
-	assert.Error(suite.T(), result.Error, "Should fail due to unique constraint on API key")
+	assert.Error(suite.T(), result.Error, "Should fail due to unique constraint on API key hash")
 
 	// Clean up
 	db.Delete(&user1)
@@ -400,12 +428,12 @@ func (suite *DatabaseTestSuite) TestDatabaseQueries() {
 	db := suite.helper.GetDB()
 	// Create multiple API keys with different statuses
 	activeKey := models.APIKey{
-		Key:      "active-key-query-test",
+		KeyHash:  "active-key-query-test",
 		Name:     "Active Key",
 		IsActive: true,
 	}
 	inactiveKey := models.APIKey{
-		Key:      "inactive-key-query-test",
+		KeyHash:  "inactive-key-query-test",
 		Name:     "Inactive Key",
 		IsActive: false,
 	}
@@ -421,7 +449,7 @@ func (suite *DatabaseTestSuite) TestDatabaseQueries() {
 	// Should include at least our test active key
 	found := false
 	for _, key := range activeKeys {
-		if key.Key == "active-key-query-test" {
+		if key.KeyHash == "active-key-query-test" {
 			found = true
 			break
 		}
@@ -436,7 +464,7 @@ func (suite *DatabaseTestSuite) TestDatabaseQueries() {
 	// Should include our inactive key
 	found = false
 	for _, key := range inactiveKeys {
-		if key.Key == "inactive-key-query-test" {
+		if key.KeyHash == "inactive-key-query-test" {
 			found = true
 			break
 		}
@@ -459,6 +487,48 @@ func (suite *DatabaseTestSuite) TestDatabaseClose() {
 	})
 }
 
+// Test adding, reading and removing job tags
+func (suite *DatabaseTestSuite) TestTags() {
+	// Earlier tests re-initialize the global connection
+	database.DB = suite.helper.GetDB()
+
+	acme := suite.helper.CreateTestTranscriptionJob(suite.T(), "Acme interview")
+	other := suite.helper.CreateTestTranscriptionJob(suite.T(), "Other interview")
+
+	assert.NoError(suite.T(), database.AddTag(acme.ID, "client", "acme"))
+	assert.NoError(suite.T(), database.AddTag(acme.ID, "speaker", "alice"))
+	assert.NoError(suite.T(), database.AddTag(acme.ID, "speaker", "bob"))
+	assert.NoError(suite.T(), database.AddTag(acme.ID, "speaker", "bob"), "Adding a tag twice should be ignored")
+	assert.NoError(suite.T(), database.AddTag(other.ID, "client", "globex"))
+
+	tags, err := database.GetTags(acme.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), map[string][]string{"client": {"acme"}, "speaker": {"alice", "bob"}}, tags)
+
+	jobs, err := database.ListJobsByTag("client", "acme")
+	assert.NoError(suite.T(), err)
+	if assert.Len(suite.T(), jobs, 1) {
+		assert.Equal(suite.T(), acme.ID, jobs[0].ID)
+	}
+	jobs, err = database.ListJobsByTag("client", "")
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), jobs, 2)
+
+	removed, err := database.RemoveTag(acme.ID, "speaker", "alice")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), removed)
+	removed, err = database.RemoveTag(acme.ID, "client", "")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), removed)
+
+	tags, err = database.GetTags(acme.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), map[string][]string{"speaker": {"bob"}}, tags)
+	jobs, err = database.ListJobsByTag("client", "acme")
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), jobs)
+}
+
 func TestDatabaseTestSuite(t *testing.T) {
 	suite.Run(t, new(DatabaseTestSuite))
 }
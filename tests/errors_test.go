@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"scriberr/internal/api"
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/queue"
+	"scriberr/internal/transcription"
+	"scriberr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type ErrorEnvelopeTestSuite struct {
+	suite.Suite
+	helper *TestHelper
+	router *gin.Engine
+}
+
+func (suite *ErrorEnvelopeTestSuite) SetupSuite() {
+	suite.helper = NewTestHelper(suite.T(), "errors_test.db")
+	unifiedProcessor := transcription.NewUnifiedJobProcessor()
+	handler := api.NewHandler(suite.helper.Config, suite.helper.AuthService, queue.NewTaskQueue(1, unifiedProcessor), unifiedProcessor, nil)
+	suite.router = api.SetupRoutes(handler, suite.helper.AuthService)
+}
+
+func (suite *ErrorEnvelopeTestSuite) TearDownSuite() {
+	suite.helper.Cleanup()
+}
+
+func (suite *ErrorEnvelopeTestSuite) request(method, url, body, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// envelope decodes an error response, checking the fields every error has
+func (suite *ErrorEnvelopeTestSuite) envelope(w *httptest.ResponseRecorder, status int, code apierror.Code) map[string]interface{} {
+	require.Equal(suite.T(), status, w.Code, w.Body.String())
+	var envelope map[string]interface{}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &envelope))
+	assert.Equal(suite.T(), string(code), envelope["code"])
+	assert.NotEmpty(suite.T(), envelope["error"])
+	assert.Equal(suite.T(), api.APIVersion, envelope["api_version"])
+	assert.NotEmpty(suite.T(), envelope["request_id"])
+	assert.Equal(suite.T(), envelope["request_id"], w.Header().Get(logger.RequestIDHeader))
+	return envelope
+}
+
+// Test the envelope of representative failures
+func (suite *ErrorEnvelopeTestSuite) TestEnvelopeShape() {
+	token := suite.helper.TestToken
+
+	envelope := suite.envelope(suite.request("GET", "/api/v1/transcription/missing", "", token), http.StatusNotFound, apierror.CodeJobNotFound)
+	assert.Equal(suite.T(), "Job not found", envelope["error"])
+	assert.NotContains(suite.T(), envelope, "details")
+
+	suite.envelope(suite.request("POST", "/api/v1/transcriptions/bulk/tags", `{"ids":["a"]}`, token), http.StatusBadRequest, apierror.CodeValidationFailed)
+	suite.envelope(suite.request("GET", "/api/v1/transcription/list", "", ""), http.StatusUnauthorized, apierror.CodeUnauthorized)
+	suite.envelope(suite.request("GET", "/api/v1/chat/models", "", token), http.StatusServiceUnavailable, apierror.CodeEngineUnavailable)
+
+	// Unknown API paths are answered by the web app's NoRoute handler
+	for _, url := range []string{"/api/v1/unknown", "/api/unknown"} {
+		envelope := suite.envelope(suite.request("GET", url, "", ""), http.StatusNotFound, apierror.CodeNotFound)
+		assert.Equal(suite.T(), "API endpoint not found", envelope["error"], url)
+	}
+}
+
+// Test that a client's request ID is echoed, and unusable ones replaced
+func (suite *ErrorEnvelopeTestSuite) TestRequestID() {
+	for id, echoed := range map[string]bool{"client-id-42": true, "has space": false, string(make([]byte, 65)): false} {
+		req, _ := http.NewRequest("GET", "/api/v1/unknown", nil)
+		req.Header.Set(logger.RequestIDHeader, id)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		var envelope apierror.Envelope
+		require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &envelope))
+		assert.Equal(suite.T(), w.Header().Get(logger.RequestIDHeader), envelope.RequestID)
+		assert.Equal(suite.T(), echoed, envelope.RequestID == id, "%q", id)
+		assert.NotEmpty(suite.T(), envelope.RequestID)
+	}
+}
+
+func TestErrorEnvelopeTestSuite(t *testing.T) {
+	suite.Run(t, new(ErrorEnvelopeTestSuite))
+}
@@ -0,0 +1,300 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"scriberr/internal/api"
+	"scriberr/internal/events"
+	"scriberr/internal/models"
+	"scriberr/internal/queue"
+	"scriberr/internal/transcription"
+	"scriberr/internal/websocket"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type EventSocketTestSuite struct {
+	suite.Suite
+	helper  *TestHelper
+	handler *api.Handler
+	server  *httptest.Server
+}
+
+func (suite *EventSocketTestSuite) SetupSuite() {
+	suite.helper = NewTestHelper(suite.T(), "event_socket_test.db")
+}
+
+func (suite *EventSocketTestSuite) TearDownSuite() {
+	suite.helper.Cleanup()
+}
+
+// SetupTest starts a server per test, as shutting one down is tested
+func (suite *EventSocketTestSuite) SetupTest() {
+	unifiedProcessor := transcription.NewUnifiedJobProcessor()
+	suite.handler = api.NewHandler(suite.helper.Config, suite.helper.AuthService, queue.NewTaskQueue(1, unifiedProcessor), unifiedProcessor, nil)
+	suite.server = httptest.NewServer(api.SetupRoutes(suite.handler, suite.helper.AuthService))
+}
+
+func (suite *EventSocketTestSuite) TearDownTest() {
+	suite.handler.CloseEventSockets()
+	suite.server.Close()
+}
+
+// socketClient is the client side of an event socket
+type socketClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// dialSocket sends a WebSocket upgrade for path to server and returns the
+// response, and the client if the server switched protocols
+func dialSocket(t *testing.T, server *httptest.Server, path string, header http.Header) (*socketClient, *http.Response) {
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	req, _ := http.NewRequest("GET", server.URL+path, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	require.NoError(t, req.Write(conn))
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	require.NoError(t, err)
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, resp
+	}
+	return &socketClient{t: t, conn: conn, r: r}, resp
+}
+
+func (suite *EventSocketTestSuite) dial(path string, header http.Header) (*socketClient, *http.Response) {
+	return dialSocket(suite.T(), suite.server, path, header)
+}
+
+// connect opens a socket with a token in the query, as browsers do
+func (suite *EventSocketTestSuite) connect(token, query string) *socketClient {
+	client, resp := suite.dial("/api/ws?token="+token+"&"+query, nil)
+	require.Equal(suite.T(), http.StatusSwitchingProtocols, resp.StatusCode)
+	var reply api.EventSocketReply
+	client.readJSON(&reply)
+	require.Equal(suite.T(), "subscribed", reply.Type)
+	return client
+}
+
+// send sends v as a masked text message
+func (c *socketClient) send(v any) {
+	payload, err := json.Marshal(v)
+	require.NoError(c.t, err)
+	c.write(websocket.OpText, payload)
+}
+
+// write sends a masked frame
+func (c *socketClient) write(op int, payload []byte) {
+	require.LessOrEqual(c.t, len(payload), 0xFFFF)
+	frame := []byte{0x80 | byte(op)}
+	if len(payload) < 126 {
+		frame = append(frame, 0x80|byte(len(payload)))
+	} else {
+		frame = binary.BigEndian.AppendUint16(append(frame, 0x80|126), uint16(len(payload)))
+	}
+	mask := []byte{7, 1, 8, 3}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := c.conn.Write(frame)
+	require.NoError(c.t, err)
+}
+
+// read reads a frame from the server
+func (c *socketClient) read() (int, []byte) {
+	var header [2]byte
+	_, err := io.ReadFull(c.r, header[:])
+	require.NoError(c.t, err)
+	length := int(header[1] & 0x7F)
+	if length == 126 {
+		var extended [2]byte
+		io.ReadFull(c.r, extended[:])
+		length = int(binary.BigEndian.Uint16(extended[:]))
+	}
+	payload := make([]byte, length)
+	_, err = io.ReadFull(c.r, payload)
+	require.NoError(c.t, err)
+	return int(header[0] & 0x0F), payload
+}
+
+// readJSON reads the next text message into v
+func (c *socketClient) readJSON(v any) {
+	op, payload := c.read()
+	require.Equal(c.t, websocket.OpText, op, "unexpected frame %q", payload)
+	require.NoError(c.t, json.Unmarshal(payload, v))
+}
+
+// expectNothing checks that no message arrives for a moment
+func (c *socketClient) expectNothing() {
+	c.conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	_, err := c.r.Peek(1)
+	assert.Error(c.t, err, "expected no message")
+	c.conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+}
+
+func (suite *EventSocketTestSuite) createJob(owner *uint, folder *uint) *models.TranscriptionJob {
+	job := &models.TranscriptionJob{Status: models.StatusPending, AudioPath: "test/path/audio.mp3", UserID: owner, FolderID: folder}
+	require.NoError(suite.T(), suite.helper.GetDB().Create(job).Error)
+	return job
+}
+
+func (suite *EventSocketTestSuite) member(name string) (*models.User, string) {
+	user := &models.User{Username: name, Password: "unused", Role: models.RoleUser}
+	require.NoError(suite.T(), suite.helper.GetDB().Create(user).Error)
+	token, err := suite.helper.AuthService.GenerateToken(user)
+	require.NoError(suite.T(), err)
+	return user, token
+}
+
+func (suite *EventSocketTestSuite) TestUpgradeRequiresAuthentication() {
+	_, resp := suite.dial("/api/ws", nil)
+	assert.Equal(suite.T(), http.StatusUnauthorized, resp.StatusCode)
+
+	_, resp = suite.dial("/api/ws?token=not-a-token", nil)
+	assert.Equal(suite.T(), http.StatusUnauthorized, resp.StatusCode)
+
+	// API keys work like on every other route
+	client, resp := suite.dial("/api/v1/ws", http.Header{"X-Api-Key": {suite.helper.TestAPIKey}})
+	require.Equal(suite.T(), http.StatusSwitchingProtocols, resp.StatusCode)
+	client.conn.Close()
+
+	// Plain requests and bad filters are refused
+	req, _ := http.NewRequest("GET", suite.server.URL+"/api/ws", nil)
+	req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+	plain, err := http.DefaultClient.Do(req)
+	require.NoError(suite.T(), err)
+	plain.Body.Close()
+	assert.Equal(suite.T(), http.StatusBadRequest, plain.StatusCode)
+
+	_, resp = suite.dial("/api/ws?types=job.deleted", http.Header{"Authorization": {"Bearer " + suite.helper.TestToken}})
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.StatusCode)
+}
+
+func (suite *EventSocketTestSuite) TestJobEvents() {
+	client := suite.connect(suite.helper.TestToken, "types=job.created,job.status,job.progress")
+
+	job := suite.createJob(nil, nil)
+	var created events.Event
+	client.readJSON(&created)
+	assert.Equal(suite.T(), events.TypeJobCreated, created.Type)
+	assert.Equal(suite.T(), job.ID, created.JobID)
+	assert.Equal(suite.T(), string(models.StatusPending), created.Status)
+
+	// Updates naming the job only in their condition are announced too
+	require.NoError(suite.T(), suite.helper.GetDB().Model(&models.TranscriptionJob{}).Where("id = ?", job.ID).
+		Updates(map[string]interface{}{"status": models.StatusProcessing, "progress": 0.5}).Error)
+	var status, progress events.Event
+	client.readJSON(&status)
+	client.readJSON(&progress)
+	assert.Equal(suite.T(), events.TypeJobStatus, status.Type)
+	assert.Equal(suite.T(), job.ID, status.JobID)
+	assert.Equal(suite.T(), string(models.StatusProcessing), status.Status)
+	assert.Equal(suite.T(), events.TypeJobProgress, progress.Type)
+	require.NotNil(suite.T(), progress.Progress)
+	assert.Equal(suite.T(), 0.5, *progress.Progress)
+
+	// Events of other types are left out
+	events.Publish(events.Event{Type: events.TypeStorageWarning, Message: "low space"})
+	client.expectNothing()
+}
+
+func (suite *EventSocketTestSuite) TestMembersSeeTheirOwnJobs() {
+	member, token := suite.member("socket-member")
+	other, _ := suite.member("socket-other")
+	client := suite.connect(token, "types=job.created")
+
+	suite.createJob(&other.ID, nil)
+	suite.createJob(nil, nil)
+	own := suite.createJob(&member.ID, nil)
+	var created events.Event
+	client.readJSON(&created)
+	assert.Equal(suite.T(), own.ID, created.JobID)
+	client.expectNothing()
+}
+
+func (suite *EventSocketTestSuite) TestSubscribeFilters() {
+	folder := models.JobFolder{Name: "Socket folder"}
+	require.NoError(suite.T(), suite.helper.GetDB().Create(&folder).Error)
+	client := suite.connect(suite.helper.TestToken, "mine=true&types=job.created")
+
+	// Admins own jobs without an owner, and their own
+	other, _ := suite.member("socket-stranger")
+	suite.createJob(&other.ID, nil)
+	unowned := suite.createJob(nil, nil)
+	var created events.Event
+	client.readJSON(&created)
+	assert.Equal(suite.T(), unowned.ID, created.JobID)
+
+	// Subscribing replaces the filter
+	client.send(map[string]interface{}{"type": "subscribe", "folder_id": folder.ID, "types": []string{"job.created"}})
+	var reply api.EventSocketReply
+	client.readJSON(&reply)
+	require.Equal(suite.T(), "subscribed", reply.Type)
+	require.NotNil(suite.T(), reply.Filter.FolderID)
+	assert.Equal(suite.T(), folder.ID, *reply.Filter.FolderID)
+	assert.False(suite.T(), reply.Filter.Mine)
+
+	suite.createJob(nil, nil)
+	filed := suite.createJob(&other.ID, &folder.ID)
+	client.readJSON(&created)
+	assert.Equal(suite.T(), filed.ID, created.JobID)
+
+	client.send(map[string]interface{}{"type": "subscribe", "types": []string{"job.deleted"}})
+	client.readJSON(&reply)
+	assert.Equal(suite.T(), "error", reply.Type)
+	assert.Contains(suite.T(), reply.Error, "job.deleted")
+}
+
+func (suite *EventSocketTestSuite) TestMetricsAndShutdown() {
+	client := suite.connect(suite.helper.TestToken, "")
+
+	resp, err := http.Get(suite.server.URL + "/metrics")
+	require.NoError(suite.T(), err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	// Sockets of earlier tests may still be closing
+	assert.Regexp(suite.T(), `\nscriberr_websocket_connections [1-9]\d*\n`, string(body))
+	assert.Contains(suite.T(), string(body), "# TYPE scriberr_websocket_dropped_events_total counter")
+	assert.Contains(suite.T(), string(body), "scriberr_websocket_slow_disconnects_total")
+
+	// Shutting down closes open sockets as going away, and refuses new ones
+	suite.handler.CloseEventSockets()
+	op, payload := client.read()
+	assert.Equal(suite.T(), websocket.OpClose, op)
+	require.GreaterOrEqual(suite.T(), len(payload), 2)
+	assert.Equal(suite.T(), websocket.CloseGoingAway, int(binary.BigEndian.Uint16(payload)))
+
+	late, resp := suite.dial("/api/ws?token="+suite.helper.TestToken, nil)
+	require.Equal(suite.T(), http.StatusSwitchingProtocols, resp.StatusCode)
+	op, payload = late.read()
+	assert.Equal(suite.T(), websocket.OpClose, op)
+	assert.True(suite.T(), strings.Contains(string(payload), "shutting down"))
+}
+
+func TestEventSocketTestSuite(t *testing.T) {
+	suite.Run(t, new(EventSocketTestSuite))
+}
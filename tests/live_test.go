@@ -0,0 +1,197 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"scriberr/internal/api"
+	"scriberr/internal/config"
+	"scriberr/internal/live"
+	"scriberr/internal/models"
+	"scriberr/internal/queue"
+	"scriberr/internal/transcription"
+	"scriberr/internal/transcription/adapters"
+	"scriberr/internal/websocket"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeLiveWhisper is a faster-whisper that reports a segment for every
+// second of the WAV file it is given
+const fakeLiveWhisper = `#!/bin/sh
+in="$1"
+while [ "$1" != "--output_dir" ]; do shift; done
+out="$2/$(basename "$in" .wav).json"
+secs=$(( ($(wc -c < "$in") - 44) / 32000 ))
+printf '{"language":"en","segments":[' > "$out"
+i=0
+while [ $i -lt $secs ]; do
+	[ $i -gt 0 ] && printf ',' >> "$out"
+	printf '{"start":%d,"end":%d,"text":" second %d"}' $i $((i+1)) $i >> "$out"
+	i=$((i+1))
+done
+printf ']}' >> "$out"
+`
+
+type LiveTestSuite struct {
+	suite.Suite
+	helper  *TestHelper
+	handler *api.Handler
+	server  *httptest.Server
+	script  string
+}
+
+func (suite *LiveTestSuite) SetupSuite() {
+	if runtime.GOOS == "windows" {
+		suite.T().Skip("Runs a shell script as faster-whisper")
+	}
+	suite.helper = NewTestHelper(suite.T(), "live_test.db")
+	suite.script = filepath.Join(suite.T().TempDir(), "faster-whisper-xxl")
+	require.NoError(suite.T(), os.WriteFile(suite.script, []byte(fakeLiveWhisper), 0755))
+	adapters.ConfigureFasterWhisper(suite.script)
+
+	unifiedProcessor := transcription.NewUnifiedJobProcessor()
+	suite.handler = api.NewHandler(suite.helper.Config, suite.helper.AuthService, queue.NewTaskQueue(1, unifiedProcessor), unifiedProcessor, nil)
+	suite.server = httptest.NewServer(api.SetupRoutes(suite.handler, suite.helper.AuthService))
+}
+
+func (suite *LiveTestSuite) TearDownSuite() {
+	adapters.ConfigureFasterWhisper("")
+	suite.handler.CloseLiveStreams()
+	suite.server.Close()
+	suite.helper.Cleanup()
+}
+
+func (suite *LiveTestSuite) SetupTest() {
+	suite.helper.Config.Live = config.LiveConfig{Model: "tiny", MaxSessionDuration: time.Minute, MaxStreamsPerUser: 1}
+}
+
+// open starts a live stream and reads its ready message. The stream of an
+// earlier test is released just after its done message, so it is retried
+// while the user is at the stream limit.
+func (suite *LiveTestSuite) open() *socketClient {
+	var client *socketClient
+	require.Eventually(suite.T(), func() bool {
+		var resp *http.Response
+		client, resp = dialSocket(suite.T(), suite.server, "/api/v1/transcription/live?title=Standup&token="+suite.helper.TestToken, nil)
+		return resp.StatusCode == http.StatusSwitchingProtocols
+	}, 5*time.Second, 20*time.Millisecond)
+	var ready api.LiveReady
+	client.readJSON(&ready)
+	require.Equal(suite.T(), "ready", ready.Type)
+	assert.Equal(suite.T(), live.SampleRate, ready.SampleRate)
+	return client
+}
+
+// finish reads events until the stream is done, returning the final
+// segments and the done message
+func (suite *LiveTestSuite) finish(client *socketClient) ([]live.Event, api.LiveDone) {
+	var finals []live.Event
+	for {
+		var message map[string]interface{}
+		op, payload := client.read()
+		require.Equal(suite.T(), websocket.OpText, op, "unexpected frame %q", payload)
+		require.NoError(suite.T(), json.Unmarshal(payload, &message))
+		switch message["type"] {
+		case live.EventFinal:
+			var event live.Event
+			json.Unmarshal(payload, &event)
+			finals = append(finals, event)
+		case "done":
+			var done api.LiveDone
+			json.Unmarshal(payload, &done)
+			op, _ := client.read()
+			assert.Equal(suite.T(), websocket.OpClose, op)
+			return finals, done
+		}
+	}
+}
+
+func (suite *LiveTestSuite) TestStreamIsSavedAsJob() {
+	client := suite.open()
+	for i := 0; i < 12; i++ {
+		client.write(websocket.OpBinary, make([]byte, 32000))
+	}
+	client.send(map[string]string{"type": "stop"})
+
+	finals, done := suite.finish(client)
+	assert.Len(suite.T(), finals, 12)
+	for i, event := range finals {
+		assert.Equal(suite.T(), float64(i), event.Start)
+	}
+	assert.Equal(suite.T(), "stopped", done.Reason)
+	assert.Equal(suite.T(), 12, done.Segments)
+	assert.Equal(suite.T(), 12.0, done.DurationSeconds)
+	require.NotEmpty(suite.T(), done.JobID)
+
+	var job models.TranscriptionJob
+	require.NoError(suite.T(), suite.helper.GetDB().First(&job, "id = ?", done.JobID).Error)
+	assert.Equal(suite.T(), models.StatusCompleted, job.Status)
+	assert.Equal(suite.T(), "Standup", *job.Title)
+	assert.Equal(suite.T(), models.BackendFasterWhisper, job.Parameters.Backend)
+	assert.Equal(suite.T(), "tiny", job.Parameters.Model)
+	require.NotNil(suite.T(), job.UserID)
+	assert.Equal(suite.T(), suite.helper.TestUser.ID, *job.UserID)
+	require.NotNil(suite.T(), job.Transcript)
+	assert.Contains(suite.T(), *job.Transcript, "second")
+
+	info, err := os.Stat(job.AudioPath)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(44+12*32000), info.Size())
+}
+
+func (suite *LiveTestSuite) TestStreamsPerUserAreLimited() {
+	client := suite.open()
+	_, resp := dialSocket(suite.T(), suite.server, "/api/v1/transcription/live?token="+suite.helper.TestToken, nil)
+	assert.Equal(suite.T(), http.StatusTooManyRequests, resp.StatusCode)
+
+	// Streams without audio are not saved
+	client.send(map[string]string{"type": "stop"})
+	_, done := suite.finish(client)
+	assert.Empty(suite.T(), done.JobID)
+
+	// The stream is released once it has been saved
+	assert.Eventually(suite.T(), func() bool {
+		_, resp := dialSocket(suite.T(), suite.server, "/api/v1/transcription/live?token="+suite.helper.TestToken, nil)
+		return resp.StatusCode == http.StatusSwitchingProtocols
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+func (suite *LiveTestSuite) TestMaxSessionDuration() {
+	suite.helper.Config.Live.MaxSessionDuration = 500 * time.Millisecond
+	client := suite.open()
+	client.write(websocket.OpBinary, make([]byte, 32000))
+
+	finals, done := suite.finish(client)
+	assert.Equal(suite.T(), "max_duration", done.Reason)
+	assert.Len(suite.T(), finals, 1)
+	assert.NotEmpty(suite.T(), done.JobID)
+}
+
+func (suite *LiveTestSuite) TestRequestsAreChecked() {
+	_, resp := dialSocket(suite.T(), suite.server, "/api/v1/transcription/live", nil)
+	assert.Equal(suite.T(), http.StatusUnauthorized, resp.StatusCode)
+
+	_, resp = dialSocket(suite.T(), suite.server, "/api/v1/transcription/live?format=mp3&token="+suite.helper.TestToken, nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.StatusCode)
+
+	_, resp = dialSocket(suite.T(), suite.server, "/api/v1/transcription/live?language=xx&token="+suite.helper.TestToken, nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.StatusCode)
+
+	adapters.ConfigureFasterWhisper(filepath.Join(suite.T().TempDir(), "missing"))
+	defer adapters.ConfigureFasterWhisper(suite.script)
+	_, resp = dialSocket(suite.T(), suite.server, "/api/v1/transcription/live?token="+suite.helper.TestToken, nil)
+	assert.Equal(suite.T(), http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestLiveTestSuite(t *testing.T) {
+	suite.Run(t, new(LiveTestSuite))
+}
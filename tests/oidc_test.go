@@ -0,0 +1,263 @@
+package tests
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"scriberr/internal/api"
+	"scriberr/internal/config"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const mockOIDCClientID = "scriberr-test-client"
+
+// mockOIDCProvider is a minimal OpenID Connect provider that issues signed ID
+// tokens for whichever identity is queued for the next authorization code
+type mockOIDCProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu     sync.Mutex
+	claims map[string]jwt.MapClaims // authorization code -> ID token claims
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	p := &mockOIDCProvider{key: key, claims: map[string]jwt.MapClaims{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/jwks", p.jwks)
+	mux.HandleFunc("/token", p.token)
+	p.server = httptest.NewServer(mux)
+	return p
+}
+
+// issueCode registers an authorization code that yields an ID token for subject
+func (p *mockOIDCProvider) issueCode(code, subject, preferredUsername, nonce string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.claims[code] = jwt.MapClaims{
+		"iss":                p.server.URL,
+		"sub":                subject,
+		"aud":                mockOIDCClientID,
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"iat":                time.Now().Unix(),
+		"nonce":              nonce,
+		"preferred_username": preferredUsername,
+		"email":              preferredUsername + "@example.com",
+	}
+}
+
+func (p *mockOIDCProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]any{
+		"issuer":                                p.server.URL,
+		"authorization_endpoint":                p.server.URL + "/authorize",
+		"token_endpoint":                        p.server.URL + "/token",
+		"jwks_uri":                              p.server.URL + "/jwks",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (p *mockOIDCProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := p.key.PublicKey
+	writeJSON(w, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": "test-key",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (p *mockOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	p.mu.Lock()
+	claims, ok := p.claims[r.PostForm.Get("code")]
+	p.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = "test-key"
+	signed, err := idToken.SignedString(p.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]any{
+		"access_token": "mock-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     signed,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+type OIDCTestSuite struct {
+	suite.Suite
+	helper   *TestHelper
+	provider *mockOIDCProvider
+	router   *gin.Engine
+}
+
+func (suite *OIDCTestSuite) SetupSuite() {
+	suite.helper = NewTestHelper(suite.T(), "oidc_test.db")
+	suite.provider = newMockOIDCProvider(suite.T())
+
+	cfg := *suite.helper.Config
+	cfg.OIDC = config.OIDCConfig{
+		ProviderURL:  suite.provider.server.URL,
+		ClientID:     mockOIDCClientID,
+		ClientSecret: "test-secret",
+		CallbackURL:  "http://localhost:8080/api/v1/auth/oidc/callback",
+	}
+	handler := api.NewHandler(&cfg, suite.helper.AuthService, nil, nil, nil)
+	suite.router = api.SetupRoutes(handler, suite.helper.AuthService)
+}
+
+func (suite *OIDCTestSuite) TearDownSuite() {
+	suite.provider.server.Close()
+	suite.helper.Cleanup()
+}
+
+// login runs the full redirect and callback flow for the given subject
+func (suite *OIDCTestSuite) login(code, subject, preferredUsername string) (*httptest.ResponseRecorder, api.LoginResponse) {
+	req := httptest.NewRequest("GET", "/api/v1/auth/oidc/login", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	require.Equal(suite.T(), http.StatusFound, w.Code)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), suite.provider.server.URL+"/authorize", location.Scheme+"://"+location.Host+location.Path)
+	assert.Equal(suite.T(), mockOIDCClientID, location.Query().Get("client_id"))
+	state := location.Query().Get("state")
+	suite.provider.issueCode(code, subject, preferredUsername, location.Query().Get("nonce"))
+
+	callback := httptest.NewRequest("GET", "/api/v1/auth/oidc/callback?code="+code+"&state="+url.QueryEscape(state), nil)
+	for _, cookie := range w.Result().Cookies() {
+		callback.AddCookie(cookie)
+	}
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, callback)
+
+	var response api.LoginResponse
+	if w.Code == http.StatusOK {
+		require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	}
+	return w, response
+}
+
+func (suite *OIDCTestSuite) TestCallbackCreatesUserAndIssuesToken() {
+	w, response := suite.login("code-alice-1", "subject-alice", "alice")
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+
+	assert.NotEmpty(suite.T(), response.Token)
+	assert.Equal(suite.T(), "alice", response.User.Username)
+	assert.Equal(suite.T(), models.RoleUser, response.User.Role)
+
+	claims, err := suite.helper.AuthService.ValidateToken(response.Token)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), response.User.ID, claims.UserID)
+
+	var user models.User
+	require.NoError(suite.T(), database.DB.First(&user, response.User.ID).Error)
+	require.NotNil(suite.T(), user.OIDCSubject)
+	assert.Equal(suite.T(), "subject-alice", *user.OIDCSubject)
+	require.NotNil(suite.T(), user.OIDCIssuer)
+	assert.Equal(suite.T(), suite.provider.server.URL, *user.OIDCIssuer)
+
+	// Logging in again with the same subject maps to the same account
+	w, again := suite.login("code-alice-2", "subject-alice", "alice-renamed")
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), response.User.ID, again.User.ID)
+	assert.Equal(suite.T(), "alice", again.User.Username)
+
+	var count int64
+	database.DB.Model(&models.User{}).Where("oidc_subject = ?", "subject-alice").Count(&count)
+	assert.Equal(suite.T(), int64(1), count)
+}
+
+func (suite *OIDCTestSuite) TestCallbackDoesNotTakeOverLocalAccount() {
+	// The test helper's local user already owns this username
+	w, response := suite.login("code-collision", "subject-collision", suite.helper.TestUser.Username)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+
+	assert.NotEqual(suite.T(), suite.helper.TestUser.ID, response.User.ID)
+	assert.Equal(suite.T(), suite.helper.TestUser.Username+"-2", response.User.Username)
+}
+
+func (suite *OIDCTestSuite) TestCallbackRejectsStateMismatch() {
+	req := httptest.NewRequest("GET", "/api/v1/auth/oidc/login", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	require.Equal(suite.T(), http.StatusFound, w.Code)
+
+	callback := httptest.NewRequest("GET", "/api/v1/auth/oidc/callback?code=anything&state=forged", nil)
+	for _, cookie := range w.Result().Cookies() {
+		callback.AddCookie(cookie)
+	}
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, callback)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *OIDCTestSuite) TestCallbackRejectsUnknownCode() {
+	req := httptest.NewRequest("GET", "/api/v1/auth/oidc/login", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(suite.T(), err)
+
+	callback := httptest.NewRequest("GET", "/api/v1/auth/oidc/callback?code=never-issued&state="+url.QueryEscape(location.Query().Get("state")), nil)
+	for _, cookie := range w.Result().Cookies() {
+		callback.AddCookie(cookie)
+	}
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, callback)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *OIDCTestSuite) TestDisabledWhenNotConfigured() {
+	handler := api.NewHandler(suite.helper.Config, suite.helper.AuthService, nil, nil, nil)
+	router := api.SetupRoutes(handler, suite.helper.AuthService)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/auth/oidc/login", nil))
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func TestOIDCTestSuite(t *testing.T) {
+	suite.Run(t, new(OIDCTestSuite))
+}
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+
+	"scriberr/internal/api"
+	"scriberr/internal/api/spec"
+	"scriberr/internal/queue"
+	"scriberr/internal/transcription"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type OpenAPITestSuite struct {
+	suite.Suite
+	helper *TestHelper
+	router *gin.Engine
+}
+
+func (suite *OpenAPITestSuite) SetupSuite() {
+	suite.helper = NewTestHelper(suite.T(), "openapi_test.db")
+	unifiedProcessor := transcription.NewUnifiedJobProcessor()
+	handler := api.NewHandler(suite.helper.Config, suite.helper.AuthService, queue.NewTaskQueue(1, unifiedProcessor), unifiedProcessor, nil)
+	suite.router = api.SetupRoutes(handler, suite.helper.AuthService)
+}
+
+func (suite *OpenAPITestSuite) TearDownSuite() {
+	suite.helper.Cleanup()
+}
+
+func (suite *OpenAPITestSuite) get(url, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", url, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// Test that every registered route is documented, so new endpoints cannot
+// go undocumented
+func (suite *OpenAPITestSuite) TestEveryRouteIsDocumented() {
+	var doc spec.Document
+	require.NoError(suite.T(), json.Unmarshal(spec.JSON(), &doc))
+
+	params := regexp.MustCompile(`[:*](\w+)`)
+	for _, route := range suite.router.Routes() {
+		// The web app's static files and the Swagger 2 UI are not API endpoints
+		if !strings.HasPrefix(route.Path, "/api/") &&
+			(strings.HasPrefix(route.Path, "/assets/") || strings.HasPrefix(route.Path, "/swagger/") || path.Ext(route.Path) != "") {
+			continue
+		}
+		documented := params.ReplaceAllString(route.Path, "{$1}")
+		if documented != "/" {
+			documented = strings.TrimSuffix(documented, "/")
+		}
+		item, ok := doc.Paths[documented]
+		if !ok {
+			// Unversioned /api paths are aliases of the current version
+			item, ok = doc.Paths["/api/"+api.APIVersion+strings.TrimPrefix(documented, "/api")]
+		}
+		if assert.True(suite.T(), ok, "%s %s is not in the OpenAPI document; add swag annotations and run go generate ./internal/api/spec", route.Method, route.Path) {
+			assert.NotNil(suite.T(), item[strings.ToLower(route.Method)], "%s %s is not in the OpenAPI document", route.Method, route.Path)
+		}
+	}
+}
+
+// Test that the document and the reference page require authentication
+func (suite *OpenAPITestSuite) TestServeDocument() {
+	assert.Equal(suite.T(), http.StatusUnauthorized, suite.get("/api/openapi.json", "").Code)
+	assert.Equal(suite.T(), http.StatusUnauthorized, suite.get("/api/docs", "").Code)
+
+	w := suite.get("/api/openapi.json", suite.helper.TestToken)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Header().Get("Content-Type"), "application/json")
+	var doc spec.Document
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(suite.T(), "Scriberr API", doc.Info.Title)
+	assert.NotNil(suite.T(), doc.Paths["/api/v1/auth/login"]["post"])
+
+	w = suite.get("/api/docs", suite.helper.TestToken)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(suite.T(), w.Body.String(), "SwaggerUIBundle")
+	assert.Contains(suite.T(), w.Body.String(), `"openapi":"3.0.3"`)
+}
+
+func TestOpenAPITestSuite(t *testing.T) {
+	suite.Run(t, new(OpenAPITestSuite))
+}
@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"scriberr/internal/api"
+	"scriberr/internal/auth"
+	"scriberr/internal/models"
+	"scriberr/internal/queue"
+	"scriberr/internal/transcription"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type PresignedUploadTestSuite struct {
+	suite.Suite
+	helper *TestHelper
+	router *gin.Engine
+}
+
+func (suite *PresignedUploadTestSuite) SetupSuite() {
+	suite.helper = NewTestHelper(suite.T(), "presigned_upload_test.db")
+	suite.helper.Config.UploadSessionTTL = time.Hour
+	unifiedProcessor := transcription.NewUnifiedJobProcessor()
+	handler := api.NewHandler(suite.helper.Config, suite.helper.AuthService, queue.NewTaskQueue(1, unifiedProcessor), unifiedProcessor, nil)
+	suite.router = api.SetupRoutes(handler, suite.helper.AuthService)
+}
+
+func (suite *PresignedUploadTestSuite) TearDownSuite() {
+	suite.helper.Cleanup()
+}
+
+// request sends a request, authenticated unless it goes to a presigned URL
+func (suite *PresignedUploadTestSuite) request(method, path string, body io.Reader, header map[string]string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, body)
+	if !strings.HasPrefix(path, "/upload/") {
+		req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+	}
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *PresignedUploadTestSuite) presign(size int) api.PresignUploadResponse {
+	body := fmt.Sprintf(`{"filename": "lecture.mp3", "size": %d}`, size)
+	w := suite.request("POST", "/api/v1/transcriptions/presign", strings.NewReader(body), map[string]string{"Content-Type": "application/json"})
+	require.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+	var response api.PresignUploadResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+// Test that upload tokens are bound to their upload, secret and expiry
+func (suite *PresignedUploadTestSuite) TestUploadTokens() {
+	service := suite.helper.AuthService
+	token := service.GenerateUploadToken("upload-1", time.Now().Add(time.Hour))
+	id, err := service.ValidateUploadToken(token)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "upload-1", id)
+
+	payload, _, _ := strings.Cut(token, ".")
+	tampered := token[:len(token)-1] + "0"
+	if strings.HasSuffix(token, "0") {
+		tampered = token[:len(token)-1] + "1"
+	}
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"other upload", strings.Replace(token, "upload-1", "upload-2", 1)},
+		{"extended expiry", payload + fmt.Sprintf(".%d.", time.Now().Add(48*time.Hour).Unix()) + token[strings.LastIndex(token, ".")+1:]},
+		{"tampered signature", tampered},
+		{"other secret", auth.NewAuthService("another-secret", 0).GenerateUploadToken("upload-1", time.Now().Add(time.Hour))},
+		{"malformed", "upload-1"},
+	}
+	for _, tt := range tests {
+		_, err := service.ValidateUploadToken(tt.token)
+		assert.ErrorIs(suite.T(), err, auth.ErrUploadTokenInvalid, tt.name)
+	}
+
+	expired := service.GenerateUploadToken("upload-1", time.Now().Add(-time.Second))
+	_, err = service.ValidateUploadToken(expired)
+	assert.ErrorIs(suite.T(), err, auth.ErrUploadTokenExpired)
+}
+
+// Test uploading to a local presigned URL in two parts and completing the job
+func (suite *PresignedUploadTestSuite) TestPresignedLocalUpload() {
+	audio := fakeMP3(strings.Repeat("presigned audio ", 4096))
+	presigned := suite.presign(len(audio))
+	assert.Equal(suite.T(), "/upload/"+presigned.UploadToken, presigned.UploadURL)
+	assert.WithinDuration(suite.T(), time.Now().Add(suite.helper.Config.UploadSessionTTL), presigned.ExpiresAt, time.Minute)
+
+	// The first part arrives, then the upload is resumed from the offset
+	half := len(audio) / 2
+	w := suite.request("PUT", presigned.UploadURL, bytes.NewReader(audio[:half]), map[string]string{"Upload-Offset": "0"})
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	w = suite.request("POST", "/api/v1/transcriptions/"+presigned.JobID+"/upload-complete", nil, nil)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code, "Expected an incomplete upload to be refused")
+
+	w = suite.request("GET", presigned.UploadURL, nil, nil)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), fmt.Sprint(half), w.Header().Get("Upload-Offset"))
+	w = suite.request("PUT", presigned.UploadURL, bytes.NewReader(audio[half:]), map[string]string{"Upload-Offset": "0"})
+	assert.Equal(suite.T(), http.StatusConflict, w.Code, "Expected a wrong offset to be refused")
+	w = suite.request("PUT", presigned.UploadURL, bytes.NewReader(audio[half:]), map[string]string{"Upload-Offset": fmt.Sprint(half)})
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+
+	form := strings.NewReader("title=Lecture&model=small")
+	w = suite.request("POST", "/api/v1/transcriptions/"+presigned.JobID+"/upload-complete", form, map[string]string{"Content-Type": "application/x-www-form-urlencoded"})
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var job models.TranscriptionJob
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), presigned.JobID, job.ID)
+	assert.Equal(suite.T(), models.StatusPending, job.Status)
+	assert.Equal(suite.T(), "Lecture", *job.Title)
+	assert.Equal(suite.T(), "small", job.Parameters.Model)
+	written, err := os.ReadFile(job.AudioPath)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), audio, written)
+
+	// Completing again returns the job; the URL no longer accepts data
+	w = suite.request("POST", "/api/v1/transcriptions/"+presigned.JobID+"/upload-complete", nil, nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	w = suite.request("PUT", presigned.UploadURL, bytes.NewReader(audio), nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+// Test that a whole-file PUT replaces a partial upload
+func (suite *PresignedUploadTestSuite) TestPresignedUploadRestart() {
+	audio := fakeMP3("complete audio file")
+	presigned := suite.presign(len(audio))
+	w := suite.request("PUT", presigned.UploadURL, bytes.NewReader([]byte("stale")), map[string]string{"Upload-Offset": "0"})
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.request("PUT", presigned.UploadURL, bytes.NewReader(audio), nil)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), fmt.Sprint(len(audio)), w.Header().Get("Upload-Offset"))
+}
+
+// Test that invalid and expired tokens are refused
+func (suite *PresignedUploadTestSuite) TestPresignedUploadTokenRejected() {
+	presigned := suite.presign(16)
+
+	w := suite.request("PUT", "/upload/"+presigned.UploadToken[:len(presigned.UploadToken)-1]+"x", strings.NewReader("data"), nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	expired := suite.helper.AuthService.GenerateUploadToken(presigned.JobID, time.Now().Add(-time.Minute))
+	w = suite.request("PUT", "/upload/"+expired, strings.NewReader("data"), nil)
+	assert.Equal(suite.T(), http.StatusGone, w.Code)
+	w = suite.request("GET", "/upload/"+expired, nil, nil)
+	assert.Equal(suite.T(), http.StatusGone, w.Code)
+
+	// Presigning requires credentials
+	req, _ := http.NewRequest("POST", "/api/v1/transcriptions/presign", strings.NewReader(`{"filename": "a.mp3", "size": 10}`))
+	recorder := httptest.NewRecorder()
+	suite.router.ServeHTTP(recorder, req)
+	assert.Equal(suite.T(), http.StatusUnauthorized, recorder.Code)
+}
+
+func TestPresignedUploadTestSuite(t *testing.T) {
+	suite.Run(t, new(PresignedUploadTestSuite))
+}
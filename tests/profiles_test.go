@@ -0,0 +1,210 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"scriberr/internal/api"
+	"scriberr/internal/models"
+	"scriberr/internal/queue"
+	"scriberr/internal/transcription"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type ProfilesTestSuite struct {
+	suite.Suite
+	helper      *TestHelper
+	router      *gin.Engine
+	memberToken string
+}
+
+func (suite *ProfilesTestSuite) SetupSuite() {
+	suite.helper = NewTestHelper(suite.T(), "profiles_test.db")
+	unifiedProcessor := transcription.NewUnifiedJobProcessor()
+	handler := api.NewHandler(suite.helper.Config, suite.helper.AuthService, queue.NewTaskQueue(1, unifiedProcessor), unifiedProcessor, nil)
+	suite.router = api.SetupRoutes(handler, suite.helper.AuthService)
+
+	member := &models.User{Username: "profiles-member", Password: "unused", Role: models.RoleUser}
+	require.NoError(suite.T(), suite.helper.GetDB().Create(member).Error)
+	token, err := suite.helper.AuthService.GenerateToken(member)
+	require.NoError(suite.T(), err)
+	suite.memberToken = token
+}
+
+func (suite *ProfilesTestSuite) TearDownSuite() {
+	suite.helper.Cleanup()
+}
+
+func (suite *ProfilesTestSuite) request(method, path string, body interface{}, token string) *httptest.ResponseRecorder {
+	var jsonBody []byte
+	if body != nil {
+		jsonBody, _ = json.Marshal(body)
+	}
+	req, _ := http.NewRequest(method, path, bytes.NewReader(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// create adds a profile through the API and returns it
+func (suite *ProfilesTestSuite) create(name string, params models.WhisperXParams, token string) models.TranscriptionProfile {
+	w := suite.request("POST", "/api/v1/profiles/", map[string]interface{}{"name": name, "parameters": params}, token)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var profile models.TranscriptionProfile
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &profile))
+	return profile
+}
+
+func (suite *ProfilesTestSuite) list(token string) map[string]bool {
+	w := suite.request("GET", "/api/v1/profiles/", nil, token)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var profiles []models.TranscriptionProfile
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &profiles))
+	names := map[string]bool{}
+	for _, profile := range profiles {
+		names[profile.Name] = true
+	}
+	return names
+}
+
+// submit uploads a dummy audio file with the given form fields
+func (suite *ProfilesTestSuite) submit(fields map[string]string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("audio", "meeting.mp3")
+	require.NoError(suite.T(), err)
+	part.Write(fakeMP3("fake audio"))
+	for key, value := range fields {
+		require.NoError(suite.T(), writer.WriteField(key, value))
+	}
+	require.NoError(suite.T(), writer.Close())
+
+	req, _ := http.NewRequest("POST", "/api/v1/transcriptions", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// Test that profiles belong to their creator and shared profiles to everyone
+func (suite *ProfilesTestSuite) TestProfileOwnership() {
+	params := models.WhisperXParams{Model: "base", Task: models.TaskTranscribe, DiarizeModel: "pyannote"}
+	own := suite.create("Admin interviews", params, suite.helper.TestToken)
+	require.NotNil(suite.T(), own.UserID)
+	assert.Equal(suite.T(), suite.helper.TestUser.ID, *own.UserID)
+	shared := models.TranscriptionProfile{Name: "Shared meetings", Parameters: params}
+	require.NoError(suite.T(), suite.helper.GetDB().Create(&shared).Error)
+
+	// Members see the shared profile but not the admin's own
+	names := suite.list(suite.memberToken)
+	assert.True(suite.T(), names["Shared meetings"])
+	assert.False(suite.T(), names["Admin interviews"])
+	w := suite.request("GET", "/api/v1/profiles/"+own.ID, nil, suite.memberToken)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	// Names only need to be unique per owner
+	memberOwn := suite.create("Admin interviews", params, suite.memberToken)
+
+	// Members cannot change shared profiles, only their own
+	w = suite.request("PUT", "/api/v1/profiles/"+shared.ID, map[string]interface{}{"name": "Renamed", "parameters": params}, suite.memberToken)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	w = suite.request("DELETE", "/api/v1/profiles/"+shared.ID, nil, suite.memberToken)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	w = suite.request("PUT", "/api/v1/profiles/"+memberOwn.ID, map[string]interface{}{"name": "Member interviews", "parameters": params}, suite.memberToken)
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var updated models.TranscriptionProfile
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &updated))
+	require.NotNil(suite.T(), updated.UserID, "Expected the owner to be kept")
+
+	// Each owner has their own default
+	w = suite.request("POST", fmt.Sprintf("/api/v1/profiles/%s/set-default", own.ID), nil, suite.helper.TestToken)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	w = suite.request("POST", fmt.Sprintf("/api/v1/profiles/%s/set-default", memberOwn.ID), nil, suite.memberToken)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var reloaded models.TranscriptionProfile
+	require.NoError(suite.T(), suite.helper.GetDB().First(&reloaded, "id = ?", own.ID).Error)
+	assert.True(suite.T(), reloaded.IsDefault, "Expected the admin's default to be unaffected by the member's")
+}
+
+// Test that jobs take a profile's parameters, with overrides the adapter declares
+func (suite *ProfilesTestSuite) TestSubmitWithProfile() {
+	language := "de"
+	profile := suite.create("German lectures", models.WhisperXParams{
+		Model: "medium", BatchSize: 8, Language: &language, Task: models.TaskTranscribe, DiarizeModel: "pyannote",
+	}, suite.helper.TestToken)
+
+	w := suite.submit(map[string]string{"profile_id": profile.ID, "model": "tiny"})
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var job models.TranscriptionJob
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), "medium", job.Parameters.Model, "Expected the profile to replace the form fields")
+	require.NotNil(suite.T(), job.Parameters.Language)
+	assert.Equal(suite.T(), "de", *job.Parameters.Language)
+
+	w = suite.submit(map[string]string{"profile_id": profile.ID, "parameters": `{"model": "small", "batch_size": 4}`})
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), "small", job.Parameters.Model)
+	assert.Equal(suite.T(), 4, job.Parameters.BatchSize)
+	assert.Equal(suite.T(), "de", *job.Parameters.Language, "Expected parameters that are not overridden to be kept")
+
+	tests := []struct {
+		name       string
+		profileID  string
+		parameters string
+	}{
+		{"unknown profile", "missing", ""},
+		{"undeclared parameter", profile.ID, `{"no_align": true}`},
+		{"out of range", profile.ID, `{"batch_size": 500}`},
+		{"unknown option", profile.ID, `{"model": "enormous"}`},
+		{"wrong type", profile.ID, `{"batch_size": "many"}`},
+		{"not an object", profile.ID, `["small"]`},
+	}
+	for _, tt := range tests {
+		w := suite.submit(map[string]string{"profile_id": tt.profileID, "parameters": tt.parameters})
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, tt.name)
+	}
+
+	// Overrides are checked against the adapter of the profile's model family
+	parakeet := suite.create("Parakeet", models.WhisperXParams{ModelFamily: "nvidia_parakeet", Task: models.TaskTranscribe, DiarizeModel: "pyannote"}, suite.helper.TestToken)
+	w = suite.submit(map[string]string{"profile_id": parakeet.ID, "parameters": `{"model": "small"}`})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "parakeet")
+}
+
+// Test that profiles used by watch folders cannot be deleted
+func (suite *ProfilesTestSuite) TestDeleteProfileInUse() {
+	profile := suite.create("Dictaphone", models.WhisperXParams{Model: "base", Task: models.TaskTranscribe, DiarizeModel: "pyannote"}, suite.helper.TestToken)
+	folder := models.WatchFolder{Path: suite.T().TempDir(), ProfileID: &profile.ID, AfterProcess: models.WatchAfterMarker}
+	require.NoError(suite.T(), suite.helper.GetDB().Create(&folder).Error)
+
+	w := suite.request("DELETE", "/api/v1/profiles/"+profile.ID, nil, suite.helper.TestToken)
+	require.Equal(suite.T(), http.StatusConflict, w.Code)
+	var conflict struct {
+		Details struct {
+			Dependents []api.ProfileDependent `json:"dependents"`
+		} `json:"details"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &conflict))
+	assert.Equal(suite.T(), []api.ProfileDependent{{Type: "watch_folder", ID: folder.ID, Name: folder.Path}}, conflict.Details.Dependents)
+
+	require.NoError(suite.T(), suite.helper.GetDB().Delete(&folder).Error)
+	w = suite.request("DELETE", "/api/v1/profiles/"+profile.ID, nil, suite.helper.TestToken)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func TestProfilesTestSuite(t *testing.T) {
+	suite.Run(t, new(ProfilesTestSuite))
+}
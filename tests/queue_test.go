@@ -3,13 +3,18 @@ package tests
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"scriberr/internal/models"
 	"scriberr/internal/queue"
+	"scriberr/internal/storage"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -96,6 +101,16 @@ func (suite *QueueTestSuite) TestEnqueueJob() {
 	assert.Equal(suite.T(), 1, queueSize)
 }
 
+// Test the job scanner heartbeat
+func (suite *QueueTestSuite) TestLastHeartbeat() {
+	tq := queue.NewTaskQueue(1, &MockJobProcessor{})
+	assert.True(suite.T(), tq.LastHeartbeat().IsZero())
+
+	tq.Start()
+	defer tq.Stop()
+	assert.WithinDuration(suite.T(), time.Now(), tq.LastHeartbeat(), queue.ScanInterval)
+}
+
 // Test job processing
 func (suite *QueueTestSuite) TestJobProcessing() {
 	// Create test job in database first
@@ -154,7 +169,9 @@ func (suite *QueueTestSuite) TestJobProcessingFailure() {
 	updatedJob, err := tq.GetJobStatus(job.ID)
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), models.StatusFailed, updatedJob.Status)
-	assert.NotNil(suite.T(), updatedJob.ErrorMessage)
+	if assert.NotNil(suite.T(), updatedJob.ErrorMessage) {
+		assert.Equal(suite.T(), assert.AnError.Error(), *updatedJob.ErrorMessage, "A failed job is not reported as cancelled")
+	}
 }
 
 // Test job cancellation
@@ -422,6 +439,541 @@ func (suite *QueueTestSuite) TestConcurrentAccess() {
 	assert.NotNil(suite.T(), stats)
 }
 
+// orderRecordingProcessor records the order jobs start in. The first job
+// blocks until release is closed, so later jobs pile up in the queue.
+type orderRecordingProcessor struct {
+	mu      sync.Mutex
+	order   []string
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *orderRecordingProcessor) ProcessJob(ctx context.Context, jobID string) error {
+	return p.ProcessJobWithProcess(ctx, jobID, nil)
+}
+
+func (p *orderRecordingProcessor) ProcessJobWithProcess(ctx context.Context, jobID string, registerProcess func(*exec.Cmd)) error {
+	p.mu.Lock()
+	p.order = append(p.order, jobID)
+	first := len(p.order) == 1
+	p.mu.Unlock()
+	if first {
+		close(p.started)
+		<-p.release
+	}
+	return nil
+}
+
+func (p *orderRecordingProcessor) processed() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.order...)
+}
+
+// Test that waiting jobs start by priority, then oldest first, whatever order
+// they were submitted in
+func (suite *QueueTestSuite) TestPriorityOrdering() {
+	processor := &orderRecordingProcessor{started: make(chan struct{}), release: make(chan struct{})}
+	tq := queue.NewTaskQueue(1, processor)
+	tq.Start()
+	defer tq.Stop()
+
+	blocker := suite.helper.CreateTestTranscriptionJob(suite.T(), "Blocking Job")
+	assert.NoError(suite.T(), tq.SubmitWithPriority(context.Background(), blocker.ID, models.PriorityNormal))
+	<-processor.started
+
+	// Jobs are created a second apart so their creation order is unambiguous
+	priorities := []int{0, -1, 1, 0, 1, -1, 0, 1}
+	jobs := make([]*models.TranscriptionJob, len(priorities))
+	base := time.Now().Add(-time.Hour)
+	for i := range priorities {
+		jobs[i] = suite.helper.CreateTestTranscriptionJob(suite.T(), fmt.Sprintf("Priority Job %d", i))
+		assert.NoError(suite.T(), suite.helper.GetDB().Model(jobs[i]).UpdateColumn("created_at", base.Add(time.Duration(i)*time.Second)).Error)
+	}
+
+	var wg sync.WaitGroup
+	for i := len(jobs) - 1; i >= 0; i-- {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(suite.T(), tq.SubmitWithPriority(context.Background(), jobs[i].ID, priorities[i]))
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(suite.T(), len(jobs), tq.GetQueueStats()["queue_size"])
+
+	// Resubmitting a waiting job moves it rather than queueing it twice
+	assert.NoError(suite.T(), tq.SubmitWithPriority(context.Background(), jobs[0].ID, models.PriorityHigh))
+	priorities[0] = models.PriorityHigh
+	assert.Equal(suite.T(), len(jobs), tq.GetQueueStats()["queue_size"])
+	assert.Error(suite.T(), tq.SubmitWithPriority(context.Background(), jobs[1].ID, 5))
+
+	close(processor.release)
+	assert.Eventually(suite.T(), func() bool {
+		return len(processor.processed()) == len(jobs)+1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	expected := []string{blocker.ID, jobs[0].ID, jobs[2].ID, jobs[4].ID, jobs[7].ID, jobs[3].ID, jobs[6].ID, jobs[1].ID, jobs[5].ID}
+	assert.Equal(suite.T(), expected, processor.processed())
+
+	var stored models.TranscriptionJob
+	assert.NoError(suite.T(), suite.helper.GetDB().First(&stored, "id = ?", jobs[0].ID).Error)
+	assert.Equal(suite.T(), models.PriorityHigh, stored.Priority)
+}
+
+// Test releasing and rescheduling scheduled jobs
+func (suite *QueueTestSuite) TestScheduledJobs() {
+	tq := queue.NewTaskQueue(1, &MockJobProcessor{})
+	ctx := context.Background()
+	now := time.Now()
+	queueSize := func() int { return tq.GetQueueStats()["queue_size"].(int) }
+	statusOf := func(job *models.TranscriptionJob) models.JobStatus {
+		var stored models.TranscriptionJob
+		assert.NoError(suite.T(), suite.helper.GetDB().First(&stored, "id = ?", job.ID).Error)
+		return stored.Status
+	}
+
+	due := suite.helper.CreateTestTranscriptionJob(suite.T(), "Due Job")
+	later := suite.helper.CreateTestTranscriptionJob(suite.T(), "Later Job")
+	assert.NoError(suite.T(), tq.ScheduleJob(ctx, due.ID, timePtr(now.Add(-time.Minute))))
+	assert.NoError(suite.T(), tq.ScheduleJob(ctx, later.ID, timePtr(now.Add(time.Hour))))
+	assert.Equal(suite.T(), models.StatusScheduled, statusOf(due))
+
+	released, err := tq.ReleaseScheduledJobs(ctx, now)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, released)
+	assert.Equal(suite.T(), models.StatusPending, statusOf(due))
+	assert.Equal(suite.T(), models.StatusScheduled, statusOf(later))
+	assert.Equal(suite.T(), 1, queueSize())
+
+	// Scheduling a waiting job takes it out of line
+	assert.NoError(suite.T(), tq.ScheduleJob(ctx, due.ID, timePtr(now.Add(time.Hour))))
+	assert.Equal(suite.T(), 0, queueSize())
+	assert.Equal(suite.T(), models.StatusScheduled, statusOf(due))
+
+	// Clearing a schedule queues the job now
+	assert.NoError(suite.T(), tq.ScheduleJob(ctx, later.ID, nil))
+	assert.Equal(suite.T(), models.StatusPending, statusOf(later))
+	assert.Equal(suite.T(), 1, queueSize())
+	var stored models.TranscriptionJob
+	assert.NoError(suite.T(), suite.helper.GetDB().First(&stored, "id = ?", later.ID).Error)
+	assert.Nil(suite.T(), stored.ScheduledAt)
+
+	completed := suite.helper.CreateTestTranscriptionJob(suite.T(), "Completed Job")
+	assert.NoError(suite.T(), suite.helper.GetDB().Model(completed).Update("status", models.StatusCompleted).Error)
+	assert.ErrorIs(suite.T(), tq.ScheduleJob(ctx, completed.ID, timePtr(now.Add(time.Hour))), queue.ErrNotSchedulable)
+	assert.ErrorIs(suite.T(), tq.ScheduleJob(ctx, completed.ID, nil), queue.ErrNotSchedulable)
+}
+
+// Test removing audio once jobs complete
+func (suite *QueueTestSuite) TestDeleteAudioAfterTranscription() {
+	newJob := func(title string, pinned bool) *models.TranscriptionJob {
+		path := filepath.Join(suite.T().TempDir(), "audio.mp3")
+		assert.NoError(suite.T(), os.WriteFile(path, []byte("audio"), 0644))
+		job := suite.helper.CreateTestTranscriptionJob(suite.T(), title)
+		assert.NoError(suite.T(), suite.helper.GetDB().Model(job).Updates(map[string]interface{}{"audio_path": path, "pinned": pinned}).Error)
+		job.AudioPath = path
+		return job
+	}
+	job := newJob("Delete Audio Job", false)
+	pinned := newJob("Pinned Audio Job", true)
+
+	mockProcessor := &MockJobProcessor{}
+	mockProcessor.On("ProcessJobWithProcess", mock.Anything, mock.Anything).Return(nil)
+	tq := queue.NewTaskQueue(1, mockProcessor)
+	tq.DeleteAudioAfterTranscription(true)
+	tq.Start()
+	defer tq.Stop()
+	// One worker finishes the pinned job before starting the other
+	assert.NoError(suite.T(), tq.EnqueueJob(pinned.ID))
+	assert.NoError(suite.T(), tq.EnqueueJob(job.ID))
+
+	assert.Eventually(suite.T(), func() bool {
+		_, err := os.Stat(job.AudioPath)
+		return os.IsNotExist(err)
+	}, 2*time.Second, 10*time.Millisecond, "Expected the audio to be deleted")
+	var stored models.TranscriptionJob
+	assert.Eventually(suite.T(), func() bool {
+		return suite.helper.GetDB().First(&stored, "id = ?", job.ID).Error == nil && stored.AudioDeletedAt != nil
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(suite.T(), models.StatusCompleted, stored.Status)
+
+	// Pinned jobs keep their audio
+	var storedPinned models.TranscriptionJob
+	assert.Eventually(suite.T(), func() bool {
+		return suite.helper.GetDB().First(&storedPinned, "id = ?", pinned.ID).Error == nil && storedPinned.Status == models.StatusCompleted
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.FileExists(suite.T(), pinned.AudioPath)
+	assert.Nil(suite.T(), storedPinned.AudioDeletedAt)
+}
+
+// Test completing jobs with the transcript of an identical completed job of
+// the same owner
+func (suite *QueueTestSuite) TestResultCache() {
+	ctx := context.Background()
+	db := suite.helper.GetDB()
+	newJob := func(title, audio, model string) *models.TranscriptionJob {
+		path := filepath.Join(suite.T().TempDir(), "audio.mp3")
+		assert.NoError(suite.T(), os.WriteFile(path, []byte(audio), 0644))
+		job := suite.helper.CreateTestTranscriptionJob(suite.T(), title)
+		assert.NoError(suite.T(), db.Model(job).Updates(map[string]interface{}{"audio_path": path, "model": model}).Error)
+		job.AudioPath = path
+		return job
+	}
+	source := newJob("Cached Source", "same audio", "base")
+	hash, err := storage.Hash(ctx, source.AudioPath)
+	assert.NoError(suite.T(), err)
+	transcript := `{"text":"hello","segments":[{"start":0,"end":1,"text":"hello"}]}`
+	assert.NoError(suite.T(), db.Model(source).Updates(map[string]interface{}{
+		"status": models.StatusCompleted, "transcript": transcript, "file_hash": hash, "detected_language": "en",
+	}).Error)
+	source.Chapters = []models.Chapter{{Type: models.ChapterMarker, Title: "Intro", Start: 0}}
+	assert.NoError(suite.T(), db.Select("chapters").Updates(source).Error)
+
+	other := models.User{Username: "cacheother", Password: "x", Role: models.RoleUser}
+	assert.NoError(suite.T(), db.Create(&other).Error)
+	defer db.Delete(&other)
+
+	hit := newJob("Cache Hit", "same audio", "base")
+	otherOptions := newJob("Other Options", "same audio", "small")
+	otherAudio := newJob("Other Audio", "other audio", "base")
+	otherOwner := newJob("Other Owner", "same audio", "base")
+	assert.NoError(suite.T(), db.Model(otherOwner).Update("user_id", other.ID).Error)
+
+	processor := &MockJobProcessor{}
+	transcribed := []*models.TranscriptionJob{otherOptions, otherAudio, otherOwner}
+	for _, job := range transcribed {
+		processor.On("ProcessJobWithProcess", mock.Anything, job.ID).Return(nil)
+	}
+	tq := queue.NewTaskQueue(1, processor)
+	tq.CacheResults(true)
+
+	// Jobs hashed when they were uploaded are completed without being queued
+	hashed := newJob("Hashed Hit", "same audio", "base")
+	assert.NoError(suite.T(), db.Model(hashed).Update("file_hash", hash).Error)
+	assert.NoError(suite.T(), tq.EnqueueJob(hashed.ID))
+	var stored models.TranscriptionJob
+	assert.NoError(suite.T(), db.First(&stored, "id = ?", hashed.ID).Error)
+	assert.Equal(suite.T(), models.StatusCompleted, stored.Status)
+	assert.Equal(suite.T(), source.ID, *stored.CachedFromJobID)
+	depth, _ := tq.WaitingStats()
+	assert.Zero(suite.T(), depth)
+	// Later jobs are matched against the source only
+	assert.NoError(suite.T(), db.Unscoped().Delete(hashed).Error)
+
+	tq.Start()
+	for _, job := range append(transcribed, hit) {
+		assert.NoError(suite.T(), tq.EnqueueJob(job.ID))
+	}
+
+	completed := func(id string) models.TranscriptionJob {
+		var stored models.TranscriptionJob
+		assert.Eventually(suite.T(), func() bool {
+			return db.First(&stored, "id = ?", id).Error == nil && stored.Status == models.StatusCompleted
+		}, 2*time.Second, 10*time.Millisecond)
+		return stored
+	}
+
+	// Workers hash audio still without a hash and complete it without running
+	stored = completed(hit.ID)
+	assert.Equal(suite.T(), transcript, *stored.Transcript)
+	assert.Equal(suite.T(), "en", *stored.DetectedLanguage)
+	assert.Equal(suite.T(), source.ID, *stored.CachedFromJobID)
+	assert.Equal(suite.T(), source.Chapters, stored.Chapters)
+	assert.Equal(suite.T(), hash, *stored.FileHash)
+	assert.NotNil(suite.T(), stored.CompletedAt)
+
+	// Other options, other audio and other users' jobs are transcribed
+	for _, job := range transcribed {
+		stored := completed(job.ID)
+		assert.Nil(suite.T(), stored.CachedFromJobID, job.ID)
+	}
+	tq.Stop()
+	processor.AssertNotCalled(suite.T(), "ProcessJobWithProcess", mock.Anything, hit.ID)
+
+	// Without the cache every job is transcribed
+	miss := newJob("Cache Disabled", "same audio", "base")
+	uncachedProcessor := &MockJobProcessor{}
+	uncachedProcessor.On("ProcessJobWithProcess", mock.Anything, miss.ID).Return(nil)
+	uncached := queue.NewTaskQueue(1, uncachedProcessor)
+	uncached.Start()
+	defer uncached.Stop()
+	assert.NoError(suite.T(), uncached.EnqueueJob(miss.ID))
+	stored = completed(miss.ID)
+	assert.Nil(suite.T(), stored.CachedFromJobID)
+	uncachedProcessor.AssertCalled(suite.T(), "ProcessJobWithProcess", mock.Anything, miss.ID)
+}
+
+// deviceCountingProcessor records the most jobs that ran at once on each
+// device. Each job takes a while so that jobs overlap.
+type deviceCountingProcessor struct {
+	mu       sync.Mutex
+	devices  map[string]string // Job ID to device
+	running  map[string]int
+	peak     map[string]int
+	finished int
+}
+
+func (p *deviceCountingProcessor) ProcessJob(ctx context.Context, jobID string) error {
+	return p.ProcessJobWithProcess(ctx, jobID, nil)
+}
+
+func (p *deviceCountingProcessor) ProcessJobWithProcess(ctx context.Context, jobID string, registerProcess func(*exec.Cmd)) error {
+	p.mu.Lock()
+	device := p.devices[jobID]
+	p.running[device]++
+	p.peak[device] = max(p.peak[device], p.running[device])
+	p.mu.Unlock()
+
+	time.Sleep(30 * time.Millisecond)
+
+	p.mu.Lock()
+	p.running[device]--
+	p.finished++
+	p.mu.Unlock()
+	return nil
+}
+
+// Test that jobs wait for a free slot on their device
+func (suite *QueueTestSuite) TestJobsPerDeviceLimit() {
+	processor := &deviceCountingProcessor{devices: map[string]string{}, running: map[string]int{}, peak: map[string]int{}}
+	tq := queue.NewTaskQueue(6, processor)
+	tq.LimitJobsPerDevice(map[string]int{"cuda": 1, "cpu": 2}, "cuda")
+
+	var jobIDs []string
+	for i, device := range []string{"cuda", "cuda", "auto", "cpu", "cpu", "cpu", "cpu", "cuda", "auto", "mps", "mps"} {
+		job := suite.helper.CreateTestTranscriptionJob(suite.T(), fmt.Sprintf("Device Job %d", i))
+		assert.NoError(suite.T(), suite.helper.GetDB().Model(job).Update("device", device).Error)
+		if device == "auto" {
+			device = "cuda"
+		}
+		processor.devices[job.ID] = device
+		jobIDs = append(jobIDs, job.ID)
+	}
+
+	// Submit from several goroutines at once
+	var wg sync.WaitGroup
+	for _, id := range jobIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(suite.T(), tq.EnqueueJob(id))
+		}()
+	}
+	wg.Wait()
+	tq.Start()
+	defer tq.Stop()
+
+	assert.Eventually(suite.T(), func() bool {
+		processor.mu.Lock()
+		defer processor.mu.Unlock()
+		return processor.finished == len(jobIDs)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	processor.mu.Lock()
+	defer processor.mu.Unlock()
+	assert.Equal(suite.T(), 1, processor.peak["cuda"], "Auto jobs should share the CUDA limit")
+	assert.Equal(suite.T(), 2, processor.peak["cpu"])
+	assert.Equal(suite.T(), 2, processor.peak["mps"], "Devices without a limit are not held back")
+}
+
+// hangingProcessor never finishes a job by itself, like WhisperX stuck on
+// malformed audio; it only returns once the job's context ends
+type hangingProcessor struct {
+	runs atomic.Int32
+}
+
+func (p *hangingProcessor) ProcessJob(ctx context.Context, jobID string) error {
+	return p.ProcessJobWithProcess(ctx, jobID, nil)
+}
+
+func (p *hangingProcessor) ProcessJobWithProcess(ctx context.Context, jobID string, registerProcess func(*exec.Cmd)) error {
+	p.runs.Add(1)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// Test that jobs running past the job timeout are retried and then failed
+func (suite *QueueTestSuite) TestJobTimeout() {
+	processor := &hangingProcessor{}
+	tq := queue.NewTaskQueue(1, processor)
+	tq.SetJobTimeout(100*time.Millisecond, 1)
+	tq.Start()
+	defer tq.Stop()
+
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Hanging Job")
+	start := time.Now()
+	assert.NoError(suite.T(), tq.EnqueueJob(job.ID))
+
+	assert.Eventually(suite.T(), func() bool {
+		stored, err := tq.GetJobStatus(job.ID)
+		return err == nil && stored.Status == models.StatusFailed
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Less(suite.T(), time.Since(start), time.Second, "Both runs should stop at the timeout")
+
+	stored, err := tq.GetJobStatus(job.ID)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int32(2), processor.runs.Load(), "The job should be retried once")
+	assert.Equal(suite.T(), 1, stored.TimeoutRetries)
+	if assert.NotNil(suite.T(), stored.ErrorMessage) {
+		assert.True(suite.T(), strings.HasPrefix(*stored.ErrorMessage, "timeout"), *stored.ErrorMessage)
+	}
+	assert.False(suite.T(), tq.IsJobRunning(job.ID))
+}
+
+// Test that background tasks wait until no job is running and run once per key
+func (suite *QueueTestSuite) TestBackgroundTasksWaitForJobs() {
+	processor := &hangingProcessor{}
+	tq := queue.NewTaskQueue(1, processor)
+	tq.Start()
+	defer tq.Stop()
+
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Busy Job")
+	assert.NoError(suite.T(), tq.EnqueueJob(job.ID))
+	assert.Eventually(suite.T(), func() bool { return tq.IsJobRunning(job.ID) }, time.Second, 10*time.Millisecond)
+
+	var runs atomic.Int32
+	task := func(ctx context.Context) { runs.Add(1) }
+	assert.True(suite.T(), tq.SubmitBackground("waveform:a", task))
+	assert.False(suite.T(), tq.SubmitBackground("waveform:a", task), "A task already waiting should not be queued twice")
+	assert.True(suite.T(), tq.SubmitBackground("waveform:b", task))
+
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(suite.T(), int32(0), runs.Load(), "Background tasks should wait while a job runs")
+
+	assert.NoError(suite.T(), tq.KillJob(job.ID))
+	assert.Eventually(suite.T(), func() bool { return runs.Load() == 2 }, 2*time.Second, 10*time.Millisecond)
+}
+
+// orderedProcessor records the order jobs run in and fails the jobs in fail
+type orderedProcessor struct {
+	mu   sync.Mutex
+	ran  []string
+	fail map[string]bool
+}
+
+func (p *orderedProcessor) ProcessJob(ctx context.Context, jobID string) error {
+	return p.ProcessJobWithProcess(ctx, jobID, nil)
+}
+
+func (p *orderedProcessor) ProcessJobWithProcess(ctx context.Context, jobID string, registerProcess func(*exec.Cmd)) error {
+	time.Sleep(20 * time.Millisecond)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ran = append(p.ran, jobID)
+	if p.fail[jobID] {
+		return assert.AnError
+	}
+	return nil
+}
+
+func (p *orderedProcessor) order() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string{}, p.ran...)
+}
+
+// createChain creates jobs that each depend on the one before
+func (suite *QueueTestSuite) createChain(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		job := suite.helper.CreateTestTranscriptionJob(suite.T(), fmt.Sprintf("Chain Job %d", i))
+		if i > 0 {
+			job.DependsOn = []string{ids[i-1]}
+			assert.NoError(suite.T(), suite.helper.DB.Model(job).Select("depends_on").Updates(job).Error)
+		}
+		ids[i] = job.ID
+	}
+	return ids
+}
+
+// Test that a chain of dependent jobs runs in order, even with free workers
+// and queued backwards
+func (suite *QueueTestSuite) TestDependencyChain() {
+	processor := &orderedProcessor{}
+	tq := queue.NewTaskQueue(3, processor)
+	ids := suite.createChain(3)
+	for i := len(ids) - 1; i >= 0; i-- {
+		assert.NoError(suite.T(), tq.EnqueueJob(ids[i]))
+	}
+	tq.Start()
+	defer tq.Stop()
+
+	assert.Eventually(suite.T(), func() bool {
+		stored, err := tq.GetJobStatus(ids[2])
+		return err == nil && stored.Status == models.StatusCompleted
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.Equal(suite.T(), ids, processor.order())
+}
+
+// Test that jobs fail without running when a job they depend on fails
+func (suite *QueueTestSuite) TestDependencyFailure() {
+	ids := suite.createChain(3)
+	processor := &orderedProcessor{fail: map[string]bool{ids[0]: true}}
+	tq := queue.NewTaskQueue(2, processor)
+	tq.Start()
+	defer tq.Stop()
+	for _, id := range ids {
+		assert.NoError(suite.T(), tq.EnqueueJob(id))
+	}
+
+	assert.Eventually(suite.T(), func() bool {
+		stored, err := tq.GetJobStatus(ids[2])
+		return err == nil && stored.Status == models.StatusFailed
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.Equal(suite.T(), ids[:1], processor.order(), "Dependent jobs should not run")
+	stored, err := tq.GetJobStatus(ids[1])
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), models.StatusFailed, stored.Status)
+	if assert.NotNil(suite.T(), stored.ErrorMessage) {
+		assert.Contains(suite.T(), *stored.ErrorMessage, ids[0])
+	}
+}
+
+// Test that runs that complete or fail are recorded in the job stats, and
+// that waiting jobs are reported
+func (suite *QueueTestSuite) TestJobStats() {
+	seconds := 90.0
+	var ids []string
+	for i := 0; i < 2; i++ {
+		job := suite.helper.CreateTestTranscriptionJob(suite.T(), fmt.Sprintf("Stats Job %d", i))
+		assert.NoError(suite.T(), suite.helper.DB.Model(job).Update("duration_seconds", seconds).Error)
+		ids = append(ids, job.ID)
+	}
+	processor := &orderedProcessor{fail: map[string]bool{ids[1]: true}}
+	tq := queue.NewTaskQueue(1, processor)
+	tq.Start()
+	defer tq.Stop()
+
+	release := tq.Hold()
+	for _, id := range ids {
+		assert.NoError(suite.T(), tq.EnqueueJob(id))
+	}
+	time.Sleep(50 * time.Millisecond)
+	waiting, oldest := tq.WaitingStats()
+	assert.Equal(suite.T(), 2, waiting)
+	assert.GreaterOrEqual(suite.T(), oldest, 50*time.Millisecond)
+	release()
+
+	var stats []models.JobStat
+	assert.Eventually(suite.T(), func() bool {
+		stats = nil
+		return suite.helper.DB.Where("job_id IN ?", ids).Order("id").Find(&stats).Error == nil && len(stats) == 2
+	}, 3*time.Second, 10*time.Millisecond)
+	if assert.Len(suite.T(), stats, 2) {
+		assert.Equal(suite.T(), []models.JobStatus{models.StatusCompleted, models.StatusFailed}, []models.JobStatus{stats[0].Status, stats[1].Status})
+		for _, stat := range stats {
+			assert.Equal(suite.T(), seconds, stat.AudioSeconds)
+			assert.GreaterOrEqual(suite.T(), stat.WallSeconds, 0.02, "Runs take at least the processor's delay")
+			assert.Equal(suite.T(), models.BackendWhisperX, stat.Engine)
+			assert.NotEmpty(suite.T(), stat.Model)
+		}
+	}
+	waiting, oldest = tq.WaitingStats()
+	assert.Zero(suite.T(), waiting)
+	assert.Zero(suite.T(), oldest)
+}
+
 func TestQueueTestSuite(t *testing.T) {
 	suite.Run(t, new(QueueTestSuite))
 }
@@ -0,0 +1,175 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"scriberr/internal/api"
+	"scriberr/internal/api/apierror"
+	"scriberr/internal/auth"
+	"scriberr/internal/models"
+	"scriberr/internal/queue"
+	"scriberr/internal/transcription"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type RateLimitTestSuite struct {
+	suite.Suite
+	helper      *TestHelper
+	router      *gin.Engine
+	memberToken string
+}
+
+func (suite *RateLimitTestSuite) SetupSuite() {
+	suite.helper = NewTestHelper(suite.T(), "rate_limit_test.db")
+	suite.helper.Config.RateLimitRead = 3
+	suite.helper.Config.RateLimitWrite = 2
+	suite.helper.Config.RateLimitSubmit = 1
+	suite.helper.Config.RateLimitLLM = 1
+	suite.router = suite.newRouter()
+
+	password, err := auth.HashPassword("memberpassword")
+	require.NoError(suite.T(), err)
+	member := models.User{Username: "member", Password: password, Role: models.RoleUser}
+	require.NoError(suite.T(), suite.helper.GetDB().Create(&member).Error)
+	suite.memberToken, err = suite.helper.AuthService.GenerateToken(&member)
+	require.NoError(suite.T(), err)
+}
+
+func (suite *RateLimitTestSuite) TearDownSuite() {
+	suite.helper.Cleanup()
+}
+
+func (suite *RateLimitTestSuite) newRouter() *gin.Engine {
+	unifiedProcessor := transcription.NewUnifiedJobProcessor()
+	handler := api.NewHandler(suite.helper.Config, suite.helper.AuthService, queue.NewTaskQueue(1, unifiedProcessor), unifiedProcessor, nil)
+	return api.SetupRoutes(handler, suite.helper.AuthService)
+}
+
+func (suite *RateLimitTestSuite) request(router *gin.Engine, method, url, body, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// exhaust makes allowed requests until one is refused, and returns the refusal
+func (suite *RateLimitTestSuite) exhaust(router *gin.Engine, method, url, token string, allowed int) *httptest.ResponseRecorder {
+	for i := 0; i < allowed; i++ {
+		w := suite.request(router, method, url, "{}", token)
+		require.NotEqual(suite.T(), http.StatusTooManyRequests, w.Code, "Request %d of %d", i+1, allowed)
+		assert.Equal(suite.T(), strconv.Itoa(allowed), w.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(suite.T(), strconv.Itoa(allowed-i-1), w.Header().Get("X-RateLimit-Remaining"))
+	}
+	return suite.request(router, method, url, "{}", token)
+}
+
+func (suite *RateLimitTestSuite) assertLimited(w *httptest.ResponseRecorder) {
+	require.Equal(suite.T(), http.StatusTooManyRequests, w.Code, w.Body.String())
+	var envelope apierror.Envelope
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &envelope))
+	assert.Equal(suite.T(), apierror.CodeRateLimited, envelope.Code)
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	require.NoError(suite.T(), err)
+	assert.Positive(suite.T(), retryAfter)
+	assert.Equal(suite.T(), "0", w.Header().Get("X-RateLimit-Remaining"))
+	assert.NotEmpty(suite.T(), w.Header().Get("X-RateLimit-Reset"))
+}
+
+// Test that each route class has its own budget per user
+func (suite *RateLimitTestSuite) TestBudgetsPerClassAndUser() {
+	router := suite.newRouter()
+	token := suite.helper.TestToken
+
+	suite.assertLimited(suite.exhaust(router, "GET", "/api/v1/transcription/list", token, 3))
+	// Unversioned aliases share the budget of their route
+	suite.assertLimited(suite.request(router, "GET", "/api/transcription/list", "", token))
+
+	// Other classes and other users are counted separately
+	assert.NotEqual(suite.T(), http.StatusTooManyRequests, suite.request(router, "POST", "/api/v1/transcriptions/bulk/tags", `{}`, token).Code)
+	assert.Equal(suite.T(), http.StatusOK, suite.request(router, "GET", "/api/v1/transcription/list", "", suite.memberToken).Code)
+
+	suite.assertLimited(suite.exhaust(router, "POST", "/api/v1/transcription/submit", token, 1))
+	suite.assertLimited(suite.exhaust(router, "POST", "/api/v1/summarize/", token, 1))
+}
+
+// Test that requests without valid credentials are counted per client IP
+func (suite *RateLimitTestSuite) TestUnauthenticatedByIP() {
+	router := suite.newRouter()
+	for i := 0; i < 3; i++ {
+		assert.Equal(suite.T(), http.StatusUnauthorized, suite.request(router, "GET", "/api/v1/transcription/list", "", "not-a-token").Code)
+	}
+	suite.assertLimited(suite.request(router, "GET", "/api/v1/transcription/list", "", ""))
+	assert.Equal(suite.T(), http.StatusOK, suite.request(router, "GET", "/api/v1/transcription/list", "", suite.helper.TestToken).Code)
+
+	// Requests outside the API are not limited
+	for i := 0; i < 5; i++ {
+		assert.NotEqual(suite.T(), http.StatusTooManyRequests, suite.request(router, "GET", "/health", "", "").Code)
+	}
+}
+
+// Test that made-up API keys are counted against the client IP, so a new key
+// on each request does not get a new budget
+func (suite *RateLimitTestSuite) TestRandomAPIKeysByIP() {
+	router := suite.newRouter()
+	withKey := func(header, value string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/v1/transcription/list", nil)
+		req.Header.Set(header, value)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	for i := 0; i < 3; i++ {
+		assert.Equal(suite.T(), http.StatusUnauthorized, withKey("X-API-Key", auth.APIKeyPrefix+uuid.NewString()).Code)
+	}
+	suite.assertLimited(withKey("Authorization", "Bearer "+auth.APIKeyPrefix+uuid.NewString()))
+	suite.assertLimited(suite.request(router, "GET", "/api/v1/transcription/list?token="+auth.APIKeyPrefix+uuid.NewString(), "", ""))
+
+	// A valid key or a token in the query counts against its owner
+	assert.Equal(suite.T(), http.StatusOK, withKey("X-API-Key", suite.helper.TestAPIKey).Code)
+	assert.NotEqual(suite.T(), http.StatusTooManyRequests, suite.request(router, "GET", "/api/v1/transcription/list?token="+suite.memberToken, "", "").Code)
+}
+
+// Test that admins can change the limits at runtime and that they are kept
+func (suite *RateLimitTestSuite) TestSettingsAPI() {
+	router := suite.newRouter()
+	defer suite.helper.GetDB().Where("1 = 1").Delete(&models.RateLimitSetting{})
+
+	w := suite.request(router, "GET", "/api/v1/admin/settings/rate-limits", "", suite.helper.TestToken)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var limits models.RateLimits
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &limits))
+	assert.Equal(suite.T(), models.RateLimits{Read: 3, Write: 2, Submit: 1, LLM: 1}, limits)
+
+	assert.Equal(suite.T(), http.StatusForbidden, suite.request(router, "PUT", "/api/v1/admin/settings/rate-limits", `{"read":0}`, suite.memberToken).Code)
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.request(router, "PUT", "/api/v1/admin/settings/rate-limits", `{"submit":-1}`, suite.helper.TestToken).Code)
+
+	// Fields left out keep their limit
+	w = suite.request(router, "PUT", "/api/v1/admin/settings/rate-limits", `{"read":5}`, suite.helper.TestToken)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &limits))
+	assert.Equal(suite.T(), models.RateLimits{Read: 5, Write: 2, Submit: 1, LLM: 1}, limits)
+	suite.assertLimited(suite.exhaust(router, "GET", "/api/v1/transcription/list", suite.memberToken, 5))
+
+	// A restarted server loads the stored limits
+	w = suite.request(suite.newRouter(), "GET", "/api/v1/admin/settings/rate-limits", "", suite.helper.TestToken)
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &limits))
+	assert.Equal(suite.T(), 5, limits.Read)
+}
+
+func TestRateLimitTestSuite(t *testing.T) {
+	suite.Run(t, new(RateLimitTestSuite))
+}
@@ -0,0 +1,208 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"scriberr/internal/api"
+	"scriberr/internal/live"
+	"scriberr/internal/models"
+	"scriberr/internal/queue"
+	"scriberr/internal/transcription"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type ResumableUploadTestSuite struct {
+	suite.Suite
+	helper  *TestHelper
+	handler *api.Handler
+	router  *gin.Engine
+}
+
+func (suite *ResumableUploadTestSuite) SetupSuite() {
+	suite.helper = NewTestHelper(suite.T(), "resumable_upload_test.db")
+	suite.helper.Config.MaxUploadSize = 1 << 30
+	suite.helper.Config.UploadSessionTTL = time.Hour
+	unifiedProcessor := transcription.NewUnifiedJobProcessor()
+	suite.handler = api.NewHandler(suite.helper.Config, suite.helper.AuthService, queue.NewTaskQueue(1, unifiedProcessor), unifiedProcessor, nil)
+	suite.router = api.SetupRoutes(suite.handler, suite.helper.AuthService)
+}
+
+func (suite *ResumableUploadTestSuite) TearDownSuite() {
+	suite.helper.Cleanup()
+}
+
+func (suite *ResumableUploadTestSuite) request(method, path string, body io.Reader, headers map[string]string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, body)
+	req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// createUpload starts an upload and returns its ID
+func (suite *ResumableUploadTestSuite) createUpload(filename string, size int64) string {
+	payload, _ := json.Marshal(map[string]any{"filename": filename, "size": size, "title": "Board meeting"})
+	w := suite.request("POST", "/api/v1/transcription/uploads", bytes.NewReader(payload), map[string]string{"Content-Type": "application/json"})
+	require.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+	var session models.UploadSession
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &session))
+	return session.ID
+}
+
+func (suite *ResumableUploadTestSuite) appendChunk(id string, offset int64, chunk io.Reader) *httptest.ResponseRecorder {
+	return suite.request("PATCH", "/api/v1/transcription/uploads/"+id, chunk, map[string]string{
+		"Content-Type":  "application/offset+octet-stream",
+		"Upload-Offset": strconv.FormatInt(offset, 10),
+	})
+}
+
+// Test uploading in chunks, resuming after a bad offset, and completing
+func (suite *ResumableUploadTestSuite) TestChunkedUpload() {
+	audio := append(live.WAVHeader(32000), make([]byte, 32000)...)
+	id := suite.createUpload("meeting.wav", int64(len(audio)))
+
+	w := suite.appendChunk(id, 0, bytes.NewReader(audio[:20000]))
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), "20000", w.Header().Get("Upload-Offset"))
+
+	// A chunk at the wrong offset is rejected with the offset to resume from
+	w = suite.appendChunk(id, 10000, bytes.NewReader(audio[10000:]))
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+	assert.Equal(suite.T(), "20000", w.Header().Get("Upload-Offset"))
+
+	// Completing early is rejected
+	w = suite.request("POST", "/api/v1/transcription/uploads/"+id+"/complete", nil, nil)
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	w = suite.request("GET", "/api/v1/transcription/uploads/"+id, nil, nil)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var session models.UploadSession
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &session))
+	assert.Equal(suite.T(), int64(20000), session.BytesReceived)
+
+	// Data past the declared size is rejected
+	w = suite.appendChunk(id, 20000, bytes.NewReader(append(audio[20000:], 0)))
+	assert.Equal(suite.T(), http.StatusRequestEntityTooLarge, w.Code)
+
+	w = suite.appendChunk(id, 20000, bytes.NewReader(audio[20000:]))
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+
+	w = suite.request("POST", "/api/v1/transcription/uploads/"+id+"/complete", nil, nil)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var job models.TranscriptionJob
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), id, job.ID)
+	assert.Equal(suite.T(), models.StatusUploaded, job.Status)
+	assert.Equal(suite.T(), "Board meeting", *job.Title)
+	assert.Equal(suite.T(), filepath.Join(suite.helper.Config.UploadDir, id+".wav"), job.AudioPath)
+	stored, err := os.ReadFile(job.AudioPath)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), audio, stored)
+
+	// Retrying the completion returns the same job
+	w = suite.request("POST", "/api/v1/transcription/uploads/"+id+"/complete", nil, nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	w = suite.request("GET", "/api/v1/transcription/uploads/"+id, nil, nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+// Test the size limit and request validation
+func (suite *ResumableUploadTestSuite) TestUploadLimits() {
+	payload := `{"filename": "huge.wav", "size": ` + strconv.FormatInt(suite.helper.Config.MaxUploadSize+1, 10) + `}`
+	w := suite.request("POST", "/api/v1/transcription/uploads", bytes.NewBufferString(payload), map[string]string{"Content-Type": "application/json"})
+	assert.Equal(suite.T(), http.StatusRequestEntityTooLarge, w.Code)
+
+	w = suite.request("POST", "/api/v1/transcription/uploads", bytes.NewBufferString(`{"filename": "empty.wav", "size": 0}`), map[string]string{"Content-Type": "application/json"})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	id := suite.createUpload("a.wav", 10)
+	w = suite.request("PATCH", "/api/v1/transcription/uploads/"+id, bytes.NewReader(make([]byte, 5)), nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.request("DELETE", "/api/v1/transcription/uploads/"+id, nil, nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.NoFileExists(suite.T(), filepath.Join(suite.helper.Config.UploadDir, "partial", id))
+	w = suite.appendChunk(id, 0, bytes.NewReader(make([]byte, 5)))
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+// Test that uploads idle past the TTL are removed
+func (suite *ResumableUploadTestSuite) TestCleanupAbandonedUploads() {
+	abandoned := suite.createUpload("abandoned.wav", 100)
+	active := suite.createUpload("active.wav", 100)
+	require.Equal(suite.T(), http.StatusOK, suite.appendChunk(abandoned, 0, bytes.NewReader(make([]byte, 50))).Code)
+	require.NoError(suite.T(), suite.helper.GetDB().Model(&models.UploadSession{}).
+		Where("id = ?", abandoned).UpdateColumn("updated_at", time.Now().Add(-2*time.Hour)).Error)
+
+	// A partial file whose session is gone is removed too
+	orphan := filepath.Join(suite.helper.Config.UploadDir, "partial", "orphan")
+	require.NoError(suite.T(), os.WriteFile(orphan, []byte("data"), 0644))
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(suite.T(), os.Chtimes(orphan, old, old))
+
+	removed, err := suite.handler.CleanupAbandonedUploads(context.Background())
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, removed)
+	assert.NoFileExists(suite.T(), filepath.Join(suite.helper.Config.UploadDir, "partial", abandoned))
+	assert.NoFileExists(suite.T(), orphan)
+	assert.Equal(suite.T(), http.StatusNotFound, suite.request("GET", "/api/v1/transcription/uploads/"+abandoned, nil, nil).Code)
+	assert.Equal(suite.T(), http.StatusOK, suite.request("GET", "/api/v1/transcription/uploads/"+active, nil, nil).Code)
+}
+
+// Test that memory use while uploading is bounded by the copy buffer, not the
+// chunk or file size
+func (suite *ResumableUploadTestSuite) TestLargeUploadStreamsToDisk() {
+	const size = 256 << 20
+	const chunkSize = 64 << 20
+	source, err := os.CreateTemp("", "scriberr-sparse-*.wav")
+	require.NoError(suite.T(), err)
+	defer os.Remove(source.Name())
+	defer source.Close()
+	_, err = source.Write(live.WAVHeader(size - 44))
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), source.Truncate(size))
+
+	id := suite.createUpload("long.wav", size)
+
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	for offset := int64(0); offset < size; offset += chunkSize {
+		w := suite.appendChunk(id, offset, io.NewSectionReader(source, offset, chunkSize))
+		require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	}
+	runtime.ReadMemStats(&after)
+	allocated := after.TotalAlloc - before.TotalAlloc
+	assert.Less(suite.T(), allocated, uint64(8<<20), "upload allocated %d bytes", allocated)
+
+	w := suite.request("POST", "/api/v1/transcription/uploads/"+id+"/complete", nil, nil)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var job models.TranscriptionJob
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	defer os.Remove(job.AudioPath)
+	info, err := os.Stat(job.AudioPath)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(size), info.Size())
+}
+
+func TestResumableUploadTestSuite(t *testing.T) {
+	suite.Run(t, new(ResumableUploadTestSuite))
+}
@@ -16,6 +16,7 @@ import (
 	"scriberr/internal/auth"
 	"scriberr/internal/config"
 	"scriberr/internal/database"
+	"scriberr/internal/models"
 	"scriberr/internal/queue"
 	"scriberr/internal/transcription"
 
@@ -56,7 +57,7 @@ func (suite *SecurityTestSuite) SetupSuite() {
 	}
 
 	// Initialize services
-	suite.authService = auth.NewAuthService(suite.config.JWTSecret)
+	suite.authService = auth.NewAuthService(suite.config.JWTSecret, suite.config.AccessTokenTTL)
 	suite.unifiedProcessor = transcription.NewUnifiedJobProcessor()
 	var err error
 	suite.quickTranscriptionService, err = transcription.NewQuickTranscriptionService(suite.config, suite.unifiedProcessor)
@@ -69,6 +70,11 @@ func (suite *SecurityTestSuite) SetupSuite() {
 	// Set up router
 	suite.router = api.SetupRoutes(suite.handler, suite.authService)
 
+	// Finish setup, so requests reach authentication
+	hashed, err := auth.HashPassword("securitypassword1")
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), database.DB.Create(&models.User{Username: "admin", Password: hashed, Role: models.RoleAdmin}).Error)
+
 	// Create upload directory
 	assert.NoError(suite.T(), os.MkdirAll(suite.config.UploadDir, 0755))
 }
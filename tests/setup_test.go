@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"scriberr/internal/api"
+	"scriberr/internal/auth"
+	"scriberr/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type SetupTestSuite struct {
+	suite.Suite
+	helper *TestHelper
+	router *gin.Engine
+}
+
+func (suite *SetupTestSuite) SetupSuite() {
+	suite.helper = NewTestHelper(suite.T(), "setup_test.db")
+}
+
+// SetupTest starts each test on a fresh install: no accounts, setup not
+// completed, and a new handler that has not seen it completed either
+func (suite *SetupTestSuite) SetupTest() {
+	db := suite.helper.DB
+	require.NoError(suite.T(), db.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped().Delete(&models.APIKey{}).Error)
+	require.NoError(suite.T(), db.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped().Delete(&models.User{}).Error)
+	require.NoError(suite.T(), db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.SetupState{}).Error)
+	require.NoError(suite.T(), db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.TranscriptionProfile{}).Error)
+	suite.newRouter()
+}
+
+func (suite *SetupTestSuite) TearDownSuite() {
+	suite.helper.Cleanup()
+}
+
+// newRouter serves the API from a new handler, as after a restart
+func (suite *SetupTestSuite) newRouter() {
+	handler := api.NewHandler(suite.helper.Config, suite.helper.AuthService, nil, nil, nil)
+	suite.router = api.SetupRoutes(handler, suite.helper.AuthService)
+}
+
+func (suite *SetupTestSuite) request(method, url string, body any, token string) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, url, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *SetupTestSuite) setupStatus() api.SetupStatusResponse {
+	w := suite.request("GET", "/api/v1/setup", nil, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	var status api.SetupStatusResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &status))
+	return status
+}
+
+func (suite *SetupTestSuite) TestSetupCreatesFirstAdmin() {
+	assert.Equal(suite.T(), api.SetupStatusResponse{SetupRequired: true}, suite.setupStatus())
+	assert.Equal(suite.T(), http.StatusOK, suite.request("GET", "/api/setup", nil, "").Code, "The unversioned alias is served too")
+
+	// Everything else under /api waits for setup, the frontend does not
+	w := suite.request("GET", "/api/v1/transcription/list", nil, "")
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"code":"SETUP_REQUIRED"`)
+	assert.Equal(suite.T(), http.StatusForbidden, suite.request("POST", "/api/v1/auth/login", map[string]string{"username": "a", "password": "b"}, "").Code)
+	assert.Equal(suite.T(), http.StatusOK, suite.request("GET", "/api/v1/auth/registration-status", nil, "").Code)
+	assert.NotEqual(suite.T(), http.StatusForbidden, suite.request("GET", "/", nil, "").Code)
+	assert.NotEqual(suite.T(), http.StatusForbidden, suite.request("GET", "/health", nil, "").Code)
+
+	for _, body := range []map[string]string{
+		{"username": "admin", "password": "short1"},
+		{"username": "admin", "password": "password123"},
+		{"username": "admin", "password": "admin12345"},
+		{"username": "admin", "password": "str0ngpassword", "device": "tpu"},
+		{"username": "admin", "password": "str0ngpassword", "default_model": "enormous"},
+	} {
+		assert.Equal(suite.T(), http.StatusBadRequest, suite.request("POST", "/api/v1/setup", body, "").Code, body)
+	}
+	assert.True(suite.T(), suite.setupStatus().SetupRequired, "Rejected requests leave setup open")
+
+	w = suite.request("POST", "/api/v1/setup", map[string]string{
+		"username": "admin", "password": "str0ngpassword", "default_model": "medium", "device": "cpu",
+	}, "")
+	require.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+	var login api.LoginResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &login))
+	assert.NotEmpty(suite.T(), login.Token)
+	assert.Equal(suite.T(), "admin", login.User.Username)
+	assert.Equal(suite.T(), models.RoleAdmin, login.User.Role)
+
+	var profile models.TranscriptionProfile
+	require.NoError(suite.T(), suite.helper.DB.Where("user_id IS NULL AND is_default = ?", true).First(&profile).Error)
+	assert.Equal(suite.T(), "medium", profile.Parameters.Model)
+	assert.Equal(suite.T(), "cpu", profile.Parameters.Device)
+
+	assert.Equal(suite.T(), api.SetupStatusResponse{AdminExists: true}, suite.setupStatus())
+	assert.Equal(suite.T(), http.StatusOK, suite.request("GET", "/api/v1/transcription/list", nil, login.Token).Code)
+	assert.Equal(suite.T(), http.StatusGone, suite.request("POST", "/api/v1/setup", map[string]string{"username": "other", "password": "str0ngpassword"}, "").Code)
+
+	// Setup stays closed after a restart, even with no admin left
+	require.NoError(suite.T(), suite.helper.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped().Delete(&models.User{}).Error)
+	suite.newRouter()
+	assert.Equal(suite.T(), http.StatusGone, suite.request("POST", "/api/v1/setup", map[string]string{"username": "other", "password": "str0ngpassword"}, "").Code)
+}
+
+func (suite *SetupTestSuite) TestRegistrationCompletesSetup() {
+	w := suite.request("POST", "/api/v1/auth/register", map[string]string{
+		"username": "admin", "password": "weak", "confirmPassword": "weak",
+	}, "")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.request("POST", "/api/v1/auth/register", map[string]string{
+		"username": "admin", "password": "str0ngpassword", "confirmPassword": "str0ngpassword",
+	}, "")
+	require.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+	assert.False(suite.T(), suite.setupStatus().SetupRequired)
+	assert.Equal(suite.T(), http.StatusGone, suite.request("POST", "/api/v1/setup", map[string]string{"username": "other", "password": "str0ngpassword"}, "").Code)
+}
+
+func (suite *SetupTestSuite) TestSeededAdminCompletesSetup() {
+	hashed, err := auth.HashPassword("seededpassword1")
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), suite.helper.DB.Create(&models.User{Username: "seeded", Password: hashed, Role: models.RoleAdmin}).Error)
+
+	assert.Equal(suite.T(), api.SetupStatusResponse{AdminExists: true}, suite.setupStatus())
+	assert.Equal(suite.T(), http.StatusGone, suite.request("POST", "/api/v1/setup", map[string]string{"username": "other", "password": "str0ngpassword"}, "").Code)
+	var states int64
+	suite.helper.DB.Model(&models.SetupState{}).Count(&states)
+	assert.Equal(suite.T(), int64(1), states)
+}
+
+func TestSetupTestSuite(t *testing.T) {
+	suite.Run(t, new(SetupTestSuite))
+}
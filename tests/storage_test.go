@@ -0,0 +1,213 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"scriberr/internal/api"
+	"scriberr/internal/cleanup"
+	"scriberr/internal/models"
+	"scriberr/internal/queue"
+	"scriberr/internal/storage"
+	"scriberr/internal/transcription"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeBucket is an S3-compatible server keeping path-style objects in memory
+type fakeBucket struct {
+	mutex   sync.Mutex
+	objects map[string][]byte
+}
+
+func (b *fakeBucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	presigned := r.URL.Query().Get("X-Amz-Signature") != ""
+	if !presigned && !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		b.objects[r.URL.Path], _ = io.ReadAll(r.Body)
+	case http.MethodDelete:
+		delete(b.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		data, ok := b.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Write(data)
+	}
+}
+
+func (b *fakeBucket) has(path string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	_, ok := b.objects[path]
+	return ok
+}
+
+type StorageTestSuite struct {
+	suite.Suite
+	helper *TestHelper
+	router *gin.Engine
+	bucket *fakeBucket
+	server *httptest.Server
+	audio  []byte
+}
+
+func (suite *StorageTestSuite) SetupSuite() {
+	suite.helper = NewTestHelper(suite.T(), "storage_test.db")
+	unifiedProcessor := transcription.NewUnifiedJobProcessor()
+	handler := api.NewHandler(suite.helper.Config, suite.helper.AuthService, queue.NewTaskQueue(1, unifiedProcessor), unifiedProcessor, nil)
+	suite.router = api.SetupRoutes(handler, suite.helper.AuthService)
+	suite.audio = writeWAV(suite.T(), filepath.Join(suite.T().TempDir(), "tone.wav"), 440)
+
+	suite.bucket = &fakeBucket{objects: map[string][]byte{}}
+	suite.server = httptest.NewServer(suite.bucket)
+	backend, err := storage.NewS3(storage.S3Options{
+		Endpoint:        suite.server.URL,
+		Bucket:          "audio",
+		AccessKeyID:     "minio",
+		SecretAccessKey: "minio-secret",
+		PathStyle:       true,
+	})
+	require.NoError(suite.T(), err)
+	storage.Configure(backend)
+}
+
+func (suite *StorageTestSuite) TearDownSuite() {
+	storage.Configure(nil)
+	suite.server.Close()
+	suite.helper.Cleanup()
+}
+
+func (suite *StorageTestSuite) request(method, path string, body io.Reader, contentType string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, body)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// Test that uploads move into the bucket and downloads redirect to it
+func (suite *StorageTestSuite) TestUploadToS3() {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("audio", "tone.wav")
+	require.NoError(suite.T(), err)
+	part.Write(suite.audio)
+	require.NoError(suite.T(), writer.Close())
+
+	w := suite.request("POST", "/api/v1/transcription/upload", body, writer.FormDataContentType())
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var job models.TranscriptionJob
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+
+	assert.Equal(suite.T(), "s3://audio/"+job.ID+".wav", job.AudioPath)
+	// The audio is hashed before it leaves the disk, for the result cache
+	assert.NotNil(suite.T(), job.FileHash)
+	assert.True(suite.T(), suite.bucket.has("/audio/"+job.ID+".wav"))
+	_, err = os.Stat(filepath.Join(suite.helper.Config.UploadDir, job.ID+".wav"))
+	assert.True(suite.T(), os.IsNotExist(err), "Expected the local copy to be removed")
+
+	w = suite.request("GET", "/api/v1/transcription/"+job.ID+"/audio", nil, "")
+	assert.Equal(suite.T(), http.StatusTemporaryRedirect, w.Code)
+	location := w.Header().Get("Location")
+	assert.True(suite.T(), strings.HasPrefix(location, suite.server.URL+"/audio/"+job.ID+".wav?"), location)
+	assert.Contains(suite.T(), location, "X-Amz-Signature=")
+
+	// Deleted jobs keep their audio until they are purged
+	w = suite.request("DELETE", "/api/v1/transcription/"+job.ID, nil, "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	assert.True(suite.T(), suite.bucket.has("/audio/"+job.ID+".wav"))
+	_, err = cleanup.Run(context.Background(), cleanup.Policy{DeletedRetentionDays: 30}, time.Now().AddDate(0, 0, 31))
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), suite.bucket.has("/audio/"+job.ID+".wav"))
+}
+
+// Test that migration moves audio left on disk into the bucket
+func (suite *StorageTestSuite) TestMigrateLocalFiles() {
+	onDisk := suite.helper.CreateTestTranscriptionJob(suite.T(), "On disk")
+	path := filepath.Join(suite.helper.Config.UploadDir, onDisk.ID+".wav")
+	require.NoError(suite.T(), os.WriteFile(path, suite.audio, 0644))
+	require.NoError(suite.T(), suite.helper.GetDB().Model(onDisk).Update("audio_path", path).Error)
+	suite.helper.CreateTestTranscriptionJob(suite.T(), "Missing file")
+
+	result, err := storage.MigrateLocalFiles(context.Background())
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, result.Moved)
+	assert.GreaterOrEqual(suite.T(), result.Missing, 1)
+
+	var migrated models.TranscriptionJob
+	require.NoError(suite.T(), suite.helper.GetDB().Where("id = ?", onDisk.ID).First(&migrated).Error)
+	assert.Equal(suite.T(), "s3://audio/"+onDisk.ID+".wav", migrated.AudioPath)
+	assert.True(suite.T(), suite.bucket.has("/audio/"+onDisk.ID+".wav"))
+	_, err = os.Stat(path)
+	assert.True(suite.T(), os.IsNotExist(err), "Expected the local copy to be removed")
+
+	// Rerunning skips jobs already in the bucket
+	result, err = storage.MigrateLocalFiles(context.Background())
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 0, result.Moved)
+}
+
+// Test that presigned uploads go to the bucket and become jobs on completion
+func (suite *StorageTestSuite) TestPresignedUploadToS3() {
+	w := suite.request("POST", "/api/v1/transcriptions/presign", strings.NewReader(
+		fmt.Sprintf(`{"filename": "tone.wav", "size": %d}`, len(suite.audio))), "application/json")
+	require.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+	var presigned api.PresignUploadResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &presigned))
+	assert.Equal(suite.T(), http.MethodPut, presigned.Method)
+	assert.True(suite.T(), strings.HasPrefix(presigned.UploadURL, suite.server.URL+"/audio/presigned/"+presigned.JobID+".wav?"), presigned.UploadURL)
+	assert.Contains(suite.T(), presigned.UploadURL, "X-Amz-Signature=")
+
+	// Completing before the upload reports it incomplete
+	w = suite.request("POST", "/api/v1/transcriptions/"+presigned.JobID+"/upload-complete", nil, "")
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	req, err := http.NewRequest(http.MethodPut, presigned.UploadURL, bytes.NewReader(suite.audio))
+	require.NoError(suite.T(), err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(suite.T(), err)
+	resp.Body.Close()
+	require.Equal(suite.T(), http.StatusOK, resp.StatusCode)
+
+	w = suite.request("POST", "/api/v1/transcriptions/"+presigned.JobID+"/upload-complete", nil, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var job models.TranscriptionJob
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(suite.T(), presigned.JobID, job.ID)
+	assert.Equal(suite.T(), "s3://audio/"+job.ID+".wav", job.AudioPath)
+	assert.True(suite.T(), suite.bucket.has("/audio/"+job.ID+".wav"))
+	assert.False(suite.T(), suite.bucket.has("/audio/presigned/"+job.ID+".wav"), "Expected the staging object to be removed")
+}
+
+func TestStorageTestSuite(t *testing.T) {
+	suite.Run(t, new(StorageTestSuite))
+}
@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"scriberr/internal/api"
+	"scriberr/internal/models"
+	"scriberr/internal/queue"
+	"scriberr/internal/transcription"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type StreamUploadTestSuite struct {
+	suite.Suite
+	helper *TestHelper
+	server *httptest.Server
+	// transferEncoding is the encoding the server saw on the last request
+	transferEncoding []string
+}
+
+func (suite *StreamUploadTestSuite) SetupSuite() {
+	suite.helper = NewTestHelper(suite.T(), "stream_upload_test.db")
+	suite.helper.Config.MaxUploadSize = 4 << 20
+	unifiedProcessor := transcription.NewUnifiedJobProcessor()
+	handler := api.NewHandler(suite.helper.Config, suite.helper.AuthService, queue.NewTaskQueue(1, unifiedProcessor), unifiedProcessor, nil)
+	router := api.SetupRoutes(handler, suite.helper.AuthService)
+	suite.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suite.transferEncoding = r.TransferEncoding
+		router.ServeHTTP(w, r)
+	}))
+}
+
+func (suite *StreamUploadTestSuite) TearDownSuite() {
+	suite.server.Close()
+	suite.helper.Cleanup()
+}
+
+// stream posts a multipart body through a pipe, so the client does not know
+// its length and sends it with chunked transfer encoding
+func (suite *StreamUploadTestSuite) stream(fields map[string]string, filename string, audio []byte) (*http.Response, []byte) {
+	pipeReader, pipeWriter := io.Pipe()
+	writer := multipart.NewWriter(pipeWriter)
+	go func() {
+		for key, value := range fields {
+			writer.WriteField(key, value)
+		}
+		part, err := writer.CreateFormFile("audio", filename)
+		if err != nil {
+			pipeWriter.CloseWithError(err)
+			return
+		}
+		// Write in small pieces, as a client reading from disk would
+		for offset := 0; offset < len(audio); offset += 32 << 10 {
+			end := min(offset+32<<10, len(audio))
+			if _, err := part.Write(audio[offset:end]); err != nil {
+				pipeWriter.CloseWithError(err)
+				return
+			}
+		}
+		pipeWriter.CloseWithError(writer.Close())
+	}()
+
+	req, err := http.NewRequest("POST", suite.server.URL+"/api/v1/transcriptions/stream", pipeReader)
+	require.NoError(suite.T(), err)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(suite.T(), err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), []string{"chunked"}, suite.transferEncoding)
+	return resp, body
+}
+
+func (suite *StreamUploadTestSuite) randomAudio(size int) []byte {
+	audio := make([]byte, size)
+	_, err := rand.Read(audio)
+	require.NoError(suite.T(), err)
+	return append(fakeMP3(""), audio...)
+}
+
+// Test that a chunked upload is written to disk intact and becomes a job
+func (suite *StreamUploadTestSuite) TestStreamUpload() {
+	audio := suite.randomAudio(3 << 20)
+	resp, body := suite.stream(map[string]string{"title": "Lecture", "model": "small"}, "lecture.mp3", audio)
+	require.Equal(suite.T(), http.StatusOK, resp.StatusCode, string(body))
+
+	var job models.TranscriptionJob
+	require.NoError(suite.T(), json.Unmarshal(body, &job))
+	require.NotEmpty(suite.T(), job.ID)
+	assert.Equal(suite.T(), "Lecture", *job.Title)
+	assert.Equal(suite.T(), "small", job.Parameters.Model)
+
+	var stored models.TranscriptionJob
+	require.NoError(suite.T(), suite.helper.GetDB().First(&stored, "id = ?", job.ID).Error)
+	written, err := os.ReadFile(stored.AudioPath)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), audio, written, "Expected the written file to match the upload")
+	if assert.NotNil(suite.T(), stored.FileSize) {
+		assert.Equal(suite.T(), int64(len(audio)), *stored.FileSize)
+	}
+}
+
+// Test that uploads over the limit are refused and leave no file behind
+func (suite *StreamUploadTestSuite) TestStreamUploadTooLarge() {
+	before, err := os.ReadDir(suite.helper.Config.UploadDir)
+	require.NoError(suite.T(), err)
+
+	resp, body := suite.stream(nil, "huge.mp3", suite.randomAudio(5<<20))
+	assert.Equal(suite.T(), http.StatusRequestEntityTooLarge, resp.StatusCode, string(body))
+
+	after, err := os.ReadDir(suite.helper.Config.UploadDir)
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), after, len(before))
+}
+
+// Test that a stream without audio or with invalid fields is rejected
+func (suite *StreamUploadTestSuite) TestStreamUploadValidation() {
+	resp, _ := suite.stream(nil, "", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.StatusCode)
+
+	resp, _ = suite.stream(map[string]string{"priority": "7"}, "talk.mp3", suite.randomAudio(1024))
+	assert.Equal(suite.T(), http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestStreamUploadTestSuite(t *testing.T) {
+	suite.Run(t, new(StreamUploadTestSuite))
+}
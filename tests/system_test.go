@@ -0,0 +1,212 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"scriberr/internal/api"
+	"scriberr/internal/config"
+	"scriberr/internal/models"
+	"scriberr/internal/queue"
+	"scriberr/internal/transcription"
+	"scriberr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type SystemTestSuite struct {
+	suite.Suite
+	helper *TestHelper
+	router *gin.Engine
+}
+
+func (suite *SystemTestSuite) SetupSuite() {
+	suite.helper = NewTestHelper(suite.T(), "system_test.db")
+	// More free space than any disk has, so uploads are always refused
+	suite.helper.Config.MinFreeSpaceMB = 1 << 40
+	suite.helper.Config.Environment = config.EnvironmentInfo()
+	unifiedProcessor := transcription.NewUnifiedJobProcessor()
+	handler := api.NewHandler(suite.helper.Config, suite.helper.AuthService, queue.NewTaskQueue(1, unifiedProcessor), unifiedProcessor, nil)
+	suite.router = api.SetupRoutes(handler, suite.helper.AuthService)
+}
+
+func (suite *SystemTestSuite) TearDownSuite() {
+	suite.helper.Cleanup()
+}
+
+func (suite *SystemTestSuite) request(method, path string, body *bytes.Buffer, contentType string) *httptest.ResponseRecorder {
+	if body == nil {
+		body = &bytes.Buffer{}
+	}
+	req, _ := http.NewRequest(method, path, body)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// Test that the storage report lists the volumes and usage categories
+func (suite *SystemTestSuite) TestGetStorageReport() {
+	w := suite.request("GET", "/api/v1/system/storage", nil, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+
+	var report api.StorageReportResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &report))
+	require.Len(suite.T(), report.Volumes, 3)
+	assert.Equal(suite.T(), "uploads", report.Volumes[0].Name)
+	assert.NotZero(suite.T(), report.Volumes[0].TotalBytes)
+	var names []string
+	for _, category := range report.Usage {
+		names = append(names, category.Name)
+	}
+	assert.Equal(suite.T(), []string{"audio", "transcripts_db", "whisper_models", "logs"}, names)
+	assert.False(suite.T(), report.UsageScannedAt.IsZero())
+	assert.True(suite.T(), report.LowSpace)
+}
+
+// Test that uploads and URL ingestion are refused when the disk is nearly full
+func (suite *SystemTestSuite) TestUploadsRefusedWhenLowOnSpace() {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("audio", "test.mp3")
+	part.Write(fakeMP3("dummy audio data"))
+	writer.Close()
+
+	w := suite.request("POST", "/api/v1/transcription/upload", body, writer.FormDataContentType())
+	assert.Equal(suite.T(), http.StatusInsufficientStorage, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"code":"INSUFFICIENT_STORAGE"`)
+
+	w = suite.request("POST", "/api/v1/transcription/from-url", bytes.NewBufferString(`{"url":"https://example.com/a.mp3"}`), "application/json")
+	assert.Equal(suite.T(), http.StatusInsufficientStorage, w.Code)
+
+	// Reading is unaffected
+	w = suite.request("GET", "/api/v1/transcription/list", nil, "")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+// Test the environment report and that only admins can repair it
+func (suite *SystemTestSuite) TestEnvironment() {
+	w := suite.request("GET", "/api/v1/system/environment?refresh=true", nil, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var response api.EnvironmentResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.NotEmpty(suite.T(), response.Host.OS)
+	assert.NotEmpty(suite.T(), response.WhisperX.ProjectPath)
+	assert.NotNil(suite.T(), response.WhisperX.CheckedAt)
+	// The test environment has no WhisperX venv
+	assert.Equal(suite.T(), transcription.EnvStateBroken, response.WhisperX.State)
+	assert.NotEmpty(suite.T(), response.WhisperX.Problems)
+
+	member := &models.User{ID: suite.helper.TestUser.ID, Username: suite.helper.TestUser.Username, Role: models.RoleUser}
+	token, err := suite.helper.AuthService.GenerateToken(member)
+	require.NoError(suite.T(), err)
+	for _, route := range []struct{ method, path string }{
+		{"POST", "/api/v1/admin/whisperx-env/repair"},
+		{"GET", "/api/v1/admin/logs"},
+		{"POST", "/api/v1/system/setup"},
+	} {
+		req, _ := http.NewRequest(route.method, route.path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		assert.Equal(suite.T(), http.StatusForbidden, w.Code, route.path)
+	}
+}
+
+// Test following recent log entries
+func (suite *SystemTestSuite) TestRecentLogs() {
+	logger.Info("System test marker", "step", "first")
+	w := suite.request("GET", "/api/v1/admin/logs?limit=1000", nil, "")
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Entries []logger.Entry `json:"entries"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.NotEmpty(suite.T(), response.Entries)
+	var marker *logger.Entry
+	for i := range response.Entries {
+		if response.Entries[i].Message == "System test marker" {
+			marker = &response.Entries[i]
+		}
+	}
+	require.NotNil(suite.T(), marker)
+	assert.Equal(suite.T(), "first", marker.Fields["step"])
+
+	w = suite.request("GET", fmt.Sprintf("/api/v1/admin/logs?after=%d", marker.Seq), nil, "")
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	for _, entry := range response.Entries {
+		assert.Greater(suite.T(), entry.Seq, marker.Seq)
+	}
+	assert.Equal(suite.T(), http.StatusBadRequest, suite.request("GET", "/api/v1/admin/logs?after=x", nil, "").Code)
+}
+
+// Test that setup creates the venv and installs WhisperX with uv, streaming
+// its output
+func (suite *SystemTestSuite) TestWhisperXSetup() {
+	if runtime.GOOS == "windows" {
+		suite.T().Skip("Runs a shell script as uv")
+	}
+	dir := suite.T().TempDir()
+	calls := filepath.Join(dir, "calls")
+	// The fake uv records its arguments and fails to install version 0.0.0
+	uv := filepath.Join(dir, "uv")
+	require.NoError(suite.T(), os.WriteFile(uv, []byte(`#!/bin/sh
+echo "$@" >> "`+calls+`"
+echo "uv $1 output"
+case "$*" in *whisperx==0.0.0*) echo "error: No solution found" >&2; exit 1;; esac
+`), 0755))
+
+	setup := func(uvPath, version string) *httptest.ResponseRecorder {
+		cfg := *suite.helper.Config
+		cfg.UVPath = uvPath
+		cfg.WhisperXEnv = filepath.Join(dir, "env")
+		cfg.WhisperXVersion = version
+		unifiedProcessor := transcription.NewUnifiedJobProcessor()
+		handler := api.NewHandler(&cfg, suite.helper.AuthService, queue.NewTaskQueue(1, unifiedProcessor), unifiedProcessor, nil)
+		req, _ := http.NewRequest("POST", "/api/v1/system/setup", nil)
+		req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+		w := httptest.NewRecorder()
+		api.SetupRoutes(handler, suite.helper.AuthService).ServeHTTP(w, req)
+		return w
+	}
+
+	w := setup(uv, "3.4.2")
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(suite.T(), "text/event-stream", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.Contains(suite.T(), body, "event:output\ndata:{\"stream\":\"stdout\",\"line\":\"uv venv output\"}")
+	assert.Contains(suite.T(), body, "event:output\ndata:{\"stream\":\"stdout\",\"line\":\"uv pip output\"}")
+	assert.Contains(suite.T(), body, `event:done`+"\n"+`data:{"status":"complete","log":"uv venv output\nuv pip output\n"}`)
+	recorded, err := os.ReadFile(calls)
+	require.NoError(suite.T(), err)
+	env := filepath.Join(dir, "env")
+	assert.Equal(suite.T(), "venv --native-tls "+env+"\npip install --native-tls --python "+env+" whisperx==3.4.2\n", string(recorded))
+
+	w = setup(uv, "0.0.0")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "error: No solution found")
+	assert.Contains(suite.T(), w.Body.String(), `"status":"error"`)
+	assert.Contains(suite.T(), w.Body.String(), `"step":"install"`)
+
+	// Without uv the setup is refused before the stream starts
+	w = setup(filepath.Join(dir, "missing-uv"), "3.4.2")
+	assert.Equal(suite.T(), http.StatusServiceUnavailable, w.Code, w.Body.String())
+}
+
+func TestSystemTestSuite(t *testing.T) {
+	suite.Run(t, new(SystemTestSuite))
+}
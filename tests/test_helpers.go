@@ -1,9 +1,11 @@
 package tests
 
 import (
+	"context"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"scriberr/internal/auth"
 	"scriberr/internal/config"
@@ -32,13 +34,15 @@ func NewTestHelper(t *testing.T, dbName string) *TestHelper {
 
 	// Create unique test config
 	cfg := &config.Config{
-		Port:         "8080",
-		Host:         "localhost",
-		DatabasePath: dbName,
-		JWTSecret:    "test-secret-key-for-unit-tests",
-		UploadDir:    "test_uploads_" + dbName,
-		UVPath:       "uv",
-		WhisperXEnv:  "test_whisperx_env",
+		Port:            "8080",
+		Host:            "localhost",
+		DatabasePath:    dbName,
+		JWTSecret:       "test-secret-key-for-unit-tests",
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 7 * 24 * time.Hour,
+		UploadDir:       "test_uploads_" + dbName,
+		UVPath:          "uv",
+		WhisperXEnv:     "test_whisperx_env",
 	}
 
 	// Initialize test database
@@ -55,7 +59,7 @@ func NewTestHelper(t *testing.T, dbName string) *TestHelper {
 	}
 
 	// Initialize auth service
-	authService := auth.NewAuthService(cfg.JWTSecret)
+	authService := auth.NewAuthService(cfg.JWTSecret, cfg.AccessTokenTTL)
 
 	helper := &TestHelper{
 		Config:      cfg,
@@ -90,6 +94,7 @@ func (h *TestHelper) createTestCredentials(t *testing.T) {
 	user := models.User{
 		Username: "testuser",
 		Password: hashedPassword,
+		Role:     models.RoleAdmin,
 	}
 
 	result := h.DB.Create(&user)
@@ -102,15 +107,9 @@ func (h *TestHelper) createTestCredentials(t *testing.T) {
 	h.TestToken = token
 
 	// Create test API key
-	apiKey := models.APIKey{
-		Key:      "test-api-key-" + strings.ReplaceAll(t.Name(), "/", "_"),
-		Name:     "Test API Key for " + strings.ReplaceAll(t.Name(), "/", "_"),
-		IsActive: true,
-	}
-
-	result = h.DB.Create(&apiKey)
-	assert.NoError(t, result.Error)
-	h.TestAPIKey = apiKey.Key
+	apiKey, err := h.AuthService.GenerateAPIKey(context.Background(), user.ID, "Test API Key for "+strings.ReplaceAll(t.Name(), "/", "_"), []string{models.ScopeAdmin}, 0)
+	assert.NoError(t, err)
+	h.TestAPIKey = apiKey
 }
 
 // CreateTestTranscriptionJob creates a test transcription job
@@ -227,3 +226,14 @@ func stringPtr(s string) *string {
 func intPtr(i int) *int {
 	return &i
 }
+
+// Helper function to create time pointer
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+// fakeMP3 returns content behind an ID3 tag, so it is accepted as an MP3
+// upload when ffprobe is not installed to look further
+func fakeMP3(content string) []byte {
+	return append([]byte("ID3\x04\x00\x00\x00\x00\x00\x00"), content...)
+}
@@ -4,12 +4,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 
+	"scriberr/internal/api"
+	"scriberr/internal/database"
 	"scriberr/internal/models"
+	"scriberr/internal/queue"
 	"scriberr/internal/transcription"
 	"scriberr/internal/transcription/interfaces"
 
@@ -482,12 +490,12 @@ func (suite *TranscriptionServiceTestSuite) TestInvalidAudioHandling() {
 // Test output directory creation
 func (suite *TranscriptionServiceTestSuite) TestOutputDirectoryCreation() {
 	testJobID := "output-dir-test-123"
-	outputDir := filepath.Join("data", "transcripts", testJobID)
+	outputDir := filepath.Join("data", "output", testJobID)
 
 	// Create the directory structure that would be used
 	err := os.MkdirAll(outputDir, 0755)
 	assert.NoError(suite.T(), err)
-	defer os.RemoveAll(filepath.Join("data", "transcripts"))
+	defer os.RemoveAll(filepath.Join("data", "output"))
 
 	// Verify directory was created
 	fileInfo, err := os.Stat(outputDir)
@@ -498,6 +506,145 @@ func (suite *TranscriptionServiceTestSuite) TestOutputDirectoryCreation() {
 	assert.Equal(suite.T(), os.FileMode(0755), fileInfo.Mode().Perm())
 }
 
+// fakeUV runs "python -m whisperx" by writing a segment for every second of
+// the WAV file to the output directory
+const fakeUV = `#!/bin/sh
+while [ "$1" != "whisperx" ]; do shift; done
+in="$2"
+while [ "$1" != "--output_dir" ]; do shift; done
+out="$2/$(basename "$in" .wav).json"
+secs=$(( ($(wc -c < "$in") - 44) / 32000 ))
+printf '{"language":"en","segments":[' > "$out"
+i=0
+while [ $i -lt $secs ]; do
+	[ $i -gt 0 ] && printf ',' >> "$out"
+	printf '{"start":%d,"end":%d,"text":" second %d"}' $i $((i+1)) $i >> "$out"
+	i=$((i+1))
+done
+printf ']}' >> "$out"
+`
+
+// Test that WhisperX output files are removed once imported, unless kept
+func (suite *TranscriptionServiceTestSuite) TestJobOutputFilesCleanup() {
+	if runtime.GOOS == "windows" {
+		suite.T().Skip("Runs a shell script as uv")
+	}
+	bin := suite.T().TempDir()
+	suite.Require().NoError(os.WriteFile(filepath.Join(bin, "uv"), []byte(fakeUV), 0755))
+	suite.T().Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	outputDir := filepath.Join(suite.T().TempDir(), "output")
+	language := "en"
+	processor := transcription.NewUnifiedJobProcessor()
+	runJob := func(keep bool) string {
+		processor.GetUnifiedService().SetOutputDirectory(outputDir, keep)
+		audioPath := filepath.Join(suite.helper.Config.UploadDir, fmt.Sprintf("output-%t.wav", keep))
+		suite.Require().NoError(os.WriteFile(audioPath, make([]byte, 44+2*32000), 0644))
+		job := models.TranscriptionJob{
+			ID:        fmt.Sprintf("output-files-%t", keep),
+			AudioPath: audioPath,
+			Status:    models.StatusProcessing,
+			Parameters: models.WhisperXParams{
+				Model:          "tiny",
+				Language:       &language,
+				Device:         "cpu",
+				ComputeType:    "int8",
+				BatchSize:      4,
+				SkipPreprocess: true,
+			},
+		}
+		suite.Require().NoError(suite.helper.GetDB().Create(&job).Error)
+		suite.Require().NoError(processor.ProcessJob(context.Background(), job.ID))
+
+		var stored models.TranscriptionJob
+		suite.Require().NoError(suite.helper.GetDB().First(&stored, "id = ?", job.ID).Error)
+		if assert.NotNil(suite.T(), stored.Transcript) {
+			assert.Contains(suite.T(), *stored.Transcript, "second 1")
+		}
+		return filepath.Join(outputDir, job.ID)
+	}
+
+	_, err := os.Stat(runJob(false))
+	assert.True(suite.T(), os.IsNotExist(err), "Output files should be removed after import")
+
+	kept, err := os.ReadDir(runJob(true))
+	assert.NoError(suite.T(), err)
+	if assert.Len(suite.T(), kept, 1, "SCRIBERR_KEEP_OUTPUT_FILES should keep output files") {
+		assert.Equal(suite.T(), "output-true.json", kept[0].Name())
+	}
+}
+
+// Test that a job run through the queue records the time of every phase
+func (suite *TranscriptionServiceTestSuite) TestJobTimeline() {
+	if runtime.GOOS == "windows" {
+		suite.T().Skip("Runs a shell script as uv")
+	}
+	bin := suite.T().TempDir()
+	suite.Require().NoError(os.WriteFile(filepath.Join(bin, "uv"), []byte(fakeUV), 0755))
+	suite.T().Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	processor := transcription.NewUnifiedJobProcessor()
+	processor.GetUnifiedService().SetOutputDirectory(suite.T().TempDir(), false)
+	taskQueue := queue.NewTaskQueue(1, processor)
+	taskQueue.Start()
+	defer taskQueue.Stop()
+	// The handler's processor downloads models, which the fake uv cannot
+	router := api.SetupRoutes(api.NewHandler(suite.helper.Config, suite.helper.AuthService, taskQueue, transcription.NewUnifiedJobProcessor(), nil), suite.helper.AuthService)
+	request := func(method, path string, body *bytes.Buffer, contentType string) *httptest.ResponseRecorder {
+		if body == nil {
+			body = &bytes.Buffer{}
+		}
+		req, _ := http.NewRequest(method, path, body)
+		req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("audio", "tone.wav")
+	suite.Require().NoError(err)
+	part.Write(writeWAV(suite.T(), filepath.Join(suite.T().TempDir(), "tone.wav"), 440))
+	for field, value := range map[string]string{"model": "tiny", "language": "en", "device": "cpu", "compute_type": "int8"} {
+		writer.WriteField(field, value)
+	}
+	suite.Require().NoError(writer.Close())
+	w := request("POST", "/api/v1/transcriptions", body, writer.FormDataContentType())
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var job models.TranscriptionJob
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &job))
+
+	suite.Require().Eventually(func() bool {
+		var stored models.TranscriptionJob
+		return suite.helper.GetDB().Select("status").First(&stored, "id = ?", job.ID).Error == nil && stored.Status == models.StatusCompleted
+	}, 10*time.Second, 20*time.Millisecond)
+
+	w = request("GET", "/api/v1/transcriptions/"+job.ID+"/timeline", nil, "")
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var timeline []api.TimelinePhase
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &timeline))
+	var phases []string
+	for _, phase := range timeline {
+		phases = append(phases, phase.Phase)
+		assert.GreaterOrEqual(suite.T(), phase.DurationMS, int64(0), phase.Phase)
+	}
+	assert.Equal(suite.T(), models.TimelinePhases, phases)
+
+	// A rerun replaces the processing phases and keeps the upload time
+	suite.Require().NoError(suite.helper.GetDB().Model(&models.TranscriptionJob{}).Where("id = ?", job.ID).
+		UpdateColumn("phase_timings", `{"upload":5,"queued":6,"preprocessing":7,"transcription":8,"post_processing":9}`).Error)
+	suite.Require().NoError(database.StartRunTimings(job.ID, 1500*time.Millisecond))
+	var stored models.TranscriptionJob
+	suite.Require().NoError(suite.helper.GetDB().Select("phase_timings").First(&stored, "id = ?", job.ID).Error)
+	assert.Equal(suite.T(), map[string]int64{"upload": 5, "queued": 1500}, stored.PhaseTimings)
+
+	assert.Equal(suite.T(), http.StatusNotFound, request("GET", "/api/v1/transcriptions/missing/timeline", nil, "").Code)
+}
+
 func TestTranscriptionServiceTestSuite(t *testing.T) {
 	suite.Run(t, new(TranscriptionServiceTestSuite))
 }
@@ -0,0 +1,290 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"scriberr/internal/api"
+	"scriberr/internal/database"
+	"scriberr/internal/models"
+	"scriberr/internal/queue"
+	"scriberr/internal/transcription"
+	"scriberr/internal/watchfolder"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// recordingQueue records enqueued jobs instead of running them
+type recordingQueue struct {
+	mu   sync.Mutex
+	jobs []string
+}
+
+func (q *recordingQueue) EnqueueJob(jobID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.jobs = append(q.jobs, jobID)
+	return nil
+}
+
+func (q *recordingQueue) count() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.jobs)
+}
+
+type WatchFolderTestSuite struct {
+	suite.Suite
+	helper *TestHelper
+	router *gin.Engine
+}
+
+func (suite *WatchFolderTestSuite) SetupSuite() {
+	suite.helper = NewTestHelper(suite.T(), "watchfolder_test.db")
+	unifiedProcessor := transcription.NewUnifiedJobProcessor()
+	handler := api.NewHandler(suite.helper.Config, suite.helper.AuthService, queue.NewTaskQueue(1, unifiedProcessor), unifiedProcessor, nil)
+	suite.router = api.SetupRoutes(handler, suite.helper.AuthService)
+}
+
+func (suite *WatchFolderTestSuite) TearDownSuite() {
+	suite.helper.Cleanup()
+}
+
+// newService creates a service watching a fresh folder stored in the database
+func (suite *WatchFolderTestSuite) newService(afterProcess string) (*watchfolder.Service, *recordingQueue, string) {
+	dir := suite.T().TempDir()
+	folder := models.WatchFolder{Path: dir, AfterProcess: afterProcess, Recursive: true, Enabled: true}
+	require.NoError(suite.T(), suite.helper.GetDB().Create(&folder).Error)
+	suite.T().Cleanup(func() { suite.helper.GetDB().Delete(&folder) })
+
+	q := &recordingQueue{}
+	service := watchfolder.NewService(suite.helper.Config, q, func() models.WhisperXParams {
+		return models.WhisperXParams{ModelFamily: "whisper", Model: "tiny"}
+	})
+	require.NoError(suite.T(), service.Reload())
+	return service, q, dir
+}
+
+func (suite *WatchFolderTestSuite) scan(service *watchfolder.Service, dir string) {
+	require.NoError(suite.T(), service.Scan(context.Background(), dir))
+}
+
+// writeWAV writes a short 16 kHz mono sine tone, so ffprobe accepts it when installed
+func writeWAV(t *testing.T, path string, frequency float64) []byte {
+	t.Helper()
+	const sampleRate = 16000
+	samples := make([]int16, sampleRate/2)
+	for i := range samples {
+		samples[i] = int16(8000 * math.Sin(2*math.Pi*frequency*float64(i)/sampleRate))
+	}
+	var buf bytes.Buffer
+	dataSize := uint32(len(samples) * 2)
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, 36+dataSize)
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, []any{uint32(16), uint16(1), uint16(1), uint32(sampleRate), uint32(sampleRate * 2), uint16(2), uint16(16)})
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, dataSize)
+	binary.Write(&buf, binary.LittleEndian, samples)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+	return buf.Bytes()
+}
+
+// Test that settled files are queued once and marked with a sidecar
+func (suite *WatchFolderTestSuite) TestMarkerMode() {
+	service, q, dir := suite.newService(models.WatchAfterMarker)
+	path := filepath.Join(dir, "meeting.wav")
+	writeWAV(suite.T(), path, 440)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not audio"), 0644)
+
+	// The first scan only records the file's size
+	suite.scan(service, dir)
+	assert.Equal(suite.T(), 0, q.count())
+
+	suite.scan(service, dir)
+	require.Equal(suite.T(), 1, q.count())
+	var job models.TranscriptionJob
+	require.NoError(suite.T(), suite.helper.GetDB().First(&job, "id = ?", q.jobs[0]).Error)
+	assert.Equal(suite.T(), models.StatusPending, job.Status)
+	assert.Equal(suite.T(), "meeting.wav", *job.Title)
+	assert.Equal(suite.T(), "tiny", job.Parameters.Model)
+	assert.FileExists(suite.T(), job.AudioPath)
+	defer os.Remove(job.AudioPath)
+
+	marker, err := os.ReadFile(path + ".scriberr")
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), string(marker), job.ID)
+	assert.FileExists(suite.T(), path)
+
+	// Rescans skip marked files
+	suite.scan(service, dir)
+	suite.scan(service, dir)
+	assert.Equal(suite.T(), 1, q.count())
+
+	statuses := service.Status()
+	require.Len(suite.T(), statuses, 1)
+	assert.Equal(suite.T(), dir, statuses[0].Path)
+	assert.Equal(suite.T(), 1, statuses[0].JobsCreated)
+	assert.NotNil(suite.T(), statuses[0].LastScanAt)
+	assert.Empty(suite.T(), statuses[0].LastError)
+}
+
+// Test that files with already transcribed content do not create jobs
+func (suite *WatchFolderTestSuite) TestDeduplicatesByContent() {
+	service, q, dir := suite.newService(models.WatchAfterMarker)
+	content := writeWAV(suite.T(), filepath.Join(dir, "original.wav"), 550)
+	suite.scan(service, dir)
+	suite.scan(service, dir)
+	require.Equal(suite.T(), 1, q.count())
+
+	// A copy with a different name, even in a subfolder, is recognized
+	copyPath := filepath.Join(dir, "backup", "copy.wav")
+	require.NoError(suite.T(), os.MkdirAll(filepath.Dir(copyPath), 0755))
+	require.NoError(suite.T(), os.WriteFile(copyPath, content, 0644))
+	suite.scan(service, dir)
+	suite.scan(service, dir)
+	assert.Equal(suite.T(), 1, q.count())
+	assert.FileExists(suite.T(), copyPath+".scriberr")
+
+	// A file still being copied is not picked up until its size settles
+	partialPath := filepath.Join(dir, "long.wav")
+	full := writeWAV(suite.T(), partialPath, 660)
+	require.NoError(suite.T(), os.WriteFile(partialPath, full[:len(full)/2], 0644))
+	suite.scan(service, dir)
+	require.NoError(suite.T(), os.WriteFile(partialPath, full, 0644))
+	suite.scan(service, dir)
+	assert.Equal(suite.T(), 1, q.count())
+	suite.scan(service, dir)
+	assert.Equal(suite.T(), 2, q.count())
+}
+
+// Test that processed files can be moved to done/
+func (suite *WatchFolderTestSuite) TestMoveMode() {
+	service, q, dir := suite.newService(models.WatchAfterMove)
+	writeWAV(suite.T(), filepath.Join(dir, "team", "standup.wav"), 770)
+
+	suite.scan(service, dir)
+	suite.scan(service, dir)
+	require.Equal(suite.T(), 1, q.count())
+	assert.NoFileExists(suite.T(), filepath.Join(dir, "team", "standup.wav"))
+	assert.FileExists(suite.T(), filepath.Join(dir, "done", "team", "standup.wav"))
+
+	// Files in done/ are not picked up again
+	suite.scan(service, dir)
+	suite.scan(service, dir)
+	assert.Equal(suite.T(), 1, q.count())
+}
+
+// Test that jobs get the folder's tags and are filed in its job folder
+func (suite *WatchFolderTestSuite) TestTagsAndJobFolder() {
+	db := suite.helper.GetDB()
+	jobFolder := models.JobFolder{Name: "Dictation"}
+	require.NoError(suite.T(), db.Create(&jobFolder).Error)
+	dir := suite.T().TempDir()
+	folder := models.WatchFolder{Path: dir, AfterProcess: models.WatchAfterMarker, Recursive: true, Enabled: true,
+		Tags: []string{"source:dictaphone", "client:acme"}, JobFolderID: &jobFolder.ID}
+	require.NoError(suite.T(), db.Create(&folder).Error)
+	suite.T().Cleanup(func() { db.Delete(&folder) })
+
+	q := &recordingQueue{}
+	service := watchfolder.NewService(suite.helper.Config, q, func() models.WhisperXParams {
+		return models.WhisperXParams{ModelFamily: "whisper", Model: "tiny"}
+	})
+	require.NoError(suite.T(), service.Reload())
+	writeWAV(suite.T(), filepath.Join(dir, "memo.wav"), 880)
+	suite.scan(service, dir)
+	suite.scan(service, dir)
+	require.Equal(suite.T(), 1, q.count())
+
+	var job models.TranscriptionJob
+	require.NoError(suite.T(), db.First(&job, "id = ?", q.jobs[0]).Error)
+	defer os.Remove(job.AudioPath)
+	require.NotNil(suite.T(), job.FolderID)
+	assert.Equal(suite.T(), jobFolder.ID, *job.FolderID)
+	tags, err := database.GetTags(job.ID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), map[string][]string{"source": {"dictaphone"}, "client": {"acme"}}, tags)
+}
+
+// Test that a missing folder is reported as the last scan error
+func (suite *WatchFolderTestSuite) TestScanErrorStatus() {
+	service, _, dir := suite.newService(models.WatchAfterMarker)
+	require.NoError(suite.T(), os.RemoveAll(dir))
+
+	suite.scan(service, dir)
+	statuses := service.Status()
+	require.Len(suite.T(), statuses, 1)
+	assert.Contains(suite.T(), statuses[0].LastError, "failed to scan folder")
+	assert.NotNil(suite.T(), statuses[0].LastErrorAt)
+}
+
+// Test the watch folder API
+func (suite *WatchFolderTestSuite) TestWatchFolderAPI() {
+	doRequest := func(method, path string, body any, token string) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+	token := suite.helper.TestToken
+	dir := suite.T().TempDir()
+
+	assert.Equal(suite.T(), 400, doRequest("POST", "/api/v1/watch-folders", map[string]any{"path": "relative/dir"}, token).Code)
+	assert.Equal(suite.T(), 400, doRequest("POST", "/api/v1/watch-folders", map[string]any{"path": filepath.Join(dir, "missing")}, token).Code)
+	assert.Equal(suite.T(), 400, doRequest("POST", "/api/v1/watch-folders", map[string]any{"path": dir, "after_process": "delete"}, token).Code)
+	assert.Equal(suite.T(), 400, doRequest("POST", "/api/v1/watch-folders", map[string]any{"path": dir, "profile_id": "missing"}, token).Code)
+	assert.Equal(suite.T(), 400, doRequest("POST", "/api/v1/watch-folders", map[string]any{"path": dir, "tags": []string{"client"}}, token).Code)
+	assert.Equal(suite.T(), 400, doRequest("POST", "/api/v1/watch-folders", map[string]any{"path": dir, "job_folder_id": 999}, token).Code)
+
+	w := doRequest("POST", "/api/v1/watch-folders", map[string]any{"path": dir, "after_process": "move", "recursive": false, "use_polling": true}, token)
+	require.Equal(suite.T(), 201, w.Code)
+	var folder models.WatchFolder
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &folder))
+	assert.False(suite.T(), folder.Recursive)
+	assert.Equal(suite.T(), 409, doRequest("POST", "/api/v1/watch-folders", map[string]any{"path": dir}, token).Code)
+
+	w = doRequest("GET", "/api/v1/watch-folders", nil, token)
+	require.Equal(suite.T(), 200, w.Code)
+	var response struct {
+		Folders []watchfolder.FolderStatus `json:"folders"`
+	}
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(suite.T(), response.Folders, 1)
+	assert.Equal(suite.T(), folder.ID, response.Folders[0].ID)
+	assert.Equal(suite.T(), watchfolder.ModePolling, response.Folders[0].Mode)
+	assert.Equal(suite.T(), models.WatchAfterMove, response.Folders[0].AfterProcess)
+
+	// Watch folders are admin only
+	member := &models.User{ID: suite.helper.TestUser.ID, Username: suite.helper.TestUser.Username, Role: models.RoleUser}
+	memberToken, err := suite.helper.AuthService.GenerateToken(member)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 403, doRequest("GET", "/api/v1/watch-folders", nil, memberToken).Code)
+
+	path := fmt.Sprintf("/api/v1/watch-folders/%d", folder.ID)
+	assert.Equal(suite.T(), 200, doRequest("DELETE", path, nil, token).Code)
+	assert.Equal(suite.T(), 404, doRequest("DELETE", path, nil, token).Code)
+	w = doRequest("GET", "/api/v1/watch-folders", nil, token)
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Empty(suite.T(), response.Folders)
+}
+
+func TestWatchFolderTestSuite(t *testing.T) {
+	suite.Run(t, new(WatchFolderTestSuite))
+}
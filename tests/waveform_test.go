@@ -0,0 +1,225 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"scriberr/internal/api"
+	"scriberr/internal/models"
+	"scriberr/internal/queue"
+	"scriberr/internal/transcription"
+	"scriberr/internal/waveform"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeFFmpeg stands in for ffmpeg decoding a WAV file to raw PCM: it writes
+// the file without its 44-byte header, and fails on empty files
+const fakeFFmpeg = `#!/bin/sh
+while [ $# -gt 0 ]; do
+	[ "$1" = "-i" ] && in="$2"
+	shift
+done
+if [ ! -s "$in" ]; then
+	echo "Invalid data found when processing input" >&2
+	exit 1
+fi
+tail -c +45 "$in"
+`
+
+type WaveformTestSuite struct {
+	suite.Suite
+	helper    *TestHelper
+	taskQueue *queue.TaskQueue
+	router    *gin.Engine
+}
+
+func (suite *WaveformTestSuite) SetupSuite() {
+	suite.helper = NewTestHelper(suite.T(), "waveform_test.db")
+	processor := &MockJobProcessor{}
+	processor.On("ProcessJobWithProcess", mock.Anything, mock.Anything).Return(nil)
+	suite.taskQueue = queue.NewTaskQueue(1, processor)
+	suite.taskQueue.Start()
+	handler := api.NewHandler(suite.helper.Config, suite.helper.AuthService, suite.taskQueue, transcription.NewUnifiedJobProcessor(), nil)
+	suite.router = api.SetupRoutes(handler, suite.helper.AuthService)
+}
+
+func (suite *WaveformTestSuite) TearDownSuite() {
+	suite.taskQueue.Stop()
+	suite.helper.Cleanup()
+}
+
+func (suite *WaveformTestSuite) SetupTest() {
+	if runtime.GOOS == "windows" {
+		suite.T().Skip("Runs a shell script as ffmpeg")
+	}
+	bin := suite.T().TempDir()
+	suite.Require().NoError(os.WriteFile(filepath.Join(bin, "ffmpeg"), []byte(fakeFFmpeg), 0755))
+	suite.T().Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func (suite *WaveformTestSuite) get(path string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// createJob creates a job whose audio is a half-second tone, which the fake
+// ffmpeg decodes as 8000 samples: 100 detail buckets
+func (suite *WaveformTestSuite) createJob() *models.TranscriptionJob {
+	job := suite.helper.CreateTestTranscriptionJob(suite.T(), "Waveform Job")
+	job.AudioPath = filepath.Join(suite.T().TempDir(), "tone.wav")
+	writeWAV(suite.T(), job.AudioPath, 440)
+	suite.Require().NoError(suite.helper.DB.Model(job).Update("audio_path", job.AudioPath).Error)
+	return job
+}
+
+// waitForWaveform polls until the waveform is no longer being generated
+func (suite *WaveformTestSuite) waitForWaveform(path string) *httptest.ResponseRecorder {
+	var w *httptest.ResponseRecorder
+	require.Eventually(suite.T(), func() bool {
+		w = suite.get(path)
+		return w.Code != http.StatusAccepted
+	}, 5*time.Second, 20*time.Millisecond)
+	return w
+}
+
+// Test that peaks are generated on first request and then served from the cache
+func (suite *WaveformTestSuite) TestGenerateOnRequest() {
+	job := suite.createJob()
+	path := "/api/v1/transcription/" + job.ID + "/waveform"
+
+	w := suite.get(path)
+	require.Equal(suite.T(), http.StatusAccepted, w.Code, w.Body.String())
+	assert.NotEmpty(suite.T(), w.Header().Get("Retry-After"))
+
+	w = suite.waitForWaveform(path)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var peaks waveform.JSONPeaks
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &peaks))
+	assert.Equal(suite.T(), 100, peaks.Length, "Short audio returns every detail bucket")
+	assert.Len(suite.T(), peaks.Data, 200)
+	assert.Equal(suite.T(), waveform.SampleRate, peaks.SampleRate)
+	assert.Equal(suite.T(), waveform.DetailSamplesPerPixel, peaks.SamplesPerPixel)
+	assert.Equal(suite.T(), int8(31), peaks.Data[1], "An 8000 amplitude tone peaks at 31 of 127")
+
+	w = suite.get(path + "?samples=10")
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &peaks))
+	assert.Equal(suite.T(), 10, peaks.Length)
+	assert.Equal(suite.T(), 10*waveform.DetailSamplesPerPixel, peaks.SamplesPerPixel)
+
+	w = suite.get(path + "?samples=10&format=dat")
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "application/octet-stream", w.Header().Get("Content-Type"))
+	var decoded waveform.Peaks
+	require.NoError(suite.T(), decoded.UnmarshalBinary(w.Body.Bytes()))
+	assert.Equal(suite.T(), peaks.Data, decoded.Data)
+}
+
+// Test that invalid parameters are rejected
+func (suite *WaveformTestSuite) TestInvalidParameters() {
+	job := suite.createJob()
+	path := "/api/v1/transcription/" + job.ID + "/waveform"
+	for _, query := range []string{"?samples=0", "?samples=100001", "?samples=many", "?format=mp3"} {
+		assert.Equal(suite.T(), http.StatusBadRequest, suite.get(path+query).Code, query)
+	}
+	assert.Equal(suite.T(), http.StatusNotFound, suite.get("/api/v1/transcription/missing/waveform").Code)
+}
+
+// Test that uploads queue their waveform without waiting for a request
+func (suite *WaveformTestSuite) TestGenerateAfterUpload() {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("audio", "tone.wav")
+	require.NoError(suite.T(), err)
+	part.Write(writeWAV(suite.T(), filepath.Join(suite.T().TempDir(), "tone.wav"), 440))
+	require.NoError(suite.T(), writer.Close())
+	req, _ := http.NewRequest("POST", "/api/v1/transcription/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+suite.helper.TestToken)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var job models.TranscriptionJob
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &job))
+
+	assert.Eventually(suite.T(), func() bool {
+		var cached models.JobWaveform
+		return suite.helper.DB.Where("job_id = ?", job.ID).First(&cached).Error == nil
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(suite.T(), http.StatusOK, suite.get("/api/v1/transcription/"+job.ID+"/waveform").Code)
+}
+
+// Test that peaks are regenerated when the job's audio changes, as when it
+// is re-normalized
+func (suite *WaveformTestSuite) TestRegenerateWhenAudioChanges() {
+	job := suite.createJob()
+	path := "/api/v1/transcription/" + job.ID + "/waveform"
+	suite.get(path)
+	require.Equal(suite.T(), http.StatusOK, suite.waitForWaveform(path).Code)
+
+	normalized := filepath.Join(suite.T().TempDir(), "tone_normalized.wav")
+	writeWAV(suite.T(), normalized, 880)
+	require.NoError(suite.T(), suite.helper.DB.Model(job).Update("audio_path", normalized).Error)
+
+	assert.Equal(suite.T(), http.StatusAccepted, suite.get(path).Code, "Peaks of the old audio are stale")
+	require.Equal(suite.T(), http.StatusOK, suite.waitForWaveform(path).Code)
+	var cached models.JobWaveform
+	require.NoError(suite.T(), suite.helper.DB.Where("job_id = ?", job.ID).First(&cached).Error)
+	assert.Equal(suite.T(), normalized, cached.AudioPath)
+}
+
+// Test that audio ffmpeg cannot decode is reported, not retried
+func (suite *WaveformTestSuite) TestUndecodableAudio() {
+	job := suite.createJob()
+	require.NoError(suite.T(), os.WriteFile(job.AudioPath, nil, 0644))
+	path := "/api/v1/transcription/" + job.ID + "/waveform"
+	suite.get(path)
+
+	w := suite.waitForWaveform(path)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code)
+	var body map[string]any
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(suite.T(), "TRANSCODE_FAILED", body["code"])
+}
+
+// Test that cached peaks outlive retention cleanup of the audio
+func (suite *WaveformTestSuite) TestDeletedAudio() {
+	job := suite.createJob()
+	path := "/api/v1/transcription/" + job.ID + "/waveform"
+	suite.get(path)
+	require.Equal(suite.T(), http.StatusOK, suite.waitForWaveform(path).Code)
+
+	now := time.Now()
+	require.NoError(suite.T(), suite.helper.DB.Model(job).Updates(map[string]any{"audio_deleted_at": now, "audio_path": ""}).Error)
+	assert.Equal(suite.T(), http.StatusOK, suite.get(path).Code)
+
+	uncached := suite.createJob()
+	require.NoError(suite.T(), suite.helper.DB.Model(uncached).Update("audio_deleted_at", now).Error)
+	assert.Equal(suite.T(), http.StatusGone, suite.get("/api/v1/transcription/"+uncached.ID+"/waveform").Code)
+}
+
+// Test that the waveform reports ffmpeg missing rather than waiting forever
+func (suite *WaveformTestSuite) TestFFmpegUnavailable() {
+	job := suite.createJob()
+	suite.T().Setenv("PATH", suite.T().TempDir())
+	assert.Equal(suite.T(), http.StatusServiceUnavailable, suite.get("/api/v1/transcription/"+job.ID+"/waveform").Code)
+}
+
+func TestWaveformTestSuite(t *testing.T) {
+	suite.Run(t, new(WaveformTestSuite))
+}
@@ -95,7 +95,7 @@ interface WordSegment {
 	start: number;
 	end: number;
 	word: string;
-	score: number;
+	score?: number;
 	speaker?: string;
 }
 
@@ -106,10 +106,18 @@ interface Transcript {
 		end: number;
 		text: string;
 		speaker?: string;
+		words?: WordSegment[] | null;
 	}>;
 	word_segments?: WordSegment[];
 }
 
+// Flattens the per-segment word timings returned with ?granularity=word into
+// the single list the player uses for highlighting and seeking
+const wordsFromSegments = (segments?: Transcript["segments"]): WordSegment[] | undefined => {
+	const words = segments?.flatMap((segment) => segment.words ?? []);
+	return words && words.length > 0 ? words : undefined;
+};
+
 interface AudioDetailViewProps {
 	audioId: string;
 }
@@ -549,7 +557,7 @@ useEffect(() => {
 		console.log("[DEBUG] *** fetchTranscriptOnly CALLED ***");
 		try {
 			const transcriptResponse = await fetch(
-				`/api/v1/transcription/${audioId}/transcript`,
+				`/api/v1/transcription/${audioId}/transcript?granularity=word`,
 				{
 					headers: {
 						...getAuthHeaders(),
@@ -574,14 +582,14 @@ useEffect(() => {
 						setTranscript({
 							text: transcriptData.transcript.text,
 							segments: transcriptData.transcript.segments,
-							word_segments: transcriptData.transcript.word_segments,
+							word_segments: wordsFromSegments(transcriptData.transcript.segments),
 						});
 					} else if (transcriptData.transcript.segments) {
 						console.log("[DEBUG] Setting transcript with SEGMENTS and word_segments");
 						setTranscript({
 							text: "",
 							segments: transcriptData.transcript.segments,
-							word_segments: transcriptData.transcript.word_segments,
+							word_segments: wordsFromSegments(transcriptData.transcript.segments),
 						});
 					}
 				}
@@ -635,7 +643,7 @@ useEffect(() => {
 				// Fetch transcript if completed
 				if (audioData.status === "completed") {
 					const transcriptResponse = await fetch(
-						`/api/v1/transcription/${audioId}/transcript`,
+						`/api/v1/transcription/${audioId}/transcript?granularity=word`,
 						{
 							headers: {
 								...getAuthHeaders(),
@@ -661,7 +669,7 @@ useEffect(() => {
 								setTranscript({
 									text: transcriptData.transcript.text,
 									segments: transcriptData.transcript.segments,
-									word_segments: transcriptData.transcript.word_segments,
+									word_segments: wordsFromSegments(transcriptData.transcript.segments),
 								});
 							} else if (transcriptData.transcript.segments) {
 								console.log("[DEBUG] INITIAL: Setting transcript with SEGMENTS only");
@@ -672,7 +680,7 @@ useEffect(() => {
 								setTranscript({
 									text: fullText,
 									segments: transcriptData.transcript.segments,
-									word_segments: transcriptData.transcript.word_segments,
+									word_segments: wordsFromSegments(transcriptData.transcript.segments),
 								});
 							}
 						}
@@ -4,6 +4,7 @@ import {
     Clock,
     XCircle,
     Loader2,
+    Download,
     MoreVertical,
     Hash,
     Trash2,
@@ -123,9 +124,11 @@ function DebouncedSearchInput({
 interface AudioFile {
 	id: string;
 	title?: string;
-	status: "uploaded" | "pending" | "processing" | "completed" | "failed";
+	status: "uploaded" | "downloading" | "pending" | "processing" | "completed" | "failed";
 	created_at: string;
 	audio_path: string;
+	progress?: number;
+	duration_seconds?: number;
 	diarization?: boolean;
 	is_multi_track?: boolean;
 	error_message?: string;
@@ -447,7 +450,7 @@ export const AudioFilesTable = memo(function AudioFilesTable({
 
 	// Check if job can be transcribed (not currently processing or pending)
 	const canTranscribe = useCallback((file: AudioFile) => {
-		return file.status !== "processing" && file.status !== "pending";
+		return file.status !== "processing" && file.status !== "pending" && file.status !== "downloading";
 	}, []);
 
 	// Handle delete action
@@ -535,7 +538,7 @@ export const AudioFilesTable = memo(function AudioFilesTable({
 	
 	useEffect(() => {
 		const activeJobs = data.filter(
-			(job) => job.status === "pending" || job.status === "processing",
+			(job) => job.status === "pending" || job.status === "processing" || job.status === "downloading",
 		);
 
 		// Clear any existing polling interval
@@ -547,7 +550,7 @@ export const AudioFilesTable = memo(function AudioFilesTable({
 		// Only poll if there are active jobs
 		if (activeJobs.length > 0) {
 			// Use shorter interval for processing jobs, longer for pending jobs
-			const hasProcessingJobs = activeJobs.some(job => job.status === "processing");
+			const hasProcessingJobs = activeJobs.some(job => job.status === "processing" || job.status === "downloading");
 			const pollingInterval = hasProcessingJobs ? 2000 : 5000; // 2s for processing, 5s for pending
 			
 			pollingIntervalRef.current = setInterval(() => {
@@ -656,6 +659,22 @@ export const AudioFilesTable = memo(function AudioFilesTable({
 						</TooltipContent>
 					</Tooltip>
 				);
+			case "downloading":
+				return (
+					<Tooltip>
+						<TooltipTrigger asChild>
+							<div className="cursor-help inline-block">
+								<Download
+									size={iconSize}
+									className="text-blue-400 animate-pulse"
+								/>
+							</div>
+						</TooltipTrigger>
+						<TooltipContent className="bg-gray-900 border-gray-700 text-white">
+							<p>Downloading{file.progress ? ` (${Math.round(file.progress * 100)}%)` : ""}</p>
+						</TooltipContent>
+					</Tooltip>
+				);
 			case "pending":
 				return (
 					<Tooltip>
@@ -699,6 +718,16 @@ export const AudioFilesTable = memo(function AudioFilesTable({
 		});
 	}, []);
 
+	const formatDuration = useCallback((totalSeconds: number) => {
+		const seconds = Math.round(totalSeconds);
+		const hours = Math.floor(seconds / 3600);
+		const minutes = Math.floor((seconds % 3600) / 60);
+		const secs = String(seconds % 60).padStart(2, "0");
+		return hours > 0
+			? `${hours}:${String(minutes).padStart(2, "0")}:${secs}`
+			: `${minutes}:${secs}`;
+	}, []);
+
 	const getFileName = useCallback((audioPath: string) => {
 		const parts = audioPath.split("/");
 		return parts[parts.length - 1];
@@ -765,9 +794,12 @@ export const AudioFilesTable = memo(function AudioFilesTable({
 						</Button>
 					);
 				},
-				cell: ({ getValue }) => (
+				cell: ({ row, getValue }) => (
 					<span className="text-gray-600 dark:text-gray-300 text-sm">
 						{formatDate(getValue() as string)}
+						{row.original.duration_seconds ? (
+							<span className="text-gray-500 dark:text-gray-400"> · {formatDuration(row.original.duration_seconds)}</span>
+						) : null}
 					</span>
 				),
 				enableGlobalFilter: false,
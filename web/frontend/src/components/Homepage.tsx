@@ -14,6 +14,7 @@ import {
 	getFileDescription, 
 	validateMultiTrackFiles 
 } from "../utils/fileProcessor";
+import { checkUpload } from "../lib/serverConfig";
 
 interface FileWithType {
 	file: File;
@@ -108,6 +109,14 @@ export function Homepage() {
 			const fileItem = processedFiles[i];
 			const file = fileItem.file;
 			const isVideo = fileItem.isVideo;
+
+			const refused = checkUpload(file);
+			if (refused) {
+				setUploadProgress(prev => prev.map((item, index) =>
+					index === i ? { ...item, status: 'error', error: refused } : item
+				));
+				continue;
+			}
 			
 			try {
 				const success = isVideo ? await uploadSingleVideo(file) : await uploadSingleFile(file);
@@ -26,6 +26,8 @@ export function AuthProvider({ children }: AuthProviderProps) {
 	const tokenCheckIntervalRef = useRef<NodeJS.Timeout | null>(null);
 	const fetchWrapperSetupRef = useRef(false);
 	const latestTokenRef = useRef<string | null>(null);
+	// Set when an authenticating reverse proxy logs users in
+	const headerAuthRef = useRef(false);
 
 	// Memoize expensive token expiry check
 	const isTokenExpired = useCallback((tokenToCheck: string): boolean => {
@@ -40,6 +42,18 @@ export function AuthProvider({ children }: AuthProviderProps) {
 		}
 	}, []);
 
+	// Start a session for the user the authenticating proxy names
+	const headerLogin = useCallback(async (): Promise<string | null> => {
+		try {
+			const res = await fetch("/api/v1/auth/header-login", { method: "POST" });
+			if (!res.ok) return null;
+			const data = await res.json();
+			return (data?.token as string) || null;
+		} catch {
+			return null;
+		}
+	}, []);
+
 	// Logout function
   const logout = useCallback(() => {
     setToken(null);
@@ -50,9 +64,17 @@ export function AuthProvider({ children }: AuthProviderProps) {
       headers: {
         "Authorization": token ? `Bearer ${token}` : "",
       },
-    }).catch(() => {
-      // Ignore errors in logout call
-    });
+    })
+      .then((res) => res.json())
+      .then((data) => {
+        // Behind an authenticating proxy, log out of the proxy as well
+        if (data?.redirect_url) {
+          window.location.href = data.redirect_url;
+        }
+      })
+      .catch(() => {
+        // Ignore errors in logout call
+      });
     // Force navigate to login (home) for any unauthorized state
     if (window.location.pathname !== "/") {
       window.history.pushState({ route: { path: 'home' } }, "", "/");
@@ -76,18 +98,26 @@ export function AuthProvider({ children }: AuthProviderProps) {
                     ? data.registration_enabled
                     : !!data.requiresRegistration;
                 setRequiresRegistration(regEnabled);
+                headerAuthRef.current = !!data.header_auth_enabled;
 					
 					// Only check for existing token if registration is not required
                     if (!regEnabled) {
-						const savedToken = localStorage.getItem("scriberr_auth_token");
-						if (savedToken) {
-							if (isTokenExpired(savedToken)) {
-								// Token expired, remove it
-								localStorage.removeItem("scriberr_auth_token");
-							} else {
-								setToken(savedToken);
+						let savedToken = localStorage.getItem("scriberr_auth_token");
+						if (savedToken && isTokenExpired(savedToken)) {
+							// Token expired, remove it
+							localStorage.removeItem("scriberr_auth_token");
+							savedToken = null;
+						}
+						// The proxy already authenticated the user, so skip the login page
+						if (!savedToken && headerAuthRef.current) {
+							savedToken = await headerLogin();
+							if (savedToken) {
+								localStorage.setItem("scriberr_auth_token", savedToken);
 							}
 						}
+						if (savedToken) {
+							setToken(savedToken);
+						}
 					}
 				}
 			} catch (error) {
@@ -107,7 +137,7 @@ export function AuthProvider({ children }: AuthProviderProps) {
 		};
 
 		initializeAuth();
-  }, [isTokenExpired]);
+  }, [isTokenExpired, headerLogin]);
 
 	const login = useCallback((newToken: string) => {
 		setToken(newToken);
@@ -127,17 +157,25 @@ export function AuthProvider({ children }: AuthProviderProps) {
 	const tryRefresh = useCallback(async (): Promise<string | null> => {
 		try {
 			const res = await fetch('/api/v1/auth/refresh', { method: 'POST' })
-			if (!res.ok) return null
-			const data = await res.json()
-			if (data?.token) {
-				login(data.token)
-				return data.token as string
+			if (res.ok) {
+				const data = await res.json()
+				if (data?.token) {
+					login(data.token)
+					return data.token as string
+				}
 			}
-			return null
 		} catch {
-			return null
+			// Fall through to the proxy login
+		}
+		if (headerAuthRef.current) {
+			const newToken = await headerLogin()
+			if (newToken) {
+				login(newToken)
+				return newToken
+			}
 		}
-	}, [login])
+		return null
+	}, [login, headerLogin])
 
 	// Consolidated token management: setup fetch wrapper once and handle token expiry
 	useEffect(() => {
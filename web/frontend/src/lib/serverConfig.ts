@@ -0,0 +1,35 @@
+// Server configuration the backend injects into index.html
+export interface ServerConfig {
+  max_upload_size: number
+  max_json_body_size: number
+  upload_extensions: string[]
+  upload_mime_types: string[]
+}
+
+declare global {
+  interface Window {
+    __SCRIBERR_CONFIG__?: ServerConfig
+  }
+}
+
+export function getServerConfig(): ServerConfig | undefined {
+  return window.__SCRIBERR_CONFIG__
+}
+
+// checkUpload returns why the server would refuse file, or undefined if it
+// would accept it. The server also checks the file's content.
+export function checkUpload(file: File): string | undefined {
+  const config = getServerConfig()
+  if (!config) {
+    return undefined
+  }
+  const dot = file.name.lastIndexOf(".")
+  const ext = dot >= 0 ? file.name.slice(dot).toLowerCase() : ""
+  if (config.upload_extensions && !config.upload_extensions.includes(ext)) {
+    return "Unsupported file type"
+  }
+  if (config.max_upload_size > 0 && file.size > config.max_upload_size) {
+    return `File is larger than ${Math.floor(config.max_upload_size / (1 << 20))} MB`
+  }
+  return undefined
+}
@@ -9,6 +9,7 @@ import { ThemeProvider } from './contexts/ThemeContext'
 import { RouterProvider } from './contexts/RouterContext'
 import { AuthProvider } from './contexts/AuthContext'
 import { ProtectedRoute } from './components/ProtectedRoute'
+import { SharedTranscript } from './pages/SharedTranscript'
 import { TooltipProvider } from '@/components/ui/tooltip'
 import { ToastProvider } from '@/components/ui/toast'
 import { ChatEventsProvider } from './contexts/ChatEventsContext'
@@ -27,24 +28,35 @@ const queryClient = new QueryClient({
   },
 })
 
+// Share links (/share/{token}) are opened without logging in
+const shareToken = window.location.pathname.match(/^\/share\/([^/]+)\/?$/)?.[1]
+
 createRoot(document.getElementById('root')!).render(
-  <StrictMode>
-    <ThemeProvider>
-      <AuthProvider>
-        <QueryClientProvider client={queryClient}>
-          <RouterProvider>
-            <TooltipProvider>
-              <ToastProvider>
-                <ChatEventsProvider>
-                  <ProtectedRoute>
-                    <App />
-                  </ProtectedRoute>
-                </ChatEventsProvider>
-              </ToastProvider>
-            </TooltipProvider>
-          </RouterProvider>
-        </QueryClientProvider>
-      </AuthProvider>
-    </ThemeProvider>
-  </StrictMode>,
+  shareToken ? (
+    <StrictMode>
+      <ThemeProvider>
+        <SharedTranscript token={decodeURIComponent(shareToken)} />
+      </ThemeProvider>
+    </StrictMode>
+  ) : (
+    <StrictMode>
+      <ThemeProvider>
+        <AuthProvider>
+          <QueryClientProvider client={queryClient}>
+            <RouterProvider>
+              <TooltipProvider>
+                <ToastProvider>
+                  <ChatEventsProvider>
+                    <ProtectedRoute>
+                      <App />
+                    </ProtectedRoute>
+                  </ChatEventsProvider>
+                </ToastProvider>
+              </TooltipProvider>
+            </RouterProvider>
+          </QueryClientProvider>
+        </AuthProvider>
+      </ThemeProvider>
+    </StrictMode>
+  ),
 )
@@ -0,0 +1,115 @@
+import { useEffect, useState } from "react";
+import { Card, CardContent, CardHeader, CardTitle } from "../components/ui/card";
+import { ScriberrLogo } from "../components/ScriberrLogo";
+import { ThemeSwitcher } from "../components/ThemeSwitcher";
+
+interface SharedSegment {
+	start: number;
+	end: number;
+	text: string;
+	speaker?: string;
+}
+
+interface SharedTranscript {
+	title?: string;
+	created_at: string;
+	duration_seconds?: number;
+	transcript?: { text?: string; segments?: SharedSegment[] };
+	speakers?: Record<string, string>;
+	audio_url?: string;
+	expires_at?: string;
+}
+
+interface SharedTranscriptProps {
+	token: string;
+}
+
+function formatTime(seconds: number) {
+	const m = Math.floor(seconds / 60);
+	const s = Math.floor(seconds % 60);
+	return `${m}:${s.toString().padStart(2, "0")}`;
+}
+
+// SharedTranscript shows a transcript opened from a public share link, without logging in
+export function SharedTranscript({ token }: SharedTranscriptProps) {
+	const [shared, setShared] = useState<SharedTranscript | null>(null);
+	const [error, setError] = useState("");
+
+	useEffect(() => {
+		const load = async () => {
+			try {
+				const response = await fetch(`/api/v1/share/${encodeURIComponent(token)}`);
+				if (response.ok) {
+					setShared(await response.json());
+				} else if (response.status === 410) {
+					setError("This link has expired or been revoked.");
+				} else if (response.status === 429) {
+					setError("This link has been opened too often. Try again in a minute.");
+				} else {
+					setError("This link does not exist.");
+				}
+			} catch (error) {
+				console.error("Shared transcript error:", error);
+				setError("Network error. Please try again.");
+			}
+		};
+		load();
+	}, [token]);
+
+	const speakerName = (speaker?: string) => (speaker && shared?.speakers?.[speaker]) || speaker;
+
+	return (
+		<div className="min-h-screen bg-gray-50 dark:bg-gray-900">
+			<div className="max-w-3xl mx-auto px-4 py-8 space-y-6">
+				<div className="flex items-center justify-between">
+					<ScriberrLogo />
+					<ThemeSwitcher />
+				</div>
+
+				{error && (
+					<div className="text-center text-gray-600 dark:text-gray-400 py-16">{error}</div>
+				)}
+
+				{!error && !shared && (
+					<div className="text-center py-16">
+						<div className="animate-spin rounded-full h-8 w-8 border-b-2 border-blue-600 mx-auto"></div>
+					</div>
+				)}
+
+				{shared && (
+					<Card>
+						<CardHeader>
+							<CardTitle>{shared.title || "Shared transcript"}</CardTitle>
+							<p className="text-sm text-gray-500 dark:text-gray-400">
+								{new Date(shared.created_at).toLocaleString()}
+								{shared.duration_seconds ? ` · ${formatTime(shared.duration_seconds)}` : ""}
+							</p>
+						</CardHeader>
+						<CardContent className="space-y-4">
+							{shared.audio_url && (
+								<audio controls preload="metadata" src={shared.audio_url} className="w-full" />
+							)}
+							{!shared.transcript && (
+								<p className="text-gray-600 dark:text-gray-400">The transcript is not ready yet.</p>
+							)}
+							{shared.transcript?.segments?.map((segment, i) => (
+								<div key={i} className="flex gap-3 text-sm">
+									<span className="text-gray-400 tabular-nums shrink-0">{formatTime(segment.start)}</span>
+									<p className="text-gray-800 dark:text-gray-200">
+										{segment.speaker && (
+											<span className="font-medium mr-2">{speakerName(segment.speaker)}:</span>
+										)}
+										{segment.text.trim()}
+									</p>
+								</div>
+							))}
+							{shared.transcript && !shared.transcript.segments?.length && shared.transcript.text && (
+								<p className="text-gray-800 dark:text-gray-200 whitespace-pre-wrap">{shared.transcript.text}</p>
+							)}
+						</CardContent>
+					</Card>
+				)}
+			</div>
+		</div>
+	);
+}